@@ -0,0 +1,261 @@
+// Package cmsai is a Go client for the CMS AI v1 API. It covers the
+// generate → deck → export flow used by integrations and by the server's
+// own end-to-end tests.
+package cmsai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls the API as the user that Token was issued to.
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the server at baseURL, e.g.
+// "https://cms.example.com".
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Error codes the server reports in APIError.Code that callers commonly
+// branch on. The server documents the full set.
+const (
+	CodeValidation    = "ERR_VALIDATION"
+	CodeSpecInvalid   = "ERR_SPEC_INVALID"
+	CodeNotFound      = "ERR_NOT_FOUND"
+	CodeQuotaExceeded = "ERR_QUOTA_EXCEEDED"
+	CodeAITimeout     = "ERR_AI_TIMEOUT"
+	// CodeAIUnavailable comes with a 503 while the server fails AI calls
+	// fast; Retry-After says when to try again.
+	CodeAIUnavailable = "ERR_AI_UNAVAILABLE"
+	// CodePolicyViolation comes with a 422 when a deck or its content breaks
+	// the org's compliance policy; Details lists the violations.
+	CodePolicyViolation = "ERR_POLICY_VIOLATION"
+)
+
+// APIError is a non-2xx response. Code, Message, Details and RequestID come
+// from the server's error body when it sent one.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    json.RawMessage
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.RequestID != "" {
+		return fmt.Sprintf("cmsai: %d %s (request %s)", e.StatusCode, msg, e.RequestID)
+	}
+	return fmt.Sprintf("cmsai: %d %s", e.StatusCode, msg)
+}
+
+// IsNotFound reports whether err is an APIError with status 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// HasCode reports whether err is an APIError with the given error code.
+func HasCode(err error, code string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// GenerateTemplate creates a template from a prompt. By default generation
+// is queued and the result carries the job; wait for it with WaitForJob.
+// With req.Sync the result carries the generated version instead.
+func (c *Client) GenerateTemplate(ctx context.Context, req GenerateTemplateRequest) (*GenerateTemplateResult, error) {
+	path := "/v1/templates/generate"
+	if req.Sync {
+		path += "?sync=true"
+	}
+	var out GenerateTemplateResult
+	if err := c.do(ctx, http.MethodPost, path, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateDeck creates a deck from a template version. Without an outline the
+// content is bound by a queued job, returned in the result.
+func (c *Client) CreateDeck(ctx context.Context, req CreateDeckRequest) (*CreateDeckResult, error) {
+	var out CreateDeckResult
+	if err := c.do(ctx, http.MethodPost, "/v1/decks", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Export queues an export of a deck version in format (FormatPPTX,
+// FormatBundle, FormatPNG, FormatGIF or FormatMarkdown; "" means PPTX) and
+// returns the export job.
+func (c *Client) Export(ctx context.Context, deckVersionID, format string) (*Job, error) {
+	return c.ExportWithQuality(ctx, deckVersionID, format, "")
+}
+
+// ExportWithQuality is Export at a quality profile: QualityDraft trades
+// polish for speed, QualityHigh the reverse; "" means QualityStandard.
+func (c *Client) ExportWithQuality(ctx context.Context, deckVersionID, format, quality string) (*Job, error) {
+	return c.ExportWithOptions(ctx, deckVersionID, ExportOptions{Format: format, Quality: quality})
+}
+
+// ExportWithOptions is Export with every export option.
+func (c *Client) ExportWithOptions(ctx context.Context, deckVersionID string, opts ExportOptions) (*Job, error) {
+	q := url.Values{}
+	if opts.Format != "" {
+		q.Set("format", opts.Format)
+	}
+	if opts.Quality != "" {
+		q.Set("quality", opts.Quality)
+	}
+	if opts.Renderer != "" {
+		q.Set("renderer", opts.Renderer)
+	}
+	if opts.AdjustContrast {
+		q.Set("adjustContrast", "true")
+	}
+	if opts.Deterministic {
+		q.Set("deterministic", "true")
+	}
+	if opts.Force {
+		q.Set("force", "true")
+	}
+	if opts.Width > 0 {
+		q.Set("width", strconv.Itoa(opts.Width))
+	}
+	path := "/v1/deck-versions/" + url.PathEscape(deckVersionID) + "/export"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var body any
+	if opts.Data != nil {
+		body = map[string]any{"data": opts.Data}
+	}
+	var out struct {
+		Job Job `json:"job"`
+	}
+	if err := c.do(ctx, http.MethodPost, path, body, &out); err != nil {
+		return nil, err
+	}
+	return &out.Job, nil
+}
+
+func (c *Client) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	var out struct {
+		Job Job `json:"job"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/jobs/"+url.PathEscape(jobID), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out.Job, nil
+}
+
+func (c *Client) GetDeck(ctx context.Context, deckID string) (*Deck, error) {
+	var out struct {
+		Deck Deck `json:"deck"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/decks/"+url.PathEscape(deckID), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out.Deck, nil
+}
+
+// DownloadAsset copies an asset's bytes to dst. A finished export job's
+// OutputRef is the ID of the asset it produced.
+func (c *Client) DownloadAsset(ctx context.Context, assetID string, dst io.Writer) (int64, error) {
+	resp, err := c.send(ctx, http.MethodGet, "/v1/assets/"+url.PathEscape(assetID), nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return io.Copy(dst, resp.Body)
+}
+
+// do sends body as JSON, if non-nil, and decodes a 2xx response into out.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("cmsai: decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// send makes the request and turns non-2xx responses into an *APIError. The
+// caller closes the body of a successful response.
+func (c *Client) send(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	var e struct {
+		Code      string          `json:"code"`
+		Message   string          `json:"message"`
+		Details   json.RawMessage `json:"details"`
+		Error     string          `json:"error"`
+		RequestID string          `json:"requestId"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&e) == nil {
+		apiErr.Code, apiErr.Message, apiErr.Details, apiErr.RequestID = e.Code, e.Message, e.Details, e.RequestID
+		if apiErr.Message == "" {
+			apiErr.Message = e.Error
+		}
+	}
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = resp.Header.Get("X-Request-ID")
+	}
+	return nil, apiErr
+}
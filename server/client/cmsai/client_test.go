@@ -0,0 +1,108 @@
+package cmsai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_SendsTokenAndDecodesAPIErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/v1/templates/generate":
+			assert.Equal(t, "true", r.URL.Query().Get("sync"))
+			var body map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "A deck about quarterly results", body["prompt"])
+			assert.NotContains(t, body, "Sync")
+			_, _ = w.Write([]byte(`{"template":{"id":"tpl-1","currentVersionId":"tv-1"},"version":{"id":"tv-1","versionNo":1}}`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"ERR_NOT_FOUND","message":"job not found","error":"job not found","requestId":"req-9"}`))
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL+"/", "tok")
+
+	res, err := c.GenerateTemplate(context.Background(), GenerateTemplateRequest{Prompt: "A deck about quarterly results", Sync: true})
+	require.NoError(t, err)
+	require.NotNil(t, res.Version)
+	assert.Equal(t, "tv-1", res.Version.ID)
+	assert.Nil(t, res.Job)
+
+	_, err = c.GetJob(context.Background(), "job-x")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "job not found", apiErr.Message)
+	assert.Equal(t, CodeNotFound, apiErr.Code)
+	assert.Equal(t, "req-9", apiErr.RequestID)
+	assert.True(t, IsNotFound(err))
+	assert.True(t, HasCode(err, CodeNotFound))
+}
+
+func TestWaitForJob_PollsThroughTransientErrorsUntilTerminal(t *testing.T) {
+	var polls atomic.Int32
+	statuses := []string{"", "Queued", "Running", "Retry", "Done"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(polls.Add(1)) - 1
+		if statuses[n] == "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"job": map[string]any{"id": "job-1", "status": statuses[n], "outputRef": "asset-1"}})
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "tok")
+
+	var seen []JobStatus
+	job, err := c.WaitForJob(context.Background(), "job-1", WaitOptions{
+		Interval:    time.Millisecond,
+		MaxInterval: 4 * time.Millisecond,
+		OnPoll:      func(j *Job) { seen = append(seen, j.Status) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "asset-1", job.OutputRef)
+	assert.Equal(t, []JobStatus{JobQueued, JobRunning, JobRetry, JobDone}, seen)
+	assert.EqualValues(t, 5, polls.Load())
+}
+
+func TestWaitForJob_StopsOnFailureAndClientErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/jobs/failed":
+			_, _ = w.Write([]byte(`{"job":{"id":"failed","status":"DeadLetter","error":"renderer crashed"}}`))
+		case "/v1/jobs/stuck":
+			_, _ = w.Write([]byte(`{"job":{"id":"stuck","status":"Running"}}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "tok")
+	opts := WaitOptions{Interval: time.Millisecond}
+
+	job, err := c.WaitForJob(context.Background(), "failed", opts)
+	require.ErrorIs(t, err, ErrJobFailed)
+	assert.Contains(t, err.Error(), "renderer crashed")
+	assert.Equal(t, JobDeadLetter, job.Status)
+
+	_, err = c.WaitForJob(context.Background(), "other", opts)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = c.WaitForJob(ctx, "stuck", opts)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
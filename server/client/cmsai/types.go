@@ -0,0 +1,192 @@
+package cmsai
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobQueued     JobStatus = "Queued"
+	JobRunning    JobStatus = "Running"
+	JobDone       JobStatus = "Done"
+	JobFailed     JobStatus = "Failed"
+	JobRetry      JobStatus = "Retry"
+	JobDeadLetter JobStatus = "DeadLetter"
+)
+
+// Export formats accepted by Export. FormatPNG exports a ZIP of one PNG per
+// slide, FormatGIF an animated GIF that flips through the slides and
+// FormatMarkdown the deck's titles and bullets as a Marp document.
+const (
+	FormatPPTX     = "pptx"
+	FormatBundle   = "bundle"
+	FormatPNG      = "png"
+	FormatGIF      = "gif"
+	FormatMarkdown = "md"
+)
+
+// Export quality profiles accepted by ExportWithQuality.
+const (
+	QualityDraft    = "draft"
+	QualityStandard = "standard"
+	QualityHigh     = "high"
+)
+
+// Renderer engines an export can name.
+const (
+	RendererGo     = "go"
+	RendererPython = "python"
+	RendererAI     = "ai"
+	RendererRemote = "remote"
+)
+
+// ExportOptions are the choices ExportWithOptions sends; empty fields use
+// the server's defaults, and an empty Renderer the org's default renderer.
+type ExportOptions struct {
+	Format   string
+	Quality  string
+	Renderer string
+	// AdjustContrast nudges the deck's text colors to meet WCAG AA contrast
+	// before rendering.
+	AdjustContrast bool
+	// Deterministic renders byte-identical files for identical decks.
+	Deterministic bool
+	// Force renders the deck again even if an unchanged copy was exported
+	// before.
+	Force bool
+	// Data fills the deck's {{name}} variables for this export, so one deck
+	// version exports as many personalized decks. Values are strings,
+	// numbers or booleans, and every variable the deck uses needs one.
+	Data map[string]any
+	// Width is the width in pixels of FormatPNG and FormatGIF exports; 0
+	// means the server's default.
+	Width int
+}
+
+type Job struct {
+	ID           string            `json:"id"`
+	OrgID        string            `json:"orgId"`
+	Type         string            `json:"type"`
+	Status       JobStatus         `json:"status"`
+	InputRef     string            `json:"inputRef"`
+	OutputRef    string            `json:"outputRef,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	RetryCount   int               `json:"retryCount"`
+	MaxRetries   int               `json:"maxRetries"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	ProgressStep string            `json:"progressStep,omitempty"`
+	ProgressPct  int               `json:"progressPct,omitempty"`
+	HeartbeatAt  *time.Time        `json:"heartbeatAt,omitempty"`
+	AutoRetries  int               `json:"autoRetries,omitempty"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+}
+
+// Listing is the card metadata templates and decks carry besides their name.
+// Language is a BCP 47 tag; CoverAssetID names an image asset.
+type Listing struct {
+	Description  string  `json:"description,omitempty"`
+	Category     string  `json:"category,omitempty"`
+	Language     string  `json:"language,omitempty"`
+	CoverAssetID *string `json:"coverAssetId,omitempty"`
+}
+
+type Template struct {
+	ID               string    `json:"id"`
+	OrgID            string    `json:"orgId"`
+	OwnerUserID      string    `json:"ownerUserId"`
+	Name             string    `json:"name"`
+	Status           string    `json:"status"`
+	CurrentVersionID *string   `json:"currentVersionId"`
+	LatestVersionNo  int       `json:"latestVersionNo"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+	Listing
+}
+
+type TemplateVersion struct {
+	ID         string          `json:"id"`
+	TemplateID string          `json:"templateId"`
+	VersionNo  int             `json:"versionNo"`
+	Spec       json.RawMessage `json:"spec"`
+	CreatedBy  string          `json:"createdBy"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+type Deck struct {
+	ID                      string    `json:"id"`
+	OrgID                   string    `json:"orgId"`
+	OwnerUserID             string    `json:"ownerUserId"`
+	Name                    string    `json:"name"`
+	SourceTemplateVersionID string    `json:"sourceTemplateVersionId"`
+	CurrentVersionID        *string   `json:"currentVersionId"`
+	LatestVersionNo         int       `json:"latestVersionNo"`
+	Content                 string    `json:"content"`
+	CreatedAt               time.Time `json:"createdAt"`
+	UpdatedAt               time.Time `json:"updatedAt"`
+	Listing
+}
+
+type DeckVersion struct {
+	ID        string          `json:"id"`
+	DeckID    string          `json:"deckId"`
+	VersionNo int             `json:"versionNo"`
+	Spec      json.RawMessage `json:"spec"`
+	CreatedBy string          `json:"createdBy"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// GenerationParams tunes the model; nil fields fall back to the org's
+// defaults.
+type GenerationParams struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"topP,omitempty"`
+	MaxSlides   *int     `json:"maxSlides,omitempty"`
+}
+
+// GenerateTemplateRequest describes a template to generate. Language, Tone
+// and RTL left unset take the org's settings.
+type GenerateTemplateRequest struct {
+	Prompt      string         `json:"prompt"`
+	Name        string         `json:"name,omitempty"`
+	BrandKitID  string         `json:"brandKitId,omitempty"`
+	RTL         bool           `json:"rtl,omitempty"`
+	Language    string         `json:"language,omitempty"`
+	Tone        string         `json:"tone,omitempty"`
+	ContentData map[string]any `json:"contentData,omitempty"`
+	GenerationParams
+	// Sync generates within the request instead of queueing a job. The
+	// server only accepts it for prompts up to 2000 characters.
+	Sync bool `json:"-"`
+}
+
+// GenerateTemplateResult holds the new template and either the queued
+// generate job or, for sync requests, the generated first version.
+type GenerateTemplateResult struct {
+	Template Template         `json:"template"`
+	Job      *Job             `json:"job,omitempty"`
+	Version  *TemplateVersion `json:"version,omitempty"`
+}
+
+type CreateDeckRequest struct {
+	Name                    string `json:"name"`
+	SourceTemplateVersionID string `json:"sourceTemplateVersionId"`
+	Content                 string `json:"content"`
+	// Outline, when set, builds the deck immediately instead of queueing
+	// an AI bind job.
+	Outline any `json:"outline,omitempty"`
+	// StockImages has the bind job add stock photos or icons to slides.
+	StockImages bool `json:"stockImages,omitempty"`
+	Listing
+	GenerationParams
+}
+
+// CreateDeckResult holds the new deck and either its first version (when an
+// outline was given) or the queued bind job that will produce it.
+type CreateDeckResult struct {
+	Deck    Deck         `json:"deck"`
+	Job     *Job         `json:"job,omitempty"`
+	Version *DeckVersion `json:"version,omitempty"`
+}
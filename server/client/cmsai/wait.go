@@ -0,0 +1,93 @@
+package cmsai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrJobFailed is wrapped by WaitForJob's error when the job ends Failed or
+// DeadLetter.
+var ErrJobFailed = errors.New("cmsai: job failed")
+
+const (
+	defaultPollInterval    = 500 * time.Millisecond
+	defaultMaxPollInterval = 10 * time.Second
+)
+
+// WaitOptions controls WaitForJob's polling. The interval starts at
+// Interval and is multiplied by Backoff after each poll, up to MaxInterval.
+// Zero values use 500ms, 10s and a factor of 2.
+type WaitOptions struct {
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Backoff     float64
+	// OnPoll, if set, is called with every job state read.
+	OnPoll func(*Job)
+}
+
+// WaitForJob polls jobID until it reaches a terminal status or ctx ends;
+// bound the wait with a context deadline. A Done job is returned with a nil
+// error; a failed one is returned along with an error wrapping ErrJobFailed.
+// Transient 5xx and network errors keep the poll going.
+func (c *Client) WaitForJob(ctx context.Context, jobID string, opts WaitOptions) (*Job, error) {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	maxInterval := opts.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultMaxPollInterval
+	}
+	backoff := opts.Backoff
+	if backoff < 1 {
+		backoff = 2
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	var lastErr error
+	for {
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		job, err := c.GetJob(ctx, jobID)
+		switch {
+		case err == nil:
+			lastErr = nil
+			if opts.OnPoll != nil {
+				opts.OnPoll(job)
+			}
+			switch job.Status {
+			case JobDone:
+				return job, nil
+			case JobFailed, JobDeadLetter:
+				return job, fmt.Errorf("%w: %s %s: %s", ErrJobFailed, job.ID, job.Status, job.Error)
+			}
+		case retryable(err):
+			lastErr = err
+		default:
+			return nil, err
+		}
+
+		timer.Reset(interval)
+		interval = min(time.Duration(float64(interval)*backoff), maxInterval)
+	}
+}
+
+// retryable reports whether a poll error may clear up on its own: server
+// errors, rate limiting and transport failures, but not 4xx answers.
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == 429
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/client/cmsai"
+)
+
+// formatPDF is exported as a bundle, which is the only export that includes a
+// PDF, and the PDF is taken out of it.
+const formatPDF = "pdf"
+
+func (c *cli) generate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	prompt := fs.String("prompt", "", "what the template is for")
+	name := fs.String("name", "", "template name")
+	contentFile := fs.String("content", "", `deck content file, or "-" for stdin`)
+	deckName := fs.String("deck-name", "", "deck name (defaults to the template name)")
+	if pos, err := parseArgs(fs, args); err != nil || len(pos) != 0 || *prompt == "" {
+		return errUsage
+	}
+
+	var content string
+	if *contentFile != "" {
+		b, err := c.readInput(*contentFile)
+		if err != nil {
+			return fmt.Errorf("read content: %w", err)
+		}
+		content = string(b)
+	}
+
+	gen, err := c.client.GenerateTemplate(ctx, cmsai.GenerateTemplateRequest{Prompt: *prompt, Name: *name})
+	if err != nil {
+		return fmt.Errorf("generate template: %w", err)
+	}
+	job, err := c.wait(ctx, gen.Job)
+	if err != nil {
+		return fmt.Errorf("generate template: %w", err)
+	}
+	out := map[string]string{"templateId": gen.Template.ID, "templateVersionId": job.OutputRef}
+
+	if content != "" {
+		if *deckName == "" {
+			*deckName = gen.Template.Name
+		}
+		deck, err := c.client.CreateDeck(ctx, cmsai.CreateDeckRequest{Name: *deckName, SourceTemplateVersionID: job.OutputRef, Content: content})
+		if err != nil {
+			return fmt.Errorf("create deck: %w", err)
+		}
+		out["deckId"] = deck.Deck.ID
+		switch {
+		case deck.Version != nil:
+			out["deckVersionId"] = deck.Version.ID
+		case deck.Job != nil:
+			job, err := c.wait(ctx, deck.Job)
+			if err != nil {
+				return fmt.Errorf("create deck: %w", err)
+			}
+			out["deckVersionId"] = job.OutputRef
+		}
+	}
+	return c.printJSON(out)
+}
+
+func (c *cli) export(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", cmsai.FormatPPTX, "pptx, bundle, pdf, png, gif or md")
+	output := fs.String("o", "", `output file, or "-" for stdout`)
+	pos, err := parseArgs(fs, args)
+	if err != nil || len(pos) != 2 || pos[0] != "deck" {
+		return errUsage
+	}
+	deckID := pos[1]
+	exportFormat := *format
+	switch *format {
+	case cmsai.FormatPPTX, cmsai.FormatBundle, cmsai.FormatPNG, cmsai.FormatGIF, cmsai.FormatMarkdown:
+	case formatPDF:
+		exportFormat = cmsai.FormatBundle
+	default:
+		return fmt.Errorf("unknown format %q (want pptx, bundle, pdf, png, gif or md)", *format)
+	}
+	if *output == "" {
+		ext := *format
+		if *format == cmsai.FormatBundle || *format == cmsai.FormatPNG {
+			ext = "zip"
+		}
+		*output = deckID + "." + ext
+	}
+
+	deck, err := c.client.GetDeck(ctx, deckID)
+	if err != nil {
+		return fmt.Errorf("get deck: %w", err)
+	}
+	if deck.CurrentVersionID == nil {
+		return fmt.Errorf("deck %s has no version to export yet", deckID)
+	}
+	job, err := c.client.Export(ctx, *deck.CurrentVersionID, exportFormat)
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	if job, err = c.wait(ctx, job); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := c.client.DownloadAsset(ctx, job.OutputRef, &buf); err != nil {
+		return fmt.Errorf("download: %w", err)
+	}
+	data := buf.Bytes()
+	if *format == formatPDF {
+		if data, err = pdfFromBundle(data); err != nil {
+			return err
+		}
+	}
+	if err := c.writeOutput(*output, data); err != nil {
+		return err
+	}
+	if *output != "-" {
+		fmt.Fprintf(c.stderr, "wrote %s (%d bytes)\n", *output, len(data))
+	}
+	return nil
+}
+
+func (c *cli) jobs(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("jobs", flag.ContinueOnError)
+	pos, err := parseArgs(fs, args)
+	if err != nil || len(pos) != 2 || pos[0] != "watch" {
+		return errUsage
+	}
+	job, err := c.wait(ctx, &cmsai.Job{ID: pos[1]})
+	if job != nil {
+		if perr := c.printJSON(job); perr != nil {
+			return perr
+		}
+	}
+	return err
+}
+
+// pdfFromBundle returns the PDF inside an export bundle, found through its
+// manifest.
+func pdfFromBundle(bundle []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	if err != nil {
+		return nil, fmt.Errorf("read bundle: %w", err)
+	}
+	var manifest struct {
+		PDF string `json:"pdf"`
+	}
+	if b, err := readZipFile(zr, "manifest.json"); err == nil {
+		_ = json.Unmarshal(b, &manifest)
+	}
+	if manifest.PDF != "" {
+		return readZipFile(zr, manifest.PDF)
+	}
+	for _, f := range zr.File {
+		if strings.EqualFold(path.Ext(f.Name), ".pdf") {
+			return readZipFile(zr, f.Name)
+		}
+	}
+	return nil, errors.New("bundle has no PDF")
+}
+
+func readZipFile(zr *zip.Reader, name string) ([]byte, error) {
+	f, err := zr.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("read bundle: %w", err)
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func (c *cli) readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(c.stdin)
+	}
+	return os.ReadFile(name)
+}
+
+func (c *cli) writeOutput(name string, data []byte) error {
+	if name == "-" {
+		_, err := c.stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
+}
+
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,138 @@
+// Command cmsctl drives the CMS AI API from scripts and CI pipelines:
+// generating templates and decks, exporting them and watching jobs. It
+// authenticates with an API key created under POST /v1/api-keys.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/client/cmsai"
+)
+
+const usage = `Usage: cmsctl [--server URL] [--api-key KEY] [--timeout D] <command> [args]
+
+Commands:
+  generate --prompt TEXT [--name NAME] [--content FILE] [--deck-name NAME]
+      Generate a template, and with --content ("-" for stdin) a deck from it.
+      Prints the created IDs as JSON.
+  export deck <id> [--format pptx|bundle|pdf|png|gif|md] [-o FILE]
+      Export the deck's current version and save it (default <id>.<format>,
+      "-" for stdout).
+  jobs watch <id>
+      Follow a job until it finishes; exits 1 if it fails.
+
+The server and key default to $CMSAI_SERVER and $CMSAI_API_KEY.
+`
+
+// errUsage makes run print the usage text and exit 2.
+var errUsage = errors.New("usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// cli holds what every command needs; output meant for scripts goes to
+// stdout and progress to stderr.
+type cli struct {
+	client  *cmsai.Client
+	timeout time.Duration
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("cmsctl", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	server := fs.String("server", envOr("CMSAI_SERVER", "http://localhost:8080"), "API base URL")
+	apiKey := fs.String("api-key", os.Getenv("CMSAI_API_KEY"), "API key")
+	timeout := fs.Duration("timeout", 10*time.Minute, "how long to wait for each job")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if *apiKey == "" {
+		fmt.Fprintln(stderr, "cmsctl: an API key is required (--api-key or CMSAI_API_KEY)")
+		return 2
+	}
+
+	c := &cli{
+		client:  cmsai.NewClient(*server, *apiKey),
+		timeout: *timeout,
+		stdin:   stdin,
+		stdout:  stdout,
+		stderr:  stderr,
+	}
+	var err error
+	switch cmd, rest := fs.Arg(0), fs.Args()[1:]; cmd {
+	case "generate":
+		err = c.generate(ctx, rest)
+	case "export":
+		err = c.export(ctx, rest)
+	case "jobs":
+		err = c.jobs(ctx, rest)
+	default:
+		err = errUsage
+	}
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		fmt.Fprint(stderr, usage)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "cmsctl: %v\n", err)
+		return 1
+	}
+}
+
+// parseArgs parses fs from args, allowing flags before, between and after
+// positional arguments, and returns the positionals.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// wait follows job until it finishes, reporting each status or progress
+// change on stderr.
+func (c *cli) wait(ctx context.Context, job *cmsai.Job) (*cmsai.Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	var last string
+	return c.client.WaitForJob(ctx, job.ID, cmsai.WaitOptions{OnPoll: func(j *cmsai.Job) {
+		line := fmt.Sprintf("%s %s", j.Type, j.Status)
+		if j.ProgressStep != "" {
+			line += fmt.Sprintf(": %s (%d%%)", j.ProgressStep, j.ProgressPct)
+		}
+		if line != last {
+			fmt.Fprintf(c.stderr, "job %s: %s\n", j.ID, line)
+			last = line
+		}
+	}})
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
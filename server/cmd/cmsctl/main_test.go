@@ -0,0 +1,116 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/api"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestCmsctl_GenerateWithAPIKeyAndWatchJobs(t *testing.T) {
+	t.Setenv("USE_MOCK_AI", "true")
+	t.Setenv("DATABASE_URL", "")
+	srv, w := api.NewServerWithWorker()
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	ctx := context.Background()
+
+	// Drain the job queue the way the worker's loop does, only faster.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(20 * time.Millisecond):
+				w.ProcessJobs()
+			}
+		}
+	}()
+
+	require.NoError(t, srv.Store.Users().CreateUser(ctx, &store.User{ID: "user-ci", Email: "ci@example.com"}))
+	require.NoError(t, srv.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "user-ci", OrgID: "org-ci", Role: auth.RoleEditor}))
+	token, err := auth.GenerateToken("user-ci", "org-ci", auth.RoleEditor)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/v1/api-keys", strings.NewReader(`{"name":"ci"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	var key struct {
+		Key string `json:"key"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&key))
+	resp.Body.Close()
+
+	cmsctl := func(stdin string, args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := run(ctx, append([]string{"--server", ts.URL, "--api-key", key.Key}, args...), strings.NewReader(stdin), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	code, out, errOut := cmsctl("Our payments API launches in March with three partner banks.",
+		"generate", "--prompt", "A product launch deck for a fintech startup", "--name", "Launch", "--content", "-")
+	require.Equal(t, 0, code, errOut)
+	var ids map[string]string
+	require.NoError(t, json.Unmarshal([]byte(out), &ids))
+	assert.NotEmpty(t, ids["templateVersionId"])
+	assert.NotEmpty(t, ids["deckVersionId"])
+	assert.Contains(t, errOut, "generate Done")
+
+	deck, ok, err := srv.Store.Decks().GetDeck(ctx, "org-ci", ids["deckId"])
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "Launch", deck.Name)
+	assert.Equal(t, "user-ci", deck.OwnerUserID, "the key acts as its creator")
+
+	failed, err := srv.Store.Jobs().Enqueue(ctx, store.Job{ID: "job-failed", OrgID: "org-ci", Type: store.JobExport, Status: store.JobFailed, Error: "renderer crashed"})
+	require.NoError(t, err)
+	code, out, errOut = cmsctl("", "jobs", "watch", failed.ID)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, `"status": "Failed"`)
+	assert.Contains(t, errOut, "renderer crashed")
+
+	code, _, _ = cmsctl("", "export", "deck", ids["deckId"], "--format", "docx")
+	assert.Equal(t, 1, code)
+	code, _, _ = cmsctl("", "export", "template", ids["templateId"])
+	assert.Equal(t, 2, code)
+
+	var stderr bytes.Buffer
+	assert.Equal(t, 2, run(ctx, []string{"--server", ts.URL, "jobs", "watch", "x"}, nil, &bytes.Buffer{}, &stderr))
+	assert.Contains(t, stderr.String(), "API key is required")
+}
+
+func TestPDFFromBundle_UsesManifest(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range map[string]string{
+		"manifest.json": `{"pptx":"deck.pptx","pdf":"deck.pdf"}`,
+		"deck.pptx":     "pptx bytes",
+		"deck.pdf":      "%PDF-1.4 bytes",
+	} {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, _ = f.Write([]byte(body))
+	}
+	require.NoError(t, zw.Close())
+
+	pdf, err := pdfFromBundle(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "%PDF-1.4 bytes", string(pdf))
+
+	_, err = pdfFromBundle([]byte("not a zip"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+// Command renderer runs the render service: it renders specs for API servers
+// configured with RENDER_SERVICE_URL, so rendering capacity can be scaled on
+// its own. RENDER_ENGINE picks the engine it wraps (go, python or ai;
+// default python) and RENDER_SERVICE_TOKEN is the bearer token clients must
+// send. It refuses to start without a token unless RENDER_SERVICE_INSECURE
+// is set; see config.RenderService.
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/config"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+)
+
+func main() {
+	cfg, err := config.LoadRenderService(os.Getenv)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logger.Initialize(&logger.Config{
+		Level:  cfg.Log.Level,
+		Format: cfg.Log.Format,
+	})
+
+	addr, engine := cfg.Addr, cfg.Engine
+	factory := &assets.RendererFactory{HuggingFaceAPIKey: cfg.HuggingFaceAPIKey}
+	renderer, err := factory.New(engine)
+	if err != nil {
+		logger.Logger.Error("renderer_invalid_engine", "engine", engine, "error", err)
+		os.Exit(1)
+	}
+	if cfg.Token == "" {
+		logger.Logger.Warn("render_service_unauthenticated")
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           assets.RenderServiceHandler(renderer, cfg.Token),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		// Renders can take minutes; the client's context bounds them.
+		IdleTimeout: 60 * time.Second,
+	}
+	go func() {
+		logger.Logger.Info("render_service_listening", "addr", addr, "engine", engine)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Logger.Error("render_service_error", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+
+	// Let renders in flight finish before exiting.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Logger.Error("render_service_shutdown_error", "error", err)
+	}
+}
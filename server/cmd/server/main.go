@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -9,36 +12,76 @@ import (
 	"time"
 
 	"github.com/ziyad/cms-ai/server/internal/api"
+	"github.com/ziyad/cms-ai/server/internal/config"
+	"github.com/ziyad/cms-ai/server/internal/egress"
 	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/redact"
+	"github.com/ziyad/cms-ai/server/internal/store/postgres"
+	"google.golang.org/grpc"
 )
 
 func main() {
-	// Initialize structured logging
-	logLevel := logger.LogLevel(env("LOG_LEVEL", "info"))
-	logFormat := env("LOG_FORMAT", "json")
+	migrateOnly := flag.Bool("migrate-only", false, "apply database migrations and exit")
+	reencrypt := flag.Bool("reencrypt", false, "re-seal encrypted columns with the primary DATA_ENCRYPTION_KEYS key and exit")
+	flag.Parse()
+
+	cfg, err := config.Load(os.Getenv)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
+	// Initialize structured logging
 	logger.Initialize(&logger.Config{
-		Level:  logLevel,
-		Format: logFormat,
+		Level:  cfg.Log.Level,
+		Format: cfg.Log.Format,
 	})
 
+	// Emails and phone numbers are always masked in logs and audit metadata;
+	// PII_REDACT_PATTERNS adds space-separated regular expressions.
+	if err := redact.Configure(cfg.Log.RedactPatterns); err != nil {
+		logger.Logger.Error("invalid_redact_patterns", "error", err)
+		os.Exit(1)
+	}
+	// Requests to addresses users supply stay off private networks except
+	// the ones EGRESS_ALLOWED_CIDRS lists.
+	egress.Configure(cfg.API.EgressAllowedCIDRs)
+
 	logger.Logger.Info("server_starting",
-		"log_level", logLevel,
-		"log_format", logFormat,
+		"log_level", cfg.Log.Level,
+		"log_format", cfg.Log.Format,
 	)
 
-	// Support both PORT (Railway) and ADDR (local dev)
-	port := env("PORT", "")
-	addr := env("ADDR", "")
-	if port != "" {
-		addr = ":" + port
-	} else if addr == "" {
-		addr = ":8080"
+	secretsCtx, stopSecrets := context.WithCancel(context.Background())
+	defer stopSecrets()
+	if err := loadSecrets(secretsCtx, cfg.Server.SecretsRefreshInterval); err != nil {
+		logger.Logger.Error("secrets_invalid", "error", err)
+		os.Exit(1)
+	}
+	// The secrets provider may have supplied DATABASE_URL and API keys, so
+	// the configuration is read again with them in place.
+	if cfg, err = config.Load(os.Getenv); err != nil {
+		logger.Logger.Error("config_invalid", "error", err)
+		os.Exit(1)
+	}
+
+	if *migrateOnly {
+		os.Exit(runMigrations(cfg.Database))
+	}
+	if *reencrypt {
+		os.Exit(runReencrypt(cfg.Database))
 	}
 
-	srv, worker := api.NewServerWithWorker()
+	addr := cfg.Server.Addr
+	srv, worker := api.NewWithWorker(cfg)
+	defer func() {
+		if err := srv.Close(); err != nil {
+			logger.Logger.Error("server_close_error", "error", err)
+		}
+	}()
 	worker.Start()
-	defer worker.Stop()
+	srv.Webhooks.Start()
+	defer srv.Webhooks.Stop()
 
 	httpSrv := &http.Server{
 		Addr:              addr,
@@ -57,6 +100,24 @@ func main() {
 		}
 	}()
 
+	// Internal services can use the typed gRPC API alongside REST.
+	var grpcSrv *grpc.Server
+	if grpcAddr := cfg.Server.GRPCAddr; grpcAddr != "" {
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			logger.Logger.Error("grpc_listen_error", "addr", grpcAddr, "error", err)
+			os.Exit(1)
+		}
+		grpcSrv = srv.GRPCServer()
+		go func() {
+			logger.Logger.Info("grpc_listening", "addr", grpcAddr)
+			if err := grpcSrv.Serve(lis); err != nil {
+				logger.Logger.Error("grpc_server_error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 	<-stop
@@ -69,12 +130,71 @@ func main() {
 	} else {
 		logger.Logger.Info("server_shutdown_complete")
 	}
+	if grpcSrv != nil {
+		stopGRPC(ctx, grpcSrv)
+	}
+
+	// With no more requests coming in, give running jobs until the drain
+	// timeout to finish; the worker requeues whatever is left.
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.Worker.DrainTimeout)
+	defer cancelDrain()
+	if err := worker.Shutdown(drainCtx); err != nil {
+		logger.Logger.Warn("worker_drain_incomplete", "in_flight", worker.InFlight(), "error", err)
+	} else {
+		logger.Logger.Info("worker_drained")
+	}
 }
 
-func env(key string, fallback string) string {
-	v := os.Getenv(key)
-	if v == "" {
-		return fallback
+// stopGRPC lets in-flight RPCs finish until ctx expires, then cuts off the
+// rest; WatchJob streams can otherwise outlive the shutdown window.
+func stopGRPC(ctx context.Context, gs *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		gs.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		gs.Stop()
+	}
+}
+
+// runMigrations brings the DATABASE_URL schema up to date for deploy steps
+// that migrate before starting new instances. It returns the exit code.
+func runMigrations(db config.Database) int {
+	if db.URL == "" {
+		logger.Logger.Error("migrate_failed", "error", "DATABASE_URL is not set")
+		return 1
+	}
+	pg, err := postgres.NewWithOptions(db.URL, postgres.Options{Pool: db.Pool, Keys: db.EncryptionKeys, LogQueries: db.LogQueries})
+	if err != nil {
+		logger.Logger.Error("migrate_failed", "error", err)
+		return 1
+	}
+	defer pg.Close()
+	logger.Logger.Info("migrate_complete")
+	return 0
+}
+
+// runReencrypt rewrites encrypted columns under the primary key after a key
+// rotation, so the old key can be retired. It returns the exit code.
+func runReencrypt(db config.Database) int {
+	if db.URL == "" {
+		logger.Logger.Error("reencrypt_failed", "error", "DATABASE_URL is not set")
+		return 1
+	}
+	pg, err := postgres.NewWithOptions(db.URL, postgres.Options{Pool: db.Pool, Keys: db.EncryptionKeys, LogQueries: db.LogQueries})
+	if err != nil {
+		logger.Logger.Error("reencrypt_failed", "error", err)
+		return 1
+	}
+	defer pg.Close()
+	n, err := pg.ReencryptAll(context.Background())
+	if err != nil {
+		logger.Logger.Error("reencrypt_failed", "rows", n, "error", err)
+		return 1
 	}
-	return v
+	logger.Logger.Info("reencrypt_complete", "rows", n)
+	return 0
 }
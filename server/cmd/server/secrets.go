@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/secrets"
+	"github.com/ziyad/cms-ai/server/internal/store/postgres"
+)
+
+func validEncryptionKeys(v string) error {
+	_, err := postgres.ParseKeyring(v)
+	return err
+}
+
+// secretSpecs are the credentials the server reads. They may come from any
+// secrets provider; the rest of the server still reads them from the
+// environment, where loadSecrets puts them.
+var secretSpecs = []secrets.Spec{
+	{Name: "JWT_SECRET", Required: true, Validate: auth.ValidateJWTSecret},
+	// JWT_SECRET_PREVIOUS keeps tokens signed before a rotation valid.
+	{Name: "JWT_SECRET_PREVIOUS", Validate: auth.ValidateJWTSecret},
+	{Name: "DATABASE_URL"},
+	{Name: "DATABASE_REPLICA_URL"},
+	{Name: "DATA_ENCRYPTION_KEYS", Validate: validEncryptionKeys},
+	{Name: "HUGGINGFACE_API_KEY", Validate: secrets.Prefix("hf_")},
+	{Name: "HUGGING_FACE_API_KEY", Validate: secrets.Prefix("hf_")},
+	{Name: "RENDER_SERVICE_TOKEN"},
+	{Name: "GOOGLE_CLIENT_SECRET"},
+	{Name: "UNSPLASH_ACCESS_KEY"},
+	{Name: "PEXELS_API_KEY"},
+	{Name: "AWS_SECRET_ACCESS_KEY"},
+}
+
+// loadSecrets fetches and validates the server's secrets from the provider
+// named by SECRETS_PROVIDER and keeps them current: every change is written
+// to the environment, and a new JWT secret is installed with the old one
+// still accepted. Non-env providers are polled every refresh until ctx is
+// done.
+func loadSecrets(ctx context.Context, refresh time.Duration) error {
+	provider, err := secrets.FromEnv(os.Getenv)
+	if err != nil {
+		return err
+	}
+	m := secrets.NewManager(provider, secretSpecs)
+	for _, s := range secretSpecs {
+		name := s.Name
+		m.OnChange(name, func(value, _ string) {
+			if value != "" {
+				os.Setenv(name, value)
+			}
+		})
+	}
+	m.OnChange("JWT_SECRET", func(value, previous string) {
+		if previous != "" {
+			logger.Logger.Info("jwt_secret_rotated")
+		}
+		if err := auth.SetJWTSecrets(value, previous, m.Get("JWT_SECRET_PREVIOUS")); err != nil {
+			logger.Logger.Error("jwt_secret_rejected", "error", err)
+		}
+	})
+	m.OnChange("DATA_ENCRYPTION_KEYS", func(value, previous string) {
+		// The store installs the first keyring itself when it connects.
+		if previous == "" {
+			return
+		}
+		if keys, err := postgres.ParseKeyring(value); err == nil {
+			postgres.SetKeyring(keys)
+			logger.Logger.Info("data_encryption_keys_rotated", "primary_key", keys.Primary())
+		}
+	})
+	if err := m.Load(ctx); err != nil {
+		return err
+	}
+	logger.Logger.Info("secrets_loaded", "provider", provider.Name())
+
+	if provider.Name() != "env" && refresh > 0 {
+		go m.Watch(ctx, refresh)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+)
+
+// maxAltText is the longest alt text kept, in runes; screen readers read
+// alt text in one go, and checkers flag long descriptions.
+const maxAltText = 250
+
+// AltTextWriter describes a spec's images for screen readers; AIService
+// implements it.
+type AltTextWriter interface {
+	// WriteAltText fills in the alt text of the images in s that have none
+	// and aren't decorative, and returns how many it wrote.
+	WriteAltText(ctx context.Context, orgID, userID string, s *spec.TemplateSpec) (int, error)
+}
+
+// WriteAltText asks the model to describe each image from its slide's text
+// and where the image came from, since the model doesn't see the picture
+// itself. Images the answer leaves out keep no alt text; an answer with
+// none of them is an error.
+func (s *AIService) WriteAltText(ctx context.Context, orgID, userID string, sp *spec.TemplateSpec) (int, error) {
+	missing := spec.MissingAltText(*sp)
+	if len(missing) == 0 {
+		return 0, nil
+	}
+	images := make(map[string]string, len(missing))
+	for _, ref := range missing {
+		images[altTextKey(ref)] = altTextContext(sp.Layouts[ref.Layout], sp.Layouts[ref.Layout].Placeholders[ref.Placeholder].Image)
+	}
+	b, err := json.Marshal(images)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal images: %w", err)
+	}
+	prompt := altTextPrompt(string(b))
+	answer, err := s.generateJSON(ctx, orgID, userID, CallAltText, prompt)
+	if err != nil {
+		return 0, fmt.Errorf("write alt text: %w", err)
+	}
+	// GenerateJSON doesn't report usage, so tokens are estimated.
+	s.recordUsage(ctx, orgID, userID, &GenerationResponse{TokenUsage: estimateTokens(prompt, answer)})
+
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	var got map[string]string
+	if start < 0 || end <= start || json.Unmarshal([]byte(answer[start:end+1]), &got) != nil {
+		return 0, fmt.Errorf("write alt text: model did not return a JSON object of descriptions")
+	}
+	written := 0
+	for _, ref := range missing {
+		alt := clipAltText(got[altTextKey(ref)])
+		if alt == "" {
+			continue
+		}
+		sp.Layouts[ref.Layout].Placeholders[ref.Placeholder].Image.Alt = alt
+		written++
+	}
+	if written == 0 {
+		return 0, fmt.Errorf("write alt text: model described none of the images")
+	}
+	return written, nil
+}
+
+func altTextKey(ref spec.ImageRef) string {
+	return fmt.Sprintf("%d/%d", ref.Layout, ref.Placeholder)
+}
+
+// altTextContext is what the model is told about an image: the text of its
+// slide, its file name and its source.
+func altTextContext(layout spec.Layout, img *spec.Image) string {
+	var text []string
+	for _, ph := range layout.Placeholders {
+		if ph.Type != "image" && strings.TrimSpace(ph.Content) != "" {
+			text = append(text, strings.Join(strings.Fields(ph.Content), " "))
+		}
+	}
+	slide := strings.Join(text, " / ")
+	if len(slide) > 300 {
+		slide = slide[:300]
+	}
+	out := "slide text: " + slide + "; file: " + path.Base(img.Path)
+	if img.Attribution != nil && img.Attribution.Text != "" {
+		out += "; source: " + img.Attribution.Text
+	} else if img.Provider != "" {
+		out += "; source: " + img.Provider
+	}
+	return out
+}
+
+// clipAltText collapses whitespace and cuts text longer than maxAltText at
+// a word boundary.
+func clipAltText(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= maxAltText {
+		return text
+	}
+	cut := string(runes[:maxAltText])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, ",;:") + "…"
+}
+
+// altTextPromptHeader opens every alt text prompt; the mock orchestrator
+// recognizes them by it.
+const altTextPromptHeader = "You write alt text for the images on presentation slides."
+
+// altTextImagesMarker precedes the images, which end the prompt.
+const altTextImagesMarker = "\nIMAGES_JSON:\n"
+
+func altTextPrompt(imagesJSON string) string {
+	return altTextPromptHeader + ` The input is a JSON object of images by key; each value describes the slide the image is on and the image's file and source. ` +
+		`For each image, write one sentence of alt text (at most 125 characters) saying what the image most likely shows and why it is on the slide. ` +
+		`Don't start with "Image of" or "Picture of", and write in the language of the slide text.
+Return ONLY a JSON object with the same keys and the alt texts as values (no markdown).
+` + altTextImagesMarker + imagesJSON
+}
@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+)
+
+func TestAIService_WriteAltText(t *testing.T) {
+	st := newMockStore()
+	svc := &AIService{orchestrator: NewMockOrchestrator(), store: st}
+	newSpec := func() *spec.TemplateSpec {
+		return &spec.TemplateSpec{Layouts: []spec.Layout{
+			{Placeholders: []spec.Placeholder{
+				{ID: "title", Type: "text", Content: "Our team"},
+				{ID: "photo", Type: "image", Image: &spec.Image{Path: "org-1/team.png"}},
+				{ID: "rule", Type: "image", Image: &spec.Image{Path: "org-1/rule.png", Decorative: true}},
+			}},
+			{Placeholders: []spec.Placeholder{
+				{ID: "title", Type: "text", Content: "Revenue"},
+				{ID: "chart", Type: "image", Image: &spec.Image{Path: "org-1/chart.png", Alt: "Bar chart of revenue"}},
+			}},
+		}}
+	}
+
+	sp := newSpec()
+	n, err := svc.WriteAltText(context.Background(), "org-1", "user-1", sp)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "Illustration for Our team", sp.Layouts[0].Placeholders[1].Image.Alt)
+	assert.Empty(t, sp.Layouts[0].Placeholders[2].Image.Alt, "decorative images need no alt text")
+	assert.Equal(t, "Bar chart of revenue", sp.Layouts[1].Placeholders[1].Image.Alt)
+	require.Len(t, st.metering, 1)
+
+	// Nothing to describe makes no call.
+	n, err = svc.WriteAltText(context.Background(), "org-1", "user-1", sp)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Len(t, st.metering, 1)
+
+	// Long answers are cut at a word; unknown keys are ignored.
+	svc.orchestrator = &mockOrchestrator{json: `{"0/1":"` + strings.Repeat("word ", 80) + `","9/9":"extra"}`}
+	sp = newSpec()
+	n, err = svc.WriteAltText(context.Background(), "org-1", "user-1", sp)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	alt := sp.Layouts[0].Placeholders[1].Image.Alt
+	assert.LessOrEqual(t, len([]rune(alt)), maxAltText+1)
+	assert.True(t, strings.HasSuffix(alt, "word…"))
+
+	svc.orchestrator = &mockOrchestrator{json: `{"other":"text"}`}
+	_, err = svc.WriteAltText(context.Background(), "org-1", "user-1", newSpec())
+	assert.Error(t, err)
+}
@@ -0,0 +1,132 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// ErrUnusableAnalysis is returned when the model's answer to an analysis
+// prompt can't be turned into a PromptAnalysis.
+var ErrUnusableAnalysis = errors.New("unusable prompt analysis")
+
+const (
+	maxAnalysisFields = 12
+	maxAnalysisSlides = 50
+)
+
+// PromptAnalysis is what a template prompt asks for: the kind of template,
+// the fields the user should fill in and roughly how many slides it needs.
+type PromptAnalysis struct {
+	TemplateType  string `json:"templateType"`
+	SuggestedName string `json:"suggestedName"`
+	Description   string `json:"description"`
+	// Language is the prompt's BCP 47 language tag. Labels, descriptions
+	// and examples are written in it.
+	Language        string          `json:"language"`
+	EstimatedSlides int             `json:"estimatedSlides"`
+	RequiredFields  []AnalysisField `json:"requiredFields"`
+}
+
+// AnalysisField is one input a template needs, like store.TemplateField.
+// Key is an English camelCase identifier whatever the prompt's language.
+type AnalysisField struct {
+	Key         string   `json:"key"`
+	Label       string   `json:"label"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Example     string   `json:"example,omitempty"`
+	Options     []string `json:"options,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// PromptAnalyzer analyzes template prompts; AIService implements it.
+type PromptAnalyzer interface {
+	AnalyzePrompt(ctx context.Context, orgID, userID, prompt string) (*PromptAnalysis, error)
+}
+
+// AnalyzePrompt asks the model what template prompt describes. An answer
+// that doesn't describe a template with at least one field is an
+// ErrUnusableAnalysis.
+func (s *AIService) AnalyzePrompt(ctx context.Context, orgID, userID, prompt string) (*PromptAnalysis, error) {
+	answer, err := s.generateJSON(ctx, orgID, userID, CallAnalyze, analysisPrompt(prompt))
+	if err != nil {
+		return nil, fmt.Errorf("analyze prompt: %w", err)
+	}
+	return parseAnalysis(answer)
+}
+
+// analysisPromptHeader opens every analysis prompt; the mock orchestrator
+// recognizes analysis requests by it.
+const analysisPromptHeader = "You analyze requests for presentation templates."
+
+// analysisPromptMarker precedes the user's prompt, which ends the analysis
+// prompt.
+const analysisPromptMarker = "\nUSER_PROMPT:\n"
+
+func analysisPrompt(prompt string) string {
+	return analysisPromptHeader + ` Read the user's prompt, in whatever language it is written, and describe the template it needs.
+Rules:
+- templateType: a short lowercase English slug, e.g. "sales-report", "product-launch"
+- suggestedName and description: short, in the prompt's language
+- language: the prompt's BCP 47 language tag, e.g. "en", "ar", "fr"
+- estimatedSlides: how many slides the template needs, 1-` + fmt.Sprint(maxAnalysisSlides) + `
+- requiredFields: up to ` + fmt.Sprint(maxAnalysisFields) + ` inputs the user should provide. key is English camelCase; label, description and example are in the prompt's language; type is one of ` + strings.Join(store.TemplateFieldTypes, ", ") + `
+Output shape: {"templateType":"...","suggestedName":"...","description":"...","language":"en","estimatedSlides":6,"requiredFields":[{"key":"...","label":"...","type":"text","required":true,"example":"...","description":"..."}]}
+Return ONLY valid JSON (no markdown).
+` + analysisPromptMarker + prompt
+}
+
+var (
+	slugInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+	fieldKey    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+)
+
+// parseAnalysis reads the JSON object in answer and tidies it: unknown field
+// types become text, fields without a usable key or label and repeated keys
+// are dropped, and the slide estimate is clamped.
+func parseAnalysis(answer string) (*PromptAnalysis, error) {
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("%w: no JSON object in answer", ErrUnusableAnalysis)
+	}
+	var a PromptAnalysis
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &a); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnusableAnalysis, err)
+	}
+
+	a.TemplateType = strings.Trim(slugInvalid.ReplaceAllString(strings.ToLower(a.TemplateType), "-"), "-")
+	a.SuggestedName = strings.TrimSpace(a.SuggestedName)
+	a.Description = strings.TrimSpace(a.Description)
+	a.Language = strings.TrimSpace(a.Language)
+	a.EstimatedSlides = min(max(a.EstimatedSlides, 1), maxAnalysisSlides)
+
+	fields := make([]AnalysisField, 0, len(a.RequiredFields))
+	seen := map[string]bool{}
+	for _, f := range a.RequiredFields {
+		f.Key, f.Label = strings.TrimSpace(f.Key), strings.TrimSpace(f.Label)
+		if !fieldKey.MatchString(f.Key) || f.Label == "" || seen[f.Key] {
+			continue
+		}
+		seen[f.Key] = true
+		if !slices.Contains(store.TemplateFieldTypes, f.Type) {
+			f.Type = "text"
+		}
+		fields = append(fields, f)
+		if len(fields) == maxAnalysisFields {
+			break
+		}
+	}
+	a.RequiredFields = fields
+
+	if a.TemplateType == "" || a.SuggestedName == "" || len(a.RequiredFields) == 0 {
+		return nil, fmt.Errorf("%w: missing template type, name or fields", ErrUnusableAnalysis)
+	}
+	return &a, nil
+}
@@ -0,0 +1,51 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAnalysis(t *testing.T) {
+	a, err := parseAnalysis("Here you go:\n```json\n" + `{"templateType":"Investor Pitch!","suggestedName":" Pitch ","estimatedSlides":90,
+		"requiredFields":[
+			{"key":"company","label":"Company","type":"text","required":true},
+			{"key":"company","label":"Again","type":"text"},
+			{"key":"raise","label":"Raise","type":"money"},
+			{"key":"","label":"No key","type":"text"},
+			{"key":"logo url","label":"Bad key","type":"text"}
+		]}` + "\n```")
+	require.NoError(t, err)
+	assert.Equal(t, "investor-pitch", a.TemplateType)
+	assert.Equal(t, "Pitch", a.SuggestedName)
+	assert.Equal(t, maxAnalysisSlides, a.EstimatedSlides)
+	require.Len(t, a.RequiredFields, 2)
+	assert.Equal(t, "text", a.RequiredFields[1].Type, "unknown types become text")
+
+	for _, answer := range []string{"no json here", `{"templateType":"x"`, `{"templateType":"deck","suggestedName":"Deck","requiredFields":[]}`} {
+		_, err := parseAnalysis(answer)
+		assert.ErrorIs(t, err, ErrUnusableAnalysis, answer)
+	}
+}
+
+func TestAIService_AnalyzePrompt(t *testing.T) {
+	svc := &AIService{orchestrator: NewMockOrchestrator(), store: newMockStore()}
+
+	a, err := svc.AnalyzePrompt(context.Background(), "org-1", "user-1", "Quarterly investment update for our bank's board")
+	require.NoError(t, err)
+	assert.Equal(t, "finance-presentation", a.TemplateType)
+	assert.Equal(t, "en", a.Language)
+	assert.Contains(t, a.RequiredFields, AnalysisField{Key: "revenue", Label: "Revenue", Type: "currency", Example: "$2.5M"})
+
+	a, err = svc.AnalyzePrompt(context.Background(), "org-1", "user-1", "عرض تقديمي عن نتائج الربع الأخير")
+	require.NoError(t, err)
+	assert.Equal(t, "ar", a.Language)
+	assert.Equal(t, "title", a.RequiredFields[0].Key)
+	assert.Equal(t, "العنوان", a.RequiredFields[0].Label)
+
+	svc.orchestrator = &mockOrchestrator{err: assert.AnError}
+	_, err = svc.AnalyzePrompt(context.Background(), "org-1", "user-1", "anything")
+	assert.ErrorIs(t, err, assert.AnError)
+}
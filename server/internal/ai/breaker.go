@@ -0,0 +1,193 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrProviderUnavailable matches the errors of calls a Breaker failed fast,
+// without reaching the provider.
+var ErrProviderUnavailable = errors.New("AI provider unavailable")
+
+// UnavailableError is a call a Breaker failed fast. It matches
+// ErrProviderUnavailable.
+type UnavailableError struct {
+	Provider string
+	Reason   string
+	// RetryAfter is how long until the provider takes calls again, as far
+	// as the breaker knows.
+	RetryAfter time.Duration
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("AI provider %s unavailable (temporary): %s", e.Provider, e.Reason)
+}
+
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrProviderUnavailable
+}
+
+// BreakerConfig tunes a provider's circuit breaker. Zero fields take their
+// defaults.
+type BreakerConfig struct {
+	// FailureThreshold consecutive failed calls open the circuit
+	// (default 5).
+	FailureThreshold int
+	// Cooldown is how long an open circuit fails calls fast before letting
+	// one probe call through (default 30s).
+	Cooldown time.Duration
+	// MaxConcurrent bounds the calls in flight to the provider (default 8).
+	MaxConcurrent int
+	// QueueTimeout is how long a call waits for one of them before failing
+	// fast (default 5s).
+	QueueTimeout time.Duration
+	// CallTimeout is each call's time budget (default 2m). A call that runs
+	// over it is a failure.
+	CallTimeout time.Duration
+}
+
+func (c BreakerConfig) withDefaults() BreakerConfig {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 5
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = 30 * time.Second
+	}
+	if c.MaxConcurrent <= 0 {
+		c.MaxConcurrent = 8
+	}
+	if c.QueueTimeout <= 0 {
+		c.QueueTimeout = 5 * time.Second
+	}
+	if c.CallTimeout <= 0 {
+		c.CallTimeout = 2 * time.Minute
+	}
+	return c
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// Breaker guards the calls to one provider. After FailureThreshold failures
+// in a row the circuit opens and calls fail fast with an UnavailableError.
+// Once Cooldown has passed the circuit is half open: one probe call goes
+// through, and closes the circuit if it succeeds or opens it again if it
+// fails. Only the provider failing counts: errors it was unreachable, timed
+// out or answered with an error status. A bad answer doesn't, and neither
+// does a caller giving up. A nil Breaker lets every call through.
+type Breaker struct {
+	provider string
+	cfg      BreakerConfig
+	slots    chan struct{}
+	now      func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewBreaker(provider string, cfg BreakerConfig) *Breaker {
+	cfg = cfg.withDefaults()
+	return &Breaker{provider: provider, cfg: cfg, slots: make(chan struct{}, cfg.MaxConcurrent), now: time.Now}
+}
+
+// Do runs call with a context bounded by the call time budget, or fails
+// fast when the circuit is open or no call slot frees up in time.
+func (b *Breaker) Do(ctx context.Context, call func(context.Context) error) error {
+	if b == nil {
+		return call(ctx)
+	}
+	probe, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	wait := time.NewTimer(b.cfg.QueueTimeout)
+	defer wait.Stop()
+	select {
+	case b.slots <- struct{}{}:
+	case <-wait.C:
+		b.skipProbe(probe)
+		return &UnavailableError{Provider: b.provider, Reason: fmt.Sprintf("all %d call slots busy", b.cfg.MaxConcurrent), RetryAfter: b.cfg.QueueTimeout}
+	case <-ctx.Done():
+		b.skipProbe(probe)
+		return ctx.Err()
+	}
+	defer func() { <-b.slots }()
+
+	callCtx, cancel := context.WithTimeout(ctx, b.cfg.CallTimeout)
+	defer cancel()
+	err = call(callCtx)
+	b.record(probe, err != nil && ctx.Err() == nil && providerFailed(err))
+	return err
+}
+
+// allow reports whether a call may go ahead and whether it is the probe of
+// a half-open circuit.
+func (b *Breaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if wait := b.cfg.Cooldown - b.now().Sub(b.openedAt); wait > 0 {
+			return false, &UnavailableError{Provider: b.provider, Reason: "circuit open after repeated failures", RetryAfter: wait}
+		}
+		b.state, b.probing = breakerHalfOpen, true
+		return true, nil
+	case breakerHalfOpen:
+		if b.probing {
+			return false, &UnavailableError{Provider: b.provider, Reason: "circuit half open, waiting on a probe call", RetryAfter: time.Second}
+		}
+		b.probing = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// skipProbe lets another call probe when the probe never reached the
+// provider.
+func (b *Breaker) skipProbe(probe bool) {
+	if probe {
+		b.mu.Lock()
+		b.probing = false
+		b.mu.Unlock()
+	}
+}
+
+func (b *Breaker) record(probe, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	switch {
+	case !failed:
+		b.state, b.failures = breakerClosed, 0
+	case probe || b.state == breakerClosed && b.failures+1 >= b.cfg.FailureThreshold:
+		b.state, b.openedAt, b.failures = breakerOpen, b.now(), 0
+	case b.state == breakerClosed:
+		b.failures++
+	}
+}
+
+// providerError marks an error as the provider failing rather than
+// answering badly.
+type providerError struct{ err error }
+
+func (e *providerError) Error() string { return e.err.Error() }
+func (e *providerError) Unwrap() error { return e.err }
+
+func providerFailed(err error) bool {
+	var pe *providerError
+	return errors.As(err, &pe) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreaker_OpensAndProbes(t *testing.T) {
+	b := NewBreaker("hf", BreakerConfig{FailureThreshold: 2, Cooldown: time.Minute})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+	calls := 0
+	fail := func(context.Context) error { calls++; return &providerError{errors.New("status 503")} }
+	ok := func(context.Context) error { calls++; return nil }
+
+	// A bad answer isn't the provider failing.
+	for range 3 {
+		_ = b.Do(ctx, func(context.Context) error { return errors.New("failed to parse AI response") })
+	}
+	require.NoError(t, b.Do(ctx, ok))
+
+	assert.Error(t, b.Do(ctx, fail))
+	assert.Error(t, b.Do(ctx, fail))
+	err := b.Do(ctx, ok)
+	var unavailable *UnavailableError
+	require.ErrorAs(t, err, &unavailable)
+	assert.ErrorIs(t, err, ErrProviderUnavailable)
+	assert.Equal(t, time.Minute, unavailable.RetryAfter)
+	assert.Equal(t, 3, calls, "an open circuit doesn't call the provider")
+
+	// After the cooldown one probe goes through; it failing reopens the
+	// circuit.
+	now = now.Add(time.Minute)
+	assert.Error(t, b.Do(ctx, fail))
+	assert.Equal(t, 4, calls)
+	assert.ErrorIs(t, b.Do(ctx, ok), ErrProviderUnavailable)
+
+	// A probe that succeeds closes it.
+	now = now.Add(time.Minute)
+	require.NoError(t, b.Do(ctx, ok))
+	require.NoError(t, b.Do(ctx, ok))
+	assert.Equal(t, 6, calls)
+}
+
+func TestBreaker_OneProbeAtATime(t *testing.T) {
+	b := NewBreaker("hf", BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+	_ = b.Do(ctx, func(context.Context) error { return &providerError{errors.New("down")} })
+	now = now.Add(time.Minute)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- b.Do(ctx, func(context.Context) error { close(started); <-release; return nil })
+	}()
+	<-started
+	assert.ErrorIs(t, b.Do(ctx, func(context.Context) error { return nil }), ErrProviderUnavailable)
+	close(release)
+	require.NoError(t, <-done)
+	assert.NoError(t, b.Do(ctx, func(context.Context) error { return nil }))
+}
+
+func TestBreaker_LimitsConcurrencyAndTime(t *testing.T) {
+	b := NewBreaker("hf", BreakerConfig{FailureThreshold: 1, MaxConcurrent: 1, QueueTimeout: 10 * time.Millisecond, CallTimeout: 20 * time.Millisecond})
+	ctx := context.Background()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- b.Do(ctx, func(context.Context) error { close(started); <-release; return nil })
+	}()
+	<-started
+	err := b.Do(ctx, func(context.Context) error { return nil })
+	assert.ErrorIs(t, err, ErrProviderUnavailable, "no slot frees up in time")
+	close(release)
+	require.NoError(t, <-done)
+
+	// A call that runs over its budget is cut off and counts as a failure.
+	err = b.Do(ctx, func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, b.Do(ctx, func(context.Context) error { return nil }), ErrProviderUnavailable)
+
+	// A caller giving up doesn't.
+	b = NewBreaker("hf", BreakerConfig{FailureThreshold: 1})
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_ = b.Do(cancelled, func(ctx context.Context) error { return &providerError{ctx.Err()} })
+	assert.NoError(t, b.Do(ctx, func(context.Context) error { return nil }))
+}
+
+func TestOrchestrator_FailsFastInsteadOfSafetyNet(t *testing.T) {
+	b := NewBreaker(ProviderHuggingFace, BreakerConfig{FailureThreshold: 1})
+	_ = b.Do(context.Background(), func(context.Context) error { return &providerError{errors.New("down")} })
+	o := &orchestrator{client: NewHuggingFaceClient("key", ""), breaker: b}
+
+	_, err := o.GenerateTemplateSpec(context.Background(), GenerationRequest{Prompt: "Roadmap"})
+	assert.ErrorIs(t, err, ErrProviderUnavailable)
+	_, err = o.GenerateJSON(context.Background(), "{}")
+	assert.ErrorIs(t, err, ErrProviderUnavailable)
+}
@@ -0,0 +1,119 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// What a provider call was for, as recorded in the call history.
+const (
+	CallGenerate  = "generate"
+	CallBind      = "bind"
+	CallRefine    = "refine"
+	CallSlide     = "slide"
+	CallTransform = "transform"
+	CallAnalyze   = "analyze"
+	CallGlossary  = "glossary"
+	CallAltText   = "alt_text"
+)
+
+// CallKinds lists every call kind, in the order they are documented.
+var CallKinds = []string{CallGenerate, CallBind, CallRefine, CallSlide, CallTransform, CallAnalyze, CallGlossary, CallAltText}
+
+// generateSpec asks the kind's models for a spec, in chain order, until one
+// answers, and records every call. A static fallback answer is recorded as a
+// failed call: the provider didn't produce it.
+func (s *AIService) generateSpec(ctx context.Context, orgID, userID, kind string, req GenerationRequest) (*GenerationResponse, error) {
+	var hash string
+	if b, err := json.Marshal(req); err == nil {
+		hash = hashRequest(string(b))
+	}
+	var resp *GenerationResponse
+	var err error
+	for i, o := range s.chain(kind) {
+		start := time.Now()
+		resp, err = o.GenerateTemplateSpec(ctx, req)
+		c := store.AICall{Kind: kind, RequestHash: hash, Attempt: i + 1, LatencyMs: time.Since(start).Milliseconds(), Success: err == nil}
+		switch {
+		case err != nil:
+			c.Model, c.Error = modelName(o), err.Error()
+		case resp != nil:
+			c.Model, c.Tokens, c.Cost = resp.Model, resp.TokenUsage, resp.Cost
+			if resp.Model == staticFallbackModel {
+				c.Success, c.Error = false, "provider unavailable, static fallback returned"
+			}
+		}
+		s.recordCall(ctx, orgID, userID, c)
+		if c.Success || ctx.Err() != nil {
+			break
+		}
+	}
+	return resp, err
+}
+
+// generateJSON asks the kind's models for a raw answer, in chain order,
+// until one answers, and records every call.
+func (s *AIService) generateJSON(ctx context.Context, orgID, userID, kind, prompt string) (string, error) {
+	var answer string
+	var err error
+	for i, o := range s.chain(kind) {
+		start := time.Now()
+		answer, err = o.GenerateJSON(ctx, prompt)
+		c := store.AICall{
+			Kind:        kind,
+			RequestHash: hashRequest(prompt),
+			Attempt:     i + 1,
+			Model:       modelName(o),
+			LatencyMs:   time.Since(start).Milliseconds(),
+			Tokens:      estimateTokens(prompt, answer),
+			Success:     err == nil,
+		}
+		if err != nil {
+			c.Error = err.Error()
+		}
+		s.recordCall(ctx, orgID, userID, c)
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	return answer, err
+}
+
+// recordCall saves c to the call history. Failing to record a call doesn't
+// fail the generation.
+func (s *AIService) recordCall(ctx context.Context, orgID, userID string, c store.AICall) {
+	if s.store == nil {
+		return
+	}
+	calls := s.store.AICalls()
+	if calls == nil {
+		return
+	}
+	c.ID, c.OrgID, c.UserID = uuid.New().String(), orgID, userID
+	_, _ = calls.Record(ctx, c)
+}
+
+// modelName is the model o calls, when it says.
+func modelName(o Orchestrator) string {
+	if mn, ok := o.(interface{ ModelName() string }); ok {
+		return mn.ModelName()
+	}
+	return ""
+}
+
+// estimateTokens estimates a call's tokens at about four characters each,
+// for answers that don't report usage.
+func estimateTokens(prompt, answer string) int {
+	return (len(prompt) + len(answer)) / 4
+}
+
+func hashRequest(request string) string {
+	sum := sha256.Sum256([]byte(request))
+	return hex.EncodeToString(sum[:])
+}
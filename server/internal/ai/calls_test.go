@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestAIService_RecordsCalls(t *testing.T) {
+	st := newMockStore()
+	orch := &mockOrchestrator{response: &GenerationResponse{Spec: &spec.TemplateSpec{Layouts: []spec.Layout{{Name: "Title"}}}, TokenUsage: 120, Cost: 0.002, Model: "m-1"}}
+	svc := &AIService{orchestrator: orch, store: st}
+	ctx := context.Background()
+
+	_, _, err := svc.GenerateTemplateForRequest(ctx, "org-1", "user-1", GenerationRequest{Prompt: "Quarterly review"}, "")
+	require.NoError(t, err)
+	_, _, err = svc.GenerateTemplateForRequest(ctx, "org-1", "user-1", GenerationRequest{Prompt: "Quarterly review"}, "")
+	require.NoError(t, err)
+	require.Len(t, st.calls, 2)
+	c := st.calls[0]
+	assert.NotEmpty(t, c.ID)
+	assert.Equal(t, "org-1", c.OrgID)
+	assert.Equal(t, "user-1", c.UserID)
+	assert.Equal(t, CallGenerate, c.Kind)
+	assert.Equal(t, "m-1", c.Model)
+	assert.Equal(t, 120, c.Tokens)
+	assert.Equal(t, 0.002, c.Cost)
+	assert.True(t, c.Success)
+	assert.Len(t, c.RequestHash, 64)
+	assert.Equal(t, c.RequestHash, st.calls[1].RequestHash, "the same request hashes the same")
+
+	// The static safety net isn't the provider's answer.
+	orch.response = &GenerationResponse{Spec: &spec.TemplateSpec{}, Model: staticFallbackModel}
+	_, _, err = svc.GenerateTemplateForRequest(ctx, "org-1", "user-1", GenerationRequest{Prompt: "Roadmap"}, "")
+	require.NoError(t, err)
+	assert.False(t, st.calls[2].Success)
+	assert.NotEqual(t, c.RequestHash, st.calls[2].RequestHash)
+
+	// Failed calls are recorded with their error, including binds that fall
+	// back to the template.
+	orch.err = errors.New("provider timeout")
+	_, _, err = svc.BindDeckSpec(ctx, "org-1", "user-1", &spec.TemplateSpec{}, "content", store.GenerationParams{})
+	require.NoError(t, err)
+	last := st.calls[len(st.calls)-1]
+	assert.Equal(t, CallBind, last.Kind)
+	assert.False(t, last.Success)
+	assert.Equal(t, "provider timeout", last.Error)
+
+	// JSON calls estimate their tokens and take the orchestrator's model.
+	svc.orchestrator = NewMockOrchestrator()
+	_, err = svc.TransformTexts(ctx, "org-1", "user-1", TransformShorten, "", map[string]string{"0/0": "Quarterly results"})
+	require.NoError(t, err)
+	last = st.calls[len(st.calls)-1]
+	assert.Equal(t, CallTransform, last.Kind)
+	assert.Equal(t, "mock", last.Model)
+	assert.Positive(t, last.Tokens)
+	assert.True(t, last.Success)
+}
@@ -0,0 +1,132 @@
+package ai
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// figurePattern matches numbers as slides write them: "42", "1,200",
+// "3.5", "$4.2", "37%", "€ 12".
+var figurePattern = regexp.MustCompile(`(?:[$€£¥]\s?)?\d+(?:[.,]\d+)*(?:\s?%)?`)
+
+// excerptRadius is how many bytes of context a review flag keeps on each
+// side of its figure.
+const excerptRadius = 40
+
+// UnsupportedFigures fact-checks the numbers in bound's text placeholders
+// against content, the text the deck was bound from, and the template's own
+// text. Numbers found in neither are returned as review flags: the model may
+// have made them up. Single digits on their own, like "3 steps", aren't
+// checked, and neither are numbers inside words, like "Q3". Figures match
+// by value, so "1,200" backs up "1200" and "$4.20" backs up "4.2%".
+func UnsupportedFigures(bound, template *spec.TemplateSpec, content string) []store.ReviewFlag {
+	if bound == nil {
+		return nil
+	}
+	known := map[string]bool{}
+	for _, f := range findFigures(content) {
+		known[f.value] = true
+	}
+	if template != nil {
+		for _, l := range template.Layouts {
+			for _, ph := range l.Placeholders {
+				for _, f := range findFigures(ph.Content) {
+					known[f.value] = true
+				}
+			}
+		}
+	}
+
+	var flags []store.ReviewFlag
+	for i, l := range bound.Layouts {
+		for _, ph := range l.Placeholders {
+			if ph.Type != "" && ph.Type != "text" {
+				continue
+			}
+			seen := map[string]bool{}
+			for _, f := range findFigures(ph.Content) {
+				if f.trivial || known[f.value] || seen[f.value] {
+					continue
+				}
+				seen[f.value] = true
+				flags = append(flags, store.ReviewFlag{Slide: i, PlaceholderID: ph.ID, Figure: f.text, Excerpt: excerpt(ph.Content, f.start, f.end)})
+			}
+		}
+	}
+	return flags
+}
+
+type figure struct {
+	text       string
+	value      string
+	start, end int
+	// trivial is a lone digit without a decimal, unit or currency.
+	trivial bool
+}
+
+// findFigures returns the numbers in text that don't sit inside a word.
+func findFigures(text string) []figure {
+	var out []figure
+	for _, loc := range figurePattern.FindAllStringIndex(text, -1) {
+		if before, _ := utf8.DecodeLastRuneInString(text[:loc[0]]); before != utf8.RuneError && (unicode.IsLetter(before) || unicode.IsDigit(before)) {
+			continue
+		}
+		raw := strings.TrimRight(text[loc[0]:loc[1]], ".,")
+		digits := strings.TrimLeft(raw, "$€£¥ ")
+		digits = strings.TrimSpace(strings.TrimSuffix(digits, "%"))
+		out = append(out, figure{
+			text:    raw,
+			value:   figureValue(digits),
+			start:   loc[0],
+			end:     loc[0] + len(raw),
+			trivial: len(digits) == 1 && raw == digits,
+		})
+	}
+	return out
+}
+
+// figureValue canonicalizes a number's digits: thousands separators and
+// leading and trailing zeros go, so "1,200.50" and "1200.5" compare equal.
+func figureValue(digits string) string {
+	v := strings.ReplaceAll(digits, ",", "")
+	if strings.Contains(v, ".") {
+		v = strings.TrimRight(strings.TrimRight(v, "0"), ".")
+	}
+	if v = strings.TrimLeft(v, "0"); v == "" || v[0] == '.' {
+		v = "0" + v
+	}
+	return v
+}
+
+// excerpt returns the text around text[start:end], cut between words and
+// marked with ellipses where it was cut.
+func excerpt(text string, start, end int) string {
+	from, to := max(start-excerptRadius, 0), min(end+excerptRadius, len(text))
+	if from > 0 {
+		if i := strings.IndexFunc(text[from:start], unicode.IsSpace); i >= 0 {
+			from += i
+		} else {
+			from = start
+		}
+	}
+	if to < len(text) {
+		if i := strings.LastIndexFunc(text[end:to], unicode.IsSpace); i >= 0 {
+			to = end + i
+		} else {
+			to = end
+		}
+	}
+	out := strings.Join(strings.Fields(text[from:to]), " ")
+	if from > 0 {
+		out = "…" + out
+	}
+	if to < len(text) {
+		out += "…"
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestUnsupportedFigures(t *testing.T) {
+	template := &spec.TemplateSpec{Layouts: []spec.Layout{{Name: "Cover", Placeholders: []spec.Placeholder{
+		{ID: "footer", Type: "text", Content: "© 2025 Acme"},
+	}}}}
+	bound := &spec.TemplateSpec{Layouts: []spec.Layout{
+		{Name: "Cover", Placeholders: []spec.Placeholder{
+			{ID: "title", Type: "text", Content: "Q3 results: revenue up 12%"},
+			{ID: "footer", Type: "text", Content: "© 2025 Acme"},
+		}},
+		{Name: "Detail", Placeholders: []spec.Placeholder{
+			{ID: "body", Type: "text", Content: "• 1,200 new customers\n• Churn fell to 3.50%, beating the 4.1% target\n• 3 new regions\n• Churn fell to 3.5%"},
+			{ID: "chart", Type: "image", Content: "chart-99.png"},
+		}},
+	}}
+	content := "Revenue grew 12 percent. We added 1200 customers and churn dropped to 3.5%."
+
+	flags := UnsupportedFigures(bound, template, content)
+	assert.Equal(t, []store.ReviewFlag{{
+		Slide:         1,
+		PlaceholderID: "body",
+		Figure:        "4.1%",
+		Excerpt:       "…• Churn fell to 3.50%, beating the 4.1% target • 3 new regions • Churn…",
+	}}, flags)
+
+	assert.Empty(t, UnsupportedFigures(template, template, ""), "the template's own figures are not claims")
+}
+
+func TestFigureValue(t *testing.T) {
+	for in, want := range map[string]string{"1,200": "1200", "3.50": "3.5", "007": "7", "0.25": "0.25", "10.0": "10", "0": "0"} {
+		assert.Equal(t, want, figureValue(in), in)
+	}
+}
@@ -0,0 +1,226 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// maxGlossaryPasses bounds how many times the model is asked to rewrite
+// texts that still use forbidden terms.
+const maxGlossaryPasses = 2
+
+// Glossary is an org's terminology rules.
+type Glossary []store.GlossaryEntry
+
+// glossary returns the org's glossary. Without one, or when it can't be
+// loaded, AI output goes unchecked.
+func (s *AIService) glossary(ctx context.Context, orgID string) Glossary {
+	if s.store == nil {
+		return nil
+	}
+	gs := s.store.Glossary()
+	if gs == nil {
+		return nil
+	}
+	entries, err := gs.List(ctx, orgID)
+	if err != nil {
+		return nil
+	}
+	return entries
+}
+
+// Rules renders the glossary as prompt instructions, one per line.
+func (g Glossary) Rules() string {
+	var b strings.Builder
+	for _, e := range g {
+		switch e.Kind {
+		case store.GlossaryPreferred:
+			fmt.Fprintf(&b, "- Write %q", e.Term)
+			if len(e.Variants) > 0 {
+				quoted := make([]string, len(e.Variants))
+				for i, v := range e.Variants {
+					quoted[i] = fmt.Sprintf("%q", v)
+				}
+				fmt.Fprintf(&b, ", never %s", strings.Join(quoted, " or "))
+			}
+		case store.GlossaryForbidden:
+			fmt.Fprintf(&b, "- Never use %q", e.Term)
+			if e.Replacement != "" {
+				fmt.Fprintf(&b, "; write %q instead", e.Replacement)
+			}
+		case store.GlossaryCasing:
+			fmt.Fprintf(&b, "- Always write %q with exactly this casing", e.Term)
+		default:
+			continue
+		}
+		if e.Note != "" {
+			fmt.Fprintf(&b, " (%s)", e.Note)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Apply corrects text without the model: variants become their preferred
+// term, terms get their casing and forbidden terms with a replacement are
+// replaced. It returns the corrected text and the forbidden terms it still
+// uses.
+func (g Glossary) Apply(text string) (string, []string) {
+	var remaining []string
+	for _, e := range g {
+		switch e.Kind {
+		case store.GlossaryPreferred:
+			for _, v := range e.Variants {
+				text = replaceWord(text, v, e.Term, false)
+			}
+			text = replaceWord(text, e.Term, e.Term, false)
+		case store.GlossaryCasing:
+			text = replaceWord(text, e.Term, e.Term, false)
+		case store.GlossaryForbidden:
+			if e.Replacement != "" {
+				text = replaceWord(text, e.Term, e.Replacement, true)
+			} else if len(wordMatches(text, e.Term)) > 0 {
+				remaining = append(remaining, e.Term)
+			}
+		}
+	}
+	return text, remaining
+}
+
+// wordMatches returns the byte ranges of whole-word matches of term in text,
+// ignoring case.
+func wordMatches(text, term string) [][]int {
+	if strings.TrimSpace(term) == "" {
+		return nil
+	}
+	var out [][]int
+	for _, loc := range regexp.MustCompile("(?i)"+regexp.QuoteMeta(term)).FindAllStringIndex(text, -1) {
+		before, _ := utf8.DecodeLastRuneInString(text[:loc[0]])
+		after, _ := utf8.DecodeRuneInString(text[loc[1]:])
+		if !isWordRune(before) && !isWordRune(after) {
+			out = append(out, loc)
+		}
+	}
+	return out
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// replaceWord replaces whole-word matches of term in text with repl. With
+// sentenceCase, a match that starts with a capital letter gets repl with its
+// first letter capitalized, so "Cheap" becomes "Affordable".
+func replaceWord(text, term, repl string, sentenceCase bool) string {
+	matches := wordMatches(text, term)
+	for i := len(matches) - 1; i >= 0; i-- {
+		r := repl
+		if first, _ := utf8.DecodeRuneInString(text[matches[i][0]:]); sentenceCase && unicode.IsUpper(first) {
+			head, size := utf8.DecodeRuneInString(r)
+			r = string(unicode.ToUpper(head)) + r[size:]
+		}
+		text = text[:matches[i][0]] + r + text[matches[i][1]:]
+	}
+	return text
+}
+
+// enforceGlossary corrects texts in place. Texts that still use forbidden
+// terms after Apply go back to the model for a rewrite, up to
+// maxGlossaryPasses times. It returns the forbidden terms left in the texts.
+func (s *AIService) enforceGlossary(ctx context.Context, orgID, userID string, g Glossary, texts map[string]string) []string {
+	if len(g) == 0 {
+		return nil
+	}
+	for pass := 0; ; pass++ {
+		flagged := map[string]string{}
+		var terms []string
+		for k, t := range texts {
+			fixed, remaining := g.Apply(t)
+			texts[k] = fixed
+			if len(remaining) > 0 {
+				flagged[k] = fixed
+				for _, term := range remaining {
+					if !slices.Contains(terms, term) {
+						terms = append(terms, term)
+					}
+				}
+			}
+		}
+		sort.Strings(terms)
+		if len(flagged) == 0 || pass == maxGlossaryPasses {
+			return terms
+		}
+
+		b, err := json.Marshal(flagged)
+		if err != nil {
+			return terms
+		}
+		prompt := glossaryPrompt(g.Rules(), terms, string(b))
+		answer, err := s.generateJSON(ctx, orgID, userID, CallGlossary, prompt)
+		if err != nil {
+			return terms
+		}
+		s.recordUsage(ctx, orgID, userID, &GenerationResponse{TokenUsage: estimateTokens(prompt, answer)})
+		start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+		var got map[string]string
+		if start < 0 || end <= start || json.Unmarshal([]byte(answer[start:end+1]), &got) != nil {
+			return terms
+		}
+		for k := range flagged {
+			if t, ok := got[k]; ok && strings.TrimSpace(t) != "" {
+				texts[k] = t
+			}
+		}
+	}
+}
+
+// enforceGlossaryOnSpec is enforceGlossary for the text placeholders of a
+// spec.
+func (s *AIService) enforceGlossaryOnSpec(ctx context.Context, orgID, userID string, g Glossary, sp *spec.TemplateSpec) []string {
+	if len(g) == 0 || sp == nil {
+		return nil
+	}
+	texts := map[string]string{}
+	refs := map[string]*string{}
+	for i := range sp.Layouts {
+		for j := range sp.Layouts[i].Placeholders {
+			ph := &sp.Layouts[i].Placeholders[j]
+			if (ph.Type == "" || ph.Type == "text") && ph.Content != "" {
+				k := fmt.Sprintf("%d/%d", i, j)
+				texts[k], refs[k] = ph.Content, &ph.Content
+			}
+		}
+	}
+	remaining := s.enforceGlossary(ctx, orgID, userID, g, texts)
+	for k, t := range texts {
+		*refs[k] = t
+	}
+	return remaining
+}
+
+// glossaryPromptHeader opens every glossary correction prompt; the mock
+// orchestrator recognizes them by it.
+const glossaryPromptHeader = "Rewrite slide texts that break the organization's terminology rules."
+
+// Markers that precede the forbidden terms, one per line, and the texts,
+// which end the prompt.
+const (
+	glossaryTermsMarker = "\nFORBIDDEN_TERMS:\n"
+	glossaryTextsMarker = "\nTEXTS_JSON:\n"
+)
+
+func glossaryPrompt(rules string, terms []string, textsJSON string) string {
+	return glossaryPromptHeader + ` The input is a JSON object of slide texts by key. Rewrite each so it no longer uses the forbidden terms, keeping its meaning, language and bullet markers, and follow every rule:
+` + rules + `Return ONLY a JSON object with the same keys and the rewritten texts as values (no markdown).
+` + glossaryTermsMarker + strings.Join(terms, "\n") + "\n" + glossaryTextsMarker + textsJSON
+}
@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+var testGlossary = Glossary{
+	{Kind: store.GlossaryPreferred, Term: "Acme Cloud", Variants: []string{"AcmeCloud", "the Acme platform"}},
+	{Kind: store.GlossaryCasing, Term: "iPhone"},
+	{Kind: store.GlossaryForbidden, Term: "cheap", Replacement: "affordable"},
+	{Kind: store.GlossaryForbidden, Term: "synergy", Note: "sounds like marketing"},
+}
+
+func TestGlossary_Apply(t *testing.T) {
+	got, remaining := testGlossary.Apply("Try acmecloud on your IPHONE: cheap, fast, Cheapskate-proof")
+	assert.Equal(t, "Try Acme Cloud on your iPhone: affordable, fast, Cheapskate-proof", got)
+	assert.Empty(t, remaining)
+
+	got, _ = testGlossary.Apply("Cheap plans")
+	assert.Equal(t, "Affordable plans", got)
+
+	got, remaining = testGlossary.Apply("Synergy across the Acme platform")
+	assert.Equal(t, "Synergy across Acme Cloud", got)
+	assert.Equal(t, []string{"synergy"}, remaining)
+
+	rules := testGlossary.Rules()
+	assert.Contains(t, rules, `- Write "Acme Cloud", never "AcmeCloud" or "the Acme platform"`)
+	assert.Contains(t, rules, `- Never use "synergy" (sounds like marketing)`)
+}
+
+func TestAIService_EnforcesGlossaryOnOutput(t *testing.T) {
+	st := newMockStore()
+	st.glossary = testGlossary
+	svc := &AIService{orchestrator: NewMockOrchestrator(), store: st}
+
+	// Forbidden terms without a replacement go back to the model.
+	deck := &spec.TemplateSpec{Layouts: []spec.Layout{{Name: "Intro", Placeholders: []spec.Placeholder{
+		{ID: "title", Type: "text", Content: "Synergy with AcmeCloud"},
+		{ID: "logo", Type: "image", Content: "cheap.png"},
+	}}}}
+	remaining := svc.enforceGlossaryOnSpec(context.Background(), "org-1", "user-1", st.glossary, deck)
+	assert.Empty(t, remaining)
+	assert.Equal(t, "with Acme Cloud", deck.Layouts[0].Placeholders[0].Content)
+	assert.Equal(t, "cheap.png", deck.Layouts[0].Placeholders[1].Content, "only text is checked")
+	require.Len(t, st.metering, 1, "the correction pass is metered")
+
+	// A model that won't drop the term gives up after maxGlossaryPasses.
+	st.metering = nil
+	svc.orchestrator = &mockOrchestrator{json: `{"0/0":"more synergy"}`}
+	texts := map[string]string{"0/0": "synergy"}
+	assert.Equal(t, []string{"synergy"}, svc.enforceGlossary(context.Background(), "org-1", "user-1", st.glossary, texts))
+	assert.Len(t, st.metering, maxGlossaryPasses)
+
+	// Transformations are corrected too.
+	svc.orchestrator = NewMockOrchestrator()
+	got, err := svc.TransformTexts(context.Background(), "org-1", "user-1", TransformExpand, "", map[string]string{"0/0": "A cheap iphone"})
+	require.NoError(t, err)
+	assert.Contains(t, got["0/0"], "A affordable iPhone")
+}
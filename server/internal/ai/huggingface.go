@@ -7,19 +7,35 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
 )
 
 type HuggingFaceClient struct {
-	apiKey     string
+	// name is the provider named in errors.
+	name string
+	// local providers run on our own hardware, so their calls cost nothing.
+	local  bool
+	apiKey string
+	// keyEnv names the environment variable apiKey came from; see key.
+	keyEnv     string
 	model      string
 	baseURL    string
 	httpClient *http.Client
+
+	pingMu      sync.Mutex
+	lastPing    time.Time
+	lastPingErr error
 }
 
+// pingCacheTTL bounds how often readiness probes reach the provider.
+const pingCacheTTL = 30 * time.Second
+
 type GenerationRequest struct {
 	Prompt      string                 `json:"prompt"`
 	BrandKitID  string                 `json:"brandKitId,omitempty"`
@@ -27,8 +43,12 @@ type GenerationRequest struct {
 	Language    string                 `json:"language,omitempty"`
 	Tone        string                 `json:"tone,omitempty"`
 	RTL         bool                   `json:"rtl"`
+	SlideSize   string                 `json:"slideSize,omitempty"`
 	Tokens      map[string]any         `json:"tokens,omitempty"`
 	ContentData map[string]interface{} `json:"contentData,omitempty"`
+	// Glossary is the org's terminology rules as rendered by Glossary.Rules.
+	Glossary string                 `json:"glossary,omitempty"`
+	Params   store.GenerationParams `json:"params"`
 }
 
 type GenerationResponse struct {
@@ -37,6 +57,9 @@ type GenerationResponse struct {
 	Cost       float64            `json:"cost"`
 	Model      string             `json:"model"`
 	Timestamp  time.Time          `json:"timestamp"`
+	// GlossaryViolations are the forbidden terms the spec still uses after
+	// the glossary correction passes.
+	GlossaryViolations []string `json:"glossaryViolations,omitempty"`
 }
 
 type chatMessage struct {
@@ -45,9 +68,11 @@ type chatMessage struct {
 }
 
 type hfChatRequest struct {
-	Messages []chatMessage `json:"messages"`
-	Model    string        `json:"model"`
-	Stream   bool          `json:"stream"`
+	Messages    []chatMessage `json:"messages"`
+	Model       string        `json:"model"`
+	Stream      bool          `json:"stream"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	TopP        *float64      `json:"top_p,omitempty"`
 }
 
 type hfChatChoice struct {
@@ -68,6 +93,7 @@ func NewHuggingFaceClient(apiKey, model string) *HuggingFaceClient {
 	}
 
 	return &HuggingFaceClient{
+		name:    "HuggingFace",
 		apiKey:  apiKey,
 		model:   model,
 		baseURL: "https://router.huggingface.co/v1/chat/completions",
@@ -77,6 +103,70 @@ func NewHuggingFaceClient(apiKey, model string) *HuggingFaceClient {
 	}
 }
 
+// Ollama defaults: where a local server listens and the model it serves.
+const (
+	DefaultOllamaURL   = "http://localhost:11434"
+	DefaultOllamaModel = "llama3.1"
+)
+
+// NewOllamaClient returns a client for a local Ollama server at baseURL,
+// through its OpenAI-compatible API. Ollama needs no key.
+func NewOllamaClient(baseURL, model string) *HuggingFaceClient {
+	if baseURL == "" {
+		baseURL = DefaultOllamaURL
+	}
+	if model == "" {
+		model = DefaultOllamaModel
+	}
+	c := NewHuggingFaceClient("", model)
+	c.name, c.local = "Ollama", true
+	c.baseURL = strings.TrimRight(baseURL, "/") + "/v1/chat/completions"
+	return c
+}
+
+// key is the API key for the next request. Clients whose key came from the
+// environment re-read it, so a key rotated through the secrets provider
+// applies without a restart.
+func (c *HuggingFaceClient) key() string {
+	if c.keyEnv != "" {
+		if k := os.Getenv(c.keyEnv); k != "" {
+			return k
+		}
+	}
+	return c.apiKey
+}
+
+// Ping checks that the provider is reachable and accepts our key. Results are
+// cached briefly so frequent readiness probes don't hammer the API.
+func (c *HuggingFaceClient) Ping(ctx context.Context) error {
+	c.pingMu.Lock()
+	defer c.pingMu.Unlock()
+	if !c.lastPing.IsZero() && time.Since(c.lastPing) < pingCacheTTL {
+		return c.lastPingErr
+	}
+
+	modelsURL := strings.TrimSuffix(c.baseURL, "/chat/completions") + "/models"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, modelsURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if k := c.key(); k != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+k)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		err = fmt.Errorf("%s API unreachable: %w", c.name, err)
+	} else {
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			err = fmt.Errorf("%s API returned status %d", c.name, resp.StatusCode)
+		}
+	}
+	c.lastPing, c.lastPingErr = time.Now(), err
+	return err
+}
+
 func (c *HuggingFaceClient) GenerateRaw(ctx context.Context, prompt string) (string, error) {
 	// Build a minimal system prompt emphasizing valid JSON output.
 	hfReq := hfChatRequest{
@@ -97,12 +187,14 @@ func (c *HuggingFaceClient) GenerateRaw(ctx context.Context, prompt string) (str
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if k := c.key(); k != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+k)
+	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("HuggingFace API unreachable: %w", err)
+		return "", &providerError{fmt.Errorf("%s API unreachable: %w", c.name, err)}
 	}
 	defer resp.Body.Close()
 
@@ -111,7 +203,7 @@ func (c *HuggingFaceClient) GenerateRaw(ctx context.Context, prompt string) (str
 		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HuggingFace API error (status %d): %s", resp.StatusCode, string(respBody))
+		return "", &providerError{fmt.Errorf("%s API error (status %d): %s", c.name, resp.StatusCode, string(respBody))}
 	}
 
 	var hfResp hfChatResponse
@@ -141,8 +233,10 @@ func (c *HuggingFaceClient) GenerateTemplateSpec(ctx context.Context, req Genera
 				Content: req.Prompt,
 			},
 		},
-		Model:  c.model,
-		Stream: false,
+		Model:       c.model,
+		Stream:      false,
+		Temperature: req.Params.Temperature,
+		TopP:        req.Params.TopP,
 	}
 
 	// Marshal request
@@ -158,13 +252,15 @@ func (c *HuggingFaceClient) GenerateTemplateSpec(ctx context.Context, req Genera
 	}
 
 	// Set headers
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if k := c.key(); k != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+k)
+	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	// Make request to HuggingFace API
+	// Make request to the provider
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("HuggingFace API unreachable: %w", err)
+		return nil, &providerError{fmt.Errorf("%s API unreachable: %w", c.name, err)}
 	}
 	defer resp.Body.Close()
 
@@ -175,7 +271,7 @@ func (c *HuggingFaceClient) GenerateTemplateSpec(ctx context.Context, req Genera
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HuggingFace API error (status %d): %s", resp.StatusCode, string(respBody))
+		return nil, &providerError{fmt.Errorf("%s API error (status %d): %s", c.name, resp.StatusCode, string(respBody))}
 	}
 
 	// Parse HuggingFace response
@@ -273,12 +369,21 @@ Rules:
 	if req.Tone != "" {
 		prompt += fmt.Sprintf("\n- Use a %s tone", req.Tone)
 	}
+	if req.SlideSize != "" {
+		prompt += fmt.Sprintf("\n- Design the layouts for %s slides", req.SlideSize)
+	}
 	if req.RTL {
 		prompt += "\n- This is for RTL (right-to-left) layout - mirror horizontal positions"
 	}
+	if req.Params.MaxSlides != nil {
+		prompt += fmt.Sprintf("\n- Include at most %d layouts", *req.Params.MaxSlides)
+	}
 	if req.BrandKit != nil {
 		prompt += "\n- Incorporate the provided brand kit colors and tokens"
 	}
+	if req.Glossary != "" {
+		prompt += "\n- Follow the organization's terminology rules:\n" + req.Glossary
+	}
 	if len(req.ContentData) > 0 {
 		prompt += "\n- Use the following content data to populate placeholders:\n"
 		for key, value := range req.ContentData {
@@ -404,6 +509,9 @@ func (c *HuggingFaceClient) estimateTokenUsage(prompt, response string) int {
 }
 
 func (c *HuggingFaceClient) calculateCost(tokens int) float64 {
+	if c.local {
+		return 0
+	}
 	// Mixtral pricing: ~$0.50 per 1M input tokens, $1.50 per 1M output tokens
 	// Assuming 30% input, 70% output
 	inputTokens := int(float64(tokens) * 0.3)
@@ -155,8 +155,8 @@ func TestHuggingFaceClient_CalculateCost(t *testing.T) {
 	assert.InDelta(t, expected, cost, 0.000001)
 }
 
-func TestOrchestrator_NewOrchestrator(t *testing.T) {
-	orch := NewOrchestrator()
+func TestOrchestrator_NewOrchestratorWithOptions(t *testing.T) {
+	orch := NewOrchestratorWithOptions(Options{})
 
 	assert.NotNil(t, orch)
 }
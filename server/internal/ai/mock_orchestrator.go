@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/ziyad/cms-ai/server/internal/spec"
 )
@@ -39,6 +42,21 @@ func (m *MockOrchestrator) GenerateTemplateSpec(ctx context.Context, req Generat
 		}, nil
 	}
 
+	if strings.HasPrefix(req.Prompt, refinePromptHeader) {
+		refined, err := m.mockRefinement(req.Prompt)
+		if err != nil {
+			return nil, err
+		}
+		return &GenerationResponse{Spec: refined, TokenUsage: 100, Model: "mock", Timestamp: time.Now()}, nil
+	}
+	if strings.HasPrefix(req.Prompt, slidePromptHeader) {
+		slide, err := m.mockSlide(req.Prompt)
+		if err != nil {
+			return nil, err
+		}
+		return &GenerationResponse{Spec: slide, TokenUsage: 20, Model: "mock", Timestamp: time.Now()}, nil
+	}
+
 	// Generate appropriate mock based on content analysis
 	templateSpec := m.generateMockSpec(req)
 
@@ -68,6 +86,9 @@ func (m *MockOrchestrator) generateMockSpec(req GenerationRequest) *spec.Templat
 
 	// Create layouts based on content
 	layouts := m.generateLayouts(req, companyName, industry)
+	if max := req.Params.MaxSlides; max != nil && *max > 0 && len(layouts) > *max {
+		layouts = layouts[:*max]
+	}
 
 	return &spec.TemplateSpec{
 		Tokens: map[string]interface{}{
@@ -459,8 +480,64 @@ func (m *MockOrchestrator) ClearCustomResponses() {
 	m.CustomResponses = make(map[string]*spec.TemplateSpec)
 }
 
+// ModelName is the model the mock's answers report.
+func (m *MockOrchestrator) ModelName() string {
+	return "mock"
+}
+
 // GenerateJSON generates raw JSON for testing
 func (m *MockOrchestrator) GenerateJSON(ctx context.Context, prompt string) (string, error) {
+	if strings.HasPrefix(prompt, analysisPromptHeader) {
+		_, userPrompt, _ := strings.Cut(prompt, analysisPromptMarker)
+		data, err := json.Marshal(m.mockAnalysis(userPrompt))
+		return string(data), err
+	}
+	if strings.HasPrefix(prompt, transformPromptHeader) {
+		rest, textsJSON, _ := strings.Cut(prompt, transformTextsMarker)
+		_, operation, _ := strings.Cut(rest, transformOperationMarker)
+		var texts map[string]string
+		if err := json.Unmarshal([]byte(textsJSON), &texts); err != nil {
+			return "", fmt.Errorf("mock transform: %w", err)
+		}
+		for k, v := range texts {
+			texts[k] = mockTransform(strings.TrimSpace(operation), v)
+		}
+		data, err := json.Marshal(texts)
+		return string(data), err
+	}
+	if strings.HasPrefix(prompt, altTextPromptHeader) {
+		_, imagesJSON, _ := strings.Cut(prompt, altTextImagesMarker)
+		var images map[string]string
+		if err := json.Unmarshal([]byte(imagesJSON), &images); err != nil {
+			return "", fmt.Errorf("mock alt text: %w", err)
+		}
+		// Each image is described by the start of its slide's text.
+		for k, v := range images {
+			slide, _, _ := strings.Cut(strings.TrimPrefix(v, "slide text: "), ";")
+			slide, _, _ = strings.Cut(slide, " / ")
+			images[k] = "Illustration for " + strings.TrimSpace(slide)
+		}
+		data, err := json.Marshal(images)
+		return string(data), err
+	}
+	if strings.HasPrefix(prompt, glossaryPromptHeader) {
+		rest, textsJSON, _ := strings.Cut(prompt, glossaryTextsMarker)
+		_, terms, _ := strings.Cut(rest, glossaryTermsMarker)
+		var texts map[string]string
+		if err := json.Unmarshal([]byte(textsJSON), &texts); err != nil {
+			return "", fmt.Errorf("mock glossary: %w", err)
+		}
+		// Forbidden terms are dropped, which is enough to satisfy the check.
+		for k, v := range texts {
+			for _, term := range strings.Split(strings.TrimSpace(terms), "\n") {
+				v = replaceWord(v, term, "", false)
+			}
+			texts[k] = strings.Join(strings.Fields(v), " ")
+		}
+		data, err := json.Marshal(texts)
+		return string(data), err
+	}
+
 	// Generate a simple JSON response
 	mockJSON := map[string]interface{}{
 		"generated": true,
@@ -477,6 +554,108 @@ func (m *MockOrchestrator) GenerateJSON(ctx context.Context, prompt string) (str
 	return string(data), nil
 }
 
+// mockTransform rewrites text recognizably for operation: shortened text
+// keeps its first half of words, and the others are marked with what was
+// done.
+func mockTransform(operation, text string) string {
+	switch operation {
+	case TransformShorten:
+		words := strings.Fields(text)
+		return strings.Join(words[:(len(words)+1)/2], " ")
+	case TransformExpand:
+		return text + " (expanded with more detail)"
+	case TransformTranslate:
+		return "[translated] " + text
+	}
+	return "[" + operation + "] " + text
+}
+
+// mockAnalysis describes prompt from its industry keywords, in Arabic when
+// it is written in Arabic script and English otherwise.
+func (m *MockOrchestrator) mockAnalysis(prompt string) PromptAnalysis {
+	industry := m.detectIndustry(strings.ToLower(prompt), nil)
+	a := PromptAnalysis{
+		TemplateType:    strings.ToLower(industry) + "-presentation",
+		SuggestedName:   industry + " Presentation",
+		Description:     fmt.Sprintf("Mock %s presentation", industry),
+		Language:        "en",
+		EstimatedSlides: min(max(len(strings.Fields(prompt))/3, 4), 12),
+		RequiredFields: []AnalysisField{
+			{Key: "title", Label: "Title", Type: "text", Required: true, Example: "Quarterly Update"},
+			{Key: "audience", Label: "Audience", Type: "text", Example: "Leadership team"},
+			{Key: "keyPoints", Label: "Key Points", Type: "list", Required: true, Example: "Goals, Results, Next steps"},
+		},
+	}
+	if strings.ContainsFunc(prompt, func(r rune) bool { return unicode.Is(unicode.Arabic, r) }) {
+		a.SuggestedName, a.Language = "عرض تقديمي", "ar"
+		a.RequiredFields[0].Label, a.RequiredFields[1].Label, a.RequiredFields[2].Label = "العنوان", "الجمهور", "النقاط الرئيسية"
+	}
+	switch industry {
+	case "Finance":
+		a.RequiredFields = append(a.RequiredFields, AnalysisField{Key: "revenue", Label: "Revenue", Type: "currency", Example: "$2.5M"})
+	case "Healthcare":
+		a.RequiredFields = append(a.RequiredFields, AnalysisField{Key: "patientOutcomes", Label: "Patient Outcomes", Type: "percentage", Example: "92%"})
+	case "Technology":
+		a.RequiredFields = append(a.RequiredFields, AnalysisField{Key: "launchDate", Label: "Launch Date", Type: "date", Example: "2025-03-01"})
+	}
+	return a
+}
+
+var (
+	mockAddSlide    = regexp.MustCompile(`(?i)\badd (?:an? )?(.+?) slide\b`)
+	mockRemoveSlide = regexp.MustCompile(`(?i)\b(?:remove|delete) slide (\d+)\b`)
+)
+
+// mockRefinement applies the instructions the mock understands to the deck
+// in a refinement prompt: "add a <topic> slide" appends a slide and "remove
+// slide N" drops one. Other instructions leave the deck as it was.
+func (m *MockOrchestrator) mockRefinement(prompt string) (*spec.TemplateSpec, error) {
+	rest, specJSON, _ := strings.Cut(prompt, refineSpecMarker)
+	_, instruction, _ := strings.Cut(rest, refineInstructionMarker)
+	var deck spec.TemplateSpec
+	if err := json.Unmarshal([]byte(specJSON), &deck); err != nil {
+		return nil, fmt.Errorf("mock refinement: %w", err)
+	}
+	if match := mockAddSlide.FindStringSubmatch(instruction); match != nil {
+		topic := strings.TrimSpace(match[1])
+		deck.Layouts = append(deck.Layouts, spec.Layout{
+			Name: fmt.Sprintf("%s %d", topic, len(deck.Layouts)+1),
+			Placeholders: []spec.Placeholder{
+				{ID: "slide_title", Type: "text", Content: strings.ToUpper(topic[:1]) + topic[1:], Geometry: spec.Geometry{X: 0.1, Y: 0.1, W: 0.8, H: 0.1}},
+				{ID: "content", Type: "text", Content: "• Key point", Geometry: spec.Geometry{X: 0.1, Y: 0.25, W: 0.8, H: 0.5}},
+			},
+		})
+	} else if match := mockRemoveSlide.FindStringSubmatch(instruction); match != nil {
+		if n, _ := strconv.Atoi(match[1]); n >= 1 && n <= len(deck.Layouts) && len(deck.Layouts) > 1 {
+			deck.Layouts = append(deck.Layouts[:n-1], deck.Layouts[n:]...)
+		}
+	}
+	return &deck, nil
+}
+
+// mockSlide rewrites the body of the slide in a slide prompt: every text
+// placeholder but the title says "Revised", with the guidance if any.
+func (m *MockOrchestrator) mockSlide(prompt string) (*spec.TemplateSpec, error) {
+	rest, slideJSON, _ := strings.Cut(prompt, slideSpecMarker)
+	_, guidance, _ := strings.Cut(rest, slideGuidanceMarker)
+	var slide spec.TemplateSpec
+	if err := json.Unmarshal([]byte(slideJSON), &slide); err != nil {
+		return nil, fmt.Errorf("mock slide: %w", err)
+	}
+	body := "• Revised"
+	if guidance = strings.TrimSpace(guidance); guidance != "" {
+		body += ": " + guidance
+	}
+	for _, l := range slide.Layouts {
+		for i, ph := range l.Placeholders {
+			if ph.Type == "text" && !strings.Contains(ph.ID, "title") {
+				l.Placeholders[i].Content = body
+			}
+		}
+	}
+	return &slide, nil
+}
+
 // RepairTemplateSpec attempts to repair an invalid template spec
 func (m *MockOrchestrator) RepairTemplateSpec(ctx context.Context, invalidSpec *spec.TemplateSpec, errors []spec.ValidationError) (*spec.TemplateSpec, error) {
 	// For mock, just return a valid spec
@@ -2,7 +2,6 @@ package ai
 
 import (
 	"context"
-	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,11 +9,7 @@ import (
 )
 
 func TestMockOrchestrator(t *testing.T) {
-	// Enable mock mode
-	os.Setenv("USE_MOCK_AI", "true")
-	defer os.Unsetenv("USE_MOCK_AI")
-
-	orchestrator := NewOrchestrator()
+	orchestrator := NewOrchestratorWithOptions(Options{Mock: true})
 
 	t.Run("Healthcare_Detection", func(t *testing.T) {
 		req := GenerationRequest{
@@ -133,12 +128,8 @@ func TestMockOrchestrator(t *testing.T) {
 }
 
 func TestMockFallback(t *testing.T) {
-	// Clear environment to test fallback behavior
-	os.Unsetenv("USE_MOCK_AI")
-	os.Unsetenv("HUGGINGFACE_API_KEY")
-
 	// Without API key, should fall back to mock
-	orchestrator := NewOrchestrator()
+	orchestrator := NewOrchestratorWithOptions(Options{})
 
 	req := GenerationRequest{
 		Prompt: "Test presentation",
@@ -155,10 +146,7 @@ func TestMockFallback(t *testing.T) {
 }
 
 func TestMockConsistency(t *testing.T) {
-	os.Setenv("USE_MOCK_AI", "true")
-	defer os.Unsetenv("USE_MOCK_AI")
-
-	orchestrator := NewOrchestrator()
+	orchestrator := NewOrchestratorWithOptions(Options{Mock: true})
 
 	// Same input should produce consistent industry detection
 	req := GenerationRequest{
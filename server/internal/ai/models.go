@@ -0,0 +1,53 @@
+package ai
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Providers a model chain may name.
+const (
+	// ProviderHuggingFace serves models through the Hugging Face router.
+	ProviderHuggingFace = "huggingface"
+	// ProviderOllama serves models from a local Ollama server.
+	ProviderOllama = "ollama"
+)
+
+// Providers lists the providers a model chain may name.
+var Providers = []string{ProviderHuggingFace, ProviderOllama}
+
+// ModelRef is one link of a model chain: a model and its provider.
+type ModelRef struct {
+	Provider string
+	Model    string
+}
+
+func (m ModelRef) String() string {
+	return m.Provider + ":" + m.Model
+}
+
+// ParseModelRef parses "provider:model". A bare model is served by Hugging
+// Face, so "meta-llama/Llama-3.1-8B-Instruct" and routed Hugging Face models
+// like "org/model:groq" parse too: a provider never contains a slash.
+func ParseModelRef(s string) (ModelRef, error) {
+	ref := ModelRef{Provider: ProviderHuggingFace, Model: strings.TrimSpace(s)}
+	if provider, model, ok := strings.Cut(ref.Model, ":"); ok && !strings.Contains(provider, "/") {
+		ref = ModelRef{Provider: strings.ToLower(strings.TrimSpace(provider)), Model: strings.TrimSpace(model)}
+	}
+	if !slices.Contains(Providers, ref.Provider) {
+		return ModelRef{}, fmt.Errorf("unknown provider %q in %q, want one of %s", ref.Provider, s, strings.Join(Providers, ", "))
+	}
+	if ref.Model == "" {
+		return ModelRef{}, fmt.Errorf("%q names no model", s)
+	}
+	return ref, nil
+}
+
+// chain returns the orchestrators that serve kind, in the order to try them.
+func (s *AIService) chain(kind string) []Orchestrator {
+	if c := s.chains[kind]; len(c) > 0 {
+		return c
+	}
+	return []Orchestrator{s.orchestrator}
+}
@@ -0,0 +1,86 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestParseModelRef(t *testing.T) {
+	for in, want := range map[string]ModelRef{
+		"meta-llama/Llama-3.1-8B-Instruct":             {Provider: ProviderHuggingFace, Model: "meta-llama/Llama-3.1-8B-Instruct"},
+		" HuggingFace : moonshotai/Kimi-K2 ":           {Provider: ProviderHuggingFace, Model: "moonshotai/Kimi-K2"},
+		"moonshotai/Kimi-K2-Instruct-0905:groq":        {Provider: ProviderHuggingFace, Model: "moonshotai/Kimi-K2-Instruct-0905:groq"},
+		"huggingface:moonshotai/Kimi-K2-Instruct:groq": {Provider: ProviderHuggingFace, Model: "moonshotai/Kimi-K2-Instruct:groq"},
+		"ollama:llama3.1:8b":                           {Provider: ProviderOllama, Model: "llama3.1:8b"},
+	} {
+		got, err := ParseModelRef(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"openai:gpt-4o", "huggingface:", ""} {
+		_, err := ParseModelRef(in)
+		assert.Error(t, err, in)
+	}
+}
+
+// namedOrchestrator is a mockOrchestrator that reports a model name.
+type namedOrchestrator struct {
+	mockOrchestrator
+	name string
+}
+
+func (n *namedOrchestrator) ModelName() string { return n.name }
+
+func TestAIService_WalksModelChain(t *testing.T) {
+	st := newMockStore()
+	limited := &namedOrchestrator{mockOrchestrator{err: errors.New("HuggingFace API error (status 429): rate limited")}, "big"}
+	small := &namedOrchestrator{mockOrchestrator{response: &GenerationResponse{Spec: &spec.TemplateSpec{Layouts: []spec.Layout{{Name: "Title"}}}, Model: "small"}, json: `{"0/0":"Short"}`}, "small"}
+	svc := &AIService{
+		orchestrator: &namedOrchestrator{mockOrchestrator{err: errors.New("unused")}, "default"},
+		chains:       map[string][]Orchestrator{CallGenerate: {limited, small}, CallTransform: {limited, small}},
+		store:        st,
+	}
+	ctx := context.Background()
+
+	_, resp, err := svc.GenerateTemplateForRequest(ctx, "org-1", "user-1", GenerationRequest{Prompt: "Roadmap"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "small", resp.Model, "the response names the model that served it")
+	require.Len(t, st.calls, 2)
+	assert.Equal(t, "big", st.calls[0].Model)
+	assert.Equal(t, 1, st.calls[0].Attempt)
+	assert.False(t, st.calls[0].Success)
+	assert.Contains(t, st.calls[0].Error, "429")
+	assert.Equal(t, "small", st.calls[1].Model)
+	assert.Equal(t, 2, st.calls[1].Attempt)
+	assert.True(t, st.calls[1].Success)
+	assert.Equal(t, st.calls[0].RequestHash, st.calls[1].RequestHash)
+
+	got, err := svc.TransformTexts(ctx, "org-1", "user-1", TransformShorten, "", map[string]string{"0/0": "Quarterly results"})
+	require.NoError(t, err)
+	assert.Equal(t, "Short", got["0/0"])
+	assert.Equal(t, "small", st.calls[len(st.calls)-1].Model)
+
+	// Kinds without a chain use the default model; a chain that fails
+	// throughout returns the last error.
+	_, _, err = svc.RefineDeckSpec(ctx, "org-1", "user-1", &spec.TemplateSpec{}, "Shorter", nil, store.GenerationParams{})
+	assert.ErrorContains(t, err, "unused")
+	assert.Equal(t, "default", st.calls[len(st.calls)-1].Model)
+
+	small.err = errors.New("down too")
+	_, _, err = svc.GenerateTemplateForRequest(ctx, "org-1", "user-1", GenerationRequest{Prompt: "Roadmap"}, "")
+	assert.ErrorContains(t, err, "down too")
+
+	// A cancelled request doesn't move on to the next model.
+	small.err = nil
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	before := len(st.calls)
+	_, _, _ = svc.GenerateTemplateForRequest(cancelled, "org-1", "user-1", GenerationRequest{Prompt: "Roadmap"}, "")
+	assert.Len(t, st.calls, before+1)
+}
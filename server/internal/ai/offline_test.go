@@ -0,0 +1,56 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOfflineOptions_CallOllama(t *testing.T) {
+	var models []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"), "Ollama needs no key")
+		var req hfChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		models = append(models, req.Model)
+		json.NewEncoder(w).Encode(hfChatResponse{Choices: []hfChatChoice{{Message: chatMessage{Role: "assistant", Content: `{"ok":true}`}}}})
+	}))
+	defer srv.Close()
+
+	svc := NewAIServiceWithOptions(newMockStore(), Options{
+		APIKey:    "hf-key",
+		Offline:   true,
+		OllamaURL: srv.URL + "/",
+		Chains:    map[string][]ModelRef{CallTransform: {{Provider: ProviderOllama, Model: "qwen2.5:14b"}}},
+	})
+	ctx := context.Background()
+
+	answer, err := svc.generateJSON(ctx, "org-1", "", CallAnalyze, "prompt")
+	require.NoError(t, err)
+	assert.Equal(t, `{"ok":true}`, answer)
+	_, err = svc.generateJSON(ctx, "org-1", "", CallTransform, "prompt")
+	require.NoError(t, err)
+	assert.Equal(t, []string{DefaultOllamaModel, "qwen2.5:14b"}, models)
+
+	def := svc.orchestrator.(*orchestrator)
+	assert.Same(t, def.breaker, svc.chains[CallTransform][0].(*orchestrator).breaker, "Ollama models share one breaker")
+	assert.Zero(t, def.client.calculateCost(1000), "local calls cost nothing")
+}
+
+func TestOllamaClient_NamesProviderInErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	_, err := NewOllamaClient(srv.URL, "missing").GenerateRaw(context.Background(), "prompt")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Ollama API error (status 404)")
+	assert.True(t, providerFailed(err))
+}
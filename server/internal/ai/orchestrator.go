@@ -3,8 +3,9 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"math"
 	"time"
 
 	"github.com/ziyad/cms-ai/server/internal/spec"
@@ -19,35 +20,94 @@ type Orchestrator interface {
 
 type orchestrator struct {
 	client *HuggingFaceClient
+	// breaker guards the calls to the client's provider; every model the
+	// provider serves shares it.
+	breaker *Breaker
+	// noSafetyNet makes failed generations errors instead of static specs,
+	// so a fallback chain can move on to its next model.
+	noSafetyNet bool
 }
 
-func NewOrchestrator() Orchestrator {
+// Options configures the orchestrator.
+type Options struct {
+	// Mock answers with canned responses instead of calling Hugging Face.
+	Mock   bool
+	APIKey string
+	// Model defaults to moonshotai/Kimi-K2-Instruct-0905.
+	Model string
+	// Chains gives call kinds, like CallBind, their own models, tried in
+	// order until one answers. Kinds without a chain use Model. Chains are
+	// ignored when mocking.
+	Chains map[string][]ModelRef
+	// Breaker tunes the circuit breaker around provider calls.
+	Breaker BreakerConfig
+	// Offline sends every call to the Ollama server at OllamaURL (default
+	// DefaultOllamaURL) instead of Hugging Face: the default model is
+	// OllamaModel (default DefaultOllamaModel) and APIKey is ignored. Chains may then only name Ollama
+	// models.
+	Offline     bool
+	OllamaURL   string
+	OllamaModel string
+}
+
+func NewOrchestratorWithOptions(opts Options) Orchestrator {
 	// Check if we should use mock mode
-	if os.Getenv("USE_MOCK_AI") == "true" {
+	if opts.Mock {
 		return NewMockOrchestrator()
 	}
 
-	apiKey := os.Getenv("HUGGINGFACE_API_KEY")
-	model := os.Getenv("HUGGINGFACE_MODEL")
+	model := opts.Model
 	if model == "" {
 		model = "moonshotai/Kimi-K2-Instruct-0905"
 	}
 
+	if opts.Offline {
+		return &orchestrator{client: NewOllamaClient(opts.OllamaURL, opts.OllamaModel), breaker: NewBreaker(ProviderOllama, opts.Breaker)}
+	}
+
 	// If no API key, use mock mode to avoid costs
-	if apiKey == "" {
+	if opts.APIKey == "" {
 		return NewMockOrchestrator()
 	}
 
-	return &orchestrator{
-		client: NewHuggingFaceClient(apiKey, model),
+	client := NewHuggingFaceClient(opts.APIKey, model)
+	client.keyEnv = "HUGGINGFACE_API_KEY"
+	return &orchestrator{client: client, breaker: NewBreaker(ProviderHuggingFace, opts.Breaker)}
+}
+
+// newChains builds the orchestrators of opts.Chains. Models of a provider
+// share its breaker from breakers, which gains the ones it lacks. Only a
+// chain's last model falls back to the static safety net.
+func newChains(opts Options, breakers map[string]*Breaker) map[string][]Orchestrator {
+	if opts.Mock || opts.APIKey == "" && !opts.Offline || len(opts.Chains) == 0 {
+		return nil
 	}
+	chains := make(map[string][]Orchestrator, len(opts.Chains))
+	for kind, refs := range opts.Chains {
+		for i, ref := range refs {
+			var client *HuggingFaceClient
+			switch ref.Provider {
+			case ProviderOllama:
+				client = NewOllamaClient(opts.OllamaURL, ref.Model)
+			default:
+				client = NewHuggingFaceClient(opts.APIKey, ref.Model)
+				client.keyEnv = "HUGGINGFACE_API_KEY"
+			}
+			if breakers[ref.Provider] == nil {
+				breakers[ref.Provider] = NewBreaker(ref.Provider, opts.Breaker)
+			}
+			chains[kind] = append(chains[kind], &orchestrator{client: client, breaker: breakers[ref.Provider], noSafetyNet: i < len(refs)-1})
+		}
+	}
+	return chains
 }
 
 func (o *orchestrator) GenerateTemplateSpec(ctx context.Context, req GenerationRequest) (*GenerationResponse, error) {
 	// 1. Primary AI Attempt
-	resp, err := o.client.GenerateTemplateSpec(ctx, req)
-	if err == nil {
-		return resp, nil
+	resp, err := o.generate(ctx, req)
+	// An open circuit fails fast, so callers can tell the provider is down.
+	if err == nil || o.noSafetyNet || errors.Is(err, ErrProviderUnavailable) {
+		return resp, err
 	}
 
 	// 2. Guaranteed Static Fallback (No AI)
@@ -55,6 +115,10 @@ func (o *orchestrator) GenerateTemplateSpec(ctx context.Context, req GenerationR
 	return o.generateStaticSafetyNet(req), nil
 }
 
+// staticFallbackModel is the model reported for the static safety net's
+// answers.
+const staticFallbackModel = "static-fallback"
+
 func (o *orchestrator) generateStaticSafetyNet(req GenerationRequest) *GenerationResponse {
 	title := "New Presentation"
 	if req.Prompt != "" {
@@ -97,13 +161,39 @@ func (o *orchestrator) generateStaticSafetyNet(req GenerationRequest) *Generatio
 		Spec:       spec,
 		TokenUsage: 0,
 		Cost:       0,
-		Model:      "static-fallback",
+		Model:      staticFallbackModel,
 		Timestamp:  time.Now(),
 	}
 }
 
+// ModelName is the model the orchestrator calls.
+func (o *orchestrator) ModelName() string {
+	return o.client.model
+}
+
+func (o *orchestrator) HealthCheck(ctx context.Context) error {
+	return o.client.Ping(ctx)
+}
+
 func (o *orchestrator) GenerateJSON(ctx context.Context, prompt string) (string, error) {
-	return o.client.GenerateRaw(ctx, prompt)
+	var answer string
+	err := o.breaker.Do(ctx, func(ctx context.Context) error {
+		var err error
+		answer, err = o.client.GenerateRaw(ctx, prompt)
+		return err
+	})
+	return answer, err
+}
+
+// generate asks the client for a spec through the breaker.
+func (o *orchestrator) generate(ctx context.Context, req GenerationRequest) (*GenerationResponse, error) {
+	var resp *GenerationResponse
+	err := o.breaker.Do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = o.client.GenerateTemplateSpec(ctx, req)
+		return err
+	})
+	return resp, err
 }
 
 func (o *orchestrator) RepairTemplateSpec(ctx context.Context, invalidSpec *spec.TemplateSpec, errors []spec.ValidationError) (*spec.TemplateSpec, error) {
@@ -115,7 +205,7 @@ func (o *orchestrator) RepairTemplateSpec(ctx context.Context, invalidSpec *spec
 		RTL:    false,
 	}
 
-	resp, err := o.client.GenerateTemplateSpec(ctx, repairReq)
+	resp, err := o.generate(ctx, repairReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to repair template spec: %w", err)
 	}
@@ -144,18 +234,32 @@ func (o *orchestrator) buildRepairPrompt(invalidSpec *spec.TemplateSpec, errors
 // AIServiceInterface defines the interface for AI template generation
 type AIServiceInterface interface {
 	GenerateTemplateForRequest(ctx context.Context, orgID, userID string, req GenerationRequest, brandKitID string) (*spec.TemplateSpec, *GenerationResponse, error)
-	BindDeckSpec(ctx context.Context, orgID, userID string, templateSpec *spec.TemplateSpec, content string) (*spec.TemplateSpec, *GenerationResponse, error)
+	BindDeckSpec(ctx context.Context, orgID, userID string, templateSpec *spec.TemplateSpec, content string, params store.GenerationParams) (*spec.TemplateSpec, *GenerationResponse, error)
 }
 
 // AIService handles AI generation for templates
 type AIService struct {
 	orchestrator Orchestrator
-	store        store.Store
+	// chains are the models configured per call kind; see Options.Chains.
+	chains map[string][]Orchestrator
+	store  store.Store
 }
 
+// NewAIService returns a service that answers with the mock orchestrator.
 func NewAIService(store store.Store) *AIService {
+	return NewAIServiceWithOptions(store, Options{Mock: true})
+}
+
+// NewAIServiceWithOptions is NewAIService with explicit orchestrator options.
+func NewAIServiceWithOptions(store store.Store, opts Options) *AIService {
+	o := NewOrchestratorWithOptions(opts)
+	breakers := map[string]*Breaker{}
+	if def, ok := o.(*orchestrator); ok {
+		breakers[def.breaker.provider] = def.breaker
+	}
 	return &AIService{
-		orchestrator: NewOrchestrator(),
+		orchestrator: o,
+		chains:       newChains(opts, breakers),
 		store:        store,
 	}
 }
@@ -177,13 +281,23 @@ func (s *AIService) GenerateTemplateForRequest(ctx context.Context, orgID, userI
 		}
 	}
 
+	g := s.glossary(ctx, orgID)
+	req.Glossary = g.Rules()
+
 	// Generate the template spec
-	resp, err := s.orchestrator.GenerateTemplateSpec(ctx, req)
+	resp, err := s.generateSpec(ctx, orgID, userID, CallGenerate, req)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to generate template spec: %w", err)
 	}
 
-	// Record token usage
+	s.recordUsage(ctx, orgID, userID, resp)
+	resp.GlossaryViolations = s.enforceGlossaryOnSpec(ctx, orgID, userID, g, resp.Spec)
+	return resp.Spec, resp, nil
+}
+
+// recordUsage meters a generation's tokens and, when the provider charges
+// for it, its cost.
+func (s *AIService) recordUsage(ctx context.Context, orgID, userID string, resp *GenerationResponse) {
 	meteringEvent := store.MeteringEvent{
 		ID:       newID("met"),
 		OrgID:    orgID,
@@ -192,23 +306,37 @@ func (s *AIService) GenerateTemplateForRequest(ctx context.Context, orgID, userI
 		Quantity: resp.TokenUsage,
 	}
 	_, _ = s.store.Metering().Record(ctx, meteringEvent)
+	if micros := int(math.Round(resp.Cost * 1e6)); micros > 0 {
+		_, _ = s.store.Metering().Record(ctx, store.MeteringEvent{ID: newID("met"), OrgID: orgID, UserID: userID, Type: store.MeterAICost, Quantity: micros})
+	}
+}
 
-	return resp.Spec, resp, nil
+// HealthCheck reports whether the configured AI provider is reachable. The
+// mock orchestrator has no external dependency and is always healthy.
+func (s *AIService) HealthCheck(ctx context.Context) error {
+	if hc, ok := s.orchestrator.(interface{ HealthCheck(context.Context) error }); ok {
+		return hc.HealthCheck(ctx)
+	}
+	return nil
 }
 
-func (s *AIService) BindDeckSpec(ctx context.Context, orgID, userID string, templateSpec *spec.TemplateSpec, content string) (*spec.TemplateSpec, *GenerationResponse, error) {
+func (s *AIService) BindDeckSpec(ctx context.Context, orgID, userID string, templateSpec *spec.TemplateSpec, content string, params store.GenerationParams) (*spec.TemplateSpec, *GenerationResponse, error) {
 	b, err := json.Marshal(templateSpec)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to marshal template spec: %w", err)
 	}
 
+	g := s.glossary(ctx, orgID)
 	bindReq := GenerationRequest{
-		Prompt: fmt.Sprintf("Bind the following content into the provided TemplateSpec by filling placeholders.content. Do not change geometry or placeholder IDs. Return ONLY valid JSON TemplateSpec.\n\nCONTENT:\n%s\n\nTEMPLATE_SPEC_JSON:\n%s", content, string(b)),
-		RTL:    false,
+		Prompt:   fmt.Sprintf("Bind the following content into the provided TemplateSpec by filling placeholders.content. Do not change geometry or placeholder IDs. Return ONLY valid JSON TemplateSpec.\n\nCONTENT:\n%s\n\nTEMPLATE_SPEC_JSON:\n%s", content, string(b)),
+		RTL:      false,
+		Glossary: g.Rules(),
+		Params:   params,
 	}
 
-	resp, err := s.orchestrator.GenerateTemplateSpec(ctx, bindReq)
+	resp, err := s.generateSpec(ctx, orgID, userID, CallBind, bindReq)
 	if err == nil {
+		resp.GlossaryViolations = s.enforceGlossaryOnSpec(ctx, orgID, userID, g, resp.Spec)
 		return resp.Spec, resp, nil
 	}
 
@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// DeckRefiner revises deck specs from natural-language instructions;
+// AIService implements it.
+type DeckRefiner interface {
+	// RefineDeckSpec applies instruction to current. history holds the
+	// deck's earlier instructions, oldest first, which are already reflected
+	// in current.
+	RefineDeckSpec(ctx context.Context, orgID, userID string, current *spec.TemplateSpec, instruction string, history []string, params store.GenerationParams) (*spec.TemplateSpec, *GenerationResponse, error)
+}
+
+// RefineDeckSpec asks the model to revise current. Unlike binding, there is
+// no fallback: an instruction the model can't apply is an error, so the
+// caller doesn't save an unchanged version.
+func (s *AIService) RefineDeckSpec(ctx context.Context, orgID, userID string, current *spec.TemplateSpec, instruction string, history []string, params store.GenerationParams) (*spec.TemplateSpec, *GenerationResponse, error) {
+	b, err := json.Marshal(current)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal deck spec: %w", err)
+	}
+	g := s.glossary(ctx, orgID)
+	resp, err := s.generateSpec(ctx, orgID, userID, CallRefine, GenerationRequest{
+		Prompt:   refinePrompt(string(b), instruction, history),
+		Glossary: g.Rules(),
+		Params:   params,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to refine deck spec: %w", err)
+	}
+	if resp.Spec == nil || len(resp.Spec.Layouts) == 0 {
+		return nil, nil, fmt.Errorf("failed to refine deck spec: model returned a deck without slides")
+	}
+	s.recordUsage(ctx, orgID, userID, resp)
+	resp.GlossaryViolations = s.enforceGlossaryOnSpec(ctx, orgID, userID, g, resp.Spec)
+	return resp.Spec, resp, nil
+}
+
+// refinePromptHeader opens every refinement prompt; the mock orchestrator
+// recognizes refinement requests by it.
+const refinePromptHeader = "Revise the provided deck TemplateSpec as the user instructs."
+
+// Markers that split a refinement prompt into its instruction and the deck's
+// spec, which ends the prompt.
+const (
+	refineInstructionMarker = "\nINSTRUCTION:\n"
+	refineSpecMarker        = "\nDECK_SPEC_JSON:\n"
+)
+
+func refinePrompt(specJSON, instruction string, history []string) string {
+	var b strings.Builder
+	b.WriteString(refinePromptHeader)
+	b.WriteString(` Change only what the instruction asks for; keep the other slides, their order, geometry, placeholder IDs and tokens as they are. Slides are the layouts, numbered from 1. A new slide gets a new layout with a unique name and placeholders in the style of the existing ones. Return ONLY valid JSON TemplateSpec.
+`)
+	if len(history) > 0 {
+		b.WriteString("\nEARLIER_INSTRUCTIONS (already applied, oldest first):\n")
+		for i, h := range history {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, h)
+		}
+	}
+	b.WriteString(refineInstructionMarker)
+	b.WriteString(instruction)
+	b.WriteString("\n")
+	b.WriteString(refineSpecMarker)
+	b.WriteString(specJSON)
+	return b.String()
+}
@@ -0,0 +1,35 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestRefinePrompt_CarriesHistory(t *testing.T) {
+	p := refinePrompt(`{"layouts":[]}`, "add a pricing slide", []string{"make slide 3 more concise", "use a warmer tone"})
+	assert.Contains(t, p, "1. make slide 3 more concise\n2. use a warmer tone\n")
+	assert.Contains(t, p, refineInstructionMarker+"add a pricing slide\n")
+	assert.Contains(t, p, refineSpecMarker+`{"layouts":[]}`)
+
+	assert.NotContains(t, refinePrompt("{}", "shorter", nil), "EARLIER_INSTRUCTIONS")
+}
+
+func TestMockRefineDeckSpec(t *testing.T) {
+	svc := &AIService{orchestrator: NewMockOrchestrator(), store: newMockStore()}
+	deck := &spec.TemplateSpec{Layouts: []spec.Layout{{Name: "Intro"}, {Name: "Agenda"}}}
+
+	refined, _, err := svc.RefineDeckSpec(context.Background(), "org-1", "user-1", deck, "Please add a pricing slide", nil, store.GenerationParams{})
+	require.NoError(t, err)
+	require.Len(t, refined.Layouts, 3)
+	assert.Equal(t, "Pricing", refined.Layouts[2].Placeholders[0].Content)
+
+	refined, _, err = svc.RefineDeckSpec(context.Background(), "org-1", "user-1", refined, "remove slide 1", []string{"add a pricing slide"}, store.GenerationParams{})
+	require.NoError(t, err)
+	assert.Equal(t, "Agenda", refined.Layouts[0].Name)
+	assert.Len(t, refined.Layouts, 2)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
 	"github.com/ziyad/cms-ai/server/internal/spec"
 	"github.com/ziyad/cms-ai/server/internal/store"
 )
@@ -15,6 +16,8 @@ type mockStore struct {
 	versions  map[string]store.TemplateVersion
 	brandKits map[string]store.BrandKit
 	metering  []store.MeteringEvent
+	glossary  []store.GlossaryEntry
+	calls     []store.AICall
 }
 
 func newMockStore() *mockStore {
@@ -38,12 +41,59 @@ func (m *mockStore) Metering() store.MeteringStore {
 	return &mockMeteringStore{metering: &m.metering}
 }
 
+func (m *mockStore) AICalls() store.AICallStore {
+	return &mockAICallStore{calls: &m.calls}
+}
+
+// mockAICallStore only records; List panics.
+type mockAICallStore struct {
+	store.AICallStore
+	calls *[]store.AICall
+}
+
+func (m *mockAICallStore) Record(_ context.Context, c store.AICall) (store.AICall, error) {
+	*m.calls = append(*m.calls, c)
+	return c, nil
+}
+
+// mockGlossaryStore only lists; the other methods panic.
+type mockGlossaryStore struct {
+	store.GlossaryStore
+	entries []store.GlossaryEntry
+}
+
+func (m mockGlossaryStore) List(_ context.Context, orgID string) ([]store.GlossaryEntry, error) {
+	return m.entries, nil
+}
+
 func (m *mockStore) Decks() store.DeckStore                 { return nil }
 func (m *mockStore) Assets() store.AssetStore               { return nil }
 func (m *mockStore) Jobs() store.JobStore                   { return nil }
 func (m *mockStore) Audit() store.AuditStore                { return nil }
 func (m *mockStore) Users() store.UserStore                 { return nil }
 func (m *mockStore) Organizations() store.OrganizationStore { return nil }
+func (m *mockStore) Permissions() store.PermissionStore     { return nil }
+func (m *mockStore) Comments() store.CommentStore           { return nil }
+func (m *mockStore) Tags() store.TagStore                   { return nil }
+func (m *mockStore) Folders() store.FolderStore             { return nil }
+func (m *mockStore) Webhooks() store.WebhookStore           { return nil }
+func (m *mockStore) Embeds() store.EmbedStore               { return nil }
+func (m *mockStore) APIKeys() store.APIKeyStore             { return nil }
+func (m *mockStore) RetryPolicies() store.RetryPolicyStore  { return nil }
+func (m *mockStore) FeatureFlags() store.FeatureFlagStore    { return nil }
+func (m *mockStore) Fonts() store.FontStore                 { return nil }
+func (m *mockStore) Glossary() store.GlossaryStore {
+	return mockGlossaryStore{entries: m.glossary}
+}
+func (m *mockStore) Schedules() store.ScheduleStore   { return nil }
+func (m *mockStore) Connectors() store.ConnectorStore { return nil }
+func (m *mockStore) GeneratedImages() store.GeneratedImageStore {
+	return nil
+}
+func (m *mockStore) Platform() store.PlatformStore { return nil }
+func (m *mockStore) WithTx(ctx context.Context, fn func(tx store.Store) error) error {
+	return fn(m)
+}
 
 type mockTemplateStore struct {
 	templates map[string]store.Template
@@ -78,6 +128,15 @@ func (m *mockTemplateStore) UpdateTemplate(ctx context.Context, t store.Template
 	return t, nil
 }
 
+func (m *mockTemplateStore) ListTemplatesFor(ctx context.Context, id auth.Identity) ([]store.Template, error) {
+	return m.ListTemplates(ctx, id.OrgID)
+}
+
+func (m *mockTemplateStore) GetTemplateFor(ctx context.Context, id auth.Identity, templateID string) (store.Template, store.Permission, bool, error) {
+	t, ok, err := m.GetTemplate(ctx, id.OrgID, templateID)
+	return t, store.PermissionManage, ok, err
+}
+
 func (m *mockTemplateStore) CreateVersion(ctx context.Context, v store.TemplateVersion) (store.TemplateVersion, error) {
 	m.versions[v.ID] = v
 	return v, nil
@@ -101,6 +160,22 @@ func (m *mockTemplateStore) GetVersion(ctx context.Context, orgID, versionID str
 	return v, true, nil
 }
 
+func (m *mockTemplateStore) GetVersions(ctx context.Context, orgID string, ids []string) (map[string]store.TemplateVersion, error) {
+	out := map[string]store.TemplateVersion{}
+	for _, id := range ids {
+		if v, ok, _ := m.GetVersion(ctx, orgID, id); ok {
+			out[id] = v
+		}
+	}
+	return out, nil
+}
+
+func (m *mockTemplateStore) ListTemplatesWithCurrentVersion(ctx context.Context, id auth.Identity) ([]store.TemplateWithVersion, error) {
+	ts, _ := m.ListTemplatesFor(ctx, id)
+	versions, _ := m.GetVersions(ctx, id.OrgID, store.CurrentVersionIDs(ts))
+	return store.WithCurrentVersions(ts, versions), nil
+}
+
 type mockBrandKitStore struct {
 	brandKits map[string]store.BrandKit
 }
@@ -129,6 +204,26 @@ func (m *mockMeteringStore) Record(ctx context.Context, e store.MeteringEvent) (
 	return e, nil
 }
 
+func (m *mockMeteringStore) SumByTarget(ctx context.Context, orgID string, eventType string) (map[string]int, error) {
+	sums := map[string]int{}
+	for _, e := range *m.metering {
+		if e.OrgID == orgID && e.Type == eventType && e.TargetRef != "" {
+			sums[e.TargetRef] += e.Quantity
+		}
+	}
+	return sums, nil
+}
+
+func (m *mockMeteringStore) SumForTarget(ctx context.Context, orgID, eventType, targetRef string) (int, error) {
+	sum := 0
+	for _, e := range *m.metering {
+		if e.OrgID == orgID && e.Type == eventType && e.TargetRef == targetRef {
+			sum += e.Quantity
+		}
+	}
+	return sum, nil
+}
+
 func (m *mockMeteringStore) SumByType(ctx context.Context, orgID string, eventType string) (int, error) {
 	sum := 0
 	for _, e := range *m.metering {
@@ -142,6 +237,7 @@ func (m *mockMeteringStore) SumByType(ctx context.Context, orgID string, eventTy
 // Mock orchestrator for testing
 type mockOrchestrator struct {
 	response *GenerationResponse
+	json     string
 	err      error
 }
 
@@ -156,7 +252,9 @@ func (m *mockOrchestrator) GenerateJSON(ctx context.Context, prompt string) (str
 	if m.err != nil {
 		return "", m.err
 	}
-	// Not used in these tests.
+	if m.json != "" {
+		return m.json, nil
+	}
 	return "{}", nil
 }
 
@@ -248,11 +346,13 @@ func TestAIService_GenerateTemplateForRequest(t *testing.T) {
 
 	// Check that metering was recorded
 	metering := mockStore.Metering().(*mockMeteringStore)
-	assert.Len(t, *metering.metering, 1)
+	require.Len(t, *metering.metering, 2)
 	assert.Equal(t, "org-1", (*metering.metering)[0].OrgID)
 	assert.Equal(t, "user-1", (*metering.metering)[0].UserID)
 	assert.Equal(t, "ai_generation", (*metering.metering)[0].Type)
 	assert.Equal(t, 100, (*metering.metering)[0].Quantity)
+	assert.Equal(t, store.MeterAICost, (*metering.metering)[1].Type)
+	assert.Equal(t, 1000, (*metering.metering)[1].Quantity, "cost is metered in millionths of a dollar")
 }
 
 func TestAIService_GenerateTemplateForRequest_NoBrandKit(t *testing.T) {
@@ -0,0 +1,80 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// SlideRegenerator rewrites single slides of a deck; AIService implements it.
+type SlideRegenerator interface {
+	// RegenerateSlide returns a new version of deck.Layouts[index]. Only that
+	// slide, the deck's tokens and the other slides' names go to the model.
+	RegenerateSlide(ctx context.Context, orgID, userID string, deck *spec.TemplateSpec, index int, guidance string, params store.GenerationParams) (*spec.Layout, *GenerationResponse, error)
+}
+
+// RegenerateSlide asks the model to rewrite one slide. The result keeps the
+// slide's name, and it is an error for the model to drop every placeholder.
+func (s *AIService) RegenerateSlide(ctx context.Context, orgID, userID string, deck *spec.TemplateSpec, index int, guidance string, params store.GenerationParams) (*spec.Layout, *GenerationResponse, error) {
+	if index < 0 || index >= len(deck.Layouts) {
+		return nil, nil, fmt.Errorf("slide %d out of range for %d slides", index, len(deck.Layouts))
+	}
+	slide := deck.Layouts[index]
+	b, err := json.Marshal(spec.TemplateSpec{Tokens: deck.Tokens, Constraints: deck.Constraints, Layouts: []spec.Layout{slide}})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal slide: %w", err)
+	}
+	outline := make([]string, len(deck.Layouts))
+	for i, l := range deck.Layouts {
+		outline[i] = l.Name
+	}
+	g := s.glossary(ctx, orgID)
+	resp, err := s.generateSpec(ctx, orgID, userID, CallSlide, GenerationRequest{
+		Prompt:   slidePrompt(string(b), index, outline, guidance),
+		Glossary: g.Rules(),
+		Params:   params,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to regenerate slide: %w", err)
+	}
+	if resp.Spec == nil || len(resp.Spec.Layouts) == 0 || len(resp.Spec.Layouts[0].Placeholders) == 0 {
+		return nil, nil, fmt.Errorf("failed to regenerate slide: model returned an empty slide")
+	}
+	s.recordUsage(ctx, orgID, userID, resp)
+	resp.Spec.Layouts = resp.Spec.Layouts[:1]
+	resp.GlossaryViolations = s.enforceGlossaryOnSpec(ctx, orgID, userID, g, resp.Spec)
+	out := resp.Spec.Layouts[0]
+	out.Name = slide.Name
+	return &out, resp, nil
+}
+
+// slidePromptHeader opens every slide regeneration prompt; the mock
+// orchestrator recognizes them by it.
+const slidePromptHeader = "Rewrite one slide of a presentation."
+
+// Markers that split a slide prompt into its guidance and the slide, which
+// ends the prompt.
+const (
+	slideGuidanceMarker = "\nGUIDANCE:\n"
+	slideSpecMarker     = "\nSLIDE_SPEC_JSON:\n"
+)
+
+func slidePrompt(slideJSON string, index int, outline []string, guidance string) string {
+	var b strings.Builder
+	b.WriteString(slidePromptHeader)
+	fmt.Fprintf(&b, ` The slide is number %d of %d; the deck's slides are: %s.
+Improve the slide's content, following the guidance if there is any. Keep its placeholder IDs, types and geometry and the deck's tokens. Return ONLY valid JSON TemplateSpec with exactly one layout: the rewritten slide.
+`, index+1, len(outline), strings.Join(outline, " | "))
+	if guidance != "" {
+		b.WriteString(slideGuidanceMarker)
+		b.WriteString(guidance)
+		b.WriteString("\n")
+	}
+	b.WriteString(slideSpecMarker)
+	b.WriteString(slideJSON)
+	return b.String()
+}
@@ -0,0 +1,30 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestMockRegenerateSlide(t *testing.T) {
+	svc := &AIService{orchestrator: NewMockOrchestrator(), store: newMockStore()}
+	deck := &spec.TemplateSpec{Layouts: []spec.Layout{
+		{Name: "Intro"},
+		{Name: "Pricing", Placeholders: []spec.Placeholder{{ID: "slide_title", Type: "text", Content: "Pricing"}, {ID: "body", Type: "text", Content: "Too long"}}},
+	}}
+
+	slide, _, err := svc.RegenerateSlide(context.Background(), "org-1", "user-1", deck, 1, "compare plans", store.GenerationParams{})
+	require.NoError(t, err)
+	assert.Equal(t, "Pricing", slide.Name)
+	assert.Equal(t, "Pricing", slide.Placeholders[0].Content, "titles are kept")
+	assert.Equal(t, "• Revised: compare plans", slide.Placeholders[1].Content)
+
+	_, _, err = svc.RegenerateSlide(context.Background(), "org-1", "user-1", deck, 0, "", store.GenerationParams{})
+	assert.Error(t, err, "a slide without placeholders comes back empty")
+	_, _, err = svc.RegenerateSlide(context.Background(), "org-1", "user-1", deck, 2, "", store.GenerationParams{})
+	assert.Error(t, err)
+}
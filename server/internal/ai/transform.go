@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Text transformations a deck's content can go through.
+const (
+	TransformShorten   = "shorten"
+	TransformExpand    = "expand"
+	TransformFormalize = "formalize"
+	TransformSimplify  = "simplify"
+	TransformTranslate = "translate"
+)
+
+// TransformOperations lists every transformation, in the order they are
+// documented.
+var TransformOperations = []string{TransformShorten, TransformExpand, TransformFormalize, TransformSimplify, TransformTranslate}
+
+var transformInstructions = map[string]string{
+	TransformShorten:   "Make each text more concise, keeping its meaning and key figures. Keep bullet lists as bullet lists with fewer words per bullet.",
+	TransformExpand:    "Expand each text with more detail and explanation, keeping its meaning. Keep bullet lists as bullet lists.",
+	TransformFormalize: "Rewrite each text in a formal, professional register.",
+	TransformSimplify:  "Rewrite each text in plain, simple language a general audience understands.",
+	TransformTranslate: "Translate each text into the language with BCP 47 tag %q. Keep names, numbers and bullet markers.",
+}
+
+// TextTransformer rewrites texts; AIService implements it.
+type TextTransformer interface {
+	// TransformTexts applies operation to every text, keeping their keys.
+	// language is the target of TransformTranslate and ignored otherwise.
+	TransformTexts(ctx context.Context, orgID, userID, operation, language string, texts map[string]string) (map[string]string, error)
+}
+
+// TransformTexts sends the texts to the model as one JSON object. A text the
+// answer leaves out keeps its original wording; an answer with none of them
+// is an error. The results are corrected against the org's glossary.
+func (s *AIService) TransformTexts(ctx context.Context, orgID, userID, operation, language string, texts map[string]string) (map[string]string, error) {
+	instruction, ok := transformInstructions[operation]
+	if !ok {
+		return nil, fmt.Errorf("unknown transformation %q", operation)
+	}
+	if operation == TransformTranslate {
+		instruction = fmt.Sprintf(instruction, language)
+	}
+	b, err := json.Marshal(texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal texts: %w", err)
+	}
+	g := s.glossary(ctx, orgID)
+	prompt := transformPrompt(operation, instruction, g.Rules(), string(b))
+	answer, err := s.generateJSON(ctx, orgID, userID, CallTransform, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("transform texts: %w", err)
+	}
+	// GenerateJSON doesn't report usage, so tokens are estimated.
+	s.recordUsage(ctx, orgID, userID, &GenerationResponse{TokenUsage: estimateTokens(prompt, answer)})
+
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	var got map[string]string
+	if start < 0 || end <= start || json.Unmarshal([]byte(answer[start:end+1]), &got) != nil {
+		return nil, fmt.Errorf("transform texts: model did not return a JSON object of texts")
+	}
+	out := make(map[string]string, len(texts))
+	changed := 0
+	for k, v := range texts {
+		if t, ok := got[k]; ok && strings.TrimSpace(t) != "" {
+			out[k] = t
+			changed++
+			continue
+		}
+		out[k] = v
+	}
+	if changed == 0 {
+		return nil, fmt.Errorf("transform texts: model returned none of the texts")
+	}
+	s.enforceGlossary(ctx, orgID, userID, g, out)
+	return out, nil
+}
+
+// transformPromptHeader opens every transformation prompt; the mock
+// orchestrator recognizes them by it.
+const transformPromptHeader = "You rewrite the texts of presentation slides."
+
+// Markers that precede the operation's name and the texts, which end the
+// prompt.
+const (
+	transformOperationMarker = "\nOPERATION: "
+	transformTextsMarker     = "\nTEXTS_JSON:\n"
+)
+
+func transformPrompt(operation, instruction, glossaryRules, textsJSON string) string {
+	if glossaryRules != "" {
+		glossaryRules = "Follow the organization's terminology rules:\n" + glossaryRules
+	}
+	return transformPromptHeader + ` The input is a JSON object of slide texts by key. ` + instruction + `
+` + glossaryRules + `Return ONLY a JSON object with the same keys and the rewritten texts as values (no markdown).
+` + transformOperationMarker + operation + "\n" + transformTextsMarker + textsJSON
+}
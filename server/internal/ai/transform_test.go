@@ -0,0 +1,40 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAIService_TransformTexts(t *testing.T) {
+	st := newMockStore()
+	svc := &AIService{orchestrator: NewMockOrchestrator(), store: st}
+	texts := map[string]string{"0/0": "Quarterly results", "0/1": "Revenue grew strongly in every region this year"}
+
+	got, err := svc.TransformTexts(context.Background(), "org-1", "user-1", TransformShorten, "", texts)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"0/0": "Quarterly", "0/1": "Revenue grew strongly in"}, got)
+	require.Len(t, st.metering, 1)
+	assert.Equal(t, "ai_generation", st.metering[0].Type)
+	assert.Positive(t, st.metering[0].Quantity)
+
+	got, err = svc.TransformTexts(context.Background(), "org-1", "user-1", TransformTranslate, "ar", texts)
+	require.NoError(t, err)
+	assert.Equal(t, "[translated] Quarterly results", got["0/0"])
+
+	_, err = svc.TransformTexts(context.Background(), "org-1", "user-1", "shout", "", texts)
+	assert.Error(t, err)
+
+	// Texts the model leaves out keep their wording; an answer without any
+	// of them is an error.
+	svc.orchestrator = &mockOrchestrator{json: "```json\n{\"0/1\":\"Revenue grew\",\"9/9\":\"extra\"}\n```"}
+	got, err = svc.TransformTexts(context.Background(), "org-1", "user-1", TransformShorten, "", texts)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"0/0": "Quarterly results", "0/1": "Revenue grew"}, got)
+
+	svc.orchestrator = &mockOrchestrator{json: `{"other":"text"}`}
+	_, err = svc.TransformTexts(context.Background(), "org-1", "user-1", TransformShorten, "", texts)
+	assert.Error(t, err)
+}
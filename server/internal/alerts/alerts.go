@@ -0,0 +1,196 @@
+// Package alerts tells platform operators about problems that need a person,
+// such as jobs landing in the dead-letter queue. Alerts go to whichever of a
+// JSON webhook, a Slack incoming webhook and email are configured.
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/slack"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// Alert is one notification. Data is sent as-is to the JSON webhook and
+// listed under the summary in Slack and email.
+type Alert struct {
+	Event   string         `json:"event"`
+	Summary string         `json:"summary"`
+	Data    map[string]any `json:"data,omitempty"`
+	At      time.Time      `json:"at"`
+}
+
+// EmailConfig is the SMTP relay alerts are mailed through.
+type EmailConfig struct {
+	Addr     string // host:port
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+type Notifier struct {
+	WebhookURL      string
+	SlackWebhookURL string
+	Email           *EmailConfig
+
+	HTTPClient *http.Client
+	Slack      *slack.Client
+
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// FromEnv configures alerts from ALERT_WEBHOOK_URL, ALERT_SLACK_WEBHOOK_URL
+// and ALERT_EMAIL_TO with SMTP_ADDR, SMTP_USERNAME, SMTP_PASSWORD and
+// SMTP_FROM. It returns nil when no channel is configured.
+func FromEnv(getenv func(string) string) *Notifier {
+	n := &Notifier{
+		WebhookURL:      getenv("ALERT_WEBHOOK_URL"),
+		SlackWebhookURL: getenv("ALERT_SLACK_WEBHOOK_URL"),
+		HTTPClient:      &http.Client{Timeout: 10 * time.Second},
+		Slack:           slack.NewClient(),
+	}
+	if to := getenv("ALERT_EMAIL_TO"); to != "" && getenv("SMTP_ADDR") != "" {
+		cfg := &EmailConfig{
+			Addr:     getenv("SMTP_ADDR"),
+			Username: getenv("SMTP_USERNAME"),
+			Password: getenv("SMTP_PASSWORD"),
+			From:     getenv("SMTP_FROM"),
+		}
+		for _, addr := range strings.Split(to, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				cfg.To = append(cfg.To, addr)
+			}
+		}
+		if cfg.From == "" {
+			cfg.From = "cms-ai@localhost"
+		}
+		n.Email = cfg
+	}
+	if n.WebhookURL == "" && n.SlackWebhookURL == "" && n.Email == nil {
+		return nil
+	}
+	return n
+}
+
+// Channels names the configured channels, for startup logs.
+func (n *Notifier) Channels() []string {
+	var out []string
+	if n.WebhookURL != "" {
+		out = append(out, "webhook")
+	}
+	if n.SlackWebhookURL != "" {
+		out = append(out, "slack")
+	}
+	if n.Email != nil {
+		out = append(out, "email")
+	}
+	return out
+}
+
+// Send delivers a to every configured channel, returning the failures
+// joined. One channel failing doesn't stop the others.
+func (n *Notifier) Send(ctx context.Context, a Alert) error {
+	if a.At.IsZero() {
+		a.At = time.Now().UTC()
+	}
+	var errs []error
+	if n.WebhookURL != "" {
+		if err := n.postWebhook(ctx, a); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		}
+	}
+	if n.SlackWebhookURL != "" {
+		cfg := &store.SlackIntegration{WebhookURL: n.SlackWebhookURL}
+		if err := n.Slack.Post(ctx, cfg, slack.Message{Text: slackText(a)}); err != nil {
+			errs = append(errs, fmt.Errorf("slack: %w", err))
+		}
+	}
+	if n.Email != nil {
+		if err := n.mail(a); err != nil {
+			errs = append(errs, fmt.Errorf("email: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) postWebhook(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.HTTPClient.Do(req)
+	// Transport errors quote the URL, which may carry a token.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (n *Notifier) mail(a Alert) error {
+	cfg := n.Email
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		host, _, _ := strings.Cut(cfg.Addr, ":")
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: [cms-ai] %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(a.Summary))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(a.Summary + "\r\n\r\n")
+	for _, line := range dataLines(a.Data) {
+		msg.WriteString(line + "\r\n")
+	}
+	send := n.sendMail
+	if send == nil {
+		send = smtp.SendMail
+	}
+	return send(cfg.Addr, auth, cfg.From, cfg.To, []byte(msg.String()))
+}
+
+func slackText(a Alert) string {
+	var b strings.Builder
+	b.WriteString(":rotating_light: " + slack.Escape(a.Summary))
+	for _, line := range dataLines(a.Data) {
+		b.WriteString("\n• " + slack.Escape(line))
+	}
+	return b.String()
+}
+
+// dataLines renders data as sorted "key: value" lines.
+func dataLines(data map[string]any) []string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, fmt.Sprintf("%s: %v", k, data[k]))
+	}
+	return out
+}
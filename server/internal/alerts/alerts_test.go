@@ -0,0 +1,89 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/slack"
+)
+
+func TestFromEnv(t *testing.T) {
+	assert.Nil(t, FromEnv(func(string) string { return "" }))
+
+	env := map[string]string{
+		"ALERT_EMAIL_TO": "oncall@example.com, ops@example.com",
+		"SMTP_ADDR":      "smtp.example.com:587",
+	}
+	n := FromEnv(func(k string) string { return env[k] })
+	require.NotNil(t, n)
+	assert.Equal(t, []string{"email"}, n.Channels())
+	assert.Equal(t, []string{"oncall@example.com", "ops@example.com"}, n.Email.To)
+
+	delete(env, "SMTP_ADDR")
+	assert.Nil(t, FromEnv(func(k string) string { return env[k] }), "email needs a relay")
+}
+
+func TestNotifier_SendsToEveryChannel(t *testing.T) {
+	var hook Alert
+	hookSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&hook))
+	}))
+	defer hookSrv.Close()
+	var slackMsg slack.Message
+	slackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&slackMsg))
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer slackSrv.Close()
+
+	var mailed string
+	var rcpts []string
+	n := &Notifier{
+		WebhookURL:      hookSrv.URL,
+		SlackWebhookURL: slackSrv.URL,
+		Email:           &EmailConfig{Addr: "smtp.example.com:25", From: "cms@example.com", To: []string{"oncall@example.com"}},
+		HTTPClient:      hookSrv.Client(),
+		Slack:           slack.NewClient(),
+		sendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			mailed, rcpts = string(msg), to
+			return nil
+		},
+	}
+	a := Alert{Event: "job.dead_lettered", Summary: "export job job-1 was dead-lettered", Data: map[string]any{"orgId": "org-1", "error": "a < b"}}
+	require.NoError(t, n.Send(context.Background(), a))
+
+	assert.Equal(t, "job.dead_lettered", hook.Event)
+	assert.Equal(t, "org-1", hook.Data["orgId"])
+	assert.False(t, hook.At.IsZero())
+	assert.Equal(t, ":rotating_light: export job job-1 was dead-lettered\n• error: a &lt; b\n• orgId: org-1", slackMsg.Text)
+	assert.Equal(t, []string{"oncall@example.com"}, rcpts)
+	assert.Contains(t, mailed, "Subject: [cms-ai] export job job-1 was dead-lettered\r\n")
+	assert.Contains(t, mailed, "orgId: org-1")
+}
+
+func TestNotifier_ReportsFailedChannels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	mailed := false
+	n := &Notifier{
+		WebhookURL: srv.URL,
+		Email:      &EmailConfig{Addr: "smtp.example.com:25", To: []string{"oncall@example.com"}},
+		HTTPClient: srv.Client(),
+		sendMail: func(string, smtp.Auth, string, []string, []byte) error {
+			mailed = true
+			return nil
+		},
+	}
+	err := n.Send(context.Background(), Alert{Summary: "down"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "webhook: responded 502")
+	assert.True(t, mailed, "a failing channel doesn't stop the others")
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const (
+	defaultAICallPageSize = 100
+	maxAICallPageSize     = 500
+)
+
+// handleListAICalls handles GET /v1/admin/ai/calls, the history of calls to
+// the AI provider, newest first. Org admins see their org's calls; platform
+// admins see every org's, or one org's with orgId.
+func (s *Server) handleListAICalls(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !id.PlatformAdmin && !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	q := r.URL.Query()
+	filter := store.AICallFilter{
+		OrgID:  id.OrgID,
+		UserID: q.Get("userId"),
+		Kind:   q.Get("kind"),
+		Model:  q.Get("model"),
+		Limit:  defaultAICallPageSize,
+	}
+	if id.PlatformAdmin {
+		filter.OrgID = q.Get("orgId")
+	} else if v := q.Get("orgId"); v != "" && v != id.OrgID {
+		writeError(w, r, http.StatusForbidden, "platform admin required")
+		return
+	}
+	if filter.Kind != "" && !slices.Contains(ai.CallKinds, filter.Kind) {
+		writeError(w, r, http.StatusBadRequest, "kind must be one of "+strings.Join(ai.CallKinds, ", "))
+		return
+	}
+	if v := q.Get("success"); v != "" {
+		ok, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "success must be true or false")
+			return
+		}
+		filter.Success = &ok
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, p.name+" must be an RFC3339 timestamp")
+				return
+			}
+			*p.dst = &t
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, r, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		filter.Limit = min(n, maxAICallPageSize)
+	}
+
+	calls, err := s.Store.AICalls().List(r.Context(), filter)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_ai_calls", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list AI calls")
+		return
+	}
+	if calls == nil {
+		calls = []store.AICall{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"calls": calls})
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestListAICalls(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	for _, c := range []store.AICall{
+		{ID: "c1", OrgID: "org-a", UserID: "user-1", Kind: "generate", Model: "m-1", Success: true, Tokens: 100},
+		{ID: "c2", OrgID: "org-a", UserID: "user-2", Kind: "refine", Model: "m-1", Success: false, Error: "timeout"},
+		{ID: "c3", OrgID: "org-b", UserID: "user-3", Kind: "generate", Model: "m-2", Success: true},
+	} {
+		_, err := s.Store.AICalls().Record(ctx, c)
+		require.NoError(t, err)
+	}
+	h := s.Handler()
+	addPlatformAdmin(t, s, "ops-1", "org-a")
+
+	listAs := func(userID, query string, role auth.Role) (int, []string) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/v1/admin/ai/calls"+query, nil)
+		addTestAuth(req, userID, "org-a", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			return w.Code, nil
+		}
+		var resp struct {
+			Calls []store.AICall `json:"calls"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		ids := []string{}
+		for _, c := range resp.Calls {
+			ids = append(ids, c.ID)
+		}
+		return w.Code, ids
+	}
+	list := func(query string, role auth.Role) (int, []string) {
+		t.Helper()
+		return listAs("user-1", query, role)
+	}
+
+	code, _ := list("", auth.RoleEditor)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// Org admins see their own org's calls, newest first.
+	_, ids := list("", auth.RoleAdmin)
+	assert.Equal(t, []string{"c2", "c1"}, ids)
+	_, ids = list("?success=false", auth.RoleAdmin)
+	assert.Equal(t, []string{"c2"}, ids)
+	_, ids = list("?kind=generate&userId=user-1", auth.RoleAdmin)
+	assert.Equal(t, []string{"c1"}, ids)
+	_, ids = list("?limit=1", auth.RoleAdmin)
+	assert.Equal(t, []string{"c2"}, ids)
+	_, ids = list("?until=2000-01-01T00:00:00Z", auth.RoleAdmin)
+	assert.Empty(t, ids)
+	code, _ = list("?orgId=org-b", auth.RoleAdmin)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// Platform admins see every org, or one, whatever their org role.
+	_, ids = listAs("ops-1", "", auth.RoleViewer)
+	assert.Equal(t, []string{"c3", "c2", "c1"}, ids)
+	_, ids = listAs("ops-1", "?orgId=org-b&model=m-2", auth.RoleViewer)
+	assert.Equal(t, []string{"c3"}, ids)
+
+	for _, query := range []string{"?kind=other", "?success=maybe", "?since=yesterday", "?limit=0"} {
+		code, _ = list(query, auth.RoleAdmin)
+		assert.Equal(t, http.StatusBadRequest, code, query)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,7 +15,9 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/ziyad/cms-ai/server/internal/ai"
 	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/service"
 	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
 )
 
 func TestGenerateTemplateWithAI(t *testing.T) {
@@ -33,7 +37,6 @@ func TestGenerateTemplateWithAI(t *testing.T) {
 		Name:     "AI Generated Template",
 		Language: "English",
 		Tone:     "Professional",
-		RTL:      false,
 	}
 
 	body, err := json.Marshal(reqBody)
@@ -102,7 +105,6 @@ func TestGenerateTemplateWithMockAI(t *testing.T) {
 	reqBody := GenerateTemplateRequest{
 		Prompt: "Create a test template",
 		Name:   "Mock AI Template",
-		RTL:    false,
 	}
 
 	body, err := json.Marshal(reqBody)
@@ -203,12 +205,83 @@ func TestGenerateTemplateValidation(t *testing.T) {
 	}
 }
 
+func TestGenerateTemplateSync(t *testing.T) {
+	srv := NewServer()
+	h := srv.Handler()
+	post := func(prompt string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(GenerateTemplateRequest{Prompt: prompt, Name: "Sync Template"})
+		req := httptest.NewRequest(http.MethodPost, "/v1/templates/generate?sync=true", bytes.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	srv.AIService = &mockAIService{}
+	w := post("Create a quarterly business review template")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Template store.Template        `json:"template"`
+		Version  store.TemplateVersion `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Template.CurrentVersion)
+	assert.Equal(t, resp.Version.ID, *resp.Template.CurrentVersion)
+	assert.Equal(t, 1, resp.Version.VersionNo)
+
+	assert.Equal(t, http.StatusBadRequest, post(strings.Repeat("x", service.MaxSyncPromptLength+1)).Code)
+
+	srv.AIService = &mockAIService{shouldError: true}
+	assert.Equal(t, http.StatusBadGateway, post("Create a quarterly business review template").Code)
+	tpls, err := srv.Store.Templates().ListTemplates(context.Background(), "org-1")
+	require.NoError(t, err)
+	assert.Len(t, tpls, 1, "failed sync generation must not leave a template behind")
+
+	// An open circuit fails fast with its own code and says when to retry.
+	srv.AIService = &mockAIService{err: fmt.Errorf("failed to generate template spec: %w", &ai.UnavailableError{Provider: "huggingface", Reason: "circuit open", RetryAfter: 12300 * time.Millisecond})}
+	w = post("Create a quarterly business review template")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "13", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), ErrCodeAIUnavailable)
+}
+
+func TestAnalyzeTemplate_UsesAIWithHeuristicFallback(t *testing.T) {
+	srv := NewServer()
+	h := srv.Handler()
+	analyze := func(prompt string) AnalyzeTemplateResponse {
+		body, err := json.Marshal(AnalyzeTemplateRequest{Prompt: prompt})
+		require.NoError(t, err)
+		req := httptest.NewRequest("POST", "/v1/templates/analyze", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp AnalyzeTemplateResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	resp := analyze("ملخص أداء المستشفى للمرضى")
+	assert.Equal(t, AnalysisSourceAI, resp.Source)
+	assert.Equal(t, "ar", resp.Language)
+	assert.NotEmpty(t, resp.RequiredFields)
+
+	// An AI service that can't analyze prompts leaves it to the keywords.
+	srv.AIService = &mockAIService{}
+	resp = analyze("Monthly sales review")
+	assert.Equal(t, AnalysisSourceHeuristic, resp.Source)
+	assert.Equal(t, "sales-report", resp.TemplateType)
+}
+
 // Mock AI service for testing
 type mockAIService struct {
 	shouldError bool
+	// err, when set, is the error generation fails with.
+	err error
 }
 
-func (m *mockAIService) BindDeckSpec(ctx context.Context, orgID, userID string, templateSpec *spec.TemplateSpec, content string) (*spec.TemplateSpec, *ai.GenerationResponse, error) {
+func (m *mockAIService) BindDeckSpec(ctx context.Context, orgID, userID string, templateSpec *spec.TemplateSpec, content string, params store.GenerationParams) (*spec.TemplateSpec, *ai.GenerationResponse, error) {
 	if m.shouldError {
 		return nil, nil, assert.AnError
 	}
@@ -224,7 +297,9 @@ func (m *mockAIService) BindDeckSpec(ctx context.Context, orgID, userID string,
 }
 
 func (m *mockAIService) GenerateTemplateForRequest(ctx context.Context, orgID, userID string, req ai.GenerationRequest, brandKitID string) (*spec.TemplateSpec, *ai.GenerationResponse, error) {
-
+	if m.err != nil {
+		return nil, nil, m.err
+	}
 	if m.shouldError {
 		return nil, nil, assert.AnError
 	}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const (
+	defaultTemplateRankingLimit = 20
+	maxTemplateRankingLimit     = 100
+)
+
+// TemplateUsage counts how a template has been used, from metering events.
+type TemplateUsage struct {
+	// Decks is how many decks were created from the template.
+	Decks int `json:"decks"`
+	// Exports is how many times the template itself was exported.
+	Exports int `json:"exports"`
+}
+
+// DeckUsage counts how a deck has been used, from metering events.
+type DeckUsage struct {
+	Exports int `json:"exports"`
+}
+
+// TemplateRanking is one entry of GET /v1/analytics/templates.
+type TemplateRanking struct {
+	TemplateID string               `json:"templateId"`
+	Name       string               `json:"name"`
+	Status     store.TemplateStatus `json:"status"`
+	TemplateUsage
+}
+
+// usageCounts holds an org's per-target deck creation and export counts.
+type usageCounts struct {
+	decks, exports map[string]int
+}
+
+func (s *Server) usageCounts(ctx context.Context, orgID string) (usageCounts, error) {
+	decks, err := s.Store.Metering().SumByTarget(ctx, orgID, store.MeterDeckCreate)
+	if err != nil {
+		return usageCounts{}, err
+	}
+	exports, err := s.Store.Metering().SumByTarget(ctx, orgID, "export")
+	if err != nil {
+		return usageCounts{}, err
+	}
+	return usageCounts{decks: decks, exports: exports}, nil
+}
+
+func (u usageCounts) template(id string) TemplateUsage {
+	return TemplateUsage{Decks: u.decks[id], Exports: u.exports[id]}
+}
+
+// templateUsage returns tpl's counts; they are informational, so a failure
+// to load them is logged and reported as zero.
+func (s *Server) templateUsage(ctx context.Context, tpl store.Template) TemplateUsage {
+	decks, err := s.Store.Metering().SumForTarget(ctx, tpl.OrgID, store.MeterDeckCreate, tpl.ID)
+	if err != nil {
+		logger.LogError(ctx, "api", "template_usage", err, "template_id", tpl.ID)
+	}
+	exports, err := s.Store.Metering().SumForTarget(ctx, tpl.OrgID, "export", tpl.ID)
+	if err != nil {
+		logger.LogError(ctx, "api", "template_usage", err, "template_id", tpl.ID)
+	}
+	return TemplateUsage{Decks: decks, Exports: exports}
+}
+
+func (s *Server) deckUsage(ctx context.Context, d store.Deck) DeckUsage {
+	exports, err := s.Store.Metering().SumForTarget(ctx, d.OrgID, "export", d.ID)
+	if err != nil {
+		logger.LogError(ctx, "api", "deck_usage", err, "deck_id", d.ID)
+	}
+	return DeckUsage{Exports: exports}
+}
+
+// handleTemplateAnalytics handles GET /v1/analytics/templates?limit=, ranking
+// the templates the caller can see by the decks created from them, then by
+// their own exports.
+func (s *Server) handleTemplateAnalytics(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	limit := defaultTemplateRankingLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, r, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, maxTemplateRankingLimit)
+	}
+
+	tpls, err := s.Store.Templates().ListTemplatesFor(r.Context(), id)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "template_analytics", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list templates")
+		return
+	}
+	counts, err := s.usageCounts(r.Context(), id.OrgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "template_analytics", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load usage")
+		return
+	}
+
+	ranking := make([]TemplateRanking, 0, len(tpls))
+	for _, t := range tpls {
+		ranking = append(ranking, TemplateRanking{TemplateID: t.ID, Name: t.Name, Status: t.Status, TemplateUsage: counts.template(t.ID)})
+	}
+	slices.SortFunc(ranking, func(a, b TemplateRanking) int {
+		return cmp.Or(
+			cmp.Compare(b.Decks, a.Decks),
+			cmp.Compare(b.Exports, a.Exports),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"templates": ranking[:min(limit, len(ranking))], "total": len(ranking)})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestTemplateAnalytics_CountsDecksAndExports(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+	for _, name := range []string{"Alpha", "Beta"} {
+		tplID := "tpl-" + strings.ToLower(name)
+		_, err := s.Store.Templates().CreateTemplate(ctx, store.Template{ID: tplID, OrgID: "org-1", Name: name, Status: store.TemplatePublished})
+		require.NoError(t, err)
+		_, err = s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-" + tplID, Template: tplID, OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"tokens":{},"layouts":[{"name":"Base"}]}`)})
+		require.NoError(t, err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	createDeck := func(tplID string) store.Deck {
+		body := fmt.Sprintf(`{"name":"Deck","sourceTemplateVersionId":"tv-%s","content":"Some content here","outline":{"slides":[{"slideNumber":1,"title":"Hi","content":["a"]}]}}`, tplID)
+		w := do(http.MethodPost, "/v1/decks", body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Deck store.Deck `json:"deck"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Deck
+	}
+
+	deck := createDeck("tpl-beta")
+	createDeck("tpl-beta")
+	createDeck("tpl-alpha")
+	w := do(http.MethodPost, "/v1/deck-versions/"+*deck.CurrentVersion+"/export", "")
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	w = do(http.MethodGet, "/v1/decks/"+deck.ID, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var deckResp struct {
+		Usage DeckUsage `json:"usage"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &deckResp))
+	assert.Equal(t, DeckUsage{Exports: 0}, deckResp.Usage, "exports count once they finish")
+
+	// The worker meters the export when it finishes.
+	_, err := s.Store.Metering().Record(ctx, store.MeteringEvent{OrgID: "org-1", UserID: "user-1", Type: "export", Quantity: 1, TargetRef: deck.ID})
+	require.NoError(t, err)
+	w = do(http.MethodGet, "/v1/decks/"+deck.ID, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &deckResp))
+	assert.Equal(t, DeckUsage{Exports: 1}, deckResp.Usage)
+
+	w = do(http.MethodGet, "/v1/templates/tpl-beta", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var tplResp struct {
+		Usage TemplateUsage `json:"usage"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tplResp))
+	assert.Equal(t, TemplateUsage{Decks: 2}, tplResp.Usage)
+
+	w = do(http.MethodGet, "/v1/analytics/templates", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var ranking struct {
+		Templates []TemplateRanking `json:"templates"`
+		Total     int               `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &ranking))
+	require.Len(t, ranking.Templates, 2)
+	assert.Equal(t, "tpl-beta", ranking.Templates[0].TemplateID)
+	assert.Equal(t, 2, ranking.Templates[0].Decks)
+	assert.Equal(t, "tpl-alpha", ranking.Templates[1].TemplateID)
+
+	w = do(http.MethodGet, "/v1/analytics/templates?limit=1", "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &ranking))
+	assert.Len(t, ranking.Templates, 1)
+	assert.Equal(t, 2, ranking.Total)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/v1/analytics/templates?limit=0", "").Code)
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/flags"
+	"github.com/ziyad/cms-ai/server/internal/queue"
+	"github.com/ziyad/cms-ai/server/internal/service"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/stock"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/cache"
+)
+
+// apiDoc annotates a route for the OpenAPI document. Request and Response
+// are zero values of what the handler decodes and writes; an envelope
+// describes a JSON object by key, which is how most handlers respond.
+type apiDoc struct {
+	Summary  string
+	Query    []string
+	Request  any
+	Upload   bool // multipart form with a "file" part
+	Status   int  // success status; 0 means 200
+	Response any
+	// ContentType marks a binary or HTML response instead of JSON.
+	ContentType string
+}
+
+type envelope map[string]any
+
+// authUser is the user object returned by the sign-in endpoints.
+var authUser = envelope{"userId": "", "email": "", "name": "", "orgId": "", "role": ""}
+
+// meResult is the user object of the /v1/auth/me endpoints.
+var meResult = envelope{"userId": "", "email": "", "name": "", "orgId": "", "role": "", "avatarUrl": "", "preferences": store.UserPreferences{}, "pendingEmail": "", "emailVerified": false}
+
+var (
+	jobAccepted    = envelope{"job": store.Job{}}
+	templateResult = envelope{"template": store.Template{}}
+	deckResult     = envelope{"deck": store.Deck{}}
+	deckAndVersion = envelope{"deck": store.Deck{}, "version": store.DeckVersion{}}
+	versionJobs    = envelope{"versionId": "", "jobs": []JobHistoryEntry{}, "lastExportedAt": (*time.Time)(nil)}
+	orgSettings    = envelope{"generationDefaults": store.GenerationParams{}, "ssoDomain": "", "ssoDomainVerification": &SSODomainVerification{}, "exportFilenameTemplate": "", "defaultRenderer": "", "googleConnected": false, "locale": "", "timezone": "", "language": "", "tone": "", "rtl": false, "slideSize": "", "storePrompts": false, "storageRegion": ""}
+	importResult   = envelope{"template": store.Template{}, "version": store.TemplateVersion{}, "warnings": []string{}, "validationErrors": []spec.ValidationError{}}
+)
+
+// apiDocs is keyed by the route pattern exactly as registered. A test checks
+// that every registered route has an entry and every entry a route.
+var apiDocs = map[string]apiDoc{
+	"GET /healthz":         {Summary: "Liveness probe", Response: envelope{"status": ""}},
+	"GET /readyz":          {Summary: "Readiness probe with dependency checks", Response: ReadinessResponse{}},
+	"GET /v1/openapi.json": {Summary: "This OpenAPI document", Response: envelope{}},
+
+	"POST /v1/auth/signup":             {Summary: "Create an account and organization", Request: SignupRequest{}, Response: envelope{"user": authUser, "token": ""}},
+	"GET /v1/auth/signup":              {Summary: "Not supported; use POST", Status: http.StatusMethodNotAllowed, Response: ErrorResponse{}},
+	"POST /v1/auth/signin":             {Summary: "Sign in with email and password", Request: SigninRequest{}, Response: envelope{"user": authUser, "token": ""}},
+	"POST /v1/auth/user":               {Summary: "Look up a user (legacy)", Request: LegacyUserRequest{}, Response: envelope{"user": authUser}},
+	"GET /v1/auth/oidc/providers":      {Summary: "List configured SSO providers", Response: envelope{"providers": []string{}}},
+	"GET /v1/auth/oidc/start":          {Summary: "Redirect to an SSO provider", Query: []string{"provider"}, Status: http.StatusFound},
+	"GET /v1/auth/oidc/callback":       {Summary: "Complete SSO sign-in", Query: []string{"code", "state", "error"}, Response: envelope{"user": authUser, "token": ""}},
+	"GET /v1/auth/me":                  {Summary: "Get the signed-in user", Response: envelope{"user": meResult}},
+	"PATCH /v1/auth/me":                {Summary: "Update the signed-in user's name, avatar and preferences", Request: UpdateProfileRequest{}, Response: envelope{"user": meResult}},
+	"POST /v1/auth/me/email":           {Summary: "Start an email change; mails a confirmation token to the new address", Request: ChangeEmailRequest{}, Status: http.StatusAccepted, Response: envelope{"pendingEmail": "", "expiresAt": time.Time{}}},
+	"POST /v1/auth/me/email/verify":    {Summary: "Confirm a pending email change", Request: VerifyEmailRequest{}, Response: envelope{"user": meResult}},
+	"POST /v1/templates/validate":      {Summary: "Validate a template spec", Request: spec.TemplateSpec{}, Response: envelope{"ok": true, "warnings": []string{}}},
+	"POST /v1/templates/analyze":       {Summary: "Suggest a template type and fields for a prompt", Request: AnalyzeTemplateRequest{}, Response: AnalyzeTemplateResponse{}},
+	"POST /v1/templates/import":        {Summary: "Import a .pptx file as a draft template", Upload: true, Response: importResult},
+	"POST /v1/templates/import/google": {Summary: "Import a Google Slides presentation as a draft template", Request: ImportGoogleSlidesRequest{}, Response: importResult},
+	"POST /v1/design/analyze":          {Summary: "Analyze slide content and suggest a design", Request: DesignAnalysisRequest{}, Response: EnhancedDesignAnalysisResponse{}},
+
+	"POST /v1/templates":                             {Summary: "Create an empty template", Request: CreateTemplateRequest{}, Response: templateResult},
+	"POST /v1/templates/generate":                    {Summary: "Generate a template with AI", Query: []string{"sync"}, Request: GenerateTemplateRequest{}, Status: http.StatusAccepted, Response: envelope{"template": store.Template{}, "job": store.Job{}}},
+	"GET /v1/templates":                              {Summary: "List templates", Query: []string{"status", "folder", "tag", "category", "q", "include"}, Response: envelope{"templates": []taggedTemplate{}}},
+	"GET /v1/templates/{id}":                         {Summary: "Get a template and its usage counts", Response: envelope{"template": store.Template{}, "usage": TemplateUsage{}}},
+	"PATCH /v1/templates/{id}":                       {Summary: "Rename a template or change its description, category, language or cover", Request: UpdateTemplateRequest{}, Response: templateResult},
+	"POST /v1/templates/{id}/versions":               {Summary: "Save a new template version", Request: CreateVersionRequest{}, Response: envelope{"template": store.Template{}, "version": store.TemplateVersion{}, "warnings": []string{}}},
+	"GET /v1/templates/{id}/versions":                {Summary: "List template versions", Response: envelope{"versions": []store.TemplateVersion{}}},
+	"GET /v1/templates/{id}/permissions":             {Summary: "Get a template's sharing settings", Response: resourceACL{}},
+	"PUT /v1/templates/{id}/permissions/{userId}":    {Summary: "Grant a user access to a template", Request: GrantPermissionRequest{}, Response: envelope{"grant": store.ResourcePermission{}}},
+	"DELETE /v1/templates/{id}/permissions/{userId}": {Summary: "Revoke a user's access to a template", Status: http.StatusNoContent},
+	"PUT /v1/templates/{id}/visibility":              {Summary: "Set who in the org can see a template", Request: UpdateVisibilityRequest{}, Response: envelope{"visibility": ""}},
+	"POST /v1/templates/{id}/submit-review":          {Summary: "Submit a template for review", Response: templateResult},
+	"POST /v1/templates/{id}/approve":                {Summary: "Approve a template in review", Response: templateResult},
+	"POST /v1/templates/{id}/reject":                 {Summary: "Send a template back to draft", Response: templateResult},
+	"POST /v1/templates/{id}/publish":                {Summary: "Publish an approved template", Response: templateResult},
+	"PUT /v1/templates/{id}/tags/{tagId}":            {Summary: "Tag a template", Status: http.StatusNoContent},
+	"DELETE /v1/templates/{id}/tags/{tagId}":         {Summary: "Untag a template", Status: http.StatusNoContent},
+	"PUT /v1/templates/{id}/folder":                  {Summary: "Move a template to a folder", Request: MoveToFolderRequest{}, Response: envelope{"folderId": ""}},
+
+	"POST /v1/decks/outline":                              {Summary: "Draft a deck outline from content", Request: CreateDeckOutlineRequest{}, Response: envelope{"outline": DeckOutline{}}},
+	"POST /v1/decks/{id}/batch-merge":                     {Summary: "Export a personalized copy of a deck per data row as one archive; the body is a JSON array of objects or a text/csv file", Request: []map[string]any{}, Query: []string{"nameBy", "quality", "renderer", "adjustContrast", "deterministic"}, Status: http.StatusAccepted, Response: envelope{"job": store.Job{}, "rows": []store.BatchMergeRow{}}},
+	"POST /v1/decks/bulk-export":                          {Summary: "Export several decks as one archive", Request: BulkExportRequest{}, Status: http.StatusAccepted, Response: envelope{"job": store.Job{}, "items": []store.BulkExportItem{}}},
+	"POST /v1/decks/merge":                                {Summary: "Build a deck from slides of other decks", Request: MergeDecksRequest{}, Response: deckAndVersion},
+	"POST /v1/decks":                                      {Summary: "Create a deck from a template version", Request: CreateDeckRequest{}, Status: http.StatusAccepted, Response: envelope{"deck": store.Deck{}, "job": store.Job{}}},
+	"GET /v1/decks":                                       {Summary: "List decks", Query: []string{"folder", "tag", "category", "q", "include"}, Response: envelope{"decks": []taggedDeck{}}},
+	"GET /v1/decks/{id}":                                  {Summary: "Get a deck and its export count", Response: envelope{"deck": store.Deck{}, "usage": DeckUsage{}}},
+	"PATCH /v1/decks/{id}":                                {Summary: "Rename a deck, replace its content or change its listing metadata", Request: UpdateDeckRequest{}, Response: deckResult},
+	"POST /v1/decks/{id}/versions":                        {Summary: "Save a new deck version", Request: CreateDeckVersionRequest{}, Response: deckAndVersion},
+	"GET /v1/decks/{id}/versions":                         {Summary: "List deck versions", Response: envelope{"versions": []store.DeckVersion{}}},
+	"POST /v1/decks/{id}/refine":                          {Summary: "Revise a deck with an AI instruction, saving the result as its next version", Request: RefineDeckRequest{}, Response: envelope{"deck": store.Deck{}, "version": store.DeckVersion{}, "refinement": store.DeckRefinement{}}},
+	"GET /v1/decks/{id}/refinements":                      {Summary: "List a deck's refinements, oldest first; instructions are only kept for orgs that store prompts", Response: envelope{"refinements": []store.DeckRefinement{}}},
+	"POST /v1/decks/{id}/translate":                       {Summary: "Translate a deck with AI into its linked deck for a language, mirroring the layout for right-to-left targets; 201 when the linked deck is created", Query: []string{"lang"}, Response: deckAndVersion},
+	"GET /v1/decks/{id}/translations":                     {Summary: "List the decks translated from a deck", Response: envelope{"decks": []store.Deck{}}},
+	"GET /v1/decks/{id}/exports":                          {Summary: "List export jobs across a deck's versions", Response: envelope{"exports": []store.Job{}, "deckId": "", "totalVersions": 0}},
+	"GET /v1/decks/{id}/present":                          {Summary: "Get a deck for presenting", Response: PresentResponse{}},
+	"GET /v1/decks/{id}/present/slides/{index}/thumbnail": {Summary: "Render one slide as a PNG", Query: []string{"v"}, ContentType: "image/png"},
+	"POST /v1/decks/{id}/embeds":                          {Summary: "Create an embed link for a deck", Request: CreateDeckEmbedRequest{}, Status: http.StatusCreated, Response: envelope{"embed": store.DeckEmbed{}, "token": "", "embedUrl": ""}},
+	"GET /v1/decks/{id}/embeds":                           {Summary: "List a deck's embed links", Response: envelope{"embeds": []store.DeckEmbed{}}},
+	"DELETE /v1/decks/{id}/embeds/{embedId}":              {Summary: "Revoke an embed link", Status: http.StatusNoContent},
+	"GET /v1/decks/{id}/permissions":                      {Summary: "Get a deck's sharing settings", Response: resourceACL{}},
+	"PUT /v1/decks/{id}/permissions/{userId}":             {Summary: "Grant a user access to a deck", Request: GrantPermissionRequest{}, Response: envelope{"grant": store.ResourcePermission{}}},
+	"DELETE /v1/decks/{id}/permissions/{userId}":          {Summary: "Revoke a user's access to a deck", Status: http.StatusNoContent},
+	"PUT /v1/decks/{id}/visibility":                       {Summary: "Set who in the org can see a deck", Request: UpdateVisibilityRequest{}, Response: envelope{"visibility": ""}},
+	"PUT /v1/decks/{id}/tags/{tagId}":                     {Summary: "Tag a deck", Status: http.StatusNoContent},
+	"DELETE /v1/decks/{id}/tags/{tagId}":                  {Summary: "Untag a deck", Status: http.StatusNoContent},
+	"PUT /v1/decks/{id}/folder":                           {Summary: "Move a deck to a folder", Request: MoveToFolderRequest{}, Response: envelope{"folderId": ""}},
+
+	"GET /v1/embed/decks/{token}":                          {Summary: "Read-only deck viewer for iframes", ContentType: "text/html"},
+	"GET /v1/embed/decks/{token}/slides/{index}/thumbnail": {Summary: "Render one slide of an embedded deck as a PNG", Query: []string{"v"}, ContentType: "image/png"},
+
+	"PATCH /v1/deck-versions/{versionId}/slides":                   {Summary: "Move, delete or insert slides", Request: EditSlidesRequest{}, Response: deckAndVersion},
+	"POST /v1/deck-versions/{versionId}/slides/{index}/regenerate": {Summary: "Rewrite one slide with AI, saving the deck with it replaced as the next version", Request: RegenerateSlideRequest{}, Response: deckAndVersion},
+	"POST /v1/deck-versions/{versionId}/transform":                 {Summary: "Shorten, expand, formalize, simplify or translate a deck version's text with AI, saving the result as the next version", Request: TransformDeckRequest{}, Response: deckAndVersion},
+	"GET /v1/deck-versions/{versionId}/review":                     {Summary: "List the figures in a version's AI-written text that the deck's content doesn't back up", Response: envelope{"versionId": "", "reviewRequired": []store.ReviewFlag{}}},
+	"POST /v1/deck-versions/{versionId}/export":                    {Summary: "Export a deck version as pptx, a bundle, a ZIP of slide PNGs, an animated GIF or Marp Markdown, optionally filling its {{name}} variables from data adding its open review comments as PowerPoint comments or compressing it toward a size target; an unchanged deck returns its cached pptx export with 200", Request: ExportDeckVersionRequest{}, Query: []string{"format", "quality", "renderer", "adjustContrast", "deterministic", "force", "comments", "width", "targetSizeMb"}, Status: http.StatusAccepted, Response: jobAccepted},
+	"POST /v1/deck-versions/{versionId}/comments":                  {Summary: "Comment on a deck version", Request: CreateCommentRequest{}, Status: http.StatusCreated, Response: envelope{"comment": store.Comment{}}},
+	"GET /v1/deck-versions/{versionId}/comments":                   {Summary: "List comment threads", Query: []string{"slide", "resolved"}, Response: envelope{"threads": []CommentThread{}}},
+	"GET /v1/deck-versions/{versionId}/jobs":                       {Summary: "List a deck version's jobs", Query: []string{"type"}, Response: versionJobs},
+	"POST /v1/comments/{commentId}/resolve":                        {Summary: "Resolve a comment thread", Response: envelope{"comment": store.Comment{}}},
+	"POST /v1/comments/{commentId}/unresolve":                      {Summary: "Reopen a comment thread", Response: envelope{"comment": store.Comment{}}},
+	"PATCH /v1/versions/{versionId}":                               {Summary: "Save an edited template version as a new version", Request: PatchVersionRequest{}, Response: envelope{"version": store.TemplateVersion{}, "warnings": []string{}}},
+	"POST /v1/versions/{versionId}/render":                         {Summary: "Render a template version", Status: http.StatusAccepted, Response: jobAccepted},
+	"POST /v1/versions/{versionId}/export":                         {Summary: "Export a template version as pptx, optionally compressed toward a size target, a bundle, a ZIP of slide PNGs, an animated GIF or Marp Markdown", Query: []string{"format", "quality", "renderer", "adjustContrast", "deterministic", "force", "width", "targetSizeMb"}, Response: envelope{"job": store.Job{}, "asset": envelope{"id": "", "downloadUrl": "", "sha256": ""}, "metadata": envelope{"filename": ""}}},
+	"GET /v1/versions/{versionId}/jobs":                            {Summary: "List a template version's jobs", Query: []string{"type"}, Response: versionJobs},
+
+	"GET /v1/assets":                         {Summary: "List assets", Query: []string{"type", "jobId", "limit", "offset", "createdAfter", "createdBefore"}, Response: envelope{"assets": []store.Asset{}, "total": 0, "limit": 0, "offset": 0, "totalBytes": int64(0)}},
+	"GET /v1/assets/{id}/download-url":       {Summary: "Get a short-lived download link", Response: envelope{"assetId": "", "downloadUrl": "", "sha256": ""}},
+	"GET /v1/assets/{id}":                    {Summary: "Download an asset", ContentType: "application/octet-stream"},
+	"DELETE /v1/assets/{id}":                 {Summary: "Delete an asset", Response: envelope{"deleted": true, "storage": StorageUsage{}}},
+	"POST /v1/jobs":                          {Summary: "Queue a job", Request: CreateJobRequest{}, Status: http.StatusAccepted, Response: jobAccepted},
+	"GET /v1/jobs/{jobId}":                   {Summary: "Get a job", Response: jobAccepted},
+	"GET /v1/jobs/{jobId}/assets/{filename}": {Summary: "Download a job's output", ContentType: "application/octet-stream"},
+
+	"GET /v1/admin/jobs/dead-letter":                  {Summary: "List the org's dead-lettered jobs", Response: envelope{"jobs": []store.Job{}}},
+	"POST /v1/admin/jobs/{jobId}/retry":               {Summary: "Retry a dead-lettered job", Response: envelope{"message": ""}},
+	"GET /v1/admin/ai/calls":                          {Summary: "List AI provider calls for debugging quality and spend", Query: []string{"orgId", "userId", "kind", "model", "success", "since", "until", "limit"}, Response: envelope{"calls": []store.AICall{}}},
+	"GET /v1/admin/platform/jobs":                     {Summary: "List jobs across all orgs", Query: []string{"orgId", "status", "type", "limit"}, Response: envelope{"jobs": []store.Job{}}},
+	"POST /v1/admin/platform/jobs/requeue":            {Summary: "Requeue jobs across orgs", Request: PlatformJobsRequest{}, Response: envelope{"requeued": []string{}, "skipped": []platformJobSkip{}}},
+	"POST /v1/admin/platform/jobs/purge":              {Summary: "Purge dead-lettered jobs", Request: PurgeDeadLetterRequest{}, Response: envelope{"purged": 0}},
+	"GET /v1/admin/platform/retry-policies":           {Summary: "List the effective job retry policies", Response: envelope{"policies": []queue.EffectivePolicy{}}},
+	"PUT /v1/admin/platform/retry-policies/{type}":    {Summary: "Override a job type's retry policy", Request: RetryPolicyRequest{}, Response: envelope{"override": store.RetryPolicyOverride{}, "policy": queue.EffectivePolicy{}}},
+	"DELETE /v1/admin/platform/retry-policies/{type}": {Summary: "Reset a job type's retry policy", Status: http.StatusNoContent},
+	"GET /v1/admin/platform/feature-flags":            {Summary: "List feature flags with their settings and org overrides", Response: envelope{"flags": []FeatureFlagStatus{}}},
+	"PUT /v1/admin/platform/feature-flags/{name}":     {Summary: "Set a feature flag platform-wide or for one org", Request: FeatureFlagRequest{}, Response: envelope{"flag": store.FeatureFlag{}}},
+	"DELETE /v1/admin/platform/feature-flags/{name}":  {Summary: "Remove a feature flag's platform-wide setting or org override", Query: []string{"orgId"}, Status: http.StatusNoContent},
+	"GET /v1/admin/platform/stats/orgs":               {Summary: "Count organizations platform-wide", Query: []string{"days"}, Response: envelope{"days": 0, "orgs": store.OrgCounts{}}},
+	"GET /v1/admin/platform/stats/active-users":       {Summary: "Count daily active users platform-wide", Query: []string{"days"}, Response: envelope{"days": 0, "activeUsers": []store.DailyCount{}}},
+	"GET /v1/admin/platform/stats/jobs":               {Summary: "Summarize job throughput and failure rates platform-wide", Query: []string{"days"}, Response: envelope{"days": 0, "total": JobThroughput{}, "types": []JobThroughput{}}},
+	"GET /v1/admin/platform/stats/ai-spend":           {Summary: "Summarize AI usage and cost per organization", Query: []string{"days"}, Response: envelope{"days": 0, "total": AISpend{}, "orgs": []AISpend{}}},
+	"GET /v1/admin/platform/stats/storage":            {Summary: "List the organizations storing the most asset bytes", Query: []string{"limit"}, Response: envelope{"totalBytes": int64(0), "orgCount": 0, "limitBytesPerOrg": int64(0), "orgs": []OrgStorage{}}},
+	"GET /v1/admin/platform/stats/cache":              {Summary: "Report the hit rate of the template and brand kit read cache", Response: envelope{"cache": cache.Stats{}}},
+	"POST /v1/admin/events/simulate":                  {Summary: "Emit a synthetic event", Request: SimulateEventRequest{}, Status: http.StatusAccepted, Response: envelope{"event": Event{}}},
+	"GET /v1/admin/db/diagnostics":                    {Summary: "Database diagnostics", Response: envelope{}},
+	"GET /v1/admin/db/query":                          {Summary: "Run a predefined diagnostic query", Query: []string{"q", "limit"}, Response: envelope{"query": "", "result": nil}},
+
+	"POST /v1/webhooks":                {Summary: "Register a webhook", Request: CreateWebhookRequest{}, Status: http.StatusCreated, Response: envelope{"webhook": store.Webhook{}, "secret": ""}},
+	"GET /v1/webhooks":                 {Summary: "List webhooks", Response: envelope{"webhooks": []store.Webhook{}}},
+	"PATCH /v1/webhooks/{id}":          {Summary: "Update a webhook", Request: UpdateWebhookRequest{}, Response: envelope{"webhook": store.Webhook{}, "secret": ""}},
+	"DELETE /v1/webhooks/{id}":         {Summary: "Delete a webhook", Status: http.StatusNoContent},
+	"GET /v1/webhooks/{id}/deliveries": {Summary: "List a webhook's recent deliveries", Query: []string{"limit"}, Response: envelope{"deliveries": []store.WebhookDelivery{}}},
+	"POST /v1/webhooks/{id}/test":      {Summary: "Send a test event to a webhook", Response: envelope{"delivery": store.WebhookDelivery{}}},
+
+	"POST /v1/brand-kits":       {Summary: "Create a brand kit", Request: CreateBrandKitRequest{}, Response: envelope{"brandKit": store.BrandKit{}}},
+	"GET /v1/brand-kits":        {Summary: "List brand kits", Response: envelope{"brandKits": []store.BrandKit{}}},
+	"POST /v1/fonts":            {Summary: "Upload a .ttf or .otf font for exports to embed", Upload: true, Status: http.StatusCreated, Response: envelope{"font": store.Font{}}},
+	"GET /v1/fonts":             {Summary: "List uploaded fonts and the standard fonts", Response: envelope{"fonts": []store.Font{}, "standardFonts": []string{}}},
+	"DELETE /v1/fonts/{id}":     {Summary: "Delete an uploaded font", Status: http.StatusNoContent},
+	"GET /v1/glossary":          {Summary: "List the org's terminology rules", Response: envelope{"entries": []store.GlossaryEntry{}}},
+	"POST /v1/glossary":         {Summary: "Add a preferred term, forbidden term or casing rule that AI output is held to", Request: GlossaryEntryRequest{}, Status: http.StatusCreated, Response: envelope{"entry": store.GlossaryEntry{}}},
+	"PUT /v1/glossary/{id}":     {Summary: "Replace a glossary entry", Request: GlossaryEntryRequest{}, Response: envelope{"entry": store.GlossaryEntry{}}},
+	"DELETE /v1/glossary/{id}":  {Summary: "Delete a glossary entry", Status: http.StatusNoContent},
+	"POST /v1/glossary/check":   {Summary: "Correct text against the glossary and list the forbidden terms left in it", Request: CheckGlossaryRequest{}, Response: envelope{"text": "", "violations": []string{}}},
+	"GET /v1/schedules":         {Summary: "List the org's recurring deck generation schedules", Response: envelope{"schedules": []store.Schedule{}}},
+	"POST /v1/schedules":        {Summary: "Schedule a deck to be generated from a template and exported on a cron cadence", Request: CreateScheduleRequest{}, Status: http.StatusCreated, Response: envelope{"schedule": store.Schedule{}}},
+	"GET /v1/schedules/{id}":    {Summary: "Get a schedule with its next and last run", Response: envelope{"schedule": store.Schedule{}}},
+	"PATCH /v1/schedules/{id}":  {Summary: "Change, pause or resume a schedule", Request: UpdateScheduleRequest{}, Response: envelope{"schedule": store.Schedule{}}},
+	"DELETE /v1/schedules/{id}": {Summary: "Delete a schedule; the decks it generated are kept", Status: http.StatusNoContent},
+	"GET /v1/folders":           {Summary: "List folders", Response: envelope{"folders": []store.Folder{}}},
+	"POST /v1/folders":          {Summary: "Create a folder", Request: CreateFolderRequest{}, Status: http.StatusCreated, Response: envelope{"folder": store.Folder{}}},
+	"PATCH /v1/folders/{id}":    {Summary: "Rename or move a folder", Request: UpdateFolderRequest{}, Response: envelope{"folder": store.Folder{}}},
+	"DELETE /v1/folders/{id}":   {Summary: "Delete an empty folder", Status: http.StatusNoContent},
+	"GET /v1/tags":              {Summary: "List tags", Response: envelope{"tags": []store.Tag{}}},
+	"POST /v1/tags":             {Summary: "Create a tag", Request: CreateTagRequest{}, Status: http.StatusCreated, Response: envelope{"tag": store.Tag{}}},
+	"PATCH /v1/tags/{id}":       {Summary: "Rename or recolor a tag", Request: UpdateTagRequest{}, Response: envelope{"tag": store.Tag{}}},
+	"DELETE /v1/tags/{id}":      {Summary: "Delete a tag", Status: http.StatusNoContent},
+
+	"GET /v1/connectors":               {Summary: "List the org's data connectors; credentials are never returned", Response: envelope{"connectors": []store.Connector{}}},
+	"POST /v1/connectors":              {Summary: "Register a CSV URL, Google Sheets range or SQL query whose fields decks bind as {{connector.<name>.<field>}}", Request: CreateConnectorRequest{}, Status: http.StatusCreated, Response: envelope{"connector": store.Connector{}}},
+	"GET /v1/connectors/{id}":          {Summary: "Get a connector with the fields its last fetched data binds", Response: envelope{"connector": store.Connector{}, "fields": map[string]string{}}},
+	"PATCH /v1/connectors/{id}":        {Summary: "Change a connector's source, credential or cache age", Request: UpdateConnectorRequest{}, Response: envelope{"connector": store.Connector{}}},
+	"DELETE /v1/connectors/{id}":       {Summary: "Delete a connector; decks binding it fail to export", Status: http.StatusNoContent},
+	"POST /v1/connectors/{id}/refresh": {Summary: "Fetch a connector's data now and list the fields it binds", Response: envelope{"connector": store.Connector{}, "fields": map[string]string{}}},
+
+	"GET /v1/usage":                       {Summary: "Get the org's quota usage", Response: UsageResponse{}},
+	"GET /v1/analytics/templates":         {Summary: "Rank templates by decks created from them, then by exports", Query: []string{"limit"}, Response: envelope{"templates": []TemplateRanking{}, "total": 0}},
+	"GET /v1/org/feature-flags":           {Summary: "Get the org's feature flags", Response: envelope{"flags": flags.Set{}}},
+	"GET /v1/org/settings":                {Summary: "Get org settings", Response: orgSettings},
+	"PUT /v1/org/settings":                {Summary: "Update org settings", Request: UpdateOrgSettingsRequest{}, Response: orgSettings},
+	"POST /v1/org/sso-domain/verify":      {Summary: "Verify the SSO domain claim through its DNS TXT record", Response: orgSettings},
+	"POST /v1/orgs/{id}/deletion-token":   {Summary: "Issue the token that confirms deleting the org (owners)", Response: envelope{"confirmationToken": "", "expiresAt": time.Time{}}},
+	"DELETE /v1/orgs/{id}":                {Summary: "Delete the org; its data is purged after a grace period (owners)", Request: DeleteOrgRequest{}, Status: http.StatusAccepted, Response: envelope{"organization": store.Organization{}}},
+	"GET /v1/orgs/{id}/export":            {Summary: "Download the org's templates, decks, specs and audit log as a ZIP (owners)", ContentType: "application/zip"},
+	"POST /v1/org/scim-token":             {Summary: "Issue a new SCIM token", Status: http.StatusCreated, Response: envelope{"token": "", "baseUrl": ""}},
+	"DELETE /v1/org/scim-token":           {Summary: "Revoke the SCIM token", Status: http.StatusNoContent},
+	"GET /v1/api-keys":                    {Summary: "List API keys (all of the org's for admins)", Response: envelope{"apiKeys": []store.APIKey{}}},
+	"POST /v1/api-keys":                   {Summary: "Create an API key acting as the caller", Request: CreateAPIKeyRequest{}, Status: http.StatusCreated, Response: envelope{"apiKey": store.APIKey{}, "key": ""}},
+	"DELETE /v1/api-keys/{id}":            {Summary: "Revoke an API key", Status: http.StatusNoContent},
+	"PUT /v1/org/google-credentials":      {Summary: "Connect a Google account for imports", Request: GoogleCredentialsRequest{}, Status: http.StatusNoContent},
+	"DELETE /v1/org/google-credentials":   {Summary: "Disconnect the Google account", Status: http.StatusNoContent},
+	"GET /v1/integrations/slack":          {Summary: "Get Slack notification settings", Response: envelope{"slack": slackIntegrationResponse{}}},
+	"PUT /v1/integrations/slack":          {Summary: "Connect Slack", Request: SlackIntegrationRequest{}, Response: envelope{"slack": slackIntegrationResponse{}}},
+	"DELETE /v1/integrations/slack":       {Summary: "Disconnect Slack", Status: http.StatusNoContent},
+	"POST /v1/integrations/slack/test":    {Summary: "Post a test notice to Slack", Status: http.StatusNoContent},
+	"GET /v1/integrations/stock-media":    {Summary: "Show whose keys stock photo search uses", Response: envelope{"stockMedia": stockMediaResponse{}}},
+	"PUT /v1/integrations/stock-media":    {Summary: "Set the org's Unsplash and Pexels API keys", Request: StockMediaKeysRequest{}, Response: envelope{"stockMedia": stockMediaResponse{}}},
+	"DELETE /v1/integrations/stock-media": {Summary: "Remove the org's stock photo API keys", Status: http.StatusNoContent},
+	"GET /v1/images/search":               {Summary: "Search stock photos or icons", Query: []string{"q", "kind", "provider", "limit"}, Response: envelope{"results": []stock.Result{}}},
+	"POST /v1/images/stock":               {Summary: "Cache a stock image for an image placeholder", Request: StockImageRequest{}, Response: envelope{"image": spec.Image{}}},
+	"POST /v1/backgrounds":                {Summary: "Generate an AI slide background from a deck's theme; a cached image returns 200 with {image, cached}", Request: GenerateBackgroundRequest{}, Status: http.StatusAccepted, Response: envelope{"job": store.Job{}, "prompt": "", "duplicate": false}},
+	"GET /v1/backgrounds":                 {Summary: "List the org's generated backgrounds", Response: envelope{"images": []store.GeneratedImage{}}},
+
+	"GET /v1/integrations/notion":             {Summary: "Show whether Notion is connected for page imports", Response: envelope{"notion": notionIntegrationResponse{}}},
+	"PUT /v1/integrations/notion":             {Summary: "Connect Notion with an internal integration token", Request: NotionIntegrationRequest{}, Response: envelope{"notion": notionIntegrationResponse{}}},
+	"DELETE /v1/integrations/notion":          {Summary: "Disconnect Notion", Status: http.StatusNoContent},
+	"POST /v1/integrations/notion/import":     {Summary: "Create a deck from a Notion page; 200 with {deck, version} when outline is set", Request: ImportPageRequest{}, Status: http.StatusAccepted, Response: envelope{"deck": store.Deck{}, "job": store.Job{}}},
+	"GET /v1/integrations/confluence":         {Summary: "Show the Confluence site page imports read from", Response: envelope{"confluence": confluenceIntegrationResponse{}}},
+	"PUT /v1/integrations/confluence":         {Summary: "Connect a Confluence site with an API or personal access token", Request: ConfluenceIntegrationRequest{}, Response: envelope{"confluence": confluenceIntegrationResponse{}}},
+	"DELETE /v1/integrations/confluence":      {Summary: "Disconnect Confluence", Status: http.StatusNoContent},
+	"POST /v1/integrations/confluence/import": {Summary: "Create a deck from a Confluence page; 200 with {deck, version} when outline is set", Request: ImportPageRequest{}, Status: http.StatusAccepted, Response: envelope{"deck": store.Deck{}, "job": store.Job{}}},
+
+	"GET /v1/org/policy":    {Summary: "Get the org's compliance policy: allowed templates, mandatory slides and content rules", Response: envelope{"policy": store.OrgPolicy{}}},
+	"PUT /v1/org/policy":    {Summary: "Replace the org's compliance policy (admins); decks breaking it fail with 422 ERR_POLICY_VIOLATION", Request: store.OrgPolicy{}, Response: envelope{"policy": store.OrgPolicy{}}},
+	"DELETE /v1/org/policy": {Summary: "Remove the org's compliance policy (admins)", Status: http.StatusNoContent},
+
+	"POST /v1/decks/{id}/upgrade-template": {Summary: "Rebuild a deck on a newer version of its source template, saving the next version; reports placeholder mappings and incompatibilities, and only reports them on a dry run", Request: UpgradeDeckTemplateRequest{}, Response: envelope{"deck": store.Deck{}, "version": store.DeckVersion{}, "report": service.TemplateUpgradeReport{}}},
+
+	"POST /v1/decks/from-markdown": {Summary: "Create a deck from a Marp or Markdown document; 202 with {deck, job, warnings} when design is set", Request: CreateDeckFromMarkdownRequest{}, Response: envelope{"deck": store.Deck{}, "version": store.DeckVersion{}, "warnings": []string{}}},
+}
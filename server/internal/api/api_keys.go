@@ -0,0 +1,175 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// apiKeyPrefix marks a bearer token as an API key rather than a JWT.
+const apiKeyPrefix = "cms_"
+
+// apiKeyUsedResolution limits last-used writes to one per key per interval.
+const apiKeyUsedResolution = time.Minute
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyAuthenticator accepts "Bearer cms_..." API keys and hands every other
+// request to next.
+type apiKeyAuthenticator struct {
+	s    *Server
+	next auth.Authenticator
+}
+
+// Authenticate resolves an API key to its creator. The key's role is capped
+// at the creator's current org role, so demoting a member demotes their keys;
+// removing them is caught by the membership check in withAuth.
+func (a apiKeyAuthenticator) Authenticate(r *http.Request) (auth.Identity, error) {
+	key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "+apiKeyPrefix)
+	if !ok {
+		return a.next.Authenticate(r)
+	}
+	k, found, err := a.s.Store.APIKeys().GetByHash(r.Context(), hashAPIKey(apiKeyPrefix+key))
+	if err != nil {
+		logger.LogError(r.Context(), "auth", "get_api_key", err)
+		return auth.Identity{}, auth.ErrUnauthenticated
+	}
+	if !found {
+		logger.LogAuthEvent(r.Context(), "api_key_auth", "", false)
+		return auth.Identity{}, auth.ErrUnauthenticated
+	}
+
+	// Without the creator's memberships the cap can't be applied, so the key
+	// is refused rather than trusted with the role it was issued with.
+	memberships, err := a.s.Store.Users().ListUserOrgs(r.Context(), k.UserID)
+	if err != nil {
+		logger.LogError(r.Context(), "auth", "list_api_key_user_orgs", err, "api_key_id", k.ID)
+		return auth.Identity{}, auth.ErrUnauthenticated
+	}
+	id := auth.Identity{UserID: k.UserID, OrgID: k.OrgID, Role: k.Role}
+	for _, m := range memberships {
+		if m.OrgID == k.OrgID && !auth.RequireRole(auth.Identity{Role: m.Role}, k.Role) {
+			id.Role = m.Role
+		}
+	}
+
+	now := time.Now().UTC()
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyUsedResolution {
+		if err := a.s.Store.APIKeys().MarkUsed(r.Context(), k.ID, now); err != nil {
+			logger.LogError(r.Context(), "auth", "mark_api_key_used", err, "api_key_id", k.ID)
+		}
+	}
+	return id, nil
+}
+
+// handleCreateAPIKey issues a key that acts as the caller, with the caller's
+// role unless a lower one is asked for. The key is only returned here.
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	role := id.Role
+	if req.Role != "" {
+		role = auth.Role(req.Role)
+	}
+	if !auth.RequireRole(id, role) {
+		writeError(w, r, http.StatusForbidden, "cannot issue a key with a higher role than your own")
+		return
+	}
+
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		logger.LogError(r.Context(), "api", "create_api_key", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to generate key")
+		return
+	}
+	key := apiKeyPrefix + hex.EncodeToString(b[:])
+	created, err := s.Store.APIKeys().Create(r.Context(), store.APIKey{
+		ID:      newID("key"),
+		OrgID:   id.OrgID,
+		UserID:  id.UserID,
+		Name:    req.Name,
+		Role:    role,
+		Prefix:  key[:len(apiKeyPrefix)+8],
+		KeyHash: hashAPIKey(key),
+	})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "create_api_key", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to create API key")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "api_key.create", TargetRef: created.ID, Metadata: map[string]any{"name": created.Name, "role": created.Role}})
+	writeJSON(w, http.StatusCreated, map[string]any{"apiKey": created, "key": key})
+}
+
+// handleListAPIKeys lists the caller's own keys, or every key in the org for
+// admins.
+func (s *Server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	keys, err := s.Store.APIKeys().List(r.Context(), id.OrgID, apiKeyOwnerFilter(id))
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_api_keys", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to list API keys")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"apiKeys": keys})
+}
+
+// handleDeleteAPIKey revokes a key. Members may revoke their own keys and
+// admins any key in the org.
+func (s *Server) handleDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	keyID := r.PathValue("id")
+
+	keys, err := s.Store.APIKeys().List(r.Context(), id.OrgID, apiKeyOwnerFilter(id))
+	if err != nil {
+		logger.LogError(r.Context(), "api", "delete_api_key", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to revoke API key")
+		return
+	}
+	owned := false
+	for _, k := range keys {
+		owned = owned || k.ID == keyID
+	}
+	if !owned {
+		writeError(w, r, http.StatusNotFound, "API key not found")
+		return
+	}
+	if _, err := s.Store.APIKeys().Delete(r.Context(), id.OrgID, keyID); err != nil {
+		logger.LogError(r.Context(), "api", "delete_api_key", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to revoke API key")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "api_key.revoke", TargetRef: keyID})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiKeyOwnerFilter scopes key listings to the caller unless they are an
+// admin.
+func apiKeyOwnerFilter(id auth.Identity) string {
+	if auth.RequireRole(id, auth.RoleAdmin) {
+		return ""
+	}
+	return id.UserID
+}
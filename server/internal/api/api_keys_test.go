@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// failingUserOrgsStore fails every ListUserOrgs.
+type failingUserOrgsStore struct{ store.Store }
+
+func (f failingUserOrgsStore) Users() store.UserStore { return failingUserOrgs{f.Store.Users()} }
+
+type failingUserOrgs struct{ store.UserStore }
+
+func (failingUserOrgs) ListUserOrgs(context.Context, string) ([]store.UserOrg, error) {
+	return nil, errors.New("list failed")
+}
+
+func TestAPIKeys_AuthenticateAsCreatorWithCappedRole(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "user-admin", Email: "admin@example.com"}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "user-admin", OrgID: "org-1", Role: auth.RoleAdmin}))
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "user-viewer", Email: "viewer@example.com"}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "user-viewer", OrgID: "org-1", Role: auth.RoleViewer}))
+
+	withJWT := func(method, path, userID string, role auth.Role, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, userID, "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	withKey := func(method, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	type created struct {
+		APIKey store.APIKey `json:"apiKey"`
+		Key    string       `json:"key"`
+	}
+	create := func(userID string, role auth.Role, body string) created {
+		w := withJWT(http.MethodPost, "/v1/api-keys", userID, role, body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var out created
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		return out
+	}
+
+	assert.Equal(t, http.StatusForbidden, withJWT(http.MethodPost, "/v1/api-keys", "user-viewer", auth.RoleViewer, `{"name":"ci","role":"Editor"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, withJWT(http.MethodPost, "/v1/api-keys", "user-viewer", auth.RoleViewer, `{"name":"ci","role":"Root"}`).Code)
+
+	adminKey := create("user-admin", auth.RoleAdmin, `{"name":"deploy pipeline"}`)
+	assert.Equal(t, auth.RoleAdmin, adminKey.APIKey.Role)
+	assert.True(t, strings.HasPrefix(adminKey.Key, apiKeyPrefix))
+	assert.True(t, strings.HasPrefix(adminKey.Key, adminKey.APIKey.Prefix))
+	viewerKey := create("user-viewer", auth.RoleViewer, `{"name":"dashboard"}`)
+
+	// The key acts as its creator.
+	w := withKey(http.MethodPost, "/v1/folders", adminKey.Key, `{"name":"From CI"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var folder struct {
+		Folder store.Folder `json:"folder"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &folder))
+	assert.Equal(t, "org-1", folder.Folder.OrgID)
+	assert.Equal(t, http.StatusUnauthorized, withKey(http.MethodGet, "/v1/folders", apiKeyPrefix+"unknown", "").Code)
+
+	// Demoting the creator demotes the key.
+	assert.Equal(t, http.StatusOK, withKey(http.MethodGet, "/v1/api-keys", adminKey.Key, "").Code)
+	require.NoError(t, s.Store.Users().SetUserOrgRole(ctx, "user-admin", "org-1", auth.RoleViewer))
+	assert.Equal(t, http.StatusForbidden, withKey(http.MethodPost, "/v1/folders", adminKey.Key, `{"name":"Denied"}`).Code)
+	require.NoError(t, s.Store.Users().SetUserOrgRole(ctx, "user-admin", "org-1", auth.RoleAdmin))
+
+	// Members see and revoke only their own keys; admins see all.
+	w = withJWT(http.MethodGet, "/v1/api-keys", "user-viewer", auth.RoleViewer, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		APIKeys []store.APIKey `json:"apiKeys"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.APIKeys, 1)
+	assert.Equal(t, viewerKey.APIKey.ID, list.APIKeys[0].ID)
+	assert.NotContains(t, w.Body.String(), viewerKey.Key)
+
+	w = withJWT(http.MethodGet, "/v1/api-keys", "user-admin", auth.RoleAdmin, "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.APIKeys, 2)
+	require.NotNil(t, list.APIKeys[0].LastUsedAt, "use is recorded")
+
+	assert.Equal(t, http.StatusNotFound, withJWT(http.MethodDelete, "/v1/api-keys/"+adminKey.APIKey.ID, "user-viewer", auth.RoleViewer, "").Code)
+	assert.Equal(t, http.StatusNoContent, withJWT(http.MethodDelete, "/v1/api-keys/"+viewerKey.APIKey.ID, "user-admin", auth.RoleAdmin, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, withKey(http.MethodGet, "/v1/folders", viewerKey.Key, "").Code, "revoked keys stop working")
+}
+
+func TestAPIKeys_RefusedWhenMembershipsCantBeRead(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "user-admin", Email: "admin@example.com"}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "user-admin", OrgID: "org-1", Role: auth.RoleAdmin}))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/api-keys", strings.NewReader(`{"name":"ci"}`))
+	req.Header.Set("Content-Type", "application/json")
+	addTestAuth(req, "user-admin", "org-1", auth.RoleAdmin)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Key string `json:"key"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	s.Store = failingUserOrgsStore{s.Store}
+	req = httptest.NewRequest(http.MethodGet, "/v1/folders", nil)
+	req.Header.Set("Authorization", "Bearer "+created.Key)
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "the key's role can't be capped, so it isn't trusted")
+}
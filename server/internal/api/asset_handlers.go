@@ -1,16 +1,102 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"mime"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ziyad/cms-ai/server/internal/assets"
 	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
 	"github.com/ziyad/cms-ai/server/internal/store"
 )
 
-// handleAssetDownload handles GET /v1/assets/{id}
+const (
+	defaultAssetPageSize = 50
+	maxAssetPageSize     = 200
+)
+
+// handleListAssets handles GET /v1/assets
+// Supported query params: type, jobId, createdAfter, createdBefore (RFC3339), limit, offset.
+func (s *Server) handleListAssets(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	q := r.URL.Query()
+
+	filter := store.AssetFilter{
+		Type:        store.AssetType(q.Get("type")),
+		SourceJobID: q.Get("jobId"),
+		Limit:       defaultAssetPageSize,
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, r, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		if n > maxAssetPageSize {
+			n = maxAssetPageSize
+		}
+		filter.Limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, r, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		filter.Offset = n
+	}
+	if v := q.Get("createdAfter"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "createdAfter must be an RFC3339 timestamp")
+			return
+		}
+		filter.CreatedAfter = &t
+	}
+	if v := q.Get("createdBefore"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "createdBefore must be an RFC3339 timestamp")
+			return
+		}
+		filter.CreatedBefore = &t
+	}
+
+	list, total, err := s.Store.Assets().List(r.Context(), id.OrgID, filter)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "failed to list assets")
+		return
+	}
+	totalBytes, err := s.Store.Assets().TotalBytes(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "failed to compute storage usage")
+		return
+	}
+	if list == nil {
+		list = []store.Asset{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"assets":     list,
+		"total":      total,
+		"limit":      filter.Limit,
+		"offset":     filter.Offset,
+		"totalBytes": totalBytes,
+	})
+}
+
+// handleAssetDownload handles GET /v1/assets/{id}. Bytes served from here
+// are checked against the asset's checksum first. Checking an object before
+// redirecting to its signed URL would mean downloading it anyway, so the
+// redirect carries the checksum as Repr-Digest for the client to check the
+// file against instead.
 func (s *Server) handleAssetDownload(w http.ResponseWriter, r *http.Request) {
 	id, _ := auth.GetIdentity(r.Context())
 	assetID := r.PathValue("id")
@@ -27,29 +113,67 @@ func (s *Server) handleAssetDownload(w http.ResponseWriter, r *http.Request) {
 	// Try to get signed URL first.
 	// If the storage returns a relative URL (local storage), don't redirect because
 	// the API server is not serving that path; instead stream the bytes directly.
-	signedURL, err := s.ObjectStorage.GetURL(r.Context(), asset.Path, 15*time.Minute)
+	signedURL, err := s.ObjectStorage.GetURL(assets.WithRegion(r.Context(), asset.Region), asset.Path, 15*time.Minute)
 	if err == nil {
 		if strings.HasPrefix(signedURL, "http://") || strings.HasPrefix(signedURL, "https://") {
 			// Redirect to a real signed URL (S3, etc.)
+			if digest := assets.DigestHeader(asset.SHA256); digest != "" {
+				w.Header().Set("Repr-Digest", digest)
+			}
 			http.Redirect(w, r, signedURL, http.StatusTemporaryRedirect)
 			return
 		}
 	}
 
 	// Fallback: direct download
-	data, err := s.ObjectStorage.Download(r.Context(), asset.Path)
+	data, ok := s.downloadAsset(w, r, asset)
+	if !ok {
+		return
+	}
+
+	serveAsset(w, r, asset, s.assetFilename(r.Context(), asset), data)
+}
+
+// downloadAsset reads asset's object and checks it against the checksum
+// recorded at upload, so a corrupted object is reported instead of served.
+func (s *Server) downloadAsset(w http.ResponseWriter, r *http.Request, asset store.Asset) ([]byte, bool) {
+	data, err := s.ObjectStorage.Download(assets.WithRegion(r.Context(), asset.Region), asset.Path)
 	if err != nil {
 		writeError(w, r, http.StatusInternalServerError, "failed to download asset")
-		return
+		return nil, false
+	}
+	if err := assets.VerifyChecksum(data, asset.SHA256); err != nil {
+		logger.LogError(r.Context(), "api", "asset_checksum_mismatch", err, "asset_id", asset.ID, "path", asset.Path)
+		writeError(w, r, http.StatusInternalServerError, "stored asset is corrupted")
+		return nil, false
 	}
+	return data, true
+}
 
-	// Determine appropriate filename based on asset type
-	filename := assetID
+// assetFilename names a download after the asset's own Filename or, for
+// older assets, the "filename" its export job was given, falling back to the
+// asset ID plus an extension for its type.
+func (s *Server) assetFilename(ctx context.Context, asset store.Asset) string {
+	if asset.Filename != "" {
+		return filepath.Base(asset.Filename)
+	}
+	if asset.SourceJobID != "" {
+		job, ok, err := s.Store.Jobs().Get(ctx, asset.OrgID, asset.SourceJobID)
+		if err == nil && ok && job.Metadata != nil {
+			if name := strings.TrimSpace((*job.Metadata)["filename"]); name != "" {
+				return filepath.Base(name)
+			}
+		}
+	}
+
+	filename := asset.ID
 	switch asset.Type {
 	case store.AssetPPTX:
 		filename += ".pptx"
 	case store.AssetPNG:
 		filename += ".png"
+	case store.AssetZIP:
+		filename += ".zip"
 	default:
 		// For generic files, try to extract from path or use generic extension
 		if ext := filepath.Ext(asset.Path); ext != "" {
@@ -58,10 +182,60 @@ func (s *Server) handleAssetDownload(w http.ResponseWriter, r *http.Request) {
 			filename += ".bin"
 		}
 	}
+	return filename
+}
 
-	w.Header().Set("Content-Type", asset.Mime)
-	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
-	w.Write(data)
+// serveAsset writes an asset's bytes with download headers. http.ServeContent
+// takes care of Content-Length, Range requests and the conditional headers;
+// stored objects never change under an asset ID, so the ID is a strong ETag.
+// Repr-Digest carries the checksum for clients to verify the file against.
+func serveAsset(w http.ResponseWriter, r *http.Request, asset store.Asset, filename string, data []byte) {
+	if asset.Mime != "" {
+		w.Header().Set("Content-Type", asset.Mime)
+	}
+	if digest := assets.DigestHeader(asset.SHA256); digest != "" {
+		w.Header().Set("Repr-Digest", digest)
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("ETag", `"`+asset.ID+`"`)
+	http.ServeContent(w, r, filename, asset.CreatedAt, bytes.NewReader(data))
+}
+
+// handleDeleteAsset handles DELETE /v1/assets/{id}. The stored object goes
+// first so a storage failure leaves the record in place for a retry instead
+// of orphaning the bytes.
+func (s *Server) handleDeleteAsset(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+	assetID := r.PathValue("id")
+	asset, ok, err := s.Store.Assets().Get(r.Context(), id.OrgID, assetID)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "failed")
+		return
+	}
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+
+	if asset.Path != "" {
+		if err := s.ObjectStorage.Delete(assets.WithRegion(r.Context(), asset.Region), asset.Path); err != nil {
+			logger.LogError(r.Context(), "api", "delete_asset_object", err, "asset_id", asset.ID)
+			writeError(w, r, http.StatusInternalServerError, "failed to delete asset object")
+			return
+		}
+	}
+	if _, err := s.Store.Assets().Delete(r.Context(), id.OrgID, asset.ID); err != nil {
+		logger.LogError(r.Context(), "api", "delete_asset", err, "asset_id", asset.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete asset")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "asset.delete", TargetRef: asset.ID, Metadata: map[string]any{"type": asset.Type, "sizeBytes": asset.SizeBytes}})
+
+	writeJSON(w, http.StatusOK, map[string]any{"deleted": true, "storage": s.storageUsage(r)})
 }
 
 // handleJobAssetDownload handles GET /v1/jobs/{jobId}/assets/{filename}
@@ -106,6 +280,8 @@ func (s *Server) handleJobAssetDownload(w http.ResponseWriter, r *http.Request)
 		expectedFilename += ".pptx"
 	case store.AssetPNG:
 		expectedFilename += ".png"
+	case store.AssetZIP:
+		expectedFilename += ".zip"
 	}
 
 	// Allow partial matches or exact matches
@@ -117,23 +293,23 @@ func (s *Server) handleJobAssetDownload(w http.ResponseWriter, r *http.Request)
 	// Try to get signed URL first.
 	// If the storage returns a relative URL (local storage), don't redirect because
 	// the API server is not serving that path; instead stream the bytes directly.
-	signedURL, err := s.ObjectStorage.GetURL(r.Context(), asset.Path, 15*time.Minute)
+	signedURL, err := s.ObjectStorage.GetURL(assets.WithRegion(r.Context(), asset.Region), asset.Path, 15*time.Minute)
 	if err == nil {
 		if strings.HasPrefix(signedURL, "http://") || strings.HasPrefix(signedURL, "https://") {
 			// Redirect to a real signed URL (S3, etc.)
+			if digest := assets.DigestHeader(asset.SHA256); digest != "" {
+				w.Header().Set("Repr-Digest", digest)
+			}
 			http.Redirect(w, r, signedURL, http.StatusTemporaryRedirect)
 			return
 		}
 	}
 
 	// Fallback: direct download
-	data, err := s.ObjectStorage.Download(r.Context(), asset.Path)
-	if err != nil {
-		writeError(w, r, http.StatusInternalServerError, "failed to download asset")
+	data, ok := s.downloadAsset(w, r, asset)
+	if !ok {
 		return
 	}
 
-	w.Header().Set("Content-Type", asset.Mime)
-	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
-	w.Write(data)
+	serveAsset(w, r, asset, filename, data)
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,7 +10,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
 )
 
 // MockObjectStorage implements ObjectStorage for testing
@@ -233,3 +238,176 @@ func TestAssetDownloadHandlers(t *testing.T) {
 		})
 	}
 }
+
+func TestListAssets(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+
+	for _, a := range []store.Asset{
+		{ID: "asset-1", OrgID: "org-1", Type: store.AssetPPTX, SizeBytes: 1024, SourceJobID: "job-1"},
+		{ID: "asset-2", OrgID: "org-1", Type: store.AssetPNG, SizeBytes: 256, SourceJobID: "job-2"},
+		{ID: "asset-3", OrgID: "org-2", Type: store.AssetPPTX, SizeBytes: 4096},
+	} {
+		_, err := s.Store.Assets().Create(ctx, a)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{name: "all org assets", query: "", expectedStatus: http.StatusOK, expectedCount: 2},
+		{name: "filter by type", query: "?type=pptx", expectedStatus: http.StatusOK, expectedCount: 1},
+		{name: "filter by job", query: "?jobId=job-2", expectedStatus: http.StatusOK, expectedCount: 1},
+		{name: "pagination", query: "?limit=1&offset=1", expectedStatus: http.StatusOK, expectedCount: 1},
+		{name: "invalid limit", query: "?limit=abc", expectedStatus: http.StatusBadRequest},
+		{name: "invalid date", query: "?createdAfter=yesterday", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/assets"+tt.query, nil)
+			addTestAuth(req, "user-1", "org-1", "Viewer")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Assets     []store.Asset `json:"assets"`
+				Total      int           `json:"total"`
+				TotalBytes int64         `json:"totalBytes"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Len(t, resp.Assets, tt.expectedCount)
+			assert.Equal(t, int64(1280), resp.TotalBytes)
+		})
+	}
+}
+
+func TestAssetDownload_FilenameETagAndRange(t *testing.T) {
+	s := NewServer()
+	storage := &LocalURLObjectStorage{}
+	s.ObjectStorage = storage
+	h := s.Handler()
+	ctx := context.Background()
+
+	_, err := storage.Upload(ctx, "obj.pptx", []byte("0123456789"), "application/vnd.openxmlformats-officedocument.presentationml.presentation")
+	require.NoError(t, err)
+	metadata := store.JSONMap{"filename": "deck-export-v2-20260101-120000.pptx"}
+	_, err = s.Store.Jobs().Enqueue(ctx, store.Job{ID: "job-dl", OrgID: "org-1", Type: store.JobExport, Status: store.JobDone, Metadata: &metadata})
+	require.NoError(t, err)
+	_, err = s.Store.Assets().Create(ctx, store.Asset{ID: "asset-dl", OrgID: "org-1", Type: store.AssetPPTX, Path: "obj.pptx", Mime: "application/vnd.openxmlformats-officedocument.presentationml.presentation", SizeBytes: 10, SourceJobID: "job-dl"})
+	require.NoError(t, err)
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/assets/asset-dl", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		addTestAuth(req, "user-1", "org-1", "Viewer")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `attachment; filename=deck-export-v2-20260101-120000.pptx`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "10", w.Header().Get("Content-Length"))
+	assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	w = get("Range", "bytes=4-")
+	require.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "456789", w.Body.String())
+	assert.Equal(t, "bytes 4-9/10", w.Header().Get("Content-Range"))
+
+	w = get("If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
+
+func TestAssetDownload_VerifiesChecksum(t *testing.T) {
+	s := NewServer()
+	storage := &LocalURLObjectStorage{}
+	s.ObjectStorage = storage
+	h := s.Handler()
+	ctx := context.Background()
+
+	_, err := storage.Upload(ctx, "good.pptx", []byte("0123456789"), "application/octet-stream")
+	require.NoError(t, err)
+	_, err = storage.Upload(ctx, "bad.pptx", []byte("01234567XX"), "application/octet-stream")
+	require.NoError(t, err)
+	sum := assets.Checksum([]byte("0123456789"))
+	for id, path := range map[string]string{"asset-good": "good.pptx", "asset-bad": "bad.pptx"} {
+		_, err = s.Store.Assets().Create(ctx, store.Asset{ID: id, OrgID: "org-1", Type: store.AssetPPTX, Path: path, SizeBytes: 10, SHA256: sum})
+		require.NoError(t, err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		addTestAuth(req, "user-1", "org-1", "Viewer")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/v1/assets/asset-good")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "sha-256=:hNiYd/DUBB77a/kaFvAkjy/Vc+avBcGflr7bn4gveII=:", w.Header().Get("Repr-Digest"))
+
+	w = get("/v1/assets/asset-bad")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "01234567XX", "corrupted bytes are not served")
+
+	w = get("/v1/assets/asset-good/download-url")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), sum)
+}
+
+func TestDeleteAsset_RemovesObjectAndFreesStorage(t *testing.T) {
+	s := NewServer()
+	storage := NewMockObjectStorage()
+	s.ObjectStorage = storage
+	h := s.Handler()
+	ctx := context.Background()
+
+	_, err := storage.Upload(ctx, "obj-del.pptx", []byte("0123456789"), "application/octet-stream")
+	require.NoError(t, err)
+	_, err = s.Store.Assets().Create(ctx, store.Asset{ID: "asset-del", OrgID: "org-1", Type: store.AssetPPTX, Path: "obj-del.pptx", SizeBytes: 10})
+	require.NoError(t, err)
+
+	del := func(role auth.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/v1/assets/asset-del", nil)
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, del(auth.RoleViewer).Code)
+
+	w := del(auth.RoleEditor)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Deleted bool         `json:"deleted"`
+		Storage StorageUsage `json:"storage"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Deleted)
+	assert.Equal(t, int64(0), resp.Storage.UsedBytes)
+
+	exists, _ := storage.Exists(ctx, "obj-del.pptx")
+	assert.False(t, exists)
+	_, ok, err := s.Store.Assets().Get(ctx, "org-1", "asset-del")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.Equal(t, http.StatusNotFound, del(auth.RoleEditor).Code)
+}
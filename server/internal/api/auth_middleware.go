@@ -1,14 +1,26 @@
 package api
 
 import (
+	"context"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
 )
 
 type ctxKeyIdentity struct{}
 
-func withAuth(a auth.Authenticator) func(http.Handler) http.Handler {
+// membershipCacheTTL bounds how long a removed member (e.g. deprovisioned via
+// SCIM on another instance) can keep using an unexpired token.
+const membershipCacheTTL = 30 * time.Second
+
+// withAuth authenticates requests and passes each identity through resolve,
+// which fills in what the credential doesn't carry and reports whether its
+// membership is still active.
+func withAuth(a auth.Authenticator, resolve func(ctx context.Context, id auth.Identity) (auth.Identity, bool)) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			id, err := a.Authenticate(r)
@@ -16,8 +28,134 @@ func withAuth(a auth.Authenticator) func(http.Handler) http.Handler {
 				writeError(w, r, http.StatusUnauthorized, "unauthorized")
 				return
 			}
-			r = r.WithContext(auth.WithIdentity(r.Context(), id))
+			if resolve != nil {
+				var active bool
+				if id, active = resolve(r.Context(), id); !active {
+					writeError(w, r, http.StatusUnauthorized, "membership revoked")
+					return
+				}
+			}
+			ctx := auth.WithIdentity(r.Context(), id)
+			ctx = logger.ContextWithIdentity(ctx, id.UserID, id.OrgID)
+			r = r.WithContext(ctx)
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+type membershipEntry struct {
+	ok            bool
+	platformAdmin bool
+	// role is the user's role in the org, empty for users the store doesn't
+	// know; tokens are capped at it.
+	role    auth.Role
+	expires time.Time
+}
+
+type membershipCache struct {
+	mu        sync.Mutex
+	entries   map[string]membershipEntry
+	lastPrune time.Time
+}
+
+func newMembershipCache() *membershipCache {
+	return &membershipCache{entries: map[string]membershipEntry{}}
+}
+
+func (c *membershipCache) get(key string) (membershipEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || !time.Now().Before(e.expires) {
+		return membershipEntry{}, false
+	}
+	return e, true
+}
+
+// put caches e under key, first dropping expired entries if it hasn't for a
+// TTL, so users who stop calling don't stay in the map.
+func (c *membershipCache) put(key string, e membershipEntry) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.lastPrune) >= membershipCacheTTL {
+		for k, old := range c.entries {
+			if !now.Before(old.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastPrune = now
+	}
+	c.entries[key] = e
+}
+
+func (c *membershipCache) invalidate(userID, orgID string) {
+	c.mu.Lock()
+	delete(c.entries, userID+"|"+orgID)
+	c.mu.Unlock()
+}
+
+// invalidateOrg forgets every cached membership in orgID.
+func (c *membershipCache) invalidateOrg(orgID string) {
+	c.mu.Lock()
+	for key := range c.entries {
+		if strings.HasSuffix(key, "|"+orgID) {
+			delete(c.entries, key)
+		}
+	}
+	c.mu.Unlock()
+}
+
+// resolveIdentity rejects tokens whose user still exists but no longer
+// belongs to the token's org, and every token for a deleted org. Tokens for
+// users unknown to the store are otherwise accepted, as before, since
+// identities may come from outside the user table. A member's token is
+// capped at their current role, so a demotion (through SCIM, say) takes
+// effect within membershipCacheTTL rather than when the token expires, and
+// PlatformAdmin is set from the user's account the same way. If the user or
+// their memberships can't be read the token is refused.
+func (s *Server) resolveIdentity(ctx context.Context, id auth.Identity) (auth.Identity, bool) {
+	key := id.UserID + "|" + id.OrgID
+	e, ok := s.membership.get(key)
+	if !ok {
+		var err error
+		if e, err = s.lookupMembership(ctx, id); err != nil {
+			logger.LogError(ctx, "auth", "resolve_identity", err, "user_id", id.UserID, "org_id", id.OrgID)
+			return id, false
+		}
+		e.expires = time.Now().Add(membershipCacheTTL)
+		s.membership.put(key, e)
+	}
+	id.PlatformAdmin = e.platformAdmin
+	if e.role != "" && !auth.RequireRole(auth.Identity{Role: e.role}, id.Role) {
+		id.Role = e.role
+	}
+	return id, e.ok
+}
+
+func (s *Server) lookupMembership(ctx context.Context, id auth.Identity) (membershipEntry, error) {
+	e := membershipEntry{ok: true}
+	user, exists, err := s.Store.Users().GetUser(ctx, id.UserID)
+	if err != nil {
+		return e, err
+	}
+	if exists {
+		e.platformAdmin = user.PlatformAdmin
+		memberships, err := s.Store.Users().ListUserOrgs(ctx, id.UserID)
+		if err != nil {
+			return e, err
+		}
+		e.ok = false
+		for _, m := range memberships {
+			if m.OrgID == id.OrgID {
+				e.ok, e.role = true, m.Role
+				break
+			}
+		}
+	}
+	// Orgs missing from the store are allowed like unknown users are.
+	if org, err := s.Store.Organizations().GetOrganization(ctx, id.OrgID); err == nil && org.DeletedAt != nil {
+		e.ok = false
+	}
+	return e, nil
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// failingGetUserStore fails every GetUser.
+type failingGetUserStore struct{ store.Store }
+
+func (f failingGetUserStore) Users() store.UserStore { return failingGetUser{f.Store.Users()} }
+
+type failingGetUser struct{ store.UserStore }
+
+func (failingGetUser) GetUser(context.Context, string) (store.User, bool, error) {
+	return store.User{}, false, errors.New("get failed")
+}
+
+func TestResolveIdentity_CapsRoleAtMembership(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "user-1", Email: "user@example.com"}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "user-1", OrgID: "org-1", Role: auth.RoleEditor}))
+
+	id, ok := s.resolveIdentity(ctx, auth.Identity{UserID: "user-1", OrgID: "org-1", Role: auth.RoleOwner})
+	require.True(t, ok)
+	assert.Equal(t, auth.RoleEditor, id.Role, "the token can't outrank the membership")
+	id, ok = s.resolveIdentity(ctx, auth.Identity{UserID: "user-1", OrgID: "org-1", Role: auth.RoleViewer})
+	require.True(t, ok)
+	assert.Equal(t, auth.RoleViewer, id.Role, "a lower token role is kept")
+
+	_, ok = s.resolveIdentity(ctx, auth.Identity{UserID: "user-1", OrgID: "org-2", Role: auth.RoleViewer})
+	assert.False(t, ok, "not a member of org-2")
+	id, ok = s.resolveIdentity(ctx, auth.Identity{UserID: "outside-1", OrgID: "org-1", Role: auth.RoleAdmin})
+	require.True(t, ok, "users outside the user table are accepted")
+	assert.Equal(t, auth.RoleAdmin, id.Role)
+}
+
+func TestResolveIdentity_RefusedOnStoreErrors(t *testing.T) {
+	for name, wrap := range map[string]func(store.Store) store.Store{
+		"get user":  func(st store.Store) store.Store { return failingGetUserStore{st} },
+		"list orgs": func(st store.Store) store.Store { return failingUserOrgsStore{st} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := NewServer()
+			ctx := context.Background()
+			require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "user-1", Email: "user@example.com"}))
+			require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "user-1", OrgID: "org-1", Role: auth.RoleAdmin}))
+			s.Store = wrap(s.Store)
+
+			_, ok := s.resolveIdentity(ctx, auth.Identity{UserID: "user-1", OrgID: "org-1", Role: auth.RoleAdmin})
+			assert.False(t, ok)
+			assert.Empty(t, s.membership.entries, "failures aren't cached")
+		})
+	}
+}
+
+func TestMembershipCache_PrunesExpiredEntries(t *testing.T) {
+	c := newMembershipCache()
+	c.put("user-1|org-1", membershipEntry{ok: true, expires: time.Now().Add(-time.Second)})
+	c.lastPrune = time.Now().Add(-membershipCacheTTL)
+	c.put("user-2|org-1", membershipEntry{ok: true, expires: time.Now().Add(membershipCacheTTL)})
+
+	assert.NotContains(t, c.entries, "user-1|org-1")
+	_, ok := c.get("user-2|org-1")
+	assert.True(t, ok)
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/imagegen"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// handleGenerateBackground handles POST /v1/backgrounds. The org's image for
+// the same prompt is returned straight away when there is one; otherwise an
+// image_gen job is enqueued, and requests for a prompt already being
+// generated share its job.
+func (s *Server) handleGenerateBackground(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+	if s.ImageGen == nil {
+		writeError(w, r, http.StatusServiceUnavailable, "image generation is not configured")
+		return
+	}
+	var req GenerateBackgroundRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if req.DeckVersionID != "" && req.TemplateVersionID != "" {
+		writeError(w, r, http.StatusBadRequest, "give deckVersionId or templateVersionId, not both")
+		return
+	}
+
+	var theme imagegen.Theme
+	if req.DeckVersionID != "" || req.TemplateVersionID != "" {
+		ts, ok := s.loadBackgroundSpec(w, r, id, req)
+		if !ok {
+			return
+		}
+		theme = imagegen.ThemeFromSpec(ts)
+	} else if strings.TrimSpace(req.Prompt) == "" {
+		writeError(w, r, http.StatusBadRequest, "prompt is required without deckVersionId or templateVersionId")
+		return
+	}
+	if style := strings.TrimSpace(req.Style); style != "" {
+		theme.Style = style
+	}
+	genReq := imagegen.Request{Prompt: imagegen.BackgroundPrompt(theme, req.Prompt), Width: req.Width, Height: req.Height}
+
+	img, cached, err := s.ImageGen.Cached(r.Context(), id.OrgID, genReq)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "generated_image_lookup", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to generate background")
+		return
+	}
+	if cached {
+		writeJSON(w, http.StatusOK, map[string]any{"image": img, "cached": true})
+		return
+	}
+	if err := s.quotas().CheckStorage(r.Context(), id); err != nil {
+		s.writeServiceError(w, r, "generate_background", "failed to check quota", err)
+		return
+	}
+
+	key := s.ImageGen.Key(genReq)
+	metadata := store.JSONMap{"prompt": genReq.Prompt, "userId": id.UserID}
+	if genReq.Width > 0 {
+		metadata["width"] = strconv.Itoa(genReq.Width)
+	}
+	if genReq.Height > 0 {
+		metadata["height"] = strconv.Itoa(genReq.Height)
+	}
+	job, duplicate, err := s.Store.Jobs().EnqueueWithDeduplication(r.Context(), store.Job{
+		ID:              newID("job"),
+		OrgID:           id.OrgID,
+		Type:            store.JobImageGen,
+		Status:          store.JobQueued,
+		InputRef:        key,
+		DeduplicationID: string(store.JobImageGen) + "-" + key,
+		Metadata:        &metadata,
+	})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "enqueue_image_gen", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to enqueue job")
+		return
+	}
+	if !duplicate {
+		_, _ = s.Store.Metering().Record(r.Context(), store.MeteringEvent{ID: newID("met"), OrgID: id.OrgID, UserID: id.UserID, Type: "image_generate", Quantity: 1})
+		_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "background.generate", TargetRef: job.ID, Metadata: map[string]any{"promptHash": key}})
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"job": job, "prompt": genReq.Prompt, "duplicate": duplicate})
+}
+
+// loadBackgroundSpec reads the spec of the deck or template version a
+// background is themed on, checking the caller can view it.
+func (s *Server) loadBackgroundSpec(w http.ResponseWriter, r *http.Request, id auth.Identity, req GenerateBackgroundRequest) (spec.TemplateSpec, bool) {
+	var raw json.RawMessage
+	if req.DeckVersionID != "" {
+		dv, ok, err := s.Store.Decks().GetDeckVersion(r.Context(), id.OrgID, req.DeckVersionID)
+		if err != nil || !ok {
+			writeError(w, r, http.StatusNotFound, "deck version not found")
+			return spec.TemplateSpec{}, false
+		}
+		if _, ok := s.authorizeDeck(w, r, id, dv.Deck, store.PermissionView); !ok {
+			return spec.TemplateSpec{}, false
+		}
+		raw = dv.SpecJSON
+	} else {
+		tv, ok, err := s.Store.Templates().GetVersion(r.Context(), id.OrgID, req.TemplateVersionID)
+		if err != nil || !ok {
+			writeError(w, r, http.StatusNotFound, "template version not found")
+			return spec.TemplateSpec{}, false
+		}
+		if _, ok := s.authorizeTemplate(w, r, id, tv.Template, store.PermissionView); !ok {
+			return spec.TemplateSpec{}, false
+		}
+		raw = tv.SpecJSON
+	}
+
+	var ts spec.TemplateSpec
+	b, err := normalize.JSON(raw)
+	if err == nil {
+		err = json.Unmarshal(b, &ts)
+	}
+	if err != nil {
+		writeError(w, r, http.StatusUnprocessableEntity, "version spec can't be read")
+		return spec.TemplateSpec{}, false
+	}
+	return ts, true
+}
+
+// handleListBackgrounds handles GET /v1/backgrounds, listing the org's
+// generated images newest first.
+func (s *Server) handleListBackgrounds(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	images, err := s.Store.GeneratedImages().List(r.Context(), id.OrgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_generated_images", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list backgrounds")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"images": images})
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/imagegen"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+type stubImageGenerator struct{}
+
+func (stubImageGenerator) Model() string { return "stub/model" }
+
+func (stubImageGenerator) Generate(context.Context, string, int, int) ([]byte, string, error) {
+	return []byte("\x89PNG\r\n\x1a\n"), "image/png", nil
+}
+
+func TestBackgrounds_EnqueueThenReuse(t *testing.T) {
+	s := NewServer()
+	s.ImageGen = nil
+	h := s.Handler()
+
+	do := func(method, path, body string, role auth.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/v1/backgrounds", `{"prompt":"ocean waves"}`, auth.RoleEditor)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	s.ImageGen = &imagegen.Pipeline{Store: s.Store, Objects: s.ObjectStorage, Generator: stubImageGenerator{}}
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/v1/backgrounds", `{"prompt":"ocean waves"}`, auth.RoleViewer).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/v1/backgrounds", `{}`, auth.RoleEditor).Code)
+
+	w = do(http.MethodPost, "/v1/backgrounds", `{"prompt":"ocean waves","style":"watercolor"}`, auth.RoleEditor)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var queued struct {
+		Job       store.Job `json:"job"`
+		Prompt    string    `json:"prompt"`
+		Duplicate bool      `json:"duplicate"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &queued))
+	assert.Equal(t, store.JobImageGen, queued.Job.Type)
+	assert.Contains(t, queued.Prompt, "watercolor style")
+	assert.Contains(t, queued.Prompt, "Ocean waves.")
+	assert.Equal(t, queued.Prompt, (*queued.Job.Metadata)["prompt"])
+
+	w = do(http.MethodPost, "/v1/backgrounds", `{"prompt":"ocean waves","style":"watercolor"}`, auth.RoleEditor)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"duplicate":true`)
+
+	// Once the job has run, the same request is answered from the cache.
+	img, err := s.ImageGen.Generate(context.Background(), "org-1", "user-1", queued.Job.ID, imagegen.Request{Prompt: queued.Prompt})
+	require.NoError(t, err)
+	w = do(http.MethodPost, "/v1/backgrounds", `{"prompt":"ocean waves","style":"watercolor"}`, auth.RoleEditor)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var cached struct {
+		Image  store.GeneratedImage `json:"image"`
+		Cached bool                 `json:"cached"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cached))
+	assert.True(t, cached.Cached)
+	assert.Equal(t, img.ID, cached.Image.ID)
+
+	w = do(http.MethodGet, "/v1/backgrounds", "", auth.RoleViewer)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Images []store.GeneratedImage `json:"images"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Images, 1)
+	assert.Equal(t, img.AssetID, list.Images[0].AssetID)
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/service"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const (
+	// maxBatchMergeRows bounds the decks one batch merge renders.
+	maxBatchMergeRows = 500
+	maxBatchMergeBody = 5 << 20
+)
+
+// handleBatchMerge handles POST /v1/decks/{id}/batch-merge: one job renders
+// the deck's current version once per data row, filling its {{name}}
+// variables, and zips the decks with a report.csv of each row's outcome.
+// The body is a JSON array of objects or, as text/csv, a CSV whose header
+// names the variables. The nameBy query parameter names each file after a
+// variable; the export options come from the query as for a single export.
+// Every row counts against the export quota.
+func (s *Server) handleBatchMerge(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	d, ok := s.authorizeDeck(w, r, id, r.PathValue("id"), store.PermissionView)
+	if !ok {
+		return
+	}
+	if d.CurrentVersion == nil {
+		writeError(w, r, http.StatusConflict, fmt.Sprintf("deck %s has no content to export yet", d.ID))
+		return
+	}
+	opts := exportOptions(r)
+	if opts.Format != "" && opts.Format != "pptx" {
+		writeError(w, r, http.StatusBadRequest, "batch merges export pptx files")
+		return
+	}
+	quality, err := service.NormalizeExportQuality(opts.Quality)
+	if err != nil {
+		s.writeServiceError(w, r, "batch_merge", "invalid export options", err)
+		return
+	}
+	if _, err := service.NormalizeRenderer(opts.Renderer); err != nil {
+		s.writeServiceError(w, r, "batch_merge", "invalid export options", err)
+		return
+	}
+	if opts.Deterministic && opts.Renderer != "" && opts.Renderer != assets.RendererGo {
+		writeError(w, r, http.StatusBadRequest, "deterministic exports use the go renderer")
+		return
+	}
+	nameBy := r.URL.Query().Get("nameBy")
+
+	body := http.MaxBytesReader(w, r.Body, maxBatchMergeBody)
+	var data []map[string]string
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "text/csv" {
+		data, err = readMergeCSV(body)
+	} else {
+		data, err = readMergeJSON(body)
+	}
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("body exceeds %d MB", maxBatchMergeBody>>20))
+		return
+	case err != nil:
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	case len(data) == 0:
+		writeError(w, r, http.StatusBadRequest, "at least one row is required")
+		return
+	case len(data) > maxBatchMergeRows:
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("at most %d rows may be merged at once", maxBatchMergeRows))
+		return
+	}
+
+	dv, ok, err := s.Store.Decks().GetDeckVersion(r.Context(), id.OrgID, *d.CurrentVersion)
+	if err != nil || !ok {
+		logger.LogError(r.Context(), "api", "batch_merge_get_version", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load deck version")
+		return
+	}
+	rows := make([]store.BatchMergeRow, len(data))
+	for i, values := range data {
+		if _, err := service.MergeSpecData(dv.SpecJSON, values); err != nil {
+			var invalid *service.InvalidError
+			if errors.As(err, &invalid) {
+				writeError(w, r, http.StatusBadRequest, fmt.Sprintf("row %d: %s", i+1, invalid.Msg))
+				return
+			}
+			s.writeServiceError(w, r, "batch_merge", "failed to read deck", err)
+			return
+		}
+		name := values[nameBy]
+		if name == "" {
+			name = fmt.Sprintf("%s %d", d.Name, i+1)
+		}
+		rows[i] = store.BatchMergeRow{Row: i + 1, Name: name, Data: values, Status: store.JobQueued}
+	}
+
+	quotas := s.quotas()
+	if err := quotas.CheckExport(r.Context(), id, len(rows)); err != nil {
+		s.writeServiceError(w, r, "batch_merge", "failed to check quota", err)
+		return
+	}
+	if err := quotas.CheckStorage(r.Context(), id); err != nil {
+		s.writeServiceError(w, r, "batch_merge", "failed to check quota", err)
+		return
+	}
+
+	rowsJSON, err := json.Marshal(rows)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "failed to encode rows")
+		return
+	}
+	metadata := store.JSONMap{"rows": string(rowsJSON), "name": d.Name, "userId": id.UserID}
+	if quality != store.ExportQualityStandard {
+		metadata["quality"] = quality
+	}
+	if renderer := service.ExportRenderer(r.Context(), s.Store, id.OrgID, quality, opts.Renderer); renderer != "" && !opts.Deterministic {
+		metadata["renderer"] = renderer
+	}
+	if opts.AdjustContrast {
+		metadata["adjustContrast"] = "true"
+	}
+	if opts.Deterministic {
+		metadata["deterministic"] = "true"
+	}
+	job, err := s.Store.Jobs().Enqueue(r.Context(), store.Job{
+		ID:       newID("job"),
+		OrgID:    id.OrgID,
+		Type:     store.JobBatchMerge,
+		Status:   store.JobQueued,
+		InputRef: dv.ID,
+		Metadata: &metadata,
+	})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "enqueue_batch_merge", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to enqueue job")
+		return
+	}
+
+	logger.Jobs().Info("batch_merge_queued", "user_id", id.UserID, "org_id", id.OrgID, "job_id", job.ID, "deck_id", d.ID, "rows", len(rows))
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.batch_merge", TargetRef: dv.ID, Metadata: map[string]any{"jobId": job.ID, "rows": len(rows)}})
+
+	writeJSON(w, http.StatusAccepted, map[string]any{"job": job, "rows": rows})
+}
+
+// readMergeJSON reads a JSON array of objects of variable values.
+func readMergeJSON(body io.Reader) ([]map[string]string, error) {
+	dec := json.NewDecoder(body)
+	dec.UseNumber()
+	var raw []map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, err
+		}
+		return nil, errors.New("body must be a JSON array of objects")
+	}
+	rows := make([]map[string]string, len(raw))
+	for i, values := range raw {
+		data, err := mergeValues(values)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		rows[i] = data
+	}
+	return rows, nil
+}
+
+// readMergeCSV reads a CSV whose header row names the variables.
+func readMergeCSV(body io.Reader) ([]map[string]string, error) {
+	records, err := csv.NewReader(body).ReadAll()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("CSV needs a header row naming the variables")
+	}
+	header := records[0]
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+		if !spec.IsVariableName(header[i]) {
+			return nil, fmt.Errorf("CSV column %q is not a variable name", name)
+		}
+	}
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		data := make(map[string]string, len(header))
+		for i, name := range header {
+			data[name] = record[i]
+		}
+		rows = append(rows, data)
+	}
+	return rows, nil
+}
+
+// mergeValues turns the JSON values of merge data into the strings they
+// fill variables with.
+func mergeValues(values map[string]any) (map[string]string, error) {
+	data := make(map[string]string, len(values))
+	for name, v := range values {
+		if !spec.IsVariableName(name) {
+			return nil, fmt.Errorf("%q is not a variable name", name)
+		}
+		switch v := v.(type) {
+		case string:
+			data[name] = v
+		case json.Number:
+			data[name] = v.String()
+		case bool:
+			data[name] = strconv.FormatBool(v)
+		default:
+			return nil, errors.New("data values must be strings, numbers or booleans")
+		}
+	}
+	return data, nil
+}
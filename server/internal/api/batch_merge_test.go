@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestBatchMerge(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+
+	versionID := "ver-batch"
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-batch", OrgID: "org-1", Name: "Proposal", CurrentVersion: &versionID})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "ver-batch", Deck: "deck-batch", OrgID: "org-1", VersionNo: 1,
+		SpecJSON: []byte(`{"layouts":[{"name":"Title","placeholders":[{"id":"title","type":"text","content":"Proposal for {{customer}}"}]}]}`)})
+	require.NoError(t, err)
+
+	post := func(query, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/decks/deck-batch/batch-merge"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	type response struct {
+		Job  store.Job             `json:"job"`
+		Rows []store.BatchMergeRow `json:"rows"`
+	}
+
+	w := post("?nameBy=customer", "text/csv", "\ufeffcustomer,region\nAcme,EU\n\"Globex, Inc\",US\n")
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var resp response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, store.JobBatchMerge, resp.Job.Type)
+	assert.Equal(t, "ver-batch", resp.Job.InputRef)
+	require.Len(t, resp.Rows, 2)
+	assert.Equal(t, "Globex, Inc", resp.Rows[1].Name)
+	assert.Equal(t, map[string]string{"customer": "Globex, Inc", "region": "US"}, resp.Rows[1].Data)
+
+	w = post("", "application/json", `[{"customer":"Acme"},{"customer":"Initech"}]`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	resp = response{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "Proposal 2", resp.Rows[1].Name, "rows are numbered without nameBy")
+
+	w = post("", "application/json", `[{"customer":"Acme"},{"region":"EU"}]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "row 2: data is missing deck variables: customer")
+
+	assert.Equal(t, http.StatusBadRequest, post("", "application/json", `[]`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("", "application/json", `{"customer":"Acme"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("", "text/csv", "first name\nAnn\n").Code)
+	assert.Equal(t, http.StatusBadRequest, post("?format=bundle", "application/json", `[{"customer":"Acme"}]`).Code)
+}
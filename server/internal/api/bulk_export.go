@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/service"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// handleBulkExportDecks enqueues one job that renders the current version of
+// each requested deck and zips them into a single asset. Every deck counts
+// against the export quota.
+func (s *Server) handleBulkExportDecks(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+
+	var req BulkExportRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	seen := map[string]bool{}
+	items := make([]store.BulkExportItem, 0, len(req.DeckIDs))
+	for _, deckID := range req.DeckIDs {
+		if seen[deckID] {
+			continue
+		}
+		seen[deckID] = true
+		d, ok := s.authorizeDeck(w, r, id, deckID, store.PermissionView)
+		if !ok {
+			return
+		}
+		if d.CurrentVersion == nil {
+			writeError(w, r, http.StatusConflict, fmt.Sprintf("deck %s has no content to export yet", deckID))
+			return
+		}
+		items = append(items, store.BulkExportItem{DeckID: d.ID, VersionID: *d.CurrentVersion, Name: d.Name, Status: store.JobQueued})
+	}
+
+	quotas := s.quotas()
+	if err := quotas.CheckExport(r.Context(), id, len(items)); err != nil {
+		s.writeServiceError(w, r, "bulk_export", "failed to check quota", err)
+		return
+	}
+	if err := quotas.CheckStorage(r.Context(), id); err != nil {
+		s.writeServiceError(w, r, "bulk_export", "failed to check quota", err)
+		return
+	}
+
+	itemsJSON, err := json.Marshal(items)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "failed to encode items")
+		return
+	}
+	metadata := store.JSONMap{"items": string(itemsJSON), "userId": id.UserID}
+	quality := req.Quality
+	if quality == "" {
+		quality = store.ExportQualityStandard
+	}
+	if quality != store.ExportQualityStandard {
+		metadata["quality"] = quality
+	}
+	if renderer := service.ExportRenderer(r.Context(), s.Store, id.OrgID, quality, req.Renderer); renderer != "" {
+		metadata["renderer"] = renderer
+	}
+	if req.AdjustContrast {
+		metadata["adjustContrast"] = "true"
+	}
+	if req.Deterministic {
+		metadata["deterministic"] = "true"
+	}
+	job, err := s.Store.Jobs().Enqueue(r.Context(), store.Job{
+		ID:       newID("job"),
+		OrgID:    id.OrgID,
+		Type:     store.JobBulkExport,
+		Status:   store.JobQueued,
+		Metadata: &metadata,
+	})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "enqueue_bulk_export", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to enqueue job")
+		return
+	}
+
+	logger.Jobs().Info("bulk_export_queued", "user_id", id.UserID, "org_id", id.OrgID, "job_id", job.ID, "decks", len(items))
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.bulk_export", TargetRef: job.ID, Metadata: map[string]any{"deckIds": req.DeckIDs}})
+
+	writeJSON(w, http.StatusAccepted, map[string]any{"job": job, "items": items})
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestBulkExport_EnqueuesOneJobForAllDecks(t *testing.T) {
+	s := NewServer()
+	s.Config.ExportLimitPerMonth = 3
+	h := s.Handler()
+	ctx := context.Background()
+	for _, id := range []string{"a", "b", "c"} {
+		v := "dv-" + id
+		_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-" + id, OrgID: "org-1", Name: "Deck " + id, CurrentVersion: &v})
+		require.NoError(t, err)
+	}
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-empty", OrgID: "org-1", Name: "Empty"})
+	require.NoError(t, err)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/decks/bulk-export", strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"deckIds":[]}`).Code)
+	assert.Equal(t, http.StatusConflict, post(`{"deckIds":["deck-a","deck-empty"]}`).Code)
+	assert.Equal(t, http.StatusNotFound, post(`{"deckIds":["deck-a","deck-missing"]}`).Code)
+
+	w := post(`{"deckIds":["deck-a","deck-b","deck-a"]}`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var resp struct {
+		Job   store.Job              `json:"job"`
+		Items []store.BulkExportItem `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, store.JobBulkExport, resp.Job.Type)
+	require.Len(t, resp.Items, 2)
+	assert.Equal(t, "dv-b", resp.Items[1].VersionID)
+	assert.Equal(t, store.JobQueued, resp.Items[1].Status)
+
+	used, err := s.Store.Metering().SumByType(ctx, "org-1", "export")
+	require.NoError(t, err)
+	assert.Zero(t, used, "exports are metered as the worker finishes them")
+
+	// The queued decks count against the quota all the same: two more
+	// would take the org past its limit of three.
+	assert.Equal(t, http.StatusPaymentRequired, post(`{"deckIds":["deck-b","deck-c"]}`).Code)
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const EventCommentMention = "comment.mention"
+
+// CommentThread is a root comment with its replies, oldest first.
+type CommentThread struct {
+	store.Comment
+	Replies []store.Comment `json:"replies"`
+}
+
+// loadCommentableVersion returns a deck version the caller can view. It
+// writes the error response and returns false on failure.
+func (s *Server) loadCommentableVersion(w http.ResponseWriter, r *http.Request, id auth.Identity, versionID string) (store.DeckVersion, bool) {
+	dv, ok, err := s.Store.Decks().GetDeckVersion(r.Context(), id.OrgID, versionID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "get_deck_version", err)
+		writeError(w, r, http.StatusInternalServerError, "failed")
+		return store.DeckVersion{}, false
+	}
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return store.DeckVersion{}, false
+	}
+	if _, ok := s.authorizeDeck(w, r, id, dv.Deck, store.PermissionView); !ok {
+		return store.DeckVersion{}, false
+	}
+	return dv, true
+}
+
+// slideCount returns the number of slides in a deck version, or -1 when the
+// spec can't be read and the anchor can't be checked.
+func slideCount(dv store.DeckVersion) int {
+	b, err := normalize.JSON(dv.SpecJSON)
+	if err != nil {
+		return -1
+	}
+	var ts spec.TemplateSpec
+	if err := json.Unmarshal(b, &ts); err != nil {
+		return -1
+	}
+	return len(ts.Layouts)
+}
+
+func (s *Server) handleCreateComment(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	dv, ok := s.loadCommentableVersion(w, r, id, r.PathValue("versionId"))
+	if !ok {
+		return
+	}
+
+	var req CreateCommentRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	c := store.Comment{
+		ID:            newID("cmt"),
+		OrgID:         id.OrgID,
+		DeckID:        dv.Deck,
+		DeckVersionID: dv.ID,
+		AuthorID:      id.UserID,
+		Body:          req.Body,
+	}
+	if req.ParentID != "" {
+		parent, ok, err := s.Store.Comments().Get(r.Context(), id.OrgID, req.ParentID)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "get_comment", err, "comment_id", req.ParentID)
+			writeError(w, r, http.StatusInternalServerError, "failed to load parent comment")
+			return
+		}
+		if !ok || parent.DeckVersionID != dv.ID {
+			writeError(w, r, http.StatusBadRequest, "parent comment not found on this deck version")
+			return
+		}
+		// Threads are one level deep: replies to a reply join its thread.
+		rootID := parent.ID
+		if parent.ParentID != nil {
+			rootID = *parent.ParentID
+		}
+		c.ParentID = &rootID
+		c.SlideIndex = parent.SlideIndex
+	} else {
+		if req.SlideIndex == nil {
+			writeError(w, r, http.StatusBadRequest, "slideIndex is required")
+			return
+		}
+		if n := slideCount(dv); n >= 0 && *req.SlideIndex >= n {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("slideIndex out of range: deck version has %d slides", n))
+			return
+		}
+		c.SlideIndex = *req.SlideIndex
+	}
+
+	seen := map[string]bool{}
+	c.Mentions = []string{}
+	for _, userID := range req.Mentions {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		isMember, err := s.isOrgMember(r.Context(), id.OrgID, userID)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "create_comment", err, "user_id", userID)
+			writeError(w, r, http.StatusInternalServerError, "failed to check membership")
+			return
+		}
+		if !isMember {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("mentioned user %s is not a member of this organization", userID))
+			return
+		}
+		c.Mentions = append(c.Mentions, userID)
+	}
+
+	created, err := s.Store.Comments().Create(r.Context(), c)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "create_comment", err, "deck_version_id", dv.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to create comment")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "comment.create", TargetRef: created.ID, Metadata: map[string]any{"deckVersionId": dv.ID, "slideIndex": created.SlideIndex}})
+	for _, userID := range created.Mentions {
+		if userID == id.UserID {
+			continue
+		}
+		s.emitEvent(r.Context(), id.UserID, Event{
+			Type:  EventCommentMention,
+			OrgID: id.OrgID,
+			Data: map[string]any{
+				"commentId":       created.ID,
+				"deckId":          created.DeckID,
+				"deckVersionId":   created.DeckVersionID,
+				"slideIndex":      created.SlideIndex,
+				"authorUserId":    id.UserID,
+				"mentionedUserId": userID,
+			},
+		})
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"comment": created})
+}
+
+func (s *Server) handleListComments(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	dv, ok := s.loadCommentableVersion(w, r, id, r.PathValue("versionId"))
+	if !ok {
+		return
+	}
+
+	slide := -1
+	if v := r.URL.Query().Get("slide"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, r, http.StatusBadRequest, "invalid slide")
+			return
+		}
+		slide = n
+	}
+	var resolved *bool
+	if v := r.URL.Query().Get("resolved"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid resolved filter")
+			return
+		}
+		resolved = &b
+	}
+
+	comments, err := s.Store.Comments().ListByDeckVersion(r.Context(), id.OrgID, dv.ID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_comments", err, "deck_version_id", dv.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to list comments")
+		return
+	}
+
+	threads := []*CommentThread{}
+	byRoot := map[string]*CommentThread{}
+	for _, c := range comments {
+		if c.ParentID != nil {
+			continue
+		}
+		if (slide >= 0 && c.SlideIndex != slide) || (resolved != nil && c.Resolved != *resolved) {
+			continue
+		}
+		t := &CommentThread{Comment: c, Replies: []store.Comment{}}
+		threads = append(threads, t)
+		byRoot[c.ID] = t
+	}
+	for _, c := range comments {
+		if c.ParentID == nil {
+			continue
+		}
+		if t, ok := byRoot[*c.ParentID]; ok {
+			t.Replies = append(t.Replies, c)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"threads": threads})
+}
+
+// handleSetCommentResolved resolves or reopens a thread. The thread's author
+// and anyone who can edit the deck may do either.
+func (s *Server) handleSetCommentResolved(resolved bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, _ := auth.GetIdentity(r.Context())
+		commentID := r.PathValue("commentId")
+
+		c, ok, err := s.Store.Comments().Get(r.Context(), id.OrgID, commentID)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "get_comment", err, "comment_id", commentID)
+			writeError(w, r, http.StatusInternalServerError, "failed to load comment")
+			return
+		}
+		if !ok {
+			writeError(w, r, http.StatusNotFound, "not found")
+			return
+		}
+		minPerm := store.PermissionEdit
+		if c.AuthorID == id.UserID {
+			minPerm = store.PermissionView
+		}
+		if _, ok := s.authorizeDeck(w, r, id, c.DeckID, minPerm); !ok {
+			return
+		}
+		if c.ParentID != nil {
+			writeError(w, r, http.StatusBadRequest, "only the first comment of a thread can be resolved")
+			return
+		}
+
+		c.Resolved = resolved
+		action := "comment.unresolve"
+		if resolved {
+			now := time.Now().UTC()
+			c.ResolvedBy, c.ResolvedAt = &id.UserID, &now
+			action = "comment.resolve"
+		} else {
+			c.ResolvedBy, c.ResolvedAt = nil, nil
+		}
+		updated, err := s.Store.Comments().Update(r.Context(), c)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "update_comment", err, "comment_id", commentID)
+			writeError(w, r, http.StatusInternalServerError, "failed to update comment")
+			return
+		}
+
+		_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: action, TargetRef: updated.ID})
+		writeJSON(w, http.StatusOK, map[string]any{"comment": updated})
+	}
+}
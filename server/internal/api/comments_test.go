@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestComments_ThreadsResolveAndMentions(t *testing.T) {
+	s := NewServer()
+	rec := &auditRecorder{Store: s.Store}
+	s.Store = rec
+	h := s.Handler()
+	ctx := context.Background()
+
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "editor", Email: "editor@acme.com"}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "editor", OrgID: "org-1", Role: auth.RoleEditor}))
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", OwnerUserID: "editor", Name: "Deck"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: "deck-1", OrgID: "org-1", VersionNo: 1,
+		SpecJSON: json.RawMessage(`{"layouts":[{"name":"Title"},{"name":"Body"}]}`)})
+	require.NoError(t, err)
+
+	do := func(userID string, role auth.Role, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, userID, "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	commentID := func(w *httptest.ResponseRecorder) string {
+		var resp struct {
+			Comment store.Comment `json:"comment"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Comment.ID
+	}
+
+	// Viewers review: they can open threads and mention teammates.
+	w := do("viewer", auth.RoleViewer, http.MethodPost, "/v1/deck-versions/dv-1/comments", `{"slideIndex":1,"body":"Typo here","mentions":["editor"]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	rootID := commentID(w)
+
+	var mentions []store.AuditLog
+	for _, e := range rec.entries {
+		if e.Action == "event."+EventCommentMention {
+			mentions = append(mentions, e)
+		}
+	}
+	require.Len(t, mentions, 1)
+	ev := mentions[0].Metadata.(map[string]any)["event"].(Event)
+	assert.Equal(t, "editor", ev.Data["mentionedUserId"])
+	assert.Equal(t, 1, ev.Data["slideIndex"])
+
+	w = do("editor", auth.RoleEditor, http.MethodPost, "/v1/deck-versions/dv-1/comments", `{"parentId":"`+rootID+`","body":"Fixed"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	replyID := commentID(w)
+	w = do("viewer", auth.RoleViewer, http.MethodPost, "/v1/deck-versions/dv-1/comments", `{"parentId":"`+replyID+`","body":"Thanks"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = do("viewer", auth.RoleViewer, http.MethodGet, "/v1/deck-versions/dv-1/comments?slide=1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Threads []CommentThread `json:"threads"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Threads, 1)
+	assert.Equal(t, rootID, list.Threads[0].ID)
+	require.Len(t, list.Threads[0].Replies, 2)
+	assert.Equal(t, rootID, *list.Threads[0].Replies[1].ParentID)
+	assert.Equal(t, 1, list.Threads[0].Replies[1].SlideIndex)
+
+	// Only the author or a deck editor resolves, and only whole threads.
+	assert.Equal(t, http.StatusForbidden, do("other-viewer", auth.RoleViewer, http.MethodPost, "/v1/comments/"+rootID+"/resolve", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do("editor", auth.RoleEditor, http.MethodPost, "/v1/comments/"+replyID+"/resolve", "").Code)
+	require.Equal(t, http.StatusOK, do("editor", auth.RoleEditor, http.MethodPost, "/v1/comments/"+rootID+"/resolve", "").Code)
+
+	w = do("viewer", auth.RoleViewer, http.MethodGet, "/v1/deck-versions/dv-1/comments?resolved=false", "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Empty(t, list.Threads)
+
+	require.Equal(t, http.StatusOK, do("viewer", auth.RoleViewer, http.MethodPost, "/v1/comments/"+rootID+"/unresolve", "").Code)
+	w = do("viewer", auth.RoleViewer, http.MethodGet, "/v1/deck-versions/dv-1/comments?resolved=false", "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Threads, 1)
+}
+
+func TestComments_Validation(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", Name: "Deck"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: "deck-1", OrgID: "org-1", VersionNo: 1,
+		SpecJSON: json.RawMessage(`{"layouts":[{"name":"Title"}]}`)})
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		path, body string
+		want       int
+	}{
+		"missing slide":      {"/v1/deck-versions/dv-1/comments", `{"body":"x"}`, http.StatusBadRequest},
+		"slide out of range": {"/v1/deck-versions/dv-1/comments", `{"slideIndex":3,"body":"x"}`, http.StatusBadRequest},
+		"empty body":         {"/v1/deck-versions/dv-1/comments", `{"slideIndex":0,"body":""}`, http.StatusBadRequest},
+		"unknown parent":     {"/v1/deck-versions/dv-1/comments", `{"parentId":"nope","body":"x"}`, http.StatusBadRequest},
+		"non-member mention": {"/v1/deck-versions/dv-1/comments", `{"slideIndex":0,"body":"x","mentions":["stranger"]}`, http.StatusBadRequest},
+		"unknown version":    {"/v1/deck-versions/missing/comments", `{"slideIndex":0,"body":"x"}`, http.StatusNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			addTestAuth(req, "viewer", "org-1", auth.RoleViewer)
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, req)
+			assert.Equal(t, tc.want, w.Code, w.Body.String())
+		})
+	}
+}
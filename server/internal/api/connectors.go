@@ -0,0 +1,283 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/connectors"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// checkConnector validates a connector about to be saved. It writes the
+// error response itself and reports whether the caller may go on.
+func (s *Server) checkConnector(w http.ResponseWriter, r *http.Request, id auth.Identity, c store.Connector) bool {
+	if !connectors.IsName(c.Name) {
+		writeError(w, r, http.StatusBadRequest, "name must start with a letter and hold only letters, digits, _ and -")
+		return false
+	}
+	if !slices.Contains(store.ConnectorKinds, c.Kind) {
+		writeError(w, r, http.StatusBadRequest, "kind must be one of "+strings.Join(store.ConnectorKinds, ", "))
+		return false
+	}
+	switch c.Kind {
+	case store.ConnectorCSV:
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			writeError(w, r, http.StatusBadRequest, "url must be an http or https URL")
+			return false
+		}
+	case store.ConnectorSheets:
+		if c.SpreadsheetID == "" || c.Range == "" || c.Credential == "" {
+			writeError(w, r, http.StatusBadRequest, "spreadsheetId, range and credential are required")
+			return false
+		}
+	case store.ConnectorSQL:
+		verb, _, _ := strings.Cut(strings.TrimSpace(c.Query), " ")
+		if verb = strings.ToUpper(verb); verb != "SELECT" && verb != "WITH" {
+			writeError(w, r, http.StatusBadRequest, "query must be a SELECT statement")
+			return false
+		}
+		if c.Credential == "" {
+			writeError(w, r, http.StatusBadRequest, "credential is required")
+			return false
+		}
+	}
+	if err := connectors.CheckDestination(r.Context(), c); err != nil {
+		writeError(w, r, http.StatusBadRequest, "source can't be reached: "+err.Error())
+		return false
+	}
+
+	existing, found, err := s.Store.Connectors().GetByName(r.Context(), id.OrgID, c.Name)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "check_connector", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to check connector name")
+		return false
+	}
+	if found && existing.ID != c.ID {
+		writeError(w, r, http.StatusConflict, "a connector named "+c.Name+" already exists")
+		return false
+	}
+	return true
+}
+
+// loadConnector fetches the connector named in the path, writing the error
+// response itself when it can't.
+func (s *Server) loadConnector(w http.ResponseWriter, r *http.Request, id auth.Identity, op string) (store.Connector, bool) {
+	c, ok, err := s.Store.Connectors().Get(r.Context(), id.OrgID, r.PathValue("id"))
+	if err != nil {
+		logger.LogError(r.Context(), "api", op, err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load connector")
+		return store.Connector{}, false
+	}
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return store.Connector{}, false
+	}
+	return c, true
+}
+
+// withCredentialFlag sets HasCredential, since the credential itself is
+// never returned.
+func withCredentialFlag(c store.Connector) store.Connector {
+	c.HasCredential = c.Credential != ""
+	return c
+}
+
+// handleCreateConnector handles POST /v1/connectors. Admins only, since a
+// connector's credential reaches outside the app.
+func (s *Server) handleCreateConnector(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	var req CreateConnectorRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	c := store.Connector{
+		ID:            newID("con"),
+		OrgID:         id.OrgID,
+		Name:          strings.TrimSpace(req.Name),
+		Kind:          req.Kind,
+		URL:           req.URL,
+		SpreadsheetID: req.SpreadsheetID,
+		Range:         req.Range,
+		Query:         req.Query,
+		Credential:    req.Credential,
+		MaxAgeSeconds: req.MaxAgeSeconds,
+		CreatedBy:     id.UserID,
+	}
+	if !s.checkConnector(w, r, id, c) {
+		return
+	}
+	created, err := s.Store.Connectors().Create(r.Context(), c)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "create_connector", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to create connector")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "connector.create", TargetRef: created.ID, Metadata: map[string]any{"name": created.Name, "kind": created.Kind}})
+	writeJSON(w, http.StatusCreated, map[string]any{"connector": withCredentialFlag(created)})
+}
+
+// handleListConnectors handles GET /v1/connectors.
+func (s *Server) handleListConnectors(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	list, err := s.Store.Connectors().List(r.Context(), id.OrgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_connectors", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list connectors")
+		return
+	}
+	out := make([]store.Connector, len(list))
+	for i, c := range list {
+		out[i] = withCredentialFlag(c)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"connectors": out})
+}
+
+// handleGetConnector handles GET /v1/connectors/{id}. The response lists the
+// fields decks can bind from the last fetched data.
+func (s *Server) handleGetConnector(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	c, ok := s.loadConnector(w, r, id, "get_connector")
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, connectorResponse(c))
+}
+
+// connectorResponse is a connector with its bindable fields.
+func connectorResponse(c store.Connector) map[string]any {
+	fields := map[string]string{}
+	if c.Data != nil {
+		fields = connectors.Fields(*c.Data)
+	}
+	return map[string]any{"connector": withCredentialFlag(c), "fields": fields}
+}
+
+// handleUpdateConnector handles PATCH /v1/connectors/{id}. Changing where
+// the data comes from drops the data fetched from the old source. Admins
+// only.
+func (s *Server) handleUpdateConnector(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	var req UpdateConnectorRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	c, ok := s.loadConnector(w, r, id, "update_connector")
+	if !ok {
+		return
+	}
+	source := [4]string{c.URL, c.SpreadsheetID, c.Range, c.Query}
+	if req.Name != nil {
+		c.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.URL != nil {
+		c.URL = *req.URL
+	}
+	if req.SpreadsheetID != nil {
+		c.SpreadsheetID = *req.SpreadsheetID
+	}
+	if req.Range != nil {
+		c.Range = *req.Range
+	}
+	if req.Query != nil {
+		c.Query = *req.Query
+	}
+	if req.Credential != nil {
+		c.Credential = *req.Credential
+	}
+	if req.MaxAgeSeconds != nil {
+		c.MaxAgeSeconds = *req.MaxAgeSeconds
+	}
+	if !s.checkConnector(w, r, id, c) {
+		return
+	}
+	if source != [4]string{c.URL, c.SpreadsheetID, c.Range, c.Query} {
+		c.Data, c.RefreshedAt, c.LastError = nil, nil, ""
+	}
+
+	updated, err := s.Store.Connectors().Update(r.Context(), c)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "update_connector", err, "connector_id", c.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to update connector")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "connector.update", TargetRef: updated.ID, Metadata: map[string]any{"name": updated.Name, "credentialChanged": req.Credential != nil}})
+	writeJSON(w, http.StatusOK, map[string]any{"connector": withCredentialFlag(updated)})
+}
+
+// handleDeleteConnector handles DELETE /v1/connectors/{id}. Decks still
+// binding it fail to export until the binding is removed. Admins only.
+func (s *Server) handleDeleteConnector(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	connID := r.PathValue("id")
+
+	deleted, err := s.Store.Connectors().Delete(r.Context(), id.OrgID, connID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "delete_connector", err, "connector_id", connID)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete connector")
+		return
+	}
+	if !deleted {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "connector.delete", TargetRef: connID})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRefreshConnector handles POST /v1/connectors/{id}/refresh: it
+// fetches the source now and answers with the fields decks can bind. A
+// source that can't be read answers 502 and keeps the earlier data.
+// Editors and above.
+func (s *Server) handleRefreshConnector(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	c, ok := s.loadConnector(w, r, id, "refresh_connector")
+	if !ok {
+		return
+	}
+	refreshed, err := s.connectors().Refresh(r.Context(), c)
+	if refreshed.LastError == "" && err != nil {
+		logger.LogError(r.Context(), "api", "refresh_connector", err, "connector_id", c.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to save connector data")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "connector.refresh", TargetRef: c.ID, Metadata: map[string]any{"name": c.Name, "ok": err == nil}})
+	if err != nil {
+		writeError(w, r, http.StatusBadGateway, "refresh failed: "+refreshed.LastError)
+		return
+	}
+	writeJSON(w, http.StatusOK, connectorResponse(refreshed))
+}
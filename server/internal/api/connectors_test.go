@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/egress"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// allowLoopback lets the test reach its httptest servers, which egress
+// otherwise refuses like any other loopback address.
+func allowLoopback(t *testing.T) {
+	egress.Configure([]netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")})
+	t.Cleanup(func() { egress.Configure(nil) })
+}
+
+func TestConnectors_CRUDAndRefresh(t *testing.T) {
+	allowLoopback(t)
+	failing := false
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte("Region,Revenue\nEMEA,1.2M\n"))
+	}))
+	defer source.Close()
+
+	s := NewServer()
+	h := s.Handler()
+	do := func(method, path, body string, role auth.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	valid := `{"name":"sales","kind":"csv","url":"` + source.URL + `","credential":"Bearer secret","maxAgeSeconds":600}`
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/v1/connectors", valid, auth.RoleEditor).Code)
+	for _, body := range []string{
+		`{"name":"sales.q3","kind":"csv","url":"https://example.com/a.csv"}`,
+		`{"name":"sales","kind":"excel","url":"https://example.com/a.csv"}`,
+		`{"name":"sales","kind":"csv"}`,
+		`{"name":"sales","kind":"sheets","spreadsheetId":"abc","range":"A1:B2"}`,
+		`{"name":"sales","kind":"sql","query":"DELETE FROM deals","credential":"postgres://db"}`,
+		`{"name":"sales","kind":"sql","query":"SELECT 1"}`,
+		`{"name":"sales","kind":"csv","url":"http://169.254.169.254/latest/meta-data/"}`,
+		`{"name":"sales","kind":"sql","query":"SELECT 1","credential":"postgres://u:p@10.0.0.5/crm"}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/v1/connectors", body, auth.RoleAdmin).Code, body)
+	}
+
+	w := do(http.MethodPost, "/v1/connectors", valid, auth.RoleAdmin)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "secret")
+	var created struct {
+		Connector store.Connector `json:"connector"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	conn := created.Connector
+	assert.True(t, conn.HasCredential)
+	assert.Equal(t, 600, conn.MaxAgeSeconds)
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/v1/connectors", valid, auth.RoleAdmin).Code)
+
+	w = do(http.MethodPost, "/v1/connectors/"+conn.ID+"/refresh", "", auth.RoleEditor)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var refreshed struct {
+		Connector store.Connector   `json:"connector"`
+		Fields    map[string]string `json:"fields"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &refreshed))
+	assert.Equal(t, "1.2M", refreshed.Fields["Revenue"])
+	require.NotNil(t, refreshed.Connector.RefreshedAt)
+
+	// A failed refresh keeps the earlier data and records why.
+	failing = true
+	assert.Equal(t, http.StatusBadGateway, do(http.MethodPost, "/v1/connectors/"+conn.ID+"/refresh", "", auth.RoleEditor).Code)
+	w = do(http.MethodGet, "/v1/connectors/"+conn.ID, "", auth.RoleViewer)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &refreshed))
+	assert.Equal(t, "EMEA", refreshed.Fields["Region"])
+	assert.Contains(t, refreshed.Connector.LastError, "status 500")
+
+	// Pointing the connector elsewhere drops the old data.
+	w = do(http.MethodPatch, "/v1/connectors/"+conn.ID, `{"url":"https://example.com/other.csv"}`, auth.RoleAdmin)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated struct {
+		Connector store.Connector `json:"connector"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Nil(t, updated.Connector.Data)
+	assert.Empty(t, updated.Connector.LastError)
+	assert.True(t, updated.Connector.HasCredential)
+
+	w = do(http.MethodGet, "/v1/connectors", "", auth.RoleViewer)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"sales"`)
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodDelete, "/v1/connectors/"+conn.ID, "", auth.RoleEditor).Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/v1/connectors/"+conn.ID, "", auth.RoleAdmin).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v1/connectors/"+conn.ID, "", auth.RoleViewer).Code)
+
+	logs, err := s.Store.Audit().List(context.Background(), "org-1")
+	require.NoError(t, err)
+	actions := map[string]bool{}
+	for _, l := range logs {
+		actions[l.Action] = true
+	}
+	for _, action := range []string{"connector.create", "connector.refresh", "connector.update", "connector.delete"} {
+		assert.True(t, actions[action], action)
+	}
+}
@@ -0,0 +1,320 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/docimport"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/service"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// maxImportedContent bounds the content a page import gives its deck.
+const maxImportedContent = 100000
+
+// notionIntegrationResponse says whether the org connected Notion; the
+// token itself is never returned.
+type notionIntegrationResponse struct {
+	Connected bool `json:"connected"`
+}
+
+// confluenceIntegrationResponse describes the org's Confluence site without
+// its token.
+type confluenceIntegrationResponse struct {
+	Connected bool   `json:"connected"`
+	BaseURL   string `json:"baseUrl,omitempty"`
+	Email     string `json:"email,omitempty"`
+}
+
+func newConfluenceIntegrationResponse(creds *store.ContentImportCredentials) confluenceIntegrationResponse {
+	if creds == nil || creds.ConfluenceToken == "" {
+		return confluenceIntegrationResponse{}
+	}
+	return confluenceIntegrationResponse{Connected: true, BaseURL: creds.ConfluenceBaseURL, Email: creds.ConfluenceEmail}
+}
+
+// setContentImport applies change to the org's content import credentials
+// and saves them, removing them once nothing is left. It writes the error
+// response itself and reports whether the caller may go on.
+func (s *Server) setContentImport(w http.ResponseWriter, r *http.Request, id auth.Identity, op string, change func(*store.ContentImportCredentials)) (*store.ContentImportCredentials, bool) {
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return nil, false
+	}
+	var creds store.ContentImportCredentials
+	if org.ContentImport != nil {
+		creds = *org.ContentImport
+	}
+	change(&creds)
+	var stored *store.ContentImportCredentials
+	if creds != (store.ContentImportCredentials{}) {
+		stored = &creds
+	}
+	if err := s.Store.Organizations().SetContentImport(r.Context(), id.OrgID, stored); err != nil {
+		logger.LogError(r.Context(), "api", op, err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to save credentials")
+		return nil, false
+	}
+	return stored, true
+}
+
+func (s *Server) handleGetNotionIntegration(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return
+	}
+	connected := org.ContentImport != nil && org.ContentImport.NotionToken != ""
+	writeJSON(w, http.StatusOK, map[string]any{"notion": notionIntegrationResponse{Connected: connected}})
+}
+
+// handleSetNotionIntegration handles PUT /v1/integrations/notion, storing
+// the internal integration token page imports run with.
+func (s *Server) handleSetNotionIntegration(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	var req NotionIntegrationRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if _, ok := s.setContentImport(w, r, id, "set_notion_integration", func(c *store.ContentImportCredentials) {
+		c.NotionToken = strings.TrimSpace(req.Token)
+	}); !ok {
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "integration.notion.set", TargetRef: id.OrgID})
+	writeJSON(w, http.StatusOK, map[string]any{"notion": notionIntegrationResponse{Connected: true}})
+}
+
+func (s *Server) handleDeleteNotionIntegration(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	if _, ok := s.setContentImport(w, r, id, "delete_notion_integration", func(c *store.ContentImportCredentials) {
+		c.NotionToken = ""
+	}); !ok {
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "integration.notion.delete", TargetRef: id.OrgID})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleGetConfluenceIntegration(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"confluence": newConfluenceIntegrationResponse(org.ContentImport)})
+}
+
+// handleSetConfluenceIntegration handles PUT /v1/integrations/confluence,
+// storing the site and token page imports run with.
+func (s *Server) handleSetConfluenceIntegration(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	var req ConfluenceIntegrationRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	base, err := url.Parse(strings.TrimSpace(req.BaseURL))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		writeError(w, r, http.StatusBadRequest, "baseUrl must be an http or https URL")
+		return
+	}
+	stored, ok := s.setContentImport(w, r, id, "set_confluence_integration", func(c *store.ContentImportCredentials) {
+		c.ConfluenceBaseURL = strings.TrimSuffix(base.String(), "/")
+		c.ConfluenceEmail = strings.TrimSpace(req.Email)
+		c.ConfluenceToken = strings.TrimSpace(req.Token)
+	})
+	if !ok {
+		return
+	}
+
+	resp := newConfluenceIntegrationResponse(stored)
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "integration.confluence.set", TargetRef: id.OrgID, Metadata: map[string]any{"baseUrl": resp.BaseURL}})
+	writeJSON(w, http.StatusOK, map[string]any{"confluence": resp})
+}
+
+func (s *Server) handleDeleteConfluenceIntegration(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	if _, ok := s.setContentImport(w, r, id, "delete_confluence_integration", func(c *store.ContentImportCredentials) {
+		c.ConfluenceBaseURL, c.ConfluenceEmail, c.ConfluenceToken = "", "", ""
+	}); !ok {
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "integration.confluence.delete", TargetRef: id.OrgID})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pageFetcher reads the page an import names with the org's credentials.
+// It reports false when the org hasn't connected the source.
+type pageFetcher func(ctx context.Context, creds store.ContentImportCredentials, pageID string) (*docimport.Document, bool, error)
+
+// handleImportNotionPage handles POST /v1/integrations/notion/import.
+func (s *Server) handleImportNotionPage(w http.ResponseWriter, r *http.Request) {
+	s.importPage(w, r, "notion", docimport.ParseNotionPageID, func(ctx context.Context, creds store.ContentImportCredentials, pageID string) (*docimport.Document, bool, error) {
+		if creds.NotionToken == "" {
+			return nil, false, nil
+		}
+		doc, err := s.DocImport.NotionPage(ctx, creds.NotionToken, pageID)
+		return doc, true, err
+	})
+}
+
+// handleImportConfluencePage handles POST /v1/integrations/confluence/import.
+func (s *Server) handleImportConfluencePage(w http.ResponseWriter, r *http.Request) {
+	s.importPage(w, r, "confluence", docimport.ParseConfluencePageID, func(ctx context.Context, creds store.ContentImportCredentials, pageID string) (*docimport.Document, bool, error) {
+		if creds.ConfluenceToken == "" {
+			return nil, false, nil
+		}
+		auth := docimport.ConfluenceAuth{BaseURL: creds.ConfluenceBaseURL, Email: creds.ConfluenceEmail, Token: creds.ConfluenceToken}
+		doc, err := s.DocImport.ConfluencePage(ctx, auth, pageID)
+		return doc, true, err
+	})
+}
+
+// importPage reads a page from source and creates a deck from it, the way
+// POST /v1/decks does: the page's Markdown goes to a bind job, or with
+// outline set each top-level section becomes a slide right away.
+func (s *Server) importPage(w http.ResponseWriter, r *http.Request, source string, parseID func(string) (string, bool), fetch pageFetcher) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+	var req ImportPageRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	pageID, ok := parseID(req.URL)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "url must be a "+source+" page link or page ID")
+		return
+	}
+
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return
+	}
+	var creds store.ContentImportCredentials
+	if org.ContentImport != nil {
+		creds = *org.ContentImport
+	}
+	doc, connected, err := fetch(r.Context(), creds, pageID)
+	switch {
+	case !connected:
+		writeError(w, r, http.StatusPreconditionFailed, "connect "+source+" for this organization first")
+		return
+	case errors.Is(err, docimport.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, docimport.ErrUnauthorized):
+		writeError(w, r, http.StatusPreconditionFailed, err.Error())
+		return
+	case err != nil:
+		logger.LogError(r.Context(), "api", "import_"+source+"_page", err, "page_id", pageID)
+		writeError(w, r, http.StatusBadGateway, "failed to fetch page from "+source)
+		return
+	}
+
+	content := doc.Markdown()
+	if len(doc.Blocks) == 0 {
+		writeError(w, r, http.StatusUnprocessableEntity, "page has no content")
+		return
+	}
+	if len(content) > maxImportedContent {
+		writeError(w, r, http.StatusUnprocessableEntity, "page is too long to import")
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = doc.Title
+	}
+	if len(name) < 3 {
+		name = "Imported page"
+	}
+	in := service.CreateDeckInput{
+		Name:                    name,
+		SourceTemplateVersionID: req.SourceTemplateVersion,
+		Content:                 content,
+		StockImages:             req.StockImages,
+	}
+	if req.Outline {
+		in.Outline = pageOutline(doc, name)
+	}
+
+	res, err := s.deckService().Create(r.Context(), id, in)
+	if err != nil {
+		s.writeServiceError(w, r, "import_"+source+"_page", "failed to create deck", err)
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "integration." + source + ".import", TargetRef: res.Deck.ID, Metadata: map[string]any{"pageId": pageID, "title": doc.Title}})
+	if res.Version != nil {
+		writeJSON(w, http.StatusOK, map[string]any{"deck": res.Deck, "version": res.Version})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"deck": res.Deck, "job": res.Job})
+}
+
+// pageOutline makes a slide of each of doc's sections, titling untitled
+// ones with the deck's name.
+func pageOutline(doc *docimport.Document, name string) service.DeckOutline {
+	var outline service.DeckOutline
+	for i, sec := range doc.Sections() {
+		title := sec.Title
+		if title == "" {
+			title = name
+		}
+		outline.Slides = append(outline.Slides, service.SlideOutline{SlideNumber: i + 1, Title: title, Content: sec.Points})
+	}
+	return outline
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestContentImport_NotionAndConfluence(t *testing.T) {
+	pages := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/pages/0123456789abcdef0123456789abcdef":
+			assert.Equal(t, "Bearer secret_notion", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"properties":{"Name":{"type":"title","title":[{"plain_text":"Q3 Plan"}]}}}`))
+		case r.URL.Path == "/blocks/0123456789abcdef0123456789abcdef/children":
+			_, _ = w.Write([]byte(`{"results":[
+				{"id":"b1","type":"heading_2","heading_2":{"rich_text":[{"plain_text":"Goals"}]}},
+				{"id":"b2","type":"bulleted_list_item","bulleted_list_item":{"rich_text":[{"plain_text":"Grow revenue in EMEA"}]}}
+			]}`))
+		case r.URL.Path == "/wiki/rest/api/content/98765":
+			if _, pass, _ := r.BasicAuth(); pass != "atl-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"title":"Launch","body":{"storage":{"value":"<h1>Timeline</h1><p>Beta ships in May.</p>"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer pages.Close()
+
+	s := NewServer()
+	s.DocImport.NotionAPIBase = pages.URL
+	h := s.Handler()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Org"}))
+	_, err := s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-imp", OrgID: "org-1", OwnerUserID: "user-1", Name: "Sales"})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-imp", Template: "tpl-imp", OrgID: "org-1", VersionNo: 1,
+		SpecJSON: json.RawMessage(`{"layouts":[{"name":"Content","placeholders":[{"id":"title","type":"text","geometry":{"x":0.5,"y":0.5,"w":9,"h":1}},{"id":"body","type":"text","geometry":{"x":0.5,"y":2,"w":9,"h":4}}]}]}`)})
+	require.NoError(t, err)
+	do := func(method, path, body string, role auth.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	notionImport := `{"url":"https://www.notion.so/acme/Q3-Plan-0123456789abcdef0123456789abcdef","sourceTemplateVersionId":"tv-imp"}`
+	assert.Equal(t, http.StatusPreconditionFailed, do(http.MethodPost, "/v1/integrations/notion/import", notionImport, auth.RoleEditor).Code)
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPut, "/v1/integrations/notion", `{"token":"secret_notion"}`, auth.RoleEditor).Code)
+	w := do(http.MethodPut, "/v1/integrations/notion", `{"token":"secret_notion"}`, auth.RoleAdmin)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "secret_notion")
+	assert.JSONEq(t, `{"notion":{"connected":true}}`, do(http.MethodGet, "/v1/integrations/notion", "", auth.RoleAdmin).Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/v1/integrations/notion/import", `{"url":"https://www.notion.so/acme","sourceTemplateVersionId":"tv-imp"}`, auth.RoleEditor).Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/v1/integrations/notion/import", notionImport, auth.RoleViewer).Code)
+
+	// The page's content goes to a bind job, like any new deck.
+	w = do(http.MethodPost, "/v1/integrations/notion/import", notionImport, auth.RoleEditor)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var queued struct {
+		Deck store.Deck `json:"deck"`
+		Job  store.Job  `json:"job"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &queued))
+	assert.Equal(t, "Q3 Plan", queued.Deck.Name)
+	assert.Equal(t, store.JobBind, queued.Job.Type)
+	deck, ok, err := s.Store.Decks().GetDeck(ctx, "org-1", queued.Deck.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "# Q3 Plan\n\n### Goals\n\n- Grow revenue in EMEA\n", deck.Content)
+
+	// Confluence, with an outline: each section is a slide right away.
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/v1/integrations/confluence", `{"baseUrl":"ftp://wiki","token":"t"}`, auth.RoleAdmin).Code)
+	w = do(http.MethodPut, "/v1/integrations/confluence", `{"baseUrl":"`+pages.URL+`/wiki/","email":"me@acme.com","token":"atl-token"}`, auth.RoleAdmin)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"confluence":{"connected":true,"baseUrl":"`+pages.URL+`/wiki","email":"me@acme.com"}}`, w.Body.String())
+
+	w = do(http.MethodPost, "/v1/integrations/confluence/import", `{"url":"`+pages.URL+`/wiki/spaces/ENG/pages/98765/Launch","sourceTemplateVersionId":"tv-imp","name":"Launch deck","outline":true}`, auth.RoleEditor)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var built struct {
+		Deck    store.Deck        `json:"deck"`
+		Version store.DeckVersion `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &built))
+	assert.Equal(t, "Launch deck", built.Deck.Name)
+	specJSON, err := json.Marshal(built.Version.SpecJSON)
+	require.NoError(t, err)
+	assert.Contains(t, string(specJSON), "Beta ships in May.")
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/v1/integrations/confluence/import", `{"url":"12345","sourceTemplateVersionId":"tv-imp"}`, auth.RoleEditor).Code)
+
+	// Disconnecting one source keeps the other.
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/v1/integrations/notion", "", auth.RoleAdmin).Code)
+	assert.JSONEq(t, `{"notion":{"connected":false}}`, do(http.MethodGet, "/v1/integrations/notion", "", auth.RoleAdmin).Body.String())
+	assert.Contains(t, do(http.MethodGet, "/v1/integrations/confluence", "", auth.RoleAdmin).Body.String(), `"connected":true`)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/v1/integrations/confluence", "", auth.RoleAdmin).Code)
+	org, err := s.Store.Organizations().GetOrganization(ctx, "org-1")
+	require.NoError(t, err)
+	assert.Nil(t, org.ContentImport)
+}
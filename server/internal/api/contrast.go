@@ -0,0 +1,31 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+)
+
+// specWarnings lists what's wrong with spec that doesn't stop it being
+// saved or rendered: fonts exports can't show and theme colors below WCAG AA
+// contrast.
+func (s *Server) specWarnings(r *http.Request, orgID string, sp any) []string {
+	return append(s.fontWarnings(r, orgID, sp), contrastWarnings(sp)...)
+}
+
+// contrastWarnings reports spec.CheckContrast issues in the spec's tokens.
+// A spec that can't be decoded has no warnings; validation reports that.
+func contrastWarnings(sp any) []string {
+	var ts spec.TemplateSpec
+	b, err := normalize.JSON(sp)
+	if err != nil || json.Unmarshal(b, &ts) != nil {
+		return nil
+	}
+	var warnings []string
+	for _, issue := range spec.CheckContrast(ts.Tokens) {
+		warnings = append(warnings, issue.String())
+	}
+	return warnings
+}
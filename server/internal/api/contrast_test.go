@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+)
+
+func TestValidate_WarnsOnLowContrast(t *testing.T) {
+	h := NewServer().Handler()
+	body := `{"tokens":{"colors":{"background":"#FFFFFF","text":"#CCCCCC","primary":"#1F3A93"}},"layouts":[{"name":"Title","placeholders":[{"id":"title","type":"text","geometry":{"x":0.1,"y":0.1,"w":0.8,"h":0.2}}]}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/templates/validate", strings.NewReader(body))
+	addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		OK       bool     `json:"ok"`
+		Warnings []string `json:"warnings"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.OK, "low contrast is a warning, not an error")
+	require.Len(t, resp.Warnings, 1)
+	assert.Contains(t, resp.Warnings[0], "tokens.colors.text #CCCCCC")
+}
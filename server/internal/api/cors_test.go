@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORS_PreflightForAllowedOrigin(t *testing.T) {
+	s := NewServer()
+	s.Config.CORSAllowedOrigins = []string{"https://app.example.com"}
+	s.Config.CORSAllowCredentials = true
+	s.Config.CORSMaxAgeSeconds = 300
+	h := s.Handler()
+
+	// Preflight for a protected route must succeed without auth.
+	req := httptest.NewRequest(http.MethodOptions, "/v1/templates", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "300", w.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+}
+
+func TestCORS_DisallowedOriginGetsNoHeaders(t *testing.T) {
+	s := NewServer()
+	s.Config.CORSAllowedOrigins = []string{"https://app.example.com"}
+	h := s.Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+}
+
+func TestCORS_WildcardNeverSendsCredentials(t *testing.T) {
+	s := NewServer()
+	s.Config.CORSAllowedOrigins = []string{"*"}
+	s.Config.CORSAllowCredentials = true
+	h := s.Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("Origin", "https://any.example.com")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, "https://any.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Empty(t, w.Header().Get("Strict-Transport-Security"), "HSTS only over HTTPS")
+
+	req = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Contains(t, w.Header().Get("Strict-Transport-Security"), "max-age=31536000")
+}
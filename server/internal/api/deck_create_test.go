@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// failingUpdateStore fails every UpdateDeck, including inside transactions.
+type failingUpdateStore struct{ store.Store }
+
+func (f failingUpdateStore) Decks() store.DeckStore { return failingUpdateDecks{f.Store.Decks()} }
+
+func (f failingUpdateStore) WithTx(ctx context.Context, fn func(tx store.Store) error) error {
+	return f.Store.WithTx(ctx, func(tx store.Store) error { return fn(failingUpdateStore{tx}) })
+}
+
+type failingUpdateDecks struct{ store.DeckStore }
+
+func (failingUpdateDecks) UpdateDeck(context.Context, store.Deck) (store.Deck, error) {
+	return store.Deck{}, errors.New("update failed")
+}
+
+func TestCreateDeck_RollsBackWhenSettingCurrentVersionFails(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	_, err := s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-tx", Template: "tpl-tx", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"tokens":{},"layouts":[{"name":"Base"}]}`)})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-tx", OrgID: "org-1", Name: "Tx", Status: store.TemplateDraft})
+	require.NoError(t, err)
+	s.Store = failingUpdateStore{s.Store}
+	h := s.Handler()
+
+	body := `{"name":"Atomic deck","sourceTemplateVersionId":"tv-tx","content":"Some content here","outline":{"slides":[{"slideNumber":1,"title":"Hi","content":["a"]}]}}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/decks", strings.NewReader(body))
+	addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusInternalServerError, w.Code, w.Body.String())
+
+	decks, err := s.Store.Decks().ListDecks(ctx, "org-1")
+	require.NoError(t, err)
+	assert.Empty(t, decks, "deck must not outlive its failed version")
+}
+
+func TestCreateDeck_ChecksContentDataAgainstRequiredFields(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	_, err := s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-req", Template: "tpl-req", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"tokens":{},"layouts":[{"name":"Base"}]}`),
+		RequiredFields: []store.TemplateField{
+			{Key: "revenue", Label: "Revenue", Type: "currency", Required: true},
+			{Key: "closeDate", Label: "Close date", Type: "date"},
+		}})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-req", OrgID: "org-1", Name: "Report", Status: store.TemplateDraft, LatestVersionNo: 1})
+	require.NoError(t, err)
+	h := s.Handler()
+
+	create := func(contentData string) *httptest.ResponseRecorder {
+		body := `{"name":"Q3 report","sourceTemplateVersionId":"tv-req","content":"Quarterly results","contentData":` + contentData + `}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/decks", strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	var resp ErrorResponse
+	w := create(`{"closeDate":"soon"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, ErrCodeValidation, resp.Code)
+	assert.Equal(t, []any{
+		map[string]any{"field": "contentData.revenue", "rule": "required"},
+		map[string]any{"field": "contentData.closeDate", "rule": "type", "param": "date"},
+	}, resp.Details)
+
+	w = create(`{"revenue":"$1.2M","closeDate":"2025-09-30"}`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `FIELDS:\nRevenue: $1.2M\nClose date: 2025-09-30`, "the bind job gets the content data")
+}
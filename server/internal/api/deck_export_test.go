@@ -162,3 +162,225 @@ func TestDeckExportList_AggregatesMultipleVersions(t *testing.T) {
 	_ = json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.Len(t, resp.Exports, 2, "Should return jobs from both versions")
 }
+
+func TestExportDeckVersion_BlockedByStorageQuota(t *testing.T) {
+	s := NewServer()
+	s.Config.StorageLimitBytes = 1000
+	h := s.Handler()
+	ctx := context.Background()
+
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-quota", OrgID: "org-1", Name: "Deck"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "version-quota", Deck: "deck-quota", OrgID: "org-1", VersionNo: 1})
+	require.NoError(t, err)
+	_, err = s.Store.Assets().Create(ctx, store.Asset{ID: "big-asset", OrgID: "org-1", Type: store.AssetPPTX, SizeBytes: 1000})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/deck-versions/version-quota/export", nil)
+	addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusPaymentRequired, w.Code, w.Body.String())
+	var quotaErr struct {
+		Code    string       `json:"code"`
+		Details QuotaDetails `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &quotaErr))
+	assert.Equal(t, ErrCodeQuotaExceeded, quotaErr.Code)
+	assert.Equal(t, "storage", quotaErr.Details.Quota)
+	assert.Equal(t, int64(1000), quotaErr.Details.Used)
+
+	// Deleting the asset frees the quota and is reflected in /v1/usage.
+	deleted, err := s.Store.Assets().Delete(ctx, "org-1", "big-asset")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	usageReq := httptest.NewRequest(http.MethodGet, "/v1/usage", nil)
+	addTestAuth(usageReq, "user-1", "org-1", auth.RoleEditor)
+	usageW := httptest.NewRecorder()
+	h.ServeHTTP(usageW, usageReq)
+	require.Equal(t, http.StatusOK, usageW.Code)
+	var usage UsageResponse
+	require.NoError(t, json.Unmarshal(usageW.Body.Bytes(), &usage))
+	require.NotNil(t, usage.Storage)
+	assert.Equal(t, int64(0), usage.Storage.UsedBytes)
+	assert.False(t, usage.Storage.Blocked)
+}
+
+func TestExportDeckVersion_BundleFormat(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-bundle", OrgID: "org-1", Name: "Bundle Deck"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "ver-bundle", Deck: "deck-bundle", OrgID: "org-1", VersionNo: 2, SpecJSON: []byte(`{"layouts":[]}`)})
+	require.NoError(t, err)
+
+	post := func(format string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/deck-versions/ver-bundle/export?format="+format, nil)
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, post("docx").Code)
+
+	w := post("bundle")
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var resp struct {
+		Job store.Job `json:"job"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Job.Metadata)
+	assert.Equal(t, store.ExportFormatBundle, (*resp.Job.Metadata)["format"])
+	assert.Contains(t, (*resp.Job.Metadata)["filename"], "deck-export-v2")
+	assert.Contains(t, (*resp.Job.Metadata)["filename"], ".zip")
+}
+
+func TestExportDeckVersion_WithComments(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-comments", OrgID: "org-1", Name: "Reviewed Deck"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "ver-comments", Deck: "deck-comments", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"layouts":[]}`)})
+	require.NoError(t, err)
+
+	post := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/deck-versions/ver-comments/export?"+query, nil)
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, post("comments=true&format=bundle").Code)
+
+	// Comments change without the deck changing, so each export renders
+	// afresh.
+	var jobIDs []string
+	for range 2 {
+		w := post("comments=true")
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		var resp struct {
+			Job store.Job `json:"job"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Job.Metadata)
+		assert.Equal(t, "true", (*resp.Job.Metadata)["comments"])
+		jobIDs = append(jobIDs, resp.Job.ID)
+	}
+	assert.NotEqual(t, jobIDs[0], jobIDs[1])
+}
+
+func TestExportDeckVersion_ImageFormats(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-images", OrgID: "org-1", Name: "Image Deck"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "ver-images", Deck: "deck-images", OrgID: "org-1", VersionNo: 4, SpecJSON: []byte(`{"layouts":[]}`)})
+	require.NoError(t, err)
+
+	post := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/deck-versions/ver-images/export?"+query, nil)
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	job := func(w *httptest.ResponseRecorder) store.Job {
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		var resp struct {
+			Job store.Job `json:"job"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Job.Metadata)
+		return resp.Job
+	}
+
+	for _, query := range []string{"format=png&width=100", "format=gif&width=wide", "width=800", "format=gif&comments=true"} {
+		assert.Equal(t, http.StatusBadRequest, post(query).Code, query)
+	}
+
+	png := job(post("format=png&width=1280"))
+	assert.Equal(t, store.ExportFormatPNG, (*png.Metadata)["format"])
+	assert.Equal(t, "1280", (*png.Metadata)["width"])
+	assert.Contains(t, (*png.Metadata)["filename"], ".zip")
+
+	gif := job(post("format=gif"))
+	assert.Equal(t, store.ExportFormatGIF, (*gif.Metadata)["format"])
+	assert.Equal(t, "640", (*gif.Metadata)["width"])
+	assert.Contains(t, (*gif.Metadata)["filename"], ".gif")
+	assert.NotEqual(t, gif.ID, job(post("format=gif")).ID)
+}
+
+func TestExportDeckVersion_Markdown(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-md", OrgID: "org-1", Name: "Notes Deck"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "ver-md", Deck: "deck-md", OrgID: "org-1", VersionNo: 2, SpecJSON: []byte(`{"layouts":[]}`)})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/deck-versions/ver-md/export?format=md", nil)
+	addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var resp struct {
+		Job store.Job `json:"job"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Job.Metadata)
+	assert.Equal(t, store.ExportFormatMarkdown, (*resp.Job.Metadata)["format"])
+	assert.Contains(t, (*resp.Job.Metadata)["filename"], ".md")
+}
+
+func TestExportDeckVersion_TargetSize(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-budget", OrgID: "org-1", Name: "Budget Deck"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "ver-budget", Deck: "deck-budget", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"layouts":[]}`)})
+	require.NoError(t, err)
+
+	post := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/deck-versions/ver-budget/export?"+query, nil)
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	job := func(w *httptest.ResponseRecorder) store.Job {
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		var resp struct {
+			Job store.Job `json:"job"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Job
+	}
+
+	for _, query := range []string{"targetSizeMb=0.5", "targetSizeMb=2048", "targetSizeMb=small", "targetSizeMb=NaN", "format=png&targetSizeMb=10"} {
+		assert.Equal(t, http.StatusBadRequest, post(query).Code, query)
+	}
+
+	budget := job(post("targetSizeMb=9.5"))
+	require.NotNil(t, budget.Metadata)
+	assert.Equal(t, "9961472", (*budget.Metadata)["targetSize"])
+
+	// The budget shapes the file, so it is part of the cache key: the same
+	// budget reuses the export and none or another doesn't.
+	assert.Equal(t, budget.ID, job(post("targetSizeMb=9.5")).ID)
+	assert.NotEqual(t, budget.ID, job(post("targetSizeMb=20")).ID)
+	assert.NotEqual(t, budget.ID, job(post("")).ID)
+}
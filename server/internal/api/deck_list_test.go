@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestListDecks_IncludeCurrentVersionSummary(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	current := "dv-1"
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", OwnerUserID: "user-1", Name: "Pitch", CurrentVersion: &current})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-2", OrgID: "org-1", OwnerUserID: "user-1", Name: "Empty"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: "deck-1", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"tokens":{},"layouts":[{"name":"Title"},{"name":"Close"}]}`)})
+	require.NoError(t, err)
+	_, err = s.Store.Jobs().Enqueue(ctx, store.Job{ID: "job-1", OrgID: "org-1", Type: store.JobExport, Status: store.JobDone, InputRef: "dv-1"})
+	require.NoError(t, err)
+	h := s.Handler()
+
+	list := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/decks"+query, nil)
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	type listed struct {
+		ID      string             `json:"id"`
+		Summary *store.DeckSummary `json:"currentVersionSummary"`
+	}
+	decode := func(w *httptest.ResponseRecorder) map[string]listed {
+		t.Helper()
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct{ Decks []listed }
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		out := map[string]listed{}
+		for _, d := range resp.Decks {
+			out[d.ID] = d
+		}
+		return out
+	}
+
+	plain := list("")
+	require.Len(t, decode(plain), 2)
+	assert.NotContains(t, plain.Body.String(), `"currentVersionSummary"`, "summaries are only included on request")
+
+	included := decode(list("?include=currentVersionSummary"))
+	require.Len(t, included, 2)
+	sum := included["deck-1"].Summary
+	require.NotNil(t, sum)
+	assert.Equal(t, 2, sum.SlideCount)
+	assert.NotNil(t, sum.LastExportedAt)
+	assert.Equal(t, "/v1/decks/deck-1/present/slides/0/thumbnail?v=dv-1", sum.ThumbnailURL)
+	assert.Nil(t, included["deck-2"].Summary)
+
+	assert.Equal(t, http.StatusBadRequest, list("?include=slides").Code)
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/service"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// handleCreateDeckFromMarkdown handles POST /v1/decks/from-markdown. It
+// reads the document the way the Markdown export writes it, so a deck can be
+// exported, edited as text and imported again.
+func (s *Server) handleCreateDeckFromMarkdown(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+	var req CreateDeckFromMarkdownRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	doc := spec.ParseMarkdown(req.Markdown)
+	if len(doc.Slides) == 0 {
+		writeError(w, r, http.StatusUnprocessableEntity, "document has no slides")
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = doc.Title
+	}
+	if name == "" {
+		name = doc.Slides[0].Title
+	}
+	if len(name) < 3 {
+		name = "Imported deck"
+	}
+	in := service.CreateDeckInput{
+		Name:                    name,
+		SourceTemplateVersionID: req.SourceTemplateVersion,
+		StockImages:             req.StockImages,
+	}
+	warnings := []string{}
+	if req.Design {
+		in.Content = req.Markdown
+		for _, sld := range doc.Slides {
+			if len(sld.Images) > 0 {
+				warnings = append(warnings, "images are only placed when design is off")
+				break
+			}
+		}
+	} else {
+		var outline service.DeckOutline
+		outline, warnings = s.markdownOutline(r.Context(), id.OrgID, doc, name)
+		in.Outline = outline
+	}
+
+	res, err := s.deckService().Create(r.Context(), id, in)
+	if err != nil {
+		s.writeServiceError(w, r, "create_deck_from_markdown", "failed to create deck", err)
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.import.markdown", TargetRef: res.Deck.ID, Metadata: map[string]any{"slides": len(doc.Slides), "design": req.Design}})
+	if res.Version != nil {
+		writeJSON(w, http.StatusOK, map[string]any{"deck": res.Deck, "version": res.Version, "warnings": warnings})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"deck": res.Deck, "job": res.Job, "warnings": warnings})
+}
+
+// markdownOutline makes a slide of each of doc's slides, titling untitled
+// ones with the deck's name. Images must be the org's own image assets,
+// linked by ID or API URL; others are left out with a warning.
+func (s *Server) markdownOutline(ctx context.Context, orgID string, doc spec.MarkdownDeck, name string) (service.DeckOutline, []string) {
+	var outline service.DeckOutline
+	warnings := []string{}
+	var ids []string
+	for _, sld := range doc.Slides {
+		for _, ref := range sld.Images {
+			if id, ok := markdownAssetID(ref); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	images, loadErr := s.Store.Assets().GetAssets(ctx, orgID, ids)
+	if loadErr != nil {
+		logger.LogError(ctx, "api", "markdown_images", loadErr)
+	}
+	for i, sld := range doc.Slides {
+		title := sld.Title
+		if title == "" {
+			title = name
+		}
+		so := service.SlideOutline{SlideNumber: i + 1, Title: title, Content: sld.Lines}
+		for _, ref := range sld.Images {
+			img, err := markdownImage(images, loadErr, ref)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("slide %d: image %q %v", i+1, ref.Target, err))
+				continue
+			}
+			so.Images = append(so.Images, img)
+		}
+		if len(so.Images) > 1 {
+			warnings = append(warnings, fmt.Sprintf("slide %d: only the first image is placed", i+1))
+		}
+		outline.Slides = append(outline.Slides, so)
+	}
+	return outline, warnings
+}
+
+// markdownAssetID is the asset ID an image link names, by ID or API URL.
+func markdownAssetID(ref spec.MarkdownImage) (string, bool) {
+	assetID := ref.Target
+	if u, err := url.Parse(ref.Target); err == nil {
+		assetID = u.Path
+		if i := strings.LastIndex(assetID, "/assets/"); i >= 0 {
+			assetID = assetID[i+len("/assets/"):]
+		}
+	}
+	return assetID, uuid.Validate(assetID) == nil
+}
+
+// markdownImage resolves an image link to its asset among images, the
+// org's assets the links name; loadErr is the error loading them, if any.
+func markdownImage(images map[string]store.Asset, loadErr error, ref spec.MarkdownImage) (spec.Image, error) {
+	assetID, ok := markdownAssetID(ref)
+	if !ok {
+		return spec.Image{}, errors.New("is not an asset of this organization")
+	}
+	if loadErr != nil {
+		return spec.Image{}, errors.New("could not be loaded")
+	}
+	a, ok := images[assetID]
+	if !ok {
+		return spec.Image{}, errors.New("is not an asset of this organization")
+	}
+	if !strings.HasPrefix(a.Mime, "image/") {
+		return spec.Image{}, errors.New("is not an image")
+	}
+	return spec.Image{Path: a.Path, Mime: a.Mime, Alt: ref.Alt}, nil
+}
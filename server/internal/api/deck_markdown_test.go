@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestCreateDeckFromMarkdown(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Org"}))
+	_, err := s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-md", OrgID: "org-1", OwnerUserID: "user-1", Name: "Sales"})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-md", Template: "tpl-md", OrgID: "org-1", VersionNo: 1,
+		SpecJSON: json.RawMessage(`{"layouts":[{"name":"Content","placeholders":[{"id":"title","type":"text","geometry":{"x":0.05,"y":0.05,"w":0.9,"h":0.12}},{"id":"body","type":"text","geometry":{"x":0.05,"y":0.2,"w":0.45,"h":0.7}}]}]}`)})
+	require.NoError(t, err)
+	photo, err := s.Store.Assets().Create(ctx, store.Asset{ID: "0f8fad5b-d9cb-469f-a165-70867728950e", OrgID: "org-1", Type: store.AssetPNG, Path: "org-1/team.png", Mime: "image/png"})
+	require.NoError(t, err)
+	pdf, err := s.Store.Assets().Create(ctx, store.Asset{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", OrgID: "org-1", Type: store.AssetFile, Path: "org-1/notes.pdf", Mime: "application/pdf"})
+	require.NoError(t, err)
+
+	do := func(body any, role auth.Role) *httptest.ResponseRecorder {
+		b, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/v1/decks/from-markdown", strings.NewReader(string(b)))
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	doc := "---\nmarp: true\ntitle: \"Q3 Review\"\n---\n\n# Highlights\n\n- Revenue up 20%\n- Churn down\n\n" +
+		"![Team](https://cms.example.com/v1/assets/" + photo.ID + ")\n\n---\n\n# Next\n\n" +
+		"![Notes](" + pdf.ID + ")\n![Elsewhere](https://example.com/x.png)\n\nHire two engineers\n"
+	assert.Equal(t, http.StatusForbidden, do(map[string]any{"markdown": doc, "sourceTemplateVersionId": "tv-md"}, auth.RoleViewer).Code)
+	assert.Equal(t, http.StatusBadRequest, do(map[string]any{"sourceTemplateVersionId": "tv-md"}, auth.RoleEditor).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(map[string]any{"markdown": "---\nmarp: true\n---\n<!-- empty -->\n", "sourceTemplateVersionId": "tv-md"}, auth.RoleEditor).Code)
+
+	// Built right away: each slide fills the first layout, and linked image
+	// assets go in a free spot.
+	w := do(map[string]any{"markdown": doc, "sourceTemplateVersionId": "tv-md"}, auth.RoleEditor)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var built struct {
+		Deck     store.Deck        `json:"deck"`
+		Version  store.DeckVersion `json:"version"`
+		Warnings []string          `json:"warnings"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &built))
+	assert.Equal(t, "Q3 Review", built.Deck.Name)
+	assert.Len(t, built.Warnings, 2)
+	assert.Contains(t, built.Warnings[0], "is not an image")
+	assert.Contains(t, built.Warnings[1], "is not an asset of this organization")
+
+	specJSON, err := json.Marshal(built.Version.SpecJSON)
+	require.NoError(t, err)
+	var ts spec.TemplateSpec
+	require.NoError(t, json.Unmarshal(specJSON, &ts))
+	require.Len(t, ts.Layouts, 2)
+	first := ts.Layouts[0].Placeholders
+	require.Len(t, first, 3)
+	assert.Equal(t, "Highlights", first[0].Content)
+	assert.Equal(t, "Revenue up 20%\nChurn down", first[1].Content)
+	require.NotNil(t, first[2].Image)
+	assert.Equal(t, spec.Image{Path: "org-1/team.png", Mime: "image/png", Alt: "Team"}, *first[2].Image)
+	assert.Len(t, ts.Layouts[1].Placeholders, 2)
+	assert.Equal(t, "Hire two engineers", ts.Layouts[1].Placeholders[1].Content)
+
+	// The Markdown export of the new deck reads back the same slides.
+	assert.Equal(t, spec.ParseMarkdown(doc).Slides[0].Lines, spec.ParseMarkdown(spec.Markdown(ts, built.Deck.Name)).Slides[0].Lines)
+
+	// With design, the AI binds the document to the template.
+	w = do(map[string]any{"markdown": doc, "sourceTemplateVersionId": "tv-md", "name": "Designed", "design": true}, auth.RoleEditor)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var queued struct {
+		Deck     store.Deck `json:"deck"`
+		Job      store.Job  `json:"job"`
+		Warnings []string   `json:"warnings"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &queued))
+	assert.Equal(t, "Designed", queued.Deck.Name)
+	assert.Equal(t, store.JobBind, queued.Job.Type)
+	assert.Equal(t, []string{"images are only placed when design is off"}, queued.Warnings)
+	deck, ok, err := s.Store.Decks().GetDeck(ctx, "org-1", queued.Deck.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, doc, deck.Content)
+}
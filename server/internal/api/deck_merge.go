@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// handleMergeDecks handles POST /v1/decks/merge. The new deck's spec is the
+// selected slides in request order; its tokens come from the first selection,
+// overlaid with the brand kit's when one is given, so the merged slides share
+// one look.
+func (s *Server) handleMergeDecks(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	var req MergeDecksRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	var merged spec.TemplateSpec
+	var sourceTemplateVersion string
+	sources := make([]string, 0, len(req.Selections))
+	for i, sel := range req.Selections {
+		dv, ok, err := s.Store.Decks().GetDeckVersion(r.Context(), id.OrgID, sel.DeckVersionID)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "merge_decks", err, "deck_version_id", sel.DeckVersionID)
+			writeError(w, r, http.StatusInternalServerError, "failed to load deck version")
+			return
+		}
+		if !ok {
+			writeError(w, r, http.StatusNotFound, fmt.Sprintf("deck version %s not found", sel.DeckVersionID))
+			return
+		}
+		d, ok := s.authorizeDeck(w, r, id, dv.Deck, store.PermissionView)
+		if !ok {
+			return
+		}
+
+		var ts spec.TemplateSpec
+		specBytes, err := normalize.JSON(dv.SpecJSON)
+		if err == nil {
+			err = json.Unmarshal(specBytes, &ts)
+		}
+		if err != nil {
+			logger.LogError(r.Context(), "api", "merge_decks", err, "deck_version_id", dv.ID)
+			writeErrorCode(w, r, http.StatusUnprocessableEntity, ErrCodeSpecInvalid, fmt.Sprintf("deck version %s has an unreadable spec", dv.ID), nil)
+			return
+		}
+
+		from, to := 1, len(ts.Layouts)
+		if sel.From != nil {
+			from = *sel.From
+		}
+		if sel.To != nil {
+			to = *sel.To
+		}
+		if from > to || to > len(ts.Layouts) {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("selection %d: slides %d-%d out of range for a %d-slide deck", i+1, from, to, len(ts.Layouts)))
+			return
+		}
+
+		if i == 0 {
+			merged.Tokens, merged.Constraints = ts.Tokens, ts.Constraints
+			sourceTemplateVersion = d.SourceTemplateVersion
+		}
+		merged.Layouts = append(merged.Layouts, ts.Layouts[from-1:to]...)
+		sources = append(sources, dv.ID)
+	}
+	if len(merged.Layouts) == 0 {
+		writeError(w, r, http.StatusBadRequest, "selections contain no slides")
+		return
+	}
+
+	if req.BrandKitID != "" {
+		tokens, ok, err := s.brandKitTokens(r, id.OrgID, req.BrandKitID)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "merge_decks", err, "brand_kit_id", req.BrandKitID)
+			writeError(w, r, http.StatusInternalServerError, "failed to load brand kit")
+			return
+		}
+		if !ok {
+			writeError(w, r, http.StatusNotFound, "brand kit not found")
+			return
+		}
+		merged.Tokens = mergeTokens(merged.Tokens, tokens)
+	}
+
+	specBytes, err := json.Marshal(merged)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "failed to marshal merged spec")
+		return
+	}
+
+	deck := store.Deck{
+		OrgID:                 id.OrgID,
+		OwnerUserID:           id.UserID,
+		Name:                  req.Name,
+		SourceTemplateVersion: sourceTemplateVersion,
+	}
+	var createdDeck store.Deck
+	var createdVer store.DeckVersion
+	err = s.Store.WithTx(r.Context(), func(tx store.Store) error {
+		var err error
+		if createdDeck, err = tx.Decks().CreateDeck(r.Context(), deck); err != nil {
+			return fmt.Errorf("create deck: %w", err)
+		}
+		ver := store.DeckVersion{
+			ID:        newID("dv"),
+			Deck:      createdDeck.ID,
+			OrgID:     id.OrgID,
+			VersionNo: 1,
+			SpecJSON:  json.RawMessage(specBytes),
+			CreatedBy: id.UserID,
+		}
+		if createdVer, err = tx.Decks().CreateDeckVersion(r.Context(), ver); err != nil {
+			return fmt.Errorf("create deck version: %w", err)
+		}
+		createdDeck.CurrentVersion = &createdVer.ID
+		createdDeck.LatestVersionNo = 1
+		if createdDeck, err = tx.Decks().UpdateDeck(r.Context(), createdDeck); err != nil {
+			return fmt.Errorf("set current version: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "merge_decks", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to create deck")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.merge", TargetRef: createdDeck.ID, Metadata: map[string]any{"sourceVersionIds": sources, "brandKitId": req.BrandKitID, "slides": len(merged.Layouts)}})
+	s.emitDeckCreated(r, createdDeck)
+
+	writeJSON(w, http.StatusOK, map[string]any{"deck": createdDeck, "version": createdVer})
+}
+
+// brandKitTokens returns the tokens of one of the org's brand kits. Postgres
+// hands jsonb back as raw bytes while the memory store keeps the decoded map,
+// so both are accepted.
+func (s *Server) brandKitTokens(r *http.Request, orgID, brandKitID string) (map[string]any, bool, error) {
+	kits, err := s.Store.BrandKits().List(r.Context(), orgID)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, bk := range kits {
+		if bk.ID != brandKitID {
+			continue
+		}
+		var raw []byte
+		switch t := bk.Tokens.(type) {
+		case map[string]any:
+			return t, true, nil
+		case []byte:
+			raw = t
+		case string:
+			raw = []byte(t)
+		default:
+			if raw, err = json.Marshal(t); err != nil {
+				return nil, false, err
+			}
+		}
+		var tokens map[string]any
+		if err := json.Unmarshal(raw, &tokens); err != nil {
+			return nil, false, fmt.Errorf("brand kit %s tokens: %w", brandKitID, err)
+		}
+		return tokens, true, nil
+	}
+	return nil, false, nil
+}
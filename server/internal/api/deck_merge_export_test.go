@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestExportDeckVersion_MergesData(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-merge", OrgID: "org-1", Name: "Proposal"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "ver-merge", Deck: "deck-merge", OrgID: "org-1", VersionNo: 1,
+		SpecJSON: []byte(`{"layouts":[{"name":"Title","placeholders":[{"id":"title","type":"text","content":"Proposal for {{customer}}, {{seats}} seats"}]}]}`)})
+	require.NoError(t, err)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/deck-versions/ver-merge/export", strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	job := func(w *httptest.ResponseRecorder) store.Job {
+		var resp struct {
+			Job store.Job `json:"job"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Job
+	}
+
+	w := post(`{"data":{"customer":"Acme","seats":250}}`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	acme := job(w)
+	require.NotNil(t, acme.Metadata)
+	assert.JSONEq(t, `{"customer":"Acme","seats":"250"}`, (*acme.Metadata)["mergeData"])
+
+	w = post(`{"data":{"customer":"Globex","seats":10}}`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	assert.NotEqual(t, acme.ID, job(w).ID, "each data set is its own export")
+
+	w = post(`{"data":{"customer":"Acme","seats":250}}`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	assert.Equal(t, acme.ID, job(w).ID, "the same data reuses the export")
+
+	w = post(`{"data":{"customer":"Acme"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "missing deck variables: seats")
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"data":{"customer":{"name":"Acme"},"seats":1}}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"data":{"first name":"Ann","customer":"Acme","seats":1}}`).Code)
+}
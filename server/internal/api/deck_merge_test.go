@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestMergeDecks_ConcatenatesRangesAndAppliesBrandKit(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+
+	for _, d := range []struct{ deck, version, spec string }{
+		{"deck-a", "dv-a", `{"tokens":{"colors":{"primary":"#111111","accent":"#222222"}},"constraints":{"safeMargin":0.5},"layouts":[{"name":"A1"},{"name":"A2"},{"name":"A3"}]}`},
+		{"deck-b", "dv-b", `{"tokens":{"colors":{"primary":"#999999"}},"layouts":[{"name":"B1"},{"name":"B2"}]}`},
+	} {
+		_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: d.deck, OrgID: "org-1", OwnerUserID: "user-1", Name: d.deck, SourceTemplateVersion: "tv-" + d.deck})
+		require.NoError(t, err)
+		_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: d.version, Deck: d.deck, OrgID: "org-1", VersionNo: 1, SpecJSON: json.RawMessage(d.spec)})
+		require.NoError(t, err)
+	}
+	_, err := s.Store.BrandKits().Create(ctx, store.BrandKit{ID: "bk-1", OrgID: "org-1", Name: "Brand", Tokens: map[string]any{"colors": map[string]any{"primary": "#FF0000"}}})
+	require.NoError(t, err)
+
+	do := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/decks/merge", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(`{"name":"Combined","brandKitId":"bk-1","selections":[{"deckVersionId":"dv-a","from":2,"to":3},{"deckVersionId":"dv-b"},{"deckVersionId":"dv-a","from":1,"to":1}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Deck    store.Deck `json:"deck"`
+		Version struct {
+			ID   string            `json:"id"`
+			Spec spec.TemplateSpec `json:"spec"`
+		} `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "Combined", resp.Deck.Name)
+	assert.Equal(t, "tv-deck-a", resp.Deck.SourceTemplateVersion)
+	require.NotNil(t, resp.Deck.CurrentVersion)
+	assert.Equal(t, resp.Version.ID, *resp.Deck.CurrentVersion)
+
+	var names []string
+	for _, l := range resp.Version.Spec.Layouts {
+		names = append(names, l.Name)
+	}
+	assert.Equal(t, []string{"A2", "A3", "B1", "B2", "A1"}, names)
+	colors := resp.Version.Spec.Tokens["colors"].(map[string]any)
+	assert.Equal(t, "#FF0000", colors["primary"])
+	assert.Equal(t, "#222222", colors["accent"])
+	assert.Equal(t, 0.5, resp.Version.Spec.Constraints.SafeMargin)
+
+	w = do(`{"name":"Combined","selections":[{"deckVersionId":"dv-b","from":2,"to":5}]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(`{"name":"Combined","selections":[{"deckVersionId":"dv-missing"}]}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(`{"name":"Combined","brandKitId":"bk-missing","selections":[{"deckVersionId":"dv-a"}]}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/service"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// handleRefineDeck handles POST /v1/decks/{id}/refine. The AI applies the
+// instruction to the deck's current version, with the deck's earlier
+// instructions as context, and the result becomes the next version.
+func (s *Server) handleRefineDeck(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+
+	var req RefineDeckRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	res, err := s.deckService().Refine(r.Context(), id, service.RefineInput{
+		DeckID:      r.PathValue("id"),
+		Instruction: req.Instruction,
+		Params:      req.params(),
+	})
+	if err != nil {
+		s.writeServiceError(w, r, "refine_deck", "failed to refine deck", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deck": res.Deck, "version": res.Version, "refinement": res.Refinement})
+}
+
+// handleListDeckRefinements handles GET /v1/decks/{id}/refinements.
+func (s *Server) handleListDeckRefinements(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	d, ok := s.authorizeDeck(w, r, id, r.PathValue("id"), store.PermissionView)
+	if !ok {
+		return
+	}
+	refinements, err := s.Store.Decks().ListRefinements(r.Context(), id.OrgID, d.ID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_deck_refinements", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list refinements")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"refinements": refinements})
+}
+
+// handleRegenerateSlide handles POST
+// /v1/deck-versions/{versionId}/slides/{index}/regenerate. Only the slide at
+// the 0-based index goes through the AI; the deck's next version is the
+// source version with that slide replaced.
+func (s *Server) handleRegenerateSlide(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil || index < 0 {
+		writeError(w, r, http.StatusBadRequest, "slide index must be a non-negative integer")
+		return
+	}
+	var req RegenerateSlideRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	d, ver, err := s.deckService().RegenerateSlide(r.Context(), id, service.RegenerateSlideInput{
+		VersionID: r.PathValue("versionId"),
+		Index:     index,
+		Guidance:  req.Guidance,
+		Params:    req.params(),
+	})
+	if err != nil {
+		s.writeServiceError(w, r, "regenerate_slide", "failed to regenerate slide", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deck": d, "version": ver})
+}
+
+// handleTransformDeck handles POST /v1/deck-versions/{versionId}/transform.
+// The version's texts go through the AI; the deck's next version is the
+// source version with them rewritten.
+func (s *Server) handleTransformDeck(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+
+	var req TransformDeckRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	d, ver, err := s.deckService().Transform(r.Context(), id, service.TransformInput{
+		VersionID: r.PathValue("versionId"),
+		Operation: req.Operation,
+		Language:  req.Language,
+	})
+	if err != nil {
+		s.writeServiceError(w, r, "transform_deck", "failed to transform deck", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deck": d, "version": ver})
+}
+
+// handleTranslateDeck handles POST /v1/decks/{id}/translate?lang=ar. The
+// deck's current version is translated into its linked deck for that
+// language, which is created (201) the first time and gets a new version
+// (200) after that.
+func (s *Server) handleTranslateDeck(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+
+	lang := r.URL.Query().Get("lang")
+	if err := s.validate.Var(lang, "required,bcp47_language_tag"); err != nil {
+		writeError(w, r, http.StatusBadRequest, "lang must be a BCP 47 language tag")
+		return
+	}
+
+	res, err := s.deckService().Translate(r.Context(), id, service.TranslateInput{
+		DeckID:   r.PathValue("id"),
+		Language: lang,
+	})
+	if err != nil {
+		s.writeServiceError(w, r, "translate_deck", "failed to translate deck", err)
+		return
+	}
+	status := http.StatusOK
+	if res.Created {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, map[string]any{"deck": res.Deck, "version": res.Version})
+}
+
+// handleListDeckTranslations handles GET /v1/decks/{id}/translations.
+func (s *Server) handleListDeckTranslations(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	decks, err := s.deckService().ListTranslations(r.Context(), id, r.PathValue("id"))
+	if err != nil {
+		s.writeServiceError(w, r, "list_deck_translations", "failed to list translations", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"decks": decks})
+}
+
+// handleGetDeckVersionReview handles GET
+// /v1/deck-versions/{versionId}/review: the figures in the version's
+// AI-written text that its content doesn't back up.
+func (s *Server) handleGetDeckVersionReview(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	dv, ok := s.loadCommentableVersion(w, r, id, r.PathValue("versionId"))
+	if !ok {
+		return
+	}
+	flags := dv.ReviewRequired
+	if flags == nil {
+		flags = []store.ReviewFlag{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"versionId": dv.ID, "reviewRequired": flags})
+}
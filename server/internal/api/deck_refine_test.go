@@ -0,0 +1,277 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestRefineDeck_CreatesVersionsAndKeepsConversation(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	_, err := s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-ref", Template: "tpl-ref", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"tokens":{},"layouts":[{"name":"Base","placeholders":[{"id":"title","type":"text","geometry":{"x":0.1,"y":0.1,"w":0.8,"h":0.2}}]}]}`)})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-ref", OrgID: "org-1", Name: "Refine", Status: store.TemplateDraft, LatestVersionNo: 1})
+	require.NoError(t, err)
+	h := s.Handler()
+
+	do := func(method, path, body string, role auth.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/v1/decks", `{"name":"Launch","sourceTemplateVersionId":"tv-ref","content":"Launch plan for Q3","outline":{"slides":[{"slideNumber":1,"title":"Intro","content":["a"]},{"slideNumber":2,"title":"Plan","content":["b"]}]}}`, auth.RoleEditor)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var created struct {
+		Deck store.Deck `json:"deck"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	refinePath := "/v1/decks/" + created.Deck.ID + "/refine"
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, refinePath, `{"instruction":"add a pricing slide"}`, auth.RoleViewer).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, refinePath, `{"instruction":""}`, auth.RoleEditor).Code)
+
+	var refined struct {
+		Deck       store.Deck           `json:"deck"`
+		Version    store.DeckVersion    `json:"version"`
+		Refinement store.DeckRefinement `json:"refinement"`
+	}
+	w = do(http.MethodPost, refinePath, `{"instruction":"add a pricing slide"}`, auth.RoleEditor)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &refined))
+	assert.Equal(t, 2, refined.Version.VersionNo)
+	assert.Equal(t, refined.Version.ID, *refined.Deck.CurrentVersion)
+	assert.Equal(t, *created.Deck.CurrentVersion, refined.Refinement.FromVersionID)
+	var deckSpec spec.TemplateSpec
+	require.NoError(t, json.Unmarshal(refined.Version.SpecJSON, &deckSpec))
+	assert.Len(t, deckSpec.Layouts, 3)
+
+	w = do(http.MethodPost, refinePath, `{"instruction":"remove slide 1"}`, auth.RoleEditor)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &refined))
+	assert.Equal(t, 3, refined.Version.VersionNo)
+	require.NoError(t, json.Unmarshal(refined.Version.SpecJSON, &deckSpec))
+	assert.Len(t, deckSpec.Layouts, 2)
+
+	var history struct {
+		Refinements []store.DeckRefinement `json:"refinements"`
+	}
+	w = do(http.MethodGet, "/v1/decks/"+created.Deck.ID+"/refinements", "", auth.RoleViewer)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	require.Len(t, history.Refinements, 2)
+	assert.Empty(t, history.Refinements[0].Instruction, "the org doesn't store prompts")
+	assert.Equal(t, refined.Version.ID, history.Refinements[1].VersionID)
+
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Acme"}))
+	_, err = s.Store.Organizations().SetSettings(ctx, "org-1", &store.OrgSettings{StorePrompts: true})
+	require.NoError(t, err)
+	w = do(http.MethodPost, refinePath, `{"instruction":"shorten the intro"}`, auth.RoleEditor)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(http.MethodGet, "/v1/decks/"+created.Deck.ID+"/refinements", "", auth.RoleViewer)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	require.Len(t, history.Refinements, 3)
+	assert.Equal(t, "shorten the intro", history.Refinements[2].Instruction)
+}
+
+func TestRegenerateSlide_ReplacesOnlyThatSlide(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	_, err := s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-slide", Template: "tpl-slide", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"tokens":{},"layouts":[{"name":"Base","placeholders":[{"id":"title","type":"text","geometry":{"x":0.1,"y":0.1,"w":0.8,"h":0.2}},{"id":"body","type":"text","geometry":{"x":0.1,"y":0.4,"w":0.8,"h":0.4}}]}]}`)})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-slide", OrgID: "org-1", Name: "Slides", Status: store.TemplateDraft, LatestVersionNo: 1})
+	require.NoError(t, err)
+	h := s.Handler()
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/v1/decks", `{"name":"Launch","sourceTemplateVersionId":"tv-slide","content":"Launch plan for Q3","outline":{"slides":[{"slideNumber":1,"title":"Intro","content":["a"]},{"slideNumber":2,"title":"Pricing","content":["b"]}]}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var created struct {
+		Version store.DeckVersion `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	path := "/v1/deck-versions/" + created.Version.ID + "/slides/"
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, path+"2/regenerate", `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, path+"x/regenerate", `{}`).Code)
+
+	w = do(http.MethodPost, path+"1/regenerate", `{"guidance":"compare plans"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var res struct {
+		Deck    store.Deck        `json:"deck"`
+		Version store.DeckVersion `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, 2, res.Version.VersionNo)
+	assert.Equal(t, res.Version.ID, *res.Deck.CurrentVersion)
+
+	layouts := func(v store.DeckVersion) []json.RawMessage {
+		var doc struct {
+			Layouts []json.RawMessage `json:"layouts"`
+		}
+		require.NoError(t, json.Unmarshal(v.SpecJSON, &doc))
+		return doc.Layouts
+	}
+	before, after := layouts(created.Version), layouts(res.Version)
+	require.Len(t, after, 2)
+	assert.JSONEq(t, string(before[0]), string(after[0]), "other slides are untouched")
+	assert.Contains(t, string(after[1]), "Revised: compare plans")
+}
+
+func TestTransformDeck(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-tr", OrgID: "org-1", OwnerUserID: "user-1", Name: "Results", LatestVersionNo: 1})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-tr", Deck: "deck-tr", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"tokens":{"colors":{"primary":"#123456"}},"layouts":[{"name":"Intro","notes":"keep me","placeholders":[
+		{"id":"title","type":"text","content":"Quarterly results","geometry":{"x":0.1,"y":0.1,"w":0.8,"h":0.2}},
+		{"id":"logo","type":"image","content":"logo.png","geometry":{"x":0.8,"y":0.8,"w":0.1,"h":0.1}},
+		{"id":"body","type":"text","content":"","geometry":{"x":0.1,"y":0.4,"w":0.8,"h":0.4}}]}]}`)})
+	require.NoError(t, err)
+	h := s.Handler()
+
+	transform := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/deck-versions/dv-tr/transform", strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, transform(`{"operation":"shout"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, transform(`{"operation":"translate"}`).Code, "translate needs a language")
+
+	w := transform(`{"operation":"translate","language":"ar"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var res struct {
+		Deck    store.Deck        `json:"deck"`
+		Version store.DeckVersion `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, 2, res.Version.VersionNo)
+	assert.Equal(t, "ar", res.Deck.Language)
+	assert.JSONEq(t, `{"specVersion":2,"tokens":{"colors":{"primary":"#123456"}},"layouts":[{"name":"Intro","notes":"keep me","placeholders":[
+		{"id":"title","type":"text","content":"[translated] Quarterly results","geometry":{"x":0.1,"y":0.1,"w":0.8,"h":0.2}},
+		{"id":"logo","type":"image","content":"logo.png","geometry":{"x":0.8,"y":0.8,"w":0.1,"h":0.1}},
+		{"id":"body","type":"text","content":"","geometry":{"x":0.1,"y":0.4,"w":0.8,"h":0.4}}]}]}`, string(res.Version.SpecJSON))
+
+	n, err := s.Store.Metering().SumByType(ctx, "org-1", store.MeterTransformPrefix+"translate")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestTranslateDeck_CreatesLinkedDeckThenAddsVersions(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	current := "dv-en"
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-en", OrgID: "org-1", OwnerUserID: "user-1", Name: "Results", CurrentVersion: &current, LatestVersionNo: 1, Listing: store.Listing{Language: "en"}})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-en", Deck: "deck-en", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"tokens":{},"layouts":[{"name":"Intro","placeholders":[
+		{"id":"title","type":"text","content":"Quarterly results","align":"left","geometry":{"x":0.1,"y":0.1,"w":0.5,"h":0.2}},
+		{"id":"logo","type":"image","content":"logo.png","geometry":{"x":0.8,"y":0.8,"w":0.1,"h":0.1}}]}]}`)})
+	require.NoError(t, err)
+	h := s.Handler()
+
+	translate := func(lang string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/decks/deck-en/translate?lang="+lang, nil)
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, translate("").Code)
+	assert.Equal(t, http.StatusBadRequest, translate("en").Code, "already in English")
+
+	w := translate("ar")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var res struct {
+		Deck    store.Deck        `json:"deck"`
+		Version store.DeckVersion `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.NotEqual(t, "deck-en", res.Deck.ID)
+	assert.Equal(t, "Results (ar)", res.Deck.Name)
+	assert.Equal(t, "ar", res.Deck.Language)
+	require.NotNil(t, res.Deck.TranslatedFrom)
+	assert.Equal(t, "deck-en", *res.Deck.TranslatedFrom)
+	assert.JSONEq(t, `{"specVersion":2,"tokens":{"direction":"rtl"},"layouts":[{"name":"Intro","placeholders":[
+		{"id":"title","type":"text","content":"[translated] Quarterly results","align":"right","geometry":{"x":0.4,"y":0.1,"w":0.5,"h":0.2}},
+		{"id":"logo","type":"image","content":"logo.png","geometry":{"x":0.1,"y":0.8,"w":0.1,"h":0.1}}]}]}`, string(res.Version.SpecJSON))
+
+	src, _, err := s.Store.Decks().GetDeck(ctx, "org-1", "deck-en")
+	require.NoError(t, err)
+	assert.Equal(t, 1, src.LatestVersionNo, "the source deck is untouched")
+
+	w = translate("ar")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var again struct {
+		Deck    store.Deck        `json:"deck"`
+		Version store.DeckVersion `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &again))
+	assert.Equal(t, res.Deck.ID, again.Deck.ID)
+	assert.Equal(t, 2, again.Version.VersionNo)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/decks/deck-en/translations", nil)
+	addTestAuth(req, "user-1", "org-1", auth.RoleViewer)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list struct {
+		Decks []store.Deck `json:"decks"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Decks, 1)
+	assert.Equal(t, res.Deck.ID, list.Decks[0].ID)
+}
+
+func TestGetDeckVersionReview(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-rev", OrgID: "org-1", OwnerUserID: "user-1", Name: "Results", LatestVersionNo: 2})
+	require.NoError(t, err)
+	flag := store.ReviewFlag{Slide: 1, PlaceholderID: "body", Figure: "37%", Excerpt: "growth of 37% year over year"}
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-rev-1", Deck: "deck-rev", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"layouts":[]}`), ReviewRequired: []store.ReviewFlag{flag}})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-rev-2", Deck: "deck-rev", OrgID: "org-1", VersionNo: 2, SpecJSON: []byte(`{"layouts":[]}`)})
+	require.NoError(t, err)
+	h := s.Handler()
+
+	get := func(versionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/deck-versions/"+versionID+"/review", nil)
+		addTestAuth(req, "user-1", "org-1", auth.RoleViewer)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("dv-rev-1")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"versionId":"dv-rev-1","reviewRequired":[{"slide":1,"placeholderId":"body","figure":"37%","excerpt":"growth of 37% year over year"}]}`, w.Body.String())
+
+	w = get("dv-rev-2")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"versionId":"dv-rev-2","reviewRequired":[]}`, w.Body.String())
+
+	assert.Equal(t, http.StatusNotFound, get("dv-missing").Code)
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/service"
+)
+
+// handleUpgradeDeckTemplate handles POST /v1/decks/{id}/upgrade-template.
+// The body is optional; without one the deck moves to its template's
+// current version. A dry run answers with the report alone.
+func (s *Server) handleUpgradeDeckTemplate(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+
+	var req UpgradeDeckTemplateRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeInvalidJSON(w, r)
+		return
+	}
+
+	res, err := s.deckService().UpgradeTemplate(r.Context(), id, service.UpgradeTemplateInput{
+		DeckID:            r.PathValue("id"),
+		TemplateVersionID: req.TemplateVersionID,
+		DryRun:            req.DryRun,
+	})
+	if err != nil {
+		s.writeServiceError(w, r, "upgrade_deck_template", "failed to upgrade deck template", err)
+		return
+	}
+	if res.Version == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"deck": res.Deck, "report": res.Report})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deck": res.Deck, "version": res.Version, "report": res.Report})
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/service"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestUpgradeDeckTemplate(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	v2 := "tv-2"
+	_, err := s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-1", OrgID: "org-1", Name: "Brand", Status: store.TemplateDraft, LatestVersionNo: 2, CurrentVersion: &v2})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-1", Template: "tpl-1", OrgID: "org-1", VersionNo: 1,
+		SpecJSON: []byte(`{"tokens":{},"layouts":[{"name":"Base","placeholders":[{"id":"title","type":"text","geometry":{"x":0.1,"y":0.1,"w":0.8,"h":0.1}},{"id":"body","type":"text","geometry":{"x":0.1,"y":0.3,"w":0.8,"h":0.5}}]}]}`)})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-2", Template: "tpl-1", OrgID: "org-1", VersionNo: 2,
+		SpecJSON: []byte(`{"tokens":{"font":"Inter"},"layouts":[{"name":"Base","placeholders":[{"id":"title","type":"text","geometry":{"x":0.05,"y":0.05,"w":0.9,"h":0.12}}]}]}`)})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-other", OrgID: "org-1", Name: "Other", Status: store.TemplateDraft, LatestVersionNo: 1})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-other", Template: "tpl-other", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"tokens":{},"layouts":[{"name":"Base"}]}`)})
+	require.NoError(t, err)
+	h := s.Handler()
+
+	do := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("/v1/decks", `{"name":"Deck","sourceTemplateVersionId":"tv-1","content":"Quarterly results","outline":{"slides":[{"slideNumber":1,"title":"Q3","content":["Revenue grew"]}]}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var created struct{ Deck store.Deck }
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	path := "/v1/decks/" + created.Deck.ID + "/upgrade-template"
+
+	t.Run("other templates are refused", func(t *testing.T) {
+		w := do(path, `{"templateVersionId":"tv-other"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+
+	t.Run("a dry run only reports", func(t *testing.T) {
+		w := do(path, `{"dryRun":true}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Deck    store.Deck
+			Version *store.DeckVersion
+			Report  service.TemplateUpgradeReport
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Nil(t, resp.Version)
+		assert.Equal(t, "tv-1", resp.Deck.SourceTemplateVersion)
+		assert.Equal(t, "tv-2", resp.Report.ToVersionID)
+		assert.False(t, resp.Report.Compatible)
+		require.Len(t, resp.Report.Incompatibilities, 1)
+		assert.Equal(t, service.TemplateIncompatibility{Kind: service.IncompatiblePlaceholder, Slide: 1, PlaceholderID: "body", Message: `slide 1: nowhere to put "body"; its text is dropped`}, resp.Report.Incompatibilities[0])
+	})
+
+	t.Run("the upgrade saves a version on the new template", func(t *testing.T) {
+		w := do(path, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Deck    store.Deck
+			Version store.DeckVersion
+			Report  service.TemplateUpgradeReport
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "tv-2", resp.Deck.SourceTemplateVersion)
+		assert.Equal(t, 2, resp.Version.VersionNo)
+		assert.Equal(t, map[string]string{"title": "title"}, resp.Report.Slides[0].Placeholders)
+		var deck spec.TemplateSpec
+		require.NoError(t, json.Unmarshal(resp.Version.SpecJSON, &deck))
+		assert.Equal(t, "Inter", deck.Tokens["font"])
+		require.Len(t, deck.Layouts, 1)
+		require.Len(t, deck.Layouts[0].Placeholders, 1)
+		assert.Equal(t, "Q3", deck.Layouts[0].Placeholders[0].Content)
+		assert.Equal(t, 0.9, deck.Layouts[0].Placeholders[0].Geometry.W)
+
+		w = do(path, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "the deck is already on the current version")
+	})
+}
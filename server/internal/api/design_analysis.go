@@ -39,19 +39,19 @@ type EnhancedDesignAnalysisResponse struct {
 
 func (s *Server) AnalyzeDesign(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
 	var req DesignAnalysisRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		writeInvalidJSON(w, r)
 		return
 	}
 
 	// Validate request
 	if req.Content == "" {
-		http.Error(w, "Content is required", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, "content is required")
 		return
 	}
 
@@ -85,7 +85,7 @@ func (s *Server) AnalyzeDesign(w http.ResponseWriter, r *http.Request) {
 	// Perform AI design analysis
 	designIdentity, err := aiAnalyzer.AnalyzeContentForDesign(jsonData, companyInfo)
 	if err != nil {
-		http.Error(w, "Design analysis failed", http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, "design analysis failed")
 		return
 	}
 
@@ -130,7 +130,7 @@ func (s *Server) AnalyzeDesign(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, "failed to encode response")
 		return
 	}
 }
@@ -21,7 +21,7 @@ func (s *Server) handleDatabaseDiagnostics(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Access the underlying database connection
-	db, err := pgStore.DB()
+	db, err := pgStore.ReadDB()
 
 	if err != nil || db == nil {
 		writeError(w, r, http.StatusInternalServerError, "database connection not available")
@@ -68,10 +68,36 @@ func (s *Server) handleDatabaseDiagnostics(w http.ResponseWriter, r *http.Reques
 		"organization_stats":    orgStats,
 		"problematic_templates": problematic,
 	}
+	if pool, stats, err := pgStore.PoolStats(); err == nil {
+		response["pool"] = poolDiagnostics(pool, stats)
+	}
+	response["read_replica"] = pgStore.HasReplica()
+	response["cache"] = pgStore.CacheStats()
 
 	writeJSON(w, http.StatusOK, response)
 }
 
+// poolDiagnostics shows how close the connection pool is to its limits;
+// rising wait counts mean requests are queueing for a connection.
+func poolDiagnostics(pool postgres.PoolConfig, stats sql.DBStats) map[string]interface{} {
+	return map[string]interface{}{
+		"max_open_conns":       pool.MaxOpenConns,
+		"max_idle_conns":       pool.MaxIdleConns,
+		"conn_max_lifetime":    pool.ConnMaxLifetime.String(),
+		"conn_max_idle_time":   pool.ConnMaxIdleTime.String(),
+		"statement_timeout":    pool.StatementTimeout.String(),
+		"query_timeout":        pool.QueryTimeout.String(),
+		"open_connections":     stats.OpenConnections,
+		"in_use":               stats.InUse,
+		"idle":                 stats.Idle,
+		"wait_count":           stats.WaitCount,
+		"wait_duration":        stats.WaitDuration.String(),
+		"max_idle_closed":      stats.MaxIdleClosed,
+		"max_idle_time_closed": stats.MaxIdleTimeClosed,
+		"max_lifetime_closed":  stats.MaxLifetimeClosed,
+	}
+}
+
 func (s *Server) handleDatabaseQuery(w http.ResponseWriter, r *http.Request) {
 	// Security check - only allow specific safe queries
 	query := r.URL.Query().Get("q")
@@ -87,7 +113,7 @@ func (s *Server) handleDatabaseQuery(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := pgStore.DB()
+	db, err := pgStore.ReadDB()
 	if err != nil || db == nil {
 		writeError(w, r, http.StatusInternalServerError, "database connection not available")
 		return
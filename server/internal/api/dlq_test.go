@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -177,3 +178,74 @@ func TestServer_RetryDeadLetterJob(t *testing.T) {
 		})
 	}
 }
+
+// addPlatformAdmin creates userID as a platform admin who is a viewer of
+// orgID. The flag is read from the user's account, so any token for the user
+// in orgID reaches the /v1/admin/platform endpoints.
+func addPlatformAdmin(t *testing.T, s *Server, userID, orgID string) {
+	t.Helper()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: userID, Email: userID + "@ops.example.com", PlatformAdmin: true}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: userID, OrgID: orgID, Role: auth.RoleViewer}))
+}
+
+func TestPlatformJobs_ListRequeueAndPurgeAcrossOrgs(t *testing.T) {
+	server := NewServer()
+	h := server.Handler()
+	ctx := context.Background()
+	addPlatformAdmin(t, server, "ops-1", "org-ops")
+	for _, j := range []store.Job{
+		{ID: "job-a", OrgID: "org-1", Type: store.JobRender, Status: store.JobDeadLetter},
+		{ID: "job-b", OrgID: "org-2", Type: store.JobExport, Status: store.JobDeadLetter},
+		{ID: "job-c", OrgID: "org-2", Type: store.JobExport, Status: store.JobDone},
+		{ID: "job-d", OrgID: "org-3", Type: store.JobExport, Status: store.JobDeadLetter},
+	} {
+		_, err := server.Store.Jobs().Enqueue(ctx, j)
+		require.NoError(t, err)
+	}
+
+	do := func(method, path, body, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		addTestAuth(req, userID, "org-ops", auth.RoleOwner)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, do("GET", "/v1/admin/platform/jobs", "", "owner-1").Code, "org owners aren't platform admins")
+	req := httptest.NewRequest("GET", "/v1/admin/platform/jobs", nil)
+	addTestAuth(req, "owner-1", "org-ops", auth.Role("PlatformAdmin"))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "no role, whatever its name, makes a platform admin")
+
+	w := do("GET", "/v1/admin/platform/jobs?status=DeadLetter&type=export", "", "ops-1")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list struct {
+		Jobs []store.Job `json:"jobs"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Jobs, 2)
+
+	w = do("POST", "/v1/admin/platform/jobs/requeue", `{"jobIds":["job-a","job-c","job-x"]}`, "ops-1")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var requeue struct {
+		Requeued []string          `json:"requeued"`
+		Skipped  []platformJobSkip `json:"skipped"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &requeue))
+	assert.Equal(t, []string{"job-a"}, requeue.Requeued)
+	assert.Len(t, requeue.Skipped, 2)
+	job, _, _ := server.Store.Jobs().Get(ctx, "org-1", "job-a")
+	assert.Equal(t, store.JobQueued, job.Status)
+
+	w = do("POST", "/v1/admin/platform/jobs/purge", `{"orgId":"org-2"}`, "ops-1")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"purged":1}`, w.Body.String())
+	_, ok, _ := server.Store.Jobs().Get(ctx, "org-2", "job-b")
+	assert.False(t, ok)
+	_, ok, _ = server.Store.Jobs().Get(ctx, "org-2", "job-c")
+	assert.True(t, ok)
+	_, ok, _ = server.Store.Jobs().Get(ctx, "org-3", "job-d")
+	assert.True(t, ok)
+}
@@ -0,0 +1,352 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/middleware"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// embedPathPrefix is served outside the JWT chain: the token in the path is
+// the viewer's only credential.
+const embedPathPrefix = "/v1/embed/"
+
+func embedURL(token string) string { return embedPathPrefix + "decks/" + token }
+
+func hashEmbedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeEmbedDomain lowercases d and checks it is a bare host, optionally
+// with a leading "*." wildcard. Schemes, ports and paths are rejected because
+// they cannot be matched against a Referer host.
+func (s *Server) normalizeEmbedDomain(d string) (string, bool) {
+	d = strings.ToLower(strings.TrimSpace(d))
+	host := strings.TrimPrefix(d, "*.")
+	if host == "" || strings.Contains(host, "*") {
+		return "", false
+	}
+	if err := s.validate.Var(host, "hostname_rfc1123"); err != nil {
+		return "", false
+	}
+	return d, true
+}
+
+// embedDomainAllowed matches host against the allowlist. A wildcard covers
+// subdomains only, as it does in a CSP frame-ancestors source.
+func embedDomainAllowed(allowed []string, host string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, d := range allowed {
+		if base, ok := strings.CutPrefix(d, "*."); ok {
+			if strings.HasSuffix(host, "."+base) {
+				return true
+			}
+		} else if host == d {
+			return true
+		}
+	}
+	return false
+}
+
+// handleCreateDeckEmbed handles POST /v1/decks/{id}/embeds. Embeds publish
+// the deck outside the org, so they need manage permission, like sharing.
+func (s *Server) handleCreateDeckEmbed(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	d, ok := s.authorizeDeck(w, r, id, r.PathValue("id"), store.PermissionManage)
+	if !ok {
+		return
+	}
+	var req CreateDeckEmbedRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	domains := make([]string, 0, len(req.AllowedDomains))
+	for _, raw := range req.AllowedDomains {
+		domain, ok := s.normalizeEmbedDomain(raw)
+		if !ok {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid domain %q: use a host such as wiki.example.com or *.example.com", raw))
+			return
+		}
+		domains = append(domains, domain)
+	}
+
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		logger.LogError(r.Context(), "api", "create_deck_embed", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to generate token")
+		return
+	}
+	token := "emb_" + hex.EncodeToString(b[:])
+	embed, err := s.Store.Embeds().Create(r.Context(), store.DeckEmbed{
+		ID:             newID("emb"),
+		OrgID:          id.OrgID,
+		DeckID:         d.ID,
+		TokenHash:      hashEmbedToken(token),
+		AllowedDomains: domains,
+		CreatedBy:      id.UserID,
+	})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "create_deck_embed", err, "deck_id", d.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to create embed")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.embed.create", TargetRef: d.ID, Metadata: map[string]any{"embedId": embed.ID, "allowedDomains": domains}})
+	writeJSON(w, http.StatusCreated, map[string]any{"embed": embed, "token": token, "embedUrl": embedURL(token)})
+}
+
+func (s *Server) handleListDeckEmbeds(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	d, ok := s.authorizeDeck(w, r, id, r.PathValue("id"), store.PermissionManage)
+	if !ok {
+		return
+	}
+	embeds, err := s.Store.Embeds().List(r.Context(), id.OrgID, d.ID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_deck_embeds", err, "deck_id", d.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to list embeds")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"embeds": embeds})
+}
+
+func (s *Server) handleDeleteDeckEmbed(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	d, ok := s.authorizeDeck(w, r, id, r.PathValue("id"), store.PermissionManage)
+	if !ok {
+		return
+	}
+	embedID := r.PathValue("embedId")
+	embeds, err := s.Store.Embeds().List(r.Context(), id.OrgID, d.ID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "delete_deck_embed", err, "deck_id", d.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete embed")
+		return
+	}
+	if !slices.ContainsFunc(embeds, func(e store.DeckEmbed) bool { return e.ID == embedID }) {
+		writeError(w, r, http.StatusNotFound, "embed not found")
+		return
+	}
+	if _, err := s.Store.Embeds().Delete(r.Context(), id.OrgID, embedID); err != nil {
+		logger.LogError(r.Context(), "api", "delete_deck_embed", err, "deck_id", d.ID, "embed_id", embedID)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete embed")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.embed.delete", TargetRef: d.ID, Metadata: map[string]any{"embedId": embedID}})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) embedRoutes() *routeMux {
+	mux := newRouteMux()
+	mux.HandleFunc("GET /v1/embed/decks/{token}", s.handleEmbedViewer)
+	mux.HandleFunc("GET /v1/embed/decks/{token}/slides/{index}/thumbnail", s.handleEmbedSlideThumbnail)
+	mux.HandleFunc(embedPathPrefix, func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, http.StatusNotFound, "not found")
+	})
+	return mux
+}
+
+// embedHandler serves the public viewer. It skips auth and the JSON-only
+// middleware, and relaxes X-Frame-Options per embed.
+func (s *Server) embedHandler(mux *routeMux) http.Handler {
+	h := http.Handler(mux)
+	h = withRequestID(h)
+	h = middleware.RecoveryMiddleware(h)
+	h = middleware.LoggingMiddleware(h)
+	return h
+}
+
+// loadEmbed resolves the token in the path to its embed and deck. It writes
+// the error response and returns false on failure.
+func (s *Server) loadEmbed(w http.ResponseWriter, r *http.Request) (store.DeckEmbed, store.Deck, bool) {
+	embed, ok, err := s.Store.Embeds().GetByTokenHash(r.Context(), hashEmbedToken(r.PathValue("token")))
+	if err != nil {
+		logger.LogError(r.Context(), "api", "load_embed", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load embed")
+		return store.DeckEmbed{}, store.Deck{}, false
+	}
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "embed not found")
+		return store.DeckEmbed{}, store.Deck{}, false
+	}
+	d, ok, err := s.Store.Decks().GetDeck(r.Context(), embed.OrgID, embed.DeckID)
+	if err != nil || !ok || d.DeletedAt != nil {
+		writeError(w, r, http.StatusNotFound, "embed not found")
+		return store.DeckEmbed{}, store.Deck{}, false
+	}
+	return embed, d, true
+}
+
+// embedFrameAncestors is the CSP frame-ancestors value for an allowlist.
+// Listed hosts must frame the viewer over HTTPS.
+func embedFrameAncestors(allowed []string) string {
+	if len(allowed) == 0 {
+		return "*"
+	}
+	sources := make([]string, len(allowed))
+	for i, d := range allowed {
+		sources[i] = "https://" + d
+	}
+	return strings.Join(sources, " ")
+}
+
+type embedViewerSlide struct {
+	Name string
+	URL  string
+}
+
+var embedViewerTemplate = template.Must(template.New("embed").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Title}}</title>
+<style nonce="{{.Nonce}}">
+html,body{margin:0;height:100%;font-family:system-ui,sans-serif;background:#111827;color:#f9fafb}
+.viewer{display:flex;flex-direction:column;height:100%}
+.stage{flex:1;display:flex;align-items:center;justify-content:center;min-height:0;padding:8px}
+.stage img{max-width:100%;max-height:100%;box-shadow:0 2px 12px rgba(0,0,0,.5);background:#fff}
+.bar{display:flex;align-items:center;gap:8px;padding:6px 8px;background:#1f2937}
+.bar button{background:#374151;color:inherit;border:0;border-radius:4px;padding:4px 10px;cursor:pointer}
+.bar button:disabled{opacity:.4;cursor:default}
+.bar .title{flex:1;overflow:hidden;white-space:nowrap;text-overflow:ellipsis;font-size:14px}
+.strip{display:flex;gap:6px;overflow-x:auto;padding:6px 8px;background:#1f2937}
+.strip img{height:48px;cursor:pointer;opacity:.6;border:2px solid transparent;background:#fff}
+.strip img.active{opacity:1;border-color:#60a5fa}
+.empty{margin:auto}
+</style>
+</head>
+<body>
+{{if .Slides}}<div class="viewer">
+<div class="stage"><img id="current" src="{{(index .Slides 0).URL}}" alt="{{(index .Slides 0).Name}}"></div>
+<div class="bar">
+<button id="prev" type="button" aria-label="Previous slide">&larr;</button>
+<span id="counter">1 / {{len .Slides}}</span>
+<button id="next" type="button" aria-label="Next slide">&rarr;</button>
+<span class="title">{{.Title}}</span>
+</div>
+<div class="strip">{{range $i, $s := .Slides}}<img src="{{$s.URL}}" alt="{{$s.Name}}" data-index="{{$i}}" loading="lazy">{{end}}</div>
+</div>
+<script nonce="{{.Nonce}}">
+(function(){
+var thumbs=document.querySelectorAll(".strip img"),cur=document.getElementById("current"),
+counter=document.getElementById("counter"),prev=document.getElementById("prev"),next=document.getElementById("next"),i=0;
+function show(n){
+if(n<0||n>=thumbs.length)return;
+thumbs[i].classList.remove("active");i=n;thumbs[i].classList.add("active");
+cur.src=thumbs[i].src;cur.alt=thumbs[i].alt;counter.textContent=(i+1)+" / "+thumbs.length;
+prev.disabled=i===0;next.disabled=i===thumbs.length-1;
+thumbs[i].scrollIntoView({block:"nearest",inline:"nearest"});
+}
+thumbs.forEach(function(t){t.addEventListener("click",function(){show(+t.dataset.index)})});
+prev.addEventListener("click",function(){show(i-1)});
+next.addEventListener("click",function(){show(i+1)});
+document.addEventListener("keydown",function(e){
+if(e.key==="ArrowLeft")show(i-1);else if(e.key==="ArrowRight"||e.key===" ")show(i+1);
+});
+show(0);
+})();
+</script>
+{{else}}<div class="viewer"><p class="empty">This deck has no slides.</p></div>
+{{end}}</body>
+</html>
+`))
+
+// handleEmbedViewer handles GET /v1/embed/decks/{token}, serving a
+// self-contained HTML viewer for iframes. Each view is counted on the embed
+// and metered against the org as "embed_view".
+func (s *Server) handleEmbedViewer(w http.ResponseWriter, r *http.Request) {
+	embed, d, ok := s.loadEmbed(w, r)
+	if !ok {
+		return
+	}
+	// Browsers enforce frame-ancestors; the Referer check also turns away
+	// direct links from pages outside the allowlist.
+	if ref, err := url.Parse(r.Referer()); err == nil && ref.Host != "" && !embedDomainAllowed(embed.AllowedDomains, ref.Hostname()) {
+		writeError(w, r, http.StatusForbidden, "this deck may not be embedded on "+ref.Hostname())
+		return
+	}
+	dv, ts, ok := s.presentationVersion(w, r, d)
+	if !ok {
+		return
+	}
+
+	var nb [16]byte
+	if _, err := rand.Read(nb[:]); err != nil {
+		logger.LogError(r.Context(), "api", "embed_viewer", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to render viewer")
+		return
+	}
+	nonce := base64.StdEncoding.EncodeToString(nb[:])
+	data := struct {
+		Title  string
+		Nonce  string
+		Slides []embedViewerSlide
+	}{Title: d.Name, Nonce: nonce}
+	token := r.PathValue("token")
+	for i, layout := range ts.Layouts {
+		name := layout.Name
+		if name == "" {
+			name = fmt.Sprintf("Slide %d", i+1)
+		}
+		// The version pins the URL so caches never serve a stale image.
+		data.Slides = append(data.Slides, embedViewerSlide{Name: name, URL: fmt.Sprintf("%s/slides/%d/thumbnail?v=%s", embedURL(token), i, dv.ID)})
+	}
+
+	if err := s.Store.Embeds().RecordView(r.Context(), embed.ID, time.Now().UTC()); err != nil {
+		logger.LogError(r.Context(), "api", "embed_viewer", err, "embed_id", embed.ID)
+	}
+	_, _ = s.Store.Metering().Record(r.Context(), store.MeteringEvent{ID: newID("met"), OrgID: embed.OrgID, UserID: embed.CreatedBy, Type: "embed_view", Quantity: 1})
+
+	h := w.Header()
+	h.Del("X-Frame-Options")
+	h.Set("Content-Security-Policy", fmt.Sprintf("default-src 'none'; img-src 'self'; style-src 'nonce-%s'; script-src 'nonce-%s'; base-uri 'none'; frame-ancestors %s", nonce, nonce, embedFrameAncestors(embed.AllowedDomains)))
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	h.Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := embedViewerTemplate.Execute(w, data); err != nil {
+		logger.LogError(r.Context(), "api", "embed_viewer", err, "embed_id", embed.ID)
+	}
+}
+
+func (s *Server) handleEmbedSlideThumbnail(w http.ResponseWriter, r *http.Request) {
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil || index < 0 {
+		writeError(w, r, http.StatusBadRequest, "invalid slide index")
+		return
+	}
+	_, d, ok := s.loadEmbed(w, r)
+	if !ok {
+		return
+	}
+	_, ts, ok := s.presentationVersion(w, r, d)
+	if !ok {
+		return
+	}
+	s.writeSlideThumbnail(w, r, d, ts, index)
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestDeckEmbed_ViewerServesAllowedDomainsAndCountsViews(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+
+	specJSON := `{"layouts":[{"name":"Title","placeholders":[]},{"name":"Body","placeholders":[]}]}`
+	versionID := "dv-embed"
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-embed", OrgID: "org-1", Name: "Roadmap <2027>", CurrentVersion: &versionID})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: versionID, Deck: "deck-embed", OrgID: "org-1", VersionNo: 1, SpecJSON: json.RawMessage(specJSON)})
+	require.NoError(t, err)
+
+	do := func(method, path string, role auth.Role, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	view := func(path, referer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if referer != "" {
+			req.Header.Set("Referer", referer)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/v1/decks/deck-embed/embeds", auth.RoleViewer, `{}`).Code)
+	w := do(http.MethodPost, "/v1/decks/deck-embed/embeds", auth.RoleAdmin, `{"allowedDomains":["https://wiki.example.com"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "schemes are rejected")
+
+	w = do(http.MethodPost, "/v1/decks/deck-embed/embeds", auth.RoleAdmin, `{"allowedDomains":["Wiki.Example.com","*.intranet.example"]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Embed    store.DeckEmbed `json:"embed"`
+		Token    string          `json:"token"`
+		EmbedURL string          `json:"embedUrl"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, []string{"wiki.example.com", "*.intranet.example"}, created.Embed.AllowedDomains)
+	assert.Equal(t, "/v1/embed/decks/"+created.Token, created.EmbedURL)
+
+	// The viewer needs no auth and may only be framed by the allowlist.
+	w = view(created.EmbedURL, "https://wiki.example.com/pages/roadmap")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("X-Frame-Options"))
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), "frame-ancestors https://wiki.example.com https://*.intranet.example")
+	assert.Contains(t, w.Body.String(), "Roadmap &lt;2027&gt;")
+	assert.Contains(t, w.Body.String(), created.EmbedURL+"/slides/1/thumbnail?v="+versionID)
+
+	assert.Equal(t, http.StatusOK, view(created.EmbedURL, "https://docs.intranet.example/").Code)
+	assert.Equal(t, http.StatusForbidden, view(created.EmbedURL, "https://evil.example/").Code)
+	assert.Equal(t, http.StatusForbidden, view(created.EmbedURL, "https://intranet.example/").Code, "wildcards cover subdomains only")
+	assert.Equal(t, http.StatusNotFound, view("/v1/embed/decks/emb_unknown", "").Code)
+
+	w = view(created.EmbedURL+"/slides/1/thumbnail?v="+versionID, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+
+	w = do(http.MethodGet, "/v1/decks/deck-embed/embeds", auth.RoleAdmin, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Embeds []store.DeckEmbed `json:"embeds"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Embeds, 1)
+	assert.EqualValues(t, 2, list.Embeds[0].ViewCount, "only successful page views count")
+	assert.NotContains(t, w.Body.String(), created.Token)
+
+	views, err := s.Store.Metering().SumByType(ctx, "org-1", "embed_view")
+	require.NoError(t, err)
+	assert.Equal(t, 2, views)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/v1/decks/deck-embed/embeds/"+created.Embed.ID, auth.RoleAdmin, "").Code)
+	assert.Equal(t, http.StatusNotFound, view(created.EmbedURL, "").Code, "revoked tokens stop working")
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	lib_validator "github.com/go-playground/validator/v10"
+)
+
+// Error codes returned in ErrorResponse.Code. They are part of the API: add
+// new ones freely, but never change or reuse an existing one.
+const (
+	ErrCodeBadRequest       = "ERR_BAD_REQUEST"
+	ErrCodeInvalidJSON      = "ERR_INVALID_JSON"
+	ErrCodeValidation       = "ERR_VALIDATION"
+	ErrCodeSpecInvalid      = "ERR_SPEC_INVALID"
+	ErrCodeUnauthenticated  = "ERR_UNAUTHENTICATED"
+	ErrCodeForbidden        = "ERR_FORBIDDEN"
+	ErrCodeNotFound         = "ERR_NOT_FOUND"
+	ErrCodeMethodNotAllowed = "ERR_METHOD_NOT_ALLOWED"
+	ErrCodeConflict         = "ERR_CONFLICT"
+	ErrCodePrecondition     = "ERR_PRECONDITION_FAILED"
+	ErrCodeTooLarge         = "ERR_PAYLOAD_TOO_LARGE"
+	ErrCodeUnsupportedMedia = "ERR_UNSUPPORTED_MEDIA_TYPE"
+	ErrCodeUnprocessable    = "ERR_UNPROCESSABLE"
+	ErrCodeQuotaExceeded    = "ERR_QUOTA_EXCEEDED"
+	ErrCodePolicyViolation  = "ERR_POLICY_VIOLATION"
+	ErrCodeRateLimited      = "ERR_RATE_LIMITED"
+	ErrCodeAIFailed         = "ERR_AI_FAILED"
+	ErrCodeAITimeout        = "ERR_AI_TIMEOUT"
+	ErrCodeAIUnavailable    = "ERR_AI_UNAVAILABLE"
+	ErrCodeUpstream         = "ERR_UPSTREAM"
+	ErrCodeUnavailable      = "ERR_UNAVAILABLE"
+	ErrCodeInternal         = "ERR_INTERNAL"
+)
+
+// codeForStatus is the code for errors that have nothing more specific to
+// say than their status.
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrCodeBadRequest
+	case http.StatusUnauthorized:
+		return ErrCodeUnauthenticated
+	case http.StatusPaymentRequired:
+		return ErrCodeQuotaExceeded
+	case http.StatusForbidden:
+		return ErrCodeForbidden
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusMethodNotAllowed:
+		return ErrCodeMethodNotAllowed
+	case http.StatusConflict:
+		return ErrCodeConflict
+	case http.StatusPreconditionFailed:
+		return ErrCodePrecondition
+	case http.StatusRequestEntityTooLarge:
+		return ErrCodeTooLarge
+	case http.StatusUnsupportedMediaType:
+		return ErrCodeUnsupportedMedia
+	case http.StatusUnprocessableEntity:
+		return ErrCodeUnprocessable
+	case http.StatusTooManyRequests:
+		return ErrCodeRateLimited
+	case http.StatusBadGateway:
+		return ErrCodeUpstream
+	case http.StatusServiceUnavailable:
+		return ErrCodeUnavailable
+	case http.StatusGatewayTimeout:
+		return ErrCodeAITimeout
+	}
+	if status >= 500 {
+		return ErrCodeInternal
+	}
+	return ErrCodeBadRequest
+}
+
+// FieldError is one entry in the details of an ERR_VALIDATION response.
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+// QuotaDetails are the details of an ERR_QUOTA_EXCEEDED response. Used and
+// Limit are counts for the generate and export quotas, and bytes for storage.
+type QuotaDetails struct {
+	Quota string `json:"quota"`
+	Used  int64  `json:"used"`
+	Limit int64  `json:"limit"`
+}
+
+// newRequestValidator returns the validator for request bodies. It names
+// fields by their JSON keys, which is how clients know them.
+func newRequestValidator() *lib_validator.Validate {
+	v := lib_validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+	return v
+}
+
+func writeInvalidJSON(w http.ResponseWriter, r *http.Request) {
+	writeErrorCode(w, r, http.StatusBadRequest, ErrCodeInvalidJSON, "invalid JSON body", nil)
+}
+
+// writeValidationError reports a failed s.validate.Struct, listing each
+// failing field under details.
+func writeValidationError(w http.ResponseWriter, r *http.Request, err error) {
+	var details []FieldError
+	var verrs lib_validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		for _, fe := range verrs {
+			details = append(details, FieldError{Field: fe.Field(), Rule: fe.Tag(), Param: fe.Param()})
+		}
+	}
+	writeErrorCode(w, r, http.StatusBadRequest, ErrCodeValidation, fmt.Sprintf("validation failed: %v", err), details)
+}
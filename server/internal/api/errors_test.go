@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+)
+
+func TestErrorEnvelope_Codes(t *testing.T) {
+	h := NewServer().Handler()
+	do := func(method, path, body string) (int, ErrorResponse) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Request-Id", "req-env")
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		var e ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e), w.Body.String())
+		return w.Code, e
+	}
+
+	status, e := do(http.MethodPost, "/v1/templates/generate", "{")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, ErrCodeInvalidJSON, e.Code)
+	assert.NotEmpty(t, e.Message)
+	assert.Equal(t, e.Message, e.Error)
+	assert.Equal(t, "req-env", e.Request)
+
+	status, e = do(http.MethodPost, "/v1/templates/generate", `{"prompt":"short"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, ErrCodeValidation, e.Code)
+	assert.Equal(t, []any{map[string]any{"field": "prompt", "rule": "min", "param": "10"}}, e.Details)
+
+	status, e = do(http.MethodPost, "/v1/templates/validate", `{"layouts":[{"name":"A","placeholders":[{"id":"a","type":"text","geometry":{"x":0,"y":0,"w":0.5,"h":0.5}},{"id":"b","type":"text","geometry":{"x":0.2,"y":0.2,"w":0.5,"h":0.5}}]}]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, ErrCodeSpecInvalid, e.Code)
+	assert.NotEmpty(t, e.Details)
+
+	status, e = do(http.MethodGet, "/v1/templates/missing", "")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, ErrCodeNotFound, e.Code)
+}
+
+func TestCodeForStatus(t *testing.T) {
+	assert.Equal(t, ErrCodeUnauthenticated, codeForStatus(http.StatusUnauthorized))
+	assert.Equal(t, ErrCodeQuotaExceeded, codeForStatus(http.StatusPaymentRequired))
+	assert.Equal(t, ErrCodeConflict, codeForStatus(http.StatusConflict))
+	assert.Equal(t, ErrCodeInternal, codeForStatus(http.StatusInternalServerError))
+	assert.Equal(t, ErrCodeInternal, codeForStatus(http.StatusNotImplemented))
+	assert.Equal(t, ErrCodeBadRequest, codeForStatus(http.StatusTeapot))
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+)
+
+// resourceETag derives a strong ETag from the parts of a resource that
+// change whenever its representation does, such as its update time and
+// version, so handlers can tag a response without hashing the body.
+func resourceETag(parts ...any) string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, p := range parts {
+		_ = enc.Encode(p)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// writeJSONIfChanged writes v like writeJSON, tagged with etag, or answers
+// 304 Not Modified with no body when If-None-Match already has etag. Clients
+// that poll must still revalidate each time, hence no-cache.
+func writeJSONIfChanged(w http.ResponseWriter, r *http.Request, etag string, v any) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestGetEndpoints_AnswerConditionalRequests(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+
+	_, err := s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-1", OrgID: "org-1", OwnerUserID: "user-1", Name: "Brand", Status: store.TemplateDraft})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", OwnerUserID: "user-1", Name: "Pitch"})
+	require.NoError(t, err)
+	_, err = s.Store.Jobs().Enqueue(ctx, store.Job{ID: "job-1", OrgID: "org-1", Type: store.JobExport, Status: store.JobQueued, InputRef: "dv-1"})
+	require.NoError(t, err)
+
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	etags := map[string]string{}
+	for _, path := range []string{"/v1/templates/tpl-1", "/v1/templates/tpl-1/versions", "/v1/decks/deck-1", "/v1/decks/deck-1/versions", "/v1/jobs/job-1"} {
+		w := get(path, "")
+		require.Equal(t, http.StatusOK, w.Code, path)
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag, path)
+		assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"), path)
+
+		w = get(path, etag)
+		assert.Equal(t, http.StatusNotModified, w.Code, path)
+		assert.Empty(t, w.Body.String(), path)
+		assert.Equal(t, http.StatusNotModified, get(path, `"other", W/`+etag).Code, "weak and listed validators match: "+path)
+		etags[path] = etag
+	}
+
+	// A new version changes the deck and its version list.
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: "deck-1", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"layouts":[]}`)})
+	require.NoError(t, err)
+	d, _, _ := s.Store.Decks().GetDeck(ctx, "org-1", "deck-1")
+	current := "dv-1"
+	d.CurrentVersion = &current
+	d.LatestVersionNo = 1
+	_, err = s.Store.Decks().UpdateDeck(ctx, d)
+	require.NoError(t, err)
+	for _, path := range []string{"/v1/decks/deck-1", "/v1/decks/deck-1/versions"} {
+		w := get(path, etags[path])
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.NotEqual(t, etags[path], w.Header().Get("ETag"), path)
+	}
+
+	job, _, _ := s.Store.Jobs().Get(ctx, "org-1", "job-1")
+	job.Status = store.JobRunning
+	_, err = s.Store.Jobs().Update(ctx, job)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, get("/v1/jobs/job-1", etags["/v1/jobs/job-1"]).Code, "job progress changes the tag")
+	assert.Equal(t, http.StatusNotModified, get("/v1/templates/tpl-1", etags["/v1/templates/tpl-1"]).Code)
+
+	// A deck created from the template changes its usage, and so its tag.
+	_, err = s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-1", Template: "tpl-1", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"tokens":{},"layouts":[{"name":"Base"}]}`)})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/v1/decks", strings.NewReader(`{"name":"From template","sourceTemplateVersionId":"tv-1","content":"Some content here","outline":{"slides":[{"slideNumber":1,"title":"Hi","content":["a"]}]}}`))
+	req.Header.Set("Content-Type", "application/json")
+	addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = get("/v1/templates/tpl-1", etags["/v1/templates/tpl-1"])
+	require.Equal(t, http.StatusOK, w.Code, "a stale tag must not hide the new count")
+	var got struct {
+		Usage TemplateUsage `json:"usage"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, 1, got.Usage.Decks)
+}
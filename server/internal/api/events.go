@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/service"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/webhooks"
+)
+
+const (
+	EventJobCompleted      = webhooks.EventJobCompleted
+	EventQuotaExceeded     = webhooks.EventQuotaExceeded
+	EventWebhookTest       = webhooks.EventWebhookTest
+	EventDeckCreated       = webhooks.EventDeckCreated
+	EventTemplatePublished = webhooks.EventTemplatePublished
+	EventExportCompleted   = webhooks.EventExportCompleted
+	EventJobDeadLettered   = webhooks.EventJobDeadLettered
+)
+
+type Event = webhooks.Event
+
+// emitEvent records ev and queues it for the org's subscribed webhooks.
+func (s *Server) emitEvent(ctx context.Context, actorID string, ev Event) Event {
+	return webhooks.Emit(ctx, s.Store, actorID, ev)
+}
+
+func (s *Server) emitDeckCreated(r *http.Request, d store.Deck) {
+	id, _ := auth.GetIdentity(r.Context())
+	service.EmitDeckCreated(r.Context(), s.Store, id.UserID, d)
+}
+
+// syntheticEventData returns a realistic payload for eventType so consumers
+// can exercise their parsing without running real exports.
+func (s *Server) syntheticEventData(eventType string) map[string]any {
+	switch eventType {
+	case EventJobCompleted:
+		return map[string]any{
+			"jobId":     newID("job"),
+			"jobType":   string(store.JobExport),
+			"status":    string(store.JobDone),
+			"outputRef": newID("asset"),
+		}
+	case EventQuotaExceeded:
+		return map[string]any{"quota": "export", "used": s.Config.ExportLimitPerMonth, "limit": s.Config.ExportLimitPerMonth}
+	case EventDeckCreated:
+		return map[string]any{"deckId": newID("deck"), "name": "Quarterly Review", "versionId": newID("dv")}
+	case EventTemplatePublished:
+		return map[string]any{"templateId": newID("tpl"), "name": "Corporate 2026", "versionId": newID("tv")}
+	case EventExportCompleted:
+		return map[string]any{
+			"jobId":     newID("job"),
+			"format":    "pptx",
+			"inputRef":  newID("dv"),
+			"outputRef": newID("asset"),
+		}
+	case EventJobDeadLettered:
+		return map[string]any{
+			"jobId":      newID("job"),
+			"jobType":    string(store.JobExport),
+			"inputRef":   newID("dv"),
+			"error":      "render timed out",
+			"retryCount": 3,
+		}
+	default:
+		return map[string]any{"message": "This is a test event."}
+	}
+}
+
+func (s *Server) handleSimulateEvent(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+
+	var req SimulateEventRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	// Admins can only target their own org.
+	if req.OrgID != "" && req.OrgID != id.OrgID {
+		writeError(w, r, http.StatusForbidden, "cannot simulate events for another organization")
+		return
+	}
+
+	data := s.syntheticEventData(req.Type)
+	for k, v := range req.Data {
+		data[k] = v
+	}
+
+	ev := s.emitEvent(r.Context(), id.UserID, Event{Type: req.Type, OrgID: id.OrgID, Synthetic: true, Data: data})
+	writeJSON(w, http.StatusAccepted, map[string]any{"event": ev})
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// auditRecorder captures audit entries so tests can see emitted events.
+type auditRecorder struct {
+	store.Store
+	entries []store.AuditLog
+}
+
+func (a *auditRecorder) Audit() store.AuditStore { return a }
+
+func (a *auditRecorder) Append(_ context.Context, l store.AuditLog) (store.AuditLog, error) {
+	a.entries = append(a.entries, l)
+	return l, nil
+}
+
+func (a *auditRecorder) List(_ context.Context, orgID string) ([]store.AuditLog, error) {
+	var out []store.AuditLog
+	for _, l := range a.entries {
+		if l.OrgID == orgID {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func TestSimulateEvent_EmitsThroughEventPipeline(t *testing.T) {
+	s := NewServer()
+	rec := &auditRecorder{Store: s.Store}
+	s.Store = rec
+	h := s.Handler()
+
+	body := []byte(`{"type":"job.completed","data":{"jobType":"render"}}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/events/simulate", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	addTestAuth(req, "user-1", "org-1", auth.RoleAdmin)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var resp struct {
+		Event Event `json:"event"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, EventJobCompleted, resp.Event.Type)
+	assert.Equal(t, "org-1", resp.Event.OrgID)
+	assert.True(t, resp.Event.Synthetic)
+	assert.Equal(t, "render", resp.Event.Data["jobType"])
+	assert.NotEmpty(t, resp.Event.Data["jobId"])
+
+	require.Len(t, rec.entries, 1)
+	assert.Equal(t, "event.job.completed", rec.entries[0].Action)
+	assert.Equal(t, resp.Event.ID, rec.entries[0].TargetRef)
+}
+
+func TestSimulateEvent_Rejections(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+
+	cases := []struct {
+		name string
+		role auth.Role
+		body string
+		want int
+	}{
+		{"editor forbidden", auth.RoleEditor, `{"type":"webhook.test"}`, http.StatusForbidden},
+		{"unknown type", auth.RoleAdmin, `{"type":"deck.deleted"}`, http.StatusBadRequest},
+		{"other org", auth.RoleAdmin, `{"type":"webhook.test","orgId":"org-2"}`, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/admin/events/simulate", bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", "application/json")
+			addTestAuth(req, "user-1", "org-1", tc.role)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			assert.Equal(t, tc.want, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestExportQuotaExceeded_EmitsEvent(t *testing.T) {
+	s := NewServer()
+	s.Config.StorageLimitBytes = 1
+	rec := &auditRecorder{Store: s.Store}
+	s.Store = rec
+	h := s.Handler()
+	ctx := context.Background()
+
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-evt", OrgID: "org-1", Name: "Deck"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "version-evt", Deck: "deck-evt", OrgID: "org-1", VersionNo: 1})
+	require.NoError(t, err)
+	_, err = s.Store.Assets().Create(ctx, store.Asset{ID: "asset-evt", OrgID: "org-1", Type: store.AssetPPTX, SizeBytes: 10})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/deck-versions/version-evt/export", nil)
+	addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusPaymentRequired, w.Code)
+
+	require.Len(t, rec.entries, 1)
+	assert.Equal(t, "event.quota.exceeded", rec.entries[0].Action)
+	ev := rec.entries[0].Metadata.(map[string]any)["event"].(Event)
+	assert.False(t, ev.Synthetic)
+	assert.Equal(t, "storage", ev.Data["quota"])
+}
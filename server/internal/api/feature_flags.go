@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/flags"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// FeatureFlagStatus is a flag with everything that decides its value.
+type FeatureFlagStatus struct {
+	flags.Flag
+	// Default is the value before any stored setting, after FEATURE_FLAGS.
+	Default bool `json:"default"`
+	// Platform is the platform-wide setting, if there is one.
+	Platform  *bool               `json:"platform,omitempty"`
+	Overrides []store.FeatureFlag `json:"overrides"`
+}
+
+// withFeatureFlags attaches the caller's org's flags to the request context,
+// where handlers read them with flags.Enabled. Unauthenticated requests get
+// the platform-wide values.
+func (s *Server) withFeatureFlags(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.Flags != nil {
+			id, _ := auth.GetIdentity(r.Context())
+			r = r.WithContext(flags.WithSet(r.Context(), s.Flags.For(r.Context(), id.OrgID)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleGetOrgFeatureFlags handles GET /v1/org/feature-flags, so clients can
+// show or hide features the same way the server gates them.
+func (s *Server) handleGetOrgFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"flags": flags.FromContext(r.Context())})
+}
+
+// handleListFeatureFlags handles GET /v1/admin/platform/feature-flags.
+func (s *Server) handleListFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePlatformAdmin(w, r); !ok {
+		return
+	}
+	settings, err := s.Store.FeatureFlags().List(r.Context())
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_feature_flags", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load feature flags")
+		return
+	}
+	defaults := s.Flags.Defaults()
+	out := make([]FeatureFlagStatus, 0, len(flags.Known))
+	for _, f := range flags.Known {
+		status := FeatureFlagStatus{Flag: f, Default: defaults[f.Name], Overrides: []store.FeatureFlag{}}
+		for _, setting := range settings {
+			switch {
+			case setting.Name != f.Name:
+			case setting.OrgID == "":
+				enabled := setting.Enabled
+				status.Platform = &enabled
+			default:
+				status.Overrides = append(status.Overrides, setting)
+			}
+		}
+		out = append(out, status)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"flags": out})
+}
+
+// handlePutFeatureFlag handles PUT /v1/admin/platform/feature-flags/{name}.
+// Without orgId the setting applies to every org that has no override of
+// its own. The change applies on this instance at once and on others within
+// a few seconds.
+func (s *Server) handlePutFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	id, ok := requirePlatformAdmin(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("name")
+	if _, known := flags.Lookup(name); !known {
+		writeError(w, r, http.StatusNotFound, "unknown feature flag")
+		return
+	}
+	var req FeatureFlagRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if req.OrgID != "" {
+		if _, err := s.Store.Organizations().GetOrganization(r.Context(), req.OrgID); err != nil {
+			writeError(w, r, http.StatusNotFound, "organization not found")
+			return
+		}
+	}
+
+	saved, err := s.Store.FeatureFlags().Put(r.Context(), store.FeatureFlag{Name: name, OrgID: req.OrgID, Enabled: *req.Enabled, UpdatedBy: id.UserID})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "put_feature_flag", err, "flag", name)
+		writeError(w, r, http.StatusInternalServerError, "failed to save feature flag")
+		return
+	}
+	s.Flags.Invalidate()
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "feature_flag.update", TargetRef: name, Metadata: map[string]any{"platform": true, "orgId": req.OrgID, "enabled": saved.Enabled}})
+	writeJSON(w, http.StatusOK, map[string]any{"flag": saved})
+}
+
+// handleDeleteFeatureFlag handles DELETE
+// /v1/admin/platform/feature-flags/{name}?orgId=, removing an org's override
+// or, without orgId, the platform-wide setting.
+func (s *Server) handleDeleteFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	id, ok := requirePlatformAdmin(w, r)
+	if !ok {
+		return
+	}
+	name, orgID := r.PathValue("name"), r.URL.Query().Get("orgId")
+	deleted, err := s.Store.FeatureFlags().Delete(r.Context(), name, orgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "delete_feature_flag", err, "flag", name)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete feature flag")
+		return
+	}
+	if !deleted {
+		writeError(w, r, http.StatusNotFound, "no setting for this feature flag")
+		return
+	}
+	s.Flags.Invalidate()
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "feature_flag.reset", TargetRef: name, Metadata: map[string]any{"platform": true, "orgId": orgID}})
+	w.WriteHeader(http.StatusNoContent)
+}
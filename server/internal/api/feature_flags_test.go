@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/flags"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestFeatureFlags_PlatformSettingAndOrgOverride(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-a", Name: "A"}))
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-b", Name: "B"}))
+	h := s.Handler()
+	addPlatformAdmin(t, s, "ops-1", "org-ops")
+
+	do := func(method, path, body, orgID string, role auth.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		addTestAuth(req, "user-1", orgID, role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	asOps := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		addTestAuth(req, "ops-1", "org-ops", auth.RoleViewer)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	orgFlags := func(orgID string) flags.Set {
+		w := do("GET", "/v1/org/feature-flags", "", orgID, auth.RoleViewer)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Flags flags.Set `json:"flags"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Flags
+	}
+
+	assert.False(t, orgFlags("org-a").Enabled(flags.AsyncExport))
+	assert.Equal(t, http.StatusForbidden, do("PUT", "/v1/admin/platform/feature-flags/async_export", `{"enabled":true}`, "org-a", auth.RoleOwner).Code)
+	assert.Equal(t, http.StatusNotFound, asOps("PUT", "/v1/admin/platform/feature-flags/nope", `{"enabled":true}`).Code)
+	assert.Equal(t, http.StatusNotFound, asOps("PUT", "/v1/admin/platform/feature-flags/async_export", `{"enabled":true,"orgId":"org-missing"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, asOps("PUT", "/v1/admin/platform/feature-flags/async_export", `{}`).Code)
+
+	// Roll out to one org first, then everyone but an org that opted out.
+	w := asOps("PUT", "/v1/admin/platform/feature-flags/async_export", `{"enabled":true,"orgId":"org-a"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.True(t, orgFlags("org-a").Enabled(flags.AsyncExport))
+	assert.False(t, orgFlags("org-b").Enabled(flags.AsyncExport))
+
+	require.Equal(t, http.StatusOK, asOps("PUT", "/v1/admin/platform/feature-flags/async_export", `{"enabled":true}`).Code)
+	require.Equal(t, http.StatusOK, asOps("PUT", "/v1/admin/platform/feature-flags/async_export", `{"enabled":false,"orgId":"org-b"}`).Code)
+	assert.True(t, orgFlags("org-a").Enabled(flags.AsyncExport))
+	assert.False(t, orgFlags("org-b").Enabled(flags.AsyncExport))
+	assert.True(t, orgFlags("org-c").Enabled(flags.AsyncExport))
+
+	w = asOps("GET", "/v1/admin/platform/feature-flags", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list struct {
+		Flags []FeatureFlagStatus `json:"flags"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Flags, len(flags.Known))
+	assert.Equal(t, flags.AsyncExport, list.Flags[0].Name)
+	require.NotNil(t, list.Flags[0].Platform)
+	assert.True(t, *list.Flags[0].Platform)
+	assert.Len(t, list.Flags[0].Overrides, 2)
+
+	assert.Equal(t, http.StatusNoContent, asOps("DELETE", "/v1/admin/platform/feature-flags/async_export?orgId=org-b", "").Code)
+	assert.Equal(t, http.StatusNotFound, asOps("DELETE", "/v1/admin/platform/feature-flags/async_export?orgId=org-b", "").Code)
+	assert.True(t, orgFlags("org-b").Enabled(flags.AsyncExport))
+}
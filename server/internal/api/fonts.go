@@ -0,0 +1,205 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// maxFontUploadSize caps font uploads; even large CJK fonts stay under it.
+const maxFontUploadSize = 20 << 20
+
+var fontStyles = []store.FontStyle{store.FontRegular, store.FontBold, store.FontItalic, store.FontBoldItalic}
+
+// handleUploadFont handles POST /v1/fonts. It takes a multipart upload with
+// a .ttf or .otf in "file". The family and style are read from the font and
+// may be overridden with "family" and "style" fields.
+func (s *Server) handleUploadFont(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxFontUploadSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds %d MB", maxFontUploadSize>>20))
+			return
+		}
+		writeError(w, r, http.StatusBadRequest, "multipart form with a .ttf or .otf in \"file\" is required")
+		return
+	}
+	defer file.Close()
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	if ext != ".ttf" && ext != ".otf" {
+		writeError(w, r, http.StatusBadRequest, "only .ttf and .otf fonts can be uploaded")
+		return
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "read_font_upload", err)
+		writeError(w, r, http.StatusBadRequest, "failed to read upload")
+		return
+	}
+	info, err := assets.ParseFont(data)
+	if err != nil {
+		writeError(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if family := strings.TrimSpace(r.FormValue("family")); family != "" {
+		info.Family = family
+	}
+	if style := r.FormValue("style"); style != "" {
+		info.Style = store.FontStyle(style)
+		if !slices.Contains(fontStyles, info.Style) {
+			writeError(w, r, http.StatusBadRequest, "style must be regular, bold, italic or boldItalic")
+			return
+		}
+	}
+
+	existing, err := s.Store.Fonts().List(r.Context(), id.OrgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_fonts", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to upload font")
+		return
+	}
+	for _, f := range existing {
+		if strings.EqualFold(f.Family, info.Family) && f.Style == info.Style {
+			writeError(w, r, http.StatusConflict, fmt.Sprintf("%s %s is already uploaded; delete it first to replace it", info.Family, info.Style))
+			return
+		}
+	}
+	if err := s.quotas().CheckStorage(r.Context(), id); err != nil {
+		s.writeServiceError(w, r, "upload_font", "failed to check quota", err)
+		return
+	}
+
+	objectKey := newID("font") + ext
+	mimeType := "font/" + strings.TrimPrefix(ext, ".")
+	objects, err := assets.ForOrg(r.Context(), s.Store.Organizations(), id.OrgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "upload_font", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to store font")
+		return
+	}
+	if _, err := s.ObjectStorage.Upload(objects, objectKey, data, mimeType); err != nil {
+		logger.LogError(r.Context(), "api", "upload_font", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to store font")
+		return
+	}
+	var font store.Font
+	err = s.Store.WithTx(r.Context(), func(tx store.Store) error {
+		asset, err := tx.Assets().Create(r.Context(), store.Asset{
+			ID:        newID("asset"),
+			OrgID:     id.OrgID,
+			Type:      store.AssetFont,
+			Path:      objectKey,
+			Mime:      mimeType,
+			SizeBytes: int64(len(data)),
+			Filename:  header.Filename,
+			Region:    assets.RegionFrom(objects),
+			SHA256:    assets.Checksum(data),
+		})
+		if err != nil {
+			return err
+		}
+		font, err = tx.Fonts().Create(r.Context(), store.Font{
+			ID:        newID("font"),
+			OrgID:     id.OrgID,
+			Family:    info.Family,
+			Style:     info.Style,
+			AssetID:   asset.ID,
+			Filename:  header.Filename,
+			SizeBytes: asset.SizeBytes,
+			CreatedBy: id.UserID,
+		})
+		return err
+	})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "create_font", err)
+		_ = s.ObjectStorage.Delete(objects, objectKey)
+		writeError(w, r, http.StatusInternalServerError, "failed to upload font")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "font.upload", TargetRef: font.ID, Metadata: map[string]any{"family": font.Family, "style": font.Style}})
+	writeJSON(w, http.StatusCreated, map[string]any{"font": font})
+}
+
+// handleListFonts handles GET /v1/fonts.
+func (s *Server) handleListFonts(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	fonts, err := s.Store.Fonts().List(r.Context(), id.OrgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_fonts", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list fonts")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"fonts": fonts, "standardFonts": assets.StandardFonts})
+}
+
+// handleDeleteFont handles DELETE /v1/fonts/{id}, removing the font and its
+// file. Decks already exported keep their embedded copy.
+func (s *Server) handleDeleteFont(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+	font, ok, err := s.Store.Fonts().Get(r.Context(), id.OrgID, r.PathValue("id"))
+	if err != nil {
+		logger.LogError(r.Context(), "api", "get_font", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete font")
+		return
+	}
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "font not found")
+		return
+	}
+	asset, hasAsset, _ := s.Store.Assets().Get(r.Context(), id.OrgID, font.AssetID)
+	err = s.Store.WithTx(r.Context(), func(tx store.Store) error {
+		if _, err := tx.Fonts().Delete(r.Context(), id.OrgID, font.ID); err != nil {
+			return err
+		}
+		_, err := tx.Assets().Delete(r.Context(), id.OrgID, font.AssetID)
+		return err
+	})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "delete_font", err, "font_id", font.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete font")
+		return
+	}
+	if hasAsset {
+		if err := s.ObjectStorage.Delete(assets.WithRegion(r.Context(), asset.Region), asset.Path); err != nil {
+			logger.LogError(r.Context(), "api", "delete_font_object", err, "font_id", font.ID)
+		}
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "font.delete", TargetRef: font.ID, Metadata: map[string]any{"family": font.Family, "style": font.Style}})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// fontWarnings lists the fonts spec names that exports can't show; see
+// assets.FontResolver.Warnings. Lookup failures are logged, not reported.
+func (s *Server) fontWarnings(r *http.Request, orgID string, spec any) []string {
+	warnings, err := s.fonts().Warnings(r.Context(), orgID, spec)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "font_warnings", err)
+	}
+	if warnings == nil {
+		warnings = []string{}
+	}
+	return warnings
+}
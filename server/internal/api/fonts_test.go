@@ -0,0 +1,111 @@
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// fontFile builds a TrueType file holding just a name table with family.
+func fontFile(family string) []byte {
+	u := utf16.Encode([]rune(family))
+	var name bytes.Buffer
+	binary.Write(&name, binary.BigEndian, []uint16{0, 1, 18, 3, 1, 0x409, 1, uint16(2 * len(u)), 0})
+	binary.Write(&name, binary.BigEndian, u)
+
+	var font bytes.Buffer
+	font.Write([]byte{0, 1, 0, 0})
+	binary.Write(&font, binary.BigEndian, []uint16{1, 16, 0, 0})
+	font.WriteString("name")
+	binary.Write(&font, binary.BigEndian, []uint32{0, 28, uint32(name.Len())})
+	font.Write(name.Bytes())
+	return font.Bytes()
+}
+
+func uploadFontRequest(t *testing.T, filename string, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = fw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/fonts", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+	return req
+}
+
+func TestFonts_UploadListDelete(t *testing.T) {
+	h := NewServer().Handler()
+
+	validate := func() []string {
+		body := `{"tokens":{"fonts":{"heading":"Brand Sans","body":"Arial"}},"layouts":[{"name":"Title","placeholders":[{"id":"title","type":"text","geometry":{"x":0.1,"y":0.1,"w":0.8,"h":0.2}}]}]}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/templates/validate", strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Warnings []string `json:"warnings"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Warnings
+	}
+
+	warnings := validate()
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], `"Brand Sans"`)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, uploadFontRequest(t, "brand.ttf", fontFile("Brand Sans")))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Font store.Font `json:"font"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "Brand Sans", created.Font.Family)
+	assert.Equal(t, store.FontRegular, created.Font.Style)
+	assert.Empty(t, validate())
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, uploadFontRequest(t, "brand-copy.ttf", fontFile("Brand Sans")))
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, uploadFontRequest(t, "brand.woff", fontFile("Brand Sans")))
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/fonts", nil)
+	addTestAuth(req, "user-1", "org-1", auth.RoleViewer)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list struct {
+		Fonts         []store.Font `json:"fonts"`
+		StandardFonts []string     `json:"standardFonts"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Fonts, 1)
+	assert.Contains(t, list.StandardFonts, "Arial")
+
+	req = httptest.NewRequest(http.MethodDelete, "/v1/fonts/"+created.Font.ID, nil)
+	addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.Len(t, validate(), 1)
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// decodeGlossaryEntry reads a GlossaryEntryRequest, trimming its terms. It
+// writes the error response itself and reports whether the caller may go on.
+func (s *Server) decodeGlossaryEntry(w http.ResponseWriter, r *http.Request) (GlossaryEntryRequest, bool) {
+	var req GlossaryEntryRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return req, false
+	}
+	req.Term = strings.TrimSpace(req.Term)
+	req.Replacement = strings.TrimSpace(req.Replacement)
+	for i, v := range req.Variants {
+		req.Variants[i] = strings.TrimSpace(v)
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return req, false
+	}
+	if store.GlossaryKind(req.Kind) != store.GlossaryPreferred && len(req.Variants) > 0 {
+		writeError(w, r, http.StatusBadRequest, "only preferred terms have variants")
+		return req, false
+	}
+	if store.GlossaryKind(req.Kind) != store.GlossaryForbidden && req.Replacement != "" {
+		writeError(w, r, http.StatusBadRequest, "only forbidden terms have a replacement")
+		return req, false
+	}
+	return req, true
+}
+
+// glossaryTermTaken reports whether the org already has an entry of kind for
+// term, other than exceptID.
+func (s *Server) glossaryTermTaken(ctx context.Context, orgID, kind, term, exceptID string) (bool, error) {
+	entries, err := s.Store.Glossary().List(ctx, orgID)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range entries {
+		if e.ID != exceptID && string(e.Kind) == kind && strings.EqualFold(e.Term, term) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// handleCreateGlossaryEntry handles POST /v1/glossary. Admins only.
+func (s *Server) handleCreateGlossaryEntry(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	req, ok := s.decodeGlossaryEntry(w, r)
+	if !ok {
+		return
+	}
+	if taken, err := s.glossaryTermTaken(r.Context(), id.OrgID, req.Kind, req.Term, ""); err != nil {
+		logger.LogError(r.Context(), "api", "create_glossary_entry", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to create glossary entry")
+		return
+	} else if taken {
+		writeError(w, r, http.StatusConflict, "glossary entry already exists")
+		return
+	}
+
+	e, err := s.Store.Glossary().Create(r.Context(), store.GlossaryEntry{
+		ID:          newID("gls"),
+		OrgID:       id.OrgID,
+		Kind:        store.GlossaryKind(req.Kind),
+		Term:        req.Term,
+		Variants:    req.Variants,
+		Replacement: req.Replacement,
+		Note:        req.Note,
+		CreatedBy:   id.UserID,
+	})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "create_glossary_entry", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to create glossary entry")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "glossary.create", TargetRef: e.ID, Metadata: map[string]any{"kind": e.Kind, "term": e.Term}})
+	writeJSON(w, http.StatusCreated, map[string]any{"entry": e})
+}
+
+// handleListGlossary handles GET /v1/glossary.
+func (s *Server) handleListGlossary(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	entries, err := s.Store.Glossary().List(r.Context(), id.OrgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_glossary", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list glossary")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"entries": entries})
+}
+
+// handleUpdateGlossaryEntry handles PUT /v1/glossary/{id}, replacing the
+// entry's rule. Admins only.
+func (s *Server) handleUpdateGlossaryEntry(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	req, ok := s.decodeGlossaryEntry(w, r)
+	if !ok {
+		return
+	}
+
+	e, ok, err := s.Store.Glossary().Get(r.Context(), id.OrgID, r.PathValue("id"))
+	if err != nil {
+		logger.LogError(r.Context(), "api", "update_glossary_entry", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load glossary entry")
+		return
+	}
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	if taken, err := s.glossaryTermTaken(r.Context(), id.OrgID, req.Kind, req.Term, e.ID); err != nil {
+		logger.LogError(r.Context(), "api", "update_glossary_entry", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to update glossary entry")
+		return
+	} else if taken {
+		writeError(w, r, http.StatusConflict, "glossary entry already exists")
+		return
+	}
+	e.Kind, e.Term, e.Variants, e.Replacement, e.Note = store.GlossaryKind(req.Kind), req.Term, req.Variants, req.Replacement, req.Note
+
+	updated, err := s.Store.Glossary().Update(r.Context(), e)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "update_glossary_entry", err, "entry_id", e.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to update glossary entry")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "glossary.update", TargetRef: e.ID, Metadata: map[string]any{"kind": e.Kind, "term": e.Term}})
+	writeJSON(w, http.StatusOK, map[string]any{"entry": updated})
+}
+
+// handleDeleteGlossaryEntry handles DELETE /v1/glossary/{id}. Admins only.
+func (s *Server) handleDeleteGlossaryEntry(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	e, ok, err := s.Store.Glossary().Get(r.Context(), id.OrgID, r.PathValue("id"))
+	if err != nil {
+		logger.LogError(r.Context(), "api", "delete_glossary_entry", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete glossary entry")
+		return
+	}
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	if _, err := s.Store.Glossary().Delete(r.Context(), id.OrgID, e.ID); err != nil {
+		logger.LogError(r.Context(), "api", "delete_glossary_entry", err, "entry_id", e.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete glossary entry")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "glossary.delete", TargetRef: e.ID, Metadata: map[string]any{"kind": e.Kind, "term": e.Term}})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCheckGlossary handles POST /v1/glossary/check. It corrects the text
+// the way AI output is corrected, without the AI pass, and lists the
+// forbidden terms left for a person to rewrite.
+func (s *Server) handleCheckGlossary(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+
+	var req CheckGlossaryRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	entries, err := s.Store.Glossary().List(r.Context(), id.OrgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "check_glossary", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to check glossary")
+		return
+	}
+	text, violations := ai.Glossary(entries).Apply(req.Text)
+	if violations == nil {
+		violations = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"text": text, "violations": violations})
+}
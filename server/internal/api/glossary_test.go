@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestGlossary_CRUDAndEnforcement(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	do := func(method, path, body string, role auth.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/v1/glossary", `{"kind":"casing","term":"iPhone"}`, auth.RoleEditor).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/v1/glossary", `{"kind":"banned","term":"cheap"}`, auth.RoleAdmin).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/v1/glossary", `{"kind":"casing","term":"iPhone","replacement":"phone"}`, auth.RoleAdmin).Code)
+
+	w := do(http.MethodPost, "/v1/glossary", `{"kind":"forbidden","term":"cheap"}`, auth.RoleAdmin)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Entry store.GlossaryEntry `json:"entry"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/v1/glossary", `{"kind":"forbidden","term":"Cheap"}`, auth.RoleAdmin).Code)
+	w = do(http.MethodPut, "/v1/glossary/"+created.Entry.ID, `{"kind":"forbidden","term":"cheap","replacement":"affordable"}`, auth.RoleAdmin)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(http.MethodPost, "/v1/glossary", `{"kind":"preferred","term":"Acme Cloud","variants":[" AcmeCloud "]}`, auth.RoleAdmin)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = do(http.MethodGet, "/v1/glossary", "", auth.RoleViewer)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Entries []store.GlossaryEntry `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Entries, 2)
+	assert.Equal(t, "Acme Cloud", list.Entries[0].Term)
+	assert.Equal(t, []string{"AcmeCloud"}, list.Entries[0].Variants)
+
+	w = do(http.MethodPost, "/v1/glossary/check", `{"text":"A cheap plan on acmecloud"}`, auth.RoleViewer)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"text":"A affordable plan on Acme Cloud","violations":[]}`, w.Body.String())
+
+	// AI output is held to the glossary.
+	ctx := context.Background()
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-gls", OrgID: "org-1", OwnerUserID: "user-1", Name: "Pricing", LatestVersionNo: 1})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-gls", Deck: "deck-gls", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"tokens":{},"layouts":[{"name":"Intro","placeholders":[{"id":"title","type":"text","content":"Cheap AcmeCloud plans","geometry":{"x":0.1,"y":0.1,"w":0.8,"h":0.2}}]}]}`)})
+	require.NoError(t, err)
+	w = do(http.MethodPost, "/v1/deck-versions/dv-gls/transform", `{"operation":"formalize"}`, auth.RoleEditor)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "[formalize] Affordable Acme Cloud plans")
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/v1/glossary/"+created.Entry.ID, "", auth.RoleAdmin).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/v1/glossary/"+created.Entry.ID, "", auth.RoleAdmin).Code)
+}
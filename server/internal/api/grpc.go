@@ -0,0 +1,476 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/service"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	cmsaiv1 "github.com/ziyad/cms-ai/server/proto/cmsai/v1"
+)
+
+// grpcErrorDomain is the ErrorInfo domain of errors returned over gRPC.
+const grpcErrorDomain = "cms-ai"
+
+// grpcWatchFallback is how often WatchJob re-reads a job nobody told it
+// about: this server's worker announces the jobs it saves, but jobs run by
+// another instance or changed through the API aren't announced.
+var grpcWatchFallback = 5 * time.Second
+
+// GRPCServer returns a gRPC server for the services in proto/cmsai/v1. The
+// RPCs call the same services as the REST handlers, and an interceptor
+// authenticates the caller's "authorization" metadata the way the REST API
+// does the Authorization header.
+func (s *Server) GRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(s.grpcUnaryAuth), grpc.ChainStreamInterceptor(s.grpcStreamAuth))
+	gs := grpc.NewServer(opts...)
+	cmsaiv1.RegisterTemplateServiceServer(gs, grpcTemplates{s: s})
+	cmsaiv1.RegisterDeckServiceServer(gs, grpcDecks{s: s})
+	cmsaiv1.RegisterJobServiceServer(gs, grpcJobs{s: s})
+	return gs
+}
+
+func (s *Server) grpcUnaryAuth(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, requestID, err := s.grpcAuthenticate(ctx)
+	_ = grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestID))
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) grpcStreamAuth(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, requestID, err := s.grpcAuthenticate(ss.Context())
+	_ = ss.SetHeader(metadata.Pairs("x-request-id", requestID))
+	if err != nil {
+		return err
+	}
+	return handler(srv, authedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authedStream is a stream whose context carries the caller's identity.
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s authedStream) Context() context.Context { return s.ctx }
+
+// grpcAuthenticate is withAuth for gRPC: it authenticates the call's
+// "authorization" metadata, API keys first, and checks the membership
+// behind it. The returned context carries the identity and the call's
+// request ID, taken from "x-request-id" metadata when the caller sent one.
+func (s *Server) grpcAuthenticate(ctx context.Context) (context.Context, string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestID := ""
+	if v := md.Get("x-request-id"); len(v) > 0 {
+		requestID = v[0]
+	}
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	ctx = context.WithValue(ctx, ctxKeyRequestID{}, requestID)
+	ctx = logger.ContextWithRequestID(ctx, requestID)
+
+	// The authenticators read their credential from an HTTP request.
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", nil)
+	if err != nil {
+		return ctx, requestID, status.Errorf(codes.Internal, "build request: %v", err)
+	}
+	if v := md.Get("authorization"); len(v) > 0 {
+		r.Header.Set("Authorization", v[0])
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		r.RemoteAddr = p.Addr.String()
+	}
+	id, err := apiKeyAuthenticator{s: s, next: s.Authenticator}.Authenticate(r)
+	if err != nil {
+		return ctx, requestID, grpcError(http.StatusUnauthorized, ErrCodeUnauthenticated, "unauthorized", 0)
+	}
+	id, active := s.resolveIdentity(ctx, id)
+	if !active {
+		return ctx, requestID, grpcError(http.StatusUnauthorized, ErrCodeUnauthenticated, "membership revoked", 0)
+	}
+	ctx = auth.WithIdentity(ctx, id)
+	ctx = logger.ContextWithIdentity(ctx, id.UserID, id.OrgID)
+	s.recordActivity(ctx)
+	return ctx, requestID, nil
+}
+
+// grpcError is an API error as a gRPC status: the HTTP status picks the
+// code, the API's error code travels as the ErrorInfo reason and, when the
+// caller should wait before retrying, RetryInfo says how long.
+func grpcError(httpStatus int, code, msg string, retryAfter time.Duration) error {
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: code, Domain: grpcErrorDomain}}
+	if retryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	}
+	st := status.New(grpcCode(httpStatus), msg)
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// grpcServiceError is writeServiceError for gRPC.
+func grpcServiceError(ctx context.Context, op, msg string, err error) error {
+	f := describeServiceError(ctx, op, msg, err)
+	return grpcError(f.status, f.code, f.msg, f.retryAfter)
+}
+
+// grpcValidationError is writeValidationError for gRPC.
+func grpcValidationError(err error) error {
+	return grpcError(http.StatusBadRequest, ErrCodeValidation, fmt.Sprintf("validation failed: %v", err), 0)
+}
+
+// grpcCode maps the HTTP statuses the API's errors carry onto gRPC codes.
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusRequestEntityTooLarge:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusPaymentRequired, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+type grpcTemplates struct {
+	cmsaiv1.UnimplementedTemplateServiceServer
+	s *Server
+}
+
+func (t grpcTemplates) GetTemplate(ctx context.Context, req *cmsaiv1.GetTemplateRequest) (*cmsaiv1.Template, error) {
+	id, _ := auth.GetIdentity(ctx)
+	tpl, err := t.s.templateService().Get(ctx, id, req.GetId())
+	if err != nil {
+		return nil, grpcServiceError(ctx, "grpc_get_template", "failed to get template", err)
+	}
+	return templateToProto(&tpl), nil
+}
+
+func (t grpcTemplates) ListTemplates(ctx context.Context, req *cmsaiv1.ListTemplatesRequest) (*cmsaiv1.ListTemplatesResponse, error) {
+	id, _ := auth.GetIdentity(ctx)
+	tpls, err := t.s.templateService().List(ctx, id, store.TemplateStatus(req.GetStatus()))
+	if err != nil {
+		return nil, grpcServiceError(ctx, "grpc_list_templates", "failed to list templates", err)
+	}
+	resp := &cmsaiv1.ListTemplatesResponse{Templates: make([]*cmsaiv1.Template, 0, len(tpls))}
+	for i := range tpls {
+		resp.Templates = append(resp.Templates, templateToProto(&tpls[i]))
+	}
+	return resp, nil
+}
+
+func (t grpcTemplates) GenerateTemplate(ctx context.Context, req *cmsaiv1.GenerateTemplateRequest) (*cmsaiv1.GenerateTemplateResponse, error) {
+	id, _ := auth.GetIdentity(ctx)
+	body := GenerateTemplateRequest{
+		Prompt:                  req.GetPrompt(),
+		Name:                    req.GetName(),
+		BrandKitID:              req.GetBrandKitId(),
+		Language:                req.GetLanguage(),
+		Tone:                    req.GetTone(),
+		GenerationParamsRequest: generationParamsFromProto(req.GetParams()),
+	}
+	if req.GetContentData() != nil {
+		body.ContentData = req.GetContentData().AsMap()
+	}
+	// proto3 can't tell false from unset, so only true overrides the org.
+	if req.GetRtl() {
+		rtl := true
+		body.RTL = &rtl
+	}
+	if err := t.s.validate.Struct(body); err != nil {
+		return nil, grpcValidationError(err)
+	}
+	in := service.GenerateInput{
+		Prompt:      body.Prompt,
+		Name:        body.Name,
+		BrandKitID:  body.BrandKitID,
+		RTL:         body.RTL,
+		Language:    body.Language,
+		Tone:        body.Tone,
+		ContentData: body.ContentData,
+		Params:      body.params(),
+	}
+
+	generate := t.s.templateService().Generate
+	if req.GetSync() {
+		generate = t.s.templateService().GenerateNow
+	}
+	res, err := generate(ctx, id, in)
+	if err != nil {
+		return nil, grpcServiceError(ctx, "grpc_generate_template", "failed to create template", err)
+	}
+	resp := &cmsaiv1.GenerateTemplateResponse{Template: templateToProto(&res.Template), Job: jobToProto(res.Job)}
+	if res.Version != nil {
+		resp.Version = &cmsaiv1.TemplateVersion{
+			Id:         res.Version.ID,
+			TemplateId: res.Version.Template,
+			VersionNo:  int32(res.Version.VersionNo),
+			SpecJson:   specJSON(res.Version.SpecJSON),
+			CreatedBy:  res.Version.CreatedBy,
+			CreatedAt:  timestamppb.New(res.Version.CreatedAt),
+		}
+	}
+	return resp, nil
+}
+
+type grpcDecks struct {
+	cmsaiv1.UnimplementedDeckServiceServer
+	s *Server
+}
+
+func (d grpcDecks) GetDeck(ctx context.Context, req *cmsaiv1.GetDeckRequest) (*cmsaiv1.Deck, error) {
+	id, _ := auth.GetIdentity(ctx)
+	deck, err := d.s.deckService().Get(ctx, id, req.GetId())
+	if err != nil {
+		return nil, grpcServiceError(ctx, "grpc_get_deck", "failed to get deck", err)
+	}
+	return deckToProto(&deck), nil
+}
+
+func (d grpcDecks) ListDecks(ctx context.Context, _ *cmsaiv1.ListDecksRequest) (*cmsaiv1.ListDecksResponse, error) {
+	id, _ := auth.GetIdentity(ctx)
+	decks, err := d.s.deckService().List(ctx, id)
+	if err != nil {
+		return nil, grpcServiceError(ctx, "grpc_list_decks", "failed to list decks", err)
+	}
+	resp := &cmsaiv1.ListDecksResponse{Decks: make([]*cmsaiv1.Deck, 0, len(decks))}
+	for i := range decks {
+		resp.Decks = append(resp.Decks, deckToProto(&decks[i]))
+	}
+	return resp, nil
+}
+
+func (d grpcDecks) CreateDeck(ctx context.Context, req *cmsaiv1.CreateDeckRequest) (*cmsaiv1.CreateDeckResponse, error) {
+	id, _ := auth.GetIdentity(ctx)
+	body := CreateDeckRequest{
+		Name:                    req.GetName(),
+		SourceTemplateVersion:   req.GetSourceTemplateVersionId(),
+		Content:                 req.GetContent(),
+		GenerationParamsRequest: generationParamsFromProto(req.GetParams()),
+	}
+	if req.GetOutline() != nil {
+		body.Outline = req.GetOutline().AsMap()
+	}
+	if err := d.s.validate.Struct(body); err != nil {
+		return nil, grpcValidationError(err)
+	}
+	res, err := d.s.deckService().Create(ctx, id, service.CreateDeckInput{
+		Name:                    body.Name,
+		SourceTemplateVersionID: body.SourceTemplateVersion,
+		Content:                 body.Content,
+		Outline:                 body.Outline,
+		Params:                  body.params(),
+	})
+	if err != nil {
+		return nil, grpcServiceError(ctx, "grpc_create_deck", "failed to create deck", err)
+	}
+	resp := &cmsaiv1.CreateDeckResponse{Deck: deckToProto(&res.Deck), Job: jobToProto(res.Job)}
+	if res.Version != nil {
+		resp.Version = &cmsaiv1.DeckVersion{
+			Id:        res.Version.ID,
+			DeckId:    res.Version.Deck,
+			VersionNo: int32(res.Version.VersionNo),
+			SpecJson:  specJSON(res.Version.SpecJSON),
+			CreatedBy: res.Version.CreatedBy,
+			CreatedAt: timestamppb.New(res.Version.CreatedAt),
+		}
+	}
+	return resp, nil
+}
+
+func (d grpcDecks) ExportDeckVersion(ctx context.Context, req *cmsaiv1.ExportDeckVersionRequest) (*cmsaiv1.Job, error) {
+	id, _ := auth.GetIdentity(ctx)
+	res, err := d.s.exportService().ExportDeckVersion(ctx, id, req.GetVersionId(), service.ExportOptions{Format: req.GetFormat()})
+	if err != nil {
+		return nil, grpcServiceError(ctx, "grpc_export_deck_version", "failed to enqueue job", err)
+	}
+	return jobToProto(&res.Job), nil
+}
+
+type grpcJobs struct {
+	cmsaiv1.UnimplementedJobServiceServer
+	s *Server
+}
+
+func (j grpcJobs) GetJob(ctx context.Context, req *cmsaiv1.GetJobRequest) (*cmsaiv1.Job, error) {
+	job, err := j.get(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return jobToProto(job), nil
+}
+
+// WatchJob sends the job now and again whenever its status or progress
+// changes, until it finishes. It wakes when this server's worker saves the
+// job and otherwise re-reads it every grpcWatchFallback.
+func (j grpcJobs) WatchJob(req *cmsaiv1.WatchJobRequest, stream grpc.ServerStreamingServer[cmsaiv1.Job]) error {
+	ctx := stream.Context()
+	var changed <-chan struct{}
+	if j.s.Worker != nil {
+		var stop func()
+		changed, stop = j.s.Worker.Watch(req.GetId())
+		defer stop()
+	}
+	var last *store.Job
+	for {
+		job, err := j.get(ctx, req.GetId())
+		if err != nil {
+			return err
+		}
+		if last == nil || job.Status != last.Status || job.ProgressStep != last.ProgressStep || job.ProgressPct != last.ProgressPct {
+			if err := stream.Send(jobToProto(job)); err != nil {
+				return err
+			}
+			last = job
+		}
+		switch job.Status {
+		case store.JobDone, store.JobFailed, store.JobDeadLetter:
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-changed:
+		case <-time.After(grpcWatchFallback):
+		}
+	}
+}
+
+func (j grpcJobs) get(ctx context.Context, jobID string) (*store.Job, error) {
+	id, _ := auth.GetIdentity(ctx)
+	job, ok, err := j.s.Store.Jobs().Get(ctx, id.OrgID, jobID)
+	if err != nil {
+		logger.LogError(ctx, "api", "grpc_get_job", err, "job_id", jobID)
+		return nil, grpcError(http.StatusInternalServerError, ErrCodeInternal, "failed to get job", 0)
+	}
+	if !ok {
+		return nil, grpcError(http.StatusNotFound, ErrCodeNotFound, "job not found", 0)
+	}
+	return &job, nil
+}
+
+func generationParamsFromProto(p *cmsaiv1.GenerationParams) GenerationParamsRequest {
+	var out GenerationParamsRequest
+	if p == nil {
+		return out
+	}
+	if p.Temperature != nil {
+		out.Temperature = p.Temperature
+	}
+	if p.TopP != nil {
+		out.TopP = p.TopP
+	}
+	if p.MaxSlides != nil {
+		n := int(p.GetMaxSlides())
+		out.MaxSlides = &n
+	}
+	return out
+}
+
+func templateToProto(t *store.Template) *cmsaiv1.Template {
+	return &cmsaiv1.Template{
+		Id:               t.ID,
+		OrgId:            t.OrgID,
+		OwnerUserId:      t.OwnerUserID,
+		Name:             t.Name,
+		Status:           string(t.Status),
+		CurrentVersionId: derefString(t.CurrentVersion),
+		LatestVersionNo:  int32(t.LatestVersionNo),
+		Visibility:       string(t.Visibility),
+		CreatedAt:        timestamppb.New(t.CreatedAt),
+		UpdatedAt:        timestamppb.New(t.UpdatedAt),
+	}
+}
+
+func deckToProto(d *store.Deck) *cmsaiv1.Deck {
+	return &cmsaiv1.Deck{
+		Id:                      d.ID,
+		OrgId:                   d.OrgID,
+		OwnerUserId:             d.OwnerUserID,
+		Name:                    d.Name,
+		SourceTemplateVersionId: d.SourceTemplateVersion,
+		CurrentVersionId:        derefString(d.CurrentVersion),
+		LatestVersionNo:         int32(d.LatestVersionNo),
+		Visibility:              string(d.Visibility),
+		Content:                 d.Content,
+		CreatedAt:               timestamppb.New(d.CreatedAt),
+		UpdatedAt:               timestamppb.New(d.UpdatedAt),
+	}
+}
+
+func jobToProto(j *store.Job) *cmsaiv1.Job {
+	if j == nil {
+		return nil
+	}
+	out := &cmsaiv1.Job{
+		Id:           j.ID,
+		OrgId:        j.OrgID,
+		Type:         string(j.Type),
+		Status:       string(j.Status),
+		InputRef:     j.InputRef,
+		OutputRef:    j.OutputRef,
+		Error:        j.Error,
+		RetryCount:   int32(j.RetryCount),
+		MaxRetries:   int32(j.MaxRetries),
+		ProgressStep: j.ProgressStep,
+		ProgressPct:  int32(j.ProgressPct),
+		CreatedAt:    timestamppb.New(j.CreatedAt),
+		UpdatedAt:    timestamppb.New(j.UpdatedAt),
+	}
+	if j.Metadata != nil {
+		out.Metadata = *j.Metadata
+	}
+	return out
+}
+
+// specJSON re-encodes a decoded spec document; specs are carried as JSON
+// strings rather than google.protobuf.Struct so numbers keep their form.
+func specJSON(spec any) string {
+	if spec == nil {
+		return ""
+	}
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func derefString(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	cmsaiv1 "github.com/ziyad/cms-ai/server/proto/cmsai/v1"
+)
+
+func TestGRPC_GenerateAndWatchJob(t *testing.T) {
+	t.Setenv("USE_MOCK_AI", "true")
+	t.Setenv("DATABASE_URL", "")
+	// WatchJob has to hear about the job from the worker: the fallback
+	// re-read would never come in time.
+	prev := grpcWatchFallback
+	grpcWatchFallback = time.Hour
+	t.Cleanup(func() { grpcWatchFallback = prev })
+
+	s, w := NewServerWithWorker()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "user-1", Email: "editor@example.com"}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "user-1", OrgID: "org-1", Role: auth.RoleEditor}))
+
+	lis := bufconn.Listen(1 << 20)
+	gs := s.GRPCServer()
+	go func() { _ = gs.Serve(lis) }()
+	defer gs.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	templates := cmsaiv1.NewTemplateServiceClient(conn)
+	decks := cmsaiv1.NewDeckServiceClient(conn)
+	jobs := cmsaiv1.NewJobServiceClient(conn)
+
+	_, err = templates.ListTemplates(ctx, &cmsaiv1.ListTemplatesRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	other, err := auth.GenerateToken("user-1", "org-2", auth.RoleEditor)
+	require.NoError(t, err)
+	_, err = templates.ListTemplates(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+other), &cmsaiv1.ListTemplatesRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "token for an org the user isn't in")
+
+	token, err := auth.GenerateToken("user-1", "org-1", auth.RoleEditor)
+	require.NoError(t, err)
+	authed := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+
+	_, err = templates.GenerateTemplate(authed, &cmsaiv1.GenerateTemplateRequest{Prompt: "short"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	assert.Equal(t, ErrCodeValidation, details[0].(*errdetails.ErrorInfo).GetReason())
+	_, err = decks.GetDeck(authed, &cmsaiv1.GetDeckRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	gen, err := templates.GenerateTemplate(authed, &cmsaiv1.GenerateTemplateRequest{Prompt: "A quarterly business review for a logistics company", Name: "QBR"})
+	require.NoError(t, err)
+	require.NotNil(t, gen.GetJob())
+	assert.Equal(t, "QBR", gen.GetTemplate().GetName())
+
+	stream, err := jobs.WatchJob(authed, &cmsaiv1.WatchJobRequest{Id: gen.GetJob().GetId()})
+	require.NoError(t, err)
+	first, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, string(store.JobQueued), first.GetStatus())
+
+	w.ProcessJobs()
+	var last *cmsaiv1.Job
+	for {
+		j, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		last = j
+	}
+	require.NotNil(t, last)
+	assert.Equal(t, string(store.JobDone), last.GetStatus())
+	require.NotEmpty(t, last.GetOutputRef())
+
+	tpl, err := templates.GetTemplate(authed, &cmsaiv1.GetTemplateRequest{Id: gen.GetTemplate().GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, last.GetOutputRef(), tpl.GetCurrentVersionId())
+
+	list, err := templates.ListTemplates(authed, &cmsaiv1.ListTemplatesRequest{})
+	require.NoError(t, err)
+	assert.Len(t, list.GetTemplates(), 1)
+}
+
+func TestGRPCCode(t *testing.T) {
+	assert.Equal(t, codes.PermissionDenied, grpcCode(403))
+	assert.Equal(t, codes.ResourceExhausted, grpcCode(402))
+	assert.Equal(t, codes.DeadlineExceeded, grpcCode(504))
+	assert.Equal(t, codes.Internal, grpcCode(500))
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store/postgres"
+)
+
+const (
+	readyCheckTimeout = 2 * time.Second
+	// workerHeartbeatGrace is added to the job timeout: the worker beats
+	// between jobs, so a single long job shouldn't make it look dead.
+	workerHeartbeatGrace = 30 * time.Second
+)
+
+// DependencyStatus leaves out why a dependency is down: /readyz is
+// unauthenticated, and errors can name hosts and credentials. The reason is
+// logged under the request's ID instead.
+type DependencyStatus struct {
+	Status    string `json:"status"` // ok, down, disabled
+	Critical  bool   `json:"critical"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+type ReadinessResponse struct {
+	Status string                      `json:"status"` // ok, degraded, unavailable
+	Checks map[string]DependencyStatus `json:"checks"`
+}
+
+type readyCheck struct {
+	name     string
+	critical bool
+	run      func(ctx context.Context) (disabled bool, err error)
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	checks := []readyCheck{
+		{name: "database", critical: true, run: s.checkDatabase},
+		{name: "objectStorage", critical: true, run: s.checkObjectStorage},
+		{name: "ai", critical: false, run: s.checkAIProvider},
+		{name: "worker", critical: false, run: s.checkWorker},
+	}
+
+	resp := ReadinessResponse{Status: "ok", Checks: make(map[string]DependencyStatus, len(checks))}
+	failures := map[string]error{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c readyCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), readyCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			disabled, err := c.run(ctx)
+			st := DependencyStatus{Status: "ok", Critical: c.critical, LatencyMs: time.Since(start).Milliseconds()}
+			switch {
+			case err != nil:
+				st.Status = "down"
+			case disabled:
+				st.Status = "disabled"
+			}
+
+			mu.Lock()
+			resp.Checks[c.name] = st
+			if err != nil {
+				failures[c.name] = err
+			}
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	for name, err := range failures {
+		st := resp.Checks[name]
+		logger.WithContext(r.Context()).Warn("readiness_check_failed", "dependency", name, "critical", st.Critical, "error", err.Error())
+		if st.Critical {
+			resp.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		} else if resp.Status == "ok" {
+			resp.Status = "degraded"
+		}
+	}
+	writeJSON(w, status, resp)
+}
+
+func (s *Server) checkDatabase(ctx context.Context) (bool, error) {
+	pgStore, ok := s.Store.(*postgres.PostgresStore)
+	if !ok {
+		// In-memory store has nothing to reach.
+		return false, nil
+	}
+	db, err := pgStore.DB()
+	if err != nil {
+		return false, err
+	}
+	return false, db.PingContext(ctx)
+}
+
+func (s *Server) checkObjectStorage(ctx context.Context) (bool, error) {
+	if s.ObjectStorage == nil {
+		return false, fmt.Errorf("object storage not configured")
+	}
+	// A missing key is a successful round trip; only transport errors count.
+	_, err := s.ObjectStorage.Exists(ctx, ".readyz")
+	return false, err
+}
+
+func (s *Server) checkAIProvider(ctx context.Context) (bool, error) {
+	hc, ok := s.AIService.(interface{ HealthCheck(context.Context) error })
+	if !ok {
+		return true, nil
+	}
+	return false, hc.HealthCheck(ctx)
+}
+
+func (s *Server) checkWorker(ctx context.Context) (bool, error) {
+	if s.Worker == nil {
+		return true, nil
+	}
+	last := s.Worker.LastHeartbeat()
+	if last.IsZero() {
+		return false, fmt.Errorf("worker not started")
+	}
+	maxAge := s.Worker.JobTimeout + workerHeartbeatGrace
+	if age := time.Since(last); age > maxAge {
+		return false, fmt.Errorf("last heartbeat %s ago (max %s)", age.Round(time.Second), maxAge)
+	}
+	return false, nil
+}
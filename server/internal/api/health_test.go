@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/worker"
+)
+
+type unreachableStorage struct{ assets.ObjectStorage }
+
+func (unreachableStorage) Exists(ctx context.Context, key string) (bool, error) {
+	return false, errors.New("dial tcp: connection refused")
+}
+
+func getReadyz(t *testing.T, s *Server) (int, ReadinessResponse) {
+	t.Helper()
+	w := serveReadyz(s)
+	var resp ReadinessResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w.Code, resp
+}
+
+func serveReadyz(s *Server) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	return w
+}
+
+func TestReadyz_ReportsDependencies(t *testing.T) {
+	s := NewServer()
+
+	code, resp := getReadyz(t, s)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", resp.Status)
+	assert.Equal(t, "ok", resp.Checks["database"].Status)
+	assert.Equal(t, "ok", resp.Checks["objectStorage"].Status)
+	assert.Equal(t, "disabled", resp.Checks["worker"].Status)
+
+	// A worker that never started is reported but isn't critical.
+	s.Worker = worker.New(s.Store, s.Renderer, s.ObjectStorage, s.AIService)
+	code, resp = getReadyz(t, s)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "degraded", resp.Status)
+	assert.Equal(t, "down", resp.Checks["worker"].Status)
+}
+
+func TestReadyz_CriticalDependencyDown(t *testing.T) {
+	s := NewServer()
+	s.ObjectStorage = unreachableStorage{s.ObjectStorage}
+
+	code, resp := getReadyz(t, s)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unavailable", resp.Status)
+	assert.Equal(t, "down", resp.Checks["objectStorage"].Status)
+	assert.True(t, resp.Checks["objectStorage"].Critical)
+	assert.NotContains(t, serveReadyz(s).Body.String(), "connection refused", "errors are logged, not returned")
+}
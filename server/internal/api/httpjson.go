@@ -5,9 +5,16 @@ import (
 	"net/http"
 )
 
+// ErrorResponse is the body of every non-2xx API response. Code is one of the
+// ErrCode constants and is what clients should branch on; Message is for
+// people. Details, when present, depends on the code.
 type ErrorResponse struct {
-	Error   string `json:"error"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
 	Request string `json:"requestId,omitempty"`
+	// Error repeats Message for clients written before codes existed.
+	Error string `json:"error"`
 }
 
 func writeJSON(w http.ResponseWriter, status int, v any) {
@@ -16,7 +23,13 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
+// writeError answers with the code that status stands for. Use
+// writeErrorCode when the failure has a more specific code.
 func writeError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	writeErrorCode(w, r, status, codeForStatus(status), msg, nil)
+}
+
+func writeErrorCode(w http.ResponseWriter, r *http.Request, status int, code, msg string, details any) {
 	requestID, _ := r.Context().Value(ctxKeyRequestID{}).(string)
-	writeJSON(w, status, ErrorResponse{Error: msg, Request: requestID})
+	writeJSON(w, status, ErrorResponse{Code: code, Message: msg, Details: details, Request: requestID, Error: msg})
 }
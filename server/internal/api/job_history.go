@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// versionJobTypes are the job types whose InputRef is a template or deck
+// version ID.
+var versionJobTypes = []store.JobType{store.JobRender, store.JobPreview, store.JobExport}
+
+// JobHistoryEntry is one past or running job for a version. DurationMs is only
+// set once the job has finished, and AssetID only when it produced one.
+type JobHistoryEntry struct {
+	ID         string          `json:"id"`
+	Type       store.JobType   `json:"type"`
+	Status     store.JobStatus `json:"status"`
+	Format     string          `json:"format,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	UpdatedAt  time.Time       `json:"updatedAt"`
+	DurationMs int64           `json:"durationMs,omitempty"`
+	AssetID    string          `json:"assetId,omitempty"`
+	AssetURL   string          `json:"assetUrl,omitempty"`
+}
+
+func jobHistoryEntry(j store.Job) JobHistoryEntry {
+	e := JobHistoryEntry{
+		ID:        j.ID,
+		Type:      j.Type,
+		Status:    j.Status,
+		Error:     j.Error,
+		CreatedAt: j.CreatedAt,
+		UpdatedAt: j.UpdatedAt,
+	}
+	if j.Metadata != nil {
+		e.Format = (*j.Metadata)["format"]
+	}
+	switch j.Status {
+	case store.JobDone, store.JobFailed, store.JobDeadLetter:
+		e.DurationMs = j.UpdatedAt.Sub(j.CreatedAt).Milliseconds()
+	}
+	if j.Status == store.JobDone && j.OutputRef != "" {
+		e.AssetID = j.OutputRef
+		e.AssetURL = "/v1/assets/" + j.OutputRef
+	}
+	return e
+}
+
+// writeVersionJobs lists the jobs for one version, newest first, along with
+// when it was last exported successfully. ?type= narrows to one job type.
+func (s *Server) writeVersionJobs(w http.ResponseWriter, r *http.Request, orgID, versionID string) {
+	types := versionJobTypes
+	if t := store.JobType(r.URL.Query().Get("type")); t != "" {
+		types = []store.JobType{t}
+	}
+
+	var jobs []store.Job
+	for _, t := range types {
+		js, err := s.Store.Jobs().ListByInputRef(r.Context(), orgID, versionID, t)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "list_version_jobs", err, "version_id", versionID, "type", t)
+			writeError(w, r, http.StatusInternalServerError, "failed to list jobs")
+			return
+		}
+		jobs = append(jobs, js...)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.After(jobs[j].CreatedAt) })
+
+	history := make([]JobHistoryEntry, 0, len(jobs))
+	var lastExportedAt *time.Time
+	for _, j := range jobs {
+		history = append(history, jobHistoryEntry(j))
+		if j.Type == store.JobExport && j.Status == store.JobDone && (lastExportedAt == nil || j.UpdatedAt.After(*lastExportedAt)) {
+			t := j.UpdatedAt
+			lastExportedAt = &t
+		}
+	}
+
+	// A job's update time moves with every change to it, so the jobs' IDs
+	// and update times tag the history.
+	tags := make([]any, 0, 2*len(jobs))
+	for _, j := range jobs {
+		tags = append(tags, j.ID, j.UpdatedAt)
+	}
+	writeJSONIfChanged(w, r, resourceETag(tags...), map[string]any{
+		"versionId":      versionID,
+		"jobs":           history,
+		"lastExportedAt": lastExportedAt,
+	})
+}
+
+// handleListDeckVersionJobs handles GET /v1/deck-versions/{versionId}/jobs.
+func (s *Server) handleListDeckVersionJobs(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	dv, ok := s.loadCommentableVersion(w, r, id, r.PathValue("versionId"))
+	if !ok {
+		return
+	}
+	s.writeVersionJobs(w, r, id.OrgID, dv.ID)
+}
+
+// handleListVersionJobs handles GET /v1/versions/{versionId}/jobs.
+func (s *Server) handleListVersionJobs(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	ver, ok, err := s.Store.Templates().GetVersion(r.Context(), id.OrgID, r.PathValue("versionId"))
+	if err != nil {
+		logger.LogError(r.Context(), "api", "get_template_version", err)
+		writeError(w, r, http.StatusInternalServerError, "failed")
+		return
+	}
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	if _, ok := s.authorizeTemplate(w, r, id, ver.Template, store.PermissionView); !ok {
+		return
+	}
+	s.writeVersionJobs(w, r, id.OrgID, ver.ID)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestDeckVersionJobs_ListsHistoryWithDurationAndAsset(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", Name: "Deck"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: "deck-1", OrgID: "org-1", VersionNo: 1})
+	require.NoError(t, err)
+
+	metadata := store.JSONMap{"format": store.ExportFormatBundle}
+	done, err := s.Store.Jobs().Enqueue(ctx, store.Job{ID: "job-done", OrgID: "org-1", Type: store.JobExport, Status: store.JobQueued, InputRef: "dv-1", Metadata: &metadata})
+	require.NoError(t, err)
+	done.Status, done.OutputRef = store.JobDone, "asset-1"
+	done.CreatedAt = done.CreatedAt.Add(-2 * time.Minute)
+	_, err = s.Store.Jobs().Update(ctx, done)
+	require.NoError(t, err)
+	_, err = s.Store.Jobs().Enqueue(ctx, store.Job{ID: "job-render", OrgID: "org-1", Type: store.JobRender, Status: store.JobQueued, InputRef: "dv-1"})
+	require.NoError(t, err)
+	_, err = s.Store.Jobs().Enqueue(ctx, store.Job{ID: "job-other", OrgID: "org-1", Type: store.JobExport, Status: store.JobDone, InputRef: "dv-2"})
+	require.NoError(t, err)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		addTestAuth(req, "user-1", "org-1", auth.RoleViewer)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/v1/deck-versions/dv-1/jobs")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Jobs           []JobHistoryEntry `json:"jobs"`
+		LastExportedAt *time.Time        `json:"lastExportedAt"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Jobs, 2)
+	assert.Equal(t, "job-render", resp.Jobs[0].ID)
+	assert.Zero(t, resp.Jobs[0].DurationMs)
+	exported := resp.Jobs[1]
+	assert.Equal(t, store.ExportFormatBundle, exported.Format)
+	assert.Equal(t, "asset-1", exported.AssetID)
+	assert.GreaterOrEqual(t, exported.DurationMs, (2 * time.Minute).Milliseconds())
+	require.NotNil(t, resp.LastExportedAt)
+
+	w = get("/v1/deck-versions/dv-1/jobs?type=render")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Jobs, 1)
+	assert.Nil(t, resp.LastExportedAt)
+
+	assert.Equal(t, http.StatusNotFound, get("/v1/deck-versions/dv-missing/jobs").Code)
+	assert.Equal(t, http.StatusNotFound, get("/v1/versions/tv-missing/jobs").Code)
+}
@@ -4,21 +4,28 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
-	"log"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
 )
 
 type ctxKeyRequestID struct{}
 
 func withRequestID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		id := r.Header.Get("X-Request-Id")
+		// Reuse the ID assigned by the logging middleware so error bodies and logs agree.
+		id := logger.RequestIDFromContext(r.Context())
+		if id == "" {
+			id = r.Header.Get("X-Request-Id")
+		}
 		if id == "" {
 			id = newRequestID()
 		}
 		w.Header().Set("X-Request-Id", id)
 		ctx := context.WithValue(r.Context(), ctxKeyRequestID{}, id)
+		ctx = logger.ContextWithRequestID(ctx, id)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -27,7 +34,7 @@ func withRecovery(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if v := recover(); v != nil {
-				log.Printf("panic: %v", v)
+				logger.WithContext(r.Context()).Error("panic_recovered", "error", v, "method", r.Method, "path", r.URL.Path)
 				writeError(w, r, http.StatusInternalServerError, "internal server error")
 			}
 		}()
@@ -39,15 +46,27 @@ func withLogging(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		next.ServeHTTP(w, r)
-		log.Printf("%s %s %s", r.Method, r.URL.Path, time.Since(start))
+		logger.WithContext(r.Context()).Info("http_request", "method", r.Method, "path", r.URL.Path, "duration_ms", time.Since(start).Milliseconds())
 	})
 }
 
+// uploadPaths accept multipart/form-data POSTs in addition to JSON.
+var uploadPaths = map[string]bool{
+	"/v1/templates/import": true,
+	"/v1/fonts":            true,
+}
+
+// isCSVUploadPath reports whether path accepts text/csv POSTs in addition
+// to JSON: batch merges take their rows as a CSV file.
+func isCSVUploadPath(path string) bool {
+	return strings.HasPrefix(path, "/v1/decks/") && strings.HasSuffix(path, "/batch-merge")
+}
+
 func requireJSON(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			ct := r.Header.Get("Content-Type")
-			if ct == "" || isJSONContentType(ct) {
+			if ct == "" || isJSONContentType(ct) || (uploadPaths[r.URL.Path] && strings.HasPrefix(ct, "multipart/form-data")) || (isCSVUploadPath(r.URL.Path) && strings.HasPrefix(ct, "text/csv")) {
 				next.ServeHTTP(w, r)
 				return
 			}
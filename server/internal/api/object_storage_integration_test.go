@@ -31,11 +31,12 @@ func TestSignedURLIntegration(t *testing.T) {
 
 	// Create a test asset record in the store (using memory store)
 	asset := store.Asset{
-		ID:    "test-asset-id",
-		OrgID: "test-org",
-		Type:  store.AssetPPTX,
-		Path:  metadata.Key,
-		Mime:  metadata.ContentType,
+		ID:     "test-asset-id",
+		OrgID:  "test-org",
+		Type:   store.AssetPPTX,
+		Path:   metadata.Key,
+		Mime:   metadata.ContentType,
+		SHA256: assets.Checksum(testData),
 	}
 	createdAsset, err := s.Store.Assets().Create(context.Background(), asset)
 	if err != nil {
@@ -74,6 +75,11 @@ func TestSignedURLIntegration(t *testing.T) {
 	if location != "https://mock-signed-url.com/"+metadata.Key {
 		t.Errorf("Expected mock signed URL, got %s", location)
 	}
+
+	// The redirect carries the checksum for the client to verify the file.
+	if got, want := w2.Header().Get("Repr-Digest"), assets.DigestHeader(assets.Checksum(testData)); got != want {
+		t.Errorf("Expected Repr-Digest %s, got %s", want, got)
+	}
 }
 
 // TestStorageBackendSelection tests different storage backends
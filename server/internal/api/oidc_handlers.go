@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func (s *Server) handleListOIDCProviders(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(s.OIDC))
+	for name := range s.OIDC {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	writeJSON(w, http.StatusOK, map[string]any{"providers": names})
+}
+
+func (s *Server) handleOIDCStart(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("provider")
+	provider, ok := s.OIDC[name]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "unknown identity provider")
+		return
+	}
+
+	state, nonce, err := auth.NewOIDCState(name)
+	if err != nil {
+		logger.LogError(r.Context(), "auth", "oidc_new_state", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to start sign-in")
+		return
+	}
+	authURL, err := provider.AuthCodeURL(r.Context(), state, nonce)
+	if err != nil {
+		logger.LogError(r.Context(), "auth", "oidc_discovery", err, "provider", name)
+		writeError(w, r, http.StatusBadGateway, "identity provider unavailable")
+		return
+	}
+	// The state is signed but not tied to a browser; the cookie ties it to
+	// the one that started sign-in, so a callback URL from someone else's
+	// sign-in can't log this browser into their account.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/v1/auth/oidc/callback",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// oidcStateCookie holds the state of the browser's sign-in in progress.
+const oidcStateCookie = "cmsai_oidc_state"
+
+// errSSOAccountUnverified refuses to attach an SSO sign-in to a local
+// account whose owner never proved the email: whoever registered it would
+// keep access to the account the SSO user then signs in to.
+var errSSOAccountUnverified = errors.New("an account with this email exists but its email is not verified")
+
+func (s *Server) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		logger.LogAuthEvent(r.Context(), "oidc_provider_error", "", false)
+		writeError(w, r, http.StatusUnauthorized, "sign-in was not completed: "+e)
+		return
+	}
+
+	name, nonce, err := auth.ParseOIDCState(q.Get("state"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid or expired sign-in state")
+		return
+	}
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(q.Get("state"))) != 1 {
+		writeError(w, r, http.StatusBadRequest, "sign-in was started in another browser")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/v1/auth/oidc/callback", MaxAge: -1, HttpOnly: true})
+	provider, ok := s.OIDC[name]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "unknown identity provider")
+		return
+	}
+
+	claims, err := provider.Exchange(r.Context(), q.Get("code"), nonce)
+	if err != nil {
+		logger.LogError(r.Context(), "auth", "oidc_exchange", err, "provider", name)
+		writeError(w, r, http.StatusUnauthorized, "sign-in failed")
+		return
+	}
+	if !provider.EmailVerified(claims) {
+		writeError(w, r, http.StatusForbidden, "identity provider did not return a verified email")
+		return
+	}
+	if !provider.DomainAllowed(claims.Email) {
+		writeError(w, r, http.StatusForbidden, auth.ErrOIDCDomainNotAllowed.Error())
+		return
+	}
+
+	user, membership, err := s.provisionSSOUser(r.Context(), claims)
+	if errors.Is(err, errSSOAccountUnverified) {
+		logger.LogAuthEvent(r.Context(), "oidc_unverified_account", "", false)
+		writeError(w, r, http.StatusConflict, "an account with this email already exists; sign in with your password and verify your email before using single sign-on")
+		return
+	}
+	if err != nil {
+		logger.LogError(r.Context(), "auth", "oidc_provision", err, "provider", name)
+		writeError(w, r, http.StatusInternalServerError, "failed to provision user")
+		return
+	}
+	logger.LogAuthEvent(r.Context(), "oidc_signin", user.ID, true)
+
+	token, err := auth.GenerateToken(user.ID, membership.OrgID, membership.Role)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "failed to generate token")
+		return
+	}
+
+	if s.Config.OIDCPostLoginRedirect != "" {
+		// Fragment, not query: tokens must not reach server logs or Referer headers.
+		http.Redirect(w, r, s.Config.OIDCPostLoginRedirect+"#token="+url.QueryEscape(token), http.StatusFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"user": map[string]any{
+			"userId": user.ID,
+			"email":  user.Email,
+			"name":   user.Name,
+			"orgId":  membership.OrgID,
+			"role":   membership.Role,
+		},
+		"token": token,
+	})
+}
+
+// provisionSSOUser finds the user by verified email or creates one. Existing
+// accounts must have verified the email themselves. New users join the org
+// that verified their email domain, or get their own org.
+func (s *Server) provisionSSOUser(ctx context.Context, claims *auth.OIDCClaims) (store.User, store.UserOrg, error) {
+	user, exists, err := s.Store.Users().GetUserByEmail(ctx, claims.Email)
+	if err != nil {
+		return store.User{}, store.UserOrg{}, err
+	}
+	if exists {
+		if user.EmailVerifiedAt == nil {
+			return store.User{}, store.UserOrg{}, errSSOAccountUnverified
+		}
+		memberships, err := s.Store.Users().ListUserOrgs(ctx, user.ID)
+		if err != nil {
+			return store.User{}, store.UserOrg{}, err
+		}
+		if memberships = s.withoutDeletedOrgs(ctx, memberships); len(memberships) == 0 {
+			return store.User{}, store.UserOrg{}, errors.New("user has no organization")
+		}
+		return user, memberships[0], nil
+	}
+
+	// The identity provider vouched for the email.
+	verifiedAt := time.Now().UTC()
+	user = store.User{ID: newID("user"), Email: claims.Email, Name: claims.Name, EmailVerifiedAt: &verifiedAt}
+	if err := s.Store.Users().CreateUser(ctx, &user); err != nil {
+		return store.User{}, store.UserOrg{}, err
+	}
+
+	membership := store.UserOrg{UserID: user.ID, Role: auth.RoleEditor}
+	org, found, err := s.Store.Organizations().GetOrganizationBySSODomain(ctx, auth.EmailDomain(claims.Email))
+	if err != nil {
+		return store.User{}, store.UserOrg{}, err
+	}
+	if found {
+		membership.OrgID = org.ID
+	} else {
+		org = store.Organization{ID: newID("org"), Name: claims.Name + "'s Organization"}
+		if err := s.Store.Organizations().CreateOrganization(ctx, &org); err != nil {
+			return store.User{}, store.UserOrg{}, err
+		}
+		membership.OrgID = org.ID
+		membership.Role = auth.RoleOwner
+	}
+	if err := s.Store.Users().CreateUserOrg(ctx, membership); err != nil {
+		return store.User{}, store.UserOrg{}, err
+	}
+
+	_, _ = s.Store.Audit().Append(ctx, store.AuditLog{ID: newID("aud"), OrgID: membership.OrgID, ActorID: user.ID, Action: "auth.sso.provision", TargetRef: user.ID, Metadata: map[string]any{"role": membership.Role, "domainMatch": found}})
+	return user, membership, nil
+}
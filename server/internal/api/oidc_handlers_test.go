@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestOIDCLogin_ProvisionsIntoDomainOrg(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var nonce string
+	var idp *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer": idp.URL, "authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint": idp.URL + "/token", "jwks_uri": idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "k1", "kty": "RSA",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, auth.OIDCClaims{
+			Email: "grace@acme.com", EmailVerified: true, Name: "Grace", Nonce: nonce,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer: idp.URL, Audience: jwt.ClaimStrings{"client-1"},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		})
+		tok.Header["kid"] = "k1"
+		signed, _ := tok.SignedString(key)
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+	})
+	idp = httptest.NewServer(mux)
+	defer idp.Close()
+
+	s := NewServer()
+	s.OIDC = map[string]*auth.OIDCProvider{
+		"acme": auth.NewOIDCProvider(auth.OIDCProviderConfig{Name: "acme", Issuer: idp.URL, ClientID: "client-1", RedirectURL: "https://app/cb"}),
+	}
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-acme", Name: "Acme"}))
+	_, err = s.Store.Organizations().SetSSODomain(ctx, "org-acme", "acme.com", "tok")
+	require.NoError(t, err)
+	_, err = s.Store.Organizations().VerifySSODomain(ctx, "org-acme")
+	require.NoError(t, err)
+	h := s.Handler()
+
+	// start begins a sign-in and returns its callback request, carrying the
+	// state cookie unless withCookie is false.
+	start := func(withCookie bool) *http.Request {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/start?provider=acme", nil))
+		require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+		loc, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+		nonce = loc.Query().Get("nonce")
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/callback?code=abc&state="+url.QueryEscape(loc.Query().Get("state")), nil)
+		if withCookie {
+			for _, c := range w.Result().Cookies() {
+				req.AddCookie(c)
+			}
+		}
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, start(false))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "a callback from another browser's sign-in is refused")
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, start(true))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		User  map[string]any `json:"user"`
+		Token string         `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "org-acme", resp.User["orgId"])
+	assert.Equal(t, string(auth.RoleEditor), resp.User["role"])
+	assert.NotEmpty(t, resp.Token)
+
+	user, ok, err := s.Store.Users().GetUserByEmail(ctx, "grace@acme.com")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.NotNil(t, user.EmailVerifiedAt, "the identity provider verified the email")
+
+	// A tampered state is rejected before contacting the provider.
+	req := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/callback?code=abc&state=bogus", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Someone registered grace@acme.com's twin without proving the address;
+	// SSO must not hand them the real owner's sign-in.
+	user.Email = "grace@acme.com"
+	user.EmailVerifiedAt = nil
+	require.NoError(t, s.Store.Users().UpdateUser(ctx, user))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, start(true))
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+}
+
+func TestOIDCStart_UnknownProvider(t *testing.T) {
+	s := NewServer()
+	req := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/start?provider=nope", nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestOrgSettings_SSODomainClaimRequiresMatchingEmailAndDNS(t *testing.T) {
+	s := NewServer()
+	var txt []string
+	s.LookupTXT = func(_ context.Context, name string) ([]string, error) {
+		if name != "_cmsai-verification.acme.com" {
+			return nil, errors.New("no such host")
+		}
+		return txt, nil
+	}
+	h := s.Handler()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Org"}))
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "user-1", Email: "owner@acme.com"}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "user-1", OrgID: "org-1", Role: auth.RoleOwner}))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, "user-1", "org-1", auth.RoleOwner)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/v1/org/settings", `{"ssoDomain":"gmail.com"}`).Code, "public mail domains are refused")
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPut, "/v1/org/settings", `{"ssoDomain":"other.com"}`).Code)
+	w := do(http.MethodPut, "/v1/org/settings", `{"ssoDomain":"acme.com"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		SSODomainVerification SSODomainVerification `json:"ssoDomainVerification"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	v := resp.SSODomainVerification
+	assert.Equal(t, "_cmsai-verification.acme.com", v.Record)
+	assert.False(t, v.Verified)
+
+	_, found, err := s.Store.Organizations().GetOrganizationBySSODomain(ctx, "acme.com")
+	require.NoError(t, err)
+	assert.False(t, found, "an unverified claim maps no one")
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/v1/org/sso-domain/verify", "").Code)
+	txt = []string{"v=spf1 -all", v.Value}
+	w = do(http.MethodPost, "/v1/org/sso-domain/verify", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	org, found, err := s.Store.Organizations().GetOrganizationBySSODomain(ctx, "acme.com")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "org-1", org.ID)
+}
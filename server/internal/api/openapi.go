@@ -0,0 +1,382 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// routeMux is a ServeMux that remembers its patterns, so the OpenAPI
+// document lists every route that is actually served.
+type routeMux struct {
+	*http.ServeMux
+	patterns []string
+}
+
+func newRouteMux() *routeMux {
+	return &routeMux{ServeMux: http.NewServeMux()}
+}
+
+func (m *routeMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	m.patterns = append(m.patterns, pattern)
+	m.ServeMux.HandleFunc(pattern, handler)
+}
+
+// handleOpenAPI serves GET /v1/openapi.json. The document is built on first
+// request, once every route has been registered.
+func (s *Server) handleOpenAPI(muxes ...*routeMux) http.HandlerFunc {
+	var (
+		once sync.Once
+		doc  map[string]any
+	)
+	return func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			var patterns []string
+			for _, m := range muxes {
+				patterns = append(patterns, m.patterns...)
+			}
+			doc = buildOpenAPI(patterns)
+		})
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		writeJSON(w, http.StatusOK, doc)
+	}
+}
+
+var pathParamRE = regexp.MustCompile(`\{([A-Za-z]+)\}`)
+
+// buildOpenAPI describes the routes in patterns, annotated from apiDocs.
+// Patterns without a method are catch-alls and are left out.
+func buildOpenAPI(patterns []string) map[string]any {
+	b := &schemaBuilder{schemas: map[string]any{}, names: map[reflect.Type]string{}}
+	errorRef := b.schema(reflect.TypeOf(ErrorResponse{}))
+	paths := map[string]map[string]any{}
+
+	for _, pattern := range patterns {
+		method, route, ok := strings.Cut(pattern, " ")
+		if !ok {
+			continue
+		}
+		doc := apiDocs[pattern]
+		op := map[string]any{
+			"operationId": operationID(method, route),
+			"tags":        []string{operationTag(route)},
+			"responses":   map[string]any{"default": map[string]any{"description": "Error", "content": jsonContent(errorRef)}},
+		}
+		if doc.Summary != "" {
+			op["summary"] = doc.Summary
+		}
+		if isPublicPath(route) {
+			op["security"] = []any{}
+		}
+
+		var params []any
+		for _, m := range pathParamRE.FindAllStringSubmatch(route, -1) {
+			params = append(params, map[string]any{"name": m[1], "in": "path", "required": true, "schema": map[string]any{"type": "string"}})
+		}
+		for _, q := range doc.Query {
+			params = append(params, map[string]any{"name": q, "in": "query", "schema": map[string]any{"type": "string"}})
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+
+		switch {
+		case doc.Upload:
+			op["requestBody"] = map[string]any{"required": true, "content": map[string]any{"multipart/form-data": map[string]any{"schema": map[string]any{
+				"type":       "object",
+				"required":   []string{"file"},
+				"properties": map[string]any{"file": map[string]any{"type": "string", "format": "binary"}, "name": map[string]any{"type": "string"}},
+			}}}}
+		case doc.Request != nil:
+			op["requestBody"] = map[string]any{"required": true, "content": jsonContent(b.valueSchema(doc.Request))}
+		}
+
+		status := doc.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		resp := map[string]any{"description": http.StatusText(status)}
+		switch {
+		case doc.ContentType != "":
+			resp["content"] = map[string]any{doc.ContentType: map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}}
+		case doc.Response != nil:
+			resp["content"] = jsonContent(b.valueSchema(doc.Response))
+		}
+		op["responses"].(map[string]any)[strconv.Itoa(status)] = resp
+
+		if paths[route] == nil {
+			paths[route] = map[string]any{}
+		}
+		paths[route][strings.ToLower(method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "CMS AI API",
+			"version":     "v1",
+			"description": "Generated from the server's routes and request/response types.",
+		},
+		"paths":    paths,
+		"security": []any{map[string]any{"bearerAuth": []string{}}},
+		"components": map[string]any{
+			"schemas": b.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+func isPublicPath(route string) bool {
+	for _, p := range publicPaths {
+		if p == route {
+			return true
+		}
+	}
+	return strings.HasPrefix(route, embedPathPrefix)
+}
+
+// operationTag groups operations by the first path segment after /v1.
+func operationTag(route string) string {
+	parts := strings.Split(strings.TrimPrefix(route, "/v1/"), "/")
+	return strings.TrimPrefix(parts[0], "/")
+}
+
+// operationID turns "GET /v1/decks/{id}/embeds" into "getDecksByIdEmbeds".
+func operationID(method, route string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+	for _, seg := range strings.Split(strings.TrimPrefix(route, "/v1"), "/") {
+		if name, ok := strings.CutPrefix(seg, "{"); ok {
+			sb.WriteString("By")
+			seg = strings.TrimSuffix(name, "}")
+		}
+		for _, word := range strings.FieldsFunc(seg, func(r rune) bool { return r == '-' || r == '.' || r == '_' }) {
+			sb.WriteString(upperFirst(word))
+		}
+	}
+	return sb.String()
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// schemaBuilder derives JSON Schemas from Go types. Named structs become
+// components referenced by $ref; the rest are inlined.
+type schemaBuilder struct {
+	schemas map[string]any
+	names   map[reflect.Type]string
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// valueSchema describes v's type, or for an envelope, an object with one
+// property per key.
+func (b *schemaBuilder) valueSchema(v any) map[string]any {
+	env, ok := v.(envelope)
+	if !ok {
+		return b.schema(reflect.TypeOf(v))
+	}
+	props := make(map[string]any, len(env))
+	for k, field := range env {
+		props[k] = b.valueSchema(field)
+	}
+	return map[string]any{"type": "object", "properties": props}
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	if t == nil {
+		return map[string]any{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawJSONType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name, ok := b.names[t]
+		if !ok {
+			name = b.componentName(t)
+			b.names[t] = name
+			// Registered before recursing so self-referencing types terminate.
+			b.schemas[name] = map[string]any{}
+			b.schemas[name] = b.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]any{}
+	}
+}
+
+// componentName is the type's name, qualified by its package when two
+// packages export the same name.
+func (b *schemaBuilder) componentName(t reflect.Type) string {
+	name := upperFirst(t.Name())
+	if _, taken := b.schemas[name]; taken {
+		name = upperFirst(path.Base(t.PkgPath())) + name
+	}
+	return name
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	b.addFields(t, props, &required)
+	out := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		out["required"] = required
+	}
+	return out
+}
+
+// addFields collects t's JSON properties, flattening embedded structs the
+// way encoding/json does.
+func (b *schemaBuilder) addFields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			b.addFields(ft, props, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		prop := b.schema(f.Type)
+		if applyValidateTag(prop, f.Tag.Get("validate")) {
+			*required = append(*required, name)
+		}
+		props[name] = prop
+	}
+}
+
+// applyValidateTag copies the validator rules that JSON Schema can express
+// onto schema; rules after "dive" apply to array items. It reports whether
+// the field is required.
+func applyValidateTag(schema map[string]any, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	required, dived := false, false
+	target := schema
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		if strings.Contains(rule, "|") {
+			continue
+		}
+		switch name {
+		case "required":
+			required = required || !dived
+		case "dive":
+			items, ok := target["items"].(map[string]any)
+			if !ok {
+				return required
+			}
+			target, dived = items, true
+		case "min", "gte":
+			setBound(target, "min", arg, false)
+		case "max", "lte":
+			setBound(target, "max", arg, false)
+		case "gt":
+			setBound(target, "min", arg, true)
+		case "lt":
+			setBound(target, "max", arg, true)
+		case "oneof":
+			target["enum"] = strings.Fields(arg)
+		case "url":
+			target["format"] = "uri"
+		case "email":
+			target["format"] = "email"
+		case "fqdn", "hostname_rfc1123":
+			target["format"] = "hostname"
+		case "hexcolor":
+			target["pattern"] = "^#([0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$"
+		case "startswith":
+			target["pattern"] = "^" + regexp.QuoteMeta(arg)
+		}
+	}
+	return required
+}
+
+// setBound maps a min/max rule to the keyword for the schema's type: a
+// length for strings, a count for arrays and a value for numbers.
+func setBound(schema map[string]any, bound, arg string, exclusive bool) {
+	n, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return
+	}
+	switch schema["type"] {
+	case "string":
+		schema[bound+"Length"] = int(n)
+	case "array":
+		schema[bound+"Items"] = int(n)
+	case "object":
+		schema[bound+"Properties"] = int(n)
+	case "integer", "number":
+		keyword := map[string]string{"min": "minimum", "max": "maximum"}[bound]
+		schema[keyword] = n
+		if exclusive {
+			schema["exclusive"+upperFirst(keyword)] = true
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getOpenAPI(t *testing.T) map[string]any {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil)
+	w := httptest.NewRecorder()
+	NewServer().Handler().ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, "served without auth: %s", w.Body.String())
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	return doc
+}
+
+func TestOpenAPI_DocumentsEveryRoute(t *testing.T) {
+	doc := getOpenAPI(t)
+	assert.Equal(t, "3.0.3", doc["openapi"])
+	paths := doc["paths"].(map[string]any)
+
+	// Every registered route has an annotation...
+	seen := map[string]bool{}
+	for route, item := range paths {
+		for method, op := range item.(map[string]any) {
+			pattern := strings.ToUpper(method) + " " + route
+			seen[pattern] = true
+			assert.NotEmpty(t, op.(map[string]any)["summary"], "%s has no entry in apiDocs", pattern)
+		}
+	}
+	// ...and every annotation a registered route.
+	for pattern := range apiDocs {
+		assert.True(t, seen[pattern], "apiDocs has %s but no such route is registered", pattern)
+	}
+}
+
+func TestOpenAPI_SchemasFollowTypes(t *testing.T) {
+	doc := getOpenAPI(t)
+	paths := doc["paths"].(map[string]any)
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+
+	deck := schemas["CreateDeckRequest"].(map[string]any)
+	assert.ElementsMatch(t, []any{"name", "sourceTemplateVersionId", "content"}, deck["required"])
+	props := deck["properties"].(map[string]any)
+	assert.EqualValues(t, 3, props["name"].(map[string]any)["minLength"])
+	// Embedded structs are flattened, as encoding/json does.
+	assert.EqualValues(t, 2, props["temperature"].(map[string]any)["maximum"])
+
+	hook := schemas["CreateWebhookRequest"].(map[string]any)["properties"].(map[string]any)
+	assert.Equal(t, "^https://", hook["url"].(map[string]any)["pattern"])
+	events := hook["events"].(map[string]any)
+	assert.EqualValues(t, 1, events["minItems"])
+	assert.Contains(t, events["items"].(map[string]any)["enum"], "deck.created")
+
+	embed := schemas["DeckEmbed"].(map[string]any)["properties"].(map[string]any)
+	assert.NotContains(t, embed, "tokenHash", "json:\"-\" fields are left out")
+
+	getDeck := paths["/v1/decks/{id}"].(map[string]any)["get"].(map[string]any)
+	assert.Equal(t, "getDecksById", getDeck["operationId"])
+	ok := getDeck["responses"].(map[string]any)["200"].(map[string]any)
+	schema := ok["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+	assert.Equal(t, "#/components/schemas/Deck", schema["properties"].(map[string]any)["deck"].(map[string]any)["$ref"])
+	assert.NotContains(t, getDeck, "security")
+
+	signup := paths["/v1/auth/signup"].(map[string]any)["post"].(map[string]any)
+	assert.Equal(t, []any{}, signup["security"], "public routes need no token")
+	viewer := paths["/v1/embed/decks/{token}"].(map[string]any)["get"].(map[string]any)
+	assert.Equal(t, []any{}, viewer["security"])
+}
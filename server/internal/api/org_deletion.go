@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// orgDeleteAction names org deletion in confirmation tokens.
+const orgDeleteAction = "org.delete"
+
+// orgDeletionTokenTTL is how long an owner has to confirm a deletion.
+const orgDeletionTokenTTL = 10 * time.Minute
+
+// handleCreateOrgDeletionToken issues the token DELETE /v1/orgs/{id} needs,
+// so an org is only deleted by an owner who asked to moments before.
+func (s *Server) handleCreateOrgDeletionToken(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleOwner) {
+		writeError(w, r, http.StatusForbidden, "only owners can delete the organization")
+		return
+	}
+	org, ok := s.orgFromPath(w, r, id)
+	if !ok {
+		return
+	}
+
+	token, expires, err := auth.NewConfirmationToken(orgDeleteAction, org.ID, id.UserID, orgDeletionTokenTTL)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "create_org_deletion_token", err, "org_id", org.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to issue confirmation token")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"confirmationToken": token, "expiresAt": expires.UTC()})
+}
+
+// handleDeleteOrg soft-deletes the caller's org. Members, API keys and embeds
+// stop working at once; the data is purged by the worker after
+// Config.OrgPurgeDelay.
+func (s *Server) handleDeleteOrg(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleOwner) {
+		writeError(w, r, http.StatusForbidden, "only owners can delete the organization")
+		return
+	}
+	org, ok := s.orgFromPath(w, r, id)
+	if !ok {
+		return
+	}
+
+	var req DeleteOrgRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if err := auth.VerifyConfirmationToken(req.ConfirmationToken, orgDeleteAction, org.ID, id.UserID); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid or expired confirmation token")
+		return
+	}
+
+	org, err := s.Store.Organizations().Delete(r.Context(), org.ID, time.Now().Add(s.Config.OrgPurgeDelay))
+	if err != nil {
+		logger.LogError(r.Context(), "api", "delete_org", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete organization")
+		return
+	}
+	s.membership.invalidateOrg(org.ID)
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: org.ID, ActorID: id.UserID, Action: "org.delete", TargetRef: org.ID, Metadata: map[string]any{"purgeAfter": org.PurgeAfter}})
+	writeJSON(w, http.StatusAccepted, map[string]any{"organization": org})
+}
+
+// withoutDeletedOrgs drops memberships in deleted orgs, so sign-in lands the
+// user in an org they can still use.
+func (s *Server) withoutDeletedOrgs(ctx context.Context, memberships []store.UserOrg) []store.UserOrg {
+	var live []store.UserOrg
+	for _, m := range memberships {
+		if org, err := s.Store.Organizations().GetOrganization(ctx, m.OrgID); err == nil && org.DeletedAt != nil {
+			continue
+		}
+		live = append(live, m)
+	}
+	return live
+}
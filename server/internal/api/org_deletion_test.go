@@ -0,0 +1,143 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func newOrgDeletionTestServer(t *testing.T) (*Server, http.Handler) {
+	t.Helper()
+	s := NewServer()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Acme"}))
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-2", Name: "Side project"}))
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "owner", Email: "owner@acme.com"}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "owner", OrgID: "org-1", Role: auth.RoleOwner}))
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "admin", Email: "admin@acme.com"}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "admin", OrgID: "org-1", Role: auth.RoleAdmin}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "admin", OrgID: "org-2", Role: auth.RoleAdmin}))
+
+	spec := json.RawMessage(`{"layouts":[{"name":"Title","placeholders":[]}]}`)
+	_, err := s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-1", OrgID: "org-1", Name: "Pitch"})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-1", Template: "tpl-1", OrgID: "org-1", VersionNo: 1, SpecJSON: spec})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", Name: "Q3"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: "deck-1", OrgID: "org-1", VersionNo: 1, SpecJSON: spec})
+	require.NoError(t, err)
+	return s, s.Handler()
+}
+
+func TestDeleteOrg_RequiresOwnerAndConfirmationToken(t *testing.T) {
+	s, h := newOrgDeletionTestServer(t)
+	s.Config.OrgPurgeDelay = 48 * time.Hour
+	_, err := s.Store.Embeds().Create(context.Background(), store.DeckEmbed{ID: "emb-1", OrgID: "org-1", DeckID: "deck-1", TokenHash: hashEmbedToken("emb_token")})
+	require.NoError(t, err)
+
+	do := func(method, path, userID string, role auth.Role, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		addTestAuth(req, userID, "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	tokenFor := func(userID string, role auth.Role) string {
+		w := do(http.MethodPost, "/v1/orgs/org-1/deletion-token", userID, role, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			ConfirmationToken string `json:"confirmationToken"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.ConfirmationToken
+	}
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/v1/orgs/org-1/deletion-token", "admin", auth.RoleAdmin, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/v1/orgs/org-2/deletion-token", "owner", auth.RoleOwner, "").Code)
+
+	token := tokenFor("owner", auth.RoleOwner)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodDelete, "/v1/orgs/org-1", "owner", auth.RoleOwner, `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodDelete, "/v1/orgs/org-1", "owner", auth.RoleOwner, `{"confirmationToken":"forged"}`).Code)
+
+	w := do(http.MethodDelete, "/v1/orgs/org-1", "owner", auth.RoleOwner, `{"confirmationToken":"`+token+`"}`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var resp struct {
+		Organization store.Organization `json:"organization"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Organization.PurgeAfter)
+	assert.WithinDuration(t, time.Now().Add(48*time.Hour), *resp.Organization.PurgeAfter, time.Minute)
+
+	// Nobody reaches the org any more, through the API or its embeds.
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/v1/templates", "admin", auth.RoleAdmin, "").Code)
+	req := httptest.NewRequest(http.MethodGet, "/v1/embed/decks/emb_token", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Signing in lands members in an org that still exists.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/auth/signin", strings.NewReader(`{"email":"admin@acme.com","password":"x"}`)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"orgId":"org-2"`)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/auth/signin", strings.NewReader(`{"email":"owner@acme.com","password":"x"}`)))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestExportOrg_ZipsTemplatesDecksSpecsAndAudit(t *testing.T) {
+	s, h := newOrgDeletionTestServer(t)
+	_, err := s.Store.Audit().Append(context.Background(), store.AuditLog{ID: "aud-1", OrgID: "org-1", ActorID: "owner", Action: "template.create", TargetRef: "tpl-1"})
+	require.NoError(t, err)
+
+	do := func(userID string, role auth.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/orgs/org-1/export", nil)
+		addTestAuth(req, userID, "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, do("admin", auth.RoleAdmin).Code)
+
+	w := do("owner", auth.RoleOwner)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "org-org-1-export-")
+
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], err = io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+	}
+	for _, name := range []string{"manifest.json", "templates.json", "templates/tpl-1/versions/1.json", "decks.json", "decks/deck-1/versions/1.json", "audit.json"} {
+		assert.Contains(t, files, name)
+	}
+
+	var version store.DeckVersion
+	require.NoError(t, json.Unmarshal(files["decks/deck-1/versions/1.json"], &version))
+	assert.JSONEq(t, `{"specVersion":2,"layouts":[{"name":"Title","placeholders":[]}]}`, string(version.SpecJSON), "the export has the spec as read, migrated to the current schema")
+
+	var audit []store.AuditLog
+	require.NoError(t, json.Unmarshal(files["audit.json"], &audit))
+	require.NotEmpty(t, audit)
+	assert.Equal(t, "template.create", audit[0].Action)
+}
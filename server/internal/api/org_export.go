@@ -0,0 +1,140 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// orgExportManifest is written to manifest.json at the root of an org export.
+type orgExportManifest struct {
+	Organization store.Organization `json:"organization"`
+	ExportedAt   time.Time          `json:"exportedAt"`
+	ExportedBy   string             `json:"exportedBy"`
+	Templates    int                `json:"templates"`
+	Decks        int                `json:"decks"`
+	AuditEntries int                `json:"auditEntries"`
+}
+
+// handleExportOrg serves GET /v1/orgs/{id}/export: a ZIP with everything the
+// org authored, for data portability requests. It holds
+//
+//	manifest.json
+//	templates.json, templates/{id}/versions/{n}.json
+//	decks.json, decks/{id}/versions/{n}.json
+//	audit.json
+//
+// where each version file is the version record with its spec.
+func (s *Server) handleExportOrg(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleOwner) {
+		writeError(w, r, http.StatusForbidden, "only owners can export the organization")
+		return
+	}
+	org, ok := s.orgFromPath(w, r, id)
+	if !ok {
+		return
+	}
+
+	data, err := s.buildOrgExport(r.Context(), org, id.UserID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "export_org", err, "org_id", org.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to export organization")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: org.ID, ActorID: id.UserID, Action: "org.export", TargetRef: org.ID, Metadata: map[string]any{"sizeBytes": len(data)}})
+
+	filename := fmt.Sprintf("org-%s-export-%s.zip", org.ID, time.Now().UTC().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// buildOrgExport assembles the archive in memory, so a store error becomes
+// an error response rather than a truncated download.
+func (s *Server) buildOrgExport(ctx context.Context, org store.Organization, userID string) ([]byte, error) {
+	templates, err := s.Store.Templates().ListTemplates(ctx, org.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list templates: %w", err)
+	}
+	decks, err := s.Store.Decks().ListDecks(ctx, org.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list decks: %w", err)
+	}
+	audit, err := s.Store.Audit().List(ctx, org.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list audit log: %w", err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	add := func(name string, v any) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	manifest := orgExportManifest{
+		Organization: org,
+		ExportedAt:   time.Now().UTC(),
+		ExportedBy:   userID,
+		Templates:    len(templates),
+		Decks:        len(decks),
+		AuditEntries: len(audit),
+	}
+	if err := add("manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	if err := add("templates.json", templates); err != nil {
+		return nil, err
+	}
+	for _, t := range templates {
+		versions, err := s.Store.Templates().ListVersions(ctx, org.ID, t.ID)
+		if err != nil {
+			return nil, fmt.Errorf("list versions of template %s: %w", t.ID, err)
+		}
+		for _, v := range versions {
+			if err := add(fmt.Sprintf("templates/%s/versions/%d.json", t.ID, v.VersionNo), v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := add("decks.json", decks); err != nil {
+		return nil, err
+	}
+	for _, d := range decks {
+		versions, err := s.Store.Decks().ListDeckVersions(ctx, org.ID, d.ID)
+		if err != nil {
+			return nil, fmt.Errorf("list versions of deck %s: %w", d.ID, err)
+		}
+		for _, v := range versions {
+			if err := add(fmt.Sprintf("decks/%s/versions/%d.json", d.ID, v.VersionNo), v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := add("audit.json", audit); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/policy"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// handleGetOrgPolicy returns the org's compliance policy, or an empty one
+// when it has none. Every member can read it, so editors know the rules
+// their decks are held to.
+func (s *Server) handleGetOrgPolicy(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, orgPolicyResponse(org.Policy))
+}
+
+func orgPolicyResponse(p *store.OrgPolicy) map[string]any {
+	if p == nil {
+		p = &store.OrgPolicy{}
+	}
+	return map[string]any{"policy": p}
+}
+
+func (s *Server) handleSetOrgPolicy(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+
+	var req store.OrgPolicy
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	for i := range req.AllowedTemplates {
+		req.AllowedTemplates[i] = strings.TrimSpace(req.AllowedTemplates[i])
+	}
+	if err := policy.Validate(req); err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	for _, templateID := range req.AllowedTemplates {
+		_, ok, err := s.Store.Templates().GetTemplate(r.Context(), id.OrgID, templateID)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "set_org_policy", err, "org_id", id.OrgID)
+			writeError(w, r, http.StatusInternalServerError, "failed to update organization policy")
+			return
+		}
+		if !ok {
+			writeErrorCode(w, r, http.StatusBadRequest, ErrCodeValidation, "unknown template in allowedTemplates", map[string]any{"template": templateID})
+			return
+		}
+	}
+
+	var p *store.OrgPolicy
+	if len(req.AllowedTemplates)+len(req.MandatorySlides)+len(req.ContentRules) > 0 {
+		p = &req
+	}
+	org, err := s.Store.Organizations().SetPolicy(r.Context(), id.OrgID, p)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "set_org_policy", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to update organization policy")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "org.policy.update", TargetRef: id.OrgID})
+
+	writeJSON(w, http.StatusOK, orgPolicyResponse(org.Policy))
+}
+
+func (s *Server) handleDeleteOrgPolicy(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+
+	if _, err := s.Store.Organizations().SetPolicy(r.Context(), id.OrgID, nil); err != nil {
+		logger.LogError(r.Context(), "api", "delete_org_policy", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete organization policy")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "org.policy.delete", TargetRef: id.OrgID})
+
+	w.WriteHeader(http.StatusNoContent)
+}
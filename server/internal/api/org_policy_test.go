@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestOrgPolicy(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Acme"}))
+	for _, id := range []string{"approved", "other"} {
+		_, err := s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-" + id, OrgID: "org-1", Name: id, Status: store.TemplateDraft, LatestVersionNo: 1})
+		require.NoError(t, err)
+		_, err = s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-" + id, Template: "tpl-" + id, OrgID: "org-1", VersionNo: 1,
+			SpecJSON: []byte(`{"tokens":{},"layouts":[{"name":"Base","placeholders":[{"id":"title","type":"text","geometry":{"x":0.1,"y":0.1,"w":0.8,"h":0.1}},{"id":"body","type":"text","geometry":{"x":0.1,"y":0.3,"w":0.8,"h":0.5}}]}]}`)})
+		require.NoError(t, err)
+	}
+	h := s.Handler()
+
+	do := func(method, path, body string, role auth.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	errorCode := func(w *httptest.ResponseRecorder) ErrorResponse {
+		var resp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	const policyJSON = `{
+		"allowedTemplates": ["tpl-approved"],
+		"mandatorySlides": [{"title": "Disclaimer", "body": "Forward-looking statements are not guarantees.", "position": "end"}],
+		"contentRules": [{"name": "no-guarantees", "pattern": "guaranteed (returns|profits?)", "message": "don't promise returns"}]
+	}`
+
+	t.Run("only admins set it", func(t *testing.T) {
+		w := do(http.MethodPut, "/v1/org/policy", policyJSON, auth.RoleEditor)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("invalid policies are rejected", func(t *testing.T) {
+		w := do(http.MethodPut, "/v1/org/policy", `{"contentRules":[{"name":"bad","pattern":"("}]}`, auth.RoleAdmin)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid pattern")
+
+		w = do(http.MethodPut, "/v1/org/policy", `{"allowedTemplates":["tpl-missing"]}`, auth.RoleAdmin)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, map[string]any{"template": "tpl-missing"}, errorCode(w).Details)
+	})
+
+	w := do(http.MethodPut, "/v1/org/policy", policyJSON, auth.RoleAdmin)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	t.Run("members can read it", func(t *testing.T) {
+		w := do(http.MethodGet, "/v1/org/policy", "", auth.RoleViewer)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct{ Policy store.OrgPolicy }
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, []string{"tpl-approved"}, resp.Policy.AllowedTemplates)
+		assert.Len(t, resp.Policy.ContentRules, 1)
+	})
+
+	t.Run("decks from other templates are refused", func(t *testing.T) {
+		w := do(http.MethodPost, "/v1/decks", `{"name":"Deck","sourceTemplateVersionId":"tv-other","content":"Quarterly results"}`, auth.RoleEditor)
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+		resp := errorCode(w)
+		assert.Equal(t, ErrCodePolicyViolation, resp.Code)
+		assert.Equal(t, []any{map[string]any{"kind": "template", "message": "decks must be created from one of the org's approved templates"}}, resp.Details)
+	})
+
+	t.Run("banned content is refused", func(t *testing.T) {
+		w := do(http.MethodPost, "/v1/decks", `{"name":"Deck","sourceTemplateVersionId":"tv-approved","content":"Our fund has Guaranteed Returns"}`, auth.RoleEditor)
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+		resp := errorCode(w)
+		assert.Equal(t, ErrCodePolicyViolation, resp.Code)
+		assert.Equal(t, "policy violation: don't promise returns", resp.Message)
+		assert.Equal(t, []any{map[string]any{"kind": "content", "rule": "no-guarantees", "message": "don't promise returns", "match": "Guaranteed Returns"}}, resp.Details)
+
+		w = do(http.MethodPost, "/v1/decks", `{"name":"Deck","sourceTemplateVersionId":"tv-approved","content":"Quarterly results","outline":{"slides":[{"slideNumber":1,"title":"Pitch","content":["guaranteed profit"]}]}}`, auth.RoleEditor)
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"slide":1`)
+	})
+
+	t.Run("decks get the mandatory slides", func(t *testing.T) {
+		w := do(http.MethodPost, "/v1/decks", `{"name":"Deck","sourceTemplateVersionId":"tv-approved","content":"Quarterly results","outline":{"slides":[{"slideNumber":1,"title":"Q3","content":["Revenue grew"]}]}}`, auth.RoleEditor)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct{ Version store.DeckVersion }
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		var deck spec.TemplateSpec
+		require.NoError(t, json.Unmarshal(resp.Version.SpecJSON, &deck))
+		require.Len(t, deck.Layouts, 2)
+		last := deck.Layouts[1]
+		require.Len(t, last.Placeholders, 2)
+		assert.Equal(t, "Disclaimer", last.Placeholders[0].Content)
+		assert.Equal(t, "Forward-looking statements are not guarantees.", last.Placeholders[1].Content)
+	})
+
+	t.Run("requests fail when the policy can't be read", func(t *testing.T) {
+		w := do(http.MethodPost, "/v1/decks", `{"name":"Deck","sourceTemplateVersionId":"tv-approved","content":"Quarterly results","outline":{"slides":[{"slideNumber":1,"title":"Q3","content":["Revenue grew"]}]}}`, auth.RoleEditor)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var created struct {
+			Deck    store.Deck
+			Version store.DeckVersion
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+		st := s.Store
+		s.Store = failingOrgsStore{st}
+		defer func() { s.Store = st }()
+		for _, tc := range []struct{ path, body string }{
+			{"/v1/decks", `{"name":"Deck","sourceTemplateVersionId":"tv-approved","content":"Quarterly results"}`},
+			{"/v1/decks/" + created.Deck.ID + "/refine", `{"instruction":"Make it shorter"}`},
+			{"/v1/deck-versions/" + created.Version.ID + "/slides/0/regenerate", `{"guidance":"More numbers"}`},
+			{"/v1/deck-versions/" + created.Version.ID + "/transform", `{"operation":"shorten"}`},
+		} {
+			w := do(http.MethodPost, tc.path, tc.body, auth.RoleEditor)
+			assert.Equal(t, http.StatusInternalServerError, w.Code, "%s: %s", tc.path, w.Body.String())
+		}
+	})
+
+	t.Run("deleting it lifts the rules", func(t *testing.T) {
+		w := do(http.MethodDelete, "/v1/org/policy", "", auth.RoleAdmin)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = do(http.MethodPost, "/v1/decks", `{"name":"Deck","sourceTemplateVersionId":"tv-other","content":"Guaranteed returns"}`, auth.RoleEditor)
+		assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	})
+}
+
+// failingOrgsStore fails every GetOrganization, like a database that is down.
+type failingOrgsStore struct{ store.Store }
+
+func (s failingOrgsStore) Organizations() store.OrganizationStore {
+	return failingOrgs{s.Store.Organizations()}
+}
+
+type failingOrgs struct{ store.OrganizationStore }
+
+func (failingOrgs) GetOrganization(context.Context, string) (store.Organization, error) {
+	return store.Organization{}, errors.New("database is down")
+}
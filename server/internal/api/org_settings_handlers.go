@@ -0,0 +1,355 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/service"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func (p GenerationParamsRequest) params() store.GenerationParams {
+	return store.GenerationParams{Temperature: p.Temperature, TopP: p.TopP, MaxSlides: p.MaxSlides}
+}
+
+func (s *Server) handleGetOrgSettings(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, orgSettingsResponse(org))
+}
+
+func orgSettingsResponse(org store.Organization) map[string]any {
+	defaults := store.GenerationParams{}
+	if org.GenerationDefaults != nil {
+		defaults = *org.GenerationDefaults
+	}
+	settings := store.OrgSettings{}
+	if org.Settings != nil {
+		settings = *org.Settings
+	}
+	return map[string]any{
+		"generationDefaults":     defaults,
+		"ssoDomain":              org.SSODomain,
+		"ssoDomainVerification":  ssoDomainVerification(org),
+		"exportFilenameTemplate": org.ExportFilenameTemplate,
+		"defaultRenderer":        org.DefaultRenderer,
+		"googleConnected":        org.GoogleRefreshToken != "",
+		"locale":                 settings.Locale,
+		"timezone":               settings.Timezone,
+		"language":               settings.Language,
+		"tone":                   settings.Tone,
+		"rtl":                    settings.RTL,
+		"slideSize":              settings.SlideSize,
+		"storePrompts":           settings.StorePrompts,
+		"storageRegion":          settings.StorageRegion,
+	}
+}
+
+func (s *Server) handleUpdateOrgSettings(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+
+	var req UpdateOrgSettingsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if req.ExportFilenameTemplate != nil {
+		if err := service.ValidateExportFilenameTemplate(*req.ExportFilenameTemplate); err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if req.DefaultRenderer != nil {
+		if _, err := service.NormalizeRenderer(*req.DefaultRenderer); err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if req.StorageRegion != nil {
+		if !auth.RequireRole(id, auth.RoleOwner) {
+			writeError(w, r, http.StatusForbidden, "only owners can change the storage region")
+			return
+		}
+		if *req.StorageRegion != "" && !slices.Contains(s.StorageRegions, *req.StorageRegion) {
+			writeErrorCode(w, r, http.StatusBadRequest, ErrCodeValidation, "unknown storage region", map[string]any{"storageRegions": s.StorageRegions})
+			return
+		}
+	}
+
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return
+	}
+
+	if req.SSODomain != nil && !strings.EqualFold(*req.SSODomain, org.SSODomain) {
+		domain := strings.ToLower(*req.SSODomain)
+		if status, msg := s.checkSSODomainClaim(r, id, domain); status != 0 {
+			writeError(w, r, status, msg)
+			return
+		}
+		// A new claim starts unverified; the token is what the domain's TXT
+		// record must carry for POST /v1/org/sso-domain/verify.
+		var token string
+		if domain != "" {
+			var b [16]byte
+			if _, err := rand.Read(b[:]); err != nil {
+				logger.LogError(r.Context(), "api", "update_org_settings", err)
+				writeError(w, r, http.StatusInternalServerError, "failed to update organization settings")
+				return
+			}
+			token = hex.EncodeToString(b[:])
+		}
+		org, err = s.Store.Organizations().SetSSODomain(r.Context(), id.OrgID, domain, token)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "update_org_settings", err, "org_id", id.OrgID)
+			writeError(w, r, http.StatusInternalServerError, "failed to update organization settings")
+			return
+		}
+	}
+
+	if req.GenerationDefaults != nil {
+		var defaults *store.GenerationParams
+		if params := req.GenerationDefaults.params(); !params.IsZero() {
+			defaults = &params
+		}
+		org, err = s.Store.Organizations().SetGenerationDefaults(r.Context(), id.OrgID, defaults)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "update_org_settings", err, "org_id", id.OrgID)
+			writeError(w, r, http.StatusInternalServerError, "failed to update organization settings")
+			return
+		}
+	}
+
+	if req.ExportFilenameTemplate != nil {
+		org, err = s.Store.Organizations().SetExportFilenameTemplate(r.Context(), id.OrgID, strings.TrimSpace(*req.ExportFilenameTemplate))
+		if err != nil {
+			logger.LogError(r.Context(), "api", "update_org_settings", err, "org_id", id.OrgID)
+			writeError(w, r, http.StatusInternalServerError, "failed to update organization settings")
+			return
+		}
+	}
+
+	if req.DefaultRenderer != nil {
+		org, err = s.Store.Organizations().SetDefaultRenderer(r.Context(), id.OrgID, *req.DefaultRenderer)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "update_org_settings", err, "org_id", id.OrgID)
+			writeError(w, r, http.StatusInternalServerError, "failed to update organization settings")
+			return
+		}
+	}
+
+	previousRegion := org.StorageRegion()
+	if req.settingsChanged() {
+		settings := store.OrgSettings{}
+		if org.Settings != nil {
+			settings = *org.Settings
+		}
+		req.applyTo(&settings)
+		var stored *store.OrgSettings
+		if settings != (store.OrgSettings{}) {
+			stored = &settings
+		}
+		org, err = s.Store.Organizations().SetSettings(r.Context(), id.OrgID, stored)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "update_org_settings", err, "org_id", id.OrgID)
+			writeError(w, r, http.StatusInternalServerError, "failed to update organization settings")
+			return
+		}
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "org.settings.update", TargetRef: id.OrgID})
+	if region := org.StorageRegion(); region != previousRegion {
+		_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "org.storage_region.update", TargetRef: id.OrgID, Metadata: map[string]any{"from": previousRegion, "to": region}})
+	}
+
+	writeJSON(w, http.StatusOK, orgSettingsResponse(org))
+}
+
+// settingsChanged reports whether the request sets any of the fields kept in
+// the org's OrgSettings.
+func (req UpdateOrgSettingsRequest) settingsChanged() bool {
+	return req.Locale != nil || req.Timezone != nil || req.Language != nil || req.Tone != nil ||
+		req.RTL != nil || req.SlideSize != nil || req.StorePrompts != nil || req.StorageRegion != nil
+}
+
+// applyTo copies the settings fields the request sets onto settings.
+func (req UpdateOrgSettingsRequest) applyTo(settings *store.OrgSettings) {
+	if req.Locale != nil {
+		settings.Locale = strings.TrimSpace(*req.Locale)
+	}
+	if req.Timezone != nil {
+		settings.Timezone = strings.TrimSpace(*req.Timezone)
+	}
+	if req.Language != nil {
+		settings.Language = strings.TrimSpace(*req.Language)
+	}
+	if req.Tone != nil {
+		settings.Tone = strings.TrimSpace(*req.Tone)
+	}
+	if req.SlideSize != nil {
+		settings.SlideSize = *req.SlideSize
+	}
+	if req.RTL != nil {
+		settings.RTL = *req.RTL
+	}
+	if req.StorePrompts != nil {
+		settings.StorePrompts = *req.StorePrompts
+	}
+	if req.StorageRegion != nil {
+		settings.StorageRegion = *req.StorageRegion
+	}
+}
+
+// orgFromPath loads the org named in the path, which must be the caller's
+// own; other orgs are reported as not found.
+func (s *Server) orgFromPath(w http.ResponseWriter, r *http.Request, id auth.Identity) (store.Organization, bool) {
+	if r.PathValue("id") != id.OrgID {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return store.Organization{}, false
+	}
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return store.Organization{}, false
+	}
+	return org, true
+}
+
+// checkSSODomainClaim guards domain mapping: only owners may claim a domain,
+// it can't be a public mail provider's, it must match their own account
+// email, and another org can't have verified it. The claim still maps no
+// one until the org verifies it through DNS. A zero status means the claim
+// is allowed.
+func (s *Server) checkSSODomainClaim(r *http.Request, id auth.Identity, domain string) (int, string) {
+	if !auth.RequireRole(id, auth.RoleOwner) {
+		return http.StatusForbidden, "only owners can change the SSO domain"
+	}
+	domain = strings.ToLower(domain)
+	if domain == "" {
+		return 0, ""
+	}
+	if auth.IsFreeMailDomain(domain) {
+		return http.StatusBadRequest, "public email domains can't be used for SSO"
+	}
+	user, ok, err := s.Store.Users().GetUser(r.Context(), id.UserID)
+	if err != nil || !ok {
+		return http.StatusForbidden, "cannot verify ownership of domain"
+	}
+	if auth.EmailDomain(user.Email) != domain {
+		return http.StatusForbidden, "SSO domain must match your email domain"
+	}
+	other, found, err := s.Store.Organizations().GetOrganizationBySSODomain(r.Context(), domain)
+	if err != nil {
+		return http.StatusInternalServerError, "failed to check SSO domain"
+	}
+	if found && other.ID != id.OrgID {
+		return http.StatusConflict, "SSO domain is already claimed by another organization"
+	}
+	return 0, ""
+}
+
+// ssoDomainRecordPrefix is prepended to a claimed SSO domain to name the
+// DNS TXT record that proves the claim.
+const ssoDomainRecordPrefix = "_cmsai-verification."
+
+// SSODomainVerification tells an owner which DNS TXT record proves their
+// org's SSO domain claim, and whether it has been seen.
+type SSODomainVerification struct {
+	Record     string     `json:"record"`
+	Value      string     `json:"value"`
+	Verified   bool       `json:"verified"`
+	VerifiedAt *time.Time `json:"verifiedAt,omitempty"`
+}
+
+func ssoDomainVerification(org store.Organization) *SSODomainVerification {
+	if org.SSODomain == "" {
+		return nil
+	}
+	return &SSODomainVerification{
+		Record:     ssoDomainRecordPrefix + org.SSODomain,
+		Value:      "cmsai-domain-verification=" + org.SSODomainToken,
+		Verified:   org.SSODomainVerifiedAt != nil,
+		VerifiedAt: org.SSODomainVerifiedAt,
+	}
+}
+
+// handleVerifySSODomain handles POST /v1/org/sso-domain/verify: it looks up
+// the claimed domain's TXT record and, if it carries the claim's token,
+// starts mapping the domain's SSO users into the org.
+func (s *Server) handleVerifySSODomain(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleOwner) {
+		writeError(w, r, http.StatusForbidden, "only owners can verify the SSO domain")
+		return
+	}
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return
+	}
+	if org.SSODomain == "" {
+		writeError(w, r, http.StatusBadRequest, "no SSO domain is claimed")
+		return
+	}
+	if org.SSODomainVerifiedAt != nil {
+		writeJSON(w, http.StatusOK, orgSettingsResponse(org))
+		return
+	}
+
+	v := ssoDomainVerification(org)
+	lookup := s.LookupTXT
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupTXT
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	records, err := lookup(ctx, v.Record)
+	if err != nil {
+		logger.WithContext(r.Context()).Info("sso_domain_lookup_failed", "org_id", id.OrgID, "record", v.Record, "error", err.Error())
+	}
+	if !slices.Contains(records, v.Value) {
+		writeError(w, r, http.StatusUnprocessableEntity, "TXT record "+v.Record+" does not contain "+v.Value+" yet")
+		return
+	}
+	// Another org may have verified the domain since this one claimed it.
+	if other, found, err := s.Store.Organizations().GetOrganizationBySSODomain(r.Context(), org.SSODomain); err != nil {
+		writeError(w, r, http.StatusInternalServerError, "failed to check SSO domain")
+		return
+	} else if found && other.ID != org.ID {
+		writeError(w, r, http.StatusConflict, "SSO domain is already claimed by another organization")
+		return
+	}
+
+	org, err = s.Store.Organizations().VerifySSODomain(r.Context(), id.OrgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "verify_sso_domain", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to verify SSO domain")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "org.sso_domain.verify", TargetRef: id.OrgID, Metadata: map[string]any{"domain": org.SSODomain}})
+	writeJSON(w, http.StatusOK, orgSettingsResponse(org))
+}
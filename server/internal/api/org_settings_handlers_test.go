@@ -0,0 +1,308 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestGenerateTemplate_MergesOrgGenerationDefaults(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(context.Background(), &store.Organization{ID: "org-1", Name: "Org"}))
+
+	// Editors can't change org defaults.
+	body := []byte(`{"generationDefaults":{"temperature":0.9,"topP":0.8,"maxSlides":8}}`)
+	req := httptest.NewRequest(http.MethodPut, "/v1/org/settings", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	req = httptest.NewRequest(http.MethodPut, "/v1/org/settings", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	addTestAuth(req, "user-1", "org-1", auth.RoleAdmin)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// The request's temperature overrides the default; the rest is inherited.
+	genBody := []byte(`{"prompt":"Quarterly results for the board","temperature":0.3}`)
+	req = httptest.NewRequest(http.MethodPost, "/v1/templates/generate", bytes.NewReader(genBody))
+	req.Header.Set("Content-Type", "application/json")
+	addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var resp struct {
+		Job store.Job `json:"job"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Job.Metadata)
+	var params store.GenerationParams
+	require.NoError(t, json.Unmarshal([]byte((*resp.Job.Metadata)["generationParams"]), &params))
+	assert.Equal(t, 0.3, *params.Temperature)
+	assert.Equal(t, 0.8, *params.TopP)
+	assert.Equal(t, 8, *params.MaxSlides)
+}
+
+func TestGenerateTemplate_RejectsOutOfRangeParams(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+
+	for _, body := range []string{
+		`{"prompt":"Quarterly results for the board","temperature":2.5}`,
+		`{"prompt":"Quarterly results for the board","topP":0}`,
+		`{"prompt":"Quarterly results for the board","maxSlides":0}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/templates/generate", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestExportFilenameTemplate_AppliedToDeckExports(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Org"}))
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", Name: "Q3: Board/Review"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: "deck-1", OrgID: "org-1", VersionNo: 3})
+	require.NoError(t, err)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/v1/org/settings", bytes.NewReader([]byte(body)))
+		addTestAuth(req, "user-1", "org-1", auth.RoleAdmin)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	w := put(`{"exportFilenameTemplate":"{deckName}-v{versionNo}-{author}"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "{author}")
+	w = put(`{"exportFilenameTemplate":"{deckName}-v{versionNo}.pptx"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"exportFilenameTemplate":"{deckName}-v{versionNo}.pptx"`)
+
+	for format, want := range map[string]string{"pptx": "Q3_ Board_Review-v3.pptx", "bundle": "Q3_ Board_Review-v3.zip"} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/deck-versions/dv-1/export?format="+format, nil)
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		var resp struct {
+			Job store.Job `json:"job"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Job.Metadata)
+		assert.Equal(t, want, (*resp.Job.Metadata)["filename"])
+	}
+}
+
+func TestDefaultRenderer_AppliedToDeckExports(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Org"}))
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", Name: "Deck"})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: "deck-1", OrgID: "org-1", VersionNo: 1})
+	require.NoError(t, err)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/v1/org/settings", bytes.NewReader([]byte(body)))
+		addTestAuth(req, "user-1", "org-1", auth.RoleAdmin)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusBadRequest, put(`{"defaultRenderer":"latex"}`).Code)
+	w := put(`{"defaultRenderer":"go"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"defaultRenderer":"go"`)
+
+	export := func(query string) (int, store.JSONMap) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/deck-versions/dv-1/export"+query, nil)
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		var resp struct {
+			Job store.Job `json:"job"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Job.Metadata == nil {
+			return w.Code, nil
+		}
+		return w.Code, *resp.Job.Metadata
+	}
+	code, md := export("")
+	require.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, "go", md["renderer"])
+	_, md = export("?renderer=python")
+	assert.Equal(t, "python", md["renderer"], "the request overrides the org default")
+	_, md = export("?quality=draft")
+	assert.NotContains(t, md, "renderer", "draft exports keep the draft renderer")
+	code, _ = export("?renderer=latex")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	require.Equal(t, http.StatusOK, put(`{"defaultRenderer":""}`).Code)
+	_, md = export("")
+	assert.NotContains(t, md, "renderer")
+}
+
+func TestOrgSettings_GenerationSettings(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(context.Background(), &store.Organization{ID: "org-1", Name: "Org"}))
+
+	do := func(method, path, body string, role auth.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	type settingsBody struct {
+		store.OrgSettings
+		DefaultRenderer string `json:"defaultRenderer"`
+	}
+	settings := func(w *httptest.ResponseRecorder) settingsBody {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp settingsBody
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	assert.Equal(t, settingsBody{}, settings(do(http.MethodGet, "/v1/org/settings", "", auth.RoleViewer)))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPut, "/v1/org/settings", `{"tone":"Playful"}`, auth.RoleEditor).Code)
+	for _, body := range []string{`{"timezone":"Mars/Olympus"}`, `{"locale":"not a locale"}`, `{"slideSize":"21:9"}`, `{"defaultRenderer":"crayon"}`} {
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/v1/org/settings", body, auth.RoleAdmin).Code, body)
+	}
+
+	got := settings(do(http.MethodPut, "/v1/org/settings", `{"locale":"ar-SA","timezone":"Asia/Riyadh","language":"Arabic","tone":"Formal","rtl":true,"slideSize":"4:3","defaultRenderer":"python"}`, auth.RoleAdmin))
+	assert.Equal(t, store.OrgSettings{Locale: "ar-SA", Timezone: "Asia/Riyadh", Language: "Arabic", Tone: "Formal", RTL: true, SlideSize: "4:3"}, got.OrgSettings)
+	assert.Equal(t, "python", got.DefaultRenderer)
+
+	// Only the fields sent change.
+	got = settings(do(http.MethodPut, "/v1/org/settings", `{"tone":""}`, auth.RoleAdmin))
+	assert.Equal(t, "", got.Tone)
+	assert.Equal(t, "Arabic", got.Language)
+	assert.Equal(t, got, settings(do(http.MethodGet, "/v1/org/settings", "", auth.RoleViewer)))
+
+	// Generation requests take what they leave unset from the settings.
+	job := func(body string) store.JSONMap {
+		w := do(http.MethodPost, "/v1/templates/generate", body, auth.RoleEditor)
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		var resp struct {
+			Job store.Job `json:"job"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Job.Metadata)
+		return *resp.Job.Metadata
+	}
+	m := job(`{"prompt":"Quarterly results for the board","tone":"Upbeat"}`)
+	assert.Equal(t, "Arabic", m["language"])
+	assert.Equal(t, "Upbeat", m["tone"])
+	assert.Equal(t, "true", m["rtl"])
+	assert.Equal(t, "4:3", m["slideSize"])
+	m = job(`{"prompt":"Quarterly results for the board","language":"English","rtl":false}`)
+	assert.Equal(t, "English", m["language"])
+	assert.Equal(t, "false", m["rtl"])
+}
+
+func TestOrgSettings_StorePromptsInAudit(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Org"}))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, "user-1", "org-1", auth.RoleAdmin)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	generated := func() map[string]any {
+		w := do(http.MethodPost, "/v1/templates/generate", `{"prompt":"Onboarding deck, questions to hr@example.com"}`)
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		logs, err := s.Store.Audit().List(ctx, "org-1")
+		require.NoError(t, err)
+		for i := len(logs) - 1; i >= 0; i-- {
+			if logs[i].Action == "template.generate.queued" {
+				return logs[i].Metadata.(map[string]any)
+			}
+		}
+		t.Fatal("no template.generate.queued audit entry")
+		return nil
+	}
+
+	assert.NotContains(t, generated(), "prompt")
+
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/v1/org/settings", `{"storePrompts":true}`).Code)
+	assert.Equal(t, "Onboarding deck, questions to [REDACTED]", generated()["prompt"])
+}
+
+func TestOrgSettings_StorageRegionRoutesUploads(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Org"}))
+	def, err := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	require.NoError(t, err)
+	eu, err := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	require.NoError(t, err)
+	router := assets.NewStorageRouter(def)
+	router.Add("eu", eu)
+	s.ObjectStorage, s.StorageRegions = router, router.Regions()
+
+	put := func(body string, role auth.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/v1/org/settings", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusForbidden, put(`{"storageRegion":"eu"}`, auth.RoleAdmin).Code)
+	assert.Equal(t, http.StatusBadRequest, put(`{"storageRegion":"mars"}`, auth.RoleOwner).Code)
+	w := put(`{"storageRegion":"eu"}`, auth.RoleOwner)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"storageRegion":"eu"`)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, uploadFontRequest(t, "BrandSans.ttf", fontFile("Brand Sans")))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	list, _, err := s.Store.Assets().List(ctx, "org-1", store.AssetFilter{})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "eu", list[0].Region)
+	inEU, err := eu.Exists(ctx, list[0].Path)
+	require.NoError(t, err)
+	assert.True(t, inEU)
+	inDefault, err := def.Exists(ctx, list[0].Path)
+	require.NoError(t, err)
+	assert.False(t, inDefault)
+
+	logs, err := s.Store.Audit().List(ctx, "org-1")
+	require.NoError(t, err)
+	assert.True(t, slices.ContainsFunc(logs, func(l store.AuditLog) bool { return l.Action == "org.storage_region.update" }))
+}
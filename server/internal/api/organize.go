@@ -0,0 +1,568 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// folderNone is the folder filter value for resources not in any folder.
+const folderNone = "none"
+
+type taggedTemplate struct {
+	store.TemplateWithVersion
+	Tags []store.Tag `json:"tags"`
+}
+
+type taggedDeck struct {
+	store.DeckWithSummary
+	Tags []store.Tag `json:"tags"`
+}
+
+// listFilter narrows template and deck listings by folder, tags, category
+// and a free text query matched against names, descriptions and tag names.
+type listFilter struct {
+	folder   string
+	tags     []string
+	category string
+	q        string
+}
+
+func parseListFilter(r *http.Request) listFilter {
+	q := r.URL.Query()
+	return listFilter{
+		folder:   q.Get("folder"),
+		tags:     q["tag"],
+		category: q.Get("category"),
+		q:        strings.ToLower(strings.TrimSpace(q.Get("q"))),
+	}
+}
+
+func (f listFilter) match(name string, l store.Listing, folderID *string, tags []store.Tag) bool {
+	switch {
+	case f.folder == folderNone:
+		if folderID != nil {
+			return false
+		}
+	case f.folder != "":
+		if folderID == nil || *folderID != f.folder {
+			return false
+		}
+	}
+	if f.category != "" && !strings.EqualFold(l.Category, f.category) {
+		return false
+	}
+	for _, want := range f.tags {
+		found := false
+		for _, t := range tags {
+			if t.ID == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.q == "" || strings.Contains(strings.ToLower(name), f.q) || strings.Contains(strings.ToLower(l.Description), f.q) {
+		return true
+	}
+	for _, t := range tags {
+		if strings.Contains(strings.ToLower(t.Name), f.q) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyListing copies the fields present in req onto l. A cover must be an
+// image asset in the caller's org; if it isn't, the error has been written
+// and applyListing returns false.
+func (s *Server) applyListing(w http.ResponseWriter, r *http.Request, id auth.Identity, req ListingRequest, l *store.Listing) bool {
+	if req.CoverAssetID != nil {
+		if *req.CoverAssetID == "" {
+			l.CoverAssetID = nil
+		} else {
+			a, ok, err := s.Store.Assets().Get(r.Context(), id.OrgID, *req.CoverAssetID)
+			if err != nil {
+				logger.LogError(r.Context(), "api", "load_cover_asset", err, "asset_id", *req.CoverAssetID)
+				writeError(w, r, http.StatusInternalServerError, "failed to load cover asset")
+				return false
+			}
+			if !ok {
+				writeError(w, r, http.StatusBadRequest, "cover asset not found")
+				return false
+			}
+			if !strings.HasPrefix(a.Mime, "image/") {
+				writeError(w, r, http.StatusBadRequest, "cover asset must be an image")
+				return false
+			}
+			l.CoverAssetID = &a.ID
+		}
+	}
+	if req.Description != nil {
+		l.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Category != nil {
+		l.Category = strings.TrimSpace(*req.Category)
+	}
+	if req.Language != nil {
+		l.Language = *req.Language
+	}
+	return true
+}
+
+// nonNilTags keeps untagged resources serializing as [] rather than null.
+func nonNilTags(tags []store.Tag) []store.Tag {
+	if tags == nil {
+		return []store.Tag{}
+	}
+	return tags
+}
+
+func (s *Server) handleListFolders(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	folders, err := s.Store.Folders().ListFolders(r.Context(), id.OrgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_folders", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list folders")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"folders": folders})
+}
+
+func (s *Server) handleCreateFolder(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	var req CreateFolderRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	f := store.Folder{ID: newID("fld"), OrgID: id.OrgID, Name: req.Name, CreatedBy: id.UserID}
+	if req.ParentID != "" {
+		if _, ok, err := s.Store.Folders().GetFolder(r.Context(), id.OrgID, req.ParentID); err != nil {
+			logger.LogError(r.Context(), "api", "create_folder", err)
+			writeError(w, r, http.StatusInternalServerError, "failed to load parent folder")
+			return
+		} else if !ok {
+			writeError(w, r, http.StatusBadRequest, "parent folder not found")
+			return
+		}
+		f.ParentID = &req.ParentID
+	}
+
+	created, err := s.Store.Folders().CreateFolder(r.Context(), f)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "create_folder", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to create folder")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "folder.create", TargetRef: created.ID})
+	writeJSON(w, http.StatusCreated, map[string]any{"folder": created})
+}
+
+func (s *Server) handleUpdateFolder(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	var req UpdateFolderRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	folders, err := s.Store.Folders().ListFolders(r.Context(), id.OrgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "update_folder", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load folders")
+		return
+	}
+	byID := make(map[string]store.Folder, len(folders))
+	for _, f := range folders {
+		byID[f.ID] = f
+	}
+	f, ok := byID[r.PathValue("id")]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+
+	if req.Name != nil {
+		f.Name = *req.Name
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == "" {
+			f.ParentID = nil
+		} else {
+			// Walk up from the new parent; reaching f would create a cycle.
+			for cur := *req.ParentID; cur != ""; {
+				if cur == f.ID {
+					writeError(w, r, http.StatusBadRequest, "a folder cannot be moved into itself or its subfolders")
+					return
+				}
+				parent, ok := byID[cur]
+				if !ok {
+					writeError(w, r, http.StatusBadRequest, "parent folder not found")
+					return
+				}
+				cur = ""
+				if parent.ParentID != nil {
+					cur = *parent.ParentID
+				}
+			}
+			f.ParentID = req.ParentID
+		}
+	}
+
+	updated, err := s.Store.Folders().UpdateFolder(r.Context(), f)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "update_folder", err, "folder_id", f.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to update folder")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "folder.update", TargetRef: f.ID})
+	writeJSON(w, http.StatusOK, map[string]any{"folder": updated})
+}
+
+// handleDeleteFolder only removes empty folders so nothing is silently
+// unfiled or orphaned.
+func (s *Server) handleDeleteFolder(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+	folderID := r.PathValue("id")
+
+	inUse, err := s.folderInUse(r.Context(), id.OrgID, folderID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "delete_folder", err, "folder_id", folderID)
+		writeError(w, r, http.StatusInternalServerError, "failed to check folder contents")
+		return
+	}
+	if inUse {
+		writeError(w, r, http.StatusConflict, "folder is not empty")
+		return
+	}
+
+	deleted, err := s.Store.Folders().DeleteFolder(r.Context(), id.OrgID, folderID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "delete_folder", err, "folder_id", folderID)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete folder")
+		return
+	}
+	if !deleted {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "folder.delete", TargetRef: folderID})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) folderInUse(ctx context.Context, orgID, folderID string) (bool, error) {
+	folders, err := s.Store.Folders().ListFolders(ctx, orgID)
+	if err != nil {
+		return false, err
+	}
+	for _, f := range folders {
+		if f.ParentID != nil && *f.ParentID == folderID {
+			return true, nil
+		}
+	}
+	tpls, err := s.Store.Templates().ListTemplates(ctx, orgID)
+	if err != nil {
+		return false, err
+	}
+	for _, t := range tpls {
+		if t.FolderID != nil && *t.FolderID == folderID {
+			return true, nil
+		}
+	}
+	decks, err := s.Store.Decks().ListDecks(ctx, orgID)
+	if err != nil {
+		return false, err
+	}
+	for _, d := range decks {
+		if d.FolderID != nil && *d.FolderID == folderID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Server) handleListTags(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	tags, err := s.Store.Tags().ListTags(r.Context(), id.OrgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_tags", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list tags")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tags": tags})
+}
+
+// tagNameTaken reports whether another tag in the org already uses name,
+// ignoring case.
+func (s *Server) tagNameTaken(ctx context.Context, orgID, name, exceptID string) (bool, error) {
+	tags, err := s.Store.Tags().ListTags(ctx, orgID)
+	if err != nil {
+		return false, err
+	}
+	for _, t := range tags {
+		if t.ID != exceptID && strings.EqualFold(t.Name, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Server) handleCreateTag(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	var req CreateTagRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	if taken, err := s.tagNameTaken(r.Context(), id.OrgID, req.Name, ""); err != nil {
+		logger.LogError(r.Context(), "api", "create_tag", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to create tag")
+		return
+	} else if taken {
+		writeError(w, r, http.StatusConflict, "tag already exists")
+		return
+	}
+
+	created, err := s.Store.Tags().CreateTag(r.Context(), store.Tag{ID: newID("tag"), OrgID: id.OrgID, Name: req.Name, Color: req.Color})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "create_tag", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to create tag")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "tag.create", TargetRef: created.ID, Metadata: map[string]any{"name": created.Name}})
+	writeJSON(w, http.StatusCreated, map[string]any{"tag": created})
+}
+
+func (s *Server) handleUpdateTag(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	var req UpdateTagRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if req.Name != nil {
+		trimmed := strings.TrimSpace(*req.Name)
+		req.Name = &trimmed
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	t, ok, err := s.Store.Tags().GetTag(r.Context(), id.OrgID, r.PathValue("id"))
+	if err != nil {
+		logger.LogError(r.Context(), "api", "update_tag", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load tag")
+		return
+	}
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	if req.Name != nil {
+		if taken, err := s.tagNameTaken(r.Context(), id.OrgID, *req.Name, t.ID); err != nil {
+			logger.LogError(r.Context(), "api", "update_tag", err)
+			writeError(w, r, http.StatusInternalServerError, "failed to update tag")
+			return
+		} else if taken {
+			writeError(w, r, http.StatusConflict, "tag already exists")
+			return
+		}
+		t.Name = *req.Name
+	}
+	if req.Color != nil {
+		t.Color = *req.Color
+	}
+
+	updated, err := s.Store.Tags().UpdateTag(r.Context(), t)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "update_tag", err, "tag_id", t.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to update tag")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "tag.update", TargetRef: t.ID})
+	writeJSON(w, http.StatusOK, map[string]any{"tag": updated})
+}
+
+func (s *Server) handleDeleteTag(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+	tagID := r.PathValue("id")
+
+	deleted, err := s.Store.Tags().DeleteTag(r.Context(), id.OrgID, tagID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "delete_tag", err, "tag_id", tagID)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete tag")
+		return
+	}
+	if !deleted {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "tag.delete", TargetRef: tagID})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleAttachTag(rt store.ResourceType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, _ := auth.GetIdentity(r.Context())
+		resourceID := r.PathValue("id")
+		tagID := r.PathValue("tagId")
+		if _, ok := s.authorizeResource(w, r, id, rt, resourceID, store.PermissionEdit); !ok {
+			return
+		}
+
+		if _, ok, err := s.Store.Tags().GetTag(r.Context(), id.OrgID, tagID); err != nil {
+			logger.LogError(r.Context(), "api", "attach_tag", err, "tag_id", tagID)
+			writeError(w, r, http.StatusInternalServerError, "failed to load tag")
+			return
+		} else if !ok {
+			writeError(w, r, http.StatusNotFound, "tag not found")
+			return
+		}
+
+		if err := s.Store.Tags().Attach(r.Context(), store.ResourceTag{OrgID: id.OrgID, TagID: tagID, ResourceType: rt, ResourceID: resourceID}); err != nil {
+			logger.LogError(r.Context(), "api", "attach_tag", err, "resource_id", resourceID)
+			writeError(w, r, http.StatusInternalServerError, "failed to attach tag")
+			return
+		}
+		_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: string(rt) + ".tag.attach", TargetRef: resourceID, Metadata: map[string]any{"tagId": tagID}})
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) handleDetachTag(rt store.ResourceType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, _ := auth.GetIdentity(r.Context())
+		resourceID := r.PathValue("id")
+		tagID := r.PathValue("tagId")
+		if _, ok := s.authorizeResource(w, r, id, rt, resourceID, store.PermissionEdit); !ok {
+			return
+		}
+
+		removed, err := s.Store.Tags().Detach(r.Context(), id.OrgID, tagID, rt, resourceID)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "detach_tag", err, "resource_id", resourceID)
+			writeError(w, r, http.StatusInternalServerError, "failed to detach tag")
+			return
+		}
+		if !removed {
+			writeError(w, r, http.StatusNotFound, "tag not attached")
+			return
+		}
+		_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: string(rt) + ".tag.detach", TargetRef: resourceID, Metadata: map[string]any{"tagId": tagID}})
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) handleMoveToFolder(rt store.ResourceType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, _ := auth.GetIdentity(r.Context())
+		resourceID := r.PathValue("id")
+		if _, ok := s.authorizeResource(w, r, id, rt, resourceID, store.PermissionEdit); !ok {
+			return
+		}
+
+		var req MoveToFolderRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			writeInvalidJSON(w, r)
+			return
+		}
+		var folderID *string
+		if req.FolderID != "" {
+			if _, ok, err := s.Store.Folders().GetFolder(r.Context(), id.OrgID, req.FolderID); err != nil {
+				logger.LogError(r.Context(), "api", "move_to_folder", err, "folder_id", req.FolderID)
+				writeError(w, r, http.StatusInternalServerError, "failed to load folder")
+				return
+			} else if !ok {
+				writeError(w, r, http.StatusBadRequest, "folder not found")
+				return
+			}
+			folderID = &req.FolderID
+		}
+
+		if err := s.setResourceFolder(r.Context(), id, rt, resourceID, folderID); err != nil {
+			logger.LogError(r.Context(), "api", "move_to_folder", err, "resource_id", resourceID)
+			writeError(w, r, http.StatusInternalServerError, "failed to move resource")
+			return
+		}
+		_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: string(rt) + ".move", TargetRef: resourceID, Metadata: map[string]any{"folderId": req.FolderID}})
+		writeJSON(w, http.StatusOK, map[string]any{"folderId": folderID})
+	}
+}
+
+func (s *Server) setResourceFolder(ctx context.Context, id auth.Identity, rt store.ResourceType, resourceID string, folderID *string) error {
+	if rt == store.ResourceDeck {
+		d, ok, err := s.Store.Decks().GetDeck(ctx, id.OrgID, resourceID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("deck %s not found", resourceID)
+		}
+		d.FolderID = folderID
+		_, err = s.Store.Decks().UpdateDeck(ctx, d)
+		return err
+	}
+	tpl, ok, err := s.Store.Templates().GetTemplate(ctx, id.OrgID, resourceID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("template %s not found", resourceID)
+	}
+	tpl.FolderID = folderID
+	_, err = s.Store.Templates().UpdateTemplate(ctx, tpl)
+	return err
+}
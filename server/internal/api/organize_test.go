@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestOrganize_TagsAndFoldersFilterDeckList(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+	for _, d := range []store.Deck{
+		{ID: "deck-q3", OrgID: "org-1", Name: "Q3 Sales"},
+		{ID: "deck-q4", OrgID: "org-1", Name: "Q4 Sales"},
+		{ID: "deck-hr", OrgID: "org-1", Name: "Onboarding"},
+	} {
+		_, err := s.Store.Decks().CreateDeck(ctx, d)
+		require.NoError(t, err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	createID := func(path, body, key string) string {
+		w := do(http.MethodPost, path, body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var resp map[string]map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp[key]["id"].(string)
+	}
+	listDecks := func(query string) []string {
+		w := do(http.MethodGet, "/v1/decks"+query, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Decks []taggedDeck `json:"decks"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		ids := []string{}
+		for _, d := range resp.Decks {
+			ids = append(ids, d.ID)
+		}
+		return ids
+	}
+
+	finance := createID("/v1/tags", `{"name":"Finance","color":"#00aa00"}`, "tag")
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/v1/tags", `{"name":"finance"}`).Code)
+	quarterly := createID("/v1/tags", `{"name":"Quarterly"}`, "tag")
+
+	sales := createID("/v1/folders", `{"name":"Sales"}`, "folder")
+	archive := createID("/v1/folders", `{"name":"Archive","parentId":"`+sales+`"}`, "folder")
+
+	require.Equal(t, http.StatusNoContent, do(http.MethodPut, "/v1/decks/deck-q3/tags/"+finance, "").Code)
+	require.Equal(t, http.StatusNoContent, do(http.MethodPut, "/v1/decks/deck-q3/tags/"+quarterly, "").Code)
+	require.Equal(t, http.StatusNoContent, do(http.MethodPut, "/v1/decks/deck-q4/tags/"+quarterly, "").Code)
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/v1/decks/deck-q3/folder", `{"folderId":"`+archive+`"}`).Code)
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/v1/decks/deck-q4/folder", `{"folderId":"`+sales+`"}`).Code)
+
+	assert.ElementsMatch(t, []string{"deck-q3", "deck-q4"}, listDecks("?tag="+quarterly))
+	assert.Equal(t, []string{"deck-q3"}, listDecks("?tag="+quarterly+"&tag="+finance))
+	assert.Equal(t, []string{"deck-q4"}, listDecks("?folder="+sales))
+	assert.Equal(t, []string{"deck-hr"}, listDecks("?folder=none"))
+	// Free-text search matches tag names as well as deck names.
+	assert.Equal(t, []string{"deck-q3"}, listDecks("?q=finance"))
+	assert.ElementsMatch(t, []string{"deck-q3", "deck-q4"}, listDecks("?q=sales"))
+
+	// Folders can't be moved under their own subtree or deleted while in use.
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPatch, "/v1/folders/"+sales, `{"parentId":"`+archive+`"}`).Code)
+	assert.Equal(t, http.StatusConflict, do(http.MethodDelete, "/v1/folders/"+archive, "").Code)
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/v1/decks/deck-q3/folder", `{"folderId":""}`).Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/v1/folders/"+archive, "").Code)
+
+	// Deleting a tag detaches it everywhere.
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/v1/tags/"+quarterly, "").Code)
+	assert.Empty(t, listDecks("?tag="+quarterly))
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/v1/decks/deck-q4/tags/"+quarterly, "").Code)
+}
+
+func TestOrganize_ViewersCannotOrganize(t *testing.T) {
+	s := NewServer()
+	_, err := s.Store.Decks().CreateDeck(context.Background(), store.Deck{ID: "deck-1", OrgID: "org-1", Name: "Deck"})
+	require.NoError(t, err)
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodPost, "/v1/tags", `{"name":"x"}`},
+		{http.MethodPost, "/v1/folders", `{"name":"x"}`},
+		{http.MethodPut, "/v1/decks/deck-1/folder", `{"folderId":""}`},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		addTestAuth(req, "viewer", "org-1", auth.RoleViewer)
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code, tc.path)
+	}
+}
+
+func TestListing_TemplateMetadataAndCategoryFilter(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+	for _, a := range []store.Asset{
+		{ID: "asset-cover", OrgID: "org-1", Type: store.AssetImage, Mime: "image/png"},
+		{ID: "asset-pptx", OrgID: "org-1", Type: store.AssetPPTX, Mime: "application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+		{ID: "asset-other-org", OrgID: "org-2", Type: store.AssetImage, Mime: "image/png"},
+	} {
+		_, err := s.Store.Assets().Create(ctx, a)
+		require.NoError(t, err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	template := func(w *httptest.ResponseRecorder) store.Template {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Template store.Template `json:"template"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Template
+	}
+	listTemplates := func(query string) []string {
+		w := do(http.MethodGet, "/v1/templates"+query, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Templates []taggedTemplate `json:"templates"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		names := []string{}
+		for _, t := range resp.Templates {
+			names = append(names, t.Name)
+		}
+		return names
+	}
+
+	pitch := template(do(http.MethodPost, "/v1/templates", `{"name":"Pitch","description":" Investor pitch deck ","category":"Sales","language":"en-US","coverAssetId":"asset-cover"}`))
+	assert.Equal(t, "Investor pitch deck", pitch.Description)
+	assert.Equal(t, "Sales", pitch.Category)
+	assert.Equal(t, "en-US", pitch.Language)
+	require.NotNil(t, pitch.CoverAssetID)
+	assert.Equal(t, "asset-cover", *pitch.CoverAssetID)
+	template(do(http.MethodPost, "/v1/templates", `{"name":"Onboarding","category":"HR"}`))
+
+	for body, want := range map[string]int{
+		`{"name":"Bad","language":"not a language"}`:      http.StatusBadRequest,
+		`{"name":"Bad","coverAssetId":"asset-pptx"}`:      http.StatusBadRequest,
+		`{"name":"Bad","coverAssetId":"asset-missing"}`:   http.StatusBadRequest,
+		`{"name":"Bad","coverAssetId":"asset-other-org"}`: http.StatusBadRequest,
+	} {
+		assert.Equal(t, want, do(http.MethodPost, "/v1/templates", body).Code, body)
+	}
+
+	assert.Equal(t, []string{"Pitch"}, listTemplates("?category=sales"))
+	assert.Equal(t, []string{"Onboarding"}, listTemplates("?category=HR"))
+	assert.Equal(t, []string{"Pitch"}, listTemplates("?q=investor"))
+
+	// PATCH changes only what it's given; "" clears a field.
+	updated := template(do(http.MethodPatch, "/v1/templates/"+pitch.ID, `{"category":"Marketing","coverAssetId":""}`))
+	assert.Equal(t, "Pitch", updated.Name)
+	assert.Equal(t, "Investor pitch deck", updated.Description)
+	assert.Equal(t, "Marketing", updated.Category)
+	assert.Nil(t, updated.CoverAssetID)
+	assert.Empty(t, listTemplates("?category=sales"))
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPatch, "/v1/templates/"+pitch.ID, `{"name":"x"}`).Code)
+}
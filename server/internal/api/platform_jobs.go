@@ -0,0 +1,179 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const (
+	defaultPlatformJobPageSize = 100
+	maxPlatformJobPageSize     = 500
+)
+
+// platformJobSkip explains why a job in a bulk request was left alone.
+type platformJobSkip struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// requeueableStatuses are the states a platform admin may push back onto the
+// queue. Queued and running jobs are already in flight and done jobs have an
+// output that requeueing would orphan.
+var requeueableStatuses = map[store.JobStatus]bool{
+	store.JobFailed:     true,
+	store.JobRetry:      true,
+	store.JobDeadLetter: true,
+}
+
+func requirePlatformAdmin(w http.ResponseWriter, r *http.Request) (auth.Identity, bool) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !id.PlatformAdmin {
+		writeError(w, r, http.StatusForbidden, "platform admin required")
+		return id, false
+	}
+	return id, true
+}
+
+// handleListPlatformJobs handles GET /v1/admin/platform/jobs. Unlike the DLQ
+// endpoints it is not scoped to the caller's org.
+func (s *Server) handleListPlatformJobs(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePlatformAdmin(w, r); !ok {
+		return
+	}
+	q := r.URL.Query()
+	filter := store.JobFilter{
+		OrgID:  q.Get("orgId"),
+		Status: store.JobStatus(q.Get("status")),
+		Type:   store.JobType(q.Get("type")),
+		Limit:  defaultPlatformJobPageSize,
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, r, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		filter.Limit = min(n, maxPlatformJobPageSize)
+	}
+
+	jobs, err := s.Store.Jobs().List(r.Context(), filter)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_platform_jobs", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list jobs")
+		return
+	}
+	if jobs == nil {
+		jobs = []store.Job{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"jobs": jobs})
+}
+
+// handleRequeuePlatformJobs handles POST /v1/admin/platform/jobs/requeue.
+// Jobs that can't be requeued are reported rather than failing the batch.
+func (s *Server) handleRequeuePlatformJobs(w http.ResponseWriter, r *http.Request) {
+	id, ok := requirePlatformAdmin(w, r)
+	if !ok {
+		return
+	}
+	var req PlatformJobsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	jobs, err := s.Store.Jobs().List(r.Context(), store.JobFilter{IDs: req.JobIDs})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "requeue_platform_jobs", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load jobs")
+		return
+	}
+	byID := make(map[string]store.Job, len(jobs))
+	for _, j := range jobs {
+		byID[j.ID] = j
+	}
+
+	requeued := []string{}
+	skipped := []platformJobSkip{}
+	seen := map[string]bool{}
+	for _, jobID := range req.JobIDs {
+		if seen[jobID] {
+			continue
+		}
+		seen[jobID] = true
+		job, ok := byID[jobID]
+		if !ok {
+			skipped = append(skipped, platformJobSkip{ID: jobID, Reason: "not found"})
+			continue
+		}
+		if !requeueableStatuses[job.Status] {
+			skipped = append(skipped, platformJobSkip{ID: jobID, Reason: fmt.Sprintf("job is %s", job.Status)})
+			continue
+		}
+		if err := s.Store.Jobs().RetryDeadLetterJob(r.Context(), jobID); err != nil {
+			logger.LogError(r.Context(), "api", "requeue_platform_job", err, "job_id", jobID)
+			skipped = append(skipped, platformJobSkip{ID: jobID, Reason: "failed to requeue"})
+			continue
+		}
+		_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: job.OrgID, ActorID: id.UserID, Action: "job.requeue", TargetRef: job.ID, Metadata: map[string]any{"platform": true, "previousStatus": job.Status}})
+		requeued = append(requeued, jobID)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"requeued": requeued, "skipped": skipped})
+}
+
+// handlePurgePlatformDeadLetter handles POST /v1/admin/platform/jobs/purge.
+// Only dead-lettered jobs are ever deleted, whatever IDs are passed.
+func (s *Server) handlePurgePlatformDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, ok := requirePlatformAdmin(w, r)
+	if !ok {
+		return
+	}
+	var req PurgeDeadLetterRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	filter := store.JobFilter{Status: store.JobDeadLetter, IDs: req.JobIDs}
+	if len(req.JobIDs) == 0 {
+		filter.OrgID, filter.Type = req.OrgID, store.JobType(req.Type)
+	}
+	jobs, err := s.Store.Jobs().List(r.Context(), filter)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "purge_dead_letter", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load jobs")
+		return
+	}
+	ids := make([]string, 0, len(jobs))
+	byOrg := map[string][]string{}
+	for _, j := range jobs {
+		ids = append(ids, j.ID)
+		byOrg[j.OrgID] = append(byOrg[j.OrgID], j.ID)
+	}
+
+	purged, err := s.Store.Jobs().DeleteDeadLetter(r.Context(), ids)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "purge_dead_letter", err, "count", len(ids))
+		writeError(w, r, http.StatusInternalServerError, "failed to purge jobs")
+		return
+	}
+	for orgID, jobIDs := range byOrg {
+		_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: orgID, ActorID: id.UserID, Action: "job.purge", Metadata: map[string]any{"platform": true, "jobIds": jobIDs}})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"purged": purged})
+}
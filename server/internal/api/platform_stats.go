@@ -0,0 +1,350 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/cache"
+)
+
+const (
+	defaultPlatformStatsDays  = 30
+	maxPlatformStatsDays      = 365
+	defaultPlatformStatsLimit = 20
+	maxPlatformStatsLimit     = 500
+	microsPerUSD              = 1e6
+)
+
+// JobThroughput summarizes the jobs of one type created in the window. Type
+// is empty for the total over every type.
+type JobThroughput struct {
+	Type store.JobType `json:"type,omitempty"`
+	// Total counts jobs in any status; Done, Failed and InFlight split it,
+	// with dead-lettered jobs counted as failed.
+	Total    int `json:"total"`
+	Done     int `json:"done"`
+	Failed   int `json:"failed"`
+	InFlight int `json:"inFlight"`
+	// PerDay is Done averaged over the window.
+	PerDay float64 `json:"perDay"`
+	// FailureRate is Failed over the finished (done or failed) jobs.
+	FailureRate float64 `json:"failureRate"`
+}
+
+func (t *JobThroughput) add(c store.JobCount) {
+	t.Total += c.Count
+	switch c.Status {
+	case store.JobDone:
+		t.Done += c.Count
+	case store.JobFailed, store.JobDeadLetter:
+		t.Failed += c.Count
+	default:
+		t.InFlight += c.Count
+	}
+}
+
+func (t *JobThroughput) finish(days int) {
+	t.PerDay = float64(t.Done) / float64(days)
+	if finished := t.Done + t.Failed; finished > 0 {
+		t.FailureRate = float64(t.Failed) / float64(finished)
+	}
+}
+
+// AISpend is what AI features cost in the window, platform-wide or for one
+// org.
+type AISpend struct {
+	OrgID string `json:"orgId,omitempty"`
+	Name  string `json:"name,omitempty"`
+	// Tokens is the token usage of template generations.
+	Tokens int `json:"tokens"`
+	// CostUSD is the providers' estimated cost of those generations.
+	CostUSD float64 `json:"costUsd"`
+	// Images counts generated background images.
+	Images int `json:"images"`
+}
+
+// OrgStorage is one org's stored asset bytes.
+type OrgStorage struct {
+	OrgID string `json:"orgId"`
+	Name  string `json:"name"`
+	Bytes int64  `json:"bytes"`
+}
+
+// activityCache remembers which users have been recorded active today, so
+// the store is written at most once per user and day on each instance.
+type activityCache struct {
+	mu   sync.Mutex
+	day  time.Time
+	seen map[string]bool
+}
+
+func newActivityCache() *activityCache {
+	return &activityCache{seen: map[string]bool{}}
+}
+
+// markSeen reports whether userID is new for now's UTC day.
+func (c *activityCache) markSeen(userID string, now time.Time) bool {
+	day := now.UTC().Truncate(24 * time.Hour)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !day.Equal(c.day) {
+		c.day, c.seen = day, map[string]bool{}
+	}
+	if c.seen[userID] {
+		return false
+	}
+	c.seen[userID] = true
+	return true
+}
+
+// withActivity records each authenticated user's first request of the day,
+// for the daily active user counts.
+func (s *Server) withActivity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.recordActivity(r.Context())
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recordActivity marks the caller in ctx active today.
+func (s *Server) recordActivity(ctx context.Context) {
+	id, ok := auth.GetIdentity(ctx)
+	if !ok || id.UserID == "" || s.activity == nil {
+		return
+	}
+	now := time.Now()
+	if s.activity.markSeen(id.UserID, now) {
+		if err := s.Store.Platform().RecordActivity(ctx, id.UserID, now); err != nil {
+			logger.LogError(ctx, "api", "record_user_activity", err)
+		}
+	}
+}
+
+// platformStatsDays reads the ?days= window of a stats endpoint.
+func platformStatsDays(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("days")
+	if v == "" {
+		return defaultPlatformStatsDays, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		writeError(w, r, http.StatusBadRequest, "days must be a positive integer")
+		return 0, false
+	}
+	return min(n, maxPlatformStatsDays), true
+}
+
+// windowStart returns the start of the UTC day days-1 days before today, so
+// a window of 1 day is today.
+func windowStart(days int) time.Time {
+	return time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-days)
+}
+
+// orgName returns orgID's name, or "" if it can't be loaded.
+func (s *Server) orgName(ctx context.Context, orgID string) string {
+	org, err := s.Store.Organizations().GetOrganization(ctx, orgID)
+	if err != nil {
+		return ""
+	}
+	return org.Name
+}
+
+// handlePlatformOrgStats handles GET /v1/admin/platform/stats/orgs.
+func (s *Server) handlePlatformOrgStats(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePlatformAdmin(w, r); !ok {
+		return
+	}
+	days, ok := platformStatsDays(w, r)
+	if !ok {
+		return
+	}
+	counts, err := s.Store.Platform().OrgCounts(r.Context(), windowStart(days))
+	if err != nil {
+		logger.LogError(r.Context(), "api", "platform_org_stats", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to count organizations")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"days": days, "orgs": counts})
+}
+
+// handlePlatformActiveUsers handles GET
+// /v1/admin/platform/stats/active-users. Every day of the window is listed,
+// with 0 for days nobody was active.
+func (s *Server) handlePlatformActiveUsers(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePlatformAdmin(w, r); !ok {
+		return
+	}
+	days, ok := platformStatsDays(w, r)
+	if !ok {
+		return
+	}
+	since := windowStart(days)
+	counts, err := s.Store.Platform().DailyActiveUsers(r.Context(), since)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "platform_active_users", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to count active users")
+		return
+	}
+	byDay := make(map[string]int, len(counts))
+	for _, c := range counts {
+		byDay[c.Day.UTC().Format(time.DateOnly)] = c.Count
+	}
+	out := make([]store.DailyCount, days)
+	for i := range out {
+		day := since.AddDate(0, 0, i)
+		out[i] = store.DailyCount{Day: day, Count: byDay[day.Format(time.DateOnly)]}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"days": days, "activeUsers": out})
+}
+
+// handlePlatformJobStats handles GET /v1/admin/platform/stats/jobs.
+func (s *Server) handlePlatformJobStats(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePlatformAdmin(w, r); !ok {
+		return
+	}
+	days, ok := platformStatsDays(w, r)
+	if !ok {
+		return
+	}
+	counts, err := s.Store.Platform().JobCounts(r.Context(), windowStart(days))
+	if err != nil {
+		logger.LogError(r.Context(), "api", "platform_job_stats", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to count jobs")
+		return
+	}
+	var total JobThroughput
+	byType := map[store.JobType]*JobThroughput{}
+	for _, c := range counts {
+		t, ok := byType[c.Type]
+		if !ok {
+			t = &JobThroughput{Type: c.Type}
+			byType[c.Type] = t
+		}
+		t.add(c)
+		total.add(c)
+	}
+	types := make([]JobThroughput, 0, len(byType))
+	for _, t := range byType {
+		t.finish(days)
+		types = append(types, *t)
+	}
+	slices.SortFunc(types, func(a, b JobThroughput) int { return cmp.Compare(a.Type, b.Type) })
+	total.finish(days)
+	writeJSON(w, http.StatusOK, map[string]any{"days": days, "total": total, "types": types})
+}
+
+// handlePlatformAISpend handles GET /v1/admin/platform/stats/ai-spend. Orgs
+// are ranked by cost, then tokens.
+func (s *Server) handlePlatformAISpend(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePlatformAdmin(w, r); !ok {
+		return
+	}
+	days, ok := platformStatsDays(w, r)
+	if !ok {
+		return
+	}
+	since := windowStart(days)
+	sums := map[string]map[string]int{}
+	for _, eventType := range []string{"ai_generation", store.MeterAICost, "image_generate"} {
+		byOrg, err := s.Store.Platform().SumByOrg(r.Context(), eventType, since)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "platform_ai_spend", err, "event_type", eventType)
+			writeError(w, r, http.StatusInternalServerError, "failed to sum AI usage")
+			return
+		}
+		sums[eventType] = byOrg
+	}
+
+	var total AISpend
+	byOrg := map[string]*AISpend{}
+	for eventType, perOrg := range sums {
+		for orgID, n := range perOrg {
+			spend, ok := byOrg[orgID]
+			if !ok {
+				spend = &AISpend{OrgID: orgID}
+				byOrg[orgID] = spend
+			}
+			switch eventType {
+			case "ai_generation":
+				spend.Tokens += n
+				total.Tokens += n
+			case store.MeterAICost:
+				spend.CostUSD += float64(n) / microsPerUSD
+				total.CostUSD += float64(n) / microsPerUSD
+			case "image_generate":
+				spend.Images += n
+				total.Images += n
+			}
+		}
+	}
+	orgs := make([]AISpend, 0, len(byOrg))
+	for _, spend := range byOrg {
+		spend.Name = s.orgName(r.Context(), spend.OrgID)
+		orgs = append(orgs, *spend)
+	}
+	slices.SortFunc(orgs, func(a, b AISpend) int {
+		return cmp.Or(cmp.Compare(b.CostUSD, a.CostUSD), cmp.Compare(b.Tokens, a.Tokens), cmp.Compare(a.OrgID, b.OrgID))
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"days": days, "total": total, "orgs": orgs})
+}
+
+// handlePlatformStorage handles GET /v1/admin/platform/stats/storage. It
+// lists the orgs storing the most, up to ?limit=.
+func (s *Server) handlePlatformStorage(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePlatformAdmin(w, r); !ok {
+		return
+	}
+	limit := defaultPlatformStatsLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, r, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, maxPlatformStatsLimit)
+	}
+	byOrg, err := s.Store.Platform().StorageByOrg(r.Context())
+	if err != nil {
+		logger.LogError(r.Context(), "api", "platform_storage", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load storage usage")
+		return
+	}
+	var totalBytes int64
+	orgs := make([]OrgStorage, 0, len(byOrg))
+	for orgID, n := range byOrg {
+		totalBytes += n
+		orgs = append(orgs, OrgStorage{OrgID: orgID, Bytes: n})
+	}
+	slices.SortFunc(orgs, func(a, b OrgStorage) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(a.OrgID, b.OrgID))
+	})
+	orgs = orgs[:min(limit, len(orgs))]
+	for i := range orgs {
+		orgs[i].Name = s.orgName(r.Context(), orgs[i].OrgID)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"totalBytes": totalBytes, "orgCount": len(byOrg), "limitBytesPerOrg": s.Config.StorageLimitBytes, "orgs": orgs})
+}
+
+// handlePlatformCacheStats handles GET /v1/admin/platform/stats/cache, the
+// hit rate of the template and brand kit read cache. Stores without one
+// report it disabled.
+func (s *Server) handlePlatformCacheStats(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePlatformAdmin(w, r); !ok {
+		return
+	}
+	var stats cache.Stats
+	if cs, ok := s.Store.(interface{ CacheStats() cache.Stats }); ok {
+		stats = cs.CacheStats()
+	} else {
+		stats = (*cache.Cache)(nil).Stats()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"cache": stats})
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/cache"
+)
+
+func TestPlatformStats(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-a", Name: "Acme"}))
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-b", Name: "Beta"}))
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-gone", Name: "Gone"}))
+	_, err := s.Store.Organizations().Delete(ctx, "org-gone", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	h := s.Handler()
+	addPlatformAdmin(t, s, "ops", "org-a")
+
+	get := func(path, userID string, role auth.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		addTestAuth(req, userID, "org-a", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder, v any) {
+		t.Helper()
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), v))
+	}
+
+	// Org members are counted active but can't see the dashboard.
+	for _, path := range []string{"/v1/admin/platform/stats/orgs", "/v1/admin/platform/stats/active-users", "/v1/admin/platform/stats/jobs", "/v1/admin/platform/stats/ai-spend", "/v1/admin/platform/stats/storage", "/v1/admin/platform/stats/cache"} {
+		assert.Equal(t, http.StatusForbidden, get(path, "user-1", auth.RoleOwner).Code, path)
+	}
+	get("/v1/admin/platform/stats/orgs", "user-2", auth.RoleViewer)
+	get("/v1/admin/platform/stats/orgs", "user-2", auth.RoleViewer)
+	assert.Equal(t, http.StatusBadRequest, get("/v1/admin/platform/stats/orgs?days=0", "ops", auth.RoleViewer).Code)
+
+	var orgs struct {
+		Orgs store.OrgCounts `json:"orgs"`
+	}
+	decode(get("/v1/admin/platform/stats/orgs?days=7", "ops", auth.RoleViewer), &orgs)
+	assert.Equal(t, store.OrgCounts{Total: 3, Active: 2, Deleted: 1, CreatedSince: 3}, orgs.Orgs)
+
+	var active struct {
+		ActiveUsers []store.DailyCount `json:"activeUsers"`
+	}
+	decode(get("/v1/admin/platform/stats/active-users?days=3", "ops", auth.RoleViewer), &active)
+	require.Len(t, active.ActiveUsers, 3)
+	assert.Equal(t, 0, active.ActiveUsers[0].Count)
+	assert.Equal(t, 3, active.ActiveUsers[2].Count, "user-1, user-2 and ops were active today")
+
+	for _, j := range []store.Job{
+		{OrgID: "org-a", Type: store.JobExport, Status: store.JobDone},
+		{OrgID: "org-a", Type: store.JobExport, Status: store.JobDone},
+		{OrgID: "org-b", Type: store.JobExport, Status: store.JobDeadLetter},
+		{OrgID: "org-b", Type: store.JobRender, Status: store.JobQueued},
+	} {
+		j.ID = newID("job")
+		_, err := s.Store.Jobs().Enqueue(ctx, j)
+		require.NoError(t, err)
+	}
+	var jobs struct {
+		Total JobThroughput   `json:"total"`
+		Types []JobThroughput `json:"types"`
+	}
+	decode(get("/v1/admin/platform/stats/jobs?days=2", "ops", auth.RoleViewer), &jobs)
+	assert.Equal(t, 4, jobs.Total.Total)
+	assert.Equal(t, 1, jobs.Total.InFlight)
+	require.Len(t, jobs.Types, 2)
+	assert.Equal(t, JobThroughput{Type: store.JobExport, Total: 3, Done: 2, Failed: 1, PerDay: 1, FailureRate: 1.0 / 3}, jobs.Types[0])
+
+	for _, e := range []store.MeteringEvent{
+		{OrgID: "org-a", Type: "ai_generation", Quantity: 1500},
+		{OrgID: "org-a", Type: store.MeterAICost, Quantity: 2500},
+		{OrgID: "org-b", Type: "ai_generation", Quantity: 9000},
+		{OrgID: "org-b", Type: store.MeterAICost, Quantity: 500},
+		{OrgID: "org-b", Type: "image_generate", Quantity: 2},
+		{OrgID: "org-b", Type: "export", Quantity: 1},
+	} {
+		e.ID = newID("met")
+		_, err := s.Store.Metering().Record(ctx, e)
+		require.NoError(t, err)
+	}
+	var spend struct {
+		Total AISpend   `json:"total"`
+		Orgs  []AISpend `json:"orgs"`
+	}
+	decode(get("/v1/admin/platform/stats/ai-spend", "ops", auth.RoleViewer), &spend)
+	assert.Equal(t, 10500, spend.Total.Tokens)
+	assert.InDelta(t, 0.003, spend.Total.CostUSD, 1e-9)
+	assert.Equal(t, 2, spend.Total.Images)
+	require.Len(t, spend.Orgs, 2)
+	assert.Equal(t, "Acme", spend.Orgs[0].Name, "ranked by cost, not tokens")
+	assert.Equal(t, "org-b", spend.Orgs[1].OrgID)
+
+	for orgID, size := range map[string]int64{"org-a": 100, "org-b": 300} {
+		_, err := s.Store.Assets().Create(ctx, store.Asset{ID: newID("ast"), OrgID: orgID, Type: store.AssetPPTX, Path: orgID + "/f", SizeBytes: size})
+		require.NoError(t, err)
+	}
+	var storage struct {
+		TotalBytes int64        `json:"totalBytes"`
+		OrgCount   int          `json:"orgCount"`
+		Orgs       []OrgStorage `json:"orgs"`
+	}
+	decode(get("/v1/admin/platform/stats/storage?limit=1", "ops", auth.RoleViewer), &storage)
+	assert.Equal(t, int64(400), storage.TotalBytes)
+	assert.Equal(t, 2, storage.OrgCount)
+	assert.Equal(t, []OrgStorage{{OrgID: "org-b", Name: "Beta", Bytes: 300}}, storage.Orgs)
+
+	var cacheStats struct {
+		Cache cache.Stats `json:"cache"`
+	}
+	decode(get("/v1/admin/platform/stats/cache", "ops", auth.RoleViewer), &cacheStats)
+	assert.False(t, cacheStats.Cache.Enabled, "the memory store has no read cache")
+}
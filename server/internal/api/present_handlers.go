@@ -0,0 +1,210 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// defaultThemeTokens fill in whatever a deck's spec leaves out, so the
+// presentation runtime never has to guess at a missing color or font.
+var defaultThemeTokens = map[string]any{
+	"colors": map[string]any{
+		"primary":    "#2563eb",
+		"background": "#ffffff",
+		"text":       "#1f2937",
+		"accent":     "#10b981",
+	},
+	"fonts": map[string]any{
+		"heading": "Arial",
+		"body":    "Helvetica",
+	},
+}
+
+type PresentSlide struct {
+	Index        int                `json:"index"`
+	Name         string             `json:"name"`
+	ETag         string             `json:"etag"`
+	Placeholders []spec.Placeholder `json:"placeholders"`
+	ThumbnailURL string             `json:"thumbnailUrl"`
+}
+
+type PresentResponse struct {
+	DeckID     string         `json:"deckId"`
+	VersionID  string         `json:"versionId"`
+	VersionNo  int            `json:"versionNo"`
+	Name       string         `json:"name"`
+	Theme      map[string]any `json:"theme"`
+	SafeMargin float64        `json:"safeMargin"`
+	Slides     []PresentSlide `json:"slides"`
+}
+
+// loadPresentation returns the deck's current version spec with theme tokens
+// resolved. It writes the error response and returns false on failure.
+func (s *Server) loadPresentation(w http.ResponseWriter, r *http.Request) (store.Deck, store.DeckVersion, spec.TemplateSpec, bool) {
+	id, _ := auth.GetIdentity(r.Context())
+	d, ok := s.authorizeDeck(w, r, id, r.PathValue("id"), store.PermissionView)
+	if !ok {
+		return store.Deck{}, store.DeckVersion{}, spec.TemplateSpec{}, false
+	}
+	dv, ts, ok := s.presentationVersion(w, r, d)
+	return d, dv, ts, ok
+}
+
+// presentationVersion loads d's current version and its spec with theme
+// tokens resolved, for callers that have already authorized access to d.
+func (s *Server) presentationVersion(w http.ResponseWriter, r *http.Request, d store.Deck) (store.DeckVersion, spec.TemplateSpec, bool) {
+	if d.CurrentVersion == nil {
+		writeError(w, r, http.StatusConflict, "deck has no content to present yet")
+		return store.DeckVersion{}, spec.TemplateSpec{}, false
+	}
+
+	dv, ok, err := s.Store.Decks().GetDeckVersion(r.Context(), d.OrgID, *d.CurrentVersion)
+	if err != nil || !ok {
+		logger.LogError(r.Context(), "api", "load_presentation", fmt.Errorf("current version %s unavailable: %v", *d.CurrentVersion, err), "deck_id", d.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to load deck version")
+		return store.DeckVersion{}, spec.TemplateSpec{}, false
+	}
+
+	var ts spec.TemplateSpec
+	specBytes, err := normalize.JSON(dv.SpecJSON)
+	if err == nil {
+		err = json.Unmarshal(specBytes, &ts)
+	}
+	if err != nil {
+		logger.LogError(r.Context(), "api", "load_presentation", err, "deck_id", d.ID, "version_id", dv.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to read deck spec")
+		return store.DeckVersion{}, spec.TemplateSpec{}, false
+	}
+	ts.Tokens = mergeTokens(defaultThemeTokens, ts.Tokens)
+	return dv, ts, true
+}
+
+// mergeTokens overlays tokens on base, recursing into nested maps.
+func mergeTokens(base, tokens map[string]any) map[string]any {
+	out := make(map[string]any, len(base)+len(tokens))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range tokens {
+		if nested, ok := v.(map[string]any); ok {
+			if baseNested, ok := out[k].(map[string]any); ok {
+				out[k] = mergeTokens(baseNested, nested)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// slideETag changes whenever anything that affects a slide's appearance
+// changes: its own layout or the deck's theme.
+func slideETag(layout spec.Layout, theme map[string]any) string {
+	h := sha256.New()
+	_ = json.NewEncoder(h).Encode(layout)
+	_ = json.NewEncoder(h).Encode(theme)
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// etagMatches implements If-None-Match, including lists and weak validators.
+func etagMatches(r *http.Request, etag string) bool {
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handlePresentDeck(w http.ResponseWriter, r *http.Request) {
+	d, dv, ts, ok := s.loadPresentation(w, r)
+	if !ok {
+		return
+	}
+
+	resp := PresentResponse{
+		DeckID:     d.ID,
+		VersionID:  dv.ID,
+		VersionNo:  dv.VersionNo,
+		Name:       d.Name,
+		Theme:      ts.Tokens,
+		SafeMargin: ts.Constraints.SafeMargin,
+		Slides:     make([]PresentSlide, 0, len(ts.Layouts)),
+	}
+	deckTag := sha256.New()
+	deckTag.Write([]byte(dv.ID))
+	for i, layout := range ts.Layouts {
+		placeholders := layout.Placeholders
+		if placeholders == nil {
+			placeholders = []spec.Placeholder{}
+		}
+		etag := slideETag(layout, ts.Tokens)
+		deckTag.Write([]byte(etag))
+		resp.Slides = append(resp.Slides, PresentSlide{
+			Index:        i,
+			Name:         layout.Name,
+			ETag:         etag,
+			Placeholders: placeholders,
+			// The version pins the URL so caches never serve a stale image.
+			ThumbnailURL: fmt.Sprintf("/v1/decks/%s/present/slides/%d/thumbnail?v=%s", d.ID, i, dv.ID),
+		})
+	}
+
+	etag := `"` + hex.EncodeToString(deckTag.Sum(nil))[:32] + `"`
+	writeJSONIfChanged(w, r, etag, resp)
+}
+
+func (s *Server) handlePresentSlideThumbnail(w http.ResponseWriter, r *http.Request) {
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil || index < 0 {
+		writeError(w, r, http.StatusBadRequest, "invalid slide index")
+		return
+	}
+	d, _, ts, ok := s.loadPresentation(w, r)
+	if !ok {
+		return
+	}
+	s.writeSlideThumbnail(w, r, d, ts, index)
+}
+
+// writeSlideThumbnail renders slide index of ts as a PNG, answering
+// conditional requests from the slide's ETag.
+func (s *Server) writeSlideThumbnail(w http.ResponseWriter, r *http.Request, d store.Deck, ts spec.TemplateSpec, index int) {
+	if index >= len(ts.Layouts) {
+		writeError(w, r, http.StatusNotFound, "slide not found")
+		return
+	}
+
+	layout := ts.Layouts[index]
+	etag := slideETag(layout, ts.Tokens)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if etagMatches(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	single := spec.TemplateSpec{Tokens: ts.Tokens, Constraints: ts.Constraints, Layouts: []spec.Layout{layout}}
+	thumbs, err := s.Renderer.GenerateSlideThumbnails(r.Context(), single)
+	if err != nil || len(thumbs) == 0 {
+		logger.LogError(r.Context(), "api", "slide_thumbnail", fmt.Errorf("generate thumbnail: %v", err), "deck_id", d.ID, "slide", index)
+		writeError(w, r, http.StatusInternalServerError, "failed to render thumbnail")
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(len(thumbs[0])))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(thumbs[0])
+}
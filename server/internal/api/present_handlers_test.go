@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestPresentDeck_ViewerGetsSlidesWithETags(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+
+	specJSON := `{"tokens":{"colors":{"primary":"#ff0000"}},"layouts":[
+		{"name":"Title","placeholders":[{"id":"title","type":"text","content":"Q3 Review","geometry":{"x":0.1,"y":0.1,"w":0.8,"h":0.2}}]},
+		{"name":"Body","placeholders":[{"id":"body","type":"text","content":"Revenue up","geometry":{"x":0.1,"y":0.3,"w":0.8,"h":0.5}}]}]}`
+	versionID := "dv-present"
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-present", OrgID: "org-1", Name: "Q3", CurrentVersion: &versionID})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: versionID, Deck: "deck-present", OrgID: "org-1", VersionNo: 1, SpecJSON: json.RawMessage(specJSON)})
+	require.NoError(t, err)
+
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		addTestAuth(req, "viewer-1", "org-1", auth.RoleViewer)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/v1/decks/deck-present/present", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp PresentResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Slides, 2)
+	assert.Equal(t, "Q3 Review", resp.Slides[0].Placeholders[0].Content)
+	assert.NotEqual(t, resp.Slides[0].ETag, resp.Slides[1].ETag)
+
+	// Spec tokens win; missing ones come from the default theme.
+	colors := resp.Theme["colors"].(map[string]any)
+	assert.Equal(t, "#ff0000", colors["primary"])
+	assert.NotEmpty(t, colors["background"])
+	assert.Contains(t, resp.Theme, "fonts")
+
+	assert.Equal(t, http.StatusNotModified, get("/v1/decks/deck-present/present", w.Header().Get("ETag")).Code)
+
+	w = get(resp.Slides[1].ThumbnailURL, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, resp.Slides[1].ETag, w.Header().Get("ETag"))
+	assert.Equal(t, http.StatusNotModified, get(resp.Slides[1].ThumbnailURL, resp.Slides[1].ETag).Code)
+
+	assert.Equal(t, http.StatusNotFound, get("/v1/decks/deck-present/present/slides/5/thumbnail", "").Code)
+}
+
+func TestPresentDeck_NoContentYet(t *testing.T) {
+	s := NewServer()
+	_, err := s.Store.Decks().CreateDeck(context.Background(), store.Deck{ID: "deck-empty", OrgID: "org-1", Name: "Empty"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/decks/deck-empty/present", nil)
+	addTestAuth(req, "viewer-1", "org-1", auth.RoleViewer)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
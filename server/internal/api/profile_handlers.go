@@ -0,0 +1,261 @@
+package api
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/mail"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// emailTokenTTL is how long an email change waits for confirmation.
+const emailTokenTTL = 24 * time.Hour
+
+// maxEditorPrefsBytes caps the editor settings a user may store.
+const maxEditorPrefsBytes = 64 << 10
+
+func hashEmailToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// meUser is the user object of the /v1/auth/me endpoints: the sign-in fields
+// plus the profile.
+func meUser(user store.User, id auth.Identity) map[string]any {
+	return map[string]any{
+		"userId":        user.ID,
+		"email":         user.Email,
+		"name":          user.Name,
+		"orgId":         id.OrgID,
+		"role":          id.Role,
+		"avatarUrl":     user.AvatarURL,
+		"preferences":   user.Preferences,
+		"pendingEmail":  user.PendingEmail,
+		"emailVerified": user.EmailVerifiedAt != nil,
+	}
+}
+
+func (s *Server) handleUpdateMe(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+
+	var req UpdateProfileRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	user, ok, err := s.Store.Users().GetUser(r.Context(), id.UserID)
+	if err != nil || !ok {
+		writeError(w, r, http.StatusNotFound, "user not found")
+		return
+	}
+	if req.Name != nil {
+		user.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.AvatarURL != nil {
+		user.AvatarURL = *req.AvatarURL
+	}
+	if p := req.Preferences; p != nil {
+		prefs := store.UserPreferences{}
+		if user.Preferences != nil {
+			prefs = *user.Preferences
+		}
+		if p.DefaultBrandKitID != nil {
+			if *p.DefaultBrandKitID != "" {
+				if _, found, err := s.brandKitTokens(r, id.OrgID, *p.DefaultBrandKitID); err != nil || !found {
+					writeError(w, r, http.StatusBadRequest, "brand kit not found")
+					return
+				}
+			}
+			prefs.DefaultBrandKitID = *p.DefaultBrandKitID
+		}
+		if len(p.Editor) > 0 {
+			switch {
+			case bytes.Equal(p.Editor, []byte("null")):
+				prefs.Editor = nil
+			case len(p.Editor) > maxEditorPrefsBytes:
+				writeError(w, r, http.StatusBadRequest, "editor preferences are too large")
+				return
+			case p.Editor[0] != '{':
+				writeError(w, r, http.StatusBadRequest, "editor preferences must be a JSON object")
+				return
+			default:
+				prefs.Editor = p.Editor
+			}
+		}
+		user.Preferences = &prefs
+		if prefs.DefaultBrandKitID == "" && prefs.Editor == nil {
+			user.Preferences = nil
+		}
+	}
+
+	if err := s.Store.Users().UpdateUser(r.Context(), user); err != nil {
+		logger.LogError(r.Context(), "api", "update_me", err, "user_id", id.UserID)
+		writeError(w, r, http.StatusInternalServerError, "failed to update profile")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"user": meUser(user, id)})
+}
+
+// handleChangeEmail records the new address as pending and mails it a
+// confirmation token. Asking again replaces the pending change.
+func (s *Server) handleChangeEmail(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+
+	var req ChangeEmailRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if s.Mail == nil {
+		writeError(w, r, http.StatusServiceUnavailable, "email delivery is not configured")
+		return
+	}
+
+	user, ok, err := s.Store.Users().GetUser(r.Context(), id.UserID)
+	if err != nil || !ok {
+		writeError(w, r, http.StatusNotFound, "user not found")
+		return
+	}
+	// Asking for the current address confirms it, for accounts that never
+	// verified their email.
+	if strings.EqualFold(req.Email, user.Email) && user.EmailVerifiedAt != nil {
+		writeError(w, r, http.StatusBadRequest, "email is unchanged")
+		return
+	}
+	if status, msg := s.checkEmailAvailable(r, req.Email, user.ID); status != 0 {
+		writeError(w, r, status, msg)
+		return
+	}
+
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		logger.LogError(r.Context(), "api", "change_email", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to generate token")
+		return
+	}
+	token := hex.EncodeToString(b[:])
+	expires := time.Now().UTC().Add(emailTokenTTL)
+	user.PendingEmail = req.Email
+	user.EmailTokenHash = hashEmailToken(token)
+	user.EmailTokenExpiresAt = &expires
+	if err := s.Store.Users().UpdateUser(r.Context(), user); err != nil {
+		logger.LogError(r.Context(), "api", "change_email", err, "user_id", id.UserID)
+		writeError(w, r, http.StatusInternalServerError, "failed to start email change")
+		return
+	}
+
+	if err := s.Mail.Send(r.Context(), s.emailChangeMessage(req.Email, token)); err != nil {
+		logger.LogError(r.Context(), "api", "send_email_verification", err, "user_id", id.UserID)
+		writeError(w, r, http.StatusBadGateway, "failed to send verification email")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{
+		ID:        newID("aud"),
+		OrgID:     id.OrgID,
+		ActorID:   id.UserID,
+		Action:    "user.email_change_requested",
+		TargetRef: user.ID,
+	})
+	writeJSON(w, http.StatusAccepted, map[string]any{"pendingEmail": user.PendingEmail, "expiresAt": expires})
+}
+
+func (s *Server) emailChangeMessage(to, token string) mail.Message {
+	confirm := "Your confirmation code is: " + token
+	if s.Config.EmailVerifyURL != "" {
+		confirm = "Open this link to confirm it:\n\n" + s.Config.EmailVerifyURL + "?token=" + url.QueryEscape(token)
+	}
+	return mail.Message{
+		To:      to,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Someone asked to change the email address of a cms-ai account to %s.\n%s\n\n"+
+			"The request expires in %d hours. If it wasn't you, ignore this email.\n", to, confirm, int(emailTokenTTL.Hours())),
+	}
+}
+
+// handleVerifyEmail applies the pending email change whose token is
+// presented and marks the address verified. The token is single-use.
+func (s *Server) handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	user, ok, err := s.Store.Users().GetUser(r.Context(), id.UserID)
+	if err != nil || !ok {
+		writeError(w, r, http.StatusNotFound, "user not found")
+		return
+	}
+	if user.PendingEmail == "" || user.EmailTokenExpiresAt == nil || time.Now().After(*user.EmailTokenExpiresAt) ||
+		subtle.ConstantTimeCompare([]byte(user.EmailTokenHash), []byte(hashEmailToken(req.Token))) != 1 {
+		writeError(w, r, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+	// The address may have been taken since the change was requested.
+	if status, msg := s.checkEmailAvailable(r, user.PendingEmail, user.ID); status != 0 {
+		writeError(w, r, status, msg)
+		return
+	}
+
+	previous := user.Email
+	user.Email = user.PendingEmail
+	user.PendingEmail, user.EmailTokenHash, user.EmailTokenExpiresAt = "", "", nil
+	now := time.Now().UTC()
+	user.EmailVerifiedAt = &now
+	if err := s.Store.Users().UpdateUser(r.Context(), user); err != nil {
+		logger.LogError(r.Context(), "api", "verify_email", err, "user_id", id.UserID)
+		writeError(w, r, http.StatusInternalServerError, "failed to change email")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{
+		ID:        newID("aud"),
+		OrgID:     id.OrgID,
+		ActorID:   id.UserID,
+		Action:    "user.email_changed",
+		TargetRef: user.ID,
+		Metadata:  map[string]any{"from": previous, "to": user.Email},
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"user": meUser(user, id)})
+}
+
+// checkEmailAvailable reports a status and message when email belongs to a
+// user other than userID, or 0 when it is free.
+func (s *Server) checkEmailAvailable(r *http.Request, email, userID string) (int, string) {
+	other, found, err := s.Store.Users().GetUserByEmail(r.Context(), email)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "check_email", err)
+		return http.StatusInternalServerError, "failed to check email"
+	}
+	if found && other.ID != userID {
+		return http.StatusConflict, "email is already in use"
+	}
+	return 0, ""
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/mail"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+type fakeMailer struct{ sent []mail.Message }
+
+func (f *fakeMailer) Send(_ context.Context, m mail.Message) error {
+	f.sent = append(f.sent, m)
+	return nil
+}
+
+func newProfileTestServer(t *testing.T) (*Server, http.Handler) {
+	t.Helper()
+	s := NewServer()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Org"}))
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "user-1", Email: "ana@acme.com", Name: "Ana"}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "user-1", OrgID: "org-1", Role: auth.RoleViewer}))
+	return s, s.Handler()
+}
+
+func TestUpdateMe_ProfileAndPreferences(t *testing.T) {
+	s, h := newProfileTestServer(t)
+	_, err := s.Store.BrandKits().Create(context.Background(), store.BrandKit{ID: "bk-1", OrgID: "org-1", Name: "Brand", Tokens: map[string]any{}})
+	require.NoError(t, err)
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/v1/auth/me", strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", auth.RoleViewer)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	type meResp struct {
+		User struct {
+			Name        string                 `json:"name"`
+			AvatarURL   string                 `json:"avatarUrl"`
+			Preferences *store.UserPreferences `json:"preferences"`
+		} `json:"user"`
+	}
+
+	w := do(http.MethodPatch, `{"name":" Ana B ","avatarUrl":"https://cdn.acme.com/ana.png","preferences":{"defaultBrandKitId":"bk-1","editor":{"grid":true,"zoom":1.5}}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Preferences merge; editor settings are kept as sent.
+	w = do(http.MethodPatch, `{"preferences":{"defaultBrandKitId":""}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = do(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var got meResp
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, "Ana B", got.User.Name)
+	assert.Equal(t, "https://cdn.acme.com/ana.png", got.User.AvatarURL)
+	require.NotNil(t, got.User.Preferences)
+	assert.Empty(t, got.User.Preferences.DefaultBrandKitID)
+	assert.JSONEq(t, `{"grid":true,"zoom":1.5}`, string(got.User.Preferences.Editor))
+
+	w = do(http.MethodPatch, `{"preferences":{"editor":null}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	got = meResp{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Nil(t, got.User.Preferences)
+
+	for _, body := range []string{
+		`{"preferences":{"defaultBrandKitId":"bk-other"}}`,
+		`{"preferences":{"editor":[1,2]}}`,
+		`{"avatarUrl":"javascript:alert(1)"}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPatch, body).Code, body)
+	}
+}
+
+func TestChangeEmail_Verification(t *testing.T) {
+	s, h := newProfileTestServer(t)
+	ctx := context.Background()
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "user-2", Email: "taken@acme.com"}))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", auth.RoleViewer)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// Without a mailer there is no way to confirm the address.
+	require.Equal(t, http.StatusServiceUnavailable, do(http.MethodPost, "/v1/auth/me/email", `{"email":"ana@new.com"}`).Code)
+
+	mailer := &fakeMailer{}
+	s.Mail = mailer
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/v1/auth/me/email", `{"email":"taken@acme.com"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/v1/auth/me/email", `{"email":"not-an-email"}`).Code)
+
+	w := do(http.MethodPost, "/v1/auth/me/email", `{"email":"ana@new.com"}`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	require.Len(t, mailer.sent, 1)
+	assert.Equal(t, "ana@new.com", mailer.sent[0].To)
+	token := regexp.MustCompile(`[0-9a-f]{64}`).FindString(mailer.sent[0].Body)
+	require.NotEmpty(t, token)
+
+	// The old address stays until the token is confirmed.
+	u, _, err := s.Store.Users().GetUser(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "ana@acme.com", u.Email)
+	assert.Equal(t, "ana@new.com", u.PendingEmail)
+	assert.NotContains(t, u.EmailTokenHash, token, "only the token's hash is stored")
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/v1/auth/me/email/verify", `{"token":"wrong"}`).Code)
+
+	w = do(http.MethodPost, "/v1/auth/me/email/verify", `{"token":"`+token+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	u, _, err = s.Store.Users().GetUser(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "ana@new.com", u.Email)
+	assert.Empty(t, u.PendingEmail)
+	assert.Empty(t, u.EmailTokenHash)
+	assert.NotNil(t, u.EmailVerifiedAt)
+
+	// Tokens are single-use.
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/v1/auth/me/email/verify", `{"token":"`+token+`"}`).Code)
+	// Once verified, asking for the same address again is a no-op request.
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/v1/auth/me/email", `{"email":"ana@new.com"}`).Code)
+}
+
+func TestChangeEmail_ConfirmsCurrentAddress(t *testing.T) {
+	s, h := newProfileTestServer(t)
+	mailer := &fakeMailer{}
+	s.Mail = mailer
+	do := func(path, body string) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", auth.RoleViewer)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusAccepted, do("/v1/auth/me/email", `{"email":"ana@acme.com"}`), "unverified accounts may confirm the address they have")
+	require.Len(t, mailer.sent, 1)
+	token := regexp.MustCompile(`[0-9a-f]{64}`).FindString(mailer.sent[0].Body)
+	require.Equal(t, http.StatusOK, do("/v1/auth/me/email/verify", `{"token":"`+token+`"}`))
+
+	u, _, err := s.Store.Users().GetUser(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "ana@acme.com", u.Email)
+	assert.NotNil(t, u.EmailVerifiedAt)
+}
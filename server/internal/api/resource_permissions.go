@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// authorizeTemplate loads a template the caller can see and checks they hold
+// at least min on it. It writes the error response and returns false
+// otherwise; templates the caller can't see are reported as not found.
+func (s *Server) authorizeTemplate(w http.ResponseWriter, r *http.Request, id auth.Identity, templateID string, min store.Permission) (store.Template, bool) {
+	tpl, perm, ok, err := s.Store.Templates().GetTemplateFor(r.Context(), id, templateID)
+	if !checkResourceAccess(w, r, perm, ok, err, min) {
+		return store.Template{}, false
+	}
+	return tpl, true
+}
+
+// authorizeDeck is authorizeTemplate for decks.
+func (s *Server) authorizeDeck(w http.ResponseWriter, r *http.Request, id auth.Identity, deckID string, min store.Permission) (store.Deck, bool) {
+	d, perm, ok, err := s.Store.Decks().GetDeckFor(r.Context(), id, deckID)
+	if !checkResourceAccess(w, r, perm, ok, err, min) {
+		return store.Deck{}, false
+	}
+	return d, true
+}
+
+func checkResourceAccess(w http.ResponseWriter, r *http.Request, perm store.Permission, found bool, err error, min store.Permission) bool {
+	switch {
+	case err != nil:
+		logger.LogError(r.Context(), "api", "check_resource_access", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load resource")
+		return false
+	case !found:
+		writeError(w, r, http.StatusNotFound, "not found")
+		return false
+	case !perm.Allows(min):
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return false
+	}
+	return true
+}
+
+type resourceACL struct {
+	OwnerUserID string                     `json:"ownerUserId"`
+	Visibility  store.Visibility           `json:"visibility"`
+	Grants      []store.ResourcePermission `json:"grants"`
+}
+
+// authorizeResource checks the caller holds min on the template or deck.
+func (s *Server) authorizeResource(w http.ResponseWriter, r *http.Request, id auth.Identity, rt store.ResourceType, resourceID string, min store.Permission) (resourceACL, bool) {
+	if rt == store.ResourceDeck {
+		d, ok := s.authorizeDeck(w, r, id, resourceID, min)
+		return resourceACL{OwnerUserID: d.OwnerUserID, Visibility: d.Visibility}, ok
+	}
+	tpl, ok := s.authorizeTemplate(w, r, id, resourceID, min)
+	return resourceACL{OwnerUserID: tpl.OwnerUserID, Visibility: tpl.Visibility}, ok
+}
+
+func (s *Server) setResourceVisibility(ctx context.Context, id auth.Identity, rt store.ResourceType, resourceID string, vis store.Visibility) error {
+	if rt == store.ResourceDeck {
+		d, ok, err := s.Store.Decks().GetDeck(ctx, id.OrgID, resourceID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("deck %s not found", resourceID)
+		}
+		d.Visibility = vis
+		_, err = s.Store.Decks().UpdateDeck(ctx, d)
+		return err
+	}
+	tpl, ok, err := s.Store.Templates().GetTemplate(ctx, id.OrgID, resourceID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("template %s not found", resourceID)
+	}
+	tpl.Visibility = vis
+	_, err = s.Store.Templates().UpdateTemplate(ctx, tpl)
+	return err
+}
+
+func (s *Server) handleListResourcePermissions(rt store.ResourceType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, _ := auth.GetIdentity(r.Context())
+		resourceID := r.PathValue("id")
+		acl, ok := s.authorizeResource(w, r, id, rt, resourceID, store.PermissionView)
+		if !ok {
+			return
+		}
+
+		grants, err := s.Store.Permissions().List(r.Context(), id.OrgID, rt, resourceID)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "list_permissions", err, "resource_id", resourceID)
+			writeError(w, r, http.StatusInternalServerError, "failed to list permissions")
+			return
+		}
+		acl.Grants = grants
+		writeJSON(w, http.StatusOK, acl)
+	}
+}
+
+func (s *Server) handleGrantResourcePermission(rt store.ResourceType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, _ := auth.GetIdentity(r.Context())
+		resourceID := r.PathValue("id")
+		userID := r.PathValue("userId")
+		if _, ok := s.authorizeResource(w, r, id, rt, resourceID, store.PermissionManage); !ok {
+			return
+		}
+
+		var req GrantPermissionRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			writeInvalidJSON(w, r)
+			return
+		}
+		if err := s.validate.Struct(req); err != nil {
+			writeValidationError(w, r, err)
+			return
+		}
+
+		// Grants only extend access within the org, never across it.
+		if isMember, err := s.isOrgMember(r.Context(), id.OrgID, userID); err != nil {
+			logger.LogError(r.Context(), "api", "grant_permission", err, "user_id", userID)
+			writeError(w, r, http.StatusInternalServerError, "failed to check membership")
+			return
+		} else if !isMember {
+			writeError(w, r, http.StatusBadRequest, "user is not a member of this organization")
+			return
+		}
+
+		grant, err := s.Store.Permissions().Grant(r.Context(), store.ResourcePermission{
+			OrgID:        id.OrgID,
+			ResourceType: rt,
+			ResourceID:   resourceID,
+			UserID:       userID,
+			Permission:   store.Permission(req.Permission),
+			GrantedBy:    id.UserID,
+		})
+		if err != nil {
+			logger.LogError(r.Context(), "api", "grant_permission", err, "resource_id", resourceID)
+			writeError(w, r, http.StatusInternalServerError, "failed to grant permission")
+			return
+		}
+
+		_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: string(rt) + ".permission.grant", TargetRef: resourceID, Metadata: map[string]any{"userId": userID, "permission": grant.Permission}})
+		writeJSON(w, http.StatusOK, map[string]any{"grant": grant})
+	}
+}
+
+func (s *Server) handleRevokeResourcePermission(rt store.ResourceType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, _ := auth.GetIdentity(r.Context())
+		resourceID := r.PathValue("id")
+		userID := r.PathValue("userId")
+		if _, ok := s.authorizeResource(w, r, id, rt, resourceID, store.PermissionManage); !ok {
+			return
+		}
+
+		removed, err := s.Store.Permissions().Revoke(r.Context(), id.OrgID, rt, resourceID, userID)
+		if err != nil {
+			logger.LogError(r.Context(), "api", "revoke_permission", err, "resource_id", resourceID)
+			writeError(w, r, http.StatusInternalServerError, "failed to revoke permission")
+			return
+		}
+		if !removed {
+			writeError(w, r, http.StatusNotFound, "grant not found")
+			return
+		}
+
+		_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: string(rt) + ".permission.revoke", TargetRef: resourceID, Metadata: map[string]any{"userId": userID}})
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) handleUpdateResourceVisibility(rt store.ResourceType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, _ := auth.GetIdentity(r.Context())
+		resourceID := r.PathValue("id")
+		if _, ok := s.authorizeResource(w, r, id, rt, resourceID, store.PermissionManage); !ok {
+			return
+		}
+
+		var req UpdateVisibilityRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			writeInvalidJSON(w, r)
+			return
+		}
+		if err := s.validate.Struct(req); err != nil {
+			writeValidationError(w, r, err)
+			return
+		}
+
+		if err := s.setResourceVisibility(r.Context(), id, rt, resourceID, store.Visibility(req.Visibility)); err != nil {
+			logger.LogError(r.Context(), "api", "update_visibility", err, "resource_id", resourceID)
+			writeError(w, r, http.StatusInternalServerError, "failed to update visibility")
+			return
+		}
+
+		_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: string(rt) + ".visibility.update", TargetRef: resourceID, Metadata: map[string]any{"visibility": req.Visibility}})
+		writeJSON(w, http.StatusOK, map[string]any{"visibility": req.Visibility})
+	}
+}
+
+func (s *Server) isOrgMember(ctx context.Context, orgID, userID string) (bool, error) {
+	memberships, err := s.Store.Users().ListUserOrgs(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range memberships {
+		if m.OrgID == orgID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestResourcePermissions_PrivateTemplateSharing(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Org"}))
+	for _, u := range []string{"owner", "editor", "viewer"} {
+		require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: u, Email: u + "@acme.com"}))
+	}
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "owner", OrgID: "org-1", Role: auth.RoleEditor}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "editor", OrgID: "org-1", Role: auth.RoleEditor}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "viewer", OrgID: "org-1", Role: auth.RoleViewer}))
+	_, err := s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-1", OrgID: "org-1", OwnerUserID: "owner", Name: "Plan"})
+	require.NoError(t, err)
+
+	do := func(userID string, role auth.Role, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, userID, "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	listCount := func(userID string, role auth.Role) int {
+		w := do(userID, role, http.MethodGet, "/v1/templates", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Templates []store.Template `json:"templates"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return len(resp.Templates)
+	}
+
+	// Org-visible by default: org roles apply.
+	assert.Equal(t, 1, listCount("editor", auth.RoleEditor))
+	assert.Equal(t, http.StatusForbidden, do("viewer", auth.RoleViewer, http.MethodPost, "/v1/templates/tpl-1/versions", `{"spec":{}}`).Code)
+
+	// Only the owner (or an admin) can change visibility.
+	assert.Equal(t, http.StatusForbidden, do("editor", auth.RoleEditor, http.MethodPut, "/v1/templates/tpl-1/visibility", `{"visibility":"private"}`).Code)
+	require.Equal(t, http.StatusOK, do("owner", auth.RoleEditor, http.MethodPut, "/v1/templates/tpl-1/visibility", `{"visibility":"private"}`).Code)
+
+	assert.Equal(t, 0, listCount("editor", auth.RoleEditor))
+	assert.Equal(t, http.StatusNotFound, do("editor", auth.RoleEditor, http.MethodGet, "/v1/templates/tpl-1", "").Code)
+	assert.Equal(t, 1, listCount("admin", auth.RoleAdmin))
+
+	// An edit grant lets an org Viewer edit this one template.
+	w := do("owner", auth.RoleEditor, http.MethodPut, "/v1/templates/tpl-1/permissions/viewer", `{"permission":"edit"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 1, listCount("viewer", auth.RoleViewer))
+	assert.Equal(t, http.StatusOK, do("viewer", auth.RoleViewer, http.MethodPost, "/v1/templates/tpl-1/versions", `{"spec":{}}`).Code)
+	// Grantees can't re-share.
+	assert.Equal(t, http.StatusForbidden, do("viewer", auth.RoleViewer, http.MethodPut, "/v1/templates/tpl-1/permissions/editor", `{"permission":"view"}`).Code)
+
+	assert.Equal(t, http.StatusBadRequest, do("owner", auth.RoleEditor, http.MethodPut, "/v1/templates/tpl-1/permissions/stranger", `{"permission":"view"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do("owner", auth.RoleEditor, http.MethodPut, "/v1/templates/tpl-1/permissions/editor", `{"permission":"manage"}`).Code)
+
+	require.Equal(t, http.StatusNoContent, do("owner", auth.RoleEditor, http.MethodDelete, "/v1/templates/tpl-1/permissions/viewer", "").Code)
+	assert.Equal(t, 0, listCount("viewer", auth.RoleViewer))
+}
+
+func TestResourcePermissions_DeckEditGrant(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "viewer", Email: "viewer@acme.com"}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "viewer", OrgID: "org-1", Role: auth.RoleViewer}))
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", OwnerUserID: "owner", Name: "Deck"})
+	require.NoError(t, err)
+
+	patch := func() int {
+		req := httptest.NewRequest(http.MethodPatch, "/v1/decks/deck-1", strings.NewReader(`{"name":"Renamed"}`))
+		addTestAuth(req, "viewer", "org-1", auth.RoleViewer)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusForbidden, patch())
+
+	_, err = s.Store.Permissions().Grant(ctx, store.ResourcePermission{OrgID: "org-1", ResourceType: store.ResourceDeck, ResourceID: "deck-1", UserID: "viewer", Permission: store.PermissionEdit})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, patch())
+}
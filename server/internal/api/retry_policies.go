@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/queue"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// retryPolicies resolves the policies the worker applies: platform settings
+// over RETRY_<TYPE>_* env vars over the built-in defaults.
+func (s *Server) retryPolicies(r *http.Request) (queue.PolicySet, error) {
+	settings, err := s.Store.RetryPolicies().List(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	return queue.Resolve(s.Config.RetryOverrides, settings), nil
+}
+
+// handleListRetryPolicies handles GET /v1/admin/platform/retry-policies.
+func (s *Server) handleListRetryPolicies(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requirePlatformAdmin(w, r); !ok {
+		return
+	}
+	policies, err := s.retryPolicies(r)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_retry_policies", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load retry policies")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"policies": policies.List()})
+}
+
+// handlePutRetryPolicy handles PUT /v1/admin/platform/retry-policies/{type}.
+// The override replaces any earlier one for the type. Jobs already retrying
+// keep the retry limit they were given at their first failure.
+func (s *Server) handlePutRetryPolicy(w http.ResponseWriter, r *http.Request) {
+	id, ok := requirePlatformAdmin(w, r)
+	if !ok {
+		return
+	}
+	jobType := store.JobType(r.PathValue("type"))
+	if !slices.Contains(queue.JobTypes, jobType) {
+		writeError(w, r, http.StatusNotFound, "unknown job type")
+		return
+	}
+	var req RetryPolicyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	o := store.RetryPolicyOverride{
+		JobType:     jobType,
+		MaxRetries:  req.MaxRetries,
+		BaseDelayMs: req.BaseDelayMs,
+		MaxDelayMs:  req.MaxDelayMs,
+		Jitter:      req.Jitter,
+		UpdatedBy:   id.UserID,
+	}
+	effective := queue.Resolve(s.Config.RetryOverrides, []store.RetryPolicyOverride{o})[jobType]
+	if effective.MaxDelayMs < effective.BaseDelayMs {
+		writeErrorCode(w, r, http.StatusBadRequest, ErrCodeValidation, "maxDelayMs must not be less than baseDelayMs",
+			[]FieldError{{Field: "maxDelayMs", Rule: "gtefield", Param: "baseDelayMs"}})
+		return
+	}
+
+	saved, err := s.Store.RetryPolicies().Put(r.Context(), o)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "put_retry_policy", err, "job_type", jobType)
+		writeError(w, r, http.StatusInternalServerError, "failed to save retry policy")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "retry_policy.update", TargetRef: string(jobType), Metadata: map[string]any{"platform": true, "override": saved}})
+	writeJSON(w, http.StatusOK, map[string]any{"override": saved, "policy": effective})
+}
+
+// handleDeleteRetryPolicy handles DELETE
+// /v1/admin/platform/retry-policies/{type}, returning the type to its env or
+// default policy.
+func (s *Server) handleDeleteRetryPolicy(w http.ResponseWriter, r *http.Request) {
+	id, ok := requirePlatformAdmin(w, r)
+	if !ok {
+		return
+	}
+	jobType := store.JobType(r.PathValue("type"))
+	deleted, err := s.Store.RetryPolicies().Delete(r.Context(), jobType)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "delete_retry_policy", err, "job_type", jobType)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete retry policy")
+		return
+	}
+	if !deleted {
+		writeError(w, r, http.StatusNotFound, "no retry policy override for this job type")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "retry_policy.reset", TargetRef: string(jobType), Metadata: map[string]any{"platform": true}})
+	w.WriteHeader(http.StatusNoContent)
+}
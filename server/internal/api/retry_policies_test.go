@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/queue"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestRetryPolicies_OverrideAndReset(t *testing.T) {
+	server := NewServer()
+	retries := 7
+	base := 2 * time.Second
+	server.Config.RetryOverrides = map[store.JobType]queue.Override{
+		store.JobPreview: {MaxRetries: &retries, InitialDelay: &base},
+	}
+	h := server.Handler()
+	addPlatformAdmin(t, server, "ops-1", "org-ops")
+
+	do := func(method, path, body, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		addTestAuth(req, userID, "org-ops", auth.RoleOwner)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	policies := func() map[store.JobType]queue.EffectivePolicy {
+		w := do("GET", "/v1/admin/platform/retry-policies", "", "ops-1")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Policies []queue.EffectivePolicy `json:"policies"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		out := map[store.JobType]queue.EffectivePolicy{}
+		for _, p := range resp.Policies {
+			out[p.JobType] = p
+		}
+		return out
+	}
+
+	assert.Equal(t, http.StatusForbidden, do("GET", "/v1/admin/platform/retry-policies", "", "owner-1").Code)
+	assert.Equal(t, http.StatusForbidden, do("PUT", "/v1/admin/platform/retry-policies/export", `{"maxRetries":1}`, "owner-1").Code)
+
+	got := policies()
+	assert.Len(t, got, len(queue.JobTypes))
+	assert.Equal(t, queue.SourceDefault, got[store.JobExport].Source)
+	assert.Equal(t, 5, got[store.JobExport].MaxRetries)
+	assert.Equal(t, queue.SourceEnv, got[store.JobPreview].Source)
+	assert.Equal(t, 7, got[store.JobPreview].MaxRetries)
+	assert.Equal(t, int64(2000), got[store.JobPreview].BaseDelayMs)
+
+	w := do("PUT", "/v1/admin/platform/retry-policies/preview", `{"maxRetries":1,"jitter":0.5}`, "ops-1")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	got = policies()
+	assert.Equal(t, queue.SourceSettings, got[store.JobPreview].Source)
+	assert.Equal(t, 1, got[store.JobPreview].MaxRetries)
+	assert.Equal(t, 0.5, got[store.JobPreview].Jitter)
+	assert.Equal(t, int64(2000), got[store.JobPreview].BaseDelayMs, "fields the override leaves out come from the env")
+
+	assert.Equal(t, http.StatusBadRequest, do("PUT", "/v1/admin/platform/retry-policies/export", `{"jitter":2}`, "ops-1").Code)
+	w = do("PUT", "/v1/admin/platform/retry-policies/export", `{"baseDelayMs":60000,"maxDelayMs":1000}`, "ops-1")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), ErrCodeValidation)
+	assert.Equal(t, http.StatusNotFound, do("PUT", "/v1/admin/platform/retry-policies/nope", `{"maxRetries":1}`, "ops-1").Code)
+
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/v1/admin/platform/retry-policies/preview", "", "ops-1").Code)
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/v1/admin/platform/retry-policies/preview", "", "ops-1").Code)
+	assert.Equal(t, queue.SourceEnv, policies()[store.JobPreview].Source)
+}
@@ -70,3 +70,25 @@ func TestValidateEndpoint_InvalidOverlap(t *testing.T) {
 		t.Fatalf("expected overlap in response, got: %s", w.Body.String())
 	}
 }
+
+func TestRequestIDPropagatesToErrorBody(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/templates/missing", nil)
+	req.Header.Set("X-Request-Id", "req-abc123")
+	addTestAuth(req, "user-1", "org-1", "Editor")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if got := w.Header().Get("X-Request-Id"); got != "req-abc123" {
+		t.Fatalf("expected response header to echo request id, got %q", got)
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode error body: %v", err)
+	}
+	if body.Request != "req-abc123" {
+		t.Fatalf("expected requestId in error body, got %q", body.Request)
+	}
+}
@@ -1,58 +1,99 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/assets"
 	"github.com/ziyad/cms-ai/server/internal/auth"
 	"github.com/ziyad/cms-ai/server/internal/logger"
 	"github.com/ziyad/cms-ai/server/internal/middleware"
+	"github.com/ziyad/cms-ai/server/internal/service"
 	"github.com/ziyad/cms-ai/server/internal/spec"
 	"github.com/ziyad/cms-ai/server/internal/store"
 )
 
+// publicPaths are served without authentication.
+var publicPaths = []string{
+	"/v1/auth/signup",
+	"/v1/auth/signin",
+	"/v1/auth/user", // Legacy endpoint
+	"/v1/auth/oidc/providers",
+	"/v1/auth/oidc/start",
+	"/v1/auth/oidc/callback",
+	"/v1/openapi.json",
+	"/healthz",
+	"/readyz",
+}
+
 func (s *Server) Handler() http.Handler {
-	mux := http.NewServeMux()
+	mux := newRouteMux()
+	embedRoutes := s.embedRoutes()
 
 	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 	})
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	mux.HandleFunc("GET /v1/openapi.json", s.handleOpenAPI(mux, embedRoutes))
 
 	// Auth endpoints (no auth middleware for signup/signin)
 	mux.HandleFunc("POST /v1/auth/signup", s.handleSignup)
 	mux.HandleFunc("GET /v1/auth/signup", func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("DEBUG: GET request to /v1/auth/signup - this should be POST")
 		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed - use POST")
 	})
 	mux.HandleFunc("/v1/auth/signup", func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("DEBUG: %s request to /v1/auth/signup - unsupported method", r.Method)
 		writeError(w, r, http.StatusMethodNotAllowed, "only POST supported")
 	})
 	mux.HandleFunc("POST /v1/auth/signin", s.handleSignin)
 	mux.HandleFunc("POST /v1/auth/user", s.handleGetOrCreateUser) // Legacy endpoint
+	mux.HandleFunc("GET /v1/auth/oidc/providers", s.handleListOIDCProviders)
+	mux.HandleFunc("GET /v1/auth/oidc/start", s.handleOIDCStart)
+	mux.HandleFunc("GET /v1/auth/oidc/callback", s.handleOIDCCallback)
 
 	// Protected auth endpoint (requires auth)
 	mux.HandleFunc("GET /v1/auth/me", s.handleGetMe) // Get current user from JWT
+	mux.HandleFunc("PATCH /v1/auth/me", s.handleUpdateMe)
+	mux.HandleFunc("POST /v1/auth/me/email", s.handleChangeEmail)
+	mux.HandleFunc("POST /v1/auth/me/email/verify", s.handleVerifyEmail)
 
 	mux.HandleFunc("POST /v1/templates/validate", s.handleValidateTemplateSpec)
 	mux.HandleFunc("POST /v1/templates/analyze", s.handleAnalyzeTemplate)
+	mux.HandleFunc("POST /v1/templates/import", s.handleImportTemplate)
+	mux.HandleFunc("POST /v1/templates/import/google", s.handleImportGoogleSlides)
 	mux.HandleFunc("POST /v1/design/analyze", s.AnalyzeDesign)
 	mux.HandleFunc("POST /v1/templates", s.handleCreateTemplate)
 	mux.HandleFunc("POST /v1/templates/generate", s.handleGenerateTemplate)
 	mux.HandleFunc("GET /v1/templates", s.handleListTemplates)
 	mux.HandleFunc("GET /v1/templates/{id}", s.handleGetTemplate)
+	mux.HandleFunc("PATCH /v1/templates/{id}", s.handleUpdateTemplate)
 	mux.HandleFunc("POST /v1/templates/{id}/versions", s.handleCreateVersion)
 	mux.HandleFunc("GET /v1/templates/{id}/versions", s.handleListVersions)
+	mux.HandleFunc("GET /v1/templates/{id}/permissions", s.handleListResourcePermissions(store.ResourceTemplate))
+	mux.HandleFunc("PUT /v1/templates/{id}/permissions/{userId}", s.handleGrantResourcePermission(store.ResourceTemplate))
+	mux.HandleFunc("DELETE /v1/templates/{id}/permissions/{userId}", s.handleRevokeResourcePermission(store.ResourceTemplate))
+	mux.HandleFunc("PUT /v1/templates/{id}/visibility", s.handleUpdateResourceVisibility(store.ResourceTemplate))
+	mux.HandleFunc("POST /v1/templates/{id}/submit-review", s.handleTemplateTransition(store.TemplateInReview, "submit_review"))
+	mux.HandleFunc("POST /v1/templates/{id}/approve", s.handleTemplateTransition(store.TemplateApproved, "approve"))
+	mux.HandleFunc("POST /v1/templates/{id}/reject", s.handleTemplateTransition(store.TemplateDraft, "reject"))
+	mux.HandleFunc("POST /v1/templates/{id}/publish", s.handleTemplateTransition(store.TemplatePublished, "publish"))
+	mux.HandleFunc("PUT /v1/templates/{id}/tags/{tagId}", s.handleAttachTag(store.ResourceTemplate))
+	mux.HandleFunc("DELETE /v1/templates/{id}/tags/{tagId}", s.handleDetachTag(store.ResourceTemplate))
+	mux.HandleFunc("PUT /v1/templates/{id}/folder", s.handleMoveToFolder(store.ResourceTemplate))
 
 	mux.HandleFunc("POST /v1/decks/outline", s.handleCreateDeckOutline)
+	mux.HandleFunc("POST /v1/decks/from-markdown", s.handleCreateDeckFromMarkdown)
+	mux.HandleFunc("POST /v1/decks/bulk-export", s.handleBulkExportDecks)
+	mux.HandleFunc("POST /v1/decks/{id}/batch-merge", s.handleBatchMerge)
+	mux.HandleFunc("POST /v1/decks/merge", s.handleMergeDecks)
 	mux.HandleFunc("POST /v1/decks", s.handleCreateDeck)
 	mux.HandleFunc("GET /v1/decks", s.handleListDecks)
 	mux.HandleFunc("GET /v1/decks/{id}", s.handleGetDeck)
@@ -60,98 +101,271 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("POST /v1/decks/{id}/versions", s.handleCreateDeckVersion)
 	mux.HandleFunc("GET /v1/decks/{id}/versions", s.handleListDeckVersions)
 	mux.HandleFunc("GET /v1/decks/{id}/exports", s.handleListDeckExports)
+	mux.HandleFunc("POST /v1/decks/{id}/refine", s.handleRefineDeck)
+	mux.HandleFunc("GET /v1/decks/{id}/refinements", s.handleListDeckRefinements)
+	mux.HandleFunc("POST /v1/decks/{id}/translate", s.handleTranslateDeck)
+	mux.HandleFunc("POST /v1/decks/{id}/upgrade-template", s.handleUpgradeDeckTemplate)
+	mux.HandleFunc("GET /v1/decks/{id}/translations", s.handleListDeckTranslations)
+	mux.HandleFunc("GET /v1/decks/{id}/present", s.handlePresentDeck)
+	mux.HandleFunc("GET /v1/decks/{id}/present/slides/{index}/thumbnail", s.handlePresentSlideThumbnail)
+	mux.HandleFunc("POST /v1/decks/{id}/embeds", s.handleCreateDeckEmbed)
+	mux.HandleFunc("GET /v1/decks/{id}/embeds", s.handleListDeckEmbeds)
+	mux.HandleFunc("DELETE /v1/decks/{id}/embeds/{embedId}", s.handleDeleteDeckEmbed)
+	mux.HandleFunc("GET /v1/decks/{id}/permissions", s.handleListResourcePermissions(store.ResourceDeck))
+	mux.HandleFunc("PUT /v1/decks/{id}/permissions/{userId}", s.handleGrantResourcePermission(store.ResourceDeck))
+	mux.HandleFunc("DELETE /v1/decks/{id}/permissions/{userId}", s.handleRevokeResourcePermission(store.ResourceDeck))
+	mux.HandleFunc("PUT /v1/decks/{id}/visibility", s.handleUpdateResourceVisibility(store.ResourceDeck))
+	mux.HandleFunc("PUT /v1/decks/{id}/tags/{tagId}", s.handleAttachTag(store.ResourceDeck))
+	mux.HandleFunc("DELETE /v1/decks/{id}/tags/{tagId}", s.handleDetachTag(store.ResourceDeck))
+	mux.HandleFunc("PUT /v1/decks/{id}/folder", s.handleMoveToFolder(store.ResourceDeck))
+	mux.HandleFunc("PATCH /v1/deck-versions/{versionId}/slides", s.handleEditSlides)
+	mux.HandleFunc("POST /v1/deck-versions/{versionId}/slides/{index}/regenerate", s.handleRegenerateSlide)
+	mux.HandleFunc("POST /v1/deck-versions/{versionId}/transform", s.handleTransformDeck)
+	mux.HandleFunc("GET /v1/deck-versions/{versionId}/review", s.handleGetDeckVersionReview)
 	mux.HandleFunc("POST /v1/deck-versions/{versionId}/export", s.handleExportDeckVersion)
+	mux.HandleFunc("POST /v1/deck-versions/{versionId}/comments", s.handleCreateComment)
+	mux.HandleFunc("GET /v1/deck-versions/{versionId}/comments", s.handleListComments)
+	mux.HandleFunc("GET /v1/deck-versions/{versionId}/jobs", s.handleListDeckVersionJobs)
+	mux.HandleFunc("POST /v1/comments/{commentId}/resolve", s.handleSetCommentResolved(true))
+	mux.HandleFunc("POST /v1/comments/{commentId}/unresolve", s.handleSetCommentResolved(false))
 	mux.HandleFunc("PATCH /v1/versions/{versionId}", s.handlePatchVersion)
 	mux.HandleFunc("POST /v1/versions/{versionId}/render", s.handleRenderVersion)
 	mux.HandleFunc("POST /v1/versions/{versionId}/export", s.handleExportVersion)
+	mux.HandleFunc("GET /v1/versions/{versionId}/jobs", s.handleListVersionJobs)
+	mux.HandleFunc("GET /v1/assets", s.handleListAssets)
 	mux.HandleFunc("GET /v1/assets/{id}/download-url", s.handleDownloadURL)
 	mux.HandleFunc("GET /v1/assets/{id}", s.handleAssetDownload)
+	mux.HandleFunc("DELETE /v1/assets/{id}", s.handleDeleteAsset)
 	mux.HandleFunc("POST /v1/jobs", s.handleCreateJob)
 	mux.HandleFunc("GET /v1/jobs/{jobId}", s.handleGetJob)
 	mux.HandleFunc("GET /v1/jobs/{jobId}/assets/{filename}", s.handleJobAssetDownload)
 	mux.HandleFunc("GET /v1/admin/jobs/dead-letter", s.handleListDeadLetterJobs)
 	mux.HandleFunc("POST /v1/admin/jobs/{jobId}/retry", s.handleRetryDeadLetterJob)
+	mux.HandleFunc("GET /v1/admin/ai/calls", s.handleListAICalls)
+	mux.HandleFunc("GET /v1/admin/platform/jobs", s.handleListPlatformJobs)
+	mux.HandleFunc("POST /v1/admin/platform/jobs/requeue", s.handleRequeuePlatformJobs)
+	mux.HandleFunc("POST /v1/admin/platform/jobs/purge", s.handlePurgePlatformDeadLetter)
+	mux.HandleFunc("GET /v1/admin/platform/retry-policies", s.handleListRetryPolicies)
+	mux.HandleFunc("PUT /v1/admin/platform/retry-policies/{type}", s.handlePutRetryPolicy)
+	mux.HandleFunc("DELETE /v1/admin/platform/retry-policies/{type}", s.handleDeleteRetryPolicy)
+	mux.HandleFunc("GET /v1/admin/platform/feature-flags", s.handleListFeatureFlags)
+	mux.HandleFunc("PUT /v1/admin/platform/feature-flags/{name}", s.handlePutFeatureFlag)
+	mux.HandleFunc("DELETE /v1/admin/platform/feature-flags/{name}", s.handleDeleteFeatureFlag)
+	mux.HandleFunc("GET /v1/admin/platform/stats/orgs", s.handlePlatformOrgStats)
+	mux.HandleFunc("GET /v1/admin/platform/stats/active-users", s.handlePlatformActiveUsers)
+	mux.HandleFunc("GET /v1/admin/platform/stats/jobs", s.handlePlatformJobStats)
+	mux.HandleFunc("GET /v1/admin/platform/stats/ai-spend", s.handlePlatformAISpend)
+	mux.HandleFunc("GET /v1/admin/platform/stats/storage", s.handlePlatformStorage)
+	mux.HandleFunc("GET /v1/admin/platform/stats/cache", s.handlePlatformCacheStats)
+	mux.HandleFunc("POST /v1/admin/events/simulate", s.handleSimulateEvent)
+	mux.HandleFunc("POST /v1/webhooks", s.handleCreateWebhook)
+	mux.HandleFunc("GET /v1/webhooks", s.handleListWebhooks)
+	mux.HandleFunc("PATCH /v1/webhooks/{id}", s.handleUpdateWebhook)
+	mux.HandleFunc("DELETE /v1/webhooks/{id}", s.handleDeleteWebhook)
+	mux.HandleFunc("GET /v1/webhooks/{id}/deliveries", s.handleListWebhookDeliveries)
+	mux.HandleFunc("POST /v1/webhooks/{id}/test", s.handleTestWebhook)
 	mux.HandleFunc("POST /v1/brand-kits", s.handleCreateBrandKit)
 	mux.HandleFunc("GET /v1/brand-kits", s.handleListBrandKits)
+	mux.HandleFunc("POST /v1/fonts", s.handleUploadFont)
+	mux.HandleFunc("GET /v1/fonts", s.handleListFonts)
+	mux.HandleFunc("DELETE /v1/fonts/{id}", s.handleDeleteFont)
+	mux.HandleFunc("GET /v1/glossary", s.handleListGlossary)
+	mux.HandleFunc("POST /v1/glossary", s.handleCreateGlossaryEntry)
+	mux.HandleFunc("PUT /v1/glossary/{id}", s.handleUpdateGlossaryEntry)
+	mux.HandleFunc("DELETE /v1/glossary/{id}", s.handleDeleteGlossaryEntry)
+	mux.HandleFunc("POST /v1/glossary/check", s.handleCheckGlossary)
+	mux.HandleFunc("GET /v1/schedules", s.handleListSchedules)
+	mux.HandleFunc("POST /v1/schedules", s.handleCreateSchedule)
+	mux.HandleFunc("GET /v1/schedules/{id}", s.handleGetSchedule)
+	mux.HandleFunc("PATCH /v1/schedules/{id}", s.handleUpdateSchedule)
+	mux.HandleFunc("DELETE /v1/schedules/{id}", s.handleDeleteSchedule)
+	mux.HandleFunc("GET /v1/connectors", s.handleListConnectors)
+	mux.HandleFunc("POST /v1/connectors", s.handleCreateConnector)
+	mux.HandleFunc("GET /v1/connectors/{id}", s.handleGetConnector)
+	mux.HandleFunc("PATCH /v1/connectors/{id}", s.handleUpdateConnector)
+	mux.HandleFunc("DELETE /v1/connectors/{id}", s.handleDeleteConnector)
+	mux.HandleFunc("POST /v1/connectors/{id}/refresh", s.handleRefreshConnector)
+	mux.HandleFunc("GET /v1/folders", s.handleListFolders)
+	mux.HandleFunc("POST /v1/folders", s.handleCreateFolder)
+	mux.HandleFunc("PATCH /v1/folders/{id}", s.handleUpdateFolder)
+	mux.HandleFunc("DELETE /v1/folders/{id}", s.handleDeleteFolder)
+	mux.HandleFunc("GET /v1/tags", s.handleListTags)
+	mux.HandleFunc("POST /v1/tags", s.handleCreateTag)
+	mux.HandleFunc("PATCH /v1/tags/{id}", s.handleUpdateTag)
+	mux.HandleFunc("DELETE /v1/tags/{id}", s.handleDeleteTag)
 	mux.HandleFunc("GET /v1/usage", s.handleUsage)
+	mux.HandleFunc("GET /v1/analytics/templates", s.handleTemplateAnalytics)
+	mux.HandleFunc("GET /v1/org/feature-flags", s.handleGetOrgFeatureFlags)
+	mux.HandleFunc("GET /v1/org/settings", s.handleGetOrgSettings)
+	mux.HandleFunc("PUT /v1/org/settings", s.handleUpdateOrgSettings)
+	mux.HandleFunc("POST /v1/org/sso-domain/verify", s.handleVerifySSODomain)
+	mux.HandleFunc("GET /v1/org/policy", s.handleGetOrgPolicy)
+	mux.HandleFunc("PUT /v1/org/policy", s.handleSetOrgPolicy)
+	mux.HandleFunc("DELETE /v1/org/policy", s.handleDeleteOrgPolicy)
+	mux.HandleFunc("POST /v1/orgs/{id}/deletion-token", s.handleCreateOrgDeletionToken)
+	mux.HandleFunc("DELETE /v1/orgs/{id}", s.handleDeleteOrg)
+	mux.HandleFunc("GET /v1/orgs/{id}/export", s.handleExportOrg)
+	mux.HandleFunc("POST /v1/org/scim-token", s.handleRotateSCIMToken)
+	mux.HandleFunc("DELETE /v1/org/scim-token", s.handleRevokeSCIMToken)
+	mux.HandleFunc("GET /v1/api-keys", s.handleListAPIKeys)
+	mux.HandleFunc("POST /v1/api-keys", s.handleCreateAPIKey)
+	mux.HandleFunc("DELETE /v1/api-keys/{id}", s.handleDeleteAPIKey)
+	mux.HandleFunc("PUT /v1/org/google-credentials", s.handleSetGoogleCredentials)
+	mux.HandleFunc("DELETE /v1/org/google-credentials", s.handleDeleteGoogleCredentials)
+	mux.HandleFunc("GET /v1/integrations/slack", s.handleGetSlackIntegration)
+	mux.HandleFunc("PUT /v1/integrations/slack", s.handleSetSlackIntegration)
+	mux.HandleFunc("DELETE /v1/integrations/slack", s.handleDeleteSlackIntegration)
+	mux.HandleFunc("POST /v1/integrations/slack/test", s.handleTestSlackIntegration)
+	mux.HandleFunc("GET /v1/integrations/stock-media", s.handleGetStockMediaIntegration)
+	mux.HandleFunc("PUT /v1/integrations/stock-media", s.handleSetStockMediaIntegration)
+	mux.HandleFunc("DELETE /v1/integrations/stock-media", s.handleDeleteStockMediaIntegration)
+	mux.HandleFunc("GET /v1/integrations/notion", s.handleGetNotionIntegration)
+	mux.HandleFunc("PUT /v1/integrations/notion", s.handleSetNotionIntegration)
+	mux.HandleFunc("DELETE /v1/integrations/notion", s.handleDeleteNotionIntegration)
+	mux.HandleFunc("POST /v1/integrations/notion/import", s.handleImportNotionPage)
+	mux.HandleFunc("GET /v1/integrations/confluence", s.handleGetConfluenceIntegration)
+	mux.HandleFunc("PUT /v1/integrations/confluence", s.handleSetConfluenceIntegration)
+	mux.HandleFunc("DELETE /v1/integrations/confluence", s.handleDeleteConfluenceIntegration)
+	mux.HandleFunc("POST /v1/integrations/confluence/import", s.handleImportConfluencePage)
+	mux.HandleFunc("GET /v1/images/search", s.handleSearchImages)
+	mux.HandleFunc("POST /v1/images/stock", s.handleImportStockImage)
+	mux.HandleFunc("POST /v1/backgrounds", s.handleGenerateBackground)
+	mux.HandleFunc("GET /v1/backgrounds", s.handleListBackgrounds)
 
 	// Database diagnostics endpoints
 	mux.HandleFunc("GET /v1/admin/db/diagnostics", s.handleDatabaseDiagnostics)
 	mux.HandleFunc("GET /v1/admin/db/query", s.handleDatabaseQuery)
 
 	h := http.Handler(mux)
+	h = s.withFeatureFlags(h)
+	h = s.withActivity(h)
 	h = requireJSON(h)
 	h = middleware.ValidationMiddleware(h)
 	h = withRequestID(h)
 
 	// Re-enable auth middleware with skip paths for public endpoints
-	skipPaths := []string{
-		"/v1/auth/signup",
-		"/v1/auth/signin",
-		"/v1/auth/user", // Legacy endpoint
-		"/healthz",
-	}
-	// Use the server's configured authenticator (JWT only - header auth removed for security)
-	authMiddleware := withAuth(s.Authenticator)
-	h = skipAuthForPaths(h, skipPaths, authMiddleware)
+	// API keys are checked first; anything else goes to the server's
+	// configured authenticator (JWT only - header auth removed for security)
+	authMiddleware := withAuth(apiKeyAuthenticator{s: s, next: s.Authenticator}, s.resolveIdentity)
+	h = skipAuthForPaths(h, publicPaths, authMiddleware)
 
 	h = middleware.RecoveryMiddleware(h)
 	h = middleware.LoggingMiddleware(h)
 
+	// SCIM uses its own bearer tokens, so it bypasses the JWT chain entirely
+	scim := s.scimHandler()
+	// Embeds are public and authenticated by the token in their path
+	embed := s.embedHandler(embedRoutes)
+
 	// Wrap with catch-all handler that returns 404 for unmatched routes
 	// This prevents auth middleware from returning unauthorized for non-API routes
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	root := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/scim/v2/") {
+			scim.ServeHTTP(w, r)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, embedPathPrefix) {
+			embed.ServeHTTP(w, r)
+			return
+		}
+
 		// If path doesn't match any route, return 404 without auth
-		if !strings.HasPrefix(r.URL.Path, "/v1/") && r.URL.Path != "/healthz" {
+		if !strings.HasPrefix(r.URL.Path, "/v1/") && r.URL.Path != "/healthz" && r.URL.Path != "/readyz" {
 			writeError(w, r, http.StatusNotFound, "not found")
 			return
 		}
 
-		// DEBUG: Log all /v1/* requests to help debug routing issues
-		urlParts := strings.Split(r.URL.Path, "/")
-		log.Printf("DEBUG: %s %s - urlParts: %v", r.Method, r.URL.Path, urlParts)
-
 		// Otherwise, use the main handler (which includes auth for /v1/*)
 		h.ServeHTTP(w, r)
 	})
+	return withSecurityHeaders(s.Config, withCORS(s.Config, root))
 }
 
 func (s *Server) handleValidateTemplateSpec(w http.ResponseWriter, r *http.Request) {
 	var ts spec.TemplateSpec
 	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
 	if err := dec.Decode(&ts); err != nil {
-		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		writeInvalidJSON(w, r)
 		return
 	}
 
 	errList := s.Validator.Validate(ts)
 	if len(errList) > 0 {
-		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"errors": errList})
+		writeErrorCode(w, r, http.StatusUnprocessableEntity, ErrCodeSpecInvalid, "template spec is invalid", errList)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	id, _ := auth.GetIdentity(r.Context())
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "warnings": s.specWarnings(r, id.OrgID, ts)})
+}
+
+// checkSpecVersion refuses a spec written for a newer schema than this
+// server's, which it could only save by dropping what it doesn't know.
+func checkSpecVersion(w http.ResponseWriter, r *http.Request, specJSON []byte) bool {
+	if err := spec.CheckVersion(specJSON); err != nil {
+		writeErrorCode(w, r, http.StatusUnprocessableEntity, ErrCodeSpecInvalid, err.Error(), []spec.ValidationError{{Path: "$.specVersion", Message: err.Error()}})
+		return false
+	}
+	return true
 }
 
 func (s *Server) handleAnalyzeTemplate(w http.ResponseWriter, r *http.Request) {
 	var req AnalyzeTemplateRequest
 	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
 		logger.LogError(r.Context(), "api", "decode_request", err)
-		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		writeInvalidJSON(w, r)
 		return
 	}
 
 	// Validate request
 	if err := s.validate.Struct(req); err != nil {
-		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("validation failed: %v", err))
+		writeValidationError(w, r, err)
 		return
 	}
 
 	logger.AI().Info("analyzing_template_prompt", "prompt_len", len(req.Prompt))
 
-	// Analyze the prompt to determine template type and required fields
-	analysis := analyzeTemplatePrompt(req.Prompt)
+	id, _ := auth.GetIdentity(r.Context())
+	writeJSON(w, http.StatusOK, s.analyzePrompt(r.Context(), id, req.Prompt))
+}
 
-	writeJSON(w, http.StatusOK, analysis)
+// templateFields converts request fields for storage on a template version.
+func templateFields(fields []RequiredField) []store.TemplateField {
+	if len(fields) == 0 {
+		return nil
+	}
+	out := make([]store.TemplateField, len(fields))
+	for i, f := range fields {
+		out[i] = store.TemplateField(f)
+	}
+	return out
+}
+
+// analyzePrompt asks the AI service what template prompt describes, falling
+// back to keyword heuristics when the service can't analyze prompts, fails,
+// or gives an unusable answer.
+func (s *Server) analyzePrompt(ctx context.Context, id auth.Identity, prompt string) AnalyzeTemplateResponse {
+	if analyzer, ok := s.AIService.(ai.PromptAnalyzer); ok {
+		a, err := analyzer.AnalyzePrompt(ctx, id.OrgID, id.UserID, prompt)
+		if err == nil {
+			fields := make([]RequiredField, len(a.RequiredFields))
+			for i, f := range a.RequiredFields {
+				fields[i] = RequiredField(f)
+			}
+			return AnalyzeTemplateResponse{
+				TemplateType:    a.TemplateType,
+				SuggestedName:   a.SuggestedName,
+				RequiredFields:  fields,
+				EstimatedSlides: a.EstimatedSlides,
+				Description:     a.Description,
+				Language:        a.Language,
+				Source:          AnalysisSourceAI,
+			}
+		}
+		logger.AI().Warn("template_analysis_fallback", "error", err)
+	}
+	analysis := analyzeTemplatePrompt(prompt)
+	analysis.Source = AnalysisSourceHeuristic
+	return analysis
 }
 
 func analyzeTemplatePrompt(prompt string) AnalyzeTemplateResponse {
@@ -232,22 +446,26 @@ func (s *Server) handleCreateTemplate(w http.ResponseWriter, r *http.Request) {
 	var req CreateTemplateRequest
 	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
 		logger.LogError(r.Context(), "api", "decode_request", err)
-		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		writeInvalidJSON(w, r)
 		return
 	}
 
 	// Validate request
 	if err := s.validate.Struct(req); err != nil {
-		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("validation failed: %v", err))
+		writeValidationError(w, r, err)
 		return
 	}
 
 	template := store.Template{
+		ID:          newID("tpl"),
 		OrgID:       id.OrgID,
 		OwnerUserID: id.UserID,
 		Name:        req.Name,
 		Status:      store.TemplateDraft,
 	}
+	if !s.applyListing(w, r, id, req.ListingRequest, &template.Listing) {
+		return
+	}
 
 	created, err := s.Store.Templates().CreateTemplate(r.Context(), template)
 	if err != nil {
@@ -268,136 +486,186 @@ func (s *Server) handleGenerateTemplate(w http.ResponseWriter, r *http.Request)
 	var req GenerateTemplateRequest
 	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
 		logger.LogError(r.Context(), "api", "decode_request", err)
-		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		writeInvalidJSON(w, r)
 		return
 	}
 
 	// Validate request
 	if err := s.validate.Struct(req); err != nil {
-		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("validation failed: %v", err))
+		writeValidationError(w, r, err)
 		return
 	}
 
-	if isBlocked, usage := s.enforceQuota(r); isBlocked {
-		writeJSON(w, http.StatusPaymentRequired, usage)
-		return
+	in := service.GenerateInput{
+		Prompt:         req.Prompt,
+		Name:           req.Name,
+		BrandKitID:     req.BrandKitID,
+		RTL:            req.RTL,
+		Language:       req.Language,
+		Tone:           req.Tone,
+		ContentData:    req.ContentData,
+		Params:         req.params(),
+		RequiredFields: templateFields(req.RequiredFields),
 	}
 
-	template := store.Template{
-		ID:          newID("tpl"),
-		OrgID:       id.OrgID,
-		OwnerUserID: id.UserID,
-		Name:        req.Name,
-		Status:      store.TemplateDraft,
-	}
-	if template.Name == "" {
-		template.Name = "Untitled"
+	// ?sync=true generates inside the request; only short prompts qualify
+	// since long ones can outlast the HTTP timeout.
+	if r.URL.Query().Get("sync") == "true" {
+		res, err := s.templateService().GenerateNow(r.Context(), id, in)
+		if err != nil {
+			s.writeServiceError(w, r, "generate_template_sync", "failed to save template", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"template": res.Template, "version": res.Version, "aiResponse": res.AIResponse})
+		return
 	}
 
-	created, err := s.Store.Templates().CreateTemplate(r.Context(), template)
+	res, err := s.templateService().Generate(r.Context(), id, in)
 	if err != nil {
-		logger.LogError(r.Context(), "api", "create_template", err)
-		writeError(w, r, http.StatusInternalServerError, "failed to create template")
+		s.writeServiceError(w, r, "generate_template", "failed to create template", err)
 		return
 	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"template": res.Template, "job": res.Job})
+}
 
-	// Enqueue async generation job
-	metadata := store.JSONMap{
-		"prompt":     req.Prompt,
-		"language":   req.Language,
-		"tone":       req.Tone,
-		"rtl":        fmt.Sprintf("%v", req.RTL),
-		"brandKitId": req.BrandKitID,
-		"userId":     id.UserID,
-	}
+func (s *Server) handleListTemplates(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
 
-	job := store.Job{
-		ID:              newID("job"),
-		OrgID:           id.OrgID,
-		Type:            store.JobGenerate,
-		Status:          store.JobQueued,
-		InputRef:        created.ID,
-		DeduplicationID: fmt.Sprintf("generate-%s", created.ID),
-		Metadata:        &metadata,
+	// The deck-creation picker asks for status=Published so only approved,
+	// released templates are offered.
+	status := store.TemplateStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", store.TemplateDraft, store.TemplateInReview, store.TemplateApproved, store.TemplatePublished, store.TemplateArchived:
+	default:
+		writeError(w, r, http.StatusBadRequest, "invalid status")
+		return
 	}
 
-	createdJob, _, err := s.Store.Jobs().EnqueueWithDeduplication(r.Context(), job)
+	// ?include=currentVersion adds each template's current version, loaded
+	// in one batch rather than a request per template.
+	var tpls []store.TemplateWithVersion
+	var err error
+	switch include := r.URL.Query().Get("include"); include {
+	case "":
+		var ts []store.Template
+		ts, err = s.Store.Templates().ListTemplatesFor(r.Context(), id)
+		tpls = store.WithCurrentVersions(ts, nil)
+	case "currentVersion":
+		tpls, err = s.Store.Templates().ListTemplatesWithCurrentVersion(r.Context(), id)
+	default:
+		writeError(w, r, http.StatusBadRequest, "include must be currentVersion")
+		return
+	}
 	if err != nil {
-		log.Printf("ERROR: Failed to enqueue generate job: %v", err)
-		writeError(w, r, http.StatusInternalServerError, "failed to enqueue job")
+		logger.LogError(r.Context(), "api", "list_templates", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list templates")
+		return
+	}
+	tags, err := s.Store.Tags().ListAttached(r.Context(), id.OrgID, store.ResourceTemplate)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_templates", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list templates")
 		return
 	}
 
-	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "template.generate.queued", TargetRef: created.ID, Metadata: map[string]any{"jobId": createdJob.ID}})
-
-	writeJSON(w, http.StatusAccepted, map[string]any{"template": created, "job": createdJob})
+	filter := parseListFilter(r)
+	out := make([]taggedTemplate, 0, len(tpls))
+	for _, t := range tpls {
+		if status != "" && t.Status != status {
+			continue
+		}
+		if filter.match(t.Name, t.Listing, t.FolderID, tags[t.ID]) {
+			out = append(out, taggedTemplate{TemplateWithVersion: t, Tags: nonNilTags(tags[t.ID])})
+		}
+	}
+	logger.WithContext(r.Context()).Debug("templates_listed", "count", len(out))
+	writeJSON(w, http.StatusOK, map[string]any{"templates": out})
 }
 
-func (s *Server) handleListTemplates(w http.ResponseWriter, r *http.Request) {
-	id, ok := auth.GetIdentity(r.Context())
-	log.Printf("DEBUG: handleListTemplates - Auth OK: %v, UserID: %s, OrgID: %s", ok, id.UserID, id.OrgID)
+func (s *Server) handleGetTemplate(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
 
-	log.Printf("DEBUG: About to call ListTemplates for OrgID: %s", id.OrgID)
-	tpls, err := s.Store.Templates().ListTemplates(r.Context(), id.OrgID)
-	if err != nil {
-		log.Printf("ERROR: ListTemplates failed for OrgID %s: %v", id.OrgID, err)
-		writeError(w, r, http.StatusInternalServerError, "failed to list templates")
+	tpl, ok := s.authorizeTemplate(w, r, id, r.PathValue("id"), store.PermissionView)
+	if !ok {
 		return
 	}
-	log.Printf("DEBUG: ListTemplates success for OrgID %s, found %d templates", id.OrgID, len(tpls))
-	writeJSON(w, http.StatusOK, map[string]any{"templates": tpls})
+	// The usage counts are part of the body, so they are part of the tag:
+	// a new deck or export changes both.
+	usage := s.templateUsage(r.Context(), tpl)
+	etag := resourceETag(tpl.ID, tpl.UpdatedAt, tpl.Status, tpl.LatestVersionNo, tpl.CurrentVersion, usage)
+	writeJSONIfChanged(w, r, etag, map[string]any{"template": tpl, "usage": usage})
 }
 
-func (s *Server) handleGetTemplate(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleUpdateTemplate(w http.ResponseWriter, r *http.Request) {
 	id, _ := auth.GetIdentity(r.Context())
 
-	tplID := r.PathValue("id")
-	tpl, ok, err := s.Store.Templates().GetTemplate(r.Context(), id.OrgID, tplID)
-	if err != nil {
-		writeError(w, r, http.StatusInternalServerError, "failed to get template")
+	var req UpdateTemplateRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
 		return
 	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	tpl, ok := s.authorizeTemplate(w, r, id, r.PathValue("id"), store.PermissionEdit)
 	if !ok {
-		writeError(w, r, http.StatusNotFound, "not found")
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"template": tpl})
+	if req.Name != nil {
+		tpl.Name = *req.Name
+	}
+	if !s.applyListing(w, r, id, req.ListingRequest, &tpl.Listing) {
+		return
+	}
+
+	updated, err := s.Store.Templates().UpdateTemplate(r.Context(), tpl)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "update_template", err, "template_id", tpl.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to update template")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "template.update", TargetRef: updated.ID, Metadata: map[string]any{"name": updated.Name}})
+	writeJSON(w, http.StatusOK, map[string]any{"template": updated})
 }
 
 func (s *Server) handleListVersions(w http.ResponseWriter, r *http.Request) {
 	id, _ := auth.GetIdentity(r.Context())
 	pl := r.PathValue("id")
+	if _, ok := s.authorizeTemplate(w, r, id, pl, store.PermissionView); !ok {
+		return
+	}
 
 	vs, err := s.Store.Templates().ListVersions(r.Context(), id.OrgID, pl)
 	if err != nil {
 		writeError(w, r, http.StatusInternalServerError, "failed to list versions")
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"versions": vs})
+	// Versions never change once created, so their IDs tag the list.
+	ids := make([]string, len(vs))
+	for i, v := range vs {
+		ids[i] = v.ID
+	}
+	writeJSONIfChanged(w, r, resourceETag(ids), map[string]any{"versions": vs})
 }
 
 func (s *Server) handleCreateVersion(w http.ResponseWriter, r *http.Request) {
 	id, _ := auth.GetIdentity(r.Context())
 
-	tplID := r.PathValue("id")
-	tpl, ok, err := s.Store.Templates().GetTemplate(r.Context(), id.OrgID, tplID)
-	if err != nil {
-		writeError(w, r, http.StatusInternalServerError, "failed to get template")
-		return
-	}
+	tpl, ok := s.authorizeTemplate(w, r, id, r.PathValue("id"), store.PermissionEdit)
 	if !ok {
-		writeError(w, r, http.StatusNotFound, "not found")
-		return
-	}
-
-	if !auth.RequireRole(id, auth.RoleEditor) {
-		writeError(w, r, http.StatusForbidden, "forbidden")
 		return
 	}
 
 	var req CreateVersionRequest
 	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
-		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		writeInvalidJSON(w, r)
+		return
+	}
+	fields := templateFields(req.RequiredFields)
+	if err := service.ValidateTemplateFields(fields); err != nil {
+		s.writeServiceError(w, r, "create_version", "failed to create version", err)
 		return
 	}
 
@@ -410,12 +678,15 @@ func (s *Server) handleCreateVersion(w http.ResponseWriter, r *http.Request) {
 	// Convert spec to JSON for storage
 	specJSONBytes, err := json.Marshal(specJSON)
 	if err != nil {
-		log.Printf("ERROR: Failed to marshal spec JSON: %v", err)
+		logger.LogError(r.Context(), "api", "marshal_spec", err)
 		writeError(w, r, http.StatusInternalServerError, "failed to create version")
 		return
 	}
+	if !checkSpecVersion(w, r, specJSONBytes) {
+		return
+	}
 
-	ver := store.TemplateVersion{Template: tpl.ID, OrgID: tpl.OrgID, VersionNo: newNo, SpecJSON: json.RawMessage(specJSONBytes), CreatedBy: id.UserID}
+	ver := store.TemplateVersion{Template: tpl.ID, OrgID: tpl.OrgID, VersionNo: newNo, SpecJSON: json.RawMessage(specJSONBytes), CreatedBy: id.UserID, RequiredFields: fields}
 	created, err := s.Store.Templates().CreateVersion(r.Context(), ver)
 	if err != nil {
 		writeError(w, r, http.StatusInternalServerError, "failed to create version")
@@ -426,7 +697,7 @@ func (s *Server) handleCreateVersion(w http.ResponseWriter, r *http.Request) {
 
 	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "template.version.create", TargetRef: created.ID, Metadata: map[string]any{"templateId": tpl.ID}})
 
-	writeJSON(w, http.StatusOK, map[string]any{"template": createdTpl, "version": created})
+	writeJSON(w, http.StatusOK, map[string]any{"template": createdTpl, "version": created, "warnings": s.specWarnings(r, id.OrgID, specJSONBytes)})
 }
 
 func (s *Server) handlePatchVersion(w http.ResponseWriter, r *http.Request) {
@@ -442,37 +713,43 @@ func (s *Server) handlePatchVersion(w http.ResponseWriter, r *http.Request) {
 		writeError(w, r, http.StatusNotFound, "not found")
 		return
 	}
-	if !auth.RequireRole(id, auth.RoleEditor) {
-		writeError(w, r, http.StatusForbidden, "forbidden")
+	tpl, ok := s.authorizeTemplate(w, r, id, v.Template, store.PermissionEdit)
+	if !ok {
 		return
 	}
 
 	var req PatchVersionRequest
 	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
-		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		writeInvalidJSON(w, r)
 		return
 	}
 	if req.Spec == nil {
 		writeError(w, r, http.StatusBadRequest, "spec is required")
 		return
 	}
+	fields := v.RequiredFields
+	if req.RequiredFields != nil {
+		fields = templateFields(*req.RequiredFields)
+		if err := service.ValidateTemplateFields(fields); err != nil {
+			s.writeServiceError(w, r, "patch_version", "failed to create version", err)
+			return
+		}
+	}
 
 	// Immutable versions strategy: create a new version with incremented version number.
-	tpl, ok2, err := s.Store.Templates().GetTemplate(r.Context(), id.OrgID, v.Template)
-	if err != nil || !ok2 {
-		writeError(w, r, http.StatusInternalServerError, "failed to load template")
-		return
-	}
 	newNo := tpl.LatestVersionNo + 1
 	// Convert spec to JSON for storage
 	specJSONBytes, err := json.Marshal(req.Spec)
 	if err != nil {
-		log.Printf("ERROR: Failed to marshal spec JSON: %v", err)
+		logger.LogError(r.Context(), "api", "marshal_spec", err)
 		writeError(w, r, http.StatusInternalServerError, "failed to create version")
 		return
 	}
+	if !checkSpecVersion(w, r, specJSONBytes) {
+		return
+	}
 
-	newV := store.TemplateVersion{Template: tpl.ID, OrgID: tpl.OrgID, VersionNo: newNo, SpecJSON: json.RawMessage(specJSONBytes), CreatedBy: id.UserID}
+	newV := store.TemplateVersion{Template: tpl.ID, OrgID: tpl.OrgID, VersionNo: newNo, SpecJSON: json.RawMessage(specJSONBytes), CreatedBy: id.UserID, RequiredFields: fields}
 	created, err := s.Store.Templates().CreateVersion(r.Context(), newV)
 	if err != nil {
 		writeError(w, r, http.StatusInternalServerError, "failed to create version")
@@ -483,13 +760,13 @@ func (s *Server) handlePatchVersion(w http.ResponseWriter, r *http.Request) {
 
 	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "template.version.patch", TargetRef: created.ID, Metadata: map[string]any{"fromVersionId": v.ID}})
 
-	writeJSON(w, http.StatusOK, map[string]any{"version": created})
+	writeJSON(w, http.StatusOK, map[string]any{"version": created, "warnings": s.specWarnings(r, id.OrgID, specJSONBytes)})
 }
 
 func (s *Server) handleRenderVersion(w http.ResponseWriter, r *http.Request) {
 	id, _ := auth.GetIdentity(r.Context())
 	versionID := r.PathValue("versionId")
-	_, ok, err := s.Store.Templates().GetVersion(r.Context(), id.OrgID, versionID)
+	v, ok, err := s.Store.Templates().GetVersion(r.Context(), id.OrgID, versionID)
 	if err != nil {
 		writeError(w, r, http.StatusInternalServerError, "failed")
 		return
@@ -498,8 +775,7 @@ func (s *Server) handleRenderVersion(w http.ResponseWriter, r *http.Request) {
 		writeError(w, r, http.StatusNotFound, "not found")
 		return
 	}
-	if !auth.RequireRole(id, auth.RoleEditor) {
-		writeError(w, r, http.StatusForbidden, "forbidden")
+	if _, ok := s.authorizeTemplate(w, r, id, v.Template, store.PermissionEdit); !ok {
 		return
 	}
 
@@ -513,7 +789,7 @@ func (s *Server) handleRenderVersion(w http.ResponseWriter, r *http.Request) {
 	}
 	created, wasDuplicate, err := s.Store.Jobs().EnqueueWithDeduplication(r.Context(), job)
 	if err != nil {
-		log.Printf("ERROR: Failed to enqueue render job: %v", err)
+		logger.LogError(r.Context(), "api", "enqueue_render_job", err)
 		writeError(w, r, http.StatusInternalServerError, "failed to enqueue job")
 		return
 	}
@@ -549,7 +825,8 @@ func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
 		writeError(w, r, http.StatusNotFound, "job not found")
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"job": job})
+	etag := resourceETag(job.ID, job.UpdatedAt, job.Status, job.HeartbeatAt)
+	writeJSONIfChanged(w, r, etag, map[string]any{"job": job})
 }
 
 func (s *Server) handleCreateDeckOutline(w http.ResponseWriter, r *http.Request) {
@@ -562,13 +839,13 @@ func (s *Server) handleCreateDeckOutline(w http.ResponseWriter, r *http.Request)
 	var req CreateDeckOutlineRequest
 	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2<<20)).Decode(&req); err != nil {
 		logger.LogError(r.Context(), "api", "decode_request", err)
-		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		writeInvalidJSON(w, r)
 		return
 	}
 
 	// Validate request
 	if err := s.validate.Struct(req); err != nil {
-		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("validation failed: %v", err))
+		writeValidationError(w, r, err)
 		return
 	}
 
@@ -610,7 +887,7 @@ SOURCE_CONTENT:
 
 	logger.AI().Info("generating_deck_outline", "user_id", id.UserID, "prompt_len", len(req.Prompt), "content_len", len(req.Content))
 
-	jsonText, err := ai.NewOrchestrator().GenerateJSON(r.Context(), genReq.Prompt)
+	jsonText, err := s.orchestrator().GenerateJSON(r.Context(), genReq.Prompt)
 	if err != nil {
 		logger.LogError(r.Context(), "ai", "generate_outline", err)
 		writeError(w, r, http.StatusBadGateway, "failed to generate outline")
@@ -634,271 +911,123 @@ SOURCE_CONTENT:
 	writeJSON(w, http.StatusOK, map[string]any{"outline": outline})
 }
 
-func parseDeckOutline(v any) (*DeckOutline, error) {
-	b, err := assetsSpecBytes(v)
-	if err != nil {
-		return nil, err
-	}
-	var out DeckOutline
-	if err := json.Unmarshal(b, &out); err != nil {
-		return nil, err
-	}
-	if len(out.Slides) == 0 {
-		return nil, fmt.Errorf("no slides")
-	}
-	return &out, nil
-}
-
-func buildDeckSpecFromOutline(templateSpec *spec.TemplateSpec, outline *DeckOutline) *spec.TemplateSpec {
-	// Clone tokens/constraints but replace layouts with one per slide.
-	out := &spec.TemplateSpec{
-		Tokens:      templateSpec.Tokens,
-		Constraints: templateSpec.Constraints,
-		Layouts:     []spec.Layout{},
-	}
-
-	// Pick a base layout to clone.
-	base := spec.Layout{Name: "Slide", Placeholders: []spec.Placeholder{}}
-	if len(templateSpec.Layouts) > 0 {
-		base = templateSpec.Layouts[0]
-	}
-
-	// Choose title + body placeholders by convention.
-	titleID := ""
-	bodyID := ""
-	for _, ph := range base.Placeholders {
-		if ph.Type != "text" {
-			continue
-		}
-		id := strings.ToLower(ph.ID)
-		if titleID == "" && strings.Contains(id, "title") {
-			titleID = ph.ID
-			continue
-		}
-		if bodyID == "" && (strings.Contains(id, "body") || strings.Contains(id, "content") || strings.Contains(id, "subtitle")) {
-			bodyID = ph.ID
-			continue
-		}
-	}
-	// Fallback to first/second text placeholders.
-	if titleID == "" || bodyID == "" {
-		textIDs := []string{}
-		for _, ph := range base.Placeholders {
-			if ph.Type == "text" {
-				textIDs = append(textIDs, ph.ID)
-			}
-		}
-		if titleID == "" && len(textIDs) > 0 {
-			titleID = textIDs[0]
-		}
-		if bodyID == "" {
-			if len(textIDs) > 1 {
-				bodyID = textIDs[1]
-			} else if len(textIDs) == 1 {
-				bodyID = textIDs[0]
-			}
-		}
-	}
-
-	for _, sld := range outline.Slides {
-		layoutName := sld.LayoutHint
-		if layoutName == "" {
-			layoutName = "simple"
-		}
-		layout := spec.Layout{Name: layoutName, Placeholders: []spec.Placeholder{}}
-		for _, ph := range base.Placeholders {
-			p := ph
-			if p.Type == "text" {
-				if p.ID == titleID {
-					p.Content = sld.Title
-				} else if p.ID == bodyID {
-					p.Content = strings.Join(sld.Content, "\n")
-				} else {
-					p.Content = ""
-				}
-			}
-			layout.Placeholders = append(layout.Placeholders, p)
-		}
-		out.Layouts = append(out.Layouts, layout)
-	}
-
-	return out
-}
-
 func (s *Server) handleCreateDeck(w http.ResponseWriter, r *http.Request) {
 	id, _ := auth.GetIdentity(r.Context())
-	if !auth.RequireRole(id, auth.RoleEditor) {
-		writeError(w, r, http.StatusForbidden, "forbidden")
-		return
-	}
 
 	var req CreateDeckRequest
 	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
-		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		writeInvalidJSON(w, r)
 		return
 	}
 
 	// Validate request
 	if err := s.validate.Struct(req); err != nil {
-		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("validation failed: %v", err))
+		writeValidationError(w, r, err)
 		return
 	}
 
-	// Load template version spec (the "template")
-	tv, ok, err := s.Store.Templates().GetVersion(r.Context(), id.OrgID, req.SourceTemplateVersion)
-	if err != nil {
-		logger.LogError(r.Context(), "api", "load_template_version", err)
-		writeError(w, r, http.StatusInternalServerError, "failed to load template version")
-		return
-	}
-	if !ok {
-		writeError(w, r, http.StatusNotFound, "template version not found")
+	var listing store.Listing
+	if !s.applyListing(w, r, id, req.ListingRequest, &listing) {
 		return
 	}
 
-	var templateSpec spec.TemplateSpec
-	specBytes, err := assetsSpecBytes(tv.SpecJSON)
+	res, err := s.deckService().Create(r.Context(), id, service.CreateDeckInput{
+		Name:                    req.Name,
+		SourceTemplateVersionID: req.SourceTemplateVersion,
+		Content:                 req.Content,
+		ContentData:             req.ContentData,
+		Outline:                 req.Outline,
+		Params:                  req.params(),
+		StockImages:             req.StockImages,
+		Listing:                 listing,
+	})
 	if err != nil {
-		writeError(w, r, http.StatusInternalServerError, "failed to read template spec")
+		s.writeServiceError(w, r, "create_deck", "failed to create deck", err)
 		return
 	}
-	if err := json.Unmarshal(specBytes, &templateSpec); err != nil {
-		writeError(w, r, http.StatusBadRequest, "invalid stored template spec")
+	if res.Version != nil {
+		writeJSON(w, http.StatusOK, map[string]any{"deck": res.Deck, "version": res.Version})
 		return
 	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"deck": res.Deck, "job": res.Job})
+}
 
-	var boundSpec *spec.TemplateSpec
-
-	// Create deck record first
-	deck := store.Deck{
-		OrgID:                 id.OrgID,
-		OwnerUserID:           id.UserID,
-		Name:                  req.Name,
-		SourceTemplateVersion: req.SourceTemplateVersion,
-		Content:               req.Content,
-	}
-
-	createdDeck, err := s.Store.Decks().CreateDeck(r.Context(), deck)
-	if err != nil {
-		writeError(w, r, http.StatusInternalServerError, "failed to create deck")
-		return
-	}
-
-	if req.Outline != nil {
-		// Synchronous path for provided outlines (instant)
-		outline, err := parseDeckOutline(req.Outline)
-		if err != nil {
-			writeError(w, r, http.StatusBadRequest, "invalid outline")
-			return
-		}
-		boundSpec = buildDeckSpecFromOutline(&templateSpec, outline)
-
-		boundBytes, err := json.Marshal(boundSpec)
-		if err != nil {
-			writeError(w, r, http.StatusInternalServerError, "failed to marshal bound spec")
-			return
-		}
+func (s *Server) handleListDecks(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
 
-		ver := store.DeckVersion{
-			ID:        newID("dv"),
-			Deck:      createdDeck.ID,
-			OrgID:     id.OrgID,
-			VersionNo: 1,
-			SpecJSON:  json.RawMessage(boundBytes),
-			CreatedBy: id.UserID,
+	// ?include=currentVersionSummary adds each deck's slide count, last
+	// export and thumbnail, read in one query rather than a request per deck.
+	var ds []store.DeckWithSummary
+	var err error
+	switch include := r.URL.Query().Get("include"); include {
+	case "":
+		var plain []store.Deck
+		plain, err = s.Store.Decks().ListDecksFor(r.Context(), id)
+		for _, d := range plain {
+			ds = append(ds, store.DeckWithSummary{Deck: d})
 		}
-		createdVer, err := s.Store.Decks().CreateDeckVersion(r.Context(), ver)
-		if err != nil {
-			writeError(w, r, http.StatusInternalServerError, "failed to create deck version")
-			return
-		}
-		createdDeck.CurrentVersion = &createdVer.ID
-		createdDeck.LatestVersionNo = 1
-		createdDeck, _ = s.Store.Decks().UpdateDeck(r.Context(), createdDeck)
-
-		writeJSON(w, http.StatusOK, map[string]any{"deck": createdDeck, "version": createdVer})
+	case "currentVersionSummary":
+		ds, err = s.Store.Decks().ListDecksWithSummary(r.Context(), id)
+	default:
+		writeError(w, r, http.StatusBadRequest, "include must be currentVersionSummary")
 		return
 	}
-
-	// Asynchronous path for AI binding
-	metadata := store.JSONMap{
-		"sourceTemplateVersionId": req.SourceTemplateVersion,
-		"content":                 req.Content,
-		"userId":                  id.UserID,
-	}
-
-	job := store.Job{
-		ID:              newID("job"),
-		OrgID:           id.OrgID,
-		Type:            store.JobBind,
-		Status:          store.JobQueued,
-		InputRef:        createdDeck.ID,
-		DeduplicationID: fmt.Sprintf("bind-%s", createdDeck.ID),
-		Metadata:        &metadata,
-	}
-
-	createdJob, _, err := s.Store.Jobs().EnqueueWithDeduplication(r.Context(), job)
 	if err != nil {
-		writeError(w, r, http.StatusInternalServerError, "failed to enqueue bind job")
+		logger.LogError(r.Context(), "api", "list_decks", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list decks")
 		return
 	}
-
-	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.bind.queued", TargetRef: createdDeck.ID, Metadata: map[string]any{"jobId": createdJob.ID}})
-
-	writeJSON(w, http.StatusAccepted, map[string]any{"deck": createdDeck, "job": createdJob})
-}
-
-func (s *Server) handleListDecks(w http.ResponseWriter, r *http.Request) {
-	id, _ := auth.GetIdentity(r.Context())
-	ds, err := s.Store.Decks().ListDecks(r.Context(), id.OrgID)
+	tags, err := s.Store.Tags().ListAttached(r.Context(), id.OrgID, store.ResourceDeck)
 	if err != nil {
+		logger.LogError(r.Context(), "api", "list_decks", err)
 		writeError(w, r, http.StatusInternalServerError, "failed to list decks")
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"decks": ds})
+
+	filter := parseListFilter(r)
+	out := make([]taggedDeck, 0, len(ds))
+	for _, d := range ds {
+		if !filter.match(d.Name, d.Listing, d.FolderID, tags[d.ID]) {
+			continue
+		}
+		if d.Summary != nil && d.Summary.SlideCount > 0 {
+			d.Summary.ThumbnailURL = fmt.Sprintf("/v1/decks/%s/present/slides/0/thumbnail?v=%s", d.ID, d.Summary.VersionID)
+		}
+		out = append(out, taggedDeck{DeckWithSummary: d, Tags: nonNilTags(tags[d.ID])})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"decks": out})
 }
 
 func (s *Server) handleGetDeck(w http.ResponseWriter, r *http.Request) {
 	id, _ := auth.GetIdentity(r.Context())
-	deckID := r.PathValue("id")
-	d, ok, err := s.Store.Decks().GetDeck(r.Context(), id.OrgID, deckID)
-	if err != nil {
-		writeError(w, r, http.StatusInternalServerError, "failed to get deck")
-		return
-	}
+	d, ok := s.authorizeDeck(w, r, id, r.PathValue("id"), store.PermissionView)
 	if !ok {
-		writeError(w, r, http.StatusNotFound, "not found")
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"deck": d})
+	// As for templates, the usage counts are part of the tag.
+	usage := s.deckUsage(r.Context(), d)
+	etag := resourceETag(d.ID, d.UpdatedAt, d.DeletedAt, d.LatestVersionNo, d.CurrentVersion, usage)
+	writeJSONIfChanged(w, r, etag, map[string]any{"deck": d, "usage": usage})
 }
 
 func (s *Server) handleUpdateDeck(w http.ResponseWriter, r *http.Request) {
 	id, _ := auth.GetIdentity(r.Context())
-	if !auth.RequireRole(id, auth.RoleEditor) {
-		writeError(w, r, http.StatusForbidden, "forbidden")
-		return
-	}
 
 	deckID := r.PathValue("id")
 
-	var req struct {
-		Name    *string `json:"name"`
-		Content *string `json:"content"`
-	}
+	var req UpdateDeckRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, r, http.StatusBadRequest, "invalid JSON")
 		return
 	}
-
-	// Get existing deck
-	d, ok, err := s.Store.Decks().GetDeck(r.Context(), id.OrgID, deckID)
-	if err != nil {
-		writeError(w, r, http.StatusInternalServerError, "failed to get deck")
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
 		return
 	}
+
+	// Get existing deck
+	d, ok := s.authorizeDeck(w, r, id, deckID, store.PermissionEdit)
 	if !ok {
-		writeError(w, r, http.StatusNotFound, "not found")
 		return
 	}
 
@@ -909,6 +1038,9 @@ func (s *Server) handleUpdateDeck(w http.ResponseWriter, r *http.Request) {
 	if req.Content != nil {
 		d.Content = *req.Content
 	}
+	if !s.applyListing(w, r, id, req.ListingRequest, &d.Listing) {
+		return
+	}
 
 	// Save updated deck
 	updated, err := s.Store.Decks().UpdateDeck(r.Context(), d)
@@ -923,59 +1055,55 @@ func (s *Server) handleUpdateDeck(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleListDeckVersions(w http.ResponseWriter, r *http.Request) {
 	id, _ := auth.GetIdentity(r.Context())
 	deckID := r.PathValue("id")
+	if _, ok := s.authorizeDeck(w, r, id, deckID, store.PermissionView); !ok {
+		return
+	}
 	vs, err := s.Store.Decks().ListDeckVersions(r.Context(), id.OrgID, deckID)
 	if err != nil {
 		writeError(w, r, http.StatusInternalServerError, "failed to list versions")
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"versions": vs})
+	ids := make([]string, len(vs))
+	for i, v := range vs {
+		ids[i] = v.ID
+	}
+	writeJSONIfChanged(w, r, resourceETag(ids), map[string]any{"versions": vs})
 }
 
 func (s *Server) handleListDeckExports(w http.ResponseWriter, r *http.Request) {
 	id, _ := auth.GetIdentity(r.Context())
 	deckID := r.PathValue("id")
-
-	log.Printf("🔍 DEBUG: handleListDeckExports - OrgID: '%s', DeckID: '%s'", id.OrgID, deckID)
+	if _, ok := s.authorizeDeck(w, r, id, deckID, store.PermissionView); !ok {
+		return
+	}
 
 	// Get all deck versions for this deck
 	versions, err := s.Store.Decks().ListDeckVersions(r.Context(), id.OrgID, deckID)
 	if err != nil {
-		log.Printf("🚨 ERROR: Failed to list deck versions: %v", err)
+		logger.LogError(r.Context(), "api", "list_deck_versions", err, "deck_id", deckID)
 		writeError(w, r, http.StatusInternalServerError, "failed to list deck versions")
 		return
 	}
 
-	log.Printf("🔍 DEBUG: handleListDeckExports - Found %d versions for deck %s", len(versions), deckID)
-
 	// Collect all export jobs for all versions
 	var allExports []store.Job
 	for _, version := range versions {
 		jobs, err := s.Store.Jobs().ListByInputRef(r.Context(), id.OrgID, version.ID, store.JobExport)
 		if err != nil {
 			// Log error but don't fail the whole request
-			log.Printf("🚨 ERROR: Failed to get export jobs for version %s: %v", version.ID, err)
+			logger.LogError(r.Context(), "api", "list_export_jobs", err, "version_id", version.ID)
 			continue
 		}
-		if len(jobs) > 0 {
-			log.Printf("🔍 DEBUG: handleListDeckExports - Found %d jobs for version %s", len(jobs), version.ID)
-		}
 		allExports = append(allExports, jobs...)
 	}
 
-	log.Printf("🔍 DEBUG: handleListDeckExports - Total exports collected: %d", len(allExports))
+	logger.WithContext(r.Context()).Debug("deck_exports_listed", "deck_id", deckID, "versions", len(versions), "exports", len(allExports))
 
 	// Sort all exports by update time (most recent first)
 	sort.Slice(allExports, func(i, j int) bool {
 		return allExports[i].UpdatedAt.After(allExports[j].UpdatedAt)
 	})
 
-	// SAFETY FALLBACK: If still empty, search for ANY recent export jobs for this Org
-	if len(allExports) == 0 {
-		log.Printf("🔍 DEBUG: No jobs found for deck versions. Trying safety fallback for Org %s", id.OrgID)
-		// Fetch recent jobs (last 50) directly from the store if possible, or just broader search
-		// Since we don't have a ListByOrg method, we'll log this for now and ensure the query above is correct
-	}
-
 	writeJSON(w, http.StatusOK, map[string]any{
 		"exports":       allExports,
 		"deckId":        deckID,
@@ -985,33 +1113,22 @@ func (s *Server) handleListDeckExports(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleCreateDeckVersion(w http.ResponseWriter, r *http.Request) {
 	id, _ := auth.GetIdentity(r.Context())
-	if !auth.RequireRole(id, auth.RoleEditor) {
-		writeError(w, r, http.StatusForbidden, "forbidden")
-		return
-	}
 
-	deckID := r.PathValue("id")
-	d, ok, err := s.Store.Decks().GetDeck(r.Context(), id.OrgID, deckID)
-	if err != nil {
-		logger.LogError(r.Context(), "api", "get_deck", err)
-		writeError(w, r, http.StatusInternalServerError, "failed to get deck")
-		return
-	}
+	d, ok := s.authorizeDeck(w, r, id, r.PathValue("id"), store.PermissionEdit)
 	if !ok {
-		writeError(w, r, http.StatusNotFound, "not found")
 		return
 	}
 
 	var req CreateDeckVersionRequest
 	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
 		logger.LogError(r.Context(), "api", "decode_request", err)
-		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		writeInvalidJSON(w, r)
 		return
 	}
 
 	// Validate request
 	if err := s.validate.Struct(req); err != nil {
-		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("validation failed: %v", err))
+		writeValidationError(w, r, err)
 		return
 	}
 
@@ -1022,183 +1139,154 @@ func (s *Server) handleCreateDeckVersion(w http.ResponseWriter, r *http.Request)
 		writeError(w, r, http.StatusInternalServerError, "failed to marshal spec")
 		return
 	}
+	if !checkSpecVersion(w, r, specBytes) {
+		return
+	}
 
 	ver := store.DeckVersion{ID: newID("dv"), Deck: d.ID, OrgID: id.OrgID, VersionNo: newNo, SpecJSON: json.RawMessage(specBytes), CreatedBy: id.UserID}
-	created, err := s.Store.Decks().CreateDeckVersion(r.Context(), ver)
+	var created store.DeckVersion
+	var updated store.Deck
+	err = s.Store.WithTx(r.Context(), func(tx store.Store) error {
+		var err error
+		if created, err = tx.Decks().CreateDeckVersion(r.Context(), ver); err != nil {
+			return err
+		}
+		d.LatestVersionNo = newNo
+		d.CurrentVersion = &created.ID
+		updated, err = tx.Decks().UpdateDeck(r.Context(), d)
+		return err
+	})
 	if err != nil {
 		logger.LogError(r.Context(), "api", "create_deck_version", err)
 		writeError(w, r, http.StatusInternalServerError, "failed to create version")
 		return
 	}
-	d.LatestVersionNo = newNo
-	d.CurrentVersion = &created.ID
-	updated, _ := s.Store.Decks().UpdateDeck(r.Context(), d)
 
 	writeJSON(w, http.StatusOK, map[string]any{"deck": updated, "version": created})
 }
 
+// exportOptions reads the format, quality, renderer, adjustContrast,
+// deterministic, force, comments, width and targetSizeMb query parameters
+// of an export.
+func exportOptions(r *http.Request) service.ExportOptions {
+	q := r.URL.Query()
+	opts := service.ExportOptions{
+		Format:         q.Get("format"),
+		Quality:        q.Get("quality"),
+		Renderer:       q.Get("renderer"),
+		AdjustContrast: q.Get("adjustContrast") == "true",
+		Deterministic:  q.Get("deterministic") == "true",
+		Force:          q.Get("force") == "true",
+		Comments:       q.Get("comments") == "true",
+	}
+	if v := q.Get("width"); v != "" {
+		var err error
+		if opts.Width, err = strconv.Atoi(v); err != nil {
+			// Out of range, so the service rejects it with the valid range.
+			opts.Width = -1
+		}
+	}
+	if v := q.Get("targetSizeMb"); v != "" {
+		mb, err := strconv.ParseFloat(v, 64)
+		if err != nil || !(mb > 0 && mb <= service.MaxExportTargetSize>>20) {
+			// Out of range, so the service rejects it with the valid range.
+			mb = -1
+		}
+		opts.TargetSize = int64(mb * (1 << 20))
+	}
+	return opts
+}
+
 func (s *Server) handleExportDeckVersion(w http.ResponseWriter, r *http.Request) {
 	id, _ := auth.GetIdentity(r.Context())
-	versionID := r.PathValue("versionId")
-
-	dv, ok, err := s.Store.Decks().GetDeckVersion(r.Context(), id.OrgID, versionID)
-	if err != nil {
-		logger.LogError(r.Context(), "api", "get_deck_version", err)
-		writeError(w, r, http.StatusInternalServerError, "failed")
+	opts := exportOptions(r)
+	// The body is optional; it carries the data for a merge export.
+	var req ExportDeckVersionRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeInvalidJSON(w, r)
 		return
 	}
-	if !ok {
-		writeError(w, r, http.StatusNotFound, "not found")
-		return
+	if req.Data != nil {
+		data, err := mergeValues(req.Data)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.Data = data
 	}
-	if isBlocked, usage := s.enforceExportQuota(r); isBlocked {
-		writeJSON(w, http.StatusPaymentRequired, usage)
+	res, err := s.exportService().ExportDeckVersion(r.Context(), id, r.PathValue("versionId"), opts)
+	if err != nil {
+		s.writeServiceError(w, r, "export_deck_version", "failed to enqueue job", err)
 		return
 	}
-
-	// Async export using job queue - NO deduplication for exports to allow multiple entries
-	metadata := store.JSONMap{
-		"versionNo": fmt.Sprintf("%d", dv.VersionNo),
-		"filename":  fmt.Sprintf("deck-export-v%d-%s.pptx", dv.VersionNo, time.Now().Format("20060102-150405")),
-	}
-
-	job := store.Job{
-		ID:       newID("job"),
-		OrgID:    id.OrgID,
-		Type:     store.JobExport,
-		Status:   store.JobQueued,
-		InputRef: versionID,
-		Metadata: &metadata,
-	}
-	createdJob, err := s.Store.Jobs().Enqueue(r.Context(), job)
-	if err != nil {
-		logger.LogError(r.Context(), "api", "enqueue_export_job", err)
-		writeError(w, r, http.StatusInternalServerError, "failed to enqueue job")
+	if res.Asset != nil {
+		// An unchanged deck was exported before; hand back that file.
+		writeJSON(w, http.StatusOK, map[string]any{
+			"job":       res.Job,
+			"asset":     map[string]any{"id": res.Asset.ID, "downloadUrl": "/v1/assets/" + res.Asset.ID, "sha256": res.Asset.SHA256},
+			"metadata":  map[string]any{"filename": res.Asset.Filename},
+			"duplicate": true,
+		})
 		return
 	}
-
 	// Return job ID immediately - frontend can poll for completion
-	logger.Jobs().Info("deck_export_queued", "user_id", id.UserID, "org_id", id.OrgID, "job_id", createdJob.ID, "version_id", versionID)
-	_, _ = s.Store.Metering().Record(r.Context(), store.MeteringEvent{ID: newID("met"), OrgID: id.OrgID, UserID: id.UserID, Type: "export", Quantity: 1})
-	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.export", TargetRef: versionID, Metadata: map[string]any{"jobId": createdJob.ID, "versionNo": dv.VersionNo}})
-
-	writeJSON(w, http.StatusAccepted, map[string]any{"job": createdJob})
+	resp := map[string]any{"job": res.Job}
+	if res.Duplicate {
+		resp["duplicate"] = true
+	}
+	writeJSON(w, http.StatusAccepted, resp)
 }
 
 func (s *Server) handleExportVersion(w http.ResponseWriter, r *http.Request) {
 	id, _ := auth.GetIdentity(r.Context())
 	versionID := r.PathValue("versionId")
-	
 	logger.API().Info("handle_export_version", "user_id", id.UserID, "org_id", id.OrgID, "version_id", versionID)
-	
-	ver, ok, err := s.Store.Templates().GetVersion(r.Context(), id.OrgID, versionID)
+
+	res, err := s.exportService().ExportTemplateVersion(r.Context(), id, versionID, exportOptions(r))
 	if err != nil {
-		logger.LogError(r.Context(), "api", "get_template_version", err)
-		writeError(w, r, http.StatusInternalServerError, "failed")
+		s.writeServiceError(w, r, "export_template_version", "export failed", err)
 		return
 	}
-	if !ok {
-		writeError(w, r, http.StatusNotFound, "not found")
-		return
-	}
-	if isBlocked, usage := s.enforceExportQuota(r); isBlocked {
-		writeJSON(w, http.StatusPaymentRequired, usage)
+	job := res.Job
+	if !res.Duplicate && res.Asset == nil {
+		// Bundles and image exports are rendered by the worker.
+		writeJSON(w, http.StatusAccepted, map[string]any{"job": job})
 		return
 	}
-
-	job := store.Job{
-		ID:              newID("job"),
-		OrgID:           id.OrgID,
-		Type:            store.JobExport,
-		Status:          store.JobQueued,
-		InputRef:        versionID,
-		DeduplicationID: fmt.Sprintf("%s-%s", string(store.JobExport), versionID),
-	}
-	createdJob, wasDuplicate, err := s.Store.Jobs().EnqueueWithDeduplication(r.Context(), job)
-	if err != nil {
-		logger.LogError(r.Context(), "api", "enqueue_export_job", err)
-		writeError(w, r, http.StatusInternalServerError, "failed to enqueue job")
-		return
-	}
-	if wasDuplicate {
-		logger.Jobs().Info("export_job_duplicate", "job_id", createdJob.ID, "status", createdJob.Status)
-		if createdJob.Status == store.JobDone && createdJob.OutputRef != "" {
-			// Get the asset to return unified format
-			asset, ok, err := s.Store.Assets().Get(r.Context(), id.OrgID, createdJob.OutputRef)
-			if err == nil && ok {
-				// Return unified format: {asset: {id, downloadUrl}, job: {id, status}, metadata: {filename, fileSize}}
-				filename := fmt.Sprintf("template-export-%s.pptx", createdJob.OutputRef[:8])
-				writeJSON(w, http.StatusOK, map[string]any{
-					"job": createdJob,
-					"duplicate": true,
-					"asset": map[string]any{"id": asset.ID, "downloadUrl": "/v1/assets/" + asset.ID},
-					"metadata": map[string]any{"filename": filename},
-				})
-				return
-			}
+	if res.Duplicate {
+		switch {
+		case res.Asset != nil:
+		case job.Status == store.JobDone && job.OutputRef != "":
 			// Fallback for missing assets (backward compatibility)
-			writeJSON(w, http.StatusOK, map[string]any{"job": createdJob, "duplicate": true, "assetPath": createdJob.OutputRef})
+			writeJSON(w, http.StatusOK, map[string]any{"job": job, "duplicate": true, "assetPath": job.OutputRef})
 			return
-		}
-		// If duplicate job failed, return error immediately
-		if createdJob.Status == store.JobFailed || createdJob.Status == store.JobDeadLetter {
-			writeJSON(w, http.StatusOK, map[string]any{"job": createdJob, "duplicate": true, "error": createdJob.Error})
+		case job.Status == store.JobFailed || job.Status == store.JobDeadLetter:
+			// If duplicate job failed, return error immediately
+			writeJSON(w, http.StatusOK, map[string]any{"job": job, "duplicate": true, "error": job.Error})
+			return
+		default:
+			// Otherwise, job is still in progress
+			writeJSON(w, http.StatusAccepted, map[string]any{"job": job, "duplicate": true})
 			return
 		}
-		// Otherwise, job is still in progress
-		writeJSON(w, http.StatusAccepted, map[string]any{"job": createdJob, "duplicate": true})
-		return
-	}
-
-	// Use a random filename for the stored object; the DB asset ID will be a UUID.
-	objectKey := newID("asset") + ".pptx"
-
-	// Render to temporary file first
-	tempPath := filepath.Join(os.TempDir(), objectKey)
-	if err := s.Renderer.RenderPPTX(r.Context(), ver.SpecJSON, tempPath); err != nil {
-		writeError(w, r, http.StatusInternalServerError, "render failed")
-		return
 	}
-	defer os.Remove(tempPath)
 
-	// Read the rendered file and upload to object storage
-	data, err := os.ReadFile(tempPath)
-	if err != nil {
-		writeError(w, r, http.StatusInternalServerError, "failed to read rendered file")
-		return
-	}
-
-	_, err = s.ObjectStorage.Upload(r.Context(), objectKey, data, "application/vnd.openxmlformats-officedocument.presentationml.presentation")
-	if err != nil {
-		writeError(w, r, http.StatusInternalServerError, "failed to upload asset")
-		return
+	// Return unified format: {asset: {id, downloadUrl, sha256}, job: {id, status}, metadata: {filename, fileSize}}
+	filename := res.Asset.Filename
+	if filename == "" {
+		filename = fmt.Sprintf("template-export-%s.pptx", job.OutputRef[:8])
 	}
-
-	asset := store.Asset{OrgID: id.OrgID, Type: store.AssetPPTX, Path: objectKey, Mime: "application/vnd.openxmlformats-officedocument.presentationml.presentation"}
-	createdAsset, err := s.Store.Assets().Create(r.Context(), asset)
-	if err != nil {
-		writeError(w, r, http.StatusInternalServerError, "failed to create asset")
-		return
+	resp := map[string]any{
+		"job":      job,
+		"asset":    map[string]any{"id": res.Asset.ID, "downloadUrl": "/v1/assets/" + res.Asset.ID, "sha256": res.Asset.SHA256},
+		"metadata": map[string]any{"filename": filename},
 	}
-
-	createdJob.Status = store.JobDone
-	createdJob.OutputRef = createdAsset.ID
-	if _, err := s.Store.Jobs().Update(r.Context(), createdJob); err != nil {
-		requestID, _ := r.Context().Value(ctxKeyRequestID{}).(string)
-		log.Printf("ERROR: Failed to update export job status: request_id=%s job_id=%s err=%v", requestID, createdJob.ID, err)
-		writeError(w, r, http.StatusInternalServerError, "failed to update job")
-		return
+	if res.Duplicate {
+		resp["duplicate"] = true
 	}
-	_, _ = s.Store.Metering().Record(r.Context(), store.MeteringEvent{ID: newID("met"), OrgID: id.OrgID, UserID: id.UserID, Type: "export", Quantity: 1})
-	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "version.export", TargetRef: versionID, Metadata: map[string]any{"jobId": createdJob.ID, "assetId": createdAsset.ID}})
-
-	// Return unified format: {asset: {id, downloadUrl}, job: {id, status}, metadata: {filename, fileSize}}
-	filename := fmt.Sprintf("template-export-%s.pptx", createdAsset.ID[:8])
-	writeJSON(w, http.StatusOK, map[string]any{
-		"job": createdJob,
-		"asset": map[string]any{"id": createdAsset.ID, "downloadUrl": "/v1/assets/" + createdAsset.ID},
-		"metadata": map[string]any{"filename": filename},
-	})
+	writeJSON(w, http.StatusOK, resp)
 }
 
 func (s *Server) handleDownloadURL(w http.ResponseWriter, r *http.Request) {
@@ -1217,13 +1305,13 @@ func (s *Server) handleDownloadURL(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate signed URL
-	signedURL, err := s.ObjectStorage.GetURL(r.Context(), asset.Path, 15*time.Minute)
+	signedURL, err := s.ObjectStorage.GetURL(assets.WithRegion(r.Context(), asset.Region), asset.Path, 15*time.Minute)
 	if err != nil {
 		writeError(w, r, http.StatusInternalServerError, "failed to generate download URL")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"assetId": assetID, "downloadUrl": signedURL})
+	writeJSON(w, http.StatusOK, map[string]any{"assetId": assetID, "downloadUrl": signedURL, "sha256": asset.SHA256})
 }
 
 func (s *Server) handleCreateBrandKit(w http.ResponseWriter, r *http.Request) {
@@ -1233,12 +1321,9 @@ func (s *Server) handleCreateBrandKit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var payload struct {
-		Name   string `json:"name"`
-		Tokens any    `json:"tokens"`
-	}
+	var payload CreateBrandKitRequest
 	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&payload); err != nil {
-		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		writeInvalidJSON(w, r)
 		return
 	}
 	if strings.TrimSpace(payload.Name) == "" {
@@ -1271,41 +1356,26 @@ func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
 
 	gen, _ := s.Store.Metering().SumByType(r.Context(), id.OrgID, "generate")
 	exp, _ := s.Store.Metering().SumByType(r.Context(), id.OrgID, "export")
+	storage := s.storageUsage(r)
 
 	limits := map[string]int{"generate": s.Config.GenerateLimitPerMonth, "export": s.Config.ExportLimitPerMonth}
 	used := map[string]int{"generate": gen, "export": exp}
-	blocked := gen >= limits["generate"] || exp >= limits["export"]
+	blocked := gen >= limits["generate"] || exp >= limits["export"] || storage.Blocked
 
-	writeJSON(w, http.StatusOK, UsageResponse{OrgID: id.OrgID, Limits: limits, Used: used, Blocked: blocked})
+	writeJSON(w, http.StatusOK, UsageResponse{OrgID: id.OrgID, Limits: limits, Used: used, Storage: &storage, Blocked: blocked})
 }
 
-func (s *Server) enforceQuota(r *http.Request) (bool, UsageResponse) {
+func (s *Server) storageUsage(r *http.Request) StorageUsage {
 	id, _ := auth.GetIdentity(r.Context())
-	gen, _ := s.Store.Metering().SumByType(r.Context(), id.OrgID, "generate")
-	limits := map[string]int{"generate": s.Config.GenerateLimitPerMonth, "export": s.Config.ExportLimitPerMonth}
-	used := map[string]int{"generate": gen}
-	blocked := gen >= limits["generate"]
-	return blocked, UsageResponse{OrgID: id.OrgID, Limits: limits, Used: used, Blocked: blocked}
-}
-
-func (s *Server) enforceExportQuota(r *http.Request) (bool, UsageResponse) {
-	id, _ := auth.GetIdentity(r.Context())
-	exp, _ := s.Store.Metering().SumByType(r.Context(), id.OrgID, "export")
-	limits := map[string]int{"generate": s.Config.GenerateLimitPerMonth, "export": s.Config.ExportLimitPerMonth}
-	used := map[string]int{"export": exp}
-	blocked := exp >= limits["export"]
-	return blocked, UsageResponse{OrgID: id.OrgID, Limits: limits, Used: used, Blocked: blocked}
+	used, blocked := s.quotas().StorageUsage(r.Context(), id.OrgID)
+	return StorageUsage{UsedBytes: used, LimitBytes: s.Config.StorageLimitBytes, Blocked: blocked}
 }
 
 func (s *Server) handleGetOrCreateUser(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		UserID string `json:"userId"`
-		Email  string `json:"email"`
-		Name   string `json:"name"`
-	}
+	var req LegacyUserRequest
 
 	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
-		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		writeInvalidJSON(w, r)
 		return
 	}
 
@@ -1353,20 +1423,14 @@ func (s *Server) handleGetOrCreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// User not found - return error so frontend can call signup
-	writeJSON(w, http.StatusNotFound, map[string]any{"error": "user not found"})
+	writeError(w, r, http.StatusNotFound, "user not found")
 }
 
 func (s *Server) handleSignup(w http.ResponseWriter, r *http.Request) {
-	log.Printf("DEBUG: handleSignup called - Method: %s, Path: %s", r.Method, r.URL.Path)
-
-	var req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-		Name     string `json:"name"`
-	}
+	var req SignupRequest
 
 	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
-		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		writeInvalidJSON(w, r)
 		return
 	}
 
@@ -1452,13 +1516,10 @@ func (s *Server) handleSignup(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleSignin(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
+	var req SigninRequest
 
 	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
-		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		writeInvalidJSON(w, r)
 		return
 	}
 
@@ -1482,28 +1543,29 @@ func (s *Server) handleSignin(w http.ResponseWriter, r *http.Request) {
 	// In production, you'd hash passwords with bcrypt and verify here
 
 	// Get user's org membership
-	log.Printf("DEBUG: Looking up memberships for user ID: %s", foundUser.ID)
 	memberships, err := s.Store.Users().ListUserOrgs(r.Context(), foundUser.ID)
 	if err != nil {
-		log.Printf("ERROR: Failed to list user orgs: %v", err)
+		logger.LogError(r.Context(), "auth", "list_user_orgs", err)
 		writeError(w, r, http.StatusInternalServerError, "failed to lookup user orgs")
 		return
 	}
 	if len(memberships) == 0 {
-		log.Printf("ERROR: No memberships found for user ID: %s", foundUser.ID)
+		logger.WithContext(r.Context()).Warn("signin_no_memberships", "component", "auth")
 		writeError(w, r, http.StatusInternalServerError, "failed to lookup user orgs")
 		return
 	}
+	if memberships = s.withoutDeletedOrgs(r.Context(), memberships); len(memberships) == 0 {
+		writeError(w, r, http.StatusForbidden, "organization has been deleted")
+		return
+	}
 
 	membership := memberships[0]
-	log.Printf("DEBUG: Found membership - OrgID: %s, Role: %s", membership.OrgID, membership.Role)
 	org, err := s.Store.Organizations().GetOrganization(r.Context(), membership.OrgID)
 	if err != nil {
-		log.Printf("ERROR: Failed to get organization for OrgID %s: %v", membership.OrgID, err)
+		logger.LogError(r.Context(), "auth", "get_organization", err, "org_id", membership.OrgID)
 		writeError(w, r, http.StatusInternalServerError, "failed to lookup organization")
 		return
 	}
-	log.Printf("DEBUG: Found organization: %s", org.Name)
 
 	// Generate JWT token
 	token, err := auth.GenerateToken(foundUser.ID, org.ID, membership.Role)
@@ -1548,15 +1610,8 @@ func (s *Server) handleGetMe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseUser := map[string]any{
-		"userId": user.ID,
-		"email":  user.Email,
-		"name":   user.Name,
-		"orgId":  org.ID,
-		"role":   id.Role,
-	}
-
-	writeJSON(w, http.StatusOK, map[string]any{"user": responseUser})
+	id.OrgID = org.ID
+	writeJSON(w, http.StatusOK, map[string]any{"user": meUser(user, id)})
 }
 
 func (s *Server) handleListDeadLetterJobs(w http.ResponseWriter, r *http.Request) {
@@ -1632,13 +1687,10 @@ func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		Type     string `json:"type"`
-		InputRef string `json:"inputRef"`
-	}
+	var req CreateJobRequest
 
 	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
-		writeError(w, r, http.StatusBadRequest, "invalid JSON body")
+		writeInvalidJSON(w, r)
 		return
 	}
 
@@ -1673,7 +1725,7 @@ func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 
 	createdJob, wasDuplicate, err := s.Store.Jobs().EnqueueWithDeduplication(r.Context(), job)
 	if err != nil {
-		log.Printf("ERROR: Failed to enqueue job: %v", err)
+		logger.LogError(r.Context(), "api", "enqueue_job", err)
 		writeError(w, r, http.StatusInternalServerError, "failed to enqueue job")
 		return
 	}
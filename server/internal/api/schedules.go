@@ -0,0 +1,260 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/cron"
+	"github.com/ziyad/cms-ai/server/internal/egress"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/service"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// checkSchedule validates a schedule about to be saved and sets its next run
+// from now, or clears it when the schedule is disabled. It writes the error
+// response itself and reports whether the caller may go on.
+func (s *Server) checkSchedule(w http.ResponseWriter, r *http.Request, id auth.Identity, sched *store.Schedule, now time.Time) bool {
+	expr, err := cron.Parse(sched.Cron)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return false
+	}
+	loc, err := time.LoadLocation(sched.Timezone)
+	if err != nil || sched.Timezone == "Local" {
+		writeError(w, r, http.StatusBadRequest, "unknown timezone "+sched.Timezone)
+		return false
+	}
+	next := expr.Next(now.In(loc))
+	if next.IsZero() {
+		writeError(w, r, http.StatusBadRequest, "cron expression never runs")
+		return false
+	}
+	if sched.SourceURL != "" {
+		u, err := url.Parse(sched.SourceURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			writeError(w, r, http.StatusBadRequest, "sourceUrl must be an http or https URL")
+			return false
+		}
+		if err := egress.CheckHost(r.Context(), u.Hostname()); err != nil {
+			writeError(w, r, http.StatusBadRequest, "sourceUrl can't be reached: "+err.Error())
+			return false
+		}
+	}
+	if strings.TrimSpace(sched.Content) == "" && sched.SourceURL == "" {
+		writeError(w, r, http.StatusBadRequest, "content or sourceUrl is required")
+		return false
+	}
+	quality, err := service.NormalizeExportQuality(sched.Quality)
+	if err != nil {
+		s.writeServiceError(w, r, "check_schedule", "invalid schedule", err)
+		return false
+	}
+	if quality == store.ExportQualityStandard {
+		quality = ""
+	}
+	sched.Quality = quality
+	if _, err := service.NormalizeRenderer(sched.Renderer); err != nil {
+		s.writeServiceError(w, r, "check_schedule", "invalid schedule", err)
+		return false
+	}
+
+	tv, ok, err := s.Store.Templates().GetVersion(r.Context(), id.OrgID, sched.TemplateVersionID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "check_schedule", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load template version")
+		return false
+	}
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "template version not found")
+		return false
+	}
+	if _, ok := s.authorizeTemplate(w, r, id, tv.Template, store.PermissionView); !ok {
+		return false
+	}
+
+	if sched.Enabled {
+		next = next.UTC()
+		sched.NextRunAt = &next
+	} else {
+		sched.NextRunAt = nil
+	}
+	return true
+}
+
+// loadSchedule fetches the schedule named in the path, writing the error
+// response itself when it can't.
+func (s *Server) loadSchedule(w http.ResponseWriter, r *http.Request, id auth.Identity, op string) (store.Schedule, bool) {
+	sched, ok, err := s.Store.Schedules().Get(r.Context(), id.OrgID, r.PathValue("id"))
+	if err != nil {
+		logger.LogError(r.Context(), "api", op, err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load schedule")
+		return store.Schedule{}, false
+	}
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return store.Schedule{}, false
+	}
+	return sched, true
+}
+
+// handleCreateSchedule handles POST /v1/schedules. Editors and above.
+func (s *Server) handleCreateSchedule(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	var req CreateScheduleRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	sched := store.Schedule{
+		ID:                newID("sch"),
+		OrgID:             id.OrgID,
+		Name:              strings.TrimSpace(req.Name),
+		Cron:              strings.TrimSpace(req.Cron),
+		Timezone:          req.Timezone,
+		TemplateVersionID: req.TemplateVersionID,
+		Content:           req.Content,
+		SourceURL:         req.SourceURL,
+		Quality:           req.Quality,
+		Renderer:          req.Renderer,
+		Enabled:           req.Enabled == nil || *req.Enabled,
+		CreatedBy:         id.UserID,
+	}
+	if !s.checkSchedule(w, r, id, &sched, time.Now()) {
+		return
+	}
+	created, err := s.Store.Schedules().Create(r.Context(), sched)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "create_schedule", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to create schedule")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "schedule.create", TargetRef: created.ID, Metadata: map[string]any{"name": created.Name, "cron": created.Cron, "templateVersionId": created.TemplateVersionID}})
+	writeJSON(w, http.StatusCreated, map[string]any{"schedule": created})
+}
+
+// handleListSchedules handles GET /v1/schedules.
+func (s *Server) handleListSchedules(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	schedules, err := s.Store.Schedules().List(r.Context(), id.OrgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_schedules", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list schedules")
+		return
+	}
+	if schedules == nil {
+		schedules = []store.Schedule{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"schedules": schedules})
+}
+
+// handleGetSchedule handles GET /v1/schedules/{id}.
+func (s *Server) handleGetSchedule(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	sched, ok := s.loadSchedule(w, r, id, "get_schedule")
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"schedule": sched})
+}
+
+// handleUpdateSchedule handles PATCH /v1/schedules/{id}. The next run is
+// worked out afresh, so re-enabling a schedule doesn't make up missed runs.
+// Editors and above.
+func (s *Server) handleUpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	var req UpdateScheduleRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	sched, ok := s.loadSchedule(w, r, id, "update_schedule")
+	if !ok {
+		return
+	}
+	if req.Name != nil {
+		sched.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Cron != nil {
+		sched.Cron = strings.TrimSpace(*req.Cron)
+	}
+	if req.Timezone != nil {
+		sched.Timezone = *req.Timezone
+	}
+	if req.TemplateVersionID != nil {
+		sched.TemplateVersionID = *req.TemplateVersionID
+	}
+	if req.Content != nil {
+		sched.Content = *req.Content
+	}
+	if req.SourceURL != nil {
+		sched.SourceURL = *req.SourceURL
+	}
+	if req.Quality != nil {
+		sched.Quality = *req.Quality
+	}
+	if req.Renderer != nil {
+		sched.Renderer = *req.Renderer
+	}
+	if req.Enabled != nil {
+		sched.Enabled = *req.Enabled
+	}
+	if !s.checkSchedule(w, r, id, &sched, time.Now()) {
+		return
+	}
+
+	updated, err := s.Store.Schedules().Update(r.Context(), sched)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "update_schedule", err, "schedule_id", sched.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to update schedule")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "schedule.update", TargetRef: updated.ID, Metadata: map[string]any{"name": updated.Name, "cron": updated.Cron, "enabled": updated.Enabled}})
+	writeJSON(w, http.StatusOK, map[string]any{"schedule": updated})
+}
+
+// handleDeleteSchedule handles DELETE /v1/schedules/{id}. Decks it already
+// generated are kept. Editors and above.
+func (s *Server) handleDeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	schedID := r.PathValue("id")
+
+	deleted, err := s.Store.Schedules().Delete(r.Context(), id.OrgID, schedID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "delete_schedule", err, "schedule_id", schedID)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete schedule")
+		return
+	}
+	if !deleted {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "schedule.delete", TargetRef: schedID})
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestSchedules_CRUD(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+	_, err := s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-sch", OrgID: "org-1", OwnerUserID: "user-1", Name: "Sales"})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-sch", Template: "tpl-sch", OrgID: "org-1", VersionNo: 1, SpecJSON: json.RawMessage(`{"layouts":[]}`)})
+	require.NoError(t, err)
+	do := func(method, path, body string, role auth.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	valid := `{"name":"Weekly sales","cron":"0 9 * * mon","timezone":"Europe/Berlin","templateVersionId":"tv-sch","sourceUrl":"https://reports.example.com/weekly","quality":"high"}`
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/v1/schedules", valid, auth.RoleViewer).Code)
+	for _, body := range []string{
+		`{"name":"x","cron":"61 * * * *","templateVersionId":"tv-sch","content":"c"}`,
+		`{"name":"x","cron":"0 0 30 2 *","templateVersionId":"tv-sch","content":"c"}`,
+		`{"name":"x","cron":"@daily","timezone":"Mars/Olympus","templateVersionId":"tv-sch","content":"c"}`,
+		`{"name":"x","cron":"@daily","templateVersionId":"tv-sch"}`,
+		`{"name":"x","cron":"@daily","templateVersionId":"tv-sch","sourceUrl":"ftp://example.com/data"}`,
+		`{"name":"x","cron":"@daily","templateVersionId":"tv-missing","content":"c"}`,
+	} {
+		w := do(http.MethodPost, "/v1/schedules", body, auth.RoleEditor)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	w := do(http.MethodPost, "/v1/schedules", valid, auth.RoleEditor)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Schedule store.Schedule `json:"schedule"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	sched := created.Schedule
+	assert.True(t, sched.Enabled)
+	assert.Equal(t, "user-1", sched.CreatedBy)
+	require.NotNil(t, sched.NextRunAt)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	next := sched.NextRunAt.In(berlin)
+	assert.Equal(t, time.Monday, next.Weekday())
+	assert.Equal(t, 9, next.Hour())
+
+	w = do(http.MethodGet, "/v1/schedules", "", auth.RoleViewer)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), sched.ID)
+
+	w = do(http.MethodPatch, "/v1/schedules/"+sched.ID, `{"enabled":false}`, auth.RoleEditor)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated struct {
+		Schedule store.Schedule `json:"schedule"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.False(t, updated.Schedule.Enabled)
+	assert.Nil(t, updated.Schedule.NextRunAt, "paused schedules have no next run")
+	assert.Equal(t, "high", updated.Schedule.Quality)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPatch, "/v1/schedules/"+sched.ID, `{"sourceUrl":""}`, auth.RoleEditor).Code, "a schedule needs content or a source")
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPatch, "/v1/schedules/"+sched.ID, `{"sourceUrl":"http://169.254.169.254/latest/meta-data/"}`, auth.RoleEditor).Code, "sources must be public")
+
+	w = do(http.MethodGet, "/v1/schedules/"+sched.ID, "", auth.RoleViewer)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v1/schedules/sch-missing", "", auth.RoleViewer).Code)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/v1/schedules/"+sched.ID, "", auth.RoleEditor).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/v1/schedules/"+sched.ID, "", auth.RoleEditor).Code)
+}
@@ -0,0 +1,724 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/middleware"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const (
+	scimUserSchema     = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema    = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimListSchema     = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema    = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimSPConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+
+	scimContentType = "application/scim+json"
+	scimMaxPage     = 200
+)
+
+// scimGroups are the org roles exposed to the identity provider as groups.
+var scimGroups = []auth.Role{auth.RoleOwner, auth.RoleAdmin, auth.RoleEditor, auth.RoleViewer}
+
+var scimEqFilter = regexp.MustCompile(`(?i)^\s*(userName|displayName)\s+eq\s+"([^"]*)"\s*$`)
+
+type ctxKeySCIMOrg struct{}
+
+type scimName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type scimEmail struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimRef struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+type scimUser struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *scimName   `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []scimEmail `json:"emails,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+	Groups      []scimRef   `json:"groups,omitempty"`
+}
+
+type scimGroup struct {
+	Schemas     []string  `json:"schemas"`
+	ID          string    `json:"id"`
+	DisplayName string    `json:"displayName"`
+	Members     []scimRef `json:"members"`
+}
+
+type scimListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+type scimPatchRequest struct {
+	Operations []scimPatchOp `json:"Operations"`
+}
+
+type scimPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+func writeSCIM(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", scimContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeSCIMError(w http.ResponseWriter, status int, detail string) {
+	writeSCIM(w, status, map[string]any{
+		"schemas": []string{scimErrorSchema},
+		"status":  strconv.Itoa(status),
+		"detail":  detail,
+	})
+}
+
+func hashSCIMToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// scimHandler serves /scim/v2. It authenticates with the org's SCIM bearer
+// token rather than a user JWT, so it is mounted outside the /v1 chain.
+func (s *Server) scimHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /scim/v2/ServiceProviderConfig", s.handleSCIMServiceProviderConfig)
+	mux.HandleFunc("GET /scim/v2/Users", s.handleSCIMListUsers)
+	mux.HandleFunc("POST /scim/v2/Users", s.handleSCIMCreateUser)
+	mux.HandleFunc("GET /scim/v2/Users/{id}", s.handleSCIMGetUser)
+	mux.HandleFunc("PUT /scim/v2/Users/{id}", s.handleSCIMReplaceUser)
+	mux.HandleFunc("PATCH /scim/v2/Users/{id}", s.handleSCIMPatchUser)
+	mux.HandleFunc("DELETE /scim/v2/Users/{id}", s.handleSCIMDeleteUser)
+	mux.HandleFunc("GET /scim/v2/Groups", s.handleSCIMListGroups)
+	mux.HandleFunc("GET /scim/v2/Groups/{id}", s.handleSCIMGetGroup)
+	mux.HandleFunc("PATCH /scim/v2/Groups/{id}", s.handleSCIMPatchGroup)
+	mux.HandleFunc("/scim/v2/", func(w http.ResponseWriter, r *http.Request) {
+		writeSCIMError(w, http.StatusNotFound, "resource not found")
+	})
+
+	h := s.withSCIMAuth(mux)
+	h = withRequestID(h)
+	h = middleware.RecoveryMiddleware(h)
+	h = middleware.LoggingMiddleware(h)
+	return h
+}
+
+func (s *Server) withSCIMAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			writeSCIMError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+		hash := hashSCIMToken(token)
+		org, found, err := s.Store.Organizations().GetOrganizationBySCIMTokenHash(r.Context(), hash)
+		if err != nil {
+			logger.LogError(r.Context(), "scim", "authenticate", err)
+			writeSCIMError(w, http.StatusInternalServerError, "failed to authenticate")
+			return
+		}
+		if !found || subtle.ConstantTimeCompare([]byte(org.SCIMTokenHash), []byte(hash)) != 1 {
+			logger.LogAuthEvent(r.Context(), "scim_auth", "", false)
+			writeSCIMError(w, http.StatusUnauthorized, "invalid bearer token")
+			return
+		}
+		ctx := context.WithValue(r.Context(), ctxKeySCIMOrg{}, org.ID)
+		ctx = logger.ContextWithIdentity(ctx, "", org.ID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func scimOrgID(ctx context.Context) string {
+	orgID, _ := ctx.Value(ctxKeySCIMOrg{}).(string)
+	return orgID
+}
+
+// handleRotateSCIMToken issues a new SCIM token for the caller's org,
+// invalidating the previous one. The plaintext is only returned here.
+func (s *Server) handleRotateSCIMToken(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleOwner) {
+		writeError(w, r, http.StatusForbidden, "only owners can manage the SCIM token")
+		return
+	}
+
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		logger.LogError(r.Context(), "api", "rotate_scim_token", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to generate token")
+		return
+	}
+	token := "scim_" + hex.EncodeToString(b[:])
+	if err := s.Store.Organizations().SetSCIMTokenHash(r.Context(), id.OrgID, hashSCIMToken(token)); err != nil {
+		logger.LogError(r.Context(), "api", "rotate_scim_token", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to store token")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "scim.token.rotate", TargetRef: id.OrgID})
+	writeJSON(w, http.StatusCreated, map[string]any{"token": token, "baseUrl": "/scim/v2"})
+}
+
+func (s *Server) handleRevokeSCIMToken(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleOwner) {
+		writeError(w, r, http.StatusForbidden, "only owners can manage the SCIM token")
+		return
+	}
+	if err := s.Store.Organizations().SetSCIMTokenHash(r.Context(), id.OrgID, ""); err != nil {
+		logger.LogError(r.Context(), "api", "revoke_scim_token", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to revoke token")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "scim.token.revoke", TargetRef: id.OrgID})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleSCIMServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	writeSCIM(w, http.StatusOK, map[string]any{
+		"schemas":        []string{scimSPConfigSchema},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": scimMaxPage},
+		"changePassword": map[string]bool{"supported": false},
+		"sort":           map[string]bool{"supported": false},
+		"etag":           map[string]bool{"supported": false},
+		"authenticationSchemes": []map[string]string{{
+			"type": "oauthbearertoken",
+			"name": "OAuth Bearer Token",
+		}},
+	})
+}
+
+// auditSCIM records directory-driven changes. The org itself is the actor
+// since no user session is involved.
+func (s *Server) auditSCIM(ctx context.Context, orgID, action, target string, meta map[string]any) {
+	_, _ = s.Store.Audit().Append(ctx, store.AuditLog{ID: newID("aud"), OrgID: orgID, ActorID: orgID, Action: action, TargetRef: target, Metadata: meta})
+}
+
+func (s *Server) scimMembership(ctx context.Context, orgID, userID string) (store.UserOrg, bool, error) {
+	members, err := s.Store.Users().ListOrgMembers(ctx, orgID)
+	if err != nil {
+		return store.UserOrg{}, false, err
+	}
+	for _, m := range members {
+		if m.UserID == userID {
+			return m, true, nil
+		}
+	}
+	return store.UserOrg{}, false, nil
+}
+
+func toSCIMUser(u store.User, role auth.Role, active bool) scimUser {
+	su := scimUser{
+		Schemas:     []string{scimUserSchema},
+		ID:          u.ID,
+		UserName:    u.Email,
+		DisplayName: u.Name,
+		Emails:      []scimEmail{{Value: u.Email, Primary: true}},
+		Active:      &active,
+	}
+	if u.Name != "" {
+		su.Name = &scimName{Formatted: u.Name}
+	}
+	if active {
+		su.Groups = []scimRef{{Value: string(role), Display: string(role)}}
+	}
+	return su
+}
+
+// displayName picks the best available name from a SCIM user payload.
+func (u scimUser) displayName() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	if u.Name != nil {
+		if u.Name.Formatted != "" {
+			return u.Name.Formatted
+		}
+		return strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+	}
+	return ""
+}
+
+func (u scimUser) email() string {
+	for _, e := range u.Emails {
+		if e.Primary && e.Value != "" {
+			return e.Value
+		}
+	}
+	if strings.Contains(u.UserName, "@") {
+		return u.UserName
+	}
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return ""
+}
+
+// scimPage parses 1-based startIndex/count query parameters.
+func scimPage(r *http.Request) (start, count int) {
+	start, count = 1, 100
+	if v, err := strconv.Atoi(r.URL.Query().Get("startIndex")); err == nil && v > 0 {
+		start = v
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("count")); err == nil && v >= 0 {
+		count = min(v, scimMaxPage)
+	}
+	return start, count
+}
+
+func scimList(r *http.Request, items []any) scimListResponse {
+	start, count := scimPage(r)
+	page := []any{}
+	if from := start - 1; from < len(items) {
+		page = items[from:min(from+count, len(items))]
+	}
+	return scimListResponse{
+		Schemas:      []string{scimListSchema},
+		TotalResults: len(items),
+		StartIndex:   start,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	}
+}
+
+// scimFilter supports the `attr eq "value"` form identity providers use to
+// look up existing resources before creating them.
+func scimFilter(r *http.Request, attr string) (value string, filtered bool, err error) {
+	f := r.URL.Query().Get("filter")
+	if f == "" {
+		return "", false, nil
+	}
+	m := scimEqFilter.FindStringSubmatch(f)
+	if m == nil || !strings.EqualFold(m[1], attr) {
+		return "", false, fmt.Errorf("unsupported filter %q", f)
+	}
+	return m[2], true, nil
+}
+
+func (s *Server) handleSCIMListUsers(w http.ResponseWriter, r *http.Request) {
+	orgID := scimOrgID(r.Context())
+	userName, filtered, err := scimFilter(r, "userName")
+	if err != nil {
+		writeSCIMError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	members, err := s.Store.Users().ListOrgMembers(r.Context(), orgID)
+	if err != nil {
+		logger.LogError(r.Context(), "scim", "list_users", err, "org_id", orgID)
+		writeSCIMError(w, http.StatusInternalServerError, "failed to list users")
+		return
+	}
+
+	items := []any{}
+	for _, m := range members {
+		u, ok, err := s.Store.Users().GetUser(r.Context(), m.UserID)
+		if err != nil || !ok {
+			continue
+		}
+		if filtered && !strings.EqualFold(u.Email, userName) {
+			continue
+		}
+		items = append(items, toSCIMUser(u, m.Role, true))
+	}
+	writeSCIM(w, http.StatusOK, scimList(r, items))
+}
+
+func (s *Server) handleSCIMCreateUser(w http.ResponseWriter, r *http.Request) {
+	orgID := scimOrgID(r.Context())
+	var req scimUser
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	email := req.email()
+	if email == "" {
+		writeSCIMError(w, http.StatusBadRequest, "userName or emails must contain an email address")
+		return
+	}
+
+	user, exists, err := s.Store.Users().GetUserByEmail(r.Context(), email)
+	if err != nil {
+		logger.LogError(r.Context(), "scim", "create_user", err, "org_id", orgID)
+		writeSCIMError(w, http.StatusInternalServerError, "failed to look up user")
+		return
+	}
+	if exists {
+		if _, member, err := s.scimMembership(r.Context(), orgID, user.ID); err == nil && member {
+			writeSCIMError(w, http.StatusConflict, "user is already a member of this organization")
+			return
+		}
+	} else {
+		user = store.User{ID: newID("user"), Email: email, Name: req.displayName()}
+		if err := s.Store.Users().CreateUser(r.Context(), &user); err != nil {
+			logger.LogError(r.Context(), "scim", "create_user", err, "org_id", orgID)
+			writeSCIMError(w, http.StatusInternalServerError, "failed to create user")
+			return
+		}
+	}
+
+	if req.Active != nil && !*req.Active {
+		// Provisioned as inactive: the account exists but gets no access.
+		writeSCIM(w, http.StatusCreated, toSCIMUser(user, "", false))
+		return
+	}
+	if err := s.Store.Users().CreateUserOrg(r.Context(), store.UserOrg{UserID: user.ID, OrgID: orgID, Role: auth.RoleEditor}); err != nil {
+		logger.LogError(r.Context(), "scim", "create_user", err, "org_id", orgID)
+		writeSCIMError(w, http.StatusInternalServerError, "failed to add user to organization")
+		return
+	}
+	s.membership.invalidate(user.ID, orgID)
+	s.auditSCIM(r.Context(), orgID, "scim.user.provision", user.ID, map[string]any{"role": auth.RoleEditor})
+
+	writeSCIM(w, http.StatusCreated, toSCIMUser(user, auth.RoleEditor, true))
+}
+
+func (s *Server) handleSCIMGetUser(w http.ResponseWriter, r *http.Request) {
+	orgID := scimOrgID(r.Context())
+	userID := r.PathValue("id")
+	m, member, err := s.scimMembership(r.Context(), orgID, userID)
+	if err != nil || !member {
+		writeSCIMError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	u, ok, err := s.Store.Users().GetUser(r.Context(), userID)
+	if err != nil || !ok {
+		writeSCIMError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	writeSCIM(w, http.StatusOK, toSCIMUser(u, m.Role, true))
+}
+
+func (s *Server) handleSCIMReplaceUser(w http.ResponseWriter, r *http.Request) {
+	var req scimUser
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	active := req.Active == nil || *req.Active
+	name := req.displayName()
+	s.updateSCIMUser(w, r, &name, &active)
+}
+
+func (s *Server) handleSCIMPatchUser(w http.ResponseWriter, r *http.Request) {
+	var req scimPatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	var name *string
+	var active *bool
+	for _, op := range req.Operations {
+		if !strings.EqualFold(op.Op, "replace") && !strings.EqualFold(op.Op, "add") {
+			continue
+		}
+		// Without a path the value is a partial user object (Azure AD style).
+		var fields map[string]json.RawMessage
+		if op.Path == "" {
+			if err := json.Unmarshal(op.Value, &fields); err != nil {
+				writeSCIMError(w, http.StatusBadRequest, "invalid patch value")
+				return
+			}
+		} else {
+			fields = map[string]json.RawMessage{op.Path: op.Value}
+		}
+		for path, raw := range fields {
+			switch strings.ToLower(path) {
+			case "active":
+				var v bool
+				if err := json.Unmarshal(raw, &v); err != nil {
+					// Some providers send "True"/"False" strings.
+					var str string
+					if json.Unmarshal(raw, &str) != nil {
+						writeSCIMError(w, http.StatusBadRequest, "active must be a boolean")
+						return
+					}
+					v = strings.EqualFold(str, "true")
+				}
+				active = &v
+			case "displayname", "name.formatted":
+				var v string
+				if err := json.Unmarshal(raw, &v); err == nil {
+					name = &v
+				}
+			}
+		}
+	}
+	s.updateSCIMUser(w, r, name, active)
+}
+
+// updateSCIMUser applies name and active changes. Deactivation removes the
+// org membership, which immediately revokes the user's tokens for this org.
+// Deactivated users are no longer addressable by ID; providers re-provision
+// them with POST, so an org can't pull in arbitrary users by guessing IDs.
+func (s *Server) updateSCIMUser(w http.ResponseWriter, r *http.Request, name *string, active *bool) {
+	orgID := scimOrgID(r.Context())
+	userID := r.PathValue("id")
+
+	m, member, err := s.scimMembership(r.Context(), orgID, userID)
+	if err != nil || !member {
+		writeSCIMError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	u, ok, err := s.Store.Users().GetUser(r.Context(), userID)
+	if err != nil || !ok {
+		writeSCIMError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	if name != nil && *name != "" && *name != u.Name {
+		u.Name = *name
+		if err := s.Store.Users().UpdateUser(r.Context(), u); err != nil {
+			logger.LogError(r.Context(), "scim", "update_user", err, "org_id", orgID)
+			writeSCIMError(w, http.StatusInternalServerError, "failed to update user")
+			return
+		}
+	}
+
+	if active != nil && !*active {
+		if status, msg := s.removeSCIMMember(r.Context(), orgID, m); status != 0 {
+			writeSCIMError(w, status, msg)
+			return
+		}
+		writeSCIM(w, http.StatusOK, toSCIMUser(u, "", false))
+		return
+	}
+	writeSCIM(w, http.StatusOK, toSCIMUser(u, m.Role, true))
+}
+
+func (s *Server) handleSCIMDeleteUser(w http.ResponseWriter, r *http.Request) {
+	orgID := scimOrgID(r.Context())
+	m, member, err := s.scimMembership(r.Context(), orgID, r.PathValue("id"))
+	if err != nil || !member {
+		writeSCIMError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if status, msg := s.removeSCIMMember(r.Context(), orgID, m); status != 0 {
+		writeSCIMError(w, status, msg)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeSCIMMember deprovisions a member. The user record is kept since it
+// may belong to other orgs. A zero status means success.
+func (s *Server) removeSCIMMember(ctx context.Context, orgID string, m store.UserOrg) (int, string) {
+	if m.Role == auth.RoleOwner {
+		if last, err := s.isLastOwner(ctx, orgID, m.UserID); err != nil || last {
+			return http.StatusConflict, "cannot remove the last owner of the organization"
+		}
+	}
+	if _, err := s.Store.Users().DeleteUserOrg(ctx, m.UserID, orgID); err != nil {
+		logger.LogError(ctx, "scim", "deprovision_user", err, "org_id", orgID)
+		return http.StatusInternalServerError, "failed to remove user"
+	}
+	s.membership.invalidate(m.UserID, orgID)
+	s.auditSCIM(ctx, orgID, "scim.user.deprovision", m.UserID, map[string]any{"role": m.Role})
+	return 0, ""
+}
+
+func (s *Server) isLastOwner(ctx context.Context, orgID, userID string) (bool, error) {
+	members, err := s.Store.Users().ListOrgMembers(ctx, orgID)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range members {
+		if m.Role == auth.RoleOwner && m.UserID != userID {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func scimGroupRole(id string) (auth.Role, bool) {
+	for _, role := range scimGroups {
+		if strings.EqualFold(string(role), id) {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+func (s *Server) scimGroup(ctx context.Context, orgID string, role auth.Role) (scimGroup, error) {
+	g := scimGroup{Schemas: []string{scimGroupSchema}, ID: string(role), DisplayName: string(role), Members: []scimRef{}}
+	members, err := s.Store.Users().ListOrgMembers(ctx, orgID)
+	if err != nil {
+		return g, err
+	}
+	for _, m := range members {
+		if m.Role != role {
+			continue
+		}
+		ref := scimRef{Value: m.UserID}
+		if u, ok, err := s.Store.Users().GetUser(ctx, m.UserID); err == nil && ok {
+			ref.Display = u.Email
+		}
+		g.Members = append(g.Members, ref)
+	}
+	return g, nil
+}
+
+func (s *Server) handleSCIMListGroups(w http.ResponseWriter, r *http.Request) {
+	orgID := scimOrgID(r.Context())
+	displayName, filtered, err := scimFilter(r, "displayName")
+	if err != nil {
+		writeSCIMError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items := []any{}
+	for _, role := range scimGroups {
+		if filtered && !strings.EqualFold(string(role), displayName) {
+			continue
+		}
+		g, err := s.scimGroup(r.Context(), orgID, role)
+		if err != nil {
+			logger.LogError(r.Context(), "scim", "list_groups", err, "org_id", orgID)
+			writeSCIMError(w, http.StatusInternalServerError, "failed to list groups")
+			return
+		}
+		items = append(items, g)
+	}
+	writeSCIM(w, http.StatusOK, scimList(r, items))
+}
+
+func (s *Server) handleSCIMGetGroup(w http.ResponseWriter, r *http.Request) {
+	role, ok := scimGroupRole(r.PathValue("id"))
+	if !ok {
+		writeSCIMError(w, http.StatusNotFound, "group not found")
+		return
+	}
+	g, err := s.scimGroup(r.Context(), scimOrgID(r.Context()), role)
+	if err != nil {
+		writeSCIMError(w, http.StatusInternalServerError, "failed to load group")
+		return
+	}
+	writeSCIM(w, http.StatusOK, g)
+}
+
+// handleSCIMPatchGroup maps group membership onto roles: adding a member
+// grants that role, removing one drops them back to Viewer. Membership of
+// the org itself is managed through Users.
+func (s *Server) handleSCIMPatchGroup(w http.ResponseWriter, r *http.Request) {
+	orgID := scimOrgID(r.Context())
+	role, ok := scimGroupRole(r.PathValue("id"))
+	if !ok {
+		writeSCIMError(w, http.StatusNotFound, "group not found")
+		return
+	}
+
+	var req scimPatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	for _, op := range req.Operations {
+		var target auth.Role
+		switch strings.ToLower(op.Op) {
+		case "add":
+			target = role
+		case "remove":
+			target = auth.RoleViewer
+		default:
+			writeSCIMError(w, http.StatusBadRequest, fmt.Sprintf("unsupported operation %q", op.Op))
+			return
+		}
+
+		userIDs, err := scimPatchMembers(op)
+		if err != nil {
+			writeSCIMError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		for _, userID := range userIDs {
+			m, member, err := s.scimMembership(r.Context(), orgID, userID)
+			if err != nil || !member {
+				writeSCIMError(w, http.StatusNotFound, fmt.Sprintf("user %s is not a member", userID))
+				return
+			}
+			// Removing from a group the user isn't in is a no-op.
+			if target == auth.RoleViewer && m.Role != role {
+				continue
+			}
+			if m.Role == target {
+				continue
+			}
+			if m.Role == auth.RoleOwner {
+				if last, err := s.isLastOwner(r.Context(), orgID, userID); err != nil || last {
+					writeSCIMError(w, http.StatusConflict, "cannot demote the last owner of the organization")
+					return
+				}
+			}
+			if err := s.Store.Users().SetUserOrgRole(r.Context(), userID, orgID, target); err != nil {
+				logger.LogError(r.Context(), "scim", "patch_group", err, "org_id", orgID)
+				writeSCIMError(w, http.StatusInternalServerError, "failed to update role")
+				return
+			}
+			s.membership.invalidate(userID, orgID)
+			s.auditSCIM(r.Context(), orgID, "scim.role.update", userID, map[string]any{"from": m.Role, "to": target})
+		}
+	}
+
+	g, err := s.scimGroup(r.Context(), orgID, role)
+	if err != nil {
+		writeSCIMError(w, http.StatusInternalServerError, "failed to load group")
+		return
+	}
+	writeSCIM(w, http.StatusOK, g)
+}
+
+var scimMemberPathFilter = regexp.MustCompile(`(?i)^members\[value eq "([^"]+)"\]$`)
+
+// scimPatchMembers extracts user IDs from either a members value list or a
+// `members[value eq "id"]` path.
+func scimPatchMembers(op scimPatchOp) ([]string, error) {
+	if m := scimMemberPathFilter.FindStringSubmatch(op.Path); m != nil {
+		return []string{m[1]}, nil
+	}
+	if !strings.EqualFold(op.Path, "members") {
+		return nil, fmt.Errorf("unsupported path %q", op.Path)
+	}
+	var refs []scimRef
+	if err := json.Unmarshal(op.Value, &refs); err != nil {
+		return nil, fmt.Errorf("members value must be a list of references")
+	}
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		ids = append(ids, ref.Value)
+	}
+	return ids, nil
+}
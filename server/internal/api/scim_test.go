@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func newSCIMTestServer(t *testing.T) (*Server, http.Handler, string) {
+	t.Helper()
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Org"}))
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "owner-1", Email: "owner@acme.com"}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "owner-1", OrgID: "org-1", Role: auth.RoleOwner}))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/org/scim-token", nil)
+	addTestAuth(req, "owner-1", "org-1", auth.RoleOwner)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var resp struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Token)
+	return s, h, resp.Token
+}
+
+func scimDo(h http.Handler, token, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", scimContentType)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestSCIM_ProvisionPromoteAndDeprovision(t *testing.T) {
+	s, h, token := newSCIMTestServer(t)
+
+	w := scimDo(h, "wrong", http.MethodGet, "/scim/v2/Users", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = scimDo(h, token, http.MethodPost, "/scim/v2/Users",
+		`{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"ada@acme.com","name":{"givenName":"Ada","familyName":"Lovelace"},"active":true}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, scimContentType, w.Header().Get("Content-Type"))
+	var created scimUser
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "Ada Lovelace", created.DisplayName)
+
+	w = scimDo(h, token, http.MethodPost, "/scim/v2/Users", `{"userName":"ada@acme.com"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = scimDo(h, token, http.MethodGet, "/scim/v2/Users?filter="+url.QueryEscape(`userName eq "ada@acme.com"`), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list scimListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, 1, list.TotalResults)
+
+	// Adding to the Admin group promotes the member.
+	w = scimDo(h, token, http.MethodPatch, "/scim/v2/Groups/Admin",
+		`{"Operations":[{"op":"add","path":"members","value":[{"value":"`+created.ID+`"}]}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	m, ok, err := s.scimMembership(context.Background(), "org-1", created.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, auth.RoleAdmin, m.Role)
+
+	// The last owner can't be removed.
+	w = scimDo(h, token, http.MethodDelete, "/scim/v2/Users/owner-1", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Deactivation revokes the user's existing tokens immediately.
+	meReq := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/templates", nil)
+		addTestAuth(req, created.ID, "org-1", auth.RoleAdmin)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	require.Equal(t, http.StatusOK, meReq())
+
+	w = scimDo(h, token, http.MethodPatch, "/scim/v2/Users/"+created.ID,
+		`{"Operations":[{"op":"replace","value":{"active":false}}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, meReq())
+
+	w = scimDo(h, token, http.MethodGet, "/scim/v2/Users/"+created.ID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSCIM_GroupRemovalDemotesIssuedTokens(t *testing.T) {
+	s, h, token := newSCIMTestServer(t)
+	ctx := context.Background()
+	require.NoError(t, s.Store.Users().CreateUser(ctx, &store.User{ID: "admin-1", Email: "admin@acme.com"}))
+	require.NoError(t, s.Store.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "admin-1", OrgID: "org-1", Role: auth.RoleAdmin}))
+
+	listWebhooks := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/webhooks", nil)
+		addTestAuth(req, "admin-1", "org-1", auth.RoleAdmin)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	require.Equal(t, http.StatusOK, listWebhooks())
+
+	w := scimDo(h, token, http.MethodPatch, "/scim/v2/Groups/Admin",
+		`{"Operations":[{"op":"remove","path":"members","value":[{"value":"admin-1"}]}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusForbidden, listWebhooks(), "the Admin token issued before the demotion acts as a Viewer")
+}
+
+func TestSCIMToken_RequiresOwnerAndRevokes(t *testing.T) {
+	_, h, token := newSCIMTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/org/scim-token", nil)
+	addTestAuth(req, "owner-1", "org-1", auth.RoleAdmin)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/v1/org/scim-token", nil)
+	addTestAuth(req, "owner-1", "org-1", auth.RoleOwner)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, scimDo(h, token, http.MethodGet, "/scim/v2/Groups", "").Code)
+}
@@ -1,12 +1,24 @@
 package api
 
 import (
+	"context"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/ziyad/cms-ai/server/internal/ai"
 	"github.com/ziyad/cms-ai/server/internal/assets"
 	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/flags"
+	"github.com/ziyad/cms-ai/server/internal/docimport"
+	"github.com/ziyad/cms-ai/server/internal/gslides"
+	"github.com/ziyad/cms-ai/server/internal/imagegen"
+	"github.com/ziyad/cms-ai/server/internal/mail"
+	"github.com/ziyad/cms-ai/server/internal/service"
+	"github.com/ziyad/cms-ai/server/internal/slack"
 	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/stock"
 	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/webhooks"
+	"github.com/ziyad/cms-ai/server/internal/worker"
 )
 
 type Server struct {
@@ -15,7 +27,53 @@ type Server struct {
 	Store         store.Store
 	Validator     spec.Validator
 	ObjectStorage assets.ObjectStorage
-	AIService     ai.AIServiceInterface
-	Renderer      assets.Renderer
-	validate      *validator.Validate
+	// StorageRegions are the regions ObjectStorage routes to besides the
+	// default backend; orgs pick one with the storageRegion setting.
+	StorageRegions []string
+	AIService      ai.AIServiceInterface
+	// Orchestrator answers the AI calls made outside AIService: deck
+	// outlines and stock image plans. nil uses the mock.
+	Orchestrator ai.Orchestrator
+	Renderer     assets.Renderer
+	// DraftRenderer and HighRenderer serve the draft and high export
+	// quality profiles; nil falls back to Renderer.
+	DraftRenderer assets.Renderer
+	HighRenderer  assets.Renderer
+	// RendererFactory builds renderers for exports that name an engine,
+	// either themselves or through their org's default; nil ignores them.
+	RendererFactory *assets.RendererFactory
+	Worker          *worker.Worker // set when jobs run in-process; reported by /readyz
+	OIDC            map[string]*auth.OIDCProvider
+	GoogleSlides    *gslides.Client
+	DocImport       *docimport.Client // reads Notion and Confluence pages for imports
+	Webhooks        *webhooks.Dispatcher
+	Slack           *slack.Client
+	Stock           *stock.Client
+	ImageGen        *imagegen.Pipeline // generates slide backgrounds; nil without a HuggingFace key
+	Mail            mail.Sender        // sends email change confirmations; nil without SMTP_ADDR
+	Flags           *flags.Evaluator   // resolves each request's feature flags
+	// LookupTXT resolves the DNS TXT records that verify SSO domain claims;
+	// nil uses the system resolver.
+	LookupTXT       func(ctx context.Context, name string) ([]string, error)
+	membership      *membershipCache
+	activity        *activityCache
+	refinements     *service.RefinementHistory
+	validate        *validator.Validate
+	closeStore      func() error // flushes the memory store snapshot, if any
+}
+
+func (s *Server) orchestrator() ai.Orchestrator {
+	if s.Orchestrator == nil {
+		return ai.NewMockOrchestrator()
+	}
+	return s.Orchestrator
+}
+
+// Close releases resources the server owns, such as the memory store's
+// snapshot writer. It does not close the Store itself.
+func (s *Server) Close() error {
+	if s.closeStore == nil {
+		return nil
+	}
+	return s.closeStore()
 }
@@ -2,76 +2,241 @@ package api
 
 import (
 	"context"
-	"log"
 	"os"
+	"time"
 
-	lib_validator "github.com/go-playground/validator/v10"
 	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/alerts"
 	"github.com/ziyad/cms-ai/server/internal/assets"
 	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/config"
+	"github.com/ziyad/cms-ai/server/internal/flags"
+	"github.com/ziyad/cms-ai/server/internal/docimport"
+	"github.com/ziyad/cms-ai/server/internal/gslides"
+	"github.com/ziyad/cms-ai/server/internal/imagegen"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/mail"
+	"github.com/ziyad/cms-ai/server/internal/service"
+	"github.com/ziyad/cms-ai/server/internal/slack"
 	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/stock"
 	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/cache"
 	"github.com/ziyad/cms-ai/server/internal/store/memory"
 	"github.com/ziyad/cms-ai/server/internal/store/postgres"
+	"github.com/ziyad/cms-ai/server/internal/webhooks"
 	"github.com/ziyad/cms-ai/server/internal/worker"
 )
 
+// NewServer builds a server from the environment. It panics if the
+// environment doesn't hold a valid configuration; cmd/server loads and
+// reports it itself and calls New.
 func NewServer() *Server {
-	log.Println("Starting server initialization...")
-	config := LoadConfig()
+	return New(mustLoadConfig())
+}
+
+func mustLoadConfig() *config.Config {
+	cfg, err := config.Load(os.Getenv)
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+// New builds a server from cfg, falling back to local storage and the
+// in-memory store when the configured ones can't be reached.
+func New(cfg *config.Config) *Server {
+	logger.Logger.Info("server_init_start")
+	// cmd/server installs rotated secrets from its provider as they change;
+	// a server built straight from cfg signs with the configured ones.
+	if cfg.API.JWTSecret != "" {
+		if err := auth.SetJWTSecrets(cfg.API.JWTSecret, cfg.API.JWTSecretPrevious); err != nil {
+			logger.Logger.Error("jwt_secret_rejected", "error", err)
+		}
+	}
 	authenticator := auth.JWTAuthenticator{}
 	validator := spec.DefaultValidator{}
 
 	// Create object storage (fall back to local if cloud fails)
 	factory := assets.NewStorageFactory()
-	objectStorage, err := factory.CreateStorage(context.Background())
+	objectStorage, err := factory.CreateStorageWithConfig(context.Background(), cfg.Storage)
 	if err != nil {
-		log.Printf("Object storage init failed (%v), using local storage", err)
+		logger.Storage().Warn("object_storage_init_failed_using_local", "error", err)
 		objectStorage, _ = assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: "/tmp/cms-ai-assets"})
 	}
+	storageRouter := assets.NewStorageRouter(objectStorage)
+	for region, regionCfg := range cfg.StorageRegions {
+		backend, err := factory.CreateStorageWithConfig(context.Background(), regionCfg)
+		if err != nil {
+			// No fallback here: the region's orgs get failed uploads rather
+			// than files stored outside it.
+			logger.Storage().Error("storage_region_init_failed", "region", region, "error", err)
+			continue
+		}
+		storageRouter.Add(region, backend)
+	}
+	objectStorage = storageRouter
 
 	var st store.Store
-	dsn := os.Getenv("DATABASE_URL")
-
-	if dsn != "" {
-		pg, err := postgres.New(dsn)
+	var closeStore func() error
+	if dsn := cfg.Database.URL; dsn != "" {
+		pg, err := postgres.NewWithOptions(dsn, cfg.Database.PostgresOptions())
 		if err != nil {
-			log.Printf("Postgres connection failed: %v. Falling back to in-memory store.", err)
-			st = memory.New()
+			logger.Database().Error("postgres_connect_failed_using_memory", "error", err)
+			st, closeStore = newMemoryStore(cfg.Database.MemorySnapshotPath)
 		} else {
+			if c, err := cache.New(cfg.Database.Cache); err != nil {
+				logger.Database().Warn("store_cache_disabled", "error", err)
+			} else {
+				pg.UseCache(c)
+			}
 			st = pg
-			log.Println("Connected to PostgreSQL")
+			logger.Database().Info("postgres_connected")
 		}
 	} else {
-		log.Println("No DATABASE_URL set, using in-memory store")
-		st = memory.New()
+		logger.Database().Info("database_url_unset_using_memory")
+		st, closeStore = newMemoryStore(cfg.Database.MemorySnapshotPath)
 	}
 
-	aiService := ai.NewAIService(st)
+	aiOptions := ai.Options{
+		Mock:        cfg.AI.Mock,
+		APIKey:      cfg.API.HuggingFaceAPIKey,
+		Model:       cfg.AI.Model,
+		Chains:      cfg.AI.Chains,
+		Breaker:     cfg.AI.Breaker,
+		Offline:     cfg.AI.Offline,
+		OllamaURL:   cfg.AI.OllamaURL,
+		OllamaModel: cfg.AI.OllamaModel,
+	}
+	aiService := ai.NewAIServiceWithOptions(st, aiOptions)
 
-	var renderer assets.Renderer
-	if os.Getenv("HUGGINGFACE_API_KEY") != "" {
-		renderer = assets.NewAIEnhancedRenderer(st)
-	} else {
-		renderer = assets.NewPythonPPTXRenderer("")
+	rendererFactory := &assets.RendererFactory{Store: st, HuggingFaceAPIKey: cfg.Renderer.HuggingFaceAPIKey, Offline: cfg.AI.Offline}
+	if cfg.AI.Offline {
+		logger.Logger.Info("offline_mode", "ollama_url", cfg.AI.OllamaURL, "ollama_model", cfg.AI.OllamaModel)
+	} else if url := cfg.Renderer.ServiceURL; url != "" {
+		rendererFactory.Remote = assets.NewRemoteRenderer(url, cfg.Renderer.ServiceToken)
+		logger.Logger.Info("render_service_configured", "url", url)
+	}
+	engine := cfg.Renderer.Default
+	renderer, err := rendererFactory.New(engine)
+	if err != nil {
+		if engine != "" {
+			logger.Logger.Warn("default_renderer_unavailable", "renderer", engine, "error", err)
+		}
+		switch {
+		case cfg.AI.Offline:
+			engine = assets.RendererGo
+		case rendererFactory.Remote != nil:
+			engine = assets.RendererRemote
+		case cfg.API.HuggingFaceAPIKey != "":
+			engine = assets.RendererAI
+		default:
+			engine = assets.RendererPython
+		}
+		renderer, _ = rendererFactory.New(engine)
+	}
+
+	// Offline, high quality exports get the Go renderer too: the
+	// AI-enhanced one needs Python and Hugging Face.
+	highRenderer := assets.Renderer(assets.NewAIEnhancedRenderer(st, cfg.Renderer.HuggingFaceAPIKey))
+	if cfg.AI.Offline {
+		highRenderer = renderer
+	}
+
+	var imageGen *imagegen.Pipeline
+	if cfg.API.HuggingFaceAPIKey != "" && !cfg.AI.Offline {
+		imageGen = &imagegen.Pipeline{Store: st, Objects: objectStorage, Generator: imagegen.NewHuggingFace(cfg.API.HuggingFaceAPIKey, cfg.API.HuggingFaceImageModel)}
+	}
+
+	var mailer mail.Sender
+	if m := mail.FromEnv(os.Getenv); m != nil {
+		mailer = m
+	}
+
+	oidcProviders := make(map[string]*auth.OIDCProvider, len(cfg.API.OIDCProviders))
+	for _, p := range cfg.API.OIDCProviders {
+		oidcProviders[p.Name] = auth.NewOIDCProvider(p)
 	}
 
-	log.Println("Server initialization complete")
+	logger.Logger.Info("server_init_complete")
 	return &Server{
-		Config:        config,
-		Authenticator: authenticator,
-		Store:         st,
-		Validator:     validator,
-		Renderer:      renderer,
-		ObjectStorage: objectStorage,
-		AIService:     aiService,
-		validate:      lib_validator.New(),
+		Config:          cfg.API,
+		Authenticator:   authenticator,
+		Store:           st,
+		Validator:       validator,
+		Renderer:        renderer,
+		DraftRenderer:   assets.NewDraftRenderer(),
+		HighRenderer:    highRenderer,
+		RendererFactory: rendererFactory,
+		ObjectStorage:   objectStorage,
+		StorageRegions:  storageRouter.Regions(),
+		AIService:       aiService,
+		Orchestrator:    ai.NewOrchestratorWithOptions(aiOptions),
+		OIDC:            oidcProviders,
+		GoogleSlides:    gslides.NewClient(cfg.API.GoogleClientID, cfg.API.GoogleClientSecret),
+		DocImport:       docimport.NewClient(),
+		Webhooks:        webhooks.NewDispatcher(st),
+		Slack:           slack.NewClient(),
+		Stock:           stock.NewClient(cfg.API.StockMediaKeys),
+		ImageGen:        imageGen,
+		Mail:            mailer,
+		Flags:           flags.NewEvaluator(st.FeatureFlags(), cfg.Features),
+		membership:      newMembershipCache(),
+		activity:        newActivityCache(),
+		refinements:     service.NewRefinementHistory(),
+		validate:        newRequestValidator(),
+		closeStore:      closeStore,
 	}
 }
 
+// memorySnapshotInterval is how often a snapshotting memory store is saved.
+const memorySnapshotInterval = 30 * time.Second
+
+// newMemoryStore returns an in-memory store. When a snapshot path is set
+// the store is loaded from that file and saved back to it periodically, so a
+// dev server keeps its data across restarts; the returned func stops saving
+// and writes a final snapshot.
+func newMemoryStore(path string) (*memory.MemoryStore, func() error) {
+	ms := memory.New()
+	if path == "" {
+		return ms, nil
+	}
+	if err := ms.LoadSnapshot(path); err != nil {
+		logger.Database().Error("memory_snapshot_load_failed", "path", path, "error", err)
+		return ms, nil
+	}
+	logger.Database().Info("memory_snapshot_enabled", "path", path)
+	return ms, ms.AutoSave(path, memorySnapshotInterval)
+}
+
+// NewServerWithWorker is NewServer with a worker sharing its store, storage
+// and renderers.
 func NewServerWithWorker() (*Server, *worker.Worker) {
-	srv := NewServer()
+	return NewWithWorker(mustLoadConfig())
+}
+
+// NewWithWorker is New with a worker tuned by cfg.Worker.
+func NewWithWorker(cfg *config.Config) (*Server, *worker.Worker) {
+	srv := New(cfg)
 	// Create worker with the same object storage as the server
 	w := worker.New(srv.Store, srv.Renderer, srv.ObjectStorage, srv.AIService)
+	w.Concurrency = cfg.Worker.Concurrency
+	w.HighPrioritySlots = cfg.Worker.HighPrioritySlots
+	w.RetryOverrides = srv.Config.RetryOverrides
+	w.DraftRenderer, w.HighRenderer = srv.DraftRenderer, srv.HighRenderer
+	w.RendererFactory = srv.RendererFactory
+	w.Fonts = srv.fonts()
+	w.Connectors = srv.connectors()
+	w.Slack = srv.Slack
+	w.StockImages = srv.stockIllustrator()
+	w.ImageGen = srv.ImageGen
+	w.DeadLetterRetryInterval = cfg.Worker.DeadLetterRetryInterval
+	w.DeadLetterMaxAutoRetries = cfg.Worker.DeadLetterMaxAutoRetries
+	w.OrgPurgeInterval = cfg.Worker.OrgPurgeInterval
+	w.ScheduleInterval = cfg.Worker.ScheduleInterval
+	if w.Alerts = alerts.FromEnv(os.Getenv); w.Alerts != nil {
+		logger.Jobs().Info("dead_letter_alerts_enabled", "channels", w.Alerts.Channels())
+	}
+	srv.Worker = w
 	return srv, w
 }
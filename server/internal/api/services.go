@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/connectors"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/policy"
+	"github.com/ziyad/cms-ai/server/internal/service"
+)
+
+// The service layer is built from the server's fields on each use, so it
+// always sees the current Store, AI service and renderer.
+
+func (s *Server) quotas() service.Quotas {
+	return service.Quotas{Store: s.Store, Limits: service.Limits{
+		GeneratePerMonth: s.Config.GenerateLimitPerMonth,
+		ExportPerMonth:   s.Config.ExportLimitPerMonth,
+		StorageBytes:     s.Config.StorageLimitBytes,
+	}}
+}
+
+func (s *Server) templateService() *service.TemplateService {
+	return &service.TemplateService{Store: s.Store, AI: s.AIService, Quotas: s.quotas()}
+}
+
+func (s *Server) deckService() *service.DeckService {
+	return &service.DeckService{Store: s.Store, AI: s.AIService, Quotas: s.quotas(), History: s.refinements}
+}
+
+func (s *Server) exportService() *service.ExportService {
+	return &service.ExportService{Store: s.Store, Quotas: s.quotas(), Renderers: s.renderers(), Objects: s.ObjectStorage, Fonts: s.fonts(), Connectors: s.connectors()}
+}
+
+func (s *Server) fonts() *assets.FontResolver {
+	return &assets.FontResolver{Store: s.Store, Objects: s.ObjectStorage}
+}
+
+func (s *Server) connectors() *connectors.Resolver {
+	return &connectors.Resolver{Store: s.Store}
+}
+
+func (s *Server) renderers() assets.QualityRenderers {
+	return assets.QualityRenderers{Draft: s.DraftRenderer, Standard: s.Renderer, High: s.HighRenderer, Factory: s.RendererFactory}
+}
+
+// writeServiceError answers with the status and code a service error stands
+// for. Unexpected errors are logged under op and reported to the caller as msg.
+func (s *Server) writeServiceError(w http.ResponseWriter, r *http.Request, op, msg string, err error) {
+	f := describeServiceError(r.Context(), op, msg, err)
+	if secs := int(math.Ceil(f.retryAfter.Seconds())); secs > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(secs))
+	}
+	writeErrorCode(w, r, f.status, f.code, f.msg, f.details)
+}
+
+// serviceFailure is what the caller is told about a failed service call.
+type serviceFailure struct {
+	status     int
+	code       string
+	msg        string
+	details    any
+	retryAfter time.Duration
+}
+
+// describeServiceError maps a service error onto the HTTP status and error
+// code it stands for; gRPC derives its status from the same. Unexpected
+// errors are logged under op and reported as msg.
+func describeServiceError(ctx context.Context, op, msg string, err error) serviceFailure {
+	var invalid *service.InvalidError
+	var quota *service.QuotaError
+	var violation *policy.ViolationError
+	switch {
+	case errors.As(err, &quota):
+		return serviceFailure{status: http.StatusPaymentRequired, code: ErrCodeQuotaExceeded, msg: quota.Error(), details: QuotaDetails{Quota: quota.Quota, Used: quota.Used, Limit: quota.Limit}}
+	case errors.As(err, &violation):
+		return serviceFailure{status: http.StatusUnprocessableEntity, code: ErrCodePolicyViolation, msg: violation.Error(), details: violation.Violations}
+	case errors.As(err, &invalid) && len(invalid.Fields) > 0:
+		details := make([]FieldError, len(invalid.Fields))
+		for i, f := range invalid.Fields {
+			details[i] = FieldError(f)
+		}
+		return serviceFailure{status: http.StatusBadRequest, code: ErrCodeValidation, msg: invalid.Msg, details: details}
+	case errors.As(err, &invalid):
+		return serviceFailure{status: http.StatusBadRequest, code: invalidCode(invalid), msg: invalid.Msg}
+	case errors.Is(err, service.ErrNotFound):
+		return serviceFailure{status: http.StatusNotFound, code: ErrCodeNotFound, msg: err.Error()}
+	case errors.Is(err, service.ErrForbidden):
+		return serviceFailure{status: http.StatusForbidden, code: ErrCodeForbidden, msg: "forbidden"}
+	case errors.Is(err, service.ErrGenerationFailed):
+		logger.LogError(ctx, "api", op, err)
+		var unavailable *ai.UnavailableError
+		if errors.As(err, &unavailable) {
+			return serviceFailure{status: http.StatusServiceUnavailable, code: ErrCodeAIUnavailable, msg: "the AI provider is unavailable; retry later", retryAfter: unavailable.RetryAfter}
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return serviceFailure{status: http.StatusGatewayTimeout, code: ErrCodeAITimeout, msg: "template generation timed out; retry without sync"}
+		}
+		return serviceFailure{status: http.StatusBadGateway, code: ErrCodeAIFailed, msg: "template generation failed"}
+	default:
+		logger.LogError(ctx, "api", op, err)
+		return serviceFailure{status: http.StatusInternalServerError, code: codeForStatus(http.StatusInternalServerError), msg: msg}
+	}
+}
+
+// invalidCode tells a stored spec the service couldn't read apart from bad
+// caller input.
+func invalidCode(e *service.InvalidError) string {
+	if e.Spec {
+		return ErrCodeSpecInvalid
+	}
+	return ErrCodeBadRequest
+}
+
+// writeQuotaError answers 402 with the quota that ran out and its usage.
+func writeQuotaError(w http.ResponseWriter, r *http.Request, qe *service.QuotaError) {
+	writeErrorCode(w, r, http.StatusPaymentRequired, ErrCodeQuotaExceeded, qe.Error(), QuotaDetails{Quota: qe.Quota, Used: qe.Used, Limit: qe.Limit})
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/slack"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// slackIntegrationResponse describes an org's Slack settings without the
+// webhook URL or bot token.
+type slackIntegrationResponse struct {
+	Configured     bool   `json:"configured"`
+	Mode           string `json:"mode,omitempty"`
+	Channel        string `json:"channel,omitempty"`
+	NotifyExports  bool   `json:"notifyExports"`
+	NotifyFailures bool   `json:"notifyFailures"`
+}
+
+func newSlackIntegrationResponse(cfg *store.SlackIntegration) slackIntegrationResponse {
+	if cfg == nil {
+		return slackIntegrationResponse{}
+	}
+	resp := slackIntegrationResponse{
+		Configured:     true,
+		Mode:           "webhook",
+		NotifyExports:  cfg.NotifyExports,
+		NotifyFailures: cfg.NotifyFailures,
+	}
+	if cfg.WebhookURL == "" {
+		resp.Mode, resp.Channel = "bot", cfg.Channel
+	}
+	return resp
+}
+
+func (s *Server) handleGetSlackIntegration(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"slack": newSlackIntegrationResponse(org.Slack)})
+}
+
+// handleSetSlackIntegration handles PUT /v1/integrations/slack, replacing
+// the org's Slack settings.
+func (s *Server) handleSetSlackIntegration(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	var req SlackIntegrationRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	req.WebhookURL, req.BotToken = strings.TrimSpace(req.WebhookURL), strings.TrimSpace(req.BotToken)
+	req.Channel = strings.TrimSpace(req.Channel)
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	cfg := &store.SlackIntegration{
+		WebhookURL:     req.WebhookURL,
+		BotToken:       req.BotToken,
+		NotifyExports:  req.NotifyExports == nil || *req.NotifyExports,
+		NotifyFailures: req.NotifyFailures == nil || *req.NotifyFailures,
+	}
+	if cfg.BotToken != "" {
+		cfg.Channel = req.Channel
+	}
+	if err := s.Store.Organizations().SetSlackIntegration(r.Context(), id.OrgID, cfg); err != nil {
+		logger.LogError(r.Context(), "api", "set_slack_integration", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to save Slack integration")
+		return
+	}
+
+	resp := newSlackIntegrationResponse(cfg)
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "integration.slack.set", TargetRef: id.OrgID, Metadata: map[string]any{"mode": resp.Mode, "channel": resp.Channel, "notifyExports": resp.NotifyExports, "notifyFailures": resp.NotifyFailures}})
+	writeJSON(w, http.StatusOK, map[string]any{"slack": resp})
+}
+
+func (s *Server) handleDeleteSlackIntegration(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	if err := s.Store.Organizations().SetSlackIntegration(r.Context(), id.OrgID, nil); err != nil {
+		logger.LogError(r.Context(), "api", "delete_slack_integration", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to remove Slack integration")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "integration.slack.delete", TargetRef: id.OrgID})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleTestSlackIntegration handles POST /v1/integrations/slack/test,
+// posting a sample notice so admins can check the channel receives it.
+func (s *Server) handleTestSlackIntegration(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return
+	}
+
+	msg := slack.Message{Text: fmt.Sprintf(":wave: Test notice for *%s*. Export and job failure notices will appear here.", slack.Escape(org.Name))}
+	err = s.Slack.Post(r.Context(), org.Slack, msg)
+	switch {
+	case errors.Is(err, slack.ErrNotConfigured):
+		writeError(w, r, http.StatusPreconditionFailed, err.Error())
+		return
+	case err != nil:
+		logger.LogError(r.Context(), "api", "test_slack_integration", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusBadGateway, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestSlackIntegration_ConfigureAndTest(t *testing.T) {
+	var posts int
+	slackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer slackSrv.Close()
+
+	s := NewServer()
+	h := s.Handler()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(context.Background(), &store.Organization{ID: "org-1", Name: "Org"}))
+
+	do := func(method string, role auth.Role, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, auth.RoleAdmin, "/v1/integrations/slack/test", "")
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code, "nothing configured yet")
+
+	w = do(http.MethodPut, auth.RoleEditor, "/v1/integrations/slack", `{"webhookUrl":"https://hooks.slack.com/services/T/B/x"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	for _, body := range []string{
+		`{}`,
+		`{"botToken":"xoxb-1"}`,
+		`{"webhookUrl":"https://hooks.slack.com/services/T/B/x","botToken":"xoxb-1","channel":"#c"}`,
+		`{"botToken":"not-a-bot-token","channel":"#c"}`,
+	} {
+		w = do(http.MethodPut, auth.RoleAdmin, "/v1/integrations/slack", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	w = do(http.MethodPut, auth.RoleAdmin, "/v1/integrations/slack", `{"botToken":"xoxb-secret","channel":"#exports","notifyFailures":false}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"mode":"bot"`)
+	assert.Contains(t, w.Body.String(), `"notifyExports":true,"notifyFailures":false`)
+
+	w = do(http.MethodGet, auth.RoleAdmin, "/v1/integrations/slack", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"channel":"#exports"`)
+	assert.NotContains(t, w.Body.String(), "xoxb-secret")
+
+	// httptest serves plain http, so store the URL directly for the test post.
+	require.NoError(t, s.Store.Organizations().SetSlackIntegration(context.Background(), "org-1", &store.SlackIntegration{WebhookURL: slackSrv.URL, NotifyExports: true}))
+	w = do(http.MethodPost, auth.RoleAdmin, "/v1/integrations/slack/test", "")
+	assert.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.Equal(t, 1, posts)
+
+	w = do(http.MethodDelete, auth.RoleAdmin, "/v1/integrations/slack", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = do(http.MethodGet, auth.RoleAdmin, "/v1/integrations/slack", "")
+	assert.Contains(t, w.Body.String(), `"configured":false`)
+}
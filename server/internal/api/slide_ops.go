@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// applySlideOperations runs ops in order against layouts. Layouts are kept as
+// raw JSON so fields the spec package doesn't model survive the edit.
+func applySlideOperations(layouts []json.RawMessage, ops []SlideOperation) ([]json.RawMessage, error) {
+	out := append([]json.RawMessage(nil), layouts...)
+	for i, op := range ops {
+		idx := *op.Index
+		switch op.Op {
+		case "delete":
+			if idx >= len(out) {
+				return nil, fmt.Errorf("operation %d: index %d out of range for %d slides", i, idx, len(out))
+			}
+			out = append(out[:idx], out[idx+1:]...)
+		case "move":
+			to := *op.To
+			if idx >= len(out) || to >= len(out) {
+				return nil, fmt.Errorf("operation %d: move %d to %d out of range for %d slides", i, idx, to, len(out))
+			}
+			slide := out[idx]
+			out = append(out[:idx], out[idx+1:]...)
+			out = append(out[:to], append([]json.RawMessage{slide}, out[to:]...)...)
+		case "insert":
+			if idx > len(out) {
+				return nil, fmt.Errorf("operation %d: index %d out of range for %d slides", i, idx, len(out))
+			}
+			slide, err := json.Marshal(op.Layout)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+			out = append(out[:idx], append([]json.RawMessage{slide}, out[idx:]...)...)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("a deck must keep at least one slide")
+	}
+	return out, nil
+}
+
+// handleEditSlides handles PATCH /v1/deck-versions/{versionId}/slides. It
+// applies move/delete/insert operations to the version's layouts and saves
+// the result as the deck's next version, leaving the source version as is.
+func (s *Server) handleEditSlides(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+
+	dv, ok, err := s.Store.Decks().GetDeckVersion(r.Context(), id.OrgID, r.PathValue("versionId"))
+	if err != nil {
+		logger.LogError(r.Context(), "api", "get_deck_version", err)
+		writeError(w, r, http.StatusInternalServerError, "failed")
+		return
+	}
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	d, ok := s.authorizeDeck(w, r, id, dv.Deck, store.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	var req EditSlidesRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	var doc map[string]json.RawMessage
+	var layouts []json.RawMessage
+	specBytes, err := normalize.JSON(dv.SpecJSON)
+	if err == nil {
+		err = json.Unmarshal(specBytes, &doc)
+	}
+	if err == nil && len(doc["layouts"]) > 0 {
+		err = json.Unmarshal(doc["layouts"], &layouts)
+	}
+	if err != nil {
+		logger.LogError(r.Context(), "api", "edit_slides", err, "deck_version_id", dv.ID)
+		writeErrorCode(w, r, http.StatusUnprocessableEntity, ErrCodeSpecInvalid, "deck version has an unreadable spec", nil)
+		return
+	}
+
+	layouts, err = applySlideOperations(layouts, req.Operations)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if doc["layouts"], err = json.Marshal(layouts); err == nil {
+		specBytes, err = json.Marshal(doc)
+	}
+	if err != nil {
+		logger.LogError(r.Context(), "api", "marshal_spec", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to marshal spec")
+		return
+	}
+
+	newNo := d.LatestVersionNo + 1
+	ver := store.DeckVersion{ID: newID("dv"), Deck: d.ID, OrgID: id.OrgID, VersionNo: newNo, SpecJSON: json.RawMessage(specBytes), CreatedBy: id.UserID}
+	var created store.DeckVersion
+	var updated store.Deck
+	err = s.Store.WithTx(r.Context(), func(tx store.Store) error {
+		var err error
+		if created, err = tx.Decks().CreateDeckVersion(r.Context(), ver); err != nil {
+			return err
+		}
+		d.LatestVersionNo = newNo
+		d.CurrentVersion = &created.ID
+		updated, err = tx.Decks().UpdateDeck(r.Context(), d)
+		return err
+	})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "edit_slides", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to create version")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.slides.edit", TargetRef: d.ID, Metadata: map[string]any{"fromVersionId": dv.ID, "versionId": created.ID, "operations": len(req.Operations)}})
+
+	writeJSON(w, http.StatusOK, map[string]any{"deck": updated, "version": created})
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestEditSlides_CreatesNewVersion(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+	ctx := context.Background()
+
+	_, err := s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", OwnerUserID: "user-1", Name: "Deck", LatestVersionNo: 1})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: "deck-1", OrgID: "org-1", VersionNo: 1,
+		SpecJSON: json.RawMessage(`{"tokens":{"colors":{}},"layouts":[{"name":"A","notes":"keep me"},{"name":"B"},{"name":"C"}]}`)})
+	require.NoError(t, err)
+
+	do := func(userID string, role auth.Role, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/v1/deck-versions/dv-1/slides", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, userID, "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("user-1", auth.RoleEditor, `{"operations":[{"op":"move","index":0,"to":2},{"op":"delete","index":0},{"op":"insert","index":0,"layout":{"name":"New"}}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Deck    store.Deck `json:"deck"`
+		Version struct {
+			ID        string `json:"id"`
+			VersionNo int    `json:"versionNo"`
+			Spec      struct {
+				Layouts []map[string]any `json:"layouts"`
+			} `json:"spec"`
+		} `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 2, resp.Version.VersionNo)
+	assert.Equal(t, 2, resp.Deck.LatestVersionNo)
+	require.NotNil(t, resp.Deck.CurrentVersion)
+	assert.Equal(t, resp.Version.ID, *resp.Deck.CurrentVersion)
+	require.Len(t, resp.Version.Spec.Layouts, 3)
+	assert.Equal(t, "New", resp.Version.Spec.Layouts[0]["name"])
+	assert.Equal(t, "C", resp.Version.Spec.Layouts[1]["name"])
+	assert.Equal(t, "A", resp.Version.Spec.Layouts[2]["name"])
+	assert.Equal(t, "keep me", resp.Version.Spec.Layouts[2]["notes"])
+
+	orig, _, err := s.Store.Decks().GetDeckVersion(ctx, "org-1", "dv-1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, slideCount(orig))
+
+	w = do("user-1", auth.RoleEditor, `{"operations":[{"op":"delete","index":3}]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do("user-1", auth.RoleEditor, `{"operations":[{"op":"move","index":0}]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do("user-1", auth.RoleEditor, `{"operations":[{"op":"delete","index":0},{"op":"delete","index":0},{"op":"delete","index":0}]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do("user-2", auth.RoleViewer, `{"operations":[{"op":"delete","index":0}]}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
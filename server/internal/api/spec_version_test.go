@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestSpecVersion_FutureSpecsAreRefused(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	_, err := s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-1", OrgID: "org-1", OwnerUserID: "user-1", Name: "Brand", Status: store.TemplateDraft})
+	require.NoError(t, err)
+	_, err = s.Store.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", OwnerUserID: "user-1", Name: "Deck"})
+	require.NoError(t, err)
+	h := s.Handler()
+
+	do := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{"/v1/templates/tpl-1/versions", "/v1/decks/deck-1/versions"} {
+		w := do(path, `{"spec":{"specVersion":99,"tokens":{},"layouts":[{"name":"Title"}]}}`)
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+		var resp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, ErrCodeSpecInvalid, resp.Code)
+		assert.Equal(t, "spec version 99 is newer than this server supports (up to 2)", resp.Message)
+	}
+
+	w := do("/v1/templates/tpl-1/versions", `{"spec":{"tokens":{},"layouts":[{"name":"Title","placeholders":[{"id":"title"}]}]}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/templates/tpl-1/versions", nil)
+	addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"specVersion":2`, "specs are read at the current version")
+	assert.Contains(t, w.Body.String(), `"type":"text"`)
+}
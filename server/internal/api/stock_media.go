@@ -0,0 +1,233 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/stock"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// stockIllustrator picks slide images for bind jobs that ask for them.
+func (s *Server) stockIllustrator() *stock.Illustrator {
+	if s.Stock == nil {
+		return nil
+	}
+	return &stock.Illustrator{Client: s.Stock, Cache: s.stockCache(), Planner: s.orchestrator()}
+}
+
+func (s *Server) stockCache() *stock.Cache {
+	return &stock.Cache{Client: s.Stock, Objects: s.ObjectStorage}
+}
+
+// stockMediaResponse says whose key each photo provider uses: "org",
+// "server", or "" when neither has one. The keys themselves are never
+// returned.
+type stockMediaResponse struct {
+	Unsplash string `json:"unsplash"`
+	Pexels   string `json:"pexels"`
+}
+
+func (s *Server) newStockMediaResponse(keys *store.StockMediaKeys) stockMediaResponse {
+	source := func(org, server string) string {
+		switch {
+		case org != "":
+			return "org"
+		case server != "":
+			return "server"
+		default:
+			return ""
+		}
+	}
+	var org store.StockMediaKeys
+	if keys != nil {
+		org = *keys
+	}
+	server := s.Config.StockMediaKeys
+	return stockMediaResponse{
+		Unsplash: source(org.UnsplashAccessKey, server.UnsplashAccessKey),
+		Pexels:   source(org.PexelsAPIKey, server.PexelsAPIKey),
+	}
+}
+
+// writeStockError maps stock client errors to responses.
+func writeStockError(w http.ResponseWriter, r *http.Request, op string, err error) {
+	switch {
+	case errors.Is(err, stock.ErrNotConfigured):
+		writeError(w, r, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, stock.ErrUnknownProvider):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+	case errors.Is(err, stock.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, err.Error())
+	default:
+		logger.LogError(r.Context(), "api", op, err)
+		writeError(w, r, http.StatusBadGateway, "stock media provider request failed")
+	}
+}
+
+// handleSearchImages handles GET /v1/images/search, proxying a search to
+// Unsplash, Pexels or Iconify with the org's keys.
+func (s *Server) handleSearchImages(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+	q := stock.Query{
+		Text:     strings.TrimSpace(r.URL.Query().Get("q")),
+		Kind:     stock.Kind(r.URL.Query().Get("kind")),
+		Provider: r.URL.Query().Get("provider"),
+	}
+	if q.Text == "" {
+		writeError(w, r, http.StatusBadRequest, "q is required")
+		return
+	}
+	if q.Kind == "" {
+		q.Kind = stock.KindPhoto
+	}
+	if q.Kind != stock.KindPhoto && q.Kind != stock.KindIcon {
+		writeError(w, r, http.StatusBadRequest, "kind must be photo or icon")
+		return
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, r, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		q.Limit = n
+	}
+
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return
+	}
+	results, err := s.Stock.Search(r.Context(), org.StockMedia, q)
+	if err != nil {
+		writeStockError(w, r, "search_images", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"results": results})
+}
+
+// handleImportStockImage handles POST /v1/images/stock. It caches a search
+// result in object storage and returns the image to set on an image
+// placeholder, attribution included.
+func (s *Server) handleImportStockImage(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+	var req StockImageRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return
+	}
+	result, err := s.Stock.Get(r.Context(), org.StockMedia, req.Provider, req.ID)
+	if err != nil {
+		writeStockError(w, r, "get_stock_image", err)
+		return
+	}
+	img, err := s.stockCache().Fetch(r.Context(), org.StockMedia, result)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "cache_stock_image", err, "provider", req.Provider, "id", req.ID)
+		writeError(w, r, http.StatusBadGateway, "failed to download stock image")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"image": img})
+}
+
+func (s *Server) handleGetStockMediaIntegration(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"stockMedia": s.newStockMediaResponse(org.StockMedia)})
+}
+
+// handleSetStockMediaIntegration handles PUT /v1/integrations/stock-media,
+// updating the org's own provider keys.
+func (s *Server) handleSetStockMediaIntegration(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	var req StockMediaKeysRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return
+	}
+
+	var keys store.StockMediaKeys
+	if org.StockMedia != nil {
+		keys = *org.StockMedia
+	}
+	if req.UnsplashAccessKey != nil {
+		keys.UnsplashAccessKey = strings.TrimSpace(*req.UnsplashAccessKey)
+	}
+	if req.PexelsAPIKey != nil {
+		keys.PexelsAPIKey = strings.TrimSpace(*req.PexelsAPIKey)
+	}
+	var stored *store.StockMediaKeys
+	if keys != (store.StockMediaKeys{}) {
+		stored = &keys
+	}
+	if err := s.Store.Organizations().SetStockMediaKeys(r.Context(), id.OrgID, stored); err != nil {
+		logger.LogError(r.Context(), "api", "set_stock_media_keys", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to save stock media keys")
+		return
+	}
+
+	resp := s.newStockMediaResponse(stored)
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "integration.stock_media.set", TargetRef: id.OrgID, Metadata: map[string]any{"unsplash": resp.Unsplash, "pexels": resp.Pexels}})
+	writeJSON(w, http.StatusOK, map[string]any{"stockMedia": resp})
+}
+
+func (s *Server) handleDeleteStockMediaIntegration(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	if err := s.Store.Organizations().SetStockMediaKeys(r.Context(), id.OrgID, nil); err != nil {
+		logger.LogError(r.Context(), "api", "delete_stock_media_keys", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to remove stock media keys")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "integration.stock_media.delete", TargetRef: id.OrgID})
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestStockMedia_KeysAndSearch(t *testing.T) {
+	var gotAuth string
+	unsplash := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"results":[{"id":"abc","urls":{"small":"https://images.example/abc-small.jpg"},"user":{"name":"Ada"}}]}`))
+	}))
+	defer unsplash.Close()
+
+	s := NewServer()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(context.Background(), &store.Organization{ID: "org-1", Name: "Org"}))
+	s.Config.StockMediaKeys = store.StockMediaKeys{}
+	s.Stock.Keys = store.StockMediaKeys{}
+	s.Stock.UnsplashBase = unsplash.URL
+	h := s.Handler()
+
+	do := func(method, path, body string, role auth.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/v1/images/search?q=teamwork", "", auth.RoleEditor)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code, w.Body.String())
+
+	w = do(http.MethodPut, "/v1/integrations/stock-media", `{"unsplashAccessKey":"org-key"}`, auth.RoleEditor)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = do(http.MethodPut, "/v1/integrations/stock-media", `{"unsplashAccessKey":"org-key"}`, auth.RoleAdmin)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"stockMedia":{"unsplash":"org","pexels":""}}`, w.Body.String())
+	assert.NotContains(t, do(http.MethodGet, "/v1/integrations/stock-media", "", auth.RoleAdmin).Body.String(), "org-key")
+
+	w = do(http.MethodGet, "/v1/images/search?q=teamwork&limit=3", "", auth.RoleEditor)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "Client-ID org-key", gotAuth)
+	var resp struct {
+		Results []struct {
+			ID          string `json:"id"`
+			Attribution struct {
+				Text string `json:"text"`
+			} `json:"attribution"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Results, 1)
+	assert.Equal(t, "Photo by Ada on Unsplash", resp.Results[0].Attribution.Text)
+
+	w = do(http.MethodGet, "/v1/images/search?q=teamwork&kind=video", "", auth.RoleEditor)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(http.MethodDelete, "/v1/integrations/stock-media", "", auth.RoleAdmin)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	w = do(http.MethodGet, "/v1/images/search?q=teamwork", "", auth.RoleEditor)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code, w.Body.String())
+}
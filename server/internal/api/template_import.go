@@ -0,0 +1,247 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/gslides"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// maxPPTXUploadSize caps template imports; corporate templates with embedded
+// images rarely go past a few MB.
+const maxPPTXUploadSize = 50 << 20
+
+// handleImportTemplate handles POST /v1/templates/import. It takes a
+// multipart upload with a .pptx in "file" and an optional "name".
+func (s *Server) handleImportTemplate(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPPTXUploadSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds %d MB", maxPPTXUploadSize>>20))
+			return
+		}
+		writeError(w, r, http.StatusBadRequest, "multipart form with a .pptx in \"file\" is required")
+		return
+	}
+	defer file.Close()
+	if !strings.EqualFold(filepath.Ext(header.Filename), ".pptx") {
+		writeError(w, r, http.StatusBadRequest, "only .pptx files can be imported")
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		name = strings.TrimSpace(strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename)))
+	}
+	if len(name) < 3 {
+		writeError(w, r, http.StatusBadRequest, "name must be at least 3 characters")
+		return
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "read_template_upload", err)
+		writeError(w, r, http.StatusBadRequest, "failed to read upload")
+		return
+	}
+	imported, err := assets.ImportPPTX(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		writeError(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	s.saveImportedTemplate(w, r, id, name, imported.Spec, imported.Warnings, map[string]any{"source": "pptx", "filename": header.Filename})
+}
+
+// saveImportedTemplate stores an imported spec as version 1 of a new draft
+// template and writes the response. Validation issues are returned rather
+// than rejected, since imported geometry often needs a pass in the editor
+// before it fits the safe margins.
+func (s *Server) saveImportedTemplate(w http.ResponseWriter, r *http.Request, id auth.Identity, name string, ts spec.TemplateSpec, warnings []string, auditMeta map[string]any) {
+	specJSON, err := json.Marshal(ts)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "marshal_spec", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to marshal spec")
+		return
+	}
+
+	template := store.Template{
+		OrgID:       id.OrgID,
+		OwnerUserID: id.UserID,
+		Name:        name,
+		Status:      store.TemplateDraft,
+	}
+	var created store.Template
+	var version store.TemplateVersion
+	err = s.Store.WithTx(r.Context(), func(tx store.Store) error {
+		var err error
+		if created, err = tx.Templates().CreateTemplate(r.Context(), template); err != nil {
+			return err
+		}
+		v := store.TemplateVersion{
+			ID:        newID("tv"),
+			Template:  created.ID,
+			OrgID:     id.OrgID,
+			VersionNo: 1,
+			SpecJSON:  json.RawMessage(specJSON),
+			CreatedBy: id.UserID,
+		}
+		if version, err = tx.Templates().CreateVersion(r.Context(), v); err != nil {
+			return err
+		}
+		created.CurrentVersion = &version.ID
+		created.LatestVersionNo = 1
+		created, err = tx.Templates().UpdateTemplate(r.Context(), created)
+		return err
+	})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "import_template", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to save template")
+		return
+	}
+
+	auditMeta["versionId"] = version.ID
+	auditMeta["layouts"] = len(ts.Layouts)
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "template.import", TargetRef: created.ID, Metadata: auditMeta})
+
+	warnings = append(warnings, s.specWarnings(r, id.OrgID, specJSON)...)
+	validationErrors := spec.DefaultValidator{}.Validate(ts)
+	if validationErrors == nil {
+		validationErrors = []spec.ValidationError{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"template":         created,
+		"version":          version,
+		"warnings":         warnings,
+		"validationErrors": validationErrors,
+	})
+}
+
+// handleImportGoogleSlides handles POST /v1/templates/import/google. It reads
+// the presentation with the org's connected Google account and saves it as a
+// draft template, the same way a .pptx import is saved.
+func (s *Server) handleImportGoogleSlides(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		writeError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	var req ImportGoogleSlidesRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	presentationID, ok := gslides.ParsePresentationID(req.URL)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "url must be a Google Slides link or presentation ID")
+		return
+	}
+	if !s.GoogleSlides.Configured() {
+		writeError(w, r, http.StatusServiceUnavailable, "Google Slides import is not configured")
+		return
+	}
+
+	org, err := s.Store.Organizations().GetOrganization(r.Context(), id.OrgID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "organization not found")
+		return
+	}
+	if org.GoogleRefreshToken == "" {
+		writeError(w, r, http.StatusPreconditionFailed, "connect a Google account for this organization first")
+		return
+	}
+
+	p, err := s.GoogleSlides.GetPresentation(r.Context(), org.GoogleRefreshToken, presentationID)
+	switch {
+	case errors.Is(err, gslides.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, gslides.ErrReauthorize):
+		writeError(w, r, http.StatusPreconditionFailed, err.Error())
+		return
+	case err != nil:
+		logger.LogError(r.Context(), "api", "import_google_slides", err, "presentation_id", presentationID)
+		writeError(w, r, http.StatusBadGateway, "failed to fetch presentation from Google Slides")
+		return
+	}
+
+	ts, warnings := gslides.ToTemplateSpec(p)
+	if len(ts.Layouts) == 0 {
+		writeError(w, r, http.StatusUnprocessableEntity, "presentation has no slides or layouts")
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = strings.TrimSpace(p.Title)
+	}
+	if len(name) < 3 {
+		name = "Imported presentation"
+	}
+	s.saveImportedTemplate(w, r, id, name, ts, warnings, map[string]any{"source": "google_slides", "presentationId": presentationID})
+}
+
+// handleSetGoogleCredentials handles PUT /v1/org/google-credentials, storing
+// the refresh token Google Slides imports run with.
+func (s *Server) handleSetGoogleCredentials(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	var req GoogleCredentialsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if err := s.Store.Organizations().SetGoogleRefreshToken(r.Context(), id.OrgID, strings.TrimSpace(req.RefreshToken)); err != nil {
+		logger.LogError(r.Context(), "api", "set_google_credentials", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to store credentials")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "google.credentials.set", TargetRef: id.OrgID})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeleteGoogleCredentials(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	if err := s.Store.Organizations().SetGoogleRefreshToken(r.Context(), id.OrgID, ""); err != nil {
+		logger.LogError(r.Context(), "api", "delete_google_credentials", err, "org_id", id.OrgID)
+		writeError(w, r, http.StatusInternalServerError, "failed to remove credentials")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "google.credentials.delete", TargetRef: id.OrgID})
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func importTemplateRequest(t *testing.T, filename string, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = fw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/templates/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestImportTemplate_CreatesDraftFromPPTX(t *testing.T) {
+	s := NewServer()
+	h := s.Handler()
+
+	var pptx bytes.Buffer
+	zw := zip.NewWriter(&pptx)
+	for name, xml := range map[string]string{
+		"ppt/presentation.xml": `<p:presentation xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"><p:sldSz cx="12192000" cy="6858000"/></p:presentation>`,
+		"ppt/slideLayouts/slideLayout1.xml": `<p:sldLayout xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"><p:cSld name="Title Slide"><p:spTree>
+<p:sp><p:nvSpPr><p:cNvPr id="2" name="Title 1"/><p:cNvSpPr/><p:nvPr><p:ph type="ctrTitle"/></p:nvPr></p:nvSpPr>
+<p:spPr><a:xfrm><a:off x="1524000" y="1122363"/><a:ext cx="9144000" cy="2387600"/></a:xfrm></p:spPr></p:sp>
+</p:spTree></p:cSld></p:sldLayout>`,
+	} {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(xml))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	req := importTemplateRequest(t, "Corporate 2026.pptx", pptx.Bytes())
+	addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Template store.Template `json:"template"`
+		Version  struct {
+			ID   string `json:"id"`
+			Spec struct {
+				Layouts []struct {
+					Name string `json:"name"`
+				} `json:"layouts"`
+			} `json:"spec"`
+		} `json:"version"`
+		Warnings []string `json:"warnings"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "Corporate 2026", resp.Template.Name)
+	assert.Equal(t, store.TemplateDraft, resp.Template.Status)
+	require.NotNil(t, resp.Template.CurrentVersion)
+	assert.Equal(t, resp.Version.ID, *resp.Template.CurrentVersion)
+	require.Len(t, resp.Version.Spec.Layouts, 1)
+	assert.Equal(t, "Title Slide", resp.Version.Spec.Layouts[0].Name)
+	assert.Contains(t, resp.Warnings, "no theme found; colors and fonts were left empty")
+
+	req = importTemplateRequest(t, "notes.docx", pptx.Bytes())
+	addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req = importTemplateRequest(t, "broken.pptx", []byte("not a zip"))
+	addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	req = importTemplateRequest(t, "Corporate.pptx", pptx.Bytes())
+	addTestAuth(req, "user-2", "org-1", auth.RoleViewer)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestImportGoogleSlides_UsesOrgCredentials(t *testing.T) {
+	google := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			_, _ = w.Write([]byte(`{"access_token":"access-1"}`))
+		case "/presentations/1AbCdEfGhIjK":
+			_, _ = w.Write([]byte(`{"title":"Board Update","slides":[{"objectId":"s1","pageElements":[
+{"objectId":"e1","size":{"width":{"magnitude":4572000,"unit":"EMU"},"height":{"magnitude":1000000,"unit":"EMU"}},
+ "transform":{"scaleX":1,"scaleY":1,"translateX":457200,"translateY":500000,"unit":"EMU"},
+ "shape":{"shapeType":"TEXT_BOX","placeholder":{"type":"TITLE"}}}]}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer google.Close()
+
+	s := NewServer()
+	s.GoogleSlides.ClientID, s.GoogleSlides.ClientSecret = "client", "secret"
+	s.GoogleSlides.TokenURL, s.GoogleSlides.APIBase = google.URL+"/token", google.URL
+	h := s.Handler()
+	require.NoError(t, s.Store.Organizations().CreateOrganization(context.Background(), &store.Organization{ID: "org-1", Name: "Org"}))
+
+	do := func(method, path string, role auth.Role, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	importBody := `{"url":"https://docs.google.com/presentation/d/1AbCdEfGhIjK/edit"}`
+
+	w := do(http.MethodPost, "/v1/templates/import/google", auth.RoleEditor, importBody)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code, "no Google account connected yet")
+
+	w = do(http.MethodPut, "/v1/org/google-credentials", auth.RoleEditor, `{"refreshToken":"refresh-1"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = do(http.MethodPut, "/v1/org/google-credentials", auth.RoleAdmin, `{"refreshToken":"refresh-1"}`)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	w = do(http.MethodGet, "/v1/org/settings", auth.RoleViewer, "")
+	assert.Contains(t, w.Body.String(), `"googleConnected":true`)
+	assert.NotContains(t, w.Body.String(), "refresh-1")
+
+	w = do(http.MethodPost, "/v1/templates/import/google", auth.RoleEditor, importBody)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Template store.Template `json:"template"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "Board Update", resp.Template.Name)
+	assert.Equal(t, store.TemplateDraft, resp.Template.Status)
+
+	w = do(http.MethodPost, "/v1/templates/import/google", auth.RoleEditor, `{"url":"https://docs.google.com/presentation/d/0000000000000/edit"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(http.MethodPost, "/v1/templates/import/google", auth.RoleEditor, `{"url":"https://example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
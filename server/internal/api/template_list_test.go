@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestListTemplates_IncludeCurrentVersion(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	current := "tv-1"
+	_, err := s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-1", OrgID: "org-1", OwnerUserID: "user-1", Name: "Brand", Status: store.TemplateDraft, CurrentVersion: &current})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-2", OrgID: "org-1", OwnerUserID: "user-1", Name: "Empty", Status: store.TemplateDraft})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-1", Template: "tpl-1", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"tokens":{},"layouts":[{"name":"Title"}]}`)})
+	require.NoError(t, err)
+	h := s.Handler()
+
+	list := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/templates"+query, nil)
+		addTestAuth(req, "user-1", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	type listed struct {
+		ID             string                 `json:"id"`
+		CurrentVersion *store.TemplateVersion `json:"currentVersion"`
+	}
+	decode := func(w *httptest.ResponseRecorder) map[string]listed {
+		t.Helper()
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct{ Templates []listed }
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		out := map[string]listed{}
+		for _, tpl := range resp.Templates {
+			out[tpl.ID] = tpl
+		}
+		return out
+	}
+
+	plain := decode(list(""))
+	require.Len(t, plain, 2)
+	assert.Nil(t, plain["tpl-1"].CurrentVersion, "versions are only included on request")
+	assert.NotContains(t, list("").Body.String(), `"currentVersion"`)
+
+	included := decode(list("?include=currentVersion"))
+	require.Len(t, included, 2)
+	require.NotNil(t, included["tpl-1"].CurrentVersion)
+	assert.Equal(t, "tv-1", included["tpl-1"].CurrentVersion.ID)
+	assert.Nil(t, included["tpl-2"].CurrentVersion)
+
+	assert.Equal(t, http.StatusBadRequest, list("?include=everything").Code)
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// handleTemplateTransition moves a template through the review workflow.
+// Anyone who can edit a template may submit it; every other step is a
+// reviewer decision and needs an org Admin.
+func (s *Server) handleTemplateTransition(to store.TemplateStatus, action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, _ := auth.GetIdentity(r.Context())
+		minPerm := store.PermissionEdit
+		if to != store.TemplateInReview {
+			if !auth.RequireRole(id, auth.RoleAdmin) {
+				writeError(w, r, http.StatusForbidden, "insufficient permissions")
+				return
+			}
+			minPerm = store.PermissionView
+		}
+		tpl, ok := s.authorizeTemplate(w, r, id, r.PathValue("id"), minPerm)
+		if !ok {
+			return
+		}
+
+		from := tpl.Status
+		if !from.CanTransitionTo(to) {
+			writeError(w, r, http.StatusConflict, fmt.Sprintf("cannot move template from %s to %s", from, to))
+			return
+		}
+		if to == store.TemplateInReview && tpl.CurrentVersion == nil {
+			writeError(w, r, http.StatusConflict, "template has no version to review")
+			return
+		}
+
+		tpl.Status = to
+		updated, err := s.Store.Templates().UpdateTemplate(r.Context(), tpl)
+		if err != nil {
+			logger.LogError(r.Context(), "api", action, err, "template_id", tpl.ID)
+			writeError(w, r, http.StatusInternalServerError, "failed to update template")
+			return
+		}
+
+		meta := map[string]any{"from": from, "to": to}
+		if tpl.CurrentVersion != nil {
+			meta["versionId"] = *tpl.CurrentVersion
+		}
+		_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "template." + action, TargetRef: tpl.ID, Metadata: meta})
+		if to == store.TemplatePublished {
+			data := map[string]any{"templateId": updated.ID, "name": updated.Name}
+			if updated.CurrentVersion != nil {
+				data["versionId"] = *updated.CurrentVersion
+			}
+			s.emitEvent(r.Context(), id.UserID, Event{Type: EventTemplatePublished, OrgID: id.OrgID, Data: data})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"template": updated})
+	}
+}
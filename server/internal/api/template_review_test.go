@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestTemplateReview_Workflow(t *testing.T) {
+	s := NewServer()
+	rec := &auditRecorder{Store: s.Store}
+	s.Store = rec
+	h := s.Handler()
+	ctx := context.Background()
+
+	versionID := "tv-1"
+	_, err := s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-1", OrgID: "org-1", OwnerUserID: "editor", Name: "Plan", Status: store.TemplateDraft, CurrentVersion: &versionID})
+	require.NoError(t, err)
+	_, err = s.Store.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-2", OrgID: "org-1", Name: "Other", Status: store.TemplateDraft})
+	require.NoError(t, err)
+
+	post := func(userID string, role auth.Role, path string) int {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		addTestAuth(req, userID, "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+	pickerCount := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/templates?status=Published", nil)
+		addTestAuth(req, "editor", "org-1", auth.RoleEditor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Templates []store.Template `json:"templates"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return len(resp.Templates)
+	}
+
+	assert.Equal(t, http.StatusForbidden, post("viewer", auth.RoleViewer, "/v1/templates/tpl-1/submit-review"))
+	assert.Equal(t, http.StatusForbidden, post("editor", auth.RoleEditor, "/v1/templates/tpl-1/approve"), "approve is admin-only")
+	assert.Equal(t, http.StatusConflict, post("admin", auth.RoleAdmin, "/v1/templates/tpl-1/approve"), "must be in review first")
+	assert.Equal(t, http.StatusConflict, post("editor", auth.RoleEditor, "/v1/templates/tpl-2/submit-review"), "nothing to review")
+
+	require.Equal(t, http.StatusOK, post("editor", auth.RoleEditor, "/v1/templates/tpl-1/submit-review"))
+	require.Equal(t, http.StatusOK, post("admin", auth.RoleAdmin, "/v1/templates/tpl-1/reject"))
+	require.Equal(t, http.StatusOK, post("editor", auth.RoleEditor, "/v1/templates/tpl-1/submit-review"))
+	require.Equal(t, http.StatusOK, post("admin", auth.RoleAdmin, "/v1/templates/tpl-1/approve"))
+	assert.Equal(t, 0, pickerCount())
+	require.Equal(t, http.StatusOK, post("admin", auth.RoleAdmin, "/v1/templates/tpl-1/publish"))
+	assert.Equal(t, 1, pickerCount())
+
+	var actions []string
+	for _, e := range rec.entries {
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []string{"template.submit_review", "template.reject", "template.submit_review", "template.approve", "template.publish", "event.template.published"}, actions)
+	assert.Equal(t, "tv-1", rec.entries[3].Metadata.(map[string]any)["versionId"])
+}
@@ -1,5 +1,11 @@
 package api
 
+import (
+	"encoding/json"
+
+	"github.com/ziyad/cms-ai/server/internal/service"
+)
+
 type AnalyzeTemplateRequest struct {
 	Prompt string `json:"prompt" validate:"required,min=3"`
 }
@@ -20,31 +26,120 @@ type AnalyzeTemplateResponse struct {
 	RequiredFields  []RequiredField `json:"requiredFields"`
 	EstimatedSlides int             `json:"estimatedSlides"`
 	Description     string          `json:"description"`
+	// Language is the prompt's BCP 47 tag, when the AI analyzed it.
+	Language string `json:"language,omitempty"`
+	// Source is AnalysisSourceAI or AnalysisSourceHeuristic.
+	Source string `json:"source"`
+}
+
+// Where an AnalyzeTemplateResponse came from: the AI service, or keyword
+// matching when the AI is unavailable.
+const (
+	AnalysisSourceAI        = "ai"
+	AnalysisSourceHeuristic = "heuristic"
+)
+
+// GenerationParamsRequest holds optional AI sampling overrides. Unset fields
+// fall back to the organization's defaults, then to the model's.
+type GenerationParamsRequest struct {
+	Temperature *float64 `json:"temperature,omitempty" validate:"omitempty,gte=0,lte=2"`
+	TopP        *float64 `json:"topP,omitempty" validate:"omitempty,gt=0,lte=1"`
+	MaxSlides   *int     `json:"maxSlides,omitempty" validate:"omitempty,gte=1,lte=50"`
 }
 
 type GenerateTemplateRequest struct {
 	Prompt      string                 `json:"prompt" validate:"required,min=10"`
 	Name        string                 `json:"name,omitempty"`
 	BrandKitID  string                 `json:"brandKitId,omitempty"`
-	RTL         bool                   `json:"rtl"`
+	RTL         *bool                  `json:"rtl,omitempty"`
 	Language    string                 `json:"language,omitempty"`
 	Tone        string                 `json:"tone,omitempty"`
 	ContentData map[string]interface{} `json:"contentData,omitempty"`
+	// RequiredFields, typically from POST /v1/templates/analyze, are stored
+	// on the generated version and enforced when decks are made from it.
+	RequiredFields []RequiredField `json:"requiredFields,omitempty" validate:"omitempty,max=20,dive"`
+	GenerationParamsRequest
+}
+
+type SignupRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+}
+
+type SigninRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// LegacyUserRequest is the body of POST /v1/auth/user, kept for older clients.
+type LegacyUserRequest struct {
+	UserID string `json:"userId"`
+	Email  string `json:"email"`
+	Name   string `json:"name"`
+}
+
+// UpdateProfileRequest is the body of PATCH /v1/auth/me. Only the fields
+// that are present change; "" clears the avatar.
+type UpdateProfileRequest struct {
+	Name        *string                 `json:"name,omitempty" validate:"omitempty,max=200"`
+	AvatarURL   *string                 `json:"avatarUrl,omitempty" validate:"omitempty,http_url,max=2048"`
+	Preferences *UserPreferencesRequest `json:"preferences,omitempty"`
+}
+
+// UserPreferencesRequest changes the preferences that are present. Editor
+// replaces the stored editor settings wholesale; null clears them.
+type UserPreferencesRequest struct {
+	DefaultBrandKitID *string         `json:"defaultBrandKitId,omitempty"`
+	Editor            json.RawMessage `json:"editor,omitempty"`
+}
+
+// ChangeEmailRequest starts an email change; the address takes effect once
+// the token mailed to it is confirmed.
+type ChangeEmailRequest struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// DeleteOrgRequest is the body of DELETE /v1/orgs/{id}. The token comes
+// from POST /v1/orgs/{id}/deletion-token.
+type DeleteOrgRequest struct {
+	ConfirmationToken string `json:"confirmationToken" validate:"required"`
+}
+
+// ListingRequest sets a template or deck's card metadata. Only the fields
+// that are present change; "" clears one.
+type ListingRequest struct {
+	Description  *string `json:"description,omitempty" validate:"omitempty,max=2000"`
+	Category     *string `json:"category,omitempty" validate:"omitempty,max=64"`
+	Language     *string `json:"language,omitempty" validate:"omitempty,bcp47_language_tag"`
+	CoverAssetID *string `json:"coverAssetId,omitempty"`
 }
 
 type CreateTemplateRequest struct {
 	Name string `json:"name" validate:"required,min=3"`
+	ListingRequest
 }
 
-type SlideOutline struct {
-	SlideNumber int      `json:"slide_number" validate:"required"`
-	Title       string   `json:"title" validate:"required"`
-	Content     []string `json:"content"`
-	LayoutHint  string   `json:"layout_hint,omitempty"`
+// UpdateTemplateRequest changes only the fields that are present.
+type UpdateTemplateRequest struct {
+	Name *string `json:"name" validate:"omitempty,min=3"`
+	ListingRequest
 }
 
-type DeckOutline struct {
-	Slides []SlideOutline `json:"slides" validate:"required,dive"`
+type (
+	SlideOutline = service.SlideOutline
+	DeckOutline  = service.DeckOutline
+)
+
+// UpdateDeckRequest changes only the fields that are present.
+type UpdateDeckRequest struct {
+	Name    *string `json:"name"`
+	Content *string `json:"content"`
+	ListingRequest
 }
 
 type CreateDeckOutlineRequest struct {
@@ -61,6 +156,46 @@ type CreateDeckRequest struct {
 	SourceTemplateVersion string `json:"sourceTemplateVersionId" validate:"required"`
 	Content               string `json:"content" validate:"required,min=10"`
 	Outline               any    `json:"outline,omitempty"`
+	// ContentData supplies the template version's required fields by key.
+	ContentData map[string]any `json:"contentData,omitempty"`
+	// StockImages has the AI bind job add stock photos or icons to slides.
+	StockImages bool `json:"stockImages,omitempty"`
+	ListingRequest
+	GenerationParamsRequest
+}
+
+// ExportDeckVersionRequest is the optional body of a deck version export.
+type ExportDeckVersionRequest struct {
+	// Data fills the deck's {{name}} variables for this export only, so one
+	// deck version exports as many personalized decks. Values are strings,
+	// numbers or booleans, and every variable the deck uses needs one.
+	Data map[string]any `json:"data,omitempty"`
+}
+
+// UpdateOrgSettingsRequest changes only the fields that are present.
+type UpdateOrgSettingsRequest struct {
+	GenerationDefaults *GenerationParamsRequest `json:"generationDefaults,omitempty"`
+	// SSODomain may only be claimed by an owner whose own email is in it; "" releases it.
+	SSODomain *string `json:"ssoDomain,omitempty" validate:"omitempty,fqdn"`
+	// ExportFilenameTemplate may use {deckName}, {templateName}, {name},
+	// {versionNo}, {date} and {timestamp}; "" restores the built-in names.
+	ExportFilenameTemplate *string `json:"exportFilenameTemplate,omitempty" validate:"omitempty,max=200"`
+	// DefaultRenderer is the engine for standard-quality exports that don't
+	// name one: go, python, ai or remote; "" restores the server default.
+	DefaultRenderer *string `json:"defaultRenderer,omitempty"`
+
+	// The rest are the org's OrgSettings; "" (or false) clears one.
+	Locale       *string `json:"locale,omitempty" validate:"omitempty,bcp47_language_tag"`
+	Timezone     *string `json:"timezone,omitempty" validate:"omitempty,timezone"`
+	Language     *string `json:"language,omitempty" validate:"omitempty,max=64"`
+	Tone         *string `json:"tone,omitempty" validate:"omitempty,max=64"`
+	RTL          *bool   `json:"rtl,omitempty"`
+	SlideSize    *string `json:"slideSize,omitempty" validate:"omitempty,oneof=16:9 4:3"`
+	StorePrompts *bool   `json:"storePrompts,omitempty"`
+	// StorageRegion is one of the server's storage regions, or "" for the
+	// default backend; only owners may change it. Files already stored stay
+	// where they are.
+	StorageRegion *string `json:"storageRegion,omitempty"`
 }
 
 type CreateDeckVersionRequest struct {
@@ -68,16 +203,366 @@ type CreateDeckVersionRequest struct {
 }
 
 type CreateVersionRequest struct {
-	Spec any `json:"spec" validate:"required"`
+	Spec           any             `json:"spec" validate:"required"`
+	RequiredFields []RequiredField `json:"requiredFields,omitempty" validate:"omitempty,max=20,dive"`
 }
 
+// PatchVersionRequest creates a version from Spec. It keeps the patched
+// version's required fields unless RequiredFields is present; [] clears them.
 type PatchVersionRequest struct {
-	Spec any `json:"spec" validate:"required"`
+	Spec           any              `json:"spec" validate:"required"`
+	RequiredFields *[]RequiredField `json:"requiredFields,omitempty" validate:"omitempty,max=20,dive"`
+}
+
+type CreateJobRequest struct {
+	Type     string `json:"type"`
+	InputRef string `json:"inputRef"`
+}
+
+type CreateBrandKitRequest struct {
+	Name   string `json:"name"`
+	Tokens any    `json:"tokens"`
 }
 
 type UsageResponse struct {
 	OrgID   string         `json:"orgId"`
 	Limits  map[string]int `json:"limits"`
 	Used    map[string]int `json:"used"`
+	Storage *StorageUsage  `json:"storage,omitempty"`
 	Blocked bool           `json:"blocked"`
 }
+
+type StorageUsage struct {
+	UsedBytes  int64 `json:"usedBytes"`
+	LimitBytes int64 `json:"limitBytes"`
+	Blocked    bool  `json:"blocked"`
+}
+
+type SimulateEventRequest struct {
+	Type  string         `json:"type" validate:"required,oneof=job.completed quota.exceeded webhook.test deck.created template.published export.completed"`
+	OrgID string         `json:"orgId,omitempty"`
+	Data  map[string]any `json:"data,omitempty"`
+}
+
+// CreateWebhookRequest registers an endpoint for the listed event types.
+// Deliveries are only sent over HTTPS.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,startswith=https://,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=job.completed quota.exceeded webhook.test deck.created template.published export.completed"`
+}
+
+// UpdateWebhookRequest changes a webhook; missing fields are left as they
+// are. RotateSecret issues a new signing secret, returned once.
+type UpdateWebhookRequest struct {
+	URL          *string  `json:"url,omitempty" validate:"omitempty,url,startswith=https://,max=2048"`
+	Events       []string `json:"events,omitempty" validate:"omitempty,min=1,dive,oneof=job.completed quota.exceeded webhook.test deck.created template.published export.completed"`
+	Active       *bool    `json:"active,omitempty"`
+	RotateSecret bool     `json:"rotateSecret,omitempty"`
+}
+
+// SlackIntegrationRequest connects Slack through an incoming webhook or a bot
+// token, not both; a bot also needs the channel to post to. Both kinds of
+// notice are on unless turned off.
+type SlackIntegrationRequest struct {
+	WebhookURL     string `json:"webhookUrl,omitempty" validate:"required_without=BotToken,excluded_with=BotToken,omitempty,url,startswith=https://"`
+	BotToken       string `json:"botToken,omitempty" validate:"required_without=WebhookURL,omitempty,startswith=xoxb-"`
+	Channel        string `json:"channel,omitempty" validate:"required_with=BotToken,max=80"`
+	NotifyExports  *bool  `json:"notifyExports,omitempty"`
+	NotifyFailures *bool  `json:"notifyFailures,omitempty"`
+}
+
+// StockMediaKeysRequest sets the org's own stock photo API keys. A key left
+// out is kept and "" removes it, falling back to the server's key.
+type StockMediaKeysRequest struct {
+	UnsplashAccessKey *string `json:"unsplashAccessKey,omitempty" validate:"omitempty,max=200"`
+	PexelsAPIKey      *string `json:"pexelsApiKey,omitempty" validate:"omitempty,max=200"`
+}
+
+// NotionIntegrationRequest stores the token of a Notion internal
+// integration; pages have to be shared with the integration to import.
+type NotionIntegrationRequest struct {
+	Token string `json:"token" validate:"required,max=500"`
+}
+
+// ConfluenceIntegrationRequest connects a Confluence site. Email is set for
+// Atlassian Cloud API tokens and left out for personal access tokens.
+type ConfluenceIntegrationRequest struct {
+	BaseURL string `json:"baseUrl" validate:"required,url,max=2048"`
+	Email   string `json:"email,omitempty" validate:"omitempty,email,max=320"`
+	Token   string `json:"token" validate:"required,max=500"`
+}
+
+// ImportPageRequest creates a deck from a Notion or Confluence page. The
+// page's content is bound to the template version by the AI, or with
+// Outline each top-level section of the page becomes a slide without it.
+type ImportPageRequest struct {
+	URL                   string `json:"url" validate:"required,max=2048"`
+	SourceTemplateVersion string `json:"sourceTemplateVersionId" validate:"required"`
+	Name                  string `json:"name,omitempty" validate:"omitempty,min=3"`
+	Outline               bool   `json:"outline,omitempty"`
+	StockImages           bool   `json:"stockImages,omitempty"`
+}
+
+// CreateDeckFromMarkdownRequest creates a deck from a Marp or Markdown
+// document. Each of its slides is built from the template version's first
+// layout, or with Design the AI binds the document to the template instead.
+type CreateDeckFromMarkdownRequest struct {
+	Markdown              string `json:"markdown" validate:"required,max=100000"`
+	SourceTemplateVersion string `json:"sourceTemplateVersionId" validate:"required"`
+	Name                  string `json:"name,omitempty" validate:"omitempty,min=3"`
+	Design                bool   `json:"design,omitempty"`
+	StockImages           bool   `json:"stockImages,omitempty"`
+}
+
+// StockImageRequest names a stock search result to cache for a slide.
+type StockImageRequest struct {
+	Provider string `json:"provider" validate:"required,oneof=unsplash pexels iconify"`
+	ID       string `json:"id" validate:"required,max=200"`
+}
+
+// GenerateBackgroundRequest asks for an AI slide background. The prompt is
+// built from the theme of the given deck or template version, with Prompt
+// added as extra direction; without a version Prompt is required.
+type GenerateBackgroundRequest struct {
+	DeckVersionID     string `json:"deckVersionId,omitempty" validate:"omitempty,max=100"`
+	TemplateVersionID string `json:"templateVersionId,omitempty" validate:"omitempty,max=100"`
+	Prompt            string `json:"prompt,omitempty" validate:"max=1000"`
+	Style             string `json:"style,omitempty" validate:"max=100"`
+	Width             int    `json:"width,omitempty" validate:"omitempty,min=256,max=2048"`
+	Height            int    `json:"height,omitempty" validate:"omitempty,min=256,max=2048"`
+}
+
+// PlatformJobsRequest selects jobs for a platform-wide bulk action.
+type PlatformJobsRequest struct {
+	JobIDs []string `json:"jobIds" validate:"required,min=1,max=500,dive,required"`
+}
+
+// PurgeDeadLetterRequest purges the listed dead-lettered jobs or, when JobIDs
+// is empty, every dead-lettered job matching OrgID and Type.
+type PurgeDeadLetterRequest struct {
+	JobIDs []string `json:"jobIds,omitempty" validate:"max=500,dive,required"`
+	OrgID  string   `json:"orgId,omitempty"`
+	Type   string   `json:"type,omitempty"`
+}
+
+// RetryPolicyRequest sets a job type's retry policy override. Omitted fields
+// keep the value from the environment or the default.
+type RetryPolicyRequest struct {
+	MaxRetries  *int     `json:"maxRetries,omitempty" validate:"omitempty,min=0,max=50"`
+	BaseDelayMs *int64   `json:"baseDelayMs,omitempty" validate:"omitempty,min=0"`
+	MaxDelayMs  *int64   `json:"maxDelayMs,omitempty" validate:"omitempty,min=0"`
+	Jitter      *float64 `json:"jitter,omitempty" validate:"omitempty,min=0,max=1"`
+}
+
+// FeatureFlagRequest sets a flag platform-wide, or for one org when OrgID is
+// set.
+type FeatureFlagRequest struct {
+	Enabled *bool  `json:"enabled" validate:"required"`
+	OrgID   string `json:"orgId,omitempty"`
+}
+
+type GrantPermissionRequest struct {
+	Permission string `json:"permission" validate:"required,oneof=view edit"`
+}
+
+type UpdateVisibilityRequest struct {
+	Visibility string `json:"visibility" validate:"required,oneof=org private"`
+}
+
+// CreateCommentRequest starts a thread on a slide, or replies to one when
+// ParentID is set; replies inherit the thread's slide.
+type CreateCommentRequest struct {
+	SlideIndex *int     `json:"slideIndex,omitempty" validate:"omitempty,min=0"`
+	ParentID   string   `json:"parentId,omitempty"`
+	Body       string   `json:"body" validate:"required,max=10000"`
+	Mentions   []string `json:"mentions,omitempty" validate:"max=20,dive,required"`
+}
+
+type CreateFolderRequest struct {
+	Name     string `json:"name" validate:"required,max=200"`
+	ParentID string `json:"parentId,omitempty"`
+}
+
+// UpdateFolderRequest renames and/or moves a folder. An empty ParentID moves
+// it to the top level; a missing one leaves it where it is.
+type UpdateFolderRequest struct {
+	Name     *string `json:"name,omitempty" validate:"omitempty,min=1,max=200"`
+	ParentID *string `json:"parentId,omitempty"`
+}
+
+type CreateTagRequest struct {
+	Name  string `json:"name" validate:"required,max=64"`
+	Color string `json:"color,omitempty" validate:"omitempty,hexcolor"`
+}
+
+type UpdateTagRequest struct {
+	Name  *string `json:"name,omitempty" validate:"omitempty,min=1,max=64"`
+	Color *string `json:"color,omitempty" validate:"omitempty,hexcolor"`
+}
+
+// MoveToFolderRequest files a template or deck; an empty FolderID unfiles it.
+type MoveToFolderRequest struct {
+	FolderID string `json:"folderId"`
+}
+
+type BulkExportRequest struct {
+	DeckIDs  []string `json:"deckIds" validate:"required,min=1,max=50,dive,required"`
+	Quality  string   `json:"quality,omitempty" validate:"omitempty,oneof=draft standard high"`
+	Renderer string   `json:"renderer,omitempty" validate:"omitempty,oneof=go python ai remote"`
+	// AdjustContrast nudges each deck's text colors to meet WCAG AA contrast.
+	AdjustContrast bool `json:"adjustContrast,omitempty"`
+	// Deterministic renders each deck with the deterministic Go renderer.
+	Deterministic bool `json:"deterministic,omitempty"`
+}
+
+// DeckMergeSelection picks slides From..To (1-based, inclusive) of a deck
+// version; either bound may be omitted to run to that end of the deck.
+type DeckMergeSelection struct {
+	DeckVersionID string `json:"deckVersionId" validate:"required"`
+	From          *int   `json:"from,omitempty" validate:"omitempty,min=1"`
+	To            *int   `json:"to,omitempty" validate:"omitempty,min=1"`
+}
+
+// MergeDecksRequest builds a new deck from slides of existing ones, in the
+// order given. BrandKitID, when set, restyles the result with that kit's tokens.
+type MergeDecksRequest struct {
+	Name       string               `json:"name" validate:"required,min=3"`
+	BrandKitID string               `json:"brandKitId,omitempty"`
+	Selections []DeckMergeSelection `json:"selections" validate:"required,min=1,max=50,dive"`
+}
+
+// SlideOperation is one structural edit. Index and To are 0-based and refer
+// to the slide order after the preceding operations have been applied.
+type SlideOperation struct {
+	Op     string         `json:"op" validate:"required,oneof=move delete insert"`
+	Index  *int           `json:"index" validate:"required,min=0"`
+	To     *int           `json:"to,omitempty" validate:"required_if=Op move,omitempty,min=0"`
+	Layout map[string]any `json:"layout,omitempty" validate:"required_if=Op insert"`
+}
+
+type EditSlidesRequest struct {
+	Operations []SlideOperation `json:"operations" validate:"required,min=1,max=100,dive"`
+}
+
+// RefineDeckRequest asks the AI to revise a deck, e.g. "make slide 3 more
+// concise" or "add a pricing slide".
+type RefineDeckRequest struct {
+	Instruction string `json:"instruction" validate:"required,min=3,max=2000"`
+	GenerationParamsRequest
+}
+
+// TransformDeckRequest rewrites every text of a deck version. Language, a
+// BCP 47 tag such as "ar", is the target of translate.
+type TransformDeckRequest struct {
+	Operation string `json:"operation" validate:"required,oneof=shorten expand formalize simplify translate"`
+	Language  string `json:"language,omitempty" validate:"required_if=Operation translate,omitempty,bcp47_language_tag"`
+}
+
+// UpgradeDeckTemplateRequest moves a deck onto another version of its
+// source template, the template's current version when
+// TemplateVersionID is empty. DryRun only reports the mapping.
+type UpgradeDeckTemplateRequest struct {
+	TemplateVersionID string `json:"templateVersionId,omitempty"`
+	DryRun            bool   `json:"dryRun,omitempty"`
+}
+
+// RegenerateSlideRequest rewrites one slide; Guidance, e.g. "focus on
+// pricing", is optional.
+type RegenerateSlideRequest struct {
+	Guidance string `json:"guidance,omitempty" validate:"omitempty,max=2000"`
+	GenerationParamsRequest
+}
+
+type ImportGoogleSlidesRequest struct {
+	URL  string `json:"url" validate:"required"`
+	Name string `json:"name" validate:"omitempty,min=3"`
+}
+
+type GoogleCredentialsRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// GlossaryEntryRequest is a terminology rule. Variants, the wordings the
+// term replaces, are for preferred terms; Replacement is for forbidden ones.
+type GlossaryEntryRequest struct {
+	Kind        string   `json:"kind" validate:"required,oneof=preferred forbidden casing"`
+	Term        string   `json:"term" validate:"required,max=200"`
+	Variants    []string `json:"variants,omitempty" validate:"max=50,dive,required,max=200"`
+	Replacement string   `json:"replacement,omitempty" validate:"max=200"`
+	Note        string   `json:"note,omitempty" validate:"max=500"`
+}
+
+// CheckGlossaryRequest is text to check against the org's glossary.
+type CheckGlossaryRequest struct {
+	Text string `json:"text" validate:"required,max=100000"`
+}
+
+// CreateScheduleRequest defines a recurring generation: on each run of Cron
+// (in Timezone, UTC by default) a deck is bound from the template version
+// with Content, or the body fetched from SourceURL, and exported. New
+// schedules are enabled unless Enabled says otherwise.
+type CreateScheduleRequest struct {
+	Name              string `json:"name" validate:"required,max=200"`
+	Cron              string `json:"cron" validate:"required,max=100"`
+	Timezone          string `json:"timezone,omitempty" validate:"max=64"`
+	TemplateVersionID string `json:"templateVersionId" validate:"required"`
+	Content           string `json:"content,omitempty" validate:"required_without=SourceURL,max=100000"`
+	SourceURL         string `json:"sourceUrl,omitempty" validate:"omitempty,url,max=2048"`
+	Quality           string `json:"quality,omitempty" validate:"omitempty,oneof=draft standard high"`
+	Renderer          string `json:"renderer,omitempty" validate:"omitempty,oneof=go python ai remote"`
+	Enabled           *bool  `json:"enabled,omitempty"`
+}
+
+// UpdateScheduleRequest changes a schedule; missing fields are left as they
+// are, and empty strings clear the optional ones.
+type UpdateScheduleRequest struct {
+	Name              *string `json:"name,omitempty" validate:"omitempty,min=1,max=200"`
+	Cron              *string `json:"cron,omitempty" validate:"omitempty,min=1,max=100"`
+	Timezone          *string `json:"timezone,omitempty" validate:"omitempty,max=64"`
+	TemplateVersionID *string `json:"templateVersionId,omitempty" validate:"omitempty,min=1"`
+	Content           *string `json:"content,omitempty" validate:"omitempty,max=100000"`
+	SourceURL         *string `json:"sourceUrl,omitempty" validate:"omitempty,max=2048"`
+	Quality           *string `json:"quality,omitempty"`
+	Renderer          *string `json:"renderer,omitempty"`
+	Enabled           *bool   `json:"enabled,omitempty"`
+}
+
+// CreateConnectorRequest registers a data source. Which fields are needed
+// depends on Kind: csv reads URL, sending Credential as the Authorization
+// header if set; sheets reads Range of SpreadsheetID with Credential as the
+// Google API key; sql runs Query against the Credential connection string.
+type CreateConnectorRequest struct {
+	Name          string `json:"name" validate:"required,max=64"`
+	Kind          string `json:"kind" validate:"required,oneof=csv sheets sql"`
+	URL           string `json:"url,omitempty" validate:"omitempty,url,max=2048"`
+	SpreadsheetID string `json:"spreadsheetId,omitempty" validate:"max=200"`
+	Range         string `json:"range,omitempty" validate:"max=200"`
+	Query         string `json:"query,omitempty" validate:"max=20000"`
+	Credential    string `json:"credential,omitempty" validate:"max=4096"`
+	MaxAgeSeconds int    `json:"maxAgeSeconds,omitempty" validate:"min=0,max=604800"`
+}
+
+// UpdateConnectorRequest changes a connector; missing fields are left as
+// they are. Its kind can't change.
+type UpdateConnectorRequest struct {
+	Name          *string `json:"name,omitempty" validate:"omitempty,min=1,max=64"`
+	URL           *string `json:"url,omitempty" validate:"omitempty,max=2048"`
+	SpreadsheetID *string `json:"spreadsheetId,omitempty" validate:"omitempty,max=200"`
+	Range         *string `json:"range,omitempty" validate:"omitempty,max=200"`
+	Query         *string `json:"query,omitempty" validate:"omitempty,max=20000"`
+	Credential    *string `json:"credential,omitempty" validate:"omitempty,max=4096"`
+	MaxAgeSeconds *int    `json:"maxAgeSeconds,omitempty" validate:"omitempty,min=0,max=604800"`
+}
+
+// CreateDeckEmbedRequest lists the hosts allowed to frame the viewer, exact
+// ("wiki.example.com") or wildcard ("*.example.com"). Empty allows any site.
+type CreateDeckEmbedRequest struct {
+	AllowedDomains []string `json:"allowedDomains" validate:"max=20,dive,required,max=253"`
+}
+
+// CreateAPIKeyRequest names a new API key. Role defaults to the caller's own
+// and may not exceed it.
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+	Role string `json:"role,omitempty" validate:"omitempty,oneof=Owner Admin Editor Viewer"`
+}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/egress"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/webhooks"
+)
+
+const (
+	defaultDeliveryPageSize = 50
+	maxDeliveryPageSize     = 200
+)
+
+// handleCreateWebhook handles POST /v1/webhooks. The signing secret is only
+// returned here and when it is rotated.
+func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if err := egress.CheckURL(r.Context(), req.URL); err != nil {
+		writeError(w, r, http.StatusBadRequest, "url can't be reached: "+err.Error())
+		return
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		logger.LogError(r.Context(), "api", "create_webhook", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to generate secret")
+		return
+	}
+	hook, err := s.Store.Webhooks().Create(r.Context(), store.Webhook{
+		ID:        newID("whk"),
+		OrgID:     id.OrgID,
+		URL:       req.URL,
+		Events:    uniqueEvents(req.Events),
+		Secret:    secret,
+		Active:    true,
+		CreatedBy: id.UserID,
+	})
+	if err != nil {
+		logger.LogError(r.Context(), "api", "create_webhook", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to create webhook")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "webhook.create", TargetRef: hook.ID, Metadata: map[string]any{"url": hook.URL, "events": hook.Events}})
+	writeJSON(w, http.StatusCreated, map[string]any{"webhook": hook, "secret": secret})
+}
+
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	hooks, err := s.Store.Webhooks().List(r.Context(), id.OrgID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_webhooks", err)
+		writeError(w, r, http.StatusInternalServerError, "failed to list webhooks")
+		return
+	}
+	if hooks == nil {
+		hooks = []store.Webhook{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"webhooks": hooks})
+}
+
+func (s *Server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if err := s.validate.Struct(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	if req.URL != nil {
+		if err := egress.CheckURL(r.Context(), *req.URL); err != nil {
+			writeError(w, r, http.StatusBadRequest, "url can't be reached: "+err.Error())
+			return
+		}
+	}
+
+	hook, ok := s.loadWebhook(w, r, id, "update_webhook")
+	if !ok {
+		return
+	}
+	if req.URL != nil {
+		hook.URL = *req.URL
+	}
+	if req.Events != nil {
+		hook.Events = uniqueEvents(req.Events)
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+	if req.RotateSecret {
+		secret, err := webhooks.NewSecret()
+		if err != nil {
+			logger.LogError(r.Context(), "api", "update_webhook", err)
+			writeError(w, r, http.StatusInternalServerError, "failed to generate secret")
+			return
+		}
+		hook.Secret = secret
+	}
+
+	updated, err := s.Store.Webhooks().Update(r.Context(), hook)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "update_webhook", err, "webhook_id", hook.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to update webhook")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "webhook.update", TargetRef: hook.ID, Metadata: map[string]any{"url": updated.URL, "events": updated.Events, "active": updated.Active, "secretRotated": req.RotateSecret}})
+
+	resp := map[string]any{"webhook": updated}
+	if req.RotateSecret {
+		resp["secret"] = updated.Secret
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	hookID := r.PathValue("id")
+
+	deleted, err := s.Store.Webhooks().Delete(r.Context(), id.OrgID, hookID)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "delete_webhook", err, "webhook_id", hookID)
+		writeError(w, r, http.StatusInternalServerError, "failed to delete webhook")
+		return
+	}
+	if !deleted {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "webhook.delete", TargetRef: hookID})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListWebhookDeliveries handles GET /v1/webhooks/{id}/deliveries, the
+// webhook's delivery log, newest first.
+func (s *Server) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	limit := defaultDeliveryPageSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxDeliveryPageSize {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxDeliveryPageSize))
+			return
+		}
+		limit = n
+	}
+
+	hook, ok := s.loadWebhook(w, r, id, "list_webhook_deliveries")
+	if !ok {
+		return
+	}
+	deliveries, err := s.Store.Webhooks().ListDeliveries(r.Context(), id.OrgID, hook.ID, limit)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "list_webhook_deliveries", err, "webhook_id", hook.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to list deliveries")
+		return
+	}
+	if deliveries == nil {
+		deliveries = []store.WebhookDelivery{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deliveries": deliveries})
+}
+
+// handleTestWebhook handles POST /v1/webhooks/{id}/test. It sends a
+// webhook.test event to this endpoint only, waits for the answer and records
+// it in the delivery log. Test fires are not retried, so a failure shows up
+// at once instead of lingering in the retry queue.
+func (s *Server) handleTestWebhook(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.GetIdentity(r.Context())
+	if !auth.RequireRole(id, auth.RoleAdmin) {
+		writeError(w, r, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	hook, ok := s.loadWebhook(w, r, id, "test_webhook")
+	if !ok {
+		return
+	}
+
+	ev := Event{
+		ID:        newID("evt"),
+		Type:      EventWebhookTest,
+		OrgID:     id.OrgID,
+		Synthetic: true,
+		Data:      s.syntheticEventData(EventWebhookTest),
+	}
+	del, err := webhooks.NewDelivery(hook, ev)
+	if err != nil {
+		logger.LogError(r.Context(), "api", "test_webhook", err, "webhook_id", hook.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to build test event")
+		return
+	}
+	del = s.Webhooks.Send(r.Context(), hook, del)
+	if del.Status != store.WebhookDeliverySucceeded {
+		del.Status = store.WebhookDeliveryFailed
+	}
+	if del, err = s.Store.Webhooks().CreateDelivery(r.Context(), del); err != nil {
+		logger.LogError(r.Context(), "api", "test_webhook", err, "webhook_id", hook.ID)
+		writeError(w, r, http.StatusInternalServerError, "failed to record delivery")
+		return
+	}
+
+	_, _ = s.Store.Audit().Append(r.Context(), store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "webhook.test", TargetRef: hook.ID, Metadata: map[string]any{"deliveryId": del.ID, "status": del.Status}})
+	writeJSON(w, http.StatusOK, map[string]any{"delivery": del})
+}
+
+// loadWebhook fetches the webhook named by the {id} path value in the
+// caller's org, writing the error response when it can't.
+func (s *Server) loadWebhook(w http.ResponseWriter, r *http.Request, id auth.Identity, op string) (store.Webhook, bool) {
+	hook, ok, err := s.Store.Webhooks().Get(r.Context(), id.OrgID, r.PathValue("id"))
+	if err != nil {
+		logger.LogError(r.Context(), "api", op, err)
+		writeError(w, r, http.StatusInternalServerError, "failed to load webhook")
+		return store.Webhook{}, false
+	}
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return store.Webhook{}, false
+	}
+	return hook, true
+}
+
+func uniqueEvents(events []string) []string {
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		if !slices.Contains(out, e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/webhooks"
+)
+
+func TestWebhooks_RegisterTestFireAndDeliveryLog(t *testing.T) {
+	allowLoopback(t)
+	var gotSig, gotBody, gotEvent string
+	endpoint := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotSig, gotBody, gotEvent = r.Header.Get(webhooks.SignatureHeader), string(body), r.Header.Get(webhooks.EventHeader)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer endpoint.Close()
+
+	s := NewServer()
+	s.Webhooks.Client = endpoint.Client()
+	h := s.Handler()
+
+	do := func(method, path string, role auth.Role, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestAuth(req, "user-1", "org-1", role)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/v1/webhooks", auth.RoleEditor, `{"url":"`+endpoint.URL+`","events":["deck.created"]}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = do(http.MethodPost, "/v1/webhooks", auth.RoleAdmin, `{"url":"http://insecure.example/hook","events":["deck.created"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "plain http is rejected")
+	w = do(http.MethodPost, "/v1/webhooks", auth.RoleAdmin, `{"url":"`+endpoint.URL+`","events":["deck.deleted"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "unknown event type")
+	w = do(http.MethodPost, "/v1/webhooks", auth.RoleAdmin, `{"url":"https://169.254.169.254/hook","events":["deck.created"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "metadata endpoint is refused")
+
+	w = do(http.MethodPost, "/v1/webhooks", auth.RoleAdmin, `{"url":"`+endpoint.URL+`","events":["deck.created","template.published","deck.created"]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Webhook store.Webhook `json:"webhook"`
+		Secret  string        `json:"secret"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.True(t, strings.HasPrefix(created.Secret, "whsec_"))
+	assert.Equal(t, []string{"deck.created", "template.published"}, created.Webhook.Events)
+	hookPath := "/v1/webhooks/" + created.Webhook.ID
+
+	w = do(http.MethodGet, "/v1/webhooks", auth.RoleAdmin, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), created.Webhook.ID)
+	assert.NotContains(t, w.Body.String(), created.Secret)
+
+	w = do(http.MethodPost, hookPath+"/test", auth.RoleAdmin, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var fired struct {
+		Delivery store.WebhookDelivery `json:"delivery"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &fired))
+	assert.Equal(t, store.WebhookDeliverySucceeded, fired.Delivery.Status)
+	assert.Equal(t, http.StatusOK, fired.Delivery.ResponseStatus)
+	assert.Equal(t, EventWebhookTest, gotEvent)
+	assert.True(t, webhooks.Verify(created.Secret, gotSig, []byte(gotBody), time.Now(), time.Minute))
+
+	// Events the hook subscribes to are queued in its delivery log.
+	s.emitEvent(t.Context(), "user-1", Event{Type: EventDeckCreated, OrgID: "org-1", Data: map[string]any{"deckId": "deck-1"}})
+	s.emitEvent(t.Context(), "user-1", Event{Type: EventExportCompleted, OrgID: "org-1"})
+	w = do(http.MethodGet, hookPath+"/deliveries", auth.RoleAdmin, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var log struct {
+		Deliveries []store.WebhookDelivery `json:"deliveries"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &log))
+	require.Len(t, log.Deliveries, 2)
+	var types []string
+	for _, d := range log.Deliveries {
+		types = append(types, d.EventType)
+	}
+	assert.ElementsMatch(t, []string{EventWebhookTest, EventDeckCreated}, types)
+
+	w = do(http.MethodPatch, hookPath, auth.RoleAdmin, `{"active":false,"rotateSecret":true}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated struct {
+		Webhook store.Webhook `json:"webhook"`
+		Secret  string        `json:"secret"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.False(t, updated.Webhook.Active)
+	assert.NotEqual(t, created.Secret, updated.Secret)
+
+	w = do(http.MethodDelete, hookPath, auth.RoleAdmin, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = do(http.MethodGet, hookPath+"/deliveries", auth.RoleAdmin, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,394 @@
+package assets
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+)
+
+// decorativeExtURI marks the Office 2019 extension that flags a picture as
+// decorative, so checkers and screen readers skip it.
+const decorativeExtURI = "{C183D7F6-B498-43B3-948B-1728B52AA6E4}"
+
+const decorativeExt = `<a:ext uri="` + decorativeExtURI + `"><adec:decorative xmlns:adec="http://schemas.microsoft.com/office/drawing/2017/decorative" val="1"/></a:ext>`
+
+// minShapeOverlap is how much of a shape and a placeholder must coincide,
+// as intersection over union, for the shape to count as showing it.
+const minShapeOverlap = 0.5
+
+var descrAttr = regexp.MustCompile(`\sdescr="[^"]*"`)
+
+// slideShape is a top-level shape of a slide's shape tree, as a byte range
+// of the slide XML.
+type slideShape struct {
+	start, end int
+	kind       string
+	// cNvPr is the shape's non-visual properties element: the range of its
+	// start tag, and where its end tag starts (0 when it is self-closing).
+	cNvPrStart, cNvPrTagEnd, cNvPrClose int
+	cNvPrPrefix                         string
+	x, y, cx, cy                        int64
+	placed                              bool
+}
+
+// AccessibilityRenderer wraps next so the decks it renders carry their
+// spec's alt text and reading order; see AddAccessibility. describe, when
+// set, first fills in the alt text the spec is missing. The stored spec is
+// not changed.
+func AccessibilityRenderer(next Renderer, describe func(context.Context, *spec.TemplateSpec)) Renderer {
+	return &accessibilityRenderer{Renderer: next, describe: describe}
+}
+
+type accessibilityRenderer struct {
+	Renderer
+	describe func(context.Context, *spec.TemplateSpec)
+}
+
+func (a *accessibilityRenderer) RenderPPTXBytes(ctx context.Context, s any) ([]byte, error) {
+	data, err := a.Renderer.RenderPPTXBytes(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	return a.annotate(ctx, s, data), nil
+}
+
+func (a *accessibilityRenderer) RenderPPTX(ctx context.Context, s any, outPath string) error {
+	if err := a.Renderer.RenderPPTX(ctx, s, outPath); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, a.annotate(ctx, s, data), 0o644)
+}
+
+// annotate returns pptx with the spec's accessibility metadata. It is best
+// effort: the deck is still usable without it, so failures are logged and
+// the original returned.
+func (a *accessibilityRenderer) annotate(ctx context.Context, s any, pptx []byte) []byte {
+	b, err := normalize.JSON(s)
+	var ts spec.TemplateSpec
+	if err == nil {
+		err = json.Unmarshal(b, &ts)
+	}
+	if err == nil {
+		if a.describe != nil {
+			a.describe(ctx, &ts)
+		}
+		var out []byte
+		if out, err = AddAccessibility(pptx, ts); err == nil {
+			return out
+		}
+	}
+	logger.Logger.Warn("accessibility_metadata_failed", "component", "renderer", "error", err)
+	return pptx
+}
+
+// AddAccessibility writes the accessibility metadata of s into a PPTX
+// rendered from it. Pictures showing an image placeholder get its alt text,
+// or are marked decorative when it is; pictures showing none (backgrounds
+// and ornaments the renderer adds) are marked decorative too. Shapes
+// showing placeholders are put in the layout's reading order, keeping the
+// places the other shapes hold. Shapes are matched to placeholders by where
+// they sit on the slide.
+func AddAccessibility(pptx []byte, s spec.TemplateSpec) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(pptx), int64(len(pptx)))
+	if err != nil {
+		return nil, fmt.Errorf("open pptx: %w", err)
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	pres, err := readPPTXPart(files, "ppt/presentation.xml")
+	if err != nil {
+		return nil, err
+	}
+	rels, err := readPPTXPart(files, "ppt/_rels/presentation.xml.rels")
+	if err != nil {
+		return nil, err
+	}
+	slides, err := slideParts(pres, rels)
+	if err != nil {
+		return nil, err
+	}
+	var size struct {
+		SldSz struct {
+			Cx int64 `xml:"cx,attr"`
+			Cy int64 `xml:"cy,attr"`
+		} `xml:"sldSz"`
+	}
+	if err := xml.Unmarshal(pres, &size); err != nil {
+		return nil, fmt.Errorf("parse ppt/presentation.xml: %w", err)
+	}
+	if size.SldSz.Cx <= 0 || size.SldSz.Cy <= 0 {
+		return nil, errors.New("pptx has no slide size")
+	}
+
+	parts := map[string][]byte{}
+	for i, name := range slides {
+		if i >= len(s.Layouts) {
+			break
+		}
+		data, err := readPPTXPart(files, name)
+		if err != nil {
+			return nil, err
+		}
+		out, err := slideAccessibility(data, s.Layouts[i], float64(size.SldSz.Cx), float64(size.SldSz.Cy))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if !bytes.Equal(out, data) {
+			parts[name] = out
+		}
+	}
+	if len(parts) == 0 {
+		return pptx, nil
+	}
+
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	for _, f := range zr.File {
+		if data, ok := parts[f.Name]; ok {
+			w, err := zw.Create(f.Name)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := w.Write(data); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := zw.Copy(f); err != nil {
+			return nil, fmt.Errorf("copy %s: %w", f.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// slideAccessibility applies layout's alt text and reading order to one
+// slide's XML.
+func slideAccessibility(data []byte, layout spec.Layout, width, height float64) ([]byte, error) {
+	shapes, err := parseSlideShapes(data)
+	if err != nil || len(shapes) == 0 {
+		return data, err
+	}
+
+	// Each placeholder goes to the shape that overlaps it best; pictures
+	// only show image placeholders, and other shapes only the rest.
+	matched := make([]int, len(shapes))
+	for i := range matched {
+		matched[i] = -1
+	}
+	for pi, ph := range layout.Placeholders {
+		image := ph.Type == "image"
+		best, bestOverlap := -1, minShapeOverlap
+		for si, sh := range shapes {
+			if matched[si] >= 0 || !sh.placed || (sh.kind == "pic") != image {
+				continue
+			}
+			o := overlap(ph.Geometry, spec.Geometry{X: float64(sh.x) / width, Y: float64(sh.y) / height, W: float64(sh.cx) / width, H: float64(sh.cy) / height})
+			if o >= bestOverlap {
+				best, bestOverlap = si, o
+			}
+		}
+		if best >= 0 {
+			matched[best] = pi
+		}
+	}
+
+	edited := make([][]byte, len(shapes))
+	for si, sh := range shapes {
+		edited[si] = data[sh.start:sh.end]
+		if sh.kind != "pic" || sh.cNvPrTagEnd == 0 {
+			continue
+		}
+		var img *spec.Image
+		if matched[si] >= 0 {
+			img = layout.Placeholders[matched[si]].Image
+		}
+		switch {
+		case img == nil || img.Decorative:
+			edited[si] = markDecorative(data, sh)
+		case img.Alt != "":
+			edited[si] = setDescr(data, sh, img.Alt)
+		}
+	}
+
+	// Shapes showing placeholders swap into each other's places in reading
+	// order; the rest keep theirs, and so their stacking.
+	var slots []int
+	for si := range shapes {
+		if matched[si] >= 0 {
+			slots = append(slots, si)
+		}
+	}
+	if len(layout.ReadingOrder) > 0 && len(slots) > 1 {
+		rank := map[int]int{}
+		for r, pi := range spec.ReadingOrder(layout) {
+			rank[pi] = r
+		}
+		ordered := append([]int(nil), slots...)
+		sort.SliceStable(ordered, func(a, b int) bool { return rank[matched[ordered[a]]] < rank[matched[ordered[b]]] })
+		moved := make([][]byte, len(shapes))
+		copy(moved, edited)
+		for i, si := range slots {
+			moved[si] = edited[ordered[i]]
+		}
+		edited = moved
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for si, sh := range shapes {
+		out.Write(data[last:sh.start])
+		out.Write(edited[si])
+		last = sh.end
+	}
+	out.Write(data[last:])
+	return out.Bytes(), nil
+}
+
+// parseSlideShapes finds the top-level shapes of a slide's shape tree.
+func parseSlideShapes(data []byte) ([]slideShape, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	var shapes []slideShape
+	var cur *slideShape
+	depth, tree, cNvPrDepth := 0, 0, 0
+	for {
+		off := int(d.InputOffset())
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			return shapes, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse slide: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case tree == 0 && t.Name.Local == "spTree":
+				tree = depth
+			case tree > 0 && depth == tree+1:
+				switch t.Name.Local {
+				case "sp", "pic", "grpSp", "graphicFrame", "cxnSp":
+					cur = &slideShape{start: off, kind: t.Name.Local}
+				}
+			case cur == nil:
+			case t.Name.Local == "cNvPr" && cur.cNvPrTagEnd == 0:
+				cur.cNvPrStart, cur.cNvPrTagEnd, cur.cNvPrPrefix = off, int(d.InputOffset()), t.Name.Space
+				cNvPrDepth = depth
+			case t.Name.Local == "off" && !cur.placed:
+				cur.x, cur.y = attrInt(t, "x"), attrInt(t, "y")
+			case t.Name.Local == "ext" && t.Name.Space == "a" && cur.cx == 0 && attr(t, "cx") != "":
+				cur.cx, cur.cy = attrInt(t, "cx"), attrInt(t, "cy")
+				cur.placed = cur.cx > 0 && cur.cy > 0
+			}
+		case xml.EndElement:
+			switch {
+			case cur != nil && depth == cNvPrDepth && t.Name.Local == "cNvPr":
+				if bytes.HasSuffix(data[cur.cNvPrStart:cur.cNvPrTagEnd], []byte("/>")) {
+					cur.cNvPrClose = 0
+				} else {
+					cur.cNvPrClose = off
+				}
+				cNvPrDepth = 0
+			case cur != nil && depth == tree+1:
+				cur.end = int(d.InputOffset())
+				shapes = append(shapes, *cur)
+				cur = nil
+			case depth == tree:
+				return shapes, nil
+			}
+			depth--
+		}
+	}
+}
+
+// setDescr sets the alt text of a picture.
+func setDescr(data []byte, sh slideShape, alt string) []byte {
+	tag := descrAttr.ReplaceAllString(string(data[sh.cNvPrStart:sh.cNvPrTagEnd]), "")
+	end := len(tag) - 1
+	if strings.HasSuffix(tag, "/>") {
+		end--
+	}
+	tag = strings.TrimRight(tag[:end], " ") + ` descr="` + xmlEscape(alt) + `"` + tag[end:]
+	return splice(data, sh, sh.cNvPrStart, sh.cNvPrTagEnd, tag)
+}
+
+// markDecorative flags a picture as decorative.
+func markDecorative(data []byte, sh slideShape) []byte {
+	if sh.cNvPrClose == 0 {
+		tag := string(data[sh.cNvPrStart:sh.cNvPrTagEnd])
+		tag = strings.TrimRight(strings.TrimSuffix(tag, "/>"), " ") + `><a:extLst>` + decorativeExt + `</a:extLst></` + qualified(sh.cNvPrPrefix, "cNvPr") + `>`
+		return splice(data, sh, sh.cNvPrStart, sh.cNvPrTagEnd, tag)
+	}
+	children := string(data[sh.cNvPrTagEnd:sh.cNvPrClose])
+	if strings.Contains(children, decorativeExtURI) {
+		return data[sh.start:sh.end]
+	}
+	if i := strings.Index(children, "<a:extLst>"); i >= 0 {
+		at := sh.cNvPrTagEnd + i + len("<a:extLst>")
+		return splice(data, sh, at, at, decorativeExt)
+	}
+	return splice(data, sh, sh.cNvPrClose, sh.cNvPrClose, `<a:extLst>`+decorativeExt+`</a:extLst>`)
+}
+
+// splice returns the shape's XML with data[from:to] replaced by s.
+func splice(data []byte, sh slideShape, from, to int, s string) []byte {
+	out := make([]byte, 0, sh.end-sh.start+len(s))
+	out = append(out, data[sh.start:from]...)
+	out = append(out, s...)
+	return append(out, data[to:sh.end]...)
+}
+
+// overlap is the intersection over union of two areas.
+func overlap(a, b spec.Geometry) float64 {
+	w := min(a.X+a.W, b.X+b.W) - max(a.X, b.X)
+	h := min(a.Y+a.H, b.Y+b.H) - max(a.Y, b.Y)
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+	inter := w * h
+	return inter / (a.W*a.H + b.W*b.H - inter)
+}
+
+func qualified(prefix, local string) string {
+	if prefix == "" {
+		return local
+	}
+	return prefix + ":" + local
+}
+
+func attr(t xml.StartElement, name string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func attrInt(t xml.StartElement, name string) int64 {
+	n, _ := strconv.ParseInt(attr(t, name), 10, 64)
+	return n
+}
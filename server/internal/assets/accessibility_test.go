@@ -0,0 +1,99 @@
+package assets
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+)
+
+func TestAddAccessibility(t *testing.T) {
+	shape := func(kind, cNvPr string, x, y, cx, cy int) string {
+		xfrm := `<a:xfrm><a:off x="` + strconv.Itoa(x) + `" y="` + strconv.Itoa(y) + `"/><a:ext cx="` + strconv.Itoa(cx) + `" cy="` + strconv.Itoa(cy) + `"/></a:xfrm>`
+		if kind == "pic" {
+			return `<p:pic><p:nvPicPr>` + cNvPr + `<p:cNvPicPr/><p:nvPr/></p:nvPicPr><p:spPr>` + xfrm + `</p:spPr></p:pic>`
+		}
+		return `<p:sp><p:nvSpPr>` + cNvPr + `<p:cNvSpPr/><p:nvPr/></p:nvSpPr><p:spPr>` + xfrm + `</p:spPr><p:txBody/></p:sp>`
+	}
+	background := shape("pic", `<p:cNvPr id="2" name="Background"/>`, 0, 0, 10000, 5000)
+	title := shape("sp", `<p:cNvPr id="3" name="Title"/>`, 500, 250, 9000, 750)
+	body := shape("sp", `<p:cNvPr id="4" name="Body"/>`, 500, 1250, 4500, 3250)
+	photo := shape("pic", `<p:cNvPr id="5" name="Photo" descr="old"><a:hlinkClick r:id=""/></p:cNvPr>`, 5500, 1000, 4000, 3000)
+	rule := shape("pic", `<p:cNvPr id="6" name="Rule"><a:extLst><a:ext uri="{X}"/></a:extLst></p:cNvPr>`, 5500, 4500, 4000, 250)
+	slide := `<?xml version="1.0" encoding="UTF-8"?><p:sld xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><p:cSld><p:spTree><p:nvGrpSpPr><p:cNvPr id="1" name=""/><p:cNvGrpSpPr/><p:nvPr/></p:nvGrpSpPr><p:grpSpPr/>` +
+		background + "\n" + title + body + photo + rule + `</p:spTree></p:cSld></p:sld>`
+
+	var pptx bytes.Buffer
+	zw := zip.NewWriter(&pptx)
+	for _, part := range [][2]string{
+		{"ppt/presentation.xml", `<?xml version="1.0" encoding="UTF-8"?><p:presentation xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><p:sldIdLst><p:sldId id="256" r:id="rId2"/><p:sldId id="257" r:id="rId3"/></p:sldIdLst><p:sldSz cx="10000" cy="5000"/></p:presentation>`},
+		{"ppt/_rels/presentation.xml.rels", `<?xml version="1.0" encoding="UTF-8"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId2" Type="slide" Target="slides/slide1.xml"/><Relationship Id="rId3" Type="slide" Target="slides/slide2.xml"/></Relationships>`},
+		{"ppt/slides/slide1.xml", slide},
+		{"ppt/slides/slide2.xml", slide},
+	} {
+		f, err := zw.Create(part[0])
+		require.NoError(t, err)
+		_, err = f.Write([]byte(part[1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	s := spec.TemplateSpec{Layouts: []spec.Layout{{
+		Placeholders: []spec.Placeholder{
+			{ID: "title", Type: "text", Geometry: spec.Geometry{X: 0.05, Y: 0.05, W: 0.9, H: 0.15}},
+			{ID: "body", Type: "text", Geometry: spec.Geometry{X: 0.05, Y: 0.25, W: 0.45, H: 0.65}},
+			{ID: "photo", Type: "image", Geometry: spec.Geometry{X: 0.55, Y: 0.2, W: 0.4, H: 0.6}, Image: &spec.Image{Path: "a.png", Alt: `Team "offsite"`}},
+			{ID: "rule", Type: "image", Geometry: spec.Geometry{X: 0.55, Y: 0.9, W: 0.4, H: 0.05}, Image: &spec.Image{Path: "b.png", Decorative: true}},
+		},
+		ReadingOrder: []string{"photo", "body", "title"},
+	}}}
+	out, err := AddAccessibility(pptx.Bytes(), s)
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(out), int64(len(out)))
+	require.NoError(t, err)
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		parts[f.Name] = string(b)
+	}
+
+	got := parts["ppt/slides/slide1.xml"]
+	decorative := `<a:ext uri="{C183D7F6-B498-43B3-948B-1728B52AA6E4}"><adec:decorative xmlns:adec="http://schemas.microsoft.com/office/drawing/2017/decorative" val="1"/></a:ext>`
+	assert.Contains(t, got, `<p:cNvPr id="2" name="Background"><a:extLst>`+decorative+`</a:extLst></p:cNvPr>`)
+	assert.Contains(t, got, `<p:cNvPr id="5" name="Photo" descr="Team &#34;offsite&#34;"><a:hlinkClick r:id=""/></p:cNvPr>`)
+	assert.Contains(t, got, `<p:cNvPr id="6" name="Rule"><a:extLst>`+decorative+`<a:ext uri="{X}"/></a:extLst></p:cNvPr>`)
+
+	// The background keeps its place at the back; the placeholders' shapes
+	// are read photo, body, title.
+	order := func(names ...string) []int {
+		var at []int
+		for _, n := range names {
+			at = append(at, strings.Index(got, `name="`+n+`"`))
+		}
+		return at
+	}
+	at := order("Background", "Photo", "Body", "Title", "Rule")
+	for i := 1; i < len(at); i++ {
+		assert.Less(t, at[i-1], at[i])
+	}
+	assert.Contains(t, got, "\n"+`<p:pic><p:nvPicPr><p:cNvPr id="5"`)
+
+	// Slides beyond the spec's layouts are left alone.
+	assert.Equal(t, slide, parts["ppt/slides/slide2.xml"])
+
+	// A rendered deck that already has it all comes back unchanged.
+	again, err := AddAccessibility(out, s)
+	require.NoError(t, err)
+	assert.Equal(t, out, again)
+}
@@ -19,6 +19,10 @@ const (
 	ThemeEducation
 )
 
+// themeOrder lists every ThemeType in a fixed order, for walking theme
+// counts reproducibly.
+var themeOrder = []ThemeType{ThemeTechnology, ThemeBusiness, ThemeSecurity, ThemeInnovation, ThemeHealthcare, ThemeFinance, ThemeGovernment, ThemeEducation}
+
 type DesignIdentity struct {
 	Industry        string  `json:"industry"`
 	Formality       string  `json:"formality"`
@@ -37,6 +41,7 @@ type ContentThemeAnalysis struct {
 	KeyConcepts     []string
 	ContentLength   int
 	SlideCount      int
+	ThemeCounts     map[ThemeType]int
 }
 
 type CompanyContext struct {
@@ -69,6 +74,36 @@ func (a *AIDesignAnalyzer) AnalyzeContentForDesign(jsonData map[string]any, comp
 	return a.generateDesignIdentity(themeAnalysis, companyInfo), nil
 }
 
+// AnalyzeContentForDesignSeeded is AnalyzeContentForDesign with ties between
+// equally strong themes broken by seed instead of map order, so the same
+// content and seed always give the same identity.
+func (a *AIDesignAnalyzer) AnalyzeContentForDesignSeeded(jsonData map[string]any, companyInfo CompanyContext, seed uint64) (*DesignIdentity, error) {
+	themeAnalysis := a.analyzeContentThemes(a.extractAllContent(jsonData), jsonData)
+	themeAnalysis.DominantTheme, themeAnalysis.ThemeStrength = seededDominantTheme(themeAnalysis.ThemeCounts, seed)
+	return a.generateDesignIdentity(themeAnalysis, companyInfo), nil
+}
+
+// seededDominantTheme returns the theme with the most keyword hits, picking
+// among ties with seed. Content with no hits gets ThemeBusiness, as in
+// analyzeContentThemes.
+func seededDominantTheme(counts map[ThemeType]int, seed uint64) (ThemeType, int) {
+	var tied []ThemeType
+	maxCount := 0
+	for _, theme := range themeOrder {
+		switch count := counts[theme]; {
+		case count > maxCount:
+			maxCount = count
+			tied = []ThemeType{theme}
+		case count == maxCount && count > 0:
+			tied = append(tied, theme)
+		}
+	}
+	if len(tied) == 0 {
+		return ThemeBusiness, 0
+	}
+	return tied[seed%uint64(len(tied))], maxCount
+}
+
 func (a *AIDesignAnalyzer) extractAllContent(jsonData map[string]any) string {
 	var allContent []string
 
@@ -157,6 +192,7 @@ func (a *AIDesignAnalyzer) analyzeContentThemes(content string, jsonData map[str
 		KeyConcepts:   keyConcepts,
 		ContentLength: wordCount,
 		SlideCount:    len(slides),
+		ThemeCounts:   themeCounts,
 	}
 }
 
@@ -16,10 +16,11 @@ type AIEnhancedRenderer struct {
 	store          store.Store
 }
 
-// NewAIEnhancedRenderer creates a new AI-enhanced renderer
-func NewAIEnhancedRenderer(st store.Store) *AIEnhancedRenderer {
+// NewAIEnhancedRenderer creates a new AI-enhanced renderer; huggingFaceAPIKey
+// is passed to the Python renderer it wraps.
+func NewAIEnhancedRenderer(st store.Store, huggingFaceAPIKey string) *AIEnhancedRenderer {
 	return &AIEnhancedRenderer{
-		pythonRenderer: NewPythonPPTXRenderer(os.Getenv("HUGGING_FACE_API_KEY")),
+		pythonRenderer: NewPythonPPTXRenderer(huggingFaceAPIKey),
 		store:          st,
 	}
 }
@@ -0,0 +1,41 @@
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrChecksumMismatch means a stored object's bytes no longer match the
+// checksum recorded when it was uploaded.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Checksum returns the hex SHA-256 of data, as recorded on assets.
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyChecksum checks data against want, a Checksum. Assets stored before
+// checksums were recorded have none, and pass.
+func VerifyChecksum(data []byte, want string) error {
+	if want == "" {
+		return nil
+	}
+	if got := Checksum(data); got != want {
+		return fmt.Errorf("%w: want sha256 %s, got %s", ErrChecksumMismatch, want, got)
+	}
+	return nil
+}
+
+// DigestHeader formats a Checksum as a Repr-Digest header value (RFC 9530),
+// or returns "" for an invalid one.
+func DigestHeader(checksum string) string {
+	sum, err := hex.DecodeString(checksum)
+	if err != nil || len(sum) != sha256.Size {
+		return ""
+	}
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum) + ":"
+}
@@ -0,0 +1,20 @@
+package assets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("deck bytes")
+	sum := Checksum(data)
+
+	assert.Len(t, sum, 64)
+	assert.NoError(t, VerifyChecksum(data, sum))
+	assert.NoError(t, VerifyChecksum(data, ""), "assets without a checksum pass")
+	assert.ErrorIs(t, VerifyChecksum([]byte("deck bytez"), sum), ErrChecksumMismatch)
+
+	assert.Equal(t, "sha-256=:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=:", DigestHeader(Checksum(nil)))
+	assert.Empty(t, DigestHeader("not hex"))
+}
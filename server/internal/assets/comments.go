@@ -0,0 +1,270 @@
+package assets
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	commentAuthorsRelType     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/commentAuthors"
+	commentsRelType           = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/comments"
+	commentAuthorsContentType = "application/vnd.openxmlformats-officedocument.presentationml.commentAuthors+xml"
+	commentsContentType       = "application/vnd.openxmlformats-officedocument.presentationml.comments+xml"
+	// threadingExtURI marks the PowerPoint 2013 extension that links a reply
+	// to the comment it answers.
+	threadingExtURI = "{C676402C-5697-4E1C-873F-D02D1690AC5C}"
+	pmlNamespace    = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"`
+	xmlHeader       = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+)
+
+// PPTXComment is a review comment to add to a slide of a PPTX. ParentID
+// names the first comment of the thread a reply belongs to; a thread's
+// replies follow it.
+type PPTXComment struct {
+	ID         string
+	ParentID   string
+	SlideIndex int
+	Author     string
+	Text       string
+	Created    time.Time
+}
+
+type pptxSlideList struct {
+	SldIDs []struct {
+		RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sldIdLst>sldId"`
+}
+
+type pptxRelationships struct {
+	Rels []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// AddComments adds comments to a PPTX as native PowerPoint comments, so
+// they show in its comments pane. Comments on slides the deck doesn't have
+// are left out.
+func AddComments(pptx []byte, comments []PPTXComment) ([]byte, error) {
+	if len(comments) == 0 {
+		return pptx, nil
+	}
+	zr, err := zip.NewReader(bytes.NewReader(pptx), int64(len(pptx)))
+	if err != nil {
+		return nil, fmt.Errorf("open pptx: %w", err)
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	if files["ppt/commentAuthors.xml"] != nil {
+		return nil, errors.New("pptx already has comments")
+	}
+	read := func(name string) ([]byte, error) { return readPPTXPart(files, name) }
+	parts := map[string][]byte{}
+	for _, name := range []string{"[Content_Types].xml", "ppt/_rels/presentation.xml.rels"} {
+		if parts[name], err = read(name); err != nil {
+			return nil, err
+		}
+	}
+	pres, err := read("ppt/presentation.xml")
+	if err != nil {
+		return nil, err
+	}
+	slides, err := slideParts(pres, parts["ppt/_rels/presentation.xml.rels"])
+	if err != nil {
+		return nil, err
+	}
+
+	// Comments are numbered per author; a reply points at its parent by
+	// author and number.
+	type ref struct{ author, idx int }
+	var authors []string
+	authorIDs := map[string]int{}
+	lastIdx := map[int]int{}
+	refs := map[string]ref{}
+	bySlide := map[int]*strings.Builder{}
+	threads := map[int]int{}
+	for _, c := range comments {
+		if c.SlideIndex < 0 || c.SlideIndex >= len(slides) {
+			continue
+		}
+		id, ok := authorIDs[c.Author]
+		if !ok {
+			id = len(authors)
+			authorIDs[c.Author] = id
+			authors = append(authors, c.Author)
+		}
+		lastIdx[id]++
+		idx := lastIdx[id]
+		if c.ID != "" {
+			refs[c.ID] = ref{id, idx}
+		}
+		b := bySlide[c.SlideIndex]
+		if b == nil {
+			b = &strings.Builder{}
+			bySlide[c.SlideIndex] = b
+		}
+		// Threads stack down the slide's left edge, replies beside their
+		// thread's first comment.
+		parent, reply := refs[c.ParentID]
+		reply = reply && c.ParentID != ""
+		if !reply {
+			threads[c.SlideIndex]++
+		}
+		fmt.Fprintf(b, `<p:cm authorId="%d" dt="%s" idx="%d"><p:pos x="10" y="%d"/><p:text>%s</p:text>`,
+			id, c.Created.UTC().Format("2006-01-02T15:04:05.000"), idx, 10+146*(threads[c.SlideIndex]-1), xmlEscape(c.Text))
+		if reply {
+			fmt.Fprintf(b, `<p:extLst><p:ext uri="%s"><p15:threadingInfo xmlns:p15="http://schemas.microsoft.com/office/powerpoint/2012/main" timeZoneBias="0"><p15:parentCm authorId="%d" idx="%d"/></p15:threadingInfo></p:ext></p:extLst>`,
+				threadingExtURI, parent.author, parent.idx)
+		}
+		b.WriteString(`</p:cm>`)
+	}
+	if len(bySlide) == 0 {
+		return pptx, nil
+	}
+
+	var authorList strings.Builder
+	authorList.WriteString(xmlHeader + `<p:cmAuthorLst ` + pmlNamespace + `>`)
+	for id, name := range authors {
+		fmt.Fprintf(&authorList, `<p:cmAuthor id="%d" name="%s" initials="%s" lastIdx="%d" clrIdx="%d"/>`, id, xmlEscape(name), xmlEscape(initials(name)), lastIdx[id], id)
+	}
+	authorList.WriteString(`</p:cmAuthorLst>`)
+	added := map[string][]byte{"ppt/commentAuthors.xml": []byte(authorList.String())}
+	overrides := `<Override PartName="/ppt/commentAuthors.xml" ContentType="` + commentAuthorsContentType + `"/>`
+
+	indexes := make([]int, 0, len(bySlide))
+	for i := range bySlide {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	for n, i := range indexes {
+		name := fmt.Sprintf("comment%d.xml", n+1)
+		added["ppt/comments/"+name] = []byte(xmlHeader + `<p:cmLst ` + pmlNamespace + `>` + bySlide[i].String() + `</p:cmLst>`)
+		overrides += `<Override PartName="/ppt/comments/` + name + `" ContentType="` + commentsContentType + `"/>`
+
+		slide := slides[i]
+		relsName := path.Join(path.Dir(slide), "_rels", path.Base(slide)+".rels")
+		rels := []byte(xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"></Relationships>`)
+		if files[relsName] != nil {
+			if rels, err = read(relsName); err != nil {
+				return nil, err
+			}
+		}
+		rel := `<Relationship Id="rIdCmsComments" Type="` + commentsRelType + `" Target="../comments/` + name + `"/>`
+		rels = []byte(strings.Replace(string(rels), "</Relationships>", rel+"</Relationships>", 1))
+		if files[relsName] != nil {
+			parts[relsName] = rels
+		} else {
+			added[relsName] = rels
+		}
+	}
+
+	parts["[Content_Types].xml"] = []byte(strings.Replace(string(parts["[Content_Types].xml"]), "</Types>", overrides+"</Types>", 1))
+	rel := `<Relationship Id="rIdCmsCommentAuthors" Type="` + commentAuthorsRelType + `" Target="commentAuthors.xml"/>`
+	parts["ppt/_rels/presentation.xml.rels"] = []byte(strings.Replace(string(parts["ppt/_rels/presentation.xml.rels"]), "</Relationships>", rel+"</Relationships>", 1))
+
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	for _, f := range zr.File {
+		if data, ok := parts[f.Name]; ok {
+			w, err := zw.Create(f.Name)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := w.Write(data); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := zw.Copy(f); err != nil {
+			return nil, fmt.Errorf("copy %s: %w", f.Name, err)
+		}
+	}
+	names := make([]string, 0, len(added))
+	for name := range added {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(added[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// readPPTXPart reads the named part of a PPTX whose files are by name.
+func readPPTXPart(files map[string]*zip.File, name string) ([]byte, error) {
+	f := files[name]
+	if f == nil {
+		return nil, fmt.Errorf("pptx is missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(io.LimitReader(rc, maxPPTXPartSize))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	return b, nil
+}
+
+// slideParts returns the names of a PPTX's slide parts in slide order.
+func slideParts(pres, rels []byte) ([]string, error) {
+	var list pptxSlideList
+	if err := xml.Unmarshal(pres, &list); err != nil {
+		return nil, fmt.Errorf("parse ppt/presentation.xml: %w", err)
+	}
+	var r pptxRelationships
+	if err := xml.Unmarshal(rels, &r); err != nil {
+		return nil, fmt.Errorf("parse ppt/_rels/presentation.xml.rels: %w", err)
+	}
+	targets := map[string]string{}
+	for _, rel := range r.Rels {
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join("ppt", rel.Target)
+		}
+	}
+	slides := make([]string, 0, len(list.SldIDs))
+	for _, s := range list.SldIDs {
+		target, ok := targets[s.RID]
+		if !ok {
+			return nil, fmt.Errorf("pptx slide relationship %s is missing", s.RID)
+		}
+		slides = append(slides, target)
+	}
+	return slides, nil
+}
+
+// initials are the first letters of up to the first two words of name.
+func initials(name string) string {
+	var out []rune
+	for _, word := range strings.Fields(name) {
+		if len(out) == 2 {
+			break
+		}
+		out = append(out, unicode.ToUpper([]rune(word)[0]))
+	}
+	return string(out)
+}
@@ -0,0 +1,73 @@
+package assets
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddComments(t *testing.T) {
+	var pptx bytes.Buffer
+	zw := zip.NewWriter(&pptx)
+	for name, content := range map[string]string{
+		"[Content_Types].xml":              `<?xml version="1.0" encoding="UTF-8"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="xml" ContentType="application/xml"/></Types>`,
+		"ppt/presentation.xml":             `<?xml version="1.0" encoding="UTF-8"?><p:presentation xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><p:sldIdLst><p:sldId id="256" r:id="rId7"/><p:sldId id="257" r:id="rId3"/></p:sldIdLst></p:presentation>`,
+		"ppt/_rels/presentation.xml.rels":  `<?xml version="1.0" encoding="UTF-8"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId3" Type="slide" Target="slides/slide1.xml"/><Relationship Id="rId7" Type="slide" Target="/ppt/slides/slide2.xml"/></Relationships>`,
+		"ppt/slides/slide1.xml":            `<p:sld/>`,
+		"ppt/slides/slide2.xml":            `<p:sld/>`,
+		"ppt/slides/_rels/slide2.xml.rels": `<?xml version="1.0" encoding="UTF-8"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="slideLayout" Target="../slideLayouts/slideLayout1.xml"/></Relationships>`,
+	} {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	out, err := AddComments(pptx.Bytes(), []PPTXComment{
+		{ID: "c1", SlideIndex: 0, Author: "Ana Lima", Text: "Is <this> right?", Created: at},
+		{ID: "c2", ParentID: "c1", SlideIndex: 0, Author: "bo", Text: "Yes", Created: at.Add(time.Hour)},
+		{ID: "c3", SlideIndex: 0, Author: "Ana Lima", Text: "Second thread", Created: at},
+		{ID: "c4", SlideIndex: 5, Author: "Ana Lima", Text: "No such slide", Created: at},
+	})
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(out), int64(len(out)))
+	require.NoError(t, err)
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		parts[f.Name] = string(b)
+	}
+
+	// The first slide in sldIdLst order is slide2.xml.
+	assert.Contains(t, parts["ppt/slides/_rels/slide2.xml.rels"], "slideLayout1.xml")
+	assert.Contains(t, parts["ppt/slides/_rels/slide2.xml.rels"], `Target="../comments/comment1.xml"`)
+	assert.NotContains(t, parts, "ppt/slides/_rels/slide1.xml.rels")
+	assert.Contains(t, parts["ppt/commentAuthors.xml"], `<p:cmAuthor id="0" name="Ana Lima" initials="AL" lastIdx="2" clrIdx="0"/>`)
+	assert.Contains(t, parts["ppt/commentAuthors.xml"], `<p:cmAuthor id="1" name="bo" initials="B" lastIdx="1" clrIdx="1"/>`)
+	comments := parts["ppt/comments/comment1.xml"]
+	assert.Contains(t, comments, `<p:cm authorId="0" dt="2026-03-01T09:30:00.000" idx="1"><p:pos x="10" y="10"/><p:text>Is &lt;this&gt; right?</p:text></p:cm>`)
+	assert.Contains(t, comments, `<p15:parentCm authorId="0" idx="1"/>`)
+	assert.Contains(t, comments, `<p:cm authorId="0" dt="2026-03-01T09:30:00.000" idx="2"><p:pos x="10" y="156"/>`)
+	assert.NotContains(t, comments, "No such slide")
+	assert.Contains(t, parts["[Content_Types].xml"], `<Override PartName="/ppt/commentAuthors.xml"`)
+	assert.Contains(t, parts["[Content_Types].xml"], `<Override PartName="/ppt/comments/comment1.xml"`)
+	assert.Contains(t, parts["ppt/_rels/presentation.xml.rels"], `Target="commentAuthors.xml"`)
+
+	_, err = AddComments(out, []PPTXComment{{SlideIndex: 0, Author: "Ana", Text: "Again"}})
+	assert.Error(t, err)
+	same, err := AddComments(pptx.Bytes(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, pptx.Bytes(), same)
+}
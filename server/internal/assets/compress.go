@@ -0,0 +1,332 @@
+package assets
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// compressStep is one round of CompressPPTX: raster images wider than
+// width are scaled down to it and opaque ones re-encoded as JPEG at
+// quality.
+type compressStep struct {
+	width, quality int
+}
+
+// compressSteps go from barely visible losses to what still reads on a
+// projector; CompressPPTX stops at the first that fits the budget.
+var compressSteps = []compressStep{
+	{1920, 85},
+	{1600, 75},
+	{1280, 65},
+	{960, 55},
+	{640, 45},
+}
+
+const (
+	// minCompressImage is the smallest media part worth re-encoding; icons
+	// and logos below it stay as they are.
+	minCompressImage = 32 << 10
+	// maxCompressImage and maxCompressPixels bound the images CompressPPTX
+	// decodes; larger ones are kept as they are.
+	maxCompressImage  = 64 << 20
+	maxCompressPixels = 50_000_000
+)
+
+var relTarget = regexp.MustCompile(`Target="([^"]*)"`)
+
+// CompressReport says what CompressPPTX did.
+type CompressReport struct {
+	// OriginalBytes and Bytes are the file's size before and after.
+	OriginalBytes int64
+	Bytes         int64
+	// RemovedMedia counts media parts dropped because nothing used them.
+	RemovedMedia int
+	// ResampledImages counts images scaled down or re-encoded.
+	ResampledImages int
+}
+
+// OverBudget reports whether the compressed file is still larger than
+// budget.
+func (r CompressReport) OverBudget(budget int64) bool {
+	return r.Bytes > budget
+}
+
+// CompressPPTX shrinks a PPTX toward budget bytes. It drops media parts no
+// relationship points at, then, while the file is too big, scales down and
+// re-encodes its PNG and JPEG images a step at a time, each step starting
+// from the original images. Opaque images become JPEGs; images with
+// transparency stay PNGs and are only scaled. The result may still be over
+// budget when the deck's size isn't in its images; see
+// CompressReport.OverBudget.
+func CompressPPTX(pptx []byte, budget int64) ([]byte, CompressReport, error) {
+	report := CompressReport{OriginalBytes: int64(len(pptx)), Bytes: int64(len(pptx))}
+	zr, err := zip.NewReader(bytes.NewReader(pptx), int64(len(pptx)))
+	if err != nil {
+		return nil, report, fmt.Errorf("open pptx: %w", err)
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	used := map[string]bool{}
+	rels := map[string][]byte{}
+	for _, f := range zr.File {
+		if !strings.HasSuffix(f.Name, ".rels") {
+			continue
+		}
+		data, err := readPPTXPart(files, f.Name)
+		if err != nil {
+			return nil, report, err
+		}
+		rels[f.Name] = data
+		for _, m := range relTarget.FindAllSubmatch(data, -1) {
+			used[resolveRelTarget(f.Name, string(m[1]))] = true
+		}
+	}
+	removed := map[string]bool{}
+	var images []string
+	for _, f := range zr.File {
+		if !strings.HasPrefix(f.Name, "ppt/media/") {
+			continue
+		}
+		if !used[f.Name] {
+			removed[f.Name] = true
+			continue
+		}
+		switch strings.ToLower(path.Ext(f.Name)) {
+		case ".png", ".jpg", ".jpeg":
+			if f.UncompressedSize64 >= minCompressImage && f.UncompressedSize64 <= maxCompressImage {
+				images = append(images, f.Name)
+			}
+		}
+	}
+	report.RemovedMedia = len(removed)
+
+	out, err := rewritePPTX(zr, nil, removed)
+	if err != nil {
+		return nil, report, err
+	}
+	report.Bytes = int64(len(out))
+	if report.Bytes <= budget || len(images) == 0 {
+		return out, report, nil
+	}
+
+	decoded := map[string]image.Image{}
+	for _, name := range images {
+		if img := decodeMedia(files[name]); img != nil {
+			decoded[name] = img
+		}
+	}
+	for _, step := range compressSteps {
+		parts := map[string][]byte{}
+		renamed := map[string]string{}
+		resampled := 0
+		for _, name := range images {
+			img := decoded[name]
+			if img == nil {
+				continue
+			}
+			data, ext, err := resampleImage(img, step)
+			if err != nil {
+				return nil, report, fmt.Errorf("compress %s: %w", name, err)
+			}
+			if int64(len(data)) >= int64(files[name].UncompressedSize64) {
+				continue
+			}
+			target := name
+			if imageExt(name) != ext {
+				target = strings.TrimSuffix(name, path.Ext(name)) + ext
+				for files[target] != nil || parts[target] != nil {
+					target = strings.TrimSuffix(target, ext) + "-1" + ext
+				}
+				renamed[name] = target
+			}
+			parts[target] = data
+			resampled++
+		}
+		if resampled == 0 {
+			continue
+		}
+		if len(renamed) > 0 {
+			for name, data := range rels {
+				parts[name] = renameRelTargets(name, data, renamed)
+			}
+			types, err := readPPTXPart(files, "[Content_Types].xml")
+			if err != nil {
+				return nil, report, err
+			}
+			parts["[Content_Types].xml"] = withJPEGDefault(types)
+		}
+		drop := map[string]bool{}
+		for name := range removed {
+			drop[name] = true
+		}
+		for name := range renamed {
+			drop[name] = true
+		}
+		if out, err = rewritePPTX(zr, parts, drop); err != nil {
+			return nil, report, err
+		}
+		report.Bytes, report.ResampledImages = int64(len(out)), resampled
+		if report.Bytes <= budget {
+			break
+		}
+	}
+	return out, report, nil
+}
+
+// decodeMedia decodes an image part, or returns nil for one that is too
+// large or doesn't decode.
+func decodeMedia(f *zip.File) image.Image {
+	rc, err := f.Open()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxCompressImage))
+	if err != nil {
+		return nil
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width == 0 || cfg.Height == 0 || cfg.Width*cfg.Height > maxCompressPixels {
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	return img
+}
+
+// resampleImage scales img down to step's width, keeping its aspect ratio,
+// and encodes it, returning the data and its file extension.
+func resampleImage(img image.Image, step compressStep) ([]byte, string, error) {
+	// Whether the image is opaque is read before scaling, which can round
+	// full alpha down a little at the edges.
+	o, ok := img.(interface{ Opaque() bool })
+	transparent := ok && !o.Opaque()
+	b := img.Bounds()
+	if b.Dx() > step.width {
+		height := max(1, (step.width*b.Dy()+b.Dx()/2)/b.Dx())
+		img = scaleBilinear(img, step.width, height)
+	}
+	var buf bytes.Buffer
+	if transparent {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), ".png", nil
+	}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: step.quality}); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), ".jpeg", nil
+}
+
+// rewritePPTX copies the PPTX in zr with parts replacing or adding parts
+// and the parts in drop left out. Added parts go at the end.
+func rewritePPTX(zr *zip.Reader, parts map[string][]byte, drop map[string]bool) ([]byte, error) {
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	written := map[string]bool{}
+	write := func(name string, data []byte) error {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		written[name] = true
+		return err
+	}
+	for _, f := range zr.File {
+		if drop[f.Name] {
+			continue
+		}
+		if data, ok := parts[f.Name]; ok {
+			if err := write(f.Name, data); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := zw.Copy(f); err != nil {
+			return nil, fmt.Errorf("copy %s: %w", f.Name, err)
+		}
+	}
+	added := make([]string, 0, len(parts))
+	for name := range parts {
+		if !written[name] {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		if err := write(name, parts[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// resolveRelTarget resolves a relationship target against the part a .rels
+// file describes, giving a part name.
+func resolveRelTarget(relsName, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return path.Clean(target[1:])
+	}
+	// "dir/_rels/name.xml.rels" describes "dir/name.xml".
+	return path.Join(path.Dir(path.Dir(relsName)), target)
+}
+
+// renameRelTargets points the relationships in a .rels file at the new
+// names of renamed parts.
+func renameRelTargets(relsName string, data []byte, renamed map[string]string) []byte {
+	return relTarget.ReplaceAllFunc(data, func(m []byte) []byte {
+		target := string(relTarget.FindSubmatch(m)[1])
+		to, ok := renamed[resolveRelTarget(relsName, target)]
+		if !ok {
+			return m
+		}
+		return []byte(`Target="` + path.Join(path.Dir(target), path.Base(to)) + `"`)
+	})
+}
+
+// withJPEGDefault adds the JPEG content type to [Content_Types].xml if it
+// lacks one.
+func withJPEGDefault(types []byte) []byte {
+	if bytes.Contains(bytes.ToLower(types), []byte(`extension="jpeg"`)) {
+		return types
+	}
+	return bytes.Replace(types, []byte("</Types>"), []byte(`<Default Extension="jpeg" ContentType="image/jpeg"/></Types>`), 1)
+}
+
+// imageExt is the extension resampleImage gives images of name's kind.
+func imageExt(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".jpg", ".jpeg":
+		return ".jpeg"
+	}
+	return strings.ToLower(path.Ext(name))
+}
+
+// SizeWarning describes an export of size bytes that is over its budget,
+// or returns "" when it fits or has no budget.
+func SizeWarning(size, budget int64) string {
+	if budget <= 0 || size <= budget {
+		return ""
+	}
+	const mb = 1 << 20
+	return fmt.Sprintf("export is %.1f MB, over its %.1f MB size target even with its images compressed", float64(size)/mb, float64(budget)/mb)
+}
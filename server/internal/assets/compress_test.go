@@ -0,0 +1,119 @@
+package assets
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressPPTX(t *testing.T) {
+	// Noise compresses badly, like a photo would.
+	noise := func(w, h int, alpha uint8) []byte {
+		rng := rand.New(rand.NewSource(1))
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for i := range img.Pix {
+			img.Pix[i] = uint8(rng.Intn(256))
+			if i%4 == 3 {
+				img.Pix[i] = alpha
+			}
+		}
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, img))
+		return buf.Bytes()
+	}
+	// Over maxPPTXPartSize, like a generated background can be.
+	background := noise(2100, 1000, 255)
+	logo := noise(400, 200, 128)
+
+	build := func() []byte {
+		var pptx bytes.Buffer
+		zw := zip.NewWriter(&pptx)
+		for _, part := range []struct {
+			name string
+			data []byte
+		}{
+			{"[Content_Types].xml", []byte(`<?xml version="1.0" encoding="UTF-8"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="png" ContentType="image/png"/></Types>`)},
+			{"ppt/slides/slide1.xml", []byte(`<p:sld/>`)},
+			{"ppt/slides/_rels/slide1.xml.rels", []byte(`<Relationships><Relationship Id="rId1" Type="image" Target="../media/image1.png"/><Relationship Id="rId2" Type="image" Target="/ppt/media/image2.png"/><Relationship Id="rId3" Type="hyperlink" Target="https://example.com/x.png" TargetMode="External"/></Relationships>`)},
+			{"ppt/media/image1.png", background},
+			{"ppt/media/image2.png", logo},
+			{"ppt/media/image3.png", background},
+		} {
+			w, err := zw.CreateHeader(&zip.FileHeader{Name: part.name, Method: zip.Store})
+			require.NoError(t, err)
+			_, err = w.Write(part.data)
+			require.NoError(t, err)
+		}
+		require.NoError(t, zw.Close())
+		return pptx.Bytes()
+	}
+	read := func(data []byte) map[string][]byte {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		parts := map[string][]byte{}
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			b, err := io.ReadAll(rc)
+			rc.Close()
+			require.NoError(t, err)
+			parts[f.Name] = b
+		}
+		return parts
+	}
+	pptx := build()
+
+	t.Run("unused media is dropped first", func(t *testing.T) {
+		out, report, err := CompressPPTX(pptx, int64(len(pptx)))
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.RemovedMedia)
+		assert.Zero(t, report.ResampledImages)
+		assert.Equal(t, int64(len(pptx)), report.OriginalBytes)
+		assert.Equal(t, int64(len(out)), report.Bytes)
+		parts := read(out)
+		assert.NotContains(t, parts, "ppt/media/image3.png")
+		assert.Equal(t, background, parts["ppt/media/image1.png"])
+	})
+
+	t.Run("images are resampled to fit", func(t *testing.T) {
+		out, report, err := CompressPPTX(pptx, 2<<20)
+		require.NoError(t, err)
+		assert.False(t, report.OverBudget(2<<20))
+		assert.Equal(t, 1, report.ResampledImages)
+		parts := read(out)
+		assert.NotContains(t, parts, "ppt/media/image1.png")
+		img, format, err := image.Decode(bytes.NewReader(parts["ppt/media/image1.jpeg"]))
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.LessOrEqual(t, img.Bounds().Dx(), 1920)
+		// The logo has transparency, so it could only stay a PNG, and
+		// it is small enough that scaling doesn't touch it.
+		assert.Equal(t, logo, parts["ppt/media/image2.png"])
+
+		rels := string(parts["ppt/slides/_rels/slide1.xml.rels"])
+		assert.Contains(t, rels, `Target="../media/image1.jpeg"`)
+		assert.Contains(t, rels, `Target="/ppt/media/image2.png"`)
+		assert.Contains(t, rels, `Target="https://example.com/x.png"`)
+		assert.Contains(t, string(parts["[Content_Types].xml"]), `<Default Extension="jpeg" ContentType="image/jpeg"/></Types>`)
+	})
+
+	t.Run("a budget too small is reported", func(t *testing.T) {
+		out, report, err := CompressPPTX(pptx, 1<<10)
+		require.NoError(t, err)
+		assert.True(t, report.OverBudget(1<<10))
+		assert.Less(t, report.Bytes, report.OriginalBytes)
+		assert.Contains(t, read(out), "ppt/media/image1.jpeg")
+	})
+
+	t.Run("not a pptx", func(t *testing.T) {
+		_, _, err := CompressPPTX([]byte("nope"), 1<<20)
+		assert.Error(t, err)
+	})
+}
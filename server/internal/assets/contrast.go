@@ -0,0 +1,59 @@
+package assets
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+)
+
+// ContrastRenderer wraps next so the theme of each deck it renders is
+// adjusted to meet WCAG AA text contrast first; see spec.AdjustContrast.
+// The stored spec is not changed.
+func ContrastRenderer(next Renderer) Renderer {
+	return &contrastRenderer{Renderer: next}
+}
+
+type contrastRenderer struct {
+	Renderer
+}
+
+func (c *contrastRenderer) RenderPPTX(ctx context.Context, s any, outPath string) error {
+	return c.Renderer.RenderPPTX(ctx, adjustSpecContrast(s), outPath)
+}
+
+func (c *contrastRenderer) RenderPPTXBytes(ctx context.Context, s any) ([]byte, error) {
+	return c.Renderer.RenderPPTXBytes(ctx, adjustSpecContrast(s))
+}
+
+func (c *contrastRenderer) GenerateSlideThumbnails(ctx context.Context, s any) ([][]byte, error) {
+	return c.Renderer.GenerateSlideThumbnails(ctx, adjustSpecContrast(s))
+}
+
+// adjustSpecContrast returns s with its theme colors adjusted, or s itself
+// when nothing needs changing or it can't be read.
+func adjustSpecContrast(s any) any {
+	b, err := normalize.JSON(s)
+	if err != nil {
+		return s
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return s
+	}
+	tokens, _ := doc["tokens"].(map[string]any)
+	issues := spec.AdjustContrast(tokens)
+	if len(issues) == 0 {
+		return s
+	}
+	for _, issue := range issues {
+		logger.Logger.Info("theme_contrast_adjusted", "component", "renderer", "token", issue.Token, "from", issue.Color, "to", issue.Adjusted, "background", issue.Background)
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return s
+	}
+	return json.RawMessage(out)
+}
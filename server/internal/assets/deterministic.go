@@ -0,0 +1,80 @@
+package assets
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// zipEpoch is the modification time canonicalZip gives every entry: the
+// earliest a zip header can hold.
+var zipEpoch = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// NewDeterministicRenderer returns the Go renderer in deterministic mode, for
+// tests and for exports cached by content hash.
+func NewDeterministicRenderer() *GoPPTXRenderer {
+	r := NewGoPPTXRenderer()
+	r.Deterministic = true
+	return r
+}
+
+// DeterministicRenderer returns the deterministic renderer for an export of
+// quality; drafts keep the default theme, as with NewDraftRenderer.
+func DeterministicRenderer(quality string) Renderer {
+	r := NewDeterministicRenderer()
+	r.SkipDesignAnalysis = quality == store.ExportQualityDraft
+	return r
+}
+
+// specSeed derives the theme seed from the spec, so equal specs pick the
+// same theme. The JSON is compacted first so whitespace doesn't matter.
+func specSeed(specBytes []byte) uint64 {
+	h := fnv.New64a()
+	var buf bytes.Buffer
+	if json.Compact(&buf, specBytes) == nil {
+		specBytes = buf.Bytes()
+	}
+	_, _ = h.Write(specBytes)
+	return h.Sum64()
+}
+
+// canonicalZip rewrites a zip archive with its entries sorted by name, the
+// same timestamp on each and fresh compression, so archives with the same
+// contents are byte-identical.
+func canonicalZip(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("read pptx: %w", err)
+	}
+	files := append([]*zip.File(nil), zr.File...)
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: zipEpoch})
+		if err != nil {
+			return nil, err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", f.Name, err)
+		}
+		_, err = io.Copy(w, rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("copy %s: %w", f.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,66 @@
+package assets
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func zipOf(t *testing.T, modified time.Time, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: modified})
+		require.NoError(t, err)
+		_, err = w.Write([]byte("contents of " + name))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestCanonicalZip(t *testing.T) {
+	a, err := canonicalZip(zipOf(t, time.Now(), "ppt/presentation.xml", "[Content_Types].xml", "_rels/.rels"))
+	require.NoError(t, err)
+	b, err := canonicalZip(zipOf(t, time.Now().Add(-time.Hour), "_rels/.rels", "ppt/presentation.xml", "[Content_Types].xml"))
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	zr, err := zip.NewReader(bytes.NewReader(a), int64(len(a)))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"[Content_Types].xml", "_rels/.rels", "ppt/presentation.xml"}, names)
+
+	_, err = canonicalZip([]byte("not a zip"))
+	assert.Error(t, err)
+}
+
+func TestAnalyzeContentForDesignSeeded(t *testing.T) {
+	a := NewAIDesignAnalyzer()
+	// "software" and "patient" tie technology with healthcare.
+	data := map[string]any{"slides": []any{map[string]any{"title": "software for patient care"}}}
+	industries := map[string]bool{}
+	for seed := uint64(0); seed < 4; seed++ {
+		first, err := a.AnalyzeContentForDesignSeeded(data, CompanyContext{}, seed)
+		require.NoError(t, err)
+		for range 5 {
+			again, err := a.AnalyzeContentForDesignSeeded(data, CompanyContext{}, seed)
+			require.NoError(t, err)
+			assert.Equal(t, first, again)
+		}
+		industries[first.Industry] = true
+	}
+	assert.Equal(t, map[string]bool{"Technology/Software": true, "Healthcare/Medical": true}, industries)
+
+	none, err := a.AnalyzeContentForDesignSeeded(map[string]any{}, CompanyContext{}, 7)
+	require.NoError(t, err)
+	assert.Equal(t, "Corporate/Consulting", none.Industry)
+}
@@ -0,0 +1,474 @@
+package assets
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// StandardFonts ship with Office and the common desktop platforms, so decks
+// can name them without uploading anything.
+var StandardFonts = []string{
+	"Aptos", "Arial", "Arial Black", "Book Antiqua", "Calibri", "Calibri Light",
+	"Cambria", "Candara", "Century Gothic", "Consolas", "Constantia", "Corbel",
+	"Courier New", "Franklin Gothic", "Garamond", "Georgia", "Gill Sans MT",
+	"Helvetica", "Impact", "Lucida Console", "Palatino Linotype", "Segoe UI",
+	"Tahoma", "Times New Roman", "Trebuchet MS", "Verdana",
+}
+
+// IsStandardFont reports whether family is one of StandardFonts, ignoring
+// case.
+func IsStandardFont(family string) bool {
+	for _, f := range StandardFonts {
+		if strings.EqualFold(f, family) {
+			return true
+		}
+	}
+	return false
+}
+
+// FontInfo is what a font file says about itself.
+type FontInfo struct {
+	Family string
+	Style  store.FontStyle
+}
+
+// ParseFont reads the family and style from a TrueType or OpenType file's
+// name table. Collections and web fonts are rejected, since PowerPoint can
+// only embed plain TTF/OTF data.
+func ParseFont(data []byte) (FontInfo, error) {
+	if len(data) < 12 {
+		return FontInfo{}, errors.New("not a font file")
+	}
+	switch string(data[:4]) {
+	case "\x00\x01\x00\x00", "OTTO", "true":
+	case "ttcf":
+		return FontInfo{}, errors.New("font collections (.ttc) aren't supported; upload each font separately")
+	case "wOFF", "wOF2":
+		return FontInfo{}, errors.New("web fonts (.woff, .woff2) aren't supported; upload the TTF or OTF")
+	default:
+		return FontInfo{}, errors.New("not a TrueType or OpenType font")
+	}
+
+	numTables := int(binary.BigEndian.Uint16(data[4:6]))
+	var name []byte
+	for i := range numTables {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return FontInfo{}, errors.New("font table directory is truncated")
+		}
+		if string(data[rec:rec+4]) != "name" {
+			continue
+		}
+		off := int(binary.BigEndian.Uint32(data[rec+8 : rec+12]))
+		n := int(binary.BigEndian.Uint32(data[rec+12 : rec+16]))
+		if off < 0 || n < 6 || off+n > len(data) {
+			return FontInfo{}, errors.New("font name table is truncated")
+		}
+		name = data[off : off+n]
+		break
+	}
+	if name == nil {
+		return FontInfo{}, errors.New("font has no name table")
+	}
+
+	family := fontName(name, 1)
+	if family == "" {
+		return FontInfo{}, errors.New("font has no family name")
+	}
+	return FontInfo{Family: family, Style: fontStyle(fontName(name, 2))}, nil
+}
+
+// fontName returns name record id, preferring Windows US English, then any
+// Unicode record, then Mac Roman.
+func fontName(table []byte, id uint16) string {
+	count := int(binary.BigEndian.Uint16(table[2:4]))
+	base := int(binary.BigEndian.Uint16(table[4:6]))
+	best, bestRank := "", 0
+	for i := range count {
+		rec := 6 + 12*i
+		if rec+12 > len(table) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(table[rec:])
+		lang := binary.BigEndian.Uint16(table[rec+4:])
+		if binary.BigEndian.Uint16(table[rec+6:]) != id {
+			continue
+		}
+		n := int(binary.BigEndian.Uint16(table[rec+8:]))
+		off := base + int(binary.BigEndian.Uint16(table[rec+10:]))
+		if off+n > len(table) {
+			continue
+		}
+		raw := table[off : off+n]
+		var s string
+		var rank int
+		switch {
+		case platform == 3 && lang == 0x409:
+			s, rank = decodeUTF16BE(raw), 3
+		case platform == 3 || platform == 0:
+			s, rank = decodeUTF16BE(raw), 2
+		case platform == 1:
+			s, rank = string(raw), 1
+		}
+		if rank > bestRank && strings.TrimSpace(s) != "" {
+			best, bestRank = strings.TrimSpace(s), rank
+		}
+	}
+	return best
+}
+
+func decodeUTF16BE(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.BigEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(u))
+}
+
+func fontStyle(subfamily string) store.FontStyle {
+	s := strings.ToLower(subfamily)
+	bold := strings.Contains(s, "bold")
+	italic := strings.Contains(s, "italic") || strings.Contains(s, "oblique")
+	switch {
+	case bold && italic:
+		return store.FontBoldItalic
+	case bold:
+		return store.FontBold
+	case italic:
+		return store.FontItalic
+	default:
+		return store.FontRegular
+	}
+}
+
+// SpecFonts maps each font token in spec's tokens.fonts, e.g. "heading", to
+// the family it names. A token may be a family name or an object with a
+// "family" field.
+func SpecFonts(spec any) map[string]string {
+	b, err := normalize.JSON(spec)
+	if err != nil {
+		return nil
+	}
+	var s struct {
+		Tokens struct {
+			Fonts map[string]any `json:"fonts"`
+		} `json:"tokens"`
+	}
+	if json.Unmarshal(b, &s) != nil {
+		return nil
+	}
+	out := map[string]string{}
+	for token, v := range s.Tokens.Fonts {
+		var family string
+		switch v := v.(type) {
+		case string:
+			family = v
+		case map[string]any:
+			family, _ = v["family"].(string)
+		}
+		if family = strings.TrimSpace(family); family != "" {
+			out[token] = family
+		}
+	}
+	return out
+}
+
+// EmbeddedFont is one face of a family to embed in a deck.
+type EmbeddedFont struct {
+	Family string
+	Style  store.FontStyle
+	Data   []byte
+}
+
+const (
+	fontRelType     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/font"
+	fontContentType = "application/x-fontdata"
+)
+
+// embeddedFontSlots is the order PowerPoint expects the faces of an
+// embedded family in.
+var embeddedFontSlots = []store.FontStyle{store.FontRegular, store.FontBold, store.FontItalic, store.FontBoldItalic}
+
+// EmbedFonts adds fonts to a PPTX, so it shows in the brand's fonts on
+// machines that don't have them installed.
+func EmbedFonts(pptx []byte, fonts []EmbeddedFont) ([]byte, error) {
+	if len(fonts) == 0 {
+		return pptx, nil
+	}
+	zr, err := zip.NewReader(bytes.NewReader(pptx), int64(len(pptx)))
+	if err != nil {
+		return nil, fmt.Errorf("open pptx: %w", err)
+	}
+	parts := map[string][]byte{}
+	for _, name := range []string{"[Content_Types].xml", "ppt/presentation.xml", "ppt/_rels/presentation.xml.rels"} {
+		f, err := zr.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("pptx is missing %s", name)
+		}
+		parts[name], err = io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+	}
+
+	byFamily := map[string]map[store.FontStyle]string{}
+	var families []string
+	var rels, fontParts strings.Builder
+	added := map[string][]byte{}
+	for i, f := range fonts {
+		if byFamily[f.Family] == nil {
+			byFamily[f.Family] = map[store.FontStyle]string{}
+			families = append(families, f.Family)
+		}
+		relID := fmt.Sprintf("rIdCmsFont%d", i+1)
+		target := fmt.Sprintf("fonts/cmsfont%d.fntdata", i+1)
+		byFamily[f.Family][f.Style] = relID
+		added["ppt/"+target] = f.Data
+		fmt.Fprintf(&rels, `<Relationship Id="%s" Type="%s" Target="%s"/>`, relID, fontRelType, target)
+	}
+	for _, family := range families {
+		fontParts.WriteString(`<p:embeddedFont><p:font typeface="` + xmlEscape(family) + `"/>`)
+		for _, style := range embeddedFontSlots {
+			if id, ok := byFamily[family][style]; ok {
+				fmt.Fprintf(&fontParts, `<p:%s r:id="%s"/>`, style, id)
+			}
+		}
+		fontParts.WriteString(`</p:embeddedFont>`)
+	}
+
+	ct := string(parts["[Content_Types].xml"])
+	if !strings.Contains(ct, `Extension="fntdata"`) {
+		ct = strings.Replace(ct, "</Types>", `<Default Extension="fntdata" ContentType="`+fontContentType+`"/></Types>`, 1)
+	}
+	parts["[Content_Types].xml"] = []byte(ct)
+	parts["ppt/_rels/presentation.xml.rels"] = []byte(strings.Replace(string(parts["ppt/_rels/presentation.xml.rels"]), "</Relationships>", rels.String()+"</Relationships>", 1))
+	pres, err := addEmbeddedFontList(string(parts["ppt/presentation.xml"]), fontParts.String())
+	if err != nil {
+		return nil, err
+	}
+	parts["ppt/presentation.xml"] = []byte(pres)
+
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	for _, f := range zr.File {
+		if data, ok := parts[f.Name]; ok {
+			w, err := zw.Create(f.Name)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := w.Write(data); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if _, ok := added[f.Name]; ok {
+			continue
+		}
+		if err := zw.Copy(f); err != nil {
+			return nil, fmt.Errorf("copy %s: %w", f.Name, err)
+		}
+	}
+	names := make([]string, 0, len(added))
+	for name := range added {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(added[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// presentationAfterFonts are the presentation.xml elements that follow
+// embeddedFontLst in schema order.
+var presentationAfterFonts = []string{"<p:custShowLst", "<p:photoAlbum", "<p:custDataLst", "<p:kinsoku", "<p:defaultTextStyle", "<p:modifyVerifier", "<p:extLst", "</p:presentation>"}
+
+func addEmbeddedFontList(pres, fonts string) (string, error) {
+	if i := strings.Index(pres, "</p:embeddedFontLst>"); i >= 0 {
+		return pres[:i] + fonts + pres[i:], nil
+	}
+	at := -1
+	for _, tag := range presentationAfterFonts {
+		if i := strings.Index(pres, tag); i >= 0 && (at < 0 || i < at) {
+			at = i
+		}
+	}
+	if at < 0 {
+		return "", errors.New("pptx presentation.xml is malformed")
+	}
+	pres = pres[:at] + "<p:embeddedFontLst>" + fonts + "</p:embeddedFontLst>" + pres[at:]
+	if !strings.Contains(pres, "embedTrueTypeFonts=") {
+		pres = strings.Replace(pres, "<p:presentation ", `<p:presentation embedTrueTypeFonts="1" `, 1)
+	}
+	return pres, nil
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// FontResolver finds an org's uploaded fonts for the families a spec names.
+type FontResolver struct {
+	Store   store.Store
+	Objects ObjectStorage
+}
+
+// Warnings describes the fonts spec names that are neither standard nor
+// uploaded by the org; exports would show those in a fallback font.
+func (r *FontResolver) Warnings(ctx context.Context, orgID string, spec any) ([]string, error) {
+	refs := SpecFonts(spec)
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	fonts, err := r.Store.Fonts().List(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]string, 0, len(refs))
+	for token := range refs {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	var out []string
+	for _, token := range tokens {
+		family := refs[token]
+		if IsStandardFont(family) || hasFamily(fonts, family) {
+			continue
+		}
+		out = append(out, fmt.Sprintf("font %q (tokens.fonts.%s) isn't a standard font and hasn't been uploaded; exports will fall back to a default font", family, token))
+	}
+	return out, nil
+}
+
+func hasFamily(fonts []store.Font, family string) bool {
+	for _, f := range fonts {
+		if strings.EqualFold(f.Family, family) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve loads the org's uploaded files for the families spec names.
+func (r *FontResolver) Resolve(ctx context.Context, orgID string, spec any) ([]EmbeddedFont, error) {
+	refs := SpecFonts(spec)
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	fonts, err := r.Store.Fonts().List(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list fonts: %w", err)
+	}
+	var named []store.Font
+	var assetIDs []string
+	for _, f := range fonts {
+		for _, family := range refs {
+			if strings.EqualFold(f.Family, family) {
+				named = append(named, f)
+				assetIDs = append(assetIDs, f.AssetID)
+				break
+			}
+		}
+	}
+	if len(named) == 0 {
+		return nil, nil
+	}
+	fontAssets, err := r.Store.Assets().GetAssets(ctx, orgID, assetIDs)
+	if err != nil {
+		return nil, fmt.Errorf("load font assets: %w", err)
+	}
+	var out []EmbeddedFont
+	for _, f := range named {
+		asset, ok := fontAssets[f.AssetID]
+		if !ok {
+			return nil, fmt.Errorf("font %s asset %s is missing", f.ID, f.AssetID)
+		}
+		data, err := r.Objects.Download(WithRegion(ctx, asset.Region), asset.Path)
+		if err == nil {
+			err = VerifyChecksum(data, asset.SHA256)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("download font %s: %w", f.ID, err)
+		}
+		out = append(out, EmbeddedFont{Family: f.Family, Style: f.Style, Data: data})
+	}
+	return out, nil
+}
+
+// Renderer wraps next so decks it renders for orgID embed the org's fonts.
+// A nil resolver returns next unchanged.
+func (r *FontResolver) Renderer(next Renderer, orgID string) Renderer {
+	if r == nil {
+		return next
+	}
+	return &fontRenderer{Renderer: next, fonts: r, orgID: orgID}
+}
+
+type fontRenderer struct {
+	Renderer
+	fonts *FontResolver
+	orgID string
+}
+
+func (f *fontRenderer) RenderPPTXBytes(ctx context.Context, spec any) ([]byte, error) {
+	data, err := f.Renderer.RenderPPTXBytes(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	return f.embed(ctx, spec, data), nil
+}
+
+func (f *fontRenderer) RenderPPTX(ctx context.Context, spec any, outPath string) error {
+	if err := f.Renderer.RenderPPTX(ctx, spec, outPath); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, f.embed(ctx, spec, data), 0o644)
+}
+
+// embed returns pptx with the spec's fonts embedded. Embedding is best
+// effort: the deck is still usable without them, so failures are logged and
+// the original returned.
+func (f *fontRenderer) embed(ctx context.Context, spec any, pptx []byte) []byte {
+	fonts, err := f.fonts.Resolve(ctx, f.orgID, spec)
+	if err == nil && len(fonts) > 0 {
+		var out []byte
+		if out, err = EmbedFonts(pptx, fonts); err == nil {
+			return out
+		}
+	}
+	if err != nil {
+		logger.Logger.Warn("font_embed_failed", "component", "renderer", "org_id", f.orgID, "error", err)
+	}
+	return pptx
+}
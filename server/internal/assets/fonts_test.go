@@ -0,0 +1,120 @@
+package assets
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// testFont builds a minimal TrueType file holding only a name table with
+// the family (ID 1) and subfamily (ID 2) as Windows English records.
+func testFont(family, subfamily string) []byte {
+	encode := func(s string) []byte {
+		u := utf16.Encode([]rune(s))
+		b := make([]byte, 2*len(u))
+		for i, c := range u {
+			binary.BigEndian.PutUint16(b[2*i:], c)
+		}
+		return b
+	}
+	names := [][]byte{encode(family), encode(subfamily)}
+
+	var name bytes.Buffer
+	storage := 6 + 12*len(names)
+	binary.Write(&name, binary.BigEndian, []uint16{0, uint16(len(names)), uint16(storage)})
+	off := 0
+	for i, n := range names {
+		binary.Write(&name, binary.BigEndian, []uint16{3, 1, 0x409, uint16(i + 1), uint16(len(n)), uint16(off)})
+		off += len(n)
+	}
+	for _, n := range names {
+		name.Write(n)
+	}
+
+	var font bytes.Buffer
+	font.Write([]byte{0, 1, 0, 0})
+	binary.Write(&font, binary.BigEndian, []uint16{1, 16, 0, 0})
+	font.WriteString("name")
+	binary.Write(&font, binary.BigEndian, []uint32{0, 12 + 16, uint32(name.Len())})
+	font.Write(name.Bytes())
+	return font.Bytes()
+}
+
+func TestParseFont(t *testing.T) {
+	info, err := ParseFont(testFont("Brand Sans", "Bold Italic"))
+	require.NoError(t, err)
+	assert.Equal(t, FontInfo{Family: "Brand Sans", Style: store.FontBoldItalic}, info)
+
+	info, err = ParseFont(testFont("Brand Serif", "Regular"))
+	require.NoError(t, err)
+	assert.Equal(t, store.FontRegular, info.Style)
+
+	_, err = ParseFont([]byte("wOFF0000000000000000"))
+	assert.ErrorContains(t, err, "web fonts")
+	_, err = ParseFont([]byte("not a font at all"))
+	assert.Error(t, err)
+}
+
+func TestSpecFonts(t *testing.T) {
+	spec := map[string]any{"tokens": map[string]any{"fonts": map[string]any{
+		"heading": "Brand Sans",
+		"body":    map[string]any{"family": "Arial", "size": 14},
+		"empty":   "",
+	}}}
+	assert.Equal(t, map[string]string{"heading": "Brand Sans", "body": "Arial"}, SpecFonts(spec))
+	assert.Empty(t, SpecFonts(map[string]any{"layouts": []any{}}))
+}
+
+func TestEmbedFonts(t *testing.T) {
+	var pptx bytes.Buffer
+	zw := zip.NewWriter(&pptx)
+	for name, content := range map[string]string{
+		"[Content_Types].xml":             `<?xml version="1.0" encoding="UTF-8"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="xml" ContentType="application/xml"/></Types>`,
+		"ppt/presentation.xml":            `<?xml version="1.0" encoding="UTF-8"?><p:presentation xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><p:sldSz cx="12192000" cy="6858000"/><p:notesSz cx="6858000" cy="9144000"/></p:presentation>`,
+		"ppt/_rels/presentation.xml.rels": `<?xml version="1.0" encoding="UTF-8"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"></Relationships>`,
+	} {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	out, err := EmbedFonts(pptx.Bytes(), []EmbeddedFont{
+		{Family: "Brand Sans", Style: store.FontRegular, Data: []byte("regular")},
+		{Family: "Brand Sans", Style: store.FontBold, Data: []byte("bold")},
+	})
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(out), int64(len(out)))
+	require.NoError(t, err)
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		parts[f.Name] = string(b)
+	}
+
+	assert.Equal(t, "regular", parts["ppt/fonts/cmsfont1.fntdata"])
+	assert.Equal(t, "bold", parts["ppt/fonts/cmsfont2.fntdata"])
+	assert.Contains(t, parts["[Content_Types].xml"], `Extension="fntdata"`)
+	assert.Contains(t, parts["ppt/_rels/presentation.xml.rels"], "fonts/cmsfont1.fntdata")
+	assert.Contains(t, parts["ppt/presentation.xml"], `embedTrueTypeFonts="1"`)
+	assert.Contains(t, parts["ppt/presentation.xml"], `<p:font typeface="Brand Sans"`)
+	assert.Contains(t, parts["ppt/presentation.xml"], "<p:regular ")
+	assert.Contains(t, parts["ppt/presentation.xml"], "<p:bold ")
+
+	same, err := EmbedFonts(pptx.Bytes(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, pptx.Bytes(), same)
+}
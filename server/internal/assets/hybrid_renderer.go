@@ -3,6 +3,8 @@ package assets
 import (
 	"context"
 	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
 )
 
 // HybridRenderer chooses between Go and Python renderers based on requirements
@@ -49,7 +51,7 @@ func (h *HybridRenderer) selectRenderer(spec any) Renderer {
 
 // requiresRichVisuals determines if presentation needs rich visual features
 func (h *HybridRenderer) requiresRichVisuals(spec any) bool {
-	specBytes, err := specToJSONBytes(spec)
+	specBytes, err := normalize.JSON(spec)
 	if err != nil {
 		return false // Default to Go if can't parse
 	}
@@ -0,0 +1,140 @@
+package assets
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"time"
+)
+
+// Slide image export widths, in pixels; heights follow each slide's aspect
+// ratio.
+const (
+	MinSlideImageWidth = 320
+	MaxSlideImageWidth = 3840
+	// DefaultPNGWidth is full HD, sharp enough for documents and posts.
+	DefaultPNGWidth = 1920
+	// DefaultGIFWidth keeps animated previews small enough to embed in
+	// email.
+	DefaultGIFWidth = 640
+	// GIFFrameDelay is how long an animated preview shows each slide.
+	GIFFrameDelay = 2 * time.Second
+)
+
+// SlideImagesPNG scales slide images, as GenerateSlideThumbnails returns
+// them, to width pixels wide and encodes each as a PNG.
+func SlideImagesPNG(images [][]byte, width int) ([][]byte, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no slides to write")
+	}
+	out := make([][]byte, len(images))
+	for i, data := range images {
+		img, err := decodeSlideImage(data, width)
+		if err != nil {
+			return nil, fmt.Errorf("slide %d: %w", i+1, err)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("slide %d: %w", i+1, err)
+		}
+		out[i] = buf.Bytes()
+	}
+	return out, nil
+}
+
+// SlideImagesGIF builds an animated GIF that flips through slide images,
+// scaled to width pixels wide, showing each for delay and looping forever.
+func SlideImagesGIF(images [][]byte, width int, delay time.Duration) ([]byte, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no slides to write")
+	}
+	anim := &gif.GIF{LoopCount: 0}
+	var bounds image.Rectangle
+	for i, data := range images {
+		img, err := decodeSlideImage(data, width)
+		if err != nil {
+			return nil, fmt.Errorf("slide %d: %w", i+1, err)
+		}
+		// Every frame takes the first slide's size, so slides of another
+		// shape don't leave the previous frame showing around them.
+		if i == 0 {
+			bounds = img.Bounds()
+		}
+		frame := image.NewPaletted(bounds, palette.Plan9)
+		draw.Draw(frame, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.FloydSteinberg.Draw(frame, bounds, img, image.Point{})
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, int(delay/(10*time.Millisecond)))
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		return nil, fmt.Errorf("encode gif: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeSlideImage decodes a slide image and scales it to width pixels wide.
+func decodeSlideImage(data []byte, width int) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return nil, fmt.Errorf("empty image")
+	}
+	height := max(1, (width*b.Dy()+b.Dx()/2)/b.Dx())
+	return scaleBilinear(img, width, height), nil
+}
+
+// scaleBilinear resamples src to width x height, blending the four nearest
+// source pixels of each output pixel.
+func scaleBilinear(src image.Image, width, height int) *image.RGBA {
+	sb := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if sb.Dx() == width && sb.Dy() == height {
+		draw.Draw(dst, dst.Bounds(), src, sb.Min, draw.Src)
+		return dst
+	}
+	xRatio := float64(sb.Dx()) / float64(width)
+	yRatio := float64(sb.Dy()) / float64(height)
+	for y := 0; y < height; y++ {
+		sy := (float64(y)+0.5)*yRatio - 0.5
+		y0 := clampInt(int(sy), 0, sb.Dy()-1)
+		y1 := clampInt(y0+1, 0, sb.Dy()-1)
+		fy := max(0, sy-float64(y0))
+		for x := 0; x < width; x++ {
+			sx := (float64(x)+0.5)*xRatio - 0.5
+			x0 := clampInt(int(sx), 0, sb.Dx()-1)
+			x1 := clampInt(x0+1, 0, sb.Dx()-1)
+			fx := max(0, sx-float64(x0))
+			var px [4]float64
+			for _, s := range [4]struct {
+				x, y int
+				w    float64
+			}{
+				{x0, y0, (1 - fx) * (1 - fy)},
+				{x1, y0, fx * (1 - fy)},
+				{x0, y1, (1 - fx) * fy},
+				{x1, y1, fx * fy},
+			} {
+				r, g, b, a := src.At(sb.Min.X+s.x, sb.Min.Y+s.y).RGBA()
+				px[0] += float64(r) * s.w
+				px[1] += float64(g) * s.w
+				px[2] += float64(b) * s.w
+				px[3] += float64(a) * s.w
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(px[0] / 257), uint8(px[1] / 257), uint8(px[2] / 257), uint8(px[3] / 257)})
+		}
+	}
+	return dst
+}
+
+func clampInt(v, lo, hi int) int {
+	return min(max(v, lo), hi)
+}
@@ -0,0 +1,67 @@
+package assets
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSlideImage(t *testing.T, w, h int, c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestSlideImagesPNG(t *testing.T) {
+	red := color.RGBA{200, 30, 30, 255}
+	out, err := SlideImagesPNG([][]byte{testSlideImage(t, 400, 300, red), testSlideImage(t, 160, 90, red)}, 800)
+	require.NoError(t, err)
+	require.Len(t, out, 2)
+
+	first, err := png.Decode(bytes.NewReader(out[0]))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 800, 600), first.Bounds())
+	r, g, b, _ := first.At(400, 300).RGBA()
+	assert.Equal(t, [3]uint32{200, 30, 30}, [3]uint32{r >> 8, g >> 8, b >> 8})
+	second, err := png.Decode(bytes.NewReader(out[1]))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 800, 450), second.Bounds())
+
+	_, err = SlideImagesPNG(nil, 800)
+	assert.Error(t, err)
+	_, err = SlideImagesPNG([][]byte{[]byte("not an image")}, 800)
+	assert.Error(t, err)
+}
+
+func TestSlideImagesGIF(t *testing.T) {
+	out, err := SlideImagesGIF([][]byte{
+		testSlideImage(t, 400, 225, color.RGBA{255, 255, 255, 255}),
+		testSlideImage(t, 400, 225, color.RGBA{0, 0, 0, 255}),
+		testSlideImage(t, 400, 300, color.RGBA{0, 0, 255, 255}),
+	}, 320, 1500*time.Millisecond)
+	require.NoError(t, err)
+
+	anim, err := gif.DecodeAll(bytes.NewReader(out))
+	require.NoError(t, err)
+	require.Len(t, anim.Image, 3)
+	assert.Equal(t, []int{150, 150, 150}, anim.Delay)
+	assert.Equal(t, 0, anim.LoopCount)
+	for _, frame := range anim.Image {
+		assert.Equal(t, image.Rect(0, 0, 320, 180), frame.Bounds())
+	}
+	r, g, b, _ := anim.Image[1].At(10, 10).RGBA()
+	assert.Equal(t, [3]uint32{0, 0, 0}, [3]uint32{r >> 8, g >> 8, b >> 8})
+}
@@ -0,0 +1,51 @@
+package assets
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+)
+
+// MergeRenderer wraps next so the {{name}} variables of each deck it renders
+// are replaced with data first; see spec.MergeVariables. The stored spec is
+// not changed.
+func MergeRenderer(next Renderer, data map[string]string) Renderer {
+	return &mergeRenderer{Renderer: next, data: data}
+}
+
+type mergeRenderer struct {
+	Renderer
+	data map[string]string
+}
+
+func (m *mergeRenderer) RenderPPTX(ctx context.Context, s any, outPath string) error {
+	return m.Renderer.RenderPPTX(ctx, m.merge(s), outPath)
+}
+
+func (m *mergeRenderer) RenderPPTXBytes(ctx context.Context, s any) ([]byte, error) {
+	return m.Renderer.RenderPPTXBytes(ctx, m.merge(s))
+}
+
+func (m *mergeRenderer) GenerateSlideThumbnails(ctx context.Context, s any) ([][]byte, error) {
+	return m.Renderer.GenerateSlideThumbnails(ctx, m.merge(s))
+}
+
+// merge returns s with the data merged in, or s itself when it can't be
+// read.
+func (m *mergeRenderer) merge(s any) any {
+	b, err := normalize.JSON(s)
+	if err != nil {
+		return s
+	}
+	var doc any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return s
+	}
+	out, err := json.Marshal(spec.MergeVariables(doc, m.data))
+	if err != nil {
+		return s
+	}
+	return json.RawMessage(out)
+}
@@ -43,7 +43,8 @@ type ObjectMetadata struct {
 	ETag         string    `json:"etag"`
 	ContentType  string    `json:"contentType"`
 	LastModified time.Time `json:"lastModified"`
-	URL          string    `json:"url,omitempty"` // Signed URL if requested
+	URL          string    `json:"url,omitempty"`    // Signed URL if requested
+	Region       string    `json:"region,omitempty"` // Storage region, if routed; see WithRegion
 }
 
 // StorageConfig holds configuration for object storage backends
@@ -0,0 +1,105 @@
+package assets
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	_ "image/png"
+)
+
+// pdfPageWidth is the page width in points; heights follow each image's
+// aspect ratio, so 16:9 slides come out at 720x405.
+const pdfPageWidth = 720.0
+
+// ImagesToPDF builds a PDF with one full-bleed page per image. It is how
+// exports get a PDF without a native PDF renderer: the slide thumbnails are
+// already rendered, so each becomes a page.
+func ImagesToPDF(images [][]byte) ([]byte, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no pages to write")
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+	// Object numbers: 1 catalog, 2 page tree, then page, content and image
+	// objects for each slide in turn.
+	beginObj := func() {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n", len(offsets))
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	beginObj()
+	buf.WriteString("<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+
+	beginObj()
+	buf.WriteString("<< /Type /Pages /Kids [")
+	for i := range images {
+		fmt.Fprintf(&buf, " %d 0 R", 3+i*3)
+	}
+	fmt.Fprintf(&buf, " ] /Count %d >>\nendobj\n", len(images))
+
+	for i, data := range images {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		b := img.Bounds()
+		if b.Dx() == 0 || b.Dy() == 0 {
+			return nil, fmt.Errorf("page %d: empty image", i+1)
+		}
+		width, height := pdfPageWidth, pdfPageWidth*float64(b.Dy())/float64(b.Dx())
+		contentObj, imageObj := 4+i*3, 5+i*3
+
+		beginObj()
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Contents %d 0 R /Resources << /XObject << /Im0 %d 0 R >> >> >>\nendobj\n",
+			width, height, contentObj, imageObj)
+
+		content := fmt.Sprintf("q %.2f 0 0 %.2f 0 0 cm /Im0 Do Q", width, height)
+		beginObj()
+		fmt.Fprintf(&buf, "<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(content), content)
+
+		pixels, err := deflateRGB(img)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		beginObj()
+		fmt.Fprintf(&buf, "<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n",
+			b.Dx(), b.Dy(), len(pixels))
+		buf.Write(pixels)
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes(), nil
+}
+
+// deflateRGB flattens img to 8-bit RGB rows and zlib-compresses them, the
+// layout a /FlateDecode /DeviceRGB image stream expects.
+func deflateRGB(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	var out bytes.Buffer
+	zw := zlib.NewWriter(&out)
+	row := make([]byte, 0, b.Dx()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row = row[:0]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			row = append(row, byte(r>>8), byte(g>>8), byte(bl>>8))
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,43 @@
+package assets
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.RGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestImagesToPDF(t *testing.T) {
+	pdf, err := ImagesToPDF([][]byte{testPNG(t, 16, 9), testPNG(t, 4, 3)})
+	require.NoError(t, err)
+
+	out := string(pdf)
+	assert.True(t, strings.HasPrefix(out, "%PDF-1.4"))
+	assert.Contains(t, out, "/Count 2")
+	assert.Contains(t, out, "/MediaBox [0 0 720.00 405.00]")
+	assert.Contains(t, out, "/MediaBox [0 0 720.00 540.00]")
+	assert.True(t, strings.HasSuffix(out, "%%EOF\n"))
+}
+
+func TestImagesToPDF_RejectsBadInput(t *testing.T) {
+	_, err := ImagesToPDF(nil)
+	assert.Error(t, err)
+	_, err = ImagesToPDF([][]byte{[]byte("not an image")})
+	assert.ErrorContains(t, err, "page 1")
+}
@@ -0,0 +1,407 @@
+package assets
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+)
+
+// The importer reads the OOXML parts directly rather than through gooxml,
+// which this package only uses to write decks; an import needs a few parts.
+const (
+	maxPPTXPartSize = 8 << 20
+	maxPPTXLayouts  = 50
+
+	// defaultSlideWidth and defaultSlideHeight are the 16:9 size PowerPoint
+	// uses when presentation.xml doesn't say, in EMUs.
+	defaultSlideWidth  = 12192000
+	defaultSlideHeight = 6858000
+)
+
+// PPTXImport is the template draft built from a .pptx. Warnings list what
+// couldn't be carried over so the editor can point it out.
+type PPTXImport struct {
+	Spec     spec.TemplateSpec
+	Warnings []string
+}
+
+type pptxPresentation struct {
+	SldSz struct {
+		Cx int64 `xml:"cx,attr"`
+		Cy int64 `xml:"cy,attr"`
+	} `xml:"sldSz"`
+}
+
+type pptxColor struct {
+	SrgbClr *struct {
+		Val string `xml:"val,attr"`
+	} `xml:"srgbClr"`
+	SysClr *struct {
+		LastClr string `xml:"lastClr,attr"`
+	} `xml:"sysClr"`
+}
+
+func (c pptxColor) hex() string {
+	switch {
+	case c.SrgbClr != nil && c.SrgbClr.Val != "":
+		return "#" + strings.ToUpper(c.SrgbClr.Val)
+	case c.SysClr != nil && c.SysClr.LastClr != "":
+		return "#" + strings.ToUpper(c.SysClr.LastClr)
+	}
+	return ""
+}
+
+type pptxTheme struct {
+	ClrScheme struct {
+		Dk1     pptxColor `xml:"dk1"`
+		Lt1     pptxColor `xml:"lt1"`
+		Dk2     pptxColor `xml:"dk2"`
+		Lt2     pptxColor `xml:"lt2"`
+		Accent1 pptxColor `xml:"accent1"`
+		Accent2 pptxColor `xml:"accent2"`
+		Accent3 pptxColor `xml:"accent3"`
+		Accent4 pptxColor `xml:"accent4"`
+		Accent5 pptxColor `xml:"accent5"`
+		Accent6 pptxColor `xml:"accent6"`
+	} `xml:"themeElements>clrScheme"`
+	MajorFont struct {
+		Typeface string `xml:"typeface,attr"`
+	} `xml:"themeElements>fontScheme>majorFont>latin"`
+	MinorFont struct {
+		Typeface string `xml:"typeface,attr"`
+	} `xml:"themeElements>fontScheme>minorFont>latin"`
+}
+
+type pptxXfrm struct {
+	Off struct {
+		X int64 `xml:"x,attr"`
+		Y int64 `xml:"y,attr"`
+	} `xml:"off"`
+	Ext struct {
+		Cx int64 `xml:"cx,attr"`
+		Cy int64 `xml:"cy,attr"`
+	} `xml:"ext"`
+}
+
+type pptxPh struct {
+	Type string `xml:"type,attr"`
+	Idx  string `xml:"idx,attr"`
+}
+
+type pptxShape struct {
+	CNvPr struct {
+		Name string `xml:"name,attr"`
+	} `xml:"nvSpPr>cNvPr"`
+	CNvSpPr struct {
+		TxBox string `xml:"txBox,attr"`
+	} `xml:"nvSpPr>cNvSpPr"`
+	Ph    *pptxPh   `xml:"nvSpPr>nvPr>ph"`
+	Xfrm  *pptxXfrm `xml:"spPr>xfrm"`
+	Paras []struct {
+		Runs []string `xml:"r>t"`
+	} `xml:"txBody>p"`
+}
+
+type pptxPic struct {
+	Ph   *pptxPh   `xml:"nvPicPr>nvPr>ph"`
+	Xfrm *pptxXfrm `xml:"spPr>xfrm"`
+}
+
+// pptxSlidePart covers slides, layouts and masters, which share the cSld tree.
+type pptxSlidePart struct {
+	CSld struct {
+		Name   string      `xml:"name,attr"`
+		Shapes []pptxShape `xml:"spTree>sp"`
+		Pics   []pptxPic   `xml:"spTree>pic"`
+		Groups []struct{}  `xml:"spTree>grpSp"`
+	} `xml:"cSld"`
+}
+
+func (s pptxShape) text() string {
+	var lines []string
+	for _, p := range s.Paras {
+		if line := strings.TrimSpace(strings.Join(p.Runs, "")); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// phKey identifies a placeholder across master and layout so a layout
+// placeholder without its own position can inherit the master's.
+func phKey(ph *pptxPh) string {
+	switch t := phType(ph); t {
+	case "title", "ctrTitle":
+		return "title"
+	case "body", "obj":
+		if ph.Idx != "" && ph.Idx != "1" {
+			return "body:" + ph.Idx
+		}
+		return "body"
+	default:
+		return t
+	}
+}
+
+// phType applies the OOXML default: a placeholder with no type is "obj".
+func phType(ph *pptxPh) string {
+	if ph.Type == "" {
+		return "obj"
+	}
+	return ph.Type
+}
+
+// footerPlaceholders are slide chrome, not content a template author fills.
+var footerPlaceholders = map[string]bool{"dt": true, "ftr": true, "sldNum": true, "hdr": true}
+
+// ImportPPTX converts a .pptx into a TemplateSpec draft: theme colors and
+// fonts become tokens and each slide layout becomes a layout, with positions
+// scaled to the 0-1 space the spec uses. Files without layouts fall back to
+// their slides.
+func ImportPPTX(r io.ReaderAt, size int64) (*PPTXImport, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid .pptx file: %w", err)
+	}
+	parts := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+	if parts["ppt/presentation.xml"] == nil {
+		return nil, fmt.Errorf("not a valid .pptx file: ppt/presentation.xml is missing")
+	}
+
+	out := &PPTXImport{Spec: spec.TemplateSpec{
+		Tokens:      map[string]any{},
+		Constraints: spec.Constraints{SafeMargin: 0.05},
+	}}
+
+	var pres pptxPresentation
+	if err := decodePPTXPart(parts["ppt/presentation.xml"], &pres); err != nil {
+		return nil, err
+	}
+	width, height := float64(pres.SldSz.Cx), float64(pres.SldSz.Cy)
+	if width <= 0 || height <= 0 {
+		width, height = defaultSlideWidth, defaultSlideHeight
+	}
+
+	if themes := numberedParts(parts, "ppt/theme/theme"); len(themes) > 0 {
+		var theme pptxTheme
+		if err := decodePPTXPart(parts[themes[0]], &theme); err != nil {
+			return nil, err
+		}
+		out.Spec.Tokens = themeTokens(theme)
+	} else {
+		out.Warnings = append(out.Warnings, "no theme found; colors and fonts were left empty")
+	}
+
+	inherited := map[string]*pptxXfrm{}
+	for _, name := range numberedParts(parts, "ppt/slideMasters/slideMaster") {
+		var master pptxSlidePart
+		if err := decodePPTXPart(parts[name], &master); err != nil {
+			return nil, err
+		}
+		for _, sh := range master.CSld.Shapes {
+			if sh.Ph != nil && sh.Xfrm != nil {
+				if _, ok := inherited[phKey(sh.Ph)]; !ok {
+					inherited[phKey(sh.Ph)] = sh.Xfrm
+				}
+			}
+		}
+	}
+
+	sources := numberedParts(parts, "ppt/slideLayouts/slideLayout")
+	kind := "layout"
+	if len(sources) == 0 {
+		sources, kind = numberedParts(parts, "ppt/slides/slide"), "slide"
+	}
+	if len(sources) > maxPPTXLayouts {
+		out.Warnings = append(out.Warnings, fmt.Sprintf("only the first %d of %d %ss were imported", maxPPTXLayouts, len(sources), kind))
+		sources = sources[:maxPPTXLayouts]
+	}
+
+	for i, name := range sources {
+		var part pptxSlidePart
+		if err := decodePPTXPart(parts[name], &part); err != nil {
+			return nil, err
+		}
+		layoutName := strings.TrimSpace(part.CSld.Name)
+		if layoutName == "" {
+			layoutName = fmt.Sprintf("%s %d", strings.ToUpper(kind[:1])+kind[1:], i+1)
+		}
+		layout := spec.Layout{Name: layoutName}
+		ids := map[string]int{}
+		add := func(base, typ, content string, x *pptxXfrm) {
+			ids[base]++
+			id := base
+			if n := ids[base]; n > 1 {
+				id = fmt.Sprintf("%s_%d", base, n)
+			}
+			layout.Placeholders = append(layout.Placeholders, spec.Placeholder{
+				ID: id, Type: typ, Content: content, Geometry: scaleXfrm(x, width, height),
+			})
+		}
+
+		for _, sh := range part.CSld.Shapes {
+			xfrm := sh.Xfrm
+			switch {
+			case sh.Ph != nil:
+				if footerPlaceholders[phType(sh.Ph)] {
+					continue
+				}
+				if xfrm == nil {
+					xfrm = inherited[phKey(sh.Ph)]
+				}
+				if xfrm == nil {
+					out.Warnings = append(out.Warnings, fmt.Sprintf("%s: placeholder %q has no position and was skipped", layoutName, sh.CNvPr.Name))
+					continue
+				}
+				typ := "text"
+				if phType(sh.Ph) == "pic" {
+					typ = "image"
+				}
+				// Placeholder text in a layout is PowerPoint's prompt ("Click
+				// to edit..."), so only slides keep theirs.
+				content := ""
+				if kind == "slide" {
+					content = sh.text()
+				}
+				add(placeholderID(phType(sh.Ph)), typ, content, xfrm)
+			case sh.CNvSpPr.TxBox == "1" || sh.text() != "":
+				if xfrm == nil {
+					continue
+				}
+				add("text", "text", sh.text(), xfrm)
+			}
+		}
+		for _, pic := range part.CSld.Pics {
+			xfrm := pic.Xfrm
+			if xfrm == nil && pic.Ph != nil {
+				xfrm = inherited[phKey(pic.Ph)]
+			}
+			if xfrm != nil {
+				add("image", "image", "", xfrm)
+			}
+		}
+		if len(part.CSld.Groups) > 0 {
+			out.Warnings = append(out.Warnings, fmt.Sprintf("%s: %d grouped shapes were not imported", layoutName, len(part.CSld.Groups)))
+		}
+		if len(layout.Placeholders) == 0 {
+			out.Warnings = append(out.Warnings, fmt.Sprintf("%s: no text boxes or placeholders found", layoutName))
+		}
+		out.Spec.Layouts = append(out.Spec.Layouts, layout)
+	}
+
+	if len(out.Spec.Layouts) == 0 {
+		return nil, fmt.Errorf("no slide layouts or slides found")
+	}
+	return out, nil
+}
+
+// themeTokens maps the theme's color scheme onto the token names the
+// renderer and AI prompts use, keeping the full scheme alongside.
+func themeTokens(t pptxTheme) map[string]any {
+	cs := t.ClrScheme
+	scheme := map[string]any{}
+	for k, c := range map[string]pptxColor{
+		"dk1": cs.Dk1, "lt1": cs.Lt1, "dk2": cs.Dk2, "lt2": cs.Lt2,
+		"accent1": cs.Accent1, "accent2": cs.Accent2, "accent3": cs.Accent3,
+		"accent4": cs.Accent4, "accent5": cs.Accent5, "accent6": cs.Accent6,
+	} {
+		if h := c.hex(); h != "" {
+			scheme[k] = h
+		}
+	}
+	colors := map[string]any{}
+	for token, key := range map[string]string{"primary": "accent1", "accent": "accent2", "background": "lt1", "text": "dk1"} {
+		if v, ok := scheme[key]; ok {
+			colors[token] = v
+		}
+	}
+	fonts := map[string]any{}
+	if t.MajorFont.Typeface != "" {
+		fonts["heading"] = t.MajorFont.Typeface
+	}
+	if t.MinorFont.Typeface != "" {
+		fonts["body"] = t.MinorFont.Typeface
+	}
+	return map[string]any{
+		"colors":      colors,
+		"fonts":       fonts,
+		"themeColors": scheme,
+		"logos":       []any{},
+		"images":      []any{},
+	}
+}
+
+func placeholderID(phType string) string {
+	switch phType {
+	case "title", "ctrTitle":
+		return "title"
+	case "subTitle":
+		return "subtitle"
+	case "pic":
+		return "image"
+	case "body", "obj":
+		return "body"
+	default:
+		return phType
+	}
+}
+
+func scaleXfrm(x *pptxXfrm, width, height float64) spec.Geometry {
+	clamp := func(v float64) float64 {
+		return min(max(v, 0), 1)
+	}
+	return spec.Geometry{
+		X: clamp(float64(x.Off.X) / width),
+		Y: clamp(float64(x.Off.Y) / height),
+		W: clamp(float64(x.Ext.Cx) / width),
+		H: clamp(float64(x.Ext.Cy) / height),
+	}
+}
+
+// numberedParts returns the parts named prefix<N>.xml ordered by N, so
+// slideLayout10 sorts after slideLayout2.
+func numberedParts(parts map[string]*zip.File, prefix string) []string {
+	type numbered struct {
+		name string
+		n    int
+	}
+	var found []numbered
+	for name := range parts {
+		if !strings.HasPrefix(name, prefix) || path.Ext(name) != ".xml" {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".xml"))
+		if err != nil {
+			continue
+		}
+		found = append(found, numbered{name, n})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].n < found[j].n })
+	names := make([]string, len(found))
+	for i, f := range found {
+		names[i] = f.name
+	}
+	return names
+}
+
+func decodePPTXPart(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, maxPPTXPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("parse %s: %w", f.Name, err)
+	}
+	return nil
+}
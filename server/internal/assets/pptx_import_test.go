@@ -0,0 +1,120 @@
+package assets
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	pptxNS = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"`
+
+	testPresentationXML = `<p:presentation ` + pptxNS + `><p:sldSz cx="10000000" cy="5000000"/></p:presentation>`
+
+	testThemeXML = `<a:theme ` + pptxNS + `><a:themeElements>
+<a:clrScheme name="Corp">
+<a:dk1><a:sysClr val="windowText" lastClr="000000"/></a:dk1>
+<a:lt1><a:srgbClr val="ffffff"/></a:lt1>
+<a:accent1><a:srgbClr val="1F4E79"/></a:accent1>
+<a:accent2><a:srgbClr val="ED7D31"/></a:accent2>
+</a:clrScheme>
+<a:fontScheme name="Corp"><a:majorFont><a:latin typeface="Georgia"/></a:majorFont><a:minorFont><a:latin typeface="Verdana"/></a:minorFont></a:fontScheme>
+</a:themeElements></a:theme>`
+
+	testMasterXML = `<p:sldMaster ` + pptxNS + `><p:cSld><p:spTree>
+<p:sp><p:nvSpPr><p:cNvPr id="2" name="Title"/><p:cNvSpPr/><p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr>
+<p:spPr><a:xfrm><a:off x="500000" y="250000"/><a:ext cx="9000000" cy="1000000"/></a:xfrm></p:spPr></p:sp>
+</p:spTree></p:cSld></p:sldMaster>`
+
+	testLayoutXML = `<p:sldLayout ` + pptxNS + `><p:cSld name="Title and Content"><p:spTree>
+<p:sp><p:nvSpPr><p:cNvPr id="2" name="Title 1"/><p:cNvSpPr/><p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr><p:spPr/>
+<p:txBody><a:p><a:r><a:t>Click to edit Master title style</a:t></a:r></a:p></p:txBody></p:sp>
+<p:sp><p:nvSpPr><p:cNvPr id="3" name="Content 2"/><p:cNvSpPr/><p:nvPr><p:ph idx="1"/></p:nvPr></p:nvSpPr>
+<p:spPr><a:xfrm><a:off x="500000" y="1500000"/><a:ext cx="9000000" cy="3000000"/></a:xfrm></p:spPr></p:sp>
+<p:sp><p:nvSpPr><p:cNvPr id="4" name="Footer"/><p:cNvSpPr/><p:nvPr><p:ph type="ftr"/></p:nvPr></p:nvSpPr>
+<p:spPr><a:xfrm><a:off x="0" y="4700000"/><a:ext cx="1000000" cy="200000"/></a:xfrm></p:spPr></p:sp>
+<p:sp><p:nvSpPr><p:cNvPr id="5" name="TextBox 4"/><p:cNvSpPr txBox="1"/><p:nvPr/></p:nvSpPr>
+<p:spPr><a:xfrm><a:off x="8000000" y="4600000"/><a:ext cx="1500000" cy="300000"/></a:xfrm></p:spPr>
+<p:txBody><a:p><a:r><a:t>Confidential</a:t></a:r></a:p></p:txBody></p:sp>
+</p:spTree></p:cSld></p:sldLayout>`
+)
+
+func buildTestPPTX(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range parts {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestImportPPTX_LayoutsThemeAndTextBoxes(t *testing.T) {
+	data := buildTestPPTX(t, map[string]string{
+		"ppt/presentation.xml":              testPresentationXML,
+		"ppt/theme/theme1.xml":              testThemeXML,
+		"ppt/slideMasters/slideMaster1.xml": testMasterXML,
+		"ppt/slideLayouts/slideLayout1.xml": testLayoutXML,
+	})
+
+	imp, err := ImportPPTX(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	colors := imp.Spec.Tokens["colors"].(map[string]any)
+	assert.Equal(t, "#1F4E79", colors["primary"])
+	assert.Equal(t, "#ED7D31", colors["accent"])
+	assert.Equal(t, "#FFFFFF", colors["background"])
+	assert.Equal(t, "#000000", colors["text"])
+	fonts := imp.Spec.Tokens["fonts"].(map[string]any)
+	assert.Equal(t, "Georgia", fonts["heading"])
+	assert.Equal(t, "Verdana", fonts["body"])
+
+	require.Len(t, imp.Spec.Layouts, 1)
+	layout := imp.Spec.Layouts[0]
+	assert.Equal(t, "Title and Content", layout.Name)
+	require.Len(t, layout.Placeholders, 3)
+
+	title := layout.Placeholders[0]
+	assert.Equal(t, "title", title.ID)
+	assert.Empty(t, title.Content, "layout prompt text is dropped")
+	assert.InDelta(t, 0.05, title.Geometry.X, 1e-9, "inherited from the master")
+	assert.InDelta(t, 0.2, title.Geometry.H, 1e-9)
+
+	body := layout.Placeholders[1]
+	assert.Equal(t, "body", body.ID)
+	assert.InDelta(t, 0.3, body.Geometry.Y, 1e-9)
+
+	box := layout.Placeholders[2]
+	assert.Equal(t, "text", box.ID)
+	assert.Equal(t, "Confidential", box.Content)
+}
+
+func TestImportPPTX_FallsBackToSlides(t *testing.T) {
+	data := buildTestPPTX(t, map[string]string{
+		"ppt/presentation.xml":   testPresentationXML,
+		"ppt/slides/slide2.xml":  `<p:sld ` + pptxNS + `><p:cSld><p:spTree/></p:cSld></p:sld>`,
+		"ppt/slides/slide10.xml": `<p:sld ` + pptxNS + `><p:cSld><p:spTree/></p:cSld></p:sld>`,
+	})
+
+	imp, err := ImportPPTX(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Len(t, imp.Spec.Layouts, 2)
+	assert.Equal(t, "Slide 1", imp.Spec.Layouts[0].Name)
+	assert.Contains(t, imp.Warnings, "no theme found; colors and fonts were left empty")
+}
+
+func TestImportPPTX_RejectsNonPPTX(t *testing.T) {
+	_, err := ImportPPTX(bytes.NewReader([]byte("not a zip")), 9)
+	assert.ErrorContains(t, err, "not a valid .pptx")
+
+	data := buildTestPPTX(t, map[string]string{"word/document.xml": "<w:document/>"})
+	_, err = ImportPPTX(bytes.NewReader(data), int64(len(data)))
+	assert.ErrorContains(t, err, "presentation.xml is missing")
+}
@@ -0,0 +1,43 @@
+package assets
+
+import "github.com/ziyad/cms-ai/server/internal/store"
+
+// QualityRenderers holds a renderer per export quality profile. Profiles
+// left nil use Standard. Factory, when set, builds the renderers for exports
+// that name an engine.
+type QualityRenderers struct {
+	Draft    Renderer
+	Standard Renderer
+	High     Renderer
+	Factory  *RendererFactory
+}
+
+// For returns the renderer for quality, one of the store.ExportQuality*
+// profiles; unknown or empty profiles get Standard.
+func (q QualityRenderers) For(quality string) Renderer {
+	switch {
+	case quality == store.ExportQualityDraft && q.Draft != nil:
+		return q.Draft
+	case quality == store.ExportQualityHigh && q.High != nil:
+		return q.High
+	default:
+		return q.Standard
+	}
+}
+
+// Pick returns the renderer for an export: a new one of engine when the
+// export names one and there is a Factory, otherwise For(quality).
+func (q QualityRenderers) Pick(quality, engine string) (Renderer, error) {
+	if engine == "" || q.Factory == nil {
+		return q.For(quality), nil
+	}
+	return q.Factory.New(engine)
+}
+
+// NewDraftRenderer returns the Go renderer with AI design analysis turned
+// off: it needs no Python and makes no model calls, so it is the fastest.
+func NewDraftRenderer() *GoPPTXRenderer {
+	r := NewGoPPTXRenderer()
+	r.SkipDesignAnalysis = true
+	return r
+}
@@ -0,0 +1,168 @@
+package assets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+)
+
+const (
+	defaultRemoteAttempts   = 3
+	defaultRemoteRetryDelay = 500 * time.Millisecond
+)
+
+// RemoteRenderer renders through a render service (see RenderServiceHandler)
+// so rendering can be scaled apart from the API. Failed calls are retried
+// when the service is unreachable, overloaded or answers 5xx.
+type RemoteRenderer struct {
+	// BaseURL is the render service root, e.g. http://renderer:8090.
+	BaseURL string
+	// Token is sent as a bearer token; empty sends none.
+	Token      string
+	HTTPClient *http.Client
+	// Attempts is how many times a call is tried; zero means 3.
+	Attempts int
+	// RetryDelay is the wait before the first retry, doubled each time;
+	// zero means 500ms.
+	RetryDelay time.Duration
+}
+
+// NewRemoteRenderer returns a renderer for the service at baseURL. Renders
+// can take minutes, so the client has no overall timeout; callers bound
+// them with their context.
+func NewRemoteRenderer(baseURL, token string) *RemoteRenderer {
+	return &RemoteRenderer{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{},
+	}
+}
+
+// errPermanent marks a render service failure that retrying won't fix.
+type errPermanent struct{ err error }
+
+func (e errPermanent) Error() string { return e.err.Error() }
+func (e errPermanent) Unwrap() error { return e.err }
+
+// RenderPPTX streams the rendered file to outPath.
+func (r *RemoteRenderer) RenderPPTX(ctx context.Context, spec any, outPath string) error {
+	body, err := normalize.JSON(spec)
+	if err != nil {
+		return fmt.Errorf("marshal spec: %w", err)
+	}
+	err = r.call(ctx, renderPath, body, func(resp io.Reader) error {
+		f, err := os.Create(outPath)
+		if err != nil {
+			return errPermanent{err}
+		}
+		if _, err := io.Copy(f, resp); err != nil {
+			f.Close()
+			return fmt.Errorf("download pptx: %w", err)
+		}
+		return f.Close()
+	})
+	if err != nil {
+		os.Remove(outPath)
+	}
+	return err
+}
+
+// RenderPPTXBytes renders to memory.
+func (r *RemoteRenderer) RenderPPTXBytes(ctx context.Context, spec any) ([]byte, error) {
+	body, err := normalize.JSON(spec)
+	if err != nil {
+		return nil, fmt.Errorf("marshal spec: %w", err)
+	}
+	var out []byte
+	err = r.call(ctx, renderPath, body, func(resp io.Reader) error {
+		out, err = io.ReadAll(resp)
+		if err != nil {
+			return fmt.Errorf("download pptx: %w", err)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// GenerateSlideThumbnails returns one PNG per slide.
+func (r *RemoteRenderer) GenerateSlideThumbnails(ctx context.Context, spec any) ([][]byte, error) {
+	body, err := normalize.JSON(spec)
+	if err != nil {
+		return nil, fmt.Errorf("marshal spec: %w", err)
+	}
+	var out thumbnailsResponse
+	err = r.call(ctx, thumbnailsPath, body, func(resp io.Reader) error {
+		if err := json.NewDecoder(resp).Decode(&out); err != nil {
+			return fmt.Errorf("decode thumbnails: %w", err)
+		}
+		return nil
+	})
+	return out.Thumbnails, err
+}
+
+// call POSTs body to path and hands a successful response to read, retrying
+// the whole exchange when it fails transiently.
+func (r *RemoteRenderer) call(ctx context.Context, path string, body []byte, read func(io.Reader) error) error {
+	attempts := r.Attempts
+	if attempts <= 0 {
+		attempts = defaultRemoteAttempts
+	}
+	delay := r.RetryDelay
+	if delay <= 0 {
+		delay = defaultRemoteRetryDelay
+	}
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = r.do(ctx, path, body, read)
+		var perm errPermanent
+		if err == nil || errors.As(err, &perm) || ctx.Err() != nil || attempt >= attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	var perm errPermanent
+	if errors.As(err, &perm) {
+		return perm.err
+	}
+	return err
+}
+
+func (r *RemoteRenderer) do(ctx context.Context, path string, body []byte, read func(io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return errPermanent{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+	resp, err := r.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("render service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err := fmt.Errorf("render service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+		if resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented) {
+			return err
+		}
+		return errPermanent{err}
+	}
+	return read(resp.Body)
+}
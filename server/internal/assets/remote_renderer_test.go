@@ -0,0 +1,120 @@
+package assets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+)
+
+// echoRenderer "renders" a spec by writing it back.
+type echoRenderer struct{}
+
+func (echoRenderer) RenderPPTX(ctx context.Context, spec any, outPath string) error {
+	b, err := normalize.JSON(spec)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, b, 0o644)
+}
+
+func (echoRenderer) RenderPPTXBytes(ctx context.Context, spec any) ([]byte, error) {
+	return normalize.JSON(spec)
+}
+
+func (echoRenderer) GenerateSlideThumbnails(ctx context.Context, spec any) ([][]byte, error) {
+	return [][]byte{[]byte("png-1"), []byte("png-2")}, nil
+}
+
+func TestRemoteRenderer_RoundTrip(t *testing.T) {
+	srv := httptest.NewServer(RenderServiceHandler(echoRenderer{}, "secret"))
+	defer srv.Close()
+	r := NewRemoteRenderer(srv.URL+"/", "secret")
+	ctx := context.Background()
+	spec := []byte(`{"layouts":[]}`)
+
+	out := filepath.Join(t.TempDir(), "deck.pptx")
+	require.NoError(t, r.RenderPPTX(ctx, spec, out))
+	got, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(spec), string(got))
+
+	got, err = r.RenderPPTXBytes(ctx, string(spec))
+	require.NoError(t, err)
+	assert.JSONEq(t, string(spec), string(got))
+
+	thumbs, err := r.GenerateSlideThumbnails(ctx, spec)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("png-1"), []byte("png-2")}, thumbs)
+
+	_, err = NewRemoteRenderer(srv.URL, "wrong").RenderPPTXBytes(ctx, spec)
+	assert.ErrorContains(t, err, "401")
+}
+
+// brokenRenderer fails the way a renderer with a bad script path does.
+type brokenRenderer struct{ echoRenderer }
+
+func (brokenRenderer) RenderPPTX(ctx context.Context, spec any, outPath string) error {
+	return errors.New("exec /app/tools/renderer/render_pptx.py: no such file")
+}
+
+func (brokenRenderer) GenerateSlideThumbnails(ctx context.Context, spec any) ([][]byte, error) {
+	return nil, errors.New("exec /app/tools/renderer/thumbnails.py: no such file")
+}
+
+func TestRenderServiceHandler_HidesRendererErrors(t *testing.T) {
+	srv := httptest.NewServer(RenderServiceHandler(brokenRenderer{}, "secret"))
+	defer srv.Close()
+	r := NewRemoteRenderer(srv.URL, "secret")
+
+	_, err := r.RenderPPTXBytes(context.Background(), []byte(`{"layouts":[]}`))
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "render failed")
+	assert.NotContains(t, err.Error(), "/app/tools")
+
+	_, err = r.GenerateSlideThumbnails(context.Background(), []byte(`{"layouts":[]}`))
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "/app/tools")
+}
+
+func TestRemoteRenderer_Retries(t *testing.T) {
+	var calls atomic.Int32
+	handler := RenderServiceHandler(echoRenderer{}, "")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	}))
+	defer srv.Close()
+	r := NewRemoteRenderer(srv.URL, "")
+	r.RetryDelay = 1
+
+	got, err := r.RenderPPTXBytes(context.Background(), []byte(`{"a":1}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":1}`, string(got))
+	assert.Equal(t, int32(3), calls.Load())
+
+	calls.Store(-10)
+	_, err = r.RenderPPTXBytes(context.Background(), []byte(`{"a":1}`))
+	assert.ErrorContains(t, err, "503")
+	assert.Equal(t, int32(-7), calls.Load(), "gives up after three attempts")
+
+	calls.Store(10)
+	r.BaseURL = srv.URL + "/missing"
+	out := filepath.Join(t.TempDir(), "deck.pptx")
+	err = r.RenderPPTX(context.Background(), []byte(`{"a":1}`), out)
+	assert.ErrorContains(t, err, "404")
+	assert.Equal(t, int32(11), calls.Load(), "client errors aren't retried")
+	assert.NoFileExists(t, out)
+}
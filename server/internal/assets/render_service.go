@@ -0,0 +1,106 @@
+package assets
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+)
+
+// Render service routes, shared by RemoteRenderer and RenderServiceHandler.
+const (
+	renderPath     = "/v1/render"
+	thumbnailsPath = "/v1/thumbnails"
+)
+
+// maxRenderSpecBytes caps the spec a render service accepts.
+const maxRenderSpecBytes = 10 << 20
+
+type thumbnailsResponse struct {
+	Thumbnails [][]byte `json:"thumbnails"`
+}
+
+// RenderServiceHandler serves r to RemoteRenderer clients: POST /v1/render
+// takes a spec and streams back the PPTX, POST /v1/thumbnails returns the
+// slide images, and GET /healthz reports liveness. When token is set,
+// requests must carry it as a bearer token.
+func RenderServiceHandler(r Renderer, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("POST "+renderPath, func(w http.ResponseWriter, req *http.Request) {
+		spec, ok := readRenderSpec(w, req)
+		if !ok {
+			return
+		}
+		f, err := os.CreateTemp("", "render-*.pptx")
+		if err != nil {
+			http.Error(w, "failed to create output file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		if err := r.RenderPPTX(req.Context(), spec, f.Name()); err != nil {
+			logger.Logger.Error("render_service_render_failed", "component", "renderer", "error", err)
+			http.Error(w, "render failed", http.StatusUnprocessableEntity)
+			return
+		}
+		info, err := f.Stat()
+		if err != nil {
+			http.Error(w, "failed to read output file", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.presentationml.presentation")
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+		if _, err := io.Copy(w, f); err != nil {
+			logger.Logger.Warn("render_service_stream_failed", "component", "renderer", "error", err)
+		}
+	})
+	mux.HandleFunc("POST "+thumbnailsPath, func(w http.ResponseWriter, req *http.Request) {
+		spec, ok := readRenderSpec(w, req)
+		if !ok {
+			return
+		}
+		thumbs, err := r.GenerateSlideThumbnails(req.Context(), spec)
+		if err != nil {
+			logger.Logger.Error("render_service_thumbnails_failed", "component", "renderer", "error", err)
+			http.Error(w, "thumbnails failed", http.StatusUnprocessableEntity)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(thumbnailsResponse{Thumbnails: thumbs})
+	})
+	if token == "" {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/healthz" {
+			got, _ := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		mux.ServeHTTP(w, req)
+	})
+}
+
+func readRenderSpec(w http.ResponseWriter, req *http.Request) (json.RawMessage, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxRenderSpecBytes))
+	if err != nil {
+		http.Error(w, "spec too large or unreadable", http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	if !json.Valid(body) {
+		http.Error(w, "spec must be JSON", http.StatusBadRequest)
+		return nil, false
+	}
+	return json.RawMessage(body), true
+}
@@ -3,7 +3,6 @@ package assets
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -18,6 +17,8 @@ import (
 
 	"baliance.com/gooxml/measurement"
 	"baliance.com/gooxml/presentation"
+
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
 )
 
 type Renderer interface {
@@ -26,94 +27,6 @@ type Renderer interface {
 	GenerateSlideThumbnails(ctx context.Context, spec any) ([][]byte, error)
 }
 
-func specToJSONBytes(spec any) ([]byte, error) {
-	// SpecJSON (type any) arrives in different Go types depending on the source:
-	//   []byte:          from in-memory store or manual construction
-	//   json.RawMessage: from explicit JSON handling
-	//   string:          from PostgreSQL jsonb via pgx/GORM
-	//   map/slice/etc:   from Go code constructing specs directly
-	//
-	// CRITICAL: When GORM writes []byte to a jsonb column, it calls
-	// json.Marshal([]byte) which base64-encodes the data. The jsonb column
-	// then stores a JSON string "eyJ0b2..." (base64). When pgx reads it
-	// back into `any`, it returns a Go string containing:
-	//   - base64 (if GORM wrote []byte)
-	//   - raw JSON (if GORM wrote map/struct)
-	//   - quoted base64 (if raw jsonb text is returned)
-	//
-	// We must detect and decode base64, and unwrap quoted JSON strings.
-	var b []byte
-	switch v := spec.(type) {
-	case []byte:
-		b = v
-	case json.RawMessage:
-		b = []byte(v)
-	case string:
-		b = []byte(v)
-	default:
-		return json.Marshal(spec)
-	}
-
-	result := NormalizeJSONBytes(b)
-	log.Printf("[specToJSONBytes] input type=%T len=%d first50=%q → output len=%d first50=%q",
-		spec, len(b), truncate(b, 50), len(result), truncate(result, 50))
-	return result, nil
-}
-
-func truncate(b []byte, n int) string {
-	if len(b) <= n {
-		return string(b)
-	}
-	return string(b[:n]) + "..."
-}
-
-// NormalizeJSONBytes ensures the bytes contain a raw JSON object/array,
-// not a quoted string or base64-encoded JSON.
-func NormalizeJSONBytes(b []byte) []byte {
-	b = bytes.TrimSpace(b)
-	if len(b) == 0 {
-		return b
-	}
-
-	// Already a JSON object or array → done.
-	if b[0] == '{' || b[0] == '[' {
-		return b
-	}
-
-	// Quoted JSON string: "eyJ0b2..." or "{\"layouts\":...}"
-	// Unwrap the JSON string first.
-	if b[0] == '"' {
-		var unwrapped string
-		if err := json.Unmarshal(b, &unwrapped); err == nil {
-			b = []byte(unwrapped)
-			// After unwrapping, check if it's now a JSON object
-			if len(b) > 0 && (b[0] == '{' || b[0] == '[') {
-				return b
-			}
-			// Otherwise fall through to base64 decode
-		}
-	}
-
-	// Try base64 decode — try multiple encodings for robustness.
-	// json.Marshal uses StdEncoding, but padding might be stripped.
-	for _, enc := range []*base64.Encoding{
-		base64.StdEncoding,
-		base64.RawStdEncoding,    // no padding
-		base64.URLEncoding,       // URL-safe with padding
-		base64.RawURLEncoding,    // URL-safe no padding
-	} {
-		decoded, err := enc.DecodeString(string(b))
-		if err == nil && len(decoded) > 0 && (decoded[0] == '{' || decoded[0] == '[') {
-			log.Printf("[NormalizeJSONBytes] base64 decoded OK (len %d → %d)", len(b), len(decoded))
-			return decoded
-		}
-	}
-
-	log.Printf("[NormalizeJSONBytes] WARN: could not normalize, first50=%q", truncate(b, 50))
-	// Return as-is if nothing worked.
-	return b
-}
-
 type PythonPPTXRenderer struct {
 	PythonPath         string
 	ScriptPath         string
@@ -236,7 +149,7 @@ func (r PythonPPTXRenderer) RenderPPTXWithCompany(ctx context.Context, spec any,
 	defer os.Remove(tmpSpec.Name())
 	defer tmpSpec.Close()
 
-	b, err := specToJSONBytes(spec)
+	b, err := normalize.JSON(spec)
 	if err != nil {
 		return err
 	}
@@ -335,7 +248,7 @@ func (r PythonPPTXRenderer) RenderPPTXBytes(ctx context.Context, spec any) ([]by
 // GenerateSlideThumbnails creates preview thumbnails for each slide
 // For Python renderer, this returns placeholder thumbnails
 func (r PythonPPTXRenderer) GenerateSlideThumbnails(ctx context.Context, spec any) ([][]byte, error) {
-	specBytes, err := specToJSONBytes(spec)
+	specBytes, err := normalize.JSON(spec)
 	if err != nil {
 		return nil, err
 	}
@@ -397,6 +310,17 @@ type GoPPTXRenderer struct {
 	visualEnhancer        *VisualEnhancementRenderer
 	typographySystem      *AdvancedTypographySystem
 	templateLibrary       *DesignTemplateLibrary
+
+	// SkipDesignAnalysis renders with the default theme instead of asking
+	// the AI analyzers for one, for draft exports.
+	SkipDesignAnalysis bool
+	// Deterministic makes identical specs render to identical bytes: the
+	// theme comes from the local keyword analyzer seeded by the spec, with
+	// no model calls, and the PPTX is written in a canonical form.
+	Deterministic bool
+	// Offline keeps design analysis on this host: the olama bridge, which
+	// calls Hugging Face, is skipped for the local analyzer.
+	Offline bool
 }
 
 func NewGoPPTXRenderer() *GoPPTXRenderer {
@@ -427,7 +351,7 @@ func (r GoPPTXRenderer) RenderPPTX(ctx context.Context, spec any, outPath string
 
 func (r GoPPTXRenderer) RenderPPTXBytes(ctx context.Context, spec any) ([]byte, error) {
 	// Parse the template spec
-	specBytes, err := specToJSONBytes(spec)
+	specBytes, err := normalize.JSON(spec)
 	if err != nil {
 		return nil, err
 	}
@@ -477,8 +401,12 @@ func (r GoPPTXRenderer) RenderPPTXBytes(ctx context.Context, spec any) ([]byte,
 	var designIdentity *DesignIdentity
 	var aiErr error
 
-	// Try olama AI first (if HUGGINGFACE_API_KEY is available)
-	if r.olamaAI.IsAvailable() && os.Getenv("HUGGINGFACE_API_KEY") != "" {
+	if r.SkipDesignAnalysis {
+		designIdentity = &DesignIdentity{Industry: "Corporate/Consulting"}
+	} else if r.Deterministic {
+		designIdentity, aiErr = r.aiDesignAnalyzer.AnalyzeContentForDesignSeeded(jsonData, companyInfo, specSeed(specBytes))
+	} else if !r.Offline && r.olamaAI.IsAvailable() && r.olamaAI.APIKey != "" {
+		// Try olama AI first (if HUGGINGFACE_API_KEY is available)
 		designIdentity, aiErr = r.olamaAI.AnalyzeContentForDesign(jsonData, companyInfo)
 		if aiErr != nil {
 			// Log the error but fall back to the regular AI analyzer
@@ -503,6 +431,9 @@ func (r GoPPTXRenderer) RenderPPTXBytes(ctx context.Context, spec any) ([]byte,
 		// Extract title and content for smart analysis
 		var title, content string
 		for _, ph := range layout.Placeholders {
+			if ph.Type == "image" {
+				continue
+			}
 			if strings.Contains(strings.ToLower(ph.ID), "title") {
 				title = ph.Content
 			} else {
@@ -576,6 +507,9 @@ func (r GoPPTXRenderer) RenderPPTXBytes(ctx context.Context, spec any) ([]byte,
 	// Clean up temp file
 	os.Remove(tmpPath)
 
+	if r.Deterministic {
+		return canonicalZip(data)
+	}
 	return data, nil
 }
 
@@ -710,7 +644,7 @@ func (r GoPPTXRenderer) parseColor(hexColor string) color.RGBA {
 // GenerateSlideThumbnails creates preview thumbnails for each slide
 func (r GoPPTXRenderer) GenerateSlideThumbnails(ctx context.Context, spec any) ([][]byte, error) {
 	// Parse the template spec
-	specBytes, err := specToJSONBytes(spec)
+	specBytes, err := normalize.JSON(spec)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,67 @@
+package assets
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// RendererVersion identifies what the renderers produce. Exports are cached
+// under it, so bump it whenever a renderer change alters the files it makes.
+const RendererVersion = "1"
+
+// Renderer engines an org default or an export request can name.
+const (
+	RendererGo     = "go"
+	RendererPython = "python"
+	RendererAI     = "ai"
+	RendererRemote = "remote"
+)
+
+// RendererEngines lists the engines RendererFactory can build.
+var RendererEngines = []string{RendererGo, RendererPython, RendererAI, RendererRemote}
+
+// IsRendererEngine reports whether name is one of RendererEngines.
+func IsRendererEngine(name string) bool {
+	return slices.Contains(RendererEngines, name)
+}
+
+// RendererFactory builds a renderer per job, so the engine can follow the
+// org's default or the export request rather than being fixed at startup.
+type RendererFactory struct {
+	// Store backs the AI-enhanced renderer's company lookups.
+	Store store.Store
+	// HuggingFaceAPIKey is passed to the Python and AI-enhanced renderers.
+	HuggingFaceAPIKey string
+	// Remote is the render service client; nil means no service is
+	// deployed.
+	Remote *RemoteRenderer
+	// Offline restricts the factory to the Go renderer, in offline mode:
+	// the other engines need Python, Hugging Face or a render service.
+	Offline bool
+}
+
+// New instantiates the named engine.
+func (f *RendererFactory) New(engine string) (Renderer, error) {
+	if f.Offline && engine != RendererGo && IsRendererEngine(engine) {
+		return nil, fmt.Errorf("renderer %q is unavailable in offline mode, only %q is", engine, RendererGo)
+	}
+	switch engine {
+	case RendererGo:
+		r := NewGoPPTXRenderer()
+		r.Offline = f.Offline
+		return r, nil
+	case RendererPython:
+		return NewPythonPPTXRenderer(f.HuggingFaceAPIKey), nil
+	case RendererAI:
+		return NewAIEnhancedRenderer(f.Store, f.HuggingFaceAPIKey), nil
+	case RendererRemote:
+		if f.Remote == nil {
+			return nil, fmt.Errorf("remote renderer is not configured")
+		}
+		return f.Remote, nil
+	default:
+		return nil, fmt.Errorf("unknown renderer %q", engine)
+	}
+}
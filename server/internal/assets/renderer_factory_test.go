@@ -0,0 +1,25 @@
+package assets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRendererFactory_OfflineOnlyBuildsGoRenderer(t *testing.T) {
+	f := &RendererFactory{Offline: true, Remote: NewRemoteRenderer("http://render.internal", "")}
+
+	r, err := f.New(RendererGo)
+	require.NoError(t, err)
+	goRenderer, ok := r.(*GoPPTXRenderer)
+	require.True(t, ok)
+	assert.True(t, goRenderer.Offline)
+
+	for _, engine := range []string{RendererPython, RendererAI, RendererRemote} {
+		_, err := f.New(engine)
+		assert.ErrorContains(t, err, "offline mode", engine)
+	}
+	_, err = f.New("nope")
+	assert.ErrorContains(t, err, "unknown renderer")
+}
@@ -2,11 +2,9 @@ package assets
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
-	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -105,73 +103,6 @@ func TestGoPPTXRenderer_RenderPPTXBytes_WithJSONBytes(t *testing.T) {
 	assert.Equal(t, []byte{0x50, 0x4B, 0x03, 0x04}, data[:4])
 }
 
-func TestSpecToJSONBytes_string_from_pgx(t *testing.T) {
-	// CRITICAL: When GORM reads a jsonb column via pgx, SpecJSON (type any)
-	// comes back as a Go string. specToJSONBytes must handle this without
-	// double-encoding. json.Marshal(string) wraps it in quotes, producing
-	// `"{\"layouts\":...}"` which is NOT a JSON object — it's a JSON string.
-	// This causes: AttributeError: 'str' object has no attribute 'get'
-	jsonStr := `{"layouts":[{"name":"title"}]}`
-
-	result, err := specToJSONBytes(jsonStr)
-	require.NoError(t, err)
-
-	// Must get raw JSON bytes back, NOT a quoted string
-	assert.Equal(t, jsonStr, string(result), "string spec must pass through as-is, not double-encoded")
-	assert.Equal(t, byte('{'), result[0], "must start with { not quote")
-}
-
-// TDD RED: Simulates the ACTUAL production bug.
-// When DeckVersion.SpecJSON is []byte, GORM writes to jsonb via json.Marshal([]byte)
-// which base64-encodes it. So jsonb stores a JSON string: "eyJ0b2...".
-// When pgx reads it back, SpecJSON (type any) is Go string: "eyJ0b2..." (base64).
-// specToJSONBytes must detect base64 and decode it to raw JSON.
-func TestSpecToJSONBytes_base64_from_pgx_roundtrip(t *testing.T) {
-	// 1. Original spec as []byte (what processBindJob creates)
-	originalJSON := `{"layouts":[{"name":"title","placeholders":[{"id":"t","type":"text","content":"Hello"}]}]}`
-
-	// 2. GORM writes []byte to jsonb → json.Marshal([]byte) → base64
-	base64Encoded, err := json.Marshal([]byte(originalJSON))
-	require.NoError(t, err)
-	// base64Encoded is: "eyJsYXlvdXRzIj..."  (a JSON string containing base64)
-
-	// 3. pgx reads jsonb string → Go string (unwraps JSON string quotes)
-	var pgxValue string
-	err = json.Unmarshal(base64Encoded, &pgxValue)
-	require.NoError(t, err)
-	// pgxValue is: eyJsYXlvdXRzIj...  (base64 without quotes)
-
-	// 4. specToJSONBytes must handle this and return raw JSON
-	result, err := specToJSONBytes(pgxValue)
-	require.NoError(t, err)
-	assert.Equal(t, byte('{'), result[0], "must decode base64 to JSON object, got: %s", string(result[:50]))
-	assert.JSONEq(t, originalJSON, string(result))
-}
-
-// Same bug but the string arrives WITH JSON quotes (raw jsonb text)
-func TestSpecToJSONBytes_quoted_base64_string(t *testing.T) {
-	originalJSON := `{"layouts":[{"name":"title"}]}`
-	base64Str, _ := json.Marshal([]byte(originalJSON))
-	// base64Str is: "eyJsYXlvdXRz..." (with quotes — raw jsonb text)
-
-	result, err := specToJSONBytes(string(base64Str))
-	require.NoError(t, err)
-	assert.Equal(t, byte('{'), result[0], "must unwrap quoted base64 to JSON object")
-}
-
-// Base64 without padding (= stripped) should also be decoded.
-func TestSpecToJSONBytes_base64_no_padding(t *testing.T) {
-	originalJSON := `{"layouts":[{"name":"title"}]}`
-	b64 := base64.StdEncoding.EncodeToString([]byte(originalJSON))
-	// Strip padding
-	b64NoPad := strings.TrimRight(b64, "=")
-
-	result, err := specToJSONBytes(b64NoPad)
-	require.NoError(t, err)
-	assert.Equal(t, byte('{'), result[0], "must decode base64 without padding, got: %s", string(result[:min(50, len(result))]))
-	assert.JSONEq(t, originalJSON, string(result))
-}
-
 func TestGoPPTXRenderer_RenderPPTXBytes_WithString(t *testing.T) {
 	// Simulates what happens when GORM reads SpecJSON from PostgreSQL jsonb:
 	// the value comes back as a Go string, not []byte or map.
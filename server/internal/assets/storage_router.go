@@ -0,0 +1,162 @@
+package assets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// ErrUnknownRegion is returned for a storage region the server has no
+// backend for. Calls fail rather than fall back to the default backend, so
+// an org's files never land outside its region.
+var ErrUnknownRegion = errors.New("unknown storage region")
+
+type regionKey struct{}
+
+// WithRegion routes StorageRouter calls made with ctx to region's backend;
+// "" is the default backend. Reads and deletes of an asset use the region
+// recorded on it.
+func WithRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, regionKey{}, region)
+}
+
+// RegionFrom returns the region set by WithRegion, or "" for the default
+// backend.
+func RegionFrom(ctx context.Context) string {
+	region, _ := ctx.Value(regionKey{}).(string)
+	return region
+}
+
+// ForOrg routes ctx to orgID's storage region, for storing new files. An org
+// with no record has no region setting and uses the default backend, but if
+// the org can't be read its region isn't known, so ForOrg returns an error
+// rather than risk writing an in-region org's files to the default backend;
+// callers fail or retry the upload.
+func ForOrg(ctx context.Context, orgs store.OrganizationStore, orgID string) (context.Context, error) {
+	org, err := orgs.GetOrganization(ctx, orgID)
+	if errors.Is(err, store.ErrNotFound) {
+		return WithRegion(ctx, ""), nil
+	}
+	if err != nil {
+		return ctx, fmt.Errorf("load storage region of org %s: %w", orgID, err)
+	}
+	return WithRegion(ctx, org.StorageRegion()), nil
+}
+
+// StorageRouter is an ObjectStorage that sends each call to the backend for
+// the region in its context (see WithRegion), so orgs that need their files
+// kept in-region get their own bucket and endpoint.
+type StorageRouter struct {
+	def     ObjectStorage
+	regions map[string]ObjectStorage
+}
+
+// NewStorageRouter returns a router with def as the default backend and no
+// regions.
+func NewStorageRouter(def ObjectStorage) *StorageRouter {
+	return &StorageRouter{def: def, regions: map[string]ObjectStorage{}}
+}
+
+// Add registers backend for region.
+func (r *StorageRouter) Add(region string, backend ObjectStorage) {
+	r.regions[region] = backend
+}
+
+// Regions returns the configured regions, sorted.
+func (r *StorageRouter) Regions() []string {
+	return slices.Sorted(maps.Keys(r.regions))
+}
+
+// For returns region's backend; "" is the default backend.
+func (r *StorageRouter) For(region string) (ObjectStorage, error) {
+	if region == "" {
+		return r.def, nil
+	}
+	backend, ok := r.regions[region]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownRegion, region)
+	}
+	return backend, nil
+}
+
+func (r *StorageRouter) backend(ctx context.Context) (ObjectStorage, error) {
+	return r.For(RegionFrom(ctx))
+}
+
+func (r *StorageRouter) Upload(ctx context.Context, key string, data []byte, contentType string) (*ObjectMetadata, error) {
+	b, err := r.backend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.Upload(ctx, key, data, contentType)
+}
+
+func (r *StorageRouter) UploadStream(ctx context.Context, key string, reader io.Reader, contentType string) (*ObjectMetadata, error) {
+	b, err := r.backend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.UploadStream(ctx, key, reader, contentType)
+}
+
+func (r *StorageRouter) GetURL(ctx context.Context, key string, expiration time.Duration) (string, error) {
+	b, err := r.backend(ctx)
+	if err != nil {
+		return "", err
+	}
+	return b.GetURL(ctx, key, expiration)
+}
+
+func (r *StorageRouter) Download(ctx context.Context, key string) ([]byte, error) {
+	b, err := r.backend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.Download(ctx, key)
+}
+
+func (r *StorageRouter) DownloadStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	b, err := r.backend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.DownloadStream(ctx, key)
+}
+
+func (r *StorageRouter) Delete(ctx context.Context, key string) error {
+	b, err := r.backend(ctx)
+	if err != nil {
+		return err
+	}
+	return b.Delete(ctx, key)
+}
+
+func (r *StorageRouter) Exists(ctx context.Context, key string) (bool, error) {
+	b, err := r.backend(ctx)
+	if err != nil {
+		return false, err
+	}
+	return b.Exists(ctx, key)
+}
+
+func (r *StorageRouter) ListObjects(ctx context.Context, prefix string) ([]*ObjectMetadata, error) {
+	b, err := r.backend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.ListObjects(ctx, prefix)
+}
+
+func (r *StorageRouter) GetMetadata(ctx context.Context, key string) (*ObjectMetadata, error) {
+	b, err := r.backend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetMetadata(ctx, key)
+}
@@ -0,0 +1,68 @@
+package assets
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+func TestStorageRouter_RoutesByContextRegion(t *testing.T) {
+	def, err := NewLocalStorage(StorageConfig{Type: "local", BasePath: t.TempDir()})
+	require.NoError(t, err)
+	eu, err := NewLocalStorage(StorageConfig{Type: "local", BasePath: t.TempDir()})
+	require.NoError(t, err)
+	r := NewStorageRouter(def)
+	r.Add("eu", eu)
+	assert.Equal(t, []string{"eu"}, r.Regions())
+
+	ctx := context.Background()
+	_, err = r.Upload(WithRegion(ctx, "eu"), "a.txt", []byte("in region"), "text/plain")
+	require.NoError(t, err)
+
+	inEU, err := eu.Exists(ctx, "a.txt")
+	require.NoError(t, err)
+	assert.True(t, inEU)
+	inDefault, err := r.Exists(ctx, "a.txt")
+	require.NoError(t, err)
+	assert.False(t, inDefault, "a context without a region uses the default backend")
+
+	data, err := r.Download(WithRegion(ctx, "eu"), "a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "in region", string(data))
+
+	_, err = r.Upload(WithRegion(ctx, "ap"), "b.txt", []byte("x"), "text/plain")
+	assert.ErrorIs(t, err, ErrUnknownRegion, "unknown regions never fall back to the default backend")
+}
+
+// failingOrgs fails every GetOrganization, like a database that is down.
+type failingOrgs struct{ store.OrganizationStore }
+
+func (failingOrgs) GetOrganization(context.Context, string) (store.Organization, error) {
+	return store.Organization{}, errors.New("connection refused")
+}
+
+func TestForOrg_UsesOrgStorageRegion(t *testing.T) {
+	st := memory.New()
+	ctx := context.Background()
+	require.NoError(t, st.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-eu", Name: "EU", Settings: &store.OrgSettings{StorageRegion: "eu"}}))
+	require.NoError(t, st.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Default"}))
+
+	routed, err := ForOrg(ctx, st.Organizations(), "org-eu")
+	require.NoError(t, err)
+	assert.Equal(t, "eu", RegionFrom(routed))
+	routed, err = ForOrg(ctx, st.Organizations(), "org-1")
+	require.NoError(t, err)
+	assert.Equal(t, "", RegionFrom(routed))
+
+	routed, err = ForOrg(ctx, st.Organizations(), "missing")
+	require.NoError(t, err)
+	assert.Equal(t, "", RegionFrom(routed), "an org with no record has no region setting")
+
+	_, err = ForOrg(ctx, failingOrgs{st.Organizations()}, "org-eu")
+	assert.Error(t, err, "an org whose region can't be read isn't sent to the default backend")
+}
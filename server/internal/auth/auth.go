@@ -19,6 +19,10 @@ type Identity struct {
 	UserID string
 	OrgID  string
 	Role   Role
+	// PlatformAdmin marks platform operators, who may use the cross-org
+	// /v1/admin/platform endpoints. It comes from the user's account, not
+	// the token or an org membership, so no org role can grant it.
+	PlatformAdmin bool
 }
 
 type ctxKeyIdentity struct{}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// confirmationClaims bind a confirmation token to one user, action and
+// subject, e.g. user-1 deleting org-1.
+type confirmationClaims struct {
+	Action string `json:"act"`
+	UserID string `json:"uid"`
+	jwt.RegisteredClaims
+}
+
+// confirmationPurpose derives confirmation keys from the JWT secret, as
+// oidcStatePurpose does, so confirmation tokens are neither access tokens nor
+// OIDC state.
+const confirmationPurpose = "confirmation"
+
+// NewConfirmationToken returns a token with which userID confirms a
+// destructive action on subject within ttl, and when it expires.
+func NewConfirmationToken(action, subject, userID string, ttl time.Duration) (string, time.Time, error) {
+	expires := time.Now().Add(ttl)
+	claims := confirmationClaims{
+		Action: action,
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(expires),
+		},
+	}
+	key, err := signingKey(confirmationPurpose)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	return token, expires, err
+}
+
+// VerifyConfirmationToken checks that token was issued to userID for action
+// on subject and has not expired.
+func VerifyConfirmationToken(token, action, subject, userID string) error {
+	claims := &confirmationClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return verificationKeys(confirmationPurpose)
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired(), jwt.WithSubject(subject))
+	if err != nil {
+		return fmt.Errorf("invalid confirmation token: %w", err)
+	}
+	if claims.Action != action || claims.UserID != userID {
+		return fmt.Errorf("invalid confirmation token: issued for another action or user")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmationToken_BoundToActionSubjectAndUser(t *testing.T) {
+	token, expires, err := NewConfirmationToken("org.delete", "org-1", "user-1", time.Minute)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expires, time.Second)
+
+	assert.NoError(t, VerifyConfirmationToken(token, "org.delete", "org-1", "user-1"))
+	assert.Error(t, VerifyConfirmationToken(token, "org.delete", "org-2", "user-1"))
+	assert.Error(t, VerifyConfirmationToken(token, "org.delete", "org-1", "user-2"))
+	assert.Error(t, VerifyConfirmationToken(token, "org.export", "org-1", "user-1"))
+
+	expired, _, err := NewConfirmationToken("org.delete", "org-1", "user-1", -time.Minute)
+	require.NoError(t, err)
+	assert.Error(t, VerifyConfirmationToken(expired, "org.delete", "org-1", "user-1"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	_, err = JWTAuthenticator{}.Authenticate(req)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
@@ -1,26 +1,86 @@
 package auth
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"errors"
-	"log"
+	"fmt"
 	"net/http"
-	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(getJWTSecret())
+// MinJWTSecretLength is the shortest JWT_SECRET accepted.
+const MinJWTSecretLength = 32
 
-func getJWTSecret() string {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		log.Fatal("JWT_SECRET environment variable is required. Please set it before starting the server.")
+// ErrNoJWTSecret is returned when tokens are issued before SetJWTSecrets.
+var ErrNoJWTSecret = errors.New("JWT secret is not configured")
+
+// jwtSecrets signs with current and also accepts tokens signed with the
+// previous secrets, so rotating the secret doesn't sign everyone out.
+type jwtSecrets struct {
+	current  []byte
+	previous [][]byte
+}
+
+var jwtKeys atomic.Pointer[jwtSecrets]
+
+// ValidateJWTSecret checks that secret is long enough to sign tokens with.
+func ValidateJWTSecret(secret string) error {
+	if len(secret) < MinJWTSecretLength {
+		return fmt.Errorf("must be at least %d characters long", MinJWTSecretLength)
+	}
+	return nil
+}
+
+// SetJWTSecrets makes current the signing secret. Tokens signed with any of
+// previous stay valid until they expire.
+func SetJWTSecrets(current string, previous ...string) error {
+	if err := ValidateJWTSecret(current); err != nil {
+		return fmt.Errorf("JWT secret: %w", err)
+	}
+	keys := &jwtSecrets{current: []byte(current)}
+	for _, p := range previous {
+		if p != "" && p != current {
+			keys.previous = append(keys.previous, []byte(p))
+		}
+	}
+	jwtKeys.Store(keys)
+	return nil
+}
+
+// signingKey derives the key for purpose from the current secret; an empty
+// purpose is the secret itself, used for access tokens.
+func signingKey(purpose string) ([]byte, error) {
+	keys := jwtKeys.Load()
+	if keys == nil {
+		return nil, ErrNoJWTSecret
+	}
+	return deriveKey(keys.current, purpose), nil
+}
+
+// verificationKeys are the keys for purpose from every accepted secret.
+func verificationKeys(purpose string) (jwt.VerificationKeySet, error) {
+	keys := jwtKeys.Load()
+	if keys == nil {
+		return jwt.VerificationKeySet{}, ErrNoJWTSecret
 	}
-	if len(secret) < 32 {
-		log.Fatal("JWT_SECRET must be at least 32 characters long for security")
+	set := jwt.VerificationKeySet{Keys: []jwt.VerificationKey{deriveKey(keys.current, purpose)}}
+	for _, p := range keys.previous {
+		set.Keys = append(set.Keys, deriveKey(p, purpose))
 	}
-	return secret
+	return set, nil
+}
+
+func deriveKey(secret []byte, purpose string) []byte {
+	if purpose == "" {
+		return secret
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
 }
 
 type JWTAuthenticator struct{}
@@ -50,7 +110,7 @@ func (JWTAuthenticator) Authenticate(r *http.Request) (Identity, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("invalid signing method")
 		}
-		return jwtSecret, nil
+		return verificationKeys("")
 	})
 
 	if err != nil {
@@ -82,6 +142,10 @@ func GenerateToken(userID, orgID string, role Role) (string, error) {
 		},
 	}
 
+	key, err := signingKey("")
+	if err != nil {
+		return "", err
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return token.SignedString(key)
 }
@@ -3,12 +3,21 @@ package auth
 import (
 	"context"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+func TestMain(m *testing.M) {
+	if err := SetJWTSecrets("test-secret-test-secret-test-secret"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
 func TestGenerateToken(t *testing.T) {
 	userID := "user-123"
 	orgID := "org-456"
@@ -126,4 +135,34 @@ func TestIdentityContext(t *testing.T) {
 	// Test getting identity from empty context
 	_, ok = GetIdentity(ctx)
 	assert.False(t, ok, "Should not find identity in empty context")
-}
\ No newline at end of file
+}
+func TestSetJWTSecrets_AcceptsPreviousSecretAfterRotation(t *testing.T) {
+	original := jwtKeys.Load()
+	t.Cleanup(func() { jwtKeys.Store(original) })
+
+	oldSecret := strings.Repeat("o", MinJWTSecretLength)
+	newSecret := strings.Repeat("n", MinJWTSecretLength)
+	require.NoError(t, SetJWTSecrets(oldSecret))
+	oldToken, err := GenerateToken("user-1", "org-1", RoleEditor)
+	require.NoError(t, err)
+
+	require.NoError(t, SetJWTSecrets(newSecret, oldSecret))
+	newToken, err := GenerateToken("user-1", "org-1", RoleEditor)
+	require.NoError(t, err)
+	for _, token := range []string{oldToken, newToken} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		id, err := JWTAuthenticator{}.Authenticate(req)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", id.UserID)
+	}
+
+	// Once the old secret is dropped its tokens stop working.
+	require.NoError(t, SetJWTSecrets(newSecret))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+oldToken)
+	_, err = JWTAuthenticator{}.Authenticate(req)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	assert.Error(t, SetJWTSecrets("too-short"))
+}
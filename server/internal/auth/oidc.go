@@ -0,0 +1,385 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCProviderConfig describes one identity provider (Google, Microsoft, ...).
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// AllowedDomains restricts sign-in to these email domains; empty allows any.
+	AllowedDomains []string
+	// EmailVerification is how the provider vouches for the email in its ID
+	// tokens: EmailVerifiedClaim (the default) or EmailVerifiedEntra.
+	EmailVerification string
+}
+
+// Email verification policies for OIDCProviderConfig.EmailVerification.
+const (
+	// EmailVerifiedClaim trusts the standard email_verified claim.
+	EmailVerifiedClaim = "email_verified"
+	// EmailVerifiedEntra is for Microsoft Entra ID, which omits
+	// email_verified. It also accepts xms_edov, the optional claim Entra sets
+	// when the tenant has verified it owns the email's domain.
+	EmailVerifiedEntra = "entra"
+)
+
+// OIDCClaims are the ID token fields used for provisioning.
+type OIDCClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	// EmailDomainOwnerVerified is Entra's xms_edov claim.
+	EmailDomainOwnerVerified bool   `json:"xms_edov,omitempty"`
+	Name                     string `json:"name"`
+	Nonce                    string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+var ErrOIDCDomainNotAllowed = errors.New("email domain not allowed for this provider")
+
+// OIDCProvider implements the authorization code flow against a single
+// issuer. Discovery and signing keys are fetched lazily and cached.
+type OIDCProvider struct {
+	Config     OIDCProviderConfig
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey
+	// keysFetched is when the JWKS was last requested; see jwksRefreshInterval.
+	keysFetched time.Time
+}
+
+// jwksRefreshInterval is how often an ID token with an unknown key ID may
+// make a provider refetch its JWKS. Tokens with made-up key IDs would
+// otherwise turn each callback into a request to the provider.
+const jwksRefreshInterval = time.Minute
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func NewOIDCProvider(cfg OIDCProviderConfig) *OIDCProvider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &OIDCProvider{
+		Config:     cfg,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		keys:       map[string]*rsa.PublicKey{},
+	}
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var d oidcDiscovery
+	wellKnown := strings.TrimSuffix(p.Config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &d); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: incomplete provider metadata")
+	}
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+// AuthCodeURL returns the provider URL to send the browser to.
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.Config.ClientID},
+		"redirect_uri":  {p.Config.RedirectURL},
+		"scope":         {strings.Join(p.Config.Scopes, " ")},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange trades an authorization code for a verified ID token.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, nonce string) (*OIDCClaims, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.Config.RedirectURL},
+		"client_id":     {p.Config.ClientID},
+		"client_secret": {p.Config.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc token exchange: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tok struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil || tok.IDToken == "" {
+		return nil, errors.New("oidc token exchange: no id_token in response")
+	}
+	return p.VerifyIDToken(ctx, tok.IDToken, nonce)
+}
+
+// VerifyIDToken checks signature, issuer, audience, expiry and nonce.
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, raw, nonce string) (*OIDCClaims, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	issuer := d.Issuer
+	if issuer == "" {
+		issuer = p.Config.Issuer
+	}
+
+	claims := &OIDCClaims{}
+	_, err = jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.signingKey(ctx, d.JWKSURI, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(p.Config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+	if !hmac.Equal([]byte(claims.Nonce), []byte(nonce)) {
+		return nil, errors.New("invalid id_token: nonce mismatch")
+	}
+	return claims, nil
+}
+
+// EmailVerified reports whether the provider vouches for claims.Email under
+// its EmailVerification policy.
+func (p *OIDCProvider) EmailVerified(claims *OIDCClaims) bool {
+	if claims.Email == "" {
+		return false
+	}
+	if claims.EmailVerified {
+		return true
+	}
+	return p.Config.EmailVerification == EmailVerifiedEntra && claims.EmailDomainOwnerVerified
+}
+
+// DomainAllowed reports whether email may sign in through this provider.
+func (p *OIDCProvider) DomainAllowed(email string) bool {
+	if len(p.Config.AllowedDomains) == 0 {
+		return true
+	}
+	domain := EmailDomain(email)
+	for _, d := range p.Config.AllowedDomains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// EmailDomain returns the lower-cased domain part of an email address.
+func EmailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(email[at+1:])
+}
+
+// freeMailDomains are public mailbox providers. Anyone can hold an address
+// at them, so no org may claim one as its SSO domain.
+var freeMailDomains = map[string]bool{
+	"gmail.com": true, "googlemail.com": true,
+	"outlook.com": true, "hotmail.com": true, "live.com": true, "msn.com": true,
+	"yahoo.com": true, "ymail.com": true, "rocketmail.com": true,
+	"icloud.com": true, "me.com": true, "mac.com": true,
+	"aol.com": true, "aim.com": true,
+	"proton.me": true, "protonmail.com": true, "pm.me": true,
+	"gmx.com": true, "gmx.net": true, "gmx.de": true, "web.de": true,
+	"mail.com": true, "zoho.com": true, "yandex.com": true, "yandex.ru": true,
+	"mail.ru": true, "qq.com": true, "163.com": true, "126.com": true,
+	"fastmail.com": true, "hey.com": true, "tutanota.com": true, "tuta.io": true,
+}
+
+// IsFreeMailDomain reports whether domain is a public mailbox provider,
+// including its country variants such as yahoo.co.uk.
+func IsFreeMailDomain(domain string) bool {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	if freeMailDomains[domain] {
+		return true
+	}
+	for _, provider := range []string{"yahoo.", "hotmail.", "outlook.", "live.", "gmail."} {
+		if strings.HasPrefix(domain, provider) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *OIDCProvider) signingKey(ctx context.Context, jwksURI, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	throttled := !ok && time.Since(p.keysFetched) < jwksRefreshInterval
+	if !ok && !throttled {
+		p.keysFetched = time.Now()
+	}
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if throttled {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	// Unknown kid: the provider may have rotated keys, so refetch.
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// oidcStateClaims round-trip the login attempt through the provider so the
+// callback needs no server-side session.
+type oidcStateClaims struct {
+	Provider string `json:"provider"`
+	Nonce    string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// oidcStatePurpose derives state keys from the JWT secret so state tokens
+// can never be presented as API access tokens.
+const oidcStatePurpose = "oidc-state"
+
+// NewOIDCState returns a signed state value and the nonce bound to it.
+func NewOIDCState(provider string) (state, nonce string, err error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", "", err
+	}
+	nonce = hex.EncodeToString(b[:])
+	claims := oidcStateClaims{
+		Provider: provider,
+		Nonce:    nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(10 * time.Minute)),
+		},
+	}
+	key, err := signingKey(oidcStatePurpose)
+	if err != nil {
+		return "", "", err
+	}
+	state, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	return state, nonce, err
+}
+
+// ParseOIDCState validates a state value and returns its provider and nonce.
+func ParseOIDCState(state string) (provider, nonce string, err error) {
+	claims := &oidcStateClaims{}
+	_, err = jwt.ParseWithClaims(state, claims, func(t *jwt.Token) (interface{}, error) {
+		return verificationKeys(oidcStatePurpose)
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired())
+	if err != nil {
+		return "", "", fmt.Errorf("invalid state: %w", err)
+	}
+	return claims.Provider, claims.Nonce, nil
+}
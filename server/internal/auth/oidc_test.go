@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIssuer serves discovery, JWKS and a token endpoint that returns idToken.
+func fakeIssuer(t *testing.T, key *rsa.PrivateKey, idToken func(issuer string) string) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 srv.URL,
+			"authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint":         srv.URL + "/token",
+			"jwks_uri":               srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "k1",
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": idToken(srv.URL)})
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func signIDToken(t *testing.T, key *rsa.PrivateKey, claims OIDCClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = "k1"
+	s, err := tok.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func TestOIDCProvider_ExchangeVerifiesIDToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	nonce := "n-123"
+	audience := "client-1"
+	srv := fakeIssuer(t, key, func(issuer string) string {
+		return signIDToken(t, key, OIDCClaims{
+			Email:         "ada@acme.com",
+			EmailVerified: true,
+			Nonce:         nonce,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    issuer,
+				Subject:   "sub-1",
+				Audience:  jwt.ClaimStrings{audience},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		})
+	})
+
+	p := NewOIDCProvider(OIDCProviderConfig{Name: "test", Issuer: srv.URL, ClientID: "client-1", RedirectURL: "https://app/cb"})
+	ctx := context.Background()
+
+	authURL, err := p.AuthCodeURL(ctx, "state-1", nonce)
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/authorize", u.Path)
+	assert.Equal(t, "state-1", u.Query().Get("state"))
+	assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+
+	claims, err := p.Exchange(ctx, "code", nonce)
+	require.NoError(t, err)
+	assert.Equal(t, "ada@acme.com", claims.Email)
+
+	_, err = p.Exchange(ctx, "code", "other-nonce")
+	assert.ErrorContains(t, err, "nonce")
+
+	audience = "someone-else"
+	_, err = p.Exchange(ctx, "code", nonce)
+	assert.Error(t, err, "token for another client must be rejected")
+}
+
+func TestOIDCProvider_RefetchesJWKSAtMostOncePerInterval(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	srv := fakeIssuer(t, key, func(string) string { return "" })
+	var fetches atomic.Int32
+	issuer := srv.Config.Handler
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/jwks" {
+			fetches.Add(1)
+		}
+		issuer.ServeHTTP(w, r)
+	})
+
+	p := NewOIDCProvider(OIDCProviderConfig{Name: "test", Issuer: srv.URL, ClientID: "client-1"})
+	token := func(kid string) string {
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, OIDCClaims{
+			Nonce: "n",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    srv.URL,
+				Audience:  jwt.ClaimStrings{"client-1"},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		})
+		tok.Header["kid"] = kid
+		s, err := tok.SignedString(key)
+		require.NoError(t, err)
+		return s
+	}
+	ctx := context.Background()
+
+	_, err = p.VerifyIDToken(ctx, token("k1"), "n")
+	require.NoError(t, err)
+	for range 3 {
+		_, err = p.VerifyIDToken(ctx, token("made-up"), "n")
+		assert.ErrorContains(t, err, "unknown signing key")
+	}
+	assert.Equal(t, int32(1), fetches.Load(), "unknown key IDs must not refetch within the interval")
+
+	p.mu.Lock()
+	p.keysFetched = time.Now().Add(-jwksRefreshInterval)
+	p.mu.Unlock()
+	_, err = p.VerifyIDToken(ctx, token("made-up"), "n")
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestOIDCProvider_EmailVerified(t *testing.T) {
+	generic := NewOIDCProvider(OIDCProviderConfig{})
+	entra := NewOIDCProvider(OIDCProviderConfig{EmailVerification: EmailVerifiedEntra})
+
+	verified := &OIDCClaims{Email: "ada@acme.com", EmailVerified: true}
+	assert.True(t, generic.EmailVerified(verified))
+	assert.True(t, entra.EmailVerified(verified))
+
+	domainOwner := &OIDCClaims{Email: "ada@acme.com", EmailDomainOwnerVerified: true}
+	assert.False(t, generic.EmailVerified(domainOwner), "xms_edov only counts for Entra")
+	assert.True(t, entra.EmailVerified(domainOwner))
+
+	assert.False(t, entra.EmailVerified(&OIDCClaims{Email: "ada@acme.com"}))
+	assert.False(t, entra.EmailVerified(&OIDCClaims{EmailVerified: true, EmailDomainOwnerVerified: true}))
+}
+
+func TestOIDCState_RoundTripAndNotAnAccessToken(t *testing.T) {
+	state, nonce, err := NewOIDCState("google")
+	require.NoError(t, err)
+
+	provider, gotNonce, err := ParseOIDCState(state)
+	require.NoError(t, err)
+	assert.Equal(t, "google", provider)
+	assert.Equal(t, nonce, gotNonce)
+
+	_, _, err = ParseOIDCState(state + "x")
+	assert.Error(t, err)
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+state)
+	_, err = JWTAuthenticator{}.Authenticate(req)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestOIDCProvider_DomainAllowed(t *testing.T) {
+	p := NewOIDCProvider(OIDCProviderConfig{AllowedDomains: []string{"acme.com"}})
+	assert.True(t, p.DomainAllowed("ada@ACME.com"))
+	assert.False(t, p.DomainAllowed("eve@evil.com"))
+	assert.True(t, NewOIDCProvider(OIDCProviderConfig{}).DomainAllowed("anyone@x.io"))
+}
+
+func TestIsFreeMailDomain(t *testing.T) {
+	for _, d := range []string{"gmail.com", "Outlook.com", "yahoo.co.uk", "proton.me."} {
+		assert.True(t, IsFreeMailDomain(d), d)
+	}
+	for _, d := range []string{"acme.com", "mail.acme.com", "gmailer.io"} {
+		assert.False(t, IsFreeMailDomain(d), d)
+	}
+}
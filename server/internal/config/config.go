@@ -0,0 +1,684 @@
+// Package config loads every server setting from the environment into one
+// typed Config, checks it, and hands it to the constructors that need it.
+// Each field's comment names the variable it comes from and its default;
+// .env.example shows them in context.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/flags"
+	"github.com/ziyad/cms-ai/server/internal/imagegen"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/queue"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/cache"
+	"github.com/ziyad/cms-ai/server/internal/store/postgres"
+)
+
+// Config is the whole server configuration.
+type Config struct {
+	Server   Server
+	Log      Log
+	Database Database
+	// Storage is the object storage backend, from STORAGE_TYPE (local, s3
+	// or gcs; default local), S3_BUCKET, AWS_REGION, AWS_ACCESS_KEY_ID,
+	// AWS_SECRET_KEY, S3_ENDPOINT, LOCAL_STORAGE_PATH and PUBLIC_BASE_URL.
+	Storage assets.StorageConfig
+	// StorageRegions are further backends orgs can keep their files in,
+	// from STORAGE_REGIONS=eu,me-central and STORAGE_<REGION>_* vars (TYPE,
+	// BUCKET, AWS_REGION, ENDPOINT, ACCESS_KEY_ID, SECRET_KEY, LOCAL_PATH,
+	// PUBLIC_BASE_URL; a dash in the name becomes _). TYPE, AWS_REGION and
+	// the credentials default to the main storage's.
+	StorageRegions map[string]assets.StorageConfig
+	Renderer       Renderer
+	AI             AI
+	Worker         Worker
+	API            API
+	// Features overrides feature flags' built-in defaults
+	// (FEATURE_FLAGS=async_export=true,streaming_generation=false).
+	// Platform admins can change them per org at runtime.
+	Features map[string]bool
+}
+
+// Server holds the listeners.
+type Server struct {
+	// Addr is ":"+PORT when PORT is set (Railway), else ADDR, else ":8080".
+	Addr string
+	// GRPCAddr serves the gRPC API as well (GRPC_ADDR); empty disables it.
+	GRPCAddr string
+	// SecretsRefreshInterval is how often a non-env secrets provider is
+	// re-read (SECRETS_REFRESH_INTERVAL, default 5m; 0 disables it).
+	SecretsRefreshInterval time.Duration
+}
+
+// Log configures structured logging.
+type Log struct {
+	// Level is debug, info, warn or error (LOG_LEVEL, default info).
+	Level logger.LogLevel
+	// Format is json or text (LOG_FORMAT, default json).
+	Format string
+	// RedactPatterns are extra regular expressions masked in logs and audit
+	// metadata (PII_REDACT_PATTERNS, space-separated).
+	RedactPatterns []string
+}
+
+// Database selects the store.
+type Database struct {
+	// URL is the Postgres DSN (DATABASE_URL); empty uses the in-memory store.
+	URL string
+	// ReplicaURL serves list and diagnostics reads (DATABASE_REPLICA_URL).
+	ReplicaURL string
+	// MemorySnapshotPath persists the in-memory store to a JSON file
+	// (MEMORY_SNAPSHOT_PATH).
+	MemorySnapshotPath string
+	// Pool is read from DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS,
+	// DB_CONN_MAX_LIFETIME, DB_CONN_MAX_IDLE_TIME, DB_STATEMENT_TIMEOUT and
+	// DB_QUERY_TIMEOUT; see postgres.DefaultPoolConfig for the defaults.
+	Pool postgres.PoolConfig
+	// Cache holds hot template and brand kit reads in front of Postgres:
+	// STORE_CACHE_SIZE entries (default 1000; 0 disables it) for
+	// STORE_CACHE_TTL (default 30s), shared between instances through the
+	// Redis at STORE_CACHE_REDIS_URL when it is set.
+	Cache cache.Config
+	// EncryptionKeys seal the encrypted columns, from DATA_ENCRYPTION_KEYS
+	// or the file named by DATA_ENCRYPTION_KEYS_FILE; nil stores them in
+	// plain text.
+	EncryptionKeys *postgres.Keyring
+	// LogQueries logs every SQL statement (DEV_MODE).
+	LogQueries bool
+}
+
+// PostgresOptions are the settings the Postgres store at URL opens with.
+func (d Database) PostgresOptions() postgres.Options {
+	return postgres.Options{ReplicaURL: d.ReplicaURL, Pool: d.Pool, Keys: d.EncryptionKeys, LogQueries: d.LogQueries}
+}
+
+// Renderer picks how decks are rendered.
+type Renderer struct {
+	// Default is the engine used when neither the org nor the export names
+	// one (DEFAULT_RENDERER). Empty picks remote when ServiceURL is set, ai
+	// when a Hugging Face key is set and python otherwise.
+	Default string
+	// ServiceURL and ServiceToken reach the render service for the remote
+	// engine (RENDER_SERVICE_URL, RENDER_SERVICE_TOKEN).
+	ServiceURL   string
+	ServiceToken string
+	// HuggingFaceAPIKey is passed to the Python renderers
+	// (HUGGING_FACE_API_KEY).
+	HuggingFaceAPIKey string
+}
+
+// AI configures template generation.
+type AI struct {
+	// Mock answers with canned specs instead of calling Hugging Face
+	// (USE_MOCK_AI, default false).
+	Mock bool
+	// Model is the Hugging Face chat model (HUGGINGFACE_MODEL).
+	Model string
+	// Chains are the models for one kind of call, tried in order until one
+	// answers (AI_MODELS_<KIND>, like AI_MODELS_BIND, a comma-separated
+	// list of provider:model). Kinds without a chain use Model.
+	Chains map[string][]ai.ModelRef
+	// Breaker guards provider calls: AI_BREAKER_FAILURES (default 5)
+	// failures in a row fail calls fast for AI_BREAKER_COOLDOWN (default
+	// 30s), at most AI_MAX_CONCURRENT_CALLS (default 8) calls run at once,
+	// waiting up to AI_QUEUE_TIMEOUT (default 5s) for a slot, and each call
+	// gets AI_CALL_TIMEOUT (default 2m).
+	Breaker ai.BreakerConfig
+	// Offline runs without Hugging Face or any other external API, for
+	// air-gapped deployments (OFFLINE_MODE, default false): every AI call
+	// goes to the Ollama server at OllamaURL (OLLAMA_URL, default
+	// http://localhost:11434) running OllamaModel (OLLAMA_MODEL, default
+	// llama3.1), AI_MODELS_* may only name ollama models, and decks render
+	// with the Go renderer alone, so DEFAULT_RENDERER must be go or unset.
+	Offline     bool
+	OllamaURL   string
+	OllamaModel string
+}
+
+// Worker tunes the background job worker.
+type Worker struct {
+	// Concurrency is how many jobs run at once (WORKER_CONCURRENCY,
+	// default 4); HighPrioritySlots of them are kept for interactive work
+	// (WORKER_HIGH_PRIORITY_SLOTS, default 1).
+	Concurrency       int
+	HighPrioritySlots int
+	// DrainTimeout is how long running jobs get to finish on shutdown
+	// (WORKER_DRAIN_TIMEOUT, default 30s).
+	DrainTimeout time.Duration
+	// DeadLetterRetryInterval requeues transiently failed dead-lettered
+	// jobs, each at most DeadLetterMaxAutoRetries times
+	// (DLQ_AUTO_RETRY_INTERVAL, unset disables; DLQ_AUTO_RETRY_MAX,
+	// default 3).
+	DeadLetterRetryInterval  time.Duration
+	DeadLetterMaxAutoRetries int
+	// OrgPurgeInterval is how often deleted orgs are purged
+	// (ORG_PURGE_INTERVAL, default 1h).
+	OrgPurgeInterval time.Duration
+	// ScheduleInterval is how often the worker starts the scheduled
+	// generations that are due (SCHEDULE_INTERVAL, default 1m; 0 disables
+	// them).
+	ScheduleInterval time.Duration
+}
+
+// API holds the HTTP API's settings.
+type API struct {
+	// JWTSecret signs session tokens (JWT_SECRET); tokens signed with
+	// JWTSecretPrevious are still accepted after a rotation
+	// (JWT_SECRET_PREVIOUS). Both must be at least auth.MinJWTSecretLength
+	// characters.
+	JWTSecret         string
+	JWTSecretPrevious string
+
+	// Monthly quotas per org (GENERATE_LIMIT_PER_MONTH, default 50;
+	// EXPORT_LIMIT_PER_MONTH, default 200) and stored bytes
+	// (STORAGE_LIMIT_MB, default 1024).
+	GenerateLimitPerMonth int
+	ExportLimitPerMonth   int
+	StorageLimitBytes     int64
+	// HuggingFaceAPIKey enables AI generation and image generation
+	// (HUGGINGFACE_API_KEY).
+	HuggingFaceAPIKey string
+	// HuggingFaceImageModel generates slide backgrounds; see imagegen
+	// (HUGGINGFACE_IMAGE_MODEL).
+	HuggingFaceImageModel string
+
+	// CORS: origins may include "*"; credentials are only sent to listed
+	// origins (CORS_ALLOWED_ORIGINS, comma-separated;
+	// CORS_ALLOW_CREDENTIALS; CORS_MAX_AGE_SECONDS, default 600;
+	// HSTS_MAX_AGE_SECONDS, default one year).
+	CORSAllowedOrigins   []string
+	CORSAllowCredentials bool
+	CORSMaxAgeSeconds    int
+	HSTSMaxAgeSeconds    int
+
+	// OIDC providers, from OIDC_PROVIDERS=google,microsoft and OIDC_<NAME>_* vars.
+	OIDCProviders []auth.OIDCProviderConfig
+	// OIDCPostLoginRedirect receives the session token in the URL fragment
+	// after SSO; when empty the callback responds with JSON
+	// (OIDC_POST_LOGIN_REDIRECT).
+	OIDCPostLoginRedirect string
+
+	// EmailVerifyURL is the page that confirms an email change; the token is
+	// appended as ?token=. When empty the email carries the bare token
+	// (EMAIL_VERIFY_URL).
+	EmailVerifyURL string
+
+	// OrgPurgeDelay is how long a deleted org's data is kept before the
+	// worker purges it (ORG_PURGE_DELAY, default 720h).
+	OrgPurgeDelay time.Duration
+
+	// Google OAuth client used for Google Slides imports; orgs supply their
+	// own refresh token (GOOGLE_CLIENT_ID, GOOGLE_CLIENT_SECRET).
+	GoogleClientID     string
+	GoogleClientSecret string
+
+	// StockMediaKeys are the server's Unsplash and Pexels keys, used by orgs
+	// that haven't configured their own (UNSPLASH_ACCESS_KEY, PEXELS_API_KEY).
+	StockMediaKeys store.StockMediaKeys
+
+	// RetryOverrides tune job retry policies, from RETRY_<TYPE>_* vars.
+	RetryOverrides map[store.JobType]queue.Override
+
+	// EgressAllowedCIDRs are private networks that connectors, scheduled
+	// sources and webhooks may reach anyway; every other non-public
+	// address is refused (EGRESS_ALLOWED_CIDRS, comma-separated, such as
+	// 10.20.0.0/16).
+	EgressAllowedCIDRs []netip.Prefix
+}
+
+// Load reads the configuration through getenv (os.Getenv in production) and
+// validates it. Every invalid value is reported in the one error, so a bad
+// deploy fails at startup with the whole list rather than one at a time.
+func Load(getenv func(string) string) (*Config, error) {
+	l := &loader{getenv: getenv}
+	cfg := &Config{}
+
+	cfg.Server.Addr = l.str("ADDR", ":8080")
+	if port := l.str("PORT", ""); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			l.invalid("PORT", "must be a port number, got %q", port)
+		}
+		cfg.Server.Addr = ":" + port
+	}
+	cfg.Server.GRPCAddr = l.str("GRPC_ADDR", "")
+	cfg.Server.SecretsRefreshInterval = l.duration("SECRETS_REFRESH_INTERVAL", 5*time.Minute)
+
+	cfg.Log.Level = logger.LogLevel(l.oneOf("LOG_LEVEL", "info", "debug", "info", "warn", "error"))
+	cfg.Log.Format = l.oneOf("LOG_FORMAT", "json", "json", "text")
+	cfg.Log.RedactPatterns = strings.Fields(l.str("PII_REDACT_PATTERNS", ""))
+	for _, p := range cfg.Log.RedactPatterns {
+		if _, err := regexp.Compile(p); err != nil {
+			l.invalid("PII_REDACT_PATTERNS", "%v", err)
+		}
+	}
+
+	pool := postgres.DefaultPoolConfig()
+	cfg.Database = Database{
+		URL:                l.str("DATABASE_URL", ""),
+		ReplicaURL:         l.str("DATABASE_REPLICA_URL", ""),
+		MemorySnapshotPath: l.str("MEMORY_SNAPSHOT_PATH", ""),
+		Pool: postgres.PoolConfig{
+			MaxOpenConns:     l.int("DB_MAX_OPEN_CONNS", pool.MaxOpenConns, 0),
+			MaxIdleConns:     l.int("DB_MAX_IDLE_CONNS", pool.MaxIdleConns, 0),
+			ConnMaxLifetime:  l.duration("DB_CONN_MAX_LIFETIME", pool.ConnMaxLifetime),
+			ConnMaxIdleTime:  l.duration("DB_CONN_MAX_IDLE_TIME", pool.ConnMaxIdleTime),
+			StatementTimeout: l.duration("DB_STATEMENT_TIMEOUT", pool.StatementTimeout),
+			QueryTimeout:     l.duration("DB_QUERY_TIMEOUT", pool.QueryTimeout),
+		},
+		Cache: cache.Config{
+			Size:     l.int("STORE_CACHE_SIZE", cache.DefaultSize, 0),
+			TTL:      l.duration("STORE_CACHE_TTL", cache.DefaultTTL),
+			RedisURL: l.str("STORE_CACHE_REDIS_URL", ""),
+		},
+		EncryptionKeys: l.keyring(),
+		LogQueries:     l.bool("DEV_MODE", false),
+	}
+	if u := cfg.Database.Cache.RedisURL; u != "" {
+		if _, err := cache.NewRedis(u); err != nil {
+			l.invalid("STORE_CACHE_REDIS_URL", "%v", err)
+		}
+	}
+	if cfg.Database.ReplicaURL != "" && cfg.Database.URL == "" {
+		l.invalid("DATABASE_REPLICA_URL", "needs DATABASE_URL")
+	}
+
+	cfg.Storage = assets.StorageConfigFromEnv(getenv)
+	switch cfg.Storage.Type {
+	case "local":
+	case "s3", "gcs":
+		if cfg.Storage.Bucket == "" {
+			l.invalid("S3_BUCKET", "is required for STORAGE_TYPE=%s", cfg.Storage.Type)
+		}
+	default:
+		l.invalid("STORAGE_TYPE", "must be local, s3 or gcs, got %q", cfg.Storage.Type)
+	}
+	cfg.StorageRegions = l.storageRegions(cfg.Storage)
+
+	cfg.Renderer = Renderer{
+		Default:           l.str("DEFAULT_RENDERER", ""),
+		ServiceURL:        l.str("RENDER_SERVICE_URL", ""),
+		ServiceToken:      l.str("RENDER_SERVICE_TOKEN", ""),
+		HuggingFaceAPIKey: l.str("HUGGING_FACE_API_KEY", ""),
+	}
+	if d := cfg.Renderer.Default; d != "" {
+		if !assets.IsRendererEngine(d) {
+			l.invalid("DEFAULT_RENDERER", "must be one of %s, got %q", strings.Join(assets.RendererEngines, ", "), d)
+		} else if d == assets.RendererRemote && cfg.Renderer.ServiceURL == "" {
+			l.invalid("DEFAULT_RENDERER", "remote needs RENDER_SERVICE_URL")
+		}
+	}
+
+	cfg.AI = AI{
+		Mock:        l.bool("USE_MOCK_AI", false),
+		Model:       l.str("HUGGINGFACE_MODEL", "moonshotai/Kimi-K2-Instruct-0905"),
+		Offline:     l.bool("OFFLINE_MODE", false),
+		OllamaURL:   l.str("OLLAMA_URL", ai.DefaultOllamaURL),
+		OllamaModel: l.str("OLLAMA_MODEL", ai.DefaultOllamaModel),
+		Breaker: ai.BreakerConfig{
+			FailureThreshold: l.int("AI_BREAKER_FAILURES", 5, 1),
+			Cooldown:         l.duration("AI_BREAKER_COOLDOWN", 30*time.Second),
+			MaxConcurrent:    l.int("AI_MAX_CONCURRENT_CALLS", 8, 1),
+			QueueTimeout:     l.duration("AI_QUEUE_TIMEOUT", 5*time.Second),
+			CallTimeout:      l.duration("AI_CALL_TIMEOUT", 2*time.Minute),
+		},
+	}
+	for _, kind := range ai.CallKinds {
+		key := "AI_MODELS_" + strings.ToUpper(kind)
+		for _, v := range l.list(key) {
+			ref, err := ai.ParseModelRef(v)
+			if err != nil {
+				l.invalid(key, "%v", err)
+				continue
+			}
+			if cfg.AI.Offline && ref.Provider != ai.ProviderOllama {
+				l.invalid(key, "%s is not an %s model, which OFFLINE_MODE requires", ref, ai.ProviderOllama)
+				continue
+			}
+			if cfg.AI.Chains == nil {
+				cfg.AI.Chains = map[string][]ai.ModelRef{}
+			}
+			cfg.AI.Chains[kind] = append(cfg.AI.Chains[kind], ref)
+		}
+	}
+	if cfg.AI.Offline {
+		if u, err := url.Parse(cfg.AI.OllamaURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			l.invalid("OLLAMA_URL", "must be an http(s) URL, got %q", cfg.AI.OllamaURL)
+		}
+		if d := cfg.Renderer.Default; assets.IsRendererEngine(d) && d != assets.RendererGo {
+			l.invalid("DEFAULT_RENDERER", "must be %s with OFFLINE_MODE, got %q", assets.RendererGo, d)
+		}
+	}
+
+	cfg.Worker = Worker{
+		Concurrency:              l.int("WORKER_CONCURRENCY", 4, 1),
+		HighPrioritySlots:        l.int("WORKER_HIGH_PRIORITY_SLOTS", 1, 0),
+		DrainTimeout:             l.duration("WORKER_DRAIN_TIMEOUT", 30*time.Second),
+		DeadLetterRetryInterval:  l.duration("DLQ_AUTO_RETRY_INTERVAL", 0),
+		DeadLetterMaxAutoRetries: l.int("DLQ_AUTO_RETRY_MAX", 3, 1),
+		OrgPurgeInterval:         l.duration("ORG_PURGE_INTERVAL", time.Hour),
+		ScheduleInterval:         l.duration("SCHEDULE_INTERVAL", time.Minute),
+	}
+	if w := cfg.Worker; w.HighPrioritySlots >= w.Concurrency {
+		l.invalid("WORKER_HIGH_PRIORITY_SLOTS", "must be less than WORKER_CONCURRENCY (%d)", w.Concurrency)
+	}
+
+	cfg.API = API{
+		JWTSecret:             l.str("JWT_SECRET", ""),
+		JWTSecretPrevious:     l.str("JWT_SECRET_PREVIOUS", ""),
+		GenerateLimitPerMonth: l.int("GENERATE_LIMIT_PER_MONTH", 50, 1),
+		ExportLimitPerMonth:   l.int("EXPORT_LIMIT_PER_MONTH", 200, 1),
+		StorageLimitBytes:     int64(l.int("STORAGE_LIMIT_MB", 1024, 1)) << 20,
+		HuggingFaceAPIKey:     l.str("HUGGINGFACE_API_KEY", ""),
+		HuggingFaceImageModel: l.str("HUGGINGFACE_IMAGE_MODEL", imagegen.DefaultModel),
+		CORSAllowedOrigins:    l.list("CORS_ALLOWED_ORIGINS"),
+		CORSAllowCredentials:  l.bool("CORS_ALLOW_CREDENTIALS", false),
+		CORSMaxAgeSeconds:     l.int("CORS_MAX_AGE_SECONDS", 600, 0),
+		HSTSMaxAgeSeconds:     l.int("HSTS_MAX_AGE_SECONDS", 31536000, 0),
+		OIDCProviders:         l.oidcProviders(),
+		OIDCPostLoginRedirect: l.str("OIDC_POST_LOGIN_REDIRECT", ""),
+		EmailVerifyURL:        l.str("EMAIL_VERIFY_URL", ""),
+		OrgPurgeDelay:         l.duration("ORG_PURGE_DELAY", 30*24*time.Hour),
+		GoogleClientID:        l.str("GOOGLE_CLIENT_ID", ""),
+		GoogleClientSecret:    l.str("GOOGLE_CLIENT_SECRET", ""),
+		StockMediaKeys: store.StockMediaKeys{
+			UnsplashAccessKey: l.str("UNSPLASH_ACCESS_KEY", ""),
+			PexelsAPIKey:      l.str("PEXELS_API_KEY", ""),
+		},
+		RetryOverrides: queue.EnvOverrides(getenv),
+	}
+	for _, key := range []string{"JWT_SECRET", "JWT_SECRET_PREVIOUS"} {
+		if secret := l.str(key, ""); secret != "" {
+			if err := auth.ValidateJWTSecret(secret); err != nil {
+				l.invalid(key, "%v", err)
+			}
+		}
+	}
+	for _, v := range l.list("EGRESS_ALLOWED_CIDRS") {
+		p, err := netip.ParsePrefix(v)
+		if err != nil {
+			l.invalid("EGRESS_ALLOWED_CIDRS", "want a CIDR such as 10.0.0.0/8, got %q", v)
+			continue
+		}
+		cfg.API.EgressAllowedCIDRs = append(cfg.API.EgressAllowedCIDRs, p.Masked())
+	}
+
+	cfg.Features = map[string]bool{}
+	for _, pair := range l.list("FEATURE_FLAGS") {
+		name, value, _ := strings.Cut(pair, "=")
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		switch {
+		case err != nil:
+			l.invalid("FEATURE_FLAGS", "want name=true or name=false, got %q", pair)
+		case !isFlag(strings.TrimSpace(name)):
+			l.invalid("FEATURE_FLAGS", "unknown flag %q", strings.TrimSpace(name))
+		default:
+			cfg.Features[strings.TrimSpace(name)] = enabled
+		}
+	}
+
+	if len(l.problems) > 0 {
+		return nil, errors.New("invalid configuration: " + strings.Join(l.problems, "; "))
+	}
+	return cfg, nil
+}
+
+// RenderService is the configuration of cmd/renderer, the standalone
+// render service.
+type RenderService struct {
+	// Addr is ":"+PORT (default :8090).
+	Addr string
+	Log  Log
+	// Engine is the renderer it wraps: go, python or ai (RENDER_ENGINE,
+	// default python).
+	Engine string
+	// Token is the bearer token clients must send (RENDER_SERVICE_TOKEN).
+	// It is required unless Insecure is set.
+	Token string
+	// Insecure serves without a token (RENDER_SERVICE_INSECURE), for local
+	// development only: anyone who can reach the service could render on it.
+	Insecure bool
+	// HuggingFaceAPIKey is passed to the ai engine (HUGGING_FACE_API_KEY).
+	HuggingFaceAPIKey string
+}
+
+// LoadRenderService reads and validates the render service's
+// configuration the way Load does the server's.
+func LoadRenderService(getenv func(string) string) (*RenderService, error) {
+	l := &loader{getenv: getenv}
+	cfg := &RenderService{
+		Addr:              ":8090",
+		Engine:            l.str("RENDER_ENGINE", assets.RendererPython),
+		Token:             l.str("RENDER_SERVICE_TOKEN", ""),
+		Insecure:          l.bool("RENDER_SERVICE_INSECURE", false),
+		HuggingFaceAPIKey: l.str("HUGGING_FACE_API_KEY", ""),
+	}
+	if port := l.str("PORT", ""); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			l.invalid("PORT", "must be a port number, got %q", port)
+		}
+		cfg.Addr = ":" + port
+	}
+	cfg.Log.Level = logger.LogLevel(l.oneOf("LOG_LEVEL", "info", "debug", "info", "warn", "error"))
+	cfg.Log.Format = l.oneOf("LOG_FORMAT", "json", "json", "text")
+	if !assets.IsRendererEngine(cfg.Engine) || cfg.Engine == assets.RendererRemote {
+		l.invalid("RENDER_ENGINE", "must be go, python or ai, got %q", cfg.Engine)
+	}
+	if cfg.Token == "" && !cfg.Insecure {
+		l.invalid("RENDER_SERVICE_TOKEN", "is required; set RENDER_SERVICE_INSECURE=true to serve without one in development")
+	}
+
+	if len(l.problems) > 0 {
+		return nil, errors.New("invalid configuration: " + strings.Join(l.problems, "; "))
+	}
+	return cfg, nil
+}
+
+func isFlag(name string) bool {
+	_, ok := flags.Lookup(name)
+	return ok
+}
+
+// knownOIDCIssuers lets well-known providers omit OIDC_<NAME>_ISSUER.
+// Microsoft issuers are tenant-specific, so they must be configured.
+var knownOIDCIssuers = map[string]string{
+	"google": "https://accounts.google.com",
+}
+
+// knownEmailVerification lets well-known providers omit
+// OIDC_<NAME>_EMAIL_VERIFICATION.
+var knownEmailVerification = map[string]string{
+	"microsoft": auth.EmailVerifiedEntra,
+}
+
+func (l *loader) oidcProviders() []auth.OIDCProviderConfig {
+	var out []auth.OIDCProviderConfig
+	for _, name := range l.list("OIDC_PROVIDERS") {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		cfg := auth.OIDCProviderConfig{
+			Name:              name,
+			Issuer:            l.str(prefix+"ISSUER", knownOIDCIssuers[name]),
+			ClientID:          l.str(prefix+"CLIENT_ID", ""),
+			ClientSecret:      l.str(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:       l.str(prefix+"REDIRECT_URL", ""),
+			Scopes:            l.list(prefix + "SCOPES"),
+			AllowedDomains:    l.list(prefix + "ALLOWED_DOMAINS"),
+			EmailVerification: l.str(prefix+"EMAIL_VERIFICATION", knownEmailVerification[name]),
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+			l.invalid("OIDC_PROVIDERS", "%s needs %sISSUER, %sCLIENT_ID and %sREDIRECT_URL", name, prefix, prefix, prefix)
+			continue
+		}
+		switch cfg.EmailVerification {
+		case "", auth.EmailVerifiedClaim, auth.EmailVerifiedEntra:
+		default:
+			l.invalid(prefix+"EMAIL_VERIFICATION", "must be %s or %s", auth.EmailVerifiedClaim, auth.EmailVerifiedEntra)
+			continue
+		}
+		out = append(out, cfg)
+	}
+	return out
+}
+
+// keyring reads DATA_ENCRYPTION_KEYS, or the file named by
+// DATA_ENCRYPTION_KEYS_FILE, where a KMS or secrets manager can place the
+// keys it decrypts at startup. It returns nil when neither is set.
+func (l *loader) keyring() *postgres.Keyring {
+	key, spec := "DATA_ENCRYPTION_KEYS", l.str("DATA_ENCRYPTION_KEYS", "")
+	if path := l.str("DATA_ENCRYPTION_KEYS_FILE", ""); spec == "" && path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			l.invalid("DATA_ENCRYPTION_KEYS_FILE", "%v", err)
+			return nil
+		}
+		key, spec = "DATA_ENCRYPTION_KEYS_FILE", strings.TrimSpace(string(b))
+	}
+	if spec == "" {
+		return nil
+	}
+	keys, err := postgres.ParseKeyring(spec)
+	if err != nil {
+		l.invalid(key, "%v", err)
+		return nil
+	}
+	return keys
+}
+
+var storageRegionName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+func (l *loader) storageRegions(base assets.StorageConfig) map[string]assets.StorageConfig {
+	out := map[string]assets.StorageConfig{}
+	for _, name := range l.list("STORAGE_REGIONS") {
+		name = strings.ToLower(name)
+		if !storageRegionName.MatchString(name) {
+			l.invalid("STORAGE_REGIONS", "%q must be lowercase letters, digits and dashes", name)
+			continue
+		}
+		prefix := "STORAGE_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		cfg := assets.StorageConfig{
+			Type:          strings.ToLower(l.str(prefix+"TYPE", base.Type)),
+			Bucket:        l.str(prefix+"BUCKET", ""),
+			Region:        l.str(prefix+"AWS_REGION", base.Region),
+			AccessKeyID:   l.str(prefix+"ACCESS_KEY_ID", base.AccessKeyID),
+			SecretKey:     l.str(prefix+"SECRET_KEY", base.SecretKey),
+			Endpoint:      l.str(prefix+"ENDPOINT", ""),
+			BasePath:      l.str(prefix+"LOCAL_PATH", ""),
+			URLExpiration: base.URLExpiration,
+			Settings:      map[string]string{},
+		}
+		if publicURL := l.str(prefix+"PUBLIC_BASE_URL", ""); publicURL != "" {
+			cfg.Settings["publicBaseURL"] = publicURL
+		}
+		switch cfg.Type {
+		case "local":
+			// Without its own path a local region would share the default
+			// backend's directory.
+			if cfg.BasePath == "" {
+				l.invalid("STORAGE_REGIONS", "%s needs %sLOCAL_PATH", name, prefix)
+			}
+		case "s3", "gcs":
+			if cfg.Bucket == "" {
+				l.invalid("STORAGE_REGIONS", "%s needs %sBUCKET", name, prefix)
+			}
+		default:
+			l.invalid(prefix+"TYPE", "must be local, s3 or gcs, got %q", cfg.Type)
+		}
+		out[name] = cfg
+	}
+	return out
+}
+
+// loader reads typed values and collects what's wrong with them. Problems
+// name the variable and, since none of the validated settings are secrets,
+// the offending value.
+type loader struct {
+	getenv   func(string) string
+	problems []string
+}
+
+func (l *loader) invalid(key, format string, args ...any) {
+	l.problems = append(l.problems, key+": "+fmt.Sprintf(format, args...))
+}
+
+func (l *loader) str(key, fallback string) string {
+	if v := strings.TrimSpace(l.getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// int reads a whole number of at least min.
+func (l *loader) int(key string, fallback, min int) int {
+	v := l.str(key, "")
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min {
+		l.invalid(key, "must be a whole number of at least %d, got %q", min, v)
+		return fallback
+	}
+	return n
+}
+
+// duration reads a Go duration such as "45s"; zero is allowed and usually
+// disables the setting.
+func (l *loader) duration(key string, fallback time.Duration) time.Duration {
+	v := l.str(key, "")
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		l.invalid(key, "must be a duration such as 30s or 5m, got %q", v)
+		return fallback
+	}
+	return d
+}
+
+func (l *loader) bool(key string, fallback bool) bool {
+	v := l.str(key, "")
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.invalid(key, "must be true or false, got %q", v)
+		return fallback
+	}
+	return b
+}
+
+func (l *loader) oneOf(key, fallback string, allowed ...string) string {
+	v := strings.ToLower(l.str(key, fallback))
+	if !slices.Contains(allowed, v) {
+		l.invalid(key, "must be one of %s, got %q", strings.Join(allowed, ", "), v)
+		return fallback
+	}
+	return v
+}
+
+// list reads a comma-separated list, dropping empty entries.
+func (l *loader) list(key string) []string {
+	var out []string
+	for _, v := range strings.Split(l.getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"encoding/base64"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store/cache"
+	"github.com/ziyad/cms-ai/server/internal/store/postgres"
+)
+
+func getenv(vars map[string]string) func(string) string {
+	return func(k string) string { return vars[k] }
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := Load(getenv(nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, ":8080", cfg.Server.Addr)
+	assert.Equal(t, logger.LevelInfo, cfg.Log.Level)
+	assert.Equal(t, "json", cfg.Log.Format)
+	assert.Equal(t, postgres.DefaultPoolConfig(), cfg.Database.Pool)
+	assert.Equal(t, cache.Config{Size: cache.DefaultSize, TTL: cache.DefaultTTL}, cfg.Database.Cache)
+	assert.Equal(t, "local", cfg.Storage.Type)
+	assert.Equal(t, 4, cfg.Worker.Concurrency)
+	assert.Equal(t, 30*time.Second, cfg.Worker.DrainTimeout)
+	assert.Zero(t, cfg.Worker.DeadLetterRetryInterval)
+	assert.Equal(t, 50, cfg.API.GenerateLimitPerMonth)
+	assert.Equal(t, int64(1024)<<20, cfg.API.StorageLimitBytes)
+	assert.Equal(t, 30*24*time.Hour, cfg.API.OrgPurgeDelay)
+	assert.Nil(t, cfg.Database.EncryptionKeys)
+	assert.False(t, cfg.Database.LogQueries)
+	assert.Empty(t, cfg.API.JWTSecret)
+}
+
+func TestLoad_ReadsSettings(t *testing.T) {
+	cfg, err := Load(getenv(map[string]string{
+		"PORT":                      "9000",
+		"ADDR":                      ":7000",
+		"LOG_LEVEL":                 "DEBUG",
+		"DB_MAX_OPEN_CONNS":         "40",
+		"DB_QUERY_TIMEOUT":          "0",
+		"STORE_CACHE_SIZE":          "0",
+		"DEFAULT_RENDERER":          "remote",
+		"RENDER_SERVICE_URL":        "http://renderer:8090",
+		"USE_MOCK_AI":               "true",
+		"WORKER_CONCURRENCY":        "8",
+		"CORS_ALLOWED_ORIGINS":      "https://a.example.com, ,https://b.example.com",
+		"CORS_ALLOW_CREDENTIALS":    "true",
+		"OIDC_PROVIDERS":            "Google",
+		"OIDC_GOOGLE_CLIENT_ID":     "client",
+		"OIDC_GOOGLE_REDIRECT_URL":  "https://app.example.com/cb",
+		"RETRY_EXPORT_MAX_RETRIES":  "5",
+		"FEATURE_FLAGS":             "async_export=true, streaming_generation=false",
+		"STORAGE_TYPE":              "s3",
+		"S3_BUCKET":                 "assets-us",
+		"AWS_REGION":                "us-east-1",
+		"AWS_ACCESS_KEY_ID":         "AKID",
+		"STORAGE_REGIONS":           "eu,me-central",
+		"STORAGE_EU_BUCKET":         "assets-eu",
+		"STORAGE_EU_AWS_REGION":     "eu-central-1",
+		"STORAGE_ME_CENTRAL_TYPE":   "gcs",
+		"STORAGE_ME_CENTRAL_BUCKET": "assets-me",
+		"AI_MODELS_BIND":            "small/model, huggingface:big/model",
+		"AI_BREAKER_COOLDOWN":       "1m",
+		"EGRESS_ALLOWED_CIDRS":      "10.20.0.0/16",
+		"DEV_MODE":                  "true",
+		"JWT_SECRET":                "current-secret-current-secret-123",
+		"JWT_SECRET_PREVIOUS":       "previous-secret-previous-secret-12",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, ":9000", cfg.Server.Addr, "PORT wins over ADDR")
+	assert.Equal(t, logger.LevelDebug, cfg.Log.Level)
+	assert.Equal(t, 40, cfg.Database.Pool.MaxOpenConns)
+	assert.Zero(t, cfg.Database.Pool.QueryTimeout)
+	assert.Zero(t, cfg.Database.Cache.Size)
+	assert.True(t, cfg.Database.LogQueries)
+	assert.Equal(t, "current-secret-current-secret-123", cfg.API.JWTSecret)
+	assert.Equal(t, "previous-secret-previous-secret-12", cfg.API.JWTSecretPrevious)
+	assert.Equal(t, "remote", cfg.Renderer.Default)
+	assert.True(t, cfg.AI.Mock)
+	assert.Equal(t, time.Minute, cfg.AI.Breaker.Cooldown)
+	assert.Equal(t, 8, cfg.AI.Breaker.MaxConcurrent)
+	assert.Equal(t, map[string][]ai.ModelRef{"bind": {{Provider: "huggingface", Model: "small/model"}, {Provider: "huggingface", Model: "big/model"}}}, cfg.AI.Chains)
+	assert.Equal(t, 8, cfg.Worker.Concurrency)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.API.CORSAllowedOrigins)
+	assert.True(t, cfg.API.CORSAllowCredentials)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.20.0.0/16")}, cfg.API.EgressAllowedCIDRs)
+	require.Len(t, cfg.API.OIDCProviders, 1)
+	assert.Equal(t, "https://accounts.google.com", cfg.API.OIDCProviders[0].Issuer)
+	assert.Equal(t, 5, *cfg.API.RetryOverrides["export"].MaxRetries)
+	assert.Equal(t, map[string]bool{"async_export": true, "streaming_generation": false}, cfg.Features)
+	require.Len(t, cfg.StorageRegions, 2)
+	eu := cfg.StorageRegions["eu"]
+	assert.Equal(t, "s3", eu.Type)
+	assert.Equal(t, "assets-eu", eu.Bucket)
+	assert.Equal(t, "eu-central-1", eu.Region)
+	assert.Equal(t, "AKID", eu.AccessKeyID, "credentials default to the main storage's")
+	assert.Equal(t, "gcs", cfg.StorageRegions["me-central"].Type)
+}
+
+func TestLoad_ReportsEveryInvalidValue(t *testing.T) {
+	_, err := Load(getenv(map[string]string{
+		"PORT":                       "http",
+		"LOG_FORMAT":                 "xml",
+		"PII_REDACT_PATTERNS":        "CUST-(",
+		"DB_STATEMENT_TIMEOUT":       "soon",
+		"STORAGE_TYPE":               "s3",
+		"DEFAULT_RENDERER":           "remote",
+		"WORKER_CONCURRENCY":         "2",
+		"WORKER_HIGH_PRIORITY_SLOTS": "2",
+		"GENERATE_LIMIT_PER_MONTH":   "0",
+		"CORS_ALLOW_CREDENTIALS":     "yes please",
+		"OIDC_PROVIDERS":             "microsoft",
+		"FEATURE_FLAGS":              "async_export,dark_mode=true",
+		"STORAGE_REGIONS":            "eu,EU West",
+		"AI_MODELS_REFINE":           "openai:gpt-4o",
+		"STORE_CACHE_REDIS_URL":      "memcached://cache:11211",
+		"EGRESS_ALLOWED_CIDRS":       "10.0.0.0",
+		"JWT_SECRET":                 "short",
+		"DATA_ENCRYPTION_KEYS":       "not-a-key",
+	}))
+	require.Error(t, err)
+	for _, want := range []string{
+		`PORT: must be a port number, got "http"`,
+		`LOG_FORMAT: must be one of json, text, got "xml"`,
+		"PII_REDACT_PATTERNS:",
+		`DB_STATEMENT_TIMEOUT: must be a duration`,
+		"S3_BUCKET: is required for STORAGE_TYPE=s3",
+		"DEFAULT_RENDERER: remote needs RENDER_SERVICE_URL",
+		"WORKER_HIGH_PRIORITY_SLOTS: must be less than WORKER_CONCURRENCY (2)",
+		`GENERATE_LIMIT_PER_MONTH: must be a whole number of at least 1, got "0"`,
+		"CORS_ALLOW_CREDENTIALS: must be true or false",
+		"OIDC_PROVIDERS: microsoft needs OIDC_MICROSOFT_ISSUER",
+		`FEATURE_FLAGS: want name=true or name=false, got "async_export"`,
+		`FEATURE_FLAGS: unknown flag "dark_mode"`,
+		"STORAGE_REGIONS: eu needs STORAGE_EU_BUCKET",
+		`STORAGE_REGIONS: "eu west" must be lowercase letters, digits and dashes`,
+		`AI_MODELS_REFINE: unknown provider "openai"`,
+		`STORE_CACHE_REDIS_URL: invalid redis url: scheme must be redis or rediss, got "memcached"`,
+		`EGRESS_ALLOWED_CIDRS: want a CIDR such as 10.0.0.0/8, got "10.0.0.0"`,
+		"JWT_SECRET: must be at least 32 characters long",
+		"DATA_ENCRYPTION_KEYS: encryption key 1: want id:base64key",
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestLoad_EncryptionKeys(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	cfg, err := Load(getenv(map[string]string{"DATA_ENCRYPTION_KEYS": "k2:" + key + ",k1:" + key}))
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Database.EncryptionKeys)
+	assert.Equal(t, "k2", cfg.Database.EncryptionKeys.Primary())
+	assert.Equal(t, cfg.Database.EncryptionKeys, cfg.Database.PostgresOptions().Keys)
+
+	path := filepath.Join(t.TempDir(), "keys")
+	require.NoError(t, os.WriteFile(path, []byte("k3:"+key+"\n"), 0o600))
+	cfg, err = Load(getenv(map[string]string{"DATA_ENCRYPTION_KEYS_FILE": path}))
+	require.NoError(t, err)
+	assert.Equal(t, "k3", cfg.Database.EncryptionKeys.Primary())
+
+	_, err = Load(getenv(map[string]string{"DATA_ENCRYPTION_KEYS_FILE": filepath.Join(t.TempDir(), "missing")}))
+	assert.ErrorContains(t, err, "DATA_ENCRYPTION_KEYS_FILE:")
+}
+
+func TestLoad_OfflineMode(t *testing.T) {
+	cfg, err := Load(getenv(map[string]string{
+		"OFFLINE_MODE":     "true",
+		"OLLAMA_URL":       "http://ollama.internal:11434",
+		"AI_MODELS_BIND":   "ollama:qwen2.5:14b",
+		"DEFAULT_RENDERER": "go",
+	}))
+	require.NoError(t, err)
+	assert.True(t, cfg.AI.Offline)
+	assert.Equal(t, "http://ollama.internal:11434", cfg.AI.OllamaURL)
+	assert.Equal(t, ai.DefaultOllamaModel, cfg.AI.OllamaModel)
+	assert.Equal(t, map[string][]ai.ModelRef{"bind": {{Provider: "ollama", Model: "qwen2.5:14b"}}}, cfg.AI.Chains)
+
+	_, err = Load(getenv(map[string]string{
+		"OFFLINE_MODE":     "true",
+		"OLLAMA_URL":       "ollama:11434",
+		"AI_MODELS_BIND":   "big/model",
+		"DEFAULT_RENDERER": "python",
+	}))
+	require.Error(t, err)
+	for _, want := range []string{
+		`OLLAMA_URL: must be an http(s) URL, got "ollama:11434"`,
+		"AI_MODELS_BIND: huggingface:big/model is not an ollama model",
+		`DEFAULT_RENDERER: must be go with OFFLINE_MODE, got "python"`,
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestLoadRenderService(t *testing.T) {
+	_, err := LoadRenderService(getenv(map[string]string{}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "RENDER_SERVICE_TOKEN: is required")
+
+	cfg, err := LoadRenderService(getenv(map[string]string{"RENDER_SERVICE_INSECURE": "true"}))
+	require.NoError(t, err)
+	assert.Equal(t, ":8090", cfg.Addr)
+	assert.Equal(t, "python", cfg.Engine)
+	assert.Empty(t, cfg.Token)
+
+	cfg, err = LoadRenderService(getenv(map[string]string{
+		"PORT":                 "9100",
+		"RENDER_ENGINE":        "ai",
+		"RENDER_SERVICE_TOKEN": "secret",
+		"HUGGING_FACE_API_KEY": "hf-key",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, ":9100", cfg.Addr)
+	assert.Equal(t, "ai", cfg.Engine)
+	assert.Equal(t, "secret", cfg.Token)
+	assert.Equal(t, "hf-key", cfg.HuggingFaceAPIKey)
+
+	_, err = LoadRenderService(getenv(map[string]string{"RENDER_ENGINE": "remote", "RENDER_SERVICE_TOKEN": "secret"}))
+	assert.ErrorContains(t, err, "RENDER_ENGINE: must be go, python or ai")
+}
@@ -0,0 +1,294 @@
+// Package connectors fetches live data from the data sources orgs register
+// (CSV URLs, Google Sheets ranges and SQL queries) and fills the
+// {{connector.<name>.<field>}} bindings of decks with it when they render.
+package connectors
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/ziyad/cms-ai/server/internal/egress"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const (
+	// MaxRows bounds the rows kept from a source.
+	MaxRows = 1000
+	// maxBodyBytes bounds a CSV file or Sheets response.
+	maxBodyBytes = 5 << 20
+	// queryTimeout bounds a SQL connector's query.
+	queryTimeout = 30 * time.Second
+)
+
+// SheetsBaseURL is the Google Sheets API the sheets connector reads from.
+var SheetsBaseURL = "https://sheets.googleapis.com/v4/spreadsheets"
+
+// namePattern is a connector name: a letter, then letters, digits, _ and -.
+// Dots separate the parts of a binding, so names can't hold them.
+var namePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_\-]*$`)
+
+// IsName reports whether name can name a connector.
+func IsName(name string) bool {
+	return len(name) <= 64 && namePattern.MatchString(name)
+}
+
+// Fetch reads c's data source.
+func Fetch(ctx context.Context, c store.Connector) (store.ConnectorData, error) {
+	var data store.ConnectorData
+	var err error
+	switch c.Kind {
+	case store.ConnectorCSV:
+		data, err = fetchCSV(ctx, c.URL, c.Credential)
+	case store.ConnectorSheets:
+		data, err = fetchSheet(ctx, c.SpreadsheetID, c.Range, c.Credential)
+	case store.ConnectorSQL:
+		data, err = fetchSQL(ctx, c.Credential, c.Query)
+	default:
+		return data, fmt.Errorf("unknown connector kind %q", c.Kind)
+	}
+	if err != nil {
+		return data, err
+	}
+	if len(data.Rows) > MaxRows {
+		data.Rows = data.Rows[:MaxRows]
+	}
+	return data, nil
+}
+
+// CheckDestination reports whether c's source may be reached: CSV URLs and
+// the hosts of SQL connection strings must resolve to public addresses, or
+// ones EGRESS_ALLOWED_CIDRS lists. Fetch enforces the same rule when it
+// connects; this rejects a bad source when it is saved.
+func CheckDestination(ctx context.Context, c store.Connector) error {
+	switch c.Kind {
+	case store.ConnectorCSV:
+		return egress.CheckURL(ctx, c.URL)
+	case store.ConnectorSQL:
+		cfg, err := pgx.ParseConfig(c.Credential)
+		if err != nil {
+			return errors.New("credential must be a Postgres connection string")
+		}
+		hosts := []string{cfg.Host}
+		for _, fb := range cfg.Fallbacks {
+			hosts = append(hosts, fb.Host)
+		}
+		for _, host := range hosts {
+			if strings.HasPrefix(host, "/") {
+				return fmt.Errorf("%w: unix socket %s", egress.ErrBlocked, host)
+			}
+			if err := egress.CheckHost(ctx, host); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// get fetches u, sending authorization as the Authorization header when set.
+// Like every fetch of a user-supplied URL it only reaches public addresses.
+func get(ctx context.Context, u, authorization string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := egress.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxBodyBytes {
+		return nil, fmt.Errorf("response exceeds %d bytes", maxBodyBytes)
+	}
+	return body, nil
+}
+
+func fetchCSV(ctx context.Context, u, authorization string) (store.ConnectorData, error) {
+	body, err := get(ctx, u, authorization)
+	if err != nil {
+		return store.ConnectorData{}, fmt.Errorf("fetch csv: %w", err)
+	}
+	r := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(body), "\ufeff")))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return store.ConnectorData{}, fmt.Errorf("read csv: %w", err)
+	}
+	return table(records)
+}
+
+func fetchSheet(ctx context.Context, spreadsheetID, cells, apiKey string) (store.ConnectorData, error) {
+	u := fmt.Sprintf("%s/%s/values/%s?key=%s", SheetsBaseURL, url.PathEscape(spreadsheetID), url.PathEscape(cells), url.QueryEscape(apiKey))
+	body, err := get(ctx, u, "")
+	if err != nil {
+		return store.ConnectorData{}, fmt.Errorf("fetch sheet: %w", err)
+	}
+	var resp struct {
+		Values [][]any `json:"values"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return store.ConnectorData{}, fmt.Errorf("read sheet: %w", err)
+	}
+	records := make([][]string, len(resp.Values))
+	for i, row := range resp.Values {
+		records[i] = make([]string, len(row))
+		for j, v := range row {
+			records[i][j] = cellText(v)
+		}
+	}
+	return table(records)
+}
+
+func fetchSQL(ctx context.Context, dsn, query string) (store.ConnectorData, error) {
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return store.ConnectorData{}, fmt.Errorf("connect: %w", err)
+	}
+	// Like HTTP sources, the database must be on a public address.
+	timeout := cfg.ConnectTimeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	cfg.DialFunc = egress.Dialer(timeout).DialContext
+	sqlDB := stdlib.OpenDB(*cfg)
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		return store.ConnectorData{}, fmt.Errorf("connect: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var data store.ConnectorData
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The query can only read, however it is written.
+		if err := tx.Exec("SET TRANSACTION READ ONLY").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL statement_timeout = %d", queryTimeout.Milliseconds())).Error; err != nil {
+			return err
+		}
+		rows, err := tx.Raw(query).Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		if data.Columns, err = rows.Columns(); err != nil {
+			return err
+		}
+		for rows.Next() && len(data.Rows) < MaxRows {
+			values := make([]any, len(data.Columns))
+			ptrs := make([]any, len(values))
+			for i := range values {
+				ptrs[i] = &values[i]
+			}
+			if err := rows.Scan(ptrs...); err != nil {
+				return err
+			}
+			row := make([]string, len(values))
+			for i, v := range values {
+				row[i] = cellText(v)
+			}
+			data.Rows = append(data.Rows, row)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return store.ConnectorData{}, fmt.Errorf("query: %w", err)
+	}
+	return data, nil
+}
+
+// table makes the first record the header and the rest the rows.
+func table(records [][]string) (store.ConnectorData, error) {
+	if len(records) == 0 {
+		return store.ConnectorData{}, errors.New("source has no header row")
+	}
+	return store.ConnectorData{Columns: records[0], Rows: records[1:]}, nil
+}
+
+// cellText formats a value read from a sheet or database.
+func cellText(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// FieldName is the binding name of a column: runs of characters other than
+// letters, digits, _ and - become one underscore, except at the end, so
+// "Q3 Revenue ($)" is bound as "Q3_Revenue".
+func FieldName(column string) string {
+	var b strings.Builder
+	gap := false
+	for _, r := range strings.TrimSpace(column) {
+		if r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			b.WriteRune(r)
+			gap = false
+		} else if !gap {
+			b.WriteByte('_')
+			gap = true
+		}
+	}
+	if gap {
+		return strings.TrimSuffix(b.String(), "_")
+	}
+	return b.String()
+}
+
+// Fields flattens data into binding values: "<field>" is the column's
+// value in the first row and "<field>.<n>" its value in row n, from 1.
+func Fields(data store.ConnectorData) map[string]string {
+	out := map[string]string{}
+	for col, name := range data.Columns {
+		field := FieldName(name)
+		if field == "" {
+			continue
+		}
+		for i, row := range data.Rows {
+			value := ""
+			if col < len(row) {
+				value = row[col]
+			}
+			if i == 0 {
+				out[field] = value
+			}
+			out[field+"."+strconv.Itoa(i+1)] = value
+		}
+	}
+	return out
+}
@@ -0,0 +1,218 @@
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/egress"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+// allowLoopback lets the test reach its httptest servers, which egress
+// otherwise refuses like any other loopback address.
+func allowLoopback(t *testing.T) {
+	egress.Configure([]netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")})
+	t.Cleanup(func() { egress.Configure(nil) })
+}
+
+func TestFetch_CSV(t *testing.T) {
+	allowLoopback(t)
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte("\ufeffRegion,Q3 Revenue ($)\nEMEA,1.2M\nAPAC,900K\n"))
+	}))
+	defer srv.Close()
+
+	data, err := Fetch(context.Background(), store.Connector{Kind: store.ConnectorCSV, URL: srv.URL, Credential: "Bearer t0k"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer t0k", gotAuth)
+	assert.Equal(t, []string{"Region", "Q3 Revenue ($)"}, data.Columns)
+	assert.Equal(t, [][]string{{"EMEA", "1.2M"}, {"APAC", "900K"}}, data.Rows)
+}
+
+func TestFetch_CSVFailures(t *testing.T) {
+	allowLoopback(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/empty" {
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	_, err := Fetch(context.Background(), store.Connector{Kind: store.ConnectorCSV, URL: srv.URL + "/denied"})
+	assert.ErrorContains(t, err, "status 403")
+	_, err = Fetch(context.Background(), store.Connector{Kind: store.ConnectorCSV, URL: srv.URL + "/empty"})
+	assert.ErrorContains(t, err, "no header row")
+	_, err = Fetch(context.Background(), store.Connector{Kind: "ftp"})
+	assert.ErrorContains(t, err, "unknown connector kind")
+}
+
+func TestFetch_Sheets(t *testing.T) {
+	allowLoopback(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/sheet-1/values/Q3!A1:B3", r.URL.Path)
+		assert.Equal(t, "api-key", r.URL.Query().Get("key"))
+		_, _ = w.Write([]byte(`{"range":"Q3!A1:B3","values":[["Metric","Value"],["Deals",42],["Win rate"]]}`))
+	}))
+	defer srv.Close()
+	old := SheetsBaseURL
+	SheetsBaseURL = srv.URL
+	defer func() { SheetsBaseURL = old }()
+
+	data, err := Fetch(context.Background(), store.Connector{Kind: store.ConnectorSheets, SpreadsheetID: "sheet-1", Range: "Q3!A1:B3", Credential: "api-key"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Metric", "Value"}, data.Columns)
+	assert.Equal(t, [][]string{{"Deals", "42"}, {"Win rate"}}, data.Rows)
+}
+
+func TestFetch_RefusesPrivateDestinations(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("a\n1\n"))
+	}))
+	defer srv.Close()
+
+	_, err := Fetch(context.Background(), store.Connector{Kind: store.ConnectorCSV, URL: srv.URL})
+	assert.ErrorIs(t, err, egress.ErrBlocked)
+	_, err = Fetch(context.Background(), store.Connector{Kind: store.ConnectorSQL, Credential: "postgres://u:p@127.0.0.1:1/db?connect_timeout=1", Query: "SELECT 1"})
+	assert.ErrorIs(t, err, egress.ErrBlocked)
+}
+
+func TestCheckDestination(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range []store.Connector{
+		{Kind: store.ConnectorCSV, URL: "http://169.254.169.254/latest/meta-data/"},
+		{Kind: store.ConnectorCSV, URL: "http://10.0.0.5/export.csv"},
+		{Kind: store.ConnectorSQL, Credential: "postgres://u:p@192.168.1.10:5432/crm"},
+		{Kind: store.ConnectorSQL, Credential: "host=/var/run/postgresql dbname=crm"},
+		{Kind: store.ConnectorSQL, Credential: "postgres://u:p@93.184.215.14,127.0.0.1/crm"},
+	} {
+		assert.ErrorIs(t, CheckDestination(ctx, c), egress.ErrBlocked, c.URL+c.Credential)
+	}
+	assert.NoError(t, CheckDestination(ctx, store.Connector{Kind: store.ConnectorCSV, URL: "https://93.184.215.14/export.csv"}))
+	assert.NoError(t, CheckDestination(ctx, store.Connector{Kind: store.ConnectorSQL, Credential: "postgres://u:p@93.184.215.14:5432/crm"}))
+	assert.Error(t, CheckDestination(ctx, store.Connector{Kind: store.ConnectorSQL, Credential: "not a dsn ="}))
+}
+
+func TestIsName(t *testing.T) {
+	for _, name := range []string{"sales", "Sales_2024", "q3-metrics"} {
+		assert.True(t, IsName(name), name)
+	}
+	for _, name := range []string{"", "2024", "sales.q3", "sales q3", "_x"} {
+		assert.False(t, IsName(name), name)
+	}
+}
+
+func TestFields(t *testing.T) {
+	assert.Equal(t, "Q3_Revenue", FieldName("Q3 Revenue ($)"))
+	assert.Equal(t, "win-rate", FieldName(" win-rate "))
+	assert.Equal(t, "", FieldName("%"))
+
+	fields := Fields(store.ConnectorData{
+		Columns: []string{"Region", "Q3 Revenue ($)", "%"},
+		Rows:    [][]string{{"EMEA", "1.2M", "x"}, {"APAC"}},
+	})
+	assert.Equal(t, map[string]string{
+		"Region": "EMEA", "Region.1": "EMEA", "Region.2": "APAC",
+		"Q3_Revenue": "1.2M", "Q3_Revenue.1": "1.2M", "Q3_Revenue.2": "",
+	}, fields)
+}
+
+func TestLive(t *testing.T) {
+	assert.True(t, Live(json.RawMessage(`{"slides":[{"title":"Revenue {{connector.sales.Revenue}}"}]}`)))
+	assert.False(t, Live(json.RawMessage(`{"slides":[{"title":"Hi {{name}}"}]}`)))
+}
+
+// recordingRenderer keeps the spec it was asked to render.
+type recordingRenderer struct {
+	assets.Renderer
+	spec any
+}
+
+func (r *recordingRenderer) RenderPPTXBytes(_ context.Context, s any) ([]byte, error) {
+	r.spec = s
+	return []byte("pptx"), nil
+}
+
+func TestResolver_FillsBindingsAtRender(t *testing.T) {
+	allowLoopback(t)
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		_, _ = w.Write([]byte("Region,Revenue\nEMEA,1.2M\nAPAC,900K\n"))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	st := memory.New()
+	_, err := st.Connectors().Create(ctx, store.Connector{ID: "con-1", OrgID: "org-1", Name: "sales", Kind: store.ConnectorCSV, URL: srv.URL, MaxAgeSeconds: 3600})
+	require.NoError(t, err)
+	res := &Resolver{Store: st}
+
+	next := &recordingRenderer{}
+	r := res.Renderer(next, "org-1")
+	_, err = r.RenderPPTXBytes(ctx, json.RawMessage(`{"slides":[{"title":"{{connector.sales.Region.2}}: {{connector.sales.Revenue.2}}","body":"Hi {{name}}"}]}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"slides":[{"title":"APAC: 900K","body":"Hi {{name}}"}]}`, string(next.spec.(json.RawMessage)))
+
+	saved, ok, err := st.Connectors().Get(ctx, "org-1", "con-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotNil(t, saved.RefreshedAt)
+	assert.Len(t, saved.Data.Rows, 2)
+
+	// Within MaxAgeSeconds the fetched data is reused.
+	_, err = r.RenderPPTXBytes(ctx, json.RawMessage(`{"title":"{{connector.sales.Revenue}}"}`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, hits)
+
+	for spec, want := range map[string]string{
+		`{"title":"{{connector.crm.Deals}}"}`:    `unknown connector "crm"`,
+		`{"title":"{{connector.sales.Margin}}"}`: `no field "Margin"`,
+		`{"title":"{{connector.sales}}"}`:        "names no field",
+	} {
+		_, err := r.RenderPPTXBytes(ctx, json.RawMessage(spec))
+		assert.ErrorContains(t, err, want, spec)
+	}
+
+	// A spec without bindings goes through unchanged, and a nil resolver
+	// leaves the renderer alone.
+	plain := json.RawMessage(`{"title":"x"}`)
+	_, err = r.RenderPPTXBytes(ctx, plain)
+	require.NoError(t, err)
+	assert.Equal(t, plain, next.spec)
+	assert.Same(t, next, (*Resolver)(nil).Renderer(next, "org-1"))
+}
+
+func TestResolver_FallsBackToLastData(t *testing.T) {
+	allowLoopback(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	st := memory.New()
+	stale := time.Now().Add(-time.Hour)
+	_, err := st.Connectors().Create(ctx, store.Connector{ID: "con-1", OrgID: "org-1", Name: "sales", Kind: store.ConnectorCSV, URL: srv.URL,
+		Data: &store.ConnectorData{Columns: []string{"Revenue"}, Rows: [][]string{{"1.1M"}}}, RefreshedAt: &stale})
+	require.NoError(t, err)
+	res := &Resolver{Store: st}
+
+	values, err := res.Values(ctx, "org-1", map[string]any{"title": "{{connector.sales.Revenue}}"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"connector.sales.Revenue": "1.1M"}, values)
+
+	saved, _, err := st.Connectors().Get(ctx, "org-1", "con-1")
+	require.NoError(t, err)
+	assert.Contains(t, saved.LastError, "status 503")
+	assert.Equal(t, stale.Unix(), saved.RefreshedAt.Unix())
+}
@@ -0,0 +1,187 @@
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// Resolver fills connector bindings with the data of the org's connectors.
+type Resolver struct {
+	Store store.Store
+}
+
+// Bindings lists the connector bindings in doc, a decoded JSON spec.
+func Bindings(doc any) []string {
+	var out []string
+	for _, name := range spec.Variables(doc) {
+		if spec.IsConnectorVariable(name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// Live reports whether specJSON has connector bindings, so that rendering it
+// twice may give different decks.
+func Live(specJSON any) bool {
+	b, err := normalize.JSON(specJSON)
+	if err != nil {
+		return false
+	}
+	var doc any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return false
+	}
+	return len(Bindings(doc)) > 0
+}
+
+// Refresh fetches c's data and saves it on the connector. A failed fetch is
+// recorded as the connector's LastError, keeping its earlier data, and
+// returned.
+func (r *Resolver) Refresh(ctx context.Context, c store.Connector) (store.Connector, error) {
+	data, fetchErr := Fetch(ctx, c)
+	if fetchErr == nil {
+		now := time.Now().UTC()
+		c.Data, c.RefreshedAt, c.LastError = &data, &now, ""
+	} else {
+		c.LastError = fetchErr.Error()
+	}
+	updated, err := r.Store.Connectors().Update(ctx, c)
+	if err != nil {
+		return c, fmt.Errorf("save connector data: %w", err)
+	}
+	return updated, fetchErr
+}
+
+// data returns c's data for an export: what was last fetched if that is
+// within c's MaxAgeSeconds, else fresh data. When the source can't be read
+// the last data is used if there is any.
+func (r *Resolver) data(ctx context.Context, c store.Connector) (store.ConnectorData, error) {
+	maxAge := time.Duration(c.MaxAgeSeconds) * time.Second
+	if c.Data != nil && c.RefreshedAt != nil && maxAge > 0 && time.Since(*c.RefreshedAt) <= maxAge {
+		return *c.Data, nil
+	}
+	refreshed, err := r.Refresh(ctx, c)
+	if err != nil {
+		if c.Data == nil {
+			return store.ConnectorData{}, fmt.Errorf("connector %s: %w", c.Name, err)
+		}
+		logger.Logger.Warn("connector_refresh_failed", "component", "connectors", "org_id", c.OrgID, "connector", c.Name, "error", err)
+		return *c.Data, nil
+	}
+	return *refreshed.Data, nil
+}
+
+// Values returns the value of each connector binding in doc, a decoded JSON
+// spec, by binding name. It fails when a binding names a connector or field
+// the org doesn't have.
+func (r *Resolver) Values(ctx context.Context, orgID string, doc any) (map[string]string, error) {
+	bindings := Bindings(doc)
+	if len(bindings) == 0 {
+		return nil, nil
+	}
+	fields := map[string]map[string]string{}
+	values := make(map[string]string, len(bindings))
+	for _, binding := range bindings {
+		name, field, ok := strings.Cut(strings.TrimPrefix(binding, spec.ConnectorPrefix), ".")
+		if !ok || field == "" {
+			return nil, fmt.Errorf("binding %s names no field", binding)
+		}
+		f, seen := fields[name]
+		if !seen {
+			c, found, err := r.Store.Connectors().GetByName(ctx, orgID, name)
+			if err != nil {
+				return nil, fmt.Errorf("load connector %s: %w", name, err)
+			}
+			if !found {
+				return nil, fmt.Errorf("binding %s names unknown connector %q", binding, name)
+			}
+			data, err := r.data(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+			f = Fields(data)
+			fields[name] = f
+		}
+		value, ok := f[field]
+		if !ok {
+			return nil, fmt.Errorf("connector %s has no field %q", name, field)
+		}
+		values[binding] = value
+	}
+	return values, nil
+}
+
+// Renderer wraps next so decks it renders for orgID have their connector
+// bindings filled first; a binding that can't be filled fails the render.
+// The stored spec is not changed. A nil resolver returns next unchanged.
+func (r *Resolver) Renderer(next assets.Renderer, orgID string) assets.Renderer {
+	if r == nil {
+		return next
+	}
+	return &connectorRenderer{Renderer: next, resolver: r, orgID: orgID}
+}
+
+type connectorRenderer struct {
+	assets.Renderer
+	resolver *Resolver
+	orgID    string
+}
+
+func (c *connectorRenderer) RenderPPTX(ctx context.Context, s any, outPath string) error {
+	filled, err := c.fill(ctx, s)
+	if err != nil {
+		return err
+	}
+	return c.Renderer.RenderPPTX(ctx, filled, outPath)
+}
+
+func (c *connectorRenderer) RenderPPTXBytes(ctx context.Context, s any) ([]byte, error) {
+	filled, err := c.fill(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	return c.Renderer.RenderPPTXBytes(ctx, filled)
+}
+
+func (c *connectorRenderer) GenerateSlideThumbnails(ctx context.Context, s any) ([][]byte, error) {
+	filled, err := c.fill(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	return c.Renderer.GenerateSlideThumbnails(ctx, filled)
+}
+
+// fill returns s with its connector bindings filled, or s itself when it
+// has none.
+func (c *connectorRenderer) fill(ctx context.Context, s any) (any, error) {
+	b, err := normalize.JSON(s)
+	if err != nil {
+		return s, nil
+	}
+	var doc any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return s, nil
+	}
+	values, err := c.resolver.Values(ctx, c.orgID, doc)
+	if err != nil {
+		return nil, fmt.Errorf("fill connector bindings: %w", err)
+	}
+	if len(values) == 0 {
+		return s, nil
+	}
+	out, err := json.Marshal(spec.MergeVariables(doc, values))
+	if err != nil {
+		return nil, fmt.Errorf("fill connector bindings: %w", err)
+	}
+	return json.RawMessage(out), nil
+}
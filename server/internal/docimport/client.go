@@ -0,0 +1,59 @@
+package docimport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultNotionAPIBase = "https://api.notion.com/v1"
+
+// notionVersion is the Notion API version the block types are read as.
+const notionVersion = "2022-06-28"
+
+// Client fetches pages with the tokens an org configured.
+type Client struct {
+	NotionAPIBase string
+	HTTPClient    *http.Client
+}
+
+func NewClient() *Client {
+	return &Client{NotionAPIBase: defaultNotionAPIBase, HTTPClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// getJSON fetches u with the given Authorization header and decodes the
+// response into out.
+func (c *Client) getJSON(ctx context.Context, u, authorization string, header http.Header, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package docimport
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	confluencePageURL = regexp.MustCompile(`/pages/(\d+)(?:/|$|\?)`)
+	confluencePageID  = regexp.MustCompile(`[?&]pageId=(\d+)`)
+	confluenceBareID  = regexp.MustCompile(`^\d+$`)
+)
+
+// ParseConfluencePageID accepts a Confluence page URL, in the
+// /pages/<id>/<title> or ?pageId=<id> form, or a bare page ID.
+func ParseConfluencePageID(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if confluenceBareID.MatchString(s) {
+		return s, true
+	}
+	for _, re := range []*regexp.Regexp{confluencePageURL, confluencePageID} {
+		if m := re.FindStringSubmatch(s); m != nil {
+			return m[1], true
+		}
+	}
+	return "", false
+}
+
+// ConfluenceAuth is how an org reaches its Confluence site. BaseURL is the
+// site's root, e.g. "https://acme.atlassian.net/wiki". With Email the token
+// is an Atlassian API token sent with basic auth; without it, a personal
+// access token sent as a bearer token.
+type ConfluenceAuth struct {
+	BaseURL string
+	Email   string
+	Token   string
+}
+
+func (a ConfluenceAuth) header() string {
+	if a.Email != "" {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(a.Email+":"+a.Token))
+	}
+	return "Bearer " + a.Token
+}
+
+// ConfluencePage reads a page's storage-format body.
+func (c *Client) ConfluencePage(ctx context.Context, auth ConfluenceAuth, pageID string) (*Document, error) {
+	u := strings.TrimSuffix(auth.BaseURL, "/") + "/rest/api/content/" + url.PathEscape(pageID) + "?expand=body.storage"
+	var page struct {
+		Title string `json:"title"`
+		Body  struct {
+			Storage struct {
+				Value string `json:"value"`
+			} `json:"storage"`
+		} `json:"body"`
+	}
+	if err := c.getJSON(ctx, u, auth.header(), nil, &page); err != nil {
+		return nil, fmt.Errorf("fetch confluence page: %w", err)
+	}
+	doc, err := ParseConfluenceStorage(page.Body.Storage.Value)
+	if err != nil {
+		return nil, err
+	}
+	doc.Title = strings.TrimSpace(page.Title)
+	return doc, nil
+}
+
+// ParseConfluenceStorage converts a page body in Confluence's storage
+// format, XHTML with ac: and ri: macro elements, into blocks. Macros other
+// than code are read for their text.
+func ParseConfluenceStorage(body string) (*Document, error) {
+	doc := &Document{}
+	d := xml.NewDecoder(strings.NewReader("<root>" + body + "</root>"))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	var (
+		cur   *Block
+		lists []BlockKind // the kinds of the lists the parser is in
+		skip  int         // depth inside elements whose text isn't content
+		cells int         // cells read in the current table row
+	)
+	flush := func() {
+		if cur != nil {
+			doc.add(*cur)
+			cur = nil
+		}
+	}
+	start := func(b Block) {
+		flush()
+		cur = &b
+	}
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read confluence page: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 {
+				skip++
+				continue
+			}
+			switch name := elementName(t.Name); name {
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				start(Block{Kind: BlockHeading, Level: min(int(name[1]-'0'), 3)})
+			case "p", "div":
+				// Paragraphs inside list items, quotes and cells continue them.
+				if cur == nil || cur.Kind == BlockParagraph || cur.Kind == BlockHeading {
+					start(Block{Kind: BlockParagraph})
+				} else {
+					cur.Text += " "
+				}
+			case "ul", "ol":
+				flush()
+				kind := BlockBullet
+				if name == "ol" {
+					kind = BlockNumbered
+				}
+				lists = append(lists, kind)
+			case "li":
+				kind := BlockBullet
+				if len(lists) > 0 {
+					kind = lists[len(lists)-1]
+				}
+				start(Block{Kind: kind, Level: max(len(lists)-1, 0)})
+			case "blockquote":
+				start(Block{Kind: BlockQuote})
+			case "pre":
+				start(Block{Kind: BlockCode})
+			case "ac:structured-macro":
+				if attr(t, "name") == "code" {
+					start(Block{Kind: BlockCode})
+				}
+			case "ac:parameter", "style", "script":
+				skip = 1
+			case "tr":
+				start(Block{Kind: BlockBullet})
+				cells = 0
+			case "td", "th":
+				if cur != nil && cells > 0 {
+					cur.Text += " | "
+				}
+				cells++
+			case "br":
+				if cur != nil {
+					cur.Text += "\n"
+				}
+			}
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			switch name := elementName(t.Name); name {
+			case "h1", "h2", "h3", "h4", "h5", "h6", "li", "blockquote", "pre", "tr":
+				flush()
+			case "p", "div":
+				if cur != nil && cur.Kind == BlockParagraph {
+					flush()
+				}
+			case "ac:structured-macro":
+				if cur != nil && cur.Kind == BlockCode {
+					flush()
+				}
+			case "ul", "ol":
+				flush()
+				if len(lists) > 0 {
+					lists = lists[:len(lists)-1]
+				}
+			}
+		case xml.CharData:
+			if skip > 0 {
+				continue
+			}
+			text := string(t)
+			if cur == nil {
+				if strings.TrimSpace(text) == "" {
+					continue
+				}
+				start(Block{Kind: BlockParagraph})
+			}
+			cur.Text += text
+		}
+	}
+	flush()
+	return doc, nil
+}
+
+// elementName is the element's name with its namespace prefix, as written.
+func elementName(n xml.Name) string {
+	if n.Space == "" {
+		return strings.ToLower(n.Local)
+	}
+	return strings.ToLower(n.Space + ":" + n.Local)
+}
+
+func attr(t xml.StartElement, name string) string {
+	for _, a := range t.Attr {
+		if elementName(a.Name) == "ac:"+name || a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+package docimport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePageIDs(t *testing.T) {
+	id, ok := ParseNotionPageID("https://www.notion.so/acme/Q3-Plan-0123456789abcdef0123456789ABCDEF?pvs=4")
+	assert.True(t, ok)
+	assert.Equal(t, "0123456789abcdef0123456789abcdef", id)
+	id, ok = ParseNotionPageID("01234567-89ab-cdef-0123-456789abcdef")
+	assert.True(t, ok)
+	assert.Equal(t, "0123456789abcdef0123456789abcdef", id)
+	_, ok = ParseNotionPageID("https://www.notion.so/acme/Q3-Plan")
+	assert.False(t, ok)
+
+	for in, want := range map[string]string{
+		"https://acme.atlassian.net/wiki/spaces/ENG/pages/98765/Q3+Plan": "98765",
+		"https://wiki.acme.com/pages/viewpage.action?pageId=4242":        "4242",
+		"12345": "12345",
+	} {
+		id, ok := ParseConfluencePageID(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, want, id, in)
+	}
+	_, ok = ParseConfluencePageID("https://acme.atlassian.net/wiki/spaces/ENG")
+	assert.False(t, ok)
+}
+
+func TestNotionPage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret_abc", r.Header.Get("Authorization"))
+		assert.Equal(t, notionVersion, r.Header.Get("Notion-Version"))
+		switch r.URL.Path {
+		case "/pages/page1":
+			_, _ = w.Write([]byte(`{"properties":{"Name":{"type":"title","title":[{"plain_text":"Q3 "},{"plain_text":"Plan"}]},"Tags":{"type":"multi_select"}}}`))
+		case "/blocks/page1/children":
+			if r.URL.Query().Get("start_cursor") == "" {
+				_, _ = w.Write([]byte(`{"results":[
+					{"id":"b1","type":"heading_1","heading_1":{"rich_text":[{"plain_text":"Goals"}]}},
+					{"id":"b2","type":"bulleted_list_item","has_children":true,"bulleted_list_item":{"rich_text":[{"plain_text":"Grow revenue"}]}},
+					{"id":"b3","type":"child_page","has_children":true,"child_page":{"title":"Other"}}
+				],"has_more":true,"next_cursor":"c2"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"results":[
+				{"id":"b4","type":"heading_1","heading_1":{"rich_text":[{"plain_text":"Risks"}]}},
+				{"id":"b5","type":"paragraph","paragraph":{"rich_text":[{"plain_text":"Hiring is slow."}]}},
+				{"id":"b6","type":"code","code":{"rich_text":[{"plain_text":"SELECT 1"}]}}
+			],"has_more":false}`))
+		case "/blocks/b2/children":
+			_, _ = w.Write([]byte(`{"results":[{"id":"b7","type":"numbered_list_item","numbered_list_item":{"rich_text":[{"plain_text":"EMEA first"}]}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	c := NewClient()
+	c.NotionAPIBase = srv.URL
+
+	doc, err := c.NotionPage(context.Background(), "secret_abc", "page1")
+	require.NoError(t, err)
+	assert.Equal(t, "Q3 Plan", doc.Title)
+	assert.Equal(t, []Block{
+		{Kind: BlockHeading, Text: "Goals", Level: 1},
+		{Kind: BlockBullet, Text: "Grow revenue"},
+		{Kind: BlockNumbered, Text: "EMEA first", Level: 1},
+		{Kind: BlockHeading, Text: "Risks", Level: 1},
+		{Kind: BlockParagraph, Text: "Hiring is slow."},
+		{Kind: BlockCode, Text: "SELECT 1"},
+	}, doc.Blocks)
+	assert.Equal(t, "# Q3 Plan\n\n## Goals\n\n- Grow revenue\n  1. EMEA first\n\n## Risks\n\nHiring is slow.\n\n```\nSELECT 1\n```\n", doc.Markdown())
+	assert.Equal(t, []Section{
+		{Title: "Goals", Points: []string{"Grow revenue", "EMEA first"}},
+		{Title: "Risks", Points: []string{"Hiring is slow."}},
+	}, doc.Sections())
+
+	_, err = c.NotionPage(context.Background(), "secret_abc", "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestConfluencePage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "me@acme.com" || pass != "tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "/wiki/rest/api/content/98765", r.URL.Path)
+		assert.Equal(t, "body.storage", r.URL.Query().Get("expand"))
+		_, _ = w.Write([]byte(`{"title":"Launch plan","body":{"storage":{"value":"<p>Intro&nbsp;text</p><h2>Timeline</h2><ul><li><p>Beta</p><ol><li>Invite users</li></ol></li><li>GA</li></ul>"}}}`))
+	}))
+	defer srv.Close()
+	c := NewClient()
+
+	doc, err := c.ConfluencePage(context.Background(), ConfluenceAuth{BaseURL: srv.URL + "/wiki/", Email: "me@acme.com", Token: "tok"}, "98765")
+	require.NoError(t, err)
+	assert.Equal(t, "Launch plan", doc.Title)
+	assert.Equal(t, []Section{
+		{Title: "Launch plan", Points: []string{"Intro text"}},
+		{Title: "Timeline", Points: []string{"Beta", "Invite users", "GA"}},
+	}, doc.Sections())
+
+	_, err = c.ConfluencePage(context.Background(), ConfluenceAuth{BaseURL: srv.URL + "/wiki", Token: "pat"}, "98765")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestParseConfluenceStorage(t *testing.T) {
+	doc, err := ParseConfluenceStorage(`<h1>Q3</h1>
+<blockquote><p>Ship it.</p></blockquote>
+<ac:structured-macro ac:name="code"><ac:parameter ac:name="language">sql</ac:parameter><ac:plain-text-body><![CDATA[SELECT 1;]]></ac:plain-text-body></ac:structured-macro>
+<table><tbody><tr><th>Region</th><th>Revenue</th></tr><tr><td>EMEA</td><td>1.2M</td></tr></tbody></table>
+<h4>Notes<br/>and more</h4>`)
+	require.NoError(t, err)
+	assert.Equal(t, []Block{
+		{Kind: BlockHeading, Text: "Q3", Level: 1},
+		{Kind: BlockQuote, Text: "Ship it."},
+		{Kind: BlockCode, Text: "SELECT 1;"},
+		{Kind: BlockBullet, Text: "Region | Revenue"},
+		{Kind: BlockBullet, Text: "EMEA | 1.2M"},
+		{Kind: BlockHeading, Text: "Notes and more", Level: 3},
+	}, doc.Blocks)
+}
@@ -0,0 +1,126 @@
+// Package docimport reads pages from Notion and Confluence and converts
+// them into structured content that decks are created from.
+package docimport
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNotFound means the page doesn't exist or the token can't see it.
+	ErrNotFound = errors.New("page not found or not shared with the integration")
+	// ErrUnauthorized means the source rejected the org's token.
+	ErrUnauthorized = errors.New("the page source rejected the org's credentials")
+)
+
+// maxBlocks bounds the blocks read from one page.
+const maxBlocks = 2000
+
+// BlockKind is what a block of a page is.
+type BlockKind string
+
+const (
+	BlockHeading   BlockKind = "heading"
+	BlockParagraph BlockKind = "paragraph"
+	BlockBullet    BlockKind = "bullet"
+	BlockNumbered  BlockKind = "numbered"
+	BlockQuote     BlockKind = "quote"
+	BlockCode      BlockKind = "code"
+)
+
+// Block is one piece of a page. Level is 1 to 3 for headings and the
+// nesting depth, from 0, for list items.
+type Block struct {
+	Kind  BlockKind `json:"kind"`
+	Text  string    `json:"text"`
+	Level int       `json:"level,omitempty"`
+}
+
+// Document is a page converted into blocks.
+type Document struct {
+	Title  string  `json:"title"`
+	Blocks []Block `json:"blocks"`
+}
+
+// Section is a run of blocks under one of the page's top headings; decks
+// built from an outline get a slide per section.
+type Section struct {
+	Title  string
+	Points []string
+}
+
+// add appends a block, collapsing its whitespace, unless it holds no text.
+func (d *Document) add(b Block) {
+	if b.Kind != BlockCode {
+		b.Text = strings.Join(strings.Fields(b.Text), " ")
+	} else {
+		b.Text = strings.Trim(b.Text, "\n")
+	}
+	if strings.TrimSpace(b.Text) == "" || len(d.Blocks) >= maxBlocks {
+		return
+	}
+	d.Blocks = append(d.Blocks, b)
+}
+
+// Markdown renders the document as Markdown, the content a bind job reads.
+func (d *Document) Markdown() string {
+	var b strings.Builder
+	if d.Title != "" {
+		fmt.Fprintf(&b, "# %s\n\n", d.Title)
+	}
+	for i, blk := range d.Blocks {
+		switch blk.Kind {
+		case BlockHeading:
+			fmt.Fprintf(&b, "%s %s\n\n", strings.Repeat("#", blk.Level+1), blk.Text)
+		case BlockBullet, BlockNumbered:
+			marker := "-"
+			if blk.Kind == BlockNumbered {
+				marker = "1."
+			}
+			fmt.Fprintf(&b, "%s%s %s\n", strings.Repeat("  ", blk.Level), marker, blk.Text)
+			// A list ends with a blank line before whatever isn't part of it.
+			if i+1 == len(d.Blocks) || (d.Blocks[i+1].Kind != BlockBullet && d.Blocks[i+1].Kind != BlockNumbered) {
+				b.WriteString("\n")
+			}
+		case BlockQuote:
+			fmt.Fprintf(&b, "> %s\n\n", blk.Text)
+		case BlockCode:
+			fmt.Fprintf(&b, "```\n%s\n```\n\n", blk.Text)
+		default:
+			fmt.Fprintf(&b, "%s\n\n", blk.Text)
+		}
+	}
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+// Sections splits the document at its top-level headings. Text before the
+// first heading is a section titled with the page's title, if there is any.
+// Code is left out, since it doesn't fit on a slide.
+func (d *Document) Sections() []Section {
+	top := 0
+	for _, blk := range d.Blocks {
+		if blk.Kind == BlockHeading && (top == 0 || blk.Level < top) {
+			top = blk.Level
+		}
+	}
+	var out []Section
+	cur, headed := Section{Title: d.Title}, false
+	for _, blk := range d.Blocks {
+		switch {
+		case blk.Kind == BlockHeading && blk.Level == top:
+			if headed || len(cur.Points) > 0 {
+				out = append(out, cur)
+			}
+			cur, headed = Section{Title: blk.Text}, true
+		case blk.Kind == BlockCode:
+		default:
+			cur.Points = append(cur.Points, blk.Text)
+		}
+	}
+	if headed || len(cur.Points) > 0 {
+		out = append(out, cur)
+	}
+	return out
+}
@@ -0,0 +1,148 @@
+package docimport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxNotionDepth bounds how deep nested blocks are read.
+const maxNotionDepth = 3
+
+var notionID = regexp.MustCompile(`([0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12})(?:[?#].*)?$`)
+
+// ParseNotionPageID accepts a Notion page URL or a bare page ID, with or
+// without dashes.
+func ParseNotionPageID(s string) (string, bool) {
+	m := notionID.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", false
+	}
+	return strings.ToLower(strings.ReplaceAll(m[1], "-", "")), true
+}
+
+type notionText struct {
+	PlainText string `json:"plain_text"`
+}
+
+func plainText(rt []notionText) string {
+	var b strings.Builder
+	for _, t := range rt {
+		b.WriteString(t.PlainText)
+	}
+	return b.String()
+}
+
+type notionBlock struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	HasChildren bool   `json:"has_children"`
+	// Text is the rich text of the block's type-named field.
+	Text string `json:"-"`
+}
+
+func (b *notionBlock) UnmarshalJSON(data []byte) error {
+	type plain notionBlock
+	if err := json.Unmarshal(data, (*plain)(b)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var body struct {
+		RichText []notionText `json:"rich_text"`
+	}
+	if raw, ok := fields[b.Type]; ok {
+		_ = json.Unmarshal(raw, &body)
+	}
+	b.Text = plainText(body.RichText)
+	return nil
+}
+
+// NotionPage reads a page and its blocks with an integration token.
+func (c *Client) NotionPage(ctx context.Context, token, pageID string) (*Document, error) {
+	var page struct {
+		Properties map[string]struct {
+			Type  string       `json:"type"`
+			Title []notionText `json:"title"`
+		} `json:"properties"`
+	}
+	if err := c.notionGet(ctx, token, "/pages/"+url.PathEscape(pageID), &page); err != nil {
+		return nil, fmt.Errorf("fetch notion page: %w", err)
+	}
+	doc := &Document{}
+	for _, p := range page.Properties {
+		if p.Type == "title" {
+			doc.Title = strings.TrimSpace(plainText(p.Title))
+		}
+	}
+	if err := c.notionBlocks(ctx, token, pageID, 0, doc); err != nil {
+		return nil, fmt.Errorf("fetch notion blocks: %w", err)
+	}
+	return doc, nil
+}
+
+func (c *Client) notionGet(ctx context.Context, token, path string, out any) error {
+	header := http.Header{"Notion-Version": {notionVersion}}
+	return c.getJSON(ctx, strings.TrimSuffix(c.NotionAPIBase, "/")+path, "Bearer "+token, header, out)
+}
+
+// notionBlocks adds the children of block id to doc, page by page, and the
+// children of those down to maxNotionDepth. List items nest one level deeper
+// per parent item; other containers keep their children at their own depth.
+func (c *Client) notionBlocks(ctx context.Context, token, id string, depth int, doc *Document) error {
+	cursor := ""
+	for {
+		path := "/blocks/" + url.PathEscape(id) + "/children?page_size=100"
+		if cursor != "" {
+			path += "&start_cursor=" + url.QueryEscape(cursor)
+		}
+		var resp struct {
+			Results    []notionBlock `json:"results"`
+			HasMore    bool          `json:"has_more"`
+			NextCursor string        `json:"next_cursor"`
+		}
+		if err := c.notionGet(ctx, token, path, &resp); err != nil {
+			return err
+		}
+		for _, blk := range resp.Results {
+			if len(doc.Blocks) >= maxBlocks {
+				return nil
+			}
+			childDepth := depth
+			switch blk.Type {
+			case "heading_1", "heading_2", "heading_3":
+				doc.add(Block{Kind: BlockHeading, Text: blk.Text, Level: int(blk.Type[len(blk.Type)-1] - '0')})
+			case "paragraph":
+				doc.add(Block{Kind: BlockParagraph, Text: blk.Text})
+			case "bulleted_list_item", "to_do", "toggle":
+				doc.add(Block{Kind: BlockBullet, Text: blk.Text, Level: depth})
+				childDepth = depth + 1
+			case "numbered_list_item":
+				doc.add(Block{Kind: BlockNumbered, Text: blk.Text, Level: depth})
+				childDepth = depth + 1
+			case "quote", "callout":
+				doc.add(Block{Kind: BlockQuote, Text: blk.Text})
+			case "code":
+				doc.add(Block{Kind: BlockCode, Text: blk.Text})
+			case "child_page", "child_database":
+				// Other pages; importing them is a separate import.
+				continue
+			}
+			if blk.HasChildren && childDepth < maxNotionDepth {
+				if err := c.notionBlocks(ctx, token, blk.ID, childDepth, doc); err != nil {
+					return err
+				}
+			}
+		}
+		if !resp.HasMore || resp.NextCursor == "" {
+			return nil
+		}
+		cursor = resp.NextCursor
+	}
+}
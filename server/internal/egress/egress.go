@@ -0,0 +1,161 @@
+// Package egress guards the requests the server makes to addresses its users
+// supply (connector sources and databases, schedule source URLs, webhook
+// endpoints), so they can't be pointed at the server's own network. Every
+// connection is checked after DNS resolution, when the socket is dialled,
+// which also catches names that resolve to a public address once and a
+// private one the next time.
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrBlocked is returned for connections to loopback, private, link-local
+// (such as the 169.254.169.254 cloud metadata endpoint) and other
+// non-public addresses.
+var ErrBlocked = errors.New("destination is not a public address")
+
+// nonPublic are ranges net/netip has no predicate for: "this network",
+// carrier-grade NAT, the IETF protocol block, benchmarking, the reserved
+// 240.0.0.0/4 and NAT64, which can embed any of the IPv4 ones.
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+var (
+	mu      sync.RWMutex
+	allowed []netip.Prefix
+)
+
+// Configure lets connections reach prefixes even though they aren't public,
+// replacing any allowed earlier. Deployments whose data sources live on
+// their own network list them in EGRESS_ALLOWED_CIDRS.
+func Configure(prefixes []netip.Prefix) {
+	mu.Lock()
+	allowed = append([]netip.Prefix(nil), prefixes...)
+	mu.Unlock()
+}
+
+// Permitted reports whether connections to ip are allowed: it is public, or
+// falls in a configured prefix.
+func Permitted(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, p := range allowed {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublic {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// control is a net.Dialer Control func that refuses sockets to addresses
+// that aren't Permitted, and any that aren't TCP or UDP, like Unix sockets.
+func control(network, address string, _ syscall.RawConn) error {
+	switch network {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+	default:
+		return fmt.Errorf("%w: %s %s", ErrBlocked, network, address)
+	}
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlocked, address)
+	}
+	if !Permitted(ap.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlocked, ap.Addr())
+	}
+	return nil
+}
+
+// Dialer returns a dialer that only connects to permitted addresses.
+func Dialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second, Control: control}
+}
+
+// NewClient returns an HTTP client that only connects to permitted
+// addresses, including those it is redirected to, and never through a
+// proxy, which would make the dial target the proxy instead.
+func NewClient(timeout time.Duration) *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = Dialer(10 * time.Second).DialContext
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     t,
+		CheckRedirect: checkRedirect,
+	}
+}
+
+// Client is the client shared by everything that fetches URLs users
+// supply, such as connector sources and schedules' source URLs.
+var Client = NewClient(30 * time.Second)
+
+// checkRedirect follows up to 10 redirects, like the default policy, as
+// long as they stay on http or https; the dialer checks where they lead.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("redirect to %s URL refused", req.URL.Scheme)
+	}
+	return nil
+}
+
+// CheckHost resolves host and returns ErrBlocked unless every address it has
+// is permitted. Requests are checked again when they dial, so this only
+// rejects bad destinations early, when they are saved; a host that doesn't
+// resolve yet is let through to fail then.
+func CheckHost(ctx context.Context, host string) error {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !Permitted(ip) {
+			return fmt.Errorf("%w: %s", ErrBlocked, host)
+		}
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, ip := range ips {
+		if !Permitted(ip) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrBlocked, host, ip)
+		}
+	}
+	return nil
+}
+
+// CheckURL checks the host of an http or https URL with CheckHost.
+func CheckURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("must be an http or https URL")
+	}
+	return CheckHost(ctx, u.Hostname())
+}
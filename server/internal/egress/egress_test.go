@@ -0,0 +1,72 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermitted(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"8.8.8.8":                true,
+		"2606:4700::1111":        true,
+		"127.0.0.1":              false,
+		"::1":                    false,
+		"10.1.2.3":               false,
+		"172.16.0.1":             false,
+		"192.168.1.1":            false,
+		"169.254.169.254":        false,
+		"fe80::1":                false,
+		"fd00::1":                false,
+		"0.0.0.0":                false,
+		"100.64.0.1":             false,
+		"::ffff:169.254.169.254": false,
+		"64:ff9b::a9fe:a9fe":     false,
+		"224.0.0.1":              false,
+	} {
+		assert.Equal(t, want, Permitted(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestConfigure_AllowsListedPrefixes(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+	Configure([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+	assert.True(t, Permitted(netip.MustParseAddr("10.1.2.3")))
+	assert.False(t, Permitted(netip.MustParseAddr("192.168.1.1")))
+}
+
+func TestNewClient_RefusesNonPublicDestinations(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := NewClient(0).Get(srv.URL)
+	assert.True(t, errors.Is(err, ErrBlocked), "got %v", err)
+
+	// A public page can't redirect the client onto the server's network
+	// either; here the allowed "public" server sends it to localhost.
+	t.Cleanup(func() { Configure(nil) })
+	Configure([]netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")})
+	redirect := httptest.NewServer(http.RedirectHandler("http://[::1]:1/", http.StatusFound))
+	defer redirect.Close()
+	_, err = NewClient(0).Get(redirect.URL)
+	assert.True(t, errors.Is(err, ErrBlocked), "got %v", err)
+
+	resp, err := NewClient(0).Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestCheckURL(t *testing.T) {
+	ctx := context.Background()
+	assert.NoError(t, CheckURL(ctx, "https://93.184.215.14/data.csv"))
+	assert.ErrorIs(t, CheckURL(ctx, "http://169.254.169.254/latest/meta-data/"), ErrBlocked)
+	assert.ErrorIs(t, CheckURL(ctx, "http://[::1]:8080/"), ErrBlocked)
+	assert.ErrorIs(t, CheckURL(ctx, "http://localhost/"), ErrBlocked)
+	assert.Error(t, CheckURL(ctx, "file:///etc/passwd"))
+}
@@ -0,0 +1,141 @@
+// Package flags gates features that are rolled out gradually, such as async
+// export or streaming generation. A flag's value for an org is the org's
+// override if it has one, else the platform-wide setting, else the default
+// (FEATURE_FLAGS, then the flag's built-in default).
+package flags
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// Flag names.
+const (
+	AsyncExport         = "async_export"
+	StreamingGeneration = "streaming_generation"
+)
+
+// Flag is a feature that can be switched per org.
+type Flag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+}
+
+// Known lists every flag. Settings for other names are rejected, so a typo
+// can't silently do nothing.
+var Known = []Flag{
+	{Name: AsyncExport, Description: "Export decks through the job queue and notify when the file is ready."},
+	{Name: StreamingGeneration, Description: "Stream generated slides to the client as they are produced."},
+}
+
+// Lookup returns the known flag called name.
+func Lookup(name string) (Flag, bool) {
+	i := slices.IndexFunc(Known, func(f Flag) bool { return f.Name == name })
+	if i < 0 {
+		return Flag{}, false
+	}
+	return Known[i], true
+}
+
+// Set holds every known flag's value for one org.
+type Set map[string]bool
+
+// Enabled reports whether name is on; unknown flags are off.
+func (s Set) Enabled(name string) bool { return s[name] }
+
+// Resolve computes orgID's flags from the defaults (built-in defaults with
+// defaults applied over them) and the stored settings.
+func Resolve(defaults map[string]bool, settings []store.FeatureFlag, orgID string) Set {
+	set := make(Set, len(Known))
+	for _, f := range Known {
+		set[f.Name] = f.Default
+		if v, ok := defaults[f.Name]; ok {
+			set[f.Name] = v
+		}
+	}
+	// Platform-wide settings first, so org overrides win.
+	for _, platform := range []bool{true, false} {
+		for _, s := range settings {
+			if _, known := set[s.Name]; !known || (s.OrgID == "") != platform {
+				continue
+			}
+			if platform || s.OrgID == orgID {
+				set[s.Name] = s.Enabled
+			}
+		}
+	}
+	return set
+}
+
+// cacheTTL bounds how long a flag flipped on another instance takes to apply
+// here; flips through this instance apply at once.
+const cacheTTL = 15 * time.Second
+
+// Evaluator resolves flags for orgs from the store, caching the settings
+// briefly since every request consults them.
+type Evaluator struct {
+	store    store.FeatureFlagStore
+	defaults map[string]bool
+
+	mu       sync.Mutex
+	settings []store.FeatureFlag
+	loaded   time.Time
+}
+
+// NewEvaluator returns an evaluator over st with defaults (from
+// FEATURE_FLAGS) applied over the built-in ones.
+func NewEvaluator(st store.FeatureFlagStore, defaults map[string]bool) *Evaluator {
+	return &Evaluator{store: st, defaults: defaults}
+}
+
+// Defaults returns each known flag's value before any stored setting.
+func (e *Evaluator) Defaults() Set {
+	return Resolve(e.defaults, nil, "")
+}
+
+// For returns orgID's flags. If the settings can't be loaded the last ones
+// loaded are used, or the defaults if there are none.
+func (e *Evaluator) For(ctx context.Context, orgID string) Set {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if time.Since(e.loaded) > cacheTTL {
+		settings, err := e.store.List(ctx)
+		if err != nil {
+			logger.Logger.Warn("feature_flags_load_failed", "error", err)
+		} else {
+			e.settings, e.loaded = settings, time.Now()
+		}
+	}
+	return Resolve(e.defaults, e.settings, orgID)
+}
+
+// Invalidate makes the next For reload the settings.
+func (e *Evaluator) Invalidate() {
+	e.mu.Lock()
+	e.loaded = time.Time{}
+	e.mu.Unlock()
+}
+
+type ctxKey struct{}
+
+// WithSet attaches an org's flags to ctx.
+func WithSet(ctx context.Context, s Set) context.Context {
+	return context.WithValue(ctx, ctxKey{}, s)
+}
+
+// FromContext returns the flags attached by WithSet, or nil.
+func FromContext(ctx context.Context) Set {
+	s, _ := ctx.Value(ctxKey{}).(Set)
+	return s
+}
+
+// Enabled reports whether name is on for the request in ctx.
+func Enabled(ctx context.Context, name string) bool {
+	return FromContext(ctx).Enabled(name)
+}
@@ -0,0 +1,54 @@
+package flags
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+func TestResolve_OrgOverridesPlatformOverridesDefaults(t *testing.T) {
+	settings := []store.FeatureFlag{
+		{Name: AsyncExport, OrgID: "org-a", Enabled: false},
+		{Name: AsyncExport, Enabled: true},
+		{Name: StreamingGeneration, OrgID: "org-b", Enabled: true},
+		{Name: "retired_flag", Enabled: true},
+	}
+	defaults := map[string]bool{StreamingGeneration: false}
+
+	a := Resolve(defaults, settings, "org-a")
+	assert.False(t, a.Enabled(AsyncExport), "the org override wins over the platform setting")
+	assert.False(t, a.Enabled(StreamingGeneration))
+	assert.NotContains(t, a, "retired_flag")
+
+	b := Resolve(defaults, settings, "org-b")
+	assert.True(t, b.Enabled(AsyncExport))
+	assert.True(t, b.Enabled(StreamingGeneration))
+
+	assert.True(t, Resolve(map[string]bool{StreamingGeneration: true}, nil, "").Enabled(StreamingGeneration))
+}
+
+func TestEvaluator_CachesUntilInvalidated(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New().FeatureFlags()
+	e := NewEvaluator(st, nil)
+	assert.False(t, e.For(ctx, "org-1").Enabled(AsyncExport))
+
+	_, err := st.Put(ctx, store.FeatureFlag{Name: AsyncExport, OrgID: "org-1", Enabled: true})
+	require.NoError(t, err)
+	assert.False(t, e.For(ctx, "org-1").Enabled(AsyncExport), "settings are cached")
+
+	e.Invalidate()
+	assert.True(t, e.For(ctx, "org-1").Enabled(AsyncExport))
+	assert.False(t, e.For(ctx, "org-2").Enabled(AsyncExport))
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, Enabled(ctx, AsyncExport))
+	ctx = WithSet(ctx, Set{AsyncExport: true})
+	assert.True(t, Enabled(ctx, AsyncExport))
+}
@@ -0,0 +1,137 @@
+// Package gslides reads presentations from the Google Slides API and converts
+// them into template specs.
+package gslides
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTokenURL = "https://oauth2.googleapis.com/token"
+	defaultAPIBase  = "https://slides.googleapis.com/v1"
+)
+
+var (
+	// ErrNotFound means the presentation doesn't exist or the connected
+	// Google account can't see it; Google answers 404 and 403 alike here.
+	ErrNotFound = errors.New("presentation not found or not shared with the connected Google account")
+	// ErrReauthorize means the refresh token was revoked or expired and the
+	// org has to connect its Google account again.
+	ErrReauthorize = errors.New("google credentials were rejected; reconnect the Google account")
+)
+
+// Client fetches presentations on behalf of an org, trading its refresh
+// token for an access token on each import.
+type Client struct {
+	ClientID     string
+	ClientSecret string
+	TokenURL     string
+	APIBase      string
+	HTTPClient   *http.Client
+}
+
+func NewClient(clientID, clientSecret string) *Client {
+	return &Client{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     defaultTokenURL,
+		APIBase:      defaultAPIBase,
+		HTTPClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Configured reports whether OAuth client credentials were provided.
+func (c *Client) Configured() bool {
+	return c != nil && c.ClientID != "" && c.ClientSecret != ""
+}
+
+var presentationURL = regexp.MustCompile(`/presentation/d/([A-Za-z0-9_-]+)`)
+var presentationID = regexp.MustCompile(`^[A-Za-z0-9_-]{10,}$`)
+
+// ParsePresentationID accepts a Google Slides URL or a bare presentation ID.
+func ParsePresentationID(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if m := presentationURL.FindStringSubmatch(s); m != nil {
+		return m[1], true
+	}
+	if presentationID.MatchString(s) {
+		return s, true
+	}
+	return "", false
+}
+
+// GetPresentation fetches one presentation using the org's refresh token.
+func (c *Client) GetPresentation(ctx context.Context, refreshToken, id string) (*Presentation, error) {
+	token, err := c.accessToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.APIBase+"/presentations/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch presentation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden:
+		return nil, ErrNotFound
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, ErrReauthorize
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("slides api returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var p Presentation
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(&p); err != nil {
+		return nil, fmt.Errorf("decode presentation: %w", err)
+	}
+	return &p, nil
+}
+
+func (c *Client) accessToken(ctx context.Context, refreshToken string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("refresh google token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body)
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		return "", ErrReauthorize
+	}
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		return "", fmt.Errorf("refresh google token: status %d %s", resp.StatusCode, body.Error)
+	}
+	return body.AccessToken, nil
+}
@@ -0,0 +1,265 @@
+package gslides
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+)
+
+const (
+	// emuPerPoint converts the API's PT magnitudes to EMUs, its other unit.
+	emuPerPoint = 12700
+
+	// defaultPageWidth and defaultPageHeight are Slides' 16:9 page in EMUs.
+	defaultPageWidth  = 9144000
+	defaultPageHeight = 5143500
+)
+
+// Presentation is the subset of the Slides API resource the importer reads.
+type Presentation struct {
+	PresentationID string `json:"presentationId"`
+	Title          string `json:"title"`
+	PageSize       Size   `json:"pageSize"`
+	Slides         []Page `json:"slides"`
+	Layouts        []Page `json:"layouts"`
+	Masters        []Page `json:"masters"`
+}
+
+type Dimension struct {
+	Magnitude float64 `json:"magnitude"`
+	Unit      string  `json:"unit"`
+}
+
+func (d Dimension) emu() float64 {
+	if d.Unit == "PT" {
+		return d.Magnitude * emuPerPoint
+	}
+	return d.Magnitude
+}
+
+type Size struct {
+	Width  Dimension `json:"width"`
+	Height Dimension `json:"height"`
+}
+
+type Transform struct {
+	ScaleX     float64 `json:"scaleX"`
+	ScaleY     float64 `json:"scaleY"`
+	TranslateX float64 `json:"translateX"`
+	TranslateY float64 `json:"translateY"`
+	Unit       string  `json:"unit"`
+}
+
+type Page struct {
+	ObjectID         string        `json:"objectId"`
+	PageElements     []PageElement `json:"pageElements"`
+	LayoutProperties *struct {
+		DisplayName string `json:"displayName"`
+	} `json:"layoutProperties,omitempty"`
+	SlideProperties *struct {
+		LayoutObjectID string `json:"layoutObjectId"`
+	} `json:"slideProperties,omitempty"`
+	PageProperties *struct {
+		ColorScheme *struct {
+			Colors []struct {
+				Type  string `json:"type"`
+				Color struct {
+					Red   float64 `json:"red"`
+					Green float64 `json:"green"`
+					Blue  float64 `json:"blue"`
+				} `json:"color"`
+			} `json:"colors"`
+		} `json:"colorScheme,omitempty"`
+	} `json:"pageProperties,omitempty"`
+}
+
+type PageElement struct {
+	ObjectID  string     `json:"objectId"`
+	Size      *Size      `json:"size,omitempty"`
+	Transform *Transform `json:"transform,omitempty"`
+	Shape     *struct {
+		ShapeType   string `json:"shapeType"`
+		Placeholder *struct {
+			Type string `json:"type"`
+		} `json:"placeholder,omitempty"`
+		Text *struct {
+			TextElements []struct {
+				TextRun *struct {
+					Content string `json:"content"`
+				} `json:"textRun,omitempty"`
+			} `json:"textElements"`
+		} `json:"text,omitempty"`
+	} `json:"shape,omitempty"`
+	Image        *struct{} `json:"image,omitempty"`
+	ElementGroup *struct{} `json:"elementGroup,omitempty"`
+	Table        *struct{} `json:"table,omitempty"`
+}
+
+func (e PageElement) text() string {
+	if e.Shape == nil || e.Shape.Text == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, te := range e.Shape.Text.TextElements {
+		if te.TextRun != nil {
+			b.WriteString(te.TextRun.Content)
+		}
+	}
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// chromePlaceholders are slide furniture rather than content.
+var chromePlaceholders = map[string]bool{"SLIDE_NUMBER": true, "FOOTER": true, "DATE_AND_TIME": true, "HEADER": true}
+
+// ToTemplateSpec maps a presentation onto a TemplateSpec draft. Each slide
+// becomes a layout named after the Slides layout it uses, and the first
+// master's color scheme becomes the color tokens. Presentations without
+// slides fall back to their layouts.
+func ToTemplateSpec(p *Presentation) (spec.TemplateSpec, []string) {
+	var warnings []string
+	out := spec.TemplateSpec{
+		Tokens:      map[string]any{"colors": map[string]any{}, "fonts": map[string]any{}, "logos": []any{}, "images": []any{}},
+		Constraints: spec.Constraints{SafeMargin: 0.05},
+	}
+	if len(p.Masters) > 0 {
+		out.Tokens["colors"], out.Tokens["themeColors"] = masterColors(p.Masters[0])
+	} else {
+		warnings = append(warnings, "no master found; colors were left empty")
+	}
+
+	width, height := p.PageSize.Width.emu(), p.PageSize.Height.emu()
+	if width <= 0 || height <= 0 {
+		width, height = defaultPageWidth, defaultPageHeight
+	}
+
+	layoutNames := map[string]string{}
+	for _, l := range p.Layouts {
+		if l.LayoutProperties != nil {
+			layoutNames[l.ObjectID] = l.LayoutProperties.DisplayName
+		}
+	}
+
+	pages, kind := p.Slides, "Slide"
+	if len(pages) == 0 {
+		pages, kind = p.Layouts, "Layout"
+	}
+	for i, page := range pages {
+		name := ""
+		switch {
+		case page.LayoutProperties != nil:
+			name = page.LayoutProperties.DisplayName
+		case page.SlideProperties != nil:
+			name = layoutNames[page.SlideProperties.LayoutObjectID]
+		}
+		if name = strings.TrimSpace(name); name == "" {
+			name = fmt.Sprintf("%s %d", kind, i+1)
+		}
+
+		layout := spec.Layout{Name: name}
+		ids := map[string]int{}
+		var skipped int
+		for _, el := range page.PageElements {
+			base, typ := "", ""
+			switch {
+			case el.Image != nil:
+				base, typ = "image", "image"
+			case el.Shape != nil && el.Shape.Placeholder != nil:
+				pt := el.Shape.Placeholder.Type
+				if chromePlaceholders[pt] {
+					continue
+				}
+				base, typ = placeholderID(pt), "text"
+				if pt == "PICTURE" {
+					typ = "image"
+				}
+			case el.Shape != nil && (el.Shape.ShapeType == "TEXT_BOX" || el.text() != ""):
+				base, typ = "text", "text"
+			default:
+				if el.ElementGroup != nil || el.Table != nil {
+					skipped++
+				}
+				continue
+			}
+			if el.Size == nil || el.Transform == nil {
+				skipped++
+				continue
+			}
+			ids[base]++
+			id := base
+			if n := ids[base]; n > 1 {
+				id = fmt.Sprintf("%s_%d", base, n)
+			}
+			layout.Placeholders = append(layout.Placeholders, spec.Placeholder{
+				ID:       id,
+				Type:     typ,
+				Content:  el.text(),
+				Geometry: geometry(el, width, height),
+			})
+		}
+		if skipped > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: %d groups, tables or unpositioned elements were not imported", name, skipped))
+		}
+		out.Layouts = append(out.Layouts, layout)
+	}
+	return out, warnings
+}
+
+func placeholderID(t string) string {
+	switch t {
+	case "TITLE", "CENTERED_TITLE":
+		return "title"
+	case "SUBTITLE":
+		return "subtitle"
+	case "PICTURE":
+		return "image"
+	default:
+		return "body"
+	}
+}
+
+func geometry(el PageElement, width, height float64) spec.Geometry {
+	t := *el.Transform
+	scale := 1.0
+	if t.Unit == "PT" {
+		scale = emuPerPoint
+	}
+	sx, sy := t.ScaleX, t.ScaleY
+	if sx == 0 {
+		sx = 1
+	}
+	if sy == 0 {
+		sy = 1
+	}
+	clamp := func(v float64) float64 { return min(max(v, 0), 1) }
+	return spec.Geometry{
+		X: clamp(t.TranslateX * scale / width),
+		Y: clamp(t.TranslateY * scale / height),
+		W: clamp(el.Size.Width.emu() * sx / width),
+		H: clamp(el.Size.Height.emu() * sy / height),
+	}
+}
+
+// masterColors returns the renderer's color tokens and the full scheme.
+func masterColors(master Page) (map[string]any, map[string]any) {
+	colors, scheme := map[string]any{}, map[string]any{}
+	if master.PageProperties == nil || master.PageProperties.ColorScheme == nil {
+		return colors, scheme
+	}
+	for _, c := range master.PageProperties.ColorScheme.Colors {
+		scheme[strings.ToLower(c.Type)] = fmt.Sprintf("#%02X%02X%02X",
+			int(c.Color.Red*255+0.5), int(c.Color.Green*255+0.5), int(c.Color.Blue*255+0.5))
+	}
+	for token, key := range map[string]string{"primary": "accent1", "accent": "accent2", "background": "light1", "text": "dark1"} {
+		if v, ok := scheme[key]; ok {
+			colors[token] = v
+		}
+	}
+	return colors, scheme
+}
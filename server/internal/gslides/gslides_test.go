@@ -0,0 +1,116 @@
+package gslides
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPresentationJSON = `{
+  "presentationId": "abc123XYZ_-",
+  "title": "Quarterly Review",
+  "pageSize": {"width": {"magnitude": 9144000, "unit": "EMU"}, "height": {"magnitude": 5143500, "unit": "EMU"}},
+  "masters": [{"objectId": "m1", "pageProperties": {"colorScheme": {"colors": [
+    {"type": "DARK1", "color": {}},
+    {"type": "LIGHT1", "color": {"red": 1, "green": 1, "blue": 1}},
+    {"type": "ACCENT1", "color": {"red": 0.2, "green": 0.4, "blue": 0.8}}
+  ]}}}],
+  "layouts": [{"objectId": "l1", "layoutProperties": {"displayName": "Title slide"}}],
+  "slides": [{
+    "objectId": "s1",
+    "slideProperties": {"layoutObjectId": "l1"},
+    "pageElements": [
+      {"objectId": "e1", "size": {"width": {"magnitude": 3000000, "unit": "EMU"}, "height": {"magnitude": 3000000, "unit": "EMU"}},
+       "transform": {"scaleX": 2, "scaleY": 0.5, "translateX": 457200, "translateY": 514350, "unit": "EMU"},
+       "shape": {"shapeType": "TEXT_BOX", "placeholder": {"type": "CENTERED_TITLE"},
+                 "text": {"textElements": [{"textRun": {"content": "Q3 Results\n"}}]}}},
+      {"objectId": "e2", "size": {"width": {"magnitude": 72, "unit": "PT"}, "height": {"magnitude": 36, "unit": "PT"}},
+       "transform": {"scaleX": 1, "scaleY": 1, "translateX": 0, "translateY": 0, "unit": "EMU"},
+       "shape": {"shapeType": "TEXT_BOX", "placeholder": {"type": "SLIDE_NUMBER"}}},
+      {"objectId": "e3", "elementGroup": {}},
+      {"objectId": "e4", "size": {"width": {"magnitude": 914400, "unit": "EMU"}, "height": {"magnitude": 914400, "unit": "EMU"}},
+       "transform": {"scaleX": 1, "scaleY": 1, "translateX": 8000000, "translateY": 4000000, "unit": "EMU"},
+       "image": {}}
+    ]
+  }]
+}`
+
+func TestParsePresentationID(t *testing.T) {
+	for in, want := range map[string]string{
+		"https://docs.google.com/presentation/d/1AbC-dEf_GhIjKlMn/edit#slide=id.p": "1AbC-dEf_GhIjKlMn",
+		"  1AbC-dEf_GhIjKlMn  ": "1AbC-dEf_GhIjKlMn",
+	} {
+		got, ok := ParsePresentationID(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, want, got)
+	}
+	for _, in := range []string{"", "https://example.com/slides", "short"} {
+		_, ok := ParsePresentationID(in)
+		assert.False(t, ok, in)
+	}
+}
+
+func TestToTemplateSpec(t *testing.T) {
+	var p Presentation
+	require.NoError(t, json.Unmarshal([]byte(testPresentationJSON), &p))
+
+	ts, warnings := ToTemplateSpec(&p)
+	colors := ts.Tokens["colors"].(map[string]any)
+	assert.Equal(t, "#3366CC", colors["primary"])
+	assert.Equal(t, "#FFFFFF", colors["background"])
+	assert.Equal(t, "#000000", colors["text"])
+
+	require.Len(t, ts.Layouts, 1)
+	layout := ts.Layouts[0]
+	assert.Equal(t, "Title slide", layout.Name)
+	require.Len(t, layout.Placeholders, 2, "slide number is dropped")
+
+	title := layout.Placeholders[0]
+	assert.Equal(t, "title", title.ID)
+	assert.Equal(t, "Q3 Results", title.Content)
+	assert.InDelta(t, 0.05, title.Geometry.X, 1e-9)
+	assert.InDelta(t, 0.1, title.Geometry.Y, 1e-9)
+	assert.InDelta(t, 6000000.0/9144000, title.Geometry.W, 1e-9)
+
+	assert.Equal(t, "image", layout.Placeholders[1].Type)
+	assert.Equal(t, []string{"Title slide: 1 groups, tables or unpositioned elements were not imported"}, warnings)
+}
+
+func TestClient_GetPresentation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			require.NoError(t, r.ParseForm())
+			if r.PostForm.Get("refresh_token") != "good-refresh" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"access-1"}`))
+		case "/presentations/abc123XYZ_-":
+			assert.Equal(t, "Bearer access-1", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(testPresentationJSON))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient("client", "secret")
+	c.TokenURL, c.APIBase = srv.URL+"/token", srv.URL
+
+	p, err := c.GetPresentation(context.Background(), "good-refresh", "abc123XYZ_-")
+	require.NoError(t, err)
+	assert.Equal(t, "Quarterly Review", p.Title)
+
+	_, err = c.GetPresentation(context.Background(), "good-refresh", "missing-deck-id")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = c.GetPresentation(context.Background(), "revoked", "abc123XYZ_-")
+	assert.ErrorIs(t, err, ErrReauthorize)
+}
@@ -0,0 +1,119 @@
+// Package imagegen generates images for decks, such as slide backgrounds,
+// from prompts built out of a deck's design theme. Generated images are
+// stored as assets and reused whenever the same prompt comes up again.
+package imagegen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultModel is the text-to-image model used when none is configured.
+	DefaultModel = "black-forest-labs/FLUX.1-schnell"
+
+	defaultHuggingFaceBase = "https://router.huggingface.co/hf-inference/models"
+
+	// maxImageBytes caps a generated image.
+	maxImageBytes = 20 << 20
+)
+
+// Generator turns a prompt into an image.
+type Generator interface {
+	// Model names the model images come from; it is part of the cache key.
+	Model() string
+	// Generate returns the image bytes and their MIME type.
+	Generate(ctx context.Context, prompt string, width, height int) ([]byte, string, error)
+}
+
+// HuggingFace generates images with a text-to-image model on the
+// HuggingFace inference API.
+type HuggingFace struct {
+	APIKey     string
+	ModelID    string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewHuggingFace returns a generator for model, or DefaultModel when model
+// is empty.
+func NewHuggingFace(apiKey, model string) *HuggingFace {
+	if model == "" {
+		model = DefaultModel
+	}
+	return &HuggingFace{
+		APIKey:  apiKey,
+		ModelID: model,
+		BaseURL: defaultHuggingFaceBase,
+		// Cold models take a while to load before the first image.
+		HTTPClient: &http.Client{Timeout: 180 * time.Second},
+	}
+}
+
+func (h *HuggingFace) Model() string { return h.ModelID }
+
+type hfImageRequest struct {
+	Inputs     string            `json:"inputs"`
+	Parameters hfImageParameters `json:"parameters"`
+}
+
+type hfImageParameters struct {
+	Width          int    `json:"width,omitempty"`
+	Height         int    `json:"height,omitempty"`
+	NegativePrompt string `json:"negative_prompt,omitempty"`
+}
+
+func (h *HuggingFace) Generate(ctx context.Context, prompt string, width, height int) ([]byte, string, error) {
+	body, err := json.Marshal(hfImageRequest{
+		Inputs:     prompt,
+		Parameters: hfImageParameters{Width: width, Height: height, NegativePrompt: negativePrompt},
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(h.BaseURL, "/")+"/"+h.ModelID, bytes.NewReader(body))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+h.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "image/png")
+
+	resp, err := h.HTTPClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("HuggingFace API unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusTooManyRequests:
+		// The model is loading or we're rate limited; a retry will do.
+		return nil, "", fmt.Errorf("HuggingFace image API temporary error (status %d): %s", resp.StatusCode, truncate(data))
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("HuggingFace image API error (status %d): %s", resp.StatusCode, truncate(data))
+	case len(data) > maxImageBytes:
+		return nil, "", fmt.Errorf("generated image exceeds %d MB", maxImageBytes>>20)
+	}
+	mime := http.DetectContentType(data)
+	if !strings.HasPrefix(mime, "image/") {
+		return nil, "", fmt.Errorf("HuggingFace image API returned %s, not an image", mime)
+	}
+	return data, mime, nil
+}
+
+func truncate(b []byte) string {
+	if len(b) > 300 {
+		b = b[:300]
+	}
+	return string(b)
+}
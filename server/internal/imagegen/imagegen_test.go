@@ -0,0 +1,142 @@
+package imagegen
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+// pngBytes is enough of a PNG for content sniffing.
+var pngBytes = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type fakeGenerator struct {
+	calls int
+}
+
+func (g *fakeGenerator) Model() string { return "fake/model" }
+
+func (g *fakeGenerator) Generate(context.Context, string, int, int) ([]byte, string, error) {
+	g.calls++
+	return pngBytes, "image/png", nil
+}
+
+func TestBackgroundPrompt(t *testing.T) {
+	theme := ThemeFromSpec(spec.TemplateSpec{
+		Tokens: map[string]any{
+			"colors": map[string]any{"primary": "#0B1F4D", "secondary": "#2E75B6", "accent": "#F39C12", "background": "#FFFFFF"},
+			"style":  "minimal",
+		},
+		Layouts: []spec.Layout{{Placeholders: []spec.Placeholder{
+			{ID: "subtitle", Content: "Q3"},
+			{ID: "title", Content: "Quarterly results"},
+		}}},
+	})
+	assert.Equal(t, Theme{Colors: []string{"#0B1F4D", "#2E75B6", "#F39C12", "#FFFFFF"}, Style: "minimal", Subject: "Quarterly results"}, theme)
+
+	assert.Equal(t,
+		`Abstract presentation slide background in a minimal style for a presentation about "Quarterly results", color palette of deep blue, blue, orange and white. `+
+			`Warm morning light. Soft gradients and subtle shapes, plenty of calm empty space for slide content, high resolution. No text, letters, logos or watermarks.`,
+		BackgroundPrompt(theme, "warm morning light."))
+	assert.Equal(t,
+		`Abstract presentation slide background. Soft gradients and subtle shapes, plenty of calm empty space for slide content, high resolution. No text, letters, logos or watermarks.`,
+		BackgroundPrompt(Theme{Colors: []string{"not a color"}}, ""))
+}
+
+func TestColorName(t *testing.T) {
+	for hex, want := range map[string]string{
+		"#000000": "black",
+		"#F5F5F5": "white",
+		"#7F8C8D": "gray",
+		"#E74C3C": "red",
+		"#0B3D0B": "deep green",
+		"#D6EAF8": "pale blue",
+		"#8E44AD": "purple",
+		"#1ABC9C": "teal",
+		"#12345":  "",
+	} {
+		assert.Equal(t, want, colorName(hex), hex)
+	}
+}
+
+func TestHuggingFace_Generate(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	var got hfImageRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/fake/model", r.URL.Path)
+		assert.Equal(t, "Bearer hf-key", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"error":"Model is currently loading"}`))
+			return
+		}
+		_, _ = w.Write(pngBytes)
+	}))
+	defer srv.Close()
+
+	h := NewHuggingFace("hf-key", "fake/model")
+	h.BaseURL = srv.URL
+	_, _, err := h.Generate(context.Background(), "a calm gradient", 1344, 768)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "temporary", "loading models are retried")
+
+	status = http.StatusOK
+	data, mime, err := h.Generate(context.Background(), "a calm gradient", 1344, 768)
+	require.NoError(t, err)
+	assert.Equal(t, pngBytes, data)
+	assert.Equal(t, "image/png", mime)
+	assert.Equal(t, hfImageRequest{Inputs: "a calm gradient", Parameters: hfImageParameters{Width: 1344, Height: 768, NegativePrompt: negativePrompt}}, got)
+}
+
+func TestPipeline_ReusesImagesByPrompt(t *testing.T) {
+	ctx := context.Background()
+	objects, err := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	require.NoError(t, err)
+	st := memory.New()
+	gen := &fakeGenerator{}
+	p := &Pipeline{Store: st, Objects: objects, Generator: gen}
+	req := Request{Prompt: "a calm gradient"}
+
+	img, err := p.Generate(ctx, "org-1", "user-1", "job-1", req)
+	require.NoError(t, err)
+	assert.Equal(t, p.Key(Request{Prompt: "a calm gradient", Width: DefaultWidth, Height: DefaultHeight}), img.PromptHash)
+	assert.Equal(t, "fake/model", img.Model)
+	asset, ok, err := st.Assets().Get(ctx, "org-1", img.AssetID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, store.AssetImage, asset.Type)
+	assert.Equal(t, "job-1", asset.SourceJobID)
+	data, err := objects.Download(ctx, img.Path)
+	require.NoError(t, err)
+	assert.Equal(t, pngBytes, data)
+
+	again, err := p.Generate(ctx, "org-1", "user-2", "job-2", req)
+	require.NoError(t, err)
+	assert.Equal(t, img.ID, again.ID)
+	assert.Equal(t, 1, gen.calls)
+
+	// Other orgs, sizes and deleted assets don't hit the cache.
+	_, err = p.Generate(ctx, "org-2", "user-3", "job-3", req)
+	require.NoError(t, err)
+	_, err = p.Generate(ctx, "org-1", "user-1", "job-4", Request{Prompt: req.Prompt, Width: 512, Height: 512})
+	require.NoError(t, err)
+	assert.Equal(t, 3, gen.calls)
+
+	_, err = st.Assets().Delete(ctx, "org-1", img.AssetID)
+	require.NoError(t, err)
+	_, ok, err = p.Cached(ctx, "org-1", req)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, err = p.Generate(ctx, "org-1", "user-1", "job-5", req)
+	require.NoError(t, err)
+	assert.Equal(t, 4, gen.calls)
+}
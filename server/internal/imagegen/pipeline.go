@@ -0,0 +1,137 @@
+package imagegen
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// Default size of a generated background: 16:9, in multiples of 64 as
+// diffusion models prefer.
+const (
+	DefaultWidth  = 1344
+	DefaultHeight = 768
+)
+
+// Request is an image to generate.
+type Request struct {
+	Prompt string
+	Width  int
+	Height int
+}
+
+func (r Request) withDefaults() Request {
+	if r.Width <= 0 {
+		r.Width = DefaultWidth
+	}
+	if r.Height <= 0 {
+		r.Height = DefaultHeight
+	}
+	return r
+}
+
+// Pipeline generates images and keeps them as org assets, reusing an org's
+// earlier image whenever the same prompt, model and size come up again.
+type Pipeline struct {
+	Store     store.Store
+	Objects   assets.ObjectStorage
+	Generator Generator
+}
+
+// Key is the cache key for req.
+func (p *Pipeline) Key(req Request) string {
+	req = req.withDefaults()
+	return PromptKey(p.Generator.Model(), req.Prompt, req.Width, req.Height)
+}
+
+// Cached returns the org's stored image for req. Images whose asset has
+// since been deleted don't count.
+func (p *Pipeline) Cached(ctx context.Context, orgID string, req Request) (store.GeneratedImage, bool, error) {
+	img, ok, err := p.Store.GeneratedImages().GetByPromptHash(ctx, orgID, p.Key(req))
+	if err != nil || !ok {
+		return store.GeneratedImage{}, false, err
+	}
+	if _, ok, err := p.Store.Assets().Get(ctx, orgID, img.AssetID); err != nil || !ok {
+		return store.GeneratedImage{}, false, err
+	}
+	return img, true, nil
+}
+
+// Generate returns the org's image for req, generating and storing it first
+// when there is none. jobID and createdBy are recorded on new images.
+func (p *Pipeline) Generate(ctx context.Context, orgID, createdBy, jobID string, req Request) (store.GeneratedImage, error) {
+	req = req.withDefaults()
+	if img, ok, err := p.Cached(ctx, orgID, req); err != nil {
+		return store.GeneratedImage{}, fmt.Errorf("check image cache: %w", err)
+	} else if ok {
+		logger.Jobs().Info("generated_image_reused", "org_id", orgID, "image_id", img.ID)
+		return img, nil
+	}
+
+	data, mime, err := p.Generator.Generate(ctx, req.Prompt, req.Width, req.Height)
+	if err != nil {
+		return store.GeneratedImage{}, err
+	}
+
+	assetID := uuid.New().String()
+	key := assetID + extension(mime)
+	objects, err := assets.ForOrg(ctx, p.Store.Organizations(), orgID)
+	if err != nil {
+		return store.GeneratedImage{}, err
+	}
+	if _, err := p.Objects.Upload(objects, key, data, mime); err != nil {
+		return store.GeneratedImage{}, fmt.Errorf("failed to upload generated image: %w", err)
+	}
+	var img store.GeneratedImage
+	err = p.Store.WithTx(ctx, func(tx store.Store) error {
+		asset, err := tx.Assets().Create(ctx, store.Asset{
+			ID:          assetID,
+			OrgID:       orgID,
+			Type:        store.AssetImage,
+			Path:        key,
+			Mime:        mime,
+			SizeBytes:   int64(len(data)),
+			SourceJobID: jobID,
+			Region:      assets.RegionFrom(objects),
+			SHA256:      assets.Checksum(data),
+		})
+		if err != nil {
+			return err
+		}
+		img, err = tx.GeneratedImages().Create(ctx, store.GeneratedImage{
+			ID:          uuid.New().String(),
+			OrgID:       orgID,
+			PromptHash:  p.Key(req),
+			Prompt:      req.Prompt,
+			Model:       p.Generator.Model(),
+			Width:       req.Width,
+			Height:      req.Height,
+			AssetID:     asset.ID,
+			Path:        key,
+			Mime:        mime,
+			SourceJobID: jobID,
+			CreatedBy:   createdBy,
+		})
+		return err
+	})
+	if err != nil {
+		_ = p.Objects.Delete(objects, key)
+		return store.GeneratedImage{}, fmt.Errorf("failed to save generated image: %w", err)
+	}
+	return img, nil
+}
+
+func extension(mime string) string {
+	switch mime {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ".png"
+	}
+}
@@ -0,0 +1,176 @@
+package imagegen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+)
+
+// negativePrompt steers models that accept one away from what would clash
+// with the slide's own content.
+const negativePrompt = "text, letters, words, numbers, logo, watermark, signature, people, faces, cluttered"
+
+// Theme is the part of a deck's design a background prompt is built from.
+type Theme struct {
+	// Colors are hex colors, most prominent first.
+	Colors []string
+	// Style is a free-form design style, e.g. "minimal" or "corporate".
+	Style string
+	// Subject is what the deck is about, typically its title slide.
+	Subject string
+}
+
+// themeColors are the color tokens a background uses, in order.
+var themeColors = []string{"primary", "secondary", "accent", "background"}
+
+// ThemeFromSpec reads a Theme from a deck or template spec: the colors and
+// "style" tokens and the first slide's title.
+func ThemeFromSpec(s spec.TemplateSpec) Theme {
+	var t Theme
+	if colors, ok := s.Tokens["colors"].(map[string]any); ok {
+		for _, name := range themeColors {
+			if c, ok := colors[name].(string); ok && c != "" {
+				t.Colors = append(t.Colors, c)
+			}
+		}
+	}
+	if style, ok := s.Tokens["style"].(string); ok {
+		t.Style = strings.TrimSpace(style)
+	}
+	if len(s.Layouts) > 0 {
+		for _, ph := range s.Layouts[0].Placeholders {
+			id := strings.ToLower(ph.ID)
+			if strings.Contains(id, "title") && !strings.Contains(id, "subtitle") {
+				t.Subject = strings.TrimSpace(ph.Content)
+				break
+			}
+		}
+	}
+	return t
+}
+
+// BackgroundPrompt describes a slide background for t. extra, when set, is
+// added as the user's own direction.
+func BackgroundPrompt(t Theme, extra string) string {
+	var b strings.Builder
+	b.WriteString("Abstract presentation slide background")
+	if t.Style != "" {
+		fmt.Fprintf(&b, " in a %s style", t.Style)
+	}
+	if t.Subject != "" {
+		fmt.Fprintf(&b, " for a presentation about %q", t.Subject)
+	}
+	if names := colorNames(t.Colors); len(names) > 0 {
+		b.WriteString(", color palette of ")
+		if len(names) == 1 {
+			b.WriteString(names[0])
+		} else {
+			b.WriteString(strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1])
+		}
+	}
+	b.WriteString(". ")
+	if extra = strings.TrimSpace(extra); extra != "" {
+		b.WriteString(strings.ToUpper(extra[:1]) + strings.TrimSuffix(extra[1:], ".") + ". ")
+	}
+	b.WriteString("Soft gradients and subtle shapes, plenty of calm empty space for slide content, high resolution. No text, letters, logos or watermarks.")
+	return b.String()
+}
+
+// PromptKey identifies the image model would produce for prompt at the
+// given size; images are cached under it.
+func PromptKey(model, prompt string, width, height int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%dx%d\n%s", model, width, height, prompt)))
+	return hex.EncodeToString(sum[:])
+}
+
+// colorNames turns hex colors into words models understand, dropping
+// repeats and colors that can't be parsed.
+func colorNames(colors []string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, c := range colors {
+		name := colorName(c)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// colorName names a #RRGGBB color, e.g. "deep blue" or "light gray".
+func colorName(hexColor string) string {
+	h := strings.TrimPrefix(strings.TrimSpace(hexColor), "#")
+	if len(h) != 6 {
+		return ""
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return ""
+	}
+	r, g, bl := float64(v>>16&0xff)/255, float64(v>>8&0xff)/255, float64(v&0xff)/255
+	hi, lo := math.Max(r, math.Max(g, bl)), math.Min(r, math.Min(g, bl))
+	light := (hi + lo) / 2
+
+	if hi-lo < 0.12 {
+		switch {
+		case light < 0.15:
+			return "black"
+		case light > 0.92:
+			return "white"
+		case light < 0.4:
+			return "dark gray"
+		case light > 0.7:
+			return "light gray"
+		default:
+			return "gray"
+		}
+	}
+
+	var hue float64
+	switch hi {
+	case r:
+		hue = math.Mod((g-bl)/(hi-lo), 6)
+	case g:
+		hue = (bl-r)/(hi-lo) + 2
+	default:
+		hue = (r-g)/(hi-lo) + 4
+	}
+	hue *= 60
+	if hue < 0 {
+		hue += 360
+	}
+
+	var name string
+	switch {
+	case hue < 15 || hue >= 335:
+		name = "red"
+	case hue < 40:
+		name = "orange"
+	case hue < 65:
+		name = "yellow"
+	case hue < 165:
+		name = "green"
+	case hue < 195:
+		name = "teal"
+	case hue < 255:
+		name = "blue"
+	case hue < 290:
+		name = "purple"
+	default:
+		name = "pink"
+	}
+	switch {
+	case light < 0.3:
+		return "deep " + name
+	case light > 0.8:
+		return "pale " + name
+	}
+	return name
+}
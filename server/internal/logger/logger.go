@@ -5,6 +5,8 @@ import (
 	"io"
 	"log/slog"
 	"os"
+
+	"github.com/ziyad/cms-ai/server/internal/redact"
 )
 
 var (
@@ -66,7 +68,8 @@ func Initialize(config *Config) {
 	// Create handler based on format
 	var handler slog.Handler
 	opts := &slog.HandlerOptions{
-		Level: level,
+		Level:       level,
+		ReplaceAttr: redactAttr,
 	}
 
 	if config.Format == "json" {
@@ -79,6 +82,20 @@ func Initialize(config *Config) {
 	slog.SetDefault(Logger)
 }
 
+// redactAttr masks personal data in string and error values, so prompts,
+// emails and the like never reach the log output verbatim.
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(redact.String(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(redact.String(err.Error()))
+		}
+	}
+	return a
+}
+
 func init() {
 	// Ensure Logger is never nil by setting a default
 	if Logger == nil {
@@ -96,6 +113,29 @@ const (
 	TraceIDKey   contextKey = "trace_id"
 )
 
+// ContextWithRequestID stores the request ID so WithContext can attach it to
+// every log line emitted while serving the request, including store calls.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, RequestIDKey, requestID)
+}
+
+// ContextWithIdentity stores the authenticated user and org for log correlation.
+func ContextWithIdentity(ctx context.Context, userID, orgID string) context.Context {
+	ctx = context.WithValue(ctx, UserIDKey, userID)
+	return context.WithValue(ctx, OrgIDKey, orgID)
+}
+
+// RequestIDFromContext returns the request ID stored by ContextWithRequestID.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDKey).(string)
+	return id
+}
+
+// DebugEnabled reports whether debug output is enabled (LOG_LEVEL=debug).
+func DebugEnabled(ctx context.Context) bool {
+	return Logger.Enabled(ctx, slog.LevelDebug)
+}
+
 // WithContext adds context values to logger
 func WithContext(ctx context.Context) *slog.Logger {
 	logger := Logger
@@ -196,7 +236,7 @@ func LogAuthEvent(ctx context.Context, event string, userID string, success bool
 }
 
 func LogError(ctx context.Context, component string, operation string, err error, fields ...any) {
-	logger := Logger.With(
+	logger := WithContext(ctx).With(
 		"component", component,
 		"operation", operation,
 		"error", err.Error(),
@@ -211,7 +251,7 @@ func LogError(ctx context.Context, component string, operation string, err error
 }
 
 func LogBusinessEvent(ctx context.Context, event string, fields ...any) {
-	logger := Logger.With("event", event)
+	logger := WithContext(ctx).With("event", event)
 
 	if len(fields) > 0 {
 		logger = logger.With(fields...)
@@ -0,0 +1,75 @@
+// Package mail sends transactional email to users, such as the links that
+// confirm a change of address, through an SMTP relay.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// Message is one plain-text email to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages. SMTP is the production implementation.
+type Sender interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// SMTP sends through a relay, authenticating when Username is set.
+type SMTP struct {
+	Addr     string // host:port
+	Username string
+	Password string
+	From     string
+
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// FromEnv configures a relay from SMTP_ADDR, SMTP_USERNAME, SMTP_PASSWORD
+// and SMTP_FROM, the same variables alert email uses. It returns nil when
+// SMTP_ADDR is unset.
+func FromEnv(getenv func(string) string) *SMTP {
+	addr := getenv("SMTP_ADDR")
+	if addr == "" {
+		return nil
+	}
+	s := &SMTP{
+		Addr:     addr,
+		Username: getenv("SMTP_USERNAME"),
+		Password: getenv("SMTP_PASSWORD"),
+		From:     getenv("SMTP_FROM"),
+	}
+	if s.From == "" {
+		s.From = "cms-ai@localhost"
+	}
+	return s
+}
+
+// Send implements Sender. net/smtp takes no context, so ctx is unused.
+func (s *SMTP) Send(_ context.Context, m Message) error {
+	if strings.ContainsAny(m.To, "\r\n") {
+		return fmt.Errorf("invalid recipient %q", m.To)
+	}
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, _ := strings.Cut(s.Addr, ":")
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", s.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", m.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(m.Subject))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	send := s.sendMail
+	if send == nil {
+		send = smtp.SendMail
+	}
+	return send(s.Addr, auth, s.From, []string{m.To}, []byte(msg.String()))
+}
@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"context"
+	"net/smtp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromEnv(t *testing.T) {
+	assert.Nil(t, FromEnv(func(string) string { return "" }))
+
+	env := map[string]string{"SMTP_ADDR": "smtp.example.com:587", "SMTP_USERNAME": "u"}
+	s := FromEnv(func(k string) string { return env[k] })
+	require.NotNil(t, s)
+	assert.Equal(t, "smtp.example.com:587", s.Addr)
+	assert.Equal(t, "cms-ai@localhost", s.From)
+}
+
+func TestSMTP_Send(t *testing.T) {
+	var mailed string
+	var rcpts []string
+	s := &SMTP{
+		Addr: "smtp.example.com:25",
+		From: "cms@example.com",
+		sendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			mailed, rcpts = string(msg), to
+			return nil
+		},
+	}
+	require.NoError(t, s.Send(context.Background(), Message{To: "ana@example.com", Subject: "Confirm\nyour email", Body: "line 1\nline 2"}))
+
+	assert.Equal(t, []string{"ana@example.com"}, rcpts)
+	assert.Contains(t, mailed, "To: ana@example.com\r\n")
+	assert.Contains(t, mailed, "Subject: Confirm your email\r\n")
+	assert.Contains(t, mailed, "line 1\r\nline 2")
+
+	assert.Error(t, s.Send(context.Background(), Message{To: "a@example.com\r\nBcc: b@example.com"}))
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+)
+
+// writeError answers with the API's error body, so requests rejected here
+// look the same to clients as those rejected by handlers. The codes are
+// those of api.ErrorResponse.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"code":      code,
+		"message":   msg,
+		"error":     msg,
+		"requestId": logger.RequestIDFromContext(r.Context()),
+	})
+}
@@ -1,12 +1,12 @@
 package middleware
 
 import (
-	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"regexp"
 	"strings"
@@ -27,15 +27,18 @@ func GenerateRequestID() string {
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		requestID := GenerateRequestID()
+		// Honor an upstream request ID (load balancer, frontend) so logs correlate end to end.
+		requestID := r.Header.Get("X-Request-Id")
+		if requestID == "" {
+			requestID = GenerateRequestID()
+		}
 
 		// Add request ID to context
-		ctx := context.WithValue(r.Context(), logger.RequestIDKey, requestID)
+		ctx := logger.ContextWithRequestID(r.Context(), requestID)
 
 		// Add authentication context if available
 		if identity, ok := auth.GetIdentity(r.Context()); ok {
-			ctx = context.WithValue(ctx, logger.UserIDKey, identity.UserID)
-			ctx = context.WithValue(ctx, logger.OrgIDKey, identity.OrgID)
+			ctx = logger.ContextWithIdentity(ctx, identity.UserID, identity.OrgID)
 		}
 
 		r = r.WithContext(ctx)
@@ -50,7 +53,7 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		wrapped.Header().Set("X-Request-ID", requestID)
 
 		// Log request start
-		logger.WithContext(ctx).Info("request_start",
+		logger.WithContext(ctx).Debug("request_start",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"query", r.URL.RawQuery,
@@ -98,7 +101,7 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 					"path", r.URL.Path,
 				)
 
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				writeError(w, r, http.StatusInternalServerError, "ERR_INTERNAL", "internal server error")
 			}
 		}()
 
@@ -114,29 +117,30 @@ func ValidationMiddleware(next http.Handler) http.Handler {
 		// Validate request method
 		if !isValidHTTPMethod(r.Method) {
 			logger.WithContext(ctx).Warn("invalid_http_method", "method", r.Method)
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			writeError(w, r, http.StatusMethodNotAllowed, "ERR_METHOD_NOT_ALLOWED", "method not allowed")
 			return
 		}
 
 		// Validate URL path for suspicious patterns
 		if hasSuspiciousPath(r.URL.Path) {
 			logger.WithContext(ctx).Warn("suspicious_url_path", "path", r.URL.Path)
-			http.Error(w, "Invalid request path", http.StatusBadRequest)
+			writeError(w, r, http.StatusBadRequest, "ERR_BAD_REQUEST", "invalid request path")
 			return
 		}
 
 		// Validate headers for injection attempts
 		if hasSuspiciousHeaders(r.Header) {
 			logger.WithContext(ctx).Warn("suspicious_headers")
-			http.Error(w, "Invalid request headers", http.StatusBadRequest)
+			writeError(w, r, http.StatusBadRequest, "ERR_BAD_REQUEST", "invalid request headers")
 			return
 		}
 
-		// Validate and sanitize JSON body for POST/PUT requests
-		if r.Method == "POST" || r.Method == "PUT" || r.Method == "PATCH" {
+		// Validate and sanitize JSON body for POST/PUT requests. File uploads
+		// are multipart or CSV and are size-checked by their handlers instead.
+		if (r.Method == "POST" || r.Method == "PUT" || r.Method == "PATCH") && !isUpload(r) {
 			if err := validateJSONBody(r); err != nil {
 				logger.WithContext(ctx).Warn("invalid_json_body", "error", err)
-				http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+				writeError(w, r, http.StatusBadRequest, "ERR_INVALID_JSON", fmt.Sprintf("invalid request body: %v", err))
 				return
 			}
 		}
@@ -144,7 +148,7 @@ func ValidationMiddleware(next http.Handler) http.Handler {
 		// Validate query parameters
 		if err := validateQueryParams(r); err != nil {
 			logger.WithContext(ctx).Warn("invalid_query_params", "error", err)
-			http.Error(w, fmt.Sprintf("Invalid query parameters: %v", err), http.StatusBadRequest)
+			writeError(w, r, http.StatusBadRequest, "ERR_BAD_REQUEST", fmt.Sprintf("invalid query parameters: %v", err))
 			return
 		}
 
@@ -207,6 +211,12 @@ func hasSuspiciousHeaders(headers http.Header) bool {
 	return false
 }
 
+// isUpload reports whether the request carries a multipart or CSV upload
+func isUpload(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && (mediaType == "multipart/form-data" || mediaType == "text/csv")
+}
+
 // validateJSONBody validates and limits JSON request bodies
 func validateJSONBody(r *http.Request) error {
 	if r.Body == nil {
@@ -0,0 +1,263 @@
+// Package policy enforces an org's compliance rules on decks: which
+// templates decks may start from, slides every deck must carry, and what
+// deck content must not say. A nil policy allows everything.
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// Limits on a policy's size, so checking one stays cheap.
+const (
+	MaxAllowedTemplates = 200
+	MaxMandatorySlides  = 5
+	MaxContentRules     = 50
+	maxPatternLength    = 500
+	maxSlideText        = 2000
+)
+
+// Kinds of Violation.
+const (
+	KindTemplate = "template"
+	KindContent  = "content"
+)
+
+// Violation is one way a deck breaks its org's policy.
+type Violation struct {
+	Kind string `json:"kind"`
+	// Rule names the content rule broken; empty for other kinds.
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+	// Slide is the 1-based slide the content is on; 0 means the request's
+	// own content.
+	Slide int `json:"slide,omitempty"`
+	// Match is the text that broke the rule.
+	Match string `json:"match,omitempty"`
+}
+
+// ViolationError is a deck or request that breaks its org's policy.
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	msg := "policy violation: " + e.Violations[0].Message
+	if n := len(e.Violations) - 1; n > 0 {
+		msg += fmt.Sprintf(" (and %d more)", n)
+	}
+	return msg
+}
+
+// Validate checks that p is well formed: within the limits, with content
+// rules that are named, unique and compile, and mandatory slides that have
+// text and a known position.
+func Validate(p store.OrgPolicy) error {
+	if len(p.AllowedTemplates) > MaxAllowedTemplates {
+		return fmt.Errorf("at most %d allowed templates", MaxAllowedTemplates)
+	}
+	for _, id := range p.AllowedTemplates {
+		if strings.TrimSpace(id) == "" {
+			return fmt.Errorf("allowed template IDs must not be empty")
+		}
+	}
+	if len(p.MandatorySlides) > MaxMandatorySlides {
+		return fmt.Errorf("at most %d mandatory slides", MaxMandatorySlides)
+	}
+	for i, m := range p.MandatorySlides {
+		if strings.TrimSpace(m.Title) == "" && strings.TrimSpace(m.Body) == "" {
+			return fmt.Errorf("mandatory slide %d needs a title or body", i+1)
+		}
+		if len(m.Title)+len(m.Body) > maxSlideText {
+			return fmt.Errorf("mandatory slide %d is longer than %d characters", i+1, maxSlideText)
+		}
+		if m.Position != "" && m.Position != "start" && m.Position != "end" {
+			return fmt.Errorf("mandatory slide %d: position must be start or end", i+1)
+		}
+	}
+	if len(p.ContentRules) > MaxContentRules {
+		return fmt.Errorf("at most %d content rules", MaxContentRules)
+	}
+	names := map[string]bool{}
+	for i, r := range p.ContentRules {
+		if strings.TrimSpace(r.Name) == "" {
+			return fmt.Errorf("content rule %d needs a name", i+1)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("content rule %q is defined twice", r.Name)
+		}
+		names[r.Name] = true
+		if r.Pattern == "" || len(r.Pattern) > maxPatternLength {
+			return fmt.Errorf("content rule %q needs a pattern of at most %d characters", r.Name, maxPatternLength)
+		}
+		if _, err := compile(r.Pattern); err != nil {
+			return fmt.Errorf("content rule %q: invalid pattern: %v", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// CheckTemplate fails with a ViolationError when p doesn't allow decks from
+// templateID.
+func CheckTemplate(p *store.OrgPolicy, templateID string) error {
+	if p == nil || len(p.AllowedTemplates) == 0 || slices.Contains(p.AllowedTemplates, templateID) {
+		return nil
+	}
+	return &ViolationError{Violations: []Violation{{Kind: KindTemplate, Message: "decks must be created from one of the org's approved templates"}}}
+}
+
+// CheckText fails with a ViolationError when text, such as the content a
+// deck is generated from, breaks p's content rules.
+func CheckText(p *store.OrgPolicy, text string) error {
+	if v := contentViolations(p, text, 0); len(v) > 0 {
+		return &ViolationError{Violations: v}
+	}
+	return nil
+}
+
+// CheckSpec fails with a ViolationError when the text of any slide of s
+// breaks p's content rules.
+func CheckSpec(p *store.OrgPolicy, s spec.TemplateSpec) error {
+	if p == nil || len(p.ContentRules) == 0 {
+		return nil
+	}
+	var out []Violation
+	for i, layout := range s.Layouts {
+		out = append(out, slideViolations(p, layout, i+1)...)
+	}
+	if len(out) > 0 {
+		return &ViolationError{Violations: out}
+	}
+	return nil
+}
+
+// CheckSlide is CheckSpec for one slide, the nth of its deck counting from
+// 1.
+func CheckSlide(p *store.OrgPolicy, slide spec.Layout, n int) error {
+	if v := slideViolations(p, slide, n); len(v) > 0 {
+		return &ViolationError{Violations: v}
+	}
+	return nil
+}
+
+func slideViolations(p *store.OrgPolicy, slide spec.Layout, n int) []Violation {
+	var text []string
+	for _, ph := range slide.Placeholders {
+		if ph.Content != "" {
+			text = append(text, ph.Content)
+		}
+	}
+	return contentViolations(p, strings.Join(text, "\n"), n)
+}
+
+// contentViolations checks text, found on slide (0 for request content),
+// against each of p's content rules, reporting each rule's first match.
+// Rules whose pattern doesn't compile, which Validate keeps out, are
+// skipped.
+func contentViolations(p *store.OrgPolicy, text string, slide int) []Violation {
+	if p == nil {
+		return nil
+	}
+	var out []Violation
+	for _, r := range p.ContentRules {
+		re, err := compile(r.Pattern)
+		if err != nil {
+			continue
+		}
+		match := re.FindString(text)
+		if match == "" {
+			continue
+		}
+		msg := r.Message
+		if msg == "" {
+			msg = fmt.Sprintf("content breaks the %q rule", r.Name)
+		}
+		out = append(out, Violation{Kind: KindContent, Rule: r.Name, Message: msg, Slide: slide, Match: match})
+	}
+	return out
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// AddMandatorySlides adds the mandatory slides of p that s lacks, at the
+// start or end, and returns how many it added. A slide counts as present
+// when one of s's slides already carries its body, or its title when it has
+// no body. New slides reuse the geometry of a layout of s with a title and
+// a body, so they match the deck's design.
+func AddMandatorySlides(p *store.OrgPolicy, s *spec.TemplateSpec) int {
+	if p == nil {
+		return 0
+	}
+	var start, end []spec.Layout
+	for _, m := range p.MandatorySlides {
+		if hasSlide(*s, m) {
+			continue
+		}
+		slide := mandatoryLayout(*s, m)
+		if m.Position == "start" {
+			start = append(start, slide)
+		} else {
+			end = append(end, slide)
+		}
+	}
+	if len(start)+len(end) == 0 {
+		return 0
+	}
+	s.Layouts = append(append(start, s.Layouts...), end...)
+	return len(start) + len(end)
+}
+
+// hasSlide reports whether a slide of s already carries m.
+func hasSlide(s spec.TemplateSpec, m store.MandatorySlide) bool {
+	want := squash(m.Body)
+	if want == "" {
+		want = squash(m.Title)
+	}
+	if want == "" {
+		return true
+	}
+	for _, layout := range s.Layouts {
+		for _, ph := range layout.Placeholders {
+			if strings.Contains(squash(ph.Content), want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// squash lowercases text and collapses its whitespace, so line breaks and
+// case don't hide a slide's text.
+func squash(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// mandatoryLayout builds m as a slide. It copies the first layout of s with
+// two or more text placeholders, filling the first with the title and the
+// second with the body and dropping the rest; without one it falls back to
+// a plain title and body.
+func mandatoryLayout(s spec.TemplateSpec, m store.MandatorySlide) spec.Layout {
+	title := spec.Placeholder{ID: "title", Type: "text", Geometry: spec.Geometry{X: 0.05, Y: 0.05, W: 0.9, H: 0.15}}
+	body := spec.Placeholder{ID: "body", Type: "text", Geometry: spec.Geometry{X: 0.05, Y: 0.25, W: 0.9, H: 0.65}}
+	for _, layout := range s.Layouts {
+		var text []spec.Placeholder
+		for _, ph := range layout.Placeholders {
+			if ph.Type == "" || ph.Type == "text" {
+				text = append(text, ph)
+			}
+		}
+		if len(text) >= 2 {
+			title, body = text[0], text[1]
+			break
+		}
+	}
+	title.Content, body.Content = m.Title, m.Body
+	return spec.Layout{Name: "Mandatory slide", Placeholders: []spec.Placeholder{title, body}}
+}
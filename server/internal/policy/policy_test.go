@@ -0,0 +1,114 @@
+package policy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(store.OrgPolicy{}))
+	assert.NoError(t, Validate(store.OrgPolicy{
+		AllowedTemplates: []string{"tpl-1"},
+		MandatorySlides:  []store.MandatorySlide{{Title: "Disclaimer", Position: "start"}},
+		ContentRules:     []store.ContentRule{{Name: "ssn", Pattern: `\d{3}-\d{2}-\d{4}`}},
+	}))
+
+	for name, p := range map[string]store.OrgPolicy{
+		"blank template":     {AllowedTemplates: []string{" "}},
+		"empty slide":        {MandatorySlides: []store.MandatorySlide{{Position: "end"}}},
+		"bad position":       {MandatorySlides: []store.MandatorySlide{{Title: "x", Position: "middle"}}},
+		"too many slides":    {MandatorySlides: make([]store.MandatorySlide, MaxMandatorySlides+1)},
+		"unnamed rule":       {ContentRules: []store.ContentRule{{Pattern: "x"}}},
+		"duplicate rule":     {ContentRules: []store.ContentRule{{Name: "a", Pattern: "x"}, {Name: "a", Pattern: "y"}}},
+		"empty pattern":      {ContentRules: []store.ContentRule{{Name: "a"}}},
+		"long pattern":       {ContentRules: []store.ContentRule{{Name: "a", Pattern: strings.Repeat("x", maxPatternLength+1)}}},
+		"invalid pattern":    {ContentRules: []store.ContentRule{{Name: "a", Pattern: "(x"}}},
+		"long slide":         {MandatorySlides: []store.MandatorySlide{{Body: strings.Repeat("x", maxSlideText+1)}}},
+		"too many templates": {AllowedTemplates: make([]string, MaxAllowedTemplates+1)},
+	} {
+		assert.Error(t, Validate(p), name)
+	}
+}
+
+func TestCheckTemplate(t *testing.T) {
+	assert.NoError(t, CheckTemplate(nil, "tpl-1"))
+	assert.NoError(t, CheckTemplate(&store.OrgPolicy{}, "tpl-1"))
+	p := &store.OrgPolicy{AllowedTemplates: []string{"tpl-1"}}
+	assert.NoError(t, CheckTemplate(p, "tpl-1"))
+
+	var violation *ViolationError
+	require.ErrorAs(t, CheckTemplate(p, "tpl-2"), &violation)
+	assert.Equal(t, KindTemplate, violation.Violations[0].Kind)
+}
+
+func TestCheckContent(t *testing.T) {
+	p := &store.OrgPolicy{ContentRules: []store.ContentRule{
+		{Name: "ssn", Pattern: `\d{3}-\d{2}-\d{4}`, Message: "remove social security numbers"},
+		{Name: "superlatives", Pattern: `\bbest in class\b`},
+	}}
+
+	assert.NoError(t, CheckText(nil, "123-45-6789"))
+	assert.NoError(t, CheckText(p, "Revenue grew 12%"))
+
+	err := CheckText(p, "Best In Class service for 123-45-6789")
+	var violation *ViolationError
+	require.ErrorAs(t, err, &violation)
+	assert.Equal(t, []Violation{
+		{Kind: KindContent, Rule: "ssn", Message: "remove social security numbers", Match: "123-45-6789"},
+		{Kind: KindContent, Rule: "superlatives", Message: `content breaks the "superlatives" rule`, Match: "Best In Class"},
+	}, violation.Violations)
+	assert.Equal(t, "policy violation: remove social security numbers (and 1 more)", err.Error())
+
+	deck := spec.TemplateSpec{Layouts: []spec.Layout{
+		{Placeholders: []spec.Placeholder{{ID: "title", Content: "Intro"}}},
+		{Placeholders: []spec.Placeholder{{ID: "title", Content: "Team"}, {ID: "body", Content: "We are best in class"}}},
+	}}
+	assert.NoError(t, CheckSpec(p, spec.TemplateSpec{Layouts: deck.Layouts[:1]}))
+	require.ErrorAs(t, CheckSpec(p, deck), &violation)
+	assert.Equal(t, 2, violation.Violations[0].Slide)
+
+	require.ErrorAs(t, CheckSlide(p, deck.Layouts[1], 7), &violation)
+	assert.Equal(t, 7, violation.Violations[0].Slide)
+	assert.NoError(t, CheckSlide(p, deck.Layouts[0], 1))
+	assert.False(t, errors.As(CheckSpec(nil, deck), &violation))
+}
+
+func TestAddMandatorySlides(t *testing.T) {
+	p := &store.OrgPolicy{MandatorySlides: []store.MandatorySlide{
+		{Title: "Confidential", Position: "start"},
+		{Title: "Disclaimer", Body: "Past performance is no guarantee of future results."},
+	}}
+	content := spec.Layout{Name: "Content", Placeholders: []spec.Placeholder{
+		{ID: "heading", Type: "text", Geometry: spec.Geometry{X: 0.1, Y: 0.1, W: 0.8, H: 0.1}, Content: "Results"},
+		{ID: "logo", Type: "image"},
+		{ID: "text", Type: "text", Geometry: spec.Geometry{X: 0.1, Y: 0.3, W: 0.8, H: 0.6}, Content: "Up 12%"},
+	}}
+	deck := spec.TemplateSpec{Layouts: []spec.Layout{content}}
+
+	assert.Zero(t, AddMandatorySlides(nil, &deck))
+	require.Equal(t, 2, AddMandatorySlides(p, &deck))
+	require.Len(t, deck.Layouts, 3)
+	assert.Equal(t, "Confidential", deck.Layouts[0].Placeholders[0].Content)
+	assert.Equal(t, "Content", deck.Layouts[1].Name)
+	last := deck.Layouts[2]
+	assert.Equal(t, "Mandatory slide", last.Name)
+	require.Len(t, last.Placeholders, 2)
+	assert.Equal(t, content.Placeholders[0].Geometry, last.Placeholders[0].Geometry, "new slides take the deck's design")
+	assert.Equal(t, content.Placeholders[2].Geometry, last.Placeholders[1].Geometry)
+	assert.Equal(t, "Past performance is no guarantee of future results.", last.Placeholders[1].Content)
+
+	assert.Zero(t, AddMandatorySlides(p, &deck), "slides already there aren't added again")
+
+	// Case and line breaks don't hide a slide that is already there.
+	other := spec.TemplateSpec{Layouts: []spec.Layout{{Placeholders: []spec.Placeholder{
+		{ID: "a", Content: "CONFIDENTIAL"},
+		{ID: "b", Content: "Note: past performance is no\nguarantee of future results."},
+	}}}}
+	assert.Zero(t, AddMandatorySlides(p, &other))
+}
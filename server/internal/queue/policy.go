@@ -1,6 +1,8 @@
 package queue
 
 import (
+	"fmt"
+	"hash/fnv"
 	"time"
 )
 
@@ -9,6 +11,9 @@ type RetryPolicy struct {
 	InitialDelay  time.Duration
 	MaxDelay      time.Duration
 	BackoffFactor float64
+	// Jitter is the fraction of each delay, from 0 to 1, that may be taken
+	// off so jobs that failed together don't all retry together.
+	Jitter float64
 }
 
 type ErrorType string
@@ -37,6 +42,13 @@ var DefaultRetryPolicies = map[string]RetryPolicy{
 		MaxDelay:      600 * time.Second,
 		BackoffFactor: 1.5,
 	},
+	// Text-to-image models are often cold and answer 503 while loading.
+	"image_gen": {
+		MaxRetries:    4,
+		InitialDelay:  20 * time.Second,
+		MaxDelay:      300 * time.Second,
+		BackoffFactor: 2.0,
+	},
 }
 
 func GetRetryPolicy(jobType string) RetryPolicy {
@@ -119,6 +131,20 @@ func CalculateNextRetryDelay(policy RetryPolicy, retryCount int) time.Duration {
 	return time.Duration(delay)
 }
 
+// JitteredDelay is CalculateNextRetryDelay shortened by up to the policy's
+// Jitter. The amount is derived from key and retryCount rather than drawn at
+// random, so every check of whether a job is due agrees.
+func JitteredDelay(policy RetryPolicy, retryCount int, key string) time.Duration {
+	delay := CalculateNextRetryDelay(policy, retryCount)
+	if policy.Jitter <= 0 {
+		return delay
+	}
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s/%d", key, retryCount)
+	frac := float64(h.Sum64()%1000) / 1000
+	return delay - time.Duration(float64(delay)*min(policy.Jitter, 1)*frac)
+}
+
 func pow(base, exp float64) float64 {
 	if exp == 0 {
 		return 1
@@ -0,0 +1,182 @@
+package queue
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// Where an effective policy's values came from. Each layer overrides the one
+// before it field by field.
+const (
+	SourceDefault  = "default"
+	SourceEnv      = "env"
+	SourceSettings = "settings"
+)
+
+// JobTypes are the job types a retry policy can be configured for.
+var JobTypes = []store.JobType{
+	store.JobRender,
+	store.JobPreview,
+	store.JobExport,
+	store.JobGenerate,
+	store.JobBind,
+	store.JobBulkExport,
+	store.JobImageGen,
+	store.JobBatchMerge,
+}
+
+// Override replaces part of a job type's retry policy; nil fields keep the
+// value underneath.
+type Override struct {
+	MaxRetries   *int
+	InitialDelay *time.Duration
+	MaxDelay     *time.Duration
+	Jitter       *float64
+}
+
+func (o Override) empty() bool {
+	return o.MaxRetries == nil && o.InitialDelay == nil && o.MaxDelay == nil && o.Jitter == nil
+}
+
+func (o Override) apply(p RetryPolicy) RetryPolicy {
+	if o.MaxRetries != nil {
+		p.MaxRetries = *o.MaxRetries
+	}
+	if o.InitialDelay != nil {
+		p.InitialDelay = *o.InitialDelay
+	}
+	if o.MaxDelay != nil {
+		p.MaxDelay = *o.MaxDelay
+	}
+	if o.Jitter != nil {
+		p.Jitter = *o.Jitter
+	}
+	return p
+}
+
+// OverrideFromSettings converts a platform admin's stored override.
+func OverrideFromSettings(s store.RetryPolicyOverride) Override {
+	o := Override{MaxRetries: s.MaxRetries, Jitter: s.Jitter}
+	if s.BaseDelayMs != nil {
+		d := time.Duration(*s.BaseDelayMs) * time.Millisecond
+		o.InitialDelay = &d
+	}
+	if s.MaxDelayMs != nil {
+		d := time.Duration(*s.MaxDelayMs) * time.Millisecond
+		o.MaxDelay = &d
+	}
+	return o
+}
+
+// EnvOverrides reads RETRY_<TYPE>_MAX_RETRIES, _BASE_DELAY, _MAX_DELAY and
+// _JITTER for each job type, e.g. RETRY_BULK_EXPORT_MAX_DELAY=15m. Delays are
+// Go durations and jitter a fraction from 0 to 1; invalid values are logged
+// and ignored.
+func EnvOverrides(getenv func(string) string) map[store.JobType]Override {
+	out := map[store.JobType]Override{}
+	for _, t := range JobTypes {
+		prefix := "RETRY_" + strings.ToUpper(string(t)) + "_"
+		var o Override
+		if v := getenv(prefix + "MAX_RETRIES"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+				o.MaxRetries = &n
+			} else {
+				logger.Jobs().Warn("invalid_retry_setting", "key", prefix+"MAX_RETRIES", "value", v)
+			}
+		}
+		for key, dst := range map[string]**time.Duration{"BASE_DELAY": &o.InitialDelay, "MAX_DELAY": &o.MaxDelay} {
+			v := getenv(prefix + key)
+			if v == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+				*dst = &d
+			} else {
+				logger.Jobs().Warn("invalid_retry_setting", "key", prefix+key, "value", v)
+			}
+		}
+		if v := getenv(prefix + "JITTER"); v != "" {
+			if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+				o.Jitter = &f
+			} else {
+				logger.Jobs().Warn("invalid_retry_setting", "key", prefix+"JITTER", "value", v)
+			}
+		}
+		if !o.empty() {
+			out[t] = o
+		}
+	}
+	return out
+}
+
+// EffectivePolicy is a job type's retry policy as the worker applies it.
+type EffectivePolicy struct {
+	JobType       store.JobType `json:"jobType"`
+	MaxRetries    int           `json:"maxRetries"`
+	BaseDelayMs   int64         `json:"baseDelayMs"`
+	MaxDelayMs    int64         `json:"maxDelayMs"`
+	BackoffFactor float64       `json:"backoffFactor"`
+	Jitter        float64       `json:"jitter"`
+	// Source is the most specific layer that changed the policy.
+	Source string `json:"source"`
+
+	Policy RetryPolicy `json:"-"`
+}
+
+// PolicySet holds the effective retry policy of every job type.
+type PolicySet map[store.JobType]EffectivePolicy
+
+// Resolve layers the environment's overrides and then the platform settings
+// over the built-in defaults.
+func Resolve(env map[store.JobType]Override, settings []store.RetryPolicyOverride) PolicySet {
+	saved := make(map[store.JobType]store.RetryPolicyOverride, len(settings))
+	for _, s := range settings {
+		saved[s.JobType] = s
+	}
+	out := make(PolicySet, len(JobTypes))
+	for _, t := range JobTypes {
+		p, source := GetRetryPolicy(string(t)), SourceDefault
+		if o, ok := env[t]; ok && !o.empty() {
+			p, source = o.apply(p), SourceEnv
+		}
+		if s, ok := saved[t]; ok {
+			if o := OverrideFromSettings(s); !o.empty() {
+				p, source = o.apply(p), SourceSettings
+			}
+		}
+		out[t] = EffectivePolicy{
+			JobType:       t,
+			MaxRetries:    p.MaxRetries,
+			BaseDelayMs:   p.InitialDelay.Milliseconds(),
+			MaxDelayMs:    p.MaxDelay.Milliseconds(),
+			BackoffFactor: p.BackoffFactor,
+			Jitter:        p.Jitter,
+			Source:        source,
+			Policy:        p,
+		}
+	}
+	return out
+}
+
+// For returns t's policy, or the built-in default for types the set lacks.
+func (s PolicySet) For(t store.JobType) RetryPolicy {
+	if p, ok := s[t]; ok {
+		return p.Policy
+	}
+	return GetRetryPolicy(string(t))
+}
+
+// List returns the set in JobTypes order.
+func (s PolicySet) List() []EffectivePolicy {
+	out := make([]EffectivePolicy, 0, len(s))
+	for _, t := range JobTypes {
+		if p, ok := s[t]; ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestEnvOverrides(t *testing.T) {
+	env := map[string]string{
+		"RETRY_BULK_EXPORT_MAX_RETRIES": "1",
+		"RETRY_BULK_EXPORT_MAX_DELAY":   "15m",
+		"RETRY_RENDER_BASE_DELAY":       "soon",
+		"RETRY_RENDER_JITTER":           "0.25",
+		"RETRY_EXPORT_JITTER":           "3",
+	}
+	got := EnvOverrides(func(k string) string { return env[k] })
+
+	require.Contains(t, got, store.JobBulkExport)
+	assert.Equal(t, 1, *got[store.JobBulkExport].MaxRetries)
+	assert.Equal(t, 15*time.Minute, *got[store.JobBulkExport].MaxDelay)
+	assert.Nil(t, got[store.JobBulkExport].InitialDelay)
+
+	assert.Nil(t, got[store.JobRender].InitialDelay, "unparseable values are ignored")
+	assert.Equal(t, 0.25, *got[store.JobRender].Jitter)
+	assert.NotContains(t, got, store.JobExport, "out-of-range jitter is ignored")
+}
+
+func TestResolve_LayersSettingsOverEnvOverDefaults(t *testing.T) {
+	envRetries, envDelay := 9, time.Second
+	settingsRetries, settingsMaxMs := 0, int64(30000)
+	set := Resolve(
+		map[store.JobType]Override{
+			store.JobExport: {MaxRetries: &envRetries, InitialDelay: &envDelay},
+			store.JobRender: {MaxRetries: &envRetries},
+		},
+		[]store.RetryPolicyOverride{
+			{JobType: store.JobExport, MaxRetries: &settingsRetries, MaxDelayMs: &settingsMaxMs},
+			{JobType: store.JobPreview},
+		},
+	)
+
+	export := set[store.JobExport]
+	assert.Equal(t, SourceSettings, export.Source)
+	assert.Equal(t, RetryPolicy{MaxRetries: 0, InitialDelay: time.Second, MaxDelay: 30 * time.Second, BackoffFactor: 1.5}, export.Policy)
+
+	assert.Equal(t, SourceEnv, set[store.JobRender].Source)
+	assert.Equal(t, SourceDefault, set[store.JobPreview].Source, "an override that sets nothing changes nothing")
+	assert.Equal(t, DefaultRetryPolicies["render"], set.For(store.JobBind))
+	assert.Equal(t, DefaultRetryPolicies["render"], set.For("unknown"))
+	assert.Len(t, set.List(), len(JobTypes))
+}
+
+func TestJitteredDelay(t *testing.T) {
+	policy := RetryPolicy{InitialDelay: 10 * time.Second, MaxDelay: time.Minute, BackoffFactor: 2}
+	assert.Equal(t, CalculateNextRetryDelay(policy, 2), JitteredDelay(policy, 2, "job-1"))
+
+	policy.Jitter = 0.5
+	distinct := map[time.Duration]bool{}
+	for _, id := range []string{"job-1", "job-2", "job-3", "job-4"} {
+		d := JitteredDelay(policy, 2, id)
+		assert.Equal(t, d, JitteredDelay(policy, 2, id), "the same job always gets the same delay")
+		assert.LessOrEqual(t, d, 20*time.Second)
+		assert.GreaterOrEqual(t, d, 10*time.Second)
+		distinct[d] = true
+	}
+	assert.Greater(t, len(distinct), 1, "jobs are spread out")
+}
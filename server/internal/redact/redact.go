@@ -0,0 +1,100 @@
+// Package redact masks personal data (email addresses, phone numbers and any
+// operator-configured patterns) in text before it is logged or persisted in
+// audit metadata.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// Mask replaces every match.
+const Mask = "[REDACTED]"
+
+var builtin = []*regexp.Regexp{
+	regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	// Phone numbers need a separator between digit groups (or a leading +)
+	// so dates, IDs and plain counts are left alone.
+	regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)[\s.-]?|\b\d{2,4}[\s.-])\d{3,4}[\s.-]?\d{3,4}\b`),
+	regexp.MustCompile(`\+\d{8,15}\b`),
+}
+
+var (
+	mu       sync.RWMutex
+	patterns = builtin
+)
+
+// Configure adds extra regular expressions to the built-in ones, replacing
+// any added earlier. Nothing changes if a pattern doesn't compile.
+func Configure(extra []string) error {
+	next := append([]*regexp.Regexp(nil), builtin...)
+	for _, p := range extra {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("redact pattern %q: %w", p, err)
+		}
+		next = append(next, re)
+	}
+	mu.Lock()
+	patterns = next
+	mu.Unlock()
+	return nil
+}
+
+// String masks everything in s that matches a pattern.
+func String(s string) string {
+	if s == "" {
+		return s
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, re := range patterns {
+		s = re.ReplaceAllString(s, Mask)
+	}
+	return s
+}
+
+// Value masks the strings in v, descending into the maps and slices that
+// JSON metadata is made of. It returns copies; v is not modified. Other
+// values are returned as they are.
+func Value(v any) any {
+	switch v := v.(type) {
+	case string:
+		return String(v)
+	case map[string]any:
+		return Map(v)
+	case map[string]string:
+		out := make(map[string]string, len(v))
+		for k, s := range v {
+			out[k] = String(s)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = Value(e)
+		}
+		return out
+	case []string:
+		out := make([]string, len(v))
+		for i, s := range v {
+			out[i] = String(s)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// Map returns a copy of m with its values masked by Value.
+func Map(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = Value(v)
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestString(t *testing.T) {
+	cases := map[string]string{
+		"contact jane.doe+deck@example.co.uk today": "contact [REDACTED] today",
+		"call +966 50 123 4567 or (555) 123-4567":   "call [REDACTED] or [REDACTED]",
+		"office 555-123-4567":                       "office [REDACTED]",
+		"whatsapp +15551234567":                     "whatsapp [REDACTED]",
+		// Dates, UUIDs and plain numbers are not phone numbers.
+		"due 2026-10-16, 1500 attendees":           "due 2026-10-16, 1500 attendees",
+		"job 550e8400-e29b-41d4-a716-446655440000": "job 550e8400-e29b-41d4-a716-446655440000",
+		"Q4 revenue grew 15% to $2.5M":             "Q4 revenue grew 15% to $2.5M",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, String(in), in)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, Configure(nil)) })
+
+	require.NoError(t, Configure([]string{`\bACME-\d+\b`}))
+	assert.Equal(t, "ticket [REDACTED] from [REDACTED]", String("ticket ACME-42 from ops@example.com"))
+
+	assert.Error(t, Configure([]string{`(`}))
+	assert.Equal(t, "ticket [REDACTED]", String("ticket ACME-42"), "a bad pattern keeps the previous set")
+
+	require.NoError(t, Configure(nil))
+	assert.Equal(t, "ticket ACME-42", String("ticket ACME-42"))
+}
+
+func TestMap_CopiesNestedValues(t *testing.T) {
+	in := map[string]any{
+		"prompt": "Pitch deck for bob@example.com",
+		"slides": 3,
+		"nested": map[string]any{"emails": []string{"a@example.com"}},
+		"list":   []any{"call 555-123-4567", true},
+	}
+	out := Map(in)
+
+	assert.Equal(t, "Pitch deck for [REDACTED]", out["prompt"])
+	assert.Equal(t, 3, out["slides"])
+	assert.Equal(t, map[string]any{"emails": []string{Mask}}, out["nested"])
+	assert.Equal(t, []any{"call " + Mask, true}, out["list"])
+	assert.Equal(t, "Pitch deck for bob@example.com", in["prompt"], "input is not modified")
+	assert.Nil(t, Map(nil))
+}
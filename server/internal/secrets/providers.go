@@ -0,0 +1,267 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// Env reads each secret from the environment variable of the same name.
+type Env struct {
+	Getenv func(string) string
+}
+
+func (Env) Name() string { return "env" }
+
+// Fetch implements Provider.
+func (e Env) Fetch(_ context.Context, names []string) (map[string]string, error) {
+	getenv := e.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	out := map[string]string{}
+	for _, name := range names {
+		if v := getenv(name); v != "" {
+			out[name] = v
+		}
+	}
+	return out, nil
+}
+
+// Dir reads each secret from a file named after it, the way Docker and
+// Kubernetes mount secrets. A trailing newline is dropped.
+type Dir struct {
+	Path string
+}
+
+func (Dir) Name() string { return "file" }
+
+// Fetch implements Provider.
+func (d Dir) Fetch(_ context.Context, names []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, name := range names {
+		b, err := os.ReadFile(filepath.Join(d.Path, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		out[name] = strings.TrimRight(string(b), "\r\n")
+	}
+	return out, nil
+}
+
+// Chain asks each provider in turn; the first to hold a secret wins.
+type Chain []Provider
+
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, p := range c {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+// Fetch implements Provider.
+func (c Chain) Fetch(ctx context.Context, names []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, p := range c {
+		var missing []string
+		for _, name := range names {
+			if _, ok := out[name]; !ok {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) == 0 {
+			break
+		}
+		got, err := p.Fetch(ctx, missing)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Name(), err)
+		}
+		for k, v := range got {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// AWSSecretsManager reads one secret whose SecretString is a JSON object of
+// name/value pairs, e.g. {"JWT_SECRET": "...", "HUGGINGFACE_API_KEY": "..."}.
+type AWSSecretsManager struct {
+	SecretID string
+	Region   string
+	// Endpoint overrides https://secretsmanager.<region>.amazonaws.com.
+	Endpoint   string
+	HTTPClient *http.Client
+	// Credentials default to the standard AWS chain (env, profile, role).
+	Credentials aws.CredentialsProvider
+}
+
+func (AWSSecretsManager) Name() string { return "aws-secrets-manager" }
+
+// Fetch implements Provider.
+func (a AWSSecretsManager) Fetch(ctx context.Context, names []string) (map[string]string, error) {
+	creds := a.Credentials
+	if creds == nil {
+		cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(a.Region))
+		if err != nil {
+			return nil, fmt.Errorf("load aws config: %w", err)
+		}
+		creds = cfg.Credentials
+	}
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", a.Region)
+	}
+
+	body, _ := json.Marshal(map[string]string{"SecretId": a.SecretID})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	cred, err := creds.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("aws credentials: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, cred, req, hex.EncodeToString(sum[:]), "secretsmanager", a.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("sign request: %w", err)
+	}
+
+	var resp struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := doJSON(a.HTTPClient, req, &resp); err != nil {
+		return nil, err
+	}
+	return pick(resp.SecretString, names)
+}
+
+// Vault reads a KV version 2 secret whose keys are secret names.
+type Vault struct {
+	Addr  string
+	Token string
+	// Mount is the KV engine's mount point, "secret" by default.
+	Mount      string
+	Path       string
+	HTTPClient *http.Client
+}
+
+func (Vault) Name() string { return "vault" }
+
+// Fetch implements Provider.
+func (v Vault) Fetch(ctx context.Context, names []string) (map[string]string, error) {
+	mount := v.Mount
+	if mount == "" {
+		mount = "secret"
+	}
+	url := fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimRight(v.Addr, "/"), strings.Trim(mount, "/"), strings.TrimLeft(v.Path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+
+	var resp struct {
+		Data struct {
+			Data json.RawMessage `json:"data"`
+		} `json:"data"`
+	}
+	if err := doJSON(v.HTTPClient, req, &resp); err != nil {
+		return nil, err
+	}
+	return pick(string(resp.Data.Data), names)
+}
+
+func doJSON(client *http.Client, req *http.Request, out any) error {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		// The body may echo the request; only the status is reported.
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// pick decodes a JSON object of secrets and keeps the requested names.
+func pick(doc string, names []string) (map[string]string, error) {
+	var all map[string]any
+	if err := json.Unmarshal([]byte(doc), &all); err != nil {
+		return nil, errors.New("secret is not a JSON object of name/value pairs")
+	}
+	out := map[string]string{}
+	for _, name := range names {
+		if s, ok := all[name].(string); ok && s != "" {
+			out[name] = s
+		}
+	}
+	return out, nil
+}
+
+// FromEnv picks the provider named by SECRETS_PROVIDER:
+//
+//   - env (default): environment variables
+//   - file: files in SECRETS_DIR (default /run/secrets)
+//   - aws: the JSON secret SECRETS_AWS_SECRET_ID in SECRETS_AWS_REGION
+//     (or AWS_REGION)
+//   - vault: the KV v2 secret VAULT_SECRET_PATH under VAULT_SECRET_MOUNT at
+//     VAULT_ADDR, read with VAULT_TOKEN
+//
+// Secrets a non-env provider doesn't hold fall back to the environment.
+func FromEnv(getenv func(string) string) (Provider, error) {
+	env := Env{Getenv: getenv}
+	switch kind := getenv("SECRETS_PROVIDER"); kind {
+	case "", "env":
+		return env, nil
+	case "file":
+		dir := getenv("SECRETS_DIR")
+		if dir == "" {
+			dir = "/run/secrets"
+		}
+		return Chain{Dir{Path: dir}, env}, nil
+	case "aws":
+		region := getenv("SECRETS_AWS_REGION")
+		if region == "" {
+			region = getenv("AWS_REGION")
+		}
+		if getenv("SECRETS_AWS_SECRET_ID") == "" || region == "" {
+			return nil, errors.New("SECRETS_PROVIDER=aws needs SECRETS_AWS_SECRET_ID and SECRETS_AWS_REGION")
+		}
+		return Chain{AWSSecretsManager{SecretID: getenv("SECRETS_AWS_SECRET_ID"), Region: region, Endpoint: getenv("SECRETS_AWS_ENDPOINT")}, env}, nil
+	case "vault":
+		if getenv("VAULT_ADDR") == "" || getenv("VAULT_TOKEN") == "" || getenv("VAULT_SECRET_PATH") == "" {
+			return nil, errors.New("SECRETS_PROVIDER=vault needs VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH")
+		}
+		return Chain{Vault{Addr: getenv("VAULT_ADDR"), Token: getenv("VAULT_TOKEN"), Mount: getenv("VAULT_SECRET_MOUNT"), Path: getenv("VAULT_SECRET_PATH")}, env}, nil
+	default:
+		return nil, fmt.Errorf("unknown SECRETS_PROVIDER %q (want env, file, aws or vault)", kind)
+	}
+}
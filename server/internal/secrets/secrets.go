@@ -0,0 +1,157 @@
+// Package secrets loads the server's credentials (JWT signing secret, API
+// keys, encryption keys) from a configurable provider (environment
+// variables, a directory of files, AWS Secrets Manager or Vault), checks
+// them at startup and refreshes them so rotated values take effect without
+// a restart.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+)
+
+// Provider fetches secrets by name. Names it doesn't hold are left out of
+// the result rather than reported as errors.
+type Provider interface {
+	Name() string
+	Fetch(ctx context.Context, names []string) (map[string]string, error)
+}
+
+// Spec describes one secret the server uses.
+type Spec struct {
+	Name     string
+	Required bool
+	// Validate checks a present value; nil accepts anything.
+	Validate func(string) error
+}
+
+// Manager holds the current secret values and tells subscribers when a
+// refresh changes one.
+type Manager struct {
+	provider Provider
+	specs    []Spec
+
+	mu       sync.RWMutex
+	values   map[string]string
+	handlers map[string][]func(value, previous string)
+}
+
+// NewManager returns a manager for specs, fetched from p. Nothing is read
+// until Load.
+func NewManager(p Provider, specs []Spec) *Manager {
+	return &Manager{provider: p, specs: specs, values: map[string]string{}, handlers: map[string][]func(string, string){}}
+}
+
+// OnChange registers fn to be called with the new and previous value of
+// name whenever Load finds it changed, including the first Load.
+func (m *Manager) OnChange(name string, fn func(value, previous string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[name] = append(m.handlers[name], fn)
+}
+
+// Get returns the current value of name, or "" if it isn't set.
+func (m *Manager) Get(name string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.values[name]
+}
+
+// Load fetches every spec'd secret and validates the set. If anything is
+// missing or malformed the current values are kept and the returned error
+// lists every problem, so one bad rotation can't take the server down.
+func (m *Manager) Load(ctx context.Context) error {
+	names := make([]string, len(m.specs))
+	for i, s := range m.specs {
+		names[i] = s.Name
+	}
+	fetched, err := m.provider.Fetch(ctx, names)
+	if err != nil {
+		return fmt.Errorf("fetch secrets from %s: %w", m.provider.Name(), err)
+	}
+	if err := Validate(m.specs, fetched); err != nil {
+		return err
+	}
+
+	type change struct {
+		name, value, previous string
+	}
+	var changes []change
+	m.mu.Lock()
+	for _, s := range m.specs {
+		value := fetched[s.Name]
+		if previous, seen := m.values[s.Name]; !seen || previous != value {
+			changes = append(changes, change{s.Name, value, previous})
+		}
+		m.values[s.Name] = value
+	}
+	handlers := m.handlers
+	m.mu.Unlock()
+
+	for _, c := range changes {
+		for _, fn := range handlers[c.name] {
+			fn(c.value, c.previous)
+		}
+	}
+	return nil
+}
+
+// Watch reloads the secrets every interval until ctx is done. Failed
+// reloads are logged and the previous values stay in force.
+func (m *Manager) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Load(ctx); err != nil {
+				logger.Logger.Error("secrets_refresh_failed", "provider", m.provider.Name(), "error", err)
+			}
+		}
+	}
+}
+
+// Validate checks values against specs and reports every required secret
+// that is missing and every present one that is malformed. Values are never
+// included in the error.
+func Validate(specs []Spec, values map[string]string) error {
+	var problems []string
+	for _, s := range specs {
+		v, ok := values[s.Name]
+		if !ok || v == "" {
+			if s.Required {
+				problems = append(problems, s.Name+" is required")
+			}
+			continue
+		}
+		if s.Validate != nil {
+			if err := s.Validate(v); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", s.Name, err))
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return errors.New("invalid secrets: " + strings.Join(problems, "; "))
+}
+
+// Prefix rejects values that don't start with prefix, e.g. "hf_".
+func Prefix(prefix string) func(string) error {
+	return func(v string) error {
+		if !strings.HasPrefix(v, prefix) {
+			return fmt.Errorf("must start with %q", prefix)
+		}
+		return nil
+	}
+}
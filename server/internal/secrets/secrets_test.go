@@ -0,0 +1,142 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapProvider serves secrets from a map that tests change between loads.
+type mapProvider map[string]string
+
+func (mapProvider) Name() string { return "map" }
+
+func (m mapProvider) Fetch(_ context.Context, names []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, n := range names {
+		if v, ok := m[n]; ok {
+			out[n] = v
+		}
+	}
+	return out, nil
+}
+
+func TestValidate(t *testing.T) {
+	specs := []Spec{
+		{Name: "JWT_SECRET", Required: true},
+		{Name: "HF_KEY", Validate: Prefix("hf_")},
+		{Name: "OPTIONAL"},
+	}
+	assert.NoError(t, Validate(specs, map[string]string{"JWT_SECRET": "s"}))
+
+	err := Validate(specs, map[string]string{"HF_KEY": "sk-live-123"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "JWT_SECRET is required")
+	assert.Contains(t, err.Error(), `HF_KEY: must start with "hf_"`)
+	assert.NotContains(t, err.Error(), "sk-live-123", "values never appear in errors")
+}
+
+func TestManager_LoadNotifiesChangesAndKeepsValuesOnFailure(t *testing.T) {
+	p := mapProvider{"JWT_SECRET": "first"}
+	m := NewManager(p, []Spec{{Name: "JWT_SECRET", Required: true}, {Name: "OPTIONAL"}})
+	var seen [][2]string
+	m.OnChange("JWT_SECRET", func(value, previous string) { seen = append(seen, [2]string{value, previous}) })
+
+	require.NoError(t, m.Load(context.Background()))
+	require.NoError(t, m.Load(context.Background()))
+	assert.Equal(t, [][2]string{{"first", ""}}, seen, "unchanged values aren't reported again")
+
+	p["JWT_SECRET"] = "second"
+	require.NoError(t, m.Load(context.Background()))
+	assert.Equal(t, [2]string{"second", "first"}, seen[1])
+
+	delete(p, "JWT_SECRET")
+	assert.Error(t, m.Load(context.Background()))
+	assert.Equal(t, "second", m.Get("JWT_SECRET"))
+	assert.Len(t, seen, 2)
+}
+
+func TestDirAndChain(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "JWT_SECRET"), []byte("from-file\n"), 0o600))
+	env := Env{Getenv: func(k string) string {
+		return map[string]string{"JWT_SECRET": "from-env", "PEXELS_API_KEY": "pexels"}[k]
+	}}
+
+	got, err := Chain{Dir{Path: dir}, env}.Fetch(context.Background(), []string{"JWT_SECRET", "PEXELS_API_KEY", "MISSING"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"JWT_SECRET": "from-file", "PEXELS_API_KEY": "pexels"}, got)
+}
+
+func TestVault_ReadsKVv2Secret(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/kv/data/cms-ai/prod", r.URL.Path)
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"data":{"JWT_SECRET":"vault-secret","OTHER":"x"},"metadata":{"version":3}}}`)
+	}))
+	defer srv.Close()
+
+	v := Vault{Addr: srv.URL, Token: "root", Mount: "kv", Path: "cms-ai/prod"}
+	got, err := v.Fetch(context.Background(), []string{"JWT_SECRET"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"JWT_SECRET": "vault-secret"}, got)
+
+	v.Token = "wrong"
+	_, err = v.Fetch(context.Background(), []string{"JWT_SECRET"})
+	assert.ErrorContains(t, err, "403")
+}
+
+func TestAWSSecretsManager_SignsAndReadsJSONSecret(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "cms-ai/prod", body["SecretId"])
+		secret, _ := json.Marshal(map[string]string{"JWT_SECRET": "aws-secret", "HUGGINGFACE_API_KEY": "hf_abc"})
+		_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": string(secret)})
+	}))
+	defer srv.Close()
+
+	a := AWSSecretsManager{
+		SecretID:    "cms-ai/prod",
+		Region:      "us-east-1",
+		Endpoint:    srv.URL,
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+	}
+	got, err := a.Fetch(context.Background(), []string{"JWT_SECRET", "HUGGINGFACE_API_KEY", "PEXELS_API_KEY"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"JWT_SECRET": "aws-secret", "HUGGINGFACE_API_KEY": "hf_abc"}, got)
+}
+
+func TestFromEnv(t *testing.T) {
+	provider := func(env map[string]string) (Provider, error) {
+		return FromEnv(func(k string) string { return env[k] })
+	}
+
+	p, err := provider(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "env", p.Name())
+
+	p, err = provider(map[string]string{"SECRETS_PROVIDER": "vault", "VAULT_ADDR": "http://vault", "VAULT_TOKEN": "t", "VAULT_SECRET_PATH": "cms"})
+	require.NoError(t, err)
+	assert.Equal(t, "vault+env", p.Name())
+
+	_, err = provider(map[string]string{"SECRETS_PROVIDER": "aws"})
+	assert.Error(t, err)
+	_, err = provider(map[string]string{"SECRETS_PROVIDER": "keychain"})
+	assert.Error(t, err)
+}
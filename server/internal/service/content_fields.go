@@ -0,0 +1,174 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// maxTemplateFields bounds the fields a template version may require.
+const maxTemplateFields = 20
+
+var (
+	templateFieldKey = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	// currencyValue accepts amounts like "$2.5M", "SAR 1,200" or "1.200,50 EUR".
+	currencyValue = regexp.MustCompile(`^(?:[A-Z]{3}\s?|[$€£¥₹﷼]\s?)?-?\d[\d,.\s]*[KMBkmb]?(?:\s?[A-Z]{3})?$`)
+	// percentageValue accepts "15%", "15.5 %" or a bare number.
+	percentageValue = regexp.MustCompile(`^-?\d+(?:[.,]\d+)?\s?%?$`)
+	// dateLayouts are the date formats content data may use.
+	dateLayouts = []string{time.DateOnly, time.RFC3339, "2006-01", "January 2, 2006", "Jan 2, 2006", "2 January 2006", "January 2006", "Jan 2006"}
+)
+
+// ValidateTemplateFields checks fields a template version is to require:
+// each needs a distinct identifier key, a label and a known type.
+func ValidateTemplateFields(fields []store.TemplateField) error {
+	if len(fields) > maxTemplateFields {
+		return invalidf("at most %d required fields are allowed", maxTemplateFields)
+	}
+	seen := map[string]bool{}
+	for _, f := range fields {
+		switch {
+		case !templateFieldKey.MatchString(f.Key):
+			return invalidf("required field key %q must start with a letter and contain only letters, digits and underscores", f.Key)
+		case seen[f.Key]:
+			return invalidf("required field key %q is repeated", f.Key)
+		case strings.TrimSpace(f.Label) == "":
+			return invalidf("required field %q needs a label", f.Key)
+		case !slices.Contains(store.TemplateFieldTypes, f.Type):
+			return invalidf("required field %q has unknown type %q", f.Key, f.Type)
+		}
+		seen[f.Key] = true
+	}
+	return nil
+}
+
+// CheckContentData checks data against a template version's fields. Required
+// fields must be present and non-empty, and every field present must have a
+// value of its type and, if it has options, be one of them. Keys the fields
+// don't mention are allowed. Errors name the field as contentData.<key>.
+func CheckContentData(fields []store.TemplateField, data map[string]any) []FieldError {
+	var errs []FieldError
+	for _, f := range fields {
+		name := "contentData." + f.Key
+		v, ok := data[f.Key]
+		if !ok || isEmptyValue(v) {
+			if f.Required {
+				errs = append(errs, FieldError{Field: name, Rule: "required"})
+			}
+			continue
+		}
+		if !hasFieldType(f.Type, v) {
+			errs = append(errs, FieldError{Field: name, Rule: "type", Param: f.Type})
+			continue
+		}
+		if len(f.Options) > 0 {
+			if s, isString := v.(string); !isString || !slices.Contains(f.Options, strings.TrimSpace(s)) {
+				errs = append(errs, FieldError{Field: name, Rule: "oneof", Param: strings.Join(f.Options, " ")})
+			}
+		}
+	}
+	return errs
+}
+
+func isEmptyValue(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// hasFieldType reports whether a decoded JSON value fits a field type.
+// Numbers may be sent as JSON numbers or as strings.
+func hasFieldType(fieldType string, v any) bool {
+	if _, isNumber := v.(float64); isNumber {
+		return fieldType != "date" && fieldType != "list"
+	}
+	s, isString := v.(string)
+	switch fieldType {
+	case "list":
+		if items, isList := v.([]any); isList {
+			return !slices.ContainsFunc(items, func(item any) bool {
+				_, nested := item.(map[string]any)
+				_, nestedList := item.([]any)
+				return nested || nestedList
+			})
+		}
+		return isString
+	case "text":
+		return isString
+	}
+	if !isString {
+		return false
+	}
+	s = strings.TrimSpace(s)
+	switch fieldType {
+	case "number":
+		_, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+		return err == nil
+	case "currency":
+		return currencyValue.MatchString(s)
+	case "percentage":
+		return percentageValue.MatchString(s)
+	case "date":
+		for _, layout := range dateLayouts {
+			if _, err := time.Parse(layout, s); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withContentData appends the deck's content data to the content the AI
+// binds, so the slides can use it. The template's fields come first, under
+// their labels, then any other keys in order.
+func withContentData(content string, fields []store.TemplateField, data map[string]any) string {
+	if len(data) == 0 {
+		return content
+	}
+	var b strings.Builder
+	b.WriteString(content)
+	b.WriteString("\n\nFIELDS:\n")
+	described := map[string]bool{}
+	for _, f := range fields {
+		if v, ok := data[f.Key]; ok && !isEmptyValue(v) {
+			fmt.Fprintf(&b, "%s: %s\n", f.Label, formatFieldValue(v))
+		}
+		described[f.Key] = true
+	}
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		if !described[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !isEmptyValue(data[k]) {
+			fmt.Fprintf(&b, "%s: %s\n", k, formatFieldValue(data[k]))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func formatFieldValue(v any) string {
+	if items, ok := v.([]any); ok {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(v)
+}
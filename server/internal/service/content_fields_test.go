@@ -0,0 +1,89 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+var reportFields = []store.TemplateField{
+	{Key: "company", Label: "Company", Type: "text", Required: true},
+	{Key: "revenue", Label: "Revenue", Type: "currency", Required: true},
+	{Key: "growth", Label: "Growth", Type: "percentage"},
+	{Key: "period", Label: "Period", Type: "date", Required: true},
+	{Key: "tone", Label: "Tone", Type: "text", Options: []string{"formal", "casual"}},
+}
+
+func TestCheckContentData(t *testing.T) {
+	cases := []struct {
+		name string
+		data map[string]any
+		want []FieldError
+	}{
+		{
+			name: "valid",
+			data: map[string]any{"company": "Acme", "revenue": "$2.5M", "growth": "15%", "period": "2025-03-31", "tone": "formal"},
+		},
+		{
+			name: "numbers fit currency and percentage",
+			data: map[string]any{"company": "Acme", "revenue": 2500000.0, "growth": 15.0, "period": "March 2025"},
+		},
+		{
+			name: "missing and empty required fields",
+			data: map[string]any{"company": "  ", "growth": "15%"},
+			want: []FieldError{
+				{Field: "contentData.company", Rule: "required"},
+				{Field: "contentData.revenue", Rule: "required"},
+				{Field: "contentData.period", Rule: "required"},
+			},
+		},
+		{
+			name: "wrong types and options",
+			data: map[string]any{"company": "Acme", "revenue": "lots", "growth": "fast", "period": "someday", "tone": "loud"},
+			want: []FieldError{
+				{Field: "contentData.revenue", Rule: "type", Param: "currency"},
+				{Field: "contentData.growth", Rule: "type", Param: "percentage"},
+				{Field: "contentData.period", Rule: "type", Param: "date"},
+				{Field: "contentData.tone", Rule: "oneof", Param: "formal casual"},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := CheckContentData(reportFields, tc.data)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateTemplateFields(t *testing.T) {
+	if err := ValidateTemplateFields(reportFields); err != nil {
+		t.Fatalf("valid fields: %v", err)
+	}
+	for _, fields := range [][]store.TemplateField{
+		{{Key: "1st", Label: "First", Type: "text"}},
+		{{Key: "a", Label: "A", Type: "text"}, {Key: "a", Label: "Again", Type: "text"}},
+		{{Key: "a", Type: "text"}},
+		{{Key: "a", Label: "A", Type: "color"}},
+	} {
+		var invalid *InvalidError
+		if err := ValidateTemplateFields(fields); !errors.As(err, &invalid) {
+			t.Errorf("%+v: expected InvalidError, got %v", fields, err)
+		}
+	}
+}
+
+func TestWithContentData(t *testing.T) {
+	got := withContentData("Quarterly update", reportFields, map[string]any{"revenue": "$2.5M", "company": "Acme", "extra": []any{"a", "b"}})
+	want := "Quarterly update\n\nFIELDS:\nCompany: Acme\nRevenue: $2.5M\nextra: a, b"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := withContentData("Plain", reportFields, nil); got != "Plain" {
+		t.Errorf("no content data should leave content alone, got %q", got)
+	}
+}
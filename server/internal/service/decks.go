@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/policy"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/stock"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/webhooks"
+)
+
+type SlideOutline struct {
+	SlideNumber int      `json:"slide_number" validate:"required"`
+	Title       string   `json:"title" validate:"required"`
+	Content     []string `json:"content"`
+	LayoutHint  string   `json:"layout_hint,omitempty"`
+	// Images are stored pictures for the slide. Only the first is placed,
+	// and only where the layout has room for it.
+	Images []spec.Image `json:"images,omitempty"`
+}
+
+type DeckOutline struct {
+	Slides []SlideOutline `json:"slides" validate:"required,dive"`
+}
+
+// DeckService reads decks, creates them from template versions and refines
+// them with the AI.
+type DeckService struct {
+	Store  store.Store
+	AI     ai.AIServiceInterface
+	Quotas Quotas
+	// History remembers the refinement instructions that aren't stored;
+	// nil forgets them.
+	History *RefinementHistory
+}
+
+// Get returns a deck the caller can view.
+func (ds *DeckService) Get(ctx context.Context, id auth.Identity, deckID string) (store.Deck, error) {
+	return authorizeDeck(ctx, ds.Store, id, deckID, store.PermissionView)
+}
+
+// List returns the decks the caller can view.
+func (ds *DeckService) List(ctx context.Context, id auth.Identity) ([]store.Deck, error) {
+	decks, err := ds.Store.Decks().ListDecksFor(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("list decks: %w", err)
+	}
+	return decks, nil
+}
+
+// CreateDeckInput describes a deck to create from a template version.
+type CreateDeckInput struct {
+	Name                    string
+	SourceTemplateVersionID string
+	Content                 string
+	// ContentData supplies the template version's required fields, by key.
+	// The bind job gets it along with Content.
+	ContentData map[string]any
+	// Outline, when set, builds the first version immediately instead of
+	// queueing an AI bind job. It is a DeckOutline or its decoded JSON.
+	Outline any
+	// Params override the org's generation defaults for the bind job.
+	Params store.GenerationParams
+	// StockImages has the bind job pick stock photos or icons for the
+	// slides. Decks built from an outline don't get them.
+	StockImages bool
+	// Listing is the deck's card metadata. An empty language is taken from
+	// the source template.
+	Listing store.Listing
+}
+
+// CreateDeckResult is the new deck with either its first version (when an
+// outline was given) or the queued bind job that will produce it.
+type CreateDeckResult struct {
+	Deck    store.Deck
+	Version *store.DeckVersion
+	Job     *store.Job
+}
+
+// Create makes a deck from a template version the caller can view. Editors
+// and above only.
+func (ds *DeckService) Create(ctx context.Context, id auth.Identity, in CreateDeckInput) (CreateDeckResult, error) {
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		return CreateDeckResult{}, ErrForbidden
+	}
+
+	tv, ok, err := ds.Store.Templates().GetVersion(ctx, id.OrgID, in.SourceTemplateVersionID)
+	if err != nil {
+		return CreateDeckResult{}, fmt.Errorf("load template version: %w", err)
+	}
+	if !ok {
+		return CreateDeckResult{}, fmt.Errorf("template version %w", ErrNotFound)
+	}
+	if _, err := authorizeTemplate(ctx, ds.Store, id, tv.Template, store.PermissionView); err != nil {
+		return CreateDeckResult{}, err
+	}
+	if errs := CheckContentData(tv.RequiredFields, in.ContentData); len(errs) > 0 {
+		return CreateDeckResult{}, &InvalidError{Msg: "content data does not satisfy the template's required fields", Fields: errs}
+	}
+	pol, err := orgPolicy(ctx, ds.Store, id.OrgID)
+	if err != nil {
+		return CreateDeckResult{}, err
+	}
+	if err := policy.CheckTemplate(pol, tv.Template); err != nil {
+		return CreateDeckResult{}, err
+	}
+	content := withContentData(in.Content, tv.RequiredFields, in.ContentData)
+	if err := policy.CheckText(pol, content); err != nil {
+		return CreateDeckResult{}, err
+	}
+
+	var templateSpec spec.TemplateSpec
+	specBytes, err := normalize.JSON(tv.SpecJSON)
+	if err != nil {
+		return CreateDeckResult{}, fmt.Errorf("read template spec: %w", err)
+	}
+	if err := json.Unmarshal(specBytes, &templateSpec); err != nil {
+		return CreateDeckResult{}, &InvalidError{Msg: "invalid stored template spec", Spec: true}
+	}
+
+	deck := store.Deck{
+		ID:                    newID("deck"),
+		OrgID:                 id.OrgID,
+		OwnerUserID:           id.UserID,
+		Name:                  in.Name,
+		SourceTemplateVersion: in.SourceTemplateVersionID,
+		Content:               in.Content,
+		Listing:               in.Listing,
+	}
+	if deck.Language == "" {
+		if tpl, ok, err := ds.Store.Templates().GetTemplate(ctx, id.OrgID, tv.Template); err == nil && ok {
+			deck.Language = tpl.Language
+		}
+	}
+	var res CreateDeckResult
+	if in.Outline != nil {
+		res, err = ds.createFromOutline(ctx, id, deck, &templateSpec, in.Outline, pol)
+	} else {
+		res, err = ds.createWithBindJob(ctx, id, deck, content, in.Params, in.StockImages)
+	}
+	if err != nil {
+		return CreateDeckResult{}, err
+	}
+	_, _ = ds.Store.Metering().Record(ctx, store.MeteringEvent{ID: newID("met"), OrgID: id.OrgID, UserID: id.UserID, Type: store.MeterDeckCreate, Quantity: 1, TargetRef: tv.Template})
+	return res, nil
+}
+
+// createFromOutline fills the template's layouts from the outline, without
+// AI, and stores the deck with that as its first version, checked against
+// the org's policy and given its mandatory slides.
+func (ds *DeckService) createFromOutline(ctx context.Context, id auth.Identity, deck store.Deck, templateSpec *spec.TemplateSpec, rawOutline any, pol *store.OrgPolicy) (CreateDeckResult, error) {
+	outline, err := parseDeckOutline(rawOutline)
+	if err != nil {
+		return CreateDeckResult{}, invalidf("invalid outline")
+	}
+	bound := BuildDeckSpecFromOutline(templateSpec, outline)
+	if err := policy.CheckSpec(pol, *bound); err != nil {
+		return CreateDeckResult{}, err
+	}
+	policy.AddMandatorySlides(pol, bound)
+	boundBytes, err := json.Marshal(bound)
+	if err != nil {
+		return CreateDeckResult{}, fmt.Errorf("marshal bound spec: %w", err)
+	}
+
+	// The deck, its first version and the pointer between them are written
+	// together so a failure can't leave a deck with no version.
+	var createdDeck store.Deck
+	var createdVer store.DeckVersion
+	err = ds.Store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		if createdDeck, err = tx.Decks().CreateDeck(ctx, deck); err != nil {
+			return fmt.Errorf("create deck: %w", err)
+		}
+		ver := store.DeckVersion{
+			ID:        newID("dv"),
+			Deck:      createdDeck.ID,
+			OrgID:     id.OrgID,
+			VersionNo: 1,
+			SpecJSON:  json.RawMessage(boundBytes),
+			CreatedBy: id.UserID,
+		}
+		if createdVer, err = tx.Decks().CreateDeckVersion(ctx, ver); err != nil {
+			return fmt.Errorf("create deck version: %w", err)
+		}
+		createdDeck.CurrentVersion = &createdVer.ID
+		createdDeck.LatestVersionNo = 1
+		if createdDeck, err = tx.Decks().UpdateDeck(ctx, createdDeck); err != nil {
+			return fmt.Errorf("set current version: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return CreateDeckResult{}, err
+	}
+
+	EmitDeckCreated(ctx, ds.Store, id.UserID, createdDeck)
+	return CreateDeckResult{Deck: createdDeck, Version: &createdVer}, nil
+}
+
+// createWithBindJob stores the deck and queues the AI job that binds content
+// (the deck's, with its content data) to the template.
+func (ds *DeckService) createWithBindJob(ctx context.Context, id auth.Identity, deck store.Deck, content string, params store.GenerationParams, stockImages bool) (CreateDeckResult, error) {
+	metadata := store.JSONMap{
+		"sourceTemplateVersionId": deck.SourceTemplateVersion,
+		"content":                 content,
+		"userId":                  id.UserID,
+	}
+	if stockImages {
+		metadata["stockImages"] = "true"
+	}
+	setParamsMetadata(metadata, resolveParams(ctx, ds.Store, id.OrgID, params))
+
+	// The bind job is enqueued with the deck so a deck never exists without
+	// either a version or a job that will produce one.
+	var createdDeck store.Deck
+	var createdJob store.Job
+	err := ds.Store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		if createdDeck, err = tx.Decks().CreateDeck(ctx, deck); err != nil {
+			return fmt.Errorf("create deck: %w", err)
+		}
+		job := store.Job{
+			ID:              newID("job"),
+			OrgID:           id.OrgID,
+			Type:            store.JobBind,
+			Status:          store.JobQueued,
+			InputRef:        createdDeck.ID,
+			DeduplicationID: fmt.Sprintf("bind-%s", createdDeck.ID),
+			Metadata:        &metadata,
+		}
+		if createdJob, _, err = tx.Jobs().EnqueueWithDeduplication(ctx, job); err != nil {
+			return fmt.Errorf("enqueue bind job: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return CreateDeckResult{}, err
+	}
+
+	_, _ = ds.Store.Audit().Append(ctx, store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.bind.queued", TargetRef: createdDeck.ID, Metadata: withPrompt(ctx, ds.Store, id.OrgID, map[string]any{"jobId": createdJob.ID}, "content", deck.Content)})
+	EmitDeckCreated(ctx, ds.Store, id.UserID, createdDeck)
+	return CreateDeckResult{Deck: createdDeck, Job: &createdJob}, nil
+}
+
+// EmitDeckCreated announces a new deck to the org's webhooks.
+func EmitDeckCreated(ctx context.Context, st store.Store, actorID string, d store.Deck) {
+	data := map[string]any{"deckId": d.ID, "name": d.Name}
+	if d.CurrentVersion != nil {
+		data["versionId"] = *d.CurrentVersion
+	}
+	webhooks.Emit(ctx, st, actorID, webhooks.Event{Type: webhooks.EventDeckCreated, OrgID: d.OrgID, Data: data})
+}
+
+func parseDeckOutline(v any) (*DeckOutline, error) {
+	b, err := normalize.JSON(v)
+	if err != nil {
+		return nil, err
+	}
+	var out DeckOutline
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Slides) == 0 {
+		return nil, errors.New("no slides")
+	}
+	return &out, nil
+}
+
+// BuildDeckSpecFromOutline makes one slide per outline entry from the
+// template's first layout, putting each title and its content lines into the
+// layout's title and body placeholders, and its image in a free spot.
+func BuildDeckSpecFromOutline(templateSpec *spec.TemplateSpec, outline *DeckOutline) *spec.TemplateSpec {
+	// Clone tokens/constraints but replace layouts with one per slide.
+	out := &spec.TemplateSpec{
+		SpecVersion: templateSpec.SpecVersion,
+		Tokens:      templateSpec.Tokens,
+		Constraints: templateSpec.Constraints,
+		Layouts:     []spec.Layout{},
+	}
+
+	// Pick a base layout to clone.
+	base := spec.Layout{Name: "Slide", Placeholders: []spec.Placeholder{}}
+	if len(templateSpec.Layouts) > 0 {
+		base = templateSpec.Layouts[0]
+	}
+
+	// Choose title + body placeholders by convention.
+	titleID := ""
+	bodyID := ""
+	for _, ph := range base.Placeholders {
+		if ph.Type != "text" {
+			continue
+		}
+		id := strings.ToLower(ph.ID)
+		if titleID == "" && strings.Contains(id, "title") {
+			titleID = ph.ID
+			continue
+		}
+		if bodyID == "" && (strings.Contains(id, "body") || strings.Contains(id, "content") || strings.Contains(id, "subtitle")) {
+			bodyID = ph.ID
+			continue
+		}
+	}
+	// Fallback to first/second text placeholders.
+	if titleID == "" || bodyID == "" {
+		textIDs := []string{}
+		for _, ph := range base.Placeholders {
+			if ph.Type == "text" {
+				textIDs = append(textIDs, ph.ID)
+			}
+		}
+		if titleID == "" && len(textIDs) > 0 {
+			titleID = textIDs[0]
+		}
+		if bodyID == "" {
+			if len(textIDs) > 1 {
+				bodyID = textIDs[1]
+			} else if len(textIDs) == 1 {
+				bodyID = textIDs[0]
+			}
+		}
+	}
+
+	for _, sld := range outline.Slides {
+		layoutName := sld.LayoutHint
+		if layoutName == "" {
+			layoutName = "simple"
+		}
+		layout := spec.Layout{Name: layoutName, Placeholders: []spec.Placeholder{}}
+		for _, ph := range base.Placeholders {
+			p := ph
+			if p.Type == "text" {
+				if p.ID == titleID {
+					p.Content = sld.Title
+				} else if p.ID == bodyID {
+					p.Content = strings.Join(sld.Content, "\n")
+				} else {
+					p.Content = ""
+				}
+			}
+			layout.Placeholders = append(layout.Placeholders, p)
+		}
+		out.Layouts = append(out.Layouts, layout)
+		if len(sld.Images) > 0 {
+			stock.Place(out, len(out.Layouts)-1, sld.Images[0])
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// Built-in export names, used when the org hasn't set its own template.
+const (
+	DefaultDeckExportFilename     = "deck-export-v{versionNo}-{timestamp}"
+	DefaultTemplateExportFilename = "template-export-v{versionNo}-{timestamp}"
+	maxExportFilenameLength       = 150
+)
+
+var filenamePlaceholder = regexp.MustCompile(`\{([A-Za-z]+)\}`)
+
+// ExportFilenameVars are the values an export filename template can use.
+type ExportFilenameVars struct {
+	Name      string // deck or template name
+	VersionNo int
+	Time      time.Time
+}
+
+func (v ExportFilenameVars) lookup(key string) (string, bool) {
+	switch key {
+	case "name", "deckName", "templateName":
+		return v.Name, true
+	case "versionNo":
+		return strconv.Itoa(v.VersionNo), true
+	case "date":
+		return v.Time.Format("2006-01-02"), true
+	case "timestamp":
+		return v.Time.Format("20060102-150405"), true
+	default:
+		return "", false
+	}
+}
+
+// ValidateExportFilenameTemplate rejects templates with unknown placeholders
+// so typos surface when the setting is saved rather than at export time.
+func ValidateExportFilenameTemplate(tmpl string) error {
+	for _, m := range filenamePlaceholder.FindAllStringSubmatch(tmpl, -1) {
+		if _, ok := (ExportFilenameVars{}).lookup(m[1]); !ok {
+			return fmt.Errorf("unknown placeholder {%s}; use {deckName}, {templateName}, {name}, {versionNo}, {date} or {timestamp}", m[1])
+		}
+	}
+	return nil
+}
+
+// RenderExportFilename fills in tmpl and returns a safe file name ending in
+// ext. Any extension written into the template is replaced, since the export
+// format decides it.
+func RenderExportFilename(tmpl string, vars ExportFilenameVars, ext string) string {
+	name := filenamePlaceholder.ReplaceAllStringFunc(tmpl, func(p string) string {
+		v, _ := vars.lookup(p[1 : len(p)-1])
+		return strings.TrimSpace(v)
+	})
+	switch strings.ToLower(path.Ext(name)) {
+	case ".pptx", ".zip", ".pdf":
+		name = name[:len(name)-len(path.Ext(name))]
+	}
+	name = sanitizeFilename(name)
+	if name == "" {
+		name = "export"
+	}
+	return name + ext
+}
+
+// sanitizeFilename drops characters that are unsafe in file names or headers
+// and trims the result to maxExportFilenameLength runes.
+func sanitizeFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 0x20 || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Join(strings.Fields(name), " ")
+	if utf8.RuneCountInString(name) > maxExportFilenameLength {
+		name = string([]rune(name)[:maxExportFilenameLength])
+	}
+	return strings.Trim(name, " .")
+}
+
+// ExportFilename names an export using the org's filename template, or
+// fallback when the org has none.
+func ExportFilename(ctx context.Context, st store.Store, orgID, fallback string, vars ExportFilenameVars, ext string) string {
+	tmpl := fallback
+	if org, err := st.Organizations().GetOrganization(ctx, orgID); err != nil {
+		logger.WithContext(ctx).Debug("export_filename_template_unavailable", "org_id", orgID, "error", err)
+	} else if org.ExportFilenameTemplate != "" {
+		tmpl = org.ExportFilenameTemplate
+	}
+	if vars.Time.IsZero() {
+		vars.Time = time.Now().UTC()
+	}
+	return RenderExportFilename(tmpl, vars, ext)
+}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderExportFilename(t *testing.T) {
+	vars := ExportFilenameVars{Name: "  Sales  Deck ", VersionNo: 2, Time: time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)}
+	assert.Equal(t, "Sales Deck-v2-2024-05-06.pptx", RenderExportFilename("{deckName}-v{versionNo}-{date}.pptx", vars, ".pptx"))
+	assert.Equal(t, "template-export-v2-20240506-070809.zip", RenderExportFilename(DefaultTemplateExportFilename, vars, ".zip"))
+	assert.Equal(t, "export.pptx", RenderExportFilename("{name}", ExportFilenameVars{}, ".pptx"))
+	assert.Len(t, []rune(RenderExportFilename(strings.Repeat("a", 300), vars, "")), maxExportFilenameLength)
+}
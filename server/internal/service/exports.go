@@ -0,0 +1,600 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/connectors"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const pptxMime = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+
+// ExportService exports deck and template versions.
+type ExportService struct {
+	Store     store.Store
+	Quotas    Quotas
+	Renderers assets.QualityRenderers
+	Objects   assets.ObjectStorage
+	// Fonts embeds the org's uploaded fonts in rendered decks; nil skips it.
+	Fonts *assets.FontResolver
+	// Connectors fills connector bindings with live data; nil leaves them
+	// as they are.
+	Connectors *connectors.Resolver
+}
+
+// ExportOptions are the caller's choices for an export; zero values give a
+// standard-quality PPTX from the org's default renderer.
+type ExportOptions struct {
+	Format  string
+	Quality string
+	// Renderer names the engine, one of assets.RendererEngines.
+	Renderer string
+	// AdjustContrast nudges the theme's text colors to meet WCAG AA contrast
+	// before rendering; see spec.AdjustContrast.
+	AdjustContrast bool
+	// Deterministic renders with assets.NewDeterministicRenderer, so the same
+	// content always gives the same file.
+	Deterministic bool
+	// Force renders afresh instead of returning an earlier export of the
+	// same content; the new export then serves later requests.
+	Force bool
+	// Data fills the deck's {{name}} variables when it renders, so one deck
+	// version exports as many personalized decks; see spec.MergeVariables.
+	// Every variable the deck uses needs a value. Only deck version exports
+	// take data.
+	Data map[string]string
+	// Comments adds the version's open review threads to the PPTX as native
+	// PowerPoint comments. Only deck version PPTX exports take comments.
+	Comments bool
+	// Width is the width in pixels of png and gif exports' slide images; 0
+	// means assets.DefaultPNGWidth or assets.DefaultGIFWidth.
+	Width int
+	// TargetSize is a size budget in bytes for a PPTX export: the file is
+	// compressed toward it with assets.CompressPPTX, and an export still
+	// over it carries a warning. 0 means no budget.
+	TargetSize int64
+}
+
+// Export size budgets, in bytes; see ExportOptions.TargetSize.
+const (
+	MinExportTargetSize = 1 << 20
+	MaxExportTargetSize = 1 << 30
+)
+
+// maxMergeVariables bounds an export's data.
+const maxMergeVariables = 200
+
+// ExportResult is an export job and, once the export is done, its asset.
+// Duplicate is set when an earlier export of the same content was reused;
+// see ExportService.exportCacheKey.
+type ExportResult struct {
+	Job       store.Job
+	Asset     *store.Asset
+	Duplicate bool
+}
+
+// NormalizeExportFormat checks an export format; "" means the plain PPTX
+// export.
+func NormalizeExportFormat(format string) (string, error) {
+	switch format {
+	case "", "pptx":
+		return "pptx", nil
+	case store.ExportFormatBundle, store.ExportFormatPNG, store.ExportFormatGIF, store.ExportFormatMarkdown:
+		return format, nil
+	default:
+		return "", invalidf("format must be pptx, bundle, png, gif or md")
+	}
+}
+
+// exportExtension is the file extension of an export in format.
+func exportExtension(format string) string {
+	switch format {
+	case store.ExportFormatBundle, store.ExportFormatPNG:
+		return ".zip"
+	case store.ExportFormatGIF:
+		return ".gif"
+	case store.ExportFormatMarkdown:
+		return ".md"
+	default:
+		return ".pptx"
+	}
+}
+
+// NormalizeExportQuality checks an export quality profile; "" means
+// standard.
+func NormalizeExportQuality(quality string) (string, error) {
+	switch quality {
+	case "", store.ExportQualityStandard:
+		return store.ExportQualityStandard, nil
+	case store.ExportQualityDraft, store.ExportQualityHigh:
+		return quality, nil
+	default:
+		return "", invalidf("quality must be draft, standard or high")
+	}
+}
+
+// NormalizeRenderer checks a renderer engine; "" leaves the choice to the
+// org default and then the server.
+func NormalizeRenderer(engine string) (string, error) {
+	if engine != "" && !assets.IsRendererEngine(engine) {
+		return "", invalidf("renderer must be one of %s", strings.Join(assets.RendererEngines, ", "))
+	}
+	return engine, nil
+}
+
+// ExportRenderer picks the engine an export job records: the requested one,
+// else the org's default for standard-quality exports. Draft and high
+// exports otherwise keep their profile's renderer, and "" means the server
+// default.
+func ExportRenderer(ctx context.Context, st store.Store, orgID, quality, requested string) string {
+	if requested != "" || quality != store.ExportQualityStandard {
+		return requested
+	}
+	org, err := st.Organizations().GetOrganization(ctx, orgID)
+	if err != nil {
+		return ""
+	}
+	return org.DefaultRenderer
+}
+
+func (o ExportOptions) normalize() (ExportOptions, error) {
+	format, err := NormalizeExportFormat(o.Format)
+	if err != nil {
+		return o, err
+	}
+	quality, err := NormalizeExportQuality(o.Quality)
+	if err != nil {
+		return o, err
+	}
+	renderer, err := NormalizeRenderer(o.Renderer)
+	if err != nil {
+		return o, err
+	}
+	if o.Deterministic && renderer != "" && renderer != assets.RendererGo {
+		return o, invalidf("deterministic exports use the go renderer")
+	}
+	if len(o.Data) > maxMergeVariables {
+		return o, invalidf("data may hold at most %d variables", maxMergeVariables)
+	}
+	for name := range o.Data {
+		if !spec.IsVariableName(name) {
+			return o, invalidf("data variable %q must start with a letter or underscore and hold only letters, digits, _, . and -", name)
+		}
+		if spec.IsConnectorVariable(name) {
+			return o, invalidf("data variable %q: names starting with %q are filled from data connectors", name, spec.ConnectorPrefix)
+		}
+	}
+	if o.Comments && format != "pptx" {
+		return o, invalidf("comments are only added to pptx exports")
+	}
+	width := o.Width
+	switch format {
+	case store.ExportFormatPNG, store.ExportFormatGIF:
+		if width == 0 {
+			width = assets.DefaultPNGWidth
+			if format == store.ExportFormatGIF {
+				width = assets.DefaultGIFWidth
+			}
+		}
+		if width < assets.MinSlideImageWidth || width > assets.MaxSlideImageWidth {
+			return o, invalidf("width must be between %d and %d pixels", assets.MinSlideImageWidth, assets.MaxSlideImageWidth)
+		}
+	default:
+		if width != 0 {
+			return o, invalidf("width only applies to png and gif exports")
+		}
+	}
+	if o.TargetSize != 0 {
+		if format != "pptx" {
+			return o, invalidf("a target size only applies to pptx exports")
+		}
+		if o.TargetSize < MinExportTargetSize || o.TargetSize > MaxExportTargetSize {
+			return o, invalidf("target size must be between %d and %d MB", MinExportTargetSize>>20, MaxExportTargetSize>>20)
+		}
+	}
+	return ExportOptions{Format: format, Quality: quality, Renderer: renderer, AdjustContrast: o.AdjustContrast, Deterministic: o.Deterministic, Force: o.Force, Data: o.Data, Comments: o.Comments, Width: width, TargetSize: o.TargetSize}, nil
+}
+
+// resolve fills in the org's default renderer. Deterministic exports always
+// use the Go renderer, so the org default doesn't apply.
+func (o ExportOptions) resolve(ctx context.Context, st store.Store, orgID string) ExportOptions {
+	if o.Deterministic {
+		o.Renderer = ""
+		return o
+	}
+	o.Renderer = ExportRenderer(ctx, st, orgID, o.Quality, o.Renderer)
+	return o
+}
+
+// tag records the non-default options in job metadata for the worker and
+// returns metadata.
+func (o ExportOptions) tag(metadata store.JSONMap) store.JSONMap {
+	if o.Quality != store.ExportQualityStandard {
+		metadata["quality"] = o.Quality
+	}
+	if o.Renderer != "" {
+		metadata["renderer"] = o.Renderer
+	}
+	if o.AdjustContrast {
+		metadata["adjustContrast"] = "true"
+	}
+	if o.Deterministic {
+		metadata["deterministic"] = "true"
+	}
+	if len(o.Data) > 0 {
+		b, _ := json.Marshal(o.Data)
+		metadata["mergeData"] = string(b)
+	}
+	if o.Comments {
+		metadata["comments"] = "true"
+	}
+	if o.Width > 0 {
+		metadata["width"] = strconv.Itoa(o.Width)
+	}
+	if o.TargetSize > 0 {
+		metadata["targetSize"] = strconv.FormatInt(o.TargetSize, 10)
+	}
+	return metadata
+}
+
+// ExportDeckVersion queues an export of a deck version the caller can view.
+// A PPTX export of content that was exported before returns that export,
+// marked Duplicate, with its asset once done; other formats are always made
+// afresh.
+func (es *ExportService) ExportDeckVersion(ctx context.Context, id auth.Identity, versionID string, opts ExportOptions) (ExportResult, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return ExportResult{}, err
+	}
+	format := opts.Format
+	dv, ok, err := es.Store.Decks().GetDeckVersion(ctx, id.OrgID, versionID)
+	if err != nil {
+		return ExportResult{}, fmt.Errorf("get deck version: %w", err)
+	}
+	if !ok {
+		return ExportResult{}, ErrNotFound
+	}
+	deck, err := authorizeDeck(ctx, es.Store, id, dv.Deck, store.PermissionView)
+	if err != nil {
+		return ExportResult{}, err
+	}
+	opts = opts.resolve(ctx, es.Store, id.OrgID)
+	// The cache key covers the merged content, so each data set is its own
+	// export and merging the same data again reuses it.
+	specJSON := dv.SpecJSON
+	if opts.Data != nil {
+		if specJSON, err = MergeSpecData(dv.SpecJSON, opts.Data); err != nil {
+			return ExportResult{}, err
+		}
+	}
+
+	metadata := store.JSONMap{
+		"versionNo": fmt.Sprintf("%d", dv.VersionNo),
+		"filename":  ExportFilename(ctx, es.Store, id.OrgID, DefaultDeckExportFilename, ExportFilenameVars{Name: deck.Name, VersionNo: dv.VersionNo}, exportExtension(format)),
+		"userId":    id.UserID,
+	}
+	if format != "pptx" {
+		metadata["format"] = format
+	}
+	opts.tag(metadata)
+	job := store.Job{
+		ID:       newID("job"),
+		OrgID:    id.OrgID,
+		Type:     store.JobExport,
+		Status:   store.JobQueued,
+		InputRef: versionID,
+		Metadata: &metadata,
+	}
+	// Decks bound to data connectors render differently as the data
+	// changes, and comments change without the deck doing so, so neither is
+	// served from the cache.
+	if format == "pptx" && !opts.Comments && !connectors.Live(specJSON) {
+		if job.DeduplicationID, err = es.exportCacheKey(ctx, id.OrgID, specJSON, opts); err != nil {
+			return ExportResult{}, err
+		}
+		if res, ok, err := es.cachedExport(ctx, id.OrgID, job.DeduplicationID, opts); err != nil || ok {
+			return res, err
+		}
+	}
+	if err := es.checkQuotas(ctx, id); err != nil {
+		return ExportResult{}, err
+	}
+	if job, err = es.Store.Jobs().Enqueue(ctx, job); err != nil {
+		return ExportResult{}, fmt.Errorf("enqueue export job: %w", err)
+	}
+
+	logger.Jobs().Info("deck_export_queued", "user_id", id.UserID, "org_id", id.OrgID, "job_id", job.ID, "version_id", versionID)
+	_, _ = es.Store.Audit().Append(ctx, store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.export", TargetRef: versionID, Metadata: map[string]any{"jobId": job.ID, "versionNo": dv.VersionNo, "format": format, "quality": opts.Quality, "renderer": opts.Renderer}})
+	return ExportResult{Job: job}, nil
+}
+
+// ExportTemplateVersion exports a template version the caller can view.
+// Formats other than PPTX are queued for the worker. PPTX exports render
+// within the call, and a repeat export of the same content returns the
+// earlier job, marked Duplicate, with its asset if done.
+func (es *ExportService) ExportTemplateVersion(ctx context.Context, id auth.Identity, versionID string, opts ExportOptions) (ExportResult, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return ExportResult{}, err
+	}
+	if opts.Data != nil {
+		return ExportResult{}, invalidf("only deck version exports take data")
+	}
+	if opts.Comments {
+		return ExportResult{}, invalidf("only deck version exports take comments")
+	}
+	ver, ok, err := es.Store.Templates().GetVersion(ctx, id.OrgID, versionID)
+	if err != nil {
+		return ExportResult{}, fmt.Errorf("get template version: %w", err)
+	}
+	if !ok {
+		return ExportResult{}, ErrNotFound
+	}
+	tpl, err := authorizeTemplate(ctx, es.Store, id, ver.Template, store.PermissionView)
+	if err != nil {
+		return ExportResult{}, err
+	}
+	opts = opts.resolve(ctx, es.Store, id.OrgID)
+	nameVars := ExportFilenameVars{Name: tpl.Name, VersionNo: ver.VersionNo}
+	if opts.Format != "pptx" {
+		if err := es.checkQuotas(ctx, id); err != nil {
+			return ExportResult{}, err
+		}
+		job, err := es.queueTemplateExport(ctx, id, ver, opts, ExportFilename(ctx, es.Store, id.OrgID, DefaultTemplateExportFilename, nameVars, exportExtension(opts.Format)))
+		return ExportResult{Job: job}, err
+	}
+
+	var cacheKey string
+	if !connectors.Live(ver.SpecJSON) {
+		if cacheKey, err = es.exportCacheKey(ctx, id.OrgID, ver.SpecJSON, opts); err != nil {
+			return ExportResult{}, err
+		}
+		if res, ok, err := es.cachedExport(ctx, id.OrgID, cacheKey, opts); err != nil || ok {
+			return res, err
+		}
+	}
+	if err := es.checkQuotas(ctx, id); err != nil {
+		return ExportResult{}, err
+	}
+	var metadata *store.JSONMap
+	if tags := (store.JSONMap{}); len(opts.tag(tags)) > 0 {
+		metadata = &tags
+	}
+	job, err := es.Store.Jobs().Enqueue(ctx, store.Job{
+		ID:              newID("job"),
+		OrgID:           id.OrgID,
+		Type:            store.JobExport,
+		Status:          store.JobQueued,
+		InputRef:        versionID,
+		DeduplicationID: cacheKey,
+		Metadata:        metadata,
+	})
+	if err != nil {
+		return ExportResult{}, fmt.Errorf("enqueue export job: %w", err)
+	}
+
+	asset, err := es.renderTemplatePPTX(ctx, id, ver, job, opts, ExportFilename(ctx, es.Store, id.OrgID, DefaultTemplateExportFilename, nameVars, ".pptx"))
+	if err != nil {
+		return ExportResult{}, err
+	}
+	job.Status = store.JobDone
+	job.OutputRef = asset.ID
+	if warning := assets.SizeWarning(asset.SizeBytes, asset.BudgetBytes); warning != "" {
+		if job.Metadata == nil {
+			job.Metadata = &store.JSONMap{}
+		}
+		(*job.Metadata)["sizeWarning"] = warning
+	}
+	if _, err := es.Store.Jobs().Update(ctx, job); err != nil {
+		return ExportResult{}, fmt.Errorf("update export job %s: %w", job.ID, err)
+	}
+	_, _ = es.Store.Metering().Record(ctx, store.MeteringEvent{ID: newID("met"), OrgID: id.OrgID, UserID: id.UserID, Type: "export", Quantity: 1, TargetRef: ver.Template})
+	_, _ = es.Store.Audit().Append(ctx, store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "version.export", TargetRef: versionID, Metadata: map[string]any{"jobId": job.ID, "assetId": asset.ID, "quality": opts.Quality, "renderer": opts.Renderer}})
+	return ExportResult{Job: job, Asset: &asset}, nil
+}
+
+func (es *ExportService) checkQuotas(ctx context.Context, id auth.Identity) error {
+	if err := es.Quotas.CheckExport(ctx, id, 1); err != nil {
+		return err
+	}
+	return es.Quotas.CheckStorage(ctx, id)
+}
+
+func (es *ExportService) queueTemplateExport(ctx context.Context, id auth.Identity, ver store.TemplateVersion, opts ExportOptions, filename string) (store.Job, error) {
+	metadata := opts.tag(store.JSONMap{
+		"format":    opts.Format,
+		"versionNo": fmt.Sprintf("%d", ver.VersionNo),
+		"filename":  filename,
+		"userId":    id.UserID,
+	})
+	job, err := es.Store.Jobs().Enqueue(ctx, store.Job{
+		ID:       newID("job"),
+		OrgID:    id.OrgID,
+		Type:     store.JobExport,
+		Status:   store.JobQueued,
+		InputRef: ver.ID,
+		Metadata: &metadata,
+	})
+	if err != nil {
+		return store.Job{}, fmt.Errorf("enqueue export job: %w", err)
+	}
+
+	logger.Jobs().Info("template_export_queued", "user_id", id.UserID, "org_id", id.OrgID, "job_id", job.ID, "version_id", ver.ID, "format", opts.Format)
+	_, _ = es.Store.Audit().Append(ctx, store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "version.export", TargetRef: ver.ID, Metadata: map[string]any{"jobId": job.ID, "format": opts.Format, "quality": opts.Quality, "renderer": opts.Renderer}})
+	return job, nil
+}
+
+// renderTemplatePPTX renders the version with opts, uploads it and records
+// the asset as the output of job.
+func (es *ExportService) renderTemplatePPTX(ctx context.Context, id auth.Identity, ver store.TemplateVersion, job store.Job, opts ExportOptions, filename string) (store.Asset, error) {
+	var renderer assets.Renderer
+	var err error
+	if opts.Deterministic {
+		renderer = assets.DeterministicRenderer(opts.Quality)
+	} else if renderer, err = es.Renderers.Pick(opts.Quality, opts.Renderer); err != nil {
+		return store.Asset{}, err
+	}
+	if opts.AdjustContrast {
+		renderer = assets.ContrastRenderer(renderer)
+	}
+	renderer = es.Connectors.Renderer(renderer, id.OrgID)
+	renderer = es.Fonts.Renderer(renderer, id.OrgID)
+	// Use a random filename for the stored object; the DB asset ID will be a UUID.
+	objectKey := newID("asset") + ".pptx"
+	tempPath := filepath.Join(os.TempDir(), objectKey)
+	if err := renderer.RenderPPTX(ctx, ver.SpecJSON, tempPath); err != nil {
+		return store.Asset{}, fmt.Errorf("render: %w", err)
+	}
+	defer os.Remove(tempPath)
+
+	data, err := os.ReadFile(tempPath)
+	if err != nil {
+		return store.Asset{}, fmt.Errorf("read rendered file: %w", err)
+	}
+	var originalSize int64
+	if opts.TargetSize > 0 {
+		originalSize = int64(len(data))
+		if compressed, _, err := assets.CompressPPTX(data, opts.TargetSize); err != nil {
+			logger.Jobs().Warn("export_compress_failed", "job_id", job.ID, "error", err)
+		} else {
+			data = compressed
+		}
+	}
+	objects, err := assets.ForOrg(ctx, es.Store.Organizations(), id.OrgID)
+	if err != nil {
+		return store.Asset{}, err
+	}
+	if _, err := es.Objects.Upload(objects, objectKey, data, pptxMime); err != nil {
+		return store.Asset{}, fmt.Errorf("upload asset: %w", err)
+	}
+	asset, err := es.Store.Assets().Create(ctx, store.Asset{
+		ID:                newID("asset"),
+		OrgID:             id.OrgID,
+		Type:              store.AssetPPTX,
+		Path:              objectKey,
+		Mime:              pptxMime,
+		SizeBytes:         int64(len(data)),
+		SourceJobID:       job.ID,
+		Filename:          filename,
+		Region:            assets.RegionFrom(objects),
+		SHA256:            assets.Checksum(data),
+		BudgetBytes:       opts.TargetSize,
+		OriginalSizeBytes: originalSize,
+	})
+	if err != nil {
+		return store.Asset{}, fmt.Errorf("create asset: %w", err)
+	}
+	return asset, nil
+}
+
+// exportCacheKey is the cache key of a PPTX export of specJSON, used as its
+// job's DeduplicationID: a hash of the normalized spec and of everything
+// else that shapes the file, namely the export options, the renderer version
+// and the org's fonts, which get embedded.
+func (es *ExportService) exportCacheKey(ctx context.Context, orgID string, specJSON any, opts ExportOptions) (string, error) {
+	b, err := normalize.JSON(specJSON)
+	if err != nil {
+		return "", fmt.Errorf("read spec: %w", err)
+	}
+	// Round-tripping drops whitespace and sorts object keys.
+	var spec any
+	if err := json.Unmarshal(b, &spec); err != nil {
+		return "", fmt.Errorf("read spec: %w", err)
+	}
+	if b, err = json.Marshal(spec); err != nil {
+		return "", fmt.Errorf("read spec: %w", err)
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "renderer=%s/%s\nquality=%s\ncontrast=%t\ndeterministic=%t\n", opts.Renderer, assets.RendererVersion, opts.Quality, opts.AdjustContrast, opts.Deterministic)
+	// Written only when set, so exports without a budget keep their keys.
+	if opts.TargetSize > 0 {
+		fmt.Fprintf(h, "targetSize=%d\n", opts.TargetSize)
+	}
+	if es.Fonts != nil {
+		fonts, err := es.Store.Fonts().List(ctx, orgID)
+		if err != nil {
+			return "", fmt.Errorf("list fonts: %w", err)
+		}
+		for _, f := range fonts {
+			fmt.Fprintf(h, "font=%s/%s\n", f.ID, f.AssetID)
+		}
+	}
+	h.Write(b)
+	return fmt.Sprintf("%s-%s", store.JobExport, hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// MergeSpecData returns specJSON with data merged into its {{name}}
+// variables. It fails with an InvalidError when data lacks any of them: a
+// personalized deck with a variable left in would go out looking broken.
+// Connector bindings are left for the renderer to fill.
+func MergeSpecData(specJSON any, data map[string]string) (json.RawMessage, error) {
+	b, err := normalize.JSON(specJSON)
+	if err != nil {
+		return nil, fmt.Errorf("read spec: %w", err)
+	}
+	var doc any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("read spec: %w", err)
+	}
+	var missing []string
+	for _, name := range spec.Variables(doc) {
+		if _, ok := data[name]; !ok && !spec.IsConnectorVariable(name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, invalidf("data is missing deck variables: %s", strings.Join(missing, ", "))
+	}
+	if b, err = json.Marshal(spec.MergeVariables(doc, data)); err != nil {
+		return nil, fmt.Errorf("merge data: %w", err)
+	}
+	return b, nil
+}
+
+// cachedExport finds the export cached under key: one still running, or a
+// finished one whose asset is still there. opts.Force skips the lookup.
+// Cached exports don't count against quotas.
+func (es *ExportService) cachedExport(ctx context.Context, orgID, key string, opts ExportOptions) (ExportResult, bool, error) {
+	if opts.Force {
+		return ExportResult{}, false, nil
+	}
+	job, ok, err := es.Store.Jobs().GetByDeduplicationID(ctx, orgID, key)
+	if err != nil {
+		return ExportResult{}, false, fmt.Errorf("look up cached export: %w", err)
+	}
+	if !ok {
+		return ExportResult{}, false, nil
+	}
+	switch job.Status {
+	case store.JobQueued, store.JobRunning, store.JobRetry:
+		logger.Jobs().Info("export_job_duplicate", "job_id", job.ID, "status", job.Status)
+		return ExportResult{Job: job, Duplicate: true}, true, nil
+	case store.JobDone:
+		if job.OutputRef == "" {
+			return ExportResult{}, false, nil
+		}
+		asset, ok, err := es.Store.Assets().Get(ctx, orgID, job.OutputRef)
+		if err != nil {
+			return ExportResult{}, false, fmt.Errorf("get cached export asset: %w", err)
+		}
+		if !ok {
+			return ExportResult{}, false, nil
+		}
+		logger.Jobs().Info("export_cache_hit", "job_id", job.ID, "asset_id", asset.ID)
+		return ExportResult{Job: job, Asset: &asset, Duplicate: true}, true, nil
+	}
+	return ExportResult{}, false, nil
+}
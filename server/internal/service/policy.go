@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ziyad/cms-ai/server/internal/policy"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// orgPolicy is the org's compliance policy, or nil when it has none. An org
+// that can't be read fails the request rather than skipping its rules.
+func orgPolicy(ctx context.Context, st store.Store, orgID string) (*store.OrgPolicy, error) {
+	org, err := st.Organizations().GetOrganization(ctx, orgID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load org policy: %w", err)
+	}
+	return org.Policy, nil
+}
+
+// checkSpecPolicy checks a deck spec the AI wrote against the org's content
+// rules, failing with a *policy.ViolationError.
+func checkSpecPolicy(ctx context.Context, st store.Store, orgID string, specJSON []byte) error {
+	p, err := orgPolicy(ctx, st, orgID)
+	if err != nil {
+		return err
+	}
+	if p == nil || len(p.ContentRules) == 0 {
+		return nil
+	}
+	var s spec.TemplateSpec
+	if err := json.Unmarshal(specJSON, &s); err != nil {
+		return fmt.Errorf("read deck spec: %w", err)
+	}
+	return policy.CheckSpec(p, s)
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/webhooks"
+)
+
+// Quota names, as reported in QuotaError and quota.exceeded events.
+const (
+	QuotaGenerate = "generate"
+	QuotaExport   = "export"
+	QuotaStorage  = "storage"
+)
+
+// Limits are an org's allowances. A zero StorageBytes means unlimited.
+type Limits struct {
+	GeneratePerMonth int
+	ExportPerMonth   int
+	StorageBytes     int64
+}
+
+// QuotaError means the org has used up a quota. Used and Limit are counts
+// for generate and export, and bytes for storage.
+type QuotaError struct {
+	Quota string
+	Used  int64
+	Limit int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s quota exceeded", e.Quota)
+}
+
+// Quotas checks metered usage against Limits. A failed check also emits a
+// quota.exceeded event for the org.
+type Quotas struct {
+	Store  store.Store
+	Limits Limits
+}
+
+// CheckGenerate fails once the org has used its monthly generations.
+func (q Quotas) CheckGenerate(ctx context.Context, id auth.Identity) error {
+	used, _ := q.Store.Metering().SumByType(ctx, id.OrgID, QuotaGenerate)
+	if used >= q.Limits.GeneratePerMonth {
+		return q.exceeded(ctx, id, QuotaGenerate, int64(used), int64(q.Limits.GeneratePerMonth))
+	}
+	return nil
+}
+
+// CheckExport fails unless n more exports fit in the org's monthly allowance.
+// Exports are metered when they finish, so the ones still queued or running
+// count as used too.
+func (q Quotas) CheckExport(ctx context.Context, id auth.Identity, n int) error {
+	used, _ := q.Store.Metering().SumByType(ctx, id.OrgID, QuotaExport)
+	used += q.pendingExports(ctx, id.OrgID)
+	if used+n > q.Limits.ExportPerMonth {
+		return q.exceeded(ctx, id, QuotaExport, int64(used), int64(q.Limits.ExportPerMonth))
+	}
+	return nil
+}
+
+// pendingExports counts the exports in the org's unfinished jobs: one per
+// export job, and one per deck or row of bulk exports and batch merges.
+func (q Quotas) pendingExports(ctx context.Context, orgID string) int {
+	n := 0
+	for _, status := range []store.JobStatus{store.JobQueued, store.JobRunning, store.JobRetry} {
+		jobs, _ := q.Store.Jobs().List(ctx, store.JobFilter{OrgID: orgID, Status: status})
+		for _, j := range jobs {
+			switch j.Type {
+			case store.JobExport:
+				n++
+			case store.JobBulkExport, store.JobBatchMerge:
+				if j.Metadata == nil {
+					continue
+				}
+				var entries []json.RawMessage
+				key := "items"
+				if j.Type == store.JobBatchMerge {
+					key = "rows"
+				}
+				_ = json.Unmarshal([]byte((*j.Metadata)[key]), &entries)
+				n += len(entries)
+			}
+		}
+	}
+	return n
+}
+
+// StorageUsage reports the bytes the org's assets take up and whether that
+// blocks new exports.
+func (q Quotas) StorageUsage(ctx context.Context, orgID string) (used int64, blocked bool) {
+	used, _ = q.Store.Assets().TotalBytes(ctx, orgID)
+	return used, q.Limits.StorageBytes > 0 && used >= q.Limits.StorageBytes
+}
+
+// CheckStorage fails once the org has used up its storage, so no new export
+// assets are produced.
+func (q Quotas) CheckStorage(ctx context.Context, id auth.Identity) error {
+	if used, blocked := q.StorageUsage(ctx, id.OrgID); blocked {
+		return q.exceeded(ctx, id, QuotaStorage, used, q.Limits.StorageBytes)
+	}
+	return nil
+}
+
+func (q Quotas) exceeded(ctx context.Context, id auth.Identity, quota string, used, limit int64) error {
+	webhooks.Emit(ctx, q.Store, id.UserID, webhooks.Event{
+		Type:  webhooks.EventQuotaExceeded,
+		OrgID: id.OrgID,
+		Data:  map[string]any{"quota": quota, "used": used, "limit": limit},
+	})
+	return &QuotaError{Quota: quota, Used: used, Limit: limit}
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/policy"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// maxRefinementHistory bounds how many earlier instructions go to the AI
+// with a new one; older turns are reflected in the deck's spec anyway.
+const maxRefinementHistory = 10
+
+// maxRememberedDecks bounds how many decks a RefinementHistory remembers;
+// the least recently refined are forgotten first.
+const maxRememberedDecks = 10000
+
+// RefinementHistory remembers the refinement instructions of orgs that don't
+// store prompts, so their earlier turns still go to the AI as context. It is
+// kept in memory only: after a restart, or on another instance, those decks
+// are refined without them. A nil RefinementHistory remembers nothing.
+type RefinementHistory struct {
+	mu    sync.Mutex
+	decks map[string]*list.Element
+	lru   *list.List // of *rememberedDeck, most recently refined first
+}
+
+type rememberedDeck struct {
+	key   string
+	turns []rememberedTurn // oldest first, at most maxRefinementHistory
+}
+
+type rememberedTurn struct {
+	refinementID string
+	instruction  string
+}
+
+// NewRefinementHistory returns an empty history.
+func NewRefinementHistory() *RefinementHistory {
+	return &RefinementHistory{decks: map[string]*list.Element{}, lru: list.New()}
+}
+
+// instructions returns the deck's remembered instructions by refinement ID.
+func (h *RefinementHistory) instructions(orgID, deckID string) map[string]string {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	el, ok := h.decks[orgID+"/"+deckID]
+	if !ok {
+		return nil
+	}
+	out := make(map[string]string, len(el.Value.(*rememberedDeck).turns))
+	for _, t := range el.Value.(*rememberedDeck).turns {
+		out[t.refinementID] = t.instruction
+	}
+	return out
+}
+
+func (h *RefinementHistory) remember(orgID, deckID, refinementID, instruction string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := orgID + "/" + deckID
+	el, ok := h.decks[key]
+	if ok {
+		h.lru.MoveToFront(el)
+	} else {
+		el = h.lru.PushFront(&rememberedDeck{key: key})
+		h.decks[key] = el
+		if h.lru.Len() > maxRememberedDecks {
+			oldest := h.lru.Remove(h.lru.Back()).(*rememberedDeck)
+			delete(h.decks, oldest.key)
+		}
+	}
+	deck := el.Value.(*rememberedDeck)
+	deck.turns = append(deck.turns, rememberedTurn{refinementID, instruction})
+	deck.turns = deck.turns[max(len(deck.turns)-maxRefinementHistory, 0):]
+}
+
+// RefineInput is an instruction for revising a deck, like "make slide 3 more
+// concise".
+type RefineInput struct {
+	DeckID      string
+	Instruction string
+	// Params override the org's generation defaults.
+	Params store.GenerationParams
+}
+
+// RefineResult is the deck with the version the instruction produced and the
+// conversation turn recording it.
+type RefineResult struct {
+	Deck       store.Deck
+	Version    store.DeckVersion
+	Refinement store.DeckRefinement
+}
+
+// Refine has the AI apply an instruction to the deck's current version and
+// saves the result as the deck's next version. The deck's earlier
+// instructions go along as context; the instruction itself is only stored
+// if the org stores prompts. Refining counts against the generate
+// quota; editors and above only.
+func (ds *DeckService) Refine(ctx context.Context, id auth.Identity, in RefineInput) (RefineResult, error) {
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		return RefineResult{}, ErrForbidden
+	}
+	d, err := authorizeDeck(ctx, ds.Store, id, in.DeckID, store.PermissionEdit)
+	if err != nil {
+		return RefineResult{}, err
+	}
+	if d.CurrentVersion == nil {
+		return RefineResult{}, invalidf("deck has no version to refine yet")
+	}
+	refiner, ok := ds.AI.(ai.DeckRefiner)
+	if !ok {
+		return RefineResult{}, fmt.Errorf("%w: the AI service cannot refine decks", ErrGenerationFailed)
+	}
+	dv, ok, err := ds.Store.Decks().GetDeckVersion(ctx, id.OrgID, *d.CurrentVersion)
+	if err != nil {
+		return RefineResult{}, fmt.Errorf("load deck version: %w", err)
+	}
+	if !ok {
+		return RefineResult{}, fmt.Errorf("deck version %w", ErrNotFound)
+	}
+	var current spec.TemplateSpec
+	specBytes, err := normalize.JSON(dv.SpecJSON)
+	if err != nil || json.Unmarshal(specBytes, &current) != nil {
+		return RefineResult{}, &InvalidError{Msg: "invalid stored deck spec", Spec: true}
+	}
+	pol, err := orgPolicy(ctx, ds.Store, id.OrgID)
+	if err != nil {
+		return RefineResult{}, err
+	}
+	if err := policy.CheckText(pol, in.Instruction); err != nil {
+		return RefineResult{}, err
+	}
+	if err := ds.Quotas.CheckGenerate(ctx, id); err != nil {
+		return RefineResult{}, err
+	}
+
+	turns, err := ds.Store.Decks().ListRefinements(ctx, id.OrgID, d.ID)
+	if err != nil {
+		return RefineResult{}, fmt.Errorf("load refinements: %w", err)
+	}
+	turns = turns[max(len(turns)-maxRefinementHistory, 0):]
+	remembered := ds.History.instructions(id.OrgID, d.ID)
+	history := make([]string, 0, len(turns))
+	for _, t := range turns {
+		if t.Instruction == "" {
+			t.Instruction = remembered[t.ID]
+		}
+		if t.Instruction != "" {
+			history = append(history, t.Instruction)
+		}
+	}
+
+	params := resolveParams(ctx, ds.Store, id.OrgID, in.Params)
+	genCtx, cancel := context.WithTimeout(ctx, syncGenerateTimeout)
+	defer cancel()
+	refined, aiResp, err := refiner.RefineDeckSpec(genCtx, id.OrgID, id.UserID, &current, in.Instruction, history, params)
+	if err != nil {
+		if genCtx.Err() != nil {
+			return RefineResult{}, fmt.Errorf("%w: %w", ErrGenerationFailed, genCtx.Err())
+		}
+		return RefineResult{}, fmt.Errorf("%w: %w", ErrGenerationFailed, err)
+	}
+	if err := policy.CheckSpec(pol, *refined); err != nil {
+		return RefineResult{}, err
+	}
+	refinedJSON, err := json.Marshal(refined)
+	if err != nil {
+		return RefineResult{}, fmt.Errorf("encode deck spec: %w", err)
+	}
+
+	ver := store.DeckVersion{ID: newID("dv"), Deck: d.ID, OrgID: id.OrgID, VersionNo: d.LatestVersionNo + 1, SpecJSON: json.RawMessage(refinedJSON), CreatedBy: id.UserID}
+	if !params.IsZero() {
+		ver.GenerationParams = &params
+	}
+	// Orgs that haven't opted in to storing prompts get the turn recorded
+	// without its instruction, which only ds.History remembers.
+	keep := storesPrompts(ctx, ds.Store, id.OrgID)
+	stored := in.Instruction
+	if !keep {
+		stored = ""
+	}
+	var res RefineResult
+	err = ds.Store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		if res.Version, err = tx.Decks().CreateDeckVersion(ctx, ver); err != nil {
+			return err
+		}
+		d.LatestVersionNo = ver.VersionNo
+		d.CurrentVersion = &res.Version.ID
+		if res.Deck, err = tx.Decks().UpdateDeck(ctx, d); err != nil {
+			return err
+		}
+		res.Refinement, err = tx.Decks().AppendRefinement(ctx, store.DeckRefinement{
+			ID:            newID("ref"),
+			OrgID:         id.OrgID,
+			DeckID:        d.ID,
+			UserID:        id.UserID,
+			Instruction:   stored,
+			FromVersionID: dv.ID,
+			VersionID:     res.Version.ID,
+			Model:         aiResp.Model,
+		})
+		return err
+	})
+	if err != nil {
+		return RefineResult{}, fmt.Errorf("save refined deck: %w", err)
+	}
+	if !keep {
+		ds.History.remember(id.OrgID, d.ID, res.Refinement.ID, in.Instruction)
+	}
+
+	_, _ = ds.Store.Audit().Append(ctx, store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.refine", TargetRef: d.ID, Metadata: withPrompt(ctx, ds.Store, id.OrgID, map[string]any{"fromVersionId": dv.ID, "versionId": res.Version.ID}, "instruction", in.Instruction)})
+	return res, nil
+}
+
+// RegenerateSlideInput picks one slide of a deck version to rewrite, by its
+// 0-based index, with optional guidance like "focus on pricing".
+type RegenerateSlideInput struct {
+	VersionID string
+	Index     int
+	Guidance  string
+	// Params override the org's generation defaults.
+	Params store.GenerationParams
+}
+
+// RegenerateSlide has the AI rewrite one slide of a deck version and saves
+// the version with that slide replaced as the deck's next version. Only the
+// slide goes to the AI, and the rest of the spec is kept byte for byte.
+// It returns the updated deck and the new version. Regenerating counts
+// against the generate quota; editors and above only.
+func (ds *DeckService) RegenerateSlide(ctx context.Context, id auth.Identity, in RegenerateSlideInput) (store.Deck, store.DeckVersion, error) {
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		return store.Deck{}, store.DeckVersion{}, ErrForbidden
+	}
+	dv, ok, err := ds.Store.Decks().GetDeckVersion(ctx, id.OrgID, in.VersionID)
+	if err != nil {
+		return store.Deck{}, store.DeckVersion{}, fmt.Errorf("load deck version: %w", err)
+	}
+	if !ok {
+		return store.Deck{}, store.DeckVersion{}, fmt.Errorf("deck version %w", ErrNotFound)
+	}
+	d, err := authorizeDeck(ctx, ds.Store, id, dv.Deck, store.PermissionEdit)
+	if err != nil {
+		return store.Deck{}, store.DeckVersion{}, err
+	}
+	regenerator, ok := ds.AI.(ai.SlideRegenerator)
+	if !ok {
+		return store.Deck{}, store.DeckVersion{}, fmt.Errorf("%w: the AI service cannot regenerate slides", ErrGenerationFailed)
+	}
+
+	// The AI reads the modeled spec; the new version is written from the raw
+	// document so fields the spec package doesn't model survive.
+	var doc map[string]json.RawMessage
+	var layouts []json.RawMessage
+	var current spec.TemplateSpec
+	specBytes, err := normalize.JSON(dv.SpecJSON)
+	if err == nil {
+		err = json.Unmarshal(specBytes, &doc)
+	}
+	if err == nil && len(doc["layouts"]) > 0 {
+		err = json.Unmarshal(doc["layouts"], &layouts)
+	}
+	if err == nil {
+		err = json.Unmarshal(specBytes, &current)
+	}
+	if err != nil || len(layouts) != len(current.Layouts) {
+		return store.Deck{}, store.DeckVersion{}, &InvalidError{Msg: "invalid stored deck spec", Spec: true}
+	}
+	if in.Index < 0 || in.Index >= len(layouts) {
+		return store.Deck{}, store.DeckVersion{}, invalidf("slide %d out of range for %d slides", in.Index, len(layouts))
+	}
+	pol, err := orgPolicy(ctx, ds.Store, id.OrgID)
+	if err != nil {
+		return store.Deck{}, store.DeckVersion{}, err
+	}
+	if err := policy.CheckText(pol, in.Guidance); err != nil {
+		return store.Deck{}, store.DeckVersion{}, err
+	}
+	if err := ds.Quotas.CheckGenerate(ctx, id); err != nil {
+		return store.Deck{}, store.DeckVersion{}, err
+	}
+
+	params := resolveParams(ctx, ds.Store, id.OrgID, in.Params)
+	genCtx, cancel := context.WithTimeout(ctx, syncGenerateTimeout)
+	defer cancel()
+	slide, _, err := regenerator.RegenerateSlide(genCtx, id.OrgID, id.UserID, &current, in.Index, in.Guidance, params)
+	if err != nil {
+		if genCtx.Err() != nil {
+			return store.Deck{}, store.DeckVersion{}, fmt.Errorf("%w: %w", ErrGenerationFailed, genCtx.Err())
+		}
+		return store.Deck{}, store.DeckVersion{}, fmt.Errorf("%w: %w", ErrGenerationFailed, err)
+	}
+	if err := policy.CheckSlide(pol, *slide, in.Index+1); err != nil {
+		return store.Deck{}, store.DeckVersion{}, err
+	}
+	if layouts[in.Index], err = json.Marshal(slide); err == nil {
+		if doc["layouts"], err = json.Marshal(layouts); err == nil {
+			specBytes, err = json.Marshal(doc)
+		}
+	}
+	if err != nil {
+		return store.Deck{}, store.DeckVersion{}, fmt.Errorf("encode deck spec: %w", err)
+	}
+
+	ver := store.DeckVersion{ID: newID("dv"), Deck: d.ID, OrgID: id.OrgID, VersionNo: d.LatestVersionNo + 1, SpecJSON: json.RawMessage(specBytes), CreatedBy: id.UserID}
+	if !params.IsZero() {
+		ver.GenerationParams = &params
+	}
+	var created store.DeckVersion
+	var updated store.Deck
+	err = ds.Store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		if created, err = tx.Decks().CreateDeckVersion(ctx, ver); err != nil {
+			return err
+		}
+		d.LatestVersionNo = ver.VersionNo
+		d.CurrentVersion = &created.ID
+		updated, err = tx.Decks().UpdateDeck(ctx, d)
+		return err
+	})
+	if err != nil {
+		return store.Deck{}, store.DeckVersion{}, fmt.Errorf("save regenerated slide: %w", err)
+	}
+
+	_, _ = ds.Store.Audit().Append(ctx, store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.slide.regenerate", TargetRef: d.ID, Metadata: withPrompt(ctx, ds.Store, id.OrgID, map[string]any{"fromVersionId": dv.ID, "versionId": created.ID, "slideIndex": in.Index}, "guidance", in.Guidance)})
+	return updated, created, nil
+}
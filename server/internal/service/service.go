@@ -0,0 +1,86 @@
+// Package service holds the business rules behind template generation, deck
+// creation and export: authorization, quotas, persistence, jobs, audit and
+// events. The HTTP handlers are thin adapters over it, and the worker, gRPC
+// server or CLI can call it the same way. Callers pass the acting identity
+// explicitly; nothing here depends on net/http.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+var (
+	// ErrNotFound is returned, possibly wrapped with the kind of resource,
+	// when something doesn't exist or isn't visible to the caller.
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden means the caller's role or resource permission is too low.
+	ErrForbidden = errors.New("forbidden")
+	// ErrGenerationFailed wraps AI failures during synchronous generation; it
+	// also wraps context.DeadlineExceeded when generation timed out.
+	ErrGenerationFailed = errors.New("template generation failed")
+)
+
+// InvalidError is a request the caller has to correct; its message is safe
+// to show them.
+type InvalidError struct {
+	Msg string
+	// Spec is set when the problem is a template or deck spec rather than
+	// the request itself.
+	Spec bool
+	// Fields lists the request fields at fault, when the problem is theirs.
+	Fields []FieldError
+}
+
+// FieldError is a request field that broke Rule, e.g. "required", with
+// Param qualifying the rule, e.g. the expected type.
+type FieldError struct {
+	Field string
+	Rule  string
+	Param string
+}
+
+func (e *InvalidError) Error() string { return e.Msg }
+
+func invalidf(format string, args ...any) error {
+	return &InvalidError{Msg: fmt.Sprintf(format, args...)}
+}
+
+func newID(prefix string) string {
+	return uuid.New().String()
+}
+
+// authorizeTemplate loads a template the caller holds at least min on.
+func authorizeTemplate(ctx context.Context, st store.Store, id auth.Identity, templateID string, min store.Permission) (store.Template, error) {
+	tpl, perm, ok, err := st.Templates().GetTemplateFor(ctx, id, templateID)
+	if err := checkAccess(perm, ok, err, min); err != nil {
+		return store.Template{}, err
+	}
+	return tpl, nil
+}
+
+// authorizeDeck is authorizeTemplate for decks.
+func authorizeDeck(ctx context.Context, st store.Store, id auth.Identity, deckID string, min store.Permission) (store.Deck, error) {
+	d, perm, ok, err := st.Decks().GetDeckFor(ctx, id, deckID)
+	if err := checkAccess(perm, ok, err, min); err != nil {
+		return store.Deck{}, err
+	}
+	return d, nil
+}
+
+func checkAccess(perm store.Permission, found bool, err error, min store.Permission) error {
+	switch {
+	case err != nil:
+		return fmt.Errorf("load resource: %w", err)
+	case !found:
+		return ErrNotFound
+	case !perm.Allows(min):
+		return ErrForbidden
+	}
+	return nil
+}
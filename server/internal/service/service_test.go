@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+func seedTemplateVersion(t *testing.T, st store.Store) store.TemplateVersion {
+	t.Helper()
+	ctx := context.Background()
+	tpl, err := st.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-1", OrgID: "org-1", OwnerUserID: "user-1", Name: "Base", Status: store.TemplateDraft})
+	require.NoError(t, err)
+	spec := `{"layouts":[{"name":"Base","placeholders":[{"id":"title","type":"text"},{"id":"body","type":"text"}]}]}`
+	tv, err := st.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-1", Template: tpl.ID, OrgID: "org-1", VersionNo: 1, SpecJSON: json.RawMessage(spec), CreatedBy: "user-1"})
+	require.NoError(t, err)
+	return tv
+}
+
+func TestDeckService_Create(t *testing.T) {
+	st := memory.New()
+	tv := seedTemplateVersion(t, st)
+	ds := &DeckService{Store: st}
+	ctx := context.Background()
+	editor := auth.Identity{UserID: "user-1", OrgID: "org-1", Role: auth.RoleEditor}
+	in := CreateDeckInput{Name: "Q3 review", SourceTemplateVersionID: tv.ID, Content: "Revenue grew 20% quarter over quarter."}
+
+	_, err := ds.Create(ctx, auth.Identity{UserID: "user-2", OrgID: "org-1", Role: auth.RoleViewer}, in)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	missing := in
+	missing.SourceTemplateVersionID = "tv-missing"
+	_, err = ds.Create(ctx, editor, missing)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.EqualError(t, err, "template version not found")
+
+	queued, err := ds.Create(ctx, editor, in)
+	require.NoError(t, err)
+	require.NotNil(t, queued.Job)
+	assert.Nil(t, queued.Version)
+	assert.Equal(t, store.JobBind, queued.Job.Type)
+	assert.Equal(t, queued.Deck.ID, queued.Job.InputRef)
+
+	withOutline := in
+	withOutline.Outline = DeckOutline{Slides: []SlideOutline{{SlideNumber: 1, Title: "Results", Content: []string{"+20%"}}}}
+	built, err := ds.Create(ctx, editor, withOutline)
+	require.NoError(t, err)
+	require.NotNil(t, built.Version)
+	assert.Nil(t, built.Job)
+	assert.Equal(t, built.Version.ID, *built.Deck.CurrentVersion)
+
+	withOutline.Outline = map[string]any{"slides": []any{}}
+	_, err = ds.Create(ctx, editor, withOutline)
+	var invalid *InvalidError
+	assert.True(t, errors.As(err, &invalid))
+}
+
+// historyRecorder is the mock AI service, noting the history each
+// refinement is sent with.
+type historyRecorder struct {
+	*ai.AIService
+	history []string
+}
+
+func (h *historyRecorder) RefineDeckSpec(ctx context.Context, orgID, userID string, current *spec.TemplateSpec, instruction string, history []string, params store.GenerationParams) (*spec.TemplateSpec, *ai.GenerationResponse, error) {
+	h.history = history
+	return h.AIService.RefineDeckSpec(ctx, orgID, userID, current, instruction, history, params)
+}
+
+func TestDeckService_RefineStoresInstructionsOnlyForOrgsThatStorePrompts(t *testing.T) {
+	st := memory.New()
+	tv := seedTemplateVersion(t, st)
+	ctx := context.Background()
+	require.NoError(t, st.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Acme"}))
+	recorder := &historyRecorder{AIService: ai.NewAIService(st)}
+	ds := &DeckService{Store: st, AI: recorder, Quotas: Quotas{Store: st, Limits: Limits{GeneratePerMonth: 10}}, History: NewRefinementHistory()}
+	editor := auth.Identity{UserID: "user-1", OrgID: "org-1", Role: auth.RoleEditor}
+	built, err := ds.Create(ctx, editor, CreateDeckInput{Name: "Q3 review", SourceTemplateVersionID: tv.ID, Outline: DeckOutline{Slides: []SlideOutline{{SlideNumber: 1, Title: "Results", Content: []string{"+20%"}}}}})
+	require.NoError(t, err)
+	refine := func(instruction string) RefineResult {
+		t.Helper()
+		res, err := ds.Refine(ctx, editor, RefineInput{DeckID: built.Deck.ID, Instruction: instruction})
+		require.NoError(t, err)
+		return res
+	}
+
+	assert.Empty(t, refine("add a pricing slide").Refinement.Instruction)
+	refine("make it shorter")
+	assert.Equal(t, []string{"add a pricing slide"}, recorder.history, "remembered in memory")
+	turns, err := st.Decks().ListRefinements(ctx, "org-1", built.Deck.ID)
+	require.NoError(t, err)
+	require.Len(t, turns, 2)
+	for _, turn := range turns {
+		assert.Empty(t, turn.Instruction)
+	}
+
+	ds.History = NewRefinementHistory()
+	refine("use a darker theme")
+	assert.Empty(t, recorder.history, "a restart forgets unstored instructions")
+
+	_, err = st.Organizations().SetSettings(ctx, "org-1", &store.OrgSettings{StorePrompts: true})
+	require.NoError(t, err)
+	assert.Equal(t, "add a chart", refine("add a chart").Refinement.Instruction)
+	ds.History = nil
+	refine("label the axes")
+	assert.Equal(t, []string{"add a chart"}, recorder.history)
+}
+
+func TestTemplateService_GenerateUsesOrgSettings(t *testing.T) {
+	st := memory.New()
+	ctx := context.Background()
+	require.NoError(t, st.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Acme"}))
+	_, err := st.Organizations().SetSettings(ctx, "org-1", &store.OrgSettings{Language: "fr", Tone: "formal", SlideSize: "4:3"})
+	require.NoError(t, err)
+
+	ts := &TemplateService{Store: st, Quotas: Quotas{Store: st, Limits: Limits{GeneratePerMonth: 5}}}
+	id := auth.Identity{UserID: "user-1", OrgID: "org-1", Role: auth.RoleEditor}
+
+	res, err := ts.Generate(ctx, id, GenerateInput{Prompt: "Quarterly review", Tone: "playful"})
+	require.NoError(t, err)
+	require.NotNil(t, res.Job)
+	m := *res.Job.Metadata
+	assert.Equal(t, "4:3", m["slideSize"])
+	assert.Equal(t, "fr", m["language"])
+	assert.Equal(t, "playful", m["tone"])
+
+	res, err = ts.Generate(ctx, id, GenerateInput{Prompt: "Launch plan", SlideSize: "16:9"})
+	require.NoError(t, err)
+	assert.Equal(t, "16:9", (*res.Job.Metadata)["slideSize"])
+}
+
+func TestExportService_Quotas(t *testing.T) {
+	st := memory.New()
+	ctx := context.Background()
+	tv := seedTemplateVersion(t, st)
+	deck, err := st.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", OwnerUserID: "user-1", Name: "Deck", SourceTemplateVersion: tv.ID})
+	require.NoError(t, err)
+	dv, err := st.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: deck.ID, OrgID: "org-1", VersionNo: 1})
+	require.NoError(t, err)
+
+	es := &ExportService{Store: st, Quotas: Quotas{Store: st, Limits: Limits{GeneratePerMonth: 1, ExportPerMonth: 1}}}
+	id := auth.Identity{UserID: "user-1", OrgID: "org-1", Role: auth.RoleEditor}
+
+	_, err = es.ExportDeckVersion(ctx, id, dv.ID, ExportOptions{Format: "docx"})
+	var invalid *InvalidError
+	assert.True(t, errors.As(err, &invalid))
+
+	res, err := es.ExportDeckVersion(ctx, id, dv.ID, ExportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, store.JobExport, res.Job.Type)
+	assert.Equal(t, dv.ID, res.Job.InputRef)
+
+	_, err = es.ExportDeckVersion(ctx, id, dv.ID, ExportOptions{Format: store.ExportFormatBundle})
+	var quota *QuotaError
+	require.True(t, errors.As(err, &quota))
+	assert.Equal(t, QuotaExport, quota.Quota)
+	assert.Equal(t, int64(1), quota.Used)
+}
+
+// namedRenderer writes its name as the rendered file.
+type namedRenderer string
+
+func (n namedRenderer) RenderPPTX(ctx context.Context, spec any, outPath string) error {
+	return os.WriteFile(outPath, []byte(n), 0o644)
+}
+
+func (n namedRenderer) RenderPPTXBytes(ctx context.Context, spec any) ([]byte, error) {
+	return []byte(n), nil
+}
+
+func (n namedRenderer) GenerateSlideThumbnails(ctx context.Context, spec any) ([][]byte, error) {
+	return nil, nil
+}
+
+func TestExportService_Quality(t *testing.T) {
+	st := memory.New()
+	ctx := context.Background()
+	tv := seedTemplateVersion(t, st)
+	deck, err := st.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", OwnerUserID: "user-1", Name: "Deck", SourceTemplateVersion: tv.ID})
+	require.NoError(t, err)
+	dv, err := st.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: deck.ID, OrgID: "org-1", VersionNo: 1})
+	require.NoError(t, err)
+	objects, err := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	require.NoError(t, err)
+
+	es := &ExportService{
+		Store:     st,
+		Quotas:    Quotas{Store: st, Limits: Limits{ExportPerMonth: 100}},
+		Renderers: assets.QualityRenderers{Draft: namedRenderer("draft"), Standard: namedRenderer("standard")},
+		Objects:   objects,
+	}
+	id := auth.Identity{UserID: "user-1", OrgID: "org-1", Role: auth.RoleEditor}
+
+	_, err = es.ExportDeckVersion(ctx, id, dv.ID, ExportOptions{Quality: "ultra"})
+	var invalid *InvalidError
+	assert.True(t, errors.As(err, &invalid))
+
+	res, err := es.ExportDeckVersion(ctx, id, dv.ID, ExportOptions{Quality: store.ExportQualityDraft})
+	require.NoError(t, err)
+	assert.Equal(t, store.ExportQualityDraft, (*res.Job.Metadata)["quality"])
+	res, err = es.ExportDeckVersion(ctx, id, dv.ID, ExportOptions{Quality: store.ExportQualityStandard})
+	require.NoError(t, err)
+	assert.False(t, res.Duplicate, "a draft export doesn't stand in for a standard one")
+	assert.NotContains(t, *res.Job.Metadata, "quality", "standard is the default and isn't recorded")
+
+	render := func(quality string) (ExportResult, string) {
+		res, err := es.ExportTemplateVersion(ctx, id, tv.ID, ExportOptions{Quality: quality})
+		require.NoError(t, err)
+		require.NotNil(t, res.Asset)
+		data, err := objects.Download(ctx, res.Asset.Path)
+		require.NoError(t, err)
+		return res, string(data)
+	}
+	draft, body := render(store.ExportQualityDraft)
+	assert.Equal(t, "draft", body)
+	std, body := render("")
+	assert.Equal(t, "standard", body)
+	assert.False(t, std.Duplicate, "a draft export doesn't stand in for a standard one")
+	again, _ := render(store.ExportQualityDraft)
+	assert.True(t, again.Duplicate)
+	assert.Equal(t, draft.Job.ID, again.Job.ID)
+	_, body = render(store.ExportQualityHigh)
+	assert.Equal(t, "standard", body, "profiles without a renderer use the standard one")
+}
+
+func TestExportService_CachesByContent(t *testing.T) {
+	st := memory.New()
+	ctx := context.Background()
+	tv := seedTemplateVersion(t, st)
+	deck, err := st.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", OwnerUserID: "user-1", Name: "Deck", SourceTemplateVersion: tv.ID})
+	require.NoError(t, err)
+	for i, spec := range []string{
+		`{"layouts":[{"name":"A","placeholders":[{"id":"title","type":"text","content":"Hi"}]}]}`,
+		`{"layouts": [{"placeholders": [{"content": "Hi", "id": "title", "type": "text"}], "name": "A"}]}`,
+		`{"layouts":[{"name":"A","placeholders":[{"id":"title","type":"text","content":"Bye"}]}]}`,
+	} {
+		_, err := st.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: fmt.Sprintf("dv-%d", i+1), Deck: deck.ID, OrgID: "org-1", VersionNo: i + 1, SpecJSON: json.RawMessage(spec)})
+		require.NoError(t, err)
+	}
+
+	es := &ExportService{Store: st, Quotas: Quotas{Store: st, Limits: Limits{ExportPerMonth: 5}}}
+	id := auth.Identity{UserID: "user-1", OrgID: "org-1", Role: auth.RoleEditor}
+	export := func(versionID string, opts ExportOptions) ExportResult {
+		t.Helper()
+		res, err := es.ExportDeckVersion(ctx, id, versionID, opts)
+		require.NoError(t, err)
+		return res
+	}
+
+	first := export("dv-1", ExportOptions{})
+	assert.False(t, first.Duplicate)
+	running := export("dv-1", ExportOptions{})
+	assert.True(t, running.Duplicate)
+	assert.Equal(t, first.Job.ID, running.Job.ID)
+	assert.Nil(t, running.Asset, "the first export hasn't finished")
+
+	asset, err := st.Assets().Create(ctx, store.Asset{ID: "asset-1", OrgID: "org-1", Type: store.AssetPPTX, Path: "a.pptx", SourceJobID: first.Job.ID})
+	require.NoError(t, err)
+	first.Job.Status, first.Job.OutputRef = store.JobDone, asset.ID
+	_, err = st.Jobs().Update(ctx, first.Job)
+	require.NoError(t, err)
+	// The worker meters an export as it finishes it.
+	_, err = st.Metering().Record(ctx, store.MeteringEvent{OrgID: "org-1", UserID: "user-1", Type: QuotaExport, Quantity: 1, TargetRef: deck.ID})
+	require.NoError(t, err)
+
+	cached := export("dv-2", ExportOptions{})
+	assert.True(t, cached.Duplicate, "key order and whitespace don't change the cache key")
+	assert.Equal(t, first.Job.ID, cached.Job.ID)
+	require.NotNil(t, cached.Asset)
+	assert.Equal(t, asset.ID, cached.Asset.ID)
+
+	assert.False(t, export("dv-3", ExportOptions{}).Duplicate)
+	assert.False(t, export("dv-1", ExportOptions{AdjustContrast: true}).Duplicate)
+	assert.False(t, export("dv-1", ExportOptions{Deterministic: true}).Duplicate)
+	forced := export("dv-1", ExportOptions{Force: true})
+	assert.False(t, forced.Duplicate)
+	again := export("dv-1", ExportOptions{})
+	assert.Equal(t, forced.Job.ID, again.Job.ID, "the forced export replaces the cached one")
+
+	// Quota is spent; cached exports are still served.
+	_, err = es.ExportDeckVersion(ctx, id, "dv-1", ExportOptions{Quality: store.ExportQualityHigh})
+	var quota *QuotaError
+	require.True(t, errors.As(err, &quota))
+	assert.Equal(t, forced.Job.ID, export("dv-2", ExportOptions{}).Job.ID)
+
+	_, err = es.ExportDeckVersion(ctx, id, "dv-1", ExportOptions{Deterministic: true, Renderer: assets.RendererPython})
+	var invalid *InvalidError
+	assert.True(t, errors.As(err, &invalid))
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// Kinds of TemplateIncompatibility.
+const (
+	// IncompatibleLayout is a slide whose layout the new version lacks; it
+	// takes the new version's first layout.
+	IncompatibleLayout = "layout_missing"
+	// IncompatiblePlaceholder is content whose placeholder the new layout
+	// lacks, with no free placeholder of its type to take it. It is dropped.
+	IncompatiblePlaceholder = "placeholder_removed"
+	// IncompatibleType is content whose placeholder has changed type, with
+	// no free placeholder of its old type to take it. It is dropped.
+	IncompatibleType = "type_changed"
+)
+
+// UpgradeTemplateInput names a deck to move onto another version of its
+// source template, the template's current version when TemplateVersionID
+// is empty. DryRun only reports what the upgrade would do.
+type UpgradeTemplateInput struct {
+	DeckID            string
+	TemplateVersionID string
+	DryRun            bool
+}
+
+// SlideMapping is where one slide of the deck went in the new template
+// version: the layout it took and, by old ID, the placeholders its content
+// moved to.
+type SlideMapping struct {
+	// Slide is 1-based.
+	Slide        int               `json:"slide"`
+	Layout       string            `json:"layout"`
+	Placeholders map[string]string `json:"placeholders"`
+}
+
+// TemplateIncompatibility is a slide, or content on it, the new template
+// version has no place for.
+type TemplateIncompatibility struct {
+	Kind  string `json:"kind"`
+	Slide int    `json:"slide"`
+	// PlaceholderID is the deck placeholder whose content is dropped; empty
+	// for IncompatibleLayout.
+	PlaceholderID string `json:"placeholderId,omitempty"`
+	Message       string `json:"message"`
+}
+
+// TemplateUpgradeReport says how a deck's slides map onto a new template
+// version and what doesn't carry over.
+type TemplateUpgradeReport struct {
+	FromVersionID     string                    `json:"fromTemplateVersionId"`
+	ToVersionID       string                    `json:"toTemplateVersionId"`
+	Slides            []SlideMapping            `json:"slides"`
+	Incompatibilities []TemplateIncompatibility `json:"incompatibilities"`
+	// Compatible is set when every slide and all its content carry over.
+	Compatible bool `json:"compatible"`
+}
+
+// UpgradeTemplateResult is the upgraded deck with its new version, or for a
+// dry run the deck as it was and no version.
+type UpgradeTemplateResult struct {
+	Deck    store.Deck
+	Version *store.DeckVersion
+	Report  TemplateUpgradeReport
+}
+
+// UpgradeTemplate rebuilds a deck's current version on another version of
+// the template the deck was made from and saves it as the deck's next
+// version, now bound to that template version. Slides keep their content;
+// the new version supplies the layouts, tokens and geometry. Incompatibilities
+// are reported rather than refused, so callers wanting a clean upgrade
+// should try a dry run first. Editors and above only.
+func (ds *DeckService) UpgradeTemplate(ctx context.Context, id auth.Identity, in UpgradeTemplateInput) (UpgradeTemplateResult, error) {
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		return UpgradeTemplateResult{}, ErrForbidden
+	}
+	d, err := authorizeDeck(ctx, ds.Store, id, in.DeckID, store.PermissionEdit)
+	if err != nil {
+		return UpgradeTemplateResult{}, err
+	}
+	if d.CurrentVersion == nil {
+		return UpgradeTemplateResult{}, invalidf("deck has no version to upgrade yet")
+	}
+	from, ok, err := ds.Store.Templates().GetVersion(ctx, id.OrgID, d.SourceTemplateVersion)
+	if err != nil {
+		return UpgradeTemplateResult{}, fmt.Errorf("load template version: %w", err)
+	}
+	if !ok {
+		return UpgradeTemplateResult{}, invalidf("deck's source template version no longer exists")
+	}
+	tpl, err := authorizeTemplate(ctx, ds.Store, id, from.Template, store.PermissionView)
+	if err != nil {
+		return UpgradeTemplateResult{}, err
+	}
+	toID := in.TemplateVersionID
+	if toID == "" {
+		if tpl.CurrentVersion == nil {
+			return UpgradeTemplateResult{}, invalidf("template has no current version")
+		}
+		toID = *tpl.CurrentVersion
+	}
+	if toID == from.ID {
+		return UpgradeTemplateResult{}, invalidf("deck already uses this template version")
+	}
+	to, ok, err := ds.Store.Templates().GetVersion(ctx, id.OrgID, toID)
+	if err != nil {
+		return UpgradeTemplateResult{}, fmt.Errorf("load template version: %w", err)
+	}
+	if !ok {
+		return UpgradeTemplateResult{}, fmt.Errorf("template version %w", ErrNotFound)
+	}
+	if to.Template != from.Template {
+		return UpgradeTemplateResult{}, invalidf("template version belongs to another template")
+	}
+
+	dv, ok, err := ds.Store.Decks().GetDeckVersion(ctx, id.OrgID, *d.CurrentVersion)
+	if err != nil {
+		return UpgradeTemplateResult{}, fmt.Errorf("load deck version: %w", err)
+	}
+	if !ok {
+		return UpgradeTemplateResult{}, fmt.Errorf("deck version %w", ErrNotFound)
+	}
+	var deckSpec, templateSpec spec.TemplateSpec
+	if err := decodeSpec(dv.SpecJSON, &deckSpec); err != nil {
+		return UpgradeTemplateResult{}, &InvalidError{Msg: "invalid stored deck spec", Spec: true}
+	}
+	if err := decodeSpec(to.SpecJSON, &templateSpec); err != nil || len(templateSpec.Layouts) == 0 {
+		return UpgradeTemplateResult{}, &InvalidError{Msg: "invalid stored template spec", Spec: true}
+	}
+
+	upgraded, report := upgradeDeckSpec(deckSpec, templateSpec)
+	report.FromVersionID, report.ToVersionID = from.ID, to.ID
+	if in.DryRun {
+		return UpgradeTemplateResult{Deck: d, Report: report}, nil
+	}
+	specBytes, err := json.Marshal(upgraded)
+	if err != nil {
+		return UpgradeTemplateResult{}, fmt.Errorf("encode deck spec: %w", err)
+	}
+
+	ver := store.DeckVersion{ID: newID("dv"), Deck: d.ID, OrgID: id.OrgID, VersionNo: d.LatestVersionNo + 1, SpecJSON: json.RawMessage(specBytes), CreatedBy: id.UserID}
+	var created store.DeckVersion
+	var updated store.Deck
+	err = ds.Store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		if created, err = tx.Decks().CreateDeckVersion(ctx, ver); err != nil {
+			return err
+		}
+		d.LatestVersionNo = ver.VersionNo
+		d.CurrentVersion = &created.ID
+		d.SourceTemplateVersion = to.ID
+		updated, err = tx.Decks().UpdateDeck(ctx, d)
+		return err
+	})
+	if err != nil {
+		return UpgradeTemplateResult{}, fmt.Errorf("save upgraded deck: %w", err)
+	}
+
+	_, _ = ds.Store.Audit().Append(ctx, store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.template.upgrade", TargetRef: d.ID, Metadata: map[string]any{
+		"fromTemplateVersionId": from.ID,
+		"toTemplateVersionId":   to.ID,
+		"versionId":             created.ID,
+		"incompatibilities":     len(report.Incompatibilities),
+	}})
+	return UpgradeTemplateResult{Deck: updated, Version: &created, Report: report}, nil
+}
+
+func decodeSpec(raw []byte, out *spec.TemplateSpec) error {
+	b, err := normalize.JSON(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// upgradeDeckSpec rebuilds each slide of deck on the layout of template
+// that matches it (see matchLayout) and moves its content across with
+// mapPlaceholders; content that finds no place is reported.
+// The deck's text direction survives the template's tokens.
+func upgradeDeckSpec(deck, template spec.TemplateSpec) (spec.TemplateSpec, TemplateUpgradeReport) {
+	out := spec.TemplateSpec{SpecVersion: template.SpecVersion, Tokens: maps.Clone(template.Tokens), Constraints: template.Constraints}
+	if dir, ok := deck.Tokens["direction"]; ok {
+		if out.Tokens == nil {
+			out.Tokens = map[string]any{}
+		}
+		out.Tokens["direction"] = dir
+	}
+	report := TemplateUpgradeReport{Slides: []SlideMapping{}, Incompatibilities: []TemplateIncompatibility{}}
+	for i, slide := range deck.Layouts {
+		n := i + 1
+		filled := filledPlaceholders(slide)
+		base, matched := matchLayout(slide.Name, filled, template.Layouts)
+		if !matched && len(filled) > 0 {
+			report.Incompatibilities = append(report.Incompatibilities, TemplateIncompatibility{Kind: IncompatibleLayout, Slide: n,
+				Message: fmt.Sprintf("slide %d: no layout like %q; using %q", n, slide.Name, base.Name)})
+		}
+
+		layout := spec.Layout{Name: base.Name, ReadingOrder: base.ReadingOrder, Placeholders: make([]spec.Placeholder, len(base.Placeholders))}
+		for j, ph := range base.Placeholders {
+			if placeholderType(ph) == "text" {
+				ph.Content = ""
+			}
+			layout.Placeholders[j] = ph
+		}
+		mapping := SlideMapping{Slide: n, Layout: layout.Name, Placeholders: map[string]string{}}
+		targets, _ := mapPlaceholders(filled, layout.Placeholders)
+		for k, ph := range filled {
+			if j := targets[k]; j >= 0 {
+				to := &layout.Placeholders[j]
+				to.Content = ph.Content
+				if ph.Image != nil {
+					to.Image = ph.Image
+				}
+				mapping.Placeholders[ph.ID] = to.ID
+				continue
+			}
+			kind, msg := IncompatiblePlaceholder, fmt.Sprintf("slide %d: nowhere to put %q; its %s is dropped", n, ph.ID, placeholderType(ph))
+			if j := findPlaceholder(layout.Placeholders, nil, func(p spec.Placeholder) bool { return p.ID == ph.ID }); j >= 0 {
+				kind, msg = IncompatibleType, fmt.Sprintf("slide %d: %q is now %s, not %s; its content is dropped", n, ph.ID, placeholderType(layout.Placeholders[j]), placeholderType(ph))
+			}
+			report.Incompatibilities = append(report.Incompatibilities, TemplateIncompatibility{Kind: kind, Slide: n, PlaceholderID: ph.ID, Message: msg})
+		}
+		out.Layouts = append(out.Layouts, layout)
+		report.Slides = append(report.Slides, mapping)
+	}
+	report.Compatible = len(report.Incompatibilities) == 0
+	return out, report
+}
+
+// filledPlaceholders are the placeholders of slide with text or an image.
+func filledPlaceholders(slide spec.Layout) []spec.Placeholder {
+	var out []spec.Placeholder
+	for _, ph := range slide.Placeholders {
+		if strings.TrimSpace(ph.Content) != "" || ph.Image != nil {
+			out = append(out, ph)
+		}
+	}
+	return out
+}
+
+// matchLayout picks the layout for a slide named name with the filled
+// placeholders, reporting false when it fell back to the first layout
+// because none was named alike or shared a placeholder. Among the others,
+// the layout with room for the most content wins, then the one keeping the
+// most placeholder IDs.
+func matchLayout(name string, filled []spec.Placeholder, layouts []spec.Layout) (spec.Layout, bool) {
+	for _, l := range layouts {
+		if name != "" && strings.EqualFold(l.Name, name) {
+			return l, true
+		}
+	}
+	best, bestPlaced, bestKept := 0, 0, 0
+	for i, l := range layouts {
+		targets, kept := mapPlaceholders(filled, l.Placeholders)
+		placed := 0
+		for _, j := range targets {
+			if j >= 0 {
+				placed++
+			}
+		}
+		if kept > 0 && (placed > bestPlaced || placed == bestPlaced && kept > bestKept) {
+			best, bestPlaced, bestKept = i, placed, kept
+		}
+	}
+	return layouts[best], bestKept > 0
+}
+
+// mapPlaceholders finds each filled placeholder's place among phs, as an
+// index or -1: the placeholder with its ID and type, or failing that the
+// next free one of its type. Those keeping their ID are placed first so
+// the fallback can't take their places. It also returns how many kept
+// their ID.
+func mapPlaceholders(filled, phs []spec.Placeholder) ([]int, int) {
+	targets := make([]int, len(filled))
+	used := make([]bool, len(phs))
+	kept := 0
+	for k, ph := range filled {
+		targets[k] = findPlaceholder(phs, used, func(p spec.Placeholder) bool {
+			return p.ID == ph.ID && placeholderType(p) == placeholderType(ph)
+		})
+		if targets[k] >= 0 {
+			used[targets[k]] = true
+			kept++
+		}
+	}
+	for k, ph := range filled {
+		if targets[k] >= 0 {
+			continue
+		}
+		targets[k] = findPlaceholder(phs, used, func(p spec.Placeholder) bool {
+			return placeholderType(p) == placeholderType(ph)
+		})
+		if targets[k] >= 0 {
+			used[targets[k]] = true
+		}
+	}
+	return targets, kept
+}
+
+// findPlaceholder returns the index of the first placeholder not yet used
+// that match accepts, or -1. A nil used counts none as used.
+func findPlaceholder(phs []spec.Placeholder, used []bool, match func(spec.Placeholder) bool) int {
+	for j, p := range phs {
+		if (used == nil || !used[j]) && match(p) {
+			return j
+		}
+	}
+	return -1
+}
+
+// placeholderType is ph's type; placeholders without one are text.
+func placeholderType(ph spec.Placeholder) string {
+	if ph.Type == "" {
+		return "text"
+	}
+	return ph.Type
+}
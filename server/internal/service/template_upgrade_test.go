@@ -0,0 +1,113 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+)
+
+func TestUpgradeDeckSpec(t *testing.T) {
+	newTemplate := spec.TemplateSpec{
+		Tokens: map[string]any{"colors": map[string]any{"primary": "#123456"}},
+		Layouts: []spec.Layout{
+			{Name: "Title", Placeholders: []spec.Placeholder{
+				{ID: "title", Type: "text", Content: "Sample title", Geometry: spec.Geometry{X: 0.1, Y: 0.4, W: 0.8, H: 0.2}},
+			}},
+			{Name: "Content", ReadingOrder: []string{"heading", "body"}, Placeholders: []spec.Placeholder{
+				{ID: "heading", Type: "text", Geometry: spec.Geometry{X: 0.05, Y: 0.05, W: 0.9, H: 0.1}},
+				{ID: "body", Type: "text", Geometry: spec.Geometry{X: 0.05, Y: 0.2, W: 0.9, H: 0.7}},
+				{ID: "chart", Type: "text", Geometry: spec.Geometry{X: 0.6, Y: 0.2, W: 0.3, H: 0.3}},
+			}},
+		},
+	}
+	deck := spec.TemplateSpec{
+		Tokens: map[string]any{"direction": "rtl"},
+		Layouts: []spec.Layout{
+			{Name: "title", Placeholders: []spec.Placeholder{{ID: "title", Type: "text", Content: "Q3 review"}}},
+			// Made from an outline, so named after the slide rather than a
+			// template layout.
+			{Name: "simple", Placeholders: []spec.Placeholder{
+				{ID: "title", Type: "text", Content: "Results"},
+				{ID: "body", Content: "Revenue grew"},
+				{ID: "chart", Type: "image", Image: &spec.Image{Path: "assets/chart.png"}},
+			}},
+			{Name: "Gallery", Placeholders: []spec.Placeholder{{ID: "photo", Type: "image", Image: &spec.Image{Path: "assets/photo.png"}}}},
+		},
+	}
+
+	out, report := upgradeDeckSpec(deck, newTemplate)
+
+	if len(out.Layouts) != 3 {
+		t.Fatalf("expected 3 slides, got %d", len(out.Layouts))
+	}
+	if out.Tokens["direction"] != "rtl" || out.Tokens["colors"] == nil {
+		t.Errorf("expected the template's tokens with the deck's direction, got %v", out.Tokens)
+	}
+	if newTemplate.Tokens["direction"] != nil {
+		t.Errorf("the template's tokens must not change")
+	}
+
+	title := out.Layouts[0]
+	if title.Name != "Title" || title.Placeholders[0].Content != "Q3 review" || title.Placeholders[0].Geometry.Y != 0.4 {
+		t.Errorf("slide 1: expected the Title layout with the deck's text, got %+v", title)
+	}
+
+	content := out.Layouts[1]
+	if content.Name != "Content" {
+		t.Fatalf("slide 2: expected the layout sharing its placeholders, got %q", content.Name)
+	}
+	if got := content.Placeholders[0].Content; got != "Results" {
+		t.Errorf("slide 2: expected the title to move to the free text placeholder, got %q", got)
+	}
+	if got := content.Placeholders[1].Content; got != "Revenue grew" {
+		t.Errorf("slide 2: expected the body to keep its ID, got %q", got)
+	}
+	if got := content.Placeholders[2].Content; got != "" {
+		t.Errorf("slide 2: expected the chart placeholder to stay empty, got %q", got)
+	}
+	if len(content.ReadingOrder) != 2 {
+		t.Errorf("slide 2: expected the layout's reading order, got %v", content.ReadingOrder)
+	}
+	if want := map[string]string{"title": "heading", "body": "body"}; len(report.Slides[1].Placeholders) != 2 || report.Slides[1].Placeholders["title"] != want["title"] || report.Slides[1].Placeholders["body"] != want["body"] {
+		t.Errorf("slide 2: unexpected mapping %v", report.Slides[1].Placeholders)
+	}
+
+	if out.Layouts[2].Name != "Title" {
+		t.Errorf("slide 3: expected the first layout as a fallback, got %q", out.Layouts[2].Name)
+	}
+
+	want := []TemplateIncompatibility{
+		{Kind: IncompatibleType, Slide: 2, PlaceholderID: "chart"},
+		{Kind: IncompatibleLayout, Slide: 3},
+		{Kind: IncompatiblePlaceholder, Slide: 3, PlaceholderID: "photo"},
+	}
+	if len(report.Incompatibilities) != len(want) {
+		t.Fatalf("expected %d incompatibilities, got %+v", len(want), report.Incompatibilities)
+	}
+	for i, w := range want {
+		got := report.Incompatibilities[i]
+		if got.Kind != w.Kind || got.Slide != w.Slide || got.PlaceholderID != w.PlaceholderID || got.Message == "" {
+			t.Errorf("incompatibility %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+	if report.Compatible {
+		t.Errorf("expected the upgrade to be reported incompatible")
+	}
+}
+
+func TestUpgradeDeckSpec_Compatible(t *testing.T) {
+	layout := spec.Layout{Name: "Base", Placeholders: []spec.Placeholder{
+		{ID: "title", Type: "text", Geometry: spec.Geometry{W: 0.5, H: 0.1}},
+		{ID: "body", Type: "text"},
+	}}
+	deck := spec.TemplateSpec{Layouts: []spec.Layout{{Name: "Base", Placeholders: []spec.Placeholder{{ID: "title", Type: "text", Content: "Hello"}}}}}
+
+	out, report := upgradeDeckSpec(deck, spec.TemplateSpec{Layouts: []spec.Layout{layout}})
+
+	if !report.Compatible || len(report.Incompatibilities) != 0 {
+		t.Errorf("expected a compatible upgrade, got %+v", report.Incompatibilities)
+	}
+	if got := out.Layouts[0].Placeholders; len(got) != 2 || got[0].Content != "Hello" || got[0].Geometry.W != 0.5 {
+		t.Errorf("expected the new layout's placeholders with the deck's text, got %+v", got)
+	}
+}
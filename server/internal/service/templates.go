@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const (
+	// MaxSyncPromptLength caps prompts for synchronous generation; longer
+	// prompts take long enough that they must go through the job queue.
+	MaxSyncPromptLength = 2000
+	syncGenerateTimeout = 25 * time.Second
+)
+
+// TemplateService reads and generates templates.
+type TemplateService struct {
+	Store  store.Store
+	AI     ai.AIServiceInterface
+	Quotas Quotas
+}
+
+// Get returns a template the caller can view.
+func (ts *TemplateService) Get(ctx context.Context, id auth.Identity, templateID string) (store.Template, error) {
+	return authorizeTemplate(ctx, ts.Store, id, templateID, store.PermissionView)
+}
+
+// List returns the templates the caller can view, only those in status if
+// it is set.
+func (ts *TemplateService) List(ctx context.Context, id auth.Identity, status store.TemplateStatus) ([]store.Template, error) {
+	switch status {
+	case "", store.TemplateDraft, store.TemplateInReview, store.TemplateApproved, store.TemplatePublished, store.TemplateArchived:
+	default:
+		return nil, invalidf("invalid status")
+	}
+	tpls, err := ts.Store.Templates().ListTemplatesFor(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("list templates: %w", err)
+	}
+	if status == "" {
+		return tpls, nil
+	}
+	out := make([]store.Template, 0, len(tpls))
+	for _, t := range tpls {
+		if t.Status == status {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// GenerateInput describes a template to generate from a prompt. RTL,
+// Language, Tone and SlideSize fall back to the org's settings when unset.
+type GenerateInput struct {
+	Prompt      string
+	Name        string // "Untitled" when empty
+	BrandKitID  string
+	RTL         *bool
+	Language    string
+	Tone        string
+	SlideSize   string // aspect ratio to design for: "16:9" or "4:3"
+	ContentData map[string]any
+	// Params override the org's generation defaults.
+	Params store.GenerationParams
+	// RequiredFields are stored on the generated version; decks made from
+	// it must supply them in their content data.
+	RequiredFields []store.TemplateField
+}
+
+// GenerateResult is the new template with either the queued generation job
+// (Generate) or the generated first version (GenerateNow).
+type GenerateResult struct {
+	Template   store.Template
+	Job        *store.Job
+	Version    *store.TemplateVersion
+	AIResponse *ai.GenerationResponse
+}
+
+// Generate creates a draft template and queues a job that generates its
+// first version.
+func (ts *TemplateService) Generate(ctx context.Context, id auth.Identity, in GenerateInput) (GenerateResult, error) {
+	if err := ValidateTemplateFields(in.RequiredFields); err != nil {
+		return GenerateResult{}, err
+	}
+	if err := ts.Quotas.CheckGenerate(ctx, id); err != nil {
+		return GenerateResult{}, err
+	}
+	in = resolveInput(ctx, ts.Store, id.OrgID, in)
+
+	created, err := ts.Store.Templates().CreateTemplate(ctx, newDraftTemplate(id, in.Name))
+	if err != nil {
+		return GenerateResult{}, fmt.Errorf("create template: %w", err)
+	}
+
+	metadata := store.JSONMap{
+		"prompt":     in.Prompt,
+		"language":   in.Language,
+		"tone":       in.Tone,
+		"slideSize":  in.SlideSize,
+		"rtl":        fmt.Sprintf("%v", in.rtl()),
+		"brandKitId": in.BrandKitID,
+		"userId":     id.UserID,
+	}
+	setParamsMetadata(metadata, in.Params)
+	if len(in.RequiredFields) > 0 {
+		if b, err := json.Marshal(in.RequiredFields); err == nil {
+			metadata["requiredFields"] = string(b)
+		}
+	}
+	job, _, err := ts.Store.Jobs().EnqueueWithDeduplication(ctx, store.Job{
+		ID:              newID("job"),
+		OrgID:           id.OrgID,
+		Type:            store.JobGenerate,
+		Status:          store.JobQueued,
+		InputRef:        created.ID,
+		DeduplicationID: fmt.Sprintf("generate-%s", created.ID),
+		Metadata:        &metadata,
+	})
+	if err != nil {
+		return GenerateResult{}, fmt.Errorf("enqueue generate job: %w", err)
+	}
+
+	_, _ = ts.Store.Audit().Append(ctx, store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "template.generate.queued", TargetRef: created.ID, Metadata: withPrompt(ctx, ts.Store, id.OrgID, map[string]any{"jobId": job.ID}, "prompt", in.Prompt)})
+	return GenerateResult{Template: created, Job: &job}, nil
+}
+
+// GenerateNow generates the template within the call, the way the worker's
+// generate job does, and stores it with its first version. Only prompts up to
+// MaxSyncPromptLength qualify. Nothing is stored if generation fails.
+func (ts *TemplateService) GenerateNow(ctx context.Context, id auth.Identity, in GenerateInput) (GenerateResult, error) {
+	if len(in.Prompt) > MaxSyncPromptLength {
+		return GenerateResult{}, invalidf("prompt too long for sync generation (max %d characters); omit sync to generate in the background", MaxSyncPromptLength)
+	}
+	if err := ValidateTemplateFields(in.RequiredFields); err != nil {
+		return GenerateResult{}, err
+	}
+	if err := ts.Quotas.CheckGenerate(ctx, id); err != nil {
+		return GenerateResult{}, err
+	}
+	in = resolveInput(ctx, ts.Store, id.OrgID, in)
+
+	genCtx, cancel := context.WithTimeout(ctx, syncGenerateTimeout)
+	defer cancel()
+	templateSpec, aiResp, err := ts.AI.GenerateTemplateForRequest(genCtx, id.OrgID, id.UserID, ai.GenerationRequest{
+		Prompt:      in.Prompt,
+		Language:    in.Language,
+		Tone:        in.Tone,
+		SlideSize:   in.SlideSize,
+		RTL:         in.rtl(),
+		ContentData: in.ContentData,
+		Params:      in.Params,
+	}, in.BrandKitID)
+	if err != nil {
+		if genCtx.Err() != nil {
+			return GenerateResult{}, fmt.Errorf("%w: %w", ErrGenerationFailed, genCtx.Err())
+		}
+		return GenerateResult{}, fmt.Errorf("%w: %w", ErrGenerationFailed, err)
+	}
+	specJSON, err := json.Marshal(templateSpec)
+	if err != nil {
+		return GenerateResult{}, fmt.Errorf("encode template spec: %w", err)
+	}
+
+	var created store.Template
+	var version store.TemplateVersion
+	err = ts.Store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		if created, err = tx.Templates().CreateTemplate(ctx, newDraftTemplate(id, in.Name)); err != nil {
+			return err
+		}
+		v := store.TemplateVersion{
+			ID:             newID("tv"),
+			Template:       created.ID,
+			OrgID:          id.OrgID,
+			VersionNo:      1,
+			SpecJSON:       json.RawMessage(specJSON),
+			CreatedBy:      id.UserID,
+			RequiredFields: in.RequiredFields,
+		}
+		if params := in.Params; !params.IsZero() {
+			v.GenerationParams = &params
+		}
+		if version, err = tx.Templates().CreateVersion(ctx, v); err != nil {
+			return err
+		}
+		created.CurrentVersion = &version.ID
+		created.LatestVersionNo = 1
+		created, err = tx.Templates().UpdateTemplate(ctx, created)
+		return err
+	})
+	if err != nil {
+		return GenerateResult{}, fmt.Errorf("save generated template: %w", err)
+	}
+
+	_, _ = ts.Store.Audit().Append(ctx, store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "template.generate", TargetRef: created.ID, Metadata: withPrompt(ctx, ts.Store, id.OrgID, map[string]any{"versionId": version.ID, "sync": true}, "prompt", in.Prompt)})
+	return GenerateResult{Template: created, Version: &version, AIResponse: aiResp}, nil
+}
+
+func newDraftTemplate(id auth.Identity, name string) store.Template {
+	if name == "" {
+		name = "Untitled"
+	}
+	return store.Template{
+		ID:          newID("tpl"),
+		OrgID:       id.OrgID,
+		OwnerUserID: id.UserID,
+		Name:        name,
+		Status:      store.TemplateDraft,
+	}
+}
+
+func (in GenerateInput) rtl() bool {
+	return in.RTL != nil && *in.RTL
+}
+
+// resolveInput fills what a generate request leaves unset from the org: its
+// generation defaults under Params, and the language, tone, direction and
+// slide size in its settings. Like resolveParams, the result is what the job records.
+func resolveInput(ctx context.Context, st store.Store, orgID string, in GenerateInput) GenerateInput {
+	org, err := st.Organizations().GetOrganization(ctx, orgID)
+	if err != nil {
+		logger.WithContext(ctx).Debug("generation_defaults_unavailable", "org_id", orgID, "error", err)
+		return in
+	}
+	in.Params = in.Params.WithDefaults(org.GenerationDefaults)
+	if settings := org.Settings; settings != nil {
+		if in.Language == "" {
+			in.Language = settings.Language
+		}
+		if in.Tone == "" {
+			in.Tone = settings.Tone
+		}
+		if in.RTL == nil {
+			in.RTL = &settings.RTL
+		}
+		if in.SlideSize == "" {
+			in.SlideSize = settings.SlideSize
+		}
+	}
+	return in
+}
+
+// resolveParams layers a request's overrides on top of the org defaults. The
+// result travels to the worker in job metadata so the version records
+// exactly what was used, even if the defaults change meanwhile.
+func resolveParams(ctx context.Context, st store.Store, orgID string, params store.GenerationParams) store.GenerationParams {
+	org, err := st.Organizations().GetOrganization(ctx, orgID)
+	if err != nil {
+		logger.WithContext(ctx).Debug("generation_defaults_unavailable", "org_id", orgID, "error", err)
+		return params
+	}
+	return params.WithDefaults(org.GenerationDefaults)
+}
+
+// setParamsMetadata stores resolved params on job metadata; the worker reads
+// them back with the same key.
+func setParamsMetadata(m store.JSONMap, params store.GenerationParams) {
+	if params.IsZero() {
+		return
+	}
+	if b, err := json.Marshal(params); err == nil {
+		m["generationParams"] = string(b)
+	}
+}
+
+// withPrompt adds what the user typed to audit metadata when the org has
+// opted in to storing prompts. The audit store masks personal data in it.
+func withPrompt(ctx context.Context, st store.Store, orgID string, meta map[string]any, key string, value any) map[string]any {
+	if storesPrompts(ctx, st, orgID) {
+		meta[key] = value
+	}
+	return meta
+}
+
+// storesPrompts reports whether the org has opted in to storing prompts. An
+// org that can't be read hasn't.
+func storesPrompts(ctx context.Context, st store.Store, orgID string) bool {
+	org, err := st.Organizations().GetOrganization(ctx, orgID)
+	return err == nil && org.StoresPrompts()
+}
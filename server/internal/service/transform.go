@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// TransformInput names a deck version and the transformation to run its text
+// through: one of ai.TransformOperations, with Language, a BCP 47 tag, for
+// ai.TransformTranslate.
+type TransformInput struct {
+	VersionID string
+	Operation string
+	Language  string
+}
+
+// Transform runs the text placeholders of a deck version through the AI and
+// saves the result as the deck's next version. Only the texts go to the AI;
+// everything else in the spec is kept. A translation also sets the deck's
+// language. Each transformation is metered under its operation and counts
+// against the generate quota; editors and above only.
+func (ds *DeckService) Transform(ctx context.Context, id auth.Identity, in TransformInput) (store.Deck, store.DeckVersion, error) {
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		return store.Deck{}, store.DeckVersion{}, ErrForbidden
+	}
+	if !slices.Contains(ai.TransformOperations, in.Operation) {
+		return store.Deck{}, store.DeckVersion{}, invalidf("operation must be one of %s", strings.Join(ai.TransformOperations, ", "))
+	}
+	if in.Operation == ai.TransformTranslate && in.Language == "" {
+		return store.Deck{}, store.DeckVersion{}, invalidf("language is required to translate")
+	}
+	dv, ok, err := ds.Store.Decks().GetDeckVersion(ctx, id.OrgID, in.VersionID)
+	if err != nil {
+		return store.Deck{}, store.DeckVersion{}, fmt.Errorf("load deck version: %w", err)
+	}
+	if !ok {
+		return store.Deck{}, store.DeckVersion{}, fmt.Errorf("deck version %w", ErrNotFound)
+	}
+	d, err := authorizeDeck(ctx, ds.Store, id, dv.Deck, store.PermissionEdit)
+	if err != nil {
+		return store.Deck{}, store.DeckVersion{}, err
+	}
+	transformer, ok := ds.AI.(ai.TextTransformer)
+	if !ok {
+		return store.Deck{}, store.DeckVersion{}, fmt.Errorf("%w: the AI service cannot transform text", ErrGenerationFailed)
+	}
+
+	var doc map[string]any
+	specBytes, err := normalize.JSON(dv.SpecJSON)
+	if err == nil {
+		err = json.Unmarshal(specBytes, &doc)
+	}
+	if err != nil {
+		return store.Deck{}, store.DeckVersion{}, &InvalidError{Msg: "invalid stored deck spec", Spec: true}
+	}
+	texts := textPlaceholders(doc)
+	if len(texts) == 0 {
+		return store.Deck{}, store.DeckVersion{}, invalidf("deck version has no text to transform")
+	}
+	if err := ds.Quotas.CheckGenerate(ctx, id); err != nil {
+		return store.Deck{}, store.DeckVersion{}, err
+	}
+	if err := rewriteTexts(ctx, id, transformer, texts, in.Operation, in.Language); err != nil {
+		return store.Deck{}, store.DeckVersion{}, err
+	}
+	if specBytes, err = json.Marshal(doc); err != nil {
+		return store.Deck{}, store.DeckVersion{}, fmt.Errorf("encode deck spec: %w", err)
+	}
+	if err := checkSpecPolicy(ctx, ds.Store, id.OrgID, specBytes); err != nil {
+		return store.Deck{}, store.DeckVersion{}, err
+	}
+
+	ver := store.DeckVersion{ID: newID("dv"), Deck: d.ID, OrgID: id.OrgID, VersionNo: d.LatestVersionNo + 1, SpecJSON: json.RawMessage(specBytes), CreatedBy: id.UserID}
+	var created store.DeckVersion
+	var updated store.Deck
+	err = ds.Store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		if created, err = tx.Decks().CreateDeckVersion(ctx, ver); err != nil {
+			return err
+		}
+		d.LatestVersionNo = ver.VersionNo
+		d.CurrentVersion = &created.ID
+		if in.Operation == ai.TransformTranslate {
+			d.Language = in.Language
+		}
+		updated, err = tx.Decks().UpdateDeck(ctx, d)
+		return err
+	})
+	if err != nil {
+		return store.Deck{}, store.DeckVersion{}, fmt.Errorf("save transformed deck: %w", err)
+	}
+
+	_, _ = ds.Store.Metering().Record(ctx, store.MeteringEvent{ID: newID("met"), OrgID: id.OrgID, UserID: id.UserID, Type: store.MeterTransformPrefix + in.Operation, Quantity: 1, TargetRef: d.ID})
+	meta := map[string]any{"fromVersionId": dv.ID, "versionId": created.ID, "operation": in.Operation, "texts": len(texts)}
+	if in.Language != "" {
+		meta["language"] = in.Language
+	}
+	_, _ = ds.Store.Audit().Append(ctx, store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.transform", TargetRef: d.ID, Metadata: meta})
+	return updated, created, nil
+}
+
+// rewriteTexts runs the texts through the AI and writes the results back
+// into their placeholders.
+func rewriteTexts(ctx context.Context, id auth.Identity, transformer ai.TextTransformer, texts map[string]map[string]any, operation, language string) error {
+	originals := make(map[string]string, len(texts))
+	for k, ph := range texts {
+		originals[k] = ph["content"].(string)
+	}
+	genCtx, cancel := context.WithTimeout(ctx, syncGenerateTimeout)
+	defer cancel()
+	transformed, err := transformer.TransformTexts(genCtx, id.OrgID, id.UserID, operation, language, originals)
+	if err != nil {
+		if genCtx.Err() != nil {
+			return fmt.Errorf("%w: %w", ErrGenerationFailed, genCtx.Err())
+		}
+		return fmt.Errorf("%w: %w", ErrGenerationFailed, err)
+	}
+	for k, ph := range texts {
+		ph["content"] = transformed[k]
+	}
+	return nil
+}
+
+// textPlaceholders returns the decoded spec's text placeholders that have
+// content, keyed by "<layout>/<placeholder>" indexes. Placeholders without a
+// type are text.
+func textPlaceholders(doc map[string]any) map[string]map[string]any {
+	out := map[string]map[string]any{}
+	layouts, _ := doc["layouts"].([]any)
+	for i, l := range layouts {
+		layout, _ := l.(map[string]any)
+		placeholders, _ := layout["placeholders"].([]any)
+		for j, p := range placeholders {
+			ph, _ := p.(map[string]any)
+			content, _ := ph["content"].(string)
+			if typ, _ := ph["type"].(string); (typ == "" || typ == "text") && strings.TrimSpace(content) != "" {
+				out[fmt.Sprintf("%d/%d", i, j)] = ph
+			}
+		}
+	}
+	return out
+}
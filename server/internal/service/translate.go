@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// rtlLanguages and rtlScripts are the primary language and script subtags
+// written right to left.
+var (
+	rtlLanguages = []string{"ar", "ckb", "dv", "fa", "he", "ps", "sd", "ug", "ur", "yi"}
+	rtlScripts   = []string{"arab", "hebr", "nkoo", "syrc", "thaa"}
+)
+
+// rtlLanguage reports whether the BCP 47 tag names a right-to-left language.
+// An explicit script wins, so "az-Arab" is right to left and "ku-Latn" isn't.
+func rtlLanguage(tag string) bool {
+	subtags := strings.Split(strings.ToLower(tag), "-")
+	for _, s := range subtags[1:] {
+		if len(s) == 4 {
+			return slices.Contains(rtlScripts, s)
+		}
+	}
+	return slices.Contains(rtlLanguages, subtags[0])
+}
+
+// TranslateInput names the deck to translate and the BCP 47 tag of the
+// language to translate it into.
+type TranslateInput struct {
+	DeckID   string
+	Language string
+}
+
+// TranslateResult is the translated deck with its new version. Created is
+// false when an existing translation got the new version.
+type TranslateResult struct {
+	Deck    store.Deck
+	Version store.DeckVersion
+	Created bool
+}
+
+// Translate has the AI translate the text placeholders of a deck's current
+// version and saves the result to the deck's translation into that language:
+// a linked deck, created on the first translation, that later translations
+// add versions to. When the text direction changes, placeholders are
+// mirrored horizontally, left and right alignments swap and the tokens
+// record the new direction. Translating is metered like the translate
+// transformation and counts against the generate quota; editors and above
+// only.
+func (ds *DeckService) Translate(ctx context.Context, id auth.Identity, in TranslateInput) (TranslateResult, error) {
+	if !auth.RequireRole(id, auth.RoleEditor) {
+		return TranslateResult{}, ErrForbidden
+	}
+	src, err := authorizeDeck(ctx, ds.Store, id, in.DeckID, store.PermissionView)
+	if err != nil {
+		return TranslateResult{}, err
+	}
+	if src.CurrentVersion == nil {
+		return TranslateResult{}, invalidf("deck has no version to translate yet")
+	}
+	if strings.EqualFold(src.Language, in.Language) {
+		return TranslateResult{}, invalidf("deck is already in %s", in.Language)
+	}
+	transformer, ok := ds.AI.(ai.TextTransformer)
+	if !ok {
+		return TranslateResult{}, fmt.Errorf("%w: the AI service cannot translate text", ErrGenerationFailed)
+	}
+	dv, ok, err := ds.Store.Decks().GetDeckVersion(ctx, id.OrgID, *src.CurrentVersion)
+	if err != nil {
+		return TranslateResult{}, fmt.Errorf("load deck version: %w", err)
+	}
+	if !ok {
+		return TranslateResult{}, fmt.Errorf("deck version %w", ErrNotFound)
+	}
+
+	// An earlier translation into the language gets the new version; the
+	// caller must be able to edit it.
+	target, found, err := ds.findTranslation(ctx, id.OrgID, src.ID, in.Language)
+	if err != nil {
+		return TranslateResult{}, err
+	}
+	if found {
+		if target, err = authorizeDeck(ctx, ds.Store, id, target.ID, store.PermissionEdit); err != nil {
+			return TranslateResult{}, err
+		}
+	}
+
+	var doc map[string]any
+	specBytes, err := normalize.JSON(dv.SpecJSON)
+	if err == nil {
+		err = json.Unmarshal(specBytes, &doc)
+	}
+	if err != nil {
+		return TranslateResult{}, &InvalidError{Msg: "invalid stored deck spec", Spec: true}
+	}
+	texts := textPlaceholders(doc)
+	if len(texts) == 0 {
+		return TranslateResult{}, invalidf("deck has no text to translate")
+	}
+	if err := ds.Quotas.CheckGenerate(ctx, id); err != nil {
+		return TranslateResult{}, err
+	}
+	if err := rewriteTexts(ctx, id, transformer, texts, ai.TransformTranslate, in.Language); err != nil {
+		return TranslateResult{}, err
+	}
+	mirrored := setDirection(doc, src.Language, rtlLanguage(in.Language))
+	if specBytes, err = json.Marshal(doc); err != nil {
+		return TranslateResult{}, fmt.Errorf("encode deck spec: %w", err)
+	}
+	if err := checkSpecPolicy(ctx, ds.Store, id.OrgID, specBytes); err != nil {
+		return TranslateResult{}, err
+	}
+
+	if !found {
+		listing := src.Listing
+		listing.Language = in.Language
+		target = store.Deck{
+			ID:                    newID("deck"),
+			OrgID:                 id.OrgID,
+			OwnerUserID:           id.UserID,
+			Name:                  fmt.Sprintf("%s (%s)", src.Name, in.Language),
+			SourceTemplateVersion: src.SourceTemplateVersion,
+			FolderID:              src.FolderID,
+			TranslatedFrom:        &src.ID,
+			Content:               src.Content,
+			Listing:               listing,
+		}
+	}
+	res := TranslateResult{Created: !found}
+	err = ds.Store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		if !found {
+			if target, err = tx.Decks().CreateDeck(ctx, target); err != nil {
+				return fmt.Errorf("create deck: %w", err)
+			}
+		}
+		ver := store.DeckVersion{ID: newID("dv"), Deck: target.ID, OrgID: id.OrgID, VersionNo: target.LatestVersionNo + 1, SpecJSON: json.RawMessage(specBytes), CreatedBy: id.UserID}
+		if res.Version, err = tx.Decks().CreateDeckVersion(ctx, ver); err != nil {
+			return err
+		}
+		target.LatestVersionNo = ver.VersionNo
+		target.CurrentVersion = &res.Version.ID
+		res.Deck, err = tx.Decks().UpdateDeck(ctx, target)
+		return err
+	})
+	if err != nil {
+		return TranslateResult{}, fmt.Errorf("save translated deck: %w", err)
+	}
+
+	if res.Created {
+		EmitDeckCreated(ctx, ds.Store, id.UserID, res.Deck)
+	}
+	_, _ = ds.Store.Metering().Record(ctx, store.MeteringEvent{ID: newID("met"), OrgID: id.OrgID, UserID: id.UserID, Type: store.MeterTransformPrefix + ai.TransformTranslate, Quantity: 1, TargetRef: res.Deck.ID})
+	meta := map[string]any{"sourceDeckId": src.ID, "fromVersionId": dv.ID, "versionId": res.Version.ID, "language": in.Language, "texts": len(texts), "mirrored": mirrored}
+	_, _ = ds.Store.Audit().Append(ctx, store.AuditLog{ID: newID("aud"), OrgID: id.OrgID, ActorID: id.UserID, Action: "deck.translate", TargetRef: res.Deck.ID, Metadata: meta})
+	return res, nil
+}
+
+// ListTranslations returns the decks translated from the deck that the
+// caller may view.
+func (ds *DeckService) ListTranslations(ctx context.Context, id auth.Identity, deckID string) ([]store.Deck, error) {
+	if _, err := authorizeDeck(ctx, ds.Store, id, deckID, store.PermissionView); err != nil {
+		return nil, err
+	}
+	decks, err := ds.Store.Decks().ListDecksFor(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("list decks: %w", err)
+	}
+	out := []store.Deck{}
+	for _, d := range decks {
+		if d.TranslatedFrom != nil && *d.TranslatedFrom == deckID && d.DeletedAt == nil {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// findTranslation returns the live deck translated from deckID into
+// language, whether or not the caller may see it.
+func (ds *DeckService) findTranslation(ctx context.Context, orgID, deckID, language string) (store.Deck, bool, error) {
+	decks, err := ds.Store.Decks().ListDecks(ctx, orgID)
+	if err != nil {
+		return store.Deck{}, false, fmt.Errorf("list decks: %w", err)
+	}
+	for _, d := range decks {
+		if d.TranslatedFrom != nil && *d.TranslatedFrom == deckID && d.DeletedAt == nil && strings.EqualFold(d.Language, language) {
+			return d, true, nil
+		}
+	}
+	return store.Deck{}, false, nil
+}
+
+// setDirection records the text direction in the decoded spec's tokens and,
+// when it differs from the spec's current one, mirrors the layouts: each
+// placeholder's x becomes 1-x-w and "left" and "right" alignments swap. The
+// current direction is the tokens' "direction", or else sourceLanguage's.
+// It reports whether the layouts were mirrored.
+func setDirection(doc map[string]any, sourceLanguage string, rtl bool) bool {
+	tokens, _ := doc["tokens"].(map[string]any)
+	if tokens == nil {
+		tokens = map[string]any{}
+		doc["tokens"] = tokens
+	}
+	wasRTL := rtlLanguage(sourceLanguage)
+	if dir, ok := tokens["direction"].(string); ok && dir != "" {
+		wasRTL = strings.EqualFold(dir, "rtl")
+	}
+	tokens["direction"] = "ltr"
+	if rtl {
+		tokens["direction"] = "rtl"
+	}
+	if wasRTL == rtl {
+		return false
+	}
+
+	layouts, _ := doc["layouts"].([]any)
+	for _, l := range layouts {
+		layout, _ := l.(map[string]any)
+		placeholders, _ := layout["placeholders"].([]any)
+		for _, p := range placeholders {
+			ph, _ := p.(map[string]any)
+			if geom, ok := ph["geometry"].(map[string]any); ok {
+				x, _ := geom["x"].(float64)
+				w, _ := geom["w"].(float64)
+				// Rounded so mirroring twice gives back the original geometry.
+				geom["x"] = math.Round((1-x-w)*1e6) / 1e6
+			}
+			switch align, _ := ph["align"].(string); align {
+			case "left":
+				ph["align"] = "right"
+			case "right":
+				ph["align"] = "left"
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRTLLanguage(t *testing.T) {
+	for tag, want := range map[string]bool{
+		"ar": true, "ar-EG": true, "he": true, "fa-IR": true, "az-Arab": true,
+		"en": false, "fr-CA": false, "ku-Latn": false, "": false,
+	} {
+		if got := rtlLanguage(tag); got != want {
+			t.Errorf("rtlLanguage(%q) = %v, want %v", tag, got, want)
+		}
+	}
+}
+
+func TestSetDirection(t *testing.T) {
+	const ltr = `{"tokens":{},"layouts":[{"placeholders":[{"id":"a","align":"left","geometry":{"x":0.1,"y":0.2,"w":0.3,"h":0.4}}]}]}`
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(ltr), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if setDirection(doc, "en", false) {
+		t.Errorf("same direction should not mirror")
+	}
+	if !setDirection(doc, "en", true) {
+		t.Fatalf("ltr to rtl should mirror")
+	}
+	ph := doc["layouts"].([]any)[0].(map[string]any)["placeholders"].([]any)[0].(map[string]any)
+	if x := ph["geometry"].(map[string]any)["x"]; x != 0.6 {
+		t.Errorf("x = %v, want 0.6", x)
+	}
+	if ph["align"] != "right" {
+		t.Errorf("align = %v, want right", ph["align"])
+	}
+
+	// The tokens now say rtl, so going back to an LTR language mirrors again
+	// whatever the source language claims.
+	if !setDirection(doc, "en", false) {
+		t.Fatalf("rtl to ltr should mirror")
+	}
+	got, _ := json.Marshal(doc)
+	want := `{"layouts":[{"placeholders":[{"align":"left","geometry":{"h":0.4,"w":0.3,"x":0.1,"y":0.2},"id":"a"}]}],"tokens":{"direction":"ltr"}}`
+	if string(got) != want {
+		t.Errorf("round trip = %s, want %s", got, want)
+	}
+}
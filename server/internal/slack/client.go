@@ -0,0 +1,127 @@
+// Package slack posts notifications to an org's Slack workspace, through an
+// incoming webhook or the chat.postMessage API with a bot token.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const defaultAPIBase = "https://slack.com/api"
+
+// ErrNotConfigured means the org has neither a webhook URL nor a bot token.
+var ErrNotConfigured = errors.New("slack integration is not configured")
+
+type Client struct {
+	APIBase    string
+	HTTPClient *http.Client
+}
+
+func NewClient() *Client {
+	return &Client{
+		APIBase:    defaultAPIBase,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Message is a notification. Text is Slack mrkdwn and doubles as the
+// fallback shown in push notifications.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Post sends msg using cfg's incoming webhook if it has one, otherwise as the
+// bot to cfg.Channel.
+func (c *Client) Post(ctx context.Context, cfg *store.SlackIntegration, msg Message) error {
+	switch {
+	case cfg == nil:
+		return ErrNotConfigured
+	case cfg.WebhookURL != "":
+		return c.postWebhook(ctx, cfg.WebhookURL, msg)
+	case cfg.BotToken != "":
+		return c.postMessage(ctx, cfg.BotToken, cfg.Channel, msg)
+	default:
+		return ErrNotConfigured
+	}
+}
+
+// postWebhook posts to an incoming webhook, which answers 200 "ok" or an
+// error status with a short reason in the body.
+func (c *Client) postWebhook(ctx context.Context, webhookURL string, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, webhookURL, "", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	reason, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack webhook responded %d: %s", resp.StatusCode, strings.TrimSpace(string(reason)))
+	}
+	return nil
+}
+
+// postMessage calls chat.postMessage, which reports failures as
+// {"ok":false,"error":"..."} with a 200 status.
+func (c *Client) postMessage(ctx context.Context, token, channel string, msg Message) error {
+	body, err := json.Marshal(map[string]string{"channel": channel, "text": msg.Text})
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, c.APIBase+"/chat.postMessage", token, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack api responded %d", resp.StatusCode)
+	}
+	var out struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&out); err != nil {
+		return fmt.Errorf("decode slack response: %w", err)
+	}
+	if !out.OK {
+		return fmt.Errorf("slack api error: %s", out.Error)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, endpoint, token string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	// Transport errors quote the URL, and a webhook URL is a credential.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return nil, fmt.Errorf("post to slack: %w", urlErr.Err)
+	}
+	return resp, err
+}
+
+// Escape makes s safe to embed in mrkdwn, where &, < and > are control
+// characters.
+func Escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
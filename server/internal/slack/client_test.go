@@ -0,0 +1,68 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+func TestPost_IncomingWebhook(t *testing.T) {
+	var got Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		if r.URL.Path == "/services/revoked" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("invalid_token"))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	require.NoError(t, c.Post(context.Background(), &store.SlackIntegration{WebhookURL: srv.URL + "/services/T1/B1"}, Message{Text: "Export ready"}))
+	assert.Equal(t, "Export ready", got.Text)
+
+	err := c.Post(context.Background(), &store.SlackIntegration{WebhookURL: srv.URL + "/services/revoked"}, Message{Text: "x"})
+	assert.EqualError(t, err, "slack webhook responded 403: invalid_token")
+}
+
+func TestPost_BotToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat.postMessage", r.URL.Path)
+		assert.Equal(t, "Bearer xoxb-1", r.Header.Get("Authorization"))
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["channel"] != "#exports" {
+			_, _ = w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	c.APIBase = srv.URL
+	require.NoError(t, c.Post(context.Background(), &store.SlackIntegration{BotToken: "xoxb-1", Channel: "#exports"}, Message{Text: "hi"}))
+	err := c.Post(context.Background(), &store.SlackIntegration{BotToken: "xoxb-1", Channel: "#gone"}, Message{Text: "hi"})
+	assert.EqualError(t, err, "slack api error: channel_not_found")
+}
+
+func TestPost_ErrorsDoNotLeakWebhookURL(t *testing.T) {
+	c := NewClient()
+	assert.ErrorIs(t, c.Post(context.Background(), nil, Message{}), ErrNotConfigured)
+
+	err := c.Post(context.Background(), &store.SlackIntegration{WebhookURL: "http://127.0.0.1:1/services/T1/B1/secret"}, Message{Text: "x"})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+func TestEscape(t *testing.T) {
+	assert.Equal(t, "Q&amp;A &lt;draft&gt;", Escape("Q&A <draft>"))
+}
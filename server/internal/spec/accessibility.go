@@ -0,0 +1,47 @@
+package spec
+
+// ImageRef locates an image placeholder in a spec.
+type ImageRef struct {
+	Layout      int
+	Placeholder int
+}
+
+// ReadingOrder returns the indexes of the layout's placeholders in the order
+// screen readers should read them: the ones ReadingOrder lists, then the
+// rest as declared. IDs that name no placeholder are skipped.
+func ReadingOrder(l Layout) []int {
+	index := make(map[string]int, len(l.Placeholders))
+	for i, ph := range l.Placeholders {
+		if _, ok := index[ph.ID]; !ok {
+			index[ph.ID] = i
+		}
+	}
+	out := make([]int, 0, len(l.Placeholders))
+	listed := map[int]bool{}
+	for _, id := range l.ReadingOrder {
+		if i, ok := index[id]; ok && !listed[i] {
+			listed[i] = true
+			out = append(out, i)
+		}
+	}
+	for i := range l.Placeholders {
+		if !listed[i] {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// MissingAltText lists the images in s that have no alt text and aren't
+// marked decorative.
+func MissingAltText(s TemplateSpec) []ImageRef {
+	var out []ImageRef
+	for li, layout := range s.Layouts {
+		for pi, ph := range layout.Placeholders {
+			if img := ph.Image; img != nil && !img.Decorative && img.Alt == "" {
+				out = append(out, ImageRef{Layout: li, Placeholder: pi})
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,28 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadingOrder(t *testing.T) {
+	l := Layout{
+		Placeholders: []Placeholder{{ID: "title"}, {ID: "body"}, {ID: "photo"}, {ID: "footer"}},
+		ReadingOrder: []string{"photo", "missing", "title", "photo"},
+	}
+	assert.Equal(t, []int{2, 0, 1, 3}, ReadingOrder(l))
+	assert.Equal(t, []int{0, 1}, ReadingOrder(Layout{Placeholders: []Placeholder{{ID: "a"}, {ID: "b"}}}))
+}
+
+func TestMissingAltText(t *testing.T) {
+	s := TemplateSpec{Layouts: []Layout{
+		{Placeholders: []Placeholder{{ID: "title"}, {ID: "photo", Type: "image", Image: &Image{Path: "a.png"}}}},
+		{Placeholders: []Placeholder{
+			{ID: "logo", Type: "image", Image: &Image{Path: "b.png", Alt: "Acme logo"}},
+			{ID: "rule", Type: "image", Image: &Image{Path: "c.png", Decorative: true}},
+			{ID: "chart", Type: "image", Image: &Image{Path: "d.png"}},
+		}},
+	}}
+	assert.Equal(t, []ImageRef{{Layout: 0, Placeholder: 1}, {Layout: 1, Placeholder: 2}}, MissingAltText(s))
+}
@@ -0,0 +1,148 @@
+package spec
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WCAG 2.x AA contrast minimums.
+const (
+	MinContrastText      = 4.5
+	MinContrastLargeText = 3.0
+)
+
+// contrastPairs are the theme colors renderers draw as text on
+// tokens.colors.background, with the contrast each needs: body text and
+// small secondary text such as slide numbers need MinContrastText; primary
+// is used for titles, which are large.
+var contrastPairs = []struct {
+	token string
+	min   float64
+}{
+	{"text", MinContrastText},
+	{"primary", MinContrastLargeText},
+	{"secondary", MinContrastText},
+}
+
+// ContrastIssue is a theme text color that doesn't stand out enough from
+// the background.
+type ContrastIssue struct {
+	Token      string  `json:"token"`
+	Color      string  `json:"color"`
+	Background string  `json:"background"`
+	Ratio      float64 `json:"ratio"`
+	Min        float64 `json:"min"`
+	// Adjusted is the color AdjustContrast replaced Color with.
+	Adjusted string `json:"adjusted,omitempty"`
+}
+
+func (c ContrastIssue) String() string {
+	msg := fmt.Sprintf("tokens.colors.%s %s on background %s has contrast %.2f:1, below the WCAG AA minimum of %.1f:1", c.Token, c.Color, c.Background, c.Ratio, c.Min)
+	if c.Adjusted != "" {
+		msg += "; adjusted to " + c.Adjusted
+	}
+	return msg
+}
+
+// CheckContrast reports the theme text colors in tokens that fall short of
+// WCAG AA contrast against tokens.colors.background. Colors that are
+// missing or not #RRGGBB are skipped.
+func CheckContrast(tokens map[string]any) []ContrastIssue {
+	colors, _ := tokens["colors"].(map[string]any)
+	bgHex, _ := colors["background"].(string)
+	bg, ok := parseHexColor(bgHex)
+	if !ok {
+		return nil
+	}
+	var issues []ContrastIssue
+	for _, p := range contrastPairs {
+		fgHex, _ := colors[p.token].(string)
+		fg, ok := parseHexColor(fgHex)
+		if !ok {
+			continue
+		}
+		if ratio := contrastRatio(fg, bg); ratio < p.min {
+			issues = append(issues, ContrastIssue{Token: p.token, Color: fgHex, Background: bgHex, Ratio: math.Floor(ratio*100) / 100, Min: p.min})
+		}
+	}
+	return issues
+}
+
+// AdjustContrast fixes the issues CheckContrast finds, in place. Each
+// failing text color is blended toward black or white, whichever the
+// background contrasts with more, just far enough to pass, so it keeps as
+// much of its hue as it can. The background is left alone: it is the
+// theme's dominant color, and black or white text can always meet AA on it.
+func AdjustContrast(tokens map[string]any) []ContrastIssue {
+	issues := CheckContrast(tokens)
+	if len(issues) == 0 {
+		return nil
+	}
+	colors := tokens["colors"].(map[string]any)
+	bg, _ := parseHexColor(issues[0].Background)
+	target := rgb{0, 0, 0}
+	if contrastRatio(rgb{255, 255, 255}, bg) > contrastRatio(target, bg) {
+		target = rgb{255, 255, 255}
+	}
+	for i := range issues {
+		fg, _ := parseHexColor(issues[i].Color)
+		adjusted := target
+		for step := 1; step < 40; step++ {
+			c := fg.blend(target, float64(step)/40)
+			if contrastRatio(c, bg) >= issues[i].Min {
+				adjusted = c
+				break
+			}
+		}
+		issues[i].Adjusted = adjusted.hex()
+		colors[issues[i].Token] = issues[i].Adjusted
+	}
+	return issues
+}
+
+type rgb struct{ r, g, b float64 }
+
+func parseHexColor(s string) (rgb, bool) {
+	h := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(h) != 6 {
+		return rgb{}, false
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return rgb{}, false
+	}
+	return rgb{float64(v >> 16 & 0xff), float64(v >> 8 & 0xff), float64(v & 0xff)}, true
+}
+
+func (c rgb) hex() string {
+	return fmt.Sprintf("#%02X%02X%02X", int(c.r), int(c.g), int(c.b))
+}
+
+// blend moves c a fraction t of the way to "to", rounding to whole
+// channel values so the result is exactly what hex writes.
+func (c rgb) blend(to rgb, t float64) rgb {
+	mix := func(a, b float64) float64 { return math.Round(a + (b-a)*t) }
+	return rgb{mix(c.r, to.r), mix(c.g, to.g), mix(c.b, to.b)}
+}
+
+// luminance is the WCAG relative luminance of c.
+func (c rgb) luminance() float64 {
+	channel := func(v float64) float64 {
+		v /= 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.r) + 0.7152*channel(c.g) + 0.0722*channel(c.b)
+}
+
+func contrastRatio(a, b rgb) float64 {
+	la, lb := a.luminance(), b.luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
@@ -0,0 +1,49 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckContrast(t *testing.T) {
+	tokens := map[string]any{"colors": map[string]any{
+		"background": "#FFFFFF",
+		"text":       "#AAAAAA",
+		"primary":    "#0B1F4D",
+		"secondary":  "#999999",
+		"accent":     "#FFFF00",
+	}}
+	issues := CheckContrast(tokens)
+	require.Len(t, issues, 2)
+	assert.Equal(t, "text", issues[0].Token)
+	assert.Equal(t, 2.32, issues[0].Ratio)
+	assert.Equal(t, MinContrastText, issues[0].Min)
+	assert.Equal(t, "secondary", issues[1].Token)
+	assert.Equal(t, "tokens.colors.text #AAAAAA on background #FFFFFF has contrast 2.32:1, below the WCAG AA minimum of 4.5:1", issues[0].String())
+
+	assert.Empty(t, CheckContrast(map[string]any{"colors": map[string]any{"text": "#AAAAAA"}}), "no background to check against")
+	assert.Empty(t, CheckContrast(map[string]any{"colors": map[string]any{"background": "white", "text": "#FFFFFF"}}))
+	assert.Empty(t, CheckContrast(nil))
+}
+
+func TestAdjustContrast(t *testing.T) {
+	colors := map[string]any{"background": "#1A1A2E", "text": "#333344", "primary": "#2A2A6F", "secondary": "#EEEEEE"}
+	tokens := map[string]any{"colors": colors}
+
+	issues := AdjustContrast(tokens)
+	require.Len(t, issues, 2)
+	for _, issue := range issues {
+		assert.NotEmpty(t, issue.Adjusted)
+		assert.Equal(t, issue.Adjusted, colors[issue.Token])
+		fg, _ := parseHexColor(issue.Adjusted)
+		bg, _ := parseHexColor("#1A1A2E")
+		assert.GreaterOrEqual(t, contrastRatio(fg, bg), issue.Min, issue.Token)
+		assert.NotEqual(t, "#FFFFFF", issue.Adjusted, "lightened just enough, not to white")
+	}
+	assert.Equal(t, "#1A1A2E", colors["background"])
+	assert.Equal(t, "#EEEEEE", colors["secondary"])
+	assert.Empty(t, CheckContrast(tokens))
+	assert.Nil(t, AdjustContrast(tokens), "passing themes are left alone")
+}
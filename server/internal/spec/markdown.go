@@ -0,0 +1,263 @@
+package spec
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+var (
+	// markdownBlockStart matches text that Markdown would read as the start
+	// of a heading, list, quote, rule, table or code block, rather than as
+	// plain text.
+	markdownBlockStart = regexp.MustCompile("^([#>+*|=`~-])")
+	// markdownOrderedItem matches text Markdown would read as a numbered
+	// list item.
+	markdownOrderedItem = regexp.MustCompile(`^(\d+)([.)])`)
+	// bulletMarker matches a bullet the content was written with.
+	bulletMarker = regexp.MustCompile(`^([-*•–·▪‣]|\d+[.)])\s+`)
+	// markdownHeading matches an ATX heading, capturing its level and text.
+	markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)(\s+#+)?$`)
+	// markdownListItem matches a bullet or numbered list item's marker.
+	markdownListItem = regexp.MustCompile(`^([-*+]|\d+[.)])\s+`)
+	// markdownImage matches an inline image, capturing its alt text and
+	// link target; a quoted link title is left out of the target.
+	markdownImage = regexp.MustCompile(`!\[([^\]]*)\]\(\s*<?([^\s)>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	// markdownEscape matches a backslash-escaped punctuation character.
+	markdownEscape = regexp.MustCompile("\\\\([!-/:-@[-`{-~])")
+)
+
+// Markdown writes s as a Marp document, so a deck's content can go through
+// docs pipelines or be edited as plain text and imported again. Front matter
+// names the deck, "---" separates the slides, and each slide has its title
+// placeholder as a heading, subtitles as text and the lines of its other
+// text placeholders as bullets. Images are left out.
+func Markdown(s TemplateSpec, title string) string {
+	var b strings.Builder
+	b.WriteString("---\nmarp: true\n")
+	if title = strings.TrimSpace(title); title != "" {
+		// A JSON string is a valid YAML double-quoted scalar.
+		quoted, _ := json.Marshal(title)
+		b.WriteString("title: " + string(quoted) + "\n")
+	}
+	b.WriteString("paginate: true\n---\n")
+	for i, layout := range s.Layouts {
+		if i > 0 {
+			b.WriteString("\n---\n")
+		}
+		titleID := slideTitleID(layout)
+		var subtitles, bullets []string
+		for _, ph := range layout.Placeholders {
+			if (ph.Type != "" && ph.Type != "text") || ph.ID == titleID {
+				continue
+			}
+			lines := contentLines(ph.Content)
+			if strings.Contains(strings.ToLower(ph.ID), "subtitle") {
+				subtitles = append(subtitles, lines...)
+			} else {
+				bullets = append(bullets, lines...)
+			}
+		}
+		for _, ph := range layout.Placeholders {
+			if ph.ID == titleID {
+				if text := strings.Join(contentLines(ph.Content), " "); text != "" {
+					b.WriteString("\n# " + escapeMarkdownLine(text) + "\n")
+				}
+				break
+			}
+		}
+		if len(subtitles) > 0 {
+			b.WriteString("\n")
+			for _, line := range subtitles {
+				b.WriteString(escapeMarkdownLine(line) + "\n")
+			}
+		}
+		if len(bullets) > 0 {
+			b.WriteString("\n")
+			for _, line := range bullets {
+				b.WriteString("- " + escapeMarkdownLine(bulletMarker.ReplaceAllString(line, "")) + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// slideTitleID picks the layout's title placeholder: the first text
+// placeholder named like a title, else the first text placeholder with
+// content.
+func slideTitleID(layout Layout) string {
+	first := ""
+	for _, ph := range layout.Placeholders {
+		if ph.Type != "" && ph.Type != "text" {
+			continue
+		}
+		id := strings.ToLower(ph.ID)
+		if strings.Contains(id, "title") && !strings.Contains(id, "subtitle") {
+			return ph.ID
+		}
+		if first == "" && strings.TrimSpace(ph.Content) != "" {
+			first = ph.ID
+		}
+	}
+	return first
+}
+
+// contentLines splits placeholder content into its non-blank lines.
+func contentLines(content string) []string {
+	var out []string
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// escapeMarkdownLine keeps a line of content reading as text, escaping
+// characters Markdown would otherwise read as block markup.
+func escapeMarkdownLine(line string) string {
+	if markdownBlockStart.MatchString(line) {
+		return `\` + line
+	}
+	return markdownOrderedItem.ReplaceAllString(line, `$1\$2`)
+}
+
+// MarkdownDeck is a Markdown document read as slides.
+type MarkdownDeck struct {
+	// Title is the front matter's title, if it has one.
+	Title  string
+	Slides []MarkdownSlide
+}
+
+// MarkdownSlide is one slide of a MarkdownDeck.
+type MarkdownSlide struct {
+	Title string
+	// Lines are the slide's text other than its title: paragraphs, list
+	// items, quotes and lower headings, without their markup.
+	Lines  []string
+	Images []MarkdownImage
+}
+
+// MarkdownImage is an image a slide links to.
+type MarkdownImage struct {
+	Alt    string
+	Target string
+}
+
+// ParseMarkdown reads a Marp or plain Markdown document as slides, the
+// reverse of Markdown. Slides are separated by "---" lines; a document
+// without any is split at its top-level headings instead. Each slide's first
+// heading is its title. Code blocks, HTML comments (which carry Marp
+// directives) and slides with nothing on them are left out.
+func ParseMarkdown(doc string) MarkdownDeck {
+	doc = strings.TrimPrefix(strings.ReplaceAll(doc, "\r\n", "\n"), "\ufeff")
+	lines := strings.Split(doc, "\n")
+
+	var out MarkdownDeck
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == "---" {
+				out.Title = frontMatterTitle(lines[1:i])
+				lines = lines[i+1:]
+				break
+			}
+		}
+	}
+
+	// Drop code and comments first, so separators and headings inside them
+	// don't split slides.
+	var body []string
+	fenced, comment := false, false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fenced:
+			fenced = !strings.HasPrefix(trimmed, "```") && !strings.HasPrefix(trimmed, "~~~")
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fenced = true
+		case comment:
+			comment = !strings.Contains(trimmed, "-->")
+		case strings.HasPrefix(trimmed, "<!--"):
+			comment = !strings.Contains(trimmed, "-->")
+		default:
+			body = append(body, trimmed)
+		}
+	}
+
+	separated, top := false, 0
+	for _, line := range body {
+		if line == "---" {
+			separated = true
+		}
+		if m := markdownHeading.FindStringSubmatch(line); m != nil && (top == 0 || len(m[1]) < top) {
+			top = len(m[1])
+		}
+	}
+
+	var cur MarkdownSlide
+	flush := func() {
+		if cur.Title != "" || len(cur.Lines) > 0 || len(cur.Images) > 0 {
+			out.Slides = append(out.Slides, cur)
+		}
+		cur = MarkdownSlide{}
+	}
+	for _, line := range body {
+		if line == "" {
+			continue
+		}
+		if line == "---" && separated {
+			flush()
+			continue
+		}
+		if m := markdownHeading.FindStringSubmatch(line); m != nil {
+			if !separated && len(m[1]) == top {
+				flush()
+			}
+			if text := unescapeMarkdown(m[2]); cur.Title == "" && len(cur.Lines) == 0 {
+				cur.Title = text
+			} else if text != "" {
+				cur.Lines = append(cur.Lines, text)
+			}
+			continue
+		}
+		for _, m := range markdownImage.FindAllStringSubmatch(line, -1) {
+			cur.Images = append(cur.Images, MarkdownImage{Alt: unescapeMarkdown(m[1]), Target: m[2]})
+		}
+		line = strings.TrimSpace(markdownImage.ReplaceAllString(line, ""))
+		for strings.HasPrefix(line, ">") {
+			line = strings.TrimSpace(line[1:])
+		}
+		line = markdownListItem.ReplaceAllString(line, "")
+		if text := unescapeMarkdown(line); text != "" {
+			cur.Lines = append(cur.Lines, text)
+		}
+	}
+	flush()
+	return out
+}
+
+// frontMatterTitle reads the title key from YAML front matter lines.
+func frontMatterTitle(lines []string) string {
+	for _, line := range lines {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) != "title" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		var quoted string
+		if strings.HasPrefix(value, `"`) && json.Unmarshal([]byte(value), &quoted) == nil {
+			return strings.TrimSpace(quoted)
+		}
+		if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+		}
+		return strings.TrimSpace(value)
+	}
+	return ""
+}
+
+// unescapeMarkdown undoes escapeMarkdownLine and other backslash escapes,
+// and collapses whitespace.
+func unescapeMarkdown(text string) string {
+	return strings.Join(strings.Fields(markdownEscape.ReplaceAllString(text, "$1")), " ")
+}
@@ -0,0 +1,111 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdown(t *testing.T) {
+	s := TemplateSpec{Layouts: []Layout{
+		{Name: "Cover", Placeholders: []Placeholder{
+			{ID: "title", Type: "text", Content: "Q3  Review"},
+			{ID: "subtitle", Type: "text", Content: "# of deals\n\n2024. A good year"},
+			{ID: "logo", Type: "image", Content: "ignored"},
+		}},
+		{Name: "Content", Placeholders: []Placeholder{
+			{ID: "heading", Type: "text", Content: "Highlights"},
+			{ID: "body", Type: "text", Content: "• Revenue up 20%\n- Churn down\n1. Hired 12\n> quoted"},
+		}},
+		{Name: "Blank"},
+	}}
+	assert.Equal(t, `---
+marp: true
+title: "Sales \"Q3\""
+paginate: true
+---
+
+# Q3 Review
+
+\# of deals
+2024\. A good year
+
+---
+
+# Highlights
+
+- Revenue up 20%
+- Churn down
+- Hired 12
+- \> quoted
+
+---
+`, Markdown(s, ` Sales "Q3" `))
+
+	assert.Equal(t, "---\nmarp: true\npaginate: true\n---\n", Markdown(TemplateSpec{}, ""))
+}
+
+func TestParseMarkdown(t *testing.T) {
+	deck := ParseMarkdown("\ufeff---\r\nmarp: true\r\ntitle: \"Sales \\\"Q3\\\"\"\r\n---\r\n" + `
+<!-- _class: lead -->
+
+# Q3 Review
+
+\# of deals
+2024\. A good year
+
+---
+
+## Highlights ##
+
+- Revenue up 20%
+  * Churn down
+1. Hired 12
+> quoted
+
+![Team photo](https://example.com/team.png "The team") Caption
+
+` + "```\n---\n# not a slide\n```" + `
+
+---
+
+<!--
+speaker notes
+-->
+`)
+	assert.Equal(t, MarkdownDeck{
+		Title: `Sales "Q3"`,
+		Slides: []MarkdownSlide{
+			{Title: "Q3 Review", Lines: []string{"# of deals", "2024. A good year"}},
+			{
+				Title:  "Highlights",
+				Lines:  []string{"Revenue up 20%", "Churn down", "Hired 12", "quoted", "Caption"},
+				Images: []MarkdownImage{{Alt: "Team photo", Target: "https://example.com/team.png"}},
+			},
+		},
+	}, deck)
+
+	// Without separators, slides start at the top-level headings.
+	deck = ParseMarkdown("---\ntitle: 'It''s here'\n---\nIntro text\n## One\nfirst\n### Detail\n## Two\n")
+	assert.Equal(t, "It's here", deck.Title)
+	assert.Equal(t, []MarkdownSlide{
+		{Lines: []string{"Intro text"}},
+		{Title: "One", Lines: []string{"first", "Detail"}},
+		{Title: "Two"},
+	}, deck.Slides)
+
+	assert.Empty(t, ParseMarkdown("").Slides)
+}
+
+func TestParseMarkdown_RoundTrip(t *testing.T) {
+	s := TemplateSpec{Layouts: []Layout{
+		{Placeholders: []Placeholder{{ID: "title", Type: "text", Content: "#1 Priority"}, {ID: "body", Type: "text", Content: "- Ship it\n> 3. Then rest"}}},
+		{Placeholders: []Placeholder{{ID: "title", Type: "text", Content: "Close"}}},
+	}}
+	deck := ParseMarkdown(Markdown(s, "Plan"))
+	assert.Equal(t, "Plan", deck.Title)
+	assert.Equal(t, []MarkdownSlide{
+		{Title: "#1 Priority", Lines: []string{"Ship it", "> 3. Then rest"}},
+		{Title: "Close"},
+	}, deck.Slides)
+}
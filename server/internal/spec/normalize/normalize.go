@@ -0,0 +1,152 @@
+// Package normalize turns template and deck specs, in whatever form they
+// were stored or passed around, into one canonical form: raw JSON.
+//
+// Specs reach renderers and handlers as different Go types depending on
+// where they came from:
+//
+//	[]byte, json.RawMessage: a version's SpecJSON, or code that built the JSON
+//	string:                  a request body or a jsonb value read through pgx
+//	map, struct, ...:        Go code constructing a spec directly
+//
+// GORM used to write a []byte spec to a jsonb column by JSON-encoding it,
+// which made it a base64 string, so older rows and memory snapshots may hold
+// raw JSON, base64 (padded or not, standard or URL alphabet) or a JSON string
+// wrapping either. Load undoes all of these; Save gives the value to write so
+// that none of them happen again.
+package normalize
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+)
+
+// ErrNotJSON is returned for input that doesn't decode to a JSON document.
+var ErrNotJSON = errors.New("spec is not a JSON document")
+
+// SpecDocument is a spec as raw JSON. The zero value is the JSON null.
+type SpecDocument struct {
+	raw json.RawMessage
+}
+
+// Load reads a spec in any of the forms described in the package doc. A nil
+// spec, or a nil json.RawMessage, loads as null, as it would marshal.
+func Load(v any) (SpecDocument, error) {
+	var b []byte
+	switch t := v.(type) {
+	case SpecDocument:
+		return t, nil
+	case *SpecDocument:
+		return *t, nil
+	case nil:
+		return SpecDocument{}, nil
+	case []byte:
+		b = t
+	case json.RawMessage:
+		if t == nil {
+			return SpecDocument{}, nil
+		}
+		b = t
+	case string:
+		b = []byte(t)
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return SpecDocument{}, err
+		}
+		return SpecDocument{raw: raw}, nil
+	}
+	raw, ok := unwrap(bytes.TrimSpace(b))
+	if !ok {
+		return SpecDocument{}, ErrNotJSON
+	}
+	return SpecDocument{raw: raw}, nil
+}
+
+// JSON is Load followed by Bytes, for callers that just want the JSON.
+func JSON(v any) ([]byte, error) {
+	doc, err := Load(v)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Bytes(), nil
+}
+
+// unwrap finds the JSON document in b: b itself, the contents of a JSON
+// string, or base64-decoded text, in that order.
+func unwrap(b []byte) ([]byte, bool) {
+	if isDocument(b) {
+		return b, true
+	}
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return nil, false
+		}
+		b = bytes.TrimSpace([]byte(s))
+		if isDocument(b) {
+			return b, true
+		}
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		decoded, err := enc.DecodeString(string(b))
+		if err == nil {
+			if decoded = bytes.TrimSpace(decoded); isDocument(decoded) {
+				return decoded, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// isDocument reports whether b is a JSON object, array or null.
+func isDocument(b []byte) bool {
+	if len(b) == 0 || !json.Valid(b) {
+		return false
+	}
+	return b[0] == '{' || b[0] == '[' || string(b) == "null"
+}
+
+// Bytes returns the spec's JSON.
+func (d SpecDocument) Bytes() []byte {
+	if d.raw == nil {
+		return []byte("null")
+	}
+	return d.raw
+}
+
+// Save returns the value to store as a version's SpecJSON. It is a
+// json.RawMessage, which encodes as the JSON itself rather than base64.
+func (d SpecDocument) Save() json.RawMessage {
+	return json.RawMessage(d.Bytes())
+}
+
+// Decode unmarshals the spec into dst.
+func (d SpecDocument) Decode(dst any) error {
+	return json.Unmarshal(d.Bytes(), dst)
+}
+
+// TemplateSpec decodes the spec as a spec.TemplateSpec.
+func (d SpecDocument) TemplateSpec() (spec.TemplateSpec, error) {
+	var ts spec.TemplateSpec
+	err := d.Decode(&ts)
+	return ts, err
+}
+
+// MarshalJSON writes the spec as-is.
+func (d SpecDocument) MarshalJSON() ([]byte, error) {
+	return d.Bytes(), nil
+}
+
+// UnmarshalJSON keeps b as the spec, unwrapping a string-encoded one.
+func (d *SpecDocument) UnmarshalJSON(b []byte) error {
+	doc, err := Load(json.RawMessage(b))
+	if err != nil {
+		return err
+	}
+	*d = doc
+	return nil
+}
@@ -0,0 +1,164 @@
+package normalize
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+)
+
+// specJSON has characters that base64 encodes as '+' and '/', so the URL
+// alphabet variants differ from the standard ones.
+const specJSON = `{"layouts":[{"name":"title","placeholders":[{"id":"t","type":"text","content":"Growth ~ 3x?"}]}]}`
+
+// gormWrite is what a jsonb column holds after GORM writes b: json.Marshal
+// of a []byte, a quoted base64 string.
+func gormWrite(t *testing.T, b []byte) []byte {
+	t.Helper()
+	out, err := json.Marshal(b)
+	require.NoError(t, err)
+	return out
+}
+
+// pgxRead is what pgx hands back for a jsonb string: the string unquoted.
+func pgxRead(t *testing.T, column []byte) string {
+	t.Helper()
+	var s string
+	require.NoError(t, json.Unmarshal(column, &s))
+	return s
+}
+
+func TestLoad_Variants(t *testing.T) {
+	std := base64.StdEncoding.EncodeToString([]byte(specJSON))
+	require.True(t, strings.ContainsAny(std, "+/"), "the URL alphabet must differ for these cases to mean anything")
+	quoted, err := json.Marshal(specJSON)
+	require.NoError(t, err)
+
+	for name, input := range map[string]any{
+		"string from pgx":            specJSON,
+		"[]byte":                     []byte(specJSON),
+		"json.RawMessage":            json.RawMessage(specJSON),
+		"surrounding whitespace":     "\n  " + specJSON + "\t\n",
+		"quoted JSON string":         string(quoted),
+		"quoted JSON []byte":         quoted,
+		"base64 from pgx":            pgxRead(t, gormWrite(t, []byte(specJSON))),
+		"quoted base64 (jsonb text)": string(gormWrite(t, []byte(specJSON))),
+		"base64 []byte":              []byte(std),
+		"base64 without padding":     strings.TrimRight(std, "="),
+		"URL base64":                 base64.URLEncoding.EncodeToString([]byte(specJSON)),
+		"URL base64 without padding": base64.RawURLEncoding.EncodeToString([]byte(specJSON)),
+		"SpecDocument":               SpecDocument{raw: json.RawMessage(specJSON)},
+		"*SpecDocument":              &SpecDocument{raw: json.RawMessage(specJSON)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			doc, err := Load(input)
+			require.NoError(t, err)
+			assert.Equal(t, specJSON, string(doc.Bytes()))
+		})
+	}
+}
+
+func TestLoad_GoValues(t *testing.T) {
+	var ts spec.TemplateSpec
+	require.NoError(t, json.Unmarshal([]byte(specJSON), &ts))
+	var m map[string]any
+	require.NoError(t, json.Unmarshal([]byte(specJSON), &m))
+
+	for name, input := range map[string]any{"map": m, "TemplateSpec": ts, "*TemplateSpec": &ts} {
+		t.Run(name, func(t *testing.T) {
+			doc, err := Load(input)
+			require.NoError(t, err)
+			got, err := doc.TemplateSpec()
+			require.NoError(t, err)
+			assert.Equal(t, ts, got)
+		})
+	}
+
+	doc, err := Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(doc.Bytes()))
+	doc, err = Load(json.RawMessage(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(doc.Bytes()))
+	assert.Equal(t, "null", string(SpecDocument{}.Bytes()))
+}
+
+func TestLoad_Rejects(t *testing.T) {
+	for name, input := range map[string]any{
+		"empty string":    "",
+		"whitespace":      "   ",
+		"empty []byte":    []byte{},
+		"plain text":      "not a spec",
+		"JSON string":     `"just a string"`,
+		"JSON number":     "42",
+		"truncated JSON":  `{"layouts":[`,
+		"base64 of text":  base64.StdEncoding.EncodeToString([]byte("hello")),
+		"base64 of array": base64.StdEncoding.EncodeToString([]byte("[1,")),
+		"unmarshalable":   map[string]any{"f": func() {}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(input)
+			assert.Error(t, err)
+		})
+	}
+	_, err := JSON("not a spec")
+	assert.ErrorIs(t, err, ErrNotJSON)
+}
+
+// TestSave_RoundTrip follows a spec through a Postgres write and read: what
+// Save gives is stored without base64, and whatever pgx returns loads back
+// to the same JSON, however many times it goes around.
+func TestSave_RoundTrip(t *testing.T) {
+	for name, input := range map[string]any{
+		"[]byte":          []byte(specJSON),
+		"string":          specJSON,
+		"base64 from pgx": pgxRead(t, gormWrite(t, []byte(specJSON))),
+	} {
+		t.Run(name, func(t *testing.T) {
+			value := input
+			for range 3 {
+				doc, err := Load(value)
+				require.NoError(t, err)
+				saved := doc.Save()
+
+				column, err := json.Marshal(saved)
+				require.NoError(t, err)
+				assert.Equal(t, specJSON, string(column), "Save must not be base64-encoded on write")
+
+				// pgx returns a jsonb object as its text.
+				value = string(column)
+			}
+			got, err := JSON(value)
+			require.NoError(t, err)
+			assert.Equal(t, specJSON, string(got))
+		})
+	}
+}
+
+func TestSpecDocument_JSON(t *testing.T) {
+	type version struct {
+		Spec SpecDocument `json:"spec"`
+	}
+	doc, err := Load([]byte(specJSON))
+	require.NoError(t, err)
+
+	b, err := json.Marshal(version{Spec: doc})
+	require.NoError(t, err)
+	assert.Equal(t, `{"spec":`+specJSON+`}`, string(b))
+
+	var got version
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, specJSON, string(got.Spec.Bytes()))
+
+	// A spec that was stored as a base64 string still decodes.
+	require.NoError(t, json.Unmarshal([]byte(`{"spec":`+string(gormWrite(t, []byte(specJSON)))+`}`), &got))
+	assert.Equal(t, specJSON, string(got.Spec.Bytes()))
+
+	var m map[string]any
+	require.NoError(t, doc.Decode(&m))
+	assert.Contains(t, m, "layouts")
+}
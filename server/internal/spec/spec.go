@@ -1,6 +1,9 @@
 package spec
 
 type TemplateSpec struct {
+	// SpecVersion is the schema version the spec was written in; see
+	// CurrentVersion.
+	SpecVersion int            `json:"specVersion,omitempty"`
 	Tokens      map[string]any `json:"tokens"`
 	Constraints Constraints    `json:"constraints"`
 	Layouts     []Layout       `json:"layouts"`
@@ -13,6 +16,9 @@ type Constraints struct {
 type Layout struct {
 	Name         string        `json:"name"`
 	Placeholders []Placeholder `json:"placeholders"`
+	// ReadingOrder lists placeholder IDs in the order screen readers should
+	// read them; placeholders it leaves out follow in their own order.
+	ReadingOrder []string `json:"readingOrder,omitempty"`
 }
 
 type Placeholder struct {
@@ -20,6 +26,32 @@ type Placeholder struct {
 	Type     string   `json:"type,omitempty"`
 	Content  string   `json:"content,omitempty"`
 	Geometry Geometry `json:"geometry"`
+	Image    *Image   `json:"image,omitempty"`
+}
+
+// Image is the picture an "image" placeholder shows: a stored object and,
+// for stock media, where it came from.
+type Image struct {
+	Path string `json:"path"`
+	Mime string `json:"mime,omitempty"`
+	// Alt describes the picture for screen readers. Decorative pictures,
+	// which add nothing to the slide's meaning, need none and are skipped.
+	Alt         string       `json:"alt,omitempty"`
+	Decorative  bool         `json:"decorative,omitempty"`
+	Provider    string       `json:"provider,omitempty"`
+	SourceID    string       `json:"sourceId,omitempty"`
+	Attribution *Attribution `json:"attribution,omitempty"`
+}
+
+// Attribution credits a stock image the way its provider's license asks.
+type Attribution struct {
+	Text       string `json:"text"`
+	Author     string `json:"author,omitempty"`
+	AuthorURL  string `json:"authorUrl,omitempty"`
+	Source     string `json:"source"`
+	SourceURL  string `json:"sourceUrl,omitempty"`
+	License    string `json:"license,omitempty"`
+	LicenseURL string `json:"licenseUrl,omitempty"`
 }
 
 type Geometry struct {
@@ -11,6 +11,12 @@ type DefaultValidator struct{}
 func (v DefaultValidator) Validate(spec TemplateSpec) []ValidationError {
 	var errors []ValidationError
 
+	if spec.SpecVersion > CurrentVersion {
+		errors = append(errors, ValidationError{Path: "$.specVersion", Message: (&FutureVersionError{Version: spec.SpecVersion}).Error()})
+	} else if spec.SpecVersion < 0 {
+		errors = append(errors, ValidationError{Path: "$.specVersion", Message: "specVersion must be a whole number of at least 1"})
+	}
+
 	if spec.Tokens == nil {
 		errors = append(errors, ValidationError{Path: "$.tokens", Message: "tokens is required"})
 	}
@@ -64,6 +70,21 @@ func (v DefaultValidator) Validate(spec TemplateSpec) []ValidationError {
 			rects = append(rects, rect{x: x, y: y, w: w, h: h, id: placeholder.ID})
 		}
 
+		ids := make(map[string]bool, len(layout.Placeholders))
+		for _, placeholder := range layout.Placeholders {
+			ids[placeholder.ID] = true
+		}
+		read := map[string]bool{}
+		for i, id := range layout.ReadingOrder {
+			orderPath := fmt.Sprintf("%s.readingOrder[%d]", layoutPath, i)
+			if !ids[id] {
+				errors = append(errors, ValidationError{Path: orderPath, Message: fmt.Sprintf("no placeholder has id %q", id)})
+			} else if read[id] {
+				errors = append(errors, ValidationError{Path: orderPath, Message: fmt.Sprintf("%s is listed more than once", id)})
+			}
+			read[id] = true
+		}
+
 		for i := 0; i < len(rects); i++ {
 			for j := i + 1; j < len(rects); j++ {
 				if rectsOverlap(rects[i], rects[j]) {
@@ -350,3 +350,27 @@ func TestRectsOverlap(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultValidator_ReadingOrder(t *testing.T) {
+	v := DefaultValidator{}
+
+	s := TemplateSpec{
+		Tokens: map[string]any{},
+		Layouts: []Layout{{
+			Name: "Title",
+			Placeholders: []Placeholder{
+				{ID: "title", Geometry: Geometry{X: 0.1, Y: 0.2, W: 0.8, H: 0.2}},
+				{ID: "subtitle", Geometry: Geometry{X: 0.1, Y: 0.45, W: 0.8, H: 0.15}},
+			},
+			ReadingOrder: []string{"subtitle", "title"},
+		}},
+	}
+	assert.Empty(t, v.Validate(s))
+
+	s.Layouts[0].ReadingOrder = []string{"title", "body", "title"}
+	errs := v.Validate(s)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "$.layouts[0].readingOrder[1]", errs[0].Path)
+	assert.Equal(t, `no placeholder has id "body"`, errs[0].Message)
+	assert.Equal(t, "$.layouts[0].readingOrder[2]", errs[1].Path)
+}
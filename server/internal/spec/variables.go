@@ -0,0 +1,78 @@
+package spec
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// variableName is the name in a {{name}} variable: a letter or underscore,
+// then letters, digits, underscores, dots and dashes, so data can be
+// namespaced like {{customer.name}}.
+const variableName = `[A-Za-z_][A-Za-z0-9_.\-]*`
+
+// ConnectorPrefix starts the variables bound to an org's data connectors,
+// like {{connector.sales.revenue}}. They are filled with live data when a
+// deck renders rather than from export data.
+const ConnectorPrefix = "connector."
+
+var (
+	variablePattern = regexp.MustCompile(`\{\{\s*(` + variableName + `)\s*\}\}`)
+	variableNameRE  = regexp.MustCompile(`^` + variableName + `$`)
+)
+
+// IsVariableName reports whether name can be written as a {{name}} variable.
+func IsVariableName(name string) bool {
+	return variableNameRE.MatchString(name)
+}
+
+// Variables lists the {{name}} variables used by the strings of doc, a
+// decoded JSON spec, sorted and without repeats.
+func Variables(doc any) []string {
+	var names []string
+	walkStrings(doc, func(s string) string {
+		for _, m := range variablePattern.FindAllStringSubmatch(s, -1) {
+			names = append(names, m[1])
+		}
+		return s
+	})
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// MergeVariables replaces each {{name}} variable in the strings of doc, a
+// decoded JSON spec, with data[name], in place, and returns doc. Variables
+// data has no value for are left as they are.
+func MergeVariables(doc any, data map[string]string) any {
+	return walkStrings(doc, func(s string) string {
+		return variablePattern.ReplaceAllStringFunc(s, func(v string) string {
+			if value, ok := data[variablePattern.FindStringSubmatch(v)[1]]; ok {
+				return value
+			}
+			return v
+		})
+	})
+}
+
+// IsConnectorVariable reports whether name is bound to a data connector.
+func IsConnectorVariable(name string) bool {
+	return strings.HasPrefix(name, ConnectorPrefix)
+}
+
+// walkStrings replaces every string value in doc with f of it. Object keys
+// are left alone.
+func walkStrings(doc any, f func(string) string) any {
+	switch v := doc.(type) {
+	case string:
+		return f(v)
+	case map[string]any:
+		for k, e := range v {
+			v[k] = walkStrings(e, f)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = walkStrings(e, f)
+		}
+	}
+	return doc
+}
@@ -0,0 +1,37 @@
+package spec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeVariables(t *testing.T) {
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"layouts": [{"name": "Title", "placeholders": [
+			{"id": "title", "content": "Proposal for {{ customer.name }}"},
+			{"id": "body", "content": "{{customer.name}} saves {{savings}} a year, {{unknown}} stays"}
+		]}],
+		"tokens": {"colors": {"primary": "#112233"}}
+	}`), &doc))
+
+	assert.Equal(t, []string{"customer.name", "savings", "unknown"}, Variables(doc))
+
+	merged := MergeVariables(doc, map[string]string{"customer.name": "Acme", "savings": "$2M", "unused": "x"})
+	placeholders := merged.(map[string]any)["layouts"].([]any)[0].(map[string]any)["placeholders"].([]any)
+	assert.Equal(t, "Proposal for Acme", placeholders[0].(map[string]any)["content"])
+	assert.Equal(t, "Acme saves $2M a year, {{unknown}} stays", placeholders[1].(map[string]any)["content"])
+	assert.Equal(t, []string{"unknown"}, Variables(merged))
+}
+
+func TestIsVariableName(t *testing.T) {
+	for _, name := range []string{"name", "customer.name", "_id", "deal-size2"} {
+		assert.True(t, IsVariableName(name), name)
+	}
+	for _, name := range []string{"", "2fast", "first name", "a}}{{b"} {
+		assert.False(t, IsVariableName(name), name)
+	}
+}
@@ -0,0 +1,119 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// CurrentVersion is the spec schema version this server reads and writes.
+// Specs without a specVersion are version 1.
+//
+// To change the schema, bump CurrentVersion and append the migration from
+// the old version to migrations, so specs stored before the change are
+// upgraded as they are read.
+const CurrentVersion = 2
+
+// Migration upgrades a decoded spec by one version, in place.
+type Migration func(doc map[string]any) error
+
+// migrations[i] upgrades a spec from version i+1 to i+2.
+var migrations = []Migration{
+	typeTextPlaceholders,
+}
+
+// FutureVersionError is a spec written by a newer server than this one,
+// which can't read it without risking losing what it doesn't know about.
+type FutureVersionError struct {
+	Version int
+}
+
+func (e *FutureVersionError) Error() string {
+	return fmt.Sprintf("spec version %d is newer than this server supports (up to %d)", e.Version, CurrentVersion)
+}
+
+// Version returns the schema version of a decoded spec. A spec whose
+// specVersion is not a whole number of at least 1 is an error; one newer
+// than CurrentVersion is a FutureVersionError.
+func Version(doc map[string]any) (int, error) {
+	v, ok := doc["specVersion"]
+	if !ok || v == nil {
+		return 1, nil
+	}
+	var f float64
+	switch n := v.(type) {
+	case float64:
+		f = n
+	case json.Number:
+		var err error
+		if f, err = n.Float64(); err != nil {
+			return 0, fmt.Errorf("specVersion must be a number")
+		}
+	default:
+		return 0, fmt.Errorf("specVersion must be a number")
+	}
+	if f < 1 || f != math.Trunc(f) {
+		return 0, fmt.Errorf("specVersion must be a whole number of at least 1")
+	}
+	if f > CurrentVersion {
+		return 0, &FutureVersionError{Version: int(min(f, math.MaxInt32))}
+	}
+	return int(f), nil
+}
+
+// CheckVersion fails when the spec JSON in raw has a specVersion this
+// server can't read. Anything that isn't a JSON object passes; it is left
+// to the spec's other checks.
+func CheckVersion(raw []byte) error {
+	var doc map[string]any
+	if json.Unmarshal(raw, &doc) != nil || doc == nil {
+		return nil
+	}
+	_, err := Version(doc)
+	return err
+}
+
+// Migrate upgrades the spec JSON in raw to CurrentVersion. A spec that is
+// already current, or isn't a JSON object, is returned as it is. A spec
+// from a newer server fails with a FutureVersionError.
+func Migrate(raw []byte) ([]byte, error) {
+	var doc map[string]any
+	if json.Unmarshal(raw, &doc) != nil || doc == nil {
+		return raw, nil
+	}
+	from, err := Version(doc)
+	if err != nil {
+		return nil, err
+	}
+	if from == CurrentVersion {
+		return raw, nil
+	}
+	for v := from; v < CurrentVersion; v++ {
+		if err := migrations[v-1](doc); err != nil {
+			return nil, fmt.Errorf("migrate spec from version %d: %w", v, err)
+		}
+	}
+	doc["specVersion"] = CurrentVersion
+	return json.Marshal(doc)
+}
+
+// typeTextPlaceholders is the migration to version 2, which spells out
+// the type of text placeholders. Version 1 left it out, and not every
+// reader took a missing type to mean text.
+func typeTextPlaceholders(doc map[string]any) error {
+	layouts, _ := doc["layouts"].([]any)
+	for _, l := range layouts {
+		layout, _ := l.(map[string]any)
+		placeholders, _ := layout["placeholders"].([]any)
+		for _, p := range placeholders {
+			ph, ok := p.(map[string]any)
+			if !ok {
+				continue
+			}
+			if typ, _ := ph["type"].(string); typ == "" {
+				ph["type"] = "text"
+			}
+		}
+	}
+	return nil
+}
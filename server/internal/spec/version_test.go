@@ -0,0 +1,56 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations_CoverEveryVersion(t *testing.T) {
+	assert.Len(t, migrations, CurrentVersion-1, "each version up to CurrentVersion needs a migration from the one before")
+}
+
+func TestMigrate(t *testing.T) {
+	out, err := Migrate([]byte(`{"tokens":{},"layouts":[{"name":"A","placeholders":[{"id":"title"},{"id":"photo","type":"image"},{"id":"body","type":""}]}]}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"specVersion":2,"tokens":{},"layouts":[{"name":"A","placeholders":[{"id":"title","type":"text"},{"id":"photo","type":"image"},{"id":"body","type":"text"}]}]}`, string(out))
+
+	current := []byte(`{"specVersion":2,"layouts":[{"placeholders":[{"id":"title"}]}]}`)
+	out, err = Migrate(current)
+	require.NoError(t, err)
+	assert.Equal(t, current, out, "current specs are left as they are")
+
+	for _, raw := range []string{`null`, `[]`, `"not an object"`} {
+		out, err = Migrate([]byte(raw))
+		require.NoError(t, err)
+		assert.Equal(t, raw, string(out))
+	}
+
+	_, err = Migrate([]byte(`{"specVersion":3}`))
+	var future *FutureVersionError
+	require.ErrorAs(t, err, &future)
+	assert.Equal(t, 3, future.Version)
+	assert.EqualError(t, err, "spec version 3 is newer than this server supports (up to 2)")
+}
+
+func TestCheckVersion(t *testing.T) {
+	assert.NoError(t, CheckVersion([]byte(`{"layouts":[]}`)))
+	assert.NoError(t, CheckVersion([]byte(`{"specVersion":1}`)))
+	assert.NoError(t, CheckVersion([]byte(`{"specVersion":2}`)))
+	assert.NoError(t, CheckVersion([]byte(`not json`)))
+	assert.Error(t, CheckVersion([]byte(`{"specVersion":3}`)))
+	assert.Error(t, CheckVersion([]byte(`{"specVersion":0}`)))
+	assert.Error(t, CheckVersion([]byte(`{"specVersion":1.5}`)))
+	assert.Error(t, CheckVersion([]byte(`{"specVersion":"2"}`)))
+}
+
+func TestValidate_RejectsFutureSpecVersion(t *testing.T) {
+	s := TemplateSpec{SpecVersion: CurrentVersion + 1, Tokens: map[string]any{}, Layouts: []Layout{{Name: "A", Placeholders: []Placeholder{{ID: "t", Geometry: Geometry{X: 0.1, Y: 0.1, W: 0.5, H: 0.2}}}}}}
+	errs := DefaultValidator{}.Validate(s)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "$.specVersion", errs[0].Path)
+
+	s.SpecVersion = CurrentVersion
+	assert.Empty(t, DefaultValidator{}.Validate(s))
+}
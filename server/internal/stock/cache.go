@@ -0,0 +1,114 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// maxImageBytes caps a downloaded stock image.
+const maxImageBytes = 15 << 20
+
+// unsafeKeyChars are replaced in provider IDs before they become object
+// keys.
+var unsafeKeyChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Cache keeps the stock images decks use in object storage, so renders and
+// viewers don't depend on the provider and each image is downloaded once.
+// Images are shared between orgs: they're public and keyed by provider ID.
+type Cache struct {
+	Client  *Client
+	Objects assets.ObjectStorage
+}
+
+// ObjectKey is where r's image is cached.
+func ObjectKey(r Result) string {
+	ext := "jpg"
+	if r.Kind == KindIcon {
+		ext = "svg"
+	}
+	return fmt.Sprintf("stock/%s/%s.%s", r.Provider, unsafeKeyChars.ReplaceAllString(r.ID, "_"), ext)
+}
+
+func mimeFor(kind Kind) string {
+	if kind == KindIcon {
+		return "image/svg+xml"
+	}
+	return "image/jpeg"
+}
+
+// Fetch caches r's image if it isn't already and returns the placeholder
+// image for it, credited as the provider requires.
+func (c *Cache) Fetch(ctx context.Context, org *store.StockMediaKeys, r Result) (spec.Image, error) {
+	key := ObjectKey(r)
+	cached, err := c.Objects.Exists(ctx, key)
+	if err != nil {
+		return spec.Image{}, fmt.Errorf("check cache: %w", err)
+	}
+	if !cached {
+		if r.downloadURL == "" {
+			return spec.Image{}, fmt.Errorf("%s %s has no download URL", r.Provider, r.ID)
+		}
+		data, err := c.download(ctx, r.downloadURL)
+		if err != nil {
+			return spec.Image{}, err
+		}
+		if _, err := c.Objects.Upload(ctx, key, data, mimeFor(r.Kind)); err != nil {
+			return spec.Image{}, fmt.Errorf("cache image: %w", err)
+		}
+	}
+	if r.trackURL != "" {
+		c.track(ctx, org, r)
+	}
+
+	attribution := r.Attribution
+	return spec.Image{
+		Path:        key,
+		Mime:        mimeFor(r.Kind),
+		Alt:         r.Description,
+		Provider:    r.Provider,
+		SourceID:    r.ID,
+		Attribution: &attribution,
+	}, nil
+}
+
+func (c *Cache) download(ctx context.Context, src string) ([]byte, error) {
+	resp, err := c.Client.get(ctx, src, "")
+	if err != nil {
+		return nil, fmt.Errorf("download image: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download image: %s responded %d", hostOf(src), resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("download image: %w", err)
+	}
+	if len(data) > maxImageBytes {
+		return nil, fmt.Errorf("image exceeds %d MB", maxImageBytes>>20)
+	}
+	return data, nil
+}
+
+// track reports a use of an Unsplash photo. It is best effort: a missed
+// ping shouldn't keep the image out of the deck.
+func (c *Cache) track(ctx context.Context, org *store.StockMediaKeys, r Result) {
+	if !strings.HasPrefix(r.trackURL, c.Client.UnsplashBase) {
+		return
+	}
+	resp, err := c.Client.get(ctx, r.trackURL, "Client-ID "+c.Client.keys(org).UnsplashAccessKey)
+	if err != nil {
+		logger.Logger.Warn("stock_track_failed", "component", "stock", "provider", r.Provider, "id", r.ID, "error", err)
+		return
+	}
+	resp.Body.Close()
+}
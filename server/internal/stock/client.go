@@ -0,0 +1,249 @@
+// Package stock searches stock photo and icon libraries (Unsplash, Pexels
+// and Iconify), caches the images decks use in object storage and picks
+// images for generated slides.
+package stock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// Providers a search or an image can come from.
+const (
+	ProviderUnsplash = "unsplash"
+	ProviderPexels   = "pexels"
+	ProviderIconify  = "iconify"
+)
+
+// Kind is what a search looks for.
+type Kind string
+
+const (
+	KindPhoto Kind = "photo"
+	KindIcon  Kind = "icon"
+)
+
+const (
+	defaultUnsplashBase = "https://api.unsplash.com"
+	defaultPexelsBase   = "https://api.pexels.com"
+	defaultIconifyBase  = "https://api.iconify.design"
+
+	defaultLimit = 10
+	maxLimit     = 30
+)
+
+var (
+	// ErrNotConfigured means no provider for the requested kind has an API
+	// key, from the org or the server.
+	ErrNotConfigured = errors.New("no stock photo provider is configured")
+	// ErrUnknownProvider means the provider doesn't exist or doesn't serve
+	// the requested kind.
+	ErrUnknownProvider = errors.New("unknown stock media provider")
+	// ErrNotFound means the provider has no image with the requested ID.
+	ErrNotFound = errors.New("stock image not found")
+)
+
+// Result is one search hit.
+type Result struct {
+	Provider    string           `json:"provider"`
+	ID          string           `json:"id"`
+	Kind        Kind             `json:"kind"`
+	Description string           `json:"description,omitempty"`
+	Width       int              `json:"width,omitempty"`
+	Height      int              `json:"height,omitempty"`
+	PreviewURL  string           `json:"previewUrl"`
+	Attribution spec.Attribution `json:"attribution"`
+
+	// downloadURL is the file Cache stores. trackURL, when set, must be
+	// requested each time the image is used (Unsplash's API terms).
+	downloadURL string
+	trackURL    string
+}
+
+// Query is a search request. An empty Provider uses the first configured
+// provider for Kind.
+type Query struct {
+	Text     string
+	Kind     Kind
+	Provider string
+	Limit    int
+}
+
+type Client struct {
+	UnsplashBase string
+	PexelsBase   string
+	IconifyBase  string
+	HTTPClient   *http.Client
+	// Keys are the server's API keys, used for providers the org has no key
+	// of its own for.
+	Keys store.StockMediaKeys
+}
+
+func NewClient(keys store.StockMediaKeys) *Client {
+	return &Client{
+		UnsplashBase: defaultUnsplashBase,
+		PexelsBase:   defaultPexelsBase,
+		IconifyBase:  defaultIconifyBase,
+		HTTPClient:   &http.Client{Timeout: 15 * time.Second},
+		Keys:         keys,
+	}
+}
+
+// keys merges org's keys over the server's.
+func (c *Client) keys(org *store.StockMediaKeys) store.StockMediaKeys {
+	k := c.Keys
+	if org != nil {
+		if org.UnsplashAccessKey != "" {
+			k.UnsplashAccessKey = org.UnsplashAccessKey
+		}
+		if org.PexelsAPIKey != "" {
+			k.PexelsAPIKey = org.PexelsAPIKey
+		}
+	}
+	return k
+}
+
+// Providers lists the providers that can serve kind with org's keys, in
+// the order searches prefer them. Iconify needs no key.
+func (c *Client) Providers(org *store.StockMediaKeys, kind Kind) []string {
+	if kind == KindIcon {
+		return []string{ProviderIconify}
+	}
+	k := c.keys(org)
+	var out []string
+	if k.UnsplashAccessKey != "" {
+		out = append(out, ProviderUnsplash)
+	}
+	if k.PexelsAPIKey != "" {
+		out = append(out, ProviderPexels)
+	}
+	return out
+}
+
+// provider resolves the provider to use for kind.
+func (c *Client) provider(org *store.StockMediaKeys, kind Kind, name string) (string, error) {
+	if kind != KindPhoto && kind != KindIcon {
+		return "", fmt.Errorf("kind must be %q or %q", KindPhoto, KindIcon)
+	}
+	available := c.Providers(org, kind)
+	if name == "" {
+		if len(available) == 0 {
+			return "", ErrNotConfigured
+		}
+		return available[0], nil
+	}
+	switch {
+	case slices.Contains(available, name):
+		return name, nil
+	case kind == KindPhoto && (name == ProviderUnsplash || name == ProviderPexels):
+		return "", fmt.Errorf("%w: %s has no API key", ErrNotConfigured, name)
+	default:
+		return "", fmt.Errorf("%w: %s for %ss", ErrUnknownProvider, name, kind)
+	}
+}
+
+// Search runs q against one provider.
+func (c *Client) Search(ctx context.Context, org *store.StockMediaKeys, q Query) ([]Result, error) {
+	if q.Kind == "" {
+		q.Kind = KindPhoto
+	}
+	provider, err := c.provider(org, q.Kind, q.Provider)
+	if err != nil {
+		return nil, err
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	limit = min(limit, maxLimit)
+	text := strings.TrimSpace(q.Text)
+	if text == "" {
+		return nil, errors.New("search text is required")
+	}
+
+	k := c.keys(org)
+	switch provider {
+	case ProviderUnsplash:
+		return c.searchUnsplash(ctx, k.UnsplashAccessKey, text, limit)
+	case ProviderPexels:
+		return c.searchPexels(ctx, k.PexelsAPIKey, text, limit)
+	default:
+		return c.searchIconify(ctx, text, limit)
+	}
+}
+
+// Get looks up one image by the ID a search returned.
+func (c *Client) Get(ctx context.Context, org *store.StockMediaKeys, provider, id string) (Result, error) {
+	kind := KindPhoto
+	if provider == ProviderIconify {
+		kind = KindIcon
+	}
+	if _, err := c.provider(org, kind, provider); err != nil {
+		return Result{}, err
+	}
+	k := c.keys(org)
+	switch provider {
+	case ProviderUnsplash:
+		return c.getUnsplash(ctx, k.UnsplashAccessKey, id)
+	case ProviderPexels:
+		return c.getPexels(ctx, k.PexelsAPIKey, id)
+	default:
+		return c.getIconify(ctx, id)
+	}
+}
+
+// getJSON GETs endpoint and decodes the response into out. A 404 is
+// ErrNotFound.
+func (c *Client) getJSON(ctx context.Context, endpoint, authorization string, out any) error {
+	resp, err := c.get(ctx, endpoint, authorization)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return fmt.Errorf("%s responded %d: %s", hostOf(endpoint), resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(out); err != nil {
+		return fmt.Errorf("decode %s response: %w", hostOf(endpoint), err)
+	}
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, endpoint, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	// Transport errors quote the URL; keep them short and free of queries.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return nil, fmt.Errorf("request %s: %w", hostOf(endpoint), urlErr.Err)
+	}
+	return resp, err
+}
+
+func hostOf(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "stock provider"
+}
@@ -0,0 +1,159 @@
+package stock
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// fakeProviders serves the parts of the Unsplash, Pexels and Iconify APIs
+// the client uses, counting image downloads and Unsplash use pings.
+type fakeProviders struct {
+	*httptest.Server
+	auth      map[string]string
+	downloads atomic.Int32
+	tracked   atomic.Int32
+}
+
+func newFakeProviders(t *testing.T) *fakeProviders {
+	f := &fakeProviders{auth: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /unsplash/search/photos", func(w http.ResponseWriter, r *http.Request) {
+		f.auth["unsplash"] = r.Header.Get("Authorization")
+		writeFakeJSON(w, map[string]any{"results": []any{map[string]any{
+			"id": "abc", "width": 4000, "height": 3000, "alt_description": "team meeting",
+			"urls":  map[string]any{"regular": f.URL + "/files/abc.jpg", "small": f.URL + "/files/abc-small.jpg"},
+			"links": map[string]any{"html": "https://unsplash.com/photos/abc", "download_location": f.URL + "/unsplash/photos/abc/download"},
+			"user":  map[string]any{"name": "Ada", "links": map[string]any{"html": "https://unsplash.com/@ada"}},
+		}}})
+	})
+	mux.HandleFunc("GET /unsplash/photos/abc/download", func(w http.ResponseWriter, r *http.Request) {
+		f.tracked.Add(1)
+		writeFakeJSON(w, map[string]any{"url": f.URL + "/files/abc.jpg"})
+	})
+	mux.HandleFunc("GET /pexels/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		f.auth["pexels"] = r.Header.Get("Authorization")
+		writeFakeJSON(w, map[string]any{"photos": []any{map[string]any{
+			"id": 42, "width": 1200, "height": 800, "url": "https://www.pexels.com/photo/42/",
+			"photographer": "Lin", "photographer_url": "https://www.pexels.com/@lin", "alt": "city skyline",
+			"src": map[string]any{"large2x": f.URL + "/files/42.jpg", "medium": f.URL + "/files/42-medium.jpg"},
+		}}})
+	})
+	mux.HandleFunc("GET /iconify/search", func(w http.ResponseWriter, r *http.Request) {
+		writeFakeJSON(w, map[string]any{
+			"icons": []string{"mdi:rocket-launch", "not an id"},
+			"collections": map[string]any{"mdi": map[string]any{
+				"name":    "Material Design Icons",
+				"author":  map[string]any{"name": "Pictogrammers", "url": "https://github.com/Templarian/MaterialDesign"},
+				"license": map[string]any{"title": "Apache 2.0", "url": "https://github.com/Templarian/MaterialDesign/blob/master/LICENSE"},
+			}},
+		})
+	})
+	mux.HandleFunc("GET /files/", func(w http.ResponseWriter, r *http.Request) {
+		f.downloads.Add(1)
+		_, _ = w.Write([]byte("image bytes"))
+	})
+	mux.HandleFunc("GET /iconify/mdi/rocket-launch.svg", func(w http.ResponseWriter, r *http.Request) {
+		f.downloads.Add(1)
+		_, _ = w.Write([]byte("<svg/>"))
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func writeFakeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeProviders) client(keys store.StockMediaKeys) *Client {
+	c := NewClient(keys)
+	c.UnsplashBase, c.PexelsBase, c.IconifyBase = f.URL+"/unsplash", f.URL+"/pexels", f.URL+"/iconify"
+	return c
+}
+
+func TestSearch_OrgKeysOverrideServerKeys(t *testing.T) {
+	f := newFakeProviders(t)
+	c := f.client(store.StockMediaKeys{UnsplashAccessKey: "server-unsplash", PexelsAPIKey: "server-pexels"})
+	ctx := context.Background()
+
+	results, err := c.Search(ctx, &store.StockMediaKeys{UnsplashAccessKey: "org-unsplash"}, Query{Text: "teamwork"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Client-ID org-unsplash", f.auth["unsplash"])
+	assert.Equal(t, Result{
+		Provider: ProviderUnsplash, ID: "abc", Kind: KindPhoto, Description: "team meeting",
+		Width: 4000, Height: 3000, PreviewURL: f.URL + "/files/abc-small.jpg",
+		Attribution: spec.Attribution{
+			Text: "Photo by Ada on Unsplash", Author: "Ada", AuthorURL: "https://unsplash.com/@ada" + utmSuffix,
+			Source: "Unsplash", SourceURL: "https://unsplash.com/photos/abc" + utmSuffix,
+			License: "Unsplash License", LicenseURL: "https://unsplash.com/license",
+		},
+		downloadURL: f.URL + "/files/abc.jpg",
+		trackURL:    f.URL + "/unsplash/photos/abc/download",
+	}, results[0])
+
+	results, err = c.Search(ctx, nil, Query{Text: "city", Provider: ProviderPexels})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "server-pexels", f.auth["pexels"])
+	assert.Equal(t, "42", results[0].ID)
+	assert.Equal(t, "Photo by Lin on Pexels", results[0].Attribution.Text)
+}
+
+func TestSearch_ProviderAvailability(t *testing.T) {
+	f := newFakeProviders(t)
+	c := f.client(store.StockMediaKeys{})
+	ctx := context.Background()
+
+	_, err := c.Search(ctx, nil, Query{Text: "teamwork"})
+	assert.ErrorIs(t, err, ErrNotConfigured)
+	_, err = c.Search(ctx, &store.StockMediaKeys{PexelsAPIKey: "k"}, Query{Text: "x", Provider: ProviderUnsplash})
+	assert.ErrorIs(t, err, ErrNotConfigured)
+	_, err = c.Search(ctx, nil, Query{Text: "x", Kind: KindIcon, Provider: ProviderPexels})
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+
+	icons, err := c.Search(ctx, nil, Query{Text: "launch", Kind: KindIcon})
+	require.NoError(t, err)
+	require.Len(t, icons, 1)
+	assert.Equal(t, "mdi:rocket-launch", icons[0].ID)
+	assert.Equal(t, "rocket-launch icon from Material Design Icons by Pictogrammers (Apache 2.0)", icons[0].Attribution.Text)
+	assert.Equal(t, "https://icon-sets.iconify.design/mdi/rocket-launch/", icons[0].Attribution.SourceURL)
+}
+
+func TestCache_DownloadsOnce(t *testing.T) {
+	f := newFakeProviders(t)
+	c := f.client(store.StockMediaKeys{UnsplashAccessKey: "k"})
+	objects, err := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	require.NoError(t, err)
+	cache := &Cache{Client: c, Objects: objects}
+	ctx := context.Background()
+
+	results, err := c.Search(ctx, nil, Query{Text: "teamwork"})
+	require.NoError(t, err)
+	img, err := cache.Fetch(ctx, nil, results[0])
+	require.NoError(t, err)
+	assert.Equal(t, "stock/unsplash/abc.jpg", img.Path)
+	assert.Equal(t, "team meeting", img.Alt)
+	require.NotNil(t, img.Attribution)
+	assert.Equal(t, "Photo by Ada on Unsplash", img.Attribution.Text)
+
+	data, err := objects.Download(ctx, img.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "image bytes", string(data))
+
+	_, err = cache.Fetch(ctx, nil, results[0])
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), f.downloads.Load())
+	assert.Equal(t, int32(2), f.tracked.Load(), "each use is reported to Unsplash")
+}
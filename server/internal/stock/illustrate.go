@@ -0,0 +1,261 @@
+package stock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// Planner is the model Illustrator asks for search queries;
+// ai.Orchestrator satisfies it.
+type Planner interface {
+	GenerateJSON(ctx context.Context, prompt string) (string, error)
+}
+
+// Illustrator picks a stock photo or icon for each slide of a generated
+// deck and places it in a free image placeholder.
+type Illustrator struct {
+	Client *Client
+	Cache  *Cache
+	// Planner chooses a query and kind per slide; when it is nil or its
+	// answer can't be used, slide titles are searched instead.
+	Planner Planner
+}
+
+// Free areas tried, in order, for an image placeholder on a slide that has
+// none. They sit inside the validator's default safe margin.
+var (
+	photoSlots = []spec.Geometry{{X: 0.55, Y: 0.2, W: 0.38, H: 0.6}, {X: 0.65, Y: 0.25, W: 0.28, H: 0.5}}
+	iconSlots  = []spec.Geometry{{X: 0.83, Y: 0.06, W: 0.1, H: 0.12}, {X: 0.83, Y: 0.82, W: 0.1, H: 0.12}}
+)
+
+// slidePlan is the image one slide should get.
+type slidePlan struct {
+	Slide int    `json:"slide"`
+	Query string `json:"query"`
+	Kind  Kind   `json:"kind"`
+}
+
+// Illustrate fills s in place and returns how many slides got an image.
+// Slides that already show an image, have nowhere to put one or find no
+// match are left alone.
+func (il *Illustrator) Illustrate(ctx context.Context, org *store.StockMediaKeys, s *spec.TemplateSpec) int {
+	photos := len(il.Client.Providers(org, KindPhoto)) > 0
+	used := map[string]bool{}
+	added := 0
+	for i, p := range il.plan(ctx, s, photos) {
+		if p.Query == "" {
+			continue
+		}
+		if imagePlaceholder(&s.Layouts[i], p.Kind, safeMargin(s)) < 0 {
+			continue
+		}
+		results, err := il.Client.Search(ctx, org, Query{Text: p.Query, Kind: p.Kind, Limit: 5})
+		if err != nil {
+			logger.Logger.Warn("stock_search_failed", "component", "stock", "query", p.Query, "error", err)
+			continue
+		}
+		for _, r := range results {
+			if used[r.Provider+r.ID] {
+				continue
+			}
+			img, err := il.Cache.Fetch(ctx, org, r)
+			if err != nil {
+				logger.Logger.Warn("stock_fetch_failed", "component", "stock", "provider", r.Provider, "id", r.ID, "error", err)
+				break
+			}
+			used[r.Provider+r.ID] = true
+			if place(s, i, img, p.Kind) {
+				added++
+			}
+			break
+		}
+	}
+	return added
+}
+
+// Place puts img on slide i of s, in an empty image placeholder or in a new
+// one where a photo fits, and reports whether there was room. Slides that
+// already show an image have none.
+func Place(s *spec.TemplateSpec, i int, img spec.Image) bool {
+	return place(s, i, img, KindPhoto)
+}
+
+func place(s *spec.TemplateSpec, i int, img spec.Image, kind Kind) bool {
+	layout := &s.Layouts[i]
+	idx := imagePlaceholder(layout, kind, safeMargin(s))
+	if idx < 0 {
+		return false
+	}
+	if idx == len(layout.Placeholders) {
+		geo, _ := freeSlot(layout, kind, safeMargin(s))
+		layout.Placeholders = append(layout.Placeholders, spec.Placeholder{ID: newPlaceholderID(layout), Type: "image", Geometry: geo})
+	}
+	layout.Placeholders[idx].Image = &img
+	return true
+}
+
+// plan returns one slidePlan per layout, from the Planner if it gives a
+// usable answer and from the slide titles otherwise.
+func (il *Illustrator) plan(ctx context.Context, s *spec.TemplateSpec, photos bool) []slidePlan {
+	plans := make([]slidePlan, len(s.Layouts))
+	for i, layout := range s.Layouts {
+		plans[i] = slidePlan{Slide: i + 1, Query: slideTitle(layout), Kind: KindIcon}
+		if photos {
+			if _, ok := freeSlot(&s.Layouts[i], KindPhoto, safeMargin(s)); ok || hasEmptyImage(layout) {
+				plans[i].Kind = KindPhoto
+			}
+		}
+	}
+	if il.Planner == nil {
+		return plans
+	}
+
+	answer, err := il.Planner.GenerateJSON(ctx, planPrompt(s, photos))
+	if err != nil {
+		logger.Logger.Warn("stock_plan_failed", "component", "stock", "error", err)
+		return plans
+	}
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start < 0 || end <= start {
+		return plans
+	}
+	var out struct {
+		Slides []slidePlan `json:"slides"`
+	}
+	if json.Unmarshal([]byte(answer[start:end+1]), &out) != nil || len(out.Slides) == 0 {
+		return plans
+	}
+	for _, p := range out.Slides {
+		if p.Slide < 1 || p.Slide > len(plans) {
+			continue
+		}
+		if p.Kind != KindPhoto || !photos {
+			p.Kind = KindIcon
+		}
+		p.Query = strings.TrimSpace(p.Query)
+		plans[p.Slide-1] = p
+	}
+	return plans
+}
+
+func planPrompt(s *spec.TemplateSpec, photos bool) string {
+	var b strings.Builder
+	b.WriteString("You choose stock imagery for presentation slides. For each slide, give a short search query (2-4 words) for an image that supports its message, or an empty query if the slide needs no image.\n")
+	if photos {
+		b.WriteString(`Set "kind" to "photo" for scenes, people and places, or "icon" for simple concepts.` + "\n")
+	} else {
+		b.WriteString(`Only icons are available; set "kind" to "icon".` + "\n")
+	}
+	b.WriteString(`Output shape: {"slides":[{"slide":1,"query":"...","kind":"photo"}]}` + "\nReturn ONLY valid JSON (no markdown).\n\nSLIDES:\n")
+	for i, layout := range s.Layouts {
+		var text []string
+		for _, ph := range layout.Placeholders {
+			if ph.Type != "image" && strings.TrimSpace(ph.Content) != "" {
+				text = append(text, strings.TrimSpace(ph.Content))
+			}
+		}
+		body := strings.Join(text, " / ")
+		if len(body) > 300 {
+			body = body[:300]
+		}
+		fmt.Fprintf(&b, "%d. %s\n", i+1, body)
+	}
+	return b.String()
+}
+
+// slideTitle is the content of the layout's title placeholder, if any.
+func slideTitle(layout spec.Layout) string {
+	for _, ph := range layout.Placeholders {
+		if strings.Contains(strings.ToLower(ph.ID), "title") && !strings.Contains(strings.ToLower(ph.ID), "subtitle") {
+			return strings.TrimSpace(ph.Content)
+		}
+	}
+	return ""
+}
+
+// newPlaceholderID names an added image placeholder uniquely in layout.
+func newPlaceholderID(layout *spec.Layout) string {
+	taken := map[string]bool{}
+	for _, ph := range layout.Placeholders {
+		taken[ph.ID] = true
+	}
+	id := "stock_image"
+	for n := 2; taken[id]; n++ {
+		id = fmt.Sprintf("stock_image_%d", n)
+	}
+	return id
+}
+
+func hasEmptyImage(layout spec.Layout) bool {
+	for _, ph := range layout.Placeholders {
+		if ph.Type == "image" && ph.Image == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// imagePlaceholder returns the index of the placeholder the slide's image
+// goes in: an empty image placeholder, or len(Placeholders) when a new one
+// fits. It is -1 when the slide already has an image or has no room.
+func imagePlaceholder(layout *spec.Layout, kind Kind, margin float64) int {
+	empty := -1
+	for i, ph := range layout.Placeholders {
+		if ph.Type != "image" {
+			continue
+		}
+		if ph.Image != nil {
+			return -1
+		}
+		if empty < 0 {
+			empty = i
+		}
+	}
+	if empty >= 0 {
+		return empty
+	}
+	if _, ok := freeSlot(layout, kind, margin); ok {
+		return len(layout.Placeholders)
+	}
+	return -1
+}
+
+// freeSlot finds an area for a new image placeholder that overlaps nothing
+// on the slide.
+func freeSlot(layout *spec.Layout, kind Kind, margin float64) (spec.Geometry, bool) {
+	slots := photoSlots
+	if kind == KindIcon {
+		slots = iconSlots
+	}
+	for _, g := range slots {
+		if g.X < margin || g.Y < margin || g.X+g.W > 1-margin || g.Y+g.H > 1-margin {
+			continue
+		}
+		free := true
+		for _, ph := range layout.Placeholders {
+			o := ph.Geometry
+			if g.X < o.X+o.W && o.X < g.X+g.W && g.Y < o.Y+o.H && o.Y < g.Y+g.H {
+				free = false
+				break
+			}
+		}
+		if free {
+			return g, true
+		}
+	}
+	return spec.Geometry{}, false
+}
+
+// safeMargin mirrors spec.DefaultValidator's default.
+func safeMargin(s *spec.TemplateSpec) float64 {
+	if m := s.Constraints.SafeMargin; m > 0 && m < 0.5 {
+		return m
+	}
+	return 0.05
+}
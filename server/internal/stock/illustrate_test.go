@@ -0,0 +1,95 @@
+package stock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+type fakePlanner string
+
+func (p fakePlanner) GenerateJSON(context.Context, string) (string, error) {
+	return string(p), nil
+}
+
+func TestIllustrate(t *testing.T) {
+	f := newFakeProviders(t)
+	c := f.client(store.StockMediaKeys{UnsplashAccessKey: "k"})
+	objects, err := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	require.NoError(t, err)
+
+	text := func(id, content string, g spec.Geometry) spec.Placeholder {
+		return spec.Placeholder{ID: id, Type: "text", Content: content, Geometry: g}
+	}
+	deck := &spec.TemplateSpec{Layouts: []spec.Layout{
+		// Left column only: room for a photo on the right.
+		{Name: "Team", Placeholders: []spec.Placeholder{
+			text("title", "Our team", spec.Geometry{X: 0.05, Y: 0.05, W: 0.45, H: 0.15}),
+			text("body", "Who we are", spec.Geometry{X: 0.05, Y: 0.25, W: 0.45, H: 0.6}),
+		}},
+		// Full width, but the top-right corner is free for an icon.
+		{Name: "Launch", Placeholders: []spec.Placeholder{
+			text("title", "Launch plan", spec.Geometry{X: 0.05, Y: 0.05, W: 0.7, H: 0.15}),
+			text("body", "Phases", spec.Geometry{X: 0.05, Y: 0.25, W: 0.88, H: 0.55}),
+		}},
+		// The planner skips this one.
+		{Name: "Thanks", Placeholders: []spec.Placeholder{
+			text("title", "Thank you", spec.Geometry{X: 0.05, Y: 0.05, W: 0.45, H: 0.15}),
+		}},
+	}}
+
+	il := &Illustrator{
+		Client:  c,
+		Cache:   &Cache{Client: c, Objects: objects},
+		Planner: fakePlanner("```json\n" + `{"slides":[{"slide":1,"query":"teamwork","kind":"photo"},{"slide":2,"query":"rocket","kind":"icon"},{"slide":3,"query":""}]}` + "\n```"),
+	}
+	assert.Equal(t, 2, il.Illustrate(context.Background(), nil, deck))
+
+	team := deck.Layouts[0].Placeholders
+	require.Len(t, team, 3)
+	assert.Equal(t, "stock_image", team[2].ID)
+	assert.Equal(t, "image", team[2].Type)
+	assert.Equal(t, photoSlots[0], team[2].Geometry)
+	require.NotNil(t, team[2].Image)
+	assert.Equal(t, "stock/unsplash/abc.jpg", team[2].Image.Path)
+	assert.Equal(t, "Photo by Ada on Unsplash", team[2].Image.Attribution.Text)
+
+	launch := deck.Layouts[1].Placeholders
+	require.Len(t, launch, 3)
+	assert.Equal(t, iconSlots[0], launch[2].Geometry)
+	assert.Equal(t, "stock/iconify/mdi_rocket-launch.svg", launch[2].Image.Path)
+	assert.Equal(t, "Iconify", launch[2].Image.Attribution.Source)
+
+	assert.Len(t, deck.Layouts[2].Placeholders, 1)
+	deck.Tokens = map[string]any{}
+	assert.Empty(t, spec.DefaultValidator{}.Validate(*deck), "added placeholders respect margins and overlaps")
+
+	// Without a planner slide titles are searched, and slides that already
+	// show an image are left alone.
+	assert.Equal(t, 1, (&Illustrator{Client: c, Cache: il.Cache}).Illustrate(context.Background(), nil, deck))
+	assert.Len(t, deck.Layouts[0].Placeholders, 3)
+	assert.Len(t, deck.Layouts[1].Placeholders, 3)
+	assert.Len(t, deck.Layouts[2].Placeholders, 2)
+}
+
+func TestPlace(t *testing.T) {
+	s := &spec.TemplateSpec{Layouts: []spec.Layout{
+		{Placeholders: []spec.Placeholder{{ID: "title", Type: "text", Geometry: spec.Geometry{X: 0.05, Y: 0.05, W: 0.9, H: 0.1}}}},
+		{Placeholders: []spec.Placeholder{{ID: "body", Type: "text", Geometry: spec.Geometry{X: 0.05, Y: 0.05, W: 0.9, H: 0.9}}}},
+	}}
+	img := spec.Image{Path: "org-1/a.png"}
+	require.True(t, Place(s, 0, img))
+	require.Len(t, s.Layouts[0].Placeholders, 2)
+	assert.Equal(t, "stock_image", s.Layouts[0].Placeholders[1].ID)
+	assert.Equal(t, photoSlots[0], s.Layouts[0].Placeholders[1].Geometry)
+	assert.Equal(t, &img, s.Layouts[0].Placeholders[1].Image)
+
+	// A slide that shows an image or is full gets no other.
+	assert.False(t, Place(s, 0, spec.Image{Path: "org-1/b.png"}))
+	assert.False(t, Place(s, 1, img))
+}
@@ -0,0 +1,245 @@
+package stock
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+)
+
+// utmSuffix is the referral query Unsplash asks attribution links to carry.
+const utmSuffix = "?utm_source=cms-ai&utm_medium=referral"
+
+type unsplashPhoto struct {
+	ID             string `json:"id"`
+	Width          int    `json:"width"`
+	Height         int    `json:"height"`
+	Description    string `json:"description"`
+	AltDescription string `json:"alt_description"`
+	URLs           struct {
+		Regular string `json:"regular"`
+		Small   string `json:"small"`
+	} `json:"urls"`
+	Links struct {
+		HTML             string `json:"html"`
+		DownloadLocation string `json:"download_location"`
+	} `json:"links"`
+	User struct {
+		Name  string `json:"name"`
+		Links struct {
+			HTML string `json:"html"`
+		} `json:"links"`
+	} `json:"user"`
+}
+
+func (p unsplashPhoto) result() Result {
+	desc := p.AltDescription
+	if desc == "" {
+		desc = p.Description
+	}
+	return Result{
+		Provider:    ProviderUnsplash,
+		ID:          p.ID,
+		Kind:        KindPhoto,
+		Description: desc,
+		Width:       p.Width,
+		Height:      p.Height,
+		PreviewURL:  p.URLs.Small,
+		Attribution: spec.Attribution{
+			Text:       fmt.Sprintf("Photo by %s on Unsplash", p.User.Name),
+			Author:     p.User.Name,
+			AuthorURL:  p.User.Links.HTML + utmSuffix,
+			Source:     "Unsplash",
+			SourceURL:  p.Links.HTML + utmSuffix,
+			License:    "Unsplash License",
+			LicenseURL: "https://unsplash.com/license",
+		},
+		downloadURL: p.URLs.Regular,
+		trackURL:    p.Links.DownloadLocation,
+	}
+}
+
+func (c *Client) searchUnsplash(ctx context.Context, key, text string, limit int) ([]Result, error) {
+	q := url.Values{"query": {text}, "per_page": {strconv.Itoa(limit)}, "content_filter": {"high"}}
+	var out struct {
+		Results []unsplashPhoto `json:"results"`
+	}
+	if err := c.getJSON(ctx, c.UnsplashBase+"/search/photos?"+q.Encode(), "Client-ID "+key, &out); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(out.Results))
+	for _, p := range out.Results {
+		results = append(results, p.result())
+	}
+	return results, nil
+}
+
+func (c *Client) getUnsplash(ctx context.Context, key, id string) (Result, error) {
+	var p unsplashPhoto
+	if err := c.getJSON(ctx, c.UnsplashBase+"/photos/"+url.PathEscape(id), "Client-ID "+key, &p); err != nil {
+		return Result{}, err
+	}
+	return p.result(), nil
+}
+
+type pexelsPhoto struct {
+	ID              int64  `json:"id"`
+	Width           int    `json:"width"`
+	Height          int    `json:"height"`
+	URL             string `json:"url"`
+	Photographer    string `json:"photographer"`
+	PhotographerURL string `json:"photographer_url"`
+	Alt             string `json:"alt"`
+	Src             struct {
+		Large2x string `json:"large2x"`
+		Medium  string `json:"medium"`
+	} `json:"src"`
+}
+
+func (p pexelsPhoto) result() Result {
+	return Result{
+		Provider:    ProviderPexels,
+		ID:          strconv.FormatInt(p.ID, 10),
+		Kind:        KindPhoto,
+		Description: p.Alt,
+		Width:       p.Width,
+		Height:      p.Height,
+		PreviewURL:  p.Src.Medium,
+		Attribution: spec.Attribution{
+			Text:       fmt.Sprintf("Photo by %s on Pexels", p.Photographer),
+			Author:     p.Photographer,
+			AuthorURL:  p.PhotographerURL,
+			Source:     "Pexels",
+			SourceURL:  p.URL,
+			License:    "Pexels License",
+			LicenseURL: "https://www.pexels.com/license/",
+		},
+		downloadURL: p.Src.Large2x,
+	}
+}
+
+func (c *Client) searchPexels(ctx context.Context, key, text string, limit int) ([]Result, error) {
+	q := url.Values{"query": {text}, "per_page": {strconv.Itoa(limit)}}
+	var out struct {
+		Photos []pexelsPhoto `json:"photos"`
+	}
+	if err := c.getJSON(ctx, c.PexelsBase+"/v1/search?"+q.Encode(), key, &out); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(out.Photos))
+	for _, p := range out.Photos {
+		results = append(results, p.result())
+	}
+	return results, nil
+}
+
+func (c *Client) getPexels(ctx context.Context, key, id string) (Result, error) {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return Result{}, ErrNotFound
+	}
+	var p pexelsPhoto
+	if err := c.getJSON(ctx, c.PexelsBase+"/v1/photos/"+id, key, &p); err != nil {
+		return Result{}, err
+	}
+	return p.result(), nil
+}
+
+// iconifyCollection is an icon set's credits, as Iconify reports them.
+type iconifyCollection struct {
+	Name   string `json:"name"`
+	Author struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"author"`
+	License struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+	} `json:"license"`
+}
+
+// iconifyID matches Iconify's "prefix:name" icon IDs.
+var iconifyID = regexp.MustCompile(`^([a-z0-9]+(?:-[a-z0-9]+)*):([a-z0-9]+(?:-[a-z0-9]+)*)$`)
+
+// iconifyMinLimit is the smallest page Iconify's search accepts.
+const iconifyMinLimit = 32
+
+func (c *Client) iconResult(id string, set iconifyCollection) Result {
+	prefix, name, _ := strings.Cut(id, ":")
+	svg := fmt.Sprintf("%s/%s/%s.svg", c.IconifyBase, prefix, name)
+	text := fmt.Sprintf("%s icon from %s", name, set.Name)
+	if set.Author.Name != "" {
+		text += " by " + set.Author.Name
+	}
+	if set.License.Title != "" {
+		text += " (" + set.License.Title + ")"
+	}
+	return Result{
+		Provider:    ProviderIconify,
+		ID:          id,
+		Kind:        KindIcon,
+		Description: strings.ReplaceAll(name, "-", " "),
+		PreviewURL:  svg,
+		Attribution: spec.Attribution{
+			Text:       text,
+			Author:     set.Author.Name,
+			AuthorURL:  set.Author.URL,
+			Source:     "Iconify",
+			SourceURL:  fmt.Sprintf("https://icon-sets.iconify.design/%s/%s/", prefix, name),
+			License:    set.License.Title,
+			LicenseURL: set.License.URL,
+		},
+		downloadURL: svg,
+	}
+}
+
+func (c *Client) searchIconify(ctx context.Context, text string, limit int) ([]Result, error) {
+	q := url.Values{"query": {text}, "limit": {strconv.Itoa(max(limit, iconifyMinLimit))}}
+	var out struct {
+		Icons       []string                     `json:"icons"`
+		Collections map[string]iconifyCollection `json:"collections"`
+	}
+	if err := c.getJSON(ctx, c.IconifyBase+"/search?"+q.Encode(), "", &out); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, min(limit, len(out.Icons)))
+	for _, id := range out.Icons {
+		m := iconifyID.FindStringSubmatch(id)
+		if m == nil {
+			continue
+		}
+		results = append(results, c.iconResult(id, out.Collections[m[1]]))
+		if len(results) == limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+func (c *Client) getIconify(ctx context.Context, id string) (Result, error) {
+	m := iconifyID.FindStringSubmatch(id)
+	if m == nil {
+		return Result{}, ErrNotFound
+	}
+	prefix, name := m[1], m[2]
+	var icons struct {
+		Icons   map[string]any `json:"icons"`
+		Aliases map[string]any `json:"aliases"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/%s.json?icons=%s", c.IconifyBase, prefix, name), "", &icons); err != nil {
+		return Result{}, err
+	}
+	_, isIcon := icons.Icons[name]
+	_, isAlias := icons.Aliases[name]
+	if !isIcon && !isAlias {
+		return Result{}, ErrNotFound
+	}
+	var sets map[string]iconifyCollection
+	if err := c.getJSON(ctx, c.IconifyBase+"/collections?prefixes="+prefix, "", &sets); err != nil {
+		return Result{}, err
+	}
+	return c.iconResult(id, sets[prefix]), nil
+}
@@ -0,0 +1,41 @@
+package store
+
+import "time"
+
+// AICall is one call to the AI provider, kept so regressions in generation
+// quality and spend can be traced back to the calls behind them. Prompts
+// aren't stored; RequestHash tells repeated requests apart.
+type AICall struct {
+	ID     string `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID  string `json:"orgId" gorm:"type:uuid;index"`
+	UserID string `json:"userId,omitempty" gorm:"index"`
+	// Kind is what the call was for, like "generate" or "refine".
+	Kind        string `json:"kind" gorm:"index"`
+	RequestHash string `json:"requestHash"`
+	// Attempt is the call's place in its model fallback chain, 1 for the
+	// first choice; a request served by a fallback model has failed calls
+	// with lower attempts and the same hash before it.
+	Attempt   int    `json:"attempt"`
+	Model     string `json:"model" gorm:"index"`
+	LatencyMs int64  `json:"latencyMs"`
+	// Tokens is the provider's count, or an estimate for providers that
+	// don't report one.
+	Tokens  int     `json:"tokens"`
+	Cost    float64 `json:"cost"`
+	Success bool    `json:"success"`
+	// Error is why an unsuccessful call failed.
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+}
+
+// AICallFilter narrows an AI call listing. Zero fields don't filter.
+type AICallFilter struct {
+	OrgID   string
+	UserID  string
+	Kind    string
+	Model   string
+	Success *bool
+	Since   *time.Time
+	Until   *time.Time
+	Limit   int
+}
@@ -0,0 +1,23 @@
+package store
+
+import (
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+)
+
+// APIKey lets scripts and CI call the API as the member who created it,
+// with at most Role. Only a hash of the key is kept; the key itself is
+// shown once at creation.
+type APIKey struct {
+	ID     string    `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID  string    `json:"orgId" gorm:"type:uuid;index;not null"`
+	UserID string    `json:"userId" gorm:"type:uuid;index;not null"`
+	Name   string    `json:"name" gorm:"not null"`
+	Role   auth.Role `json:"role" gorm:"not null"`
+	// Prefix is the start of the key, kept so users can tell keys apart.
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-" gorm:"uniqueIndex;not null"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
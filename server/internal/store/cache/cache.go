@@ -0,0 +1,323 @@
+// Package cache keeps hot template and brand kit reads away from the
+// database. Reads go through an in-process LRU whose entries expire after a
+// TTL, optionally backed by a Redis shared by every instance, and every
+// write through the wrapped stores invalidates what it changed.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Kinds of cached reads, as reported in Stats.
+const (
+	KindTemplate         = "template"
+	KindTemplateVersion  = "template_version"
+	KindTemplateVersions = "template_versions"
+	KindBrandKits        = "brand_kits"
+)
+
+const (
+	// DefaultSize and DefaultTTL are the configuration's defaults.
+	DefaultSize = 1000
+	DefaultTTL  = 30 * time.Second
+
+	// sharedLocalTTL caps in-process entries when a shared tier is
+	// configured: another instance's write deletes the shared entry but
+	// can't reach this instance's LRU, so those entries must age out fast.
+	sharedLocalTTL = 5 * time.Second
+)
+
+// Config configures a Cache.
+type Config struct {
+	// Size is how many entries the in-process LRU holds; 0 disables
+	// caching.
+	Size int
+	// TTL is how long an entry is served before it is read again; 0 means
+	// DefaultTTL.
+	TTL time.Duration
+	// RedisURL, when set, adds a shared tier, as
+	// redis://[:password@]host:port[/db] or rediss:// for TLS.
+	RedisURL string
+}
+
+// Shared is a cache tier shared between instances.
+type Shared interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Cache is the read cache. A nil *Cache caches nothing, so callers can hold
+// one without checking whether caching is enabled.
+type Cache struct {
+	s *state
+	// tx is set on the view of the cache a transaction uses; see Begin.
+	tx *txLog
+}
+
+type state struct {
+	size     int
+	ttl      time.Duration
+	localTTL time.Duration
+	shared   Shared
+	now      func() time.Time
+
+	mu    sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+	// gen counts invalidations, so a read that loaded a value before a
+	// write invalidated it doesn't put the stale value back.
+	gen   uint64
+	stats Stats
+	kinds map[string]*KindStats
+}
+
+type entry struct {
+	key     string
+	orgID   string
+	value   []byte
+	expires time.Time
+}
+
+type txLog struct {
+	mu   sync.Mutex
+	keys []string
+	orgs []string
+}
+
+// New returns the cache cfg describes, or nil when caching is disabled.
+func New(cfg Config) (*Cache, error) {
+	if cfg.Size <= 0 {
+		return nil, nil
+	}
+	var shared Shared
+	if cfg.RedisURL != "" {
+		r, err := NewRedis(cfg.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+		shared = r
+	}
+	return newCache(cfg.Size, cfg.TTL, shared), nil
+}
+
+func newCache(size int, ttl time.Duration, shared Shared) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	localTTL := ttl
+	if shared != nil && localTTL > sharedLocalTTL {
+		localTTL = sharedLocalTTL
+	}
+	return &Cache{s: &state{
+		size:     size,
+		ttl:      ttl,
+		localTTL: localTTL,
+		shared:   shared,
+		now:      time.Now,
+		lru:      list.New(),
+		items:    map[string]*list.Element{},
+		kinds:    map[string]*KindStats{},
+	}}
+}
+
+// Begin returns the view of c a transaction uses. Reads through it skip the
+// cache, since they must see the transaction's own writes, and what its
+// writes invalidate is invalidated again by End, once the transaction has
+// committed or rolled back, so a read that raced the commit can't leave a
+// stale entry behind.
+func (c *Cache) Begin() *Cache {
+	if c == nil {
+		return nil
+	}
+	return &Cache{s: c.s, tx: &txLog{}}
+}
+
+// End finishes a view returned by Begin.
+func (c *Cache) End(ctx context.Context) {
+	if c == nil || c.tx == nil {
+		return
+	}
+	c.tx.mu.Lock()
+	keys, orgs := c.tx.keys, c.tx.orgs
+	c.tx.keys, c.tx.orgs = nil, nil
+	c.tx.mu.Unlock()
+	base := &Cache{s: c.s}
+	base.Invalidate(ctx, keys...)
+	for _, orgID := range orgs {
+		base.InvalidateOrg(orgID)
+	}
+}
+
+// Invalidate drops the entries under keys from both tiers.
+func (c *Cache) Invalidate(ctx context.Context, keys ...string) {
+	if c == nil || len(keys) == 0 {
+		return
+	}
+	if c.tx != nil {
+		c.tx.mu.Lock()
+		c.tx.keys = append(c.tx.keys, keys...)
+		c.tx.mu.Unlock()
+	}
+	s := c.s
+	s.mu.Lock()
+	s.gen++
+	for _, key := range keys {
+		if el, ok := s.items[key]; ok {
+			s.remove(el)
+		}
+		s.stats.Invalidations++
+	}
+	s.mu.Unlock()
+	if s.shared != nil {
+		if err := s.shared.Delete(ctx, keys...); err != nil {
+			s.sharedError()
+		}
+	}
+}
+
+// InvalidateOrg drops every in-process entry of orgID, for writes that
+// change an org's rows wholesale. Shared entries are left to expire.
+func (c *Cache) InvalidateOrg(orgID string) {
+	if c == nil {
+		return
+	}
+	if c.tx != nil {
+		c.tx.mu.Lock()
+		c.tx.orgs = append(c.tx.orgs, orgID)
+		c.tx.mu.Unlock()
+	}
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gen++
+	for el := s.lru.Front(); el != nil; {
+		next := el.Next()
+		if el.Value.(*entry).orgID == orgID {
+			s.remove(el)
+			s.stats.Invalidations++
+		}
+		el = next
+	}
+}
+
+// get returns the value cached under key, loading and caching it on a miss.
+// Values are stored encoded so callers never share what they are handed.
+// Only found values are cached; shared says whether the value may go to
+// the shared tier.
+func get[T any](ctx context.Context, c *Cache, kind, orgID, key string, shared bool, load func() (T, bool, error)) (T, bool, error) {
+	if c == nil || c.tx != nil {
+		return load()
+	}
+	s := c.s
+	if raw, ok := s.lookup(key); ok {
+		var v T
+		if decode(raw, &v) == nil {
+			s.count(kind, true, false)
+			return v, true, nil
+		}
+	}
+	s.mu.Lock()
+	gen := s.gen
+	s.mu.Unlock()
+	if shared && s.shared != nil {
+		raw, ok, err := s.shared.Get(ctx, key)
+		if err != nil {
+			s.sharedError()
+		} else if ok {
+			var v T
+			if decode(raw, &v) == nil {
+				s.count(kind, true, true)
+				s.store(key, orgID, raw, gen)
+				return v, true, nil
+			}
+		}
+	}
+	s.count(kind, false, false)
+	v, ok, err := load()
+	if err != nil || !ok {
+		return v, ok, err
+	}
+	raw, err := encode(v)
+	if err != nil {
+		return v, true, nil
+	}
+	if s.store(key, orgID, raw, gen) && shared && s.shared != nil {
+		if err := s.shared.Set(ctx, key, raw, s.ttl); err != nil {
+			s.sharedError()
+		}
+	}
+	return v, true, nil
+}
+
+func (s *state) lookup(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if !s.now().Before(e.expires) {
+		s.remove(el)
+		return nil, false
+	}
+	s.lru.MoveToFront(el)
+	return e.value, true
+}
+
+// store caches value under key unless something was invalidated since gen
+// was read, reporting whether it did.
+func (s *state) store(key, orgID string, value []byte, gen uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.gen != gen {
+		return false
+	}
+	e := &entry{key: key, orgID: orgID, value: value, expires: s.now().Add(s.localTTL)}
+	if el, ok := s.items[key]; ok {
+		el.Value = e
+		s.lru.MoveToFront(el)
+		return true
+	}
+	s.items[key] = s.lru.PushFront(e)
+	for s.lru.Len() > s.size {
+		s.remove(s.lru.Back())
+		s.stats.Evictions++
+	}
+	return true
+}
+
+func (s *state) remove(el *list.Element) {
+	s.lru.Remove(el)
+	delete(s.items, el.Value.(*entry).key)
+}
+
+func (s *state) count(kind string, hit, shared bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := s.kinds[kind]
+	if k == nil {
+		k = &KindStats{}
+		s.kinds[kind] = k
+	}
+	if hit {
+		s.stats.Hits++
+		k.Hits++
+		if shared {
+			s.stats.SharedHits++
+		}
+	} else {
+		s.stats.Misses++
+		k.Misses++
+	}
+}
+
+func (s *state) sharedError() {
+	s.mu.Lock()
+	s.stats.SharedErrors++
+	s.mu.Unlock()
+}
@@ -0,0 +1,262 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+// countingTemplates counts the reads that reach the store.
+type countingTemplates struct {
+	store.TemplateStore
+	gets, versionGets, versionLists int
+}
+
+func (c *countingTemplates) GetTemplate(ctx context.Context, orgID, id string) (store.Template, bool, error) {
+	c.gets++
+	return c.TemplateStore.GetTemplate(ctx, orgID, id)
+}
+
+func (c *countingTemplates) GetVersion(ctx context.Context, orgID, id string) (store.TemplateVersion, bool, error) {
+	c.versionGets++
+	return c.TemplateStore.GetVersion(ctx, orgID, id)
+}
+
+func (c *countingTemplates) ListVersions(ctx context.Context, orgID, templateID string) ([]store.TemplateVersion, error) {
+	c.versionLists++
+	return c.TemplateStore.ListVersions(ctx, orgID, templateID)
+}
+
+type countingBrandKits struct {
+	store.BrandKitStore
+	lists int
+}
+
+func (c *countingBrandKits) List(ctx context.Context, orgID string) ([]store.BrandKit, error) {
+	c.lists++
+	return c.BrandKitStore.List(ctx, orgID)
+}
+
+// fakeShared is a Shared tier in a map.
+type fakeShared struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func (f *fakeShared) Get(_ context.Context, key string) ([]byte, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.values[key]
+	return v, ok, nil
+}
+
+func (f *fakeShared) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[key] = value
+	return nil
+}
+
+func (f *fakeShared) Delete(_ context.Context, keys ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, k := range keys {
+		delete(f.values, k)
+	}
+	return nil
+}
+
+func newTemplates(t *testing.T) *countingTemplates {
+	t.Helper()
+	ts := &countingTemplates{TemplateStore: memory.New().Templates()}
+	_, err := ts.CreateTemplate(context.Background(), store.Template{ID: "tpl-1", OrgID: "org-1", Name: "Brand", Status: store.TemplateDraft})
+	require.NoError(t, err)
+	return ts
+}
+
+func TestTemplates_CachesReadsUntilWritten(t *testing.T) {
+	ctx := context.Background()
+	inner := newTemplates(t)
+	c := newCache(10, time.Minute, nil)
+	ts := c.Templates(inner)
+
+	for range 3 {
+		tpl, ok, err := ts.GetTemplate(ctx, "org-1", "tpl-1")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "Brand", tpl.Name)
+	}
+	assert.Equal(t, 1, inner.gets)
+
+	_, ok, err := ts.GetTemplate(ctx, "org-2", "tpl-1")
+	require.NoError(t, err)
+	assert.False(t, ok, "other orgs don't see the cached template")
+
+	tpl, _, _ := ts.GetTemplate(ctx, "org-1", "tpl-1")
+	tpl.Name = "Renamed"
+	_, err = ts.UpdateTemplate(ctx, tpl)
+	require.NoError(t, err)
+	tpl, _, _ = ts.GetTemplate(ctx, "org-1", "tpl-1")
+	assert.Equal(t, "Renamed", tpl.Name)
+
+	_, err = ts.CreateVersion(ctx, store.TemplateVersion{ID: "tv-1", Template: "tpl-1", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"layouts":[]}`)})
+	require.NoError(t, err)
+	vs, err := ts.ListVersions(ctx, "org-1", "tpl-1")
+	require.NoError(t, err)
+	require.Len(t, vs, 1)
+	_, err = ts.CreateVersion(ctx, store.TemplateVersion{ID: "tv-2", Template: "tpl-1", OrgID: "org-1", VersionNo: 2, SpecJSON: []byte(`{"layouts":[]}`)})
+	require.NoError(t, err)
+	vs, err = ts.ListVersions(ctx, "org-1", "tpl-1")
+	require.NoError(t, err)
+	assert.Len(t, vs, 2, "creating a version drops the cached list")
+	vs[0].SpecJSON[0] = 'x'
+	vs, _ = ts.ListVersions(ctx, "org-1", "tpl-1")
+	assert.Equal(t, 2, inner.versionLists)
+	assert.True(t, json.Valid(vs[0].SpecJSON), "callers get their own copy of cached values")
+
+	for range 2 {
+		v, ok, err := ts.GetVersion(ctx, "org-1", "tv-1")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, 1, v.VersionNo)
+	}
+	assert.Equal(t, 1, inner.versionGets)
+}
+
+func TestCache_ExpiresAndEvicts(t *testing.T) {
+	ctx := context.Background()
+	inner := newTemplates(t)
+	_, err := inner.CreateTemplate(ctx, store.Template{ID: "tpl-2", OrgID: "org-1", Name: "Other", Status: store.TemplateDraft})
+	require.NoError(t, err)
+	c := newCache(1, time.Minute, nil)
+	now := time.Now()
+	c.s.now = func() time.Time { return now }
+	ts := c.Templates(inner)
+
+	ts.GetTemplate(ctx, "org-1", "tpl-1")
+	ts.GetTemplate(ctx, "org-1", "tpl-1")
+	assert.Equal(t, 1, inner.gets)
+	now = now.Add(time.Minute)
+	ts.GetTemplate(ctx, "org-1", "tpl-1")
+	assert.Equal(t, 2, inner.gets, "expired entries are read again")
+
+	ts.GetTemplate(ctx, "org-1", "tpl-2")
+	ts.GetTemplate(ctx, "org-1", "tpl-1")
+	assert.Equal(t, 4, inner.gets, "the least recently used entry is evicted")
+
+	stats := c.Stats()
+	assert.True(t, stats.Enabled)
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(4), stats.Misses)
+	assert.Equal(t, 0.2, stats.HitRate)
+	assert.Equal(t, int64(2), stats.Evictions)
+	assert.Equal(t, KindStats{Hits: 1, Misses: 4, HitRate: 0.2}, stats.Kinds[KindTemplate])
+}
+
+func TestCache_TransactionsBypassReadsAndInvalidateOnEnd(t *testing.T) {
+	ctx := context.Background()
+	inner := newTemplates(t)
+	c := newCache(10, time.Minute, nil)
+	c.Templates(inner).GetTemplate(ctx, "org-1", "tpl-1")
+
+	tx := c.Begin()
+	txTemplates := tx.Templates(inner)
+	txTemplates.GetTemplate(ctx, "org-1", "tpl-1")
+	assert.Equal(t, 2, inner.gets, "reads inside a transaction skip the cache")
+	_, err := txTemplates.UpdateTemplate(ctx, store.Template{ID: "tpl-1", OrgID: "org-1", Name: "Renamed", Status: store.TemplateDraft})
+	require.NoError(t, err)
+
+	// A read racing the commit still sees the old row and caches it...
+	c.s.store(templateKey("org-1", "tpl-1"), "org-1", []byte(`{"name":"Brand"}`), c.s.gen)
+	// ...until the transaction ends.
+	tx.End(ctx)
+	tpl, _, _ := c.Templates(inner).GetTemplate(ctx, "org-1", "tpl-1")
+	assert.Equal(t, "Renamed", tpl.Name)
+}
+
+func TestCache_StaleLoadsAreNotStored(t *testing.T) {
+	ctx := context.Background()
+	c := newCache(10, time.Minute, nil)
+	key := templateKey("org-1", "tpl-1")
+	_, _, err := get(ctx, c, KindTemplate, "org-1", key, true, func() (store.Template, bool, error) {
+		// A write lands while the row is being read.
+		c.Invalidate(ctx, key)
+		return store.Template{Name: "Old"}, true, nil
+	})
+	require.NoError(t, err)
+	_, ok := c.s.lookup(key)
+	assert.False(t, ok)
+}
+
+func TestCache_InvalidateOrg(t *testing.T) {
+	ctx := context.Background()
+	inner := newTemplates(t)
+	c := newCache(10, time.Minute, nil)
+	ts := c.Templates(inner)
+	ts.GetTemplate(ctx, "org-1", "tpl-1")
+	ts.GetVersion(ctx, "org-1", "tv-missing")
+	c.InvalidateOrg("org-2")
+	assert.Equal(t, 1, c.Stats().Entries, "misses aren't cached; other orgs are kept")
+	c.InvalidateOrg("org-1")
+	assert.Zero(t, c.Stats().Entries)
+}
+
+func TestCache_SharedTier(t *testing.T) {
+	ctx := context.Background()
+	inner := newTemplates(t)
+	shared := &fakeShared{values: map[string][]byte{}}
+	a := newCache(10, time.Minute, shared)
+	b := newCache(10, time.Minute, shared)
+	assert.Equal(t, sharedLocalTTL, a.s.localTTL)
+
+	a.Templates(inner).GetTemplate(ctx, "org-1", "tpl-1")
+	tpl, ok, err := b.Templates(inner).GetTemplate(ctx, "org-1", "tpl-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "Brand", tpl.Name)
+	assert.Equal(t, 1, inner.gets, "the second instance is answered by the shared tier")
+	assert.Equal(t, int64(1), b.Stats().SharedHits)
+
+	_, err = a.Templates(inner).UpdateTemplate(ctx, store.Template{ID: "tpl-1", OrgID: "org-1", Name: "Renamed", Status: store.TemplateDraft})
+	require.NoError(t, err)
+	assert.NotContains(t, shared.values, templateKey("org-1", "tpl-1"))
+
+	kits := &countingBrandKits{BrandKitStore: memory.New().BrandKits()}
+	bks := a.BrandKits(kits)
+	_, err = bks.Create(ctx, store.BrandKit{ID: "bk-1", OrgID: "org-1", Name: "Kit", Tokens: map[string]any{"primary": "#000"}})
+	require.NoError(t, err)
+	list, err := bks.List(ctx, "org-1")
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	bks.List(ctx, "org-1")
+	assert.Equal(t, 1, kits.lists)
+	assert.NotContains(t, shared.values, brandKitsKey("org-1"), "brand kit tokens stay out of the shared tier")
+
+	_, err = bks.Create(ctx, store.BrandKit{ID: "bk-2", OrgID: "org-1", Name: "Second"})
+	require.NoError(t, err)
+	list, _ = bks.List(ctx, "org-1")
+	assert.Len(t, list, 2)
+}
+
+func TestNilCache(t *testing.T) {
+	var c *Cache
+	inner := newTemplates(t)
+	assert.Same(t, inner, c.Templates(inner))
+	assert.Nil(t, c.Begin())
+	c.End(context.Background())
+	c.Invalidate(context.Background(), "k")
+	c.InvalidateOrg("org-1")
+	assert.False(t, c.Stats().Enabled)
+
+	c, err := New(Config{})
+	require.NoError(t, err)
+	assert.Nil(t, c, "size 0 disables caching")
+}
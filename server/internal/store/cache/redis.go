@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisTimeout bounds each command, so a slow Redis costs a cache miss
+// rather than a slow request.
+const redisTimeout = 500 * time.Millisecond
+
+// Redis is a Shared tier on a Redis server. It speaks just enough of the
+// protocol for GET, SET PX and DEL over one connection, redialled after
+// any error.
+type Redis struct {
+	addr     string
+	tls      bool
+	password string
+	db       int
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// NewRedis returns a client for url, redis://[:password@]host:port[/db] or
+// rediss:// for TLS. It doesn't connect until the first command.
+func NewRedis(rawURL string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// url.Parse quotes the URL, password and all.
+		return nil, errors.New("invalid redis url")
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("invalid redis url: scheme must be redis or rediss, got %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("invalid redis url: missing host")
+	}
+	r := &Redis{addr: u.Host, tls: u.Scheme == "rediss"}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil || r.db < 0 {
+			return nil, fmt.Errorf("invalid redis url: database must be a number, got %q", db)
+		}
+	}
+	return r, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	v, err := r.do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	b, ok := v.([]byte)
+	return b, ok, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := r.do(ctx, "SET", key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := r.do(ctx, "DEL", keys...)
+	return err
+}
+
+// Close closes the connection, if there is one.
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn, r.rd = nil, nil
+	return err
+}
+
+// redisError is an error reply from the server.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+func (r *Redis) do(ctx context.Context, cmd string, args ...string) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deadline := time.Now().Add(redisTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if r.conn == nil {
+		if err := r.dial(ctx, deadline); err != nil {
+			return nil, err
+		}
+	}
+	v, err := r.roundTrip(deadline, append([]string{cmd}, args...))
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection may hold half a reply; start over next time.
+		r.conn.Close()
+		r.conn, r.rd = nil, nil
+	}
+	return v, err
+}
+
+func (r *Redis) dial(ctx context.Context, deadline time.Time) error {
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	var conn net.Conn
+	var err error
+	if r.tls {
+		host, _, _ := net.SplitHostPort(r.addr)
+		d := &tls.Dialer{Config: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}}
+		conn, err = d.DialContext(ctx, "tcp", r.addr)
+	} else {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", r.addr)
+	}
+	if err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+	r.conn, r.rd = conn, bufio.NewReader(conn)
+	var setup [][]string
+	if r.password != "" {
+		setup = append(setup, []string{"AUTH", r.password})
+	}
+	if r.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(r.db)})
+	}
+	for _, cmd := range setup {
+		if _, err := r.roundTrip(deadline, cmd); err != nil {
+			conn.Close()
+			r.conn, r.rd = nil, nil
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Redis) roundTrip(deadline time.Time, args []string) (any, error) {
+	if err := r.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(r.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readReply(r.rd)
+}
+
+// readReply reads one reply: a string, an integer, a []byte bulk string,
+// nil for a missing value, or a redisError.
+func readReply(rd *bufio.Reader) (any, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: bad bulk length %q", line[1:])
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis answers the commands Redis sends from an in-memory map.
+type fakeRedis struct {
+	ln       net.Listener
+	password string
+
+	mu       sync.Mutex
+	values   map[string]string
+	commands []string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	f := &fakeRedis{ln: ln, password: password, values: map[string]string{}}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, strings.Join(args, " "))
+		var reply string
+		switch {
+		case args[0] == "AUTH":
+			if args[1] == f.password {
+				authed = true
+				reply = "+OK\r\n"
+			} else {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "SELECT":
+			reply = "+OK\r\n"
+		case args[0] == "GET":
+			if v, ok := f.values[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case args[0] == "SET":
+			f.values[args[1]] = args[2]
+			reply = "+OK\r\n"
+		case args[0] == "DEL":
+			n := 0
+			for _, k := range args[1:] {
+				if _, ok := f.values[k]; ok {
+					delete(f.values, k)
+					n++
+				}
+			}
+			reply = fmt.Sprintf(":%d\r\n", n)
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		v, err := readReply(rd)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = string(v.([]byte))
+	}
+	return args, nil
+}
+
+func TestRedis(t *testing.T) {
+	f := newFakeRedis(t, "s3cret")
+	r, err := NewRedis("redis://:s3cret@" + f.ln.Addr().String() + "/2")
+	require.NoError(t, err)
+	defer r.Close()
+	ctx := context.Background()
+
+	_, ok, err := r.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, r.Set(ctx, "k", []byte("line one\r\nline two"), 1500*time.Millisecond))
+	v, ok, err := r.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "line one\r\nline two", string(v))
+
+	require.NoError(t, r.Delete(ctx, "k", "other"))
+	_, ok, err = r.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	f.mu.Lock()
+	assert.Equal(t, []string{"AUTH s3cret", "SELECT 2", "GET k", "SET k line one\r\nline two PX 1500", "GET k", "DEL k other", "GET k"}, f.commands)
+	f.mu.Unlock()
+}
+
+func TestRedis_Errors(t *testing.T) {
+	f := newFakeRedis(t, "s3cret")
+	r, err := NewRedis("redis://:wrong@" + f.ln.Addr().String())
+	require.NoError(t, err)
+	_, _, err = r.Get(context.Background(), "k")
+	assert.EqualError(t, err, "redis: WRONGPASS invalid password")
+
+	f.ln.Close()
+	r, err = NewRedis("redis://" + f.ln.Addr().String())
+	require.NoError(t, err)
+	_, _, err = r.Get(context.Background(), "k")
+	assert.Error(t, err, "an unreachable server is an error, for the cache to count as a miss")
+}
+
+func TestNewRedis(t *testing.T) {
+	r, err := NewRedis("rediss://cache.internal")
+	require.NoError(t, err)
+	assert.Equal(t, "cache.internal:6379", r.addr)
+	assert.True(t, r.tls)
+
+	for url, want := range map[string]string{
+		"http://cache:6379":        `invalid redis url: scheme must be redis or rediss, got "http"`,
+		"redis://":                 "invalid redis url: missing host",
+		"redis://cache:6379/zero":  `invalid redis url: database must be a number, got "zero"`,
+		"redis://:p%zz@cache:6379": "invalid redis url",
+	} {
+		_, err := NewRedis(url)
+		assert.EqualError(t, err, want, url)
+	}
+}
@@ -0,0 +1,62 @@
+package cache
+
+import "encoding/json"
+
+// Stats describes how well the cache is doing.
+type Stats struct {
+	Enabled bool `json:"enabled"`
+	Shared  bool `json:"shared"`
+	// Entries and Capacity are the in-process LRU's fill.
+	Entries  int `json:"entries"`
+	Capacity int `json:"capacity"`
+	// Hits counts reads served from either tier, SharedHits the part of
+	// them the shared tier answered.
+	Hits          int64   `json:"hits"`
+	Misses        int64   `json:"misses"`
+	HitRate       float64 `json:"hitRate"`
+	SharedHits    int64   `json:"sharedHits"`
+	SharedErrors  int64   `json:"sharedErrors"`
+	Evictions     int64   `json:"evictions"`
+	Invalidations int64   `json:"invalidations"`
+	// Kinds breaks hits and misses down by what was read.
+	Kinds map[string]KindStats `json:"kinds"`
+}
+
+// KindStats are the hits and misses of one kind of read.
+type KindStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hitRate"`
+}
+
+// Stats returns a snapshot of c's counters. A nil c reports Enabled false.
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{Kinds: map[string]KindStats{}}
+	}
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := s.stats
+	out.Enabled = true
+	out.Shared = s.shared != nil
+	out.Entries = s.lru.Len()
+	out.Capacity = s.size
+	out.HitRate = hitRate(out.Hits, out.Misses)
+	out.Kinds = make(map[string]KindStats, len(s.kinds))
+	for kind, k := range s.kinds {
+		out.Kinds[kind] = KindStats{Hits: k.Hits, Misses: k.Misses, HitRate: hitRate(k.Hits, k.Misses)}
+	}
+	return out
+}
+
+func hitRate(hits, misses int64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+func encode(v any) ([]byte, error) { return json.Marshal(v) }
+
+func decode(raw []byte, v any) error { return json.Unmarshal(raw, v) }
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// Cache keys name the kind of read, the org and what was read.
+func templateKey(orgID, id string) string { return "cmsai:tpl:" + orgID + ":" + id }
+
+func versionKey(orgID, id string) string { return "cmsai:tv:" + orgID + ":" + id }
+
+func versionsKey(orgID, templateID string) string { return "cmsai:tvs:" + orgID + ":" + templateID }
+
+func brandKitsKey(orgID string) string { return "cmsai:bks:" + orgID }
+
+// Templates returns ts with GetTemplate, GetVersion and ListVersions served
+// from c. A nil c returns ts as it is.
+func (c *Cache) Templates(ts store.TemplateStore) store.TemplateStore {
+	if c == nil {
+		return ts
+	}
+	return &templateStore{c: c, next: ts}
+}
+
+// BrandKits returns bs with List served from c. Brand kit tokens are
+// encrypted at rest, so they stay out of the shared tier. A nil c returns
+// bs as it is.
+func (c *Cache) BrandKits(bs store.BrandKitStore) store.BrandKitStore {
+	if c == nil {
+		return bs
+	}
+	return &brandKitStore{c: c, next: bs}
+}
+
+type templateStore struct {
+	c    *Cache
+	next store.TemplateStore
+}
+
+func (t *templateStore) CreateTemplate(ctx context.Context, tpl store.Template) (store.Template, error) {
+	return t.next.CreateTemplate(ctx, tpl)
+}
+
+func (t *templateStore) ListTemplates(ctx context.Context, orgID string) ([]store.Template, error) {
+	return t.next.ListTemplates(ctx, orgID)
+}
+
+func (t *templateStore) GetTemplate(ctx context.Context, orgID, id string) (store.Template, bool, error) {
+	return get(ctx, t.c, KindTemplate, orgID, templateKey(orgID, id), true, func() (store.Template, bool, error) {
+		return t.next.GetTemplate(ctx, orgID, id)
+	})
+}
+
+func (t *templateStore) UpdateTemplate(ctx context.Context, tpl store.Template) (store.Template, error) {
+	out, err := t.next.UpdateTemplate(ctx, tpl)
+	t.c.Invalidate(ctx, templateKey(tpl.OrgID, tpl.ID))
+	return out, err
+}
+
+func (t *templateStore) ListTemplatesFor(ctx context.Context, id auth.Identity) ([]store.Template, error) {
+	return t.next.ListTemplatesFor(ctx, id)
+}
+
+func (t *templateStore) GetTemplateFor(ctx context.Context, id auth.Identity, templateID string) (store.Template, store.Permission, bool, error) {
+	return t.next.GetTemplateFor(ctx, id, templateID)
+}
+
+func (t *templateStore) ListTemplatesWithCurrentVersion(ctx context.Context, id auth.Identity) ([]store.TemplateWithVersion, error) {
+	return t.next.ListTemplatesWithCurrentVersion(ctx, id)
+}
+
+func (t *templateStore) CreateVersion(ctx context.Context, v store.TemplateVersion) (store.TemplateVersion, error) {
+	out, err := t.next.CreateVersion(ctx, v)
+	t.c.Invalidate(ctx, versionsKey(v.OrgID, v.Template))
+	return out, err
+}
+
+func (t *templateStore) ListVersions(ctx context.Context, orgID, templateID string) ([]store.TemplateVersion, error) {
+	vs, _, err := get(ctx, t.c, KindTemplateVersions, orgID, versionsKey(orgID, templateID), true, func() ([]store.TemplateVersion, bool, error) {
+		vs, err := t.next.ListVersions(ctx, orgID, templateID)
+		return vs, err == nil, err
+	})
+	return vs, err
+}
+
+func (t *templateStore) GetVersion(ctx context.Context, orgID, versionID string) (store.TemplateVersion, bool, error) {
+	return get(ctx, t.c, KindTemplateVersion, orgID, versionKey(orgID, versionID), true, func() (store.TemplateVersion, bool, error) {
+		return t.next.GetVersion(ctx, orgID, versionID)
+	})
+}
+
+func (t *templateStore) GetVersions(ctx context.Context, orgID string, ids []string) (map[string]store.TemplateVersion, error) {
+	return t.next.GetVersions(ctx, orgID, ids)
+}
+
+type brandKitStore struct {
+	c    *Cache
+	next store.BrandKitStore
+}
+
+func (b *brandKitStore) Create(ctx context.Context, bk store.BrandKit) (store.BrandKit, error) {
+	out, err := b.next.Create(ctx, bk)
+	b.c.Invalidate(ctx, brandKitsKey(bk.OrgID))
+	return out, err
+}
+
+func (b *brandKitStore) List(ctx context.Context, orgID string) ([]store.BrandKit, error) {
+	bks, _, err := get(ctx, b.c, KindBrandKits, orgID, brandKitsKey(orgID), false, func() ([]store.BrandKit, bool, error) {
+		bks, err := b.next.List(ctx, orgID)
+		return bks, err == nil, err
+	})
+	return bks, err
+}
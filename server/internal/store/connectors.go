@@ -0,0 +1,58 @@
+package store
+
+import "time"
+
+// Connector kinds.
+const (
+	// ConnectorCSV reads a CSV file from a URL; the first row is the header.
+	ConnectorCSV = "csv"
+	// ConnectorSheets reads a range of a Google Sheets spreadsheet; the
+	// range's first row is the header.
+	ConnectorSheets = "sheets"
+	// ConnectorSQL runs a read-only query against a PostgreSQL database.
+	ConnectorSQL = "sql"
+)
+
+// ConnectorKinds lists every connector kind.
+var ConnectorKinds = []string{ConnectorCSV, ConnectorSheets, ConnectorSQL}
+
+// Connector is a data source an org registered for live metrics. Decks bind
+// its fields as {{connector.<name>.<field>}} variables, which are filled
+// with the source's current data when the deck is exported.
+type Connector struct {
+	ID    string `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID string `json:"orgId" gorm:"type:uuid;index"`
+	// Name is how bindings refer to the connector; unique within the org.
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// URL is the CSV file's address.
+	URL string `json:"url,omitempty"`
+	// SpreadsheetID and Range pick the Google Sheets cells, e.g. "Q3!A1:D20".
+	SpreadsheetID string `json:"spreadsheetId,omitempty"`
+	Range         string `json:"range,omitempty"`
+	// Query is the SQL connector's SELECT statement.
+	Query string `json:"query,omitempty"`
+	// Credential is the Authorization header sent for a CSV URL, the Google
+	// API key of a sheet, or the connection string of a SQL database. It is
+	// never returned by the API.
+	Credential    string `json:"-" gorm:"serializer:encrypted"`
+	HasCredential bool   `json:"hasCredential" gorm:"-"`
+	// MaxAgeSeconds is how old the last fetched data may be for an export to
+	// use it instead of fetching afresh; 0 fetches on every export.
+	MaxAgeSeconds int `json:"maxAgeSeconds"`
+	// Data is the last data fetched and RefreshedAt when; LastError is why
+	// the last fetch failed, "" if it didn't.
+	Data        *ConnectorData `json:"data,omitempty" gorm:"type:jsonb;serializer:encrypted"`
+	RefreshedAt *time.Time     `json:"refreshedAt,omitempty"`
+	LastError   string         `json:"lastError,omitempty"`
+	CreatedBy   string         `json:"createdBy"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+}
+
+// ConnectorData is a table a connector fetched: column names and rows of
+// cell text.
+type ConnectorData struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
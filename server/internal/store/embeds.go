@@ -0,0 +1,21 @@
+package store
+
+import "time"
+
+// DeckEmbed lets anyone holding its token view a deck read-only, for example
+// in an iframe on a wiki. Only a hash of the token is kept; the token itself
+// is shown once at creation.
+type DeckEmbed struct {
+	ID        string `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID     string `json:"orgId" gorm:"type:uuid;index;not null"`
+	DeckID    string `json:"deckId" gorm:"type:uuid;index;not null"`
+	TokenHash string `json:"-" gorm:"uniqueIndex;not null"`
+	// AllowedDomains lists the hosts that may frame the viewer, each either
+	// exact ("wiki.example.com") or a subdomain wildcard ("*.example.com").
+	// Empty allows any site.
+	AllowedDomains []string   `json:"allowedDomains" gorm:"type:jsonb;serializer:json"`
+	ViewCount      int64      `json:"viewCount" gorm:"not null;default:0"`
+	LastViewedAt   *time.Time `json:"lastViewedAt,omitempty"`
+	CreatedBy      string     `json:"createdBy" gorm:"type:uuid"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
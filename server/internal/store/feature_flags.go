@@ -0,0 +1,14 @@
+package store
+
+import "time"
+
+// FeatureFlag turns a feature on or off platform-wide (OrgID "") or for one
+// org. An org's row overrides the platform-wide one, which overrides the
+// flag's built-in default.
+type FeatureFlag struct {
+	Name      string    `json:"name" gorm:"primaryKey"`
+	OrgID     string    `json:"orgId,omitempty" gorm:"primaryKey"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedBy string    `json:"updatedBy"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
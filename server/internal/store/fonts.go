@@ -0,0 +1,27 @@
+package store
+
+import "time"
+
+// FontStyle is the face of a family that a font file provides.
+type FontStyle string
+
+const (
+	FontRegular    FontStyle = "regular"
+	FontBold       FontStyle = "bold"
+	FontItalic     FontStyle = "italic"
+	FontBoldItalic FontStyle = "boldItalic"
+)
+
+// Font is a TTF or OTF file an org uploaded so exports can embed it. The
+// file itself is stored as an asset, so it counts against storage.
+type Font struct {
+	ID        string    `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID     string    `json:"orgId" gorm:"type:uuid;index"`
+	Family    string    `json:"family"`
+	Style     FontStyle `json:"style"`
+	AssetID   string    `json:"assetId"`
+	Filename  string    `json:"filename"`
+	SizeBytes int64     `json:"sizeBytes"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,23 @@
+package store
+
+import "time"
+
+// GeneratedImage is an AI-generated image, such as a slide background,
+// stored as an asset. PromptHash identifies the prompt, model and size that
+// produced it, so an org generates each image once and reuses it across
+// slides and decks.
+type GeneratedImage struct {
+	ID          string    `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID       string    `json:"orgId" gorm:"type:uuid;index:idx_generated_images_prompt"`
+	PromptHash  string    `json:"promptHash" gorm:"index:idx_generated_images_prompt"`
+	Prompt      string    `json:"prompt"`
+	Model       string    `json:"model"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	AssetID     string    `json:"assetId"`
+	Path        string    `json:"path"`
+	Mime        string    `json:"mime"`
+	SourceJobID string    `json:"sourceJobId,omitempty"`
+	CreatedBy   string    `json:"createdBy,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
@@ -0,0 +1,35 @@
+package store
+
+import "time"
+
+// GlossaryKind is what a glossary entry asks of AI-written text.
+type GlossaryKind string
+
+const (
+	// GlossaryPreferred replaces the entry's variants with its term.
+	GlossaryPreferred GlossaryKind = "preferred"
+	// GlossaryForbidden bans the term. It is replaced by the entry's
+	// replacement when there is one and otherwise rewritten by the AI.
+	GlossaryForbidden GlossaryKind = "forbidden"
+	// GlossaryCasing writes the term exactly as given, like "iPhone".
+	GlossaryCasing GlossaryKind = "casing"
+)
+
+// GlossaryKinds lists every kind, in the order they are documented.
+var GlossaryKinds = []GlossaryKind{GlossaryPreferred, GlossaryForbidden, GlossaryCasing}
+
+// GlossaryEntry is one of an org's terminology rules. AI prompts carry the
+// org's glossary, and AI output is checked against it and corrected.
+// Terms and variants match whole words, ignoring case.
+type GlossaryEntry struct {
+	ID          string       `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID       string       `json:"orgId" gorm:"type:uuid;index"`
+	Kind        GlossaryKind `json:"kind"`
+	Term        string       `json:"term"`
+	Variants    []string     `json:"variants,omitempty" gorm:"type:jsonb;serializer:json"`
+	Replacement string       `json:"replacement,omitempty"`
+	Note        string       `json:"note,omitempty"`
+	CreatedBy   string       `json:"createdBy"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+}
@@ -2,16 +2,23 @@ package memory
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"maps"
+	"slices"
 	"sort"
 	"sync"
 	"time"
 
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/redact"
+	"github.com/ziyad/cms-ai/server/internal/spec"
 	"github.com/ziyad/cms-ai/server/internal/store"
 )
 
 type MemoryStore struct {
-	mu sync.RWMutex
+	mu   sync.RWMutex
+	txMu sync.Mutex
 
 	templates map[string]store.Template
 	versions  map[string]store.TemplateVersion
@@ -20,12 +27,32 @@ type MemoryStore struct {
 	brandKits map[string]store.BrandKit
 	assets    map[string]store.Asset
 	assetData map[string][]byte
+	storage   map[string]int64
 	jobs      map[string]store.Job
 	metering  []store.MeteringEvent
 	audit     []store.AuditLog
+	aiCalls   []store.AICall
 	users     map[string]store.User
 	orgs      map[string]store.Organization
 	userOrgs  []store.UserOrg
+	perms     []store.ResourcePermission
+	comments  map[string]store.Comment
+	refines   map[string]store.DeckRefinement
+	tags      map[string]store.Tag
+	tagLinks  []store.ResourceTag
+	folders   map[string]store.Folder
+	hooks     map[string]store.Webhook
+	hookDels  map[string]store.WebhookDelivery
+	embeds    map[string]store.DeckEmbed
+	apiKeys   map[string]store.APIKey
+	retries   map[store.JobType]store.RetryPolicyOverride
+	flags     map[string]store.FeatureFlag // by name|orgID
+	fonts     map[string]store.Font
+	glossary  map[string]store.GlossaryEntry
+	schedules map[string]store.Schedule
+	conns     map[string]store.Connector
+	genImages map[string]store.GeneratedImage
+	activity  map[string]store.UserActivity // by userID|day
 }
 
 func New() *MemoryStore {
@@ -37,12 +64,32 @@ func New() *MemoryStore {
 		brandKits: map[string]store.BrandKit{},
 		assets:    map[string]store.Asset{},
 		assetData: map[string][]byte{},
+		storage:   map[string]int64{},
 		jobs:      map[string]store.Job{},
 		metering:  []store.MeteringEvent{},
 		audit:     []store.AuditLog{},
+		aiCalls:   []store.AICall{},
 		users:     map[string]store.User{},
 		orgs:      map[string]store.Organization{},
 		userOrgs:  []store.UserOrg{},
+		perms:     []store.ResourcePermission{},
+		comments:  map[string]store.Comment{},
+		refines:   map[string]store.DeckRefinement{},
+		tags:      map[string]store.Tag{},
+		tagLinks:  []store.ResourceTag{},
+		folders:   map[string]store.Folder{},
+		hooks:     map[string]store.Webhook{},
+		hookDels:  map[string]store.WebhookDelivery{},
+		embeds:    map[string]store.DeckEmbed{},
+		apiKeys:   map[string]store.APIKey{},
+		retries:   map[store.JobType]store.RetryPolicyOverride{},
+		flags:     map[string]store.FeatureFlag{},
+		fonts:     map[string]store.Font{},
+		glossary:  map[string]store.GlossaryEntry{},
+		schedules: map[string]store.Schedule{},
+		conns:     map[string]store.Connector{},
+		genImages: map[string]store.GeneratedImage{},
+		activity:  map[string]store.UserActivity{},
 	}
 }
 
@@ -55,6 +102,102 @@ func (m *MemoryStore) Metering() store.MeteringStore          { return (*meterin
 func (m *MemoryStore) Audit() store.AuditStore                { return (*auditStore)(m) }
 func (m *MemoryStore) Users() store.UserStore                 { return (*userStore)(m) }
 func (m *MemoryStore) Organizations() store.OrganizationStore { return (*organizationStore)(m) }
+func (m *MemoryStore) Permissions() store.PermissionStore     { return (*permissionStore)(m) }
+func (m *MemoryStore) Comments() store.CommentStore           { return (*commentStore)(m) }
+func (m *MemoryStore) Tags() store.TagStore                   { return (*tagStore)(m) }
+func (m *MemoryStore) Folders() store.FolderStore             { return (*folderStore)(m) }
+func (m *MemoryStore) Webhooks() store.WebhookStore           { return (*webhookStore)(m) }
+func (m *MemoryStore) Embeds() store.EmbedStore               { return (*embedStore)(m) }
+func (m *MemoryStore) APIKeys() store.APIKeyStore             { return (*apiKeyStore)(m) }
+func (m *MemoryStore) RetryPolicies() store.RetryPolicyStore  { return (*retryPolicyStore)(m) }
+func (m *MemoryStore) FeatureFlags() store.FeatureFlagStore    { return (*featureFlagStore)(m) }
+func (m *MemoryStore) Fonts() store.FontStore                 { return (*fontStore)(m) }
+func (m *MemoryStore) Glossary() store.GlossaryStore          { return (*glossaryStore)(m) }
+func (m *MemoryStore) Schedules() store.ScheduleStore         { return (*scheduleStore)(m) }
+func (m *MemoryStore) Connectors() store.ConnectorStore       { return (*connectorStore)(m) }
+func (m *MemoryStore) AICalls() store.AICallStore             { return (*aiCallStore)(m) }
+func (m *MemoryStore) GeneratedImages() store.GeneratedImageStore {
+	return (*generatedImageStore)(m)
+}
+
+func (m *MemoryStore) Platform() store.PlatformStore { return (*platformStore)(m) }
+
+// WithTx runs transactions one at a time and restores a snapshot of every
+// collection if fn fails. Writes made outside the transaction while it runs
+// are rolled back with it, which is fine for the tests and local runs this
+// store backs.
+func (m *MemoryStore) WithTx(ctx context.Context, fn func(tx store.Store) error) error {
+	m.txMu.Lock()
+	defer m.txMu.Unlock()
+
+	m.mu.Lock()
+	snap := m.snapshot()
+	m.mu.Unlock()
+
+	if err := fn(m); err != nil {
+		m.mu.Lock()
+		m.restore(snap)
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (m *MemoryStore) snapshot() *MemoryStore {
+	return &MemoryStore{
+		templates: maps.Clone(m.templates),
+		versions:  maps.Clone(m.versions),
+		decks:     maps.Clone(m.decks),
+		deckVers:  maps.Clone(m.deckVers),
+		brandKits: maps.Clone(m.brandKits),
+		assets:    maps.Clone(m.assets),
+		assetData: maps.Clone(m.assetData),
+		storage:   maps.Clone(m.storage),
+		jobs:      maps.Clone(m.jobs),
+		metering:  slices.Clone(m.metering),
+		audit:     slices.Clone(m.audit),
+		aiCalls:   slices.Clone(m.aiCalls),
+		users:     maps.Clone(m.users),
+		orgs:      maps.Clone(m.orgs),
+		userOrgs:  slices.Clone(m.userOrgs),
+		perms:     slices.Clone(m.perms),
+		comments:  maps.Clone(m.comments),
+		refines:   maps.Clone(m.refines),
+		tags:      maps.Clone(m.tags),
+		tagLinks:  slices.Clone(m.tagLinks),
+		folders:   maps.Clone(m.folders),
+		hooks:     maps.Clone(m.hooks),
+		hookDels:  maps.Clone(m.hookDels),
+		embeds:    maps.Clone(m.embeds),
+		apiKeys:   maps.Clone(m.apiKeys),
+		retries:   maps.Clone(m.retries),
+		flags:     maps.Clone(m.flags),
+		fonts:     maps.Clone(m.fonts),
+		glossary:  maps.Clone(m.glossary),
+		schedules: maps.Clone(m.schedules),
+		conns:     maps.Clone(m.conns),
+		genImages: maps.Clone(m.genImages),
+		activity:  maps.Clone(m.activity),
+	}
+}
+
+func (m *MemoryStore) restore(s *MemoryStore) {
+	m.templates, m.versions = s.templates, s.versions
+	m.decks, m.deckVers = s.decks, s.deckVers
+	m.brandKits = s.brandKits
+	m.assets, m.assetData, m.storage = s.assets, s.assetData, s.storage
+	m.jobs = s.jobs
+	m.metering, m.audit, m.aiCalls = s.metering, s.audit, s.aiCalls
+	m.users, m.orgs, m.userOrgs = s.users, s.orgs, s.userOrgs
+	m.perms = s.perms
+	m.comments, m.refines = s.comments, s.refines
+	m.tags, m.tagLinks, m.folders = s.tags, s.tagLinks, s.folders
+	m.hooks, m.hookDels = s.hooks, s.hookDels
+	m.embeds, m.apiKeys = s.embeds, s.apiKeys
+	m.retries, m.flags, m.fonts, m.glossary = s.retries, s.flags, s.fonts, s.glossary
+	m.schedules, m.conns = s.schedules, s.conns
+	m.genImages, m.activity = s.genImages, s.activity
+}
 
 type templateStore MemoryStore
 
@@ -74,16 +217,38 @@ type userStore MemoryStore
 
 type organizationStore MemoryStore
 
-var errNotFound = errors.New("not found")
+type permissionStore MemoryStore
+
+type commentStore MemoryStore
+
+type tagStore MemoryStore
+
+type folderStore MemoryStore
+
+type webhookStore MemoryStore
+
+type embedStore MemoryStore
+
+type apiKeyStore MemoryStore
+
+var errNotFound = store.ErrNotFound
+
+// errDuplicateVersion mirrors the Postgres unique index on
+// (template_id|deck_id, version_no).
+var errDuplicateVersion = errors.New("version number already exists")
 
 func (m *templateStore) CreateTemplate(_ context.Context, t store.Template) (store.Template, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	now := time.Now().UTC()
-	t.CreatedAt = now
-	t.UpdatedAt = now
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now().UTC()
+	}
+	t.UpdatedAt = t.CreatedAt
+	if t.Visibility == "" {
+		t.Visibility = store.VisibilityOrg
+	}
 	ms.templates[t.ID] = t
 	return t, nil
 }
@@ -127,12 +292,54 @@ func (m *templateStore) UpdateTemplate(_ context.Context, t store.Template) (sto
 	return t, nil
 }
 
+func (m *templateStore) ListTemplatesFor(_ context.Context, id auth.Identity) ([]store.Template, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.Template{}
+	for _, t := range ms.templates {
+		if t.OrgID != id.OrgID {
+			continue
+		}
+		grant := ms.grantLocked(t.OrgID, store.ResourceTemplate, t.ID, id.UserID)
+		if store.EffectivePermission(id, t.OwnerUserID, t.Visibility, grant).Allows(store.PermissionView) {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (m *templateStore) GetTemplateFor(_ context.Context, id auth.Identity, templateID string) (store.Template, store.Permission, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	t, ok := ms.templates[templateID]
+	if !ok || t.OrgID != id.OrgID {
+		return store.Template{}, store.PermissionNone, false, nil
+	}
+	grant := ms.grantLocked(t.OrgID, store.ResourceTemplate, t.ID, id.UserID)
+	perm := store.EffectivePermission(id, t.OwnerUserID, t.Visibility, grant)
+	if !perm.Allows(store.PermissionView) {
+		return store.Template{}, store.PermissionNone, false, nil
+	}
+	return t, perm, true, nil
+}
+
 func (m *templateStore) CreateVersion(_ context.Context, v store.TemplateVersion) (store.TemplateVersion, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	v.CreatedAt = time.Now().UTC()
+	for id, existing := range ms.versions {
+		if v.Template != "" && id != v.ID && existing.Template == v.Template && existing.VersionNo == v.VersionNo {
+			return store.TemplateVersion{}, errDuplicateVersion
+		}
+	}
+	if v.CreatedAt.IsZero() {
+		v.CreatedAt = time.Now().UTC()
+	}
 	ms.versions[v.ID] = v
 	return v, nil
 }
@@ -145,12 +352,27 @@ func (m *templateStore) ListVersions(_ context.Context, orgID, templateID string
 	out := []store.TemplateVersion{}
 	for _, v := range ms.versions {
 		if v.OrgID == orgID && v.Template == templateID {
+			v.SpecJSON = migrateSpec(v.SpecJSON)
 			out = append(out, v)
 		}
 	}
+	sort.Slice(out, func(i, j int) bool { return out[i].VersionNo > out[j].VersionNo })
 	return out, nil
 }
 
+// migrateSpec upgrades a stored spec to the current schema on read, as the
+// postgres store does. Specs it can't upgrade are returned as they are.
+func migrateSpec(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+	out, err := spec.Migrate(raw)
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
 func (m *templateStore) GetVersion(_ context.Context, orgID, versionID string) (store.TemplateVersion, bool, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
@@ -160,17 +382,49 @@ func (m *templateStore) GetVersion(_ context.Context, orgID, versionID string) (
 	if !ok || v.OrgID != orgID {
 		return store.TemplateVersion{}, false, nil
 	}
+	v.SpecJSON = migrateSpec(v.SpecJSON)
 	return v, true, nil
 }
 
+func (m *templateStore) GetVersions(_ context.Context, orgID string, ids []string) (map[string]store.TemplateVersion, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := make(map[string]store.TemplateVersion, len(ids))
+	for _, id := range ids {
+		if v, ok := ms.versions[id]; ok && v.OrgID == orgID {
+			v.SpecJSON = migrateSpec(v.SpecJSON)
+			out[id] = v
+		}
+	}
+	return out, nil
+}
+
+func (m *templateStore) ListTemplatesWithCurrentVersion(ctx context.Context, id auth.Identity) ([]store.TemplateWithVersion, error) {
+	ts, err := m.ListTemplatesFor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := m.GetVersions(ctx, id.OrgID, store.CurrentVersionIDs(ts))
+	if err != nil {
+		return nil, err
+	}
+	return store.WithCurrentVersions(ts, versions), nil
+}
+
 func (m *deckStore) CreateDeck(_ context.Context, d store.Deck) (store.Deck, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	now := time.Now().UTC()
-	d.CreatedAt = now
-	d.UpdatedAt = now
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now().UTC()
+	}
+	d.UpdatedAt = d.CreatedAt
+	if d.Visibility == "" {
+		d.Visibility = store.VisibilityOrg
+	}
 	ms.decks[d.ID] = d
 	return d, nil
 }
@@ -186,6 +440,7 @@ func (m *deckStore) ListDecks(_ context.Context, orgID string) ([]store.Deck, er
 			out = append(out, d)
 		}
 	}
+	sortDecks(out)
 	return out, nil
 }
 
@@ -214,12 +469,110 @@ func (m *deckStore) UpdateDeck(_ context.Context, d store.Deck) (store.Deck, err
 	return d, nil
 }
 
+func (m *deckStore) ListDecksFor(_ context.Context, id auth.Identity) ([]store.Deck, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.Deck{}
+	for _, d := range ms.decks {
+		if d.OrgID != id.OrgID {
+			continue
+		}
+		grant := ms.grantLocked(d.OrgID, store.ResourceDeck, d.ID, id.UserID)
+		if store.EffectivePermission(id, d.OwnerUserID, d.Visibility, grant).Allows(store.PermissionView) {
+			out = append(out, d)
+		}
+	}
+	sortDecks(out)
+	return out, nil
+}
+
+// sortDecks orders decks most recently updated first, as Postgres does.
+func sortDecks(ds []store.Deck) {
+	sort.Slice(ds, func(i, j int) bool {
+		if !ds[i].UpdatedAt.Equal(ds[j].UpdatedAt) {
+			return ds[i].UpdatedAt.After(ds[j].UpdatedAt)
+		}
+		return ds[i].ID < ds[j].ID
+	})
+}
+
+func (m *deckStore) GetDeckFor(_ context.Context, id auth.Identity, deckID string) (store.Deck, store.Permission, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	d, ok := ms.decks[deckID]
+	if !ok || d.OrgID != id.OrgID {
+		return store.Deck{}, store.PermissionNone, false, nil
+	}
+	grant := ms.grantLocked(d.OrgID, store.ResourceDeck, d.ID, id.UserID)
+	perm := store.EffectivePermission(id, d.OwnerUserID, d.Visibility, grant)
+	if !perm.Allows(store.PermissionView) {
+		return store.Deck{}, store.PermissionNone, false, nil
+	}
+	return d, perm, true, nil
+}
+
+func (m *deckStore) ListDecksWithSummary(ctx context.Context, id auth.Identity) ([]store.DeckWithSummary, error) {
+	ds, err := m.ListDecksFor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ms := (*MemoryStore)(m)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	// Export jobs name the deck version they exported, so the latest per
+	// deck goes through the version to find its deck.
+	lastExported := map[string]time.Time{}
+	for _, j := range ms.jobs {
+		if j.OrgID != id.OrgID || j.Type != store.JobExport || j.Status != store.JobDone {
+			continue
+		}
+		v, ok := ms.deckVers[j.InputRef]
+		if ok && j.UpdatedAt.After(lastExported[v.Deck]) {
+			lastExported[v.Deck] = j.UpdatedAt
+		}
+	}
+
+	out := make([]store.DeckWithSummary, len(ds))
+	for i, d := range ds {
+		out[i].Deck = d
+		if d.CurrentVersion == nil {
+			continue
+		}
+		v, ok := ms.deckVers[*d.CurrentVersion]
+		if !ok || v.OrgID != d.OrgID {
+			continue
+		}
+		var doc struct {
+			Layouts []json.RawMessage `json:"layouts"`
+		}
+		_ = json.Unmarshal(v.SpecJSON, &doc)
+		sum := &store.DeckSummary{VersionID: v.ID, VersionNo: v.VersionNo, SlideCount: len(doc.Layouts)}
+		if t, ok := lastExported[d.ID]; ok {
+			sum.LastExportedAt = &t
+		}
+		out[i].Summary = sum
+	}
+	return out, nil
+}
+
 func (m *deckStore) CreateDeckVersion(_ context.Context, v store.DeckVersion) (store.DeckVersion, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	v.CreatedAt = time.Now().UTC()
+	for id, existing := range ms.deckVers {
+		if v.Deck != "" && id != v.ID && existing.Deck == v.Deck && existing.VersionNo == v.VersionNo {
+			return store.DeckVersion{}, errDuplicateVersion
+		}
+	}
+	if v.CreatedAt.IsZero() {
+		v.CreatedAt = time.Now().UTC()
+	}
 	ms.deckVers[v.ID] = v
 	return v, nil
 }
@@ -232,9 +585,11 @@ func (m *deckStore) ListDeckVersions(_ context.Context, orgID, deckID string) ([
 	out := []store.DeckVersion{}
 	for _, v := range ms.deckVers {
 		if v.OrgID == orgID && v.Deck == deckID {
+			v.SpecJSON = migrateSpec(v.SpecJSON)
 			out = append(out, v)
 		}
 	}
+	sort.Slice(out, func(i, j int) bool { return out[i].VersionNo > out[j].VersionNo })
 	return out, nil
 }
 
@@ -247,344 +602,2058 @@ func (m *deckStore) GetDeckVersion(_ context.Context, orgID, versionID string) (
 	if !ok || v.OrgID != orgID {
 		return store.DeckVersion{}, false, nil
 	}
+	v.SpecJSON = migrateSpec(v.SpecJSON)
 	return v, true, nil
 }
 
-func (m *brandKitStore) Create(_ context.Context, b store.BrandKit) (store.BrandKit, error) {
+func (m *deckStore) AppendRefinement(_ context.Context, r store.DeckRefinement) (store.DeckRefinement, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	b.CreatedAt = time.Now().UTC()
-	ms.brandKits[b.ID] = b
-	return b, nil
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now().UTC()
+	}
+	ms.refines[r.ID] = r
+	return r, nil
 }
 
-func (m *brandKitStore) List(_ context.Context, orgID string) ([]store.BrandKit, error) {
+func (m *deckStore) ListRefinements(_ context.Context, orgID, deckID string) ([]store.DeckRefinement, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	out := []store.BrandKit{}
-	for _, b := range ms.brandKits {
-		if b.OrgID == orgID {
-			out = append(out, b)
+	out := []store.DeckRefinement{}
+	for _, r := range ms.refines {
+		if r.OrgID == orgID && r.DeckID == deckID {
+			out = append(out, r)
 		}
 	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
 	return out, nil
 }
 
-func (m *assetStore) Create(_ context.Context, a store.Asset) (store.Asset, error) {
+// grantLocked returns the user's explicit grant on a resource. Callers must
+// hold ms.mu.
+func (ms *MemoryStore) grantLocked(orgID string, rt store.ResourceType, resourceID, userID string) store.Permission {
+	for _, p := range ms.perms {
+		if p.OrgID == orgID && p.ResourceType == rt && p.ResourceID == resourceID && p.UserID == userID {
+			return p.Permission
+		}
+	}
+	return store.PermissionNone
+}
+
+func (m *permissionStore) Grant(_ context.Context, p store.ResourcePermission) (store.ResourcePermission, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	a.CreatedAt = time.Now().UTC()
-	ms.assets[a.ID] = a
-	return a, nil
+	p.CreatedAt = time.Now().UTC()
+	for i, existing := range ms.perms {
+		if existing.OrgID == p.OrgID && existing.ResourceType == p.ResourceType && existing.ResourceID == p.ResourceID && existing.UserID == p.UserID {
+			ms.perms[i] = p
+			return p, nil
+		}
+	}
+	ms.perms = append(ms.perms, p)
+	return p, nil
 }
 
-func (m *assetStore) Get(_ context.Context, orgID, id string) (store.Asset, bool, error) {
+func (m *permissionStore) Revoke(_ context.Context, orgID string, rt store.ResourceType, resourceID, userID string) (bool, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	a, ok := ms.assets[id]
-	if !ok || a.OrgID != orgID {
-		return store.Asset{}, false, nil
+	for i, p := range ms.perms {
+		if p.OrgID == orgID && p.ResourceType == rt && p.ResourceID == resourceID && p.UserID == userID {
+			ms.perms = append(ms.perms[:i], ms.perms[i+1:]...)
+			return true, nil
+		}
 	}
-	return a, true, nil
+	return false, nil
 }
 
-func (m *jobStore) Enqueue(_ context.Context, j store.Job) (store.Job, error) {
+func (m *permissionStore) List(_ context.Context, orgID string, rt store.ResourceType, resourceID string) ([]store.ResourcePermission, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	now := time.Now().UTC()
-	j.CreatedAt = now
-	j.UpdatedAt = now
-	ms.jobs[j.ID] = j
-	return j, nil
+	out := []store.ResourcePermission{}
+	for _, p := range ms.perms {
+		if p.OrgID == orgID && p.ResourceType == rt && p.ResourceID == resourceID {
+			out = append(out, p)
+		}
+	}
+	return out, nil
 }
 
-func (m *jobStore) EnqueueWithDeduplication(_ context.Context, j store.Job) (store.Job, bool, error) {
+func (m *commentStore) Create(_ context.Context, c store.Comment) (store.Comment, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	if j.DeduplicationID != "" {
-		var latestJob *store.Job
-		for _, existingJob := range ms.jobs {
-			if existingJob.OrgID == j.OrgID && existingJob.DeduplicationID == j.DeduplicationID {
-				if latestJob == nil || existingJob.CreatedAt.After(latestJob.CreatedAt) {
-					latestJob = &existingJob
-				}
-			}
-		}
-		if latestJob != nil {
-			// If job is still in progress, return existing job
-			if latestJob.Status == store.JobQueued || latestJob.Status == store.JobRunning || latestJob.Status == store.JobRetry {
-				return *latestJob, true, nil
-			}
-			// If job is completed successfully, return it immediately
-			if latestJob.Status == store.JobDone {
-				return *latestJob, true, nil
-			}
-			// If job failed permanently, allow creating a new one
-		}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now().UTC()
 	}
-
-	now := time.Now().UTC()
-	j.CreatedAt = now
-	j.UpdatedAt = now
-	ms.jobs[j.ID] = j
-	return j, false, nil
+	c.UpdatedAt = c.CreatedAt
+	ms.comments[c.ID] = c
+	return c, nil
 }
 
-func (m *jobStore) Get(_ context.Context, orgID, jobID string) (store.Job, bool, error) {
+func (m *commentStore) Get(_ context.Context, orgID, id string) (store.Comment, bool, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	j, ok := ms.jobs[jobID]
-	if !ok || j.OrgID != orgID {
-		return store.Job{}, false, nil
+	c, ok := ms.comments[id]
+	if !ok || c.OrgID != orgID {
+		return store.Comment{}, false, nil
 	}
-	return j, true, nil
+	return c, true, nil
 }
 
-func (m *jobStore) GetByDeduplicationID(_ context.Context, orgID, dedupID string) (store.Job, bool, error) {
+func (m *commentStore) ListByDeckVersion(_ context.Context, orgID, deckVersionID string) ([]store.Comment, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	for _, job := range ms.jobs {
-		if job.OrgID == orgID && job.DeduplicationID == dedupID {
-			return job, true, nil
+	out := []store.Comment{}
+	for _, c := range ms.comments {
+		if c.OrgID == orgID && c.DeckVersionID == deckVersionID {
+			out = append(out, c)
 		}
 	}
-	return store.Job{}, false, nil
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
 }
 
-func (m *jobStore) Update(_ context.Context, j store.Job) (store.Job, error) {
+func (m *commentStore) Update(_ context.Context, c store.Comment) (store.Comment, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	if _, ok := ms.jobs[j.ID]; !ok {
-		return store.Job{}, errors.New("not found")
+	if existing, ok := ms.comments[c.ID]; !ok || existing.OrgID != c.OrgID {
+		return store.Comment{}, errNotFound
 	}
-	j.UpdatedAt = time.Now().UTC()
-	ms.jobs[j.ID] = j
-	return j, nil
+	c.UpdatedAt = time.Now().UTC()
+	ms.comments[c.ID] = c
+	return c, nil
 }
 
-func (m *jobStore) ListQueued(_ context.Context) ([]store.Job, error) {
+func (m *tagStore) CreateTag(_ context.Context, t store.Tag) (store.Tag, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	var queued []store.Job
-	for _, job := range ms.jobs {
-		if job.Status == store.JobQueued {
-			queued = append(queued, job)
-		}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now().UTC()
 	}
-	return queued, nil
+	t.UpdatedAt = t.CreatedAt
+	ms.tags[t.ID] = t
+	return t, nil
 }
 
-func (m *jobStore) ListRetry(_ context.Context) ([]store.Job, error) {
+func (m *tagStore) ListTags(_ context.Context, orgID string) ([]store.Tag, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	var retry []store.Job
-	for _, job := range ms.jobs {
-		if job.Status == store.JobRetry {
-			retry = append(retry, job)
+	out := []store.Tag{}
+	for _, t := range ms.tags {
+		if t.OrgID == orgID {
+			out = append(out, t)
 		}
 	}
-	return retry, nil
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
 }
 
-func (m *jobStore) ListDeadLetter(_ context.Context) ([]store.Job, error) {
+func (m *tagStore) GetTag(_ context.Context, orgID, id string) (store.Tag, bool, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	var deadLetter []store.Job
-	for _, job := range ms.jobs {
-		if job.Status == store.JobDeadLetter {
-			deadLetter = append(deadLetter, job)
-		}
+	t, ok := ms.tags[id]
+	if !ok || t.OrgID != orgID {
+		return store.Tag{}, false, nil
 	}
-	return deadLetter, nil
+	return t, true, nil
 }
 
-func (m *jobStore) MoveToDeadLetter(_ context.Context, jobID string) error {
+func (m *tagStore) UpdateTag(_ context.Context, t store.Tag) (store.Tag, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	job, ok := ms.jobs[jobID]
-	if !ok {
-		return errors.New("job not found")
+	if existing, ok := ms.tags[t.ID]; !ok || existing.OrgID != t.OrgID {
+		return store.Tag{}, errNotFound
 	}
-	job.Status = store.JobDeadLetter
-	job.UpdatedAt = time.Now().UTC()
-	ms.jobs[jobID] = job
-	return nil
+	t.UpdatedAt = time.Now().UTC()
+	ms.tags[t.ID] = t
+	return t, nil
 }
 
-func (m *jobStore) RetryDeadLetterJob(_ context.Context, jobID string) error {
+func (m *tagStore) DeleteTag(_ context.Context, orgID, id string) (bool, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	job, ok := ms.jobs[jobID]
-	if !ok {
-		return errors.New("job not found")
+	if t, ok := ms.tags[id]; !ok || t.OrgID != orgID {
+		return false, nil
 	}
-	job.Status = store.JobQueued
-	job.RetryCount = 0
-	job.Error = ""
-	job.UpdatedAt = time.Now().UTC()
-	ms.jobs[jobID] = job
-	return nil
+	delete(ms.tags, id)
+	kept := ms.tagLinks[:0]
+	for _, l := range ms.tagLinks {
+		if l.TagID != id {
+			kept = append(kept, l)
+		}
+	}
+	ms.tagLinks = kept
+	return true, nil
 }
 
-func (m *jobStore) ListByInputRef(_ context.Context, orgID, inputRef string, jobType store.JobType) ([]store.Job, error) {
+func (m *tagStore) Attach(_ context.Context, rt store.ResourceTag) error {
 	ms := (*MemoryStore)(m)
-	ms.mu.RLock()
-	defer ms.mu.RUnlock()
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
 
-	var result []store.Job
-	for _, job := range ms.jobs {
-		if job.OrgID == orgID && job.InputRef == inputRef && job.Type == jobType {
-			result = append(result, job)
+	for _, l := range ms.tagLinks {
+		if l.TagID == rt.TagID && l.ResourceType == rt.ResourceType && l.ResourceID == rt.ResourceID {
+			return nil
 		}
 	}
-
-	// Sort by UpdatedAt descending (most recent first)
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].UpdatedAt.After(result[j].UpdatedAt)
-	})
-
-	return result, nil
+	rt.CreatedAt = time.Now().UTC()
+	ms.tagLinks = append(ms.tagLinks, rt)
+	return nil
 }
 
-func (m *meteringStore) Record(_ context.Context, e store.MeteringEvent) (store.MeteringEvent, error) {
+func (m *tagStore) Detach(_ context.Context, orgID, tagID string, rt store.ResourceType, resourceID string) (bool, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	e.CreatedAt = time.Now().UTC()
-	ms.metering = append(ms.metering, e)
-	return e, nil
+	for i, l := range ms.tagLinks {
+		if l.OrgID == orgID && l.TagID == tagID && l.ResourceType == rt && l.ResourceID == resourceID {
+			ms.tagLinks = append(ms.tagLinks[:i], ms.tagLinks[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
-func (m *meteringStore) SumByType(_ context.Context, orgID string, eventType string) (int, error) {
+func (m *tagStore) ListAttached(_ context.Context, orgID string, rt store.ResourceType) (map[string][]store.Tag, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	sum := 0
-	for _, e := range ms.metering {
-		if e.OrgID == orgID && e.Type == eventType {
-			sum += e.Quantity
+	out := map[string][]store.Tag{}
+	for _, l := range ms.tagLinks {
+		if l.OrgID != orgID || l.ResourceType != rt {
+			continue
+		}
+		if t, ok := ms.tags[l.TagID]; ok {
+			out[l.ResourceID] = append(out[l.ResourceID], t)
 		}
 	}
-	return sum, nil
+	for _, tags := range out {
+		sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	}
+	return out, nil
 }
 
-func (m *auditStore) Append(_ context.Context, a store.AuditLog) (store.AuditLog, error) {
+func (m *folderStore) CreateFolder(_ context.Context, f store.Folder) (store.Folder, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if f.CreatedAt.IsZero() {
+		f.CreatedAt = time.Now().UTC()
+	}
+	f.UpdatedAt = f.CreatedAt
+	ms.folders[f.ID] = f
+	return f, nil
+}
+
+func (m *folderStore) ListFolders(_ context.Context, orgID string) ([]store.Folder, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.Folder{}
+	for _, f := range ms.folders {
+		if f.OrgID == orgID {
+			out = append(out, f)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func (m *folderStore) GetFolder(_ context.Context, orgID, id string) (store.Folder, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	f, ok := ms.folders[id]
+	if !ok || f.OrgID != orgID {
+		return store.Folder{}, false, nil
+	}
+	return f, true, nil
+}
+
+func (m *folderStore) UpdateFolder(_ context.Context, f store.Folder) (store.Folder, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if existing, ok := ms.folders[f.ID]; !ok || existing.OrgID != f.OrgID {
+		return store.Folder{}, errNotFound
+	}
+	f.UpdatedAt = time.Now().UTC()
+	ms.folders[f.ID] = f
+	return f, nil
+}
+
+func (m *folderStore) DeleteFolder(_ context.Context, orgID, id string) (bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if f, ok := ms.folders[id]; !ok || f.OrgID != orgID {
+		return false, nil
+	}
+	delete(ms.folders, id)
+	return true, nil
+}
+
+func (m *brandKitStore) Create(_ context.Context, b store.BrandKit) (store.BrandKit, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	b.CreatedAt = time.Now().UTC()
+	ms.brandKits[b.ID] = b
+	return b, nil
+}
+
+func (m *brandKitStore) List(_ context.Context, orgID string) ([]store.BrandKit, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.BrandKit{}
+	for _, b := range ms.brandKits {
+		if b.OrgID == orgID {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func (m *assetStore) Create(_ context.Context, a store.Asset) (store.Asset, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
 	a.CreatedAt = time.Now().UTC()
-	ms.audit = append(ms.audit, a)
+	if prev, ok := ms.assets[a.ID]; ok {
+		ms.storage[prev.OrgID] -= prev.SizeBytes
+	}
+	ms.assets[a.ID] = a
+	ms.storage[a.OrgID] += a.SizeBytes
 	return a, nil
 }
 
-func (m *userStore) CreateUser(_ context.Context, u *store.User) error {
+func (m *assetStore) Delete(_ context.Context, orgID, id string) (bool, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	now := time.Now().UTC()
-	u.CreatedAt = now
-	u.UpdatedAt = now
-	ms.users[u.ID] = *u
-	return nil
+	a, ok := ms.assets[id]
+	if !ok || a.OrgID != orgID {
+		return false, nil
+	}
+	delete(ms.assets, id)
+	delete(ms.assetData, id)
+	ms.storage[orgID] -= a.SizeBytes
+	if ms.storage[orgID] < 0 {
+		ms.storage[orgID] = 0
+	}
+	return true, nil
 }
 
-func (m *userStore) GetUser(_ context.Context, userID string) (store.User, bool, error) {
+func (m *assetStore) Get(_ context.Context, orgID, id string) (store.Asset, bool, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	user, ok := ms.users[userID]
-	return user, ok, nil
+	a, ok := ms.assets[id]
+	if !ok || a.OrgID != orgID {
+		return store.Asset{}, false, nil
+	}
+	return a, true, nil
 }
 
-func (m *userStore) GetUserByEmail(_ context.Context, email string) (store.User, bool, error) {
+func (m *assetStore) GetAssets(_ context.Context, orgID string, ids []string) (map[string]store.Asset, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	for _, user := range ms.users {
-		if user.Email == email {
-			return user, true, nil
+	out := make(map[string]store.Asset, len(ids))
+	for _, id := range ids {
+		if a, ok := ms.assets[id]; ok && a.OrgID == orgID {
+			out[id] = a
 		}
 	}
-	return store.User{}, false, nil
+	return out, nil
 }
 
-func (m *userStore) CreateUserOrg(_ context.Context, uo store.UserOrg) error {
+func (m *assetStore) List(_ context.Context, orgID string, f store.AssetFilter) ([]store.Asset, int, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var matched []store.Asset
+	for _, a := range ms.assets {
+		if a.OrgID != orgID {
+			continue
+		}
+		if f.Type != "" && a.Type != f.Type {
+			continue
+		}
+		if f.SourceJobID != "" && a.SourceJobID != f.SourceJobID {
+			continue
+		}
+		if f.CreatedAfter != nil && a.CreatedAt.Before(*f.CreatedAfter) {
+			continue
+		}
+		if f.CreatedBefore != nil && !a.CreatedAt.Before(*f.CreatedBefore) {
+			continue
+		}
+		matched = append(matched, a)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.After(matched[j].CreatedAt)
+	})
+
+	total := len(matched)
+	if f.Offset > 0 {
+		if f.Offset >= len(matched) {
+			return []store.Asset{}, total, nil
+		}
+		matched = matched[f.Offset:]
+	}
+	if f.Limit > 0 && f.Limit < len(matched) {
+		matched = matched[:f.Limit]
+	}
+	return matched, total, nil
+}
+
+func (m *assetStore) TotalBytes(_ context.Context, orgID string) (int64, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	return ms.storage[orgID], nil
+}
+
+func (m *jobStore) Enqueue(_ context.Context, j store.Job) (store.Job, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	ms.userOrgs = append(ms.userOrgs, uo)
-	return nil
+	if j.Priority == store.JobPriorityNormal {
+		j.Priority = store.DefaultJobPriority(j.Type)
+	}
+	now := time.Now().UTC()
+	j.CreatedAt = now
+	j.UpdatedAt = now
+	ms.jobs[j.ID] = j
+	return j, nil
 }
 
-func (m *userStore) ListUserOrgs(_ context.Context, userID string) ([]store.UserOrg, error) {
+func (m *jobStore) EnqueueWithDeduplication(_ context.Context, j store.Job) (store.Job, bool, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	var result []store.UserOrg
-	for _, uo := range ms.userOrgs {
-		if uo.UserID == userID {
-			result = append(result, uo)
+	if j.DeduplicationID != "" {
+		var latestJob *store.Job
+		for _, existingJob := range ms.jobs {
+			if existingJob.OrgID == j.OrgID && existingJob.DeduplicationID == j.DeduplicationID {
+				if latestJob == nil || existingJob.CreatedAt.After(latestJob.CreatedAt) {
+					latestJob = &existingJob
+				}
+			}
+		}
+		if latestJob != nil {
+			// If job is still in progress, return existing job
+			if latestJob.Status == store.JobQueued || latestJob.Status == store.JobRunning || latestJob.Status == store.JobRetry {
+				return *latestJob, true, nil
+			}
+			// If job is completed successfully, return it immediately
+			if latestJob.Status == store.JobDone {
+				return *latestJob, true, nil
+			}
+			// If job failed permanently, allow creating a new one
 		}
 	}
-	return result, nil
+
+	if j.Priority == store.JobPriorityNormal {
+		j.Priority = store.DefaultJobPriority(j.Type)
+	}
+	now := time.Now().UTC()
+	j.CreatedAt = now
+	j.UpdatedAt = now
+	ms.jobs[j.ID] = j
+	return j, false, nil
 }
 
-func (m *organizationStore) CreateOrganization(_ context.Context, o *store.Organization) error {
+func (m *jobStore) Get(_ context.Context, orgID, jobID string) (store.Job, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	j, ok := ms.jobs[jobID]
+	if !ok || j.OrgID != orgID {
+		return store.Job{}, false, nil
+	}
+	return j, true, nil
+}
+
+func (m *jobStore) GetByDeduplicationID(_ context.Context, orgID, dedupID string) (store.Job, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	// The newest match wins, as in the Postgres store.
+	var latest store.Job
+	found := false
+	for _, job := range ms.jobs {
+		if job.OrgID == orgID && job.DeduplicationID == dedupID && (!found || job.CreatedAt.After(latest.CreatedAt)) {
+			latest, found = job, true
+		}
+	}
+	return latest, found, nil
+}
+
+func (m *jobStore) Update(_ context.Context, j store.Job) (store.Job, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	existing, ok := ms.jobs[j.ID]
+	if !ok {
+		return store.Job{}, errors.New("not found")
+	}
+	j.HeartbeatAt = existing.HeartbeatAt
+	j.UpdatedAt = time.Now().UTC()
+	ms.jobs[j.ID] = j
+	return j, nil
+}
+
+func (m *jobStore) Heartbeat(_ context.Context, jobID string) error {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
+	j, ok := ms.jobs[jobID]
+	if !ok || j.Status != store.JobRunning {
+		return nil
+	}
 	now := time.Now().UTC()
-	o.CreatedAt = now
-	o.UpdatedAt = now
-	ms.orgs[o.ID] = *o
+	j.HeartbeatAt = &now
+	j.UpdatedAt = now
+	ms.jobs[jobID] = j
 	return nil
 }
 
-func (m *organizationStore) GetOrganization(_ context.Context, orgID string) (store.Organization, error) {
+func (m *jobStore) ListQueued(_ context.Context) ([]store.Job, error) {
 	ms := (*MemoryStore)(m)
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	org, ok := ms.orgs[orgID]
+	var queued []store.Job
+	for _, job := range ms.jobs {
+		if job.Status == store.JobQueued {
+			queued = append(queued, job)
+		}
+	}
+	sort.SliceStable(queued, func(i, j int) bool { return store.JobRunsBefore(queued[i], queued[j]) })
+	return queued, nil
+}
+
+func (m *jobStore) ListRetry(_ context.Context) ([]store.Job, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var retry []store.Job
+	for _, job := range ms.jobs {
+		if job.Status == store.JobRetry {
+			retry = append(retry, job)
+		}
+	}
+	return retry, nil
+}
+
+func (m *jobStore) ListDeadLetter(_ context.Context) ([]store.Job, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var deadLetter []store.Job
+	for _, job := range ms.jobs {
+		if job.Status == store.JobDeadLetter {
+			deadLetter = append(deadLetter, job)
+		}
+	}
+	return deadLetter, nil
+}
+
+func (m *jobStore) MoveToDeadLetter(_ context.Context, jobID string) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	job, ok := ms.jobs[jobID]
 	if !ok {
-		return store.Organization{}, errNotFound
+		return errors.New("job not found")
+	}
+	job.Status = store.JobDeadLetter
+	job.UpdatedAt = time.Now().UTC()
+	ms.jobs[jobID] = job
+	return nil
+}
+
+func (m *jobStore) RetryDeadLetterJob(_ context.Context, jobID string) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	job, ok := ms.jobs[jobID]
+	if !ok {
+		return errors.New("job not found")
+	}
+	job.Status = store.JobQueued
+	job.RetryCount = 0
+	job.Error = ""
+	job.UpdatedAt = time.Now().UTC()
+	ms.jobs[jobID] = job
+	return nil
+}
+
+func (m *jobStore) List(_ context.Context, f store.JobFilter) ([]store.Job, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var out []store.Job
+	for _, job := range ms.jobs {
+		if f.OrgID != "" && job.OrgID != f.OrgID {
+			continue
+		}
+		if f.Status != "" && job.Status != f.Status {
+			continue
+		}
+		if f.Type != "" && job.Type != f.Type {
+			continue
+		}
+		if len(f.IDs) > 0 && !slices.Contains(f.IDs, job.ID) {
+			continue
+		}
+		out = append(out, job)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[:f.Limit]
+	}
+	return out, nil
+}
+
+func (m *jobStore) DeleteDeadLetter(_ context.Context, jobIDs []string) (int, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	deleted := 0
+	for _, id := range jobIDs {
+		if job, ok := ms.jobs[id]; ok && job.Status == store.JobDeadLetter {
+			delete(ms.jobs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *jobStore) ListByInputRef(_ context.Context, orgID, inputRef string, jobType store.JobType) ([]store.Job, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var result []store.Job
+	for _, job := range ms.jobs {
+		if job.OrgID == orgID && job.InputRef == inputRef && job.Type == jobType {
+			result = append(result, job)
+		}
+	}
+
+	// Sort by UpdatedAt descending (most recent first)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UpdatedAt.After(result[j].UpdatedAt)
+	})
+
+	return result, nil
+}
+
+func (m *meteringStore) Record(_ context.Context, e store.MeteringEvent) (store.MeteringEvent, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	e.CreatedAt = time.Now().UTC()
+	ms.metering = append(ms.metering, e)
+	return e, nil
+}
+
+func (m *meteringStore) SumByType(_ context.Context, orgID string, eventType string) (int, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	sum := 0
+	for _, e := range ms.metering {
+		if e.OrgID == orgID && e.Type == eventType {
+			sum += e.Quantity
+		}
+	}
+	return sum, nil
+}
+
+func (m *meteringStore) SumByTarget(_ context.Context, orgID string, eventType string) (map[string]int, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	sums := map[string]int{}
+	for _, e := range ms.metering {
+		if e.OrgID == orgID && e.Type == eventType && e.TargetRef != "" {
+			sums[e.TargetRef] += e.Quantity
+		}
+	}
+	return sums, nil
+}
+
+func (m *meteringStore) SumForTarget(_ context.Context, orgID, eventType, targetRef string) (int, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	sum := 0
+	for _, e := range ms.metering {
+		if e.OrgID == orgID && e.Type == eventType && e.TargetRef == targetRef {
+			sum += e.Quantity
+		}
+	}
+	return sum, nil
+}
+
+func (m *auditStore) Append(_ context.Context, a store.AuditLog) (store.AuditLog, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	a.CreatedAt = time.Now().UTC()
+	a.Metadata = redact.Value(a.Metadata)
+	ms.audit = append(ms.audit, a)
+	return a, nil
+}
+
+func (m *auditStore) List(_ context.Context, orgID string) ([]store.AuditLog, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var out []store.AuditLog
+	for _, a := range ms.audit {
+		if a.OrgID == orgID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (m *userStore) CreateUser(_ context.Context, u *store.User) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now().UTC()
+	u.CreatedAt = now
+	u.UpdatedAt = now
+	ms.users[u.ID] = *u
+	return nil
+}
+
+func (m *userStore) GetUser(_ context.Context, userID string) (store.User, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[userID]
+	return user, ok, nil
+}
+
+func (m *userStore) GetUserByEmail(_ context.Context, email string) (store.User, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, user := range ms.users {
+		if user.Email == email {
+			return user, true, nil
+		}
+	}
+	return store.User{}, false, nil
+}
+
+func (m *userStore) CreateUserOrg(_ context.Context, uo store.UserOrg) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.userOrgs = append(ms.userOrgs, uo)
+	return nil
+}
+
+func (m *userStore) ListUserOrgs(_ context.Context, userID string) ([]store.UserOrg, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var result []store.UserOrg
+	for _, uo := range ms.userOrgs {
+		if uo.UserID == userID {
+			result = append(result, uo)
+		}
+	}
+	return result, nil
+}
+
+func (m *userStore) UpdateUser(_ context.Context, u store.User) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	existing, ok := ms.users[u.ID]
+	if !ok {
+		return errNotFound
+	}
+	u.CreatedAt = existing.CreatedAt
+	u.UpdatedAt = time.Now().UTC()
+	ms.users[u.ID] = u
+	return nil
+}
+
+func (m *userStore) ListOrgMembers(_ context.Context, orgID string) ([]store.UserOrg, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var result []store.UserOrg
+	for _, uo := range ms.userOrgs {
+		if uo.OrgID == orgID {
+			result = append(result, uo)
+		}
+	}
+	return result, nil
+}
+
+func (m *userStore) SetUserOrgRole(_ context.Context, userID, orgID string, role auth.Role) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for i, uo := range ms.userOrgs {
+		if uo.UserID == userID && uo.OrgID == orgID {
+			ms.userOrgs[i].Role = role
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (m *userStore) DeleteUserOrg(_ context.Context, userID, orgID string) (bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for i, uo := range ms.userOrgs {
+		if uo.UserID == userID && uo.OrgID == orgID {
+			ms.userOrgs = append(ms.userOrgs[:i], ms.userOrgs[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *organizationStore) CreateOrganization(_ context.Context, o *store.Organization) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now().UTC()
+	o.CreatedAt = now
+	o.UpdatedAt = now
+	ms.orgs[o.ID] = *o
+	return nil
+}
+
+func (m *organizationStore) GetOrganization(_ context.Context, orgID string) (store.Organization, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	org, ok := ms.orgs[orgID]
+	if !ok {
+		return store.Organization{}, errNotFound
 	}
 	return org, nil
 }
+
+func (m *organizationStore) SetGenerationDefaults(_ context.Context, orgID string, defaults *store.GenerationParams) (store.Organization, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	org, ok := ms.orgs[orgID]
+	if !ok {
+		return store.Organization{}, errNotFound
+	}
+	org.GenerationDefaults = defaults
+	org.UpdatedAt = time.Now().UTC()
+	ms.orgs[orgID] = org
+	return org, nil
+}
+
+func (m *organizationStore) SetSSODomain(_ context.Context, orgID, domain, token string) (store.Organization, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	org, ok := ms.orgs[orgID]
+	if !ok {
+		return store.Organization{}, errNotFound
+	}
+	org.SSODomain, org.SSODomainToken, org.SSODomainVerifiedAt = domain, token, nil
+	org.UpdatedAt = time.Now().UTC()
+	ms.orgs[orgID] = org
+	return org, nil
+}
+
+func (m *organizationStore) VerifySSODomain(_ context.Context, orgID string) (store.Organization, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	org, ok := ms.orgs[orgID]
+	if !ok {
+		return store.Organization{}, errNotFound
+	}
+	now := time.Now().UTC()
+	org.SSODomainVerifiedAt = &now
+	org.UpdatedAt = now
+	ms.orgs[orgID] = org
+	return org, nil
+}
+
+func (m *organizationStore) SetExportFilenameTemplate(_ context.Context, orgID, tmpl string) (store.Organization, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	org, ok := ms.orgs[orgID]
+	if !ok {
+		return store.Organization{}, errNotFound
+	}
+	org.ExportFilenameTemplate = tmpl
+	org.UpdatedAt = time.Now().UTC()
+	ms.orgs[orgID] = org
+	return org, nil
+}
+
+func (m *organizationStore) SetDefaultRenderer(_ context.Context, orgID, engine string) (store.Organization, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	org, ok := ms.orgs[orgID]
+	if !ok {
+		return store.Organization{}, errNotFound
+	}
+	org.DefaultRenderer = engine
+	org.UpdatedAt = time.Now().UTC()
+	ms.orgs[orgID] = org
+	return org, nil
+}
+
+func (m *organizationStore) SetSettings(_ context.Context, orgID string, settings *store.OrgSettings) (store.Organization, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	org, ok := ms.orgs[orgID]
+	if !ok {
+		return store.Organization{}, errNotFound
+	}
+	org.Settings = settings
+	org.UpdatedAt = time.Now().UTC()
+	ms.orgs[orgID] = org
+	return org, nil
+}
+
+func (m *organizationStore) SetSCIMTokenHash(_ context.Context, orgID, hash string) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	org, ok := ms.orgs[orgID]
+	if !ok {
+		return errNotFound
+	}
+	org.SCIMTokenHash = hash
+	org.UpdatedAt = time.Now().UTC()
+	ms.orgs[orgID] = org
+	return nil
+}
+
+func (m *organizationStore) SetGoogleRefreshToken(_ context.Context, orgID, token string) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	org, ok := ms.orgs[orgID]
+	if !ok {
+		return errNotFound
+	}
+	org.GoogleRefreshToken = token
+	org.UpdatedAt = time.Now().UTC()
+	ms.orgs[orgID] = org
+	return nil
+}
+
+func (m *organizationStore) SetSlackIntegration(_ context.Context, orgID string, s *store.SlackIntegration) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	org, ok := ms.orgs[orgID]
+	if !ok {
+		return errNotFound
+	}
+	org.Slack = s
+	org.UpdatedAt = time.Now().UTC()
+	ms.orgs[orgID] = org
+	return nil
+}
+
+func (m *organizationStore) SetContentImport(_ context.Context, orgID string, creds *store.ContentImportCredentials) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	org, ok := ms.orgs[orgID]
+	if !ok {
+		return errNotFound
+	}
+	org.ContentImport = creds
+	org.UpdatedAt = time.Now().UTC()
+	ms.orgs[orgID] = org
+	return nil
+}
+
+func (m *organizationStore) SetPolicy(_ context.Context, orgID string, policy *store.OrgPolicy) (store.Organization, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	org, ok := ms.orgs[orgID]
+	if !ok {
+		return store.Organization{}, errNotFound
+	}
+	org.Policy = policy
+	org.UpdatedAt = time.Now().UTC()
+	ms.orgs[orgID] = org
+	return org, nil
+}
+
+func (m *organizationStore) SetStockMediaKeys(_ context.Context, orgID string, keys *store.StockMediaKeys) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	org, ok := ms.orgs[orgID]
+	if !ok {
+		return errNotFound
+	}
+	org.StockMedia = keys
+	org.UpdatedAt = time.Now().UTC()
+	ms.orgs[orgID] = org
+	return nil
+}
+
+func (m *organizationStore) Delete(_ context.Context, orgID string, purgeAfter time.Time) (store.Organization, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	org, ok := ms.orgs[orgID]
+	if !ok {
+		return store.Organization{}, errNotFound
+	}
+	now := time.Now().UTC()
+	purgeAfter = purgeAfter.UTC()
+	org.DeletedAt, org.PurgeAfter = &now, &purgeAfter
+	org.SSODomain, org.SSODomainToken, org.SSODomainVerifiedAt, org.SCIMTokenHash = "", "", nil, ""
+	org.UpdatedAt = now
+	ms.orgs[orgID] = org
+	for id, t := range ms.templates {
+		if t.OrgID == orgID && t.DeletedAt == nil {
+			t.DeletedAt = &now
+			ms.templates[id] = t
+		}
+	}
+	for id, d := range ms.decks {
+		if d.OrgID == orgID && d.DeletedAt == nil {
+			d.DeletedAt = &now
+			ms.decks[id] = d
+		}
+	}
+	return org, nil
+}
+
+func (m *organizationStore) ListPurgeable(_ context.Context, now time.Time) ([]store.Organization, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var out []store.Organization
+	for _, org := range ms.orgs {
+		if org.DeletedAt != nil && org.PurgeAfter != nil && !org.PurgeAfter.After(now) {
+			out = append(out, org)
+		}
+	}
+	return out, nil
+}
+
+func (m *organizationStore) Purge(_ context.Context, orgID string) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.orgs[orgID]; !ok {
+		return errNotFound
+	}
+	maps.DeleteFunc(ms.templates, func(_ string, t store.Template) bool { return t.OrgID == orgID })
+	maps.DeleteFunc(ms.versions, func(_ string, v store.TemplateVersion) bool { return v.OrgID == orgID })
+	maps.DeleteFunc(ms.decks, func(_ string, d store.Deck) bool { return d.OrgID == orgID })
+	maps.DeleteFunc(ms.deckVers, func(_ string, v store.DeckVersion) bool { return v.OrgID == orgID })
+	maps.DeleteFunc(ms.brandKits, func(_ string, b store.BrandKit) bool { return b.OrgID == orgID })
+	for id, a := range ms.assets {
+		if a.OrgID == orgID {
+			delete(ms.assets, id)
+			delete(ms.assetData, id)
+		}
+	}
+	maps.DeleteFunc(ms.jobs, func(_ string, j store.Job) bool { return j.OrgID == orgID })
+	maps.DeleteFunc(ms.comments, func(_ string, c store.Comment) bool { return c.OrgID == orgID })
+	maps.DeleteFunc(ms.refines, func(_ string, r store.DeckRefinement) bool { return r.OrgID == orgID })
+	maps.DeleteFunc(ms.tags, func(_ string, t store.Tag) bool { return t.OrgID == orgID })
+	maps.DeleteFunc(ms.folders, func(_ string, f store.Folder) bool { return f.OrgID == orgID })
+	maps.DeleteFunc(ms.hooks, func(_ string, w store.Webhook) bool { return w.OrgID == orgID })
+	maps.DeleteFunc(ms.hookDels, func(_ string, d store.WebhookDelivery) bool { return d.OrgID == orgID })
+	maps.DeleteFunc(ms.embeds, func(_ string, e store.DeckEmbed) bool { return e.OrgID == orgID })
+	maps.DeleteFunc(ms.apiKeys, func(_ string, k store.APIKey) bool { return k.OrgID == orgID })
+	maps.DeleteFunc(ms.fonts, func(_ string, f store.Font) bool { return f.OrgID == orgID })
+	maps.DeleteFunc(ms.glossary, func(_ string, e store.GlossaryEntry) bool { return e.OrgID == orgID })
+	maps.DeleteFunc(ms.schedules, func(_ string, s store.Schedule) bool { return s.OrgID == orgID })
+	maps.DeleteFunc(ms.conns, func(_ string, c store.Connector) bool { return c.OrgID == orgID })
+	maps.DeleteFunc(ms.genImages, func(_ string, g store.GeneratedImage) bool { return g.OrgID == orgID })
+	maps.DeleteFunc(ms.flags, func(_ string, f store.FeatureFlag) bool { return f.OrgID == orgID })
+	ms.metering = slices.DeleteFunc(ms.metering, func(e store.MeteringEvent) bool { return e.OrgID == orgID })
+	ms.audit = slices.DeleteFunc(ms.audit, func(a store.AuditLog) bool { return a.OrgID == orgID })
+	ms.aiCalls = slices.DeleteFunc(ms.aiCalls, func(c store.AICall) bool { return c.OrgID == orgID })
+	ms.userOrgs = slices.DeleteFunc(ms.userOrgs, func(uo store.UserOrg) bool { return uo.OrgID == orgID })
+	ms.perms = slices.DeleteFunc(ms.perms, func(p store.ResourcePermission) bool { return p.OrgID == orgID })
+	ms.tagLinks = slices.DeleteFunc(ms.tagLinks, func(l store.ResourceTag) bool { return l.OrgID == orgID })
+	delete(ms.storage, orgID)
+	delete(ms.orgs, orgID)
+	return nil
+}
+
+func (m *organizationStore) GetOrganizationBySCIMTokenHash(_ context.Context, hash string) (store.Organization, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, org := range ms.orgs {
+		if hash != "" && org.SCIMTokenHash == hash {
+			return org, true, nil
+		}
+	}
+	return store.Organization{}, false, nil
+}
+
+func (m *organizationStore) GetOrganizationBySSODomain(_ context.Context, domain string) (store.Organization, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, org := range ms.orgs {
+		if domain != "" && org.SSODomain == domain && org.SSODomainVerifiedAt != nil {
+			return org, true, nil
+		}
+	}
+	return store.Organization{}, false, nil
+}
+
+func (m *webhookStore) Create(_ context.Context, w store.Webhook) (store.Webhook, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if w.CreatedAt.IsZero() {
+		w.CreatedAt = time.Now().UTC()
+	}
+	w.UpdatedAt = w.CreatedAt
+	ms.hooks[w.ID] = w
+	return w, nil
+}
+
+func (m *webhookStore) Get(_ context.Context, orgID, id string) (store.Webhook, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	w, ok := ms.hooks[id]
+	if !ok || w.OrgID != orgID {
+		return store.Webhook{}, false, nil
+	}
+	return w, true, nil
+}
+
+func (m *webhookStore) List(_ context.Context, orgID string) ([]store.Webhook, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.Webhook{}
+	for _, w := range ms.hooks {
+		if w.OrgID == orgID {
+			out = append(out, w)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (m *webhookStore) Update(_ context.Context, w store.Webhook) (store.Webhook, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if existing, ok := ms.hooks[w.ID]; !ok || existing.OrgID != w.OrgID {
+		return store.Webhook{}, errNotFound
+	}
+	w.UpdatedAt = time.Now().UTC()
+	ms.hooks[w.ID] = w
+	return w, nil
+}
+
+func (m *webhookStore) Delete(_ context.Context, orgID, id string) (bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if w, ok := ms.hooks[id]; !ok || w.OrgID != orgID {
+		return false, nil
+	}
+	delete(ms.hooks, id)
+	for did, d := range ms.hookDels {
+		if d.WebhookID == id {
+			delete(ms.hookDels, did)
+		}
+	}
+	return true, nil
+}
+
+func (m *webhookStore) CreateDelivery(_ context.Context, d store.WebhookDelivery) (store.WebhookDelivery, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now().UTC()
+	}
+	d.UpdatedAt = d.CreatedAt
+	ms.hookDels[d.ID] = d
+	return d, nil
+}
+
+func (m *webhookStore) UpdateDelivery(_ context.Context, d store.WebhookDelivery) (store.WebhookDelivery, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if existing, ok := ms.hookDels[d.ID]; !ok || existing.OrgID != d.OrgID {
+		return store.WebhookDelivery{}, errNotFound
+	}
+	d.UpdatedAt = time.Now().UTC()
+	ms.hookDels[d.ID] = d
+	return d, nil
+}
+
+func (m *webhookStore) ListDeliveries(_ context.Context, orgID, webhookID string, limit int) ([]store.WebhookDelivery, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.WebhookDelivery{}
+	for _, d := range ms.hookDels {
+		if d.OrgID == orgID && d.WebhookID == webhookID {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (m *webhookStore) ListDueDeliveries(_ context.Context, now time.Time, limit int) ([]store.WebhookDelivery, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.WebhookDelivery{}
+	for _, d := range ms.hookDels {
+		if d.Status == store.WebhookDeliveryPending && !d.NextAttemptAt.After(now) {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].NextAttemptAt.Before(out[j].NextAttemptAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (m *embedStore) Create(_ context.Context, e store.DeckEmbed) (store.DeckEmbed, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	ms.embeds[e.ID] = e
+	return e, nil
+}
+
+func (m *embedStore) GetByTokenHash(_ context.Context, tokenHash string) (store.DeckEmbed, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, e := range ms.embeds {
+		if e.TokenHash == tokenHash {
+			return e, true, nil
+		}
+	}
+	return store.DeckEmbed{}, false, nil
+}
+
+func (m *embedStore) List(_ context.Context, orgID, deckID string) ([]store.DeckEmbed, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.DeckEmbed{}
+	for _, e := range ms.embeds {
+		if e.OrgID == orgID && e.DeckID == deckID {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (m *embedStore) Delete(_ context.Context, orgID, id string) (bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if e, ok := ms.embeds[id]; !ok || e.OrgID != orgID {
+		return false, nil
+	}
+	delete(ms.embeds, id)
+	return true, nil
+}
+
+func (m *embedStore) RecordView(_ context.Context, id string, at time.Time) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	e, ok := ms.embeds[id]
+	if !ok {
+		return errNotFound
+	}
+	e.ViewCount++
+	e.LastViewedAt = &at
+	ms.embeds[id] = e
+	return nil
+}
+
+func (m *apiKeyStore) Create(_ context.Context, k store.APIKey) (store.APIKey, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if k.CreatedAt.IsZero() {
+		k.CreatedAt = time.Now().UTC()
+	}
+	ms.apiKeys[k.ID] = k
+	return k, nil
+}
+
+func (m *apiKeyStore) GetByHash(_ context.Context, keyHash string) (store.APIKey, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, k := range ms.apiKeys {
+		if k.KeyHash == keyHash {
+			return k, true, nil
+		}
+	}
+	return store.APIKey{}, false, nil
+}
+
+func (m *apiKeyStore) List(_ context.Context, orgID, userID string) ([]store.APIKey, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.APIKey{}
+	for _, k := range ms.apiKeys {
+		if k.OrgID == orgID && (userID == "" || k.UserID == userID) {
+			out = append(out, k)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (m *apiKeyStore) Delete(_ context.Context, orgID, id string) (bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if k, ok := ms.apiKeys[id]; !ok || k.OrgID != orgID {
+		return false, nil
+	}
+	delete(ms.apiKeys, id)
+	return true, nil
+}
+
+func (m *apiKeyStore) MarkUsed(_ context.Context, id string, at time.Time) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	k, ok := ms.apiKeys[id]
+	if !ok {
+		return errNotFound
+	}
+	k.LastUsedAt = &at
+	ms.apiKeys[id] = k
+	return nil
+}
+
+type retryPolicyStore MemoryStore
+
+func (m *retryPolicyStore) List(_ context.Context) ([]store.RetryPolicyOverride, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := make([]store.RetryPolicyOverride, 0, len(ms.retries))
+	for _, o := range ms.retries {
+		out = append(out, o)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].JobType < out[j].JobType })
+	return out, nil
+}
+
+func (m *retryPolicyStore) Put(_ context.Context, o store.RetryPolicyOverride) (store.RetryPolicyOverride, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	o.UpdatedAt = time.Now().UTC()
+	ms.retries[o.JobType] = o
+	return o, nil
+}
+
+func (m *retryPolicyStore) Delete(_ context.Context, jobType store.JobType) (bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.retries[jobType]; !ok {
+		return false, nil
+	}
+	delete(ms.retries, jobType)
+	return true, nil
+}
+
+type featureFlagStore MemoryStore
+
+func (m *featureFlagStore) List(_ context.Context) ([]store.FeatureFlag, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := make([]store.FeatureFlag, 0, len(ms.flags))
+	for _, f := range ms.flags {
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].OrgID < out[j].OrgID
+	})
+	return out, nil
+}
+
+func (m *featureFlagStore) Put(_ context.Context, f store.FeatureFlag) (store.FeatureFlag, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	f.UpdatedAt = time.Now().UTC()
+	ms.flags[f.Name+"|"+f.OrgID] = f
+	return f, nil
+}
+
+func (m *featureFlagStore) Delete(_ context.Context, name, orgID string) (bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	key := name + "|" + orgID
+	if _, ok := ms.flags[key]; !ok {
+		return false, nil
+	}
+	delete(ms.flags, key)
+	return true, nil
+}
+
+type fontStore MemoryStore
+
+func (m *fontStore) Create(_ context.Context, f store.Font) (store.Font, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	f.CreatedAt = time.Now().UTC()
+	ms.fonts[f.ID] = f
+	return f, nil
+}
+
+func (m *fontStore) List(_ context.Context, orgID string) ([]store.Font, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.Font{}
+	for _, f := range ms.fonts {
+		if f.OrgID == orgID {
+			out = append(out, f)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Family != out[j].Family {
+			return out[i].Family < out[j].Family
+		}
+		return out[i].Style < out[j].Style
+	})
+	return out, nil
+}
+
+func (m *fontStore) Get(_ context.Context, orgID, id string) (store.Font, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	f, ok := ms.fonts[id]
+	if !ok || f.OrgID != orgID {
+		return store.Font{}, false, nil
+	}
+	return f, true, nil
+}
+
+func (m *fontStore) Delete(_ context.Context, orgID, id string) (bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	f, ok := ms.fonts[id]
+	if !ok || f.OrgID != orgID {
+		return false, nil
+	}
+	delete(ms.fonts, id)
+	return true, nil
+}
+
+type aiCallStore MemoryStore
+
+func (m *aiCallStore) Record(_ context.Context, c store.AICall) (store.AICall, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	c.CreatedAt = time.Now().UTC()
+	ms.aiCalls = append(ms.aiCalls, c)
+	return c, nil
+}
+
+func (m *aiCallStore) List(_ context.Context, f store.AICallFilter) ([]store.AICall, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	out := []store.AICall{}
+	for i := len(ms.aiCalls) - 1; i >= 0; i-- {
+		c := ms.aiCalls[i]
+		if (f.OrgID != "" && c.OrgID != f.OrgID) ||
+			(f.UserID != "" && c.UserID != f.UserID) ||
+			(f.Kind != "" && c.Kind != f.Kind) ||
+			(f.Model != "" && c.Model != f.Model) ||
+			(f.Success != nil && c.Success != *f.Success) ||
+			(f.Since != nil && c.CreatedAt.Before(*f.Since)) ||
+			(f.Until != nil && !c.CreatedAt.Before(*f.Until)) {
+			continue
+		}
+		out = append(out, c)
+		if f.Limit > 0 && len(out) == f.Limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+type glossaryStore MemoryStore
+
+func (m *glossaryStore) Create(_ context.Context, e store.GlossaryEntry) (store.GlossaryEntry, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	e.CreatedAt = time.Now().UTC()
+	e.UpdatedAt = e.CreatedAt
+	ms.glossary[e.ID] = e
+	return e, nil
+}
+
+func (m *glossaryStore) List(_ context.Context, orgID string) ([]store.GlossaryEntry, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.GlossaryEntry{}
+	for _, e := range ms.glossary {
+		if e.OrgID == orgID {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Term != out[j].Term {
+			return out[i].Term < out[j].Term
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func (m *glossaryStore) Get(_ context.Context, orgID, id string) (store.GlossaryEntry, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	e, ok := ms.glossary[id]
+	if !ok || e.OrgID != orgID {
+		return store.GlossaryEntry{}, false, nil
+	}
+	return e, true, nil
+}
+
+func (m *glossaryStore) Update(_ context.Context, e store.GlossaryEntry) (store.GlossaryEntry, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if prev, ok := ms.glossary[e.ID]; !ok || prev.OrgID != e.OrgID {
+		return store.GlossaryEntry{}, errNotFound
+	}
+	e.UpdatedAt = time.Now().UTC()
+	ms.glossary[e.ID] = e
+	return e, nil
+}
+
+func (m *glossaryStore) Delete(_ context.Context, orgID, id string) (bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	e, ok := ms.glossary[id]
+	if !ok || e.OrgID != orgID {
+		return false, nil
+	}
+	delete(ms.glossary, id)
+	return true, nil
+}
+
+type scheduleStore MemoryStore
+
+func (m *scheduleStore) Create(_ context.Context, s store.Schedule) (store.Schedule, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	s.CreatedAt = time.Now().UTC()
+	s.UpdatedAt = s.CreatedAt
+	ms.schedules[s.ID] = s
+	return s, nil
+}
+
+func (m *scheduleStore) List(_ context.Context, orgID string) ([]store.Schedule, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.Schedule{}
+	for _, s := range ms.schedules {
+		if s.OrgID == orgID {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func (m *scheduleStore) Get(_ context.Context, orgID, id string) (store.Schedule, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	s, ok := ms.schedules[id]
+	if !ok || s.OrgID != orgID {
+		return store.Schedule{}, false, nil
+	}
+	return s, true, nil
+}
+
+func (m *scheduleStore) Update(_ context.Context, s store.Schedule) (store.Schedule, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if prev, ok := ms.schedules[s.ID]; !ok || prev.OrgID != s.OrgID {
+		return store.Schedule{}, errNotFound
+	}
+	s.UpdatedAt = time.Now().UTC()
+	ms.schedules[s.ID] = s
+	return s, nil
+}
+
+func (m *scheduleStore) Delete(_ context.Context, orgID, id string) (bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	s, ok := ms.schedules[id]
+	if !ok || s.OrgID != orgID {
+		return false, nil
+	}
+	delete(ms.schedules, id)
+	return true, nil
+}
+
+func (m *scheduleStore) ListDue(_ context.Context, now time.Time) ([]store.Schedule, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.Schedule{}
+	for _, s := range ms.schedules {
+		if s.Enabled && s.NextRunAt != nil && !s.NextRunAt.After(now) {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].NextRunAt.Before(*out[j].NextRunAt) })
+	return out, nil
+}
+
+func (m *scheduleStore) Claim(_ context.Context, orgID, id string, prev, next time.Time) (bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	s, ok := ms.schedules[id]
+	if !ok || s.OrgID != orgID || s.NextRunAt == nil || !s.NextRunAt.Equal(prev) {
+		return false, nil
+	}
+	next = next.UTC()
+	s.NextRunAt = &next
+	ms.schedules[id] = s
+	return true, nil
+}
+
+type connectorStore MemoryStore
+
+func (m *connectorStore) Create(_ context.Context, c store.Connector) (store.Connector, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	c.CreatedAt = time.Now().UTC()
+	c.UpdatedAt = c.CreatedAt
+	ms.conns[c.ID] = c
+	return c, nil
+}
+
+func (m *connectorStore) List(_ context.Context, orgID string) ([]store.Connector, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.Connector{}
+	for _, c := range ms.conns {
+		if c.OrgID == orgID {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func (m *connectorStore) Get(_ context.Context, orgID, id string) (store.Connector, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	c, ok := ms.conns[id]
+	if !ok || c.OrgID != orgID {
+		return store.Connector{}, false, nil
+	}
+	return c, true, nil
+}
+
+func (m *connectorStore) GetByName(_ context.Context, orgID, name string) (store.Connector, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, c := range ms.conns {
+		if c.OrgID == orgID && c.Name == name {
+			return c, true, nil
+		}
+	}
+	return store.Connector{}, false, nil
+}
+
+func (m *connectorStore) Update(_ context.Context, c store.Connector) (store.Connector, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if prev, ok := ms.conns[c.ID]; !ok || prev.OrgID != c.OrgID {
+		return store.Connector{}, errNotFound
+	}
+	c.UpdatedAt = time.Now().UTC()
+	ms.conns[c.ID] = c
+	return c, nil
+}
+
+func (m *connectorStore) Delete(_ context.Context, orgID, id string) (bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	c, ok := ms.conns[id]
+	if !ok || c.OrgID != orgID {
+		return false, nil
+	}
+	delete(ms.conns, id)
+	return true, nil
+}
+
+type generatedImageStore MemoryStore
+
+func (m *generatedImageStore) Create(_ context.Context, g store.GeneratedImage) (store.GeneratedImage, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	g.CreatedAt = time.Now().UTC()
+	ms.genImages[g.ID] = g
+	return g, nil
+}
+
+func (m *generatedImageStore) GetByPromptHash(_ context.Context, orgID, hash string) (store.GeneratedImage, bool, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var found store.GeneratedImage
+	ok := false
+	for _, g := range ms.genImages {
+		if g.OrgID == orgID && g.PromptHash == hash && (!ok || g.CreatedAt.After(found.CreatedAt)) {
+			found, ok = g, true
+		}
+	}
+	return found, ok, nil
+}
+
+func (m *generatedImageStore) List(_ context.Context, orgID string) ([]store.GeneratedImage, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	out := []store.GeneratedImage{}
+	for _, g := range ms.genImages {
+		if g.OrgID == orgID {
+			out = append(out, g)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+type platformStore MemoryStore
+
+func (m *platformStore) RecordActivity(_ context.Context, userID string, at time.Time) error {
+	ms := (*MemoryStore)(m)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	day := at.UTC().Truncate(24 * time.Hour)
+	ms.activity[userID+"|"+day.Format(time.DateOnly)] = store.UserActivity{UserID: userID, Day: day}
+	return nil
+}
+
+func (m *platformStore) DailyActiveUsers(_ context.Context, since time.Time) ([]store.DailyCount, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	since = since.UTC().Truncate(24 * time.Hour)
+	counts := map[time.Time]int{}
+	for _, a := range ms.activity {
+		if !a.Day.Before(since) {
+			counts[a.Day]++
+		}
+	}
+	out := make([]store.DailyCount, 0, len(counts))
+	for day, n := range counts {
+		out = append(out, store.DailyCount{Day: day, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Day.Before(out[j].Day) })
+	return out, nil
+}
+
+func (m *platformStore) OrgCounts(_ context.Context, since time.Time) (store.OrgCounts, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var c store.OrgCounts
+	for _, o := range ms.orgs {
+		c.Total++
+		if o.DeletedAt != nil {
+			c.Deleted++
+		} else {
+			c.Active++
+		}
+		if !o.CreatedAt.Before(since) {
+			c.CreatedSince++
+		}
+	}
+	return c, nil
+}
+
+func (m *platformStore) JobCounts(_ context.Context, since time.Time) ([]store.JobCount, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	type key struct {
+		t store.JobType
+		s store.JobStatus
+	}
+	counts := map[key]int{}
+	for _, j := range ms.jobs {
+		if !j.CreatedAt.Before(since) {
+			counts[key{j.Type, j.Status}]++
+		}
+	}
+	out := make([]store.JobCount, 0, len(counts))
+	for k, n := range counts {
+		out = append(out, store.JobCount{Type: k.t, Status: k.s, Count: n})
+	}
+	return out, nil
+}
+
+func (m *platformStore) SumByOrg(_ context.Context, eventType string, since time.Time) (map[string]int, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	sums := map[string]int{}
+	for _, e := range ms.metering {
+		if e.Type == eventType && !e.CreatedAt.Before(since) {
+			sums[e.OrgID] += e.Quantity
+		}
+	}
+	return sums, nil
+}
+
+func (m *platformStore) StorageByOrg(_ context.Context) (map[string]int64, error) {
+	ms := (*MemoryStore)(m)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	out := map[string]int64{}
+	for orgID, n := range ms.storage {
+		if n > 0 {
+			out[orgID] = n
+		}
+	}
+	return out, nil
+}
@@ -2,10 +2,15 @@ package memory
 
 import (
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/auth"
 	"github.com/ziyad/cms-ai/server/internal/store"
 )
 
@@ -155,3 +160,409 @@ func TestJobDeduplication(t *testing.T) {
 	assert.False(t, isDup)
 	assert.Equal(t, "job-3", created4.ID)
 }
+
+func TestAssetStoreList(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	seed := []store.Asset{
+		{ID: "asset-1", OrgID: "org-1", Type: store.AssetPPTX, SizeBytes: 100, SourceJobID: "job-1"},
+		{ID: "asset-2", OrgID: "org-1", Type: store.AssetPNG, SizeBytes: 20, SourceJobID: "job-2"},
+		{ID: "asset-3", OrgID: "org-1", Type: store.AssetPNG, SizeBytes: 30, SourceJobID: "job-2"},
+		{ID: "asset-4", OrgID: "org-2", Type: store.AssetPPTX, SizeBytes: 500},
+	}
+	for _, a := range seed {
+		_, err := s.Assets().Create(ctx, a)
+		require.NoError(t, err)
+	}
+
+	all, total, err := s.Assets().List(ctx, "org-1", store.AssetFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, all, 3)
+
+	pngs, total, err := s.Assets().List(ctx, "org-1", store.AssetFilter{Type: store.AssetPNG})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, pngs, 2)
+
+	byJob, total, err := s.Assets().List(ctx, "org-1", store.AssetFilter{SourceJobID: "job-1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "asset-1", byJob[0].ID)
+
+	page, total, err := s.Assets().List(ctx, "org-1", store.AssetFilter{Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, page, 1)
+
+	bytes, err := s.Assets().TotalBytes(ctx, "org-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(150), bytes)
+}
+
+func TestWithTx_RollsBackOnError(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	require.NoError(t, s.WithTx(ctx, func(tx store.Store) error {
+		_, err := tx.Decks().CreateDeck(ctx, store.Deck{ID: "deck-kept", OrgID: "org-1", Name: "Kept"})
+		return err
+	}))
+
+	err := s.WithTx(ctx, func(tx store.Store) error {
+		if _, err := tx.Decks().CreateDeck(ctx, store.Deck{ID: "deck-dropped", OrgID: "org-1", Name: "Dropped"}); err != nil {
+			return err
+		}
+		if _, err := tx.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-dropped", Deck: "deck-dropped", OrgID: "org-1", VersionNo: 1}); err != nil {
+			return err
+		}
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+
+	decks, err := s.Decks().ListDecks(ctx, "org-1")
+	require.NoError(t, err)
+	require.Len(t, decks, 1)
+	assert.Equal(t, "deck-kept", decks[0].ID)
+	_, ok, err := s.Decks().GetDeckVersion(ctx, "org-1", "dv-dropped")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestDecks_ListedByMostRecentlyUpdated(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"deck-old", "deck-mid", "deck-new"} {
+		_, err := s.Decks().CreateDeck(ctx, store.Deck{ID: id, OrgID: "org-1", Name: id, CreatedAt: base.Add(time.Duration(i) * time.Hour)})
+		require.NoError(t, err)
+	}
+	d, _, _ := s.Decks().GetDeck(ctx, "org-1", "deck-old")
+	_, err := s.Decks().UpdateDeck(ctx, d)
+	require.NoError(t, err)
+
+	decks, err := s.Decks().ListDecks(ctx, "org-1")
+	require.NoError(t, err)
+	var ids []string
+	for _, d := range decks {
+		ids = append(ids, d.ID)
+	}
+	assert.Equal(t, []string{"deck-old", "deck-new", "deck-mid"}, ids)
+}
+
+func TestVersions_RejectDuplicateVersionNumbers(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	_, err := s.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-1", Template: "tpl-1", OrgID: "org-1", VersionNo: 1})
+	require.NoError(t, err)
+	_, err = s.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-2", Template: "tpl-1", OrgID: "org-1", VersionNo: 1})
+	assert.ErrorIs(t, err, errDuplicateVersion)
+	_, err = s.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-2", Template: "tpl-1", OrgID: "org-1", VersionNo: 2})
+	require.NoError(t, err)
+
+	_, err = s.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: "deck-1", OrgID: "org-1", VersionNo: 1})
+	require.NoError(t, err)
+	_, err = s.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-2", Deck: "deck-1", OrgID: "org-1", VersionNo: 1})
+	assert.ErrorIs(t, err, errDuplicateVersion)
+
+	versions, err := s.Templates().ListVersions(ctx, "org-1", "tpl-1")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].VersionNo)
+}
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.json")
+
+	s := New()
+	_, err := s.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: "org-1", Name: "Kept"})
+	require.NoError(t, err)
+	_, err = s.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: "deck-1", OrgID: "org-1", VersionNo: 1, SpecJSON: json.RawMessage(`{"layouts":[]}`)})
+	require.NoError(t, err)
+	require.NoError(t, s.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Acme"}))
+	require.NoError(t, s.Organizations().SetSCIMTokenHash(ctx, "org-1", "hash"))
+	require.NoError(t, s.SaveSnapshot(path))
+
+	restored := New()
+	require.NoError(t, restored.LoadSnapshot(path))
+	d, ok, err := restored.Decks().GetDeck(ctx, "org-1", "deck-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "Kept", d.Name)
+	_, ok, _ = restored.Decks().GetDeckVersion(ctx, "org-1", "dv-1")
+	assert.True(t, ok)
+	org, ok, err := restored.Organizations().GetOrganizationBySCIMTokenHash(ctx, "hash")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "org-1", org.ID)
+
+	require.NoError(t, New().LoadSnapshot(filepath.Join(t.TempDir(), "missing.json")))
+}
+
+// Snapshots written while SpecJSON was an `any` hold []byte specs as base64.
+func TestSnapshot_DecodesLegacySpecs(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.json")
+	spec := `{"layouts":[{"name":"Title"}],"specVersion":2}`
+	legacy, err := json.Marshal([]byte(spec))
+	require.NoError(t, err)
+	data := `{"format":1,"versions":{"tv-1":{"id":"tv-1","orgId":"org-1","spec":` + string(legacy) + `}},` +
+		`"deckVersions":{"dv-1":{"id":"dv-1","orgId":"org-1","spec":` + spec + `}}}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	s := New()
+	require.NoError(t, s.LoadSnapshot(path))
+	tv, ok, err := s.Templates().GetVersion(ctx, "org-1", "tv-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.JSONEq(t, spec, string(tv.SpecJSON))
+	dv, ok, err := s.Decks().GetDeckVersion(ctx, "org-1", "dv-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.JSONEq(t, spec, string(dv.SpecJSON))
+}
+
+func TestVersions_MigrateSpecsOnRead(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	v1 := json.RawMessage(`{"layouts":[{"name":"Title","placeholders":[{"id":"title"},{"id":"logo","type":"image"}]}]}`)
+	const v2 = `{"specVersion":2,"layouts":[{"name":"Title","placeholders":[{"id":"title","type":"text"},{"id":"logo","type":"image"}]}]}`
+	_, err := s.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-1", Template: "tpl-1", OrgID: "org-1", SpecJSON: v1})
+	require.NoError(t, err)
+	_, err = s.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: "deck-1", OrgID: "org-1", SpecJSON: v1})
+	require.NoError(t, err)
+
+	tv, _, err := s.Templates().GetVersion(ctx, "org-1", "tv-1")
+	require.NoError(t, err)
+	assert.JSONEq(t, v2, string(tv.SpecJSON))
+	tvs, err := s.Templates().ListVersions(ctx, "org-1", "tpl-1")
+	require.NoError(t, err)
+	assert.JSONEq(t, v2, string(tvs[0].SpecJSON))
+	dv, _, err := s.Decks().GetDeckVersion(ctx, "org-1", "dv-1")
+	require.NoError(t, err)
+	assert.JSONEq(t, v2, string(dv.SpecJSON))
+	dvs, err := s.Decks().ListDeckVersions(ctx, "org-1", "deck-1")
+	require.NoError(t, err)
+	assert.JSONEq(t, v2, string(dvs[0].SpecJSON))
+}
+
+func TestBatchReads(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	current := "tv-2"
+	for _, tpl := range []store.Template{
+		{ID: "tpl-1", OrgID: "org-1", OwnerUserID: "user-1", Name: "Brand", CurrentVersion: &current},
+		{ID: "tpl-2", OrgID: "org-1", OwnerUserID: "user-1", Name: "Draft"},
+		{ID: "tpl-3", OrgID: "org-1", OwnerUserID: "user-2", Name: "Private", Visibility: store.VisibilityPrivate},
+	} {
+		_, err := s.Templates().CreateTemplate(ctx, tpl)
+		require.NoError(t, err)
+	}
+	for _, v := range []store.TemplateVersion{
+		{ID: "tv-1", Template: "tpl-1", OrgID: "org-1", VersionNo: 1},
+		{ID: "tv-2", Template: "tpl-1", OrgID: "org-1", VersionNo: 2},
+		{ID: "tv-other", Template: "tpl-x", OrgID: "org-2", VersionNo: 1},
+	} {
+		_, err := s.Templates().CreateVersion(ctx, v)
+		require.NoError(t, err)
+	}
+	for _, a := range []store.Asset{{ID: "a-1", OrgID: "org-1"}, {ID: "a-2", OrgID: "org-1"}, {ID: "a-other", OrgID: "org-2"}} {
+		_, err := s.Assets().Create(ctx, a)
+		require.NoError(t, err)
+	}
+
+	vs, err := s.Templates().GetVersions(ctx, "org-1", []string{"tv-1", "tv-2", "tv-other", "tv-missing"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"tv-1", "tv-2"}, mapKeys(vs), "other orgs' and missing versions are left out")
+
+	as, err := s.Assets().GetAssets(ctx, "org-1", []string{"a-2", "a-other"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a-2"}, mapKeys(as))
+
+	tpls, err := s.Templates().ListTemplatesWithCurrentVersion(ctx, auth.Identity{UserID: "user-1", OrgID: "org-1", Role: auth.RoleEditor})
+	require.NoError(t, err)
+	require.Len(t, tpls, 2, "templates the caller can't view are left out")
+	for _, tpl := range tpls {
+		if tpl.ID == "tpl-1" {
+			require.NotNil(t, tpl.Version)
+			assert.Equal(t, 2, tpl.Version.VersionNo)
+		} else {
+			assert.Nil(t, tpl.Version)
+		}
+	}
+}
+
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestListQueued_OrdersByPriorityThenAge(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	for _, j := range []store.Job{
+		{ID: "bulk", OrgID: "org-1", Type: store.JobBulkExport, Status: store.JobQueued},
+		{ID: "generate", OrgID: "org-1", Type: store.JobGenerate, Status: store.JobQueued},
+		{ID: "preview", OrgID: "org-1", Type: store.JobPreview, Status: store.JobQueued},
+		{ID: "export", OrgID: "org-1", Type: store.JobExport, Status: store.JobQueued},
+	} {
+		_, err := s.Jobs().Enqueue(ctx, j)
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+	}
+
+	queued, err := s.Jobs().ListQueued(ctx)
+	require.NoError(t, err)
+	var ids []string
+	for _, j := range queued {
+		ids = append(ids, j.ID)
+	}
+	assert.Equal(t, []string{"preview", "export", "generate", "bulk"}, ids)
+	assert.Equal(t, store.JobPriorityLow, queued[3].Priority)
+}
+
+func TestJobHeartbeat(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	job, err := s.Jobs().Enqueue(ctx, store.Job{ID: "job-hb", OrgID: "org-1", Type: store.JobExport, Status: store.JobQueued})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Jobs().Heartbeat(ctx, job.ID))
+	got, _, _ := s.Jobs().Get(ctx, "org-1", job.ID)
+	assert.Nil(t, got.HeartbeatAt, "only running jobs heartbeat")
+
+	job.Status = store.JobRunning
+	_, err = s.Jobs().Update(ctx, job)
+	require.NoError(t, err)
+	require.NoError(t, s.Jobs().Heartbeat(ctx, job.ID))
+	got, _, _ = s.Jobs().Get(ctx, "org-1", job.ID)
+	require.NotNil(t, got.HeartbeatAt)
+	beat := *got.HeartbeatAt
+
+	// A progress update from a stale copy of the job keeps the heartbeat.
+	job.ProgressStep = "Rendering"
+	_, err = s.Jobs().Update(ctx, job)
+	require.NoError(t, err)
+	got, _, _ = s.Jobs().Get(ctx, "org-1", job.ID)
+	require.NotNil(t, got.HeartbeatAt)
+	assert.Equal(t, beat, *got.HeartbeatAt)
+	assert.Equal(t, "Rendering", got.ProgressStep)
+}
+
+func TestOrganizationDeleteAndPurge(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	for _, id := range []string{"org-1", "org-2"} {
+		require.NoError(t, s.Organizations().CreateOrganization(ctx, &store.Organization{ID: id, Name: id, SSODomain: id + ".com"}))
+		_, err := s.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-" + id, OrgID: id, Name: "T"})
+		require.NoError(t, err)
+		_, err = s.Decks().CreateDeck(ctx, store.Deck{ID: "deck-" + id, OrgID: id, Name: "D"})
+		require.NoError(t, err)
+		_, err = s.Audit().Append(ctx, store.AuditLog{ID: "aud-" + id, OrgID: id, Action: "template.create"})
+		require.NoError(t, err)
+		require.NoError(t, s.Users().CreateUserOrg(ctx, store.UserOrg{UserID: "user-1", OrgID: id, Role: auth.RoleOwner}))
+	}
+
+	purgeAfter := time.Now().Add(time.Hour)
+	org, err := s.Organizations().Delete(ctx, "org-1", purgeAfter)
+	require.NoError(t, err)
+	require.NotNil(t, org.DeletedAt)
+	assert.Empty(t, org.SSODomain)
+	tpl, _, _ := s.Templates().GetTemplate(ctx, "org-1", "tpl-org-1")
+	assert.NotNil(t, tpl.DeletedAt)
+	deck, _, _ := s.Decks().GetDeck(ctx, "org-1", "deck-org-1")
+	assert.NotNil(t, deck.DeletedAt)
+	other, _, _ := s.Decks().GetDeck(ctx, "org-2", "deck-org-2")
+	assert.Nil(t, other.DeletedAt)
+
+	due, err := s.Organizations().ListPurgeable(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, due, "purge isn't due until purgeAfter")
+	due, err = s.Organizations().ListPurgeable(ctx, purgeAfter)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+
+	require.NoError(t, s.Organizations().Purge(ctx, "org-1"))
+	_, err = s.Organizations().GetOrganization(ctx, "org-1")
+	assert.Error(t, err)
+	_, found, _ := s.Templates().GetTemplate(ctx, "org-1", "tpl-org-1")
+	assert.False(t, found)
+	logs, err := s.Audit().List(ctx, "org-1")
+	require.NoError(t, err)
+	assert.Empty(t, logs)
+	orgs, err := s.Users().ListUserOrgs(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, orgs, 1)
+	assert.Equal(t, "org-2", orgs[0].OrgID)
+
+	logs, err = s.Audit().List(ctx, "org-2")
+	require.NoError(t, err)
+	assert.Len(t, logs, 1, "other orgs are untouched")
+}
+
+func TestAudit_RedactsMetadata(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	_, err := s.Audit().Append(ctx, store.AuditLog{ID: "aud-1", OrgID: "org-1", Action: "template.generate", Metadata: map[string]any{"prompt": "Deck for bob@example.com, call 555-123-4567", "sync": true}})
+	require.NoError(t, err)
+
+	logs, err := s.Audit().List(ctx, "org-1")
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, map[string]any{"prompt": "Deck for [REDACTED], call [REDACTED]", "sync": true}, logs[0].Metadata)
+}
+
+func TestListDecksWithSummary(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	current := "dv-2"
+	for _, d := range []store.Deck{
+		{ID: "deck-1", OrgID: "org-1", OwnerUserID: "user-1", Name: "Pitch", CurrentVersion: &current},
+		{ID: "deck-2", OrgID: "org-1", OwnerUserID: "user-1", Name: "Empty"},
+		{ID: "deck-3", OrgID: "org-1", OwnerUserID: "user-2", Name: "Private", Visibility: store.VisibilityPrivate},
+	} {
+		_, err := s.Decks().CreateDeck(ctx, d)
+		require.NoError(t, err)
+	}
+	for _, v := range []store.DeckVersion{
+		{ID: "dv-1", Deck: "deck-1", OrgID: "org-1", VersionNo: 1, SpecJSON: []byte(`{"layouts":[{"name":"Title"}]}`)},
+		{ID: "dv-2", Deck: "deck-1", OrgID: "org-1", VersionNo: 2, SpecJSON: []byte(`{"layouts":[{"name":"Title"},{"name":"Agenda"},{"name":"Close"}]}`)},
+	} {
+		_, err := s.Decks().CreateDeckVersion(ctx, v)
+		require.NoError(t, err)
+	}
+	var exported time.Time
+	for _, j := range []store.Job{
+		{ID: "job-1", OrgID: "org-1", Type: store.JobExport, Status: store.JobDone, InputRef: "dv-1"},
+		{ID: "job-2", OrgID: "org-1", Type: store.JobExport, Status: store.JobFailed, InputRef: "dv-2"},
+		{ID: "job-3", OrgID: "org-1", Type: store.JobPreview, Status: store.JobDone, InputRef: "dv-2"},
+	} {
+		j, err := s.Jobs().Enqueue(ctx, j)
+		require.NoError(t, err)
+		if j.ID == "job-1" {
+			exported = j.UpdatedAt
+		}
+	}
+
+	ds, err := s.Decks().ListDecksWithSummary(ctx, auth.Identity{UserID: "user-1", OrgID: "org-1", Role: auth.RoleEditor})
+	require.NoError(t, err)
+	require.Len(t, ds, 2, "decks the caller can't view are left out")
+	for _, d := range ds {
+		if d.ID != "deck-1" {
+			assert.Nil(t, d.Summary)
+			continue
+		}
+		require.NotNil(t, d.Summary)
+		assert.Equal(t, "dv-2", d.Summary.VersionID)
+		assert.Equal(t, 3, d.Summary.SlideCount)
+		require.NotNil(t, d.Summary.LastExportedAt, "exports of earlier versions count")
+		assert.Equal(t, exported, *d.Summary.LastExportedAt)
+	}
+}
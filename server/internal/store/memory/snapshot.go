@@ -0,0 +1,318 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const snapshotFormat = 1
+
+// snapshotOrg carries the org's credentials, which Organization hides from
+// JSON so they never reach API responses.
+type snapshotOrg struct {
+	store.Organization
+	SCIMTokenHash      string                          `json:"scimTokenHash,omitempty"`
+	GoogleRefreshToken string                          `json:"googleRefreshToken,omitempty"`
+	Slack              *store.SlackIntegration         `json:"slack,omitempty"`
+	StockMedia         *store.StockMediaKeys           `json:"stockMedia,omitempty"`
+	ContentImport      *store.ContentImportCredentials `json:"contentImport,omitempty"`
+}
+
+// snapshotWebhook carries the signing secret, hidden from JSON for the same
+// reason.
+type snapshotWebhook struct {
+	store.Webhook
+	Secret string `json:"secret"`
+}
+
+// snapshotConnector carries the connector's credential, hidden from JSON
+// for the same reason.
+type snapshotConnector struct {
+	store.Connector
+	Credential string `json:"credential"`
+}
+
+// snapshotEmbed carries the token hash that embed lookups key on.
+type snapshotEmbed struct {
+	store.DeckEmbed
+	TokenHash string `json:"tokenHash"`
+}
+
+// snapshotUser carries the pending email change's token.
+type snapshotUser struct {
+	store.User
+	EmailTokenHash      string     `json:"emailTokenHash,omitempty"`
+	EmailTokenExpiresAt *time.Time `json:"emailTokenExpiresAt,omitempty"`
+}
+
+// snapshotAPIKey carries the key hash that API key lookups key on.
+type snapshotAPIKey struct {
+	store.APIKey
+	KeyHash string `json:"keyHash"`
+}
+
+// snapshot is the on-disk form of a MemoryStore.
+type snapshot struct {
+	Format    int                                         `json:"format"`
+	SavedAt   time.Time                                   `json:"savedAt"`
+	Templates map[string]store.Template                   `json:"templates"`
+	Versions  map[string]store.TemplateVersion            `json:"versions"`
+	Decks     map[string]store.Deck                       `json:"decks"`
+	DeckVers  map[string]store.DeckVersion                `json:"deckVersions"`
+	BrandKits map[string]store.BrandKit                   `json:"brandKits"`
+	Assets    map[string]store.Asset                      `json:"assets"`
+	AssetData map[string][]byte                           `json:"assetData"`
+	Storage   map[string]int64                            `json:"storage"`
+	Jobs      map[string]store.Job                        `json:"jobs"`
+	Metering  []store.MeteringEvent                       `json:"metering"`
+	Audit     []store.AuditLog                            `json:"audit"`
+	AICalls   []store.AICall                              `json:"aiCalls"`
+	Users     map[string]snapshotUser                     `json:"users"`
+	Orgs      map[string]snapshotOrg                      `json:"orgs"`
+	UserOrgs  []store.UserOrg                             `json:"userOrgs"`
+	Perms     []store.ResourcePermission                  `json:"permissions"`
+	Comments  map[string]store.Comment                    `json:"comments"`
+	Refines   map[string]store.DeckRefinement             `json:"deckRefinements"`
+	Tags      map[string]store.Tag                        `json:"tags"`
+	TagLinks  []store.ResourceTag                         `json:"tagLinks"`
+	Folders   map[string]store.Folder                     `json:"folders"`
+	Webhooks  map[string]snapshotWebhook                  `json:"webhooks"`
+	HookDels  map[string]store.WebhookDelivery            `json:"webhookDeliveries"`
+	Embeds    map[string]snapshotEmbed                    `json:"embeds"`
+	APIKeys   map[string]snapshotAPIKey                   `json:"apiKeys"`
+	Retries   map[store.JobType]store.RetryPolicyOverride `json:"retryPolicies"`
+	Flags     map[string]store.FeatureFlag                `json:"featureFlags"`
+	Fonts     map[string]store.Font                       `json:"fonts"`
+	Glossary  map[string]store.GlossaryEntry              `json:"glossary"`
+	Schedules map[string]store.Schedule                   `json:"schedules"`
+	Conns     map[string]snapshotConnector                `json:"connectors"`
+	GenImages map[string]store.GeneratedImage             `json:"generatedImages"`
+	Activity  map[string]store.UserActivity               `json:"userActivity"`
+}
+
+// SaveSnapshot writes the whole store to path as JSON. The file is replaced
+// atomically so a crash mid-write leaves the previous snapshot intact.
+func (m *MemoryStore) SaveSnapshot(path string) error {
+	m.mu.Lock()
+	snap := snapshot{
+		Format:    snapshotFormat,
+		SavedAt:   time.Now().UTC(),
+		Templates: m.templates,
+		Versions:  m.versions,
+		Decks:     m.decks,
+		DeckVers:  m.deckVers,
+		BrandKits: m.brandKits,
+		Assets:    m.assets,
+		AssetData: m.assetData,
+		Storage:   m.storage,
+		Jobs:      m.jobs,
+		Metering:  m.metering,
+		Audit:     m.audit,
+		AICalls:   m.aiCalls,
+		Users:     make(map[string]snapshotUser, len(m.users)),
+		Orgs:      make(map[string]snapshotOrg, len(m.orgs)),
+		UserOrgs:  m.userOrgs,
+		Perms:     m.perms,
+		Comments:  m.comments,
+		Refines:   m.refines,
+		Tags:      m.tags,
+		TagLinks:  m.tagLinks,
+		Folders:   m.folders,
+		Webhooks:  make(map[string]snapshotWebhook, len(m.hooks)),
+		HookDels:  m.hookDels,
+		Embeds:    make(map[string]snapshotEmbed, len(m.embeds)),
+		APIKeys:   make(map[string]snapshotAPIKey, len(m.apiKeys)),
+		Retries:   m.retries,
+		Flags:     m.flags,
+		Fonts:     m.fonts,
+		Glossary:  m.glossary,
+		Schedules: m.schedules,
+		Conns:     make(map[string]snapshotConnector, len(m.conns)),
+		GenImages: m.genImages,
+		Activity:  m.activity,
+	}
+	for id, u := range m.users {
+		snap.Users[id] = snapshotUser{User: u, EmailTokenHash: u.EmailTokenHash, EmailTokenExpiresAt: u.EmailTokenExpiresAt}
+	}
+	for id, o := range m.orgs {
+		snap.Orgs[id] = snapshotOrg{Organization: o, SCIMTokenHash: o.SCIMTokenHash, GoogleRefreshToken: o.GoogleRefreshToken, Slack: o.Slack, StockMedia: o.StockMedia, ContentImport: o.ContentImport}
+	}
+	for id, w := range m.hooks {
+		snap.Webhooks[id] = snapshotWebhook{Webhook: w, Secret: w.Secret}
+	}
+	for id, c := range m.conns {
+		snap.Conns[id] = snapshotConnector{Connector: c, Credential: c.Credential}
+	}
+	for id, e := range m.embeds {
+		snap.Embeds[id] = snapshotEmbed{DeckEmbed: e, TokenHash: e.TokenHash}
+	}
+	for id, k := range m.apiKeys {
+		snap.APIKeys[id] = snapshotAPIKey{APIKey: k, KeyHash: k.KeyHash}
+	}
+	data, err := json.Marshal(snap)
+	m.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSnapshot replaces the store's contents with the snapshot at path. A
+// missing file is not an error, so a fresh dev server starts empty.
+func (m *MemoryStore) LoadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	if snap.Format != snapshotFormat {
+		return fmt.Errorf("snapshot format %d is not supported", snap.Format)
+	}
+
+	fresh := New()
+	maps.Copy(fresh.templates, snap.Templates)
+	maps.Copy(fresh.versions, snap.Versions)
+	maps.Copy(fresh.decks, snap.Decks)
+	maps.Copy(fresh.deckVers, snap.DeckVers)
+	maps.Copy(fresh.brandKits, snap.BrandKits)
+	maps.Copy(fresh.assets, snap.Assets)
+	maps.Copy(fresh.assetData, snap.AssetData)
+	maps.Copy(fresh.storage, snap.Storage)
+	maps.Copy(fresh.jobs, snap.Jobs)
+	maps.Copy(fresh.comments, snap.Comments)
+	maps.Copy(fresh.refines, snap.Refines)
+	maps.Copy(fresh.tags, snap.Tags)
+	maps.Copy(fresh.folders, snap.Folders)
+	maps.Copy(fresh.hookDels, snap.HookDels)
+	maps.Copy(fresh.retries, snap.Retries)
+	maps.Copy(fresh.flags, snap.Flags)
+	maps.Copy(fresh.fonts, snap.Fonts)
+	maps.Copy(fresh.glossary, snap.Glossary)
+	maps.Copy(fresh.schedules, snap.Schedules)
+	maps.Copy(fresh.genImages, snap.GenImages)
+	maps.Copy(fresh.activity, snap.Activity)
+	for id, v := range fresh.versions {
+		v.SpecJSON = snapshotSpec(v.SpecJSON)
+		fresh.versions[id] = v
+	}
+	for id, v := range fresh.deckVers {
+		v.SpecJSON = snapshotSpec(v.SpecJSON)
+		fresh.deckVers[id] = v
+	}
+	for id, u := range snap.Users {
+		u.User.EmailTokenHash = u.EmailTokenHash
+		u.User.EmailTokenExpiresAt = u.EmailTokenExpiresAt
+		fresh.users[id] = u.User
+	}
+	for id, o := range snap.Orgs {
+		o.Organization.SCIMTokenHash = o.SCIMTokenHash
+		o.Organization.GoogleRefreshToken = o.GoogleRefreshToken
+		o.Organization.Slack = o.Slack
+		o.Organization.StockMedia = o.StockMedia
+		o.Organization.ContentImport = o.ContentImport
+		fresh.orgs[id] = o.Organization
+	}
+	for id, w := range snap.Webhooks {
+		w.Webhook.Secret = w.Secret
+		fresh.hooks[id] = w.Webhook
+	}
+	for id, c := range snap.Conns {
+		c.Connector.Credential = c.Credential
+		fresh.conns[id] = c.Connector
+	}
+	for id, e := range snap.Embeds {
+		e.DeckEmbed.TokenHash = e.TokenHash
+		fresh.embeds[id] = e.DeckEmbed
+	}
+	for id, k := range snap.APIKeys {
+		k.APIKey.KeyHash = k.KeyHash
+		fresh.apiKeys[id] = k.APIKey
+	}
+	fresh.metering = append(fresh.metering, snap.Metering...)
+	fresh.audit = append(fresh.audit, snap.Audit...)
+	fresh.aiCalls = append(fresh.aiCalls, snap.AICalls...)
+	fresh.userOrgs = append(fresh.userOrgs, snap.UserOrgs...)
+	fresh.perms = append(fresh.perms, snap.Perms...)
+	fresh.tagLinks = append(fresh.tagLinks, snap.TagLinks...)
+
+	m.mu.Lock()
+	m.restore(fresh)
+	m.mu.Unlock()
+	return nil
+}
+
+// AutoSave writes a snapshot to path every interval until the returned stop
+// function is called. stop writes one final snapshot and reports its error.
+func (m *MemoryStore) AutoSave(path string, interval time.Duration) (stop func() error) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := m.SaveSnapshot(path); err != nil {
+					logger.Database().Warn("memory_snapshot_failed", "path", path, "error", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() error {
+		var err error
+		once.Do(func() {
+			close(done)
+			<-exited
+			err = m.SaveSnapshot(path)
+		})
+		return err
+	}
+}
+
+// snapshotSpec reads a spec back from a snapshot. Snapshots written while
+// SpecJSON was an `any` hold []byte specs as base64 strings; those come back
+// as the JSON they encode. Specs that can't be read are kept as they are.
+func snapshotSpec(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+	doc, err := normalize.Load(raw)
+	if err != nil {
+		return raw
+	}
+	return doc.Save()
+}
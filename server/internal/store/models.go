@@ -38,14 +38,101 @@ func (j *JSONMap) Scan(value interface{}) error {
 	return json.Unmarshal(b, j)
 }
 
+// GenerationParams are the AI sampling parameters used to produce a version.
+// Nil fields mean the model default was used.
+type GenerationParams struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"topP,omitempty"`
+	MaxSlides   *int     `json:"maxSlides,omitempty"`
+}
+
+// WithDefaults fills any unset field from defaults.
+func (g GenerationParams) WithDefaults(defaults *GenerationParams) GenerationParams {
+	if defaults == nil {
+		return g
+	}
+	if g.Temperature == nil {
+		g.Temperature = defaults.Temperature
+	}
+	if g.TopP == nil {
+		g.TopP = defaults.TopP
+	}
+	if g.MaxSlides == nil {
+		g.MaxSlides = defaults.MaxSlides
+	}
+	return g
+}
+
+// IsZero reports whether no parameter is set.
+func (g GenerationParams) IsZero() bool {
+	return g.Temperature == nil && g.TopP == nil && g.MaxSlides == nil
+}
+
+func (g GenerationParams) Value() (driver.Value, error) {
+	b, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	// See JSONMap.Value: string, not []byte, for jsonb columns.
+	return string(b), nil
+}
+
+func (g *GenerationParams) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*g = GenerationParams{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, g)
+	case string:
+		return json.Unmarshal([]byte(v), g)
+	default:
+		return fmt.Errorf("GenerationParams.Scan: unexpected type %T", value)
+	}
+}
+
 type TemplateStatus string
 
 const (
 	TemplateDraft     TemplateStatus = "Draft"
+	TemplateInReview  TemplateStatus = "InReview"
+	TemplateApproved  TemplateStatus = "Approved"
 	TemplatePublished TemplateStatus = "Published"
 	TemplateArchived  TemplateStatus = "Archived"
 )
 
+// TemplateStatuses lists every template status; the templates_status_check
+// constraint must allow each of them.
+var TemplateStatuses = []TemplateStatus{TemplateDraft, TemplateInReview, TemplateApproved, TemplatePublished, TemplateArchived}
+
+// templateTransitions is the review workflow: Draft → InReview → Approved →
+// Published, with reviewers able to send a submission back to Draft.
+var templateTransitions = map[TemplateStatus][]TemplateStatus{
+	TemplateDraft:    {TemplateInReview},
+	TemplateInReview: {TemplateApproved, TemplateDraft},
+	TemplateApproved: {TemplatePublished},
+}
+
+// CanTransitionTo reports whether the review workflow allows moving from s to next.
+func (s TemplateStatus) CanTransitionTo(next TemplateStatus) bool {
+	for _, allowed := range templateTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Listing is what galleries and pickers show on a template or deck card
+// besides its name. Language is a BCP 47 tag; CoverAssetID is an image asset
+// in the same org.
+type Listing struct {
+	Description  string  `json:"description" gorm:"not null;default:''"`
+	Category     string  `json:"category" gorm:"not null;default:'';index"`
+	Language     string  `json:"language" gorm:"not null;default:''"`
+	CoverAssetID *string `json:"coverAssetId,omitempty" gorm:"type:uuid"`
+}
+
 type Template struct {
 	ID              string         `json:"id" gorm:"type:uuid;primaryKey"`
 	OrgID           string         `json:"orgId" gorm:"type:uuid;index;not null"`
@@ -53,49 +140,186 @@ type Template struct {
 	Name            string         `json:"name" gorm:"not null"`
 	Status          TemplateStatus `json:"status" gorm:"not null"`
 	CurrentVersion  *string        `json:"currentVersionId" gorm:"type:uuid;index"`
+	Visibility      Visibility     `json:"visibility" gorm:"not null;default:'org'"`
+	FolderID        *string        `json:"folderId,omitempty" gorm:"type:uuid;index"`
 	CreatedAt       time.Time      `json:"createdAt"`
 	UpdatedAt       time.Time      `json:"updatedAt"`
+	DeletedAt       *time.Time     `json:"deletedAt,omitempty" gorm:"index"`
 	LatestVersionNo int            `json:"latestVersionNo"`
+	Listing         `gorm:"embedded"`
+}
+
+// TemplateWithVersion is a template with its current version, nil when it
+// has none.
+type TemplateWithVersion struct {
+	Template
+	Version *TemplateVersion `json:"currentVersion,omitempty"`
+}
+
+// WithCurrentVersions pairs each of ts with its current version among
+// versions, keyed by ID.
+func WithCurrentVersions(ts []Template, versions map[string]TemplateVersion) []TemplateWithVersion {
+	out := make([]TemplateWithVersion, len(ts))
+	for i, t := range ts {
+		out[i].Template = t
+		if t.CurrentVersion != nil {
+			if v, ok := versions[*t.CurrentVersion]; ok {
+				out[i].Version = &v
+			}
+		}
+	}
+	return out
+}
+
+// CurrentVersionIDs returns the IDs of ts's current versions.
+func CurrentVersionIDs(ts []Template) []string {
+	ids := make([]string, 0, len(ts))
+	for _, t := range ts {
+		if t.CurrentVersion != nil {
+			ids = append(ids, *t.CurrentVersion)
+		}
+	}
+	return ids
 }
 
 type Deck struct {
-	ID                    string    `json:"id" gorm:"type:uuid;primaryKey"`
-	OrgID                 string    `json:"orgId" gorm:"type:uuid;index;not null"`
-	OwnerUserID           string    `json:"ownerUserId" gorm:"type:uuid;index"`
-	Name                  string    `json:"name" gorm:"not null"`
-	SourceTemplateVersion string    `json:"sourceTemplateVersionId" gorm:"type:uuid;index"`
-	CurrentVersion        *string   `json:"currentVersionId" gorm:"type:uuid;index"`
-	CreatedAt             time.Time `json:"createdAt"`
-	UpdatedAt             time.Time `json:"updatedAt"`
-	LatestVersionNo       int       `json:"latestVersionNo"`
-	Content               string    `json:"content"`
+	ID                    string     `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID                 string     `json:"orgId" gorm:"type:uuid;index;not null"`
+	OwnerUserID           string     `json:"ownerUserId" gorm:"type:uuid;index"`
+	Name                  string     `json:"name" gorm:"not null"`
+	SourceTemplateVersion string     `json:"sourceTemplateVersionId" gorm:"type:uuid;index"`
+	CurrentVersion        *string    `json:"currentVersionId" gorm:"type:uuid;index"`
+	Visibility            Visibility `json:"visibility" gorm:"not null;default:'org'"`
+	FolderID              *string    `json:"folderId,omitempty" gorm:"type:uuid;index"`
+	// TranslatedFrom links a translation to the deck it was translated from;
+	// the translation's Language says into what.
+	TranslatedFrom        *string    `json:"translatedFromId,omitempty" gorm:"type:uuid;index"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	UpdatedAt             time.Time  `json:"updatedAt"`
+	DeletedAt             *time.Time `json:"deletedAt,omitempty" gorm:"index"`
+	LatestVersionNo       int        `json:"latestVersionNo"`
+	Content               string     `json:"content" gorm:"serializer:encrypted"`
+	Listing               `gorm:"embedded"`
 }
 
 type DeckVersion struct {
-	ID        string    `json:"id" gorm:"type:uuid;primaryKey"`
-	Deck      string    `json:"deckId" gorm:"type:uuid;index"`
-	OrgID     string    `json:"orgId" gorm:"type:uuid;index"`
-	VersionNo int       `json:"versionNo"`
-	SpecJSON  any       `json:"spec" gorm:"type:jsonb"`
-	CreatedBy string    `json:"createdBy" gorm:"type:uuid"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID        string          `json:"id" gorm:"type:uuid;primaryKey"`
+	Deck      string          `json:"deckId" gorm:"type:uuid;index"`
+	OrgID     string          `json:"orgId" gorm:"type:uuid;index"`
+	VersionNo int             `json:"versionNo"`
+	SpecJSON  json.RawMessage `json:"spec" gorm:"type:jsonb;serializer:spec"`
+	CreatedBy string          `json:"createdBy" gorm:"type:uuid"`
+	CreatedAt time.Time       `json:"createdAt"`
+	// GenerationParams records the AI parameters that produced this version, if any.
+	GenerationParams *GenerationParams `json:"generationParams,omitempty" gorm:"type:jsonb"`
+	// ReviewRequired lists the figures the AI wrote that the deck's content
+	// doesn't back up, for a person to check.
+	ReviewRequired []ReviewFlag `json:"reviewRequired,omitempty" gorm:"type:jsonb;serializer:json"`
+}
+
+// ReviewFlag is a number in a deck version's AI-written text that appears
+// nowhere in the content the deck was made from.
+type ReviewFlag struct {
+	// Slide is the 0-based index of the layout the figure is on.
+	Slide         int    `json:"slide"`
+	PlaceholderID string `json:"placeholderId"`
+	// Figure is the number as written, like "$4.2M" or "37%".
+	Figure string `json:"figure"`
+	// Excerpt is the text around the figure.
+	Excerpt string `json:"excerpt"`
+}
+
+// DeckSummary sums up a deck's current version for listings. LastExportedAt
+// is the deck's most recent successful export of any version, nil if it has
+// none. ThumbnailURL is left for the API to fill in.
+type DeckSummary struct {
+	VersionID      string     `json:"versionId"`
+	VersionNo      int        `json:"versionNo"`
+	SlideCount     int        `json:"slideCount"`
+	LastExportedAt *time.Time `json:"lastExportedAt"`
+	ThumbnailURL   string     `json:"thumbnailUrl,omitempty"`
+}
+
+// DeckWithSummary is a deck with the summary of its current version, nil
+// when it has none.
+type DeckWithSummary struct {
+	Deck
+	Summary *DeckSummary `json:"currentVersionSummary,omitempty"`
+}
+
+// DeckRefinement is one turn of a deck's refinement conversation: what the
+// user asked the AI to change and the version that came of it.
+type DeckRefinement struct {
+	ID          string `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID       string `json:"orgId" gorm:"type:uuid;index;not null"`
+	DeckID      string `json:"deckId" gorm:"type:uuid;index;not null"`
+	UserID      string `json:"userId" gorm:"type:uuid"`
+	// Instruction is empty unless the org stores prompts; see
+	// OrgSettings.StorePrompts.
+	Instruction string `json:"instruction" gorm:"not null;serializer:encrypted"`
+	// FromVersionID is the version the instruction was applied to and
+	// VersionID the one it produced.
+	FromVersionID string    `json:"fromVersionId" gorm:"type:uuid"`
+	VersionID     string    `json:"versionId" gorm:"type:uuid"`
+	Model         string    `json:"model,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// Comment is a review note anchored to one slide of a deck version. Replies
+// point at their thread's root comment through ParentID; only roots carry the
+// thread's resolved state.
+type Comment struct {
+	ID            string     `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID         string     `json:"orgId" gorm:"type:uuid;index;not null"`
+	DeckID        string     `json:"deckId" gorm:"type:uuid;index"`
+	DeckVersionID string     `json:"deckVersionId" gorm:"type:uuid;index;not null"`
+	SlideIndex    int        `json:"slideIndex"`
+	ParentID      *string    `json:"parentId,omitempty" gorm:"type:uuid;index"`
+	AuthorID      string     `json:"authorUserId" gorm:"type:uuid;index"`
+	Body          string     `json:"body" gorm:"not null"`
+	Mentions      []string   `json:"mentions" gorm:"type:jsonb;serializer:json"`
+	Resolved      bool       `json:"resolved" gorm:"not null;default:false"`
+	ResolvedBy    *string    `json:"resolvedBy,omitempty" gorm:"type:uuid"`
+	ResolvedAt    *time.Time `json:"resolvedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
 }
 
 type TemplateVersion struct {
-	ID        string    `json:"id" gorm:"type:uuid;primaryKey"`
-	Template  string    `json:"templateId" gorm:"type:uuid;index"`
-	OrgID     string    `json:"orgId" gorm:"type:uuid;index"`
-	VersionNo int       `json:"versionNo"`
-	SpecJSON  any       `json:"spec" gorm:"type:jsonb"`
-	CreatedBy string    `json:"createdBy" gorm:"type:uuid"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID        string          `json:"id" gorm:"type:uuid;primaryKey"`
+	Template  string          `json:"templateId" gorm:"type:uuid;index"`
+	OrgID     string          `json:"orgId" gorm:"type:uuid;index"`
+	VersionNo int             `json:"versionNo"`
+	SpecJSON  json.RawMessage `json:"spec" gorm:"type:jsonb;serializer:spec"`
+	CreatedBy string          `json:"createdBy" gorm:"type:uuid"`
+	CreatedAt time.Time       `json:"createdAt"`
+	// GenerationParams records the AI parameters that produced this version, if any.
+	GenerationParams *GenerationParams `json:"generationParams,omitempty" gorm:"type:jsonb"`
+	// RequiredFields are the content data inputs decks made from this
+	// version are checked against.
+	RequiredFields []TemplateField `json:"requiredFields,omitempty" gorm:"type:jsonb;serializer:json"`
 }
 
+// TemplateField is an input a template needs from the decks made from it,
+// supplied under Key in their content data. Type is one of TemplateFieldTypes.
+type TemplateField struct {
+	Key         string   `json:"key"`
+	Label       string   `json:"label"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Example     string   `json:"example"`
+	Options     []string `json:"options,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// TemplateFieldTypes are the types a TemplateField may have.
+var TemplateFieldTypes = []string{"text", "number", "currency", "percentage", "date", "list"}
+
 type BrandKit struct {
 	ID        string    `json:"id" gorm:"type:uuid;primaryKey"`
 	OrgID     string    `json:"orgId" gorm:"type:uuid;index"`
 	Name      string    `json:"name"`
-	Tokens    any       `json:"tokens" gorm:"type:jsonb"`
+	Tokens    any       `json:"tokens" gorm:"type:jsonb;serializer:encrypted"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
@@ -105,15 +329,61 @@ const (
 	AssetPPTX AssetType = "pptx"
 	AssetPNG  AssetType = "png"
 	AssetFile AssetType = "file"
+	AssetZIP  AssetType = "zip"
+	AssetFont AssetType = "font"
+	// AssetImage is an AI-generated image; see GeneratedImage.
+	AssetImage AssetType = "image"
 )
 
+// AssetTypes lists every asset type; the assets_type_check constraint must
+// allow each of them.
+var AssetTypes = []AssetType{AssetPPTX, AssetPNG, AssetFile, AssetZIP, AssetFont, AssetImage}
+
 type Asset struct {
-	ID        string    `json:"id" gorm:"type:uuid;primaryKey"`
-	OrgID     string    `json:"orgId" gorm:"type:uuid;index"`
-	Type      AssetType `json:"type"`
-	Path      string    `json:"path"`
-	Mime      string    `json:"mime"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID          string    `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID       string    `json:"orgId" gorm:"type:uuid;index"`
+	Type        AssetType `json:"type"`
+	Path        string    `json:"path"`
+	Mime        string    `json:"mime"`
+	SizeBytes   int64     `json:"sizeBytes"`
+	SourceJobID string    `json:"sourceJobId,omitempty" gorm:"index"`
+	// Filename is the name downloads are served under; empty falls back to
+	// the asset ID.
+	Filename string `json:"filename,omitempty"`
+	// Region is the storage region holding the object, fixed when it is
+	// stored; empty is the default backend. See OrgSettings.StorageRegion.
+	Region string `json:"region,omitempty"`
+	// SHA256 is the hex SHA-256 of the object, computed at upload and checked
+	// when it is served; empty for assets stored before checksums.
+	SHA256 string `json:"sha256,omitempty" gorm:"column:sha256"`
+	// BudgetBytes is the size an export was asked to fit in, and
+	// OriginalSizeBytes its size before it was compressed toward it; both
+	// are 0 for exports without a budget. SizeBytes above BudgetBytes means
+	// the export is over budget.
+	BudgetBytes       int64     `json:"budgetBytes,omitempty"`
+	OriginalSizeBytes int64     `json:"originalSizeBytes,omitempty"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// AssetFilter narrows an org-scoped asset listing. Zero values mean "no filter";
+// Limit <= 0 means "no limit".
+type AssetFilter struct {
+	Type          AssetType
+	SourceJobID   string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Limit         int
+	Offset        int
+}
+
+// JobFilter narrows a platform-wide job listing. Zero values mean "no
+// filter"; Limit <= 0 means "no limit".
+type JobFilter struct {
+	OrgID  string
+	Status JobStatus
+	Type   JobType
+	IDs    []string
+	Limit  int
 }
 
 type JobStatus string
@@ -133,36 +403,149 @@ const (
 	JobExport  JobType = "export"
 	JobGenerate JobType = "generate"
 	JobBind     JobType = "bind"
+	// JobBulkExport renders several decks into one ZIP. Its decks are listed
+	// JSON-encoded under the "items" metadata key as []BulkExportItem.
+	JobBulkExport JobType = "bulk_export"
+	// JobBatchMerge renders a deck version once per data row, filling its
+	// variables, into one ZIP. The version is the job's InputRef and its rows
+	// are listed JSON-encoded under the "rows" metadata key as
+	// []BatchMergeRow.
+	JobBatchMerge JobType = "batch_merge"
+	// JobImageGen generates an image from the "prompt", "width" and "height"
+	// metadata and stores it as a GeneratedImage.
+	JobImageGen JobType = "image_gen"
+)
+
+// JobTypes lists every job type; the jobs_type_check constraint must allow
+// each of them.
+var JobTypes = []JobType{JobRender, JobPreview, JobExport, JobGenerate, JobBind, JobBulkExport, JobBatchMerge, JobImageGen}
+
+// JobPriority orders the queue: higher runs first, and workers keep slots
+// free for JobPriorityHigh. The zero value is JobPriorityNormal.
+type JobPriority int
+
+const (
+	JobPriorityLow    JobPriority = -1
+	JobPriorityNormal JobPriority = 0
+	JobPriorityHigh   JobPriority = 1
+)
+
+// DefaultJobPriority is the priority a job gets when enqueued without one:
+// previews and single renders/exports have a user waiting on them, bulk
+// exports don't.
+func DefaultJobPriority(t JobType) JobPriority {
+	switch t {
+	case JobPreview, JobRender, JobExport:
+		return JobPriorityHigh
+	case JobBulkExport, JobBatchMerge:
+		return JobPriorityLow
+	default:
+		return JobPriorityNormal
+	}
+}
+
+// JobRunsBefore reports whether a should be picked up before b: higher
+// priority first, then oldest first.
+func JobRunsBefore(a, b Job) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
+
+// ExportFormatBundle, set as the "format" metadata of an export job, asks for
+// a ZIP holding the PPTX, a PDF, per-slide PNGs and a manifest.json.
+const ExportFormatBundle = "bundle"
+
+// Image export formats, set as the "format" metadata of an export job.
+// ExportFormatPNG asks for a ZIP of one PNG per slide and ExportFormatGIF
+// for an animated GIF that flips through the slides; the job's "width"
+// metadata sets their size in pixels.
+const (
+	ExportFormatPNG = "png"
+	ExportFormatGIF = "gif"
+)
+
+// ExportFormatMarkdown, set as the "format" metadata of an export job, asks
+// for the deck's titles and bullets as a Marp Markdown document.
+const ExportFormatMarkdown = "md"
+
+// Export quality profiles, set as the "quality" metadata of an export job.
+// Draft renders fast with the Go renderer and no AI design analysis; high
+// runs the AI-enhanced Python renderer, which generates images. Jobs without
+// one use standard, the server's configured renderer.
+const (
+	ExportQualityDraft    = "draft"
+	ExportQualityStandard = "standard"
+	ExportQualityHigh     = "high"
 )
 
+// BatchMergeRow is one row of a batch merge job: the data filling the deck's
+// variables for one personalized copy, named Name in the archive. The
+// worker records each row's outcome in Status and Error as it goes.
+type BatchMergeRow struct {
+	// Row is the row's 1-based position in the request.
+	Row    int               `json:"row"`
+	Name   string            `json:"name"`
+	Data   map[string]string `json:"data"`
+	Status JobStatus         `json:"status"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// BulkExportItem is one deck in a bulk export job; the worker records each
+// deck's outcome in Status and Error as it goes.
+type BulkExportItem struct {
+	DeckID    string    `json:"deckId"`
+	VersionID string    `json:"versionId"`
+	Name      string    `json:"name"`
+	Status    JobStatus `json:"status"`
+	Error     string    `json:"error,omitempty"`
+}
+
 type Job struct {
 	ID              string            `json:"id" gorm:"type:uuid;primaryKey"`
 	OrgID           string            `json:"orgId" gorm:"type:uuid;index"`
 	Type            JobType           `json:"type" gorm:"index"`
 	Status          JobStatus         `json:"status" gorm:"index"`
+	Priority        JobPriority       `json:"priority" gorm:"not null;default:0"`
 	InputRef        string            `json:"inputRef" gorm:"index"`
 	OutputRef       string            `json:"outputRef,omitempty"`
 	Error           string            `json:"error,omitempty"`
 	RetryCount      int               `json:"retryCount"`
 	MaxRetries      int               `json:"maxRetries"`
 	LastRetryAt     *time.Time        `json:"lastRetryAt,omitempty"`
+	AutoRetries     int               `json:"autoRetries,omitempty"` // dead-letter sweeps that requeued the job
 	DeduplicationID string            `json:"deduplicationId,omitempty" gorm:"index"`
-	Metadata        *JSONMap           `json:"metadata,omitempty" gorm:"type:jsonb"`
+	Metadata        *JSONMap          `json:"metadata,omitempty" gorm:"type:jsonb;serializer:encrypted"`
 	ProgressStep    string            `json:"progressStep,omitempty"`
 	ProgressPct     int               `json:"progressPct,omitempty"`
+	HeartbeatAt     *time.Time        `json:"heartbeatAt,omitempty"` // written only by JobStore.Heartbeat
 	CreatedAt       time.Time         `json:"createdAt"`
 	UpdatedAt       time.Time         `json:"updatedAt"`
 }
 
 type MeteringEvent struct {
-	ID        string    `json:"id" gorm:"type:uuid;primaryKey"`
-	OrgID     string    `json:"orgId" gorm:"type:uuid;index"`
-	UserID    string    `json:"userId" gorm:"type:uuid;index"`
-	Type      string    `json:"eventType" gorm:"index"`
-	Quantity  int       `json:"quantity"`
+	ID       string `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID    string `json:"orgId" gorm:"type:uuid;index"`
+	UserID   string `json:"userId" gorm:"type:uuid;index"`
+	Type     string `json:"eventType" gorm:"column:event_type;index"`
+	Quantity int    `json:"quantity"`
+	// TargetRef is the template or deck the event is about, if any; usage
+	// counts per template and deck are summed from it.
+	TargetRef string    `json:"targetRef,omitempty" gorm:"index"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
+// MeterDeckCreate is recorded when a deck is created from a template, with
+// the template as TargetRef. Exports ("export") carry the exported deck or
+// template, and are recorded when the export finishes.
+const MeterDeckCreate = "deck_create"
+
+// MeterTransformPrefix starts the event type recorded for each text
+// transformation of a deck, which is followed by the operation, e.g.
+// "transform_shorten". The deck is the TargetRef.
+const MeterTransformPrefix = "transform_"
+
 type AuditLog struct {
 	ID        string    `json:"id" gorm:"type:uuid;primaryKey"`
 	OrgID     string    `json:"orgId" gorm:"type:uuid;index"`
@@ -174,18 +557,182 @@ type AuditLog struct {
 }
 
 type User struct {
-	ID        string    `json:"id" gorm:"type:uuid;primaryKey"`
-	Email     string    `json:"email" gorm:"uniqueIndex:idx_users_email_production;not null"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID          string           `json:"id" gorm:"type:uuid;primaryKey"`
+	Email       string           `json:"email" gorm:"uniqueIndex:idx_users_email_production;not null"`
+	Name        string           `json:"name"`
+	AvatarURL   string           `json:"avatarUrl,omitempty"`
+	Preferences *UserPreferences `json:"preferences,omitempty" gorm:"type:jsonb;serializer:json"`
+	// PendingEmail replaces Email once the user presents the token mailed to
+	// it; EmailTokenHash is that token's SHA-256.
+	PendingEmail        string     `json:"pendingEmail,omitempty"`
+	EmailTokenHash      string     `json:"-" gorm:"index"`
+	EmailTokenExpiresAt *time.Time `json:"-"`
+	// EmailVerifiedAt is when the user proved they own Email, by a mailed
+	// token or an identity provider; nil for self-registered accounts that
+	// haven't. SSO sign-ins only attach to verified accounts.
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt,omitempty"`
+	// PlatformAdmin lets the user operate the whole platform through the
+	// /v1/admin/platform endpoints. Operators set it in the database; no
+	// API, invite, SCIM or SSO flow writes it.
+	PlatformAdmin bool `json:"platformAdmin,omitempty" gorm:"not null;default:false"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	UpdatedAt           time.Time  `json:"updatedAt"`
+}
+
+// UserPreferences are per-user settings the editor restores on sign-in.
+type UserPreferences struct {
+	// DefaultBrandKitID preselects a brand kit for new templates and decks.
+	DefaultBrandKitID string `json:"defaultBrandKitId,omitempty"`
+	// Editor is the editor's own settings, stored as the client sends them.
+	Editor json.RawMessage `json:"editor,omitempty"`
 }
 
 type Organization struct {
-	ID        string    `json:"id" gorm:"type:uuid;primaryKey"`
-	Name      string    `json:"name" gorm:"not null"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID   string `json:"id" gorm:"type:uuid;primaryKey"`
+	Name string `json:"name" gorm:"not null"`
+	// StorageBytesUsed is the running total of asset bytes, maintained by the
+	// asset store on create/delete so quota checks don't need to scan assets.
+	StorageBytesUsed int64 `json:"storageBytesUsed" gorm:"not null;default:0"`
+	// GenerationDefaults apply to AI requests that don't set their own parameters.
+	GenerationDefaults *GenerationParams `json:"generationDefaults,omitempty" gorm:"type:jsonb"`
+	// SSODomain maps verified SSO users with this email domain into the org,
+	// once SSODomainVerifiedAt shows the org proved it controls the domain
+	// by publishing SSODomainToken in a DNS TXT record.
+	SSODomain           string     `json:"ssoDomain,omitempty" gorm:"index"`
+	SSODomainToken      string     `json:"-"`
+	SSODomainVerifiedAt *time.Time `json:"ssoDomainVerifiedAt,omitempty"`
+	// ExportFilenameTemplate names export downloads, e.g.
+	// "{deckName}-v{versionNo}-{date}"; empty uses the built-in names.
+	ExportFilenameTemplate string `json:"exportFilenameTemplate,omitempty"`
+	// DefaultRenderer is the renderer engine for the org's standard-quality
+	// exports, one of assets.RendererEngines; empty uses the server default.
+	DefaultRenderer string `json:"defaultRenderer,omitempty"`
+	// Settings are the org's defaults for generation requests and display.
+	Settings *OrgSettings `json:"settings,omitempty" gorm:"type:jsonb;serializer:json"`
+	// SCIMTokenHash is the SHA-256 of the org's SCIM bearer token.
+	SCIMTokenHash string `json:"-" gorm:"index"`
+	// GoogleRefreshToken is the OAuth refresh token used to read Google
+	// Slides for imports. It is a live credential and never serialized.
+	GoogleRefreshToken string `json:"-"`
+	// Slack holds the org's Slack notification settings. Its webhook URL
+	// and bot token are credentials, so it is never serialized.
+	Slack *SlackIntegration `json:"-" gorm:"type:jsonb;serializer:json"`
+	// StockMedia holds the org's own stock photo API keys, which take the
+	// place of the server's. They are credentials, so never serialized.
+	StockMedia *StockMediaKeys `json:"-" gorm:"type:jsonb;serializer:json"`
+	// ContentImport holds the org's Notion and Confluence credentials for
+	// page imports. They are credentials, so never serialized.
+	ContentImport *ContentImportCredentials `json:"-" gorm:"type:jsonb;serializer:json"`
+	// Policy is the org's compliance rules for decks; nil imposes none.
+	Policy *OrgPolicy `json:"policy,omitempty" gorm:"type:jsonb;serializer:json"`
+	// DeletedAt is set when an owner deletes the org. From then on nobody
+	// can reach it, and after PurgeAfter it is removed with all its data.
+	DeletedAt  *time.Time `json:"deletedAt,omitempty" gorm:"index"`
+	PurgeAfter *time.Time `json:"purgeAfter,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// OrgPolicy is an org's compliance rules for decks, enforced when decks
+// are created and when the AI binds their content; see the policy package.
+type OrgPolicy struct {
+	// AllowedTemplates are the IDs of the only templates decks may be
+	// created from; empty allows any.
+	AllowedTemplates []string `json:"allowedTemplates,omitempty"`
+	// MandatorySlides are slides every new deck carries, such as a
+	// disclaimer.
+	MandatorySlides []MandatorySlide `json:"mandatorySlides,omitempty"`
+	// ContentRules are what deck content must not say.
+	ContentRules []ContentRule `json:"contentRules,omitempty"`
+}
+
+// MandatorySlide is a slide an OrgPolicy adds to decks that lack it.
+type MandatorySlide struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// Position is "start" or "end"; empty means the end.
+	Position string `json:"position,omitempty"`
+}
+
+// ContentRule forbids content matching Pattern, a case-insensitive regular
+// expression, e.g. "guarantee(d|s)?" for claims of guaranteed returns.
+type ContentRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	// Message tells whoever broke the rule why; empty names the rule.
+	Message string `json:"message,omitempty"`
+}
+
+// OrgSettings are defaults an org applies to its generation requests and
+// shows its users. Generation requests that set their own language, tone or
+// direction keep them; empty fields leave the choice to each request.
+type OrgSettings struct {
+	// Locale is a BCP 47 tag for dates and numbers, e.g. "ar-SA".
+	Locale string `json:"locale,omitempty"`
+	// Timezone is an IANA zone name, e.g. "Asia/Riyadh".
+	Timezone string `json:"timezone,omitempty"`
+	Language string `json:"language,omitempty"`
+	Tone     string `json:"tone,omitempty"`
+	RTL      bool   `json:"rtl,omitempty"`
+	// SlideSize is the aspect ratio new templates are designed for: "16:9"
+	// or "4:3".
+	SlideSize string `json:"slideSize,omitempty"`
+	// StorePrompts opts the org in to keeping generation prompts, deck
+	// content and refinement instructions in audit entries, finished jobs
+	// and deck refinements. Without it they are kept only until the job
+	// that needs them is done.
+	StorePrompts bool `json:"storePrompts,omitempty"`
+	// StorageRegion keeps the org's files in one of the server's configured
+	// storage regions (STORAGE_REGIONS); empty uses the default backend.
+	// Files already stored stay where they are when it changes.
+	StorageRegion string `json:"storageRegion,omitempty"`
+}
+
+// PromptMetadataKeys are the job metadata keys that carry what users typed:
+// generation prompts and deck content.
+var PromptMetadataKeys = []string{"prompt", "content"}
+
+// StoresPrompts reports whether the org keeps prompts past the jobs that use
+// them; see OrgSettings.StorePrompts.
+func (o Organization) StoresPrompts() bool {
+	return o.Settings != nil && o.Settings.StorePrompts
+}
+
+// StorageRegion returns the region new files for the org are stored in;
+// empty is the default backend.
+func (o Organization) StorageRegion() string {
+	if o.Settings == nil {
+		return ""
+	}
+	return o.Settings.StorageRegion
+}
+
+// SlackIntegration posts export and job failure notices to Slack, either
+// through an incoming webhook or as a bot posting to Channel.
+type SlackIntegration struct {
+	WebhookURL     string `json:"webhookUrl,omitempty"`
+	BotToken       string `json:"botToken,omitempty"`
+	Channel        string `json:"channel,omitempty"`
+	NotifyExports  bool   `json:"notifyExports"`
+	NotifyFailures bool   `json:"notifyFailures"`
+}
+
+// StockMediaKeys are API keys for the stock photo providers; an empty key
+// leaves that provider to the server's key, if any.
+type StockMediaKeys struct {
+	UnsplashAccessKey string `json:"unsplashAccessKey,omitempty"`
+	PexelsAPIKey      string `json:"pexelsApiKey,omitempty"`
+}
+
+// ContentImportCredentials reach the pages an org imports decks from. The
+// Notion token is an internal integration secret; ConfluenceBaseURL is the
+// site's root, and ConfluenceEmail is set for Atlassian API tokens and empty
+// for personal access tokens.
+type ContentImportCredentials struct {
+	NotionToken       string `json:"notionToken,omitempty"`
+	ConfluenceBaseURL string `json:"confluenceBaseUrl,omitempty"`
+	ConfluenceEmail   string `json:"confluenceEmail,omitempty"`
+	ConfluenceToken   string `json:"confluenceToken,omitempty"`
 }
 
 type UserOrg struct {
@@ -239,3 +239,15 @@ func TestSpecJSON_RawMessage_preserves_json(t *testing.T) {
 		"json.RawMessage must NOT base64-encode; got: %s", string(encoded[:20]))
 	assert.JSONEq(t, `{"layouts":[{"name":"test"}]}`, string(encoded))
 }
+
+func TestTemplateStatus_CanTransitionTo(t *testing.T) {
+	assert.True(t, TemplateDraft.CanTransitionTo(TemplateInReview))
+	assert.True(t, TemplateInReview.CanTransitionTo(TemplateApproved))
+	assert.True(t, TemplateInReview.CanTransitionTo(TemplateDraft))
+	assert.True(t, TemplateApproved.CanTransitionTo(TemplatePublished))
+
+	assert.False(t, TemplateDraft.CanTransitionTo(TemplatePublished))
+	assert.False(t, TemplateDraft.CanTransitionTo(TemplateApproved))
+	assert.False(t, TemplatePublished.CanTransitionTo(TemplateDraft))
+	assert.False(t, TemplateArchived.CanTransitionTo(TemplateInReview))
+}
@@ -0,0 +1,34 @@
+package store
+
+import "time"
+
+// Folder groups templates and decks. Folders nest through ParentID; a nil
+// ParentID is a top-level folder.
+type Folder struct {
+	ID        string    `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID     string    `json:"orgId" gorm:"type:uuid;index;not null"`
+	ParentID  *string   `json:"parentId,omitempty" gorm:"type:uuid;index"`
+	Name      string    `json:"name" gorm:"not null"`
+	CreatedBy string    `json:"createdBy" gorm:"type:uuid"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Tag is an org-wide label. Names are unique within an org.
+type Tag struct {
+	ID        string    `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID     string    `json:"orgId" gorm:"type:uuid;not null;uniqueIndex:idx_tags_org_name"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex:idx_tags_org_name"`
+	Color     string    `json:"color,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ResourceTag attaches a tag to a template or deck.
+type ResourceTag struct {
+	OrgID        string       `json:"orgId" gorm:"type:uuid;index;not null"`
+	TagID        string       `json:"tagId" gorm:"type:uuid;primaryKey"`
+	ResourceType ResourceType `json:"resourceType" gorm:"primaryKey"`
+	ResourceID   string       `json:"resourceId" gorm:"type:uuid;primaryKey;index"`
+	CreatedAt    time.Time    `json:"createdAt"`
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+)
+
+// Visibility controls who in the org can see a template or deck without an
+// explicit grant.
+type Visibility string
+
+const (
+	// VisibilityOrg exposes the resource to every member according to their
+	// org role. It is the default, and what an empty value means.
+	VisibilityOrg Visibility = "org"
+	// VisibilityPrivate limits the resource to its owner, org admins and
+	// users holding a grant.
+	VisibilityPrivate Visibility = "private"
+)
+
+type ResourceType string
+
+const (
+	ResourceTemplate ResourceType = "template"
+	ResourceDeck     ResourceType = "deck"
+)
+
+// Permission is an access level on a single resource. Levels are ordered:
+// manage implies edit implies view.
+type Permission string
+
+const (
+	PermissionNone   Permission = ""
+	PermissionView   Permission = "view"
+	PermissionEdit   Permission = "edit"
+	PermissionManage Permission = "manage"
+)
+
+func (p Permission) rank() int {
+	switch p {
+	case PermissionManage:
+		return 3
+	case PermissionEdit:
+		return 2
+	case PermissionView:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Allows reports whether p grants at least min.
+func (p Permission) Allows(min Permission) bool {
+	return p.rank() >= min.rank()
+}
+
+// ResourcePermission is an explicit grant of view or edit access to one user.
+type ResourcePermission struct {
+	OrgID        string       `json:"orgId" gorm:"type:uuid;index;not null"`
+	ResourceType ResourceType `json:"resourceType" gorm:"primaryKey"`
+	ResourceID   string       `json:"resourceId" gorm:"type:uuid;primaryKey"`
+	UserID       string       `json:"userId" gorm:"type:uuid;primaryKey;index"`
+	Permission   Permission   `json:"permission" gorm:"not null"`
+	GrantedBy    string       `json:"grantedBy" gorm:"type:uuid"`
+	CreatedAt    time.Time    `json:"createdAt"`
+}
+
+// EffectivePermission resolves what id may do with a resource. Org admins
+// and the resource owner can manage it; everyone else gets the higher of
+// their grant and, for org-visible resources, what their org role allows.
+func EffectivePermission(id auth.Identity, ownerUserID string, vis Visibility, grant Permission) Permission {
+	if auth.RequireRole(id, auth.RoleAdmin) || (ownerUserID != "" && ownerUserID == id.UserID) {
+		return PermissionManage
+	}
+	perm := grant
+	if vis != VisibilityPrivate {
+		rolePerm := PermissionView
+		if auth.RequireRole(id, auth.RoleEditor) {
+			rolePerm = PermissionEdit
+		}
+		if rolePerm.rank() > perm.rank() {
+			perm = rolePerm
+		}
+	}
+	return perm
+}
@@ -0,0 +1,37 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+)
+
+func TestEffectivePermission(t *testing.T) {
+	editor := auth.Identity{UserID: "u1", OrgID: "o1", Role: auth.RoleEditor}
+	viewer := auth.Identity{UserID: "u1", OrgID: "o1", Role: auth.RoleViewer}
+	admin := auth.Identity{UserID: "u1", OrgID: "o1", Role: auth.RoleAdmin}
+
+	cases := []struct {
+		name  string
+		id    auth.Identity
+		owner string
+		vis   Visibility
+		grant Permission
+		want  Permission
+	}{
+		{"owner manages", viewer, "u1", VisibilityPrivate, PermissionNone, PermissionManage},
+		{"admin manages private", admin, "u2", VisibilityPrivate, PermissionNone, PermissionManage},
+		{"editor edits org-visible", editor, "u2", VisibilityOrg, PermissionNone, PermissionEdit},
+		{"empty visibility is org", viewer, "u2", "", PermissionNone, PermissionView},
+		{"private hidden without grant", editor, "u2", VisibilityPrivate, PermissionNone, PermissionNone},
+		{"grant on private", editor, "u2", VisibilityPrivate, PermissionView, PermissionView},
+		{"grant lifts viewer", viewer, "u2", VisibilityOrg, PermissionEdit, PermissionEdit},
+		{"grant never lowers role", editor, "u2", VisibilityOrg, PermissionView, PermissionEdit},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, EffectivePermission(tc.id, tc.owner, tc.vis, tc.grant))
+		})
+	}
+}
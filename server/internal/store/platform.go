@@ -0,0 +1,37 @@
+package store
+
+import "time"
+
+// UserActivity records that a user made an authenticated request on Day, a
+// UTC midnight. There is one row per user and day, so counting a day's rows
+// gives its active users.
+type UserActivity struct {
+	UserID string    `json:"userId" gorm:"type:uuid;primaryKey"`
+	Day    time.Time `json:"day" gorm:"type:date;primaryKey;index"`
+}
+
+// DailyCount is a count for one UTC day.
+type DailyCount struct {
+	Day   time.Time `json:"day"`
+	Count int       `json:"count"`
+}
+
+// OrgCounts counts the platform's orgs. Deleted orgs awaiting purge are
+// counted in Total but not Active.
+type OrgCounts struct {
+	Total        int `json:"total"`
+	Active       int `json:"active"`
+	Deleted      int `json:"deleted"`
+	CreatedSince int `json:"createdSince"`
+}
+
+// JobCount is the number of jobs of one type in one status.
+type JobCount struct {
+	Type   JobType   `json:"type"`
+	Status JobStatus `json:"status"`
+	Count  int       `json:"count"`
+}
+
+// MeterAICost is recorded alongside "ai_generation" with the generation's
+// estimated provider cost in millionths of a US dollar.
+const MeterAICost = "ai_cost"
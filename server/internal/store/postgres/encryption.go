@@ -0,0 +1,279 @@
+package postgres
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Columns that may hold confidential material (brand kit tokens, job
+// metadata with prompts, deck content) are tagged serializer:encrypted.
+// With a keyring configured they are sealed with AES-256-GCM before they
+// reach Postgres and opened on the way back, so the rest of the code sees
+// plain values. Without one they are stored as before.
+func init() {
+	schema.RegisterSerializer("encrypted", encryptedSerializer{})
+}
+
+// encryptedPrefix marks a sealed value: enc:v1:<key id>:<base64 nonce+ciphertext>.
+const encryptedPrefix = "enc:v1:"
+
+// Keyring holds the data encryption keys by ID. Values are sealed with the
+// primary key and opened with whichever key sealed them, so a new key can
+// become primary while rows sealed with the old one stay readable.
+type Keyring struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+// ParseKeyring reads "id:base64key,id:base64key", primary first. Keys are
+// 32 bytes (AES-256); IDs may not contain ':'.
+func ParseKeyring(spec string) (*Keyring, error) {
+	k := &Keyring{aeads: map[string]cipher.AEAD{}}
+	for i, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			// The entry itself isn't echoed: it may be a bare key.
+			return nil, fmt.Errorf("encryption key %d: want id:base64key", i+1)
+		}
+		if _, dup := k.aeads[id]; dup {
+			return nil, fmt.Errorf("encryption key %q: duplicate id", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %w", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("encryption key %q: want 32 bytes, got %d", id, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %w", id, err)
+		}
+		if k.primary == "" {
+			k.primary = id
+		}
+		k.aeads[id] = aead
+	}
+	if k.primary == "" {
+		return nil, errors.New("no encryption keys")
+	}
+	return k, nil
+}
+
+// Primary is the ID of the key new values are sealed with.
+func (k *Keyring) Primary() string { return k.primary }
+
+func (k *Keyring) seal(plaintext []byte) (string, error) {
+	aead := k.aeads[k.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(k.primary))
+	return encryptedPrefix + k.primary + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (k *Keyring) open(value string) ([]byte, error) {
+	id, encoded, _ := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	aead, ok := k.aeads[id]
+	if !ok {
+		return nil, fmt.Errorf("sealed with unknown key %q", id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, errors.New("malformed sealed value")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+}
+
+// sealedKeyID returns the ID of the key value was sealed with, if it is sealed.
+func sealedKeyID(value string) (string, bool) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return "", false
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	return id, true
+}
+
+var activeKeyring atomic.Pointer[Keyring]
+
+// SetKeyring installs the keyring every Postgres store encrypts with; nil
+// turns encryption off for new writes (sealed rows then can't be read).
+func SetKeyring(k *Keyring) { activeKeyring.Store(k) }
+
+// encryptedSerializer seals a field's JSON encoding. String columns hold the
+// sealed value as text; jsonb columns hold it as a JSON string. Rows written
+// before encryption was enabled are read as they are and sealed the next
+// time they are saved.
+type encryptedSerializer struct{}
+
+// Scan implements schema.SerializerInterface.
+func (encryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue any) error {
+	var text string
+	switch v := dbValue.(type) {
+	case nil:
+		return nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("scan %s: unsupported type %T", field.DBName, dbValue)
+	}
+
+	sealed := text
+	if field.DataType != schema.String {
+		// A sealed jsonb value is a JSON string; anything else is plain JSON.
+		if err := json.Unmarshal([]byte(text), &sealed); err != nil {
+			sealed = ""
+		}
+	}
+	var plaintext []byte
+	if strings.HasPrefix(sealed, encryptedPrefix) {
+		k := activeKeyring.Load()
+		if k == nil {
+			return fmt.Errorf("scan %s: value is encrypted but no keys are configured", field.DBName)
+		}
+		var err error
+		if plaintext, err = k.open(sealed); err != nil {
+			return fmt.Errorf("scan %s: %w", field.DBName, err)
+		}
+	} else if field.DataType == schema.String {
+		field.ReflectValueOf(ctx, dst).Set(reflect.ValueOf(text).Convert(field.FieldType))
+		return nil
+	} else {
+		plaintext = []byte(text)
+	}
+
+	fieldValue := reflect.New(field.FieldType)
+	if err := json.Unmarshal(plaintext, fieldValue.Interface()); err != nil {
+		return fmt.Errorf("scan %s: %w", field.DBName, err)
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+// Value implements schema.SerializerInterface. Nil and empty values are
+// stored as they are; there is nothing in them to protect.
+func (encryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue any) (any, error) {
+	if fieldValue == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(fieldValue); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, nil
+	}
+	if s, ok := fieldValue.(string); ok && s == "" {
+		return "", nil
+	}
+	plaintext, err := json.Marshal(fieldValue)
+	if err != nil {
+		return nil, fmt.Errorf("value %s: %w", field.DBName, err)
+	}
+
+	k := activeKeyring.Load()
+	if k == nil {
+		if s, ok := fieldValue.(string); ok {
+			return s, nil
+		}
+		// A string, not []byte, for the same reason as store.JSONMap.Value.
+		return string(plaintext), nil
+	}
+	sealed, err := k.seal(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("value %s: %w", field.DBName, err)
+	}
+	if field.DataType == schema.String {
+		return sealed, nil
+	}
+	b, _ := json.Marshal(sealed)
+	return string(b), nil
+}
+
+// encryptedColumns are the tables and columns tagged serializer:encrypted.
+var encryptedColumns = []struct {
+	model  any
+	column string
+}{
+	{&store.BrandKit{}, "tokens"},
+	{&store.Job{}, "metadata"},
+	{&store.Deck{}, "content"},
+	{&store.Webhook{}, "secret"},
+	{&store.DeckRefinement{}, "instruction"},
+}
+
+// ReencryptAll seals every encrypted column that is still plaintext or was
+// sealed with a key other than the primary, so a retired key can be removed
+// from DATA_ENCRYPTION_KEYS. It returns how many rows were rewritten.
+func (p *PostgresStore) ReencryptAll(ctx context.Context) (int, error) {
+	k := activeKeyring.Load()
+	if k == nil {
+		return 0, errors.New("no encryption keys configured")
+	}
+	total := 0
+	for _, c := range encryptedColumns {
+		stmt := &gorm.Statement{DB: p.db}
+		if err := stmt.Parse(c.model); err != nil {
+			return total, err
+		}
+		table := stmt.Schema.Table
+
+		var rows []struct {
+			ID    string
+			Value *string
+		}
+		err := p.db.WithContext(ctx).Table(table).
+			Select("id, " + c.column + "::text AS value").
+			Where(c.column + " IS NOT NULL").
+			Find(&rows).Error
+		if err != nil {
+			return total, fmt.Errorf("list %s.%s: %w", table, c.column, err)
+		}
+		for _, row := range rows {
+			if row.Value == nil || *row.Value == "" || *row.Value == "null" {
+				continue
+			}
+			raw := *row.Value
+			if stmt.Schema.LookUpField(c.column).DataType != schema.String {
+				var s string
+				if json.Unmarshal([]byte(raw), &s) == nil {
+					raw = s
+				}
+			}
+			if id, sealed := sealedKeyID(raw); sealed && id == k.primary {
+				continue
+			}
+			// Loading and saving the column runs it through the serializer,
+			// which opens it with its old key and seals it with the primary.
+			dst := reflect.New(stmt.Schema.ModelType).Interface()
+			if err := p.db.WithContext(ctx).Where("id = ?", row.ID).Select("id", c.column).Take(dst).Error; err != nil {
+				return total, fmt.Errorf("load %s %s: %w", table, row.ID, err)
+			}
+			if err := p.db.WithContext(ctx).Model(dst).Select(c.column).Updates(dst).Error; err != nil {
+				return total, fmt.Errorf("reencrypt %s %s: %w", table, row.ID, err)
+			}
+			total++
+		}
+	}
+	return total, nil
+}
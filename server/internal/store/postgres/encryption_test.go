@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"gorm.io/gorm/schema"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+func encryptedField(t *testing.T, model any, name string) *schema.Field {
+	t.Helper()
+	s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+	f := s.LookUpField(name)
+	require.NotNil(t, f)
+	require.IsType(t, encryptedSerializer{}, f.Serializer)
+	return f
+}
+
+func useKeyring(t *testing.T, spec string) {
+	t.Helper()
+	k, err := ParseKeyring(spec)
+	require.NoError(t, err)
+	SetKeyring(k)
+	t.Cleanup(func() { SetKeyring(nil) })
+}
+
+func TestParseKeyring(t *testing.T) {
+	k, err := ParseKeyring("k2:" + testKey('b') + ", k1:" + testKey('a'))
+	require.NoError(t, err)
+	assert.Equal(t, "k2", k.Primary())
+
+	for _, bad := range []string{"", "k1", "k1:not-base64!", "k1:" + base64.StdEncoding.EncodeToString([]byte("short")), "k1:" + testKey('a') + ",k1:" + testKey('b')} {
+		_, err := ParseKeyring(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestEncryptedSerializer_JobMetadataRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	f := encryptedField(t, &store.Job{}, "Metadata")
+	useKeyring(t, "k1:"+testKey('a'))
+
+	md := store.JSONMap{"prompt": "Board update for Acme's acquisition"}
+	v, err := encryptedSerializer{}.Value(ctx, f, reflect.Value{}, &md)
+	require.NoError(t, err)
+	stored := v.(string)
+	assert.NotContains(t, stored, "Acme")
+	var sealed string
+	require.NoError(t, json.Unmarshal([]byte(stored), &sealed), "jsonb gets a JSON string")
+	assert.True(t, strings.HasPrefix(sealed, "enc:v1:k1:"))
+
+	var job store.Job
+	require.NoError(t, encryptedSerializer{}.Scan(ctx, f, reflect.ValueOf(&job).Elem(), []byte(stored)))
+	require.NotNil(t, job.Metadata)
+	assert.Equal(t, md, *job.Metadata)
+
+	v, err = encryptedSerializer{}.Value(ctx, f, reflect.Value{}, (*store.JSONMap)(nil))
+	require.NoError(t, err)
+	assert.Nil(t, v)
+}
+
+func TestEncryptedSerializer_DeckContentAndRotation(t *testing.T) {
+	ctx := context.Background()
+	f := encryptedField(t, &store.Deck{}, "Content")
+	useKeyring(t, "k1:"+testKey('a'))
+
+	v, err := encryptedSerializer{}.Value(ctx, f, reflect.Value{}, "Q3 revenue: $4.1M")
+	require.NoError(t, err)
+	oldSealed := v.(string)
+	assert.True(t, strings.HasPrefix(oldSealed, "enc:v1:k1:"), "text columns hold the sealed value as is")
+
+	// After rotating, values sealed with k1 still open and new ones use k2.
+	useKeyring(t, "k2:"+testKey('b')+",k1:"+testKey('a'))
+	var d store.Deck
+	require.NoError(t, encryptedSerializer{}.Scan(ctx, f, reflect.ValueOf(&d).Elem(), oldSealed))
+	assert.Equal(t, "Q3 revenue: $4.1M", d.Content)
+	v, err = encryptedSerializer{}.Value(ctx, f, reflect.Value{}, d.Content)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(v.(string), "enc:v1:k2:"))
+
+	// Once k1 is retired its values can't be read.
+	useKeyring(t, "k2:"+testKey('b'))
+	assert.Error(t, encryptedSerializer{}.Scan(ctx, f, reflect.ValueOf(&d).Elem(), oldSealed))
+}
+
+func TestEncryptedSerializer_WebhookSecret(t *testing.T) {
+	ctx := context.Background()
+	f := encryptedField(t, &store.Webhook{}, "Secret")
+	useKeyring(t, "k1:"+testKey('a'))
+
+	v, err := encryptedSerializer{}.Value(ctx, f, reflect.Value{}, "whsec_abc123")
+	require.NoError(t, err)
+	assert.NotContains(t, v.(string), "whsec_abc123")
+
+	var hook store.Webhook
+	require.NoError(t, encryptedSerializer{}.Scan(ctx, f, reflect.ValueOf(&hook).Elem(), v))
+	assert.Equal(t, "whsec_abc123", hook.Secret)
+
+	// Secrets saved before encryption was enabled still sign deliveries.
+	require.NoError(t, encryptedSerializer{}.Scan(ctx, f, reflect.ValueOf(&hook).Elem(), "whsec_legacy"))
+	assert.Equal(t, "whsec_legacy", hook.Secret)
+}
+
+func TestEncryptedSerializer_ReadsPlaintextRows(t *testing.T) {
+	ctx := context.Background()
+	useKeyring(t, "k1:"+testKey('a'))
+
+	var d store.Deck
+	require.NoError(t, encryptedSerializer{}.Scan(ctx, encryptedField(t, &store.Deck{}, "Content"), reflect.ValueOf(&d).Elem(), "legacy content"))
+	assert.Equal(t, "legacy content", d.Content)
+
+	var bk store.BrandKit
+	require.NoError(t, encryptedSerializer{}.Scan(ctx, encryptedField(t, &store.BrandKit{}, "Tokens"), reflect.ValueOf(&bk).Elem(), []byte(`{"colors":{"primary":"#112233"}}`)))
+	assert.Equal(t, map[string]any{"colors": map[string]any{"primary": "#112233"}}, bk.Tokens)
+}
+
+func TestEncryptedSerializer_WithoutKeysStoresPlaintext(t *testing.T) {
+	ctx := context.Background()
+	SetKeyring(nil)
+	f := encryptedField(t, &store.BrandKit{}, "Tokens")
+
+	v, err := encryptedSerializer{}.Value(ctx, f, reflect.Value{}, map[string]any{"font": "Inter"})
+	require.NoError(t, err)
+	assert.Equal(t, `{"font":"Inter"}`, v)
+}
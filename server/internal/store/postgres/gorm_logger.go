@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+)
+
+// slowQueryThreshold is the duration above which queries are logged at warn level.
+const slowQueryThreshold = 500 * time.Millisecond
+
+// slogGormLogger routes GORM output through the structured logger so every
+// query carries the request_id/user_id/org_id stored on the call's context.
+// Per-query traces are emitted at debug level and therefore only appear when
+// LOG_LEVEL=debug.
+type slogGormLogger struct {
+	level gormlogger.LogLevel
+}
+
+func newGormLogger(level gormlogger.LogLevel) gormlogger.Interface {
+	return &slogGormLogger{level: level}
+}
+
+func (l *slogGormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return &slogGormLogger{level: level}
+}
+
+func (l *slogGormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.log(ctx).Debug("gorm_info", "message", fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *slogGormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.log(ctx).Warn("gorm_warning", "message", fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *slogGormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.log(ctx).Error("gorm_error", "message", fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *slogGormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		sql, rows := fc()
+		l.log(ctx).Error("database_query_failed", "error", err, "sql", sql, "rows", rows, "duration_ms", elapsed.Milliseconds())
+	case elapsed > slowQueryThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		l.log(ctx).Warn("database_slow_query", "sql", sql, "rows", rows, "duration_ms", elapsed.Milliseconds())
+	case logger.DebugEnabled(ctx):
+		sql, rows := fc()
+		l.log(ctx).Debug("database_query", "sql", sql, "rows", rows, "duration_ms", elapsed.Milliseconds())
+	}
+}
+
+func (l *slogGormLogger) log(ctx context.Context) *slog.Logger {
+	return logger.WithContext(ctx).With("component", "database")
+}
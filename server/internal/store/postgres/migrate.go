@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"gorm.io/gorm"
+)
+
+// baselineVersion is the last migration written before schema_migrations
+// existed. Those files were applied by hand (or their tables came from
+// AutoMigrate) and are not idempotent, so on a database with no tracking rows
+// they are recorded as applied rather than run.
+const baselineVersion = 6
+
+// migrationLockID serializes migration runs across server instances.
+const migrationLockID = 7_426_031_115
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.sql$`)
+
+// Migration is one versioned SQL file.
+type Migration struct {
+	Version  int
+	Name     string
+	SQL      string
+	Checksum string
+}
+
+func (m Migration) String() string { return fmt.Sprintf("%03d_%s", m.Version, m.Name) }
+
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey"`
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string { return "schema_migrations" }
+
+// LoadMigrations reads every NNN_name.sql file in fsys, sorted by version.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+	var out []Migration
+	seen := map[int]string{}
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+		match := migrationFileRe.FindStringSubmatch(e.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %q: name must look like 001_description.sql", e.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		if prev, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %q and %q share version %d", prev, e.Name(), version)
+		}
+		seen[version] = e.Name()
+
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read migration %q: %w", e.Name(), err)
+		}
+		sum := sha256.Sum256(body)
+		out = append(out, Migration{Version: version, Name: match[2], SQL: string(body), Checksum: hex.EncodeToString(sum[:])})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Migrate applies every migration in fsys that schema_migrations has no
+// record of, in version order and in a single transaction. It fails if an
+// applied migration's file has changed since it ran.
+func Migrate(ctx context.Context, db *gorm.DB, fsys fs.FS) error {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return err
+	}
+
+	return db.WithContext(withoutQueryTimeout(ctx)).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		// Schema changes may outlast the per-statement limit on large tables.
+		if err := tx.Exec("SET LOCAL statement_timeout = 0").Error; err != nil {
+			return fmt.Errorf("disable statement timeout: %w", err)
+		}
+		if err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`).Error; err != nil {
+			return fmt.Errorf("create schema_migrations: %w", err)
+		}
+
+		var applied []schemaMigration
+		if err := tx.Find(&applied).Error; err != nil {
+			return fmt.Errorf("read schema_migrations: %w", err)
+		}
+		done := make(map[int]schemaMigration, len(applied))
+		for _, a := range applied {
+			done[a.Version] = a
+		}
+		baseline := len(applied) == 0
+
+		for _, m := range migrations {
+			if a, ok := done[m.Version]; ok {
+				if a.Checksum != m.Checksum {
+					return fmt.Errorf("migration %s has changed since it was applied", m)
+				}
+				continue
+			}
+			if baseline && m.Version <= baselineVersion {
+				logger.Database().Info("migration_baselined", "migration", m.String())
+			} else {
+				if err := tx.Exec(m.SQL).Error; err != nil {
+					return fmt.Errorf("apply migration %s: %w", m, err)
+				}
+				logger.Database().Info("migration_applied", "migration", m.String())
+			}
+			row := schemaMigration{Version: m.Version, Name: m.Name, Checksum: m.Checksum, AppliedAt: time.Now().UTC()}
+			if err := tx.Create(&row).Error; err != nil {
+				return fmt.Errorf("record migration %s: %w", m, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/migrations"
+)
+
+func TestLoadMigrations_SortsAndChecksums(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_second.sql": {Data: []byte("SELECT 2;")},
+		"002_first.sql":  {Data: []byte("SELECT 1;")},
+		"README.md":      {Data: []byte("ignored")},
+	}
+	got, err := LoadMigrations(fsys)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "002_first", got[0].String())
+	assert.Equal(t, 10, got[1].Version)
+	assert.Len(t, got[0].Checksum, 64)
+	assert.NotEqual(t, got[0].Checksum, got[1].Checksum)
+}
+
+func TestLoadMigrations_RejectsBadSets(t *testing.T) {
+	_, err := LoadMigrations(fstest.MapFS{"add_things.sql": {Data: []byte("")}})
+	assert.ErrorContains(t, err, "001_description.sql")
+
+	_, err = LoadMigrations(fstest.MapFS{
+		"003_a.sql": {Data: []byte("")},
+		"3_b.sql":   {Data: []byte("")},
+	})
+	assert.ErrorContains(t, err, "share version 3")
+}
+
+func TestEmbeddedMigrations_AreContiguous(t *testing.T) {
+	got, err := LoadMigrations(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, got)
+	for i, m := range got {
+		assert.Equal(t, i+1, m.Version, "migration %s is out of sequence", m)
+	}
+	assert.Greater(t, len(got), baselineVersion)
+}
+
+// TestStorageBytesBackfill_CountsExistingAssets runs migration 043 on an org
+// whose assets were stored before storage_bytes_used was maintained.
+func TestStorageBytesBackfill_CountsExistingAssets(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("Skipping postgres integration test: TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	s, err := New(dsn)
+	require.NoError(t, err)
+	defer s.Close()
+
+	org := store.Organization{ID: newID("org"), Name: "Backfill"}
+	require.NoError(t, s.db.Create(&org).Error)
+	// Written straight to the table, bypassing the counter like old rows did.
+	for _, size := range []int64{100, 250} {
+		require.NoError(t, s.db.Create(&store.Asset{ID: newID("asset"), OrgID: org.ID, Type: store.AssetPPTX, Path: org.ID + "/f", SizeBytes: size}).Error)
+	}
+	total, err := s.Assets().TotalBytes(ctx, org.ID)
+	require.NoError(t, err)
+	require.Zero(t, total)
+
+	all, err := LoadMigrations(migrations.FS)
+	require.NoError(t, err)
+	var backfill string
+	for _, m := range all {
+		if m.Version == 43 {
+			backfill = m.SQL
+		}
+	}
+	require.NotEmpty(t, backfill)
+	require.NoError(t, s.db.Exec(backfill).Error)
+
+	total, err = s.Assets().TotalBytes(ctx, org.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(350), total)
+}
+
+// checkConstraintValues returns the values allowed by the last definition
+// of the named "col IN (...)" CHECK constraint across the embedded
+// migrations.
+func checkConstraintValues(t *testing.T, name string) []string {
+	t.Helper()
+	all, err := LoadMigrations(migrations.FS)
+	require.NoError(t, err)
+	re := regexp.MustCompile(`ADD CONSTRAINT ` + name + ` CHECK \(\w+ IN \(([^)]*)\)\)`)
+	var values []string
+	for _, m := range all {
+		for _, match := range re.FindAllStringSubmatch(m.SQL, -1) {
+			values = values[:0]
+			for _, v := range strings.Split(match[1], ",") {
+				values = append(values, strings.Trim(strings.TrimSpace(v), "'"))
+			}
+		}
+	}
+	require.NotEmpty(t, values, "no migration defines %s", name)
+	return values
+}
+
+func TestJobsTypeCheck_AllowsEveryJobType(t *testing.T) {
+	allowed := checkConstraintValues(t, "jobs_type_check")
+	for _, jt := range store.JobTypes {
+		assert.Contains(t, allowed, string(jt), "jobs_type_check rejects job type %q", jt)
+	}
+}
+
+func TestAssetsTypeCheck_AllowsEveryAssetType(t *testing.T) {
+	allowed := checkConstraintValues(t, "assets_type_check")
+	for _, at := range store.AssetTypes {
+		assert.Contains(t, allowed, string(at), "assets_type_check rejects asset type %q", at)
+	}
+}
+
+func TestTemplatesStatusCheck_AllowsEveryStatus(t *testing.T) {
+	allowed := checkConstraintValues(t, "templates_status_check")
+	for _, st := range store.TemplateStatuses {
+		assert.Contains(t, allowed, string(st), "templates_status_check rejects status %q", st)
+	}
+}
+
+func TestMigrate_IsIdempotent(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("Skipping postgres integration test: TEST_DATABASE_URL not set")
+	}
+
+	s, err := New(dsn)
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, Migrate(context.Background(), s.db, migrations.FS))
+	var count int64
+	require.NoError(t, s.db.Model(&schemaMigration{}).Count(&count).Error)
+	all, _ := LoadMigrations(migrations.FS)
+	assert.Equal(t, int64(len(all)), count)
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PoolConfig bounds how many connections the store holds and how long any one
+// statement may run, so a slow query or a leak degrades requests instead of
+// exhausting Postgres.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// StatementTimeout is enforced by Postgres on every statement.
+	StatementTimeout time.Duration
+	// QueryTimeout is applied to the context of each query that doesn't
+	// already carry a deadline.
+	QueryTimeout time.Duration
+}
+
+// DefaultPoolConfig is sized for a single API instance.
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:     25,
+		MaxIdleConns:     10,
+		ConnMaxLifetime:  30 * time.Minute,
+		ConnMaxIdleTime:  5 * time.Minute,
+		StatementTimeout: 30 * time.Second,
+		QueryTimeout:     15 * time.Second,
+	}
+}
+
+// withStatementTimeout adds statement_timeout to dsn as a connection runtime
+// parameter unless the DSN already sets one. Both URL and key=value DSNs are
+// supported.
+func withStatementTimeout(dsn string, d time.Duration) string {
+	if d <= 0 || strings.Contains(dsn, "statement_timeout") {
+		return dsn
+	}
+	ms := strconv.FormatInt(d.Milliseconds(), 10)
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return dsn
+		}
+		q := u.Query()
+		q.Set("statement_timeout", ms)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return strings.TrimSpace(dsn) + " statement_timeout=" + ms
+}
+
+type noQueryTimeoutKey struct{}
+
+// withoutQueryTimeout exempts long-running work such as migrations from the
+// per-query deadline.
+func withoutQueryTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noQueryTimeoutKey{}, true)
+}
+
+const queryCancelKey = "cms:query_cancel"
+
+// registerQueryTimeout gives every create, query, update, delete and raw
+// statement a deadline of d unless its context already has one. Row/Rows
+// callers read results after the callback chain returns, so they rely on
+// statement_timeout instead.
+func registerQueryTimeout(db *gorm.DB, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	start := func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if _, ok := ctx.Deadline(); ok || ctx.Value(noQueryTimeoutKey{}) != nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(ctx, d)
+		tx.Statement.Context = ctx
+		tx.InstanceSet(queryCancelKey, cancel)
+	}
+	end := func(tx *gorm.DB) {
+		if cancel, ok := tx.InstanceGet(queryCancelKey); ok {
+			cancel.(context.CancelFunc)()
+		}
+	}
+
+	cb := db.Callback()
+	if err := errors.Join(
+		cb.Create().Before("gorm:create").Register("cms:timeout_start", start),
+		cb.Create().After("gorm:create").Register("cms:timeout_end", end),
+		cb.Query().Before("gorm:query").Register("cms:timeout_start", start),
+		cb.Query().After("gorm:after_query").Register("cms:timeout_end", end),
+		cb.Update().Before("gorm:update").Register("cms:timeout_start", start),
+		cb.Update().After("gorm:update").Register("cms:timeout_end", end),
+		cb.Delete().Before("gorm:delete").Register("cms:timeout_start", start),
+		cb.Delete().After("gorm:delete").Register("cms:timeout_end", end),
+		cb.Raw().Before("gorm:raw").Register("cms:timeout_start", start),
+		cb.Raw().After("gorm:raw").Register("cms:timeout_end", end),
+	); err != nil {
+		return fmt.Errorf("register query timeout: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestWithStatementTimeout(t *testing.T) {
+	assert.Equal(t,
+		"postgres://u:p@db:5432/cms?sslmode=disable&statement_timeout=30000",
+		withStatementTimeout("postgres://u:p@db:5432/cms?sslmode=disable", 30*time.Second))
+	assert.Equal(t,
+		"host=db dbname=cms statement_timeout=1500",
+		withStatementTimeout("host=db dbname=cms ", 1500*time.Millisecond))
+
+	explicit := "postgres://db/cms?statement_timeout=100"
+	assert.Equal(t, explicit, withStatementTimeout(explicit, time.Minute))
+	assert.Equal(t, "host=db", withStatementTimeout("host=db", 0))
+}
+
+func TestReader_FallsBackToPrimary(t *testing.T) {
+	open := func(dsn string) *gorm.DB {
+		db, err := gorm.Open(postgres.New(postgres.Config{DSN: dsn}), &gorm.Config{DisableAutomaticPing: true})
+		require.NoError(t, err)
+		return db
+	}
+	primary, replica := open("host=primary"), open("host=replica")
+	ctx := context.Background()
+
+	ps := &PostgresStore{db: primary}
+	assert.False(t, ps.HasReplica())
+	assert.Same(t, primary.Statement.ConnPool, ps.reader(ctx).Statement.ConnPool)
+
+	ps.replica = replica
+	assert.True(t, ps.HasReplica())
+	assert.Same(t, replica.Statement.ConnPool, ps.reader(ctx).Statement.ConnPool)
+}
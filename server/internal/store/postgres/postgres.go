@@ -3,46 +3,73 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
-	"os"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	"gorm.io/gorm/clause"
+	gormlogger "gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+	"github.com/ziyad/cms-ai/server/internal/redact"
 	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/cache"
+	"github.com/ziyad/cms-ai/server/migrations"
 )
 
 type PostgresStore struct {
-	db *gorm.DB
+	db   *gorm.DB
+	pool PoolConfig
+	// replica serves list and diagnostics reads when a read-only DSN is
+	// configured; nil means everything goes to db.
+	replica *gorm.DB
+	// cache serves hot template and brand kit reads; nil caches nothing.
+	cache *cache.Cache
 }
 
+// Options configure a Postgres store beyond its primary DSN.
+type Options struct {
+	// ReplicaURL, if set, is a read replica for list queries. A replica
+	// that can't be reached is logged and skipped so the store keeps
+	// working against the primary alone.
+	ReplicaURL string
+	Pool       PoolConfig
+	// Keys seal the encrypted columns; nil stores them in plain text.
+	Keys *Keyring
+	// LogQueries logs every SQL statement.
+	LogQueries bool
+}
+
+// New connects to dsn with the default pool and no column encryption.
 func New(dsn string) (*PostgresStore, error) {
-	// Set up GORM with a logger and a custom naming strategy
-	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Error),
-		NamingStrategy: schema.NamingStrategy{
-			IdentifierMaxLength: 64,
-		},
-	}
-	if os.Getenv("DEV_MODE") == "true" {
-		gormConfig.Logger = logger.Default.LogMode(logger.Info)
+	return NewWithOptions(dsn, Options{Pool: DefaultPoolConfig()})
+}
+
+// NewWithOptions connects to the primary at dsn and migrates it.
+func NewWithOptions(dsn string, opts Options) (*PostgresStore, error) {
+	pool := opts.Pool
+	SetKeyring(opts.Keys)
+	if opts.Keys != nil {
+		logger.Database().Info("column_encryption_enabled", "primary_key", opts.Keys.Primary())
 	}
 
-	// Use PreferSimpleProtocol for cloud compatibility (Railway/Supabase)
-	db, err := gorm.Open(postgres.New(postgres.Config{
-		DSN:                  dsn,
-		PreferSimpleProtocol: true,
-	}), gormConfig)
+	db, err := openDB(dsn, pool, opts.LogQueries)
 	if err != nil {
 		return nil, err
 	}
+	logger.Database().Info("connection_pool_configured",
+		"max_open", pool.MaxOpenConns,
+		"max_idle", pool.MaxIdleConns,
+		"statement_timeout", pool.StatementTimeout.String(),
+		"query_timeout", pool.QueryTimeout.String(),
+	)
 
 	// Auto-migrate all models EXCEPT User/UserOrg (managed manually below)
-	log.Println("Running GORM auto-migration (skipping User/UserOrg)...")
+	logger.Database().Info("auto_migration_start", "skipped", "users,user_orgs")
 	err = db.AutoMigrate(
 		&store.Organization{},
 		&store.Template{},
@@ -54,43 +81,113 @@ func New(dsn string) (*PostgresStore, error) {
 		&store.Job{},
 		&store.MeteringEvent{},
 		&store.AuditLog{},
+		&store.ResourcePermission{},
+		&store.Comment{},
+		&store.DeckRefinement{},
+		&store.Folder{},
+		&store.Tag{},
+		&store.ResourceTag{},
+		&store.Webhook{},
+		&store.WebhookDelivery{},
+		&store.DeckEmbed{},
+		&store.APIKey{},
+		&store.RetryPolicyOverride{},
+		&store.FeatureFlag{},
+		&store.Font{},
+		&store.GlossaryEntry{},
+		&store.Schedule{},
+		&store.Connector{},
+		&store.AICall{},
+		&store.GeneratedImage{},
+		&store.UserActivity{},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to auto-migrate: %w", err)
 	}
 
-	// Manual schema for User/UserOrg (after AutoMigrate so organizations FK exists).
-	// Managed manually to avoid GORM constraint name conflicts.
-	log.Println("Creating User/UserOrg tables (manual SQL)...")
-	manualSchemaSQL := `
-		CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+	// Versioned SQL runs after AutoMigrate so the organizations table that
+	// user_orgs references already exists.
+	if err := Migrate(context.Background(), db, migrations.FS); err != nil {
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
 
-		CREATE TABLE IF NOT EXISTS users (
-			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-			email TEXT NOT NULL,
-			name TEXT,
-			created_at TIMESTAMPTZ DEFAULT NOW(),
-			updated_at TIMESTAMPTZ DEFAULT NOW()
-		);
+	ps := &PostgresStore{db: db, pool: pool}
+	if opts.ReplicaURL != "" {
+		replica, err := openDB(opts.ReplicaURL, pool, opts.LogQueries)
+		if err == nil {
+			err = pingDB(replica)
+		}
+		if err != nil {
+			logger.Database().Warn("read_replica_unavailable_using_primary", "error", err)
+		} else {
+			ps.replica = replica
+			logger.Database().Info("read_replica_connected")
+		}
+	}
+	return ps, nil
+}
 
-		CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_unique ON users(email);
+func openDB(dsn string, pool PoolConfig, logQueries bool) (*gorm.DB, error) {
+	// Set up GORM with a logger and a custom naming strategy
+	// Query traces go through the structured logger; they are only visible with LOG_LEVEL=debug.
+	gormConfig := &gorm.Config{
+		Logger: newGormLogger(gormlogger.Warn),
+		NamingStrategy: schema.NamingStrategy{
+			IdentifierMaxLength: 64,
+		},
+	}
+	if logQueries {
+		gormConfig.Logger = newGormLogger(gormlogger.Info)
+	}
 
-		CREATE TABLE IF NOT EXISTS user_orgs (
-			user_id UUID REFERENCES users(id) ON DELETE CASCADE,
-			org_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
-			role TEXT NOT NULL,
-			created_at TIMESTAMPTZ DEFAULT NOW(),
-			PRIMARY KEY (user_id, org_id)
-		);
-	`
-	if err := db.Exec(manualSchemaSQL).Error; err != nil {
-		log.Printf("Manual schema warning (non-fatal): %v", err)
+	// Use PreferSimpleProtocol for cloud compatibility (Railway/Supabase)
+	db, err := gorm.Open(postgres.New(postgres.Config{
+		DSN:                  withStatementTimeout(dsn, pool.StatementTimeout),
+		PreferSimpleProtocol: true,
+	}), gormConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	if err := registerQueryTimeout(db, pool.QueryTimeout); err != nil {
+		return nil, err
 	}
+	return db, nil
+}
+
+func pingDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
 
-	return &PostgresStore{db: db}, nil
+// reader returns the connection for reads that can tolerate replica lag:
+// listings and reports, never lookups that follow a write in the same request.
+func (p *PostgresStore) reader(ctx context.Context) *gorm.DB {
+	if p.replica != nil {
+		return p.replica.WithContext(ctx)
+	}
+	return p.db.WithContext(ctx)
 }
 
 func (p *PostgresStore) Close() error {
+	if p.replica != nil {
+		if sqlDB, err := p.replica.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}
 	sqlDB, err := p.db.DB()
 	if err != nil {
 		return err
@@ -103,15 +200,72 @@ func (p *PostgresStore) DB() (*sql.DB, error) {
 	return p.db.DB()
 }
 
-func (p *PostgresStore) Templates() store.TemplateStore         { return (*postgresTemplateStore)(p) }
+// HasReplica reports whether reads are being routed to a replica.
+func (p *PostgresStore) HasReplica() bool { return p.replica != nil }
+
+// ReadDB is the connection read-only diagnostics should use: the replica
+// when one is configured, otherwise the primary.
+func (p *PostgresStore) ReadDB() (*sql.DB, error) {
+	if p.replica != nil {
+		return p.replica.DB()
+	}
+	return p.db.DB()
+}
+
+// PoolStats reports the configured pool limits alongside the live
+// database/sql counters.
+func (p *PostgresStore) PoolStats() (PoolConfig, sql.DBStats, error) {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return p.pool, sql.DBStats{}, err
+	}
+	return p.pool, sqlDB.Stats(), nil
+}
+
+func (p *PostgresStore) Templates() store.TemplateStore         { return p.cache.Templates((*postgresTemplateStore)(p)) }
 func (p *PostgresStore) Decks() store.DeckStore                 { return (*postgresDeckStore)(p) }
-func (p *PostgresStore) BrandKits() store.BrandKitStore         { return (*postgresBrandKitStore)(p) }
+func (p *PostgresStore) BrandKits() store.BrandKitStore         { return p.cache.BrandKits((*postgresBrandKitStore)(p)) }
 func (p *PostgresStore) Assets() store.AssetStore               { return (*postgresAssetStore)(p) }
 func (p *PostgresStore) Jobs() store.JobStore                   { return (*postgresJobStore)(p) }
 func (p *PostgresStore) Metering() store.MeteringStore         { return (*postgresMeteringStore)(p) }
 func (p *PostgresStore) Audit() store.AuditStore               { return (*postgresAuditStore)(p) }
 func (p *PostgresStore) Users() store.UserStore                 { return (*postgresUserStore)(p) }
 func (p *PostgresStore) Organizations() store.OrganizationStore { return (*postgresOrganizationStore)(p) }
+func (p *PostgresStore) Permissions() store.PermissionStore     { return (*postgresPermissionStore)(p) }
+func (p *PostgresStore) Comments() store.CommentStore           { return (*postgresCommentStore)(p) }
+func (p *PostgresStore) Tags() store.TagStore                   { return (*postgresTagStore)(p) }
+func (p *PostgresStore) Folders() store.FolderStore             { return (*postgresFolderStore)(p) }
+func (p *PostgresStore) Webhooks() store.WebhookStore           { return (*postgresWebhookStore)(p) }
+func (p *PostgresStore) Embeds() store.EmbedStore               { return (*postgresEmbedStore)(p) }
+func (p *PostgresStore) APIKeys() store.APIKeyStore             { return (*postgresAPIKeyStore)(p) }
+func (p *PostgresStore) RetryPolicies() store.RetryPolicyStore  { return (*postgresRetryPolicyStore)(p) }
+func (p *PostgresStore) FeatureFlags() store.FeatureFlagStore    { return (*postgresFeatureFlagStore)(p) }
+func (p *PostgresStore) Fonts() store.FontStore                 { return (*postgresFontStore)(p) }
+func (p *PostgresStore) Glossary() store.GlossaryStore          { return (*postgresGlossaryStore)(p) }
+func (p *PostgresStore) Schedules() store.ScheduleStore         { return (*postgresScheduleStore)(p) }
+func (p *PostgresStore) Connectors() store.ConnectorStore       { return (*postgresConnectorStore)(p) }
+func (p *PostgresStore) AICalls() store.AICallStore             { return (*postgresAICallStore)(p) }
+func (p *PostgresStore) GeneratedImages() store.GeneratedImageStore {
+	return (*postgresGeneratedImageStore)(p)
+}
+
+func (p *PostgresStore) Platform() store.PlatformStore { return (*postgresPlatformStore)(p) }
+
+// UseCache puts c in front of template and brand kit reads. Call it before
+// the store is shared.
+func (p *PostgresStore) UseCache(c *cache.Cache) { p.cache = c }
+
+// CacheStats reports how the read cache is doing.
+func (p *PostgresStore) CacheStats() cache.Stats { return p.cache.Stats() }
+
+func (p *PostgresStore) WithTx(ctx context.Context, fn func(tx store.Store) error) error {
+	txCache := p.cache.Begin()
+	defer txCache.End(ctx)
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// No replica inside a transaction: reads must see its own writes.
+		return fn(&PostgresStore{db: tx, pool: p.pool, cache: txCache})
+	})
+}
 
 type postgresTemplateStore PostgresStore
 
@@ -124,6 +278,9 @@ func (p *postgresTemplateStore) CreateTemplate(ctx context.Context, t store.Temp
 		t.CreatedAt = time.Now().UTC()
 	}
 	t.UpdatedAt = t.CreatedAt
+	if t.Visibility == "" {
+		t.Visibility = store.VisibilityOrg
+	}
 	err := ps.db.WithContext(ctx).Create(&t).Error
 	return t, err
 }
@@ -131,7 +288,7 @@ func (p *postgresTemplateStore) CreateTemplate(ctx context.Context, t store.Temp
 func (p *postgresTemplateStore) ListTemplates(ctx context.Context, orgID string) ([]store.Template, error) {
 	ps := (*PostgresStore)(p)
 	var ts []store.Template
-	err := ps.db.WithContext(ctx).Where("org_id = ?", orgID).Find(&ts).Error
+	err := ps.reader(ctx).Where("org_id = ?", orgID).Find(&ts).Error
 	return ts, err
 }
 
@@ -155,6 +312,30 @@ func (p *postgresTemplateStore) UpdateTemplate(ctx context.Context, t store.Temp
 	return t, err
 }
 
+func (p *postgresTemplateStore) ListTemplatesFor(ctx context.Context, id auth.Identity) ([]store.Template, error) {
+	ps := (*PostgresStore)(p)
+	var ts []store.Template
+	q := ps.reader(ctx).Where("templates.org_id = ?", id.OrgID)
+	err := ps.visibleTo(q, "templates", store.ResourceTemplate, id).Find(&ts).Error
+	return ts, err
+}
+
+func (p *postgresTemplateStore) GetTemplateFor(ctx context.Context, id auth.Identity, templateID string) (store.Template, store.Permission, bool, error) {
+	t, ok, err := (*PostgresStore)(p).Templates().GetTemplate(ctx, id.OrgID, templateID)
+	if err != nil || !ok {
+		return store.Template{}, store.PermissionNone, false, err
+	}
+	grant, err := (*PostgresStore)(p).grant(ctx, t.OrgID, store.ResourceTemplate, t.ID, id.UserID)
+	if err != nil {
+		return store.Template{}, store.PermissionNone, false, err
+	}
+	perm := store.EffectivePermission(id, t.OwnerUserID, t.Visibility, grant)
+	if !perm.Allows(store.PermissionView) {
+		return store.Template{}, store.PermissionNone, false, nil
+	}
+	return t, perm, true, nil
+}
+
 func (p *postgresTemplateStore) CreateVersion(ctx context.Context, v store.TemplateVersion) (store.TemplateVersion, error) {
 	ps := (*PostgresStore)(p)
 	if v.ID == "" {
@@ -187,78 +368,941 @@ func (p *postgresTemplateStore) GetVersion(ctx context.Context, orgID, versionID
 	return v, true, nil
 }
 
-type postgresDeckStore PostgresStore
+func (p *postgresTemplateStore) GetVersions(ctx context.Context, orgID string, ids []string) (map[string]store.TemplateVersion, error) {
+	ps := (*PostgresStore)(p)
+	out := make(map[string]store.TemplateVersion, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	var vs []store.TemplateVersion
+	if err := ps.db.WithContext(ctx).Where("org_id = ? AND id IN ?", orgID, ids).Find(&vs).Error; err != nil {
+		return nil, err
+	}
+	for _, v := range vs {
+		out[v.ID] = v
+	}
+	return out, nil
+}
+
+func (p *postgresTemplateStore) ListTemplatesWithCurrentVersion(ctx context.Context, id auth.Identity) ([]store.TemplateWithVersion, error) {
+	ts, err := p.ListTemplatesFor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := p.GetVersions(ctx, id.OrgID, store.CurrentVersionIDs(ts))
+	if err != nil {
+		return nil, err
+	}
+	return store.WithCurrentVersions(ts, versions), nil
+}
+
+type postgresDeckStore PostgresStore
+
+func (p *postgresDeckStore) CreateDeck(ctx context.Context, d store.Deck) (store.Deck, error) {
+	ps := (*PostgresStore)(p)
+	if d.ID == "" {
+		d.ID = newID("deck")
+	}
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now().UTC()
+	}
+	d.UpdatedAt = d.CreatedAt
+	if d.Visibility == "" {
+		d.Visibility = store.VisibilityOrg
+	}
+	err := ps.db.WithContext(ctx).Create(&d).Error
+	return d, err
+}
+
+func (p *postgresDeckStore) ListDecks(ctx context.Context, orgID string) ([]store.Deck, error) {
+	ps := (*PostgresStore)(p)
+	var ds []store.Deck
+	err := ps.reader(ctx).Where("org_id = ?", orgID).Order("updated_at DESC").Find(&ds).Error
+	return ds, err
+}
+
+func (p *postgresDeckStore) GetDeck(ctx context.Context, orgID, id string) (store.Deck, bool, error) {
+	ps := (*PostgresStore)(p)
+	var d store.Deck
+	err := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).First(&d).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return store.Deck{}, false, nil
+		}
+		return store.Deck{}, false, err
+	}
+	return d, true, nil
+}
+
+func (p *postgresDeckStore) UpdateDeck(ctx context.Context, d store.Deck) (store.Deck, error) {
+	ps := (*PostgresStore)(p)
+	d.UpdatedAt = time.Now().UTC()
+	err := ps.db.WithContext(ctx).Save(&d).Error
+	return d, err
+}
+
+func (p *postgresDeckStore) ListDecksFor(ctx context.Context, id auth.Identity) ([]store.Deck, error) {
+	ps := (*PostgresStore)(p)
+	var ds []store.Deck
+	q := ps.reader(ctx).Where("decks.org_id = ?", id.OrgID)
+	err := ps.visibleTo(q, "decks", store.ResourceDeck, id).Order("decks.updated_at DESC").Find(&ds).Error
+	return ds, err
+}
+
+func (p *postgresDeckStore) GetDeckFor(ctx context.Context, id auth.Identity, deckID string) (store.Deck, store.Permission, bool, error) {
+	d, ok, err := p.GetDeck(ctx, id.OrgID, deckID)
+	if err != nil || !ok {
+		return store.Deck{}, store.PermissionNone, false, err
+	}
+	grant, err := (*PostgresStore)(p).grant(ctx, d.OrgID, store.ResourceDeck, d.ID, id.UserID)
+	if err != nil {
+		return store.Deck{}, store.PermissionNone, false, err
+	}
+	perm := store.EffectivePermission(id, d.OwnerUserID, d.Visibility, grant)
+	if !perm.Allows(store.PermissionView) {
+		return store.Deck{}, store.PermissionNone, false, nil
+	}
+	return d, perm, true, nil
+}
+
+// deckSummaryRow is a deck with its current version's summary columns.
+type deckSummaryRow struct {
+	store.Deck
+	SummaryVersionID      *string
+	SummaryVersionNo      int
+	SummarySlideCount     int
+	SummaryLastExportedAt *time.Time
+}
+
+func (p *postgresDeckStore) ListDecksWithSummary(ctx context.Context, id auth.Identity) ([]store.DeckWithSummary, error) {
+	ps := (*PostgresStore)(p)
+	var rows []deckSummaryRow
+	q := ps.reader(ctx).Table("decks").Where("decks.org_id = ?", id.OrgID)
+	// Export jobs name the deck version they exported, so the latest export
+	// per deck is found through deck_versions.
+	err := ps.visibleTo(q, "decks", store.ResourceDeck, id).
+		Joins("LEFT JOIN deck_versions dv ON dv.id = decks.current_version_id").
+		Joins(`LEFT JOIN (
+			SELECT v.deck_id, MAX(j.updated_at) AS last_exported_at
+			FROM jobs j JOIN deck_versions v ON v.id::text = j.input_ref
+			WHERE j.org_id = ? AND j.type = ? AND j.status = ?
+			GROUP BY v.deck_id
+		) ex ON ex.deck_id = decks.id`, id.OrgID, store.JobExport, store.JobDone).
+		Select(`decks.*,
+			dv.id AS summary_version_id,
+			COALESCE(dv.version_no, 0) AS summary_version_no,
+			CASE WHEN jsonb_typeof(dv.spec_json->'layouts') = 'array' THEN jsonb_array_length(dv.spec_json->'layouts') ELSE 0 END AS summary_slide_count,
+			ex.last_exported_at AS summary_last_exported_at`).
+		Order("decks.updated_at DESC").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]store.DeckWithSummary, len(rows))
+	for i, row := range rows {
+		out[i].Deck = row.Deck
+		if row.SummaryVersionID != nil {
+			out[i].Summary = &store.DeckSummary{
+				VersionID:      *row.SummaryVersionID,
+				VersionNo:      row.SummaryVersionNo,
+				SlideCount:     row.SummarySlideCount,
+				LastExportedAt: row.SummaryLastExportedAt,
+			}
+		}
+	}
+	return out, nil
+}
+
+func (p *postgresDeckStore) CreateDeckVersion(ctx context.Context, v store.DeckVersion) (store.DeckVersion, error) {
+	ps := (*PostgresStore)(p)
+	if v.ID == "" {
+		v.ID = newID("dv")
+	}
+	if v.CreatedAt.IsZero() {
+		v.CreatedAt = time.Now().UTC()
+	}
+	err := ps.db.WithContext(ctx).Create(&v).Error
+	return v, err
+}
+
+func (p *postgresDeckStore) ListDeckVersions(ctx context.Context, orgID, deckID string) ([]store.DeckVersion, error) {
+	ps := (*PostgresStore)(p)
+	var vs []store.DeckVersion
+	err := ps.db.WithContext(ctx).Where("org_id = ? AND deck_id = ?", orgID, deckID).Order("version_no DESC").Find(&vs).Error
+	return vs, err
+}
+
+func (p *postgresDeckStore) GetDeckVersion(ctx context.Context, orgID, versionID string) (store.DeckVersion, bool, error) {
+	ps := (*PostgresStore)(p)
+	var v store.DeckVersion
+	err := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, versionID).First(&v).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return store.DeckVersion{}, false, nil
+		}
+		return store.DeckVersion{}, false, err
+	}
+	return v, true, nil
+}
+
+func (p *postgresDeckStore) AppendRefinement(ctx context.Context, r store.DeckRefinement) (store.DeckRefinement, error) {
+	ps := (*PostgresStore)(p)
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now().UTC()
+	}
+	err := ps.db.WithContext(ctx).Create(&r).Error
+	return r, err
+}
+
+func (p *postgresDeckStore) ListRefinements(ctx context.Context, orgID, deckID string) ([]store.DeckRefinement, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.DeckRefinement
+	err := ps.reader(ctx).Where("org_id = ? AND deck_id = ?", orgID, deckID).Order("created_at ASC").Find(&out).Error
+	return out, err
+}
+
+// visibleTo restricts q to rows of table that id may view, joining explicit
+// grants. It mirrors store.EffectivePermission; admins see everything.
+func (p *PostgresStore) visibleTo(q *gorm.DB, table string, rt store.ResourceType, id auth.Identity) *gorm.DB {
+	if auth.RequireRole(id, auth.RoleAdmin) {
+		return q
+	}
+	return q.Joins("LEFT JOIN resource_permissions rp ON rp.resource_type = ? AND rp.resource_id = "+table+".id AND rp.user_id = ?", rt, id.UserID).
+		Where(table+".visibility <> ? OR "+table+".owner_user_id = ? OR rp.user_id IS NOT NULL", store.VisibilityPrivate, id.UserID).
+		Select(table + ".*")
+}
+
+func (p *PostgresStore) grant(ctx context.Context, orgID string, rt store.ResourceType, resourceID, userID string) (store.Permission, error) {
+	var rp store.ResourcePermission
+	err := p.db.WithContext(ctx).Where("org_id = ? AND resource_type = ? AND resource_id = ? AND user_id = ?", orgID, rt, resourceID, userID).First(&rp).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return store.PermissionNone, nil
+		}
+		return store.PermissionNone, err
+	}
+	return rp.Permission, nil
+}
+
+type postgresPermissionStore PostgresStore
+
+func (p *postgresPermissionStore) Grant(ctx context.Context, rp store.ResourcePermission) (store.ResourcePermission, error) {
+	ps := (*PostgresStore)(p)
+	rp.CreatedAt = time.Now().UTC()
+	err := ps.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "resource_type"}, {Name: "resource_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"permission", "granted_by", "created_at"}),
+	}).Create(&rp).Error
+	return rp, err
+}
+
+func (p *postgresPermissionStore) Revoke(ctx context.Context, orgID string, rt store.ResourceType, resourceID, userID string) (bool, error) {
+	ps := (*PostgresStore)(p)
+	res := ps.db.WithContext(ctx).Where("org_id = ? AND resource_type = ? AND resource_id = ? AND user_id = ?", orgID, rt, resourceID, userID).Delete(&store.ResourcePermission{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (p *postgresPermissionStore) List(ctx context.Context, orgID string, rt store.ResourceType, resourceID string) ([]store.ResourcePermission, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.ResourcePermission
+	err := ps.db.WithContext(ctx).Where("org_id = ? AND resource_type = ? AND resource_id = ?", orgID, rt, resourceID).Order("created_at").Find(&out).Error
+	return out, err
+}
+
+type postgresCommentStore PostgresStore
+
+func (p *postgresCommentStore) Create(ctx context.Context, c store.Comment) (store.Comment, error) {
+	ps := (*PostgresStore)(p)
+	if c.ID == "" {
+		c.ID = newID("cmt")
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now().UTC()
+	}
+	c.UpdatedAt = c.CreatedAt
+	err := ps.db.WithContext(ctx).Create(&c).Error
+	return c, err
+}
+
+func (p *postgresCommentStore) Get(ctx context.Context, orgID, id string) (store.Comment, bool, error) {
+	ps := (*PostgresStore)(p)
+	var c store.Comment
+	err := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).First(&c).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return store.Comment{}, false, nil
+		}
+		return store.Comment{}, false, err
+	}
+	return c, true, nil
+}
+
+func (p *postgresCommentStore) ListByDeckVersion(ctx context.Context, orgID, deckVersionID string) ([]store.Comment, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.Comment
+	err := ps.db.WithContext(ctx).Where("org_id = ? AND deck_version_id = ?", orgID, deckVersionID).Order("created_at").Find(&out).Error
+	return out, err
+}
+
+func (p *postgresCommentStore) Update(ctx context.Context, c store.Comment) (store.Comment, error) {
+	ps := (*PostgresStore)(p)
+	c.UpdatedAt = time.Now().UTC()
+	err := ps.db.WithContext(ctx).Save(&c).Error
+	return c, err
+}
+
+type postgresTagStore PostgresStore
+
+func (p *postgresTagStore) CreateTag(ctx context.Context, t store.Tag) (store.Tag, error) {
+	ps := (*PostgresStore)(p)
+	if t.ID == "" {
+		t.ID = newID("tag")
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now().UTC()
+	}
+	t.UpdatedAt = t.CreatedAt
+	err := ps.db.WithContext(ctx).Create(&t).Error
+	return t, err
+}
+
+func (p *postgresTagStore) ListTags(ctx context.Context, orgID string) ([]store.Tag, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.Tag
+	err := ps.reader(ctx).Where("org_id = ?", orgID).Order("name").Find(&out).Error
+	return out, err
+}
+
+func (p *postgresTagStore) GetTag(ctx context.Context, orgID, id string) (store.Tag, bool, error) {
+	ps := (*PostgresStore)(p)
+	var t store.Tag
+	err := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).First(&t).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return store.Tag{}, false, nil
+		}
+		return store.Tag{}, false, err
+	}
+	return t, true, nil
+}
+
+func (p *postgresTagStore) UpdateTag(ctx context.Context, t store.Tag) (store.Tag, error) {
+	ps := (*PostgresStore)(p)
+	t.UpdatedAt = time.Now().UTC()
+	err := ps.db.WithContext(ctx).Save(&t).Error
+	return t, err
+}
+
+func (p *postgresTagStore) DeleteTag(ctx context.Context, orgID, id string) (bool, error) {
+	ps := (*PostgresStore)(p)
+	var deleted bool
+	err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("org_id = ? AND id = ?", orgID, id).Delete(&store.Tag{})
+		if res.Error != nil {
+			return res.Error
+		}
+		deleted = res.RowsAffected > 0
+		return tx.Where("org_id = ? AND tag_id = ?", orgID, id).Delete(&store.ResourceTag{}).Error
+	})
+	return deleted, err
+}
+
+func (p *postgresTagStore) Attach(ctx context.Context, rt store.ResourceTag) error {
+	ps := (*PostgresStore)(p)
+	rt.CreatedAt = time.Now().UTC()
+	return ps.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&rt).Error
+}
+
+func (p *postgresTagStore) Detach(ctx context.Context, orgID, tagID string, rt store.ResourceType, resourceID string) (bool, error) {
+	ps := (*PostgresStore)(p)
+	res := ps.db.WithContext(ctx).Where("org_id = ? AND tag_id = ? AND resource_type = ? AND resource_id = ?", orgID, tagID, rt, resourceID).Delete(&store.ResourceTag{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (p *postgresTagStore) ListAttached(ctx context.Context, orgID string, rt store.ResourceType) (map[string][]store.Tag, error) {
+	ps := (*PostgresStore)(p)
+	var rows []struct {
+		ResourceID string
+		store.Tag
+	}
+	err := ps.reader(ctx).Table("resource_tags").
+		Select("resource_tags.resource_id, tags.*").
+		Joins("JOIN tags ON tags.id = resource_tags.tag_id").
+		Where("resource_tags.org_id = ? AND resource_tags.resource_type = ?", orgID, rt).
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := map[string][]store.Tag{}
+	for _, r := range rows {
+		out[r.ResourceID] = append(out[r.ResourceID], r.Tag)
+	}
+	return out, nil
+}
+
+type postgresFolderStore PostgresStore
+
+func (p *postgresFolderStore) CreateFolder(ctx context.Context, f store.Folder) (store.Folder, error) {
+	ps := (*PostgresStore)(p)
+	if f.ID == "" {
+		f.ID = newID("fld")
+	}
+	if f.CreatedAt.IsZero() {
+		f.CreatedAt = time.Now().UTC()
+	}
+	f.UpdatedAt = f.CreatedAt
+	err := ps.db.WithContext(ctx).Create(&f).Error
+	return f, err
+}
+
+func (p *postgresFolderStore) ListFolders(ctx context.Context, orgID string) ([]store.Folder, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.Folder
+	err := ps.reader(ctx).Where("org_id = ?", orgID).Order("name").Find(&out).Error
+	return out, err
+}
+
+func (p *postgresFolderStore) GetFolder(ctx context.Context, orgID, id string) (store.Folder, bool, error) {
+	ps := (*PostgresStore)(p)
+	var f store.Folder
+	err := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).First(&f).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return store.Folder{}, false, nil
+		}
+		return store.Folder{}, false, err
+	}
+	return f, true, nil
+}
+
+func (p *postgresFolderStore) UpdateFolder(ctx context.Context, f store.Folder) (store.Folder, error) {
+	ps := (*PostgresStore)(p)
+	f.UpdatedAt = time.Now().UTC()
+	err := ps.db.WithContext(ctx).Save(&f).Error
+	return f, err
+}
+
+func (p *postgresFolderStore) DeleteFolder(ctx context.Context, orgID, id string) (bool, error) {
+	ps := (*PostgresStore)(p)
+	res := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).Delete(&store.Folder{})
+	return res.RowsAffected > 0, res.Error
+}
+
+type postgresWebhookStore PostgresStore
+
+func (p *postgresWebhookStore) Create(ctx context.Context, w store.Webhook) (store.Webhook, error) {
+	ps := (*PostgresStore)(p)
+	if w.ID == "" {
+		w.ID = newID("whk")
+	}
+	if w.CreatedAt.IsZero() {
+		w.CreatedAt = time.Now().UTC()
+	}
+	w.UpdatedAt = w.CreatedAt
+	err := ps.db.WithContext(ctx).Create(&w).Error
+	return w, err
+}
+
+func (p *postgresWebhookStore) Get(ctx context.Context, orgID, id string) (store.Webhook, bool, error) {
+	ps := (*PostgresStore)(p)
+	var w store.Webhook
+	err := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).First(&w).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return store.Webhook{}, false, nil
+		}
+		return store.Webhook{}, false, err
+	}
+	return w, true, nil
+}
+
+func (p *postgresWebhookStore) List(ctx context.Context, orgID string) ([]store.Webhook, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.Webhook
+	err := ps.db.WithContext(ctx).Where("org_id = ?", orgID).Order("created_at").Find(&out).Error
+	return out, err
+}
+
+func (p *postgresWebhookStore) Update(ctx context.Context, w store.Webhook) (store.Webhook, error) {
+	ps := (*PostgresStore)(p)
+	w.UpdatedAt = time.Now().UTC()
+	err := ps.db.WithContext(ctx).Save(&w).Error
+	return w, err
+}
+
+func (p *postgresWebhookStore) Delete(ctx context.Context, orgID, id string) (bool, error) {
+	ps := (*PostgresStore)(p)
+	var deleted bool
+	err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("org_id = ? AND id = ?", orgID, id).Delete(&store.Webhook{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		deleted = true
+		return tx.Where("org_id = ? AND webhook_id = ?", orgID, id).Delete(&store.WebhookDelivery{}).Error
+	})
+	return deleted, err
+}
+
+func (p *postgresWebhookStore) CreateDelivery(ctx context.Context, d store.WebhookDelivery) (store.WebhookDelivery, error) {
+	ps := (*PostgresStore)(p)
+	if d.ID == "" {
+		d.ID = newID("whd")
+	}
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now().UTC()
+	}
+	d.UpdatedAt = d.CreatedAt
+	err := ps.db.WithContext(ctx).Create(&d).Error
+	return d, err
+}
+
+func (p *postgresWebhookStore) UpdateDelivery(ctx context.Context, d store.WebhookDelivery) (store.WebhookDelivery, error) {
+	ps := (*PostgresStore)(p)
+	d.UpdatedAt = time.Now().UTC()
+	err := ps.db.WithContext(ctx).Save(&d).Error
+	return d, err
+}
+
+func (p *postgresWebhookStore) ListDeliveries(ctx context.Context, orgID, webhookID string, limit int) ([]store.WebhookDelivery, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.WebhookDelivery
+	q := ps.reader(ctx).Where("org_id = ? AND webhook_id = ?", orgID, webhookID).Order("created_at DESC")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	err := q.Find(&out).Error
+	return out, err
+}
+
+func (p *postgresWebhookStore) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]store.WebhookDelivery, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.WebhookDelivery
+	q := ps.db.WithContext(ctx).Where("status = ? AND next_attempt_at <= ?", store.WebhookDeliveryPending, now).Order("next_attempt_at")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	err := q.Find(&out).Error
+	return out, err
+}
+
+type postgresEmbedStore PostgresStore
+
+func (p *postgresEmbedStore) Create(ctx context.Context, e store.DeckEmbed) (store.DeckEmbed, error) {
+	ps := (*PostgresStore)(p)
+	if e.ID == "" {
+		e.ID = newID("emb")
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	err := ps.db.WithContext(ctx).Create(&e).Error
+	return e, err
+}
+
+func (p *postgresEmbedStore) GetByTokenHash(ctx context.Context, tokenHash string) (store.DeckEmbed, bool, error) {
+	ps := (*PostgresStore)(p)
+	var e store.DeckEmbed
+	err := ps.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&e).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return store.DeckEmbed{}, false, nil
+		}
+		return store.DeckEmbed{}, false, err
+	}
+	return e, true, nil
+}
+
+func (p *postgresEmbedStore) List(ctx context.Context, orgID, deckID string) ([]store.DeckEmbed, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.DeckEmbed
+	err := ps.reader(ctx).Where("org_id = ? AND deck_id = ?", orgID, deckID).Order("created_at").Find(&out).Error
+	return out, err
+}
+
+func (p *postgresEmbedStore) Delete(ctx context.Context, orgID, id string) (bool, error) {
+	ps := (*PostgresStore)(p)
+	res := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).Delete(&store.DeckEmbed{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// RecordView increments in SQL so concurrent views are all counted.
+func (p *postgresEmbedStore) RecordView(ctx context.Context, id string, at time.Time) error {
+	ps := (*PostgresStore)(p)
+	return ps.db.WithContext(ctx).Model(&store.DeckEmbed{ID: id}).Updates(map[string]any{
+		"view_count":     gorm.Expr("view_count + 1"),
+		"last_viewed_at": at,
+	}).Error
+}
+
+type postgresAPIKeyStore PostgresStore
+
+func (p *postgresAPIKeyStore) Create(ctx context.Context, k store.APIKey) (store.APIKey, error) {
+	ps := (*PostgresStore)(p)
+	if k.ID == "" {
+		k.ID = newID("key")
+	}
+	if k.CreatedAt.IsZero() {
+		k.CreatedAt = time.Now().UTC()
+	}
+	err := ps.db.WithContext(ctx).Create(&k).Error
+	return k, err
+}
+
+func (p *postgresAPIKeyStore) GetByHash(ctx context.Context, keyHash string) (store.APIKey, bool, error) {
+	ps := (*PostgresStore)(p)
+	var k store.APIKey
+	err := ps.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&k).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return store.APIKey{}, false, nil
+		}
+		return store.APIKey{}, false, err
+	}
+	return k, true, nil
+}
+
+func (p *postgresAPIKeyStore) List(ctx context.Context, orgID, userID string) ([]store.APIKey, error) {
+	ps := (*PostgresStore)(p)
+	q := ps.reader(ctx).Where("org_id = ?", orgID)
+	if userID != "" {
+		q = q.Where("user_id = ?", userID)
+	}
+	var out []store.APIKey
+	err := q.Order("created_at").Find(&out).Error
+	return out, err
+}
+
+func (p *postgresAPIKeyStore) Delete(ctx context.Context, orgID, id string) (bool, error) {
+	ps := (*PostgresStore)(p)
+	res := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).Delete(&store.APIKey{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (p *postgresAPIKeyStore) MarkUsed(ctx context.Context, id string, at time.Time) error {
+	ps := (*PostgresStore)(p)
+	return ps.db.WithContext(ctx).Model(&store.APIKey{ID: id}).Update("last_used_at", at).Error
+}
+
+type postgresRetryPolicyStore PostgresStore
+
+func (p *postgresRetryPolicyStore) List(ctx context.Context) ([]store.RetryPolicyOverride, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.RetryPolicyOverride
+	err := ps.reader(ctx).Order("job_type").Find(&out).Error
+	return out, err
+}
+
+func (p *postgresRetryPolicyStore) Put(ctx context.Context, o store.RetryPolicyOverride) (store.RetryPolicyOverride, error) {
+	ps := (*PostgresStore)(p)
+	o.UpdatedAt = time.Now().UTC()
+	// Save writes every column, so fields cleared by the admin go back to NULL.
+	err := ps.db.WithContext(ctx).Save(&o).Error
+	return o, err
+}
+
+func (p *postgresRetryPolicyStore) Delete(ctx context.Context, jobType store.JobType) (bool, error) {
+	ps := (*PostgresStore)(p)
+	res := ps.db.WithContext(ctx).Where("job_type = ?", jobType).Delete(&store.RetryPolicyOverride{})
+	return res.RowsAffected > 0, res.Error
+}
+
+type postgresFeatureFlagStore PostgresStore
+
+func (p *postgresFeatureFlagStore) List(ctx context.Context) ([]store.FeatureFlag, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.FeatureFlag
+	err := ps.reader(ctx).Order("name, org_id").Find(&out).Error
+	return out, err
+}
+
+func (p *postgresFeatureFlagStore) Put(ctx context.Context, f store.FeatureFlag) (store.FeatureFlag, error) {
+	ps := (*PostgresStore)(p)
+	f.UpdatedAt = time.Now().UTC()
+	err := ps.db.WithContext(ctx).Save(&f).Error
+	return f, err
+}
+
+func (p *postgresFeatureFlagStore) Delete(ctx context.Context, name, orgID string) (bool, error) {
+	ps := (*PostgresStore)(p)
+	res := ps.db.WithContext(ctx).Where("name = ? AND org_id = ?", name, orgID).Delete(&store.FeatureFlag{})
+	return res.RowsAffected > 0, res.Error
+}
+
+type postgresFontStore PostgresStore
+
+func (p *postgresFontStore) Create(ctx context.Context, f store.Font) (store.Font, error) {
+	ps := (*PostgresStore)(p)
+	if f.ID == "" {
+		f.ID = newID("font")
+	}
+	f.CreatedAt = time.Now().UTC()
+	err := ps.db.WithContext(ctx).Create(&f).Error
+	return f, err
+}
+
+func (p *postgresFontStore) List(ctx context.Context, orgID string) ([]store.Font, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.Font
+	err := ps.reader(ctx).Where("org_id = ?", orgID).Order("family, style").Find(&out).Error
+	return out, err
+}
+
+func (p *postgresFontStore) Get(ctx context.Context, orgID, id string) (store.Font, bool, error) {
+	ps := (*PostgresStore)(p)
+	var f store.Font
+	err := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).First(&f).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return store.Font{}, false, nil
+		}
+		return store.Font{}, false, err
+	}
+	return f, true, nil
+}
+
+func (p *postgresFontStore) Delete(ctx context.Context, orgID, id string) (bool, error) {
+	ps := (*PostgresStore)(p)
+	res := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).Delete(&store.Font{})
+	return res.RowsAffected > 0, res.Error
+}
+
+type postgresAICallStore PostgresStore
+
+func (p *postgresAICallStore) Record(ctx context.Context, c store.AICall) (store.AICall, error) {
+	ps := (*PostgresStore)(p)
+	if c.ID == "" {
+		c.ID = newID("aic")
+	}
+	c.CreatedAt = time.Now().UTC()
+	err := ps.db.WithContext(ctx).Create(&c).Error
+	return c, err
+}
+
+func (p *postgresAICallStore) List(ctx context.Context, f store.AICallFilter) ([]store.AICall, error) {
+	ps := (*PostgresStore)(p)
+	q := ps.reader(ctx).Model(&store.AICall{})
+	if f.OrgID != "" {
+		q = q.Where("org_id = ?", f.OrgID)
+	}
+	if f.UserID != "" {
+		q = q.Where("user_id = ?", f.UserID)
+	}
+	if f.Kind != "" {
+		q = q.Where("kind = ?", f.Kind)
+	}
+	if f.Model != "" {
+		q = q.Where("model = ?", f.Model)
+	}
+	if f.Success != nil {
+		q = q.Where("success = ?", *f.Success)
+	}
+	if f.Since != nil {
+		q = q.Where("created_at >= ?", *f.Since)
+	}
+	if f.Until != nil {
+		q = q.Where("created_at < ?", *f.Until)
+	}
+	q = q.Order("created_at DESC")
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	var out []store.AICall
+	err := q.Find(&out).Error
+	return out, err
+}
+
+type postgresGlossaryStore PostgresStore
+
+func (p *postgresGlossaryStore) Create(ctx context.Context, e store.GlossaryEntry) (store.GlossaryEntry, error) {
+	ps := (*PostgresStore)(p)
+	if e.ID == "" {
+		e.ID = newID("gls")
+	}
+	e.CreatedAt = time.Now().UTC()
+	e.UpdatedAt = e.CreatedAt
+	err := ps.db.WithContext(ctx).Create(&e).Error
+	return e, err
+}
+
+func (p *postgresGlossaryStore) List(ctx context.Context, orgID string) ([]store.GlossaryEntry, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.GlossaryEntry
+	err := ps.reader(ctx).Where("org_id = ?", orgID).Order("term, id").Find(&out).Error
+	return out, err
+}
+
+func (p *postgresGlossaryStore) Get(ctx context.Context, orgID, id string) (store.GlossaryEntry, bool, error) {
+	ps := (*PostgresStore)(p)
+	var e store.GlossaryEntry
+	err := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).First(&e).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return store.GlossaryEntry{}, false, nil
+		}
+		return store.GlossaryEntry{}, false, err
+	}
+	return e, true, nil
+}
+
+func (p *postgresGlossaryStore) Update(ctx context.Context, e store.GlossaryEntry) (store.GlossaryEntry, error) {
+	ps := (*PostgresStore)(p)
+	e.UpdatedAt = time.Now().UTC()
+	err := ps.db.WithContext(ctx).Save(&e).Error
+	return e, err
+}
+
+func (p *postgresGlossaryStore) Delete(ctx context.Context, orgID, id string) (bool, error) {
+	ps := (*PostgresStore)(p)
+	res := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).Delete(&store.GlossaryEntry{})
+	return res.RowsAffected > 0, res.Error
+}
+
+type postgresScheduleStore PostgresStore
+
+func (p *postgresScheduleStore) Create(ctx context.Context, s store.Schedule) (store.Schedule, error) {
+	ps := (*PostgresStore)(p)
+	if s.ID == "" {
+		s.ID = newID("sch")
+	}
+	s.CreatedAt = time.Now().UTC()
+	s.UpdatedAt = s.CreatedAt
+	err := ps.db.WithContext(ctx).Create(&s).Error
+	return s, err
+}
+
+func (p *postgresScheduleStore) List(ctx context.Context, orgID string) ([]store.Schedule, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.Schedule
+	err := ps.reader(ctx).Where("org_id = ?", orgID).Order("name, id").Find(&out).Error
+	return out, err
+}
+
+func (p *postgresScheduleStore) Get(ctx context.Context, orgID, id string) (store.Schedule, bool, error) {
+	ps := (*PostgresStore)(p)
+	var s store.Schedule
+	err := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).First(&s).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return store.Schedule{}, false, nil
+		}
+		return store.Schedule{}, false, err
+	}
+	return s, true, nil
+}
+
+func (p *postgresScheduleStore) Update(ctx context.Context, s store.Schedule) (store.Schedule, error) {
+	ps := (*PostgresStore)(p)
+	s.UpdatedAt = time.Now().UTC()
+	err := ps.db.WithContext(ctx).Save(&s).Error
+	return s, err
+}
+
+func (p *postgresScheduleStore) Delete(ctx context.Context, orgID, id string) (bool, error) {
+	ps := (*PostgresStore)(p)
+	res := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).Delete(&store.Schedule{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (p *postgresScheduleStore) ListDue(ctx context.Context, now time.Time) ([]store.Schedule, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.Schedule
+	err := ps.db.WithContext(ctx).Where("enabled AND next_run_at <= ?", now).Order("next_run_at").Find(&out).Error
+	return out, err
+}
 
-func (p *postgresDeckStore) CreateDeck(ctx context.Context, d store.Deck) (store.Deck, error) {
+func (p *postgresScheduleStore) Claim(ctx context.Context, orgID, id string, prev, next time.Time) (bool, error) {
 	ps := (*PostgresStore)(p)
-	if d.ID == "" {
-		d.ID = newID("deck")
-	}
-	if d.CreatedAt.IsZero() {
-		d.CreatedAt = time.Now().UTC()
+	res := ps.db.WithContext(ctx).Model(&store.Schedule{}).
+		Where("org_id = ? AND id = ? AND next_run_at = ?", orgID, id, prev).
+		Update("next_run_at", next.UTC())
+	return res.RowsAffected > 0, res.Error
+}
+
+type postgresConnectorStore PostgresStore
+
+func (p *postgresConnectorStore) Create(ctx context.Context, c store.Connector) (store.Connector, error) {
+	ps := (*PostgresStore)(p)
+	if c.ID == "" {
+		c.ID = newID("con")
 	}
-	d.UpdatedAt = d.CreatedAt
-	err := ps.db.WithContext(ctx).Create(&d).Error
-	return d, err
+	c.CreatedAt = time.Now().UTC()
+	c.UpdatedAt = c.CreatedAt
+	err := ps.db.WithContext(ctx).Create(&c).Error
+	return c, err
 }
 
-func (p *postgresDeckStore) ListDecks(ctx context.Context, orgID string) ([]store.Deck, error) {
+func (p *postgresConnectorStore) List(ctx context.Context, orgID string) ([]store.Connector, error) {
 	ps := (*PostgresStore)(p)
-	var ds []store.Deck
-	err := ps.db.WithContext(ctx).Where("org_id = ?", orgID).Order("updated_at DESC").Find(&ds).Error
-	return ds, err
+	var out []store.Connector
+	err := ps.reader(ctx).Where("org_id = ?", orgID).Order("name").Find(&out).Error
+	return out, err
 }
 
-func (p *postgresDeckStore) GetDeck(ctx context.Context, orgID, id string) (store.Deck, bool, error) {
+func (p *postgresConnectorStore) Get(ctx context.Context, orgID, id string) (store.Connector, bool, error) {
+	return p.first(ctx, "org_id = ? AND id = ?", orgID, id)
+}
+
+func (p *postgresConnectorStore) GetByName(ctx context.Context, orgID, name string) (store.Connector, bool, error) {
+	return p.first(ctx, "org_id = ? AND name = ?", orgID, name)
+}
+
+func (p *postgresConnectorStore) first(ctx context.Context, query string, args ...any) (store.Connector, bool, error) {
 	ps := (*PostgresStore)(p)
-	var d store.Deck
-	err := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).First(&d).Error
+	var c store.Connector
+	err := ps.db.WithContext(ctx).Where(query, args...).First(&c).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return store.Deck{}, false, nil
+			return store.Connector{}, false, nil
 		}
-		return store.Deck{}, false, err
+		return store.Connector{}, false, err
 	}
-	return d, true, nil
+	return c, true, nil
 }
 
-func (p *postgresDeckStore) UpdateDeck(ctx context.Context, d store.Deck) (store.Deck, error) {
+func (p *postgresConnectorStore) Update(ctx context.Context, c store.Connector) (store.Connector, error) {
 	ps := (*PostgresStore)(p)
-	d.UpdatedAt = time.Now().UTC()
-	err := ps.db.WithContext(ctx).Save(&d).Error
-	return d, err
+	c.UpdatedAt = time.Now().UTC()
+	err := ps.db.WithContext(ctx).Save(&c).Error
+	return c, err
 }
 
-func (p *postgresDeckStore) CreateDeckVersion(ctx context.Context, v store.DeckVersion) (store.DeckVersion, error) {
+func (p *postgresConnectorStore) Delete(ctx context.Context, orgID, id string) (bool, error) {
 	ps := (*PostgresStore)(p)
-	if v.ID == "" {
-		v.ID = newID("dv")
-	}
-	if v.CreatedAt.IsZero() {
-		v.CreatedAt = time.Now().UTC()
-	}
-	err := ps.db.WithContext(ctx).Create(&v).Error
-	return v, err
+	res := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).Delete(&store.Connector{})
+	return res.RowsAffected > 0, res.Error
 }
 
-func (p *postgresDeckStore) ListDeckVersions(ctx context.Context, orgID, deckID string) ([]store.DeckVersion, error) {
+type postgresGeneratedImageStore PostgresStore
+
+func (p *postgresGeneratedImageStore) Create(ctx context.Context, g store.GeneratedImage) (store.GeneratedImage, error) {
 	ps := (*PostgresStore)(p)
-	var vs []store.DeckVersion
-	err := ps.db.WithContext(ctx).Where("org_id = ? AND deck_id = ?", orgID, deckID).Order("version_no DESC").Find(&vs).Error
-	return vs, err
+	if g.ID == "" {
+		g.ID = newID("img")
+	}
+	g.CreatedAt = time.Now().UTC()
+	err := ps.db.WithContext(ctx).Create(&g).Error
+	return g, err
 }
 
-func (p *postgresDeckStore) GetDeckVersion(ctx context.Context, orgID, versionID string) (store.DeckVersion, bool, error) {
+func (p *postgresGeneratedImageStore) GetByPromptHash(ctx context.Context, orgID, hash string) (store.GeneratedImage, bool, error) {
 	ps := (*PostgresStore)(p)
-	var v store.DeckVersion
-	err := ps.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, versionID).First(&v).Error
+	var g store.GeneratedImage
+	err := ps.db.WithContext(ctx).Where("org_id = ? AND prompt_hash = ?", orgID, hash).Order("created_at DESC").First(&g).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return store.DeckVersion{}, false, nil
+			return store.GeneratedImage{}, false, nil
 		}
-		return store.DeckVersion{}, false, err
+		return store.GeneratedImage{}, false, err
 	}
-	return v, true, nil
+	return g, true, nil
+}
+
+func (p *postgresGeneratedImageStore) List(ctx context.Context, orgID string) ([]store.GeneratedImage, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.GeneratedImage
+	err := ps.reader(ctx).Where("org_id = ?", orgID).Order("created_at DESC").Find(&out).Error
+	return out, err
 }
 
 type postgresBrandKitStore PostgresStore
@@ -276,7 +1320,7 @@ func (p *postgresBrandKitStore) Create(ctx context.Context, b store.BrandKit) (s
 func (p *postgresBrandKitStore) List(ctx context.Context, orgID string) ([]store.BrandKit, error) {
 	ps := (*PostgresStore)(p)
 	var bks []store.BrandKit
-	err := ps.db.WithContext(ctx).Where("org_id = ?", orgID).Find(&bks).Error
+	err := ps.reader(ctx).Where("org_id = ?", orgID).Find(&bks).Error
 	return bks, err
 }
 
@@ -290,10 +1334,37 @@ func (p *postgresAssetStore) Create(ctx context.Context, a store.Asset) (store.A
 	if a.CreatedAt.IsZero() {
 		a.CreatedAt = time.Now().UTC()
 	}
-	err := ps.db.WithContext(ctx).Create(&a).Error
+	err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&a).Error; err != nil {
+			return err
+		}
+		return tx.Model(&store.Organization{}).Where("id = ?", a.OrgID).
+			Update("storage_bytes_used", gorm.Expr("storage_bytes_used + ?", a.SizeBytes)).Error
+	})
 	return a, err
 }
 
+func (p *postgresAssetStore) Delete(ctx context.Context, orgID, id string) (bool, error) {
+	ps := (*PostgresStore)(p)
+	deleted := false
+	err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var a store.Asset
+		if err := tx.Where("org_id = ? AND id = ?", orgID, id).First(&a).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+		if err := tx.Delete(&a).Error; err != nil {
+			return err
+		}
+		deleted = true
+		return tx.Model(&store.Organization{}).Where("id = ?", orgID).
+			Update("storage_bytes_used", gorm.Expr("GREATEST(storage_bytes_used - ?, 0)", a.SizeBytes)).Error
+	})
+	return deleted, err
+}
+
 func (p *postgresAssetStore) Get(ctx context.Context, orgID, id string) (store.Asset, bool, error) {
 	ps := (*PostgresStore)(p)
 	var a store.Asset
@@ -307,6 +1378,62 @@ func (p *postgresAssetStore) Get(ctx context.Context, orgID, id string) (store.A
 	return a, true, nil
 }
 
+func (p *postgresAssetStore) GetAssets(ctx context.Context, orgID string, ids []string) (map[string]store.Asset, error) {
+	ps := (*PostgresStore)(p)
+	out := make(map[string]store.Asset, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	var as []store.Asset
+	if err := ps.db.WithContext(ctx).Where("org_id = ? AND id IN ?", orgID, ids).Find(&as).Error; err != nil {
+		return nil, err
+	}
+	for _, a := range as {
+		out[a.ID] = a
+	}
+	return out, nil
+}
+
+func (p *postgresAssetStore) List(ctx context.Context, orgID string, f store.AssetFilter) ([]store.Asset, int, error) {
+	ps := (*PostgresStore)(p)
+	q := ps.reader(ctx).Model(&store.Asset{}).Where("org_id = ?", orgID)
+	if f.Type != "" {
+		q = q.Where("type = ?", f.Type)
+	}
+	if f.SourceJobID != "" {
+		q = q.Where("source_job_id = ?", f.SourceJobID)
+	}
+	if f.CreatedAfter != nil {
+		q = q.Where("created_at >= ?", *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		q = q.Where("created_at < ?", *f.CreatedBefore)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	q = q.Order("created_at DESC")
+	if f.Offset > 0 {
+		q = q.Offset(f.Offset)
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	var as []store.Asset
+	err := q.Find(&as).Error
+	return as, int(total), err
+}
+
+func (p *postgresAssetStore) TotalBytes(ctx context.Context, orgID string) (int64, error) {
+	ps := (*PostgresStore)(p)
+	var total int64
+	err := ps.db.WithContext(ctx).Model(&store.Organization{}).Where("id = ?", orgID).Select("COALESCE(MAX(storage_bytes_used), 0)").Scan(&total).Error
+	return total, err
+}
+
 type postgresJobStore PostgresStore
 
 func (p *postgresJobStore) Enqueue(ctx context.Context, j store.Job) (store.Job, error) {
@@ -314,8 +1441,10 @@ func (p *postgresJobStore) Enqueue(ctx context.Context, j store.Job) (store.Job,
 	if j.ID == "" {
 		j.ID = newID("job")
 	}
-	if j.MaxRetries == 0 {
-		j.MaxRetries = 3
+	// MaxRetries stays 0 unless the caller set it, so the worker applies the
+	// job type's configured retry policy.
+	if j.Priority == store.JobPriorityNormal {
+		j.Priority = store.DefaultJobPriority(j.Type)
 	}
 	j.CreatedAt = time.Now().UTC()
 	j.UpdatedAt = j.CreatedAt
@@ -367,14 +1496,22 @@ func (p *postgresJobStore) GetByDeduplicationID(ctx context.Context, orgID, dedu
 func (p *postgresJobStore) Update(ctx context.Context, j store.Job) (store.Job, error) {
 	ps := (*PostgresStore)(p)
 	j.UpdatedAt = time.Now().UTC()
-	err := ps.db.WithContext(ctx).Save(&j).Error
+	err := ps.db.WithContext(ctx).Omit("heartbeat_at").Save(&j).Error
 	return j, err
 }
 
+func (p *postgresJobStore) Heartbeat(ctx context.Context, jobID string) error {
+	ps := (*PostgresStore)(p)
+	now := time.Now().UTC()
+	return ps.db.WithContext(ctx).Model(&store.Job{}).
+		Where("id = ? AND status = ?", jobID, store.JobRunning).
+		Updates(map[string]any{"heartbeat_at": now, "updated_at": now}).Error
+}
+
 func (p *postgresJobStore) ListQueued(ctx context.Context) ([]store.Job, error) {
 	ps := (*PostgresStore)(p)
 	var jobs []store.Job
-	err := ps.db.WithContext(ctx).Where("status = ?", store.JobQueued).Order("created_at ASC").Find(&jobs).Error
+	err := ps.db.WithContext(ctx).Where("status = ?", store.JobQueued).Order("priority DESC, created_at ASC").Find(&jobs).Error
 	return jobs, err
 }
 
@@ -413,6 +1550,39 @@ func (p *postgresJobStore) RetryDeadLetterJob(ctx context.Context, jobID string)
 	}).Error
 }
 
+func (p *postgresJobStore) List(ctx context.Context, f store.JobFilter) ([]store.Job, error) {
+	ps := (*PostgresStore)(p)
+	q := ps.reader(ctx).Model(&store.Job{})
+	if f.OrgID != "" {
+		q = q.Where("org_id = ?", f.OrgID)
+	}
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+	if f.Type != "" {
+		q = q.Where("type = ?", f.Type)
+	}
+	if len(f.IDs) > 0 {
+		q = q.Where("id IN ?", f.IDs)
+	}
+	q = q.Order("created_at DESC")
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	var jobs []store.Job
+	err := q.Find(&jobs).Error
+	return jobs, err
+}
+
+func (p *postgresJobStore) DeleteDeadLetter(ctx context.Context, jobIDs []string) (int, error) {
+	ps := (*PostgresStore)(p)
+	if len(jobIDs) == 0 {
+		return 0, nil
+	}
+	res := ps.db.WithContext(ctx).Where("id IN ? AND status = ?", jobIDs, store.JobDeadLetter).Delete(&store.Job{})
+	return int(res.RowsAffected), res.Error
+}
+
 type postgresMeteringStore PostgresStore
 
 func (p *postgresMeteringStore) Record(ctx context.Context, e store.MeteringEvent) (store.MeteringEvent, error) {
@@ -432,6 +1602,36 @@ func (p *postgresMeteringStore) SumByType(ctx context.Context, orgID string, eve
 	return int(sum), err
 }
 
+func (p *postgresMeteringStore) SumByTarget(ctx context.Context, orgID string, eventType string) (map[string]int, error) {
+	ps := (*PostgresStore)(p)
+	var rows []struct {
+		TargetRef string
+		Sum       int
+	}
+	err := ps.reader(ctx).Model(&store.MeteringEvent{}).
+		Where("org_id = ? AND event_type = ? AND target_ref <> ''", orgID, eventType).
+		Group("target_ref").Select("target_ref, SUM(quantity) AS sum").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	sums := make(map[string]int, len(rows))
+	for _, r := range rows {
+		sums[r.TargetRef] = r.Sum
+	}
+	return sums, nil
+}
+
+// SumForTarget is served by idx_metering_org_type_target, unlike
+// SumByTarget, which groups the org's whole history.
+func (p *postgresMeteringStore) SumForTarget(ctx context.Context, orgID, eventType, targetRef string) (int, error) {
+	ps := (*PostgresStore)(p)
+	var sum int64
+	err := ps.reader(ctx).Model(&store.MeteringEvent{}).
+		Where("org_id = ? AND event_type = ? AND target_ref = ?", orgID, eventType, targetRef).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&sum).Error
+	return int(sum), err
+}
+
 type postgresAuditStore PostgresStore
 
 func (p *postgresAuditStore) Append(ctx context.Context, a store.AuditLog) (store.AuditLog, error) {
@@ -440,10 +1640,18 @@ func (p *postgresAuditStore) Append(ctx context.Context, a store.AuditLog) (stor
 		a.ID = newID("aud")
 	}
 	a.CreatedAt = time.Now().UTC()
+	a.Metadata = redact.Value(a.Metadata)
 	err := ps.db.WithContext(ctx).Create(&a).Error
 	return a, err
 }
 
+func (p *postgresAuditStore) List(ctx context.Context, orgID string) ([]store.AuditLog, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.AuditLog
+	err := ps.reader(ctx).Where("org_id = ?", orgID).Order("created_at ASC").Find(&out).Error
+	return out, err
+}
+
 type postgresUserStore PostgresStore
 
 func (p *postgresUserStore) CreateUser(ctx context.Context, u *store.User) error {
@@ -494,6 +1702,47 @@ func (p *postgresUserStore) ListUserOrgs(ctx context.Context, userID string) ([]
 	return uos, err
 }
 
+func (p *postgresUserStore) UpdateUser(ctx context.Context, u store.User) error {
+	ps := (*PostgresStore)(p)
+	res := ps.db.WithContext(ctx).Model(&store.User{}).Where("id = ?", u.ID).
+		Select("email", "name", "avatar_url", "preferences", "pending_email", "email_token_hash", "email_token_expires_at", "email_verified_at", "updated_at").
+		Updates(&store.User{Email: u.Email, Name: u.Name, AvatarURL: u.AvatarURL, Preferences: u.Preferences,
+			PendingEmail: u.PendingEmail, EmailTokenHash: u.EmailTokenHash, EmailTokenExpiresAt: u.EmailTokenExpiresAt,
+			EmailVerifiedAt: u.EmailVerifiedAt, UpdatedAt: time.Now().UTC()})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (p *postgresUserStore) ListOrgMembers(ctx context.Context, orgID string) ([]store.UserOrg, error) {
+	ps := (*PostgresStore)(p)
+	var uos []store.UserOrg
+	err := ps.db.WithContext(ctx).Where("org_id = ?", orgID).Find(&uos).Error
+	return uos, err
+}
+
+func (p *postgresUserStore) SetUserOrgRole(ctx context.Context, userID, orgID string, role auth.Role) error {
+	ps := (*PostgresStore)(p)
+	res := ps.db.WithContext(ctx).Model(&store.UserOrg{}).Where("user_id = ? AND org_id = ?", userID, orgID).Update("role", role)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (p *postgresUserStore) DeleteUserOrg(ctx context.Context, userID, orgID string) (bool, error) {
+	ps := (*PostgresStore)(p)
+	res := ps.db.WithContext(ctx).Where("user_id = ? AND org_id = ?", userID, orgID).Delete(&store.UserOrg{})
+	return res.RowsAffected > 0, res.Error
+}
+
 type postgresOrganizationStore PostgresStore
 
 func (p *postgresOrganizationStore) CreateOrganization(ctx context.Context, o *store.Organization) error {
@@ -510,9 +1759,308 @@ func (p *postgresOrganizationStore) GetOrganization(ctx context.Context, orgID s
 	ps := (*PostgresStore)(p)
 	var o store.Organization
 	err := ps.db.WithContext(ctx).Where("id = ?", orgID).First(&o).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return o, store.ErrNotFound
+	}
 	return o, err
 }
 
+func (p *postgresOrganizationStore) SetGenerationDefaults(ctx context.Context, orgID string, defaults *store.GenerationParams) (store.Organization, error) {
+	ps := (*PostgresStore)(p)
+	// Update only these columns so concurrent storage accounting isn't overwritten.
+	err := ps.db.WithContext(ctx).Model(&store.Organization{}).Where("id = ?", orgID).
+		Updates(map[string]any{"generation_defaults": defaults, "updated_at": time.Now().UTC()}).Error
+	if err != nil {
+		return store.Organization{}, err
+	}
+	return p.GetOrganization(ctx, orgID)
+}
+
+func (p *postgresOrganizationStore) SetSSODomain(ctx context.Context, orgID, domain, token string) (store.Organization, error) {
+	ps := (*PostgresStore)(p)
+	err := ps.db.WithContext(ctx).Model(&store.Organization{}).Where("id = ?", orgID).
+		Updates(map[string]any{"sso_domain": domain, "sso_domain_token": token, "sso_domain_verified_at": nil, "updated_at": time.Now().UTC()}).Error
+	if err != nil {
+		return store.Organization{}, err
+	}
+	return p.GetOrganization(ctx, orgID)
+}
+
+func (p *postgresOrganizationStore) VerifySSODomain(ctx context.Context, orgID string) (store.Organization, error) {
+	ps := (*PostgresStore)(p)
+	now := time.Now().UTC()
+	err := ps.db.WithContext(ctx).Model(&store.Organization{}).Where("id = ?", orgID).
+		Updates(map[string]any{"sso_domain_verified_at": now, "updated_at": now}).Error
+	if err != nil {
+		return store.Organization{}, err
+	}
+	return p.GetOrganization(ctx, orgID)
+}
+
+func (p *postgresOrganizationStore) SetExportFilenameTemplate(ctx context.Context, orgID, tmpl string) (store.Organization, error) {
+	ps := (*PostgresStore)(p)
+	err := ps.db.WithContext(ctx).Model(&store.Organization{}).Where("id = ?", orgID).
+		Updates(map[string]any{"export_filename_template": tmpl, "updated_at": time.Now().UTC()}).Error
+	if err != nil {
+		return store.Organization{}, err
+	}
+	return p.GetOrganization(ctx, orgID)
+}
+
+func (p *postgresOrganizationStore) SetDefaultRenderer(ctx context.Context, orgID, engine string) (store.Organization, error) {
+	ps := (*PostgresStore)(p)
+	err := ps.db.WithContext(ctx).Model(&store.Organization{}).Where("id = ?", orgID).
+		Updates(map[string]any{"default_renderer": engine, "updated_at": time.Now().UTC()}).Error
+	if err != nil {
+		return store.Organization{}, err
+	}
+	return p.GetOrganization(ctx, orgID)
+}
+
+func (p *postgresOrganizationStore) SetSettings(ctx context.Context, orgID string, settings *store.OrgSettings) (store.Organization, error) {
+	ps := (*PostgresStore)(p)
+	err := ps.db.WithContext(ctx).Model(&store.Organization{ID: orgID}).Select("settings", "updated_at").
+		Updates(&store.Organization{Settings: settings, UpdatedAt: time.Now().UTC()}).Error
+	if err != nil {
+		return store.Organization{}, err
+	}
+	return p.GetOrganization(ctx, orgID)
+}
+
+func (p *postgresOrganizationStore) SetSCIMTokenHash(ctx context.Context, orgID, hash string) error {
+	ps := (*PostgresStore)(p)
+	return ps.db.WithContext(ctx).Model(&store.Organization{}).Where("id = ?", orgID).
+		Updates(map[string]any{"scim_token_hash": hash, "updated_at": time.Now().UTC()}).Error
+}
+
+func (p *postgresOrganizationStore) SetGoogleRefreshToken(ctx context.Context, orgID, token string) error {
+	ps := (*PostgresStore)(p)
+	return ps.db.WithContext(ctx).Model(&store.Organization{}).Where("id = ?", orgID).
+		Updates(map[string]any{"google_refresh_token": token, "updated_at": time.Now().UTC()}).Error
+}
+
+func (p *postgresOrganizationStore) SetSlackIntegration(ctx context.Context, orgID string, s *store.SlackIntegration) error {
+	ps := (*PostgresStore)(p)
+	// Select writes Slack even when it is nil, which Updates on a struct
+	// would skip.
+	return ps.db.WithContext(ctx).Model(&store.Organization{ID: orgID}).Select("slack", "updated_at").
+		Updates(&store.Organization{Slack: s, UpdatedAt: time.Now().UTC()}).Error
+}
+
+func (p *postgresOrganizationStore) SetStockMediaKeys(ctx context.Context, orgID string, keys *store.StockMediaKeys) error {
+	ps := (*PostgresStore)(p)
+	return ps.db.WithContext(ctx).Model(&store.Organization{ID: orgID}).Select("stock_media", "updated_at").
+		Updates(&store.Organization{StockMedia: keys, UpdatedAt: time.Now().UTC()}).Error
+}
+
+func (p *postgresOrganizationStore) SetContentImport(ctx context.Context, orgID string, creds *store.ContentImportCredentials) error {
+	ps := (*PostgresStore)(p)
+	return ps.db.WithContext(ctx).Model(&store.Organization{ID: orgID}).Select("content_import", "updated_at").
+		Updates(&store.Organization{ContentImport: creds, UpdatedAt: time.Now().UTC()}).Error
+}
+
+func (p *postgresOrganizationStore) SetPolicy(ctx context.Context, orgID string, policy *store.OrgPolicy) (store.Organization, error) {
+	ps := (*PostgresStore)(p)
+	err := ps.db.WithContext(ctx).Model(&store.Organization{ID: orgID}).Select("policy", "updated_at").
+		Updates(&store.Organization{Policy: policy, UpdatedAt: time.Now().UTC()}).Error
+	if err != nil {
+		return store.Organization{}, err
+	}
+	return p.GetOrganization(ctx, orgID)
+}
+
+func (p *postgresOrganizationStore) Delete(ctx context.Context, orgID string, purgeAfter time.Time) (store.Organization, error) {
+	ps := (*PostgresStore)(p)
+	now := time.Now().UTC()
+	purgeAfter = purgeAfter.UTC()
+	err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&store.Organization{ID: orgID}).
+			Select("deleted_at", "purge_after", "sso_domain", "sso_domain_token", "sso_domain_verified_at", "scim_token_hash", "updated_at").
+			Updates(&store.Organization{DeletedAt: &now, PurgeAfter: &purgeAfter, UpdatedAt: now})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		for _, model := range []any{&store.Template{}, &store.Deck{}} {
+			if err := tx.Model(model).Where("org_id = ? AND deleted_at IS NULL", orgID).Update("deleted_at", now).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	ps.cache.InvalidateOrg(orgID)
+	if err != nil {
+		return store.Organization{}, err
+	}
+	return p.GetOrganization(ctx, orgID)
+}
+
+func (p *postgresOrganizationStore) ListPurgeable(ctx context.Context, now time.Time) ([]store.Organization, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.Organization
+	err := ps.db.WithContext(ctx).Where("deleted_at IS NOT NULL AND purge_after <= ?", now).Find(&out).Error
+	return out, err
+}
+
+// orgOwnedModels are the tables Purge empties of an org's rows, children
+// before the rows they point at.
+var orgOwnedModels = []any{
+	&store.ResourceTag{},
+	&store.Tag{},
+	&store.Folder{},
+	&store.ResourcePermission{},
+	&store.Comment{},
+	&store.DeckRefinement{},
+	&store.DeckEmbed{},
+	&store.DeckVersion{},
+	&store.Deck{},
+	&store.TemplateVersion{},
+	&store.Template{},
+	&store.BrandKit{},
+	&store.GeneratedImage{},
+	&store.Font{},
+	&store.GlossaryEntry{},
+	&store.Schedule{},
+	&store.Connector{},
+	&store.AICall{},
+	&store.Asset{},
+	&store.Job{},
+	&store.MeteringEvent{},
+	&store.AuditLog{},
+	&store.WebhookDelivery{},
+	&store.Webhook{},
+	&store.APIKey{},
+	&store.FeatureFlag{},
+	&store.UserOrg{},
+}
+
+func (p *postgresOrganizationStore) Purge(ctx context.Context, orgID string) error {
+	ps := (*PostgresStore)(p)
+	defer ps.cache.InvalidateOrg(orgID)
+	return ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range orgOwnedModels {
+			if err := tx.Where("org_id = ?", orgID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		res := tx.Where("id = ?", orgID).Delete(&store.Organization{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (p *postgresOrganizationStore) GetOrganizationBySCIMTokenHash(ctx context.Context, hash string) (store.Organization, bool, error) {
+	ps := (*PostgresStore)(p)
+	if hash == "" {
+		return store.Organization{}, false, nil
+	}
+	var o store.Organization
+	err := ps.db.WithContext(ctx).Where("scim_token_hash = ?", hash).First(&o).Error
+	if err == gorm.ErrRecordNotFound {
+		return store.Organization{}, false, nil
+	}
+	if err != nil {
+		return store.Organization{}, false, err
+	}
+	return o, true, nil
+}
+
+func (p *postgresOrganizationStore) GetOrganizationBySSODomain(ctx context.Context, domain string) (store.Organization, bool, error) {
+	ps := (*PostgresStore)(p)
+	if domain == "" {
+		return store.Organization{}, false, nil
+	}
+	var o store.Organization
+	err := ps.db.WithContext(ctx).Where("sso_domain = ? AND sso_domain_verified_at IS NOT NULL", domain).Order("created_at ASC").First(&o).Error
+	if err == gorm.ErrRecordNotFound {
+		return store.Organization{}, false, nil
+	}
+	if err != nil {
+		return store.Organization{}, false, err
+	}
+	return o, true, nil
+}
+
 func newID(prefix string) string {
 	return uuid.New().String()
-}
\ No newline at end of file
+}
+type postgresPlatformStore PostgresStore
+
+func (p *postgresPlatformStore) RecordActivity(ctx context.Context, userID string, at time.Time) error {
+	ps := (*PostgresStore)(p)
+	a := store.UserActivity{UserID: userID, Day: at.UTC().Truncate(24 * time.Hour)}
+	return ps.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&a).Error
+}
+
+func (p *postgresPlatformStore) DailyActiveUsers(ctx context.Context, since time.Time) ([]store.DailyCount, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.DailyCount
+	err := ps.reader(ctx).Model(&store.UserActivity{}).
+		Where("day >= ?", since.UTC().Truncate(24*time.Hour)).
+		Group("day").Order("day").Select("day, COUNT(*) AS count").Scan(&out).Error
+	return out, err
+}
+
+func (p *postgresPlatformStore) OrgCounts(ctx context.Context, since time.Time) (store.OrgCounts, error) {
+	ps := (*PostgresStore)(p)
+	var c store.OrgCounts
+	err := ps.reader(ctx).Model(&store.Organization{}).Select(
+		"COUNT(*) AS total, "+
+			"COUNT(*) FILTER (WHERE deleted_at IS NULL) AS active, "+
+			"COUNT(*) FILTER (WHERE deleted_at IS NOT NULL) AS deleted, "+
+			"COUNT(*) FILTER (WHERE created_at >= ?) AS created_since", since).
+		Scan(&c).Error
+	return c, err
+}
+
+func (p *postgresPlatformStore) JobCounts(ctx context.Context, since time.Time) ([]store.JobCount, error) {
+	ps := (*PostgresStore)(p)
+	var out []store.JobCount
+	err := ps.reader(ctx).Model(&store.Job{}).Where("created_at >= ?", since).
+		Group("type, status").Select("type, status, COUNT(*) AS count").Scan(&out).Error
+	return out, err
+}
+
+func (p *postgresPlatformStore) SumByOrg(ctx context.Context, eventType string, since time.Time) (map[string]int, error) {
+	ps := (*PostgresStore)(p)
+	var rows []struct {
+		OrgID string
+		Sum   int
+	}
+	err := ps.reader(ctx).Model(&store.MeteringEvent{}).
+		Where("event_type = ? AND created_at >= ?", eventType, since).
+		Group("org_id").Select("org_id, SUM(quantity) AS sum").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	sums := make(map[string]int, len(rows))
+	for _, r := range rows {
+		sums[r.OrgID] = r.Sum
+	}
+	return sums, nil
+}
+
+func (p *postgresPlatformStore) StorageByOrg(ctx context.Context) (map[string]int64, error) {
+	ps := (*PostgresStore)(p)
+	var rows []struct {
+		ID               string
+		StorageBytesUsed int64
+	}
+	err := ps.reader(ctx).Model(&store.Organization{}).Where("storage_bytes_used > 0").
+		Select("id, storage_bytes_used").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		out[r.ID] = r.StorageBytesUsed
+	}
+	return out, nil
+}
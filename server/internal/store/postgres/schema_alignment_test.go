@@ -31,6 +31,8 @@ func TestPostgresJobStore_SchemaAlignment(t *testing.T) {
 		store.JobExport,
 		store.JobGenerate,
 		store.JobBind,
+		store.JobBulkExport,
+		store.JobImageGen,
 	}
 
 	// Test all Job Statuses
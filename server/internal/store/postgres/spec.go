@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"gorm.io/gorm/schema"
+)
+
+// The version models tag SpecJSON with serializer:spec, so every spec read
+// from or written to a jsonb column goes through specSerializer.
+func init() {
+	schema.RegisterSerializer("spec", specSerializer{})
+}
+
+// specSerializer moves a json.RawMessage spec in and out of jsonb. Left to
+// GORM, a json.RawMessage is a []byte, which pgx sends as bytea; and rows
+// written while SpecJSON was an `any` may hold a base64 string rather than
+// the spec itself. Scan reads those back as the JSON they encode, so they
+// are rewritten canonically the next time the version is saved.
+//
+// Specs are also upgraded to the current schema version both ways: Scan
+// migrates older specs as they are read, and Value stamps the version on
+// write and refuses specs from a newer server.
+type specSerializer struct{}
+
+// Scan implements schema.SerializerInterface.
+func (specSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue any) error {
+	var raw json.RawMessage
+	if dbValue != nil {
+		doc, err := normalize.Load(dbValue)
+		if err != nil {
+			return fmt.Errorf("scan %s: %w", field.DBName, err)
+		}
+		raw = doc.Save()
+		// A spec that can't be migrated, such as one written by a newer
+		// server, is read as it is; writing it back fails in Value.
+		if migrated, err := spec.Migrate(raw); err == nil {
+			raw = migrated
+		}
+	}
+	field.ReflectValueOf(ctx, dst).Set(reflect.ValueOf(raw))
+	return nil
+}
+
+// Value implements schema.SerializerInterface. An empty spec is stored as
+// NULL; anything else must be a JSON document.
+func (specSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue any) (any, error) {
+	raw, _ := fieldValue.(json.RawMessage)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	b, err := normalize.JSON(raw)
+	if err == nil {
+		b, err = spec.Migrate(b)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("value %s: %w", field.DBName, err)
+	}
+	// A string, not []byte, for the same reason as store.JSONMap.Value.
+	return string(b), nil
+}
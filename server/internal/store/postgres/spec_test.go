@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"gorm.io/gorm/schema"
+)
+
+// testSpec is at the current schema version, so migration leaves it alone.
+const testSpec = `{"layouts":[{"name":"Title"}],"specVersion":2}`
+
+func specField(t *testing.T) *schema.Field {
+	t.Helper()
+	s, err := schema.Parse(&store.TemplateVersion{}, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+	f := s.LookUpField("SpecJSON")
+	require.NotNil(t, f)
+	require.IsType(t, specSerializer{}, f.Serializer)
+	return f
+}
+
+func TestSpecSerializer_Value(t *testing.T) {
+	ctx := context.Background()
+	f := specField(t)
+
+	v, err := specSerializer{}.Value(ctx, f, reflect.Value{}, json.RawMessage(testSpec))
+	require.NoError(t, err)
+	assert.Equal(t, testSpec, v, "jsonb must get the JSON as a string, not bytea or base64")
+
+	v, err = specSerializer{}.Value(ctx, f, reflect.Value{}, json.RawMessage(nil))
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	_, err = specSerializer{}.Value(ctx, f, reflect.Value{}, json.RawMessage(`not a spec`))
+	assert.Error(t, err)
+}
+
+func TestSpecSerializer_Scan(t *testing.T) {
+	ctx := context.Background()
+	f := specField(t)
+	legacy, err := json.Marshal(base64.StdEncoding.EncodeToString([]byte(testSpec)))
+	require.NoError(t, err)
+
+	for name, dbValue := range map[string]any{
+		"jsonb text":           testSpec,
+		"jsonb bytes":          []byte(testSpec),
+		"legacy base64 string": string(legacy),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var tv store.TemplateVersion
+			require.NoError(t, specSerializer{}.Scan(ctx, f, reflect.ValueOf(&tv).Elem(), dbValue))
+			assert.Equal(t, testSpec, string(tv.SpecJSON))
+		})
+	}
+
+	tv := store.TemplateVersion{SpecJSON: json.RawMessage(testSpec)}
+	require.NoError(t, specSerializer{}.Scan(ctx, f, reflect.ValueOf(&tv).Elem(), nil))
+	assert.Nil(t, tv.SpecJSON)
+
+	assert.Error(t, specSerializer{}.Scan(ctx, f, reflect.ValueOf(&tv).Elem(), "not a spec"))
+}
+
+func TestSpecSerializer_MigratesSchemaVersions(t *testing.T) {
+	ctx := context.Background()
+	f := specField(t)
+	const v1 = `{"layouts":[{"name":"Title","placeholders":[{"id":"title"}]}]}`
+	const v2 = `{"layouts":[{"name":"Title","placeholders":[{"id":"title","type":"text"}]}],"specVersion":2}`
+
+	var tv store.TemplateVersion
+	require.NoError(t, specSerializer{}.Scan(ctx, f, reflect.ValueOf(&tv).Elem(), v1))
+	assert.JSONEq(t, v2, string(tv.SpecJSON), "older specs are upgraded as they are read")
+
+	v, err := specSerializer{}.Value(ctx, f, reflect.Value{}, json.RawMessage(v1))
+	require.NoError(t, err)
+	assert.JSONEq(t, v2, v.(string))
+
+	const future = `{"layouts":[],"specVersion":99}`
+	require.NoError(t, specSerializer{}.Scan(ctx, f, reflect.ValueOf(&tv).Elem(), future))
+	assert.Equal(t, future, string(tv.SpecJSON), "a newer spec is read as it is")
+	_, err = specSerializer{}.Value(ctx, f, reflect.Value{}, json.RawMessage(future))
+	assert.ErrorContains(t, err, "spec version 99 is newer than this server supports")
+}
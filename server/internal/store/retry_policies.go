@@ -0,0 +1,16 @@
+package store
+
+import "time"
+
+// RetryPolicyOverride is a platform admin's change to a job type's retry
+// policy. Nil fields keep the value from the environment or the built-in
+// default, so an override can tune just the field that needs it.
+type RetryPolicyOverride struct {
+	JobType     JobType   `json:"jobType" gorm:"primaryKey"`
+	MaxRetries  *int      `json:"maxRetries,omitempty"`
+	BaseDelayMs *int64    `json:"baseDelayMs,omitempty"`
+	MaxDelayMs  *int64    `json:"maxDelayMs,omitempty"`
+	Jitter      *float64  `json:"jitter,omitempty"`
+	UpdatedBy   string    `json:"updatedBy"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
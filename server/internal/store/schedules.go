@@ -0,0 +1,37 @@
+package store
+
+import "time"
+
+// Schedule generates a deck from a template version on a cron cadence and
+// exports it, e.g. a weekly sales report. Each run binds the schedule's
+// content to the template, or the body fetched from SourceURL when one is
+// set, then exports the new deck. Runs are attributed to CreatedBy.
+type Schedule struct {
+	ID    string `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID string `json:"orgId" gorm:"type:uuid;index"`
+	Name  string `json:"name"`
+	// Cron is a five-field cron expression or a macro like @weekly,
+	// evaluated in Timezone (an IANA name; "" is UTC).
+	Cron              string `json:"cron"`
+	Timezone          string `json:"timezone,omitempty"`
+	TemplateVersionID string `json:"templateVersionId" gorm:"type:uuid"`
+	Content           string `json:"content,omitempty"`
+	// SourceURL is fetched at each run; its body is the run's content, after
+	// Content when both are set.
+	SourceURL string `json:"sourceUrl,omitempty"`
+	// Quality and Renderer are the export's options; "" is standard quality
+	// from the org's default renderer.
+	Quality  string `json:"quality,omitempty"`
+	Renderer string `json:"renderer,omitempty"`
+	Enabled  bool   `json:"enabled"`
+	// NextRunAt is nil while the schedule is disabled.
+	NextRunAt  *time.Time `json:"nextRunAt,omitempty" gorm:"index"`
+	LastRunAt  *time.Time `json:"lastRunAt,omitempty"`
+	LastDeckID string     `json:"lastDeckId,omitempty"`
+	LastJobID  string     `json:"lastJobId,omitempty"`
+	// LastError is why the last run could not start; "" if it did.
+	LastError string    `json:"lastError,omitempty"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
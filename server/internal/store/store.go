@@ -1,6 +1,17 @@
 package store
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/auth"
+)
+
+// ErrNotFound is returned by lookups that report a missing record as an
+// error rather than a found flag, such as GetOrganization, so callers can
+// tell it from a failed read.
+var ErrNotFound = errors.New("not found")
 
 type Store interface {
 	Templates() TemplateStore
@@ -12,6 +23,25 @@ type Store interface {
 	Audit() AuditStore
 	Users() UserStore
 	Organizations() OrganizationStore
+	Permissions() PermissionStore
+	Comments() CommentStore
+	Tags() TagStore
+	Folders() FolderStore
+	Webhooks() WebhookStore
+	Embeds() EmbedStore
+	APIKeys() APIKeyStore
+	RetryPolicies() RetryPolicyStore
+	FeatureFlags() FeatureFlagStore
+	Fonts() FontStore
+	Glossary() GlossaryStore
+	Schedules() ScheduleStore
+	Connectors() ConnectorStore
+	AICalls() AICallStore
+	GeneratedImages() GeneratedImageStore
+	Platform() PlatformStore
+	// WithTx runs fn against a Store whose writes all commit if fn returns nil
+	// and are all rolled back if it returns an error.
+	WithTx(ctx context.Context, fn func(tx Store) error) error
 }
 
 type DeckStore interface {
@@ -19,15 +49,39 @@ type DeckStore interface {
 	ListDecks(ctx context.Context, orgID string) ([]Deck, error)
 	GetDeck(ctx context.Context, orgID, id string) (Deck, bool, error)
 	UpdateDeck(ctx context.Context, d Deck) (Deck, error)
+	// ListDecksFor returns the decks in id's org that id may view.
+	ListDecksFor(ctx context.Context, id auth.Identity) ([]Deck, error)
+	// GetDeckFor returns the deck with id's effective permission on it. Decks
+	// id may not view are reported as not found.
+	GetDeckFor(ctx context.Context, id auth.Identity, deckID string) (Deck, Permission, bool, error)
+	// ListDecksWithSummary is ListDecksFor with each deck's current version
+	// summed up, read in one pass rather than a query per deck.
+	ListDecksWithSummary(ctx context.Context, id auth.Identity) ([]DeckWithSummary, error)
 
 	CreateDeckVersion(ctx context.Context, v DeckVersion) (DeckVersion, error)
 	ListDeckVersions(ctx context.Context, orgID, deckID string) ([]DeckVersion, error)
 	GetDeckVersion(ctx context.Context, orgID, versionID string) (DeckVersion, bool, error)
+
+	AppendRefinement(ctx context.Context, r DeckRefinement) (DeckRefinement, error)
+	// ListRefinements returns the deck's refinement conversation, oldest
+	// first.
+	ListRefinements(ctx context.Context, orgID, deckID string) ([]DeckRefinement, error)
 }
 
 type AssetStore interface {
 	Create(ctx context.Context, a Asset) (Asset, error)
 	Get(ctx context.Context, orgID, id string) (Asset, bool, error)
+	// GetAssets returns the org's assets among ids, keyed by ID. IDs that
+	// don't exist are left out.
+	GetAssets(ctx context.Context, orgID string, ids []string) (map[string]Asset, error)
+	// List returns the page of assets matching f (newest first) and the total
+	// number of matches before pagination.
+	List(ctx context.Context, orgID string, f AssetFilter) ([]Asset, int, error)
+	// Delete removes the asset record and releases its bytes from the org's
+	// storage total. It returns false if the asset does not exist.
+	Delete(ctx context.Context, orgID, id string) (bool, error)
+	// TotalBytes returns the org's cumulative stored asset bytes.
+	TotalBytes(ctx context.Context, orgID string) (int64, error)
 }
 
 type TemplateStore interface {
@@ -35,10 +89,22 @@ type TemplateStore interface {
 	ListTemplates(ctx context.Context, orgID string) ([]Template, error)
 	GetTemplate(ctx context.Context, orgID, id string) (Template, bool, error)
 	UpdateTemplate(ctx context.Context, t Template) (Template, error)
+	// ListTemplatesFor returns the templates in id's org that id may view.
+	ListTemplatesFor(ctx context.Context, id auth.Identity) ([]Template, error)
+	// GetTemplateFor returns the template with id's effective permission on
+	// it. Templates id may not view are reported as not found.
+	GetTemplateFor(ctx context.Context, id auth.Identity, templateID string) (Template, Permission, bool, error)
+	// ListTemplatesWithCurrentVersion is ListTemplatesFor with each
+	// template's current version, loaded together rather than one query
+	// per template.
+	ListTemplatesWithCurrentVersion(ctx context.Context, id auth.Identity) ([]TemplateWithVersion, error)
 
 	CreateVersion(ctx context.Context, v TemplateVersion) (TemplateVersion, error)
 	ListVersions(ctx context.Context, orgID, templateID string) ([]TemplateVersion, error)
 	GetVersion(ctx context.Context, orgID, versionID string) (TemplateVersion, bool, error)
+	// GetVersions returns the org's versions among ids, keyed by ID. IDs
+	// that don't exist are left out.
+	GetVersions(ctx context.Context, orgID string, ids []string) (map[string]TemplateVersion, error)
 }
 
 type BrandKitStore interface {
@@ -52,21 +118,37 @@ type JobStore interface {
 	Get(ctx context.Context, orgID, jobID string) (Job, bool, error)
 	GetByDeduplicationID(ctx context.Context, orgID, dedupID string) (Job, bool, error)
 	Update(ctx context.Context, j Job) (Job, error)
+	// Heartbeat marks a Running job as still alive, touching only its
+	// heartbeat and update times so it can't race progress updates.
+	Heartbeat(ctx context.Context, jobID string) error
 	ListQueued(ctx context.Context) ([]Job, error)
 	ListRetry(ctx context.Context) ([]Job, error)
 	ListDeadLetter(ctx context.Context) ([]Job, error)
 	ListByInputRef(ctx context.Context, orgID, inputRef string, jobType JobType) ([]Job, error)
 	MoveToDeadLetter(ctx context.Context, jobID string) error
 	RetryDeadLetterJob(ctx context.Context, jobID string) error
+	// List returns jobs across all orgs, newest first. It backs the
+	// platform admin view and must not be exposed to org-scoped callers.
+	List(ctx context.Context, f JobFilter) ([]Job, error)
+	// DeleteDeadLetter removes the given jobs that are still dead-lettered and
+	// returns how many were deleted.
+	DeleteDeadLetter(ctx context.Context, jobIDs []string) (int, error)
 }
 
 type MeteringStore interface {
 	Record(ctx context.Context, e MeteringEvent) (MeteringEvent, error)
 	SumByType(ctx context.Context, orgID string, eventType string) (int, error)
+	// SumByTarget sums the org's eventType quantities per TargetRef, leaving
+	// out events without one.
+	SumByTarget(ctx context.Context, orgID string, eventType string) (map[string]int, error)
+	// SumForTarget sums the org's eventType quantities for one TargetRef.
+	SumForTarget(ctx context.Context, orgID, eventType, targetRef string) (int, error)
 }
 
 type AuditStore interface {
 	Append(ctx context.Context, a AuditLog) (AuditLog, error)
+	// List returns the org's audit log, oldest first.
+	List(ctx context.Context, orgID string) ([]AuditLog, error)
 }
 
 type UserStore interface {
@@ -75,9 +157,220 @@ type UserStore interface {
 	GetUserByEmail(ctx context.Context, email string) (User, bool, error)
 	CreateUserOrg(ctx context.Context, uo UserOrg) error
 	ListUserOrgs(ctx context.Context, userID string) ([]UserOrg, error)
+	UpdateUser(ctx context.Context, u User) error
+	ListOrgMembers(ctx context.Context, orgID string) ([]UserOrg, error)
+	SetUserOrgRole(ctx context.Context, userID, orgID string, role auth.Role) error
+	DeleteUserOrg(ctx context.Context, userID, orgID string) (bool, error)
 }
 
 type OrganizationStore interface {
 	CreateOrganization(ctx context.Context, o *Organization) error
 	GetOrganization(ctx context.Context, orgID string) (Organization, error)
+	SetGenerationDefaults(ctx context.Context, orgID string, defaults *GenerationParams) (Organization, error)
+	// SetSSODomain claims domain for the org with the token its DNS TXT
+	// record must carry; the claim is unverified until VerifySSODomain.
+	SetSSODomain(ctx context.Context, orgID, domain, token string) (Organization, error)
+	VerifySSODomain(ctx context.Context, orgID string) (Organization, error)
+	SetExportFilenameTemplate(ctx context.Context, orgID, tmpl string) (Organization, error)
+	SetDefaultRenderer(ctx context.Context, orgID, engine string) (Organization, error)
+	// SetSettings replaces the org's settings; nil clears them.
+	SetSettings(ctx context.Context, orgID string, settings *OrgSettings) (Organization, error)
+	// GetOrganizationBySSODomain returns the org that has verified domain.
+	GetOrganizationBySSODomain(ctx context.Context, domain string) (Organization, bool, error)
+	SetSCIMTokenHash(ctx context.Context, orgID, hash string) error
+	GetOrganizationBySCIMTokenHash(ctx context.Context, hash string) (Organization, bool, error)
+	SetGoogleRefreshToken(ctx context.Context, orgID, token string) error
+	// SetSlackIntegration replaces the org's Slack settings; nil removes them.
+	SetSlackIntegration(ctx context.Context, orgID string, s *SlackIntegration) error
+	// SetStockMediaKeys replaces the org's stock photo API keys; nil removes them.
+	SetStockMediaKeys(ctx context.Context, orgID string, keys *StockMediaKeys) error
+	// SetContentImport replaces the org's Notion and Confluence credentials;
+	// nil removes them.
+	SetContentImport(ctx context.Context, orgID string, creds *ContentImportCredentials) error
+	// SetPolicy replaces the org's compliance policy; nil removes it.
+	SetPolicy(ctx context.Context, orgID string, policy *OrgPolicy) (Organization, error)
+	// Delete soft-deletes the org along with its templates and decks, and
+	// drops its SSO domain and SCIM token so it can't be signed into. The
+	// data stays until Purge, which is due after purgeAfter.
+	Delete(ctx context.Context, orgID string, purgeAfter time.Time) (Organization, error)
+	// ListPurgeable returns deleted orgs whose purge is due at now.
+	ListPurgeable(ctx context.Context, now time.Time) ([]Organization, error)
+	// Purge permanently removes a deleted org and every record that belongs
+	// to it. Asset bytes in object storage are the caller's to remove.
+	Purge(ctx context.Context, orgID string) error
+}
+
+type PermissionStore interface {
+	// Grant creates or replaces the user's grant on a resource.
+	Grant(ctx context.Context, p ResourcePermission) (ResourcePermission, error)
+	Revoke(ctx context.Context, orgID string, rt ResourceType, resourceID, userID string) (bool, error)
+	List(ctx context.Context, orgID string, rt ResourceType, resourceID string) ([]ResourcePermission, error)
+}
+
+type CommentStore interface {
+	Create(ctx context.Context, c Comment) (Comment, error)
+	Get(ctx context.Context, orgID, id string) (Comment, bool, error)
+	// ListByDeckVersion returns the version's comments, oldest first.
+	ListByDeckVersion(ctx context.Context, orgID, deckVersionID string) ([]Comment, error)
+	Update(ctx context.Context, c Comment) (Comment, error)
+}
+
+type TagStore interface {
+	CreateTag(ctx context.Context, t Tag) (Tag, error)
+	ListTags(ctx context.Context, orgID string) ([]Tag, error)
+	GetTag(ctx context.Context, orgID, id string) (Tag, bool, error)
+	UpdateTag(ctx context.Context, t Tag) (Tag, error)
+	// DeleteTag removes the tag and detaches it from every resource.
+	DeleteTag(ctx context.Context, orgID, id string) (bool, error)
+
+	Attach(ctx context.Context, rt ResourceTag) error
+	Detach(ctx context.Context, orgID, tagID string, rt ResourceType, resourceID string) (bool, error)
+	// ListAttached returns the tags on every resource of type rt in the org,
+	// keyed by resource ID.
+	ListAttached(ctx context.Context, orgID string, rt ResourceType) (map[string][]Tag, error)
+}
+
+type FolderStore interface {
+	CreateFolder(ctx context.Context, f Folder) (Folder, error)
+	ListFolders(ctx context.Context, orgID string) ([]Folder, error)
+	GetFolder(ctx context.Context, orgID, id string) (Folder, bool, error)
+	UpdateFolder(ctx context.Context, f Folder) (Folder, error)
+	DeleteFolder(ctx context.Context, orgID, id string) (bool, error)
+}
+
+type WebhookStore interface {
+	Create(ctx context.Context, w Webhook) (Webhook, error)
+	Get(ctx context.Context, orgID, id string) (Webhook, bool, error)
+	List(ctx context.Context, orgID string) ([]Webhook, error)
+	Update(ctx context.Context, w Webhook) (Webhook, error)
+	// Delete removes the webhook and its delivery log.
+	Delete(ctx context.Context, orgID, id string) (bool, error)
+
+	CreateDelivery(ctx context.Context, d WebhookDelivery) (WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, d WebhookDelivery) (WebhookDelivery, error)
+	// ListDeliveries returns the webhook's most recent deliveries, newest
+	// first.
+	ListDeliveries(ctx context.Context, orgID, webhookID string, limit int) ([]WebhookDelivery, error)
+	// ListDueDeliveries returns pending deliveries across all orgs whose next
+	// attempt is due at now, oldest first. It backs the delivery dispatcher
+	// and must not be exposed to org-scoped callers.
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]WebhookDelivery, error)
+}
+
+type EmbedStore interface {
+	Create(ctx context.Context, e DeckEmbed) (DeckEmbed, error)
+	// GetByTokenHash looks an embed up across all orgs; the token is the
+	// viewer's only credential.
+	GetByTokenHash(ctx context.Context, tokenHash string) (DeckEmbed, bool, error)
+	List(ctx context.Context, orgID, deckID string) ([]DeckEmbed, error)
+	Delete(ctx context.Context, orgID, id string) (bool, error)
+	// RecordView increments the embed's view count.
+	RecordView(ctx context.Context, id string, at time.Time) error
+}
+
+type APIKeyStore interface {
+	Create(ctx context.Context, k APIKey) (APIKey, error)
+	// GetByHash looks a key up across all orgs; the key is the caller's
+	// only credential.
+	GetByHash(ctx context.Context, keyHash string) (APIKey, bool, error)
+	// List returns the org's keys, or only userID's when it is non-empty.
+	List(ctx context.Context, orgID, userID string) ([]APIKey, error)
+	Delete(ctx context.Context, orgID, id string) (bool, error)
+	MarkUsed(ctx context.Context, id string, at time.Time) error
+}
+
+// RetryPolicyStore holds the platform-wide retry policy overrides, at most
+// one per job type.
+type RetryPolicyStore interface {
+	List(ctx context.Context) ([]RetryPolicyOverride, error)
+	// Put creates or replaces the override for o.JobType.
+	Put(ctx context.Context, o RetryPolicyOverride) (RetryPolicyOverride, error)
+	Delete(ctx context.Context, jobType JobType) (bool, error)
+}
+
+// FeatureFlagStore holds the platform-wide flag settings and per-org
+// overrides, at most one per flag and org.
+type FeatureFlagStore interface {
+	// List returns every setting and override, ordered by name then org.
+	List(ctx context.Context) ([]FeatureFlag, error)
+	// Put creates or replaces the row for f.Name and f.OrgID.
+	Put(ctx context.Context, f FeatureFlag) (FeatureFlag, error)
+	Delete(ctx context.Context, name, orgID string) (bool, error)
+}
+
+type FontStore interface {
+	Create(ctx context.Context, f Font) (Font, error)
+	// List returns the org's fonts ordered by family, then style.
+	List(ctx context.Context, orgID string) ([]Font, error)
+	Get(ctx context.Context, orgID, id string) (Font, bool, error)
+	Delete(ctx context.Context, orgID, id string) (bool, error)
+}
+
+type GlossaryStore interface {
+	Create(ctx context.Context, e GlossaryEntry) (GlossaryEntry, error)
+	// List returns the org's glossary ordered by term.
+	List(ctx context.Context, orgID string) ([]GlossaryEntry, error)
+	Get(ctx context.Context, orgID, id string) (GlossaryEntry, bool, error)
+	Update(ctx context.Context, e GlossaryEntry) (GlossaryEntry, error)
+	Delete(ctx context.Context, orgID, id string) (bool, error)
+}
+
+type ScheduleStore interface {
+	Create(ctx context.Context, s Schedule) (Schedule, error)
+	// List returns the org's schedules ordered by name.
+	List(ctx context.Context, orgID string) ([]Schedule, error)
+	Get(ctx context.Context, orgID, id string) (Schedule, bool, error)
+	Update(ctx context.Context, s Schedule) (Schedule, error)
+	Delete(ctx context.Context, orgID, id string) (bool, error)
+	// ListDue returns the enabled schedules of every org whose next run is
+	// at or before now, soonest first.
+	ListDue(ctx context.Context, now time.Time) ([]Schedule, error)
+	// Claim moves the schedule's next run from prev to next and reports
+	// whether it did; false means another worker claimed the run first.
+	Claim(ctx context.Context, orgID, id string, prev, next time.Time) (bool, error)
+}
+
+type ConnectorStore interface {
+	Create(ctx context.Context, c Connector) (Connector, error)
+	// List returns the org's connectors ordered by name.
+	List(ctx context.Context, orgID string) ([]Connector, error)
+	Get(ctx context.Context, orgID, id string) (Connector, bool, error)
+	// GetByName returns the org's connector called name.
+	GetByName(ctx context.Context, orgID, name string) (Connector, bool, error)
+	Update(ctx context.Context, c Connector) (Connector, error)
+	Delete(ctx context.Context, orgID, id string) (bool, error)
+}
+
+type AICallStore interface {
+	Record(ctx context.Context, c AICall) (AICall, error)
+	// List returns the calls matching f across orgs, newest first.
+	List(ctx context.Context, f AICallFilter) ([]AICall, error)
+}
+
+type GeneratedImageStore interface {
+	Create(ctx context.Context, g GeneratedImage) (GeneratedImage, error)
+	// GetByPromptHash returns the org's most recent image for a prompt hash.
+	GetByPromptHash(ctx context.Context, orgID, hash string) (GeneratedImage, bool, error)
+	// List returns the org's generated images, newest first.
+	List(ctx context.Context, orgID string) ([]GeneratedImage, error)
+}
+
+// PlatformStore aggregates across every org for the platform operators'
+// dashboard. Like JobStore.List it must not be exposed to org-scoped callers.
+type PlatformStore interface {
+	// RecordActivity notes that userID was active on at's UTC day. Repeat
+	// calls for the same day are no-ops.
+	RecordActivity(ctx context.Context, userID string, at time.Time) error
+	// DailyActiveUsers counts the users active on each UTC day from since's
+	// day on, oldest first. Days without activity are left out.
+	DailyActiveUsers(ctx context.Context, since time.Time) ([]DailyCount, error)
+	// OrgCounts counts orgs, with CreatedSince those created at or after since.
+	OrgCounts(ctx context.Context, since time.Time) (OrgCounts, error)
+	// JobCounts counts the jobs created at or after since by type and status.
+	JobCounts(ctx context.Context, since time.Time) ([]JobCount, error)
+	// SumByOrg sums eventType quantities recorded at or after since, per org.
+	SumByOrg(ctx context.Context, eventType string, since time.Time) (map[string]int, error)
+	// StorageByOrg returns each org's stored asset bytes, leaving out orgs
+	// that store nothing.
+	StorageByOrg(ctx context.Context) (map[string]int64, error)
 }
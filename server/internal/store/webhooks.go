@@ -0,0 +1,56 @@
+package store
+
+import "time"
+
+// Webhook is an org's subscription to a set of event types. Deliveries are
+// signed with Secret, which is shown once at creation, never serialized and
+// encrypted at rest.
+type Webhook struct {
+	ID        string    `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID     string    `json:"orgId" gorm:"type:uuid;index;not null"`
+	URL       string    `json:"url" gorm:"not null"`
+	Events    []string  `json:"events" gorm:"type:jsonb;serializer:json"`
+	Secret    string    `json:"-" gorm:"not null;serializer:encrypted"`
+	Active    bool      `json:"active" gorm:"not null;default:true"`
+	CreatedBy string    `json:"createdBy" gorm:"type:uuid"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Subscribed reports whether the webhook wants events of eventType.
+func (w Webhook) Subscribed(eventType string) bool {
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event sent to one webhook, with the outcome of its
+// latest attempt. Pending deliveries are retried once NextAttemptAt passes.
+type WebhookDelivery struct {
+	ID             string                `json:"id" gorm:"type:uuid;primaryKey"`
+	OrgID          string                `json:"orgId" gorm:"type:uuid;index;not null"`
+	WebhookID      string                `json:"webhookId" gorm:"type:uuid;index;not null"`
+	EventID        string                `json:"eventId" gorm:"index"`
+	EventType      string                `json:"eventType"`
+	Payload        string                `json:"payload" gorm:"not null"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"not null;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int                   `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `json:"nextAttemptAt" gorm:"index:idx_webhook_deliveries_due,priority:2"`
+	ResponseStatus int                   `json:"responseStatus,omitempty"`
+	Error          string                `json:"error,omitempty"`
+	DurationMs     int64                 `json:"durationMs"`
+	DeliveredAt    *time.Time            `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	UpdatedAt      time.Time             `json:"updatedAt"`
+}
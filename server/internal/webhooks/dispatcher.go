@@ -0,0 +1,168 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/egress"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const (
+	// MaxAttempts is how many times a delivery is tried before it is marked
+	// failed. With the backoff below the last try is about two hours after
+	// the event.
+	MaxAttempts = 8
+
+	baseBackoff = time.Minute
+
+	// dispatchBatch caps the deliveries sent per poll.
+	dispatchBatch = 50
+)
+
+// Backoff returns the delay before the next try of a delivery that has failed
+// attempts times: 1m, 2m, 4m and so on.
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return baseBackoff << (attempts - 1)
+}
+
+// Dispatcher sends pending deliveries and reschedules the ones that fail.
+type Dispatcher struct {
+	store  store.Store
+	Client *http.Client
+	// PollInterval is how often due deliveries are sent; 0 = default (5s).
+	PollInterval time.Duration
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewDispatcher(st store.Store) *Dispatcher {
+	// Endpoints are user-supplied, so deliveries go through the egress
+	// client, which refuses loopback, private and metadata addresses.
+	client := egress.NewClient(10 * time.Second)
+	// A redirect is reported as the endpoint's answer rather than followed,
+	// so deliveries only ever reach the registered URL.
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	return &Dispatcher{
+		store:  st,
+		Client: client,
+		stop:   make(chan struct{}),
+	}
+}
+
+func (d *Dispatcher) Start() {
+	interval := d.PollInterval
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+				d.RunOnce(context.Background())
+			}
+		}
+	}()
+}
+
+func (d *Dispatcher) Stop() {
+	close(d.stop)
+	d.wg.Wait()
+}
+
+// RunOnce sends every delivery that is due and returns how many were tried.
+func (d *Dispatcher) RunOnce(ctx context.Context) int {
+	due, err := d.store.Webhooks().ListDueDeliveries(ctx, time.Now().UTC(), dispatchBatch)
+	if err != nil {
+		logger.LogError(ctx, "webhooks", "list_due_deliveries", err)
+		return 0
+	}
+	for _, del := range due {
+		hook, ok, err := d.store.Webhooks().Get(ctx, del.OrgID, del.WebhookID)
+		if err != nil {
+			logger.LogError(ctx, "webhooks", "get_webhook", err, "webhook_id", del.WebhookID)
+			continue
+		}
+		if !ok || !hook.Active {
+			del.Status = store.WebhookDeliveryFailed
+			del.Error = "webhook was deleted or disabled"
+		} else {
+			del = d.Send(ctx, hook, del)
+			if del.Status != store.WebhookDeliverySucceeded {
+				if del.Attempts >= MaxAttempts {
+					del.Status = store.WebhookDeliveryFailed
+				} else {
+					del.NextAttemptAt = time.Now().UTC().Add(Backoff(del.Attempts))
+				}
+			}
+		}
+		if _, err := d.store.Webhooks().UpdateDelivery(ctx, del); err != nil {
+			logger.LogError(ctx, "webhooks", "update_delivery", err, "delivery_id", del.ID)
+		}
+	}
+	return len(due)
+}
+
+// Send makes one attempt at del and records its outcome on the returned
+// copy. It does not save the delivery or schedule a retry; a delivery that
+// did not succeed is left for the caller to reschedule or fail.
+func (d *Dispatcher) Send(ctx context.Context, hook store.Webhook, del store.WebhookDelivery) store.WebhookDelivery {
+	del.Attempts++
+	del.ResponseStatus = 0
+	del.Error = ""
+
+	start := time.Now()
+	status, err := d.post(ctx, hook, del)
+	del.DurationMs = time.Since(start).Milliseconds()
+	del.ResponseStatus = status
+	if err != nil {
+		del.Error = err.Error()
+		logger.Jobs().Warn("webhook_delivery_failed", "delivery_id", del.ID, "webhook_id", hook.ID, "attempt", del.Attempts, "status", status, "error", err)
+		return del
+	}
+
+	now := time.Now().UTC()
+	del.Status = store.WebhookDeliverySucceeded
+	del.DeliveredAt = &now
+	return del
+}
+
+func (d *Dispatcher) post(ctx context.Context, hook store.Webhook, del store.WebhookDelivery) (int, error) {
+	body := []byte(del.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, strings.NewReader(del.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cms-ai-webhooks/1.0")
+	req.Header.Set(EventHeader, del.EventType)
+	req.Header.Set(DeliveryHeader, del.ID)
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, time.Now(), body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
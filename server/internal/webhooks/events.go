@@ -0,0 +1,105 @@
+// Package webhooks records org events and delivers them to the HTTPS
+// endpoints orgs subscribe, signed and retried with backoff.
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const (
+	EventJobCompleted      = "job.completed"
+	EventQuotaExceeded     = "quota.exceeded"
+	EventWebhookTest       = "webhook.test"
+	EventDeckCreated       = "deck.created"
+	EventTemplatePublished = "template.published"
+	EventExportCompleted   = "export.completed"
+	EventJobDeadLettered   = "job.dead_lettered"
+)
+
+// EventTypes lists every event a webhook can subscribe to.
+var EventTypes = []string{
+	EventJobCompleted,
+	EventQuotaExceeded,
+	EventWebhookTest,
+	EventDeckCreated,
+	EventTemplatePublished,
+	EventExportCompleted,
+	EventJobDeadLettered,
+}
+
+// Event is an org-facing notification. Synthetic events come from the
+// simulator and carry the same shape as real ones.
+type Event struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	OrgID     string         `json:"orgId"`
+	Synthetic bool           `json:"synthetic"`
+	Data      map[string]any `json:"data"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// Emit is the single delivery path for org events: they are recorded in the
+// audit log and the business event stream, and a pending delivery is queued
+// for every active webhook in the org subscribed to the event's type. Failures
+// are logged rather than returned so an event never fails the action that
+// raised it.
+func Emit(ctx context.Context, st store.Store, actorID string, ev Event) Event {
+	if ev.ID == "" {
+		ev.ID = newID()
+	}
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = time.Now().UTC()
+	}
+	if _, err := st.Audit().Append(ctx, store.AuditLog{ID: newID(), OrgID: ev.OrgID, ActorID: actorID, Action: "event." + ev.Type, TargetRef: ev.ID, Metadata: map[string]any{"event": ev}}); err != nil {
+		logger.LogError(ctx, "events", "append_audit", err, "event_type", ev.Type, "org_id", ev.OrgID)
+	}
+	logger.LogBusinessEvent(ctx, ev.Type, "event_id", ev.ID, "org_id", ev.OrgID, "synthetic", ev.Synthetic)
+
+	hooks, err := st.Webhooks().List(ctx, ev.OrgID)
+	if err != nil {
+		logger.LogError(ctx, "webhooks", "list_webhooks", err, "event_id", ev.ID, "org_id", ev.OrgID)
+		return ev
+	}
+	for _, hook := range hooks {
+		if !hook.Active || !hook.Subscribed(ev.Type) {
+			continue
+		}
+		d, err := NewDelivery(hook, ev)
+		if err == nil {
+			_, err = st.Webhooks().CreateDelivery(ctx, d)
+		}
+		if err != nil {
+			logger.LogError(ctx, "webhooks", "queue_delivery", err, "event_id", ev.ID, "webhook_id", hook.ID)
+		}
+	}
+	return ev
+}
+
+// NewDelivery returns a pending delivery of ev to hook, due immediately.
+func NewDelivery(hook store.Webhook, ev Event) (store.WebhookDelivery, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return store.WebhookDelivery{}, err
+	}
+	return store.WebhookDelivery{
+		ID:            newID(),
+		OrgID:         hook.OrgID,
+		WebhookID:     hook.ID,
+		EventID:       ev.ID,
+		EventType:     ev.Type,
+		Payload:       string(payload),
+		Status:        store.WebhookDeliveryPending,
+		NextAttemptAt: time.Now().UTC(),
+	}, nil
+}
+
+// newID generates a UUID, matching the Postgres uuid columns.
+func newID() string {
+	return uuid.New().String()
+}
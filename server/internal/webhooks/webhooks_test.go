@@ -0,0 +1,156 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/egress"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1767225600, 0)
+	body := []byte(`{"type":"deck.created"}`)
+	sig := Sign("whsec_test", now, body)
+	assert.Regexp(t, `^t=1767225600,v1=[0-9a-f]{64}$`, sig)
+
+	assert.True(t, Verify("whsec_test", sig, body, now.Add(time.Minute), 5*time.Minute))
+	assert.False(t, Verify("whsec_other", sig, body, now, 5*time.Minute), "wrong secret")
+	assert.False(t, Verify("whsec_test", sig, []byte(`{}`), now, 5*time.Minute), "tampered body")
+	assert.False(t, Verify("whsec_test", sig, body, now.Add(time.Hour), 5*time.Minute), "stale signature")
+	assert.False(t, Verify("whsec_test", "v1=abc", body, now, 5*time.Minute), "missing timestamp")
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Minute, Backoff(1))
+	assert.Equal(t, 2*time.Minute, Backoff(2))
+	assert.Equal(t, 64*time.Minute, Backoff(7))
+}
+
+func TestEmit_QueuesSubscribedWebhooks(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+	for _, h := range []store.Webhook{
+		{ID: "hook-decks", OrgID: "org-1", URL: "https://a.example", Events: []string{EventDeckCreated}, Active: true},
+		{ID: "hook-exports", OrgID: "org-1", URL: "https://b.example", Events: []string{EventExportCompleted}, Active: true},
+		{ID: "hook-paused", OrgID: "org-1", URL: "https://c.example", Events: []string{EventDeckCreated}, Active: false},
+		{ID: "hook-other-org", OrgID: "org-2", URL: "https://d.example", Events: []string{EventDeckCreated}, Active: true},
+	} {
+		_, err := st.Webhooks().Create(ctx, h)
+		require.NoError(t, err)
+	}
+
+	ev := Emit(ctx, st, "user-1", Event{Type: EventDeckCreated, OrgID: "org-1", Data: map[string]any{"deckId": "deck-1"}})
+	assert.NotEmpty(t, ev.ID)
+
+	due, err := st.Webhooks().ListDueDeliveries(ctx, time.Now().UTC(), 0)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "hook-decks", due[0].WebhookID)
+	assert.Equal(t, ev.ID, due[0].EventID)
+	assert.Contains(t, due[0].Payload, `"deckId":"deck-1"`)
+}
+
+func TestDispatcher_RetriesWithBackoffThenSucceeds(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+
+	var calls atomic.Int32
+	var lastSig, lastBody string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lastSig, lastBody = r.Header.Get(SignatureHeader), string(body)
+		assert.Equal(t, EventExportCompleted, r.Header.Get(EventHeader))
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	hook, err := st.Webhooks().Create(ctx, store.Webhook{ID: "hook-1", OrgID: "org-1", URL: srv.URL, Events: []string{EventExportCompleted}, Secret: "whsec_test", Active: true})
+	require.NoError(t, err)
+	Emit(ctx, st, "user-1", Event{Type: EventExportCompleted, OrgID: "org-1"})
+
+	d := NewDispatcher(st)
+	d.Client = srv.Client()
+	assert.Equal(t, 1, d.RunOnce(ctx))
+
+	log, err := st.Webhooks().ListDeliveries(ctx, "org-1", hook.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, log, 1)
+	del := log[0]
+	assert.Equal(t, store.WebhookDeliveryPending, del.Status)
+	assert.Equal(t, 1, del.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, del.ResponseStatus)
+	assert.WithinDuration(t, time.Now().Add(Backoff(1)), del.NextAttemptAt, 5*time.Second)
+	assert.Equal(t, 0, d.RunOnce(ctx), "retry is not due yet")
+
+	del.NextAttemptAt = time.Now().UTC().Add(-time.Second)
+	_, err = st.Webhooks().UpdateDelivery(ctx, del)
+	require.NoError(t, err)
+	assert.Equal(t, 1, d.RunOnce(ctx))
+
+	log, err = st.Webhooks().ListDeliveries(ctx, "org-1", hook.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, store.WebhookDeliverySucceeded, log[0].Status)
+	assert.Equal(t, 2, log[0].Attempts)
+	assert.NotNil(t, log[0].DeliveredAt)
+	assert.True(t, Verify("whsec_test", lastSig, []byte(lastBody), time.Now(), time.Minute))
+}
+
+func TestDispatcher_RefusesPrivateEndpoints(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+	var calls atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	hook, err := st.Webhooks().Create(ctx, store.Webhook{ID: "hook-1", OrgID: "org-1", URL: srv.URL, Events: []string{EventJobCompleted}, Secret: "s", Active: true})
+	require.NoError(t, err)
+	del, err := NewDelivery(hook, Event{ID: "evt-1", Type: EventJobCompleted, OrgID: "org-1"})
+	require.NoError(t, err)
+
+	del = NewDispatcher(st).Send(ctx, hook, del)
+	assert.NotEqual(t, store.WebhookDeliverySucceeded, del.Status)
+	assert.Contains(t, del.Error, egress.ErrBlocked.Error())
+	assert.Zero(t, calls.Load(), "the loopback endpoint is never reached")
+}
+
+func TestDispatcher_FailsAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	hook, err := st.Webhooks().Create(ctx, store.Webhook{ID: "hook-1", OrgID: "org-1", URL: srv.URL, Events: []string{EventJobCompleted}, Secret: "s", Active: true})
+	require.NoError(t, err)
+	del, err := NewDelivery(hook, Event{ID: "evt-1", Type: EventJobCompleted, OrgID: "org-1"})
+	require.NoError(t, err)
+	del.Attempts = MaxAttempts - 1
+	_, err = st.Webhooks().CreateDelivery(ctx, del)
+	require.NoError(t, err)
+
+	d := NewDispatcher(st)
+	d.Client = srv.Client()
+	d.RunOnce(ctx)
+
+	log, err := st.Webhooks().ListDeliveries(ctx, "org-1", hook.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, store.WebhookDeliveryFailed, log[0].Status)
+	assert.Equal(t, MaxAttempts, log[0].Attempts)
+	assert.Equal(t, "endpoint responded 500", log[0].Error)
+}
@@ -0,0 +1,31 @@
+package worker
+
+import (
+	"context"
+
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// writeAltText has the AI describe the images in s that have no alt text
+// and aren't decorative. Bind jobs keep what it writes in the new deck,
+// where editors can correct it; exports only use it for the file. Alt text
+// is best effort, so failures leave the images as they were.
+func (w *Worker) writeAltText(ctx context.Context, job store.Job, s *spec.TemplateSpec) {
+	writer, ok := w.aiService.(ai.AltTextWriter)
+	if !ok || len(spec.MissingAltText(*s)) == 0 {
+		return
+	}
+	var userID string
+	if job.Metadata != nil {
+		userID = (*job.Metadata)["userId"]
+	}
+	n, err := writer.WriteAltText(ctx, job.OrgID, userID, s)
+	if err != nil {
+		logger.Jobs().Warn("alt_text_failed", "job_id", job.ID, "error", err)
+		return
+	}
+	logger.Jobs().Info("alt_text_written", "job_id", job.ID, "count", n)
+}
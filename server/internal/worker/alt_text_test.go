@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+// pictureRenderer renders every deck as one slide with a picture on its
+// right half.
+type pictureRenderer struct{ countingRenderer }
+
+func (pictureRenderer) RenderPPTXBytes(ctx context.Context, spec interface{}) ([]byte, error) {
+	var pptx bytes.Buffer
+	zw := zip.NewWriter(&pptx)
+	for name, content := range map[string]string{
+		"ppt/presentation.xml":            `<p:presentation xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><p:sldIdLst><p:sldId id="256" r:id="rId2"/></p:sldIdLst><p:sldSz cx="12192000" cy="6858000"/></p:presentation>`,
+		"ppt/_rels/presentation.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId2" Type="slide" Target="slides/slide1.xml"/></Relationships>`,
+		"ppt/slides/slide1.xml":           `<p:sld xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"><p:cSld><p:spTree><p:pic><p:nvPicPr><p:cNvPr id="2" name="Picture 1"/><p:cNvPicPr/><p:nvPr/></p:nvPicPr><p:spPr><a:xfrm><a:off x="6705600" y="1371600"/><a:ext cx="4876800" cy="4114800"/></a:xfrm></p:spPr></p:pic></p:spTree></p:cSld></p:sld>`,
+	} {
+		f, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			return nil, err
+		}
+	}
+	err := zw.Close()
+	return pptx.Bytes(), err
+}
+
+func TestWorker_DeckExport_WritesAltText(t *testing.T) {
+	memStore := memory.New()
+	storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	w := New(memStore, &pictureRenderer{}, storage, ai.NewAIServiceWithOptions(memStore, ai.Options{Mock: true}))
+	w.SpoolDir = t.TempDir()
+
+	ctx := context.Background()
+	orgID := "org-alt"
+	_, err := memStore.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-alt", Deck: "deck-alt", OrgID: orgID, VersionNo: 1,
+		SpecJSON: json.RawMessage(`{"layouts":[{"name":"Team","placeholders":[
+			{"id":"title","type":"text","content":"Meet the team","geometry":{"x":0.05,"y":0.05,"w":0.9,"h":0.12}},
+			{"id":"photo","type":"image","geometry":{"x":0.55,"y":0.2,"w":0.4,"h":0.6},"image":{"path":"org-alt/team.jpg"}}]}]}`)})
+	require.NoError(t, err)
+
+	job := store.Job{ID: "job-alt", OrgID: orgID, Type: store.JobExport, Status: store.JobQueued, InputRef: "dv-alt"}
+	_, err = memStore.Jobs().Enqueue(ctx, job)
+	require.NoError(t, err)
+	require.NoError(t, w.processJob(ctx, job))
+	got, _, _ := memStore.Jobs().Get(ctx, orgID, job.ID)
+	require.Equal(t, store.JobDone, got.Status, got.Error)
+
+	asset, ok, err := memStore.Assets().Get(ctx, orgID, got.OutputRef)
+	require.NoError(t, err)
+	require.True(t, ok)
+	data, err := storage.Download(ctx, asset.Path)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	f, err := zr.Open("ppt/slides/slide1.xml")
+	require.NoError(t, err)
+	slide, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Contains(t, string(slide), `<p:cNvPr id="2" name="Picture 1" descr="Illustration for Meet the team"/>`)
+
+	// The export described the image without changing the stored version.
+	dv, _, err := memStore.Decks().GetDeckVersion(ctx, orgID, "dv-alt")
+	require.NoError(t, err)
+	assert.NotContains(t, string(dv.SpecJSON), "Illustration")
+}
@@ -0,0 +1,127 @@
+package worker
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// processBatchMergeJob renders the job's deck version once per row, with the
+// row's data filling its variables, into one ZIP along with a report.csv of
+// each row's outcome. Rows that fail are marked in the job's rows and left
+// out of the archive; the job itself only fails if no row could be rendered.
+func (w *Worker) processBatchMergeJob(ctx context.Context, job store.Job) (string, error) {
+	if job.Metadata == nil {
+		return "", fmt.Errorf("batch merge job has no rows")
+	}
+	var rows []store.BatchMergeRow
+	if err := json.Unmarshal([]byte((*job.Metadata)["rows"]), &rows); err != nil {
+		return "", fmt.Errorf("invalid batch merge rows: %w", err)
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("batch merge job has no rows")
+	}
+	dv, ok, err := w.store.Decks().GetDeckVersion(ctx, job.OrgID, job.InputRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to load deck version: %w", err)
+	}
+	if !ok {
+		return "", fmt.Errorf("deck version not found")
+	}
+	specBytes, err := normalize.JSON(dv.SpecJSON)
+	if err != nil {
+		return "", fmt.Errorf("failed to normalize deck spec: %w", err)
+	}
+
+	data, err := w.renderWithSpool(ctx, job, func() ([]byte, error) {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		used := map[string]int{}
+		renderer := w.rendererFor(job)
+		rendered := 0
+
+		for i := range rows {
+			row := &rows[i]
+			w.updateProgress(ctx, &job, fmt.Sprintf("Rendering %s (%d of %d)", row.Name, i+1, len(rows)), 10+80*i/len(rows))
+
+			pptx, err := assets.MergeRenderer(renderer, row.Data).RenderPPTXBytes(ctx, json.RawMessage(specBytes))
+			if err == nil {
+				err = writeZipEntry(zw, zipEntryName(row.Name, used), pptx)
+			}
+			if err != nil {
+				logger.Jobs().Warn("batch_merge_row_failed", "job_id", job.ID, "row", row.Row, "error", err)
+				row.Status, row.Error = store.JobFailed, err.Error()
+				continue
+			}
+			row.Status, row.Error = store.JobDone, ""
+			rendered++
+		}
+		if err := writeZipEntry(zw, "report.csv", batchMergeReport(rows)); err != nil {
+			return nil, fmt.Errorf("failed to write report: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to finalize archive: %w", err)
+		}
+
+		rowsJSON, _ := json.Marshal(rows)
+		(*job.Metadata)["rows"] = string(rowsJSON)
+		if _, err := w.saveJob(ctx, job); err != nil {
+			logger.Jobs().Warn("batch_merge_rows_update_failed", "job_id", job.ID, "error", err)
+		}
+		if rendered == 0 {
+			return nil, fmt.Errorf("no rows could be rendered")
+		}
+		return buf.Bytes(), nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	w.updateProgress(ctx, &job, "Uploading archive", 90)
+
+	assetID := newID("asset")
+	metadata, err := w.uploadWithRetry(ctx, job, assetID+".zip", data, "application/zip")
+	if err != nil {
+		return "", fmt.Errorf("failed to upload batch merge archive: %w", err)
+	}
+	w.clearSpool(job)
+
+	asset := store.Asset{
+		ID:          assetID,
+		OrgID:       job.OrgID,
+		Type:        store.AssetZIP,
+		Path:        metadata.Key,
+		Mime:        metadata.ContentType,
+		SizeBytes:   int64(len(data)),
+		SourceJobID: job.ID,
+		Region:      metadata.Region,
+		SHA256:      assets.Checksum(data),
+		Filename:    safeFileName((*job.Metadata)["name"]) + " (merged).zip",
+	}
+	if _, err := w.store.Assets().Create(ctx, asset); err != nil {
+		return "", fmt.Errorf("failed to create batch merge asset record: %w", err)
+	}
+	return assetID, nil
+}
+
+// batchMergeReport is the report.csv of a batch merge: each row's number,
+// file name, status and error.
+func batchMergeReport(rows []store.BatchMergeRow) []byte {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	_ = cw.Write([]string{"row", "name", "status", "error"})
+	for _, row := range rows {
+		_ = cw.Write([]string{strconv.Itoa(row.Row), row.Name, string(row.Status), row.Error})
+	}
+	cw.Flush()
+	return buf.Bytes()
+}
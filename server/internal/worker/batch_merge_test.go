@@ -0,0 +1,89 @@
+package worker
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+// echoRenderer renders a deck as its spec JSON, and fails decks that
+// mention FAIL.
+type echoRenderer struct{ countingRenderer }
+
+func (e *echoRenderer) RenderPPTXBytes(ctx context.Context, spec interface{}) ([]byte, error) {
+	b, err := normalize.JSON(spec)
+	if err == nil && bytes.Contains(b, []byte("FAIL")) {
+		err = errors.New("render failed")
+	}
+	return b, err
+}
+
+func TestWorker_BatchMerge_RendersRowPerDeckWithReport(t *testing.T) {
+	memStore := memory.New()
+	storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	w := New(memStore, &echoRenderer{}, storage, ai.NewAIService(memStore))
+	w.SpoolDir = t.TempDir()
+
+	ctx := context.Background()
+	orgID := "org-merge"
+	_, err := memStore.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-merge", Deck: "deck-merge", OrgID: orgID, VersionNo: 1, SpecJSON: json.RawMessage(`{"layouts":[{"name":"Proposal for {{customer}}"}]}`)})
+	require.NoError(t, err)
+
+	rows, _ := json.Marshal([]store.BatchMergeRow{
+		{Row: 1, Name: "Acme", Data: map[string]string{"customer": "Acme"}, Status: store.JobQueued},
+		{Row: 2, Name: "Broken", Data: map[string]string{"customer": "FAIL"}, Status: store.JobQueued},
+		{Row: 3, Name: "Globex", Data: map[string]string{"customer": "Globex"}, Status: store.JobQueued},
+	})
+	metadata := store.JSONMap{"rows": string(rows), "name": "Proposal"}
+	job := store.Job{ID: "job-merge", OrgID: orgID, Type: store.JobBatchMerge, Status: store.JobQueued, InputRef: "dv-merge", Metadata: &metadata}
+	_, err = memStore.Jobs().Enqueue(ctx, job)
+	require.NoError(t, err)
+
+	require.NoError(t, w.processJob(ctx, job))
+	got, _, _ := memStore.Jobs().Get(ctx, orgID, job.ID)
+	require.Equal(t, store.JobDone, got.Status, got.Error)
+
+	var report []store.BatchMergeRow
+	require.NoError(t, json.Unmarshal([]byte((*got.Metadata)["rows"]), &report))
+	require.Len(t, report, 3)
+	assert.Equal(t, store.JobDone, report[0].Status)
+	assert.Equal(t, store.JobFailed, report[1].Status)
+	assert.Equal(t, "render failed", report[1].Error)
+	exports, err := memStore.Metering().SumForTarget(ctx, orgID, "export", "deck-merge")
+	require.NoError(t, err)
+	assert.Equal(t, 2, exports, "only the rows that rendered are metered")
+
+	asset, ok, err := memStore.Assets().Get(ctx, orgID, got.OutputRef)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "Proposal (merged).zip", asset.Filename)
+	data, err := storage.Download(ctx, asset.Path)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[f.Name] = string(b)
+	}
+	assert.Len(t, files, 3)
+	assert.JSONEq(t, `{"layouts":[{"name":"Proposal for Acme"}],"specVersion":2}`, files["Acme.pptx"])
+	assert.JSONEq(t, `{"layouts":[{"name":"Proposal for Globex"}],"specVersion":2}`, files["Globex.pptx"])
+	assert.Equal(t, "row,name,status,error\n1,Acme,Done,\n2,Broken,Failed,render failed\n3,Globex,Done,\n", files["report.csv"])
+}
@@ -0,0 +1,144 @@
+package worker
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// processBulkExportJob renders every deck listed in the job into one ZIP.
+// Decks that fail are marked in the job's items and left out of the archive;
+// the job itself only fails if no deck could be rendered.
+func (w *Worker) processBulkExportJob(ctx context.Context, job store.Job) (string, error) {
+	if job.Metadata == nil {
+		return "", fmt.Errorf("bulk export job has no items")
+	}
+	var items []store.BulkExportItem
+	if err := json.Unmarshal([]byte((*job.Metadata)["items"]), &items); err != nil {
+		return "", fmt.Errorf("invalid bulk export items: %w", err)
+	}
+	if len(items) == 0 {
+		return "", fmt.Errorf("bulk export job has no items")
+	}
+
+	data, err := w.renderWithSpool(ctx, job, func() ([]byte, error) {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		used := map[string]int{}
+		rendered := 0
+
+		for i := range items {
+			item := &items[i]
+			w.updateProgress(ctx, &job, fmt.Sprintf("Rendering %s (%d of %d)", item.Name, i+1, len(items)), 10+80*i/len(items))
+
+			pptx, err := w.renderDeckVersion(ctx, job, item.VersionID)
+			if err == nil {
+				err = writeZipEntry(zw, zipEntryName(item.Name, used), pptx)
+			}
+			if err != nil {
+				logger.Jobs().Warn("bulk_export_item_failed", "job_id", job.ID, "deck_id", item.DeckID, "error", err)
+				item.Status, item.Error = store.JobFailed, err.Error()
+				continue
+			}
+			item.Status, item.Error = store.JobDone, ""
+			rendered++
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to finalize archive: %w", err)
+		}
+
+		itemsJSON, _ := json.Marshal(items)
+		(*job.Metadata)["items"] = string(itemsJSON)
+		if _, err := w.saveJob(ctx, job); err != nil {
+			logger.Jobs().Warn("bulk_export_items_update_failed", "job_id", job.ID, "error", err)
+		}
+		if rendered == 0 {
+			return nil, fmt.Errorf("no decks could be rendered")
+		}
+		return buf.Bytes(), nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	w.updateProgress(ctx, &job, "Uploading archive", 90)
+
+	assetID := newID("asset")
+	metadata, err := w.uploadWithRetry(ctx, job, assetID+".zip", data, "application/zip")
+	if err != nil {
+		return "", fmt.Errorf("failed to upload bulk export archive: %w", err)
+	}
+	w.clearSpool(job)
+
+	asset := store.Asset{
+		ID:          assetID,
+		OrgID:       job.OrgID,
+		Type:        store.AssetZIP,
+		Path:        metadata.Key,
+		Mime:        metadata.ContentType,
+		SizeBytes:   int64(len(data)),
+		SourceJobID: job.ID,
+		Region:      metadata.Region,
+		SHA256:      assets.Checksum(data),
+	}
+	if _, err := w.store.Assets().Create(ctx, asset); err != nil {
+		return "", fmt.Errorf("failed to create bulk export asset record: %w", err)
+	}
+	return assetID, nil
+}
+
+func (w *Worker) renderDeckVersion(ctx context.Context, job store.Job, versionID string) ([]byte, error) {
+	dv, ok, err := w.store.Decks().GetDeckVersion(ctx, job.OrgID, versionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load deck version: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("deck version not found")
+	}
+	specBytes, err := normalize.JSON(dv.SpecJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to normalize deck spec: %w", err)
+	}
+	return w.rendererFor(job).RenderPPTXBytes(ctx, json.RawMessage(specBytes))
+}
+
+// safeFileName replaces characters that aren't allowed in archive paths.
+func safeFileName(name string) string {
+	base := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if base == "" {
+		return "untitled"
+	}
+	return base
+}
+
+// zipEntryName turns a deck name into a safe, unique archive file name.
+func zipEntryName(name string, used map[string]int) string {
+	base := safeFileName(name)
+	used[base]++
+	if n := used[base]; n > 1 {
+		return fmt.Sprintf("%s (%d).pptx", base, n)
+	}
+	return base + ".pptx"
+}
+
+func writeZipEntry(zw *zip.Writer, name string, data []byte) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
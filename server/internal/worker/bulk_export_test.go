@@ -0,0 +1,152 @@
+package worker
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+func TestWorker_BulkExport_ZipsDecksWithPerItemStatus(t *testing.T) {
+	memStore := memory.New()
+	renderer := &countingRenderer{}
+	storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	w := New(memStore, renderer, storage, ai.NewAIService(memStore))
+	w.SpoolDir = t.TempDir()
+
+	ctx := context.Background()
+	orgID := "org-bulk"
+	for _, id := range []string{"dv-a", "dv-b"} {
+		_, err := memStore.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: id, OrgID: orgID, VersionNo: 1, SpecJSON: json.RawMessage(`{"layouts":[]}`)})
+		require.NoError(t, err)
+	}
+	items, _ := json.Marshal([]store.BulkExportItem{
+		{DeckID: "deck-a", VersionID: "dv-a", Name: "Sales/Q3", Status: store.JobQueued},
+		{DeckID: "deck-b", VersionID: "dv-b", Name: "Sales/Q3", Status: store.JobQueued},
+		{DeckID: "deck-c", VersionID: "dv-gone", Name: "Gone", Status: store.JobQueued},
+	})
+	metadata := store.JSONMap{"items": string(items), "userId": "user-1"}
+	job := store.Job{ID: "job-bulk", OrgID: orgID, Type: store.JobBulkExport, Status: store.JobQueued, Metadata: &metadata}
+	_, err := memStore.Jobs().Enqueue(ctx, job)
+	require.NoError(t, err)
+
+	require.NoError(t, w.processJob(ctx, job))
+	got, _, _ := memStore.Jobs().Get(ctx, orgID, job.ID)
+	require.Equal(t, store.JobDone, got.Status, got.Error)
+
+	var result []store.BulkExportItem
+	require.NoError(t, json.Unmarshal([]byte((*got.Metadata)["items"]), &result))
+	require.Len(t, result, 3)
+	assert.Equal(t, store.JobDone, result[0].Status)
+	assert.Equal(t, store.JobDone, result[1].Status)
+	assert.Equal(t, store.JobFailed, result[2].Status)
+	assert.Contains(t, result[2].Error, "not found")
+
+	exports, err := memStore.Metering().SumByTarget(ctx, orgID, "export")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"deck-a": 1, "deck-b": 1}, exports, "only the decks that rendered are metered")
+
+	asset, ok, err := memStore.Assets().Get(ctx, orgID, got.OutputRef)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, store.AssetZIP, asset.Type)
+
+	data, err := storage.Download(ctx, asset.Path)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"Sales_Q3.pptx", "Sales_Q3 (2).pptx"}, names)
+}
+
+func TestWorker_BulkExport_FailsWhenNothingRenders(t *testing.T) {
+	memStore := memory.New()
+	w := New(memStore, &countingRenderer{}, nil, ai.NewAIService(memStore))
+	w.SpoolDir = t.TempDir()
+
+	items, _ := json.Marshal([]store.BulkExportItem{{DeckID: "deck-x", VersionID: "missing", Name: "X"}})
+	metadata := store.JSONMap{"items": string(items)}
+	job := store.Job{ID: "job-bulk-empty", OrgID: "org", Type: store.JobBulkExport, Status: store.JobQueued, Metadata: &metadata, MaxRetries: 1}
+
+	_, err := w.processBulkExportJob(context.Background(), job)
+	assert.ErrorContains(t, err, "no decks could be rendered")
+}
+
+// bundleRenderer returns a real PNG per slide so the PDF step can decode it.
+type bundleRenderer struct{ countingRenderer }
+
+func (b *bundleRenderer) GenerateSlideThumbnails(ctx context.Context, spec interface{}) ([][]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 16, 9))); err != nil {
+		return nil, err
+	}
+	return [][]byte{buf.Bytes(), buf.Bytes()}, nil
+}
+
+func TestWorker_BundleExport_WritesArchiveWithManifest(t *testing.T) {
+	memStore := memory.New()
+	storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	w := New(memStore, &bundleRenderer{}, storage, ai.NewAIService(memStore))
+	w.SpoolDir = t.TempDir()
+
+	ctx := context.Background()
+	orgID := "org-bundle"
+	_, err := memStore.Decks().CreateDeck(ctx, store.Deck{ID: "deck-1", OrgID: orgID, Name: "Q3 Review"})
+	require.NoError(t, err)
+	_, err = memStore.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-1", Deck: "deck-1", OrgID: orgID, VersionNo: 4, SpecJSON: json.RawMessage(`{"layouts":[{"name":"Title"},{"name":"Agenda"}]}`)})
+	require.NoError(t, err)
+
+	metadata := store.JSONMap{"format": store.ExportFormatBundle}
+	job := store.Job{ID: "job-bundle", OrgID: orgID, Type: store.JobExport, Status: store.JobQueued, InputRef: "dv-1", Metadata: &metadata}
+	_, err = memStore.Jobs().Enqueue(ctx, job)
+	require.NoError(t, err)
+
+	require.NoError(t, w.processJob(ctx, job))
+	got, _, _ := memStore.Jobs().Get(ctx, orgID, job.ID)
+	require.Equal(t, store.JobDone, got.Status, got.Error)
+
+	asset, ok, err := memStore.Assets().Get(ctx, orgID, got.OutputRef)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, store.AssetZIP, asset.Type)
+
+	data, err := storage.Download(ctx, asset.Path)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[f.Name] = b
+	}
+	assert.Equal(t, "rendered-pptx", string(files["Q3 Review.pptx"]))
+	assert.True(t, bytes.HasPrefix(files["Q3 Review.pdf"], []byte("%PDF-")))
+	assert.Contains(t, files, "slides/slide-01.png")
+	assert.Contains(t, files, "slides/slide-02.png")
+
+	var manifest bundleManifest
+	require.NoError(t, json.Unmarshal(files["manifest.json"], &manifest))
+	assert.Equal(t, "deck", manifest.Kind)
+	assert.Equal(t, "deck-1", manifest.SourceID)
+	assert.Equal(t, 4, manifest.VersionNo)
+	require.Len(t, manifest.Slides, 2)
+	assert.Equal(t, "Agenda", manifest.Slides[1].Name)
+}
@@ -0,0 +1,164 @@
+package worker
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+type bundleSlide struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+// bundleManifest is written to manifest.json so recipients can tell what the
+// archive holds without opening every file.
+type bundleManifest struct {
+	Name        string        `json:"name"`
+	Kind        string        `json:"kind"`
+	SourceID    string        `json:"sourceId"`
+	VersionID   string        `json:"versionId"`
+	VersionNo   int           `json:"versionNo"`
+	GeneratedAt time.Time     `json:"generatedAt"`
+	PPTX        string        `json:"pptx"`
+	PDF         string        `json:"pdf"`
+	Slides      []bundleSlide `json:"slides"`
+}
+
+// loadExportVersion fills in manifest the deck or template version job
+// exports, and returns that version's normalized spec.
+func (w *Worker) loadExportVersion(ctx context.Context, job store.Job, manifest *bundleManifest) ([]byte, error) {
+	var specJSON json.RawMessage
+	if dv, ok, err := w.store.Decks().GetDeckVersion(ctx, job.OrgID, job.InputRef); err == nil && ok {
+		manifest.Kind, manifest.SourceID, manifest.VersionNo = "deck", dv.Deck, dv.VersionNo
+		if d, ok, err := w.store.Decks().GetDeck(ctx, job.OrgID, dv.Deck); err == nil && ok {
+			manifest.Name = d.Name
+		}
+		specJSON = dv.SpecJSON
+	} else {
+		tv, ok, err := w.store.Templates().GetVersion(ctx, job.OrgID, job.InputRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get template version: %w", err)
+		}
+		if !ok {
+			return nil, fmt.Errorf("version not found")
+		}
+		manifest.Kind, manifest.SourceID, manifest.VersionNo = "template", tv.Template, tv.VersionNo
+		if t, ok, err := w.store.Templates().GetTemplate(ctx, job.OrgID, tv.Template); err == nil && ok {
+			manifest.Name = t.Name
+		}
+		specJSON = tv.SpecJSON
+	}
+
+	specBytes, err := normalize.JSON(specJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to normalize spec: %w", err)
+	}
+	return specBytes, nil
+}
+
+// processBundleExportJob exports a deck or template version as a ZIP with the
+// PPTX, a PDF built from the slide images, one PNG per slide and a manifest.
+func (w *Worker) processBundleExportJob(ctx context.Context, job store.Job) (string, error) {
+	manifest := bundleManifest{VersionID: job.InputRef, GeneratedAt: time.Now().UTC()}
+	specBytes, err := w.loadExportVersion(ctx, job, &manifest)
+	if err != nil {
+		return "", err
+	}
+	var layouts struct {
+		Layouts []struct {
+			Name string `json:"name"`
+		} `json:"layouts"`
+	}
+	_ = json.Unmarshal(specBytes, &layouts)
+
+	data, err := w.renderWithSpool(ctx, job, func() ([]byte, error) {
+		w.updateProgress(ctx, &job, "Rendering PowerPoint", 20)
+		renderer := w.rendererFor(job)
+		pptx, err := renderer.RenderPPTXBytes(ctx, json.RawMessage(specBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to render PPTX: %w", err)
+		}
+
+		w.updateProgress(ctx, &job, "Rendering slide images", 45)
+		thumbs, err := renderer.GenerateSlideThumbnails(ctx, json.RawMessage(specBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to render slide images: %w", err)
+		}
+
+		w.updateProgress(ctx, &job, "Building PDF", 65)
+		pdf, err := assets.ImagesToPDF(thumbs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build PDF: %w", err)
+		}
+
+		base := safeFileName(manifest.Name)
+		manifest.PPTX, manifest.PDF = base+".pptx", base+".pdf"
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		if err := writeZipEntry(zw, manifest.PPTX, pptx); err != nil {
+			return nil, err
+		}
+		if err := writeZipEntry(zw, manifest.PDF, pdf); err != nil {
+			return nil, err
+		}
+		for i, png := range thumbs {
+			slide := bundleSlide{Index: i, Image: fmt.Sprintf("slides/slide-%02d.png", i+1)}
+			if i < len(layouts.Layouts) {
+				slide.Name = layouts.Layouts[i].Name
+			}
+			if err := writeZipEntry(zw, slide.Image, png); err != nil {
+				return nil, err
+			}
+			manifest.Slides = append(manifest.Slides, slide)
+		}
+		manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := writeZipEntry(zw, "manifest.json", manifestJSON); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to finalize archive: %w", err)
+		}
+		return buf.Bytes(), nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	w.updateProgress(ctx, &job, "Uploading bundle", 90)
+
+	assetID := newID("asset")
+	metadata, err := w.uploadWithRetry(ctx, job, assetID+".zip", data, "application/zip")
+	if err != nil {
+		return "", fmt.Errorf("failed to upload bundle: %w", err)
+	}
+	w.clearSpool(job)
+
+	asset := store.Asset{
+		ID:          assetID,
+		OrgID:       job.OrgID,
+		Type:        store.AssetZIP,
+		Path:        metadata.Key,
+		Mime:        metadata.ContentType,
+		SizeBytes:   int64(len(data)),
+		SourceJobID: job.ID,
+		Region:      metadata.Region,
+		SHA256:      assets.Checksum(data),
+		Filename:    jobFilename(job),
+	}
+	if _, err := w.store.Assets().Create(ctx, asset); err != nil {
+		return "", fmt.Errorf("failed to create bundle asset record: %w", err)
+	}
+	return assetID, nil
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// addReviewComments adds the deck version's open review threads to its
+// rendered PPTX as native comments, when the export asked for them.
+// Resolved threads are left out, since offline reviewers have nothing left
+// to act on there.
+func (w *Worker) addReviewComments(ctx context.Context, job store.Job, dv store.DeckVersion, pptx []byte) ([]byte, error) {
+	if job.Metadata == nil || (*job.Metadata)["comments"] != "true" {
+		return pptx, nil
+	}
+	comments, err := w.store.Comments().ListByDeckVersion(ctx, job.OrgID, dv.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list comments: %w", err)
+	}
+	replies := map[string][]store.Comment{}
+	for _, c := range comments {
+		if c.ParentID != nil {
+			replies[*c.ParentID] = append(replies[*c.ParentID], c)
+		}
+	}
+	names := map[string]string{}
+	author := func(userID string) string {
+		if name, ok := names[userID]; ok {
+			return name
+		}
+		name := "Reviewer"
+		if u, ok, err := w.store.Users().GetUser(ctx, userID); err == nil && ok {
+			if u.Name != "" {
+				name = u.Name
+			} else if u.Email != "" {
+				name = u.Email
+			}
+		}
+		names[userID] = name
+		return name
+	}
+	var out []assets.PPTXComment
+	for _, root := range comments {
+		if root.ParentID != nil || root.Resolved {
+			continue
+		}
+		for i, c := range append([]store.Comment{root}, replies[root.ID]...) {
+			pc := assets.PPTXComment{ID: c.ID, SlideIndex: root.SlideIndex, Author: author(c.AuthorID), Text: c.Body, Created: c.CreatedAt}
+			if i > 0 {
+				pc.ParentID = root.ID
+			}
+			out = append(out, pc)
+		}
+	}
+	if pptx, err = assets.AddComments(pptx, out); err != nil {
+		return nil, fmt.Errorf("add comments: %w", err)
+	}
+	return pptx, nil
+}
@@ -0,0 +1,101 @@
+package worker
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+// twoSlideRenderer renders every deck as the same two-slide PPTX.
+type twoSlideRenderer struct{ countingRenderer }
+
+func (twoSlideRenderer) RenderPPTXBytes(ctx context.Context, spec interface{}) ([]byte, error) {
+	var pptx bytes.Buffer
+	zw := zip.NewWriter(&pptx)
+	for name, content := range map[string]string{
+		"[Content_Types].xml":             `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="xml" ContentType="application/xml"/></Types>`,
+		"ppt/presentation.xml":            `<p:presentation xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><p:sldIdLst><p:sldId id="256" r:id="rId2"/><p:sldId id="257" r:id="rId3"/></p:sldIdLst></p:presentation>`,
+		"ppt/_rels/presentation.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId2" Type="slide" Target="slides/slide1.xml"/><Relationship Id="rId3" Type="slide" Target="slides/slide2.xml"/></Relationships>`,
+		"ppt/slides/slide1.xml":           `<p:sld/>`,
+		"ppt/slides/slide2.xml":           `<p:sld/>`,
+	} {
+		f, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			return nil, err
+		}
+	}
+	err := zw.Close()
+	return pptx.Bytes(), err
+}
+
+func TestWorker_DeckExport_AddsOpenReviewComments(t *testing.T) {
+	memStore := memory.New()
+	storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	w := New(memStore, &twoSlideRenderer{}, storage, ai.NewAIService(memStore))
+	w.SpoolDir = t.TempDir()
+
+	ctx := context.Background()
+	orgID := "org-review"
+	require.NoError(t, memStore.Users().CreateUser(ctx, &store.User{ID: "user-ana", Email: "ana@acme.com", Name: "Ana Lima"}))
+	_, err := memStore.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-review", Deck: "deck-review", OrgID: orgID, VersionNo: 1,
+		SpecJSON: json.RawMessage(`{"layouts":[{"name":"Intro"},{"name":"Plan"}]}`)})
+	require.NoError(t, err)
+	root := "cmt-root"
+	for _, c := range []store.Comment{
+		{ID: root, OrgID: orgID, DeckVersionID: "dv-review", SlideIndex: 1, AuthorID: "user-ana", Body: "Numbers & dates?"},
+		{ID: "cmt-reply", OrgID: orgID, DeckVersionID: "dv-review", SlideIndex: 1, ParentID: &root, AuthorID: "user-bo", Body: "Fixed in v2"},
+		{ID: "cmt-done", OrgID: orgID, DeckVersionID: "dv-review", SlideIndex: 0, AuthorID: "user-ana", Body: "Typo", Resolved: true},
+	} {
+		_, err := memStore.Comments().Create(ctx, c)
+		require.NoError(t, err)
+	}
+
+	metadata := store.JSONMap{"comments": "true"}
+	job := store.Job{ID: "job-review", OrgID: orgID, Type: store.JobExport, Status: store.JobQueued, InputRef: "dv-review", Metadata: &metadata}
+	_, err = memStore.Jobs().Enqueue(ctx, job)
+	require.NoError(t, err)
+	require.NoError(t, w.processJob(ctx, job))
+	got, _, _ := memStore.Jobs().Get(ctx, orgID, job.ID)
+	require.Equal(t, store.JobDone, got.Status, got.Error)
+
+	asset, ok, err := memStore.Assets().Get(ctx, orgID, got.OutputRef)
+	require.NoError(t, err)
+	require.True(t, ok)
+	data, err := storage.Download(ctx, asset.Path)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[f.Name] = string(b)
+	}
+
+	// Only the open thread, on the second slide, makes it into the file.
+	assert.Contains(t, files["ppt/commentAuthors.xml"], `name="Ana Lima" initials="AL"`)
+	assert.Contains(t, files["ppt/commentAuthors.xml"], `name="Reviewer"`)
+	assert.Contains(t, files["ppt/comments/comment1.xml"], "Numbers &amp; dates?")
+	assert.Contains(t, files["ppt/comments/comment1.xml"], `<p15:parentCm authorId="0" idx="1"/>`)
+	assert.NotContains(t, files["ppt/comments/comment1.xml"], "Typo")
+	assert.Contains(t, files["ppt/slides/_rels/slide2.xml.rels"], "../comments/comment1.xml")
+	assert.NotContains(t, files, "ppt/slides/_rels/slide1.xml.rels")
+	assert.Contains(t, files["[Content_Types].xml"], `PartName="/ppt/comments/comment1.xml"`)
+	assert.Contains(t, files["ppt/_rels/presentation.xml.rels"], "commentAuthors.xml")
+}
@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/alerts"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/queue"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/webhooks"
+)
+
+const defaultDeadLetterMaxAutoRetries = 3
+
+// onDeadLetter tells the org, through its webhooks and Slack, and the
+// platform operators, through alerts, that job has stopped retrying.
+func (w *Worker) onDeadLetter(ctx context.Context, job store.Job) {
+	// Timeouts are a common failure, and they leave ctx already expired.
+	ctx = context.WithoutCancel(ctx)
+	data := map[string]any{
+		"jobId":      job.ID,
+		"jobType":    string(job.Type),
+		"inputRef":   job.InputRef,
+		"error":      job.Error,
+		"retryCount": job.RetryCount,
+	}
+	webhooks.Emit(ctx, w.store, job.OrgID, webhooks.Event{Type: webhooks.EventJobDeadLettered, OrgID: job.OrgID, Data: data})
+	w.notifySlackFailure(ctx, job)
+
+	if w.Alerts == nil {
+		return
+	}
+	alertData := map[string]any{"orgId": job.OrgID, "autoRetries": job.AutoRetries}
+	for k, v := range data {
+		alertData[k] = v
+	}
+	err := w.Alerts.Send(ctx, alerts.Alert{
+		Event:   webhooks.EventJobDeadLettered,
+		Summary: fmt.Sprintf("%s job %s was dead-lettered", job.Type, job.ID),
+		Data:    alertData,
+	})
+	if err != nil {
+		logger.LogError(ctx, "worker", "send_dead_letter_alert", err, "job_id", job.ID)
+	}
+}
+
+func (w *Worker) deadLetterMaxAutoRetries() int {
+	if w.DeadLetterMaxAutoRetries <= 0 {
+		return defaultDeadLetterMaxAutoRetries
+	}
+	return w.DeadLetterMaxAutoRetries
+}
+
+// maybeSweepDeadLetter runs sweepDeadLetter when auto-retry is enabled and
+// DeadLetterRetryInterval has passed since the last sweep. It is only called
+// from the poll loop.
+func (w *Worker) maybeSweepDeadLetter(ctx context.Context, now time.Time) {
+	if w.DeadLetterRetryInterval <= 0 || now.Sub(w.lastSweep) < w.DeadLetterRetryInterval {
+		return
+	}
+	w.lastSweep = now
+	w.sweepDeadLetter(ctx, now)
+}
+
+// sweepDeadLetter requeues dead-lettered jobs that failed with a transient
+// error at least DeadLetterRetryInterval ago, up to the per-job cap. Jobs
+// that failed permanently would only fail again, so they are left for an
+// admin.
+func (w *Worker) sweepDeadLetter(ctx context.Context, now time.Time) {
+	jobs, err := w.store.Jobs().List(ctx, store.JobFilter{Status: store.JobDeadLetter})
+	if err != nil {
+		logger.LogError(ctx, "worker", "list_dead_letter_jobs", err)
+		return
+	}
+	maxAuto := w.deadLetterMaxAutoRetries()
+	cutoff := now.Add(-w.DeadLetterRetryInterval)
+	for _, job := range jobs {
+		if job.AutoRetries >= maxAuto || job.UpdatedAt.After(cutoff) {
+			continue
+		}
+		if queue.ClassifyError(errors.New(job.Error)) == queue.ErrorTypePermanent {
+			continue
+		}
+		job.AutoRetries++
+		job.RetryCount = 0
+		job.LastRetryAt = nil
+		w.requeue(ctx, job, fmt.Sprintf("auto-retried from dead letter (%d/%d)", job.AutoRetries, maxAuto))
+	}
+}
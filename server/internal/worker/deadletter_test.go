@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/alerts"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+	"github.com/ziyad/cms-ai/server/internal/webhooks"
+)
+
+func TestWorker_DeadLetterAlertsOperatorsAndOrg(t *testing.T) {
+	var alerted []alerts.Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a alerts.Alert
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&a))
+		alerted = append(alerted, a)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	memStore := memory.New()
+	_, err := memStore.Webhooks().Create(ctx, store.Webhook{ID: "hook-1", OrgID: "org-1", URL: "https://hooks.example", Events: []string{webhooks.EventJobDeadLettered}, Active: true})
+	require.NoError(t, err)
+	job, err := memStore.Jobs().Enqueue(ctx, store.Job{ID: "job-1", OrgID: "org-1", Type: store.JobExport, Status: store.JobRunning, MaxRetries: 1, RetryCount: 1})
+	require.NoError(t, err)
+
+	w := New(memStore, &countingRenderer{}, nil, nil)
+	w.Alerts = &alerts.Notifier{WebhookURL: srv.URL, HTTPClient: srv.Client()}
+	_ = w.handleJobFailure(ctx, job, errors.New("connection refused"))
+
+	require.Len(t, alerted, 1)
+	assert.Equal(t, webhooks.EventJobDeadLettered, alerted[0].Event)
+	assert.Equal(t, "org-1", alerted[0].Data["orgId"])
+	assert.Contains(t, alerted[0].Data["error"], "connection refused")
+
+	deliveries, err := memStore.Webhooks().ListDeliveries(ctx, "org-1", "hook-1", 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, webhooks.EventJobDeadLettered, deliveries[0].EventType)
+}
+
+func TestWorker_SweepDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	memStore := memory.New()
+	for _, j := range []store.Job{
+		{ID: "job-transient", Error: "upload: connection refused (Error type: transient, Final retry: 3/3)"},
+		{ID: "job-permanent", Error: "deck not found (Error type: permanent, Final retry: 0/3)"},
+		{ID: "job-capped", Error: "network unreachable", AutoRetries: 2},
+	} {
+		j.OrgID, j.Type, j.Status, j.RetryCount = "org-1", store.JobExport, store.JobDeadLetter, 3
+		_, err := memStore.Jobs().Enqueue(ctx, j)
+		require.NoError(t, err)
+	}
+	w := New(agedStore{memStore, time.Hour}, &countingRenderer{}, nil, nil)
+	w.DeadLetterMaxAutoRetries = 2
+
+	w.maybeSweepDeadLetter(ctx, time.Now())
+	got, _, _ := memStore.Jobs().Get(ctx, "org-1", "job-transient")
+	assert.Equal(t, store.JobDeadLetter, got.Status, "the sweep is off until an interval is set")
+
+	w.DeadLetterRetryInterval = 2 * time.Hour
+	w.maybeSweepDeadLetter(ctx, time.Now())
+	got, _, _ = memStore.Jobs().Get(ctx, "org-1", "job-transient")
+	assert.Equal(t, store.JobDeadLetter, got.Status, "jobs dead-lettered within the interval wait")
+
+	w.DeadLetterRetryInterval = 30 * time.Minute
+	w.lastSweep = time.Time{}
+	w.maybeSweepDeadLetter(ctx, time.Now())
+	got, _, _ = memStore.Jobs().Get(ctx, "org-1", "job-transient")
+	assert.Equal(t, store.JobQueued, got.Status)
+	assert.Equal(t, 1, got.AutoRetries)
+	assert.Equal(t, 0, got.RetryCount)
+	assert.Equal(t, "auto-retried from dead letter (1/2)", got.Error)
+
+	for _, id := range []string{"job-permanent", "job-capped"} {
+		got, _, _ = memStore.Jobs().Get(ctx, "org-1", id)
+		assert.Equal(t, store.JobDeadLetter, got.Status, id)
+	}
+}
@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// processImageExportJob exports a deck or template version as slide images,
+// for embedding in emails and posts: a ZIP of one PNG per slide, or an
+// animated GIF that flips through them. The job's "width" metadata sets
+// their size.
+func (w *Worker) processImageExportJob(ctx context.Context, job store.Job, format string) (string, error) {
+	var manifest bundleManifest
+	specBytes, err := w.loadExportVersion(ctx, job, &manifest)
+	if err != nil {
+		return "", err
+	}
+	width, _ := strconv.Atoi((*job.Metadata)["width"])
+	if width <= 0 {
+		width = assets.DefaultPNGWidth
+		if format == store.ExportFormatGIF {
+			width = assets.DefaultGIFWidth
+		}
+	}
+
+	data, err := w.renderWithSpool(ctx, job, func() ([]byte, error) {
+		w.updateProgress(ctx, &job, "Rendering slide images", 30)
+		thumbs, err := w.rendererFor(job).GenerateSlideThumbnails(ctx, json.RawMessage(specBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to render slide images: %w", err)
+		}
+		if format == store.ExportFormatGIF {
+			w.updateProgress(ctx, &job, "Building animated GIF", 65)
+			return assets.SlideImagesGIF(thumbs, width, assets.GIFFrameDelay)
+		}
+
+		w.updateProgress(ctx, &job, "Encoding slide images", 65)
+		pngs, err := assets.SlideImagesPNG(thumbs, width)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for i, png := range pngs {
+			if err := writeZipEntry(zw, fmt.Sprintf("slide-%02d.png", i+1), png); err != nil {
+				return nil, err
+			}
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to finalize archive: %w", err)
+		}
+		return buf.Bytes(), nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	w.updateProgress(ctx, &job, "Uploading images", 90)
+
+	assetID := newID("asset")
+	assetType, ext, mime := store.AssetZIP, ".zip", "application/zip"
+	if format == store.ExportFormatGIF {
+		assetType, ext, mime = store.AssetFile, ".gif", "image/gif"
+	}
+	metadata, err := w.uploadWithRetry(ctx, job, assetID+ext, data, mime)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload images: %w", err)
+	}
+	w.clearSpool(job)
+
+	asset := store.Asset{
+		ID:          assetID,
+		OrgID:       job.OrgID,
+		Type:        assetType,
+		Path:        metadata.Key,
+		Mime:        metadata.ContentType,
+		SizeBytes:   int64(len(data)),
+		SourceJobID: job.ID,
+		Region:      metadata.Region,
+		SHA256:      assets.Checksum(data),
+		Filename:    jobFilename(job),
+	}
+	if _, err := w.store.Assets().Create(ctx, asset); err != nil {
+		return "", fmt.Errorf("failed to create image export asset record: %w", err)
+	}
+	return assetID, nil
+}
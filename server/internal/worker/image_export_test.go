@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/gif"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+// thumbnailRenderer renders three 400x300 slide images.
+type thumbnailRenderer struct{ countingRenderer }
+
+func (thumbnailRenderer) GenerateSlideThumbnails(ctx context.Context, spec interface{}) ([][]byte, error) {
+	var out [][]byte
+	for range 3 {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 400, 300))); err != nil {
+			return nil, err
+		}
+		out = append(out, buf.Bytes())
+	}
+	return out, nil
+}
+
+func TestWorker_ImageExport(t *testing.T) {
+	memStore := memory.New()
+	storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	w := New(memStore, &thumbnailRenderer{}, storage, ai.NewAIService(memStore))
+	w.SpoolDir = t.TempDir()
+
+	ctx := context.Background()
+	orgID := "org-images"
+	_, err := memStore.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-images", Deck: "deck-images", OrgID: orgID, VersionNo: 1, SpecJSON: json.RawMessage(`{"layouts":[{"name":"A"},{"name":"B"},{"name":"C"}]}`)})
+	require.NoError(t, err)
+
+	export := func(format, width string) (store.Asset, []byte) {
+		metadata := store.JSONMap{"format": format, "width": width, "filename": "deck." + format}
+		job := store.Job{ID: "job-" + format, OrgID: orgID, Type: store.JobExport, Status: store.JobQueued, InputRef: "dv-images", Metadata: &metadata}
+		_, err := memStore.Jobs().Enqueue(ctx, job)
+		require.NoError(t, err)
+		require.NoError(t, w.processJob(ctx, job))
+		got, _, _ := memStore.Jobs().Get(ctx, orgID, job.ID)
+		require.Equal(t, store.JobDone, got.Status, got.Error)
+		asset, ok, err := memStore.Assets().Get(ctx, orgID, got.OutputRef)
+		require.NoError(t, err)
+		require.True(t, ok)
+		data, err := storage.Download(ctx, asset.Path)
+		require.NoError(t, err)
+		return asset, data
+	}
+
+	asset, data := export(store.ExportFormatPNG, "1200")
+	assert.Equal(t, store.AssetZIP, asset.Type)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 3)
+	assert.Equal(t, "slide-01.png", zr.File[0].Name)
+	rc, err := zr.File[2].Open()
+	require.NoError(t, err)
+	cfg, err := png.DecodeConfig(rc)
+	rc.Close()
+	require.NoError(t, err)
+	assert.Equal(t, [2]int{1200, 900}, [2]int{cfg.Width, cfg.Height})
+
+	asset, data = export(store.ExportFormatGIF, "")
+	assert.Equal(t, "image/gif", asset.Mime)
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Len(t, anim.Image, 3)
+	assert.Equal(t, assets.DefaultGIFWidth, anim.Config.Width)
+}
@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/ziyad/cms-ai/server/internal/imagegen"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// processImageGenJob generates the image described by the job's metadata,
+// or reuses the org's copy if another job made it first. The output is the
+// image's asset ID.
+func (w *Worker) processImageGenJob(ctx context.Context, job store.Job) (string, error) {
+	if w.ImageGen == nil {
+		return "", fmt.Errorf("image generation is not configured")
+	}
+	if job.Metadata == nil || (*job.Metadata)["prompt"] == "" {
+		return "", fmt.Errorf("image generation job has no prompt")
+	}
+	m := *job.Metadata
+	req := imagegen.Request{Prompt: m["prompt"]}
+	req.Width, _ = strconv.Atoi(m["width"])
+	req.Height, _ = strconv.Atoi(m["height"])
+
+	w.updateProgress(ctx, &job, "Generating image", 10)
+	img, err := w.ImageGen.Generate(ctx, job.OrgID, m["userId"], job.ID, req)
+	if err != nil {
+		return "", err
+	}
+	logger.Jobs().Info("image_generated", "job_id", job.ID, "image_id", img.ID, "asset_id", img.AssetID, "model", img.Model)
+	return img.AssetID, nil
+}
@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const markdownMime = "text/markdown; charset=utf-8"
+
+// processMarkdownExportJob exports a deck or template version's titles and
+// bullets as a Marp Markdown document; see spec.Markdown. Export data and
+// connector bindings are filled in as they would be in the PPTX.
+func (w *Worker) processMarkdownExportJob(ctx context.Context, job store.Job) (string, error) {
+	var manifest bundleManifest
+	specBytes, err := w.loadExportVersion(ctx, job, &manifest)
+	if err != nil {
+		return "", err
+	}
+	var doc any
+	if err := json.Unmarshal(specBytes, &doc); err != nil {
+		return "", fmt.Errorf("failed to read spec: %w", err)
+	}
+	if data := mergeDataFromMetadata(job.Metadata); data != nil {
+		doc = spec.MergeVariables(doc, data)
+	}
+	if w.Connectors != nil {
+		values, err := w.Connectors.Values(ctx, job.OrgID, doc)
+		if err != nil {
+			return "", fmt.Errorf("fill connector bindings: %w", err)
+		}
+		doc = spec.MergeVariables(doc, values)
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to read spec: %w", err)
+	}
+	var ts spec.TemplateSpec
+	if err := json.Unmarshal(b, &ts); err != nil {
+		return "", fmt.Errorf("failed to read spec: %w", err)
+	}
+	data := []byte(spec.Markdown(ts, manifest.Name))
+
+	w.updateProgress(ctx, &job, "Uploading Markdown", 80)
+
+	assetID := newID("asset")
+	metadata, err := w.uploadWithRetry(ctx, job, assetID+".md", data, markdownMime)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload markdown: %w", err)
+	}
+
+	asset := store.Asset{
+		ID:          assetID,
+		OrgID:       job.OrgID,
+		Type:        store.AssetFile,
+		Path:        metadata.Key,
+		Mime:        metadata.ContentType,
+		SizeBytes:   int64(len(data)),
+		SourceJobID: job.ID,
+		Region:      metadata.Region,
+		SHA256:      assets.Checksum(data),
+		Filename:    jobFilename(job),
+	}
+	if _, err := w.store.Assets().Create(ctx, asset); err != nil {
+		return "", fmt.Errorf("failed to create markdown asset record: %w", err)
+	}
+	return assetID, nil
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+func TestWorker_MarkdownExport(t *testing.T) {
+	memStore := memory.New()
+	storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	w := New(memStore, &countingRenderer{}, storage, ai.NewAIService(memStore))
+
+	ctx := context.Background()
+	orgID := "org-md"
+	_, err := memStore.Decks().CreateDeck(ctx, store.Deck{ID: "deck-md", OrgID: orgID, Name: "Proposal"})
+	require.NoError(t, err)
+	_, err = memStore.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-md", Deck: "deck-md", OrgID: orgID, VersionNo: 1,
+		SpecJSON: json.RawMessage(`{"layouts":[{"name":"Cover","placeholders":[{"id":"title","type":"text","content":"Proposal for {{customer}}"},{"id":"body","type":"text","content":"Scope\nPricing"}]}]}`)})
+	require.NoError(t, err)
+
+	metadata := store.JSONMap{"format": store.ExportFormatMarkdown, "filename": "Proposal v1.md", "mergeData": `{"customer":"Acme"}`}
+	job := store.Job{ID: "job-md", OrgID: orgID, Type: store.JobExport, Status: store.JobQueued, InputRef: "dv-md", Metadata: &metadata}
+	_, err = memStore.Jobs().Enqueue(ctx, job)
+	require.NoError(t, err)
+	require.NoError(t, w.processJob(ctx, job))
+	got, _, _ := memStore.Jobs().Get(ctx, orgID, job.ID)
+	require.Equal(t, store.JobDone, got.Status, got.Error)
+
+	// Markdown needs no rendering.
+	assert.Zero(t, w.renderer.(*countingRenderer).renders)
+	exports, err := memStore.Metering().SumForTarget(ctx, orgID, "export", "deck-md")
+	require.NoError(t, err)
+	assert.Equal(t, 1, exports, "the finished export is metered against its deck")
+	asset, ok, err := memStore.Assets().Get(ctx, orgID, got.OutputRef)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "Proposal v1.md", asset.Filename)
+	data, err := storage.Download(ctx, asset.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "---\nmarp: true\ntitle: \"Proposal\"\npaginate: true\n---\n\n# Proposal for Acme\n\n- Scope\n- Pricing\n", string(data))
+}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// maybePurgeDeletedOrgs runs purgeDeletedOrgs when OrgPurgeInterval has
+// passed since the last run. It is only called from the poll loop.
+func (w *Worker) maybePurgeDeletedOrgs(ctx context.Context, now time.Time) {
+	if w.OrgPurgeInterval <= 0 || now.Sub(w.lastOrgPurge) < w.OrgPurgeInterval {
+		return
+	}
+	w.lastOrgPurge = now
+	w.purgeDeletedOrgs(ctx, now)
+}
+
+// purgeDeletedOrgs permanently removes orgs whose deletion grace period is
+// over. Stored objects go first: an org whose objects can't all be deleted
+// keeps its records, so the next run still knows what to delete.
+func (w *Worker) purgeDeletedOrgs(ctx context.Context, now time.Time) {
+	orgs, err := w.store.Organizations().ListPurgeable(ctx, now)
+	if err != nil {
+		logger.LogError(ctx, "worker", "list_purgeable_orgs", err)
+		return
+	}
+	for _, org := range orgs {
+		orgAssets, _, err := w.store.Assets().List(ctx, org.ID, store.AssetFilter{})
+		if err != nil {
+			logger.LogError(ctx, "worker", "purge_org", err, "org_id", org.ID)
+			continue
+		}
+		failed := false
+		for _, a := range orgAssets {
+			if a.Path == "" {
+				continue
+			}
+			if err := w.storage.Delete(assets.WithRegion(ctx, a.Region), a.Path); err != nil {
+				logger.LogError(ctx, "worker", "purge_org_object", err, "org_id", org.ID, "asset_id", a.ID)
+				failed = true
+			}
+		}
+		if failed {
+			continue
+		}
+		if err := w.store.Organizations().Purge(ctx, org.ID); err != nil {
+			logger.LogError(ctx, "worker", "purge_org", err, "org_id", org.ID)
+			continue
+		}
+		logger.Jobs().Info("org_purged", "org_id", org.ID, "assets", len(orgAssets))
+	}
+}
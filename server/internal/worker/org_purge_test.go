@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+func TestWorker_PurgeDeletedOrgs(t *testing.T) {
+	ctx := context.Background()
+	memStore := memory.New()
+	storage, err := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	require.NoError(t, err)
+	for _, id := range []string{"org-gone", "org-grace", "org-live"} {
+		require.NoError(t, memStore.Organizations().CreateOrganization(ctx, &store.Organization{ID: id, Name: id}))
+		meta, err := storage.Upload(ctx, id+"/deck.pptx", []byte("pptx"), "application/vnd.openxmlformats-officedocument.presentationml.presentation")
+		require.NoError(t, err)
+		_, err = memStore.Assets().Create(ctx, store.Asset{ID: "asset-" + id, OrgID: id, Type: store.AssetPPTX, Path: meta.Key, SizeBytes: 4})
+		require.NoError(t, err)
+	}
+	_, err = memStore.Organizations().Delete(ctx, "org-gone", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	_, err = memStore.Organizations().Delete(ctx, "org-grace", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	w := New(memStore, &countingRenderer{}, storage, nil)
+	w.maybePurgeDeletedOrgs(ctx, time.Now())
+	_, err = memStore.Organizations().GetOrganization(ctx, "org-gone")
+	require.NoError(t, err, "purging is off until an interval is set")
+
+	w.OrgPurgeInterval = time.Hour
+	w.maybePurgeDeletedOrgs(ctx, time.Now())
+
+	_, err = memStore.Organizations().GetOrganization(ctx, "org-gone")
+	assert.Error(t, err)
+	exists, err := storage.Exists(ctx, "org-gone/deck.pptx")
+	require.NoError(t, err)
+	assert.False(t, exists, "stored objects are purged with the org")
+
+	for _, id := range []string{"org-grace", "org-live"} {
+		_, err = memStore.Organizations().GetOrganization(ctx, id)
+		assert.NoError(t, err, id)
+		exists, err = storage.Exists(ctx, id+"/deck.pptx")
+		require.NoError(t, err)
+		assert.True(t, exists, id)
+	}
+}
@@ -0,0 +1,257 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/cron"
+	"github.com/ziyad/cms-ai/server/internal/egress"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/webhooks"
+)
+
+// maxScheduleSourceBytes bounds the content a schedule fetches from its
+// source URL.
+const maxScheduleSourceBytes = 1 << 20
+
+// maybeRunSchedules runs runDueSchedules when ScheduleInterval has passed
+// since the last run. It is only called from the poll loop.
+func (w *Worker) maybeRunSchedules(ctx context.Context, now time.Time) {
+	if w.ScheduleInterval <= 0 || now.Sub(w.lastScheduleRun) < w.ScheduleInterval {
+		return
+	}
+	w.lastScheduleRun = now
+	w.runDueSchedules(ctx, now)
+}
+
+// runDueSchedules starts a run of every schedule that is due. Each run is
+// claimed by moving the schedule to its next run first, so with several
+// workers polling only one starts it, and a schedule that was due several
+// times while nothing polled runs once.
+func (w *Worker) runDueSchedules(ctx context.Context, now time.Time) {
+	due, err := w.store.Schedules().ListDue(ctx, now)
+	if err != nil {
+		logger.LogError(ctx, "worker", "list_due_schedules", err)
+		return
+	}
+	for _, sched := range due {
+		next, err := nextScheduleRun(sched, now)
+		if err != nil {
+			// Checked when the schedule was saved, so this only happens if
+			// the timezone database changed under it.
+			w.finishScheduleRun(ctx, sched, now, store.Deck{}, store.Job{}, err)
+			continue
+		}
+		claimed, err := w.store.Schedules().Claim(ctx, sched.OrgID, sched.ID, *sched.NextRunAt, next)
+		if err != nil {
+			logger.LogError(ctx, "worker", "claim_schedule", err, "schedule_id", sched.ID)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		deck, job, err := w.startScheduledRun(ctx, sched, now)
+		w.finishScheduleRun(ctx, sched, now, deck, job, err)
+	}
+}
+
+// nextScheduleRun is when sched runs next after now.
+func nextScheduleRun(sched store.Schedule, now time.Time) (time.Time, error) {
+	expr, err := cron.Parse(sched.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := time.LoadLocation(sched.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := expr.Next(now.In(loc))
+	if next.IsZero() {
+		return time.Time{}, errors.New("cron expression never runs")
+	}
+	return next.UTC(), nil
+}
+
+// startScheduledRun creates the run's deck and queues the bind job that
+// fills it; processBindJob queues the export once the deck has a version.
+func (w *Worker) startScheduledRun(ctx context.Context, sched store.Schedule, now time.Time) (store.Deck, store.Job, error) {
+	tv, ok, err := w.store.Templates().GetVersion(ctx, sched.OrgID, sched.TemplateVersionID)
+	if err != nil {
+		return store.Deck{}, store.Job{}, fmt.Errorf("load template version: %w", err)
+	}
+	if !ok {
+		return store.Deck{}, store.Job{}, errors.New("template version not found")
+	}
+	content, err := w.scheduleContent(ctx, sched)
+	if err != nil {
+		return store.Deck{}, store.Job{}, err
+	}
+
+	loc, _ := time.LoadLocation(sched.Timezone)
+	deck := store.Deck{
+		ID:                    newID("deck"),
+		OrgID:                 sched.OrgID,
+		OwnerUserID:           sched.CreatedBy,
+		Name:                  fmt.Sprintf("%s %s", sched.Name, now.In(loc).Format("2006-01-02")),
+		SourceTemplateVersion: tv.ID,
+		Content:               content,
+	}
+	if tpl, ok, err := w.store.Templates().GetTemplate(ctx, sched.OrgID, tv.Template); err == nil && ok {
+		deck.Language = tpl.Language
+	}
+	metadata := store.JSONMap{
+		"sourceTemplateVersionId": tv.ID,
+		"content":                 content,
+		"userId":                  sched.CreatedBy,
+		"scheduleId":              sched.ID,
+	}
+	if sched.Quality != "" {
+		metadata["exportQuality"] = sched.Quality
+	}
+	if renderer := w.scheduleRenderer(ctx, sched); renderer != "" {
+		metadata["exportRenderer"] = renderer
+	}
+
+	var job store.Job
+	err = w.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		if deck, err = tx.Decks().CreateDeck(ctx, deck); err != nil {
+			return fmt.Errorf("create deck: %w", err)
+		}
+		job, _, err = tx.Jobs().EnqueueWithDeduplication(ctx, store.Job{
+			ID:              newID("job"),
+			OrgID:           sched.OrgID,
+			Type:            store.JobBind,
+			Status:          store.JobQueued,
+			InputRef:        deck.ID,
+			DeduplicationID: fmt.Sprintf("bind-%s", deck.ID),
+			Metadata:        &metadata,
+		})
+		if err != nil {
+			return fmt.Errorf("enqueue bind job: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return store.Deck{}, store.Job{}, err
+	}
+
+	_, _ = w.store.Metering().Record(ctx, store.MeteringEvent{ID: newID("met"), OrgID: sched.OrgID, UserID: sched.CreatedBy, Type: store.MeterDeckCreate, Quantity: 1, TargetRef: tv.Template})
+	_, _ = w.store.Audit().Append(ctx, store.AuditLog{ID: newID("aud"), OrgID: sched.OrgID, ActorID: sched.CreatedBy, Action: "schedule.run", TargetRef: sched.ID, Metadata: map[string]any{"deckId": deck.ID, "jobId": job.ID}})
+	webhooks.Emit(ctx, w.store, sched.CreatedBy, webhooks.Event{Type: webhooks.EventDeckCreated, OrgID: sched.OrgID, Data: map[string]any{"deckId": deck.ID, "name": deck.Name, "scheduleId": sched.ID}})
+	logger.Jobs().Info("schedule_run_started", "schedule_id", sched.ID, "org_id", sched.OrgID, "deck_id", deck.ID, "job_id", job.ID)
+	return deck, job, nil
+}
+
+// scheduleRenderer is the engine the run's export uses: the schedule's, else
+// for standard quality the org's default, as for exports through the API.
+func (w *Worker) scheduleRenderer(ctx context.Context, sched store.Schedule) string {
+	if sched.Renderer != "" || sched.Quality != "" {
+		return sched.Renderer
+	}
+	org, err := w.store.Organizations().GetOrganization(ctx, sched.OrgID)
+	if err != nil {
+		return ""
+	}
+	return org.DefaultRenderer
+}
+
+// scheduleContent is what a run binds to the template: the schedule's
+// content followed by the body of its source URL, if it has one. The URL is
+// fetched with egress.Client, so it can't reach the server's own network.
+func (w *Worker) scheduleContent(ctx context.Context, sched store.Schedule) (string, error) {
+	if sched.SourceURL == "" {
+		return sched.Content, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sched.SourceURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("fetch source: %w", err)
+	}
+	resp, err := egress.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch source: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("fetch source: status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxScheduleSourceBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("fetch source: %w", err)
+	}
+	if len(body) > maxScheduleSourceBytes {
+		return "", fmt.Errorf("fetch source: body exceeds %d bytes", maxScheduleSourceBytes)
+	}
+	fetched := strings.TrimSpace(string(body))
+	if fetched == "" {
+		return "", errors.New("fetch source: empty body")
+	}
+	if strings.TrimSpace(sched.Content) == "" {
+		return fetched, nil
+	}
+	return sched.Content + "\n\n" + fetched, nil
+}
+
+// finishScheduleRun records how a run went on the schedule. It reloads the
+// schedule so edits made while the run started are kept.
+func (w *Worker) finishScheduleRun(ctx context.Context, sched store.Schedule, now time.Time, deck store.Deck, job store.Job, runErr error) {
+	cur, ok, err := w.store.Schedules().Get(ctx, sched.OrgID, sched.ID)
+	if err != nil || !ok {
+		return
+	}
+	ranAt := now.UTC()
+	cur.LastRunAt = &ranAt
+	cur.LastDeckID, cur.LastJobID, cur.LastError = deck.ID, job.ID, ""
+	if runErr != nil {
+		cur.LastError = runErr.Error()
+		logger.Jobs().Warn("schedule_run_failed", "schedule_id", sched.ID, "org_id", sched.OrgID, "error", runErr)
+		if cur.NextRunAt != nil && cur.NextRunAt.Equal(*sched.NextRunAt) {
+			// The run was never claimed, so nothing moved it on; pause the
+			// schedule rather than fail again every poll.
+			cur.Enabled, cur.NextRunAt = false, nil
+		}
+	}
+	if _, err := w.store.Schedules().Update(ctx, cur); err != nil {
+		logger.LogError(ctx, "worker", "update_schedule", err, "schedule_id", sched.ID)
+	}
+}
+
+// queueScheduledExport exports the version a scheduled run's bind job made,
+// with the schedule's export options.
+func (w *Worker) queueScheduledExport(ctx context.Context, bind store.Job, deckName string, version store.DeckVersion) {
+	m := *bind.Metadata
+	metadata := store.JSONMap{
+		"versionNo":  strconv.Itoa(version.VersionNo),
+		"userId":     m["userId"],
+		"scheduleId": m["scheduleId"],
+	}
+	if deckName != "" {
+		metadata["filename"] = deckName + ".pptx"
+	}
+	if m["exportQuality"] != "" {
+		metadata["quality"] = m["exportQuality"]
+	}
+	if m["exportRenderer"] != "" {
+		metadata["renderer"] = m["exportRenderer"]
+	}
+	job, err := w.store.Jobs().Enqueue(ctx, store.Job{
+		ID:       newID("job"),
+		OrgID:    bind.OrgID,
+		Type:     store.JobExport,
+		Status:   store.JobQueued,
+		InputRef: version.ID,
+		Metadata: &metadata,
+	})
+	if err != nil {
+		logger.LogError(ctx, "worker", "enqueue_scheduled_export", err, "schedule_id", m["scheduleId"], "version_id", version.ID)
+		return
+	}
+	logger.Jobs().Info("scheduled_export_queued", "schedule_id", m["scheduleId"], "job_id", job.ID, "version_id", version.ID)
+}
@@ -0,0 +1,166 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/egress"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+// allowLoopback lets the test reach its httptest servers, which egress
+// otherwise refuses like any other loopback address.
+func allowLoopback(t *testing.T) {
+	egress.Configure([]netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")})
+	t.Cleanup(func() { egress.Configure(nil) })
+}
+
+func newScheduleTestStore(t *testing.T) *memory.MemoryStore {
+	t.Helper()
+	ctx := context.Background()
+	memStore := memory.New()
+	require.NoError(t, memStore.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Org"}))
+	_, err := memStore.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-1", OrgID: "org-1", Name: "Report", Listing: store.Listing{Language: "de"}})
+	require.NoError(t, err)
+	_, err = memStore.Templates().CreateVersion(ctx, store.TemplateVersion{
+		ID:        "tv-1",
+		Template:  "tpl-1",
+		OrgID:     "org-1",
+		VersionNo: 1,
+		SpecJSON:  json.RawMessage(`{"layouts":[{"name":"Title","placeholders":[{"id":"title","type":"text","geometry":{"x":0.1,"y":0.1,"w":0.8,"h":0.2}}]}]}`),
+	})
+	require.NoError(t, err)
+	return memStore
+}
+
+func TestWorker_RunDueSchedules(t *testing.T) {
+	allowLoopback(t)
+	ctx := context.Background()
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("Revenue up 12% this week"))
+	}))
+	defer source.Close()
+
+	memStore := newScheduleTestStore(t)
+	now := time.Date(2026, 3, 9, 9, 0, 30, 0, time.UTC)
+	due := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	for _, s := range []store.Schedule{
+		{ID: "sch-due", OrgID: "org-1", Name: "Weekly sales", Cron: "0 9 * * mon", TemplateVersionID: "tv-1", Content: "Summarize:", SourceURL: source.URL, Quality: store.ExportQualityDraft, Enabled: true, NextRunAt: &due, CreatedBy: "user-1"},
+		{ID: "sch-later", OrgID: "org-1", Name: "Later", Cron: "@daily", TemplateVersionID: "tv-1", Content: "x", Enabled: true, NextRunAt: &later, CreatedBy: "user-1"},
+	} {
+		_, err := memStore.Schedules().Create(ctx, s)
+		require.NoError(t, err)
+	}
+
+	w := New(memStore, &countingRenderer{}, nil, ai.NewAIServiceWithOptions(memStore, ai.Options{Mock: true}))
+	w.maybeRunSchedules(ctx, now)
+	jobs, err := memStore.Jobs().List(ctx, store.JobFilter{OrgID: "org-1"})
+	require.NoError(t, err)
+	assert.Empty(t, jobs, "scheduling is off until an interval is set")
+
+	w.ScheduleInterval = time.Minute
+	w.maybeRunSchedules(ctx, now)
+	w.runDueSchedules(ctx, now) // already claimed: runs once
+
+	sched, ok, err := memStore.Schedules().Get(ctx, "org-1", "sch-due")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Empty(t, sched.LastError)
+	require.NotNil(t, sched.NextRunAt)
+	assert.Equal(t, time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC), *sched.NextRunAt)
+	require.NotNil(t, sched.LastRunAt)
+
+	deck, ok, err := memStore.Decks().GetDeck(ctx, "org-1", sched.LastDeckID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "Weekly sales 2026-03-09", deck.Name)
+	assert.Equal(t, "user-1", deck.OwnerUserID)
+	assert.Equal(t, "de", deck.Language)
+
+	jobs, err = memStore.Jobs().List(ctx, store.JobFilter{OrgID: "org-1"})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	bind := jobs[0]
+	assert.Equal(t, store.JobBind, bind.Type)
+	assert.Equal(t, sched.LastJobID, bind.ID)
+	assert.Equal(t, "Summarize:\n\nRevenue up 12% this week", (*bind.Metadata)["content"])
+
+	require.NoError(t, w.processJob(ctx, bind))
+	jobs, err = memStore.Jobs().List(ctx, store.JobFilter{OrgID: "org-1"})
+	require.NoError(t, err)
+	var export *store.Job
+	for i := range jobs {
+		if jobs[i].Type == store.JobExport {
+			export = &jobs[i]
+		}
+	}
+	require.NotNil(t, export, "the bound deck is exported")
+	deck, _, err = memStore.Decks().GetDeck(ctx, "org-1", deck.ID)
+	require.NoError(t, err)
+	assert.Equal(t, *deck.CurrentVersion, export.InputRef)
+	assert.Equal(t, store.ExportQualityDraft, (*export.Metadata)["quality"])
+	assert.Equal(t, "Weekly sales 2026-03-09.pptx", (*export.Metadata)["filename"])
+	assert.Equal(t, "sch-due", (*export.Metadata)["scheduleId"])
+}
+
+func TestWorker_RunDueSchedules_RecordsSourceFailure(t *testing.T) {
+	allowLoopback(t)
+	ctx := context.Background()
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer source.Close()
+
+	memStore := newScheduleTestStore(t)
+	now := time.Date(2026, 3, 9, 9, 0, 30, 0, time.UTC)
+	due := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+	_, err := memStore.Schedules().Create(ctx, store.Schedule{ID: "sch-1", OrgID: "org-1", Name: "Daily", Cron: "@daily", TemplateVersionID: "tv-1", SourceURL: source.URL, Enabled: true, NextRunAt: &due, CreatedBy: "user-1"})
+	require.NoError(t, err)
+
+	w := New(memStore, &countingRenderer{}, nil, nil)
+	w.runDueSchedules(ctx, now)
+
+	sched, _, err := memStore.Schedules().Get(ctx, "org-1", "sch-1")
+	require.NoError(t, err)
+	assert.Contains(t, sched.LastError, "status 502")
+	assert.Empty(t, sched.LastDeckID)
+	assert.True(t, sched.Enabled, "a failed run doesn't stop later ones")
+	require.NotNil(t, sched.NextRunAt)
+	assert.Equal(t, time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), *sched.NextRunAt)
+
+	decks, err := memStore.Decks().ListDecks(ctx, "org-1")
+	require.NoError(t, err)
+	assert.Empty(t, decks)
+}
+
+func TestWorker_RunDueSchedules_RefusesPrivateSources(t *testing.T) {
+	ctx := context.Background()
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("internal numbers"))
+	}))
+	defer source.Close()
+
+	memStore := newScheduleTestStore(t)
+	now := time.Date(2026, 3, 9, 9, 0, 30, 0, time.UTC)
+	due := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+	_, err := memStore.Schedules().Create(ctx, store.Schedule{ID: "sch-1", OrgID: "org-1", Name: "Daily", Cron: "@daily", TemplateVersionID: "tv-1", SourceURL: source.URL, Enabled: true, NextRunAt: &due, CreatedBy: "user-1"})
+	require.NoError(t, err)
+
+	w := New(memStore, &countingRenderer{}, nil, nil)
+	w.runDueSchedules(ctx, now)
+
+	sched, _, err := memStore.Schedules().Get(ctx, "org-1", "sch-1")
+	require.NoError(t, err)
+	assert.Contains(t, sched.LastError, egress.ErrBlocked.Error())
+	assert.Empty(t, sched.LastDeckID)
+}
@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const (
+	// interruptGrace is how long Shutdown gives cancelled jobs to return
+	// before it requeues them itself.
+	interruptGrace = 5 * time.Second
+
+	// orphanGrace is added to the job timeout to tell a Running job that
+	// lost its worker from a slow one. Jobs are cancelled at their timeout,
+	// so one untouched for longer was left behind by a crash or a kill.
+	orphanGrace = time.Minute
+
+	interruptedMsg = "interrupted by worker shutdown; requeued"
+)
+
+// errOrphaned is recorded on Running jobs whose worker went away without
+// requeueing them. It counts as a transient failure, so a job that keeps
+// taking its worker down still ends up dead-lettered.
+var errOrphaned = errors.New("worker stopped while the job was running")
+
+// Shutdown stops polling and waits for in-flight jobs to finish. If ctx ends
+// first, the remaining jobs are cancelled and put back in the queue without
+// using up a retry, so the next worker to start runs them again; Shutdown
+// then returns ctx's error.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	w.stopOnce.Do(func() { close(w.stop) })
+	w.wg.Wait()
+
+	drained := make(chan struct{})
+	go func() {
+		w.slots.wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		w.cancelJobs()
+		return nil
+	case <-ctx.Done():
+	}
+
+	ids := w.slots.ids()
+	logger.Jobs().Warn("worker_drain_timeout", "in_flight", len(ids), "job_ids", ids)
+	w.cancelJobs()
+	select {
+	case <-drained:
+	case <-time.After(interruptGrace):
+		// These jobs ignored cancellation; requeue them without waiting.
+		w.requeueRunning(context.Background(), w.slots.ids())
+	}
+	return ctx.Err()
+}
+
+// InFlight returns the IDs of the jobs this worker is running.
+func (w *Worker) InFlight() []string {
+	return w.slots.ids()
+}
+
+// requeue puts job back in the queue as if it had never started.
+func (w *Worker) requeue(ctx context.Context, job store.Job, reason string) {
+	job.Status = store.JobQueued
+	job.Error = reason
+	job.ProgressStep, job.ProgressPct = "", 0
+	if _, err := w.saveJob(ctx, job); err != nil {
+		logger.LogError(ctx, "worker", "requeue_job", err, "job_id", job.ID)
+		return
+	}
+	logger.Jobs().Info("job_requeued", "job_id", job.ID, "reason", reason)
+}
+
+// requeueRunning requeues those of ids that are still Running.
+func (w *Worker) requeueRunning(ctx context.Context, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	jobs, err := w.store.Jobs().List(ctx, store.JobFilter{IDs: ids, Status: store.JobRunning})
+	if err != nil {
+		logger.LogError(ctx, "worker", "list_interrupted_jobs", err)
+		return
+	}
+	for _, job := range jobs {
+		w.requeue(ctx, job, interruptedMsg)
+	}
+}
+
+// recoverOrphaned fails Running jobs that no worker can still be running, so
+// the retry policy picks them up again.
+func (w *Worker) recoverOrphaned(ctx context.Context) {
+	running, err := w.store.Jobs().List(ctx, store.JobFilter{Status: store.JobRunning})
+	if err != nil {
+		logger.LogError(ctx, "worker", "list_running_jobs", err)
+		return
+	}
+	cutoff := time.Now().Add(-(w.jobTimeout() + orphanGrace))
+	for _, job := range running {
+		if !job.UpdatedAt.Before(cutoff) || w.slots.holds(job.ID) {
+			continue
+		}
+		logger.Jobs().Warn("orphaned_job_recovered", "job_id", job.ID, "updated_at", job.UpdatedAt)
+		_ = w.handleJobFailure(ctx, job, errOrphaned)
+	}
+}
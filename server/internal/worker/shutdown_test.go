@@ -0,0 +1,170 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+// gateRenderer renders once release is closed, or fails when its context
+// ends first.
+type gateRenderer struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newGateRenderer() *gateRenderer {
+	return &gateRenderer{started: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (g *gateRenderer) RenderPPTX(ctx context.Context, spec interface{}, outPath string) error {
+	return errors.New("not implemented")
+}
+
+func (g *gateRenderer) RenderPPTXBytes(ctx context.Context, spec interface{}) ([]byte, error) {
+	g.started <- struct{}{}
+	select {
+	case <-g.release:
+		return []byte("rendered-pptx"), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (g *gateRenderer) GenerateSlideThumbnails(ctx context.Context, spec interface{}) ([][]byte, error) {
+	return nil, errors.New("not implemented")
+}
+
+// startExportJob queues a deck export and has w pick it up, returning once
+// the renderer is running it.
+func startExportJob(t *testing.T, memStore *memory.MemoryStore, w *Worker, r *gateRenderer) store.Job {
+	t.Helper()
+	ctx := context.Background()
+	_, err := memStore.Decks().CreateDeck(ctx, store.Deck{ID: "deck-drain", OrgID: "org-drain", Name: "Drain"})
+	require.NoError(t, err)
+	_, err = memStore.Decks().CreateDeckVersion(ctx, store.DeckVersion{
+		ID: "dv-drain", Deck: "deck-drain", OrgID: "org-drain", VersionNo: 1, CreatedBy: "user-1",
+		SpecJSON: json.RawMessage(`{"layouts":[{"name":"t","placeholders":[{"id":"t","type":"text","geometry":{"x":0.1,"y":0.1,"w":0.8,"h":0.2}}]}]}`),
+	})
+	require.NoError(t, err)
+	job, err := memStore.Jobs().Enqueue(ctx, store.Job{ID: "job-drain", OrgID: "org-drain", Type: store.JobExport, Status: store.JobQueued, InputRef: "dv-drain"})
+	require.NoError(t, err)
+
+	w.dispatchJobs(false)
+	select {
+	case <-r.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job never started rendering")
+	}
+	assert.Equal(t, []string{job.ID}, w.InFlight())
+	return job
+}
+
+func TestWorker_Shutdown_DrainsInFlightJobs(t *testing.T) {
+	memStore := memory.New()
+	storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local"})
+	r := newGateRenderer()
+	w := New(memStore, r, storage, ai.NewAIService(memStore))
+	w.SpoolDir = t.TempDir()
+	job := startExportJob(t, memStore, w, r)
+
+	time.AfterFunc(50*time.Millisecond, func() { close(r.release) })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, w.Shutdown(ctx))
+	assert.Empty(t, w.InFlight())
+
+	got, _, err := memStore.Jobs().Get(context.Background(), job.OrgID, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobDone, got.Status)
+}
+
+func TestWorker_Shutdown_RequeuesJobsPastDrainTimeout(t *testing.T) {
+	memStore := memory.New()
+	storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local"})
+	r := newGateRenderer()
+	w := New(memStore, r, storage, ai.NewAIService(memStore))
+	w.SpoolDir = t.TempDir()
+	job := startExportJob(t, memStore, w, r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.Shutdown(ctx), context.DeadlineExceeded)
+	assert.Empty(t, w.InFlight())
+
+	got, _, err := memStore.Jobs().Get(context.Background(), job.OrgID, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobQueued, got.Status, "interrupted job goes back in the queue")
+	assert.Equal(t, 0, got.RetryCount, "shutdown doesn't use up a retry")
+	assert.Equal(t, interruptedMsg, got.Error)
+
+	// The next worker runs it.
+	r2 := newGateRenderer()
+	close(r2.release)
+	next := New(memStore, r2, storage, ai.NewAIService(memStore))
+	next.SpoolDir = w.SpoolDir
+	next.ProcessJobs()
+	got, _, err = memStore.Jobs().Get(context.Background(), job.OrgID, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobDone, got.Status)
+}
+
+// agedStore reports every job as last updated age ago.
+type agedStore struct {
+	*memory.MemoryStore
+	age time.Duration
+}
+
+func (s agedStore) Jobs() store.JobStore { return agedJobs{s.MemoryStore.Jobs(), s.age} }
+
+type agedJobs struct {
+	store.JobStore
+	age time.Duration
+}
+
+func (j agedJobs) List(ctx context.Context, f store.JobFilter) ([]store.Job, error) {
+	jobs, err := j.JobStore.List(ctx, f)
+	for i := range jobs {
+		jobs[i].UpdatedAt = jobs[i].UpdatedAt.Add(-j.age)
+	}
+	return jobs, err
+}
+
+func TestWorker_RecoverOrphaned_RetriesStaleRunningJobs(t *testing.T) {
+	memStore := memory.New()
+	ctx := context.Background()
+	for _, id := range []string{"job-orphan", "job-held"} {
+		_, err := memStore.Jobs().Enqueue(ctx, store.Job{ID: id, OrgID: "org-1", Type: store.JobExport, Status: store.JobRunning, InputRef: "dv-1"})
+		require.NoError(t, err)
+	}
+
+	w := New(agedStore{memStore, time.Hour}, &countingRenderer{}, nil, nil)
+	require.True(t, w.slots.acquire("job-held", false, 2, 0, false))
+	defer w.slots.release("job-held", false)
+	w.recoverOrphaned(ctx)
+
+	orphan, _, err := memStore.Jobs().Get(ctx, "org-1", "job-orphan")
+	require.NoError(t, err)
+	assert.Equal(t, store.JobRetry, orphan.Status)
+	assert.Equal(t, 1, orphan.RetryCount)
+	assert.Contains(t, orphan.Error, "worker stopped")
+
+	held, _, err := memStore.Jobs().Get(ctx, "org-1", "job-held")
+	require.NoError(t, err)
+	assert.Equal(t, store.JobRunning, held.Status, "jobs this worker is running are left alone")
+
+	fresh := New(memStore, &countingRenderer{}, nil, nil)
+	fresh.recoverOrphaned(ctx)
+	held, _, err = memStore.Jobs().Get(ctx, "org-1", "job-held")
+	require.NoError(t, err)
+	assert.Equal(t, store.JobRunning, held.Status, "recently updated jobs may still be running elsewhere")
+}
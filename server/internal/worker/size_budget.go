@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"strconv"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// fitSizeBudget compresses a rendered PPTX toward the size budget in the
+// job's "targetSize" metadata, in bytes, and returns it with the budget
+// and its size before compression, for the asset record; both are 0 when
+// the export has no budget. An export still over budget is kept, with a
+// "sizeWarning" in the job's metadata. A file that can't be compressed is
+// kept as it is.
+func fitSizeBudget(job store.Job, pptx []byte) (out []byte, budget, original int64) {
+	if job.Metadata == nil {
+		return pptx, 0, 0
+	}
+	budget, _ = strconv.ParseInt((*job.Metadata)["targetSize"], 10, 64)
+	if budget <= 0 {
+		return pptx, 0, 0
+	}
+	original = int64(len(pptx))
+	compressed, report, err := assets.CompressPPTX(pptx, budget)
+	if err != nil {
+		logger.Jobs().Warn("export_compress_failed", "job_id", job.ID, "error", err)
+	} else {
+		pptx = compressed
+		logger.Jobs().Info("export_compressed", "job_id", job.ID, "original_bytes", report.OriginalBytes, "bytes", report.Bytes, "removed_media", report.RemovedMedia, "resampled_images", report.ResampledImages)
+	}
+	if warning := assets.SizeWarning(int64(len(pptx)), budget); warning != "" {
+		(*job.Metadata)["sizeWarning"] = warning
+		logger.Jobs().Warn("export_over_budget", "job_id", job.ID, "bytes", len(pptx), "budget_bytes", budget)
+	}
+	return pptx, budget, original
+}
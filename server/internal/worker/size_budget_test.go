@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+// mediaRenderer renders every deck as one slide showing a video, with a
+// leftover image nothing uses. Both are random bytes, so neither shrinks
+// when zipped.
+type mediaRenderer struct{ countingRenderer }
+
+func (mediaRenderer) RenderPPTXBytes(ctx context.Context, spec interface{}) ([]byte, error) {
+	noise := func(n int64) []byte {
+		b := make([]byte, n)
+		rand.New(rand.NewSource(n)).Read(b)
+		return b
+	}
+	var pptx bytes.Buffer
+	zw := zip.NewWriter(&pptx)
+	for _, part := range []struct {
+		name string
+		data []byte
+	}{
+		{"[Content_Types].xml", []byte(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`)},
+		{"ppt/slides/slide1.xml", []byte(`<p:sld/>`)},
+		{"ppt/slides/_rels/slide1.xml.rels", []byte(`<Relationships><Relationship Id="rId1" Type="video" Target="../media/media1.mp4"/></Relationships>`)},
+		{"ppt/media/media1.mp4", noise(1_200_000)},
+		{"ppt/media/image1.png", noise(600_000)},
+	} {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(part.data); err != nil {
+			return nil, err
+		}
+	}
+	err := zw.Close()
+	return pptx.Bytes(), err
+}
+
+func TestWorker_DeckExport_SizeBudget(t *testing.T) {
+	memStore := memory.New()
+	storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	w := New(memStore, &mediaRenderer{}, storage, nil)
+	w.SpoolDir = t.TempDir()
+
+	ctx := context.Background()
+	orgID := "org-budget"
+	_, err := memStore.Decks().CreateDeckVersion(ctx, store.DeckVersion{ID: "dv-budget", Deck: "deck-budget", OrgID: orgID, VersionNo: 1, SpecJSON: json.RawMessage(`{"layouts":[]}`)})
+	require.NoError(t, err)
+
+	export := func(id, targetSize string) (store.Job, store.Asset) {
+		metadata := store.JSONMap{"targetSize": targetSize}
+		job := store.Job{ID: id, OrgID: orgID, Type: store.JobExport, Status: store.JobQueued, InputRef: "dv-budget", Metadata: &metadata}
+		_, err := memStore.Jobs().Enqueue(ctx, job)
+		require.NoError(t, err)
+		require.NoError(t, w.processJob(ctx, job))
+		got, _, _ := memStore.Jobs().Get(ctx, orgID, id)
+		require.Equal(t, store.JobDone, got.Status, got.Error)
+		asset, ok, err := memStore.Assets().Get(ctx, orgID, got.OutputRef)
+		require.NoError(t, err)
+		require.True(t, ok)
+		return got, asset
+	}
+
+	// Dropping the unused image is enough for 1.5 MB.
+	job, asset := export("job-fits", "1572864")
+	assert.Equal(t, int64(1572864), asset.BudgetBytes)
+	assert.Greater(t, asset.OriginalSizeBytes, int64(1_800_000))
+	assert.Less(t, asset.SizeBytes, asset.BudgetBytes)
+	assert.Empty(t, (*job.Metadata)["sizeWarning"])
+	data, err := storage.Download(ctx, asset.Path)
+	require.NoError(t, err)
+	assert.Equal(t, assets.Checksum(data), asset.SHA256)
+
+	// The video can't be compressed, so 1 MB is out of reach; the export
+	// is kept, with a warning.
+	job, asset = export("job-over", "1048576")
+	assert.Greater(t, asset.SizeBytes, asset.BudgetBytes)
+	assert.Contains(t, (*job.Metadata)["sizeWarning"], "over its 1.0 MB size target")
+}
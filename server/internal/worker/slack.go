@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/slack"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// slackLinkTTL is how long the download link in an export notice works.
+// Notices are often read hours later, so it is much longer than the API's
+// 15 minute links.
+const slackLinkTTL = 24 * time.Hour
+
+// notifySlackExport posts a finished export and its download link to the
+// org's Slack channel, if the org asked for export notices.
+func (w *Worker) notifySlackExport(ctx context.Context, job store.Job) {
+	cfg := w.slackConfig(ctx, job.OrgID)
+	if cfg == nil || !cfg.NotifyExports {
+		return
+	}
+
+	asset, ok, err := w.store.Assets().Get(ctx, job.OrgID, job.OutputRef)
+	if err != nil || !ok {
+		logger.Jobs().Warn("slack_export_asset_missing", "job_id", job.ID, "output_ref", job.OutputRef, "error", err)
+		return
+	}
+	name := asset.Filename
+	if name == "" {
+		name = asset.ID
+	}
+	text := fmt.Sprintf(":white_check_mark: Export ready: *%s*", slack.Escape(name))
+	if url, err := w.storage.GetURL(assets.WithRegion(ctx, asset.Region), asset.Path, slackLinkTTL); err == nil {
+		text += fmt.Sprintf(" <%s|Download> (link expires in %d hours)", url, int(slackLinkTTL.Hours()))
+	} else {
+		logger.Jobs().Warn("slack_export_link_failed", "job_id", job.ID, "error", err)
+	}
+	w.postSlack(ctx, job, cfg, text)
+}
+
+// notifySlackFailure posts a job that has run out of retries, if the org
+// asked for failure notices.
+func (w *Worker) notifySlackFailure(ctx context.Context, job store.Job) {
+	// Timeouts are a common failure, and they leave ctx already expired.
+	ctx = context.WithoutCancel(ctx)
+	cfg := w.slackConfig(ctx, job.OrgID)
+	if cfg == nil || !cfg.NotifyFailures {
+		return
+	}
+	text := fmt.Sprintf(":x: %s job `%s` failed after %d retries: %s", job.Type, job.ID, job.RetryCount, slack.Escape(job.Error))
+	w.postSlack(ctx, job, cfg, text)
+}
+
+func (w *Worker) slackConfig(ctx context.Context, orgID string) *store.SlackIntegration {
+	if w.Slack == nil {
+		return nil
+	}
+	org, err := w.store.Organizations().GetOrganization(ctx, orgID)
+	if err != nil {
+		return nil
+	}
+	return org.Slack
+}
+
+// postSlack sends a notice, logging failures: a Slack outage must not fail
+// or retry the job.
+func (w *Worker) postSlack(ctx context.Context, job store.Job, cfg *store.SlackIntegration, text string) {
+	if err := w.Slack.Post(ctx, cfg, slack.Message{Text: text}); err != nil {
+		logger.LogError(ctx, "worker", "post_slack_notice", err, "job_id", job.ID, "org_id", job.OrgID)
+	}
+}
@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/slack"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+func TestWorker_SlackNotices(t *testing.T) {
+	var posted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.Message
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		posted = append(posted, msg.Text)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	memStore := memory.New()
+	storage, err := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	require.NoError(t, err)
+	w := New(memStore, assets.NewGoPPTXRenderer(), storage, ai.NewAIService(memStore))
+
+	require.NoError(t, memStore.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-1", Name: "Org"}))
+	_, err = memStore.Assets().Create(ctx, store.Asset{ID: "asset-1", OrgID: "org-1", Type: store.AssetPPTX, Path: "asset-1.pptx", Filename: "Q3 <final>.pptx"})
+	require.NoError(t, err)
+	job := store.Job{ID: "job-1", OrgID: "org-1", Type: store.JobExport, Status: store.JobDone, OutputRef: "asset-1"}
+
+	// Nothing is posted until the worker has a client and the org opts in.
+	w.notifySlackExport(ctx, job)
+	w.Slack = slack.NewClient()
+	w.notifySlackExport(ctx, job)
+	assert.Empty(t, posted)
+
+	require.NoError(t, memStore.Organizations().SetSlackIntegration(ctx, "org-1", &store.SlackIntegration{WebhookURL: srv.URL, NotifyExports: true}))
+	w.notifySlackExport(ctx, job)
+	require.Len(t, posted, 1)
+	assert.Contains(t, posted[0], "Export ready: *Q3 &lt;final&gt;.pptx*")
+	assert.Contains(t, posted[0], "|Download>")
+
+	job.Status, job.Error, job.RetryCount = store.JobDeadLetter, "renderer timed out", 3
+	w.notifySlackFailure(ctx, job)
+	assert.Len(t, posted, 1, "failure notices are off")
+
+	require.NoError(t, memStore.Organizations().SetSlackIntegration(ctx, "org-1", &store.SlackIntegration{WebhookURL: srv.URL, NotifyFailures: true}))
+	w.notifySlackFailure(ctx, job)
+	require.Len(t, posted, 2)
+	assert.Equal(t, ":x: export job `job-1` failed after 3 retries: renderer timed out", posted[1])
+}
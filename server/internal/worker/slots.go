@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"sort"
+	"sync"
+)
+
+// slotPool tracks which jobs are running so the poll loop neither exceeds the
+// worker's concurrency nor starts a job that is already in flight.
+type slotPool struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	running map[string]bool
+	normal  int // running jobs that aren't high priority
+	active  sync.WaitGroup
+}
+
+// acquire claims a slot for jobID. Jobs that aren't high priority may only
+// use concurrency-reserved slots. With block set it waits for a slot instead
+// of giving up; it still returns false for a job that is already running.
+func (p *slotPool) acquire(jobID string, high bool, concurrency, reserved int, block bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cond == nil {
+		p.cond = sync.NewCond(&p.mu)
+		p.running = map[string]bool{}
+	}
+	for {
+		if p.running[jobID] {
+			return false
+		}
+		if len(p.running) < concurrency && (high || p.normal < concurrency-reserved) {
+			p.running[jobID] = true
+			if !high {
+				p.normal++
+			}
+			p.active.Add(1)
+			return true
+		}
+		if !block {
+			return false
+		}
+		p.cond.Wait()
+	}
+}
+
+func (p *slotPool) release(jobID string, high bool) {
+	p.mu.Lock()
+	delete(p.running, jobID)
+	if !high {
+		p.normal--
+	}
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	p.active.Done()
+}
+
+// ids returns the jobs holding a slot.
+func (p *slotPool) ids() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]string, 0, len(p.running))
+	for id := range p.running {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// holds reports whether jobID holds a slot.
+func (p *slotPool) holds(jobID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.running[jobID]
+}
+
+// wait blocks until every acquired slot has been released.
+func (p *slotPool) wait() {
+	p.active.Wait()
+}
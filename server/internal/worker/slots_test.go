@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlotPool_ReservesSlotsForHighPriority(t *testing.T) {
+	var p slotPool
+	const concurrency, reserved = 3, 1
+
+	assert.True(t, p.acquire("bulk-1", false, concurrency, reserved, false))
+	assert.True(t, p.acquire("bulk-2", false, concurrency, reserved, false))
+	assert.False(t, p.acquire("bulk-3", false, concurrency, reserved, false), "last slot is reserved")
+	assert.False(t, p.acquire("bulk-1", false, concurrency, reserved, false), "already running")
+
+	assert.True(t, p.acquire("preview-1", true, concurrency, reserved, false))
+	assert.False(t, p.acquire("preview-2", true, concurrency, reserved, false), "pool is full")
+
+	p.release("bulk-1", false)
+	assert.True(t, p.acquire("preview-2", true, concurrency, reserved, false), "high priority may use unreserved slots")
+
+	for _, id := range []string{"bulk-2", "preview-1", "preview-2"} {
+		p.release(id, id != "bulk-2")
+	}
+	p.wait()
+}
+
+func TestWorker_SlotLimits(t *testing.T) {
+	w := &Worker{}
+	c, r := w.slotLimits()
+	assert.Equal(t, 1, c)
+	assert.Equal(t, 0, r)
+
+	w.Concurrency, w.HighPrioritySlots = 4, 9
+	c, r = w.slotLimits()
+	assert.Equal(t, 4, c)
+	assert.Equal(t, 3, r)
+}
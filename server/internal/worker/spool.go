@@ -0,0 +1,109 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const (
+	defaultUploadAttempts = 3
+	defaultUploadBackoff  = 500 * time.Millisecond
+)
+
+// Rendered output is spooled to local disk before upload. If object storage
+// is briefly unavailable, the job retry (or a restarted worker) re-uploads the
+// spooled bytes instead of re-running the expensive render. The spool path is
+// derived from the job ID so no extra bookkeeping has to survive a restart.
+
+func (w *Worker) spoolDir() string {
+	if w.SpoolDir != "" {
+		return w.SpoolDir
+	}
+	return filepath.Join(os.TempDir(), "cms-ai-spool")
+}
+
+func (w *Worker) spoolPath(job store.Job) string {
+	return filepath.Join(w.spoolDir(), job.ID+".spool")
+}
+
+// renderWithSpool returns previously spooled output for the job if present,
+// otherwise runs render and spools the result before returning it.
+func (w *Worker) renderWithSpool(ctx context.Context, job store.Job, render func() ([]byte, error)) ([]byte, error) {
+	path := w.spoolPath(job)
+	if data, err := os.ReadFile(path); err == nil && len(data) > 0 {
+		logger.Jobs().Info("render_resumed_from_spool", "job_id", job.ID, "bytes", len(data))
+		return data, nil
+	}
+
+	renderDone := timeStep(ctx, stepRender)
+	data, err := render()
+	renderDone()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(w.spoolDir(), 0o755); err != nil {
+		logger.Jobs().Warn("spool_dir_unavailable", "job_id", job.ID, "error", err)
+		return data, nil
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		// Spooling is an optimisation; a failed write only costs a re-render on retry.
+		logger.Jobs().Warn("spool_write_failed", "job_id", job.ID, "error", err)
+	}
+	return data, nil
+}
+
+// clearSpool removes the job's spooled output once it is uploaded or the job is abandoned.
+func (w *Worker) clearSpool(job store.Job) {
+	if err := os.Remove(w.spoolPath(job)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Jobs().Warn("spool_cleanup_failed", "job_id", job.ID, "error", err)
+	}
+}
+
+// uploadWithRetry retries only the upload step with exponential backoff. The
+// file goes to the job's org's storage region, which the returned metadata
+// records.
+func (w *Worker) uploadWithRetry(ctx context.Context, job store.Job, key string, data []byte, contentType string) (*assets.ObjectMetadata, error) {
+	defer timeStep(ctx, stepUpload)()
+	ctx, err := assets.ForOrg(ctx, w.store.Organizations(), job.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	attempts := w.UploadAttempts
+	if attempts <= 0 {
+		attempts = defaultUploadAttempts
+	}
+	backoff := w.UploadBackoff
+	if backoff <= 0 {
+		backoff = defaultUploadBackoff
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		metadata, err := w.storage.Upload(ctx, key, data, contentType)
+		if err == nil {
+			metadata.Region = assets.RegionFrom(ctx)
+			return metadata, nil
+		}
+		lastErr = err
+		logger.Jobs().Warn("asset_upload_failed", "job_id", job.ID, "attempt", attempt, "max_attempts", attempts, "error", err)
+		if attempt == attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return nil, fmt.Errorf("upload failed after %d attempts: %w", attempts, lastErr)
+}
@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+const defaultHeartbeatInterval = 15 * time.Second
+
+// Steps timed for a job's stepTimingsMs metadata. Steps that run more than
+// once in an attempt, such as renders in a bulk export, add up.
+const (
+	stepAI     = "ai"
+	stepRender = "render"
+	stepUpload = "upload"
+)
+
+// stepTimingsKey is the job metadata key holding the last attempt's step
+// durations: a JSON object of milliseconds per step, plus "total".
+const stepTimingsKey = "stepTimingsMs"
+
+type stepTimingsCtxKey struct{}
+
+// stepTimings collects how long each step of one job attempt took.
+type stepTimings struct {
+	mu sync.Mutex
+	ms map[string]int64
+}
+
+func withStepTimings(ctx context.Context) (context.Context, *stepTimings) {
+	t := &stepTimings{ms: map[string]int64{}}
+	return context.WithValue(ctx, stepTimingsCtxKey{}, t), t
+}
+
+// timeStep starts timing step for the job attempt running under ctx; call
+// the returned func when the step ends.
+func timeStep(ctx context.Context, step string) func() {
+	t, _ := ctx.Value(stepTimingsCtxKey{}).(*stepTimings)
+	if t == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		t.mu.Lock()
+		t.ms[step] += time.Since(start).Milliseconds()
+		t.mu.Unlock()
+	}
+}
+
+// record puts the timings and the attempt's total on job's metadata and
+// returns them for logging.
+func (t *stepTimings) record(job *store.Job, total time.Duration) map[string]int64 {
+	t.mu.Lock()
+	out := make(map[string]int64, len(t.ms)+1)
+	for step, ms := range t.ms {
+		out[step] = ms
+	}
+	t.mu.Unlock()
+	out["total"] = total.Milliseconds()
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return out
+	}
+	// The map may be shared with copies of the job, so write a new one.
+	m := store.JSONMap{}
+	if job.Metadata != nil {
+		for k, v := range *job.Metadata {
+			m[k] = v
+		}
+	}
+	m[stepTimingsKey] = string(b)
+	job.Metadata = &m
+	return out
+}
+
+func (w *Worker) heartbeatInterval() time.Duration {
+	if w.HeartbeatInterval <= 0 {
+		return defaultHeartbeatInterval
+	}
+	return w.HeartbeatInterval
+}
+
+// keepAlive stamps the job as alive until ctx ends, so a long render shows
+// up as running rather than stuck.
+func (w *Worker) keepAlive(ctx context.Context, jobID string) {
+	ticker := time.NewTicker(w.heartbeatInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.store.Jobs().Heartbeat(ctx, jobID); err != nil && ctx.Err() == nil {
+				logger.LogError(ctx, "worker", "job_heartbeat", err, "job_id", jobID)
+			}
+		}
+	}
+}
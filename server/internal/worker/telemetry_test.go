@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+func TestWorker_HeartbeatsAndStepTimings(t *testing.T) {
+	memStore := memory.New()
+	storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local"})
+	r := newGateRenderer()
+	w := New(memStore, r, storage, ai.NewAIService(memStore))
+	w.SpoolDir = t.TempDir()
+	w.HeartbeatInterval = 10 * time.Millisecond
+	job := startExportJob(t, memStore, w, r)
+	ctx := context.Background()
+
+	require.Eventually(t, func() bool {
+		got, _, _ := memStore.Jobs().Get(ctx, job.OrgID, job.ID)
+		return got.HeartbeatAt != nil
+	}, 5*time.Second, 10*time.Millisecond, "a long render must heartbeat")
+
+	close(r.release)
+	require.NoError(t, w.Shutdown(ctx))
+
+	got, _, err := memStore.Jobs().Get(ctx, job.OrgID, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, store.JobDone, got.Status)
+	require.NotNil(t, got.Metadata)
+	var timings map[string]int64
+	require.NoError(t, json.Unmarshal([]byte((*got.Metadata)[stepTimingsKey]), &timings))
+	assert.Contains(t, timings, stepRender)
+	assert.Contains(t, timings, stepUpload)
+	assert.NotContains(t, timings, stepAI, "export jobs make no AI call")
+	assert.GreaterOrEqual(t, timings["total"], timings[stepRender])
+	assert.GreaterOrEqual(t, timings[stepRender], int64(10), "render waited for at least one heartbeat")
+}
+
+func TestStepTimings_AddUpRepeatedSteps(t *testing.T) {
+	ctx, timings := withStepTimings(context.Background())
+	for range 2 {
+		done := timeStep(ctx, stepRender)
+		time.Sleep(5 * time.Millisecond)
+		done()
+	}
+	timeStep(context.Background(), stepAI)() // no collector: ignored
+
+	shared := store.JSONMap{"filename": "deck.pptx"}
+	job := store.Job{Metadata: &shared}
+	out := timings.record(&job, 20*time.Millisecond)
+
+	assert.GreaterOrEqual(t, out[stepRender], int64(10))
+	assert.Equal(t, int64(20), out["total"])
+	assert.Equal(t, "deck.pptx", (*job.Metadata)["filename"])
+	assert.NotContains(t, shared, stepTimingsKey, "metadata shared with other copies of the job is left alone")
+}
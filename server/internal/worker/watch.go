@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ziyad/cms-ai/server/internal/store"
+)
+
+// jobWatchers wakes the callers watching a job whenever the worker saves it.
+type jobWatchers struct {
+	mu   sync.Mutex
+	subs map[string]map[chan struct{}]struct{}
+}
+
+// Watch returns a channel that receives whenever this worker saves the job,
+// and a func that stops watching. Saves in quick succession may arrive as
+// one receive, and changes made elsewhere, by another instance or the API,
+// aren't reported, so watchers still re-read the job now and then.
+func (w *Worker) Watch(jobID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	w.watchers.mu.Lock()
+	if w.watchers.subs == nil {
+		w.watchers.subs = map[string]map[chan struct{}]struct{}{}
+	}
+	if w.watchers.subs[jobID] == nil {
+		w.watchers.subs[jobID] = map[chan struct{}]struct{}{}
+	}
+	w.watchers.subs[jobID][ch] = struct{}{}
+	w.watchers.mu.Unlock()
+
+	return ch, func() {
+		w.watchers.mu.Lock()
+		defer w.watchers.mu.Unlock()
+		delete(w.watchers.subs[jobID], ch)
+		if len(w.watchers.subs[jobID]) == 0 {
+			delete(w.watchers.subs, jobID)
+		}
+	}
+}
+
+// saveJob writes job to the store and wakes its watchers.
+func (w *Worker) saveJob(ctx context.Context, job store.Job) (store.Job, error) {
+	saved, err := w.store.Jobs().Update(ctx, job)
+	if err == nil {
+		w.watchers.notify(job.ID)
+	}
+	return saved, err
+}
+
+func (ws *jobWatchers) notify(jobID string) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	for ch := range ws.subs[jobID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
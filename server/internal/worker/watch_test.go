@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/store/memory"
+)
+
+func TestWorker_WatchWakesOnSave(t *testing.T) {
+	memStore := memory.New()
+	storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local"})
+	w := New(memStore, assets.NewGoPPTXRenderer(), storage, ai.NewAIService(memStore))
+
+	ctx := context.Background()
+	_, err := memStore.Jobs().Enqueue(ctx, store.Job{ID: "job-watched", OrgID: "org-1", Type: "unsupported", Status: store.JobQueued})
+	require.NoError(t, err)
+
+	watched, stop := w.Watch("job-watched")
+	defer stop()
+	stopped, stopOther := w.Watch("job-watched")
+	stopOther()
+
+	w.processJobs()
+
+	select {
+	case <-watched:
+	case <-time.After(time.Second):
+		t.Fatal("watcher wasn't woken when the worker saved the job")
+	}
+	select {
+	case <-stopped:
+		t.Fatal("stopped watcher was woken")
+	default:
+	}
+
+	stop()
+	w.watchers.mu.Lock()
+	assert.Empty(t, w.watchers.subs)
+	w.watchers.mu.Unlock()
+}
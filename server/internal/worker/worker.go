@@ -3,17 +3,29 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/ziyad/cms-ai/server/internal/ai"
+	"github.com/ziyad/cms-ai/server/internal/alerts"
 	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/connectors"
+	"github.com/ziyad/cms-ai/server/internal/imagegen"
 	"github.com/ziyad/cms-ai/server/internal/logger"
+	"github.com/ziyad/cms-ai/server/internal/policy"
 	"github.com/ziyad/cms-ai/server/internal/queue"
+	"github.com/ziyad/cms-ai/server/internal/slack"
 	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
+	"github.com/ziyad/cms-ai/server/internal/stock"
 	"github.com/ziyad/cms-ai/server/internal/store"
+	"github.com/ziyad/cms-ai/server/internal/webhooks"
 )
 
 type Worker struct {
@@ -22,11 +34,87 @@ type Worker struct {
 	storage    assets.ObjectStorage
 	aiService  ai.AIServiceInterface
 	stop       chan struct{}
+	stopOnce   sync.Once
 	wg         sync.WaitGroup
 	JobTimeout time.Duration // max time per job; 0 = default (2 min)
+
+	// jobsCtx is the parent of every job's context; Shutdown cancels it to
+	// interrupt jobs that outlast the drain.
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+
+	SpoolDir       string        // where rendered output waits for upload; "" = $TMPDIR/cms-ai-spool
+	UploadAttempts int           // upload tries per job attempt; 0 = default (3)
+	UploadBackoff  time.Duration // initial delay between upload tries; 0 = default (500ms)
+
+	// HeartbeatInterval is how often a running job is stamped alive; 0 =
+	// default (15s).
+	HeartbeatInterval time.Duration
+
+	// Concurrency is how many jobs run at once; values below 1 mean 1.
+	// HighPrioritySlots of those are kept for JobPriorityHigh work so a bulk
+	// export can't starve previews; it is capped at Concurrency-1.
+	Concurrency       int
+	HighPrioritySlots int
+
+	// RetryOverrides are the environment's changes to the retry policies.
+	// Platform settings in the store are applied over them on every use, so
+	// operators can retune retries without a restart.
+	RetryOverrides map[store.JobType]queue.Override
+
+	// Slack posts export and failure notices for orgs that configured it;
+	// nil disables them.
+	Slack *slack.Client
+
+	// DraftRenderer and HighRenderer render exports at the draft and high
+	// quality profiles; nil falls back to the standard renderer.
+	DraftRenderer assets.Renderer
+	HighRenderer  assets.Renderer
+	// RendererFactory builds the renderer for jobs whose "renderer"
+	// metadata names an engine; nil renders them by quality alone.
+	RendererFactory *assets.RendererFactory
+	// Fonts embeds each org's uploaded fonts in the decks it renders; nil
+	// skips it.
+	Fonts *assets.FontResolver
+	// Connectors fills connector bindings in the decks it renders with live
+	// data; nil leaves them as they are.
+	Connectors *connectors.Resolver
+	// StockImages adds stock photos and icons to decks whose bind job asks
+	// for them; nil skips that step.
+	StockImages *stock.Illustrator
+	// ImageGen runs image generation jobs; nil fails them.
+	ImageGen *imagegen.Pipeline
+
+	// Alerts tells platform operators about dead-lettered jobs; nil
+	// disables it.
+	Alerts *alerts.Notifier
+
+	// DeadLetterRetryInterval is how often dead-lettered jobs that failed
+	// transiently are put back in the queue, each at most
+	// DeadLetterMaxAutoRetries times (0 = default, 3). 0 disables the sweep.
+	DeadLetterRetryInterval  time.Duration
+	DeadLetterMaxAutoRetries int
+	lastSweep                time.Time
+
+	// OrgPurgeInterval is how often deleted orgs past their purge date are
+	// removed with their data and stored objects. 0 disables purging.
+	OrgPurgeInterval time.Duration
+	lastOrgPurge     time.Time
+
+	// ScheduleInterval is how often due schedules are run; see
+	// runDueSchedules. 0 disables them.
+	ScheduleInterval time.Duration
+	lastScheduleRun  time.Time
+
+	slots slotPool
+
+	heartbeat atomic.Int64 // unix nanos of the last poll loop iteration
+
+	watchers jobWatchers
 }
 
 func New(store store.Store, renderer assets.Renderer, storage assets.ObjectStorage, aiService ai.AIServiceInterface) *Worker {
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
 	return &Worker{
 		store:      store,
 		renderer:   renderer,
@@ -34,17 +122,21 @@ func New(store store.Store, renderer assets.Renderer, storage assets.ObjectStora
 		aiService:  aiService,
 		stop:       make(chan struct{}),
 		JobTimeout: 2 * time.Minute,
+		jobsCtx:    jobsCtx,
+		cancelJobs: cancelJobs,
 	}
 }
 
 func (w *Worker) Start() {
+	w.beat()
 	w.wg.Add(1)
 	go w.run()
 }
 
+// Stop stops polling and waits, however long it takes, for in-flight jobs
+// to finish. Use Shutdown to bound the wait.
 func (w *Worker) Stop() {
-	close(w.stop)
-	w.wg.Wait()
+	_ = w.Shutdown(context.Background())
 }
 
 func (w *Worker) run() {
@@ -57,12 +149,41 @@ func (w *Worker) run() {
 		case <-w.stop:
 			return
 		case <-ticker.C:
-			w.processJobs()
+			w.beat()
+			w.recoverOrphaned(context.Background())
+			w.maybeSweepDeadLetter(context.Background(), time.Now())
+			w.maybePurgeDeletedOrgs(context.Background(), time.Now())
+			w.maybeRunSchedules(context.Background(), time.Now())
+			w.dispatchJobs(false)
 		}
 	}
 }
 
+func (w *Worker) beat() {
+	w.heartbeat.Store(time.Now().UnixNano())
+}
+
+// LastHeartbeat reports when the poll loop last ran; zero if never started.
+func (w *Worker) LastHeartbeat() time.Time {
+	ns := w.heartbeat.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// processJobs runs every job that is ready now and returns once they have all
+// finished, waiting for a free slot whenever the pool is full.
 func (w *Worker) processJobs() {
+	w.dispatchJobs(true)
+	w.slots.wait()
+}
+
+// dispatchJobs starts ready jobs in priority order, each on its own slot. The
+// poll loop doesn't block: jobs that find no free slot are picked up on a
+// later tick, which leaves reserved slots open for high-priority work that
+// arrives in the meantime.
+func (w *Worker) dispatchJobs(block bool) {
 	ctx := context.Background()
 
 	// Get all queued jobs and jobs ready for retry
@@ -82,6 +203,7 @@ func (w *Worker) processJobs() {
 	readyRetryJobs := w.filterReadyRetryJobs(ctx, retryJobs)
 
 	allJobs := append(queuedJobs, readyRetryJobs...)
+	sort.SliceStable(allJobs, func(i, j int) bool { return store.JobRunsBefore(allJobs[i], allJobs[j]) })
 
 	if len(allJobs) == 0 {
 		logger.Jobs().Debug("worker_polling_no_jobs")
@@ -90,20 +212,36 @@ func (w *Worker) processJobs() {
 
 	logger.Jobs().Info("worker_processing_jobs", "total", len(allJobs), "queued", len(queuedJobs), "retry", len(readyRetryJobs))
 
+	concurrency, reserved := w.slotLimits()
 	for _, job := range allJobs {
-		if err := w.processJob(ctx, job); err != nil {
-			logger.LogError(ctx, "worker", "process_job", err, "job_id", job.ID)
+		w.beat()
+		high := job.Priority >= store.JobPriorityHigh
+		if !w.slots.acquire(job.ID, high, concurrency, reserved, block) {
+			continue
 		}
+		go func(job store.Job) {
+			defer w.slots.release(job.ID, high)
+			if err := w.processJob(w.jobsCtx, job); err != nil {
+				logger.LogError(ctx, "worker", "process_job", err, "job_id", job.ID)
+			}
+		}(job)
 	}
 }
 
+func (w *Worker) slotLimits() (concurrency, reserved int) {
+	concurrency = max(w.Concurrency, 1)
+	reserved = min(max(w.HighPrioritySlots, 0), concurrency-1)
+	return concurrency, reserved
+}
+
 func (w *Worker) filterReadyRetryJobs(ctx context.Context, jobs []store.Job) []store.Job {
 	var readyJobs []store.Job
 	now := time.Now().UTC()
+	policies := w.retryPolicies(ctx)
 
 	for _, job := range jobs {
-		policy := queue.GetRetryPolicy(string(job.Type))
-		nextRetryDelay := queue.CalculateNextRetryDelay(policy, job.RetryCount)
+		policy := policies.For(job.Type)
+		nextRetryDelay := queue.JitteredDelay(policy, job.RetryCount, job.ID)
 
 		if job.LastRetryAt == nil || job.LastRetryAt.Add(nextRetryDelay).Before(now) {
 			readyJobs = append(readyJobs, job)
@@ -113,6 +251,23 @@ func (w *Worker) filterReadyRetryJobs(ctx context.Context, jobs []store.Job) []s
 	return readyJobs
 }
 
+// retryPolicies resolves the retry policies in force now. If the platform
+// settings can't be read, the environment's and the defaults are used.
+func (w *Worker) retryPolicies(ctx context.Context) queue.PolicySet {
+	settings, err := w.store.RetryPolicies().List(ctx)
+	if err != nil {
+		logger.LogError(ctx, "worker", "load_retry_policies", err)
+	}
+	return queue.Resolve(w.RetryOverrides, settings)
+}
+
+func (w *Worker) jobTimeout() time.Duration {
+	if w.JobTimeout == 0 {
+		return 2 * time.Minute
+	}
+	return w.JobTimeout
+}
+
 // ProcessJobs is a public wrapper for testing
 func (w *Worker) ProcessJobs() {
 	w.processJobs()
@@ -120,18 +275,19 @@ func (w *Worker) ProcessJobs() {
 
 func (w *Worker) processJob(ctx context.Context, job store.Job) error {
 	// Enforce a timeout so jobs don't hang forever (e.g., if Python renderer hangs).
-	timeout := w.JobTimeout
-	if timeout == 0 {
-		timeout = 2 * time.Minute
-	}
-	ctx, cancel := context.WithTimeout(ctx, timeout)
+	ctx, cancel := context.WithTimeout(ctx, w.jobTimeout())
 	defer cancel()
+	ctx, timings := withStepTimings(ctx)
+	started := time.Now()
 
 	// Update job status to Running
 	job.Status = store.JobRunning
-	if _, err := w.store.Jobs().Update(ctx, job); err != nil {
+	if _, err := w.saveJob(ctx, job); err != nil {
 		return fmt.Errorf("failed to update job status to running: %w", err)
 	}
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	defer stopHeartbeat()
+	go w.keepAlive(heartbeatCtx, job.ID)
 
 	var outputRef string
 	var processErr error
@@ -141,7 +297,29 @@ func (w *Worker) processJob(ctx context.Context, job store.Job) error {
 		outputRef, processErr = w.processGenerateJob(ctx, job)
 	case store.JobBind:
 		outputRef, processErr = w.processBindJob(ctx, job)
+	case store.JobBulkExport:
+		outputRef, processErr = w.processBulkExportJob(ctx, job)
+	case store.JobBatchMerge:
+		outputRef, processErr = w.processBatchMergeJob(ctx, job)
+	case store.JobImageGen:
+		outputRef, processErr = w.processImageGenJob(ctx, job)
 	case store.JobRender, store.JobExport:
+		var format string
+		if job.Metadata != nil {
+			format = (*job.Metadata)["format"]
+		}
+		if format == store.ExportFormatBundle {
+			outputRef, processErr = w.processBundleExportJob(ctx, job)
+			break
+		}
+		if format == store.ExportFormatPNG || format == store.ExportFormatGIF {
+			outputRef, processErr = w.processImageExportJob(ctx, job, format)
+			break
+		}
+		if format == store.ExportFormatMarkdown {
+			outputRef, processErr = w.processMarkdownExportJob(ctx, job)
+			break
+		}
 		// Check if it's a deck export (deck version ID) or template export
 		if deckVersion, ok, err := w.store.Decks().GetDeckVersion(ctx, job.OrgID, job.InputRef); err == nil && ok {
 			outputRef, processErr = w.processDeckRenderJob(ctx, job, deckVersion)
@@ -170,21 +348,133 @@ func (w *Worker) processJob(ctx context.Context, job store.Job) error {
 		return w.handleJobFailure(ctx, job, fmt.Errorf("unsupported job type: %s", job.Type))
 	}
 
+	stopHeartbeat()
+	stepMs := timings.record(&job, time.Since(started))
+
 	if processErr != nil {
+		if w.jobsCtx.Err() != nil {
+			// Shutdown cut the job short; that is no fault of the job's, so
+			// it goes back in the queue without using up a retry.
+			w.requeue(context.WithoutCancel(ctx), job, interruptedMsg)
+			return fmt.Errorf("job interrupted by shutdown: %w", processErr)
+		}
 		return w.handleJobFailure(ctx, job, processErr)
 	}
 
 	// Mark job as completed
 	job.Status = store.JobDone
 	job.OutputRef = outputRef
-	if _, err := w.store.Jobs().Update(ctx, job); err != nil {
+	w.dropPrompts(ctx, &job)
+	if _, err := w.saveJob(ctx, job); err != nil {
 		return fmt.Errorf("failed to update job status to done: %w", err)
 	}
 
-	logger.Jobs().Info("job_completed_successfully", "job_id", job.ID, "output_ref", outputRef)
+	logger.Jobs().Info("job_completed_successfully", "job_id", job.ID, "output_ref", outputRef, "step_timings_ms", stepMs)
+	w.recordExportUsage(ctx, job)
+	w.emitCompleted(ctx, job)
 	return nil
 }
 
+// recordExportUsage meters a finished export against the deck or template it
+// exported, so usage counts and export quotas count files made rather than
+// requested. Bulk exports and batch merges count the decks and rows that
+// rendered.
+func (w *Worker) recordExportUsage(ctx context.Context, job store.Job) {
+	m := store.JSONMap{}
+	if job.Metadata != nil {
+		m = *job.Metadata
+	}
+	record := func(targetRef string, quantity int) {
+		_, _ = w.store.Metering().Record(ctx, store.MeteringEvent{ID: newID("met"), OrgID: job.OrgID, UserID: m["userId"], Type: "export", Quantity: quantity, TargetRef: targetRef})
+	}
+	switch job.Type {
+	case store.JobExport:
+		if dv, ok, err := w.store.Decks().GetDeckVersion(ctx, job.OrgID, job.InputRef); err == nil && ok {
+			record(dv.Deck, 1)
+		} else if tv, ok, err := w.store.Templates().GetVersion(ctx, job.OrgID, job.InputRef); err == nil && ok {
+			record(tv.Template, 1)
+		}
+	case store.JobBulkExport:
+		var items []store.BulkExportItem
+		_ = json.Unmarshal([]byte(m["items"]), &items)
+		for _, item := range items {
+			if item.Status == store.JobDone {
+				record(item.DeckID, 1)
+			}
+		}
+	case store.JobBatchMerge:
+		var rows []store.BatchMergeRow
+		_ = json.Unmarshal([]byte(m["rows"]), &rows)
+		rendered := 0
+		for _, row := range rows {
+			if row.Status == store.JobDone {
+				rendered++
+			}
+		}
+		if dv, ok, err := w.store.Decks().GetDeckVersion(ctx, job.OrgID, job.InputRef); err == nil && ok && rendered > 0 {
+			record(dv.Deck, rendered)
+		}
+	}
+}
+
+// emitCompleted notifies the org that job finished, and for exports that the
+// file is ready. Jobs carry the requesting user when the handler recorded
+// one; the rest are attributed to the org, as SCIM changes are.
+func (w *Worker) emitCompleted(ctx context.Context, job store.Job) {
+	actorID := job.OrgID
+	if job.Metadata != nil && (*job.Metadata)["userId"] != "" {
+		actorID = (*job.Metadata)["userId"]
+	}
+	webhooks.Emit(ctx, w.store, actorID, webhooks.Event{
+		Type:  webhooks.EventJobCompleted,
+		OrgID: job.OrgID,
+		Data: map[string]any{
+			"jobId":     job.ID,
+			"jobType":   string(job.Type),
+			"status":    string(job.Status),
+			"inputRef":  job.InputRef,
+			"outputRef": job.OutputRef,
+		},
+	})
+	if job.Type != store.JobExport && job.Type != store.JobBulkExport && job.Type != store.JobBatchMerge {
+		return
+	}
+	format := "pptx"
+	if job.Type == store.JobBulkExport || job.Type == store.JobBatchMerge {
+		format = "zip"
+	} else if job.Metadata != nil && (*job.Metadata)["format"] != "" {
+		format = (*job.Metadata)["format"]
+	}
+	webhooks.Emit(ctx, w.store, actorID, webhooks.Event{
+		Type:  webhooks.EventExportCompleted,
+		OrgID: job.OrgID,
+		Data: map[string]any{
+			"jobId":     job.ID,
+			"format":    format,
+			"inputRef":  job.InputRef,
+			"outputRef": job.OutputRef,
+		},
+	})
+	w.notifySlackExport(ctx, job)
+}
+
+// dropPrompts removes prompts and deck content from a finished job's
+// metadata unless its org has opted in to storing them. If the org can't be
+// read they are dropped.
+func (w *Worker) dropPrompts(ctx context.Context, job *store.Job) {
+	if job.Metadata == nil {
+		return
+	}
+	if org, err := w.store.Organizations().GetOrganization(ctx, job.OrgID); err == nil && org.StoresPrompts() {
+		return
+	}
+	m := maps.Clone(*job.Metadata)
+	for _, key := range store.PromptMetadataKeys {
+		delete(m, key)
+	}
+	job.Metadata = &m
+}
+
 func (w *Worker) processGenerateJob(ctx context.Context, job store.Job) (string, error) {
 	if job.Metadata == nil {
 		return "", fmt.Errorf("missing job metadata")
@@ -193,20 +483,26 @@ func (w *Worker) processGenerateJob(ctx context.Context, job store.Job) (string,
 	prompt := m["prompt"]
 	language := m["language"]
 	tone := m["tone"]
+	slideSize := m["slideSize"]
 	rtl := m["rtl"] == "true"
 	brandKitID := m["brandKitId"]
 	userID := m["userId"]
+	params := generationParamsFromMetadata(m)
 
 	w.updateProgress(ctx, &job, "Analyzing prompt with AI", 20)
 
 	aiReq := ai.GenerationRequest{
-		Prompt:   prompt,
-		Language: language,
-		Tone:     tone,
-		RTL:      rtl,
+		Prompt:    prompt,
+		Language:  language,
+		Tone:      tone,
+		SlideSize: slideSize,
+		RTL:       rtl,
+		Params:    params,
 	}
 
+	aiDone := timeStep(ctx, stepAI)
 	templateSpec, _, err := w.aiService.GenerateTemplateForRequest(ctx, job.OrgID, userID, aiReq, brandKitID)
+	aiDone()
 	if err != nil {
 		return "", fmt.Errorf("AI template generation failed: %w", err)
 	}
@@ -226,6 +522,15 @@ func (w *Worker) processGenerateJob(ctx context.Context, job store.Job) (string,
 		SpecJSON:  json.RawMessage(specJSON),
 		CreatedBy: userID,
 	}
+	if !params.IsZero() {
+		version.GenerationParams = &params
+	}
+	if raw := m["requiredFields"]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &version.RequiredFields); err != nil {
+			logger.Jobs().Warn("invalid_required_fields", "job_id", job.ID, "error", err)
+		}
+	}
+	w.updateProgress(ctx, &job, "Saving template version", 90)
 	createdVer, err := w.store.Templates().CreateVersion(ctx, version)
 	if err != nil {
 		return "", fmt.Errorf("failed to create template version: %w", err)
@@ -251,6 +556,7 @@ func (w *Worker) processBindJob(ctx context.Context, job store.Job) (string, err
 	content := m["content"]
 	userID := m["userId"]
 	deckID := job.InputRef
+	params := generationParamsFromMetadata(m)
 
 	w.updateProgress(ctx, &job, "Summarizing content with AI", 20)
 
@@ -261,9 +567,7 @@ func (w *Worker) processBindJob(ctx context.Context, job store.Job) (string, err
 	}
 
 	var templateSpec spec.TemplateSpec
-	// tv.SpecJSON is type `any`. From pgx it arrives as Go string (not []byte).
-	// json.Marshal(string) double-encodes → "\"...\"" which breaks Unmarshal.
-	specBytes, err := anyToJSONBytes(tv.SpecJSON)
+	specBytes, err := normalize.JSON(tv.SpecJSON)
 	if err != nil {
 		return "", fmt.Errorf("invalid template spec: %w", err)
 	}
@@ -271,10 +575,40 @@ func (w *Worker) processBindJob(ctx context.Context, job store.Job) (string, err
 		return "", fmt.Errorf("invalid template spec: %w", err)
 	}
 
-	boundSpec, _, err := w.aiService.BindDeckSpec(ctx, job.OrgID, userID, &templateSpec, content)
+	aiDone := timeStep(ctx, stepAI)
+	boundSpec, _, err := w.aiService.BindDeckSpec(ctx, job.OrgID, userID, &templateSpec, content, params)
+	aiDone()
 	if err != nil {
 		return "", fmt.Errorf("AI binding failed: %w", err)
 	}
+	// The org's content rules apply to what the AI wrote; a retry may write
+	// it differently.
+	var pol *store.OrgPolicy
+	org, err := w.store.Organizations().GetOrganization(ctx, job.OrgID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return "", fmt.Errorf("load org policy: %w", err)
+	}
+	if err == nil {
+		pol = org.Policy
+	}
+	if err := policy.CheckSpec(pol, *boundSpec); err != nil {
+		return "", err
+	}
+	// Checked before stock images go in, whose attributions may carry
+	// numbers of their own.
+	flags := ai.UnsupportedFigures(boundSpec, &templateSpec, content)
+	if len(flags) > 0 {
+		logger.Jobs().Info("unsupported_figures", "job_id", job.ID, "count", len(flags))
+	}
+
+	if m["stockImages"] == "true" && w.StockImages != nil {
+		w.updateProgress(ctx, &job, "Choosing images", 60)
+		w.illustrate(ctx, job, boundSpec)
+	}
+	w.writeAltText(ctx, job, boundSpec)
+	if n := policy.AddMandatorySlides(pol, boundSpec); n > 0 {
+		logger.Jobs().Info("mandatory_slides_added", "job_id", job.ID, "count", n)
+	}
 
 	w.updateProgress(ctx, &job, "Assembling slides", 70)
 
@@ -284,12 +618,16 @@ func (w *Worker) processBindJob(ctx context.Context, job store.Job) (string, err
 	}
 
 	version := store.DeckVersion{
-		ID:        newID("dv"),
-		Deck:      deckID,
-		OrgID:     job.OrgID,
-		VersionNo: 1,
-		SpecJSON:  json.RawMessage(boundBytes),
-		CreatedBy: userID,
+		ID:             newID("dv"),
+		Deck:           deckID,
+		OrgID:          job.OrgID,
+		VersionNo:      1,
+		SpecJSON:       json.RawMessage(boundBytes),
+		CreatedBy:      userID,
+		ReviewRequired: flags,
+	}
+	if !params.IsZero() {
+		version.GenerationParams = &params
 	}
 	createdVer, err := w.store.Decks().CreateDeckVersion(ctx, version)
 	if err != nil {
@@ -303,30 +641,74 @@ func (w *Worker) processBindJob(ctx context.Context, job store.Job) (string, err
 		deck.LatestVersionNo = 1
 		_, _ = w.store.Decks().UpdateDeck(ctx, deck)
 	}
+	if m["scheduleId"] != "" {
+		w.queueScheduledExport(ctx, job, deck.Name, createdVer)
+	}
 
 	return createdVer.ID, nil
 }
 
+// illustrate adds stock images to a bound deck with the org's provider
+// keys. Images are optional, so failures leave the deck as it was.
+func (w *Worker) illustrate(ctx context.Context, job store.Job, deckSpec *spec.TemplateSpec) {
+	org, err := w.store.Organizations().GetOrganization(ctx, job.OrgID)
+	if err != nil {
+		logger.Jobs().Warn("stock_images_skipped", "job_id", job.ID, "error", err)
+		return
+	}
+	n := w.StockImages.Illustrate(ctx, org.StockMedia, deckSpec)
+	logger.Jobs().Info("stock_images_added", "job_id", job.ID, "count", n)
+}
+
+// generationParamsFromMetadata decodes the AI parameters the API resolved
+// (request overrides on top of org defaults) when the job was enqueued.
+func generationParamsFromMetadata(m store.JSONMap) store.GenerationParams {
+	var params store.GenerationParams
+	if raw := m["generationParams"]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &params); err != nil {
+			logger.Jobs().Warn("invalid_generation_params", "error", err)
+		}
+	}
+	return params
+}
+
+// mergeDataFromMetadata decodes the data an export fills the deck's
+// variables with, if any.
+func mergeDataFromMetadata(m *store.JSONMap) map[string]string {
+	if m == nil || (*m)["mergeData"] == "" {
+		return nil
+	}
+	var data map[string]string
+	if err := json.Unmarshal([]byte((*m)["mergeData"]), &data); err != nil {
+		logger.Jobs().Warn("invalid_merge_data", "error", err)
+		return nil
+	}
+	return data
+}
+
 func (w *Worker) updateProgress(ctx context.Context, job *store.Job, step string, pct int) {
 	job.ProgressStep = step
 	job.ProgressPct = pct
-	_, _ = w.store.Jobs().Update(ctx, *job)
+	_, _ = w.saveJob(ctx, *job)
 }
 
 func (w *Worker) processRenderJob(ctx context.Context, job store.Job, templateVersion store.TemplateVersion) (string, error) {
 	w.updateProgress(ctx, &job, "Generating PowerPoint slides", 20)
 
 	// Normalize spec — pgx returns jsonb as Go string, possibly base64-encoded.
-	normalizedSpec, err := anyToJSONBytes(templateVersion.SpecJSON)
+	normalizedSpec, err := normalize.JSON(templateVersion.SpecJSON)
 	if err != nil {
 		return "", fmt.Errorf("failed to normalize template spec: %w", err)
 	}
 
-	// Render PPTX
-	data, err := w.renderer.RenderPPTXBytes(ctx, json.RawMessage(normalizedSpec))
+	// Render PPTX (or resume from a previous attempt's spooled output)
+	data, err := w.renderWithSpool(ctx, job, func() ([]byte, error) {
+		return w.rendererFor(job).RenderPPTXBytes(ctx, json.RawMessage(normalizedSpec))
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to render PPTX: %w", err)
 	}
+	data, budget, originalSize := fitSizeBudget(job, data)
 
 	w.updateProgress(ctx, &job, "Applying Olama AI themes", 60)
 
@@ -335,20 +717,28 @@ func (w *Worker) processRenderJob(ctx context.Context, job store.Job, templateVe
 	storageKey := assetID + ".pptx"
 
 	// Upload to object storage
-	metadata, err := w.storage.Upload(ctx, storageKey, data, "application/vnd.openxmlformats-officedocument.presentationml.presentation")
+	metadata, err := w.uploadWithRetry(ctx, job, storageKey, data, "application/vnd.openxmlformats-officedocument.presentationml.presentation")
 	if err != nil {
 		return "", fmt.Errorf("failed to upload asset to storage: %w", err)
 	}
+	w.clearSpool(job)
 
 	w.updateProgress(ctx, &job, "Saving to database", 90)
 
 	// Create asset record with storage key
 	asset := store.Asset{
-		ID:    assetID,
-		OrgID: job.OrgID,
-		Type:  store.AssetPPTX,
-		Path:  metadata.Key,
-		Mime:  metadata.ContentType,
+		ID:                assetID,
+		OrgID:             job.OrgID,
+		Type:              store.AssetPPTX,
+		Path:              metadata.Key,
+		Mime:              metadata.ContentType,
+		SizeBytes:         int64(len(data)),
+		SourceJobID:       job.ID,
+		Region:            metadata.Region,
+		SHA256:            assets.Checksum(data),
+		Filename:          jobFilename(job),
+		BudgetBytes:       budget,
+		OriginalSizeBytes: originalSize,
 	}
 	if _, err := w.store.Assets().Create(ctx, asset); err != nil {
 		return "", fmt.Errorf("failed to create asset record: %w", err)
@@ -360,26 +750,28 @@ func (w *Worker) processRenderJob(ctx context.Context, job store.Job, templateVe
 func (w *Worker) processDeckRenderJob(ctx context.Context, job store.Job, deckVersion store.DeckVersion) (string, error) {
 	w.updateProgress(ctx, &job, "Generating deck visuals", 20)
 
-	// CRITICAL: Normalize the spec BEFORE passing to renderer.
-	// pgx returns jsonb as Go string. If GORM wrote []byte, the string is base64.
-	// The renderer's specToJSONBytes should handle this, but we normalize here as
-	// a belt-and-suspenders approach to prevent the Python script from receiving
-	// a base64 string instead of a JSON object.
-	normalizedSpec, err := anyToJSONBytes(deckVersion.SpecJSON)
+	// Validate the spec before it reaches the renderer, so a bad version fails
+	// here with a clear error rather than inside the Python script.
+	normalizedSpec, err := normalize.JSON(deckVersion.SpecJSON)
 	if err != nil {
 		return "", fmt.Errorf("failed to normalize deck spec: %w", err)
 	}
 	logger.Jobs().Info("deck_export_spec_normalized",
 		"job_id", job.ID,
-		"input_type", fmt.Sprintf("%T", deckVersion.SpecJSON),
 		"output_len", len(normalizedSpec),
 		"first50", string(normalizedSpec[:min(50, len(normalizedSpec))]))
 
 	// Render PPTX for deck version — pass normalized JSON bytes
-	data, err := w.renderer.RenderPPTXBytes(ctx, json.RawMessage(normalizedSpec))
+	data, err := w.renderWithSpool(ctx, job, func() ([]byte, error) {
+		return w.rendererFor(job).RenderPPTXBytes(ctx, json.RawMessage(normalizedSpec))
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to render deck PPTX: %w", err)
 	}
+	if data, err = w.addReviewComments(ctx, job, deckVersion, data); err != nil {
+		return "", err
+	}
+	data, budget, originalSize := fitSizeBudget(job, data)
 
 	w.updateProgress(ctx, &job, "Enhancing with AI themes", 60)
 
@@ -388,20 +780,28 @@ func (w *Worker) processDeckRenderJob(ctx context.Context, job store.Job, deckVe
 	storageKey := assetID + ".pptx"
 
 	// Upload to object storage
-	metadata, err := w.storage.Upload(ctx, storageKey, data, "application/vnd.openxmlformats-officedocument.presentationml.presentation")
+	metadata, err := w.uploadWithRetry(ctx, job, storageKey, data, "application/vnd.openxmlformats-officedocument.presentationml.presentation")
 	if err != nil {
 		return "", fmt.Errorf("failed to upload deck asset to storage: %w", err)
 	}
+	w.clearSpool(job)
 
 	w.updateProgress(ctx, &job, "Finalizing export", 90)
 
 	// Create asset record with storage key
 	asset := store.Asset{
-		ID:    assetID,
-		OrgID: job.OrgID,
-		Type:  store.AssetPPTX,
-		Path:  metadata.Key,
-		Mime:  metadata.ContentType,
+		ID:                assetID,
+		OrgID:             job.OrgID,
+		Type:              store.AssetPPTX,
+		Path:              metadata.Key,
+		Mime:              metadata.ContentType,
+		SizeBytes:         int64(len(data)),
+		SourceJobID:       job.ID,
+		Region:            metadata.Region,
+		SHA256:            assets.Checksum(data),
+		Filename:          jobFilename(job),
+		BudgetBytes:       budget,
+		OriginalSizeBytes: originalSize,
 	}
 	if _, err := w.store.Assets().Create(ctx, asset); err != nil {
 		return "", fmt.Errorf("failed to create deck asset record: %w", err)
@@ -412,7 +812,9 @@ func (w *Worker) processDeckRenderJob(ctx context.Context, job store.Job, deckVe
 
 func (w *Worker) processPreviewJob(ctx context.Context, job store.Job, templateVersion store.TemplateVersion) (string, error) {
 	// Generate thumbnails for each slide
+	renderDone := timeStep(ctx, stepRender)
 	thumbnails, err := w.renderer.GenerateSlideThumbnails(ctx, templateVersion.SpecJSON)
+	renderDone()
 	if err != nil {
 		return "", fmt.Errorf("failed to generate slide thumbnails: %w", err)
 	}
@@ -422,6 +824,10 @@ func (w *Worker) processPreviewJob(ctx context.Context, job store.Job, templateV
 	}
 
 	var firstAssetURL string
+	objects, err := assets.ForOrg(ctx, w.store.Organizations(), job.OrgID)
+	if err != nil {
+		return "", err
+	}
 
 	// Store each thumbnail as a separate asset
 	for i, thumbnailData := range thumbnails {
@@ -429,18 +835,24 @@ func (w *Worker) processPreviewJob(ctx context.Context, job store.Job, templateV
 		assetID := fmt.Sprintf("%s-%d-slide-%d.preview.png", job.ID, time.Now().Unix(), i+1)
 
 		// Upload to storage
-		metadata, err := w.storage.Upload(ctx, assetID, thumbnailData, "image/png")
+		uploadDone := timeStep(ctx, stepUpload)
+		metadata, err := w.storage.Upload(objects, assetID, thumbnailData, "image/png")
+		uploadDone()
 		if err != nil {
 			return "", fmt.Errorf("failed to upload preview data for slide %d: %w", i+1, err)
 		}
 
 		// Create preview asset record
 		asset := store.Asset{
-			ID:    assetID,
-			OrgID: job.OrgID,
-			Type:  store.AssetPNG,
-			Path:  metadata.Key,
-			Mime:  "image/png",
+			ID:          assetID,
+			OrgID:       job.OrgID,
+			Type:        store.AssetPNG,
+			Path:        metadata.Key,
+			Mime:        "image/png",
+			SizeBytes:   int64(len(thumbnailData)),
+			SourceJobID: job.ID,
+			Region:      assets.RegionFrom(objects),
+			SHA256:      assets.Checksum(thumbnailData),
 		}
 		if _, err := w.store.Assets().Create(ctx, asset); err != nil {
 			return "", fmt.Errorf("failed to create preview asset record for slide %d: %w", i+1, err)
@@ -458,7 +870,7 @@ func (w *Worker) processPreviewJob(ctx context.Context, job store.Job, templateV
 func (w *Worker) handleJobFailure(ctx context.Context, job store.Job, processErr error) error {
 	errorMsg := processErr.Error()
 	errorType := queue.ClassifyError(processErr)
-	policy := queue.GetRetryPolicy(string(job.Type))
+	policy := w.retryPolicies(ctx).For(job.Type)
 
 	// Use job's MaxRetries if set, otherwise use policy default
 	maxRetries := job.MaxRetries
@@ -470,13 +882,15 @@ func (w *Worker) handleJobFailure(ctx context.Context, job store.Job, processErr
 	logger.Jobs().Warn("job_execution_failed", "job_id", job.ID, "error_type", errorType, "error", errorMsg, "retry_count", job.RetryCount, "max_retries", maxRetries)
 
 	if errorType == queue.ErrorTypePermanent || job.RetryCount >= maxRetries {
-		// Move to dead letter queue
+		// Move to dead letter queue; nothing will resume from the spool now.
+		w.clearSpool(job)
 		job.Status = store.JobDeadLetter
 		job.Error = fmt.Sprintf("%s (Error type: %s, Final retry: %d/%d)", errorMsg, errorType, job.RetryCount, maxRetries)
-		if _, err := w.store.Jobs().Update(ctx, job); err != nil {
+		if _, err := w.saveJob(ctx, job); err != nil {
 			return fmt.Errorf("failed to update job status to dead letter: %w", err)
 		}
 		logger.Jobs().Error("job_moved_to_dead_letter", "job_id", job.ID, "retries", job.RetryCount)
+		w.onDeadLetter(ctx, job)
 		return fmt.Errorf("job moved to dead letter: %s", errorMsg)
 	}
 
@@ -487,11 +901,11 @@ func (w *Worker) handleJobFailure(ctx context.Context, job store.Job, processErr
 	now := time.Now().UTC()
 	job.LastRetryAt = &now
 
-	if _, err := w.store.Jobs().Update(ctx, job); err != nil {
+	if _, err := w.saveJob(ctx, job); err != nil {
 		return fmt.Errorf("failed to update job for retry: %w", err)
 	}
 
-	nextRetryDelay := queue.CalculateNextRetryDelay(policy, job.RetryCount)
+	nextRetryDelay := queue.JitteredDelay(policy, job.RetryCount, job.ID)
 	logger.Jobs().Info("job_scheduled_for_retry", "job_id", job.ID, "retry_no", job.RetryCount, "max_retries", maxRetries, "delay_seconds", nextRetryDelay.Seconds())
 	return fmt.Errorf("job scheduled for retry: %s", errorMsg)
 }
@@ -500,20 +914,53 @@ func (w *Worker) failJob(ctx context.Context, job store.Job, errorMsg string) er
 	return w.handleJobFailure(ctx, job, fmt.Errorf("%s", errorMsg))
 }
 
-// anyToJSONBytes converts an `any` value to JSON bytes safely.
-// Handles the pgx quirk where jsonb columns return as Go string.
-// json.Marshal(string) would double-encode, so we must handle it explicitly.
-func anyToJSONBytes(v any) ([]byte, error) {
-	switch val := v.(type) {
-	case []byte:
-		return assets.NormalizeJSONBytes(val), nil
-	case json.RawMessage:
-		return assets.NormalizeJSONBytes([]byte(val)), nil
-	case string:
-		return assets.NormalizeJSONBytes([]byte(val)), nil
-	default:
-		return json.Marshal(v)
+// rendererFor returns the renderer for job: pickRenderer's choice, writing
+// the spec's alt text and reading order, embedding the org's fonts and, with
+// "adjustContrast", fixing low-contrast theme colors and, with "mergeData",
+// filling the deck's variables.
+func (w *Worker) rendererFor(job store.Job) assets.Renderer {
+	var adjustContrast bool
+	if job.Metadata != nil {
+		adjustContrast = (*job.Metadata)["adjustContrast"] == "true"
+	}
+	r := assets.AccessibilityRenderer(w.pickRenderer(job), func(ctx context.Context, s *spec.TemplateSpec) { w.writeAltText(ctx, job, s) })
+	if data := mergeDataFromMetadata(job.Metadata); data != nil {
+		r = assets.MergeRenderer(r, data)
+	}
+	if adjustContrast {
+		r = assets.ContrastRenderer(r)
+	}
+	r = w.Connectors.Renderer(r, job.OrgID)
+	return w.Fonts.Renderer(r, job.OrgID)
+}
+
+// pickRenderer picks the renderer for job's "renderer" and "quality"
+// metadata. "deterministic" jobs always use the deterministic Go renderer.
+// An engine this worker can't build falls back to the quality profile's
+// renderer.
+func (w *Worker) pickRenderer(job store.Job) assets.Renderer {
+	var quality, engine string
+	if job.Metadata != nil {
+		quality, engine = (*job.Metadata)["quality"], (*job.Metadata)["renderer"]
+		if (*job.Metadata)["deterministic"] == "true" {
+			return assets.DeterministicRenderer(quality)
+		}
+	}
+	renderers := assets.QualityRenderers{Draft: w.DraftRenderer, Standard: w.renderer, High: w.HighRenderer, Factory: w.RendererFactory}
+	picked, err := renderers.Pick(quality, engine)
+	if err != nil {
+		logger.Jobs().Warn("renderer_unavailable", "job_id", job.ID, "renderer", engine, "error", err)
+		return renderers.For(quality)
+	}
+	return picked
+}
+
+// jobFilename is the download name the API chose for an export job, if any.
+func jobFilename(job store.Job) string {
+	if job.Metadata == nil {
+		return ""
 	}
+	return (*job.Metadata)["filename"]
 }
 
 // newID generates a proper UUID (compatible with PostgreSQL uuid columns).
@@ -95,7 +95,7 @@ func TestWorker_ProcessesExportJobsEndToEnd(t *testing.T) {
 		Template:  "worker-test-template",
 		OrgID:     orgID,
 		VersionNo: 1,
-		SpecJSON:  templateSpec,
+		SpecJSON:  rawSpec(t, templateSpec),
 		CreatedBy: "worker-test-user",
 		CreatedAt: time.Now(),
 	}
@@ -244,7 +244,7 @@ func TestWorker_ErrorHandlingAndRetries(t *testing.T) {
 		Template:  "retry-test-template",
 		OrgID:     orgID,
 		VersionNo: 1,
-		SpecJSON:  templateSpec,
+		SpecJSON:  rawSpec(t, templateSpec),
 		CreatedBy: "retry-test-user",
 		CreatedAt: time.Now(),
 	}
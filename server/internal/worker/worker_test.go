@@ -11,10 +11,21 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/ziyad/cms-ai/server/internal/ai"
 	"github.com/ziyad/cms-ai/server/internal/assets"
+	"github.com/ziyad/cms-ai/server/internal/queue"
+	"github.com/ziyad/cms-ai/server/internal/spec"
+	"github.com/ziyad/cms-ai/server/internal/spec/normalize"
 	"github.com/ziyad/cms-ai/server/internal/store"
 	"github.com/ziyad/cms-ai/server/internal/store/memory"
 )
 
+// rawSpec marshals a spec built as Go values into a version's SpecJSON.
+func rawSpec(t *testing.T, v any) json.RawMessage {
+	t.Helper()
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return b
+}
+
 func TestWorker_ProcessJobs(t *testing.T) {
 	// Setup test dependencies
 	memStore := memory.New()
@@ -60,7 +71,7 @@ func TestWorker_ProcessJobs(t *testing.T) {
 		Template:  "template-1",
 		OrgID:     orgID,
 		VersionNo: 1,
-		SpecJSON:  templateSpec,
+		SpecJSON:  rawSpec(t, templateSpec),
 		CreatedBy: "user-1",
 		CreatedAt: time.Now(),
 	}
@@ -204,7 +215,7 @@ func TestWorker_UnsupportedJobType(t *testing.T) {
 		Template:  "template-1",
 		OrgID:     orgID,
 		VersionNo: 1,
-		SpecJSON:  templateSpec,
+		SpecJSON:  rawSpec(t, templateSpec),
 		CreatedBy: "user-1",
 		CreatedAt: time.Now(),
 	}
@@ -296,7 +307,7 @@ func TestWorker_ProcessPreviewJobWithThumbnails(t *testing.T) {
 		Template:  "template-1",
 		OrgID:     "test-org",
 		VersionNo: 1,
-		SpecJSON:  templateSpec,
+		SpecJSON:  rawSpec(t, templateSpec),
 		CreatedBy: "user-1",
 		CreatedAt: time.Now(),
 	}
@@ -365,7 +376,7 @@ func TestWorker_JobRetryAndDeadLetter(t *testing.T) {
 		Template:  "template-123",
 		OrgID:     orgID,
 		VersionNo: 1,
-		SpecJSON:  templateSpec,
+		SpecJSON:  rawSpec(t, templateSpec),
 		CreatedBy: "user-123",
 		CreatedAt: time.Now(),
 	}
@@ -478,7 +489,7 @@ func TestWorker_JobDeduplication(t *testing.T) {
 		Template:  "template-dedup-123",
 		OrgID:     orgID,
 		VersionNo: 1,
-		SpecJSON:  templateSpec,
+		SpecJSON:  rawSpec(t, templateSpec),
 		CreatedBy: "user-123",
 		CreatedAt: time.Now(),
 	}
@@ -631,7 +642,7 @@ func TestWorker_ExportJob_WithMetadata_Roundtrips(t *testing.T) {
 		Template:  "tpl-meta-export",
 		OrgID:     orgID,
 		VersionNo: 1,
-		SpecJSON:  templateSpec,
+		SpecJSON:  rawSpec(t, templateSpec),
 		CreatedBy: "user-1",
 		CreatedAt: time.Now(),
 	}
@@ -700,7 +711,7 @@ func TestWorker_RenderJob_WithMetadata_Preserved(t *testing.T) {
 		Template:  "tpl-render-meta",
 		OrgID:     "org-1",
 		VersionNo: 1,
-		SpecJSON:  templateSpec,
+		SpecJSON:  rawSpec(t, templateSpec),
 		CreatedBy: "user-1",
 		CreatedAt: time.Now(),
 	}
@@ -733,7 +744,8 @@ func TestWorker_RenderJob_WithMetadata_Preserved(t *testing.T) {
 	assert.Equal(t, "req-123", (*got.Metadata)["requestId"])
 }
 
-// TDD: processBindJob must handle string SpecJSON from pgx without double-encoding.
+// TDD: processBindJob must handle a raw JSON SpecJSON, as the Postgres store's
+// spec serializer returns it, without double-encoding.
 func TestWorker_BindJob_StringSpecJSON_NotDoubleEncoded(t *testing.T) {
 	memStore := memory.New()
 	renderer := assets.NewGoPPTXRenderer()
@@ -743,7 +755,6 @@ func TestWorker_BindJob_StringSpecJSON_NotDoubleEncoded(t *testing.T) {
 	ctx := context.Background()
 	orgID := "org-bind-str"
 
-	// String spec — simulates what pgx returns when reading jsonb columns.
 	specString := `{"layouts":[{"name":"title-slide","placeholders":[{"id":"title","type":"text","content":"Hello","geometry":{"x":0.1,"y":0.1,"w":0.8,"h":0.2}}]}]}`
 
 	tv := store.TemplateVersion{
@@ -751,7 +762,7 @@ func TestWorker_BindJob_StringSpecJSON_NotDoubleEncoded(t *testing.T) {
 		Template:  "tpl-bind-str",
 		OrgID:     orgID,
 		VersionNo: 1,
-		SpecJSON:  specString, // Go string, not map — this is what pgx gives us
+		SpecJSON:  json.RawMessage(specString),
 		CreatedBy: "user-1",
 		CreatedAt: time.Now(),
 	}
@@ -791,11 +802,11 @@ func TestWorker_BindJob_StringSpecJSON_NotDoubleEncoded(t *testing.T) {
 	// "invalid template spec" — that would mean double-encoding.
 	if got.Status != store.JobDone {
 		assert.NotContains(t, got.Error, "invalid template spec",
-			"string SpecJSON from pgx must not cause double-encoding; got error: %s", got.Error)
+			"raw SpecJSON must not cause double-encoding; got error: %s", got.Error)
 	}
 }
 
-// TDD: Render with string SpecJSON (pgx returns string for jsonb).
+// TDD: Render with a raw JSON SpecJSON.
 func TestWorker_RenderJob_StringSpecJSON_Works(t *testing.T) {
 	memStore := memory.New()
 	renderer := assets.NewGoPPTXRenderer()
@@ -812,7 +823,7 @@ func TestWorker_RenderJob_StringSpecJSON_Works(t *testing.T) {
 		Template:  "tpl-render-str",
 		OrgID:     orgID,
 		VersionNo: 1,
-		SpecJSON:  specString,
+		SpecJSON:  json.RawMessage(specString),
 		CreatedBy: "user-1",
 		CreatedAt: time.Now(),
 	}
@@ -837,37 +848,10 @@ func TestWorker_RenderJob_StringSpecJSON_Works(t *testing.T) {
 	got, found, err := memStore.Jobs().Get(ctx, orgID, job.ID)
 	require.NoError(t, err)
 	require.True(t, found)
-	assert.Equal(t, store.JobDone, got.Status, "render job with string SpecJSON must succeed; error: %s", got.Error)
+	assert.Equal(t, store.JobDone, got.Status, "render job with raw SpecJSON must succeed; error: %s", got.Error)
 	assert.NotEmpty(t, got.OutputRef)
 }
 
-// Unit test for anyToJSONBytes — must handle all types without double-encoding.
-func TestAnyToJSONBytes(t *testing.T) {
-	jsonStr := `{"layouts":[{"name":"title"}]}`
-
-	tests := []struct {
-		name  string
-		input any
-		want  string
-	}{
-		{"string from pgx", jsonStr, jsonStr},
-		{"[]byte", []byte(jsonStr), jsonStr},
-		{"json.RawMessage", json.RawMessage(jsonStr), jsonStr},
-		{"map", map[string]string{"key": "val"}, `{"key":"val"}`},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := anyToJSONBytes(tt.input)
-			require.NoError(t, err)
-			assert.Equal(t, tt.want, string(got))
-			if tt.want[0] == '{' {
-				assert.Equal(t, byte('{'), got[0], "must not double-encode")
-			}
-		})
-	}
-}
-
 // failingRenderer is a mock renderer that always fails
 type failingRenderer struct{}
 
@@ -883,30 +867,6 @@ func (f *failingRenderer) GenerateSlideThumbnails(ctx context.Context, spec inte
 	return nil, errors.New("simulated thumbnail generation failure")
 }
 
-// TDD RED: anyToJSONBytes must handle base64-encoded strings from pgx.
-// When GORM writes []byte to jsonb, json.Marshal([]byte) base64-encodes it.
-// pgx reads it back as a Go string containing base64. anyToJSONBytes must
-// detect and decode base64 to get the raw JSON.
-func TestAnyToJSONBytes_base64_from_pgx(t *testing.T) {
-	originalJSON := `{"layouts":[{"name":"title","placeholders":[{"id":"t","type":"text"}]}]}`
-
-	// Simulate GORM write: json.Marshal([]byte) → base64 JSON string
-	base64Encoded, err := json.Marshal([]byte(originalJSON))
-	require.NoError(t, err)
-
-	// Simulate pgx read: JSON string → Go string (strips outer quotes)
-	var pgxValue string
-	err = json.Unmarshal(base64Encoded, &pgxValue)
-	require.NoError(t, err)
-	// pgxValue is now a base64 string like "eyJsYXlvdXRzIj..."
-
-	result, err := anyToJSONBytes(pgxValue)
-	require.NoError(t, err)
-	assert.Equal(t, byte('{'), result[0],
-		"must decode base64 to JSON object, got: %s", string(result[:min(50, len(result))]))
-	assert.JSONEq(t, originalJSON, string(result))
-}
-
 // TDD RED: Worker must enforce a timeout on job processing.
 // Without timeout, a hanging renderer keeps the job in "Running" forever.
 func TestWorker_ProcessJob_RespectsContextTimeout(t *testing.T) {
@@ -930,7 +890,7 @@ func TestWorker_ProcessJob_RespectsContextTimeout(t *testing.T) {
 
 	dv := store.DeckVersion{
 		ID: "dv-timeout", Deck: "deck-timeout", OrgID: orgID,
-		VersionNo: 1, SpecJSON: specJSON, CreatedBy: "user-1",
+		VersionNo: 1, SpecJSON: json.RawMessage(specJSON), CreatedBy: "user-1",
 		CreatedAt: time.Now(),
 	}
 	_, err = memStore.Decks().CreateDeckVersion(ctx, dv)
@@ -963,10 +923,11 @@ func TestWorker_ProcessJob_RespectsContextTimeout(t *testing.T) {
 		"timed-out job must not remain in Running state")
 }
 
-// TDD: Deck export with base64-encoded SpecJSON (simulates old data from GORM []byte write).
-// This is the EXACT production bug: GORM wrote json.Marshal([]byte) → base64 to jsonb.
-// pgx reads it back as Go string containing base64. The renderer gets a string, writes
-// it to a temp file, Python json.loads() returns a string → 'str' has no attribute 'get'.
+// TDD: Deck export with base64-encoded SpecJSON (old data from when GORM wrote
+// SpecJSON as []byte, which json.Marshal turns into base64). The Postgres
+// store's serializer decodes it on read, but a spec that reaches the worker
+// still quoted must not go to the renderer as a string: Python json.loads()
+// would return a str → 'str' has no attribute 'get'.
 func TestWorker_DeckExport_Base64SpecJSON_Decoded(t *testing.T) {
 	memStore := memory.New()
 	renderer := assets.NewGoPPTXRenderer()
@@ -979,15 +940,9 @@ func TestWorker_DeckExport_Base64SpecJSON_Decoded(t *testing.T) {
 	// The original JSON spec
 	originalJSON := `{"layouts":[{"name":"title-slide","placeholders":[{"id":"title","type":"text","content":"Hello World","geometry":{"x":0.1,"y":0.1,"w":0.8,"h":0.2}}]}]}`
 
-	// Simulate the GORM/pgx roundtrip:
-	// 1. GORM writes []byte to jsonb → json.Marshal([]byte) → base64 string in jsonb
-	// 2. pgx reads jsonb string → Go string containing base64
+	// What GORM wrote for a []byte spec: a quoted base64 string, "eyJsYXlvdXRzIj..."
 	base64JSON, err := json.Marshal([]byte(originalJSON))
 	require.NoError(t, err)
-	var pgxString string
-	err = json.Unmarshal(base64JSON, &pgxString)
-	require.NoError(t, err)
-	// pgxString is now "eyJsYXlvdXRzIj..." — exactly what production has
 
 	// Create deck and version with the base64-encoded spec
 	deck := store.Deck{ID: "deck-b64", OrgID: orgID, Name: "Base64 Test"}
@@ -996,7 +951,7 @@ func TestWorker_DeckExport_Base64SpecJSON_Decoded(t *testing.T) {
 
 	dv := store.DeckVersion{
 		ID: "dv-b64", Deck: "deck-b64", OrgID: orgID,
-		VersionNo: 1, SpecJSON: pgxString, // Go string with base64 — the production bug
+		VersionNo: 1, SpecJSON: json.RawMessage(base64JSON),
 		CreatedBy: "user-1", CreatedAt: time.Now(),
 	}
 	_, err = memStore.Decks().CreateDeckVersion(ctx, dv)
@@ -1039,3 +994,214 @@ func (s *slowRenderer) GenerateSlideThumbnails(ctx context.Context, spec interfa
 	<-ctx.Done()
 	return nil, ctx.Err()
 }
+
+// countingRenderer returns fixed bytes and counts how often it was asked to render.
+type countingRenderer struct{ renders int }
+
+func (c *countingRenderer) RenderPPTX(ctx context.Context, spec interface{}, outPath string) error {
+	return errors.New("not implemented")
+}
+
+func (c *countingRenderer) RenderPPTXBytes(ctx context.Context, spec interface{}) ([]byte, error) {
+	c.renders++
+	return []byte("rendered-pptx"), nil
+}
+
+func (c *countingRenderer) GenerateSlideThumbnails(ctx context.Context, spec interface{}) ([][]byte, error) {
+	return nil, errors.New("not implemented")
+}
+
+// flakyStorage fails the first failUploads uploads, then delegates.
+type flakyStorage struct {
+	assets.ObjectStorage
+	failUploads int
+}
+
+func (f *flakyStorage) Upload(ctx context.Context, key string, data []byte, contentType string) (*assets.ObjectMetadata, error) {
+	if f.failUploads > 0 {
+		f.failUploads--
+		return nil, errors.New("storage temporarily unavailable")
+	}
+	return f.ObjectStorage.Upload(ctx, key, data, contentType)
+}
+
+func TestWorker_UploadRetry_ResumesFromSpoolWithoutRerender(t *testing.T) {
+	memStore := memory.New()
+	renderer := &countingRenderer{}
+	local, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	storage := &flakyStorage{ObjectStorage: local, failUploads: 4}
+	w := New(memStore, renderer, storage, ai.NewAIService(memStore))
+	w.SpoolDir = t.TempDir()
+	w.UploadAttempts = 3
+	w.UploadBackoff = time.Millisecond
+
+	ctx := context.Background()
+	orgID := "org-spool"
+	_, err := memStore.Templates().CreateVersion(ctx, store.TemplateVersion{ID: "tv-spool", OrgID: orgID, VersionNo: 1, SpecJSON: json.RawMessage(`{"layouts":[]}`)})
+	require.NoError(t, err)
+	job := store.Job{ID: "job-spool", OrgID: orgID, Type: store.JobExport, Status: store.JobQueued, InputRef: "tv-spool"}
+	_, err = memStore.Jobs().Enqueue(ctx, job)
+	require.NoError(t, err)
+
+	// First attempt: render succeeds, all three upload tries fail → job retries with output spooled.
+	_ = w.processJob(ctx, job)
+	got, _, _ := memStore.Jobs().Get(ctx, orgID, job.ID)
+	require.Equal(t, store.JobRetry, got.Status)
+	assert.FileExists(t, w.spoolPath(got))
+	assert.Equal(t, 1, renderer.renders)
+
+	// Second attempt (e.g. after a worker restart): one more failure, then the spooled bytes upload.
+	require.NoError(t, w.processJob(ctx, got))
+	got, _, _ = memStore.Jobs().Get(ctx, orgID, job.ID)
+	assert.Equal(t, store.JobDone, got.Status, got.Error)
+	assert.Equal(t, 1, renderer.renders, "render must not be repeated when spooled output exists")
+	assert.NoFileExists(t, w.spoolPath(got))
+
+	asset, ok, err := memStore.Assets().Get(ctx, orgID, got.OutputRef)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, int64(len("rendered-pptx")), asset.SizeBytes)
+}
+
+func TestWorker_GenerateJob_PersistsGenerationParams(t *testing.T) {
+	memStore := memory.New()
+	storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	w := New(memStore, &countingRenderer{}, storage, ai.NewAIService(memStore))
+
+	ctx := context.Background()
+	orgID := "org-gen-params"
+	_, err := memStore.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-gen-params", OrgID: orgID, Name: "Params"})
+	require.NoError(t, err)
+
+	metadata := store.JSONMap{
+		"prompt":           "A technology pitch deck for a cloud startup",
+		"userId":           "user-1",
+		"generationParams": `{"temperature":0.2,"maxSlides":2}`,
+	}
+	job := store.Job{ID: "job-gen-params", OrgID: orgID, Type: store.JobGenerate, Status: store.JobQueued, InputRef: "tpl-gen-params", Metadata: &metadata}
+	_, err = memStore.Jobs().Enqueue(ctx, job)
+	require.NoError(t, err)
+
+	require.NoError(t, w.processJob(ctx, job))
+	got, _, _ := memStore.Jobs().Get(ctx, orgID, job.ID)
+	require.Equal(t, store.JobDone, got.Status, got.Error)
+
+	ver, ok, err := memStore.Templates().GetVersion(ctx, orgID, got.OutputRef)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotNil(t, ver.GenerationParams)
+	assert.Equal(t, 0.2, *ver.GenerationParams.Temperature)
+	assert.Nil(t, ver.GenerationParams.TopP)
+	assert.Equal(t, 2, *ver.GenerationParams.MaxSlides)
+
+	var generated spec.TemplateSpec
+	b, _ := normalize.JSON(ver.SpecJSON)
+	require.NoError(t, json.Unmarshal(b, &generated))
+	assert.LessOrEqual(t, len(generated.Layouts), 2)
+}
+
+func TestWorker_RetryPoliciesFollowSettings(t *testing.T) {
+	memStore := memory.New()
+	ctx := context.Background()
+	envDelay := time.Hour
+	w := New(memStore, &countingRenderer{}, nil, nil)
+	w.RetryOverrides = map[store.JobType]queue.Override{store.JobExport: {InitialDelay: &envDelay}}
+
+	failedAt := time.Now().UTC().Add(-time.Minute)
+	waiting := []store.Job{{ID: "job-wait", Type: store.JobExport, Status: store.JobRetry, RetryCount: 1, LastRetryAt: &failedAt}}
+	assert.Empty(t, w.filterReadyRetryJobs(ctx, waiting), "the env's hour-long delay applies")
+
+	noRetries, baseMs := 0, int64(1000)
+	_, err := memStore.RetryPolicies().Put(ctx, store.RetryPolicyOverride{JobType: store.JobExport, MaxRetries: &noRetries, BaseDelayMs: &baseMs})
+	require.NoError(t, err)
+	assert.Len(t, w.filterReadyRetryJobs(ctx, waiting), 1, "settings take effect without a restart")
+
+	job, err := memStore.Jobs().Enqueue(ctx, store.Job{ID: "job-once", OrgID: "org-1", Type: store.JobExport, Status: store.JobRunning})
+	require.NoError(t, err)
+	_ = w.handleJobFailure(ctx, job, errors.New("connection refused"))
+	got, _, err := memStore.Jobs().Get(ctx, "org-1", "job-once")
+	require.NoError(t, err)
+	assert.Equal(t, store.JobDeadLetter, got.Status, "maxRetries 0 dead-letters on the first failure")
+}
+
+func TestWorker_RendererForQuality(t *testing.T) {
+	memStore := memory.New()
+	standard, draft := assets.NewGoPPTXRenderer(), assets.NewDraftRenderer()
+	w := New(memStore, standard, nil, ai.NewAIService(memStore))
+	w.DraftRenderer = draft
+
+	job := func(quality string) store.Job {
+		return store.Job{Metadata: &store.JSONMap{"quality": quality}}
+	}
+	assert.Same(t, standard, w.pickRenderer(store.Job{}))
+	assert.Same(t, draft, w.pickRenderer(job(store.ExportQualityDraft)))
+	assert.Same(t, standard, w.pickRenderer(job(store.ExportQualityHigh)), "no high renderer configured")
+}
+
+func TestWorker_RendererForEngine(t *testing.T) {
+	memStore := memory.New()
+	standard := assets.NewDraftRenderer()
+	w := New(memStore, standard, nil, ai.NewAIService(memStore))
+
+	job := store.Job{Metadata: &store.JSONMap{"renderer": assets.RendererPython}}
+	assert.Same(t, standard, w.pickRenderer(job), "no factory: the engine is ignored")
+
+	w.RendererFactory = &assets.RendererFactory{Store: memStore}
+	assert.IsType(t, &assets.PythonPPTXRenderer{}, w.pickRenderer(job))
+	job = store.Job{Metadata: &store.JSONMap{"renderer": "latex"}}
+	assert.Same(t, standard, w.pickRenderer(job), "unknown engines fall back")
+}
+
+// specRenderer keeps the spec it last rendered.
+type specRenderer struct {
+	countingRenderer
+	spec string
+}
+
+func (r *specRenderer) RenderPPTXBytes(ctx context.Context, spec interface{}) ([]byte, error) {
+	b, err := normalize.JSON(spec)
+	r.spec = string(b)
+	return []byte("rendered-pptx"), err
+}
+
+func TestWorker_RendererForMergesData(t *testing.T) {
+	memStore := memory.New()
+	standard := &specRenderer{}
+	w := New(memStore, standard, nil, ai.NewAIService(memStore))
+	job := store.Job{Metadata: &store.JSONMap{"mergeData": `{"customer":"Acme"}`}}
+
+	_, err := w.rendererFor(job).RenderPPTXBytes(context.Background(), json.RawMessage(`{"layouts":[{"placeholders":[{"content":"Hello {{customer}}"}]}]}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"layouts":[{"placeholders":[{"content":"Hello Acme"}]}]}`, standard.spec)
+}
+
+func TestWorker_DropsPromptsUnlessOrgStoresThem(t *testing.T) {
+	memStore := memory.New()
+	storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local", BasePath: t.TempDir()})
+	w := New(memStore, &countingRenderer{}, storage, ai.NewAIService(memStore))
+	ctx := context.Background()
+
+	run := func(orgID string) store.JSONMap {
+		t.Helper()
+		_, err := memStore.Templates().CreateTemplate(ctx, store.Template{ID: "tpl-" + orgID, OrgID: orgID, Name: "Prompts"})
+		require.NoError(t, err)
+		metadata := store.JSONMap{"prompt": "Quarterly review for jane@example.com", "userId": "user-1"}
+		job := store.Job{ID: "job-" + orgID, OrgID: orgID, Type: store.JobGenerate, Status: store.JobQueued, InputRef: "tpl-" + orgID, Metadata: &metadata}
+		_, err = memStore.Jobs().Enqueue(ctx, job)
+		require.NoError(t, err)
+		require.NoError(t, w.processJob(ctx, job))
+		got, _, _ := memStore.Jobs().Get(ctx, orgID, job.ID)
+		require.Equal(t, store.JobDone, got.Status, got.Error)
+		return *got.Metadata
+	}
+
+	require.NoError(t, memStore.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-private", Name: "Private"}))
+	m := run("org-private")
+	assert.NotContains(t, m, "prompt")
+	assert.Equal(t, "user-1", m["userId"])
+
+	require.NoError(t, memStore.Organizations().CreateOrganization(ctx, &store.Organization{ID: "org-keeps", Name: "Keeps"}))
+	_, err := memStore.Organizations().SetSettings(ctx, "org-keeps", &store.OrgSettings{StorePrompts: true})
+	require.NoError(t, err)
+	assert.Equal(t, "Quarterly review for jane@example.com", run("org-keeps")["prompt"])
+}
@@ -0,0 +1,5 @@
+-- Migration 007: Bulk export jobs
+-- Allow the bulk_export job type, which renders several decks into one ZIP.
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_type_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_type_check CHECK (type IN ('render', 'preview', 'export', 'generate', 'bind', 'bulk_export'));
@@ -0,0 +1,24 @@
+-- Migration 009: Version number uniqueness
+-- Version numbers are unique per template and per deck. Databases created by
+-- AutoMigrate never got these constraints, so add them where the existing
+-- data allows; duplicates have to be cleaned up by hand first.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM template_versions GROUP BY template_id, version_no HAVING COUNT(*) > 1
+    ) THEN
+        CREATE UNIQUE INDEX IF NOT EXISTS idx_template_versions_template_version
+            ON template_versions(template_id, version_no);
+    ELSE
+        RAISE WARNING 'template_versions has duplicate version numbers; unique index not created';
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM deck_versions GROUP BY deck_id, version_no HAVING COUNT(*) > 1
+    ) THEN
+        CREATE UNIQUE INDEX IF NOT EXISTS idx_deck_versions_deck_version
+            ON deck_versions(deck_id, version_no);
+    ELSE
+        RAISE WARNING 'deck_versions has duplicate version numbers; unique index not created';
+    END IF;
+END $$;
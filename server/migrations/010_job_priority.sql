@@ -0,0 +1,7 @@
+-- Migration 010: Job priority
+-- Interactive jobs (previews, single exports) run ahead of batch work such as
+-- bulk exports. Higher values run first; 0 is normal.
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_queue_order ON jobs(priority DESC, created_at) WHERE status = 'Queued';
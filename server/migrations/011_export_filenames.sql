@@ -0,0 +1,7 @@
+-- Migration 011: Export file naming
+-- Orgs can set a filename template for exports, and assets remember the name
+-- they are downloaded under.
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS export_filename_template TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE assets ADD COLUMN IF NOT EXISTS filename TEXT NOT NULL DEFAULT '';
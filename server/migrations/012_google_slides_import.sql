@@ -0,0 +1,5 @@
+-- Migration 012: Google Slides import
+-- Orgs store the OAuth refresh token used to read presentations from Google
+-- Slides when importing them as templates.
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS google_refresh_token TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- Migration 013: Slack integration
+-- Orgs can post export and job failure notices to Slack; the settings hold
+-- an incoming webhook URL or bot token and live with the organization.
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS slack JSONB;
@@ -0,0 +1,5 @@
+-- Migration 014: Job heartbeats
+-- Workers stamp heartbeat_at on running jobs while long steps such as renders
+-- are underway, so a stuck job can be told from a slow one.
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMPTZ;
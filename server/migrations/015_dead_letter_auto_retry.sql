@@ -0,0 +1,5 @@
+-- Migration 015: Dead-letter auto-retry
+-- The worker can sweep dead-lettered jobs back into the queue on a schedule;
+-- auto_retries counts those sweeps so each job gets a capped number of them.
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS auto_retries INTEGER NOT NULL DEFAULT 0;
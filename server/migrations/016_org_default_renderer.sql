@@ -0,0 +1,5 @@
+-- Migration 016: Org default renderer
+-- Orgs can pick the renderer engine (go, python or ai) for their exports;
+-- empty keeps the server default.
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS default_renderer TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- Migration 017: Stock media keys
+-- Orgs can bring their own Unsplash and Pexels API keys for stock photo
+-- search; they live with the organization like the Slack settings.
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS stock_media JSONB;
@@ -0,0 +1,6 @@
+-- Migration 018: Image generation jobs
+-- Allow the image_gen job type, which generates slide backgrounds; the
+-- generated_images table itself is created by AutoMigrate.
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_type_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_type_check CHECK (type IN ('render', 'preview', 'export', 'generate', 'bind', 'bulk_export', 'image_gen'));
@@ -0,0 +1,15 @@
+-- Migration 019: Template and deck listing metadata
+-- Description, category, language and a cover image, so template galleries
+-- and deck pickers can show more than a name.
+
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS category TEXT NOT NULL DEFAULT '';
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT '';
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS cover_asset_id UUID;
+CREATE INDEX IF NOT EXISTS idx_templates_category ON templates (category);
+
+ALTER TABLE decks ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE decks ADD COLUMN IF NOT EXISTS category TEXT NOT NULL DEFAULT '';
+ALTER TABLE decks ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT '';
+ALTER TABLE decks ADD COLUMN IF NOT EXISTS cover_asset_id UUID;
+CREATE INDEX IF NOT EXISTS idx_decks_category ON decks (category);
@@ -0,0 +1,5 @@
+-- Migration 020: Org settings
+-- Locale, timezone and the language, tone, direction and slide size that
+-- generation requests default to.
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS settings JSONB;
@@ -0,0 +1,11 @@
+-- Migration 021: User profile
+-- Avatar, editor preferences and the pending address of an email change,
+-- with the hash of the token that confirms it.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS preferences JSONB;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_token_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_token_expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_email_token_hash ON users (email_token_hash);
@@ -0,0 +1,12 @@
+-- Migration 022: Org deletion
+-- Deleted orgs and their templates and decks are kept, unreachable, until
+-- purge_after, when the worker removes them for good.
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS purge_after TIMESTAMPTZ;
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE decks ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_organizations_deleted_at ON organizations (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_templates_deleted_at ON templates (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_decks_deleted_at ON decks (deleted_at);
@@ -0,0 +1,6 @@
+-- Migration 023: Asset storage region
+-- Orgs can keep their files in a configured storage region; each asset
+-- records the region it was stored in so reads go to the right bucket.
+-- Empty is the default backend.
+
+ALTER TABLE assets ADD COLUMN IF NOT EXISTS region TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- Migration 024: Asset checksums
+-- The SHA-256 of each stored object, recorded at upload and checked when
+-- the asset is served. Assets stored before this have none.
+
+ALTER TABLE assets ADD COLUMN IF NOT EXISTS sha256 TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+-- Migration 025: Metering targets
+-- Metering events name the template or deck they are about, so usage can be
+-- counted per template (decks created from it) and per deck (exports).
+
+ALTER TABLE metering_events ADD COLUMN IF NOT EXISTS target_ref TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_metering_org_type_target ON metering_events (org_id, event_type, target_ref);
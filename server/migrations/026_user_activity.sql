@@ -0,0 +1,11 @@
+-- Migration 026: User activity
+-- One row per user and UTC day with an authenticated request, for the
+-- platform dashboard's daily active user counts.
+
+CREATE TABLE IF NOT EXISTS user_activities (
+    user_id UUID NOT NULL,
+    day DATE NOT NULL,
+    PRIMARY KEY (user_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_activities_day ON user_activities (day);
@@ -0,0 +1,5 @@
+-- Migration 027: Template required fields
+-- The inputs a template version needs from decks made from it, checked
+-- against their content data when they are created.
+
+ALTER TABLE template_versions ADD COLUMN IF NOT EXISTS required_fields JSONB;
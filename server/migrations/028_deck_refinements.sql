@@ -0,0 +1,17 @@
+-- Migration 028: Deck refinements
+-- Each instruction given through POST /v1/decks/{id}/refine and the version
+-- it produced, kept as the deck's conversation with the AI.
+
+CREATE TABLE IF NOT EXISTS deck_refinements (
+    id UUID PRIMARY KEY,
+    org_id UUID NOT NULL,
+    deck_id UUID NOT NULL,
+    user_id UUID,
+    instruction TEXT NOT NULL,
+    from_version_id UUID,
+    version_id UUID,
+    model TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_deck_refinements_deck ON deck_refinements (org_id, deck_id, created_at);
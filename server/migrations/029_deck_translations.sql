@@ -0,0 +1,7 @@
+-- Migration 029: Deck translations
+-- A deck made by POST /v1/decks/{id}/translate points at the deck it was
+-- translated from; its language says into what.
+
+ALTER TABLE decks ADD COLUMN IF NOT EXISTS translated_from UUID;
+
+CREATE INDEX IF NOT EXISTS idx_decks_translated_from ON decks (org_id, translated_from);
@@ -0,0 +1,19 @@
+-- Migration 030: Org glossary
+-- Terminology rules injected into AI prompts and enforced on AI output:
+-- preferred terms with the variants they replace, forbidden terms with an
+-- optional replacement, and terms with fixed casing.
+
+CREATE TABLE IF NOT EXISTS glossary_entries (
+    id UUID PRIMARY KEY,
+    org_id UUID NOT NULL,
+    kind TEXT NOT NULL,
+    term TEXT NOT NULL,
+    variants JSONB,
+    replacement TEXT,
+    note TEXT,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_glossary_entries_org ON glossary_entries (org_id, term);
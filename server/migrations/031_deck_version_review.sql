@@ -0,0 +1,5 @@
+-- Migration 031: Deck version review flags
+-- Figures in AI-bound deck text that the deck's content doesn't back up,
+-- listed on the version for a person to check.
+
+ALTER TABLE deck_versions ADD COLUMN IF NOT EXISTS review_required JSONB;
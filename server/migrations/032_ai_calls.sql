@@ -0,0 +1,21 @@
+-- Migration 032: AI call history
+-- One row per call to the AI provider, for debugging regressions in
+-- generation quality and spend. Prompts aren't kept, only their hash.
+
+CREATE TABLE IF NOT EXISTS ai_calls (
+    id UUID PRIMARY KEY,
+    org_id UUID NOT NULL,
+    user_id TEXT,
+    kind TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    model TEXT,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    tokens INTEGER NOT NULL DEFAULT 0,
+    cost DOUBLE PRECISION NOT NULL DEFAULT 0,
+    success BOOLEAN NOT NULL,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_calls_org_created ON ai_calls (org_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ai_calls_created ON ai_calls (created_at DESC);
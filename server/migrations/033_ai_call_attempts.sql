@@ -0,0 +1,5 @@
+-- Migration 033: AI call attempts
+-- Calls record their place in the model fallback chain of their kind, so a
+-- request served by a fallback model can be told apart.
+
+ALTER TABLE ai_calls ADD COLUMN IF NOT EXISTS attempt INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,29 @@
+-- Migration 034: Scheduled generation
+-- Org-defined schedules that generate a deck from a template version on a
+-- cron cadence and export it. The worker polls for enabled schedules whose
+-- next run is due.
+
+CREATE TABLE IF NOT EXISTS schedules (
+    id UUID PRIMARY KEY,
+    org_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    cron TEXT NOT NULL,
+    timezone TEXT,
+    template_version_id UUID NOT NULL,
+    content TEXT,
+    source_url TEXT,
+    quality TEXT,
+    renderer TEXT,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMPTZ,
+    last_run_at TIMESTAMPTZ,
+    last_deck_id TEXT,
+    last_job_id TEXT,
+    last_error TEXT,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedules_org ON schedules (org_id, name);
+CREATE INDEX IF NOT EXISTS idx_schedules_due ON schedules (next_run_at) WHERE enabled;
@@ -0,0 +1,26 @@
+-- Migration 035: Data connectors
+-- Data sources an org registers for live metrics: a CSV URL, a Google Sheets
+-- range or a SQL query. Decks bind their fields as {{connector.name.field}}
+-- and exports fill them in. The credential and the last fetched data are
+-- encrypted like other sensitive columns when keys are configured.
+
+CREATE TABLE IF NOT EXISTS connectors (
+    id UUID PRIMARY KEY,
+    org_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    url TEXT,
+    spreadsheet_id TEXT,
+    range TEXT,
+    query TEXT,
+    credential TEXT,
+    max_age_seconds INTEGER NOT NULL DEFAULT 0,
+    data JSONB,
+    refreshed_at TIMESTAMPTZ,
+    last_error TEXT,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_connectors_org_name ON connectors (org_id, name);
@@ -0,0 +1,5 @@
+-- Migration 036: Content import credentials
+-- Orgs can create decks from Notion and Confluence pages; the tokens that
+-- reach them live with the organization like the stock media keys.
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS content_import JSONB;
@@ -0,0 +1,6 @@
+-- Migration 037: Export size budgets
+-- The size an export was asked to fit in and its size before compression.
+-- Both are 0 for assets exported without a budget.
+
+ALTER TABLE assets ADD COLUMN IF NOT EXISTS budget_bytes BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE assets ADD COLUMN IF NOT EXISTS original_size_bytes BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- Migration 038: Org compliance policies
+-- Template allowlist, mandatory slides and content rules that decks are
+-- checked against when they are created and bound.
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS policy JSONB;
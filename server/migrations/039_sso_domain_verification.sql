@@ -0,0 +1,9 @@
+-- Migration 039: SSO domain verification and verified emails
+-- A claimed SSO domain maps users into its org only once the org has
+-- published the claim's token in a DNS TXT record, and SSO sign-ins only
+-- attach to local accounts whose email has been verified. Domains claimed
+-- before this migration stay unverified until their owners verify them.
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS sso_domain_token TEXT NOT NULL DEFAULT '';
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS sso_domain_verified_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
@@ -0,0 +1,6 @@
+-- Migration 040: Batch merge jobs
+-- Allow the batch_merge job type, which renders a deck once per data row
+-- into one ZIP.
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_type_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_type_check CHECK (type IN ('render', 'preview', 'export', 'generate', 'bind', 'bulk_export', 'image_gen', 'batch_merge'));
@@ -0,0 +1,10 @@
+-- Migration 041: Asset types and template review statuses
+-- 001_initial only allowed the first asset types and template statuses.
+-- Allow ZIP, font and generated image assets, and the InReview and Approved
+-- review statuses.
+
+ALTER TABLE assets DROP CONSTRAINT IF EXISTS assets_type_check;
+ALTER TABLE assets ADD CONSTRAINT assets_type_check CHECK (type IN ('pptx', 'png', 'file', 'zip', 'font', 'image'));
+
+ALTER TABLE templates DROP CONSTRAINT IF EXISTS templates_status_check;
+ALTER TABLE templates ADD CONSTRAINT templates_status_check CHECK (status IN ('Draft', 'InReview', 'Approved', 'Published', 'Archived'));
@@ -0,0 +1,11 @@
+-- Migration 042: platform admin as a user flag
+-- Platform operators were marked by a PlatformAdmin role on one of their org
+-- memberships, which let anything that writes memberships grant it. It is a
+-- flag on the user now. Existing holders keep it and become owners of the
+-- org the role was recorded in.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS platform_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE users SET platform_admin = TRUE
+ WHERE id IN (SELECT user_id FROM user_orgs WHERE role = 'PlatformAdmin');
+UPDATE user_orgs SET role = 'Owner' WHERE role = 'PlatformAdmin';
@@ -0,0 +1,10 @@
+-- Migration 043: backfill organizations.storage_bytes_used
+-- The asset store keeps this counter up to date as assets are created and
+-- deleted, but orgs that had assets before it was added started at 0, which
+-- left their storage quota unenforced and their usage under-reported.
+-- Recompute it from the assets table.
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS storage_bytes_used BIGINT NOT NULL DEFAULT 0;
+
+UPDATE organizations o
+   SET storage_bytes_used = (SELECT COALESCE(SUM(size_bytes), 0) FROM assets a WHERE a.org_id = o.id);
@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned SQL migrations so the server binary
+// can apply them without the source tree. Files are named NNN_description.sql
+// and run in version order; an applied file must never be edited, since its
+// checksum is recorded in schema_migrations.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
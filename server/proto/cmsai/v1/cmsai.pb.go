@@ -0,0 +1,1618 @@
+// gRPC surface of the CMS AI API for internal services. Every RPC is served
+// by the same handlers as its REST counterpart (noted on each method), so
+// auth, validation, quotas and audit behave identically. Authenticate with
+// "authorization: Bearer <JWT or API key>" metadata.
+//
+// Regenerate the Go code with `make proto`.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: cmsai/v1/cmsai.proto
+
+package cmsaiv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Template struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	OrgId            string                 `protobuf:"bytes,2,opt,name=org_id,json=orgId,proto3" json:"org_id,omitempty"`
+	OwnerUserId      string                 `protobuf:"bytes,3,opt,name=owner_user_id,json=ownerUserId,proto3" json:"owner_user_id,omitempty"`
+	Name             string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Status           string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	CurrentVersionId string                 `protobuf:"bytes,6,opt,name=current_version_id,json=currentVersionId,proto3" json:"current_version_id,omitempty"`
+	LatestVersionNo  int32                  `protobuf:"varint,7,opt,name=latest_version_no,json=latestVersionNo,proto3" json:"latest_version_no,omitempty"`
+	Visibility       string                 `protobuf:"bytes,8,opt,name=visibility,proto3" json:"visibility,omitempty"`
+	CreatedAt        *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt        *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Template) Reset() {
+	*x = Template{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Template) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Template) ProtoMessage() {}
+
+func (x *Template) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Template.ProtoReflect.Descriptor instead.
+func (*Template) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Template) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Template) GetOrgId() string {
+	if x != nil {
+		return x.OrgId
+	}
+	return ""
+}
+
+func (x *Template) GetOwnerUserId() string {
+	if x != nil {
+		return x.OwnerUserId
+	}
+	return ""
+}
+
+func (x *Template) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Template) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Template) GetCurrentVersionId() string {
+	if x != nil {
+		return x.CurrentVersionId
+	}
+	return ""
+}
+
+func (x *Template) GetLatestVersionNo() int32 {
+	if x != nil {
+		return x.LatestVersionNo
+	}
+	return 0
+}
+
+func (x *Template) GetVisibility() string {
+	if x != nil {
+		return x.Visibility
+	}
+	return ""
+}
+
+func (x *Template) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Template) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type TemplateVersion struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	TemplateId string                 `protobuf:"bytes,2,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	VersionNo  int32                  `protobuf:"varint,3,opt,name=version_no,json=versionNo,proto3" json:"version_no,omitempty"`
+	// spec_json is the template spec document as JSON.
+	SpecJson      string                 `protobuf:"bytes,4,opt,name=spec_json,json=specJson,proto3" json:"spec_json,omitempty"`
+	CreatedBy     string                 `protobuf:"bytes,5,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TemplateVersion) Reset() {
+	*x = TemplateVersion{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TemplateVersion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TemplateVersion) ProtoMessage() {}
+
+func (x *TemplateVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TemplateVersion.ProtoReflect.Descriptor instead.
+func (*TemplateVersion) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TemplateVersion) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TemplateVersion) GetTemplateId() string {
+	if x != nil {
+		return x.TemplateId
+	}
+	return ""
+}
+
+func (x *TemplateVersion) GetVersionNo() int32 {
+	if x != nil {
+		return x.VersionNo
+	}
+	return 0
+}
+
+func (x *TemplateVersion) GetSpecJson() string {
+	if x != nil {
+		return x.SpecJson
+	}
+	return ""
+}
+
+func (x *TemplateVersion) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *TemplateVersion) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type Deck struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	Id                      string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	OrgId                   string                 `protobuf:"bytes,2,opt,name=org_id,json=orgId,proto3" json:"org_id,omitempty"`
+	OwnerUserId             string                 `protobuf:"bytes,3,opt,name=owner_user_id,json=ownerUserId,proto3" json:"owner_user_id,omitempty"`
+	Name                    string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	SourceTemplateVersionId string                 `protobuf:"bytes,5,opt,name=source_template_version_id,json=sourceTemplateVersionId,proto3" json:"source_template_version_id,omitempty"`
+	CurrentVersionId        string                 `protobuf:"bytes,6,opt,name=current_version_id,json=currentVersionId,proto3" json:"current_version_id,omitempty"`
+	LatestVersionNo         int32                  `protobuf:"varint,7,opt,name=latest_version_no,json=latestVersionNo,proto3" json:"latest_version_no,omitempty"`
+	Visibility              string                 `protobuf:"bytes,8,opt,name=visibility,proto3" json:"visibility,omitempty"`
+	Content                 string                 `protobuf:"bytes,9,opt,name=content,proto3" json:"content,omitempty"`
+	CreatedAt               *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt               *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *Deck) Reset() {
+	*x = Deck{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Deck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Deck) ProtoMessage() {}
+
+func (x *Deck) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Deck.ProtoReflect.Descriptor instead.
+func (*Deck) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Deck) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Deck) GetOrgId() string {
+	if x != nil {
+		return x.OrgId
+	}
+	return ""
+}
+
+func (x *Deck) GetOwnerUserId() string {
+	if x != nil {
+		return x.OwnerUserId
+	}
+	return ""
+}
+
+func (x *Deck) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Deck) GetSourceTemplateVersionId() string {
+	if x != nil {
+		return x.SourceTemplateVersionId
+	}
+	return ""
+}
+
+func (x *Deck) GetCurrentVersionId() string {
+	if x != nil {
+		return x.CurrentVersionId
+	}
+	return ""
+}
+
+func (x *Deck) GetLatestVersionNo() int32 {
+	if x != nil {
+		return x.LatestVersionNo
+	}
+	return 0
+}
+
+func (x *Deck) GetVisibility() string {
+	if x != nil {
+		return x.Visibility
+	}
+	return ""
+}
+
+func (x *Deck) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Deck) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Deck) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type DeckVersion struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	DeckId        string                 `protobuf:"bytes,2,opt,name=deck_id,json=deckId,proto3" json:"deck_id,omitempty"`
+	VersionNo     int32                  `protobuf:"varint,3,opt,name=version_no,json=versionNo,proto3" json:"version_no,omitempty"`
+	SpecJson      string                 `protobuf:"bytes,4,opt,name=spec_json,json=specJson,proto3" json:"spec_json,omitempty"`
+	CreatedBy     string                 `protobuf:"bytes,5,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeckVersion) Reset() {
+	*x = DeckVersion{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeckVersion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeckVersion) ProtoMessage() {}
+
+func (x *DeckVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeckVersion.ProtoReflect.Descriptor instead.
+func (*DeckVersion) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DeckVersion) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeckVersion) GetDeckId() string {
+	if x != nil {
+		return x.DeckId
+	}
+	return ""
+}
+
+func (x *DeckVersion) GetVersionNo() int32 {
+	if x != nil {
+		return x.VersionNo
+	}
+	return 0
+}
+
+func (x *DeckVersion) GetSpecJson() string {
+	if x != nil {
+		return x.SpecJson
+	}
+	return ""
+}
+
+func (x *DeckVersion) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *DeckVersion) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type Job struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	OrgId         string                 `protobuf:"bytes,2,opt,name=org_id,json=orgId,proto3" json:"org_id,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	InputRef      string                 `protobuf:"bytes,5,opt,name=input_ref,json=inputRef,proto3" json:"input_ref,omitempty"`
+	OutputRef     string                 `protobuf:"bytes,6,opt,name=output_ref,json=outputRef,proto3" json:"output_ref,omitempty"`
+	Error         string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	RetryCount    int32                  `protobuf:"varint,8,opt,name=retry_count,json=retryCount,proto3" json:"retry_count,omitempty"`
+	MaxRetries    int32                  `protobuf:"varint,9,opt,name=max_retries,json=maxRetries,proto3" json:"max_retries,omitempty"`
+	Metadata      map[string]string      `protobuf:"bytes,10,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ProgressStep  string                 `protobuf:"bytes,11,opt,name=progress_step,json=progressStep,proto3" json:"progress_step,omitempty"`
+	ProgressPct   int32                  `protobuf:"varint,12,opt,name=progress_pct,json=progressPct,proto3" json:"progress_pct,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Job) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Job) GetOrgId() string {
+	if x != nil {
+		return x.OrgId
+	}
+	return ""
+}
+
+func (x *Job) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Job) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Job) GetInputRef() string {
+	if x != nil {
+		return x.InputRef
+	}
+	return ""
+}
+
+func (x *Job) GetOutputRef() string {
+	if x != nil {
+		return x.OutputRef
+	}
+	return ""
+}
+
+func (x *Job) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Job) GetRetryCount() int32 {
+	if x != nil {
+		return x.RetryCount
+	}
+	return 0
+}
+
+func (x *Job) GetMaxRetries() int32 {
+	if x != nil {
+		return x.MaxRetries
+	}
+	return 0
+}
+
+func (x *Job) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Job) GetProgressStep() string {
+	if x != nil {
+		return x.ProgressStep
+	}
+	return ""
+}
+
+func (x *Job) GetProgressPct() int32 {
+	if x != nil {
+		return x.ProgressPct
+	}
+	return 0
+}
+
+func (x *Job) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Job) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+// GenerationParams tunes the model; unset fields use the org's defaults.
+type GenerationParams struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Temperature   *float64               `protobuf:"fixed64,1,opt,name=temperature,proto3,oneof" json:"temperature,omitempty"`
+	TopP          *float64               `protobuf:"fixed64,2,opt,name=top_p,json=topP,proto3,oneof" json:"top_p,omitempty"`
+	MaxSlides     *int32                 `protobuf:"varint,3,opt,name=max_slides,json=maxSlides,proto3,oneof" json:"max_slides,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerationParams) Reset() {
+	*x = GenerationParams{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerationParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerationParams) ProtoMessage() {}
+
+func (x *GenerationParams) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerationParams.ProtoReflect.Descriptor instead.
+func (*GenerationParams) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GenerationParams) GetTemperature() float64 {
+	if x != nil && x.Temperature != nil {
+		return *x.Temperature
+	}
+	return 0
+}
+
+func (x *GenerationParams) GetTopP() float64 {
+	if x != nil && x.TopP != nil {
+		return *x.TopP
+	}
+	return 0
+}
+
+func (x *GenerationParams) GetMaxSlides() int32 {
+	if x != nil && x.MaxSlides != nil {
+		return *x.MaxSlides
+	}
+	return 0
+}
+
+type GetTemplateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTemplateRequest) Reset() {
+	*x = GetTemplateRequest{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTemplateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTemplateRequest) ProtoMessage() {}
+
+func (x *GetTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTemplateRequest.ProtoReflect.Descriptor instead.
+func (*GetTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetTemplateRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListTemplatesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// status keeps only templates in that status, e.g. "Published".
+	Status        string `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTemplatesRequest) Reset() {
+	*x = ListTemplatesRequest{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTemplatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTemplatesRequest) ProtoMessage() {}
+
+func (x *ListTemplatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTemplatesRequest.ProtoReflect.Descriptor instead.
+func (*ListTemplatesRequest) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListTemplatesRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type ListTemplatesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Templates     []*Template            `protobuf:"bytes,1,rep,name=templates,proto3" json:"templates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTemplatesResponse) Reset() {
+	*x = ListTemplatesResponse{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTemplatesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTemplatesResponse) ProtoMessage() {}
+
+func (x *ListTemplatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTemplatesResponse.ProtoReflect.Descriptor instead.
+func (*ListTemplatesResponse) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListTemplatesResponse) GetTemplates() []*Template {
+	if x != nil {
+		return x.Templates
+	}
+	return nil
+}
+
+type GenerateTemplateRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Prompt      string                 `protobuf:"bytes,1,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	Name        string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	BrandKitId  string                 `protobuf:"bytes,3,opt,name=brand_kit_id,json=brandKitId,proto3" json:"brand_kit_id,omitempty"`
+	Rtl         bool                   `protobuf:"varint,4,opt,name=rtl,proto3" json:"rtl,omitempty"`
+	Language    string                 `protobuf:"bytes,5,opt,name=language,proto3" json:"language,omitempty"`
+	Tone        string                 `protobuf:"bytes,6,opt,name=tone,proto3" json:"tone,omitempty"`
+	ContentData *structpb.Struct       `protobuf:"bytes,7,opt,name=content_data,json=contentData,proto3" json:"content_data,omitempty"`
+	Params      *GenerationParams      `protobuf:"bytes,8,opt,name=params,proto3" json:"params,omitempty"`
+	// sync generates within the call instead of queueing a job; only short
+	// prompts qualify.
+	Sync          bool `protobuf:"varint,9,opt,name=sync,proto3" json:"sync,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateTemplateRequest) Reset() {
+	*x = GenerateTemplateRequest{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateTemplateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateTemplateRequest) ProtoMessage() {}
+
+func (x *GenerateTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateTemplateRequest.ProtoReflect.Descriptor instead.
+func (*GenerateTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GenerateTemplateRequest) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *GenerateTemplateRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GenerateTemplateRequest) GetBrandKitId() string {
+	if x != nil {
+		return x.BrandKitId
+	}
+	return ""
+}
+
+func (x *GenerateTemplateRequest) GetRtl() bool {
+	if x != nil {
+		return x.Rtl
+	}
+	return false
+}
+
+func (x *GenerateTemplateRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *GenerateTemplateRequest) GetTone() string {
+	if x != nil {
+		return x.Tone
+	}
+	return ""
+}
+
+func (x *GenerateTemplateRequest) GetContentData() *structpb.Struct {
+	if x != nil {
+		return x.ContentData
+	}
+	return nil
+}
+
+func (x *GenerateTemplateRequest) GetParams() *GenerationParams {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *GenerateTemplateRequest) GetSync() bool {
+	if x != nil {
+		return x.Sync
+	}
+	return false
+}
+
+// GenerateTemplateResponse carries the queued job, or for sync requests the
+// generated version.
+type GenerateTemplateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Template      *Template              `protobuf:"bytes,1,opt,name=template,proto3" json:"template,omitempty"`
+	Job           *Job                   `protobuf:"bytes,2,opt,name=job,proto3" json:"job,omitempty"`
+	Version       *TemplateVersion       `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateTemplateResponse) Reset() {
+	*x = GenerateTemplateResponse{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateTemplateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateTemplateResponse) ProtoMessage() {}
+
+func (x *GenerateTemplateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateTemplateResponse.ProtoReflect.Descriptor instead.
+func (*GenerateTemplateResponse) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GenerateTemplateResponse) GetTemplate() *Template {
+	if x != nil {
+		return x.Template
+	}
+	return nil
+}
+
+func (x *GenerateTemplateResponse) GetJob() *Job {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+func (x *GenerateTemplateResponse) GetVersion() *TemplateVersion {
+	if x != nil {
+		return x.Version
+	}
+	return nil
+}
+
+type GetDeckRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeckRequest) Reset() {
+	*x = GetDeckRequest{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeckRequest) ProtoMessage() {}
+
+func (x *GetDeckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeckRequest.ProtoReflect.Descriptor instead.
+func (*GetDeckRequest) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetDeckRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListDecksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDecksRequest) Reset() {
+	*x = ListDecksRequest{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDecksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDecksRequest) ProtoMessage() {}
+
+func (x *ListDecksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDecksRequest.ProtoReflect.Descriptor instead.
+func (*ListDecksRequest) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{12}
+}
+
+type ListDecksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Decks         []*Deck                `protobuf:"bytes,1,rep,name=decks,proto3" json:"decks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDecksResponse) Reset() {
+	*x = ListDecksResponse{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDecksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDecksResponse) ProtoMessage() {}
+
+func (x *ListDecksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDecksResponse.ProtoReflect.Descriptor instead.
+func (*ListDecksResponse) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListDecksResponse) GetDecks() []*Deck {
+	if x != nil {
+		return x.Decks
+	}
+	return nil
+}
+
+type CreateDeckRequest struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	Name                    string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	SourceTemplateVersionId string                 `protobuf:"bytes,2,opt,name=source_template_version_id,json=sourceTemplateVersionId,proto3" json:"source_template_version_id,omitempty"`
+	Content                 string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	// outline, when set, builds the deck immediately instead of queueing an
+	// AI bind job.
+	Outline       *structpb.Struct  `protobuf:"bytes,4,opt,name=outline,proto3" json:"outline,omitempty"`
+	Params        *GenerationParams `protobuf:"bytes,5,opt,name=params,proto3" json:"params,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateDeckRequest) Reset() {
+	*x = CreateDeckRequest{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDeckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDeckRequest) ProtoMessage() {}
+
+func (x *CreateDeckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDeckRequest.ProtoReflect.Descriptor instead.
+func (*CreateDeckRequest) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CreateDeckRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateDeckRequest) GetSourceTemplateVersionId() string {
+	if x != nil {
+		return x.SourceTemplateVersionId
+	}
+	return ""
+}
+
+func (x *CreateDeckRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *CreateDeckRequest) GetOutline() *structpb.Struct {
+	if x != nil {
+		return x.Outline
+	}
+	return nil
+}
+
+func (x *CreateDeckRequest) GetParams() *GenerationParams {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+// CreateDeckResponse carries the first version when an outline was given,
+// otherwise the bind job that will produce it.
+type CreateDeckResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Deck          *Deck                  `protobuf:"bytes,1,opt,name=deck,proto3" json:"deck,omitempty"`
+	Job           *Job                   `protobuf:"bytes,2,opt,name=job,proto3" json:"job,omitempty"`
+	Version       *DeckVersion           `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateDeckResponse) Reset() {
+	*x = CreateDeckResponse{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDeckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDeckResponse) ProtoMessage() {}
+
+func (x *CreateDeckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDeckResponse.ProtoReflect.Descriptor instead.
+func (*CreateDeckResponse) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CreateDeckResponse) GetDeck() *Deck {
+	if x != nil {
+		return x.Deck
+	}
+	return nil
+}
+
+func (x *CreateDeckResponse) GetJob() *Job {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+func (x *CreateDeckResponse) GetVersion() *DeckVersion {
+	if x != nil {
+		return x.Version
+	}
+	return nil
+}
+
+type ExportDeckVersionRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	VersionId string                 `protobuf:"bytes,1,opt,name=version_id,json=versionId,proto3" json:"version_id,omitempty"`
+	// format is "pptx" (the default), "bundle", "png", "gif" or "md".
+	Format        string `protobuf:"bytes,2,opt,name=format,proto3" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportDeckVersionRequest) Reset() {
+	*x = ExportDeckVersionRequest{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportDeckVersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportDeckVersionRequest) ProtoMessage() {}
+
+func (x *ExportDeckVersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportDeckVersionRequest.ProtoReflect.Descriptor instead.
+func (*ExportDeckVersionRequest) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ExportDeckVersionRequest) GetVersionId() string {
+	if x != nil {
+		return x.VersionId
+	}
+	return ""
+}
+
+func (x *ExportDeckVersionRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+type GetJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobRequest) Reset() {
+	*x = GetJobRequest{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobRequest) ProtoMessage() {}
+
+func (x *GetJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobRequest.ProtoReflect.Descriptor instead.
+func (*GetJobRequest) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetJobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type WatchJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchJobRequest) Reset() {
+	*x = WatchJobRequest{}
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchJobRequest) ProtoMessage() {}
+
+func (x *WatchJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cmsai_v1_cmsai_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchJobRequest.ProtoReflect.Descriptor instead.
+func (*WatchJobRequest) Descriptor() ([]byte, []int) {
+	return file_cmsai_v1_cmsai_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *WatchJobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+var File_cmsai_v1_cmsai_proto protoreflect.FileDescriptor
+
+const file_cmsai_v1_cmsai_proto_rawDesc = "" +
+	"\n" +
+	"\x14cmsai/v1/cmsai.proto\x12\bcmsai.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xf1\x02\n" +
+	"\bTemplate\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x15\n" +
+	"\x06org_id\x18\x02 \x01(\tR\x05orgId\x12\"\n" +
+	"\rowner_user_id\x18\x03 \x01(\tR\vownerUserId\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12,\n" +
+	"\x12current_version_id\x18\x06 \x01(\tR\x10currentVersionId\x12*\n" +
+	"\x11latest_version_no\x18\a \x01(\x05R\x0flatestVersionNo\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\b \x01(\tR\n" +
+	"visibility\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xd8\x01\n" +
+	"\x0fTemplateVersion\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1f\n" +
+	"\vtemplate_id\x18\x02 \x01(\tR\n" +
+	"templateId\x12\x1d\n" +
+	"\n" +
+	"version_no\x18\x03 \x01(\x05R\tversionNo\x12\x1b\n" +
+	"\tspec_json\x18\x04 \x01(\tR\bspecJson\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x05 \x01(\tR\tcreatedBy\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\xac\x03\n" +
+	"\x04Deck\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x15\n" +
+	"\x06org_id\x18\x02 \x01(\tR\x05orgId\x12\"\n" +
+	"\rowner_user_id\x18\x03 \x01(\tR\vownerUserId\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12;\n" +
+	"\x1asource_template_version_id\x18\x05 \x01(\tR\x17sourceTemplateVersionId\x12,\n" +
+	"\x12current_version_id\x18\x06 \x01(\tR\x10currentVersionId\x12*\n" +
+	"\x11latest_version_no\x18\a \x01(\x05R\x0flatestVersionNo\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\b \x01(\tR\n" +
+	"visibility\x12\x18\n" +
+	"\acontent\x18\t \x01(\tR\acontent\x129\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xcc\x01\n" +
+	"\vDeckVersion\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\adeck_id\x18\x02 \x01(\tR\x06deckId\x12\x1d\n" +
+	"\n" +
+	"version_no\x18\x03 \x01(\x05R\tversionNo\x12\x1b\n" +
+	"\tspec_json\x18\x04 \x01(\tR\bspecJson\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x05 \x01(\tR\tcreatedBy\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\xa0\x04\n" +
+	"\x03Job\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x15\n" +
+	"\x06org_id\x18\x02 \x01(\tR\x05orgId\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x1b\n" +
+	"\tinput_ref\x18\x05 \x01(\tR\binputRef\x12\x1d\n" +
+	"\n" +
+	"output_ref\x18\x06 \x01(\tR\toutputRef\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x12\x1f\n" +
+	"\vretry_count\x18\b \x01(\x05R\n" +
+	"retryCount\x12\x1f\n" +
+	"\vmax_retries\x18\t \x01(\x05R\n" +
+	"maxRetries\x127\n" +
+	"\bmetadata\x18\n" +
+	" \x03(\v2\x1b.cmsai.v1.Job.MetadataEntryR\bmetadata\x12#\n" +
+	"\rprogress_step\x18\v \x01(\tR\fprogressStep\x12!\n" +
+	"\fprogress_pct\x18\f \x01(\x05R\vprogressPct\x129\n" +
+	"\n" +
+	"created_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa0\x01\n" +
+	"\x10GenerationParams\x12%\n" +
+	"\vtemperature\x18\x01 \x01(\x01H\x00R\vtemperature\x88\x01\x01\x12\x18\n" +
+	"\x05top_p\x18\x02 \x01(\x01H\x01R\x04topP\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"max_slides\x18\x03 \x01(\x05H\x02R\tmaxSlides\x88\x01\x01B\x0e\n" +
+	"\f_temperatureB\b\n" +
+	"\x06_top_pB\r\n" +
+	"\v_max_slides\"$\n" +
+	"\x12GetTemplateRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\".\n" +
+	"\x14ListTemplatesRequest\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\"I\n" +
+	"\x15ListTemplatesResponse\x120\n" +
+	"\ttemplates\x18\x01 \x03(\v2\x12.cmsai.v1.TemplateR\ttemplates\"\xad\x02\n" +
+	"\x17GenerateTemplateRequest\x12\x16\n" +
+	"\x06prompt\x18\x01 \x01(\tR\x06prompt\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\fbrand_kit_id\x18\x03 \x01(\tR\n" +
+	"brandKitId\x12\x10\n" +
+	"\x03rtl\x18\x04 \x01(\bR\x03rtl\x12\x1a\n" +
+	"\blanguage\x18\x05 \x01(\tR\blanguage\x12\x12\n" +
+	"\x04tone\x18\x06 \x01(\tR\x04tone\x12:\n" +
+	"\fcontent_data\x18\a \x01(\v2\x17.google.protobuf.StructR\vcontentData\x122\n" +
+	"\x06params\x18\b \x01(\v2\x1a.cmsai.v1.GenerationParamsR\x06params\x12\x12\n" +
+	"\x04sync\x18\t \x01(\bR\x04sync\"\xa0\x01\n" +
+	"\x18GenerateTemplateResponse\x12.\n" +
+	"\btemplate\x18\x01 \x01(\v2\x12.cmsai.v1.TemplateR\btemplate\x12\x1f\n" +
+	"\x03job\x18\x02 \x01(\v2\r.cmsai.v1.JobR\x03job\x123\n" +
+	"\aversion\x18\x03 \x01(\v2\x19.cmsai.v1.TemplateVersionR\aversion\" \n" +
+	"\x0eGetDeckRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x12\n" +
+	"\x10ListDecksRequest\"9\n" +
+	"\x11ListDecksResponse\x12$\n" +
+	"\x05decks\x18\x01 \x03(\v2\x0e.cmsai.v1.DeckR\x05decks\"\xe5\x01\n" +
+	"\x11CreateDeckRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12;\n" +
+	"\x1asource_template_version_id\x18\x02 \x01(\tR\x17sourceTemplateVersionId\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x121\n" +
+	"\aoutline\x18\x04 \x01(\v2\x17.google.protobuf.StructR\aoutline\x122\n" +
+	"\x06params\x18\x05 \x01(\v2\x1a.cmsai.v1.GenerationParamsR\x06params\"\x8a\x01\n" +
+	"\x12CreateDeckResponse\x12\"\n" +
+	"\x04deck\x18\x01 \x01(\v2\x0e.cmsai.v1.DeckR\x04deck\x12\x1f\n" +
+	"\x03job\x18\x02 \x01(\v2\r.cmsai.v1.JobR\x03job\x12/\n" +
+	"\aversion\x18\x03 \x01(\v2\x15.cmsai.v1.DeckVersionR\aversion\"Q\n" +
+	"\x18ExportDeckVersionRequest\x12\x1d\n" +
+	"\n" +
+	"version_id\x18\x01 \x01(\tR\tversionId\x12\x16\n" +
+	"\x06format\x18\x02 \x01(\tR\x06format\"\x1f\n" +
+	"\rGetJobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"!\n" +
+	"\x0fWatchJobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id2\xff\x01\n" +
+	"\x0fTemplateService\x12?\n" +
+	"\vGetTemplate\x12\x1c.cmsai.v1.GetTemplateRequest\x1a\x12.cmsai.v1.Template\x12P\n" +
+	"\rListTemplates\x12\x1e.cmsai.v1.ListTemplatesRequest\x1a\x1f.cmsai.v1.ListTemplatesResponse\x12Y\n" +
+	"\x10GenerateTemplate\x12!.cmsai.v1.GenerateTemplateRequest\x1a\".cmsai.v1.GenerateTemplateResponse2\x99\x02\n" +
+	"\vDeckService\x123\n" +
+	"\aGetDeck\x12\x18.cmsai.v1.GetDeckRequest\x1a\x0e.cmsai.v1.Deck\x12D\n" +
+	"\tListDecks\x12\x1a.cmsai.v1.ListDecksRequest\x1a\x1b.cmsai.v1.ListDecksResponse\x12G\n" +
+	"\n" +
+	"CreateDeck\x12\x1b.cmsai.v1.CreateDeckRequest\x1a\x1c.cmsai.v1.CreateDeckResponse\x12F\n" +
+	"\x11ExportDeckVersion\x12\".cmsai.v1.ExportDeckVersionRequest\x1a\r.cmsai.v1.Job2v\n" +
+	"\n" +
+	"JobService\x120\n" +
+	"\x06GetJob\x12\x17.cmsai.v1.GetJobRequest\x1a\r.cmsai.v1.Job\x126\n" +
+	"\bWatchJob\x12\x19.cmsai.v1.WatchJobRequest\x1a\r.cmsai.v1.Job0\x01B7Z5github.com/ziyad/cms-ai/server/proto/cmsai/v1;cmsaiv1b\x06proto3"
+
+var (
+	file_cmsai_v1_cmsai_proto_rawDescOnce sync.Once
+	file_cmsai_v1_cmsai_proto_rawDescData []byte
+)
+
+func file_cmsai_v1_cmsai_proto_rawDescGZIP() []byte {
+	file_cmsai_v1_cmsai_proto_rawDescOnce.Do(func() {
+		file_cmsai_v1_cmsai_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_cmsai_v1_cmsai_proto_rawDesc), len(file_cmsai_v1_cmsai_proto_rawDesc)))
+	})
+	return file_cmsai_v1_cmsai_proto_rawDescData
+}
+
+var file_cmsai_v1_cmsai_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_cmsai_v1_cmsai_proto_goTypes = []any{
+	(*Template)(nil),                 // 0: cmsai.v1.Template
+	(*TemplateVersion)(nil),          // 1: cmsai.v1.TemplateVersion
+	(*Deck)(nil),                     // 2: cmsai.v1.Deck
+	(*DeckVersion)(nil),              // 3: cmsai.v1.DeckVersion
+	(*Job)(nil),                      // 4: cmsai.v1.Job
+	(*GenerationParams)(nil),         // 5: cmsai.v1.GenerationParams
+	(*GetTemplateRequest)(nil),       // 6: cmsai.v1.GetTemplateRequest
+	(*ListTemplatesRequest)(nil),     // 7: cmsai.v1.ListTemplatesRequest
+	(*ListTemplatesResponse)(nil),    // 8: cmsai.v1.ListTemplatesResponse
+	(*GenerateTemplateRequest)(nil),  // 9: cmsai.v1.GenerateTemplateRequest
+	(*GenerateTemplateResponse)(nil), // 10: cmsai.v1.GenerateTemplateResponse
+	(*GetDeckRequest)(nil),           // 11: cmsai.v1.GetDeckRequest
+	(*ListDecksRequest)(nil),         // 12: cmsai.v1.ListDecksRequest
+	(*ListDecksResponse)(nil),        // 13: cmsai.v1.ListDecksResponse
+	(*CreateDeckRequest)(nil),        // 14: cmsai.v1.CreateDeckRequest
+	(*CreateDeckResponse)(nil),       // 15: cmsai.v1.CreateDeckResponse
+	(*ExportDeckVersionRequest)(nil), // 16: cmsai.v1.ExportDeckVersionRequest
+	(*GetJobRequest)(nil),            // 17: cmsai.v1.GetJobRequest
+	(*WatchJobRequest)(nil),          // 18: cmsai.v1.WatchJobRequest
+	nil,                              // 19: cmsai.v1.Job.MetadataEntry
+	(*timestamppb.Timestamp)(nil),    // 20: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),          // 21: google.protobuf.Struct
+}
+var file_cmsai_v1_cmsai_proto_depIdxs = []int32{
+	20, // 0: cmsai.v1.Template.created_at:type_name -> google.protobuf.Timestamp
+	20, // 1: cmsai.v1.Template.updated_at:type_name -> google.protobuf.Timestamp
+	20, // 2: cmsai.v1.TemplateVersion.created_at:type_name -> google.protobuf.Timestamp
+	20, // 3: cmsai.v1.Deck.created_at:type_name -> google.protobuf.Timestamp
+	20, // 4: cmsai.v1.Deck.updated_at:type_name -> google.protobuf.Timestamp
+	20, // 5: cmsai.v1.DeckVersion.created_at:type_name -> google.protobuf.Timestamp
+	19, // 6: cmsai.v1.Job.metadata:type_name -> cmsai.v1.Job.MetadataEntry
+	20, // 7: cmsai.v1.Job.created_at:type_name -> google.protobuf.Timestamp
+	20, // 8: cmsai.v1.Job.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 9: cmsai.v1.ListTemplatesResponse.templates:type_name -> cmsai.v1.Template
+	21, // 10: cmsai.v1.GenerateTemplateRequest.content_data:type_name -> google.protobuf.Struct
+	5,  // 11: cmsai.v1.GenerateTemplateRequest.params:type_name -> cmsai.v1.GenerationParams
+	0,  // 12: cmsai.v1.GenerateTemplateResponse.template:type_name -> cmsai.v1.Template
+	4,  // 13: cmsai.v1.GenerateTemplateResponse.job:type_name -> cmsai.v1.Job
+	1,  // 14: cmsai.v1.GenerateTemplateResponse.version:type_name -> cmsai.v1.TemplateVersion
+	2,  // 15: cmsai.v1.ListDecksResponse.decks:type_name -> cmsai.v1.Deck
+	21, // 16: cmsai.v1.CreateDeckRequest.outline:type_name -> google.protobuf.Struct
+	5,  // 17: cmsai.v1.CreateDeckRequest.params:type_name -> cmsai.v1.GenerationParams
+	2,  // 18: cmsai.v1.CreateDeckResponse.deck:type_name -> cmsai.v1.Deck
+	4,  // 19: cmsai.v1.CreateDeckResponse.job:type_name -> cmsai.v1.Job
+	3,  // 20: cmsai.v1.CreateDeckResponse.version:type_name -> cmsai.v1.DeckVersion
+	6,  // 21: cmsai.v1.TemplateService.GetTemplate:input_type -> cmsai.v1.GetTemplateRequest
+	7,  // 22: cmsai.v1.TemplateService.ListTemplates:input_type -> cmsai.v1.ListTemplatesRequest
+	9,  // 23: cmsai.v1.TemplateService.GenerateTemplate:input_type -> cmsai.v1.GenerateTemplateRequest
+	11, // 24: cmsai.v1.DeckService.GetDeck:input_type -> cmsai.v1.GetDeckRequest
+	12, // 25: cmsai.v1.DeckService.ListDecks:input_type -> cmsai.v1.ListDecksRequest
+	14, // 26: cmsai.v1.DeckService.CreateDeck:input_type -> cmsai.v1.CreateDeckRequest
+	16, // 27: cmsai.v1.DeckService.ExportDeckVersion:input_type -> cmsai.v1.ExportDeckVersionRequest
+	17, // 28: cmsai.v1.JobService.GetJob:input_type -> cmsai.v1.GetJobRequest
+	18, // 29: cmsai.v1.JobService.WatchJob:input_type -> cmsai.v1.WatchJobRequest
+	0,  // 30: cmsai.v1.TemplateService.GetTemplate:output_type -> cmsai.v1.Template
+	8,  // 31: cmsai.v1.TemplateService.ListTemplates:output_type -> cmsai.v1.ListTemplatesResponse
+	10, // 32: cmsai.v1.TemplateService.GenerateTemplate:output_type -> cmsai.v1.GenerateTemplateResponse
+	2,  // 33: cmsai.v1.DeckService.GetDeck:output_type -> cmsai.v1.Deck
+	13, // 34: cmsai.v1.DeckService.ListDecks:output_type -> cmsai.v1.ListDecksResponse
+	15, // 35: cmsai.v1.DeckService.CreateDeck:output_type -> cmsai.v1.CreateDeckResponse
+	4,  // 36: cmsai.v1.DeckService.ExportDeckVersion:output_type -> cmsai.v1.Job
+	4,  // 37: cmsai.v1.JobService.GetJob:output_type -> cmsai.v1.Job
+	4,  // 38: cmsai.v1.JobService.WatchJob:output_type -> cmsai.v1.Job
+	30, // [30:39] is the sub-list for method output_type
+	21, // [21:30] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
+}
+
+func init() { file_cmsai_v1_cmsai_proto_init() }
+func file_cmsai_v1_cmsai_proto_init() {
+	if File_cmsai_v1_cmsai_proto != nil {
+		return
+	}
+	file_cmsai_v1_cmsai_proto_msgTypes[5].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_cmsai_v1_cmsai_proto_rawDesc), len(file_cmsai_v1_cmsai_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   3,
+		},
+		GoTypes:           file_cmsai_v1_cmsai_proto_goTypes,
+		DependencyIndexes: file_cmsai_v1_cmsai_proto_depIdxs,
+		MessageInfos:      file_cmsai_v1_cmsai_proto_msgTypes,
+	}.Build()
+	File_cmsai_v1_cmsai_proto = out.File
+	file_cmsai_v1_cmsai_proto_goTypes = nil
+	file_cmsai_v1_cmsai_proto_depIdxs = nil
+}
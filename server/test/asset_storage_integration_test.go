@@ -125,7 +125,7 @@ func TestAssetStorageAndIDGeneration(t *testing.T) {
 			Template:  "asset-test-template",
 			OrgID:     orgID,
 			VersionNo: 1,
-			SpecJSON:  templateSpec,
+			SpecJSON:  rawSpec(t, templateSpec),
 			CreatedBy: "asset-test-user",
 			CreatedAt: time.Now(),
 		}
@@ -234,7 +234,7 @@ func TestAssetStorageAndIDGeneration(t *testing.T) {
 			Template:  "uniqueness-template",
 			OrgID:     orgID,
 			VersionNo: 1,
-			SpecJSON:  templateSpec,
+			SpecJSON:  rawSpec(t, templateSpec),
 			CreatedBy: "uniqueness-user",
 			CreatedAt: time.Now(),
 		}
@@ -323,7 +323,7 @@ func TestAssetStorageAndIDGeneration(t *testing.T) {
 			Template:  "error-template",
 			OrgID:     orgID,
 			VersionNo: 1,
-			SpecJSON:  templateSpec,
+			SpecJSON:  rawSpec(t, templateSpec),
 			CreatedBy: "error-user",
 			CreatedAt: time.Now(),
 		}
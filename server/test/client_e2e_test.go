@@ -0,0 +1,77 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziyad/cms-ai/server/client/cmsai"
+	"github.com/ziyad/cms-ai/server/internal/api"
+	"github.com/ziyad/cms-ai/server/internal/auth"
+)
+
+// TestClientSDK_GenerateDeckExport drives the generate → deck → export flow
+// through the cmsai client against a real server and worker.
+func TestClientSDK_GenerateDeckExport(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret-key-at-least-32-chars-long-12345")
+	t.Setenv("USE_MOCK_AI", "true")
+	t.Setenv("DATABASE_URL", "")
+
+	srv, w := api.NewServerWithWorker()
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	token, err := auth.GenerateToken("user-sdk", "org-sdk", auth.RoleEditor)
+	require.NoError(t, err)
+	c := cmsai.NewClient(ts.URL, token)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// runJob drains the queue in the background while the client polls, the
+	// way the worker's loop would.
+	runJob := func(jobID string) (*cmsai.Job, error) {
+		go w.ProcessJobs()
+		return c.WaitForJob(ctx, jobID, cmsai.WaitOptions{Interval: 10 * time.Millisecond, MaxInterval: 100 * time.Millisecond})
+	}
+
+	gen, err := c.GenerateTemplate(ctx, cmsai.GenerateTemplateRequest{Prompt: "A product launch deck for a fintech startup", Name: "Launch"})
+	require.NoError(t, err)
+	require.NotNil(t, gen.Job)
+	assert.Equal(t, "Launch", gen.Template.Name)
+	genJob, err := runJob(gen.Job.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, genJob.OutputRef, "generate jobs output the template version")
+
+	deck, err := c.CreateDeck(ctx, cmsai.CreateDeckRequest{
+		Name:                    "Launch deck",
+		SourceTemplateVersionID: genJob.OutputRef,
+		Content:                 "We launch our payments API in March with three partner banks.",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, deck.Job)
+	bindJob, err := runJob(deck.Job.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, bindJob.OutputRef, "bind jobs output the deck version")
+
+	_, err = c.Export(ctx, bindJob.OutputRef, "docx")
+	var apiErr *cmsai.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+
+	exportJob, err := c.Export(ctx, bindJob.OutputRef, cmsai.FormatPPTX)
+	require.NoError(t, err)
+	assert.Equal(t, cmsai.JobQueued, exportJob.Status)
+
+	skipIfNoPptx(t)
+	if _, err := os.Stat("../tools/renderer/render_pptx.py"); err != nil {
+		t.Skip("Python renderer not available")
+	}
+	exportJob, err = runJob(exportJob.ID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, exportJob.OutputRef)
+}
@@ -109,7 +109,7 @@ func TestCompleteAIPipeline(t *testing.T) {
 		require.NoError(t, err)
 
 		// Step 3: Create AI-enhanced renderer
-		renderer := assets.NewAIEnhancedRenderer(nil) // No store needed for test
+		renderer := assets.NewAIEnhancedRenderer(nil, os.Getenv("HUGGING_FACE_API_KEY")) // No store needed for test
 
 		// Step 4: Render to PPTX
 		outputPath := filepath.Join(t.TempDir(), "healthcare_presentation.pptx")
@@ -167,7 +167,7 @@ func TestCompleteAIPipeline(t *testing.T) {
 		specJSON, err := json.Marshal(templateSpec)
 		require.NoError(t, err)
 
-		renderer := assets.NewAIEnhancedRenderer(nil)
+		renderer := assets.NewAIEnhancedRenderer(nil, os.Getenv("HUGGING_FACE_API_KEY"))
 		outputPath := filepath.Join(t.TempDir(), "finance_presentation.pptx")
 
 		err = renderer.RenderPPTX(context.Background(), specJSON, outputPath)
@@ -391,7 +391,7 @@ func BenchmarkCompleteFlow(b *testing.B) {
 	}
 
 	specJSON, _ := json.Marshal(templateSpec)
-	renderer := assets.NewAIEnhancedRenderer(nil)
+	renderer := assets.NewAIEnhancedRenderer(nil, os.Getenv("HUGGING_FACE_API_KEY"))
 	tmpDir := b.TempDir()
 
 	b.ResetTimer()
@@ -486,7 +486,7 @@ func TestCompleteAsyncExportWorkflow(t *testing.T) {
 
 		// Create mock stores for job processing
 		memStore := memory.New()
-		renderer := assets.NewAIEnhancedRenderer(memStore)
+		renderer := assets.NewAIEnhancedRenderer(memStore, os.Getenv("HUGGING_FACE_API_KEY"))
 		storage, _ := assets.NewLocalStorage(assets.StorageConfig{Type: "local"})
 		worker := worker.New(memStore, renderer, storage, ai.NewAIService(memStore))
 
@@ -3,7 +3,6 @@ package test
 import (
 	"context"
 	"encoding/json"
-	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -13,11 +12,7 @@ import (
 )
 
 func TestMockOrchestrator(t *testing.T) {
-	// Enable mock mode
-	os.Setenv("USE_MOCK_AI", "true")
-	defer os.Unsetenv("USE_MOCK_AI")
-
-	orchestrator := ai.NewOrchestrator()
+	orchestrator := ai.NewOrchestratorWithOptions(ai.Options{Mock: true})
 
 	t.Run("Healthcare_Detection", func(t *testing.T) {
 		req := ai.GenerationRequest{
@@ -169,12 +164,8 @@ func TestMockOrchestrator(t *testing.T) {
 }
 
 func TestMockFallback(t *testing.T) {
-	// Clear environment to test fallback behavior
-	os.Unsetenv("USE_MOCK_AI")
-	os.Unsetenv("HUGGINGFACE_API_KEY")
-
 	// Without API key, should fall back to mock
-	orchestrator := ai.NewOrchestrator()
+	orchestrator := ai.NewOrchestratorWithOptions(ai.Options{})
 
 	req := ai.GenerationRequest{
 		Prompt: "Test presentation",
@@ -191,9 +182,6 @@ func TestMockFallback(t *testing.T) {
 }
 
 func TestMockRepair(t *testing.T) {
-	os.Setenv("USE_MOCK_AI", "true")
-	defer os.Unsetenv("USE_MOCK_AI")
-
 	mockOrch := ai.NewMockOrchestrator()
 
 	t.Run("Repair_Invalid_Spec", func(t *testing.T) {
@@ -237,10 +225,7 @@ func TestMockRepair(t *testing.T) {
 }
 
 func BenchmarkMockGeneration(b *testing.B) {
-	os.Setenv("USE_MOCK_AI", "true")
-	defer os.Unsetenv("USE_MOCK_AI")
-
-	orchestrator := ai.NewOrchestrator()
+	orchestrator := ai.NewOrchestratorWithOptions(ai.Options{Mock: true})
 	req := ai.GenerationRequest{
 		Prompt: "Create a presentation",
 		ContentData: map[string]interface{}{
@@ -256,10 +241,7 @@ func BenchmarkMockGeneration(b *testing.B) {
 }
 
 func TestMockConsistency(t *testing.T) {
-	os.Setenv("USE_MOCK_AI", "true")
-	defer os.Unsetenv("USE_MOCK_AI")
-
-	orchestrator := ai.NewOrchestrator()
+	orchestrator := ai.NewOrchestratorWithOptions(ai.Options{Mock: true})
 
 	// Same input should produce consistent industry detection
 	req := ai.GenerationRequest{
@@ -288,9 +270,6 @@ func TestMockConsistency(t *testing.T) {
 }
 
 func TestMockJSONGeneration(t *testing.T) {
-	os.Setenv("USE_MOCK_AI", "true")
-	defer os.Unsetenv("USE_MOCK_AI")
-
 	mockOrch := ai.NewMockOrchestrator()
 
 	jsonStr, err := mockOrch.GenerateJSON(context.Background(), "test prompt")
@@ -2,6 +2,7 @@ package test
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
 	"testing"
 	"time"
@@ -31,7 +32,7 @@ func TestRegression_WorkerOutputRef_IsAssetID(t *testing.T) {
 		Template:  "reg-template-1",
 		OrgID:     "reg-org",
 		VersionNo: 1,
-		SpecJSON: map[string]interface{}{
+		SpecJSON: rawSpec(t, map[string]interface{}{
 			"layouts": []map[string]interface{}{
 				{
 					"name": "default",
@@ -43,7 +44,7 @@ func TestRegression_WorkerOutputRef_IsAssetID(t *testing.T) {
 					},
 				},
 			},
-		},
+		}),
 		CreatedAt: time.Now(),
 	}
 	_, err := memStore.Templates().CreateVersion(ctx, templateVersion)
@@ -87,4 +88,12 @@ func TestRegression_WorkerOutputRef_IsAssetID(t *testing.T) {
 	assert.Contains(t, asset.Path, ".pptx", "Linked Asset record should have the file path")
 
 	t.Log("✅ REGRESSION TEST PASSED: Worker correctly sets OutputRef to Asset ID")
-}
\ No newline at end of file
+}
+
+// rawSpec marshals a spec built as Go values into a version's SpecJSON.
+func rawSpec(t *testing.T, v any) json.RawMessage {
+	t.Helper()
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return b
+}